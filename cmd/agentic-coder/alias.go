@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+func aliasCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alias",
+		Short: "Manage model aliases",
+		Long: `Name models you use often. An alias works anywhere a model name does:
+--model, default_model, /model, review and subagent models, and the gateway.
+
+Aliases are saved in the model_aliases section of the user config
+(~/.agentic-coder/config.json) or, with --scope project, the project config
+(.agentic-coder/config.json), which overrides the user's. Both override the
+built-in aliases such as sonnet and gpt4o.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listAliases()
+		},
+	}
+
+	cmd.AddCommand(aliasSetCmd())
+	cmd.AddCommand(aliasRemoveCmd())
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List your model aliases and the built-in ones",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listAliases()
+		},
+	})
+	return cmd
+}
+
+func aliasSetCmd() *cobra.Command {
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:   "set <name>=<model>...",
+		Short: "Add or change model aliases",
+		Long: `Add or change aliases. The model may be a model ID, a built-in alias, or
+provider:model.
+
+Example:
+  agentic-coder alias set fast=gemini-1.5-flash
+  agentic-coder alias set local=ollama:qwen2.5-coder:7b review=haiku`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := scopeConfigPath(scope)
+			if err != nil {
+				return err
+			}
+			aliases, err := config.LoadModelAliases(path)
+			if err != nil {
+				return err
+			}
+			if aliases == nil {
+				aliases = make(map[string]string)
+			}
+
+			set := make(map[string]string)
+			for _, arg := range args {
+				name, target, ok := strings.Cut(arg, "=")
+				if !ok {
+					return fmt.Errorf("invalid alias %q: expected name=model", arg)
+				}
+				name, target = strings.TrimSpace(name), strings.TrimSpace(target)
+				if err := config.ValidateModelAlias(name, target); err != nil {
+					return err
+				}
+				set[name] = target
+			}
+			for name, target := range set {
+				_, pending := set[target]
+				if _, saved := provider.UserModelAliases()[target]; pending || saved {
+					return fmt.Errorf("alias %s cannot stand for the alias %s; give the model instead", name, target)
+				}
+				aliases[name] = target
+			}
+
+			if err := config.SaveModelAliases(path, aliases); err != nil {
+				return err
+			}
+			printer := ui.NewPrinter()
+			for _, name := range sortedKeys(set) {
+				printer.Success("%s = %s (%s, %s)", name, set[name], provider.DetectProviderFromModel(set[name]), path)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&scope, "scope", "s", "user", "Config to save to: user or project")
+	return cmd
+}
+
+func aliasRemoveCmd() *cobra.Command {
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:   "remove <name>...",
+		Short: "Remove model aliases",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := scopeConfigPath(scope)
+			if err != nil {
+				return err
+			}
+			aliases, err := config.LoadModelAliases(path)
+			if err != nil {
+				return err
+			}
+			for _, name := range args {
+				if _, ok := aliases[name]; !ok {
+					if _, builtin := provider.ModelAlias[name]; builtin {
+						return fmt.Errorf("%s is a built-in alias; override it with alias set instead", name)
+					}
+					return fmt.Errorf("no alias %s in %s", name, path)
+				}
+				delete(aliases, name)
+			}
+			if err := config.SaveModelAliases(path, aliases); err != nil {
+				return err
+			}
+			ui.NewPrinter().Success("Removed %s from %s", strings.Join(args, ", "), path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&scope, "scope", "s", "user", "Config to remove from: user or project")
+	return cmd
+}
+
+// listAliases prints the aliases of the user and project configs, then the
+// built-in ones they do not override
+func listAliases() error {
+	printer := ui.NewPrinter()
+	user := provider.UserModelAliases()
+	if len(user) == 0 {
+		printer.Info("No model aliases configured; add one with: agentic-coder alias set <name>=<model>")
+	} else {
+		fmt.Println("Your aliases:")
+		for _, name := range sortedKeys(user) {
+			printAlias(name, user[name])
+		}
+		if cwd, err := os.Getwd(); err == nil {
+			if aliases, err := config.LoadModelAliases(config.GetProjectConfigPath(cwd)); err == nil && len(aliases) > 0 {
+				printer.Dim("  (%d from the project config)", len(aliases))
+			}
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("Built-in aliases:")
+	for _, name := range sortedKeys(provider.ModelAlias) {
+		if _, overridden := user[name]; !overridden {
+			printAlias(name, provider.ModelAlias[name])
+		}
+	}
+	return nil
+}
+
+func printAlias(name, target string) {
+	fmt.Printf("  %-14s %-32s %s\n", name, target, provider.DetectProviderFromModel(name))
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
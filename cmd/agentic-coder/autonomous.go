@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/engine"
+	"github.com/xinguang/agentic-coder/pkg/secret"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+	"github.com/xinguang/agentic-coder/pkg/workctx"
+)
+
+// autonomousTask resolves the task of an autonomous run and the work
+// context its progress is written to: the context given with --work, or a
+// new one for the task
+func autonomousTask(cmd *cobra.Command, workMgr *workctx.Manager, cwd string) (string, *workctx.WorkContext, error) {
+	taskFlag, _ := cmd.Flags().GetString("task")
+	task, err := engine.ReadPromptValue(taskFlag)
+	if err != nil {
+		return "", nil, fmt.Errorf("task: %w", err)
+	}
+	task = strings.TrimSpace(task)
+
+	if id, _ := cmd.Flags().GetString("work"); id != "" {
+		wc, err := workMgr.Load(id)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to load work context %s: %w", id, err)
+		}
+		workMgr.SetCurrent(wc)
+		if task == "" {
+			task = wc.Goal
+		}
+		if task == "" {
+			return "", nil, fmt.Errorf("work context %s has no goal; give the task with --task", id)
+		}
+		return task, wc, nil
+	}
+
+	if task == "" {
+		return "", nil, fmt.Errorf("--autonomous needs a task: --task \"...\", --task @file, or --work <id> with a goal")
+	}
+	wc := workMgr.New("Autonomous: "+truncateLine(strings.SplitN(task, "\n", 2)[0], 60), task)
+	wc.ProjectPath = cwd
+	return task, wc, nil
+}
+
+// runAutonomous works on a task without user input until it is complete or
+// a limit is reached, writing progress reports into a work context
+func runAutonomous(cmd *cobra.Command, eng *engine.Engine, sess *session.Session, sessMgr *session.SessionManager, workMgr *workctx.Manager, printer *ui.Printer, cwd string) error {
+	task, wc, err := autonomousTask(cmd, workMgr, cwd)
+	if err != nil {
+		return err
+	}
+
+	reportEvery, _ := cmd.Flags().GetDuration("report-every")
+	maxDuration, _ := cmd.Flags().GetDuration("max-duration")
+	maxCost, _ := cmd.Flags().GetFloat64("max-cost")
+	maxRuns, _ := cmd.Flags().GetInt("max-turns")
+	resume, _ := cmd.Flags().GetBool("continue")
+
+	wc.Provider = sess.Provider
+	wc.Model = sess.Model
+	if err := workMgr.Save(wc); err != nil {
+		return fmt.Errorf("failed to save work context: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The first Ctrl+C stops the run after a final report, the second
+	// exits. A shutdown (SIGTERM) pauses the run so --continue resumes it.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		sig := <-sigCh
+		fmt.Println()
+		if sig == syscall.SIGTERM {
+			printer.Warning("Pausing after the current tool")
+			eng.Pause()
+		} else {
+			printer.Warning("Stopping after a final report. Press Ctrl+C again to exit now.")
+			cancel()
+		}
+		<-sigCh
+		os.Exit(1)
+	}()
+
+	// Nobody is there to answer prompts, so they get their unattended defaults
+	eng.SetCallbacks(&engine.CallbackOptions{
+		OnText: func(text string) {
+			fmt.Print(text)
+		},
+		OnToolUse: func(name string, input map[string]interface{}) {
+			fmt.Println()
+			printer.Dim("  %s %s", name, secret.MaskText(explainToolTarget(input)))
+		},
+		OnToolResult: func(name string, result *tool.Output) {
+			if result.IsError {
+				printer.Dim("  %s failed: %s", name, truncateLine(secret.MaskText(result.Content), 80))
+			}
+		},
+		OnError: func(err error) {
+			printer.Error("%v", err)
+		},
+		OnBudget: func(alert engine.BudgetAlert) {
+			printer.Warning("%s", alert)
+		},
+		OnStuck: func(reason string) string {
+			printer.Warning("The agent appears to be stuck: %s", reason)
+			return ""
+		},
+		OnPathAccess: func(access engine.PathAccess) engine.PathDecision {
+			printer.Warning("Denied %s access to %s, outside the project", access.Tool, access.Path)
+			return engine.PathDeny
+		},
+		OnDestructive: func(action engine.DestructiveAction) bool {
+			printer.Warning("Refused a command that %s: %s", action.Reason, action.Target)
+			return false
+		},
+	})
+
+	printer.WelcomeBanner(version, sess.Model, cwd)
+	if resume {
+		printer.Info("Continuing the run paused at %s", sess.Paused().At.Format("01/02 15:04"))
+	}
+	printer.Info("Working autonomously on work context %s; progress reports every %s", wc.ID, reportEvery)
+
+	err = eng.RunAutonomous(ctx, task, engine.AutonomousOptions{
+		ReportEvery: reportEvery,
+		MaxRuns:     maxRuns,
+		MaxDuration: maxDuration,
+		MaxCost:     maxCost,
+		Resume:      resume,
+		OnReport: func(report engine.ProgressReport) {
+			recordProgress(wc, report, cwd)
+			if err := workMgr.Save(wc); err != nil {
+				printer.Warning("Failed to save work context: %v", err)
+			}
+			if err := sessMgr.SaveSession(sess); err != nil {
+				printer.Warning("Failed to save session: %v", err)
+			}
+			printProgress(printer, report)
+		},
+	})
+
+	if sess.Paused() != nil {
+		printer.Info("Paused. Continue with: agentic-coder --autonomous --continue --work %s", wc.ID)
+	}
+	printer.Info("Progress is in work context %s: agentic-coder work show %s", wc.ID, wc.ID)
+	return err
+}
+
+// recordProgress writes a progress report into the work context: the
+// summary as a note, the changed files as key files, and how the run
+// ended as progress
+func recordProgress(wc *workctx.WorkContext, report engine.ProgressReport, cwd string) {
+	wc.AddNote(fmt.Sprintf("[%s] After %s and %d turns ($%.2f): %s",
+		time.Now().Format("2006-01-02 15:04"), report.Elapsed.Round(time.Second), report.Runs, report.Cost, report.Summary))
+	for _, file := range report.Files {
+		if rel, err := filepath.Rel(cwd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+		wc.AddKeyFile(file)
+	}
+	if report.Final {
+		wc.AddProgress("Autonomous run ended: " + report.Reason)
+	}
+}
+
+// recordWrapUp writes the summary of a run stopped at the iteration limit
+// into the current work context, if there is one
+func recordWrapUp(workMgr *workctx.Manager, summary string, printer *ui.Printer) {
+	wc := workMgr.Current()
+	if wc == nil {
+		return
+	}
+	wc.AddNote(fmt.Sprintf("[%s] Stopped at the iteration limit: %s", time.Now().Format("2006-01-02 15:04"), summary))
+	if err := workMgr.Save(wc); err != nil {
+		printer.Warning("Failed to save work context: %v", err)
+	}
+}
+
+// printProgress prints a progress report
+func printProgress(printer *ui.Printer, report engine.ProgressReport) {
+	fmt.Println()
+	title := "Progress"
+	if report.Final {
+		title = "Finished: " + report.Reason
+	}
+	printer.Info("%s (%s, %d turns, $%.2f, %d files changed)",
+		title, report.Elapsed.Round(time.Second), report.Runs, report.Cost, len(report.Files))
+	fmt.Println(report.Summary)
+}
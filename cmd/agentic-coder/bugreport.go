@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/bugreport"
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+// reportLogFiles returns the logs included in bug reports: the files in
+// ~/.config/agentic-coder/logs and the configured log file
+func reportLogFiles(cfg *config.Config) []string {
+	var files []string
+	if home, err := os.UserHomeDir(); err == nil {
+		matches, _ := filepath.Glob(filepath.Join(home, ".config", "agentic-coder", "logs", "*.log"))
+		files = append(files, matches...)
+	}
+	if cfg != nil && cfg.LogFile != "" {
+		files = append(files, cfg.LogFile)
+	}
+	return files
+}
+
+func bugReportCmd() *cobra.Command {
+	var sessionID, output string
+	var gist bool
+	var logLines int
+
+	cmd := &cobra.Command{
+		Use:   "bug-report",
+		Short: "Bundle the last session, config, and logs for an issue",
+		Long: `Package what maintainers need to reproduce a bug: the last session's
+transcript for the current project, the merged configuration, version and
+platform, the provider and model, and the end of the logs.
+
+API keys, tokens, passwords, MCP server and hook environments, and the home
+directory are redacted. Provider variables such as OPENAI_BASE_URL are listed
+by name only. Review the bundle before attaching it to an issue.
+
+The bundle is written as a .tar.gz, or uploaded as a secret gist with
+--gist (requires the GitHub CLI, gh).
+
+Example:
+  agentic-coder bug-report
+  agentic-coder bug-report --session 3f2a9c1e -o report.tar.gz
+  agentic-coder bug-report --gist`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+
+			cfg := settings.Get()
+
+			report, err := bugreport.Collect(&bugreport.Options{
+				Version:     version,
+				ProjectPath: cwd,
+				SessionID:   sessionID,
+				Config:      cfg,
+				LogFiles:    reportLogFiles(cfg),
+				LogLines:    logLines,
+			})
+			if err != nil {
+				return err
+			}
+
+			printer := ui.NewPrinter()
+			if report.Info.SessionID == "" {
+				printer.Warning("No session found for %s; the report has no transcript", cwd)
+			}
+
+			if gist {
+				url, err := uploadGist(cmd.Context(), report)
+				if err != nil {
+					return err
+				}
+				printer.Success("Uploaded bug report: %s", url)
+				return nil
+			}
+
+			if output == "" {
+				output = "agentic-coder-bug-report-" + report.Info.Created.Format("20060102-150405") + ".tar.gz"
+			}
+			f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", output, err)
+			}
+			if err := report.WriteArchive(f); err != nil {
+				f.Close()
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+
+			printer.Success("Wrote bug report to %s", output)
+			for _, file := range report.Files {
+				printer.Dim("  %s (%d bytes)", file.Name, len(file.Data))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&sessionID, "session", "", "Session ID or prefix (default: the latest session)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Archive path (default: agentic-coder-bug-report-<time>.tar.gz)")
+	cmd.Flags().BoolVar(&gist, "gist", false, "Upload the report as a secret gist with gh instead of writing an archive")
+	cmd.Flags().IntVar(&logLines, "log-lines", 200, "Lines kept from the end of each log")
+	return cmd
+}
+
+// uploadGist creates a secret gist from the report's files and returns its URL
+func uploadGist(ctx context.Context, report *bugreport.Report) (string, error) {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return "", fmt.Errorf("--gist requires the GitHub CLI (gh); write an archive instead")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "agentic-coder-bug-report-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	paths, err := report.WriteDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+
+	args := append([]string{"gist", "create", "--desc", "agentic-coder " + version + " bug report"}, paths...)
+	out, err := exec.CommandContext(ctx, "gh", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("gh gist create failed: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	return lines[len(lines)-1], nil
+}
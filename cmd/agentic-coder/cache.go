@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+// cacheResponses puts prov behind the response cache when --response-cache
+// or AGENTIC_CODER_RESPONSE_CACHE asks for it. CLI providers run tools
+// themselves, so their responses cannot be replayed and are not cached.
+func cacheResponses(prov provider.AIProvider, printer *ui.Printer) provider.AIProvider {
+	if !responseCache && os.Getenv("AGENTIC_CODER_RESPONSE_CACHE") != "1" {
+		return prov
+	}
+	if _, ok := prov.(provider.CLIChecker); ok {
+		printer.Warning("%s runs tools itself; its responses are not cached", prov.Name())
+		return prov
+	}
+	dir := responseCacheDir
+	if dir == "" {
+		var err error
+		if dir, err = config.GetResponseCacheDir(); err != nil {
+			printer.Warning("Response cache disabled: %v", err)
+			return prov
+		}
+	}
+	return provider.NewCachedProvider(prov, dir)
+}
+
+// reportResponseCache prints how many requests the response cache answered
+func reportResponseCache(prov provider.AIProvider, printer *ui.Printer) {
+	cache, ok := prov.(*provider.CachedProvider)
+	if !ok {
+		return
+	}
+	if hits, misses := cache.Stats(); hits+misses > 0 {
+		printer.Dim("Response cache: %d of %d requests replayed", hits, hits+misses)
+	}
+}
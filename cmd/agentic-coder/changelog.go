@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/changelog"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+func changelogCmd() *cobra.Command {
+	var (
+		since, release, output string
+		edit, noAI, dryRun     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "changelog",
+		Short: "Draft a CHANGELOG section from the commits since a release",
+		Long: `Group the commits since a release by their conventional commit type
+(feat, fix, perf, refactor, docs, ...) and have the model draft a changelog
+section from them. Commits that do not follow the convention are listed
+under Other Changes; breaking changes (type! or a BREAKING CHANGE footer)
+are also listed first.
+
+--since defaults to the tag of this build's version (v` + version + `) when it
+exists, otherwise to the latest tag. The section is headed with --version,
+"Unreleased" by default, and added to the top of the changelog file.
+
+Example:
+  agentic-coder changelog --since v0.1.0
+  agentic-coder changelog --version v0.2.0 --edit
+  agentic-coder changelog --no-ai --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runChangelog(cmd.Context(), since, release, output, edit, noAI, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "Tag or commit of the last release (default: v<version> or the latest tag)")
+	cmd.Flags().StringVar(&release, "version", "", "Version in the section heading (default: Unreleased)")
+	cmd.Flags().StringVarP(&output, "output", "o", "CHANGELOG.md", "Changelog file to add the section to")
+	cmd.Flags().BoolVar(&edit, "edit", false, "Open the draft in $EDITOR before writing it")
+	cmd.Flags().BoolVar(&noAI, "no-ai", false, "List the grouped commits without drafting with the model")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the section instead of writing it")
+	return cmd
+}
+
+func runChangelog(ctx context.Context, since, release, output string, edit, noAI, dryRun bool) error {
+	printer := ui.NewPrinter()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	if since == "" {
+		if changelog.TagExists(ctx, cwd, "v"+version) {
+			since = "v" + version
+		} else {
+			since = changelog.LatestTag(ctx, cwd)
+		}
+	}
+	commits, err := changelog.Commits(ctx, cwd, since)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		printer.Info("No commits since %s", since)
+		return nil
+	}
+	from := since
+	if from == "" {
+		from = "the first commit"
+	}
+	printer.Info("%d commits since %s", len(commits), from)
+
+	groups := changelog.GroupCommits(commits)
+	date := time.Now()
+	section := changelog.Render(release, date, groups)
+	if !noAI {
+		providerType := provider.DetectProviderFromModel(model)
+		prov, err := createProvider(providerType, apiKey, printer)
+		if err != nil {
+			return err
+		}
+		printer.Info("Drafting the changelog...")
+		if section, err = changelog.Draft(ctx, prov, provider.ResolveModel(model), release, date, groups); err != nil {
+			return fmt.Errorf("%w (use --no-ai to list the commits instead)", err)
+		}
+	}
+
+	if edit {
+		if section, err = editText(section, "changelog-*.md"); err != nil {
+			return err
+		}
+		if strings.TrimSpace(section) == "" {
+			printer.Warning("The changelog is empty; nothing written")
+			return nil
+		}
+	}
+
+	if dryRun {
+		fmt.Print(section)
+		return nil
+	}
+	if err := changelog.Prepend(output, section); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	if release == "" {
+		release = "Unreleased"
+	}
+	printer.Success("Added the %s section to %s", release, output)
+	return nil
+}
+
+// editText opens text in the user's editor (the editor setting, $VISUAL,
+// $EDITOR, or vi) and returns it as saved
+func editText(text, pattern string) (string, error) {
+	return globalEditor().Edit(text, pattern)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xinguang/agentic-coder/pkg/devcontainer"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+	"github.com/xinguang/agentic-coder/pkg/tool/builtin"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+// useDevcontainer runs Bash and the Test tool in the project's devcontainer
+// when --devcontainer or the global config asks for it, and returns a
+// description of the container for the system prompt, or "" when they stay
+// local. Otherwise it only points out a devcontainer it finds. A project's
+// config cannot turn it on: starting the container runs the
+// initializeCommand of its devcontainer.json on this machine.
+func useDevcontainer(cwd string, registry *tool.Registry, shells *builtin.ShellManager, printer *ui.Printer) (string, error) {
+	cfg := settings.Get().Devcontainer
+	projectEnabled := cfg.Enabled
+	global := settings.Global()
+	cfg.Enabled = global.Devcontainer.Enabled
+	forward := global.Env.Container // like env.allow, not from the project
+	if !inDevcontainer && !cfg.Enabled {
+		if devcontainer.Find(cwd) != "" && remoteHost == "" {
+			if projectEnabled {
+				printer.Dim("This project's config enables its devcontainer; pass --devcontainer to run Bash and tests inside it")
+			} else {
+				printer.Dim("This project has a devcontainer; --devcontainer runs Bash and tests inside it")
+			}
+		}
+		return "", nil
+	}
+	if remoteHost != "" {
+		printer.Warning("Not using the devcontainer: the tools run on %s", remoteHost)
+		return "", nil
+	}
+
+	c, err := devcontainer.New(cwd, cfg.Exec)
+	if err != nil {
+		return "", err
+	}
+	c.Env = forward
+	printer.Dim("Starting devcontainer...")
+	if err := c.Start(context.Background()); err != nil {
+		return "", fmt.Errorf("devcontainer: %w", err)
+	}
+	devcontainer.Use(registry, shells, c)
+	printer.Info("Devcontainer: Bash and tests run in %s", c)
+	return fmt.Sprintf("%s, with the project at %s", c, c.Folder), nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/provider/voyage"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+// embeddingProviders are the providers with an embeddings API
+var embeddingProviders = []string{"openai", "gemini", "ollama", "voyage"}
+
+func embedCmd() *cobra.Command {
+	var (
+		providerName   string
+		embeddingModel string
+		batch          int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "embed [text...]",
+		Short: "Print vector embeddings of texts",
+		Long: `Embed each argument, or each non-empty line of stdin when there are no
+arguments, and print one JSON object per text with its index, text, and
+embedding.
+
+The provider is the one of --model unless --provider names another:
+openai (text-embedding-3-small), gemini (text-embedding-004), ollama
+(nomic-embed-text), or voyage (voyage-3, with VOYAGE_API_KEY). Use
+--embedding-model for another model of the provider.
+
+Example:
+  agentic-coder embed --provider openai "hello world"
+  agentic-coder embed --provider ollama < notes.txt
+  agentic-coder embed --provider voyage --embedding-model voyage-code-3 "func main()"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			texts := args
+			if len(texts) == 0 {
+				var err error
+				if texts, err = readLines(os.Stdin); err != nil {
+					return err
+				}
+			}
+			if len(texts) == 0 {
+				return fmt.Errorf("nothing to embed: pass texts as arguments or on stdin")
+			}
+
+			printer := ui.NewPrinter()
+			embedder, err := createEmbedder(providerName, printer)
+			if err != nil {
+				return err
+			}
+			resp, err := provider.EmbedBatches(cmd.Context(), embedder, embeddingModel, texts, batch)
+			if err != nil {
+				return fmt.Errorf("%s embeddings failed: %w", embedder.Name(), err)
+			}
+
+			enc := json.NewEncoder(os.Stdout)
+			for i, vector := range resp.Vectors {
+				if err := enc.Encode(struct {
+					Index     int       `json:"index"`
+					Text      string    `json:"text"`
+					Embedding []float32 `json:"embedding"`
+				}{i, texts[i], vector}); err != nil {
+					return err
+				}
+			}
+			dims := 0
+			if len(resp.Vectors) > 0 {
+				dims = len(resp.Vectors[0])
+			}
+			fmt.Fprintf(os.Stderr, "%d embeddings of %d dimensions from %s/%s\n", len(resp.Vectors), dims, embedder.Name(), resp.Model)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&providerName, "provider", "", "Embedding provider: "+strings.Join(embeddingProviders, ", ")+" (default: the provider of --model)")
+	cmd.Flags().StringVar(&embeddingModel, "embedding-model", "", "Embedding model (default: the provider's)")
+	cmd.Flags().IntVar(&batch, "batch", 100, "Texts per request")
+	return cmd
+}
+
+// createEmbedder returns the named embedding provider, or the provider of
+// --model when name is empty
+func createEmbedder(name string, printer *ui.Printer) (provider.EmbeddingProvider, error) {
+	if name == "voyage" {
+		key := apiKey
+		if key == "" {
+			key = os.Getenv("VOYAGE_API_KEY")
+		}
+		if key == "" {
+			return nil, fmt.Errorf("VOYAGE_API_KEY not set; export it or pass --api-key")
+		}
+		return voyage.New(key), nil
+	}
+
+	providerType := provider.DetectProviderFromModel(model)
+	if name != "" {
+		providerType = provider.ProviderType(name)
+	}
+	prov, err := createProvider(providerType, apiKey, printer)
+	if err != nil {
+		return nil, err
+	}
+	embedder, ok := prov.(provider.EmbeddingProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s has no embeddings API; choose one with --provider (%s)", providerType, strings.Join(embeddingProviders, ", "))
+	}
+	return embedder, nil
+}
+
+// readLines returns the non-empty lines of r
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
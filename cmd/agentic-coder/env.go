@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/secret"
+	"github.com/xinguang/agentic-coder/pkg/tool/builtin"
+)
+
+// useEnvPolicy applies the configured env policy to the commands the tools
+// run, keeping provider keys and other secrets out of their environment.
+// Projects can deny more variables, but only the global config can pass
+// more or switch to the denylist, so a cloned repository cannot hand keys to
+// its own scripts.
+func useEnvPolicy() {
+	global := settings.Global().Env
+	builtin.SetEnvPolicy(builtin.EnvPolicy{Mode: global.Mode, Allow: global.Allow, Deny: settings.Get().Env.Deny})
+}
+
+// describeEnv lists the variables commands receive for "/env", masking
+// secret-looking values, and the names of those withheld
+func describeEnv() string {
+	policy := builtin.CurrentEnvPolicy()
+	mode := policy.Mode
+	if mode == "" {
+		mode = builtin.EnvAllowlist
+	}
+
+	env := os.Environ()
+	sort.Strings(env)
+	var b strings.Builder
+	var withheld []string
+	fmt.Fprintf(&b, "Commands receive (%s):\n", mode)
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		if !policy.Passes(name) {
+			withheld = append(withheld, name)
+			continue
+		}
+		if secret.IsName(name) {
+			value = secret.Mask(value)
+		}
+		fmt.Fprintf(&b, "  %s=%s\n", name, secret.MaskText(value))
+	}
+	if len(withheld) > 0 {
+		fmt.Fprintf(&b, "Withheld (%d): %s\n", len(withheld), strings.Join(withheld, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/engine"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/secret"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+func explainCmd() *cobra.Command {
+	var (
+		output        string
+		maxIterations int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "explain [path] [question]",
+		Short: "Explain the repository with a read-only agent",
+		Long: `Run a read-only exploration agent that produces an architecture overview
+of the repository, for onboarding new team members or quick codebase audits.
+
+The agent can only search and read files (Read, Glob, Grep, Tree,
+Targets). It cannot edit files or run commands. If the first argument is an
+existing path, the overview is limited to it. Any remaining arguments are a
+question to answer instead of writing the full overview.
+
+Example:
+  agentic-coder explain
+  agentic-coder explain -o ARCHITECTURE.md
+  agentic-coder explain pkg/engine
+  agentic-coder explain "how are sessions persisted?"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var scope string
+			if len(args) > 0 {
+				if _, err := os.Stat(args[0]); err == nil {
+					scope, args = args[0], args[1:]
+				}
+			}
+			question := strings.Join(args, " ")
+
+			return runExplain(cmd.Context(), scope, question, output, maxIterations)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the document to this file instead of printing it")
+	cmd.Flags().IntVar(&maxIterations, "max-iterations", 40, "Maximum agent iterations")
+
+	return cmd
+}
+
+func runExplain(ctx context.Context, scope, question, output string, maxIterations int) error {
+	printer := ui.NewPrinter()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		select {
+		case <-sigCh:
+			printer.Warning("Interrupted, stopping exploration...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	providerType := provider.DetectProviderFromModel(model)
+	prov, err := createProvider(providerType, apiKey, printer)
+	if err != nil {
+		return err
+	}
+
+	// Only read tools: the agent must not change the repository
+	all := tool.NewRegistry()
+	defer registerBuiltinTools(all, nil).Shutdown()
+	registry := all.FilteredRegistry(engine.ExplainTools, nil)
+
+	toolLimits, err := configuredToolLimits()
+	if err != nil {
+		return err
+	}
+
+	builder := engine.NewPromptBuilder()
+	builder.ProjectPath = cwd
+	builder.CWD = cwd
+	builder.Version = version
+	builder.LoadInstructions()
+
+	// The session is not saved; explain runs leave no history behind
+	sess := session.NewSession(&session.SessionOptions{
+		ProjectPath: cwd,
+		CWD:         cwd,
+		Provider:    string(providerType),
+		Model:       provider.ResolveModel(model),
+		Version:     version,
+		MaxTokens:   200000,
+	})
+
+	eng := engine.NewEngine(&engine.EngineOptions{
+		Provider:           prov,
+		Registry:           registry,
+		Session:            sess,
+		MaxIterations:      maxIterations,
+		MaxTokens:          16384,
+		SystemPrompt:       builder.BuildExplain(),
+		CorrectiveFeedback: true,
+		ToolLimits:         toolLimits,
+		NoStream:           noStream,
+		UsageLedger:        usageLedger(),
+		Budget:             monthlyBudget(),
+		IterationLog:       iterationLog(),
+		PathScope:          pathScope(cwd),
+	})
+
+	eng.SetCallbacks(&engine.CallbackOptions{
+		OnToolUse: func(name string, input map[string]interface{}) {
+			printer.Dim("  %s %s", name, secret.MaskText(explainToolTarget(input)))
+		},
+		OnToolResult: func(name string, result *tool.Output) {
+			if result.IsError {
+				printer.Dim("  %s failed: %s", name, truncateLine(secret.MaskText(result.Content), 80))
+			}
+		},
+		OnError: func(err error) {
+			printer.Error("%v", err)
+		},
+	})
+
+	target := "repository"
+	if scope != "" {
+		target = scope
+	}
+	printer.Info("Exploring %s (read-only)...", target)
+
+	if err := eng.Run(ctx, engine.ExplainRequest(scope, question)); err != nil {
+		return fmt.Errorf("explain failed: %w", err)
+	}
+
+	doc := lastAssistantText(sess)
+	if doc == "" {
+		return fmt.Errorf("the agent did not produce a document")
+	}
+
+	if output == "" {
+		fmt.Println()
+		fmt.Println(doc)
+		return nil
+	}
+
+	if err := os.WriteFile(output, []byte(doc+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	printer.Success("Wrote %s", output)
+	return nil
+}
+
+// explainToolTarget returns the path or pattern a read tool was called with
+func explainToolTarget(input map[string]interface{}) string {
+	for _, key := range []string{"file_path", "pattern", "path"} {
+		if v, ok := input[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// lastAssistantText returns the text of the final assistant message
+func lastAssistantText(sess *session.Session) string {
+	msgs := sess.GetMessages()
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role != provider.RoleAssistant {
+			continue
+		}
+		var parts []string
+		for _, block := range msgs[i].Content {
+			if text, ok := block.(*provider.TextBlock); ok && text.Text != "" {
+				parts = append(parts, text.Text)
+			}
+		}
+		if len(parts) > 0 {
+			return strings.TrimSpace(strings.Join(parts, "\n"))
+		}
+	}
+	return ""
+}
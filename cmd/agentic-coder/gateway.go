@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/cost"
+	"github.com/xinguang/agentic-coder/pkg/gateway"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+func gatewayCmd() *cobra.Command {
+	var (
+		addr    string
+		token   string
+		retries int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "gateway",
+		Short: "Serve an OpenAI-compatible chat completions API backed by your providers",
+		Long: `Serve POST /v1/chat/completions and GET /v1/models in the OpenAI format,
+so other local tools can use agentic-coder's providers and credentials.
+
+Each request is routed by its model the way --model is: "sonnet" or
+"claude-..." go to Claude, "gpt-4o" to OpenAI, "github/gpt-4o" to GitHub
+Models, "llama" to Ollama, and so on. Requests without a model use --model.
+Rate limits, server errors, and dropped connections are retried with
+backoff, and the usage of each response is added to the ledger shown by
+"agentic-coder usage".
+
+The gateway listens on localhost only unless --addr says otherwise. Set
+--token (or AGENTIC_CODER_GATEWAY_TOKEN) to require clients to send it as a
+bearer token. Without one, requests from web pages and requests addressed
+to hosts other than localhost are refused.
+
+Example:
+  agentic-coder gateway
+  agentic-coder gateway --addr 127.0.0.1:9000 --token s3cret
+  OPENAI_BASE_URL=http://127.0.0.1:8788/v1 some-tool`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if token == "" {
+				token = os.Getenv("AGENTIC_CODER_GATEWAY_TOKEN")
+			}
+			return runGateway(cmd.Context(), addr, token, retries)
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8788", "Address to listen on")
+	cmd.Flags().StringVar(&token, "token", "", "Bearer token clients must send (default: AGENTIC_CODER_GATEWAY_TOKEN, none if unset)")
+	cmd.Flags().IntVar(&retries, "retries", 2, "Retries of a provider call that hit a rate limit or server error")
+	return cmd
+}
+
+func runGateway(ctx context.Context, addr, token string, retries int) error {
+	printer := ui.NewPrinter()
+	cwd, _ := os.Getwd()
+
+	// Providers are created once per type. The default one is created now so
+	// that any authentication prompt happens before serving.
+	var mu sync.Mutex
+	providers := make(map[provider.ProviderType]provider.AIProvider)
+	route := func(name string) (provider.AIProvider, string, error) {
+		providerType := provider.DetectProviderFromModel(name)
+		mu.Lock()
+		defer mu.Unlock()
+		prov, ok := providers[providerType]
+		if !ok {
+			var err error
+			if prov, err = createProvider(providerType, apiKey, printer); err != nil {
+				return nil, "", fmt.Errorf("model %s: %w", name, err)
+			}
+			providers[providerType] = prov
+		}
+		return prov, provider.ResolveModel(name), nil
+	}
+	if _, _, err := route(model); err != nil {
+		return err
+	}
+
+	var models []string
+	for alias := range provider.ModelAliases() {
+		models = append(models, alias)
+	}
+	sort.Strings(models)
+
+	server := gateway.NewServer(gateway.Options{
+		Route:        route,
+		DefaultModel: model,
+		Models:       models,
+		Token:        token,
+		Retries:      retries,
+		Ledger:       usageLedger(),
+		Project:      cwd,
+		Log: func(e gateway.Entry) {
+			line := fmt.Sprintf("%s %s/%s %d in, %d out, %s", time.Now().Format("15:04:05"), e.Provider, e.Model, e.InputTokens, e.OutputTokens, cost.FormatCost(e.Cost))
+			if e.Retries > 0 {
+				line += fmt.Sprintf(", %d retries", e.Retries)
+			}
+			if e.Err != nil {
+				printer.Error("%s: %v", line, e.Err)
+				return
+			}
+			printer.Dim("%s (%s)", line, e.Duration.Round(time.Millisecond))
+		},
+	})
+
+	httpServer := &http.Server{Addr: addr, Handler: server, ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdown)
+	}()
+
+	printer.Success("Gateway listening on http://%s/v1 (default model %s)", addr, model)
+	if token == "" {
+		printer.Dim("No --token set; any local client other than a web page can use your providers")
+	}
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	printer.Info("Gateway stopped; served %s of usage", cost.FormatCost(server.Spent()))
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/engine"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+func instructionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "instructions",
+		Short: "Inspect instruction files (AGENT.md, CLAUDE.md)",
+	}
+
+	cmd.AddCommand(instructionsCheckCmd())
+	return cmd
+}
+
+func instructionsCheckCmd() *cobra.Command {
+	var (
+		contextWindow int
+		budget        float64
+		strict        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Report instruction token cost, duplicate and conflicting rules",
+		Long: `Check the AGENT.md and CLAUDE.md files that are loaded into the system prompt.
+
+Reports the estimated token cost of each file, rules that appear more than
+once or that both require and forbid the same thing, and warns when the files
+loaded into every prompt exceed a share of the context window.
+
+The budget defaults to instruction_budget from the config file (5% if unset).
+
+Example:
+  agentic-coder instructions check
+  agentic-coder instructions check --context-window 128000 --budget 0.1`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+
+			if !cmd.Flags().Changed("budget") {
+				budget = settings.Get().InstructionBudget
+			}
+
+			builder := engine.NewPromptBuilder()
+			builder.ProjectPath = cwd
+			builder.CWD = cwd
+
+			report := engine.CheckInstructions(builder.InstructionFiles(), contextWindow, budget)
+			printInstructionReport(ui.NewPrinter(), report, cwd)
+
+			if strict && len(report.Issues) > 0 {
+				return fmt.Errorf("%d instruction issue(s) found", len(report.Issues))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&contextWindow, "context-window", engine.DefaultContextWindow, "Context window size in tokens")
+	cmd.Flags().Float64Var(&budget, "budget", engine.DefaultInstructionBudget, "Maximum share of the context window for instruction files (0-1)")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Exit with an error when any issue is found")
+	return cmd
+}
+
+// printInstructionReport prints the per-file token costs and issues
+func printInstructionReport(printer *ui.Printer, report *engine.InstructionReport, cwd string) {
+	if len(report.Files) == 0 {
+		printer.Info("No instruction files found")
+		return
+	}
+
+	printer.Section("Instruction files")
+	for _, f := range report.Files {
+		note := ""
+		if f.Lazy {
+			note = " (loaded when working in this directory)"
+		}
+		fmt.Printf("  %7d tokens  %-9s %s%s\n", f.Tokens, f.Kind, displayPath(f.Path, cwd), note)
+	}
+	fmt.Println()
+	printer.Dim("Loaded into every prompt: %d tokens (%.1f%% of %d, budget %.1f%%)",
+		report.TotalTokens, report.Share()*100, report.ContextWindow, report.Budget*100)
+
+	if len(report.Issues) == 0 {
+		printer.Success("No issues found")
+		return
+	}
+
+	fmt.Println()
+	printer.Section("Issues")
+	for _, issue := range report.Issues {
+		printer.Warning("[%s] %s", issue.Kind, issue.Message)
+		for _, loc := range issue.Locations {
+			printer.Dim("    %s", displayPath(loc, cwd))
+		}
+	}
+}
+
+// displayPath shortens paths under cwd to relative paths
+func displayPath(path, cwd string) string {
+	if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
@@ -4,11 +4,16 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -18,21 +23,28 @@ import (
 	"github.com/xinguang/agentic-coder/pkg/auth"
 	"github.com/xinguang/agentic-coder/pkg/config"
 	"github.com/xinguang/agentic-coder/pkg/cost"
+	"github.com/xinguang/agentic-coder/pkg/editor"
 	"github.com/xinguang/agentic-coder/pkg/engine"
+	"github.com/xinguang/agentic-coder/pkg/permission"
 	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/provider/azureopenai"
 	"github.com/xinguang/agentic-coder/pkg/provider/claude"
 	"github.com/xinguang/agentic-coder/pkg/provider/claudecli"
 	"github.com/xinguang/agentic-coder/pkg/provider/codexcli"
 	"github.com/xinguang/agentic-coder/pkg/provider/deepseek"
 	"github.com/xinguang/agentic-coder/pkg/provider/gemini"
 	"github.com/xinguang/agentic-coder/pkg/provider/geminicli"
+	"github.com/xinguang/agentic-coder/pkg/provider/github"
 	"github.com/xinguang/agentic-coder/pkg/provider/ollama"
 	"github.com/xinguang/agentic-coder/pkg/provider/openai"
+	"github.com/xinguang/agentic-coder/pkg/secret"
 	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/task"
 	"github.com/xinguang/agentic-coder/pkg/tool"
 	"github.com/xinguang/agentic-coder/pkg/tool/builtin"
 	"github.com/xinguang/agentic-coder/pkg/tui"
 	"github.com/xinguang/agentic-coder/pkg/ui"
+	"github.com/xinguang/agentic-coder/pkg/voice"
 	"github.com/xinguang/agentic-coder/pkg/workctx"
 )
 
@@ -42,6 +54,44 @@ var (
 	apiKey  string
 	verbose bool
 	useTUI  bool
+
+	// System prompt overrides (flags, then resolved with config)
+	systemPromptFlag       string
+	appendSystemPromptFlag string
+	systemPromptOverride   string
+	systemPromptAppend     string
+	outputStyle            string
+
+	// Lifts budget_enforce for this run
+	budgetOverride bool
+
+	// Requests complete responses instead of streams
+	noStream bool
+
+	// Replays responses to identical requests from disk
+	responseCache    bool
+	responseCacheDir string
+
+	// Thinking display (flag, then resolved with config) and whether
+	// thinking is saved with session transcripts
+	thinkingDisplay string
+	keepThinking    bool
+
+	// Removes the tools that write and runs only shell commands that read
+	readOnly bool
+
+	// Keeps the tools local when the project configures a remote host, and
+	// the host:dir they run on otherwise
+	localTools bool
+	remoteHost string
+
+	// Runs Bash and tests in the project's devcontainer, described by
+	// containerInfo once it is in use
+	inDevcontainer bool
+	containerInfo  string
+
+	// The global and project config, loaded once before any command runs
+	settings *config.ConfigManager
 )
 
 func main() {
@@ -50,11 +100,32 @@ func main() {
 		Short: "AI-powered coding assistant",
 		Long: `agentic-coder is an AI-powered coding assistant that helps you
 write, edit, and understand code using natural language.`,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			cwd, _ := os.Getwd()
+			var err error
+			settings, err = loadConfig(cwd)
+
+			// Set before any printer is created
+			if accessible, _ := cmd.Flags().GetBool("accessible"); accessible || settings.Get().Accessible {
+				ui.AccessibleMode = true
+			}
+			if err != nil {
+				ui.NewPrinter().Warning("Using the default settings: %v", err)
+			}
+			provider.SetModelAliases(settings.Get().ModelAliases)
+
+			metrics = openTelemetry()
+			if cmd.HasParent() {
+				metrics.Command(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "))
+			} else {
+				metrics.Command("chat")
+			}
+		},
 		RunE: runChat,
 	}
 
 	// Flags
-	rootCmd.PersistentFlags().StringVarP(&model, "model", "m", "sonnet", "Model: sonnet/opus/haiku, geminicli, gemini, gpt4o, deepseek, llama/qwen (Ollama)")
+	rootCmd.PersistentFlags().StringVarP(&model, "model", "m", "sonnet", "Model: sonnet/opus/haiku, geminicli, gemini, gpt4o, deepseek, llama/qwen (Ollama), github/gpt-4o (GitHub Models), provider:model such as ollama:qwen2.5-coder, or an alias (see alias list)")
 	rootCmd.PersistentFlags().StringVarP(&apiKey, "api-key", "k", "", "API key (defaults to ANTHROPIC_API_KEY env var)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
 	rootCmd.PersistentFlags().BoolVarP(&useTUI, "tui", "t", true, "Enable interactive TUI mode (default: true)")
@@ -66,7 +137,37 @@ write, edit, and understand code using natural language.`,
 	rootCmd.PersistentFlags().Bool("review-security", false, "Check for security issues")
 	rootCmd.PersistentFlags().Bool("review-style", false, "Check code style")
 	rootCmd.PersistentFlags().Bool("review-incremental", false, "Enable incremental review (only review changed code)")
-	rootCmd.PersistentFlags().String("thinking", "medium", "Thinking level: high, medium, low, none")
+	rootCmd.PersistentFlags().String("thinking", "medium", "Thinking level: ultra, high, medium, low, none (prompts saying \"think hard\" or \"ultrathink\" raise it)")
+	rootCmd.PersistentFlags().Int("thinking-budget", 0, "Thinking budget in tokens, overriding --thinking (min 1024, 0 = from level)")
+	rootCmd.PersistentFlags().StringVar(&thinkingDisplay, "thinking-display", "", "Thinking output: show, collapse (one summary line), or hide (default: collapse)")
+	rootCmd.PersistentFlags().StringVar(&systemPromptFlag, "system-prompt", "", "Replace the built-in system prompt (text, or @file)")
+	rootCmd.PersistentFlags().StringVar(&appendSystemPromptFlag, "append-system-prompt", "", "Append to the system prompt (text, or @file)")
+	rootCmd.PersistentFlags().Bool("tool-feedback", false, "Replace raw tool errors with corrective feedback (cause and suggested fix)")
+	rootCmd.PersistentFlags().Int("max-tool-failures", 0, "Abort when a tool fails this many times in a row (0 = never)")
+	rootCmd.PersistentFlags().Int("loop-threshold", 3, "Interrupt the agent after this many identical tool calls (negative disables)")
+	rootCmd.PersistentFlags().Int("max-output-tokens", 0, "Most tokens each response may use; a response that reaches it ends the turn instead of being continued (default 16384)")
+	rootCmd.PersistentFlags().StringArray("stop", nil, "End responses where the model writes this text (repeatable)")
+	rootCmd.PersistentFlags().String("tool-choice", "", "Tool calls the model makes: auto, any (must call one), none, or the name of a tool it must call (default: auto)")
+	rootCmd.PersistentFlags().String("first-tool", "", "Tool the first response to each prompt must call, such as TodoWrite to plan first")
+	rootCmd.PersistentFlags().Int("first-tool-min-words", 0, "Force --first-tool only for prompts of at least this many words")
+	rootCmd.PersistentFlags().Int("stale-result-turns", 0, "Send tool results followed by this many responses as short summaries (0 = never)")
+	rootCmd.PersistentFlags().BoolVar(&noStream, "no-stream", false, "Request complete responses instead of streaming (for proxies that break SSE)")
+	rootCmd.PersistentFlags().Bool("accessible", false, "Screen-reader friendly output: plain labeled text without color, icons, or the full-screen TUI (also TERM=dumb)")
+	rootCmd.PersistentFlags().BoolVar(&responseCache, "response-cache", false, "Answer requests identical to earlier ones from a cache of their responses, for deterministic reruns (also AGENTIC_CODER_RESPONSE_CACHE=1)")
+	rootCmd.PersistentFlags().StringVar(&responseCacheDir, "response-cache-dir", "", "Directory of the response cache (default ~/.agentic-coder/cache/responses)")
+	rootCmd.PersistentFlags().Bool("speak", false, "Read out a short summary of each completed turn (say, espeak, or OpenAI speech; also voice.speak)")
+	rootCmd.PersistentFlags().BoolVar(&readOnly, "read-only", false, "Never change anything: remove the tools that write, run only shell commands that read, and plan instead of editing")
+	rootCmd.PersistentFlags().BoolVar(&localTools, "local", false, "Run the file tools and Bash on this machine even when the project configures a remote host")
+	rootCmd.PersistentFlags().BoolVar(&inDevcontainer, "devcontainer", false, "Run Bash and tests inside the project's devcontainer (.devcontainer/devcontainer.json)")
+	rootCmd.PersistentFlags().BoolVar(&budgetOverride, "budget-override", false, "Use paid providers even when the monthly budget is spent")
+	rootCmd.Flags().Bool("autonomous", false, "Work on --task without user input until it is done or a limit is reached, reporting progress into a work context")
+	rootCmd.Flags().String("task", "", "Task for --autonomous (text, or @file)")
+	rootCmd.Flags().String("work", "", "Work context ID for --autonomous progress; its goal is the task when --task is not given")
+	rootCmd.Flags().Duration("report-every", 5*time.Minute, "Interval between --autonomous progress reports")
+	rootCmd.Flags().Duration("max-duration", 0, "Stop --autonomous after this long (0 = no limit)")
+	rootCmd.Flags().Float64("max-cost", 0, "Stop --autonomous after this estimated cost in dollars (0 = no limit)")
+	rootCmd.Flags().Int("max-turns", 0, "Stop --autonomous after this many turns (0 = no limit)")
+	rootCmd.Flags().Bool("continue", false, "Continue the --autonomous run paused in the latest session")
 
 	// Subcommands
 	rootCmd.AddCommand(versionCmd())
@@ -74,8 +175,24 @@ write, edit, and understand code using natural language.`,
 	rootCmd.AddCommand(authCmd())
 	rootCmd.AddCommand(workCmd())
 	rootCmd.AddCommand(workflowCmd())
-
-	if err := rootCmd.Execute(); err != nil {
+	rootCmd.AddCommand(instructionsCmd())
+	rootCmd.AddCommand(migrateCmd())
+	rootCmd.AddCommand(sessionCmd())
+	rootCmd.AddCommand(explainCmd())
+	rootCmd.AddCommand(usageCmd())
+	rootCmd.AddCommand(bugReportCmd())
+	rootCmd.AddCommand(changelogCmd())
+	rootCmd.AddCommand(releaseCmd())
+	rootCmd.AddCommand(telemetryCmd())
+	rootCmd.AddCommand(gatewayCmd())
+	rootCmd.AddCommand(embedCmd())
+	rootCmd.AddCommand(mcpCmd())
+	rootCmd.AddCommand(aliasCmd())
+
+	err := rootCmd.Execute()
+	metrics.Error(err)
+	metrics.Close(context.Background(), version)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -92,13 +209,62 @@ func versionCmd() *cobra.Command {
 }
 
 func configCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "config",
 		Short: "Manage configuration",
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Println("Configuration management")
 		},
 	}
+
+	extendsCmd := &cobra.Command{
+		Use:   "extends",
+		Short: "List the configs the global and project configs extend, with checksums to pin",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			cm, err := config.NewConfigManager()
+			if err != nil {
+				return err
+			}
+			refresh, _ := cmd.Flags().GetBool("refresh")
+			cm.SetRefresh(refresh)
+			if err := cm.Load(cwd); err != nil {
+				return err
+			}
+
+			printer := ui.NewPrinter()
+			if len(cm.Bases()) == 0 && len(cm.Warnings()) == 0 {
+				fmt.Println("No config extends another.")
+				return nil
+			}
+			for _, base := range cm.Bases() {
+				status := "fetched"
+				switch {
+				case !strings.HasPrefix(base.Source, "https://"):
+					status = "local"
+				case base.Cached:
+					status = "cached"
+				}
+				pin := ""
+				if base.Pinned {
+					pin = ", pinned"
+				}
+				fmt.Printf("%s (%s%s)\n", base.Source, status, pin)
+				printer.Dim("  #sha256=%s", base.Checksum)
+			}
+			for _, warning := range cm.Warnings() {
+				printer.Warning("%s", warning)
+			}
+			return nil
+		},
+	}
+	extendsCmd.Flags().Bool("refresh", false, "Fetch remote configs again instead of using cached copies")
+	cmd.AddCommand(extendsCmd)
+
+	return cmd
 }
 
 func authCmd() *cobra.Command {
@@ -110,7 +276,7 @@ func authCmd() *cobra.Command {
 	// Login subcommand
 	loginCmd := &cobra.Command{
 		Use:   "login [provider]",
-		Short: "Authenticate with a provider (claude, gemini, openai)",
+		Short: "Authenticate with a provider (claude, gemini, openai, github)",
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			providerName := "claude"
@@ -129,6 +295,8 @@ func authCmd() *cobra.Command {
 				provider = auth.ProviderGemini
 			case "openai":
 				provider = auth.ProviderOpenAI
+			case "github", "gh":
+				provider = auth.ProviderGitHub
 			default:
 				return fmt.Errorf("unknown provider: %s", providerName)
 			}
@@ -159,6 +327,8 @@ func authCmd() *cobra.Command {
 				provider = auth.ProviderGemini
 			case "openai":
 				provider = auth.ProviderOpenAI
+			case "github", "gh":
+				provider = auth.ProviderGitHub
 			default:
 				return fmt.Errorf("unknown provider: %s", providerName)
 			}
@@ -426,6 +596,20 @@ func runChat(cmd *cobra.Command, args []string) error {
 
 	// Check if CLAUDE.md needs migration to AGENT.md
 	checkAndPromptMigration(cwd, printer)
+	warnConfigBases(printer)
+
+	// Resolve system prompt overrides from flags and config
+	if err := resolveSystemPrompt(); err != nil {
+		return err
+	}
+	if err := resolveThinkingDisplay(); err != nil {
+		return err
+	}
+
+	// Switch to the budget model when this month's budget is already spent
+	budget := monthlyBudget()
+	ledger := usageLedger()
+	model = budgetModel(model, budget, ledger, printer)
 
 	// Detect provider from model
 	providerType := provider.DetectProviderFromModel(model)
@@ -435,27 +619,59 @@ func runChat(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	prov = cacheResponses(prov, printer)
+	defer reportResponseCache(prov, printer)
 
 	// Create tool registry
 	registry := tool.NewRegistry()
-	registerBuiltinTools(registry)
+	useEnvPolicy()
+	supervisor := builtin.NewSupervisor(processLimits())
+	shells := registerBuiltinTools(registry, supervisor)
+	defer stopShells(shells, printer)
+	lsp := builtin.NewLSPTool()
+	autonomous, _ := cmd.Flags().GetBool("autonomous")
+	remoteHost = useRemote(cwd, registry, shells, !autonomous && stdinIsTerminal(), printer)
+	if containerInfo, err = useDevcontainer(cwd, registry, shells, printer); err != nil {
+		return err
+	}
+	if readOnly {
+		engine.RemoveWritingTools(registry)
+		printer.Info("Read-only mode: files are not changed and Bash runs only commands that read")
+	}
 
 	// Create session manager
+	signingKey, err := loadSigningKey()
+	if err != nil {
+		return fmt.Errorf("failed to load transcript signing key: %w", err)
+	}
 	sessMgr, err := session.NewSessionManager(&session.ManagerOptions{
 		ProjectPath: cwd,
+		SigningKey:  signingKey,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create session manager: %w", err)
 	}
 
-	// Try to resume the latest session for this project
+	// Try to resume the latest session for this project; autonomous runs
+	// start a session of their own
+	continueRun, _ := cmd.Flags().GetBool("continue")
+	if continueRun && !autonomous {
+		return fmt.Errorf("--continue needs --autonomous; in a chat, /continue resumes a paused run")
+	}
 	var sess *session.Session
-	sess, err = sessMgr.ResumeLatest()
-	if err != nil {
+	if !autonomous || continueRun {
+		sess, err = sessMgr.ResumeLatest()
+	}
+	if continueRun && (err != nil || sess.Paused() == nil) {
+		return fmt.Errorf("the latest session has no paused run to continue")
+	}
+	if (autonomous && !continueRun) || err != nil {
 		// No existing session, create a new one
 		sess, err = sessMgr.NewSession(&session.SessionOptions{
 			ProjectPath: cwd,
 			CWD:         cwd,
+			GitBranch:   gitBranch(cwd),
+			Provider:    string(providerType),
 			Model:       provider.ResolveModel(model),
 			Version:     version,
 			MaxTokens:   200000,
@@ -467,26 +683,143 @@ func runChat(cmd *cobra.Command, args []string) error {
 			printer.Dim("Started new session: %s", sess.ID[:8])
 		}
 	} else {
+		// Sessions saved before automatic tags existed lack this metadata
+		if sess.Provider == "" {
+			sess.Provider = string(providerType)
+		}
+		if sess.GitBranch == "" {
+			sess.GitBranch = gitBranch(cwd)
+		}
 		printer.Dim("Resumed session: %s (%d messages)", sess.ID[:8], len(sess.Messages))
 	}
 
+	sess.KeepThinking = keepThinking
+
 	// Create work context manager
 	workMgr := workctx.NewManager("")
 
 	// Get thinking level
 	thinkingLevel, _ := cmd.Flags().GetString("thinking")
+	thinkingBudget, _ := cmd.Flags().GetInt("thinking-budget")
+	if thinkingBudget != 0 && thinkingBudget < 1024 {
+		return fmt.Errorf("--thinking-budget must be at least 1024 tokens")
+	}
+	toolFeedback, _ := cmd.Flags().GetBool("tool-feedback")
+	maxToolFailures, _ := cmd.Flags().GetInt("max-tool-failures")
+	loopThreshold, _ := cmd.Flags().GetInt("loop-threshold")
+	toolLimits, err := configuredToolLimits()
+	if err != nil {
+		return err
+	}
+	jsonRepair, err := tool.ParseRepairMode(settings.Get().ToolJSONRepair)
+	if err != nil {
+		return fmt.Errorf("tool_json_repair: %w", err)
+	}
+	var toolChoice *provider.ToolChoice
+	if choice, _ := cmd.Flags().GetString("tool-choice"); choice != "" {
+		if toolChoice, err = provider.ParseToolChoice(choice); err != nil {
+			return fmt.Errorf("invalid --tool-choice: %w", err)
+		}
+	}
+	maxOutputTokens, _ := cmd.Flags().GetInt("max-output-tokens")
+	if maxOutputTokens < 0 {
+		return fmt.Errorf("--max-output-tokens must not be negative")
+	}
+	if maxOutputTokens == 0 {
+		maxOutputTokens = 16384
+	}
+	stopSequences, _ := cmd.Flags().GetStringArray("stop")
+	cfg := settings.Get()
+	firstTool, _ := cmd.Flags().GetString("first-tool")
+	firstToolMinWords, _ := cmd.Flags().GetInt("first-tool-min-words")
+	staleTurns, _ := cmd.Flags().GetInt("stale-result-turns")
+	if !cmd.Flags().Changed("stale-result-turns") {
+		staleTurns = cfg.StaleResultTurns
+	}
+
+	destructive, verifier := destructiveGuard(printer)
+
+	// Start MCP servers; the project's only once the user trusts them
+	if manager := startMCPServers(cwd, registry, !autonomous && stdinIsTerminal(), printer); manager != nil {
+		defer manager.Close()
+	}
+	// Forced tools are named as the model sees them, not by an alias
+	if toolChoice != nil && toolChoice.Type == provider.ToolChoiceTool {
+		t, err := registry.Get(toolChoice.Name)
+		if err != nil {
+			return fmt.Errorf("invalid --tool-choice: %w", err)
+		}
+		toolChoice.Name = t.Name()
+	}
+	if firstTool != "" {
+		t, err := registry.Get(firstTool)
+		if err != nil {
+			return fmt.Errorf("invalid --first-tool: %w", err)
+		}
+		firstTool = t.Name()
+	}
 
 	// Create engine
-	eng := engine.NewEngine(&engine.EngineOptions{
-		Provider:      prov,
-		Registry:      registry,
-		Session:       sess,
-		MaxIterations: 100,
-		MaxTokens:     16384,
-		SystemPrompt:  getSystemPrompt(),
-		ThinkingLevel: thinkingLevel,
+	engineOpts := &engine.EngineOptions{
+		Provider:            prov,
+		Registry:            registry,
+		Session:             sess,
+		MaxIterations:       100,
+		MaxTokens:           maxOutputTokens,
+		StopSequences:       stopSequences,
+		Temperature:         cfg.Temperature,
+		TopP:                cfg.TopP,
+		TopK:                cfg.TopK,
+		Seed:                cfg.Seed,
+		StopAtMaxTokens:     cmd.Flags().Changed("max-output-tokens"),
+		SystemPrompt:        getSystemPrompt(),
+		ThinkingLevel:       thinkingLevel,
+		ThinkingBudget:      thinkingBudget,
+		CorrectiveFeedback:  toolFeedback,
+		MaxToolFailures:     maxToolFailures,
+		LoopThreshold:       loopThreshold,
+		ToolLimits:          toolLimits,
+		NoStream:            noStream,
+		UsageLedger:         ledger,
+		Budget:              budget,
+		IterationLog:        iterationLog(),
+		PathScope:           pathScope(cwd),
+		StaleResultTurns:    staleTurns,
+		JSONRepair:          jsonRepair,
+		AttachmentBudget:    cfg.AttachmentBudget,
+		NativeWebSearch:     nativeWebSearch(),
+		ToolChoice:          toolChoice,
+		FirstTool:           firstTool,
+		FirstToolMinWords:   firstToolMinWords,
+		Destructive:         destructive,
+		DestructiveVerifier: verifier,
+		ReadOnly:            readOnly,
+	}
+	eng := engine.NewEngine(engineOpts)
+
+	// Subagents started with the Task tool use the session's provider and,
+	// unless they ask for another, its model
+	tasks := task.NewManager(func(string) provider.AIProvider { return eng.Provider() }, registry)
+	tasks.SetDefaultModel(func() string { return eng.Session().Model })
+	subagentOpts := *engineOpts
+	subagentOpts.IterationLog = nil
+	tasks.SetEngineDefaults(subagentOpts)
+	registry.Register(builtin.NewTaskTool(tasks))
+	registry.Register(builtin.NewTaskOutputTool(tasks))
+
+	// A run stopped at the iteration limit leaves its wrap-up in the
+	// current work context
+	eng.SetCallbacks(&engine.CallbackOptions{
+		OnWrapUp: func(summary string) {
+			recordWrapUp(workMgr, summary, printer)
+		},
 	})
 
+	if autonomous {
+		speakTurns(eng, speechAnnouncer(cmd, printer, false))
+		return runAutonomous(cmd, eng, sess, sessMgr, workMgr, printer, cwd)
+	}
+
 	// Check for --no-tui flag
 	noTUI, _ := cmd.Flags().GetBool("no-tui")
 	enableReview, _ := cmd.Flags().GetBool("review")
@@ -497,8 +830,10 @@ func runChat(cmd *cobra.Command, args []string) error {
 	_, _ = cmd.Flags().GetBool("review-style")        // TODO: reviewStyle
 	_, _ = cmd.Flags().GetBool("review-incremental")  // TODO: reviewIncremental
 
-	// Use TUI mode if enabled and not disabled
-	if useTUI && !noTUI {
+	// Use TUI mode if enabled and not disabled. Its redrawn screen and
+	// spinners are unusable with screen readers, so accessible mode is
+	// always classic.
+	if useTUI && !noTUI && !ui.AccessibleMode {
 		sessionID := sess.ID
 		if len(sessionID) > 8 {
 			sessionID = sessionID[:8]
@@ -507,6 +842,17 @@ func runChat(cmd *cobra.Command, args []string) error {
 		// Create a pointer to track current session for callbacks
 		currentSess := sess
 
+		// Provider of each tab's engine; eng, currentSess, and providerType
+		// are those of the tab being shown
+		tabProviders := map[*engine.Engine]provider.ProviderType{eng: providerType}
+		speech := speechAnnouncer(cmd, printer, true)
+		speakTurns(eng, speech)
+		saveSession := func(e *engine.Engine) tui.SaveSessionCallback {
+			return func() error {
+				return sessMgr.SaveSession(e.Session())
+			}
+		}
+
 		runner := tui.NewAppRunner(eng, tui.Config{
 			EnableReview:    enableReview,
 			MaxReviewCycles: 5,
@@ -515,6 +861,8 @@ func runChat(cmd *cobra.Command, args []string) error {
 			Version:      version,
 			SessionID:    sessionID,
 			MessageCount: len(sess.Messages),
+			ThinkingDisplay: thinkingDisplay,
+			Verbose:         verbose,
 			OnListSessions: func() []tui.SessionInfo {
 				sessions, err := sessMgr.ListSessions()
 				if err != nil {
@@ -551,6 +899,7 @@ func runChat(cmd *cobra.Command, args []string) error {
 				if err != nil {
 					return 0, err
 				}
+				newSess.KeepThinking = keepThinking
 				currentSess = newSess
 				eng.SetSession(newSess)
 				return len(newSess.Messages), nil
@@ -559,6 +908,8 @@ func runChat(cmd *cobra.Command, args []string) error {
 				newSess, err := sessMgr.NewSession(&session.SessionOptions{
 					ProjectPath: cwd,
 					CWD:         cwd,
+					GitBranch:   gitBranch(cwd),
+					Provider:    string(providerType),
 					Model:       currentSess.Model,
 					Version:     version,
 					MaxTokens:   200000,
@@ -566,12 +917,98 @@ func runChat(cmd *cobra.Command, args []string) error {
 				if err != nil {
 					return "", err
 				}
+				newSess.KeepThinking = keepThinking
 				currentSess = newSess
 				eng.SetSession(newSess)
 				return newSess.ID, nil
 			},
-			OnSaveSession: func() error {
-				return sessMgr.SaveSession(currentSess)
+			OnSaveSession: saveSession(eng),
+			OnNewTab: func() (*engine.Engine, string, tui.SaveSessionCallback, error) {
+				newSess, err := sessMgr.NewSession(&session.SessionOptions{
+					ProjectPath: cwd,
+					CWD:         cwd,
+					GitBranch:   gitBranch(cwd),
+					Provider:    string(providerType),
+					Model:       eng.Model(),
+					Version:     version,
+					MaxTokens:   200000,
+				})
+				if err != nil {
+					return nil, "", nil, err
+				}
+				newSess.KeepThinking = keepThinking
+				opts := *engineOpts
+				opts.Provider = eng.Provider()
+				opts.Session = newSess
+				newEng := engine.NewEngine(&opts)
+				speakTurns(newEng, speech)
+				tabProviders[newEng] = providerType
+				return newEng, newSess.Model, saveSession(newEng), nil
+			},
+			OnSwitchTab: func(e *engine.Engine) {
+				eng, currentSess, providerType = e, e.Session(), tabProviders[e]
+			},
+			OnTag: func(args []string) (string, error) {
+				msg, err := tagSession(currentSess, args)
+				if err != nil {
+					return "", err
+				}
+				return msg, sessMgr.SaveSession(currentSess)
+			},
+			OnPin: func(args []string, unpin bool) (string, error) {
+				msg, err := pinFiles(currentSess, args, unpin)
+				if err != nil {
+					return "", err
+				}
+				return msg, sessMgr.SaveSession(currentSess)
+			},
+			OnCommand: recordCommand,
+			OnError: func(err error) {
+				metrics.Error(err)
+			},
+			OnDiffContext: func(args []string) (string, error) {
+				return shareBranchDiff(eng, cwd, args)
+			},
+			OnRewind: func(args []string) (string, error) {
+				msg, err := rewindSession(currentSess, args)
+				if err != nil {
+					return "", err
+				}
+				return msg, sessMgr.SaveSession(currentSess)
+			},
+			OnTools: func(args []string) (string, error) {
+				return manageTools(registry, args)
+			},
+			OnLimits: func() string {
+				return describeRateLimits(eng.Provider())
+			},
+			OnSpeed: modelSpeeds,
+			OnEnv:   describeEnv,
+			OnProcesses: func(args []string) (string, error) {
+				return manageProcesses(supervisor, shells, args)
+			},
+			OnTasks: func(args []string) (string, error) {
+				return manageTasks(tasks, args)
+			},
+			OnRefactor: func(ctx context.Context, args []string, confirm func(preview string) bool) (string, error) {
+				return refactor(ctx, lsp, currentSess, cwd, args, confirm)
+			},
+			Voice:  voiceInput(),
+			Editor: globalEditor(),
+			OnModel: func(name string) (string, error) {
+				model, switched, err := switchModel(eng, providerType, name, printer)
+				if err != nil {
+					return "", err
+				}
+				providerType = switched
+				tabProviders[eng] = switched
+				return model, nil
+			},
+			OnOutputStyle: func(name string) (string, error) {
+				if name == "" {
+					return describeOutputStyles(), nil
+				}
+				return setOutputStyle(name, cwd, eng)
 			},
 		})
 
@@ -585,15 +1022,39 @@ func runChat(cmd *cobra.Command, args []string) error {
 
 	// Create cost tracker for classic mode
 	costTracker := cost.NewTracker(sess.Model)
+	speakTurns(eng, speechAnnouncer(cmd, printer, false))
 
 	// Set callbacks using ui package (classic mode)
+	// Shared stdin reader for the interactive loop and stuck prompts
+	reader := bufio.NewReader(os.Stdin)
+
+	// Whether streamed thinking is being printed
+	thinkingOpen := false
+
 	eng.SetCallbacks(&engine.CallbackOptions{
 		OnText: func(text string) {
 			fmt.Print(text)
 		},
 		OnThinking: func(text string) {
-			if verbose {
+			if thinkingDisplay != ui.ThinkingShow {
+				return
+			}
+			if !thinkingOpen {
+				fmt.Println()
 				printer.Thinking(text)
+				thinkingOpen = true
+			} else {
+				printer.ThinkingText(text)
+			}
+		},
+		OnThinkingDone: func(thinking string) {
+			switch thinkingDisplay {
+			case ui.ThinkingShow:
+				fmt.Println()
+				thinkingOpen = false
+			case ui.ThinkingCollapse:
+				fmt.Println()
+				printer.ThinkingCollapsed(thinking)
 			}
 		},
 		OnToolUse: func(name string, input map[string]interface{}) {
@@ -601,14 +1062,14 @@ func runChat(cmd *cobra.Command, args []string) error {
 			printer.Tool(name)
 			fmt.Println()
 			for k, v := range input {
-				printer.ToolParam(k, fmt.Sprintf("%v", v))
+				printer.ToolParam(k, secret.MaskText(fmt.Sprintf("%v", v)))
 			}
 		},
 		OnToolResult: func(name string, result *tool.Output) {
 			if result.IsError {
-				printer.ToolError(name, result.Content)
+				printer.ToolError(name, secret.MaskText(result.Content))
 			} else {
-				content := result.Content
+				content := secret.MaskText(result.Content)
 				lines := strings.Split(content, "\n")
 				if len(lines) > 5 {
 					printer.ToolSuccess(name, fmt.Sprintf("%d lines", len(lines)))
@@ -628,9 +1089,64 @@ func runChat(cmd *cobra.Command, args []string) error {
 		OnUsage: func(inputTokens, outputTokens int) {
 			costTracker.AddUsage(inputTokens, outputTokens)
 		},
+		OnAttach: func(plan *engine.AttachmentPlan) {
+			printer.Dim("📎 %s", plan)
+		},
+		OnSources: func(citations []provider.Citation) {
+			fmt.Println()
+			printer.Dim("%s", engine.FormatSources(citations))
+		},
+		OnSubtask: func(event *tool.SubtaskEvent) {
+			switch event.Kind {
+			case tool.SubtaskToolUse:
+				printer.Dim("  │ %s %s", event.Tool, explainToolTarget(event.Input))
+			case tool.SubtaskFinished:
+				printer.Dim("  └ %s %s: %s, %d tools, %s tokens", event.Agent, event.ID[:8], event.Status,
+					event.ToolCalls, ui.FormatTokens(event.InputTokens+event.OutputTokens))
+			}
+		},
 		OnError: func(err error) {
+			metrics.Error(err)
 			printer.Error("%v", err)
 		},
+		OnBudget: func(alert engine.BudgetAlert) {
+			fmt.Println()
+			printer.Warning("%s", alert)
+		},
+		OnIteration: func(summary engine.IterationSummary) {
+			if verbose {
+				printer.Dim("↻ %s", summary)
+			}
+		},
+		OnStuck: func(reason string) string {
+			fmt.Println()
+			printer.Warning("The agent appears to be stuck: %s", reason)
+			fmt.Print("Guidance for the agent (Enter to let it recover on its own): ")
+			guidance, _ := reader.ReadString('\n')
+			return strings.TrimSpace(guidance)
+		},
+		OnPathAccess: func(access engine.PathAccess) engine.PathDecision {
+			fmt.Println()
+			printer.Warning("%s wants to access %s, outside the project", access.Tool, access.Path)
+			fmt.Printf("Allow? [y]es once, [a]lways for %s, [N]o: ", access.Dir)
+			answer, _ := reader.ReadString('\n')
+			return engine.ParsePathDecision(answer)
+		},
+		OnDestructive: func(action engine.DestructiveAction) bool {
+			fmt.Println()
+			printer.Warning("The agent wants to run a command that %s:", action.Reason)
+			printer.Dim("  %s", action.Target)
+			for _, f := range action.Analysis {
+				printer.Dim("  • It %s", f.Explanation)
+			}
+			if action.Verdict != "" {
+				printer.Dim("The verifying model did not approve it: %s", action.Verdict)
+			}
+			fmt.Print("Run it? [y/N]: ")
+			answer, _ := reader.ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			return answer == "y" || answer == "yes"
+		},
 	})
 
 	// Signal handling for Ctrl+C
@@ -639,15 +1155,27 @@ func runChat(cmd *cobra.Command, args []string) error {
 
 	// Track current operation context
 	var currentCancel context.CancelFunc
-	var isRunning bool
+	var isRunning, terminating bool
 	var mu sync.Mutex
 
 	// Handle signals in background
 	go func() {
-		for range sigCh {
+		for sig := range sigCh {
 			mu.Lock()
-			if isRunning && currentCancel != nil {
-				// First Ctrl+C: cancel current operation
+			if sig == syscall.SIGTERM && isRunning && !terminating {
+				// Shutdown during a run: pause it so it can continue later
+				terminating = true
+				fmt.Println()
+				printer.Warning("Pausing the run after the current tool before exiting")
+				eng.Pause()
+				mu.Unlock()
+			} else if isRunning && eng.InterruptTool() {
+				// Ctrl+C during a tool: stop just the tool and keep the turn going
+				fmt.Println()
+				printer.Warning("Tool interrupted; the agent will continue. Press Ctrl+C again to stop the run.")
+				mu.Unlock()
+			} else if isRunning && currentCancel != nil {
+				// Ctrl+C otherwise: cancel current operation
 				printer.Warning("Interrupted. Press Ctrl+C again to exit.")
 				currentCancel()
 				mu.Unlock()
@@ -656,6 +1184,7 @@ func runChat(cmd *cobra.Command, args []string) error {
 				mu.Unlock()
 				fmt.Println()
 				printer.Dim("Goodbye!")
+				metrics.Close(context.Background(), version)
 				os.Exit(0)
 			}
 		}
@@ -663,6 +1192,9 @@ func runChat(cmd *cobra.Command, args []string) error {
 
 	// Print welcome banner
 	printer.WelcomeBanner(version, sess.Model, cwd)
+	if paused := sess.Paused(); paused != nil {
+		printer.Info("A run was paused at %s; /continue resumes it", paused.At.Format("01/02 15:04"))
+	}
 
 	// Create chat context for handling commands
 	chatCtx := &chatContext{
@@ -671,13 +1203,18 @@ func runChat(cmd *cobra.Command, args []string) error {
 		workMgr:     workMgr,
 		printer:     printer,
 		engine:      eng,
-		provider:    prov,
+		registry:    registry,
 		provType:    providerType,
 		costTracker: costTracker,
+		supervisor:  supervisor,
+		shells:      shells,
+		tasks:       tasks,
+		lsp:         lsp,
+		reader:      reader,
+		voice:       voiceInput(),
 	}
 
 	// Interactive loop
-	reader := bufio.NewReader(os.Stdin)
 	for {
 		printer.Prompt()
 
@@ -696,7 +1233,12 @@ func runChat(cmd *cobra.Command, args []string) error {
 			if handleCommand(input, chatCtx) {
 				continue
 			}
+			if chatCtx.prompt != "" {
+				input, chatCtx.prompt = chatCtx.prompt, ""
+			}
 		}
+		resume := chatCtx.resume
+		chatCtx.resume = false
 
 		// Create context for this operation
 		ctx, cancel := context.WithCancel(context.Background())
@@ -707,7 +1249,12 @@ func runChat(cmd *cobra.Command, args []string) error {
 
 		// Run engine
 		fmt.Println()
-		err = eng.Run(ctx, input)
+		printer.Status("Working")
+		if resume {
+			err = eng.Resume(ctx)
+		} else {
+			err = eng.Run(ctx, input)
+		}
 
 		// Mark operation as done
 		mu.Lock()
@@ -716,15 +1263,31 @@ func runChat(cmd *cobra.Command, args []string) error {
 		mu.Unlock()
 		cancel() // Clean up context
 
+		if errors.Is(err, engine.ErrPaused) {
+			fmt.Println()
+			printer.Status("Paused; /continue resumes the run, also after a restart")
+			if err := sessMgr.SaveSession(sess); err != nil {
+				printer.Warning("Failed to save session: %v", err)
+			}
+			mu.Lock()
+			exit := terminating
+			mu.Unlock()
+			if exit {
+				return nil
+			}
+			continue
+		}
 		if err != nil {
 			if ctx.Err() != nil {
 				// User interrupted, continue to next input
 				fmt.Println()
+				printer.Status("Stopped, ready for input")
 				continue
 			}
 			printer.Error("%v", err)
 		}
 		fmt.Println()
+		printer.Status("Done, ready for input")
 
 		// Save session
 		if err := sessMgr.SaveSession(sess); err != nil {
@@ -744,9 +1307,17 @@ type chatContext struct {
 	workMgr    *workctx.Manager
 	printer    *ui.Printer
 	engine     *engine.Engine
-	provider   provider.AIProvider
+	registry   *tool.Registry
 	provType   provider.ProviderType
 	costTracker *cost.Tracker
+	supervisor *builtin.Supervisor
+	shells     *builtin.ShellManager
+	tasks      *task.Manager
+	lsp        *builtin.LSPTool
+	reader     *bufio.Reader
+	prompt     string // set by commands that run the agent
+	resume     bool   // set by /continue to resume the paused run
+	voice      *voice.Input
 }
 
 func handleCommand(cmd string, ctx *chatContext) bool {
@@ -755,13 +1326,16 @@ func handleCommand(cmd string, ctx *chatContext) bool {
 		return true
 	}
 
+	recordCommand(parts[0])
 	switch parts[0] {
 	case "/help", "/h":
 		ctx.printer.HelpMenu()
 		return true
 
 	case "/clear", "/cls":
-		fmt.Print("\033[H\033[2J")
+		if !ctx.printer.Accessible {
+			fmt.Print("\033[H\033[2J")
+		}
 		return true
 
 	case "/session":
@@ -791,9 +1365,13 @@ func handleCommand(cmd string, ctx *chatContext) bool {
 		}
 		items := make([]ui.SessionListItem, 0, len(sessions))
 		for _, s := range sessions {
+			preview := fmt.Sprintf("%s (%d messages)", s.Model, s.MessageCount)
+			if len(s.Tags) > 0 {
+				preview += " #" + strings.Join(s.Tags, " #")
+			}
 			items = append(items, ui.SessionListItem{
 				ID:        s.ID,
-				Preview:   fmt.Sprintf("%s (%d messages)", s.Model, s.MessageCount),
+				Preview:   preview,
 				UpdatedAt: s.LastUpdated,
 				IsCurrent: s.ID == ctx.session.ID,
 			})
@@ -830,6 +1408,8 @@ func handleCommand(cmd string, ctx *chatContext) bool {
 		sess, err := ctx.sessMgr.NewSession(&session.SessionOptions{
 			ProjectPath: cwd,
 			CWD:         cwd,
+			GitBranch:   gitBranch(cwd),
+			Provider:    string(ctx.provType),
 			Model:       ctx.session.Model,
 			Version:     version,
 			MaxTokens:   200000,
@@ -842,6 +1422,106 @@ func handleCommand(cmd string, ctx *chatContext) bool {
 		ctx.printer.Success("Started new session: %s", sess.ID)
 		return true
 
+	case "/tag":
+		msg, err := tagSession(ctx.session, parts[1:])
+		if err != nil {
+			ctx.printer.Error("%v", err)
+			return true
+		}
+		if err := ctx.sessMgr.SaveSession(ctx.session); err != nil {
+			ctx.printer.Warning("Failed to save session: %v", err)
+		}
+		fmt.Println(msg)
+		return true
+
+	case "/pin", "/unpin":
+		msg, err := pinFiles(ctx.session, parts[1:], parts[0] == "/unpin")
+		if err != nil {
+			ctx.printer.Error("%v", err)
+			return true
+		}
+		if err := ctx.sessMgr.SaveSession(ctx.session); err != nil {
+			ctx.printer.Warning("Failed to save session: %v", err)
+		}
+		fmt.Println(msg)
+		return true
+
+	case "/diff-context":
+		msg, err := shareBranchDiff(ctx.engine, ctx.session.CWD, parts[1:])
+		if err != nil {
+			ctx.printer.Error("%v", err)
+			return true
+		}
+		fmt.Println(msg)
+		return true
+
+	case "/rewind":
+		msg, err := rewindSession(ctx.session, parts[1:])
+		if err != nil {
+			ctx.printer.Error("%v", err)
+			return true
+		}
+		if err := ctx.sessMgr.SaveSession(ctx.session); err != nil {
+			ctx.printer.Warning("Failed to save session: %v", err)
+		}
+		fmt.Println(msg)
+		return true
+
+	case "/tools":
+		msg, err := manageTools(ctx.registry, parts[1:])
+		if err != nil {
+			ctx.printer.Error("%v", err)
+			return true
+		}
+		fmt.Println(msg)
+		return true
+
+	case "/tab":
+		ctx.printer.Warning("Session tabs need the TUI; run without --no-tui")
+		return true
+
+	case "/pause":
+		ctx.printer.Info("Nothing is running. To pause a run in progress, use /pause in the TUI or send SIGTERM (kill %d).", os.Getpid())
+		return true
+
+	case "/continue":
+		paused := ctx.engine.Paused()
+		if paused == nil {
+			ctx.printer.Info("No paused run to continue")
+			return true
+		}
+		ctx.printer.Info("Continuing the run paused at %s", paused.At.Format("01/02 15:04"))
+		ctx.resume = true
+		return false
+
+	case "/voice":
+		return !voiceCommand(ctx, ctx.voice)
+
+	case "/edit":
+		// Compose a prompt in the editor, starting from any text given
+		text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(cmd), parts[0]))
+		edited, err := editText(text, "prompt-*.md")
+		if err != nil {
+			ctx.printer.Error("%v", err)
+			return true
+		}
+		if edited = strings.TrimSpace(edited); edited == "" {
+			ctx.printer.Info("Nothing to send")
+			return true
+		}
+		fmt.Printf("%s\n\n", edited)
+		ctx.prompt = edited
+		return false
+
+	case "/cover":
+		// Run the agent on a coverage improvement request
+		target := ""
+		if len(parts) > 1 {
+			target = parts[1]
+		}
+		ctx.prompt = engine.CoverageRequest(target)
+		return false
+
 	case "/save":
 		if err := ctx.sessMgr.SaveSession(ctx.session); err != nil {
 			ctx.printer.Error("Failed to save session: %v", err)
@@ -851,18 +1531,40 @@ func handleCommand(cmd string, ctx *chatContext) bool {
 		return true
 
 	case "/model":
-		if len(parts) > 1 {
-			ctx.session.Model = provider.ResolveModel(parts[1])
-			ctx.printer.Success("Model changed to: %s", ctx.session.Model)
-		} else {
-			ctx.printer.Info("Current model: %s", ctx.session.Model)
+		if len(parts) < 2 {
+			ctx.printer.Info("Current model: %s", ctx.engine.Model())
+			return true
+		}
+		model, provType, err := switchModel(ctx.engine, ctx.provType, parts[1], ctx.printer)
+		if err != nil {
+			ctx.printer.Error("%v", err)
+			return true
+		}
+		ctx.provType = provType
+		if ctx.costTracker != nil {
+			ctx.costTracker.SetModel(model)
 		}
+		ctx.printer.Success("Model changed to: %s", model)
 		return true
 
 	case "/work":
 		handleWorkCommand(parts[1:], ctx)
 		return true
 
+	case "/output-style":
+		if len(parts) < 2 {
+			fmt.Print(describeOutputStyles())
+			return true
+		}
+		cwd, _ := os.Getwd()
+		msg, err := setOutputStyle(parts[1], cwd, ctx.engine)
+		if err != nil {
+			ctx.printer.Error("%v", err)
+		} else {
+			ctx.printer.Success("%s", msg)
+		}
+		return true
+
 	case "/cost":
 		// Get cost statistics
 		if ctx.costTracker != nil {
@@ -872,6 +1574,11 @@ func handleCommand(cmd string, ctx *chatContext) bool {
 			ctx.printer.Dim("  Output tokens: %d", stats.OutputTokens)
 			ctx.printer.Dim("  Total tokens:  %d", stats.TotalTokens)
 			ctx.printer.Info("Cost (%s): %s", stats.Model, cost.FormatCost(stats.TotalCost))
+			if speed := ctx.engine.LastSpeed().String(); speed != "" {
+				ctx.printer.Dim("  Last call:     %s", speed)
+			}
+			ctx.printer.Info("Model speed (latest calls, last 30 days):")
+			fmt.Print(modelSpeeds())
 		} else {
 			// Fallback to session estimate
 			tokenCount := ctx.session.EstimateTokens()
@@ -880,6 +1587,71 @@ func handleCommand(cmd string, ctx *chatContext) bool {
 		}
 		return true
 
+	case "/limits":
+		fmt.Print(describeRateLimits(ctx.engine.Provider()))
+		return true
+
+	case "/env":
+		fmt.Print(describeEnv())
+		return true
+
+	case "/refactor":
+		cwd, _ := os.Getwd()
+		msg, err := refactor(context.Background(), ctx.lsp, ctx.session, cwd, parts[1:], func(preview string) bool {
+			fmt.Print(preview)
+			fmt.Print("Apply this refactor? [y/N] ")
+			answer, _ := ctx.reader.ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			return answer == "y" || answer == "yes"
+		})
+		if err != nil {
+			ctx.printer.Error("%v", err)
+		} else {
+			ctx.printer.Success("%s", msg)
+		}
+		return true
+
+	case "/ps":
+		msg, err := manageProcesses(ctx.supervisor, ctx.shells, parts[1:])
+		if err != nil {
+			ctx.printer.Error("%v", err)
+			return true
+		}
+		fmt.Print(msg)
+		return true
+
+	case "/tasks":
+		msg, err := manageTasks(ctx.tasks, parts[1:])
+		if err != nil {
+			ctx.printer.Error("%v", err)
+			return true
+		}
+		fmt.Print(msg)
+		return true
+
+	case "/thinking":
+		thinking := ctx.engine.LastThinking()
+		if thinking == "" {
+			ctx.printer.Info("No thinking yet")
+			return true
+		}
+		ctx.printer.Thinking(thinking + "\n")
+		return true
+
+	case "/budget":
+		status, ok := ctx.engine.BudgetStatus()
+		if !ok {
+			ctx.printer.Info("No monthly budget configured (set monthly_budget_usd)")
+			return true
+		}
+		if len(parts) > 1 && parts[1] == "override" {
+			ctx.engine.OverrideBudget()
+			ctx.printer.Warning("Budget limit overridden for the rest of the month")
+			return true
+		}
+		ctx.printer.Info("%s", status)
+		return true
+
 	case "/compact":
 		// Perform conversation compaction
 		opts := session.DefaultCompactOptions()
@@ -895,6 +1667,7 @@ func handleCommand(cmd string, ctx *chatContext) bool {
 
 	case "/exit", "/quit", "/q":
 		ctx.printer.Dim("Goodbye!")
+		metrics.Close(context.Background(), version)
 		os.Exit(0)
 
 	default:
@@ -1018,18 +1791,37 @@ func handleWorkCommand(args []string, ctx *chatContext) {
 	}
 }
 
-func registerBuiltinTools(registry *tool.Registry) {
+// registerBuiltinTools registers the built-in tools and returns the manager
+// of the shells started by Bash
+func registerBuiltinTools(registry *tool.Registry, supervisor *builtin.Supervisor) *builtin.ShellManager {
 	// Core file tools
 	registry.Register(builtin.NewReadTool())
 	registry.Register(builtin.NewWriteTool())
 	registry.Register(builtin.NewEditTool())
 	registry.Register(builtin.NewGlobTool())
 	registry.Register(builtin.NewGrepTool())
+	registry.Register(builtin.NewTreeTool())
+	registry.Register(builtin.NewTargetsTool())
 
 	// Shell tools
-	registry.Register(builtin.NewBashTool())
 	shellMgr := builtin.NewShellManager()
+	shellMgr.SetSupervisor(supervisor)
+	// The pane's command runs with every background shell, so only the
+	// global config sets it
+	pane, _ := builtin.PaneTemplate(settings.Global().BackgroundPane)
+	shellMgr.SetPane(pane)
+	bash := builtin.NewBashTool()
+	bash.Supervisor = supervisor
+	bash.Shells = shellMgr
+	registry.Register(bash)
+	registry.Register(builtin.NewDepsTool())
+	registry.Register(builtin.NewTestTool())
+	registry.Register(builtin.NewCoverageTool())
+	registry.Register(builtin.NewBenchTool())
+	registry.Register(builtin.NewProfileTool())
+	registry.Register(builtin.NewSnippetTool())
 	registry.Register(builtin.NewKillShellTool(shellMgr))
+	registry.Register(builtin.NewBashOutputTool(shellMgr))
 
 	// Web tools
 	registry.Register(builtin.NewWebSearchTool())
@@ -1042,6 +1834,14 @@ func registerBuiltinTools(registry *tool.Registry) {
 	var inPlanMode bool
 	registry.Register(builtin.NewEnterPlanModeTool(&inPlanMode, nil))
 	registry.Register(builtin.NewExitPlanModeTool(&inPlanMode, nil))
+	return shellMgr
+}
+
+// stopShells stops the shells still running when the session ends
+func stopShells(shells *builtin.ShellManager, printer *ui.Printer) {
+	if n := shells.Shutdown(); n > 0 {
+		printer.Dim("Stopped %d shell(s) still running", n)
+	}
 }
 
 // checkAndPromptMigration checks if CLAUDE.md exists but AGENT.md doesn't
@@ -1105,7 +1905,7 @@ func checkAndPromptMigration(cwd string, printer *ui.Printer) {
 			fmt.Println("CLAUDE.md will still be read for compatibility.")
 		}
 	} else {
-		printer.Dim("Skipped. You can create %s manually later.", engine.InstructionFileName)
+		printer.Dim("Skipped. Run `agentic-coder migrate` to migrate later.")
 	}
 	fmt.Println()
 }
@@ -1113,9 +1913,409 @@ func checkAndPromptMigration(cwd string, printer *ui.Printer) {
 func getSystemPrompt() string {
 	builder := engine.NewPromptBuilder()
 	builder.LoadInstructions() // Loads both AGENT.md and CLAUDE.md
+	builder.OverridePrompt = systemPromptOverride
+	builder.AppendPrompt = systemPromptAppend
+	builder.OutputStyle = outputStyle
+	builder.ReadOnly = readOnly
+	builder.RemoteHost = remoteHost
+	builder.Devcontainer = containerInfo
 	return builder.Build()
 }
 
+// describeOutputStyles lists the output styles, marking the current one
+func describeOutputStyles() string {
+	current := outputStyle
+	if current == "" {
+		current = engine.DefaultOutputStyle
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Output styles (use /output-style <name>):\n")
+	for _, s := range engine.OutputStyles() {
+		marker := "  "
+		if s.Name == current {
+			marker = "* "
+		}
+		sb.WriteString(fmt.Sprintf("%s%-12s %s\n", marker, s.Name, s.Description))
+	}
+	return sb.String()
+}
+
+// describeRateLimits shows the quotas the provider reported with its latest response
+func describeRateLimits(prov provider.AIProvider) string {
+	reporter, ok := prov.(provider.RateLimitReporter)
+	if !ok {
+		return fmt.Sprintf("The %s provider does not report rate limits\n", prov.Name())
+	}
+	limits := reporter.RateLimits()
+	if limits == nil {
+		return "No rate limits reported yet; they are captured from the next response\n"
+	}
+	return limits.Format(time.Now())
+}
+
+// manageProcesses handles "/ps": with no arguments it lists the commands
+// the agent is running, and "kill <pid>" stops one with its children
+func manageProcesses(supervisor *builtin.Supervisor, shells *builtin.ShellManager, args []string) (string, error) {
+	if len(args) > 0 {
+		if args[0] != "kill" || len(args) != 2 {
+			return "", fmt.Errorf("usage: /ps [kill <pid|shell>]")
+		}
+		pid, err := strconv.Atoi(args[1])
+		if err != nil {
+			if shells == nil || shells.Get(args[1]) == nil {
+				return "", fmt.Errorf("no process or shell %q", args[1])
+			}
+			if err := shells.Kill(args[1]); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Stopped shell %s\n", args[1]), nil
+		}
+		if err := supervisor.Kill(pid); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Stopped process %d and its children\n", pid), nil
+	}
+
+	var b strings.Builder
+	procs := supervisor.List()
+	if len(procs) == 0 {
+		b.WriteString("No agent commands running\n")
+	} else {
+		fmt.Fprintf(&b, "%-8s %-5s %6s %8s %8s  %s\n", "PID", "KIND", "PROCS", "CPU", "ELAPSED", "COMMAND")
+		for _, p := range procs {
+			kind := "fg"
+			if p.Background {
+				kind = "bg"
+			}
+			command := strings.Join(strings.Fields(p.Command), " ")
+			if len(command) > 60 {
+				command = command[:57] + "..."
+			}
+			fmt.Fprintf(&b, "%-8d %-5s %6d %8s %8s  %s\n", p.PID, kind, p.Processes,
+				p.CPU.Round(time.Second), time.Since(p.Started).Round(time.Second), command)
+		}
+	}
+
+	if shells != nil {
+		if list := shells.List(); len(list) > 0 {
+			fmt.Fprintf(&b, "\n%-8s %-5s %-10s %8s  %s\n", "SHELL", "KIND", "STATE", "ELAPSED", "COMMAND")
+			for _, s := range list {
+				kind := "bg"
+				if s.Foreground {
+					kind = "fg"
+				}
+				end := time.Now()
+				if s.EndTime != nil {
+					end = *s.EndTime
+				}
+				command := s.Description
+				if command == "" {
+					command = strings.Join(strings.Fields(s.Command), " ")
+				}
+				if len(command) > 60 {
+					command = command[:57] + "..."
+				}
+				fmt.Fprintf(&b, "%-8s %-5s %-10s %8s  %s\n", s.ID, kind, s.State,
+					end.Sub(s.StartTime).Round(time.Second), command)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	count, cpu := supervisor.Usage()
+	limits := supervisor.Limits()
+	fmt.Fprintf(&b, "Processes: %s · CPU time: %s\n",
+		formatLimit(strconv.Itoa(count), limits.MaxProcesses > 0, strconv.Itoa(limits.MaxProcesses)),
+		formatLimit(cpu.Round(time.Second).String(), limits.MaxCPUTime > 0, limits.MaxCPUTime.String()))
+	return b.String(), nil
+}
+
+// manageTasks lists the subagents started with the Task tool, or cancels
+// one with "cancel <id>" while the run that started it goes on
+func manageTasks(tasks *task.Manager, args []string) (string, error) {
+	if len(args) > 0 {
+		if args[0] != "cancel" || len(args) != 2 {
+			return "", fmt.Errorf("usage: /tasks [cancel <id>]")
+		}
+		var match *task.Task
+		for _, t := range tasks.ListTasks() {
+			if strings.HasPrefix(t.ID, args[1]) {
+				if match != nil {
+					return "", fmt.Errorf("%q matches several tasks", args[1])
+				}
+				match = t
+			}
+		}
+		if match == nil {
+			return "", fmt.Errorf("no task %q", args[1])
+		}
+		if err := tasks.CancelTask(match.ID); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Cancelled task %s\n", match.ID[:8]), nil
+	}
+
+	list := tasks.ListTasks()
+	if len(list) == 0 {
+		return "No subagent tasks\n", nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-8s %-16s %-10s %5s %8s %8s  %s\n", "ID", "AGENT", "STATUS", "TOOLS", "TOKENS", "ELAPSED", "DESCRIPTION")
+	for _, t := range list {
+		info := t.Info()
+		elapsed := time.Duration(0)
+		if info.Started != nil {
+			end := time.Now()
+			if info.Completed != nil {
+				end = *info.Completed
+			}
+			elapsed = end.Sub(*info.Started).Round(time.Second)
+		}
+		fmt.Fprintf(&b, "%-8s %-16s %-10s %5d %8s %8s  %s\n", info.ID[:8], info.SubagentType, info.Status,
+			info.ToolCalls, ui.FormatTokens(info.InputTokens+info.OutputTokens), elapsed, info.Description)
+	}
+	return b.String(), nil
+}
+
+// formatLimit shows a value with its limit, if there is one
+func formatLimit(value string, limited bool, limit string) string {
+	if !limited {
+		return value
+	}
+	return value + " of " + limit
+}
+
+// loadConfig loads the global config and the project config of cwd, each
+// over the configs it extends. When either cannot be read, it returns the
+// defaults with the error.
+func loadConfig(cwd string) (*config.ConfigManager, error) {
+	cm, err := config.NewConfigManager()
+	if err == nil {
+		err = cm.Load(cwd)
+	}
+	if err != nil {
+		return &config.ConfigManager{}, err
+	}
+	return cm, nil
+}
+
+// processLimits returns the configured limits on processes spawned by
+// shell commands
+func processLimits() builtin.ProcessLimits {
+	limits := builtin.DefaultProcessLimits()
+	cfg := settings.Get().ProcessLimits
+	if cfg.MaxBackground != 0 {
+		limits.MaxBackground = max(cfg.MaxBackground, 0)
+	}
+	if cfg.MaxProcesses != 0 {
+		limits.MaxProcesses = max(cfg.MaxProcesses, 0)
+	}
+	switch cfg.MaxCPUTime {
+	case "":
+	case "none":
+		limits.MaxCPUTime = 0
+	default:
+		if d, err := time.ParseDuration(cfg.MaxCPUTime); err == nil && d > 0 {
+			limits.MaxCPUTime = d
+		}
+	}
+	return limits
+}
+
+// iterationLog opens the configured log_file for appending iteration
+// summaries, or returns nil when none is set
+func iterationLog() io.Writer {
+	path := settings.Get().LogFile
+	if path == "" {
+		return nil
+	}
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil
+	}
+	return f
+}
+
+// pathScope confines file tools to cwd and the additional directories of
+// the global config, or returns nil when it bypasses permissions. A project
+// cannot widen the scope, so a cloned repository cannot open up ~/.ssh.
+func pathScope(cwd string) *permission.PathScope {
+	cfg := settings.Global()
+	if cfg.PermissionMode == "bypass" {
+		return nil
+	}
+	return permission.NewPathScope(cwd, cfg.AdditionalDirectories...)
+}
+
+// warnConfigBases reports the configs that the global and project configs
+// extend but that could not be used
+func warnConfigBases(printer *ui.Printer) {
+	for _, warning := range settings.Warnings() {
+		printer.Warning("%s", warning)
+	}
+}
+
+// destructiveGuard returns the classifier for destructive tool calls and,
+// when approval is model, the verifying model. Projects can add rules, but
+// only the global config can turn rules off or leave approval to a model,
+// so a cloned repository cannot weaken the checks.
+func destructiveGuard(printer *ui.Printer) (*permission.Classifier, *engine.DestructiveVerifier) {
+	global := settings.Global().DestructiveActions
+	approval, verifyModel, disabled := global.Approval, global.VerifyModel, global.DisableRules
+	rules := slices.DeleteFunc(permission.DefaultDestructiveRules(), func(rule permission.DestructiveRule) bool {
+		return slices.Contains(disabled, rule.Name)
+	})
+	for _, rule := range settings.Get().DestructiveActions.Rules {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			printer.Warning("Skipping destructive rule %q: %v", rule.Name, err)
+			continue
+		}
+		rules = append(rules, permission.DestructiveRule(rule))
+	}
+
+	classifier, err := permission.NewClassifier(rules)
+	if err != nil {
+		printer.Warning("%v; using the built-in destructive rules", err)
+		classifier, _ = permission.NewClassifier(permission.DefaultDestructiveRules())
+	}
+	classifier.DisableChecks(disabled...)
+	if approval != "model" {
+		return classifier, nil
+	}
+
+	if verifyModel == "" {
+		verifyModel = "haiku"
+	}
+	key := ""
+	verifyType := provider.DetectProviderFromModel(verifyModel)
+	if verifyType == provider.DetectProviderFromModel(model) {
+		key = apiKey
+	}
+	prov, err := createProvider(verifyType, key, printer)
+	if err != nil {
+		printer.Warning("Cannot verify destructive actions with %s, asking instead: %v", verifyModel, err)
+		return classifier, nil
+	}
+	return classifier, &engine.DestructiveVerifier{Provider: prov, Model: provider.ResolveModel(verifyModel)}
+}
+
+// setOutputStyle switches the output style, saves it to the project config
+// and rebuilds the engine's system prompt
+func setOutputStyle(name, cwd string, eng *engine.Engine) (string, error) {
+	style, err := engine.GetOutputStyle(name)
+	if err != nil {
+		return "", err
+	}
+
+	if err := config.SaveOutputStyle(config.GetProjectConfigPath(cwd), style.Name); err != nil {
+		return "", fmt.Errorf("failed to save output style: %w", err)
+	}
+
+	outputStyle = style.Name
+	eng.SetSystemPrompt(getSystemPrompt())
+	return fmt.Sprintf("Output style set to %s: %s", style.Name, style.Description), nil
+}
+
+// resolveSystemPrompt resolves the system prompt override and append values
+// and the output style. Flags take precedence over the global and project config.
+func resolveSystemPrompt() error {
+	override, appendPrompt := systemPromptFlag, appendSystemPromptFlag
+
+	cfg := settings.Get()
+	if override == "" {
+		override = cfg.SystemPrompt
+	}
+	if appendPrompt == "" {
+		appendPrompt = cfg.AppendSystemPrompt
+	}
+	outputStyle = cfg.OutputStyle
+
+	var err error
+	if systemPromptOverride, err = engine.ReadPromptValue(override); err != nil {
+		return fmt.Errorf("system prompt: %w", err)
+	}
+	if systemPromptAppend, err = engine.ReadPromptValue(appendPrompt); err != nil {
+		return fmt.Errorf("append system prompt: %w", err)
+	}
+	return nil
+}
+
+// resolveThinkingDisplay resolves the thinking display mode from the flag and
+// config, and whether thinking is saved with transcripts
+func resolveThinkingDisplay() error {
+	cfg := settings.Get()
+	if thinkingDisplay == "" {
+		thinkingDisplay = cfg.ThinkingDisplay
+	}
+	keepThinking = cfg.ShowThinking
+	if thinkingDisplay == "" {
+		thinkingDisplay = ui.ThinkingCollapse
+	}
+	if !ui.ValidThinkingDisplay(thinkingDisplay) {
+		return fmt.Errorf("invalid thinking display %q (use %s)", thinkingDisplay, strings.Join(ui.ThinkingDisplayModes, ", "))
+	}
+	return nil
+}
+
+// globalEditor returns the editor and editor_args settings of the global
+// config, as the editor runs on this machine
+func globalEditor() editor.Editor {
+	cfg := settings.Global()
+	return editor.Editor{Command: cfg.Editor, Args: cfg.EditorArgs}
+}
+
+// nativeWebSearch returns the providers web_search sets to use their
+// built-in search
+func nativeWebSearch() []string {
+	var names []string
+	for name, mode := range settings.Get().WebSearch {
+		if mode == "native" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// configuredToolLimits returns the tool limits from the global and project
+// config, applying the "*" entry to all tools and the others per tool
+func configuredToolLimits() (*engine.ToolLimits, error) {
+	limits := engine.DefaultToolLimits()
+	for name, cfg := range settings.Get().ToolLimits {
+		var limit engine.ToolLimit
+		if cfg.Timeout != "" {
+			var err error
+			if limit.Timeout, err = time.ParseDuration(cfg.Timeout); err != nil {
+				return nil, fmt.Errorf("tool_limits.%s.timeout: %w", name, err)
+			}
+		}
+		limit.MaxOutputBytes = cfg.MaxOutputBytes
+
+		if name == "*" {
+			if limit.Timeout != 0 {
+				limits.Default.Timeout = limit.Timeout
+			}
+			if limit.MaxOutputBytes != 0 {
+				limits.Default.MaxOutputBytes = limit.MaxOutputBytes
+			}
+			continue
+		}
+		if limits.Tools == nil {
+			limits.Tools = make(map[string]engine.ToolLimit)
+		}
+		limits.Tools[name] = limit
+	}
+	return &limits, nil
+}
+
 // AuthError represents an authentication error with helpful guidance
 type AuthError struct {
 	Provider    string
@@ -1144,8 +2344,48 @@ func promptForAuth(providerName, envVar, authCmd string, printer *ui.Printer) bo
 	return input == "y" || input == "yes"
 }
 
+// cliProviderConfig returns the configured binary and pinned version of a
+// CLI provider. Projects can pin a version, but the binary, which runs at
+// startup, comes from the global config only.
+func cliProviderConfig(providerType provider.ProviderType) config.CLIProviderConfig {
+	cfg := settings.Get().CLIProviders[string(providerType)]
+	cfg.Path = settings.Global().CLIProviders[string(providerType)].Path
+	return cfg
+}
+
+// checkCLIProvider detects the CLI tool a provider shells out to, failing
+// with install instructions when it is missing or unsupported
+func checkCLIProvider(prov provider.AIProvider, name string, printer *ui.Printer) (provider.AIProvider, error) {
+	info, err := prov.(provider.CLIChecker).Check(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	printer.Dim("%s Using local %s %s", ui.IconGear, name, info)
+	return prov, nil
+}
+
+// switchModel moves the engine to the named model from its next request,
+// creating a provider when the model is served by another one
+func switchModel(eng *engine.Engine, current provider.ProviderType, name string, printer *ui.Printer) (string, provider.ProviderType, error) {
+	model := provider.ResolveModel(name)
+	providerType := provider.DetectProviderFromModel(name)
+	if providerType == current {
+		eng.SwitchModel(name, nil)
+		return model, current, nil
+	}
+
+	prov, err := createProvider(providerType, "", printer)
+	if err != nil {
+		return "", current, fmt.Errorf("cannot switch to %s: %w", model, err)
+	}
+	eng.SwitchModel(name, cacheResponses(prov, printer))
+	return model, providerType, nil
+}
+
 // createProvider creates a provider based on type
 func createProvider(providerType provider.ProviderType, customKey string, printer *ui.Printer) (provider.AIProvider, error) {
+	metrics.Provider(string(providerType))
+
 	// Try to get credentials from auth manager first
 	authMgr := auth.NewManager("")
 
@@ -1181,8 +2421,12 @@ func createProvider(providerType provider.ProviderType, customKey string, printe
 
 	case provider.ProviderTypeClaudeCLI:
 		// Use local Claude Code CLI
-		printer.Dim("%s Using local Claude Code CLI", ui.IconGear)
-		return claudecli.New(claudecli.WithModel("sonnet")), nil
+		cli := cliProviderConfig(providerType)
+		return checkCLIProvider(claudecli.New(
+			claudecli.WithModel("sonnet"),
+			claudecli.WithCLIPath(cli.Path),
+			claudecli.WithVersion(cli.Version),
+		), claudecli.Spec.Name, printer)
 
 	case provider.ProviderTypeOpenAI:
 		// Try auth manager first (API key only)
@@ -1212,8 +2456,12 @@ func createProvider(providerType provider.ProviderType, customKey string, printe
 
 	case provider.ProviderTypeCodexCLI:
 		// Use local Codex CLI
-		printer.Dim("%s Using local Codex CLI", ui.IconGear)
-		return codexcli.New(codexcli.WithModel("o3-mini")), nil
+		cli := cliProviderConfig(providerType)
+		return checkCLIProvider(codexcli.New(
+			codexcli.WithModel("o3-mini"),
+			codexcli.WithCLIPath(cli.Path),
+			codexcli.WithVersion(cli.Version),
+		), codexcli.Spec.Name, printer)
 
 	case provider.ProviderTypeGemini:
 		// Try auth manager first (API key only)
@@ -1243,10 +2491,55 @@ func createProvider(providerType provider.ProviderType, customKey string, printe
 
 	case provider.ProviderTypeGeminiCLI:
 		// Use local Gemini CLI (auto model = gemini-3)
-		printer.Dim("%s Using local Gemini CLI", ui.IconGear)
-		return geminicli.New(
+		cli := cliProviderConfig(providerType)
+		return checkCLIProvider(geminicli.New(
 			geminicli.WithYoloMode(true), // Auto approve for agentic use
-		), nil
+			geminicli.WithCLIPath(cli.Path),
+			geminicli.WithVersion(cli.Version),
+		), geminicli.Spec.Name, printer)
+
+	case provider.ProviderTypeGitHub:
+		// GitHub Models, with a token from auth, GITHUB_TOKEN or the GitHub CLI
+		if creds, err := authMgr.GetCredentials(auth.ProviderGitHub); err == nil && creds.APIKey != "" {
+			printer.Dim("%s Using saved GitHub token for GitHub Models", ui.IconKey)
+			return github.New(creds.APIKey), nil
+		}
+
+		key := customKey
+		if key == "" {
+			key = os.Getenv("GITHUB_TOKEN")
+		}
+		if key == "" {
+			if key = auth.GitHubCLIToken(context.Background()); key != "" {
+				printer.Dim("%s Using GitHub CLI token for GitHub Models", ui.IconKey)
+			}
+		}
+		if key == "" {
+			if promptForAuth("GitHub Models", "GITHUB_TOKEN", "agentic-coder auth login github", printer) {
+				ctx := context.Background()
+				if _, err := authMgr.Authenticate(ctx, auth.ProviderGitHub); err != nil {
+					return nil, fmt.Errorf("authentication failed: %w", err)
+				}
+				if creds, err := authMgr.GetCredentials(auth.ProviderGitHub); err == nil && creds.APIKey != "" {
+					printer.Success("✓ Authentication successful")
+					return github.New(creds.APIKey), nil
+				}
+			}
+			return nil, &AuthError{Provider: "GitHub Models", EnvVar: "GITHUB_TOKEN", AuthCommand: "agentic-coder auth login github"}
+		}
+		return github.New(key), nil
+
+	case provider.ProviderTypeAzureOpenAI:
+		// Azure OpenAI, routed to the deployment the model names
+		if os.Getenv("AZURE_OPENAI_ENDPOINT") == "" {
+			return nil, &AuthError{Provider: "Azure OpenAI", EnvVar: "AZURE_OPENAI_ENDPOINT", AuthCommand: "export AZURE_OPENAI_ENDPOINT=https://<resource>.openai.azure.com"}
+		}
+		azure, err := azureopenai.FromEnv()
+		if err != nil {
+			return nil, err
+		}
+		printer.Dim("%s Using Azure OpenAI at %s", ui.IconKey, azure.Endpoint())
+		return azure, nil
 
 	case provider.ProviderTypeDeepSeek:
 		key := customKey
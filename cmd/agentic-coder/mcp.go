@@ -0,0 +1,734 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/mcp"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+	"github.com/xinguang/agentic-coder/pkg/tool/builtin"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+// mcpScopes are the config files MCP servers are saved in: the user's
+// global config and the project's .agentic-coder/config.json
+var mcpScopes = []string{"user", "project"}
+
+// mcpServerName is a valid server name; tools are named mcp__<server>__<tool>,
+// so names cannot contain "__"
+var mcpServerName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+func mcpCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Manage MCP servers",
+		Long: `Add, remove, and inspect the MCP servers in the mcp_servers section of
+the user config (~/.agentic-coder/config.json) or the project config
+(.agentic-coder/config.json).
+
+New servers are started and asked for their tools before they are saved,
+so a typo in a command or URL is caught now rather than mid-session. Pass
+--no-test to save without starting them.
+
+Servers defined by the project, in .mcp.json or the project config, run
+commands from whoever wrote the repository. They start only once you trust
+them: interactive sessions ask the first time, and again whenever they
+change; "mcp trust" decides ahead of time, for example before a headless
+run.`,
+	}
+
+	cmd.AddCommand(mcpAddCmd())
+	cmd.AddCommand(mcpAddJSONCmd())
+	cmd.AddCommand(mcpImportCmd())
+	cmd.AddCommand(mcpRemoveCmd())
+	cmd.AddCommand(mcpListCmd())
+	cmd.AddCommand(mcpGetCmd())
+	cmd.AddCommand(mcpTrustCmd())
+	return cmd
+}
+
+func mcpAddCmd() *cobra.Command {
+	var (
+		scope     string
+		transport string
+		env       []string
+		noTest    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "add <name> <command|url> [args...]",
+		Short: "Add an MCP server",
+		Long: `Add a server that runs a command (stdio, the default) or one reached at a
+URL (--transport http or sse). Flags go before the name; everything after
+the command is passed to it.
+
+Example:
+  agentic-coder mcp add fs npx -y @modelcontextprotocol/server-filesystem .
+  agentic-coder mcp add --env GITHUB_TOKEN=ghp_... github npx -y @modelcontextprotocol/server-github
+  agentic-coder mcp add --scope project --transport http docs https://example.com/mcp`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			server := config.MCPServerConfig{Name: args[0], Type: transport, AutoStart: true}
+			switch transport {
+			case "stdio":
+				server.Command, server.Args = args[1], args[2:]
+			case "sse", "http":
+				if len(args) > 2 {
+					return fmt.Errorf("%s servers take a URL and no arguments", transport)
+				}
+				server.URL = args[1]
+			default:
+				return fmt.Errorf("invalid transport %q: must be stdio, sse, or http", transport)
+			}
+			if len(env) > 0 {
+				server.Env = make(map[string]string)
+				for _, kv := range env {
+					k, v, ok := strings.Cut(kv, "=")
+					if !ok || k == "" {
+						return fmt.Errorf("invalid --env %q: expected KEY=VALUE", kv)
+					}
+					server.Env[k] = v
+				}
+			}
+			return addMCPServers(scope, noTest, server)
+		},
+	}
+
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().StringVarP(&scope, "scope", "s", "user", "Config to save to: user or project")
+	cmd.Flags().StringVar(&transport, "transport", "stdio", "How to reach the server: stdio, sse, or http")
+	cmd.Flags().StringArrayVarP(&env, "env", "e", nil, "Environment variable for the server, KEY=VALUE (repeatable)")
+	cmd.Flags().BoolVar(&noTest, "no-test", false, "Save without starting the server")
+	return cmd
+}
+
+func mcpAddJSONCmd() *cobra.Command {
+	var (
+		scope  string
+		noTest bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "add-json <name> <json>",
+		Short: "Add an MCP server from a JSON entry",
+		Long: `Add a server from an entry in the mcpServers format of .mcp.json and Claude
+Desktop, such as one copied from a server's README.
+
+Example:
+  agentic-coder mcp add-json fs '{"command":"npx","args":["-y","@modelcontextprotocol/server-filesystem","."]}'
+  agentic-coder mcp add-json docs '{"type":"http","url":"https://example.com/mcp"}'`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			server, err := mcp.ParseServer(args[0], []byte(args[1]))
+			if err != nil {
+				return err
+			}
+			return addMCPServers(scope, noTest, fromMCPServer(server))
+		},
+	}
+
+	cmd.Flags().StringVarP(&scope, "scope", "s", "user", "Config to save to: user or project")
+	cmd.Flags().BoolVar(&noTest, "no-test", false, "Save without starting the server")
+	return cmd
+}
+
+func mcpImportCmd() *cobra.Command {
+	var (
+		scope  string
+		file   string
+		noTest bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "add-from-claude-desktop [name...]",
+		Short: "Import MCP servers from Claude Desktop",
+		Long: `Import the servers of Claude Desktop's claude_desktop_config.json, or only
+the named ones. Servers whose name is already taken are skipped. --file
+reads another file in the same format, such as a project's .mcp.json.
+
+Example:
+  agentic-coder mcp add-from-claude-desktop
+  agentic-coder mcp add-from-claude-desktop --scope project filesystem
+  agentic-coder mcp add-from-claude-desktop --file ../other/.mcp.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if file == "" {
+				var err error
+				if file, err = mcp.ClaudeDesktopConfigPath(); err != nil {
+					return err
+				}
+			}
+			found, err := mcp.LoadConfigFromFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", file, err)
+			}
+
+			wanted := make(map[string]bool)
+			for _, name := range args {
+				wanted[name] = true
+			}
+			var servers []config.MCPServerConfig
+			for _, server := range found {
+				if len(wanted) == 0 || wanted[server.Name] {
+					servers = append(servers, fromMCPServer(server))
+					delete(wanted, server.Name)
+				}
+			}
+			for _, name := range args {
+				if wanted[name] {
+					return fmt.Errorf("no server %s in %s", name, file)
+				}
+			}
+			if len(servers) == 0 {
+				return fmt.Errorf("no MCP servers in %s", file)
+			}
+			return addMCPServers(scope, noTest, servers...)
+		},
+	}
+
+	cmd.Flags().StringVarP(&scope, "scope", "s", "user", "Config to save to: user or project")
+	cmd.Flags().StringVar(&file, "file", "", "Config to import from (default: Claude Desktop's)")
+	cmd.Flags().BoolVar(&noTest, "no-test", false, "Save without starting the servers")
+	return cmd
+}
+
+func mcpRemoveCmd() *cobra.Command {
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove an MCP server",
+		Long: `Remove a server from the config it is in. Use --scope when both the user
+and the project config have a server of that name.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			scopes := mcpScopes
+			if scope != "" {
+				scopes = []string{scope}
+			}
+
+			var found []string
+			for _, s := range scopes {
+				servers, _, err := loadMCPScope(s)
+				if err != nil {
+					return err
+				}
+				if indexMCPServer(servers, name) >= 0 {
+					found = append(found, s)
+				}
+			}
+			switch len(found) {
+			case 0:
+				return fmt.Errorf("no MCP server named %s", name)
+			case 1:
+			default:
+				return fmt.Errorf("%s is in both the user and the project config; choose one with --scope", name)
+			}
+
+			servers, path, err := loadMCPScope(found[0])
+			if err != nil {
+				return err
+			}
+			i := indexMCPServer(servers, name)
+			servers = append(servers[:i], servers[i+1:]...)
+			if err := config.SaveMCPServers(path, servers); err != nil {
+				return err
+			}
+			ui.NewPrinter().Success("Removed %s from %s", name, path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&scope, "scope", "s", "", "Config to remove from: user or project (default: the one that has it)")
+	return cmd
+}
+
+func mcpListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List configured MCP servers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer := ui.NewPrinter()
+			count := 0
+			for _, scope := range mcpScopes {
+				servers, _, err := loadMCPScope(scope)
+				if err != nil {
+					return err
+				}
+				for _, s := range servers {
+					fmt.Printf("%-20s %-9s %-5s %s\n", s.Name, scope, mcpType(s), mcpTarget(s))
+					count++
+				}
+			}
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			servers, err := loadMCPJSON(cwd)
+			if err != nil {
+				return err
+			}
+			for _, s := range servers {
+				fmt.Printf("%-20s %-9s %-5s %s\n", s.Name, ".mcp.json", mcpType(s), mcpTarget(s))
+				count++
+			}
+			if count == 0 {
+				printer.Info("No MCP servers configured; add one with: agentic-coder mcp add <name> <command> [args...]")
+				return nil
+			}
+			if status := describeMCPTrust(cwd); status != "" {
+				printer.Dim("%s", status)
+			}
+			return nil
+		},
+	}
+}
+
+func mcpGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <name>",
+		Short: "Show an MCP server's settings",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			found := false
+			for _, scope := range mcpScopes {
+				servers, path, err := loadMCPScope(scope)
+				if err != nil {
+					return err
+				}
+				i := indexMCPServer(servers, args[0])
+				if i < 0 {
+					continue
+				}
+				s := servers[i]
+				if found {
+					fmt.Println()
+				}
+				found = true
+				fmt.Printf("%s:\n", s.Name)
+				fmt.Printf("  Scope:   %s (%s)\n", scope, path)
+				fmt.Printf("  Type:    %s\n", mcpType(s))
+				if s.Command != "" {
+					fmt.Printf("  Command: %s\n", s.Command)
+				}
+				if len(s.Args) > 0 {
+					fmt.Printf("  Args:    %s\n", strings.Join(s.Args, " "))
+				}
+				if s.URL != "" {
+					fmt.Printf("  URL:     %s\n", s.URL)
+				}
+				// Environments often hold tokens, so only their names are shown
+				if len(s.Env) > 0 {
+					keys := make([]string, 0, len(s.Env))
+					for k := range s.Env {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					fmt.Printf("  Env:     %s\n", strings.Join(keys, ", "))
+				}
+			}
+			if !found {
+				return fmt.Errorf("no MCP server named %s", args[0])
+			}
+			return nil
+		},
+	}
+}
+
+// addMCPServers tests servers unless noTest is set and adds the ones that
+// work to the config of scope. It fails if none could be added.
+func addMCPServers(scope string, noTest bool, servers ...config.MCPServerConfig) error {
+	existing, path, err := loadMCPScope(scope)
+	if err != nil {
+		return err
+	}
+
+	printer := ui.NewPrinter()
+	added := 0
+	var lastErr error
+	// With several servers, report each failure and save the rest
+	skip := func(err error) {
+		lastErr = err
+		if len(servers) > 1 {
+			printer.Error("%v", err)
+		}
+	}
+	for _, server := range servers {
+		if !mcpServerName.MatchString(server.Name) || strings.Contains(server.Name, "__") {
+			skip(fmt.Errorf("invalid server name %q: use letters, digits, - and single _", server.Name))
+			continue
+		}
+		if indexMCPServer(existing, server.Name) >= 0 {
+			skip(fmt.Errorf("%s is already in %s; remove it first", server.Name, path))
+			continue
+		}
+		if !noTest {
+			if err := testMCPServer(server, printer); err != nil {
+				skip(fmt.Errorf("%s did not start: %w (use --no-test to save it anyway)", server.Name, err))
+				continue
+			}
+		}
+		existing = append(existing, server)
+		added++
+	}
+	if added == 0 {
+		return lastErr
+	}
+
+	if err := config.SaveMCPServers(path, existing); err != nil {
+		return err
+	}
+	printer.Success("Saved %d MCP server(s) to %s", added, path)
+	return nil
+}
+
+// testMCPServer starts a server, lists its tools, and stops it
+func testMCPServer(server config.MCPServerConfig, printer *ui.Printer) error {
+	if mcpType(server) == "sse" {
+		printer.Dim("%s: sse servers are saved without a test", server.Name)
+		return nil
+	}
+
+	printer.Dim("Starting %s...", server.Name)
+	manager := mcp.NewManager()
+	if err := manager.AddServer(toMCPServer(server)); err != nil {
+		return err
+	}
+	defer manager.RemoveServer(server.Name)
+
+	tools := manager.GetTools()
+	names := make([]string, 0, len(tools))
+	for _, t := range tools {
+		names = append(names, t.Name)
+	}
+	sort.Strings(names)
+	summary := strings.Join(names, ", ")
+	if len(summary) > 80 {
+		summary = summary[:77] + "..."
+	}
+	printer.Info("%s: connected, %d tool(s) %s", server.Name, len(tools), summary)
+	return nil
+}
+
+// loadMCPScope returns the servers of a scope's config and its path
+func loadMCPScope(scope string) ([]config.MCPServerConfig, string, error) {
+	path, err := scopeConfigPath(scope)
+	if err != nil {
+		return nil, "", err
+	}
+	servers, err := config.LoadMCPServers(path)
+	return servers, path, err
+}
+
+// scopeConfigPath returns the path of the user config or, for "project",
+// the current project's config
+func scopeConfigPath(scope string) (string, error) {
+	switch scope {
+	case "user":
+		return config.GetConfigPath()
+	case "project":
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		return config.GetProjectConfigPath(cwd), nil
+	default:
+		return "", fmt.Errorf("invalid scope %q: must be user or project", scope)
+	}
+}
+
+// indexMCPServer returns the index of the named server, or -1
+func indexMCPServer(servers []config.MCPServerConfig, name string) int {
+	for i, s := range servers {
+		if s.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// mcpType returns the type of a server, which defaults to stdio
+func mcpType(s config.MCPServerConfig) string {
+	if s.Type == "" {
+		return "stdio"
+	}
+	return s.Type
+}
+
+// mcpTarget describes what a server runs or connects to
+func mcpTarget(s config.MCPServerConfig) string {
+	if s.URL != "" {
+		return s.URL
+	}
+	return strings.TrimSpace(s.Command + " " + strings.Join(s.Args, " "))
+}
+
+func toMCPServer(s config.MCPServerConfig) mcp.ServerConfig {
+	return mcp.ServerConfig{
+		Name:      s.Name,
+		Type:      mcp.ServerType(mcpType(s)),
+		Command:   s.Command,
+		Args:      s.Args,
+		URL:       s.URL,
+		Env:       s.Env,
+		AutoStart: true,
+	}
+}
+
+func fromMCPServer(s mcp.ServerConfig) config.MCPServerConfig {
+	return config.MCPServerConfig{
+		Name:      s.Name,
+		Type:      string(s.Type),
+		Command:   s.Command,
+		Args:      s.Args,
+		URL:       s.URL,
+		Env:       s.Env,
+		AutoStart: true,
+	}
+}
+
+func mcpTrustCmd() *cobra.Command {
+	var reject, reset bool
+
+	cmd := &cobra.Command{
+		Use:   "trust",
+		Short: "Allow the current project's MCP servers to start",
+		Long: `Allow the MCP servers this project defines, in .mcp.json or the project
+config, to start with each session. The decision holds until the servers
+change. --reject keeps them from starting without asking again; --reset
+forgets the decision so the next session asks.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			printer := ui.NewPrinter()
+			if reset {
+				if err := saveMCPTrust(cwd, nil); err != nil {
+					return err
+				}
+				printer.Success("Forgot whether to trust this project's MCP servers")
+				return nil
+			}
+
+			servers, err := projectMCPServers(cwd, settings.Project())
+			if err != nil {
+				return err
+			}
+			if len(servers) == 0 {
+				return fmt.Errorf("this project defines no MCP servers")
+			}
+			if err := saveMCPTrust(cwd, newMCPTrust(servers, !reject)); err != nil {
+				return err
+			}
+			if reject {
+				printer.Success("This project's %d MCP server(s) will not start", len(servers))
+			} else {
+				printer.Success("This project's %d MCP server(s) will start with each session", len(servers))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&reject, "reject", false, "Keep the servers from starting")
+	cmd.Flags().BoolVar(&reset, "reset", false, "Forget the decision and ask again")
+	cmd.MarkFlagsMutuallyExclusive("reject", "reset")
+	return cmd
+}
+
+// projectMCPServer is a server defined by the project and where
+type projectMCPServer struct {
+	config.MCPServerConfig
+	Source string `json:"source"`
+}
+
+// loadMCPJSON returns the servers of the project's .mcp.json
+func loadMCPJSON(cwd string) ([]config.MCPServerConfig, error) {
+	path := filepath.Join(cwd, ".mcp.json")
+	found, err := mcp.LoadConfigFromFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	servers := make([]config.MCPServerConfig, 0, len(found))
+	for _, s := range found {
+		servers = append(servers, fromMCPServer(s))
+	}
+	return servers, nil
+}
+
+// projectMCPServers returns the servers the project defines in its config,
+// including the configs it extends, and in .mcp.json
+func projectMCPServers(cwd string, project *config.Config) ([]projectMCPServer, error) {
+	var servers []projectMCPServer
+	if project != nil {
+		for _, s := range project.MCPServers {
+			servers = append(servers, projectMCPServer{s, filepath.Join(config.AppDirName, "config.json")})
+		}
+	}
+	mcpJSON, err := loadMCPJSON(cwd)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range mcpJSON {
+		servers = append(servers, projectMCPServer{s, ".mcp.json"})
+	}
+	return servers, nil
+}
+
+// hashMCPServers identifies what the project's servers would run, so that
+// trusting them does not carry over to different commands
+func hashMCPServers(servers []projectMCPServer) string {
+	data, _ := json.Marshal(servers)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func newMCPTrust(servers []projectMCPServer, trusted bool) *config.MCPTrust {
+	trust := &config.MCPTrust{Trusted: trusted, Hash: hashMCPServers(servers), DecidedAt: time.Now()}
+	for _, s := range servers {
+		trust.Servers = append(trust.Servers, s.Name)
+	}
+	return trust
+}
+
+func saveMCPTrust(cwd string, trust *config.MCPTrust) error {
+	state, err := config.LoadProjectState(cwd)
+	if err != nil {
+		return err
+	}
+	state.MCPTrust = trust
+	return config.SaveProjectState(cwd, state)
+}
+
+// describeMCPTrust says whether the project's servers will start
+func describeMCPTrust(cwd string) string {
+	servers, err := projectMCPServers(cwd, settings.Project())
+	if err != nil || len(servers) == 0 {
+		return ""
+	}
+	state, err := config.LoadProjectState(cwd)
+	if err != nil {
+		return ""
+	}
+	switch trust := state.MCPTrust; {
+	case trust == nil:
+		return "Project servers have not been trusted yet; the next session asks (or run: agentic-coder mcp trust)"
+	case trust.Hash != hashMCPServers(servers):
+		return "Project servers changed since they were trusted or rejected; the next session asks again"
+	case trust.Trusted:
+		return "Project servers are trusted"
+	default:
+		return "Project servers were rejected; run agentic-coder mcp trust to start them"
+	}
+}
+
+// trustProjectMCPServers returns whether the project's servers may start,
+// asking when interactive and the user has not decided on these servers
+func trustProjectMCPServers(cwd string, servers []projectMCPServer, interactive bool, printer *ui.Printer) bool {
+	state, err := config.LoadProjectState(cwd)
+	if err != nil {
+		printer.Warning("Not starting project MCP servers: %v", err)
+		return false
+	}
+	hash := hashMCPServers(servers)
+	if trust := state.MCPTrust; trust != nil && trust.Hash == hash {
+		return trust.Trusted
+	}
+	if !interactive {
+		printer.Dim("Not starting %d project MCP server(s) that have not been trusted; run agentic-coder mcp trust to allow them", len(servers))
+		return false
+	}
+
+	if state.MCPTrust != nil {
+		printer.Warning("This project's MCP servers changed since you decided on them")
+	} else {
+		printer.Warning("This project defines MCP servers, which run on your machine with your permissions:")
+	}
+	for _, s := range servers {
+		fmt.Printf("  • %-16s %s (%s)\n", s.Name, mcpTarget(s.MCPServerConfig), s.Source)
+	}
+	fmt.Print("Start them in this project? [y/N] ")
+	input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	input = strings.TrimSpace(strings.ToLower(input))
+	trusted := input == "y" || input == "yes"
+
+	if err := saveMCPTrust(cwd, newMCPTrust(servers, trusted)); err != nil {
+		printer.Warning("Failed to remember the decision: %v", err)
+	}
+	if !trusted {
+		printer.Dim("Not starting them. Run `agentic-coder mcp trust` to change your mind.")
+	}
+	fmt.Println()
+	return trusted
+}
+
+// startMCPServers starts the user's MCP servers and, once trusted, the
+// project's, and registers their tools. Both include the servers of the
+// configs the global and project configs extend. Servers that fail to start
+// are reported and skipped.
+func startMCPServers(cwd string, registry *tool.Registry, interactive bool, printer *ui.Printer) *mcp.Manager {
+	servers := slices.Clone(settings.Global().MCPServers)
+	project, err := projectMCPServers(cwd, settings.Project())
+	if err != nil {
+		printer.Warning("Failed to load project MCP servers: %v", err)
+	}
+	if len(project) > 0 && trustProjectMCPServers(cwd, project, interactive, printer) {
+		for _, s := range project {
+			if indexMCPServer(servers, s.Name) >= 0 {
+				printer.Warning("Project MCP server %s has the name of one of yours; not starting it", s.Name)
+				continue
+			}
+			servers = append(servers, s.MCPServerConfig)
+		}
+	}
+	if len(servers) == 0 {
+		return nil
+	}
+
+	manager := mcp.NewManager()
+	started := 0
+	for _, s := range servers {
+		if err := manager.AddServer(toMCPServer(s)); err != nil {
+			printer.Warning("MCP server %s did not start: %v", s.Name, err)
+			continue
+		}
+		started++
+	}
+	if started == 0 {
+		return manager
+	}
+
+	tools := manager.GetTools()
+	for _, t := range tools {
+		registry.Register(builtin.NewMCPCallTool(manager, t))
+	}
+	registry.Register(builtin.NewListMCPResourcesTool(manager))
+	registry.Register(builtin.NewReadMCPResourceTool(manager))
+	if verbose {
+		printer.Dim("Started %d MCP server(s) with %d tool(s)", started, len(tools))
+	}
+	return manager
+}
+
+// stdinIsTerminal reports whether a user can answer prompts on stdin
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
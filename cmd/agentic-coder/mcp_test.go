@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/mcp"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+// mcpServer answers as an MCP server with one tool, search
+func mcpServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req mcp.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Method {
+		case "initialize":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{}}`, req.ID)
+		case "tools/list":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"tools":[{"name":"search"}]}}`, req.ID)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartMCPServersFromBases(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	server := mcpServer(t)
+
+	global, err := config.GetConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, global, `{"extends": ["team.json"]}`)
+	writeFile(t, filepath.Join(filepath.Dir(global), "team.json"),
+		`{"mcp_servers": [{"name": "docs", "type": "http", "url": "`+server.URL+`"}]}`)
+	cwd := filepath.Join(home, "project")
+	writeFile(t, config.GetProjectConfigPath(cwd), `{"extends": ["../shared.json"]}`)
+	writeFile(t, filepath.Join(cwd, "shared.json"),
+		`{"mcp_servers": [{"name": "wiki", "type": "http", "url": "`+server.URL+`"}]}`)
+
+	start := func() *tool.Registry {
+		t.Helper()
+		var err error
+		if settings, err = loadConfig(cwd); err != nil {
+			t.Fatal(err)
+		}
+		registry := tool.NewRegistry()
+		if manager := startMCPServers(cwd, registry, false, ui.NewPrinter()); manager != nil {
+			t.Cleanup(func() { manager.Close() })
+		}
+		return registry
+	}
+
+	// The project's servers, its bases' included, wait for trust
+	registry := start()
+	if _, err := registry.Get("mcp__docs__search"); err != nil {
+		t.Errorf("expected the server of the global config's base to start: %v", err)
+	}
+	if _, err := registry.Get("mcp__wiki__search"); err == nil {
+		t.Error("the server of the project's base started before it was trusted")
+	}
+
+	servers, err := projectMCPServers(cwd, settings.Project())
+	if err != nil || len(servers) != 1 {
+		t.Fatalf("expected the server of the project's base, got %v, %v", servers, err)
+	}
+	if err := saveMCPTrust(cwd, newMCPTrust(servers, true)); err != nil {
+		t.Fatal(err)
+	}
+	registry = start()
+	if _, err := registry.Get("mcp__wiki__search"); err != nil {
+		t.Errorf("expected the trusted server of the project's base to start: %v", err)
+	}
+}
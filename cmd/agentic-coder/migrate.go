@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/engine"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+func migrateCmd() *cobra.Command {
+	var (
+		dryRun  bool
+		pointer bool
+		yes     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate CLAUDE.md instructions to AGENT.md",
+		Long: `Detect CLAUDE.md files for this project and the user, preview how they
+merge into AGENT.md, and write the result.
+
+Project CLAUDE.md and .claude/CLAUDE.md are merged into the project AGENT.md;
+~/.claude/CLAUDE.md is merged into ~/.agentic-coder/AGENT.md. Content that is
+already in AGENT.md is not duplicated.
+
+With --pointer, each migrated CLAUDE.md is replaced by a short file that
+references AGENT.md, so tools that only read CLAUDE.md still find the rules.
+
+Example:
+  agentic-coder migrate --dry-run
+  agentic-coder migrate --pointer --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			printer := ui.NewPrinter()
+
+			builder := engine.NewPromptBuilder()
+			builder.ProjectPath = cwd
+			builder.CWD = cwd
+
+			steps, err := builder.PlanMigration()
+			if err != nil {
+				return err
+			}
+			if len(steps) == 0 {
+				printer.Info("Nothing to migrate: no CLAUDE.md instructions missing from %s", engine.InstructionFileName)
+				return nil
+			}
+
+			for _, step := range steps {
+				printMigrationStep(printer, step, pointer, cwd)
+			}
+
+			if dryRun {
+				fmt.Println()
+				printer.Dim("Dry run: no files were written")
+				return nil
+			}
+
+			if !yes {
+				fmt.Println()
+				fmt.Print("Apply this migration? [y/N] ")
+				input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				input = strings.TrimSpace(strings.ToLower(input))
+				if input != "y" && input != "yes" {
+					printer.Dim("Migration cancelled")
+					return nil
+				}
+			}
+
+			for _, step := range steps {
+				if err := step.Apply(pointer); err != nil {
+					return fmt.Errorf("migration failed: %w", err)
+				}
+				printer.Success("Wrote %s", displayPath(step.Target, cwd))
+			}
+			if !pointer {
+				printer.Dim("CLAUDE.md files were left unchanged and are still read for compatibility.")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the migration without writing files")
+	cmd.Flags().BoolVar(&pointer, "pointer", false, "Replace migrated CLAUDE.md files with a pointer to AGENT.md")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Apply without asking for confirmation")
+	return cmd
+}
+
+// printMigrationStep previews the content a migration step writes
+func printMigrationStep(printer *ui.Printer, step *engine.MigrationStep, pointer bool, cwd string) {
+	action := "create"
+	if step.Existing {
+		action = "update"
+	}
+	printer.Section("%s %s", action, displayPath(step.Target, cwd))
+	for _, source := range step.Sources {
+		printer.Dim("  from %s", displayPath(source, cwd))
+	}
+	fmt.Println()
+	for _, line := range strings.Split(step.Content, "\n") {
+		fmt.Printf("  │ %s\n", line)
+	}
+
+	if pointer {
+		for _, source := range step.Sources {
+			fmt.Println()
+			printer.Dim("  %s becomes a pointer:", displayPath(source, cwd))
+			for _, line := range strings.Split(strings.TrimSpace(engine.PointerContent(source, step.Target)), "\n") {
+				fmt.Printf("  │ %s\n", line)
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/review"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool/builtin"
+)
+
+// refactor handles "/refactor rename <symbol> <newName>": the language
+// server computes the rename, confirm is shown the diff, and the files are
+// checkpointed and written together
+func refactor(ctx context.Context, lsp *builtin.LSPTool, sess *session.Session, cwd string, args []string, confirm func(preview string) bool) (string, error) {
+	if readOnly {
+		return "", fmt.Errorf("/refactor changes files, which read-only mode does not allow")
+	}
+	if len(args) != 3 || args[0] != "rename" {
+		return "", fmt.Errorf("usage: /refactor rename <symbol|file:line:col> <newName>")
+	}
+	symbol, newName := args[1], args[2]
+
+	edit, err := lsp.Rename(ctx, cwd, symbol, newName)
+	if err != nil {
+		return "", fmt.Errorf("rename failed: %w", err)
+	}
+
+	var preview strings.Builder
+	changed := 0
+	for _, f := range edit.Files {
+		path := f.Path
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			path = rel
+		}
+		text, added, _ := review.UnifiedDiff(path, string(f.Before), string(f.After))
+		preview.WriteString(text)
+		changed += added
+	}
+	fmt.Fprintf(&preview, "\nRename %s to %s: %d lines in %d files\n", symbol, newName, changed, len(edit.Files))
+
+	if !confirm(preview.String()) {
+		return "Refactor cancelled", nil
+	}
+
+	for _, f := range edit.Files {
+		sess.Checkpoint("Refactor", f.Path)
+	}
+	if err := edit.Apply(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Renamed %s to %s in %d files; they are checkpointed for /rewind --restore", symbol, newName, len(edit.Files)), nil
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/changelog"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/release"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+// releaseOptions are the flags of the release command
+type releaseOptions struct {
+	skipTests, skipBuild bool
+	noAI, edit           bool
+	publish, dryRun      bool
+	allowDirty           bool
+}
+
+func releaseCmd() *cobra.Command {
+	var opts releaseOptions
+
+	cmd := &cobra.Command{
+		Use:   "release [major|minor|patch|<version>]",
+		Short: "Version, test, tag, build, and draft a GitHub release of a Go project",
+		Long: `Release the Go project in the current directory from end to end:
+
+  1. run the tests
+  2. add a changelog section for the release, drafted like "agentic-coder
+     changelog", unless the changelog already has one
+  3. bump the version variable in its Go source (patch by default)
+  4. build binaries for each platform into dist/, packaged as archives with
+     checksums.txt
+  5. commit the version and changelog, and tag the release
+  6. with --publish, push the commit and tag and create a draft GitHub
+     release with the changelog section as notes (requires gh)
+
+Settings go under "release" in .agentic-coder/config.json, in the style of
+goreleaser: version_file, version_var (default version), tag_prefix
+(default v), main (default .), binary, platforms (GOOS/GOARCH), ldflags
+({{.Version}}, {{.Tag}}, and {{.Commit}} are replaced), dist, test_command,
+and changelog. The version is found by searching the Go files when
+version_file is not set.
+
+Example:
+  agentic-coder release --dry-run
+  agentic-coder release minor --edit
+  agentic-coder release 1.0.0 --publish`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bump := ""
+			if len(args) == 1 {
+				bump = args[0]
+			}
+			return runRelease(cmd.Context(), bump, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.skipTests, "skip-tests", false, "Do not run the tests")
+	cmd.Flags().BoolVar(&opts.skipBuild, "skip-build", false, "Do not build binaries")
+	cmd.Flags().BoolVar(&opts.noAI, "no-ai", false, "List the grouped commits in the changelog without drafting with the model")
+	cmd.Flags().BoolVar(&opts.edit, "edit", false, "Open the changelog draft in $EDITOR before writing it")
+	cmd.Flags().BoolVar(&opts.publish, "publish", false, "Push the release and create a draft GitHub release with gh")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Show what the release would do without changing anything")
+	cmd.Flags().BoolVar(&opts.allowDirty, "allow-dirty", false, "Release even with uncommitted changes")
+	return cmd
+}
+
+func runRelease(ctx context.Context, bump string, opts releaseOptions) error {
+	printer := ui.NewPrinter()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	project := release.NewProject(cwd, settings.Get().Release)
+
+	current, err := project.FindVersion()
+	if err != nil {
+		return err
+	}
+	next, err := release.NextVersion(current, bump)
+	if err != nil {
+		return err
+	}
+	tag := project.Tag(next)
+	if changelog.TagExists(ctx, cwd, tag) {
+		return fmt.Errorf("tag %s already exists", tag)
+	}
+	since := project.Tag(current)
+	if !changelog.TagExists(ctx, cwd, since) {
+		since = changelog.LatestTag(ctx, cwd)
+	}
+
+	if !opts.allowDirty {
+		status, err := gitOutput(cwd, "status", "--porcelain", "--untracked-files=no")
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(status) != "" {
+			return fmt.Errorf("the working tree has uncommitted changes; commit them or use --allow-dirty")
+		}
+	}
+
+	printer.Info("Releasing %s: %s -> %s (%s)", project.Binary, current, next, project.VersionFile)
+	if opts.dryRun {
+		printReleasePlan(printer, project, tag, since, opts)
+		return nil
+	}
+
+	// 1. Tests run before anything changes
+	if !opts.skipTests {
+		printer.Info("Running %s", project.TestCommand)
+		test := exec.CommandContext(ctx, "sh", "-c", project.TestCommand)
+		test.Dir = cwd
+		test.Stdout, test.Stderr = os.Stdout, os.Stderr
+		if err := test.Run(); err != nil {
+			return fmt.Errorf("tests failed; nothing was changed: %w", err)
+		}
+	}
+
+	// 2. Changelog section, unless one was written by hand
+	notes, err := releaseChangelog(ctx, printer, project, tag, since, opts)
+	if err != nil {
+		return err
+	}
+
+	// 3. Version
+	if err := project.SetVersion(next); err != nil {
+		return fmt.Errorf("failed to set the version: %w", err)
+	}
+	printer.Success("Set %s to %s in %s", project.VersionVar, next, project.VersionFile)
+
+	// 4. Binaries, built from the new version before it is committed
+	var artifacts []release.Artifact
+	if !opts.skipBuild {
+		commit, _ := gitOutput(cwd, "rev-parse", "--short", "HEAD")
+		printer.Info("Building %d platforms into %s", len(project.Platforms), project.Dist)
+		artifacts, err = project.Build(ctx, release.BuildInfo{Version: next, Tag: tag, Commit: strings.TrimSpace(commit)}, os.Stdout)
+		if err != nil {
+			return fmt.Errorf("%w (the version and changelog are changed but not committed)", err)
+		}
+		for _, a := range artifacts {
+			rel, _ := filepath.Rel(cwd, a.Path)
+			printer.Dim("  %s", rel)
+		}
+	}
+
+	// 5. Commit and tag
+	if _, err := gitOutput(cwd, "add", "--", project.VersionFile, project.Changelog); err != nil {
+		return err
+	}
+	if _, err := gitOutput(cwd, "commit", "-m", "chore(release): "+tag); err != nil {
+		return err
+	}
+	if _, err := gitOutput(cwd, "tag", "-a", tag, "-m", "Release "+tag); err != nil {
+		return err
+	}
+	printer.Success("Committed and tagged %s", tag)
+
+	notesPath := filepath.Join(cwd, project.Dist, "RELEASE_NOTES.md")
+	if err := os.MkdirAll(filepath.Dir(notesPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(notesPath, []byte(notes+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write release notes: %w", err)
+	}
+
+	// 6. GitHub release
+	if !opts.publish {
+		printer.Info("Release notes are in %s. To publish: git push origin HEAD %s, then create the release, or rerun with --publish next time", filepath.Join(project.Dist, "RELEASE_NOTES.md"), tag)
+		return nil
+	}
+	if _, err := exec.LookPath("gh"); err != nil {
+		return fmt.Errorf("--publish needs the GitHub CLI (gh); the release is tagged locally")
+	}
+	if _, err := gitOutput(cwd, "push", "origin", "HEAD", tag); err != nil {
+		return err
+	}
+	args := []string{"release", "create", tag, "--draft", "--title", tag, "--notes-file", notesPath}
+	for _, a := range artifacts {
+		args = append(args, a.Path)
+	}
+	if len(artifacts) > 0 {
+		args = append(args, filepath.Join(cwd, project.Dist, "checksums.txt"))
+	}
+	gh := exec.CommandContext(ctx, "gh", args...)
+	gh.Dir = cwd
+	out, err := gh.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("gh release create failed: %s", strings.TrimSpace(string(out)))
+	}
+	printer.Success("Created a draft GitHub release: %s", strings.TrimSpace(string(out)))
+	return nil
+}
+
+// releaseChangelog returns the changelog section of a release, drafting
+// and adding one when the changelog has none yet
+func releaseChangelog(ctx context.Context, printer *ui.Printer, project *release.Project, tag, since string, opts releaseOptions) (string, error) {
+	path := filepath.Join(project.Dir, project.Changelog)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if section := changelog.Section(string(data), tag); section != "" {
+		printer.Info("Using the %s section already in %s", tag, project.Changelog)
+		return section, nil
+	}
+
+	commits, err := changelog.Commits(ctx, project.Dir, since)
+	if err != nil {
+		return "", err
+	}
+	groups := changelog.GroupCommits(commits)
+	date := time.Now()
+	section := changelog.Render(tag, date, groups)
+	if !opts.noAI && len(commits) > 0 {
+		prov, err := createProvider(provider.DetectProviderFromModel(model), apiKey, printer)
+		if err != nil {
+			return "", err
+		}
+		printer.Info("Drafting the changelog from %d commits...", len(commits))
+		if section, err = changelog.Draft(ctx, prov, provider.ResolveModel(model), tag, date, groups); err != nil {
+			return "", fmt.Errorf("%w (use --no-ai to list the commits instead)", err)
+		}
+	}
+	if opts.edit {
+		if section, err = editText(section, "changelog-*.md"); err != nil {
+			return "", err
+		}
+	}
+
+	if err := changelog.Prepend(path, section); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", project.Changelog, err)
+	}
+	printer.Success("Added the %s section to %s", tag, project.Changelog)
+	_, notes, _ := strings.Cut(strings.TrimSpace(section), "\n")
+	return strings.TrimSpace(notes), nil
+}
+
+// printReleasePlan describes what a release would do
+func printReleasePlan(printer *ui.Printer, project *release.Project, tag, since string, opts releaseOptions) {
+	var steps []string
+	if !opts.skipTests {
+		steps = append(steps, "run "+project.TestCommand)
+	}
+	from := since
+	if from == "" {
+		from = "the first commit"
+	}
+	steps = append(steps, fmt.Sprintf("add a %s section to %s from the commits since %s, unless it has one", tag, project.Changelog, from))
+	steps = append(steps, fmt.Sprintf("set %s in %s", project.VersionVar, project.VersionFile))
+	if !opts.skipBuild {
+		steps = append(steps, fmt.Sprintf("build %s for %s into %s", project.Main, strings.Join(project.Platforms, ", "), project.Dist))
+	}
+	steps = append(steps, "commit and tag "+tag)
+	if opts.publish {
+		steps = append(steps, "push and create a draft GitHub release")
+	}
+	for i, step := range steps {
+		fmt.Printf("  %d. %s\n", i+1, step)
+	}
+}
+
+// gitOutput runs a git command in dir and returns its output
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/remote"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+	"github.com/xinguang/agentic-coder/pkg/tool/builtin"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+// useRemote runs the file tools and Bash on the remote host the project at
+// cwd configures, unless --local is given, and returns it as host:dir, or ""
+// when the tools stay local. A host that only the project's config chooses
+// is used once the user trusts it.
+func useRemote(cwd string, registry *tool.Registry, shells *builtin.ShellManager, interactive bool, printer *ui.Printer) string {
+	if localTools {
+		return ""
+	}
+	cfg := settings.Get().Remote
+	if cfg.Host == "" {
+		return ""
+	}
+	if strings.HasPrefix(cfg.Host, "-") {
+		printer.Warning("Ignoring remote host %q: not a host name", cfg.Host)
+		return ""
+	}
+	if !reflect.DeepEqual(cfg, settings.Global().Remote) && !trustRemote(cwd, cfg, interactive, printer) {
+		return ""
+	}
+
+	controlDir := ""
+	if appDir, err := config.GetAppDir(); err == nil {
+		controlDir = filepath.Join(appDir, "ssh")
+	}
+	host := remote.New(cfg, cwd, controlDir)
+	removed := remote.Use(registry, shells, host, cfg.Shell)
+	printer.Info("Remote workspace: file tools and Bash run on %s", host)
+	if len(removed) > 0 {
+		printer.Dim("Not available remotely: %s", strings.Join(removed, ", "))
+	}
+	return host.String()
+}
+
+// hashRemote identifies the remote settings, so that trusting them does not
+// carry over to another host or other ssh options
+func hashRemote(cfg config.RemoteConfig) string {
+	data, _ := json.Marshal(cfg)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// trustRemote returns whether the remote host the project configures may be
+// used, asking when interactive and the user has not decided on these
+// settings. The host receives every file and command, and ssh options such
+// as ProxyCommand run commands locally, so a cloned repository must not
+// choose them silently.
+func trustRemote(cwd string, cfg config.RemoteConfig, interactive bool, printer *ui.Printer) bool {
+	state, err := config.LoadProjectState(cwd)
+	if err != nil {
+		printer.Warning("Not using the project's remote host: %v", err)
+		return false
+	}
+	hash := hashRemote(cfg)
+	if trust := state.RemoteTrust; trust != nil && trust.Hash == hash {
+		return trust.Trusted
+	}
+	if !interactive {
+		printer.Dim("Not using the remote host %s that this project configures until it is trusted in an interactive session", cfg.Host)
+		return false
+	}
+
+	if state.RemoteTrust != nil {
+		printer.Warning("This project's remote host settings changed since you decided on them")
+	} else {
+		printer.Warning("This project runs the file tools and Bash on a remote host over SSH:")
+	}
+	fmt.Printf("  • host: %s\n", cfg.Host)
+	if cfg.Port != 0 {
+		fmt.Printf("  • port: %d\n", cfg.Port)
+	}
+	if cfg.IdentityFile != "" {
+		fmt.Printf("  • identity file: %s\n", cfg.IdentityFile)
+	}
+	for _, opt := range cfg.SSHOptions {
+		fmt.Printf("  • ssh option: %s\n", opt)
+	}
+	fmt.Print("Use it in this project? [y/N] ")
+	input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	input = strings.TrimSpace(strings.ToLower(input))
+	trusted := input == "y" || input == "yes"
+
+	state.RemoteTrust = &config.RemoteTrust{Trusted: trusted, Hash: hash, Host: cfg.Host, DecidedAt: time.Now()}
+	if err := config.SaveProjectState(cwd, state); err != nil {
+		printer.Warning("Failed to remember the decision: %v", err)
+	}
+	if !trusted {
+		printer.Dim("Keeping the tools local.")
+	}
+	fmt.Println()
+	return trusted
+}
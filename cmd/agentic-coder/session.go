@@ -0,0 +1,875 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/engine"
+	"github.com/xinguang/agentic-coder/pkg/review"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool/builtin"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+func sessionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "session",
+		Short: "Manage saved sessions",
+	}
+
+	cmd.AddCommand(sessionListCmd())
+	cmd.AddCommand(sessionImportCmd())
+	cmd.AddCommand(sessionGCCmd())
+	cmd.AddCommand(sessionExportCmd())
+	cmd.AddCommand(sessionVerifyCmd())
+	cmd.AddCommand(sessionKeyCmd())
+	return cmd
+}
+
+func sessionExportCmd() *cobra.Command {
+	var output, format string
+
+	cmd := &cobra.Command{
+		Use:   "export [id]",
+		Short: "Export a session transcript as Markdown or JSONL",
+		Long: `Write the transcript of a session of the current project as Markdown:
+the prompts, the answers with the tools they called, and under each answer
+the web pages that informed it (pages fetched with WebFetch, search
+results, and pages a provider's built-in search cited).
+
+With --format jsonl the transcript is written as saved. When it was signed
+(transcript_signing), its signature is written next to it, as
+<name>.sig.json, so it can be checked with "session verify".
+
+The session is given by its ID or a prefix of it; without one the latest
+session is exported.
+
+Example:
+  agentic-coder session export
+  agentic-coder session export 3f2a9c1e -o research.md
+  agentic-coder session export --format jsonl -o audit.jsonl`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "markdown" && format != "md" && format != "jsonl" {
+				return fmt.Errorf("unknown format %q (use markdown or jsonl)", format)
+			}
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			sessMgr, err := session.NewSessionManager(&session.ManagerOptions{
+				ProjectPath: cwd,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create session manager: %w", err)
+			}
+
+			prefix := ""
+			if len(args) == 1 {
+				prefix = args[0]
+			}
+			id, err := findSession(sessMgr, prefix, cwd)
+			if err != nil {
+				return err
+			}
+			if format == "jsonl" {
+				return exportTranscript(sessMgr, id, output)
+			}
+
+			sess, err := sessMgr.GetSession(id)
+			if err != nil {
+				return fmt.Errorf("failed to load session: %w", err)
+			}
+			markdown := sess.Markdown()
+			if output == "" {
+				fmt.Print(markdown)
+				return nil
+			}
+			if err := os.WriteFile(output, []byte(markdown), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+			ui.NewPrinter().Success("Exported session %s to %s", id[:min(len(id), 8)], output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the transcript to this file instead of stdout")
+	cmd.Flags().StringVarP(&format, "format", "f", "markdown", "Export format: markdown or jsonl")
+	return cmd
+}
+
+// findSession returns the ID of the latest session of the project whose ID
+// starts with prefix
+func findSession(sessMgr *session.SessionManager, prefix, cwd string) (string, error) {
+	sessions, err := sessMgr.ListSessions()
+	if err != nil {
+		return "", fmt.Errorf("failed to list sessions: %w", err)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUpdated.After(sessions[j].LastUpdated)
+	})
+	for _, s := range sessions {
+		if strings.HasPrefix(s.ID, prefix) {
+			return s.ID, nil
+		}
+	}
+	if prefix != "" {
+		return "", fmt.Errorf("session not found: %s", prefix)
+	}
+	return "", fmt.Errorf("no sessions found for %s", cwd)
+}
+
+// exportTranscript writes a session's JSONL transcript, and its signature
+// when it has one
+func exportTranscript(sessMgr *session.SessionManager, id, output string) error {
+	data, sig, err := sessMgr.Transcript(id)
+	if err != nil {
+		return fmt.Errorf("failed to read transcript: %w", err)
+	}
+	if output == "" {
+		if sig != nil {
+			return fmt.Errorf("session %s is signed; export it with --output so the signature is written next to it", id[:min(len(id), 8)])
+		}
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	printer := ui.NewPrinter()
+	if sig == nil {
+		printer.Success("Exported session %s to %s (unsigned)", id[:min(len(id), 8)], output)
+		return nil
+	}
+	sigPath := session.SignaturePath(output)
+	if err := session.WriteSignature(sigPath, sig); err != nil {
+		return fmt.Errorf("failed to write %s: %w", sigPath, err)
+	}
+	printer.Success("Exported session %s to %s, signed in %s", id[:min(len(id), 8)], output, sigPath)
+	return nil
+}
+
+func sessionVerifyCmd() *cobra.Command {
+	var key, signature string
+
+	cmd := &cobra.Command{
+		Use:   "verify [transcript.jsonl|id]",
+		Short: "Check that a signed transcript was not modified",
+		Long: `Check a transcript against its signature: that no entry was changed,
+added, removed or reordered since it was saved, and that the signature is
+good. Transcripts are signed when transcript_signing is on.
+
+The transcript is a file exported with "session export --format jsonl",
+whose signature is read from <name>.sig.json, or a session of the current
+project given by its ID or a prefix of it (the latest without one).
+
+Without --key the check uses the key recorded in the signature, which shows
+the transcript is unmodified but not who signed it. Pass the signer's public
+key, as printed by "session key", to check that too.
+
+Example:
+  agentic-coder session verify audit.jsonl --key "$(cat signer.pub)"
+  agentic-coder session verify 3f2a9c1e`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var trusted ed25519.PublicKey
+			if key != "" {
+				if data, err := os.ReadFile(key); err == nil {
+					key = string(data)
+				}
+				var err error
+				if trusted, err = session.ParsePublicKey(key); err != nil {
+					return fmt.Errorf("invalid --key: %w", err)
+				}
+			}
+
+			name, data, sig, err := loadSignedTranscript(args, signature)
+			if err != nil {
+				return err
+			}
+			if sig == nil {
+				return fmt.Errorf("%s is not signed", name)
+			}
+			if err := session.VerifyTranscript(bytes.NewReader(data), sig, trusted); err != nil {
+				return fmt.Errorf("%s failed verification: %w", name, err)
+			}
+
+			publicKey, _ := session.ParsePublicKey(sig.PublicKey)
+			printer := ui.NewPrinter()
+			printer.Success("%s is unmodified: %d entries, signed %s with key %s",
+				name, sig.Entries, sig.Signed.Format(time.RFC3339), session.KeyFingerprint(publicKey))
+			if trusted == nil {
+				printer.Dim("The signer was not checked; pass --key with their public key to check it")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&key, "key", "", "Trusted public key, or a file holding it")
+	cmd.Flags().StringVar(&signature, "signature", "", "Signature file (default: <transcript>.sig.json)")
+	return cmd
+}
+
+// loadSignedTranscript reads the transcript to verify: the file named by the
+// argument, or else the session with that ID prefix
+func loadSignedTranscript(args []string, signature string) (string, []byte, *session.TranscriptSignature, error) {
+	if len(args) == 1 {
+		if data, err := os.ReadFile(args[0]); err == nil {
+			if signature == "" {
+				signature = session.SignaturePath(args[0])
+			}
+			sig, err := session.LoadSignature(signature)
+			if os.IsNotExist(err) {
+				return args[0], data, nil, nil
+			}
+			return args[0], data, sig, err
+		}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	sessMgr, err := session.NewSessionManager(&session.ManagerOptions{
+		ProjectPath: cwd,
+	})
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to create session manager: %w", err)
+	}
+	prefix := ""
+	if len(args) == 1 {
+		prefix = args[0]
+	}
+	id, err := findSession(sessMgr, prefix, cwd)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	data, sig, err := sessMgr.Transcript(id)
+	if err == nil && signature != "" {
+		sig, err = session.LoadSignature(signature)
+	}
+	return "session " + id[:min(len(id), 8)], data, sig, err
+}
+
+func sessionKeyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "key",
+		Short: "Print the public key that signs transcripts",
+		Long: `Print the public half of the key that signs transcripts on this machine,
+generating the key if there is none yet. Give it to whoever checks your
+exported transcripts, for "session verify --key".
+
+The private key is kept in ~/.agentic-coder/` + session.SigningKeyFile + `.
+
+Example:
+  agentic-coder session key > signer.pub`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appDir, err := config.GetAppDir()
+			if err != nil {
+				return err
+			}
+			key, err := session.LoadSigningKey(appDir)
+			if err != nil {
+				return err
+			}
+			fmt.Println(base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)))
+			return nil
+		},
+	}
+}
+
+// loadSigningKey returns the transcript signing key when transcript_signing
+// is on, generating it on first use
+func loadSigningKey() (ed25519.PrivateKey, error) {
+	if !settings.Get().TranscriptSigning {
+		return nil, nil
+	}
+	appDir, err := config.GetAppDir()
+	if err != nil {
+		return nil, err
+	}
+	return session.LoadSigningKey(appDir)
+}
+
+func sessionListCmd() *cobra.Command {
+	var tags []string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List sessions for the current project",
+		Long: `List saved sessions for the current project, newest first.
+
+Sessions carry user tags added with /tag add <tag>, and automatic tags for
+their provider, model, and git branch (provider:claude, model:<name>,
+branch:<name>). --tag keeps only sessions that have every given tag.
+
+Example:
+  agentic-coder session list --tag refactor
+  agentic-coder session list --tag provider:openai --tag branch:main`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+
+			sessMgr, err := session.NewSessionManager(&session.ManagerOptions{
+				ProjectPath: cwd,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create session manager: %w", err)
+			}
+
+			sessions, err := sessMgr.ListSessions()
+			if err != nil {
+				return fmt.Errorf("failed to list sessions: %w", err)
+			}
+			sessions = session.FilterByTags(sessions, tags...)
+			sort.Slice(sessions, func(i, j int) bool {
+				return sessions[i].LastUpdated.After(sessions[j].LastUpdated)
+			})
+
+			printer := ui.NewPrinter()
+			if len(sessions) == 0 {
+				printer.Info("No sessions found")
+				return nil
+			}
+
+			for _, s := range sessions {
+				title := s.Title
+				if title == "" {
+					title = "(untitled)"
+				}
+				shortID := s.ID
+				if len(shortID) > 8 {
+					shortID = shortID[:8]
+				}
+				fmt.Printf("%s  %s  %4d msgs  %s\n", shortID, s.LastUpdated.Format("2006-01-02 15:04"), s.MessageCount, title)
+				if all := s.AllTags(); len(all) > 0 {
+					printer.Dim("          %s", strings.Join(all, "  "))
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&tags, "tag", nil, "Only list sessions with this tag (repeatable)")
+	return cmd
+}
+
+func sessionImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <claude-code|codex> [transcript.jsonl...]",
+		Short: "Import sessions from Claude Code or Codex CLI",
+		Long: `Import conversation history from other coding agents so it can be
+resumed here with /resume.
+
+Without transcript paths, all transcripts recorded for the current directory
+are imported: Claude Code transcripts from ~/.claude/projects, and Codex CLI
+session logs from ~/.codex/sessions. Imported sessions keep their original
+IDs, so importing again replaces them, and are tagged "imported".
+
+Example:
+  agentic-coder session import claude-code
+  agentic-coder session import codex ~/.codex/sessions/2025/01/02/rollout-abc.jsonl`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return err
+			}
+
+			source, paths := args[0], args[1:]
+			var importFn func(string) (*session.Session, error)
+			var discover func(home, projectPath string) ([]string, error)
+			switch source {
+			case session.SourceClaudeCode, "claude":
+				importFn, discover = session.ImportClaudeCode, session.ClaudeCodeTranscripts
+			case session.SourceCodex:
+				importFn, discover = session.ImportCodex, session.CodexTranscripts
+			default:
+				return fmt.Errorf("unknown import source %q (available: %s, %s)", source, session.SourceClaudeCode, session.SourceCodex)
+			}
+
+			if len(paths) == 0 {
+				if paths, err = discover(home, cwd); err != nil {
+					return fmt.Errorf("failed to find transcripts: %w", err)
+				}
+			}
+
+			printer := ui.NewPrinter()
+			if len(paths) == 0 {
+				printer.Info("No %s transcripts found for %s", source, cwd)
+				return nil
+			}
+
+			sessMgr, err := session.NewSessionManager(&session.ManagerOptions{
+				ProjectPath: cwd,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create session manager: %w", err)
+			}
+
+			imported := 0
+			for _, path := range paths {
+				sess, err := importFn(path)
+				if err != nil {
+					printer.Warning("Skipped %s: %v", path, err)
+					continue
+				}
+				if err := sessMgr.SaveSession(sess); err != nil {
+					return fmt.Errorf("failed to save session %s: %w", sess.ID, err)
+				}
+				imported++
+				printer.Success("Imported %s  %s (%d messages)", sess.ID, sess.Title, len(sess.Messages))
+			}
+
+			if imported > 0 {
+				fmt.Println()
+				printer.Dim("Resume an imported session with /resume <id>")
+			}
+			return nil
+		},
+	}
+}
+
+func sessionGCCmd() *cobra.Command {
+	var olderThan string
+	var keep int
+	var archive, dryRun, allProjects bool
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Delete or archive old sessions",
+		Long: `Delete old sessions of the current project, or move them to the
+archive directory next to them with --archive, where they are no longer
+listed or resumed.
+
+Sessions not updated for --older-than (such as 30d, 2w, or 12h) are
+removed, except the --keep most recent ones. Side files left without a
+session, such as transcripts without metadata and the output copies of
+shells from sessions that did not exit cleanly, are removed too. Use
+--dry-run to see what would be removed and how much space it frees.
+
+Example:
+  agentic-coder session gc --older-than 30d --keep 50 --dry-run
+  agentic-coder session gc --older-than 90d --archive --all-projects`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if olderThan == "" && !cmd.Flags().Changed("keep") {
+				return fmt.Errorf("choose the sessions to remove with --older-than, --keep, or both")
+			}
+			if keep < 0 {
+				return fmt.Errorf("--keep must not be negative")
+			}
+			opts := session.GCOptions{Keep: keep, Archive: archive, DryRun: dryRun}
+			if olderThan != "" {
+				age, err := parseAge(olderThan)
+				if err != nil {
+					return err
+				}
+				opts.OlderThan = age
+			}
+
+			dirs, err := sessionDirs(allProjects)
+			if err != nil {
+				return err
+			}
+
+			printer := ui.NewPrinter()
+			total := &session.GCReport{}
+			for _, dir := range dirs {
+				report, err := session.CollectGarbage(dir, opts)
+				if report != nil {
+					printGCReport(report, allProjects, opts)
+					total.Sessions = append(total.Sessions, report.Sessions...)
+					total.Orphans = append(total.Orphans, report.Orphans...)
+					total.Freed += report.Freed
+					total.ArchivedBytes += report.ArchivedBytes
+					total.Kept += report.Kept
+					total.KeptBytes += report.KeptBytes
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			// Shell output copies are written while a shell runs, so only
+			// those untouched for a day are certainly left behind
+			logAge := 24 * time.Hour
+			if opts.OlderThan > logAge {
+				logAge = opts.OlderThan
+			}
+			for _, path := range builtin.StaleShellLogs(logAge) {
+				info, err := os.Stat(path)
+				if err != nil {
+					continue
+				}
+				if !dryRun && os.Remove(path) != nil {
+					continue
+				}
+				total.Orphans = append(total.Orphans, path)
+				total.Freed += info.Size()
+			}
+
+			verb := "Freed"
+			if dryRun {
+				verb = "Would free"
+			}
+			if len(total.Sessions) == 0 && len(total.Orphans) == 0 {
+				printer.Info("Nothing to remove; %d sessions kept (%s)", total.Kept, formatSize(total.KeptBytes))
+				return nil
+			}
+			fmt.Println()
+			printer.Success("%s %s: %d sessions and %d orphaned files", verb, formatSize(total.Freed), len(total.Sessions), len(total.Orphans))
+			if archive {
+				printer.Dim("%s archived", formatSize(total.ArchivedBytes))
+			}
+			printer.Dim("%d sessions kept (%s)", total.Kept, formatSize(total.KeptBytes))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Remove sessions not updated for this long (e.g. 30d, 2w, 12h)")
+	cmd.Flags().IntVar(&keep, "keep", 0, "Always keep this many of the most recent sessions")
+	cmd.Flags().BoolVar(&archive, "archive", false, "Move sessions to the archive directory instead of deleting them")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be removed without removing anything")
+	cmd.Flags().BoolVar(&allProjects, "all-projects", false, "Collect the sessions of every project, not just the current one")
+	return cmd
+}
+
+// sessionDirs returns the sessions directory of the current project, or of
+// every project
+func sessionDirs(allProjects bool) ([]string, error) {
+	if !allProjects {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		dir, err := config.GetProjectSessionsDir(cwd)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return nil, nil
+		}
+		return []string{dir}, nil
+	}
+
+	root, err := config.GetSessionsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, filepath.Join(root, entry.Name()))
+		}
+	}
+	return dirs, nil
+}
+
+// printGCReport lists the sessions and files a collection removed
+func printGCReport(report *session.GCReport, showDir bool, opts session.GCOptions) {
+	if len(report.Sessions) == 0 && len(report.Orphans) == 0 {
+		return
+	}
+	if showDir {
+		fmt.Println(filepath.Base(report.Dir))
+	}
+	action, remove := "delete", "delete"
+	if opts.Archive {
+		action = "archive"
+	}
+	if opts.DryRun {
+		action, remove = "would "+action, "would delete"
+	}
+	for _, s := range report.Sessions {
+		title := s.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		shortID := s.ID
+		if len(shortID) > 8 {
+			shortID = shortID[:8]
+		}
+		fmt.Printf("  %s  %s  %s  %4d msgs  %s\n", action, shortID, s.LastUpdated.Format("2006-01-02 15:04"), s.MessageCount, truncateLine(title, 50))
+	}
+	for _, path := range report.Orphans {
+		fmt.Printf("  %s  orphaned %s\n", remove, filepath.Base(path))
+	}
+}
+
+// parseAge parses a duration that may also be given in days or weeks
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			days, err := strconv.ParseFloat(n, 64)
+			if err != nil || days < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(days * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 30d, 2w, or 12h)", s)
+	}
+	return d, nil
+}
+
+// formatSize formats a byte count for display
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+// tagSession handles "/tag [list|add|remove] [tags...]" and returns a message to display
+func tagSession(sess *session.Session, args []string) (string, error) {
+	if len(args) == 0 || args[0] == "list" {
+		tags := sess.GetTags()
+		if len(tags) == 0 {
+			return "No tags", nil
+		}
+		return "Tags: " + strings.Join(tags, ", "), nil
+	}
+
+	if len(args) < 2 {
+		return "", fmt.Errorf("usage: /tag add|remove <tag>...")
+	}
+
+	var changed []string
+	switch args[0] {
+	case "add":
+		for _, tag := range args[1:] {
+			added, err := sess.AddTag(tag)
+			if err != nil {
+				return "", err
+			}
+			if added {
+				changed = append(changed, tag)
+			}
+		}
+		if len(changed) == 0 {
+			return "Tags already present", nil
+		}
+		return "Added tags: " + strings.Join(changed, ", "), nil
+
+	case "remove", "rm":
+		for _, tag := range args[1:] {
+			if sess.RemoveTag(tag) {
+				changed = append(changed, tag)
+			}
+		}
+		if len(changed) == 0 {
+			return "No matching tags", nil
+		}
+		return "Removed tags: " + strings.Join(changed, ", "), nil
+
+	default:
+		return "", fmt.Errorf("unknown /tag action %q: use add, remove, or list", args[0])
+	}
+}
+
+// rewindSession handles "/rewind [n|-k] [--restore]" and returns a message to display.
+// Without arguments it lists the prompts that can be rewound to.
+func rewindSession(sess *session.Session, args []string) (string, error) {
+	restore := false
+	var target string
+	for _, arg := range args {
+		switch arg {
+		case "--restore", "--files":
+			restore = true
+		default:
+			target = arg
+		}
+	}
+	if restore && readOnly {
+		return "", fmt.Errorf("--restore changes files, which read-only mode does not allow")
+	}
+
+	prompts := sess.Prompts()
+	if len(prompts) == 0 {
+		return "Nothing to rewind", nil
+	}
+
+	if target == "" {
+		var b strings.Builder
+		b.WriteString("Prompts (use /rewind <n> to return to before prompt n, add --restore to restore files):\n")
+		for _, p := range prompts {
+			fmt.Fprintf(&b, "  %3d  %s\n", p.Number, truncateLine(p.Text, 70))
+		}
+		return strings.TrimRight(b.String(), "\n"), nil
+	}
+
+	n, err := strconv.Atoi(target)
+	if err != nil {
+		return "", fmt.Errorf("usage: /rewind [n|-k] [--restore]")
+	}
+	if n < 0 {
+		// -k undoes the last k turns
+		n = len(prompts) + n + 1
+	}
+
+	result, err := sess.Rewind(n, restore)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Rewound to before prompt %d: %s\n", result.Prompt.Number, truncateLine(result.Prompt.Text, 70))
+	fmt.Fprintf(&b, "Removed %d messages", result.RemovedMessages)
+	for _, path := range result.Restored {
+		fmt.Fprintf(&b, "\nRestored %s", path)
+	}
+	for _, err := range result.Errors {
+		fmt.Fprintf(&b, "\nFailed to %v", err)
+	}
+	if restore {
+		b.WriteString("\nChanges made by shell commands were not restored")
+	}
+	return b.String(), nil
+}
+
+// pinFiles handles "/pin <file>..." and "/unpin <file>...|all" and returns a
+// message to display. Without arguments it lists the pinned files.
+func pinFiles(sess *session.Session, args []string, unpin bool) (string, error) {
+	if len(args) == 0 {
+		pinned := sess.PinnedFiles()
+		if len(pinned) == 0 {
+			return "No pinned files; /pin <file> keeps a file's current content in view", nil
+		}
+		var b strings.Builder
+		b.WriteString("Pinned files:")
+		for _, path := range pinned {
+			fmt.Fprintf(&b, "\n  %s", displayPath(path, sess.CWD))
+		}
+		return b.String(), nil
+	}
+
+	if unpin && len(args) == 1 && args[0] == "all" {
+		pinned := sess.PinnedFiles()
+		for _, path := range pinned {
+			sess.Unpin(path)
+		}
+		return fmt.Sprintf("Unpinned %d files", len(pinned)), nil
+	}
+
+	var changed []string
+	for _, arg := range args {
+		path := arg
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(sess.CWD, path)
+		}
+		path = filepath.Clean(path)
+
+		if unpin {
+			if sess.Unpin(path) {
+				changed = append(changed, arg)
+			}
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot pin %s: %w", arg, err)
+		}
+		if info.IsDir() {
+			return "", fmt.Errorf("cannot pin %s: it is a directory", arg)
+		}
+		if sess.Pin(path) {
+			changed = append(changed, arg)
+		}
+	}
+
+	switch {
+	case unpin && len(changed) == 0:
+		return "No matching pinned files", nil
+	case unpin:
+		return "Unpinned " + strings.Join(changed, ", "), nil
+	case len(changed) == 0:
+		return "Already pinned", nil
+	}
+	return "Pinned " + strings.Join(changed, ", ") + "; its current content stays in the system prompt", nil
+}
+
+// truncateLine shortens text to a single line of at most max characters
+func truncateLine(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) > max {
+		return text[:max] + "..."
+	}
+	return text
+}
+
+// gitBranch returns the current git branch of dir, or "" outside a repository
+func gitBranch(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	branch := strings.TrimSpace(string(out))
+	if branch == "HEAD" {
+		return "" // detached
+	}
+	return branch
+}
+
+// shareBranchDiff handles "/diff-context [base]": it summarizes the changes
+// of the current branch against base, or the default branch, and attaches
+// them to the next prompt
+func shareBranchDiff(eng *engine.Engine, dir string, args []string) (string, error) {
+	if len(args) > 1 {
+		return "", fmt.Errorf("usage: /diff-context [base-branch]")
+	}
+	base := ""
+	if len(args) == 1 {
+		base = args[0]
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	changes, err := review.BranchDiff(ctx, dir, base)
+	if err != nil {
+		return "", err
+	}
+	if changes.Empty() {
+		return fmt.Sprintf("No changes against %s", changes.Base), nil
+	}
+	eng.AddContext(changes.Summary())
+	return fmt.Sprintf("Shared the changes against %s (%d commits, %d changed files, %d new); they go with your next prompt",
+		changes.Base, len(changes.Commits), len(changes.Files), len(changes.Untracked)), nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/telemetry"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+// metrics counts anonymous usage when the user opted in; nil records nothing
+var metrics *telemetry.Recorder
+
+// openTelemetry loads the telemetry setting, or returns nil when it cannot
+func openTelemetry() *telemetry.Recorder {
+	path, err := config.GetTelemetryPath()
+	if err != nil {
+		return nil
+	}
+	r, err := telemetry.Open(path)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+// recordCommand counts a slash command from the help menu; other input is
+// never recorded
+func recordCommand(input string) {
+	if name := firstField(input); ui.IsCommand(name) {
+		metrics.Command(name)
+	}
+}
+
+// firstField returns the first word of input
+func firstField(input string) string {
+	for i, r := range input {
+		if r == ' ' || r == '\t' || r == '\n' {
+			return input[:i]
+		}
+	}
+	return input
+}
+
+func telemetryCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "telemetry [on|off|status]",
+		Short: "Turn anonymous usage metrics on or off",
+		Long: `Telemetry is off unless you turn it on. When on, agentic-coder counts
+which commands you use, which providers you run, and the classes of errors
+you hit (such as timeout or rate_limit), and sends the counts at most once a
+day with a random ID, the version, and the platform. It never records code,
+prompts, file paths, or error messages.
+
+"status" shows the setting and exactly what the next upload would send.
+DO_NOT_TRACK=1 or AGENTIC_CODER_TELEMETRY=off turns telemetry off whatever
+the setting.
+
+Example:
+  agentic-coder telemetry on
+  agentic-coder telemetry status
+  agentic-coder telemetry off`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: []string{"on", "off", "status"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer := ui.NewPrinter()
+			r := openTelemetry()
+			if r == nil {
+				return fmt.Errorf("cannot read the telemetry setting")
+			}
+
+			action := "status"
+			if len(args) == 1 {
+				action = args[0]
+			}
+			switch action {
+			case "on":
+				if err := r.SetEnabled(true); err != nil {
+					return err
+				}
+				printer.Success("Telemetry is on. Thank you! Turn it off any time with: agentic-coder telemetry off")
+			case "off":
+				if err := r.SetEnabled(false); err != nil {
+					return err
+				}
+				printer.Success("Telemetry is off; counts not yet sent were deleted")
+			case "status":
+				printTelemetryStatus(printer, r)
+			default:
+				return fmt.Errorf("unknown action %q: use on, off, or status", action)
+			}
+			return nil
+		},
+	}
+}
+
+// printTelemetryStatus shows the telemetry setting and the pending upload
+func printTelemetryStatus(printer *ui.Printer, r *telemetry.Recorder) {
+	switch {
+	case telemetry.DisabledByEnv():
+		printer.Info("Telemetry is off (set by DO_NOT_TRACK or AGENTIC_CODER_TELEMETRY)")
+		return
+	case !r.Enabled():
+		printer.Info("Telemetry is off. Turn it on with: agentic-coder telemetry on")
+		return
+	}
+
+	printer.Info("Telemetry is on")
+	if telemetry.Endpoint == "" {
+		printer.Dim("This build has no telemetry endpoint; counts are kept locally and never sent")
+	}
+	data, err := json.MarshalIndent(r.Payload(version), "", "  ")
+	if err != nil {
+		printer.Error("%v", err)
+		return
+	}
+	fmt.Println("Next upload:")
+	fmt.Println(string(data))
+}
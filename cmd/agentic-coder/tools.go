@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// manageTools handles "/tools [enable|disable] [name|namespace|pattern...]" and
+// returns a message to display. Changes apply from the next request on.
+func manageTools(registry *tool.Registry, args []string) (string, error) {
+	if len(args) == 0 || args[0] == "list" {
+		return describeTools(registry), nil
+	}
+
+	if len(args) < 2 {
+		return "", fmt.Errorf("usage: /tools enable|disable <name|namespace|pattern>...")
+	}
+
+	var enabled bool
+	switch args[0] {
+	case "enable", "on":
+		enabled = true
+	case "disable", "off":
+		enabled = false
+	default:
+		return "", fmt.Errorf("unknown /tools action %q: use enable, disable, or list", args[0])
+	}
+
+	var changed []string
+	for _, pattern := range args[1:] {
+		names, err := registry.SetEnabled(pattern, enabled)
+		if err != nil {
+			return "", err
+		}
+		changed = append(changed, names...)
+	}
+
+	if len(changed) == 0 {
+		return "No tools changed", nil
+	}
+	if enabled {
+		return "Enabled: " + strings.Join(changed, ", "), nil
+	}
+	return "Disabled: " + strings.Join(changed, ", "), nil
+}
+
+// describeTools lists the registered tools grouped by namespace
+func describeTools(registry *tool.Registry) string {
+	infos := registry.Tools()
+	if len(infos) == 0 {
+		return "No tools registered"
+	}
+
+	var b strings.Builder
+	b.WriteString("Tools (use /tools enable|disable <name|namespace|pattern>):")
+	namespace := ""
+	for _, info := range infos {
+		if info.Namespace != namespace {
+			namespace = info.Namespace
+			fmt.Fprintf(&b, "\n  %s\n", namespace)
+		}
+		mark := "✓"
+		if !info.Enabled {
+			mark = "✗"
+		}
+		fmt.Fprintf(&b, "    %s %s\n", mark, info.Name)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/cost"
+	"github.com/xinguang/agentic-coder/pkg/engine"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+// usageLedger returns the cross-session usage ledger, or nil when the app
+// directory is unavailable
+func usageLedger() *cost.Ledger {
+	path, err := config.GetUsageLedgerPath()
+	if err != nil {
+		return nil
+	}
+	return cost.NewLedger(path)
+}
+
+// monthlyBudget returns the monthly budget from the global and project
+// config, or nil when none is set
+func monthlyBudget() *cost.Budget {
+	cfg := settings.Get()
+	if cfg.MonthlyBudgetUSD <= 0 {
+		return nil
+	}
+	budget := &cost.Budget{
+		Monthly: cfg.MonthlyBudgetUSD,
+		Model:   cfg.BudgetModel,
+		Enforce: cfg.BudgetEnforce && !budgetOverride,
+	}
+	for _, pct := range cfg.BudgetAlerts {
+		budget.Alerts = append(budget.Alerts, pct/100)
+	}
+	return budget
+}
+
+// budgetModel returns the model to start with: the budget model when this
+// month's budget is already spent, otherwise the requested one
+func budgetModel(requested string, budget *cost.Budget, ledger *cost.Ledger, printer *ui.Printer) string {
+	if budget == nil || budget.Model == "" || ledger == nil {
+		return requested
+	}
+	spent, err := ledger.MonthSpend(time.Now())
+	if err != nil || !budget.Exceeded(spent) {
+		return requested
+	}
+	printer.Warning("Monthly budget spent ($%.2f of $%.2f), using %s", spent, budget.Monthly, budget.Model)
+	return budget.Model
+}
+
+func usageCmd() *cobra.Command {
+	var since, by string
+	var speed bool
+
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Report token usage and cost across sessions",
+		Long: `Report token usage and cost recorded by every session, grouped by day,
+model, provider, or project.
+
+Each model response is recorded in ~/.agentic-coder/usage.jsonl, so the report
+covers all sessions, explain runs, and workflows, not just the current one
+shown by /cost. Costs are estimates from the built-in price list.
+
+When monthly_budget_usd is configured, the report ends with this month's
+spending against the budget.
+
+--speed reports the latency of each model instead: the median and 90th
+percentile time to the first token, and the median output tokens per
+second, over its latest calls.
+
+Example:
+  agentic-coder usage
+  agentic-coder usage --since 7d --by model
+  agentic-coder usage --since 2024-06-01 --by project
+  agentic-coder usage --speed`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			start, err := cost.ParseSince(since, time.Now())
+			if err != nil {
+				return err
+			}
+			ledger := usageLedger()
+			if ledger == nil {
+				return fmt.Errorf("failed to locate the usage ledger")
+			}
+			records, err := ledger.Records(start)
+			if err != nil {
+				return err
+			}
+			if speed {
+				fmt.Print(describeSpeed(records))
+				return nil
+			}
+			summaries, err := cost.Summarize(records, by)
+			if err != nil {
+				return err
+			}
+
+			printer := ui.NewPrinter()
+			if len(summaries) == 0 {
+				printer.Info("No usage recorded")
+				return nil
+			}
+			fmt.Print(formatUsage(summaries, by))
+
+			if budget := monthlyBudget(); budget != nil {
+				if spent, err := ledger.MonthSpend(time.Now()); err == nil {
+					fmt.Println()
+					fmt.Println(engine.BudgetStatus{Spent: spent, Limit: budget.Monthly})
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "30d", "Only include usage since this duration ago (7d, 12h) or date (2024-06-01); empty for all")
+	cmd.Flags().StringVar(&by, "by", "day", "Group by "+strings.Join(cost.UsageGroupings, ", "))
+	cmd.Flags().BoolVar(&speed, "speed", false, fmt.Sprintf("Report time to first token and tokens per second of each model's latest %d calls", cost.SpeedWindow))
+	return cmd
+}
+
+// formatUsage renders usage summaries as a table with a total row
+func formatUsage(summaries []cost.UsageSummary, by string) string {
+	width := len(by)
+	for _, s := range summaries {
+		width = max(width, len(s.Key))
+	}
+
+	var b strings.Builder
+	row := func(key, requests, input, output, price string) {
+		fmt.Fprintf(&b, "%-*s  %8s  %12s  %12s  %10s\n", width, key, requests, input, output, price)
+	}
+
+	row(strings.ToUpper(by), "REQUESTS", "INPUT", "OUTPUT", "COST")
+	var total cost.UsageSummary
+	for _, s := range summaries {
+		row(s.Key, fmt.Sprint(s.Requests), fmt.Sprint(s.InputTokens), fmt.Sprint(s.OutputTokens), fmt.Sprintf("$%.2f", s.Cost))
+		total.Requests += s.Requests
+		total.InputTokens += s.InputTokens
+		total.OutputTokens += s.OutputTokens
+		total.Cost += s.Cost
+	}
+	if len(summaries) > 1 {
+		row("TOTAL", fmt.Sprint(total.Requests), fmt.Sprint(total.InputTokens), fmt.Sprint(total.OutputTokens), fmt.Sprintf("$%.2f", total.Cost))
+	}
+	return b.String()
+}
+
+// describeSpeed renders the rolling speed of each model in records
+func describeSpeed(records []cost.UsageRecord) string {
+	summaries := cost.SummarizeSpeed(records, cost.SpeedWindow)
+	if len(summaries) == 0 {
+		return "No call speeds recorded yet\n"
+	}
+	return cost.FormatSpeed(summaries)
+}
+
+// modelSpeeds renders the rolling speed of each model over the last 30
+// days of the ledger, for /cost
+func modelSpeeds() string {
+	ledger := usageLedger()
+	if ledger == nil {
+		return ""
+	}
+	records, err := ledger.Records(time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		return ""
+	}
+	return describeSpeed(records)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/auth"
+	"github.com/xinguang/agentic-coder/pkg/engine"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+	"github.com/xinguang/agentic-coder/pkg/voice"
+)
+
+// voiceInput returns voice input with the merged voice settings. The
+// recorder and whisper.cpp binary are commands, so only the global config
+// can set them; a cloned repository cannot make /voice run its programs.
+func voiceInput() *voice.Input {
+	cfg := settings.Get().Voice
+	global := settings.Global().Voice
+	cfg.Recorder, cfg.WhisperBinary = global.Recorder, global.WhisperBinary
+	return voice.NewInput(cfg, voiceAPIKey())
+}
+
+// speechAnnouncer returns the announcer reading out turn summaries, or nil when
+// neither --speak nor voice.speak asks for it or nothing can speak. The
+// first failure to speak is printed unless quiet, as the TUI owns the
+// terminal.
+func speechAnnouncer(cmd *cobra.Command, printer *ui.Printer, quiet bool) *voice.Announcer {
+	cfg := settings.Get().Voice
+	if speak, _ := cmd.Flags().GetBool("speak"); !speak && !cfg.Speak {
+		return nil
+	}
+	speaker, err := voice.NewSpeaker(cfg, voiceAPIKey())
+	if err != nil {
+		printer.Warning("Cannot speak turn summaries: %v", err)
+		return nil
+	}
+	var once sync.Once
+	return voice.NewAnnouncer(speaker, func(err error) {
+		if !quiet {
+			once.Do(func() { printer.Warning("Speaking failed: %v", err) })
+		}
+	})
+}
+
+// speakTurns reads out a summary of each turn eng completes
+func speakTurns(eng *engine.Engine, speech *voice.Announcer) {
+	if speech == nil {
+		return
+	}
+	eng.SetCallbacks(&engine.CallbackOptions{
+		OnTurnEnd: func(text string) {
+			speech.Announce(voice.Summary(text))
+		},
+	})
+}
+
+// voiceAPIKey returns the OpenAI key for the transcription API, or "" when
+// none is set up
+func voiceAPIKey() string {
+	if creds, err := auth.NewManager("").GetCredentials(auth.ProviderOpenAI); err == nil && creds.APIKey != "" {
+		return creds.APIKey
+	}
+	return os.Getenv("OPENAI_API_KEY")
+}
+
+// voiceCommand records until Enter, transcribes the speech, and lets the
+// user send, edit, or discard the transcript. It sets ctx.prompt and
+// returns true when the transcript should be sent.
+func voiceCommand(ctx *chatContext, in *voice.Input) bool {
+	if err := in.Start(); err != nil {
+		ctx.printer.Error("Voice input: %v", err)
+		return false
+	}
+	ctx.printer.Info("Recording... press Enter to stop")
+	ctx.reader.ReadString('\n')
+
+	ctx.printer.Dim("Transcribing...")
+	text, err := in.Stop(context.Background())
+	if err != nil {
+		ctx.printer.Error("Voice input: %v", err)
+		return false
+	}
+
+	for {
+		fmt.Printf("\n%s\n\n", text)
+		fmt.Print("Send this? [Y]es / [e]dit / [n]o: ")
+		answer, _ := ctx.reader.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "", "y", "yes":
+			ctx.prompt = text
+			return true
+		case "e", "edit":
+			edited, err := editText(text, "voice-*.txt")
+			if err != nil {
+				ctx.printer.Error("%v", err)
+				continue
+			}
+			if text = strings.TrimSpace(edited); text == "" {
+				ctx.printer.Info("Discarded")
+				return false
+			}
+		default:
+			ctx.printer.Info("Discarded")
+			return false
+		}
+	}
+}
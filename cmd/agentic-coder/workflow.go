@@ -15,6 +15,7 @@ import (
 	"github.com/xinguang/agentic-coder/pkg/provider"
 	"github.com/xinguang/agentic-coder/pkg/provider/claude"
 	"github.com/xinguang/agentic-coder/pkg/tool"
+	"github.com/xinguang/agentic-coder/pkg/tool/builtin"
 	"github.com/xinguang/agentic-coder/pkg/ui"
 	"github.com/xinguang/agentic-coder/pkg/workflow"
 	"github.com/xinguang/agentic-coder/pkg/workflow/agent"
@@ -122,9 +123,25 @@ func runWorkflow(ctx context.Context, requirement string, config *workflow.Workf
 
 	// Create engine factory
 	cwd, _ := os.Getwd()
+	if err := resolveSystemPrompt(); err != nil {
+		return err
+	}
 	registry := tool.NewRegistry()
-	registerBuiltinTools(registry)
+	useEnvPolicy()
+	shells := registerBuiltinTools(registry, builtin.NewSupervisor(processLimits()))
+	defer stopShells(shells, printer)
+	remoteHost = useRemote(cwd, registry, shells, stdinIsTerminal(), printer)
+	if containerInfo, err = useDevcontainer(cwd, registry, shells, printer); err != nil {
+		return err
+	}
+	if readOnly {
+		engine.RemoveWritingTools(registry)
+	}
 
+	ledger := usageLedger()
+	budget := monthlyBudget()
+	iterLog := iterationLog()
+	destructive, verifier := destructiveGuard(printer)
 	engFactory := func() *engine.Engine {
 		prov, _ := provFactory(config.Models.Default)
 		return engine.NewEngine(&engine.EngineOptions{
@@ -133,6 +150,15 @@ func runWorkflow(ctx context.Context, requirement string, config *workflow.Workf
 			MaxIterations: 50,
 			MaxTokens:     8192,
 			SystemPrompt:  getSystemPrompt(),
+			NoStream:      noStream,
+			UsageLedger:   ledger,
+			Budget:        budget,
+			IterationLog:  iterLog,
+			PathScope:     pathScope(cwd),
+			// Agents run unattended, so destructive calls need the verifier
+			Destructive:         destructive,
+			DestructiveVerifier: verifier,
+			ReadOnly:            readOnly,
 		})
 	}
 
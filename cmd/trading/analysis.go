@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/auth"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/provider/azureopenai"
+	"github.com/xinguang/agentic-coder/pkg/provider/claude"
+	"github.com/xinguang/agentic-coder/pkg/provider/claudecli"
+	"github.com/xinguang/agentic-coder/pkg/provider/codexcli"
+	"github.com/xinguang/agentic-coder/pkg/provider/deepseek"
+	"github.com/xinguang/agentic-coder/pkg/provider/gemini"
+	"github.com/xinguang/agentic-coder/pkg/provider/geminicli"
+	"github.com/xinguang/agentic-coder/pkg/provider/github"
+	"github.com/xinguang/agentic-coder/pkg/provider/ollama"
+	"github.com/xinguang/agentic-coder/pkg/provider/openai"
+	"github.com/xinguang/agentic-coder/pkg/trading"
+	"github.com/xinguang/agentic-coder/pkg/trading/analysis"
+	tradingconfig "github.com/xinguang/agentic-coder/pkg/trading/config"
+	"github.com/xinguang/agentic-coder/pkg/trading/engine"
+	"github.com/xinguang/agentic-coder/pkg/trading/storage"
+)
+
+// analysisTimeout bounds a single AI analysis request
+const analysisTimeout = 2 * time.Minute
+
+// briefHistory is the number of price points per symbol included in a brief
+const briefHistory = 200
+
+var analysisModel string
+
+func newBriefCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "brief",
+		Short: "Generate an AI market brief from stored signals and prices",
+		Long: `brief summarizes the last 24 hours of stored signals and prices with the
+configured agent model (analysis.model in the config file or --model).`,
+		RunE: runBrief,
+	}
+}
+
+func runBrief(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if cfg.Analysis == nil {
+		return fmt.Errorf("no analysis model configured (set analysis.model or --model)")
+	}
+
+	analyst, err := newAnalyst(cfg.Analysis.Model)
+	if err != nil {
+		return err
+	}
+
+	store, err := openStorage(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer store.Close()
+
+	input, err := buildBriefInput(store, cfg.Symbols, nil, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), analysisTimeout)
+	defer cancel()
+
+	brief, err := analyst.DailyBrief(ctx, input)
+	if err != nil {
+		return fmt.Errorf("generate brief: %w", err)
+	}
+
+	fmt.Println()
+	fmt.Println(brief)
+	return nil
+}
+
+// newAnalyst creates an analyst backed by the agent provider for the model
+func newAnalyst(model string) (*analysis.Analyst, error) {
+	p, err := newAIProvider(model)
+	if err != nil {
+		return nil, err
+	}
+	return analysis.NewAnalyst(p, model), nil
+}
+
+// newAIProvider creates the agent AI provider for a model name
+func newAIProvider(model string) (provider.AIProvider, error) {
+	authMgr := auth.NewManager("")
+
+	// apiKey prefers saved credentials and falls back to the environment
+	apiKey := func(p auth.Provider, envVar string) (string, error) {
+		if creds, err := authMgr.GetCredentials(p); err == nil && creds.APIKey != "" {
+			return creds.APIKey, nil
+		}
+		if key := os.Getenv(envVar); key != "" {
+			return key, nil
+		}
+		return "", fmt.Errorf("no API key for %s model %s: set %s", p, model, envVar)
+	}
+
+	switch provider.DetectProviderFromModel(model) {
+	case provider.ProviderTypeClaude:
+		key, err := apiKey(auth.ProviderClaude, "ANTHROPIC_API_KEY")
+		if err != nil {
+			return nil, err
+		}
+		return claude.New(key), nil
+
+	case provider.ProviderTypeOpenAI:
+		key, err := apiKey(auth.ProviderOpenAI, "OPENAI_API_KEY")
+		if err != nil {
+			return nil, err
+		}
+		return openai.New(key), nil
+
+	case provider.ProviderTypeGemini:
+		key, err := apiKey(auth.ProviderGemini, "GOOGLE_API_KEY")
+		if err != nil {
+			return nil, err
+		}
+		return gemini.New(key), nil
+
+	case provider.ProviderTypeGitHub:
+		key, err := apiKey(auth.ProviderGitHub, "GITHUB_TOKEN")
+		if err != nil {
+			return nil, err
+		}
+		return github.New(key), nil
+
+	case provider.ProviderTypeAzureOpenAI:
+		return azureopenai.FromEnv()
+
+	case provider.ProviderTypeDeepSeek:
+		key := os.Getenv("DEEPSEEK_API_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("no API key for DeepSeek model %s: set DEEPSEEK_API_KEY", model)
+		}
+		return deepseek.New(key), nil
+
+	case provider.ProviderTypeOllama:
+		baseURL := os.Getenv("OLLAMA_HOST")
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+		return ollama.New(ollama.WithBaseURL(baseURL)), nil
+
+	case provider.ProviderTypeClaudeCLI:
+		return claudecli.New(claudecli.WithModel("sonnet")), nil
+
+	case provider.ProviderTypeCodexCLI:
+		return codexcli.New(codexcli.WithModel("o3-mini")), nil
+
+	case provider.ProviderTypeGeminiCLI:
+		return geminicli.New(), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported analysis model: %s", model)
+	}
+}
+
+// buildBriefInput collects the last 24 hours of signals and recent prices
+func buildBriefInput(store storage.Storage, symbols []string, positions map[string]*trading.Position, now time.Time) (*analysis.BriefInput, error) {
+	signals, err := store.GetSignalsByTimeRange(now.Add(-24*time.Hour), now)
+	if err != nil {
+		return nil, fmt.Errorf("load signals: %w", err)
+	}
+
+	market := make(map[string][]*trading.StockData, len(symbols))
+	for _, symbol := range symbols {
+		data, err := store.GetStockData(symbol, briefHistory)
+		if err != nil {
+			return nil, fmt.Errorf("load prices for %s: %w", symbol, err)
+		}
+		market[symbol] = data
+	}
+
+	return &analysis.BriefInput{
+		Date:      now,
+		Signals:   signals,
+		Positions: positions,
+		Market:    market,
+	}, nil
+}
+
+// runAnalysis explains new signals and writes the daily brief until ctx is done
+func runAnalysis(ctx context.Context, cfg *tradingconfig.AnalysisConfig, analyst *analysis.Analyst, eng *engine.Engine, store storage.Storage) {
+	signals, cancel := eng.Subscribe()
+	defer cancel()
+
+	var briefTimer <-chan time.Time
+	schedule := func() {
+		if next := cfg.NextBrief(time.Now()); !next.IsZero() {
+			briefTimer = time.After(time.Until(next))
+		}
+	}
+	schedule()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case sig, ok := <-signals:
+			if !ok {
+				return
+			}
+			if !cfg.ExplainSignals || sig.Confidence < cfg.MinConfidence {
+				continue
+			}
+			history, _ := eng.GetStockData(sig.Symbol, 50)
+			reqCtx, reqCancel := context.WithTimeout(ctx, analysisTimeout)
+			explanation, err := analyst.ExplainSignal(reqCtx, sig, history)
+			reqCancel()
+			if err != nil {
+				fmt.Printf("Signal analysis failed: %v\n", err)
+				continue
+			}
+			fmt.Printf("\n🧠 Analysis: %s %s\n%s\n", sig.Type, sig.Symbol, explanation)
+
+		case <-briefTimer:
+			positions := eng.GetPositions()
+			if rm := eng.RiskManager(); rm != nil {
+				positions = rm.Positions()
+			}
+			input, err := buildBriefInput(store, eng.Symbols(), positions, time.Now())
+			if err == nil {
+				reqCtx, reqCancel := context.WithTimeout(ctx, analysisTimeout)
+				var brief string
+				brief, err = analyst.DailyBrief(reqCtx, input)
+				reqCancel()
+				if err == nil {
+					fmt.Printf("\n📰 Daily market brief\n%s\n", brief)
+				}
+			}
+			if err != nil {
+				fmt.Printf("Daily brief failed: %v\n", err)
+			}
+			schedule()
+		}
+	}
+}
@@ -1,63 +1,177 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/spf13/cobra"
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/trading/broker"
+	tradingconfig "github.com/xinguang/agentic-coder/pkg/trading/config"
 	"github.com/xinguang/agentic-coder/pkg/trading/engine"
+	"github.com/xinguang/agentic-coder/pkg/trading/notify"
 	"github.com/xinguang/agentic-coder/pkg/trading/provider"
-	"github.com/xinguang/agentic-coder/pkg/trading/strategy"
+	"github.com/xinguang/agentic-coder/pkg/trading/server"
+	"github.com/xinguang/agentic-coder/pkg/trading/storage"
+)
+
+var (
+	configPath string
+	symbols    []string
+	interval   time.Duration
+	dataSource string
+	executorID string
+	dbPath     string
+	httpAddr   string
+	noRisk     bool
 )
 
 func main() {
+	rootCmd := &cobra.Command{
+		Use:   "trading",
+		Short: "Daily stock trading system",
+		Long: `trading monitors stock prices, runs trading strategies and turns their
+signals into risk-checked orders.
+
+Settings are read from a YAML config file (--config) and can be overridden
+with flags. Strategy parameters in the config file are reloaded while running.`,
+		RunE: run,
+	}
+
+	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "YAML config file")
+	rootCmd.PersistentFlags().StringSliceVarP(&symbols, "symbols", "s", nil, "Symbols to monitor (comma-separated)")
+	rootCmd.PersistentFlags().DurationVarP(&interval, "interval", "i", 0, "Data update interval (e.g. 10s, 1m)")
+	rootCmd.PersistentFlags().StringVar(&dataSource, "provider", "", "Data provider: mock, sina, tencent")
+	rootCmd.PersistentFlags().StringVar(&executorID, "executor", "", "Order executor: dry-run, alpaca")
+	rootCmd.PersistentFlags().StringVar(&dbPath, "db", "", "SQLite database path (default ~/.agentic-coder/trading.db)")
+	rootCmd.PersistentFlags().StringVar(&httpAddr, "http", "", "Serve the web dashboard and JSON API on this address (e.g. 127.0.0.1:8080)")
+	rootCmd.PersistentFlags().BoolVar(&noRisk, "no-risk", false, "Disable risk management (signals only, no orders)")
+	rootCmd.PersistentFlags().StringVar(&analysisModel, "model", "", "Agent model for AI signal explanations and market briefs (e.g. sonnet, gpt-4o)")
+
+	rootCmd.AddCommand(newBriefCmd())
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("=================================================")
 	fmt.Println("       Daily Stock Trading System")
 	fmt.Println("=================================================")
 	fmt.Println()
 
-	// Configuration
-	symbols := []string{"AAPL", "GOOGL", "MSFT", "TSLA", "AMZN"}
-	updateInterval := 10 * time.Second // fetch data every 10 seconds
-
-	fmt.Printf("Monitoring stocks: %v\n", symbols)
-	fmt.Printf("Update interval: %v\n", updateInterval)
+	fmt.Printf("Monitoring stocks: %v\n", cfg.Symbols)
+	fmt.Printf("Update interval: %v\n", cfg.UpdateInterval)
+	fmt.Printf("Data provider: %s\n", cfg.Provider)
 	fmt.Println()
 
-	// Create data provider (using mock provider for demonstration)
-	dataProvider := provider.NewMockProvider()
+	dataProvider, err := provider.New(cfg.Provider)
+	if err != nil {
+		return err
+	}
 
-	// Create trading strategies
-	strategies := []engine.Strategy{
-		strategy.NewMACrossStrategy(5, 20),   // 5-day and 20-day MA crossover
-		strategy.NewMACrossStrategy(10, 50),  // 10-day and 50-day MA crossover
+	strategies, err := cfg.BuildStrategies()
+	if err != nil {
+		return err
 	}
+	printStrategies(strategies)
 
-	fmt.Println("Active strategies:")
-	for i, s := range strategies {
-		fmt.Printf("  %d. %s\n", i+1, s.Name())
+	// Open persistent storage for signals, orders and fills
+	store, err := openStorage(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
 	}
-	fmt.Println()
+	defer store.Close()
 
 	// Create engine
-	config := &engine.Config{
-		Symbols:        symbols,
-		UpdateInterval: updateInterval,
+	engineConfig := &engine.Config{
+		Symbols:        cfg.Symbols,
+		UpdateInterval: cfg.UpdateInterval,
+		Risk:           cfg.Risk,
+		Storage:        store,
 	}
 
-	eng := engine.NewEngine(config, dataProvider, strategies)
+	eng := engine.NewEngine(engineConfig, dataProvider, strategies)
+
+	if cfg.Risk != nil {
+		executor, err := newExecutor(cfg.Executor)
+		if err != nil {
+			return err
+		}
+		eng.SetExecutor(executor)
+		fmt.Printf("Order execution: %s\n", executor.Name())
+	} else {
+		fmt.Println("Risk management disabled: signals will not be executed")
+	}
+	fmt.Println()
 
 	// Start engine
 	if err := eng.Start(); err != nil {
-		fmt.Printf("Error starting engine: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("start engine: %w", err)
 	}
 
-	// Example: Set initial positions
-	// eng.UpdatePosition("AAPL", 100, 150.0)
-	// eng.UpdatePosition("GOOGL", 50, 140.0)
+	// Serve the dashboard
+	var dashboard *server.Server
+	if cfg.HTTPAddr != "" {
+		dashboard = server.New(cfg.HTTPAddr, eng)
+		if err := dashboard.Start(); err != nil {
+			eng.Stop()
+			return fmt.Errorf("start dashboard: %w", err)
+		}
+		fmt.Printf("Dashboard: http://%s\n", displayAddr(cfg.HTTPAddr))
+	}
+
+	// Hot-reload strategy parameters from the config file
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if configPath != "" {
+		go tradingconfig.Watch(ctx, configPath, 2*time.Second, func(updated *tradingconfig.Config) {
+			reloaded, err := updated.BuildStrategies()
+			if err != nil {
+				fmt.Printf("Config reload skipped: %v\n", err)
+				return
+			}
+			eng.SetStrategies(reloaded)
+			fmt.Println("\nStrategies reloaded from config")
+			printStrategies(reloaded)
+		})
+	}
+
+	// AI signal explanations and daily market brief
+	if cfg.Analysis != nil {
+		analyst, err := newAnalyst(cfg.Analysis.Model)
+		if err != nil {
+			fmt.Printf("AI analysis disabled: %v\n", err)
+		} else {
+			go runAnalysis(ctx, cfg.Analysis, analyst, eng, store)
+			fmt.Printf("AI analysis: %s\n", cfg.Analysis.Model)
+		}
+	}
+
+	// Push high-confidence signals to alert sinks
+	if cfg.Alerts != nil {
+		notifiers, err := cfg.Alerts.BuildNotifiers()
+		if err != nil {
+			fmt.Printf("Alerts disabled: %v\n", err)
+		} else if len(notifiers) > 0 {
+			dispatcher := notify.NewDispatcher(cfg.Alerts, notifiers...)
+			go runAlerts(ctx, dispatcher, eng)
+			fmt.Printf("Alerts: %s\n", notifierNames(notifiers))
+		}
+	}
 
 	fmt.Println("System running... Press Ctrl+C to stop")
 	fmt.Println()
@@ -69,6 +183,12 @@ func main() {
 
 	fmt.Println("\nShutting down...")
 
+	if dashboard != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		dashboard.Stop(shutdownCtx)
+		shutdownCancel()
+	}
+
 	// Stop engine
 	if err := eng.Stop(); err != nil {
 		fmt.Printf("Error stopping engine: %v\n", err)
@@ -88,4 +208,129 @@ func main() {
 	}
 
 	fmt.Println("\nSystem stopped gracefully")
+	return nil
+}
+
+// loadConfig loads the config file (or defaults) and applies flag overrides
+func loadConfig(cmd *cobra.Command) (*tradingconfig.Config, error) {
+	cfg := tradingconfig.DefaultConfig()
+	if configPath != "" {
+		loaded, err := tradingconfig.Load(configPath)
+		if err != nil {
+			return nil, err
+		}
+		cfg = loaded
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("symbols") {
+		cfg.Symbols = make([]string, 0, len(symbols))
+		for _, s := range symbols {
+			cfg.Symbols = append(cfg.Symbols, strings.ToUpper(strings.TrimSpace(s)))
+		}
+	}
+	if flags.Changed("interval") {
+		cfg.UpdateInterval = interval
+	}
+	if flags.Changed("provider") {
+		cfg.Provider = dataSource
+	}
+	if flags.Changed("executor") {
+		cfg.Executor = executorID
+	}
+	if flags.Changed("db") {
+		cfg.Database = dbPath
+	}
+	if flags.Changed("http") {
+		cfg.HTTPAddr = httpAddr
+	}
+	if noRisk {
+		cfg.Risk = nil
+	}
+	if flags.Changed("model") {
+		if cfg.Analysis == nil {
+			cfg.Analysis = &tradingconfig.AnalysisConfig{ExplainSignals: true, MinConfidence: 0.5}
+		}
+		cfg.Analysis.Model = analysisModel
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// newExecutor creates the order executor by name
+func newExecutor(name string) (broker.OrderExecutor, error) {
+	switch name {
+	case "alpaca":
+		return broker.NewAlpacaExecutorFromEnv()
+	default:
+		return broker.NewSimulator(0.001), nil
+	}
+}
+
+// printStrategies lists the active strategies
+func printStrategies(strategies []engine.Strategy) {
+	fmt.Println("Active strategies:")
+	for i, s := range strategies {
+		fmt.Printf("  %d. %s\n", i+1, s.Name())
+	}
+	fmt.Println()
+}
+
+// openStorage opens the SQLite database, defaulting to the application data directory
+func openStorage(path string) (*storage.SQLiteStorage, error) {
+	if path == "" {
+		appDir, err := config.GetAppDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(appDir, "trading.db")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Storage: %s\n", path)
+	return storage.NewSQLiteStorage(path)
+}
+
+// runAlerts forwards engine signals to the alert dispatcher until ctx is done
+func runAlerts(ctx context.Context, dispatcher *notify.Dispatcher, eng *engine.Engine) {
+	signals, cancel := eng.Subscribe()
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig, ok := <-signals:
+			if !ok {
+				return
+			}
+			sendCtx, sendCancel := context.WithTimeout(ctx, 30*time.Second)
+			if _, err := dispatcher.Dispatch(sendCtx, sig); err != nil {
+				fmt.Printf("Alert delivery failed: %v\n", err)
+			}
+			sendCancel()
+		}
+	}
+}
+
+// notifierNames lists the alert sink names
+func notifierNames(notifiers []notify.Notifier) string {
+	names := make([]string, 0, len(notifiers))
+	for _, n := range notifiers {
+		names = append(names, n.Name())
+	}
+	return strings.Join(names, ", ")
+}
+
+// displayAddr turns a listen address like ":8080" into a browsable host:port
+func displayAddr(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
 }
@@ -0,0 +1,130 @@
+// Package agentignore reads a project's .agentignore file, which hides paths
+// such as secrets directories, vendored trees and generated code from the
+// agent's file discovery
+package agentignore
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// FileName is the ignore file at the project root. It uses .gitignore syntax.
+const FileName = ".agentignore"
+
+// rule is one pattern from the ignore file
+type rule struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool // matched against the path relative to the root, not just the name
+}
+
+// Matcher matches paths against a project's .agentignore. A nil Matcher
+// ignores nothing.
+type Matcher struct {
+	root  string
+	rules []rule
+}
+
+// Load reads the .agentignore at root. It returns nil when there is none.
+func Load(root string) *Matcher {
+	if root == "" {
+		return nil
+	}
+	f, err := os.Open(filepath.Join(root, FileName))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	m := &Matcher{root: root}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if r, ok := parseRule(scanner.Text()); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+	if len(m.rules) == 0 {
+		return nil
+	}
+	return m
+}
+
+func parseRule(line string) (rule, bool) {
+	line = strings.TrimRight(line, " \r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+	var r rule
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+	r.pattern = line
+	return r, true
+}
+
+// Ignored reports whether path, absolute or relative to the root, is
+// ignored itself or lies in an ignored directory. Paths outside the root
+// are never ignored.
+func (m *Matcher) Ignored(p string) bool {
+	if m == nil {
+		return false
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(m.root, p)
+	}
+	rel, err := filepath.Rel(m.root, p)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	// Directories above the path are matched first, as git does
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.Match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	info, err := os.Stat(p)
+	return m.Match(rel, err == nil && info.IsDir())
+}
+
+// Match reports whether the slash-separated path rel, relative to the root,
+// matches the rules; the last matching rule wins
+func (m *Matcher) Match(rel string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		target := path.Base(rel)
+		if r.anchored {
+			target = rel
+		}
+		if ok, _ := doublestar.Match(r.pattern, target); ok {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,43 @@
+package agentignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, FileName), []byte("# secrets\nsecrets/\n/vendor\n*.pb.go\n!keep.pb.go\n"), 0644)
+	os.MkdirAll(filepath.Join(root, "secrets"), 0755)
+	os.MkdirAll(filepath.Join(root, "src", "vendor"), 0755)
+
+	m := Load(root)
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"secrets", true},
+		{"secrets/prod.env", true},
+		{filepath.Join(root, "secrets", "prod.env"), true},
+		{"vendor/lib/lib.go", true},
+		{"src/vendor/lib.go", false},
+		{"api/service.pb.go", true},
+		{"api/keep.pb.go", false},
+		{"main.go", false},
+		{FileName, false},
+		{"/elsewhere/secrets/key", false},
+	}
+	for _, tt := range tests {
+		if got := m.Ignored(tt.path); got != tt.want {
+			t.Errorf("Ignored(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if Load(t.TempDir()) != nil {
+		t.Error("expected no matcher without an ignore file")
+	}
+	if (*Matcher)(nil).Ignored("secrets/prod.env") {
+		t.Error("a nil matcher ignores nothing")
+	}
+}
@@ -62,6 +62,7 @@ const (
 	ProviderClaude  Provider = "claude"
 	ProviderGemini  Provider = "gemini"
 	ProviderOpenAI  Provider = "openai"
+	ProviderGitHub  Provider = "github"
 )
 
 // Credentials holds authentication credentials
@@ -122,6 +123,7 @@ func NewManager(configDir string) *Manager {
 	m.handlers[ProviderClaude] = NewClaudeAuthHandler()
 	m.handlers[ProviderGemini] = NewGeminiAuthHandler()
 	m.handlers[ProviderOpenAI] = NewOpenAIAuthHandler()
+	m.handlers[ProviderGitHub] = NewGitHubAuthHandler()
 
 	// Load saved credentials
 	m.loadCredentials()
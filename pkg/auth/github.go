@@ -0,0 +1,107 @@
+// Package auth provides authentication for AI providers
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	githubModelsCatalogURL = "https://models.github.ai/catalog/models"
+)
+
+// GitHubAuthHandler handles GitHub authentication for GitHub Models
+type GitHubAuthHandler struct {
+	httpClient *http.Client
+}
+
+// NewGitHubAuthHandler creates a new GitHub auth handler
+func NewGitHubAuthHandler() *GitHubAuthHandler {
+	return &GitHubAuthHandler{
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Authenticate reuses the GitHub CLI's token when available, otherwise
+// prompts for a personal access token
+func (h *GitHubAuthHandler) Authenticate(ctx context.Context) (*Credentials, error) {
+	fmt.Println("\n🔐 GitHub Models Authentication")
+	fmt.Println("==============================")
+
+	token := GitHubCLIToken(ctx)
+	if token != "" {
+		fmt.Println("\nUsing the token of the GitHub CLI (gh auth token)")
+	} else {
+		fmt.Println("\nCreate a token with the models:read permission at: https://github.com/settings/personal-access-tokens")
+		fmt.Print("Enter your GitHub token: ")
+		token = readLine()
+	}
+
+	if token == "" {
+		return nil, fmt.Errorf("GitHub token is required")
+	}
+
+	creds := &Credentials{
+		Provider: ProviderGitHub,
+		AuthType: AuthTypeAPIKey,
+		APIKey:   token,
+	}
+
+	if err := h.Validate(ctx, creds); err != nil {
+		return nil, fmt.Errorf("invalid GitHub token: %w", err)
+	}
+
+	fmt.Println("✅ Authentication successful!")
+	return creds, nil
+}
+
+// Refresh is not applicable for tokens
+func (h *GitHubAuthHandler) Refresh(ctx context.Context, creds *Credentials) (*Credentials, error) {
+	return nil, fmt.Errorf("GitHub tokens cannot be refreshed")
+}
+
+// Validate checks that the token can list GitHub Models
+func (h *GitHubAuthHandler) Validate(ctx context.Context, creds *Credentials) error {
+	if creds.APIKey == "" {
+		return fmt.Errorf("token is empty")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", githubModelsCatalogURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+creds.APIKey)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("invalid credentials or no access to GitHub Models (status %d)", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// GitHubCLIToken returns the token the GitHub CLI is logged in with, or ""
+func GitHubCLIToken(ctx context.Context) string {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "gh", "auth", "token").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
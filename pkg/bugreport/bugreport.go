@@ -0,0 +1,242 @@
+// Package bugreport bundles a session transcript, the configuration, and
+// recent logs into a report for filing issues, with credentials removed
+package bugreport
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/session"
+)
+
+// defaultLogLines is the number of lines kept from the end of each log
+const defaultLogLines = 200
+
+// providerEnv are the variables that select or authenticate providers.
+// Reports say which are set, never their values.
+var providerEnv = []string{
+	"ANTHROPIC_API_KEY", "ANTHROPIC_BASE_URL",
+	"OPENAI_API_KEY", "OPENAI_BASE_URL",
+	"GOOGLE_API_KEY", "GEMINI_API_KEY",
+	"DEEPSEEK_API_KEY", "GITHUB_TOKEN", "OLLAMA_HOST",
+	"HTTPS_PROXY", "HTTP_PROXY",
+}
+
+// Options selects what a report contains
+type Options struct {
+	Version     string
+	ProjectPath string
+	AppDir      string         // defaults to ~/.agentic-coder
+	SessionID   string         // a session ID or prefix; defaults to the latest session
+	Config      *config.Config // the merged configuration, if loaded
+	LogFiles    []string       // logs whose last lines are included
+	LogLines    int            // lines kept per log, default 200
+}
+
+// Info describes the environment and session of a report
+type Info struct {
+	Version   string    `json:"version"`
+	GoVersion string    `json:"go_version"`
+	OS        string    `json:"os"`
+	Arch      string    `json:"arch"`
+	Created   time.Time `json:"created"`
+	SessionID string    `json:"session_id,omitempty"`
+	Provider  string    `json:"provider,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Messages  int       `json:"messages,omitempty"`
+	EnvSet    []string  `json:"env_set,omitempty"` // provider variables that are set
+}
+
+// File is a file of a report
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Report is a redacted bug report
+type Report struct {
+	Info  Info
+	Files []File
+}
+
+// Collect gathers a report. A missing session or log is left out rather
+// than failing the report, since bugs can happen before either exists.
+func Collect(opts *Options) (*Report, error) {
+	r := &Report{Info: Info{
+		Version:   opts.Version,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Created:   time.Now(),
+	}}
+	for _, name := range providerEnv {
+		if os.Getenv(name) != "" {
+			r.Info.EnvSet = append(r.Info.EnvSet, name)
+		}
+	}
+
+	if err := r.addSession(opts); err != nil {
+		return nil, err
+	}
+
+	if opts.Config != nil {
+		data, err := json.Marshal(opts.Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode config: %w", err)
+		}
+		r.add("config.json", RedactJSON(data))
+	}
+
+	lines := opts.LogLines
+	if lines <= 0 {
+		lines = defaultLogLines
+	}
+	for _, path := range opts.LogFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		r.add("log-"+filepath.Base(path), []byte(RedactText(tail(string(data), lines))))
+	}
+
+	info, err := json.MarshalIndent(r.Info, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	r.Files = append([]File{{Name: "README.md", Data: []byte(r.summary())}, {Name: "info.json", Data: info}}, r.Files...)
+	return r, nil
+}
+
+// addSession adds the redacted transcript of the selected session
+func (r *Report) addSession(opts *Options) error {
+	mgr, err := session.NewSessionManager(&session.ManagerOptions{ProjectPath: opts.ProjectPath, AppDir: opts.AppDir})
+	if err != nil {
+		return err
+	}
+	sessions, err := mgr.ListSessions()
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUpdated.After(sessions[j].LastUpdated)
+	})
+
+	var meta *session.SessionInfo
+	for _, s := range sessions {
+		if opts.SessionID == "" || strings.HasPrefix(s.ID, opts.SessionID) {
+			meta = s
+			break
+		}
+	}
+	if meta == nil {
+		if opts.SessionID != "" {
+			return fmt.Errorf("session not found: %s", opts.SessionID)
+		}
+		return nil
+	}
+
+	sess, err := mgr.GetSession(meta.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+	var transcript bytes.Buffer
+	encoder := json.NewEncoder(&transcript)
+	for _, entry := range sess.Messages {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("failed to encode session: %w", err)
+		}
+	}
+
+	r.Info.SessionID = meta.ID
+	r.Info.Provider = meta.Provider
+	r.Info.Model = meta.Model
+	r.Info.Messages = meta.MessageCount
+	r.add("session.jsonl", RedactJSONLines(transcript.Bytes()))
+	return nil
+}
+
+// add appends a file to the report
+func (r *Report) add(name string, data []byte) {
+	r.Files = append(r.Files, File{Name: name, Data: data})
+}
+
+// summary is the README of a report
+func (r *Report) summary() string {
+	var b strings.Builder
+	b.WriteString("# agentic-coder bug report\n\n")
+	fmt.Fprintf(&b, "- Version: %s (%s, %s/%s)\n", r.Info.Version, r.Info.GoVersion, r.Info.OS, r.Info.Arch)
+	if r.Info.SessionID != "" {
+		fmt.Fprintf(&b, "- Session: %s, %d messages\n", r.Info.SessionID, r.Info.Messages)
+		fmt.Fprintf(&b, "- Provider: %s, model %s\n", r.Info.Provider, r.Info.Model)
+	} else {
+		b.WriteString("- Session: none found for the project\n")
+	}
+	if len(r.Info.EnvSet) > 0 {
+		fmt.Fprintf(&b, "- Set: %s\n", strings.Join(r.Info.EnvSet, ", "))
+	}
+	b.WriteString("\nFiles:\n")
+	for _, f := range r.Files {
+		fmt.Fprintf(&b, "- %s (%d bytes)\n", f.Name, len(f.Data))
+	}
+	b.WriteString("\nAPI keys, tokens, and the home directory were redacted. Review the files before sharing them.\n")
+	return b.String()
+}
+
+// WriteArchive writes the report as a gzipped tarball with its files
+// under a single directory
+func (r *Report) WriteArchive(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	dir := "agentic-coder-bug-report-" + r.Info.Created.Format("20060102-150405")
+	for _, f := range r.Files {
+		header := &tar.Header{
+			Name:    dir + "/" + f.Name,
+			Mode:    0o600,
+			Size:    int64(len(f.Data)),
+			ModTime: r.Info.Created,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.Data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// WriteDir writes the report's files into dir
+func (r *Report) WriteDir(dir string) ([]string, error) {
+	paths := make([]string, 0, len(r.Files))
+	for _, f := range r.Files {
+		path := filepath.Join(dir, f.Name)
+		if err := os.WriteFile(path, f.Data, 0o600); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// tail returns the last n lines of s
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,125 @@
+package bugreport
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces every secret removed from a report
+const redacted = "[REDACTED]"
+
+// sensitiveKey matches JSON keys whose values are always removed: API keys,
+// tokens, passwords, and the environment and headers given to MCP servers
+// and hooks, which usually carry credentials
+var sensitiveKey = regexp.MustCompile(`(?i)(^|_)(api_?keys?|token|secret|password|authorization|credentials?|env|headers)$`)
+
+// secretPatterns match credentials inside free text, such as tool inputs
+// and outputs. Each match is replaced by its first group followed by
+// [REDACTED].
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?s)(-----BEGIN [A-Z ]*PRIVATE KEY-----).*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`()\bsk-[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`()\b(?:gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})`),
+	regexp.MustCompile(`()\bAIza[0-9A-Za-z_-]{30,}`),
+	regexp.MustCompile(`()\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`()\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9._~+/=-]{8,}`),
+	regexp.MustCompile(`(?i)(\b[A-Z0-9_]*(?:API_?KEY|TOKEN|SECRET|PASSWORD)[A-Z0-9_]*["']?\s*[=:]\s*["']?)[^\s"',;]{6,}`),
+}
+
+// RedactText removes credentials from text and replaces the home directory
+// with ~, so paths do not reveal the user name
+func RedactText(s string) string {
+	for _, p := range secretPatterns {
+		s = p.ReplaceAllString(s, "${1}"+redacted)
+	}
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 1 {
+		s = strings.ReplaceAll(s, home, "~")
+	}
+	return s
+}
+
+// RedactJSON removes credentials from a JSON document. Values under
+// sensitive keys are replaced whole; other strings go through RedactText.
+// Data that is not JSON is redacted as text.
+func RedactJSON(data []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return []byte(RedactText(string(data)))
+	}
+	out, err := json.MarshalIndent(redactValue(v), "", "  ")
+	if err != nil {
+		return []byte(RedactText(string(data)))
+	}
+	return out
+}
+
+// RedactJSONLines redacts each line of a JSONL file, keeping one
+// document per line
+func RedactJSONLines(data []byte) []byte {
+	var out bytes.Buffer
+	for _, line := range bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var compact bytes.Buffer
+		if json.Compact(&compact, RedactJSON(line)) == nil {
+			out.Write(compact.Bytes())
+		} else {
+			out.WriteString(RedactText(string(line)))
+		}
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// redactValue walks a decoded JSON value
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if sensitiveKey.MatchString(key) {
+				v[key] = redactAll(value)
+			} else {
+				v[key] = redactValue(value)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactValue(value)
+		}
+		return v
+	case string:
+		return RedactText(v)
+	}
+	return v
+}
+
+// redactAll replaces every string under a sensitive key, keeping the shape
+// (which variables or headers were set) visible
+func redactAll(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = redactAll(value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactAll(value)
+		}
+		return v
+	case string:
+		if v == "" {
+			return v
+		}
+		return redacted
+	}
+	return v
+}
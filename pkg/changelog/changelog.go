@@ -0,0 +1,320 @@
+// Package changelog groups conventional commits and drafts changelog
+// sections from them
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// Commit is a commit parsed as a conventional commit. Commits that do not
+// follow the convention have the type "other" and their whole subject.
+type Commit struct {
+	Hash     string
+	Type     string
+	Scope    string
+	Subject  string
+	Body     string
+	Breaking bool
+}
+
+// Group is the commits of one kind under their changelog heading
+type Group struct {
+	Title   string
+	Commits []Commit
+}
+
+// groupTitles maps commit types to changelog headings, in changelog order
+var groupTitles = []struct {
+	types []string
+	title string
+}{
+	{[]string{"feat"}, "Features"},
+	{[]string{"fix"}, "Bug Fixes"},
+	{[]string{"perf"}, "Performance"},
+	{[]string{"refactor"}, "Refactoring"},
+	{[]string{"docs"}, "Documentation"},
+	{[]string{"test"}, "Tests"},
+	{[]string{"build", "ci"}, "Build"},
+	{[]string{"chore", "style", "revert"}, "Chores"},
+	{[]string{"other"}, "Other Changes"},
+}
+
+// conventionalSubject matches "type(scope)!: subject"
+var conventionalSubject = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// ParseCommit parses a commit message as a conventional commit
+func ParseCommit(hash, message string) Commit {
+	subject, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	c := Commit{Hash: hash, Type: "other", Subject: strings.TrimSpace(subject), Body: strings.TrimSpace(body)}
+
+	if m := conventionalSubject.FindStringSubmatch(c.Subject); m != nil && knownType(strings.ToLower(m[1])) {
+		c.Type = strings.ToLower(m[1])
+		c.Scope = m[2]
+		c.Breaking = m[3] == "!"
+		c.Subject = m[4]
+	}
+	if strings.Contains(c.Body, "BREAKING CHANGE:") || strings.Contains(c.Body, "BREAKING-CHANGE:") {
+		c.Breaking = true
+	}
+	return c
+}
+
+// knownType reports whether a conventional commit type has a heading
+func knownType(t string) bool {
+	for _, g := range groupTitles {
+		for _, name := range g.types {
+			if name == t && name != "other" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GroupCommits sorts commits under their headings. Breaking changes are
+// also listed first under their own heading; empty groups are left out.
+func GroupCommits(commits []Commit) []Group {
+	var groups []Group
+	var breaking []Commit
+	for _, c := range commits {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+	if len(breaking) > 0 {
+		groups = append(groups, Group{Title: "Breaking Changes", Commits: breaking})
+	}
+
+	for _, g := range groupTitles {
+		group := Group{Title: g.title}
+		for _, c := range commits {
+			for _, t := range g.types {
+				if c.Type == t {
+					group.Commits = append(group.Commits, c)
+				}
+			}
+		}
+		if len(group.Commits) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// Heading returns the heading of a changelog section for a version
+func Heading(version string, date time.Time) string {
+	if version == "" {
+		version = "Unreleased"
+	}
+	return fmt.Sprintf("## %s - %s", version, date.Format("2006-01-02"))
+}
+
+// Render writes a changelog section listing each commit under its heading
+func Render(version string, date time.Time, groups []Group) string {
+	var b strings.Builder
+	b.WriteString(Heading(version, date) + "\n")
+	for _, g := range groups {
+		fmt.Fprintf(&b, "\n### %s\n\n", g.Title)
+		for _, c := range g.Commits {
+			b.WriteString("- " + c.line() + "\n")
+		}
+	}
+	return b.String()
+}
+
+// line formats a commit as a changelog entry
+func (c Commit) line() string {
+	text := c.Subject
+	if c.Scope != "" {
+		text = "**" + c.Scope + ":** " + text
+	}
+	if c.Hash != "" {
+		text += " (" + shortHash(c.Hash) + ")"
+	}
+	return text
+}
+
+// draftPrompt instructs the model drafting a changelog section
+const draftPrompt = `You write release notes for a software project. From the commits
+below, grouped by type, write one changelog section in Markdown.
+
+Start with the heading given. Keep the groups as ### headings in the order
+given, and leave out groups with nothing a user would notice. Write each
+entry as one short line in the past tense describing the change for users,
+not the code. Merge commits that make the same change, drop pure noise such
+as formatting or merge commits, and keep the short hashes. Never describe a
+change the commits do not show.
+
+Reply with the Markdown section only.`
+
+// Draft asks the model to write a changelog section from grouped commits
+func Draft(ctx context.Context, prov provider.AIProvider, model, version string, date time.Time, groups []Group) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Heading: %s\n", Heading(version, date))
+	for _, g := range groups {
+		fmt.Fprintf(&b, "\n%s:\n", g.Title)
+		for _, c := range g.Commits {
+			fmt.Fprintf(&b, "- %s\n", c.line())
+			if c.Body != "" {
+				fmt.Fprintf(&b, "  %s\n", strings.ReplaceAll(truncate(c.Body, 500), "\n", "\n  "))
+			}
+		}
+	}
+
+	resp, err := prov.CreateMessage(ctx, &provider.Request{
+		Model:     model,
+		System:    []provider.ContentBlock{&provider.TextBlock{Text: draftPrompt}},
+		Messages:  []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentBlock{&provider.TextBlock{Text: b.String()}}}},
+		MaxTokens: 4096,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to draft the changelog: %w", err)
+	}
+
+	var text string
+	for _, block := range resp.Content {
+		if t, ok := block.(*provider.TextBlock); ok {
+			text += t.Text
+		}
+	}
+	text = strings.TrimSpace(stripFence(text))
+	if text == "" {
+		return "", fmt.Errorf("the model returned an empty changelog")
+	}
+	return text + "\n", nil
+}
+
+// stripFence removes a Markdown code fence around the whole reply
+func stripFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") || !strings.HasSuffix(text, "```") {
+		return text
+	}
+	_, inner, ok := strings.Cut(text, "\n")
+	if !ok {
+		return text
+	}
+	return strings.TrimSuffix(inner, "```")
+}
+
+// Prepend adds a section to the top of a changelog file, below its title,
+// creating the file when it does not exist
+func Prepend(path, section string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	section = strings.TrimRight(section, "\n") + "\n"
+
+	existing := string(data)
+	var out string
+	switch {
+	case strings.TrimSpace(existing) == "":
+		out = "# Changelog\n\n" + section
+	case strings.HasPrefix(existing, "# "):
+		title, rest, _ := strings.Cut(existing, "\n")
+		out = title + "\n\n" + section + "\n" + strings.TrimLeft(rest, "\n")
+	default:
+		out = section + "\n" + existing
+	}
+	return os.WriteFile(path, []byte(out), 0644)
+}
+
+// Section returns the section of a changelog for a version, without its
+// heading, or "" when the changelog has none
+func Section(content, version string) string {
+	var section []string
+	in := false
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "## ") {
+			if in {
+				break
+			}
+			title := strings.TrimPrefix(line, "## ")
+			in = title == version || strings.HasPrefix(title, version+" ") || strings.HasPrefix(title, "["+version+"]")
+			continue
+		}
+		if in {
+			section = append(section, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(section, "\n"))
+}
+
+// Commits returns the commits after since up to HEAD in dir, newest first.
+// An empty since means the whole history.
+func Commits(ctx context.Context, dir, since string) ([]Commit, error) {
+	rangeArg := "HEAD"
+	if since != "" {
+		rangeArg = since + "..HEAD"
+	}
+	// Fields are separated by a unit separator and commits by a record separator
+	out, err := git(ctx, dir, "log", "--no-merges", "--format=%H%x1f%B%x1e", rangeArg)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		hash, message, ok := strings.Cut(strings.TrimSpace(record), "\x1f")
+		if !ok {
+			continue
+		}
+		commits = append(commits, ParseCommit(hash, message))
+	}
+	return commits, nil
+}
+
+// LatestTag returns the most recent tag reachable from HEAD, or "" if there
+// is none
+func LatestTag(ctx context.Context, dir string) string {
+	tag, err := git(ctx, dir, "describe", "--tags", "--abbrev=0")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(tag)
+}
+
+// TagExists reports whether a tag exists in dir
+func TagExists(ctx context.Context, dir, tag string) bool {
+	_, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", "refs/tags/"+tag)
+	return err == nil
+}
+
+// git runs a git command in dir and returns its output
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if exit, ok := err.(*exec.ExitError); ok && len(exit.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exit.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// shortHash shortens a commit hash for display
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// truncate shortens text to n bytes
+func truncate(text string, n int) string {
+	if len(text) > n {
+		return text[:n] + "..."
+	}
+	return text
+}
@@ -0,0 +1,175 @@
+package changelog
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+func TestParseCommit(t *testing.T) {
+	tests := []struct {
+		message  string
+		typ      string
+		scope    string
+		subject  string
+		breaking bool
+	}{
+		{"feat: add /pin", "feat", "", "add /pin", false},
+		{"fix(tui): keep the cursor on resize", "fix", "tui", "keep the cursor on resize", false},
+		{"feat(config)!: drop the legacy keys", "feat", "config", "drop the legacy keys", true},
+		{"refactor: split the loop\n\nBREAKING CHANGE: Engine.Run takes a context", "refactor", "", "split the loop", true},
+		{"Update README", "other", "", "Update README", false},
+		{"wip: not a known type", "other", "", "wip: not a known type", false},
+	}
+	for _, tt := range tests {
+		c := ParseCommit("abc", tt.message)
+		if c.Type != tt.typ || c.Scope != tt.scope || c.Subject != tt.subject || c.Breaking != tt.breaking {
+			t.Errorf("ParseCommit(%q) = %+v", tt.message, c)
+		}
+	}
+}
+
+func TestGroupAndRender(t *testing.T) {
+	commits := []Commit{
+		ParseCommit("1111111aaaa", "fix: handle empty input"),
+		ParseCommit("2222222bbbb", "feat(cli)!: rename --model-name to --model"),
+		ParseCommit("3333333cccc", "docs: explain pins"),
+		ParseCommit("4444444dddd", "feat: add changelog"),
+	}
+	groups := GroupCommits(commits)
+
+	var titles []string
+	for _, g := range groups {
+		titles = append(titles, g.Title)
+	}
+	if got := strings.Join(titles, ","); got != "Breaking Changes,Features,Bug Fixes,Documentation" {
+		t.Errorf("unexpected groups: %s", got)
+	}
+
+	date := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	out := Render("v0.2.0", date, groups)
+	for _, want := range []string{
+		"## v0.2.0 - 2026-10-18\n",
+		"### Features\n\n- **cli:** rename --model-name to --model (2222222)\n- add changelog (4444444)\n",
+		"### Bug Fixes\n\n- handle empty input (1111111)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rendered changelog is missing %q:\n%s", want, out)
+		}
+	}
+}
+
+type draftProvider struct {
+	provider.AIProvider
+	req   *provider.Request
+	reply string
+}
+
+func (p *draftProvider) CreateMessage(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	p.req = req
+	return &provider.Response{Content: []provider.ContentBlock{&provider.TextBlock{Text: p.reply}}}, nil
+}
+
+func TestDraft(t *testing.T) {
+	prov := &draftProvider{reply: "```markdown\n## Unreleased - 2026-10-18\n\n### Features\n\n- Added a changelog command (4444444)\n```"}
+	groups := GroupCommits([]Commit{ParseCommit("4444444dddd", "feat: add changelog\n\nGroups commits by type.")})
+
+	out, err := Draft(context.Background(), prov, "test-model", "", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "## Unreleased - 2026-10-18\n") || strings.Contains(out, "```") {
+		t.Errorf("expected the section without its fence, got:\n%s", out)
+	}
+
+	request := prov.req.Messages[0].Content[0].(*provider.TextBlock).Text
+	for _, want := range []string{"Heading: ## Unreleased - 2026-10-18", "Features:", "add changelog (4444444)", "Groups commits by type."} {
+		if !strings.Contains(request, want) {
+			t.Errorf("request is missing %q:\n%s", want, request)
+		}
+	}
+}
+
+func TestPrepend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+
+	if err := Prepend(path, "## v0.1.0 - 2026-01-01\n\n- first\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Prepend(path, "## v0.2.0 - 2026-02-01\n\n- second"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Changelog\n\n## v0.2.0 - 2026-02-01\n\n- second\n\n## v0.1.0 - 2026-01-01\n\n- first\n"
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestSection(t *testing.T) {
+	content := "# Changelog\n\n## v0.2.0 - 2026-02-01\n\n### Features\n\n- second\n\n## [v0.1.0] - 2026-01-01\n\n- first\n"
+
+	if got := Section(content, "v0.2.0"); got != "### Features\n\n- second" {
+		t.Errorf("unexpected v0.2.0 section %q", got)
+	}
+	if got := Section(content, "v0.1.0"); got != "- first" {
+		t.Errorf("unexpected v0.1.0 section %q", got)
+	}
+	if got := Section(content, "v0.2"); got != "" {
+		t.Errorf("expected no section for a version prefix, got %q", got)
+	}
+}
+
+func TestCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	run("init", "-q")
+	run("commit", "-q", "--allow-empty", "-m", "chore: initial")
+	run("tag", "v0.1.0")
+	run("commit", "-q", "--allow-empty", "-m", "feat: add changelog\n\nWith a body.")
+	run("commit", "-q", "--allow-empty", "-m", "fix(git): quote paths")
+
+	ctx := context.Background()
+	if tag := LatestTag(ctx, dir); tag != "v0.1.0" {
+		t.Errorf("expected the latest tag v0.1.0, got %q", tag)
+	}
+	if !TagExists(ctx, dir, "v0.1.0") || TagExists(ctx, dir, "v9.9.9") {
+		t.Error("TagExists gave the wrong answer")
+	}
+
+	commits, err := Commits(ctx, dir, "v0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits since the tag, got %+v", commits)
+	}
+	if commits[0].Type != "fix" || commits[0].Scope != "git" || commits[1].Type != "feat" || commits[1].Body != "With a body." {
+		t.Errorf("unexpected commits: %+v", commits)
+	}
+
+	if _, err := Commits(ctx, dir, "v9.9.9"); err == nil {
+		t.Error("expected an error for an unknown tag")
+	}
+}
@@ -4,15 +4,26 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
 )
 
 // Config represents the application configuration
 type Config struct {
 	mu sync.RWMutex
 
+	// Configs this one builds on, paths or https URLs, optionally pinned with
+	// #sha256=<checksum>. Its own settings override theirs.
+	Extends []string `json:"extends,omitempty"`
+
 	// API Keys
 	APIKeys map[string]string `json:"api_keys,omitempty"`
 
@@ -20,19 +31,67 @@ type Config struct {
 	DefaultModel  string  `json:"default_model,omitempty"`
 	MaxTokens     int     `json:"max_tokens,omitempty"`
 	Temperature   float64 `json:"temperature,omitempty"`
-	ThinkingLevel string  `json:"thinking_level,omitempty"` // high, medium, low, none
+	ThinkingLevel string  `json:"thinking_level,omitempty"` // ultra, high, medium, low, none
+
+	// Sampling, sent to the providers that support it (0 and nil = the
+	// provider's default)
+	TopP float64 `json:"top_p,omitempty"`
+	TopK int     `json:"top_k,omitempty"`
+	Seed *int64  `json:"seed,omitempty"` // for reproducible generations
+
+	// Model aliases, such as "fast": "gemini-1.5-flash", accepted wherever a
+	// model name is. They override the built-in aliases.
+	ModelAliases map[string]string `json:"model_aliases,omitempty"`
+
+	// Prompt settings
+	SystemPrompt       string  `json:"system_prompt,omitempty"`        // replaces the built-in system prompt
+	AppendSystemPrompt string  `json:"append_system_prompt,omitempty"` // appended to the system prompt
+	OutputStyle        string  `json:"output_style,omitempty"`         // default, explanatory, terse, teaching
+	InstructionBudget  float64 `json:"instruction_budget,omitempty"`   // max share of the context window for instruction files
 
 	// Session settings
-	AutoSave        bool   `json:"auto_save,omitempty"`
-	SessionDir      string `json:"session_dir,omitempty"`
-	MaxIterations   int    `json:"max_iterations,omitempty"`
-	CompactPercent  float64 `json:"compact_percent,omitempty"`
+	AutoSave         bool    `json:"auto_save,omitempty"`
+	SessionDir       string  `json:"session_dir,omitempty"`
+	MaxIterations    int     `json:"max_iterations,omitempty"`
+	CompactPercent   float64 `json:"compact_percent,omitempty"`
+	StaleResultTurns int     `json:"stale_result_turns,omitempty"` // summarize tool results followed by this many responses, 0 = never
+	ToolJSONRepair   string  `json:"tool_json_repair,omitempty"`   // off, safe, lenient (default)
+	AttachmentBudget int     `json:"attachment_budget,omitempty"`  // tokens @-mentioned files may attach to a prompt, -1 = none
 
 	// Permission settings
 	PermissionMode  string   `json:"permission_mode,omitempty"` // default, plan, accept_edits, dont_ask, bypass
 	AllowedTools    []string `json:"allowed_tools,omitempty"`
 	DisallowedTools []string `json:"disallowed_tools,omitempty"`
 
+	// Directories outside the project that file tools may use without asking
+	AdditionalDirectories []string `json:"additional_directories,omitempty"`
+
+	// Tool limits, keyed by tool name ("*" applies to all tools)
+	ToolLimits map[string]ToolLimitConfig `json:"tool_limits,omitempty"`
+
+	// Limits on the processes shell commands spawn
+	ProcessLimits ProcessLimitConfig `json:"process_limits,omitempty"`
+
+	// Approval of destructive tool calls, required in every permission mode
+	DestructiveActions DestructiveConfig `json:"destructive_actions,omitempty"`
+
+	// Terminal pane that shows background shell output: auto, tmux, zellij,
+	// off, or a command template with {log}, {title}, and {id}
+	BackgroundPane string `json:"background_pane,omitempty"`
+
+	// CLI provider settings, keyed by provider (claudecli, codexcli, geminicli)
+	CLIProviders map[string]CLIProviderConfig `json:"cli_providers,omitempty"`
+
+	// Web search, keyed by provider (claude, gemini): "local" for the
+	// WebSearch tool (default) or "native" for the provider's built-in search
+	WebSearch map[string]string `json:"web_search,omitempty"`
+
+	// Budget settings
+	MonthlyBudgetUSD float64   `json:"monthly_budget_usd,omitempty"` // 0 = unlimited
+	BudgetAlerts     []float64 `json:"budget_alerts,omitempty"`      // percentages of the budget that warn, default 80 and 100
+	BudgetModel      string    `json:"budget_model,omitempty"`       // cheaper model to switch to once the budget is spent
+	BudgetEnforce    bool      `json:"budget_enforce,omitempty"`     // refuse non-Ollama providers once the budget is spent
+
 	// Hook settings
 	Hooks []HookConfig `json:"hooks,omitempty"`
 
@@ -52,14 +111,34 @@ type Config struct {
 	LogFile  string `json:"log_file,omitempty"`
 
 	// UI settings
-	Theme        string `json:"theme,omitempty"` // dark, light
-	StatusLine   bool   `json:"status_line,omitempty"`
-	ShowThinking bool   `json:"show_thinking,omitempty"`
+	Theme           string `json:"theme,omitempty"` // dark, light
+	StatusLine      bool   `json:"status_line,omitempty"`
+	ShowThinking    bool   `json:"show_thinking,omitempty"`    // also saves thinking with session transcripts
+	ThinkingDisplay string `json:"thinking_display,omitempty"` // show, collapse, hide
+	Accessible      bool   `json:"accessible,omitempty"`       // plain screen-reader friendly output
+
+	// Release automation for "agentic-coder release"
+	Release ReleaseConfig `json:"release,omitempty"`
+
+	// Voice input for /voice
+	Voice VoiceConfig `json:"voice,omitempty"`
+
+	// Remote host where file tools and Bash run, usually set per project
+	Remote RemoteConfig `json:"remote,omitempty"`
+
+	// Running Bash and tests in the project's devcontainer
+	Devcontainer DevcontainerConfig `json:"devcontainer,omitempty"`
+
+	// Environment variables passed to Bash, tests, and the devcontainer
+	Env EnvConfig `json:"env,omitempty"`
 
 	// Git settings
 	GitAutoCommit bool `json:"git_auto_commit,omitempty"`
 	GitSignCommit bool `json:"git_sign_commit,omitempty"`
 
+	// Audit settings
+	TranscriptSigning bool `json:"transcript_signing,omitempty"` // chain-hash and sign saved session transcripts
+
 	// Extra custom settings
 	Extra map[string]interface{} `json:"extra,omitempty"`
 
@@ -76,6 +155,101 @@ type HookConfig struct {
 	Env     map[string]string `json:"env,omitempty"`
 }
 
+// ToolLimitConfig limits the execution time and output size of a tool
+type ToolLimitConfig struct {
+	Timeout        string `json:"timeout,omitempty"`          // duration, e.g. "2m" or "30s"
+	MaxOutputBytes int    `json:"max_output_bytes,omitempty"` // output beyond this is truncated
+}
+
+// ProcessLimitConfig limits the processes shell commands spawn in a session.
+// Zero values use the defaults; -1 (or "none" for max_cpu_time) removes a limit.
+type ProcessLimitConfig struct {
+	MaxBackground int    `json:"max_background,omitempty"` // concurrent background shells
+	MaxProcesses  int    `json:"max_processes,omitempty"`  // concurrent processes, counting children
+	MaxCPUTime    string `json:"max_cpu_time,omitempty"`   // cumulative CPU time, e.g. "30m"
+}
+
+// DestructiveConfig controls how destructive tool calls, such as rm, force
+// pushes, and database writes, are approved
+type DestructiveConfig struct {
+	Approval     string                  `json:"approval,omitempty"`      // user (default) or model
+	VerifyModel  string                  `json:"verify_model,omitempty"`  // model that approves calls when approval is model, default haiku
+	Rules        []DestructiveRuleConfig `json:"rules,omitempty"`         // added to the built-in rules
+	DisableRules []string                `json:"disable_rules,omitempty"` // names of built-in rules to turn off
+}
+
+// DestructiveRuleConfig classifies tool calls as destructive. Pattern is a
+// regular expression matched against Bash commands, or the JSON input of
+// other tools.
+type DestructiveRuleConfig struct {
+	Name    string `json:"name"`
+	Tool    string `json:"tool,omitempty"` // tool name pattern, default Bash
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ReleaseConfig describes how "agentic-coder release" versions, builds, and
+// publishes a Go project. Empty fields use the defaults.
+type ReleaseConfig struct {
+	VersionFile string   `json:"version_file,omitempty"` // Go file declaring the version, default: searched for
+	VersionVar  string   `json:"version_var,omitempty"`  // name of the version variable or constant, default version
+	TagPrefix   string   `json:"tag_prefix,omitempty"`   // prepended to the version in tags, default v; none for bare versions
+	Main        string   `json:"main,omitempty"`         // package to build, default .
+	Binary      string   `json:"binary,omitempty"`       // binary name, default the main package's directory
+	Platforms   []string `json:"platforms,omitempty"`    // GOOS/GOARCH pairs, default linux, darwin, and windows on amd64 and arm64
+	Ldflags     string   `json:"ldflags,omitempty"`      // {{.Version}}, {{.Tag}}, and {{.Commit}} are replaced
+	Dist        string   `json:"dist,omitempty"`         // output directory, default dist
+	TestCommand string   `json:"test_command,omitempty"` // default go test ./...
+	Changelog   string   `json:"changelog,omitempty"`    // default CHANGELOG.md
+}
+
+// VoiceConfig describes how /voice records and transcribes speech, and how
+// turn summaries are read out. Empty fields use the defaults.
+type VoiceConfig struct {
+	Transcriber   string `json:"transcriber,omitempty"`    // local (whisper.cpp) or openai, default local when set up
+	WhisperBinary string `json:"whisper_binary,omitempty"` // default whisper-cli or whisper-cpp on PATH
+	WhisperModel  string `json:"whisper_model,omitempty"`  // ggml model file for whisper.cpp
+	APIModel      string `json:"api_model,omitempty"`      // OpenAI transcription model, default whisper-1
+	Language      string `json:"language,omitempty"`       // spoken language such as en, default detected
+	Recorder      string `json:"recorder,omitempty"`       // command recording 16 kHz mono WAV to {file}, default sox, arecord, or ffmpeg
+	Speak         bool   `json:"speak,omitempty"`          // read out a summary of each completed turn
+	Speaker       string `json:"speaker,omitempty"`        // system (say, espeak) or openai, default system when available
+	SpeechVoice   string `json:"speech_voice,omitempty"`   // voice name for the speaker, default its own
+}
+
+// RemoteConfig runs the file tools and Bash on a remote host over SSH, while
+// the UI and providers stay local. Empty fields use the defaults.
+type RemoteConfig struct {
+	Host         string   `json:"host,omitempty"`          // [user@]host, as for ssh
+	Dir          string   `json:"dir,omitempty"`           // project directory on the host, default the local project path
+	Port         int      `json:"port,omitempty"`          // default the ssh default
+	IdentityFile string   `json:"identity_file,omitempty"` // private key, default ssh's
+	SSHOptions   []string `json:"ssh_options,omitempty"`   // -o options such as "ProxyJump=bastion"
+	Shell        string   `json:"shell,omitempty"`         // shell for Bash commands on the host, default bash
+}
+
+// DevcontainerConfig runs Bash and the Test tool inside the container that
+// .devcontainer/devcontainer.json describes
+type DevcontainerConfig struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	Exec    string `json:"exec,omitempty"` // auto (default), cli (devcontainer exec), or docker (docker exec)
+}
+
+// EnvConfig chooses which of the agent's environment variables the commands
+// it runs receive. Names may end in * to match a prefix.
+type EnvConfig struct {
+	Mode      string   `json:"mode,omitempty"`      // allowlist (default): common toolchain variables only; denylist: all but secret-looking ones
+	Allow     []string `json:"allow,omitempty"`     // also passed, even when they look secret
+	Deny      []string `json:"deny,omitempty"`      // never passed, overriding allow
+	Container []string `json:"container,omitempty"` // local variables forwarded into the devcontainer
+}
+
+// CLIProviderConfig locates the CLI tool a provider shells out to
+type CLIProviderConfig struct {
+	Path    string `json:"path,omitempty"`    // binary to run instead of the one on PATH
+	Version string `json:"version,omitempty"` // pinned version, e.g. "1.0.44" or ">=1.0.30,<2"
+}
+
 // MCPServerConfig represents an MCP server configuration
 type MCPServerConfig struct {
 	Name      string            `json:"name"`
@@ -116,6 +290,15 @@ type ConfigManager struct {
 
 	globalPath  string
 	projectPath string
+
+	// The global and project configs over the configs they extend
+	globalView  *Config
+	projectView *Config
+	cacheDir    string
+	client      *http.Client
+	refresh     bool
+	bases       []BaseConfig
+	warnings    []string
 }
 
 // NewConfigManager creates a new configuration manager
@@ -129,6 +312,7 @@ func NewConfigManager() (*ConfigManager, error) {
 	}
 
 	cm.globalPath = configPath
+	cm.cacheDir = filepath.Join(filepath.Dir(configPath), "config-cache")
 
 	return cm, nil
 }
@@ -142,6 +326,9 @@ func (cm *ConfigManager) Load(projectPath string) error {
 	}
 	cm.globalConfig.configPath = cm.globalPath
 
+	loader := &extendsLoader{cacheDir: cm.cacheDir, client: cm.client, refresh: cm.refresh}
+	cm.globalView = loader.withBases(cm.globalConfig, cm.globalPath)
+
 	// Load project config if provided
 	if projectPath != "" {
 		cm.projectPath = GetProjectConfigPath(projectPath)
@@ -150,7 +337,9 @@ func (cm *ConfigManager) Load(projectPath string) error {
 			return fmt.Errorf("failed to load project config: %w", err)
 		}
 		cm.projectConfig.configPath = cm.projectPath
+		cm.projectView = loader.withBases(cm.projectConfig, cm.projectPath)
 	}
+	cm.bases, cm.warnings = loader.bases, loader.warnings
 
 	// Merge configs (project overrides global)
 	cm.mergedConfig = cm.merge()
@@ -176,13 +365,15 @@ func (cm *ConfigManager) loadFromFile(path string, cfg *Config) error {
 func (cm *ConfigManager) merge() *Config {
 	merged := DefaultConfig()
 
-	// Start with global config
-	if cm.globalConfig != nil {
-		copyConfig(cm.globalConfig, merged)
+	// Start with global config, over the configs it extends
+	if global := cm.Global(); global != nil {
+		copyConfig(global, merged)
 	}
 
-	// Override with project config
-	if cm.projectConfig != nil {
+	// Override with project config, over the configs it extends
+	if cm.projectView != nil {
+		mergeConfig(cm.projectView, merged)
+	} else if cm.projectConfig != nil {
 		mergeConfig(cm.projectConfig, merged)
 	}
 
@@ -200,9 +391,31 @@ func copyConfig(src, dst *Config) {
 	if src.Temperature > 0 {
 		dst.Temperature = src.Temperature
 	}
+	if src.TopP > 0 {
+		dst.TopP = src.TopP
+	}
+	if src.TopK > 0 {
+		dst.TopK = src.TopK
+	}
+	if src.Seed != nil {
+		seed := *src.Seed
+		dst.Seed = &seed
+	}
 	if src.ThinkingLevel != "" {
 		dst.ThinkingLevel = src.ThinkingLevel
 	}
+	if src.SystemPrompt != "" {
+		dst.SystemPrompt = src.SystemPrompt
+	}
+	if src.AppendSystemPrompt != "" {
+		dst.AppendSystemPrompt = src.AppendSystemPrompt
+	}
+	if src.OutputStyle != "" {
+		dst.OutputStyle = src.OutputStyle
+	}
+	if src.InstructionBudget > 0 {
+		dst.InstructionBudget = src.InstructionBudget
+	}
 	if src.SessionDir != "" {
 		dst.SessionDir = src.SessionDir
 	}
@@ -212,6 +425,15 @@ func copyConfig(src, dst *Config) {
 	if src.CompactPercent > 0 {
 		dst.CompactPercent = src.CompactPercent
 	}
+	if src.StaleResultTurns > 0 {
+		dst.StaleResultTurns = src.StaleResultTurns
+	}
+	if src.ToolJSONRepair != "" {
+		dst.ToolJSONRepair = src.ToolJSONRepair
+	}
+	if src.AttachmentBudget != 0 {
+		dst.AttachmentBudget = src.AttachmentBudget
+	}
 	if src.PermissionMode != "" {
 		dst.PermissionMode = src.PermissionMode
 	}
@@ -221,6 +443,21 @@ func copyConfig(src, dst *Config) {
 	if len(src.DisallowedTools) > 0 {
 		dst.DisallowedTools = src.DisallowedTools
 	}
+	// Directories from every level are allowed
+	for _, dir := range src.AdditionalDirectories {
+		if !slices.Contains(dst.AdditionalDirectories, dir) {
+			dst.AdditionalDirectories = append(dst.AdditionalDirectories, dir)
+		}
+	}
+	if src.MonthlyBudgetUSD > 0 {
+		dst.MonthlyBudgetUSD = src.MonthlyBudgetUSD
+	}
+	if len(src.BudgetAlerts) > 0 {
+		dst.BudgetAlerts = src.BudgetAlerts
+	}
+	if src.BudgetModel != "" {
+		dst.BudgetModel = src.BudgetModel
+	}
 	if len(src.Hooks) > 0 {
 		dst.Hooks = src.Hooks
 	}
@@ -233,6 +470,9 @@ func copyConfig(src, dst *Config) {
 	if src.Editor != "" {
 		dst.Editor = src.Editor
 	}
+	if src.EditorArgs != "" {
+		dst.EditorArgs = src.EditorArgs
+	}
 	if src.LogLevel != "" {
 		dst.LogLevel = src.LogLevel
 	}
@@ -242,6 +482,12 @@ func copyConfig(src, dst *Config) {
 	if src.Theme != "" {
 		dst.Theme = src.Theme
 	}
+	if src.ThinkingDisplay != "" {
+		dst.ThinkingDisplay = src.ThinkingDisplay
+	}
+	if src.BackgroundPane != "" {
+		dst.BackgroundPane = src.BackgroundPane
+	}
 
 	// Boolean fields
 	dst.AutoSave = src.AutoSave
@@ -250,6 +496,9 @@ func copyConfig(src, dst *Config) {
 	dst.ShowThinking = src.ShowThinking
 	dst.GitAutoCommit = src.GitAutoCommit
 	dst.GitSignCommit = src.GitSignCommit
+	dst.BudgetEnforce = dst.BudgetEnforce || src.BudgetEnforce             // a project cannot lift a global cap
+	dst.Accessible = dst.Accessible || src.Accessible                      // a project cannot turn off a user's accessible output
+	dst.TranscriptSigning = dst.TranscriptSigning || src.TranscriptSigning // a project cannot turn off signing
 
 	// Maps
 	for k, v := range src.APIKeys {
@@ -258,6 +507,74 @@ func copyConfig(src, dst *Config) {
 	for k, v := range src.Extra {
 		dst.Extra[k] = v
 	}
+	for k, v := range src.ToolLimits {
+		if dst.ToolLimits == nil {
+			dst.ToolLimits = make(map[string]ToolLimitConfig)
+		}
+		dst.ToolLimits[k] = v
+	}
+	if src.ProcessLimits.MaxBackground != 0 {
+		dst.ProcessLimits.MaxBackground = src.ProcessLimits.MaxBackground
+	}
+	if src.ProcessLimits.MaxProcesses != 0 {
+		dst.ProcessLimits.MaxProcesses = src.ProcessLimits.MaxProcesses
+	}
+	if src.ProcessLimits.MaxCPUTime != "" {
+		dst.ProcessLimits.MaxCPUTime = src.ProcessLimits.MaxCPUTime
+	}
+	if src.DestructiveActions.Approval != "" {
+		dst.DestructiveActions.Approval = src.DestructiveActions.Approval
+	}
+	if src.DestructiveActions.VerifyModel != "" {
+		dst.DestructiveActions.VerifyModel = src.DestructiveActions.VerifyModel
+	}
+	dst.DestructiveActions.Rules = append(dst.DestructiveActions.Rules, src.DestructiveActions.Rules...)
+	for _, name := range src.DestructiveActions.DisableRules {
+		if !slices.Contains(dst.DestructiveActions.DisableRules, name) {
+			dst.DestructiveActions.DisableRules = append(dst.DestructiveActions.DisableRules, name)
+		}
+	}
+	mergeRelease(&src.Release, &dst.Release)
+	mergeVoice(&src.Voice, &dst.Voice)
+	if src.Remote.Host != "" {
+		dst.Remote = src.Remote // the settings describe one host, so they are not mixed
+	}
+	dst.Devcontainer.Enabled = dst.Devcontainer.Enabled || src.Devcontainer.Enabled
+	if src.Devcontainer.Exec != "" {
+		dst.Devcontainer.Exec = src.Devcontainer.Exec
+	}
+	if src.Env.Mode != "" {
+		dst.Env.Mode = src.Env.Mode
+	}
+	for _, names := range []struct{ src, dst *[]string }{
+		{&src.Env.Allow, &dst.Env.Allow},
+		{&src.Env.Deny, &dst.Env.Deny},
+		{&src.Env.Container, &dst.Env.Container},
+	} {
+		for _, name := range *names.src {
+			if !slices.Contains(*names.dst, name) {
+				*names.dst = append(*names.dst, name)
+			}
+		}
+	}
+	for k, v := range src.CLIProviders {
+		if dst.CLIProviders == nil {
+			dst.CLIProviders = make(map[string]CLIProviderConfig)
+		}
+		dst.CLIProviders[k] = v
+	}
+	for k, v := range src.WebSearch {
+		if dst.WebSearch == nil {
+			dst.WebSearch = make(map[string]string)
+		}
+		dst.WebSearch[k] = v
+	}
+	for k, v := range src.ModelAliases {
+		if dst.ModelAliases == nil {
+			dst.ModelAliases = make(map[string]string)
+		}
+		dst.ModelAliases[k] = v
+	}
 }
 
 // mergeConfig merges src into dst (only non-zero values)
@@ -265,6 +582,71 @@ func mergeConfig(src, dst *Config) {
 	copyConfig(src, dst)
 }
 
+// mergeRelease overrides the release settings src sets
+func mergeRelease(src, dst *ReleaseConfig) {
+	if src.VersionFile != "" {
+		dst.VersionFile = src.VersionFile
+	}
+	if src.VersionVar != "" {
+		dst.VersionVar = src.VersionVar
+	}
+	if src.TagPrefix != "" {
+		dst.TagPrefix = src.TagPrefix
+	}
+	if src.Main != "" {
+		dst.Main = src.Main
+	}
+	if src.Binary != "" {
+		dst.Binary = src.Binary
+	}
+	if src.Ldflags != "" {
+		dst.Ldflags = src.Ldflags
+	}
+	if src.Dist != "" {
+		dst.Dist = src.Dist
+	}
+	if src.TestCommand != "" {
+		dst.TestCommand = src.TestCommand
+	}
+	if src.Changelog != "" {
+		dst.Changelog = src.Changelog
+	}
+	if len(src.Platforms) > 0 {
+		dst.Platforms = src.Platforms
+	}
+}
+
+// mergeVoice overrides the voice settings src sets
+func mergeVoice(src, dst *VoiceConfig) {
+	if src.Transcriber != "" {
+		dst.Transcriber = src.Transcriber
+	}
+	if src.WhisperBinary != "" {
+		dst.WhisperBinary = src.WhisperBinary
+	}
+	if src.WhisperModel != "" {
+		dst.WhisperModel = src.WhisperModel
+	}
+	if src.APIModel != "" {
+		dst.APIModel = src.APIModel
+	}
+	if src.Language != "" {
+		dst.Language = src.Language
+	}
+	if src.Recorder != "" {
+		dst.Recorder = src.Recorder
+	}
+	if src.Speak {
+		dst.Speak = true
+	}
+	if src.Speaker != "" {
+		dst.Speaker = src.Speaker
+	}
+	if src.SpeechVoice != "" {
+		dst.SpeechVoice = src.SpeechVoice
+	}
+}
+
 // Get returns the merged configuration
 func (cm *ConfigManager) Get() *Config {
 	if cm.mergedConfig == nil {
@@ -273,13 +655,43 @@ func (cm *ConfigManager) Get() *Config {
 	return cm.mergedConfig
 }
 
-// Global returns the global configuration
+// Global returns the global configuration over the configs it extends.
+// Settings a cloned repository must not weaken are read from here.
 func (cm *ConfigManager) Global() *Config {
+	if cm.globalView != nil {
+		return cm.globalView
+	}
+	if cm.globalConfig == nil {
+		return DefaultConfig()
+	}
 	return cm.globalConfig
 }
 
-// Project returns the project configuration
+// Bases returns the configs the loaded configs extend, in the order they
+// were read
+func (cm *ConfigManager) Bases() []BaseConfig {
+	return cm.bases
+}
+
+// Warnings returns the problems with configs the loaded configs extend,
+// such as checksum mismatches and failed downloads. Those configs are left
+// out.
+func (cm *ConfigManager) Warnings() []string {
+	return cm.warnings
+}
+
+// SetRefresh makes Load fetch remote configs again even when the cached
+// copies are fresh
+func (cm *ConfigManager) SetRefresh(refresh bool) {
+	cm.refresh = refresh
+}
+
+// Project returns the project configuration over the configs it extends,
+// or nil when no project was loaded
 func (cm *ConfigManager) Project() *Config {
+	if cm.projectView != nil {
+		return cm.projectView
+	}
 	return cm.projectConfig
 }
 
@@ -323,6 +735,120 @@ func (cm *ConfigManager) Save(scope string) error {
 	return nil
 }
 
+// LoadMCPServers returns the MCP servers of the config file at path, none if
+// it does not exist
+func LoadMCPServers(path string) ([]MCPServerConfig, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cfg struct {
+		MCPServers []MCPServerConfig `json:"mcp_servers"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg.MCPServers, nil
+}
+
+// SaveMCPServers replaces the MCP servers of the config file at path. Its
+// other settings are kept as written, not filled in with defaults the way
+// Save would.
+func SaveMCPServers(path string, servers []MCPServerConfig) error {
+	var value interface{}
+	if len(servers) > 0 {
+		value = servers
+	}
+	return saveSetting(path, "mcp_servers", value)
+}
+
+// LoadModelAliases returns the model aliases of the config file at path,
+// none if it does not exist
+func LoadModelAliases(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cfg struct {
+		ModelAliases map[string]string `json:"model_aliases"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg.ModelAliases, nil
+}
+
+// SaveModelAliases replaces the model aliases of the config file at path,
+// keeping its other settings as SaveMCPServers does
+func SaveModelAliases(path string, aliases map[string]string) error {
+	var value interface{}
+	if len(aliases) > 0 {
+		value = aliases
+	}
+	return saveSetting(path, "model_aliases", value)
+}
+
+// SaveOutputStyle sets the output style of the config file at path,
+// keeping its other settings as SaveMCPServers does
+func SaveOutputStyle(path, style string) error {
+	return saveSetting(path, "output_style", style)
+}
+
+// ValidateModelAlias checks an alias name and the model it stands for
+func ValidateModelAlias(name, target string) error {
+	switch {
+	case name == "" || strings.ContainsAny(name, ": \t"):
+		return fmt.Errorf("invalid alias name %q: must be non-empty, without spaces or colons", name)
+	case strings.TrimSpace(target) == "":
+		return fmt.Errorf("alias %s must name a model", name)
+	case target == name:
+		return fmt.Errorf("alias %s stands for itself", name)
+	}
+	return nil
+}
+
+// saveSetting sets one top-level setting of the config file at path, or
+// removes it when value is nil, leaving the others as written
+func saveSetting(path, key string, value interface{}) error {
+	settings := make(map[string]json.RawMessage)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+
+	if value == nil {
+		delete(settings, key)
+	} else {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", key, err)
+		}
+		settings[key] = raw
+	}
+
+	if data, err = json.MarshalIndent(settings, "", "  "); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
 // Set sets a configuration value
 func (c *Config) Set(key string, value interface{}) error {
 	c.mu.Lock()
@@ -335,25 +861,78 @@ func (c *Config) Set(key string, value interface{}) error {
 		c.MaxTokens = toInt(value)
 	case "temperature":
 		c.Temperature = toFloat(value)
+	case "top_p":
+		c.TopP = toFloat(value)
+	case "top_k":
+		c.TopK = toInt(value)
+	case "seed":
+		seed := int64(toInt(value))
+		c.Seed = &seed
 	case "thinking_level":
 		c.ThinkingLevel = value.(string)
+	case "system_prompt":
+		c.SystemPrompt = value.(string)
+	case "append_system_prompt":
+		c.AppendSystemPrompt = value.(string)
+	case "output_style":
+		c.OutputStyle = value.(string)
+	case "instruction_budget":
+		c.InstructionBudget = toFloat(value)
 	case "auto_save":
 		c.AutoSave = value.(bool)
 	case "max_iterations":
 		c.MaxIterations = toInt(value)
+	case "stale_result_turns":
+		c.StaleResultTurns = toInt(value)
+	case "tool_json_repair":
+		c.ToolJSONRepair = value.(string)
+	case "attachment_budget":
+		c.AttachmentBudget = toInt(value)
 	case "permission_mode":
 		c.PermissionMode = value.(string)
+	case "monthly_budget_usd":
+		c.MonthlyBudgetUSD = toFloat(value)
+	case "budget_model":
+		c.BudgetModel = value.(string)
+	case "budget_enforce":
+		c.BudgetEnforce = value.(bool)
 	case "verbose":
 		c.Verbose = value.(bool)
 	case "log_level":
 		c.LogLevel = value.(string)
 	case "theme":
 		c.Theme = value.(string)
+	case "editor":
+		c.Editor = value.(string)
+	case "editor_args":
+		c.EditorArgs = value.(string)
 	case "status_line":
 		c.StatusLine = value.(bool)
 	case "show_thinking":
 		c.ShowThinking = value.(bool)
+	case "accessible":
+		c.Accessible = value.(bool)
+	case "transcript_signing":
+		c.TranscriptSigning = value.(bool)
+	case "thinking_display":
+		c.ThinkingDisplay = value.(string)
+	case "background_pane":
+		c.BackgroundPane = value.(string)
 	default:
+		if name, ok := strings.CutPrefix(key, "web_search."); ok {
+			if c.WebSearch == nil {
+				c.WebSearch = make(map[string]string)
+			}
+			c.WebSearch[name] = value.(string)
+			return nil
+		}
+		if name, ok := strings.CutPrefix(key, "model_aliases."); ok {
+			if c.ModelAliases == nil {
+				c.ModelAliases = make(map[string]string)
+			}
+			c.ModelAliases[name] = value.(string)
+			return nil
+		}
 		// Store in extra
 		c.Extra[key] = value
 	}
@@ -371,15 +950,37 @@ func (c *Config) GetString(key string) string {
 		return c.DefaultModel
 	case "thinking_level":
 		return c.ThinkingLevel
+	case "system_prompt":
+		return c.SystemPrompt
+	case "append_system_prompt":
+		return c.AppendSystemPrompt
+	case "output_style":
+		return c.OutputStyle
+	case "tool_json_repair":
+		return c.ToolJSONRepair
 	case "permission_mode":
 		return c.PermissionMode
 	case "log_level":
 		return c.LogLevel
+	case "budget_model":
+		return c.BudgetModel
 	case "theme":
 		return c.Theme
 	case "editor":
 		return c.Editor
+	case "editor_args":
+		return c.EditorArgs
+	case "thinking_display":
+		return c.ThinkingDisplay
+	case "background_pane":
+		return c.BackgroundPane
 	default:
+		if name, ok := strings.CutPrefix(key, "web_search."); ok {
+			return c.WebSearch[name]
+		}
+		if name, ok := strings.CutPrefix(key, "model_aliases."); ok {
+			return c.ModelAliases[name]
+		}
 		if v, ok := c.Extra[key].(string); ok {
 			return v
 		}
@@ -397,6 +998,12 @@ func (c *Config) GetInt(key string) int {
 		return c.MaxTokens
 	case "max_iterations":
 		return c.MaxIterations
+	case "stale_result_turns":
+		return c.StaleResultTurns
+	case "attachment_budget":
+		return c.AttachmentBudget
+	case "top_k":
+		return c.TopK
 	default:
 		if v, ok := c.Extra[key].(int); ok {
 			return v
@@ -419,10 +1026,16 @@ func (c *Config) GetBool(key string) bool {
 		return c.StatusLine
 	case "show_thinking":
 		return c.ShowThinking
+	case "accessible":
+		return c.Accessible
 	case "git_auto_commit":
 		return c.GitAutoCommit
 	case "git_sign_commit":
 		return c.GitSignCommit
+	case "budget_enforce":
+		return c.BudgetEnforce
+	case "transcript_signing":
+		return c.TranscriptSigning
 	default:
 		if v, ok := c.Extra[key].(bool); ok {
 			return v
@@ -511,8 +1124,12 @@ func (c *Config) Validate() *ValidationResult {
 		"deepseek": true, "deepseek-chat": true, "deepseek-reasoner": true,
 		"llama": true, "qwen": true, "codex": true,
 		"geminicli": true, "claudecli": true, "codexcli": true,
+		"github": true,
 	}
-	if c.DefaultModel != "" && !validModels[c.DefaultModel] {
+	_, _, explicit := provider.SplitProviderPrefix(c.DefaultModel)
+	_, aliased := c.ModelAliases[c.DefaultModel]
+	if c.DefaultModel != "" && !validModels[c.DefaultModel] && !explicit && !aliased &&
+		provider.DetectProviderFromModel(c.DefaultModel) != provider.ProviderTypeGitHub {
 		result.Warnings = append(result.Warnings, ValidationError{
 			Field:   "default_model",
 			Value:   c.DefaultModel,
@@ -544,19 +1161,58 @@ func (c *Config) Validate() *ValidationResult {
 			Message: "must be between 0 and 2",
 		})
 	}
+	if c.TopP < 0 || c.TopP > 1 {
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "top_p",
+			Value:   c.TopP,
+			Message: "must be between 0 and 1",
+		})
+	}
+	if c.TopK < 0 {
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "top_k",
+			Value:   c.TopK,
+			Message: "must be non-negative",
+		})
+	}
 
 	// Validate thinking_level
 	validThinkingLevels := map[string]bool{
-		"high": true, "medium": true, "low": true, "none": true, "": true,
+		"ultra": true, "high": true, "medium": true, "low": true, "none": true, "": true,
 	}
 	if !validThinkingLevels[c.ThinkingLevel] {
 		result.Errors = append(result.Errors, ValidationError{
 			Field:   "thinking_level",
 			Value:   c.ThinkingLevel,
-			Message: "must be one of: high, medium, low, none",
+			Message: "must be one of: ultra, high, medium, low, none",
 		})
 	}
 
+	// Validate thinking_display
+	validThinkingDisplays := map[string]bool{
+		"show": true, "collapse": true, "hide": true, "": true,
+	}
+	if !validThinkingDisplays[c.ThinkingDisplay] {
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "thinking_display",
+			Value:   c.ThinkingDisplay,
+			Message: "must be one of: show, collapse, hide",
+		})
+	}
+
+	// Validate background_pane
+	switch c.BackgroundPane {
+	case "", "off", "auto", "tmux", "zellij":
+	default:
+		if !strings.Contains(c.BackgroundPane, "{log}") {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "background_pane",
+				Value:   c.BackgroundPane,
+				Message: "must be auto, tmux, zellij, off, or a command containing {log}",
+			})
+		}
+	}
+
 	// Validate max_iterations
 	if c.MaxIterations < 0 {
 		result.Errors = append(result.Errors, ValidationError{
@@ -582,6 +1238,98 @@ func (c *Config) Validate() *ValidationResult {
 		})
 	}
 
+	// Validate stale_result_turns
+	if c.StaleResultTurns < 0 {
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "stale_result_turns",
+			Value:   c.StaleResultTurns,
+			Message: "must be non-negative",
+		})
+	}
+
+	// Validate tool_json_repair
+	switch c.ToolJSONRepair {
+	case "", "off", "safe", "lenient":
+	default:
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "tool_json_repair",
+			Value:   c.ToolJSONRepair,
+			Message: "must be off, safe, or lenient",
+		})
+	}
+
+	// Validate web_search
+	for name, mode := range c.WebSearch {
+		if mode != "local" && mode != "native" {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("web_search.%s", name),
+				Value:   mode,
+				Message: "must be local or native",
+			})
+		}
+	}
+
+	// Validate model_aliases
+	for name, target := range c.ModelAliases {
+		if err := ValidateModelAlias(name, target); err != nil {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("model_aliases.%s", name),
+				Value:   target,
+				Message: err.Error(),
+			})
+		}
+	}
+
+	// Validate extends
+	for _, entry := range c.Extends {
+		ref, checksum, pinned := strings.Cut(entry, extendsPinPrefix)
+		switch {
+		case strings.HasPrefix(ref, "http://"):
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "extends",
+				Value:   entry,
+				Message: "remote configs must use https",
+			})
+		case pinned && !sha256Pattern.MatchString(checksum):
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "extends",
+				Value:   entry,
+				Message: "checksum must be 64 hex digits",
+			})
+		case isRemote(ref) && !pinned:
+			result.Warnings = append(result.Warnings, ValidationError{
+				Field:   "extends",
+				Value:   entry,
+				Message: "not pinned with #sha256=, so changes to it apply without review",
+			})
+		}
+	}
+
+	// Validate budget
+	if c.MonthlyBudgetUSD < 0 {
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "monthly_budget_usd",
+			Value:   c.MonthlyBudgetUSD,
+			Message: "must be non-negative",
+		})
+	}
+	for i, alert := range c.BudgetAlerts {
+		if alert <= 0 {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("budget_alerts[%d]", i),
+				Value:   alert,
+				Message: "must be a positive percentage of the budget",
+			})
+		}
+	}
+	if (len(c.BudgetAlerts) > 0 || c.BudgetModel != "" || c.BudgetEnforce) && c.MonthlyBudgetUSD == 0 {
+		result.Warnings = append(result.Warnings, ValidationError{
+			Field:   "monthly_budget_usd",
+			Value:   c.MonthlyBudgetUSD,
+			Message: "budget settings have no effect without a monthly budget",
+		})
+	}
+
 	// Validate permission_mode
 	validPermissionModes := map[string]bool{
 		"default": true, "plan": true, "accept_edits": true, "dont_ask": true, "bypass": true, "": true,
@@ -682,6 +1430,228 @@ func (c *Config) Validate() *ValidationResult {
 		}
 	}
 
+	// Validate tool limits
+	for name, limit := range c.ToolLimits {
+		if limit.Timeout != "" {
+			if d, err := time.ParseDuration(limit.Timeout); err != nil || d < 0 {
+				result.Errors = append(result.Errors, ValidationError{
+					Field:   fmt.Sprintf("tool_limits.%s.timeout", name),
+					Value:   limit.Timeout,
+					Message: "must be a duration such as 30s or 5m",
+				})
+			}
+		}
+		if limit.MaxOutputBytes < 0 {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("tool_limits.%s.max_output_bytes", name),
+				Value:   limit.MaxOutputBytes,
+				Message: "must not be negative",
+			})
+		}
+	}
+
+	// Validate process limits
+	for _, limit := range []struct {
+		field string
+		value int
+	}{
+		{"process_limits.max_background", c.ProcessLimits.MaxBackground},
+		{"process_limits.max_processes", c.ProcessLimits.MaxProcesses},
+	} {
+		if limit.value < -1 {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   limit.field,
+				Value:   limit.value,
+				Message: "must be positive, 0 for the default or -1 for no limit",
+			})
+		}
+	}
+	if cpu := c.ProcessLimits.MaxCPUTime; cpu != "" && cpu != "none" {
+		if d, err := time.ParseDuration(cpu); err != nil || d <= 0 {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "process_limits.max_cpu_time",
+				Value:   cpu,
+				Message: "must be a duration such as 30m, or none",
+			})
+		}
+	}
+
+	// Validate the remote host
+	if c.Remote.Host != "" {
+		if strings.HasPrefix(c.Remote.Host, "-") || strings.ContainsAny(c.Remote.Host, " \t") {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "remote.host",
+				Value:   c.Remote.Host,
+				Message: "must be a host name, optionally with user@",
+			})
+		}
+		if c.Remote.Dir != "" && !strings.HasPrefix(c.Remote.Dir, "/") {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "remote.dir",
+				Value:   c.Remote.Dir,
+				Message: "must be an absolute path",
+			})
+		}
+		if c.Remote.Port < 0 || c.Remote.Port > 65535 {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "remote.port",
+				Value:   c.Remote.Port,
+				Message: "must be between 1 and 65535, or 0 for the default",
+			})
+		}
+	}
+
+	// Validate the devcontainer exec mode
+	switch c.Devcontainer.Exec {
+	case "", "auto", "cli", "docker":
+	default:
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "devcontainer.exec",
+			Value:   c.Devcontainer.Exec,
+			Message: "must be auto, cli, or docker",
+		})
+	}
+
+	// Validate the environment of commands
+	switch c.Env.Mode {
+	case "", "allowlist", "denylist":
+	default:
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "env.mode",
+			Value:   c.Env.Mode,
+			Message: "must be allowlist or denylist",
+		})
+	}
+	for _, names := range []struct {
+		field    string
+		names    []string
+		prefixes bool
+	}{{"env.allow", c.Env.Allow, true}, {"env.deny", c.Env.Deny, true}, {"env.container", c.Env.Container, false}} {
+		for _, name := range names.names {
+			pattern, message := name, "must be a variable name"
+			if names.prefixes {
+				pattern, message = strings.TrimSuffix(name, "*"), "must be a variable name, or a prefix followed by *"
+			}
+			if pattern == "" || strings.ContainsAny(pattern, "=* \t") {
+				result.Errors = append(result.Errors, ValidationError{
+					Field:   names.field,
+					Value:   name,
+					Message: message,
+				})
+			}
+		}
+	}
+
+	// Validate destructive action approval
+	switch c.DestructiveActions.Approval {
+	case "", "user", "model":
+	default:
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "destructive_actions.approval",
+			Value:   c.DestructiveActions.Approval,
+			Message: "must be user or model",
+		})
+	}
+	for i, rule := range c.DestructiveActions.Rules {
+		field := fmt.Sprintf("destructive_actions.rules[%d]", i)
+		if rule.Name == "" {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   field + ".name",
+				Value:   rule.Name,
+				Message: "is required",
+			})
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil || rule.Pattern == "" {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   field + ".pattern",
+				Value:   rule.Pattern,
+				Message: "must be a regular expression",
+			})
+		}
+	}
+
+	// Validate release settings
+	for i, platform := range c.Release.Platforms {
+		goos, goarch, ok := strings.Cut(platform, "/")
+		if !ok || goos == "" || goarch == "" || strings.Contains(goarch, "/") {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("release.platforms[%d]", i),
+				Value:   platform,
+				Message: "must be GOOS/GOARCH, such as linux/amd64",
+			})
+		}
+	}
+	if v := c.Release.VersionVar; v != "" && !regexp.MustCompile(`^[A-Za-z_]\w*$`).MatchString(v) {
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "release.version_var",
+			Value:   v,
+			Message: "must be a Go identifier",
+		})
+	}
+
+	// Validate voice settings
+	switch c.Voice.Transcriber {
+	case "", "local", "openai":
+	default:
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "voice.transcriber",
+			Value:   c.Voice.Transcriber,
+			Message: "must be local or openai",
+		})
+	}
+	if r := c.Voice.Recorder; r != "" && !strings.Contains(r, "{file}") {
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "voice.recorder",
+			Value:   r,
+			Message: "must contain {file}, the WAV file to record to",
+		})
+	}
+	switch c.Voice.Speaker {
+	case "", "system", "openai":
+	default:
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "voice.speaker",
+			Value:   c.Voice.Speaker,
+			Message: "must be system or openai",
+		})
+	}
+
+	// Validate CLI providers
+	validCLIProviders := map[string]bool{
+		string(provider.ProviderTypeClaudeCLI): true,
+		string(provider.ProviderTypeCodexCLI):  true,
+		string(provider.ProviderTypeGeminiCLI): true,
+	}
+	for name, cli := range c.CLIProviders {
+		if !validCLIProviders[name] {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("cli_providers.%s", name),
+				Value:   name,
+				Message: "must be one of: claudecli, codexcli, geminicli",
+			})
+		}
+		if cli.Version != "" {
+			if _, err := provider.CheckVersionConstraint(provider.CLIVersion{}, cli.Version); err != nil {
+				result.Errors = append(result.Errors, ValidationError{
+					Field:   fmt.Sprintf("cli_providers.%s.version", name),
+					Value:   cli.Version,
+					Message: "must be a version such as 1.0.44 or a constraint such as >=1.0.30,<2",
+				})
+			}
+		}
+	}
+
+	// Validate additional directories
+	for i, dir := range c.AdditionalDirectories {
+		if strings.TrimSpace(dir) == "" {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("additional_directories[%d]", i),
+				Value:   dir,
+				Message: "must not be empty",
+			})
+		}
+	}
+
 	// Validate plugin paths exist
 	for i, path := range c.PluginPaths {
 		if _, err := os.Stat(path); os.IsNotExist(err) {
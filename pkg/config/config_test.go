@@ -1,6 +1,11 @@
 package config
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -188,6 +193,11 @@ func TestConfigSet(t *testing.T) {
 	if !cfg.Verbose {
 		t.Error("expected verbose to be true")
 	}
+
+	cfg.Set("append_system_prompt", "Answer in French.")
+	if cfg.GetString("append_system_prompt") != "Answer in French." {
+		t.Errorf("expected append_system_prompt to be set, got %q", cfg.AppendSystemPrompt)
+	}
 }
 
 func TestConfigGetString(t *testing.T) {
@@ -254,3 +264,525 @@ func TestValidationError(t *testing.T) {
 		t.Error("error string should not be empty")
 	}
 }
+
+func TestConfigValidate_ToolLimits(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ToolLimits = map[string]ToolLimitConfig{
+		"Bash": {Timeout: "2m", MaxOutputBytes: 50000},
+		"Read": {Timeout: "soon"},
+		"Grep": {MaxOutputBytes: -1},
+	}
+
+	result := cfg.Validate()
+	if len(result.Errors) != 2 {
+		t.Errorf("expected 2 tool limit errors, got %v", result.Errors)
+	}
+}
+
+func TestMergeToolLimits(t *testing.T) {
+	global := DefaultConfig()
+	global.ToolLimits = map[string]ToolLimitConfig{"*": {Timeout: "5m"}, "Bash": {Timeout: "1m"}}
+	project := DefaultConfig()
+	project.ToolLimits = map[string]ToolLimitConfig{"Bash": {Timeout: "10m"}}
+
+	cm := &ConfigManager{globalConfig: global, projectConfig: project}
+	merged := cm.merge()
+
+	if merged.ToolLimits["*"].Timeout != "5m" || merged.ToolLimits["Bash"].Timeout != "10m" {
+		t.Errorf("unexpected merged tool limits: %+v", merged.ToolLimits)
+	}
+}
+
+func TestConfigValidate_CLIProviders(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CLIProviders = map[string]CLIProviderConfig{
+		"claudecli": {Path: "/opt/claude/bin/claude", Version: ">=1.0.30,<2"},
+		"codexcli":  {Version: "0.44"},
+		"geminicli": {Version: "latest"},
+		"ollama":    {Path: "/usr/bin/ollama"},
+	}
+
+	result := cfg.Validate()
+	if len(result.Errors) != 2 {
+		t.Errorf("expected 2 cli provider errors, got %v", result.Errors)
+	}
+}
+
+func TestConfigWebSearch(t *testing.T) {
+	global := DefaultConfig()
+	global.WebSearch = map[string]string{"claude": "native", "gemini": "native"}
+	project := &Config{WebSearch: map[string]string{"gemini": "local"}}
+
+	merged := DefaultConfig()
+	copyConfig(global, merged)
+	mergeConfig(project, merged)
+	if merged.WebSearch["claude"] != "native" || merged.WebSearch["gemini"] != "local" {
+		t.Errorf("unexpected merged web search: %v", merged.WebSearch)
+	}
+
+	if err := merged.Set("web_search.claude", "remote"); err != nil {
+		t.Fatal(err)
+	}
+	if got := merged.GetString("web_search.claude"); got != "remote" {
+		t.Errorf("web_search.claude = %q", got)
+	}
+	if result := merged.Validate(); len(result.Errors) != 1 {
+		t.Errorf("expected 1 error, got %v", result.Errors)
+	}
+}
+
+func TestConfigValidate_Budget(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MonthlyBudgetUSD = -1
+	cfg.BudgetAlerts = []float64{80, 0}
+
+	result := cfg.Validate()
+	if len(result.Errors) != 2 {
+		t.Errorf("expected 2 budget errors, got %v", result.Errors)
+	}
+
+	cfg = DefaultConfig()
+	cfg.BudgetModel = "haiku"
+	if result := cfg.Validate(); !result.HasWarnings() {
+		t.Error("expected warning for budget settings without a budget")
+	}
+}
+
+func TestMergeBudget(t *testing.T) {
+	global := DefaultConfig()
+	global.MonthlyBudgetUSD = 50
+	global.BudgetEnforce = true
+	project := DefaultConfig()
+	project.BudgetModel = "haiku"
+
+	cm := &ConfigManager{globalConfig: global, projectConfig: project}
+	merged := cm.merge()
+
+	if merged.MonthlyBudgetUSD != 50 || merged.BudgetModel != "haiku" || !merged.BudgetEnforce {
+		t.Errorf("unexpected merged budget: %v %q %v", merged.MonthlyBudgetUSD, merged.BudgetModel, merged.BudgetEnforce)
+	}
+}
+
+func TestConfigValidate_ThinkingDisplay(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ThinkingDisplay = "collapse"
+	if result := cfg.Validate(); !result.IsValid() {
+		t.Errorf("expected collapse to be valid, got %v", result.Errors)
+	}
+	cfg.ThinkingDisplay = "fold"
+	if result := cfg.Validate(); result.IsValid() {
+		t.Error("expected error for unknown thinking display")
+	}
+}
+
+func TestConfigValidate_AdditionalDirectories(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdditionalDirectories = []string{"~/shared", " "}
+
+	result := cfg.Validate()
+	if len(result.Errors) != 1 {
+		t.Errorf("expected 1 additional directory error, got %v", result.Errors)
+	}
+}
+
+func TestMergeAdditionalDirectories(t *testing.T) {
+	global := DefaultConfig()
+	global.AdditionalDirectories = []string{"~/shared"}
+	project := DefaultConfig()
+	project.AdditionalDirectories = []string{"../sibling", "~/shared"}
+
+	cm := &ConfigManager{globalConfig: global, projectConfig: project}
+	merged := cm.merge()
+
+	if !reflect.DeepEqual(merged.AdditionalDirectories, []string{"~/shared", "../sibling"}) {
+		t.Errorf("unexpected merged additional directories: %v", merged.AdditionalDirectories)
+	}
+}
+
+func TestConfigValidate_ProcessLimits(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ProcessLimits = ProcessLimitConfig{MaxBackground: -1, MaxProcesses: 128, MaxCPUTime: "none"}
+	if result := cfg.Validate(); len(result.Errors) != 0 {
+		t.Errorf("expected valid process limits, got %v", result.Errors)
+	}
+
+	cfg.ProcessLimits = ProcessLimitConfig{MaxBackground: -2, MaxProcesses: -5, MaxCPUTime: "forever"}
+	if result := cfg.Validate(); len(result.Errors) != 3 {
+		t.Errorf("expected 3 process limit errors, got %v", result.Errors)
+	}
+}
+
+func TestMergeProcessLimits(t *testing.T) {
+	global := DefaultConfig()
+	global.ProcessLimits = ProcessLimitConfig{MaxBackground: 2, MaxCPUTime: "1h"}
+	project := DefaultConfig()
+	project.ProcessLimits = ProcessLimitConfig{MaxProcesses: 16}
+
+	cm := &ConfigManager{globalConfig: global, projectConfig: project}
+	merged := cm.merge()
+
+	want := ProcessLimitConfig{MaxBackground: 2, MaxProcesses: 16, MaxCPUTime: "1h"}
+	if merged.ProcessLimits != want {
+		t.Errorf("unexpected merged process limits: %+v", merged.ProcessLimits)
+	}
+}
+
+func TestConfigStaleResultTurns(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Set("stale_result_turns", 8)
+	if got := cfg.GetInt("stale_result_turns"); got != 8 {
+		t.Errorf("expected 8, got %d", got)
+	}
+	if result := cfg.Validate(); len(result.Errors) != 0 {
+		t.Errorf("expected a valid config, got %v", result.Errors)
+	}
+
+	cfg.StaleResultTurns = -1
+	if result := cfg.Validate(); len(result.Errors) != 1 {
+		t.Errorf("expected 1 error, got %v", result.Errors)
+	}
+
+	project := DefaultConfig()
+	project.StaleResultTurns = 4
+	cm := &ConfigManager{globalConfig: DefaultConfig(), projectConfig: project}
+	if merged := cm.merge(); merged.StaleResultTurns != 4 {
+		t.Errorf("expected the project setting to apply, got %d", merged.StaleResultTurns)
+	}
+}
+
+func TestConfigAccessible(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Set("accessible", true)
+	if !cfg.GetBool("accessible") {
+		t.Error("expected accessible to be set")
+	}
+
+	global := DefaultConfig()
+	global.Accessible = true
+	cm := &ConfigManager{globalConfig: global, projectConfig: DefaultConfig()}
+	if merged := cm.merge(); !merged.Accessible {
+		t.Error("expected a project config not to turn off accessible output")
+	}
+}
+
+func TestConfigTranscriptSigning(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Set("transcript_signing", true)
+	if !cfg.GetBool("transcript_signing") {
+		t.Error("expected transcript_signing to be set")
+	}
+
+	global := DefaultConfig()
+	global.TranscriptSigning = true
+	cm := &ConfigManager{globalConfig: global, projectConfig: DefaultConfig()}
+	if merged := cm.merge(); !merged.TranscriptSigning {
+		t.Error("expected a project config not to turn off transcript signing")
+	}
+}
+
+func TestConfigRemote(t *testing.T) {
+	global := DefaultConfig()
+	global.Remote = RemoteConfig{Host: "old", Port: 2222}
+	project := &Config{Remote: RemoteConfig{Host: "deploy@build", Dir: "/srv/app"}}
+	cm := &ConfigManager{globalConfig: global, projectConfig: project}
+	merged := cm.merge()
+	if merged.Remote.Host != "deploy@build" || merged.Remote.Dir != "/srv/app" || merged.Remote.Port != 0 {
+		t.Errorf("expected the project's remote to replace the global one, got %+v", merged.Remote)
+	}
+	if result := merged.Validate(); len(result.Errors) != 0 {
+		t.Errorf("expected a valid config, got %v", result.Errors)
+	}
+
+	merged.Remote = RemoteConfig{Host: "-oProxyCommand=sh", Dir: "srv", Port: 70000}
+	if result := merged.Validate(); len(result.Errors) != 3 {
+		t.Errorf("expected 3 errors, got %v", result.Errors)
+	}
+}
+
+func TestConfigDevcontainer(t *testing.T) {
+	global := DefaultConfig()
+	global.Devcontainer.Exec = "docker"
+	project := &Config{Devcontainer: DevcontainerConfig{Enabled: true}}
+	cm := &ConfigManager{globalConfig: global, projectConfig: project}
+	merged := cm.merge()
+	if !merged.Devcontainer.Enabled || merged.Devcontainer.Exec != "docker" {
+		t.Errorf("unexpected devcontainer settings %+v", merged.Devcontainer)
+	}
+
+	merged.Devcontainer.Exec = "podman"
+	if result := merged.Validate(); len(result.Errors) != 1 {
+		t.Errorf("expected 1 error, got %v", result.Errors)
+	}
+}
+
+func TestConfigEnv(t *testing.T) {
+	global := DefaultConfig()
+	global.Env = EnvConfig{Allow: []string{"NPM_TOKEN"}, Deny: []string{"AWS_*"}}
+	project := &Config{Env: EnvConfig{Mode: "denylist", Allow: []string{"BUILD_*", "NPM_TOKEN"}, Container: []string{"GOFLAGS"}}}
+	cm := &ConfigManager{globalConfig: global, projectConfig: project}
+	merged := cm.merge()
+	want := EnvConfig{Mode: "denylist", Allow: []string{"NPM_TOKEN", "BUILD_*"}, Deny: []string{"AWS_*"}, Container: []string{"GOFLAGS"}}
+	if !reflect.DeepEqual(merged.Env, want) {
+		t.Errorf("expected %+v, got %+v", want, merged.Env)
+	}
+	if result := merged.Validate(); len(result.Errors) != 0 {
+		t.Errorf("expected a valid config, got %v", result.Errors)
+	}
+
+	merged.Env = EnvConfig{Mode: "all", Allow: []string{"A=B", "*"}, Container: []string{"GO*"}}
+	if result := merged.Validate(); len(result.Errors) != 4 {
+		t.Errorf("expected 4 errors, got %v", result.Errors)
+	}
+}
+
+func TestConfigBackgroundPane(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Set("background_pane", "wezterm cli split-pane -- tail -f {log}")
+	if got := cfg.GetString("background_pane"); got != "wezterm cli split-pane -- tail -f {log}" {
+		t.Errorf("unexpected background_pane %q", got)
+	}
+	if result := cfg.Validate(); len(result.Errors) != 0 {
+		t.Errorf("expected a valid config, got %v", result.Errors)
+	}
+
+	cfg.BackgroundPane = "screen"
+	if result := cfg.Validate(); len(result.Errors) != 1 {
+		t.Errorf("expected 1 error, got %v", result.Errors)
+	}
+}
+
+func TestConfigDestructiveActions(t *testing.T) {
+	global := DefaultConfig()
+	global.DestructiveActions = DestructiveConfig{
+		Approval: "model",
+		Rules:    []DestructiveRuleConfig{{Name: "deploy", Pattern: `\bmake deploy\b`}},
+	}
+	project := &Config{DestructiveActions: DestructiveConfig{
+		Rules:        []DestructiveRuleConfig{{Name: "seed", Pattern: `db:seed`}},
+		DisableRules: []string{"sql-write"},
+	}}
+
+	merged := DefaultConfig()
+	copyConfig(global, merged)
+	mergeConfig(project, merged)
+	if got := merged.DestructiveActions; got.Approval != "model" || len(got.Rules) != 2 || len(got.DisableRules) != 1 {
+		t.Errorf("unexpected merged destructive_actions: %+v", got)
+	}
+	if result := merged.Validate(); len(result.Errors) != 0 {
+		t.Errorf("expected a valid config, got %v", result.Errors)
+	}
+
+	merged.DestructiveActions.Approval = "never"
+	merged.DestructiveActions.Rules = append(merged.DestructiveActions.Rules, DestructiveRuleConfig{Pattern: "("})
+	if result := merged.Validate(); len(result.Errors) != 3 {
+		t.Errorf("expected 3 errors, got %v", result.Errors)
+	}
+}
+
+func TestConfigRelease(t *testing.T) {
+	global := DefaultConfig()
+	global.Release = ReleaseConfig{TestCommand: "make test", Platforms: []string{"linux/amd64"}}
+	project := &Config{Release: ReleaseConfig{
+		Main:      "./cmd/tool",
+		TagPrefix: "none",
+		Platforms: []string{"linux/amd64", "darwin/arm64"},
+	}}
+
+	merged := DefaultConfig()
+	copyConfig(global, merged)
+	mergeConfig(project, merged)
+	if got := merged.Release; got.TestCommand != "make test" || got.Main != "./cmd/tool" || got.TagPrefix != "none" || len(got.Platforms) != 2 {
+		t.Errorf("unexpected merged release: %+v", got)
+	}
+	if result := merged.Validate(); len(result.Errors) != 0 {
+		t.Errorf("expected a valid config, got %v", result.Errors)
+	}
+
+	merged.Release.Platforms = []string{"linux", "darwin/arm64/v8"}
+	merged.Release.VersionVar = "main.Version"
+	if result := merged.Validate(); len(result.Errors) != 3 {
+		t.Errorf("expected 3 errors, got %v", result.Errors)
+	}
+}
+
+func TestConfigVoice(t *testing.T) {
+	global := DefaultConfig()
+	global.Voice = VoiceConfig{WhisperModel: "~/models/ggml-base.en.bin", Language: "en"}
+	project := &Config{Voice: VoiceConfig{Transcriber: "local", Language: "de", Speak: true, Speaker: "system"}}
+
+	merged := DefaultConfig()
+	copyConfig(global, merged)
+	mergeConfig(project, merged)
+	if got := merged.Voice; got.WhisperModel != "~/models/ggml-base.en.bin" || got.Transcriber != "local" || got.Language != "de" || !got.Speak || got.Speaker != "system" {
+		t.Errorf("unexpected merged voice: %+v", got)
+	}
+	if result := merged.Validate(); len(result.Errors) != 0 {
+		t.Errorf("expected a valid config, got %v", result.Errors)
+	}
+
+	merged.Voice.Transcriber = "cloud"
+	merged.Voice.Recorder = "rec out.wav"
+	merged.Voice.Speaker = "robot"
+	if result := merged.Validate(); len(result.Errors) != 3 {
+		t.Errorf("expected 3 errors, got %v", result.Errors)
+	}
+}
+
+func TestSaveMCPServers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if servers, err := LoadMCPServers(path); err != nil || servers != nil {
+		t.Fatalf("expected no servers from a missing file, got %v, %v", servers, err)
+	}
+	if err := os.WriteFile(path, []byte(`{"default_model": "opus", "custom": {"a": 1}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	servers := []MCPServerConfig{{Name: "fs", Type: "stdio", Command: "npx", Args: []string{"server"}, AutoStart: true}}
+	if err := SaveMCPServers(path, servers); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadMCPServers(path)
+	if err != nil || !reflect.DeepEqual(got, servers) {
+		t.Errorf("expected %+v, got %+v, %v", servers, got, err)
+	}
+
+	cfg := &Config{}
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, cfg); err != nil || cfg.DefaultModel != "opus" || cfg.MaxTokens != 0 {
+		t.Errorf("expected other settings kept as written, got %s", data)
+	}
+
+	if err := SaveMCPServers(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := LoadMCPServers(path); len(got) != 0 {
+		t.Errorf("expected the servers removed, got %+v", got)
+	}
+}
+
+func TestConfigModelAliases(t *testing.T) {
+	global := DefaultConfig()
+	global.ModelAliases = map[string]string{"fast": "gemini-1.5-flash", "local": "ollama:qwen3"}
+	project := &Config{ModelAliases: map[string]string{"fast": "haiku"}}
+
+	merged := DefaultConfig()
+	copyConfig(global, merged)
+	mergeConfig(project, merged)
+	if merged.ModelAliases["fast"] != "haiku" || merged.ModelAliases["local"] != "ollama:qwen3" {
+		t.Errorf("unexpected merged aliases: %v", merged.ModelAliases)
+	}
+
+	// An alias is a known default model
+	merged.DefaultModel = "local"
+	if result := merged.Validate(); len(result.Errors) != 0 || len(result.Warnings) != 0 {
+		t.Errorf("expected no errors or warnings, got %v %v", result.Errors, result.Warnings)
+	}
+
+	if err := merged.Set("model_aliases.bad name", "opus"); err != nil {
+		t.Fatal(err)
+	}
+	if err := merged.Set("model_aliases.loop", "loop"); err != nil {
+		t.Fatal(err)
+	}
+	if got := merged.GetString("model_aliases.fast"); got != "haiku" {
+		t.Errorf("model_aliases.fast = %q", got)
+	}
+	if result := merged.Validate(); len(result.Errors) != 2 {
+		t.Errorf("expected 2 errors, got %v", result.Errors)
+	}
+}
+
+func TestConfigSampling(t *testing.T) {
+	seed := int64(0)
+	global := DefaultConfig()
+	global.TopP, global.TopK, global.Seed = 0.9, 40, &seed
+	project := &Config{TopK: 20}
+
+	merged := DefaultConfig()
+	copyConfig(global, merged)
+	mergeConfig(project, merged)
+	if merged.TopP != 0.9 || merged.TopK != 20 || merged.Seed == nil || *merged.Seed != 0 {
+		t.Errorf("unexpected merged sampling: %v %v %v", merged.TopP, merged.TopK, merged.Seed)
+	}
+
+	merged.Set("seed", 42)
+	merged.Set("top_p", 1.5)
+	merged.Set("top_k", -1)
+	if *merged.Seed != 42 || merged.GetInt("top_k") != -1 {
+		t.Errorf("unexpected sampling after Set: %v %v", *merged.Seed, merged.TopK)
+	}
+	if result := merged.Validate(); len(result.Errors) != 2 {
+		t.Errorf("expected 2 errors, got %v", result.Errors)
+	}
+}
+
+func TestSaveModelAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"default_model": "fast"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	aliases := map[string]string{"fast": "gemini-1.5-flash"}
+	if err := SaveModelAliases(path, aliases); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadModelAliases(path)
+	if err != nil || !reflect.DeepEqual(got, aliases) {
+		t.Errorf("expected %v, got %v, %v", aliases, got, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"default_model": "fast"`) {
+		t.Errorf("expected other settings kept, got %s", data)
+	}
+
+	if err := SaveModelAliases(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "model_aliases") {
+		t.Errorf("expected the aliases removed, got %s", data)
+	}
+}
+
+func TestSaveOutputStyle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"model_aliases": {"fast": "gpt-4o-mini"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveOutputStyle(path, "explanatory"); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"model_aliases": map[string]interface{}{"fast": "gpt-4o-mini"},
+		"output_style":  "explanatory",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected only the output style added, got %s", data)
+	}
+}
+
+func TestProjectState(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	state, err := LoadProjectState("/work/repo")
+	if err != nil || state.MCPTrust != nil {
+		t.Fatalf("expected an empty state, got %+v, %v", state, err)
+	}
+	state.MCPTrust = &MCPTrust{Trusted: true, Hash: "abc", Servers: []string{"fs"}}
+	if err := SaveProjectState("/work/repo", state); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadProjectState("/work/repo")
+	if err != nil || got.MCPTrust == nil || !got.MCPTrust.Trusted || got.MCPTrust.Hash != "abc" {
+		t.Errorf("unexpected state: %+v, %v", got, err)
+	}
+	if other, _ := LoadProjectState("/work/other"); other.MCPTrust != nil {
+		t.Errorf("expected the state of another project to be empty, got %+v", other.MCPTrust)
+	}
+}
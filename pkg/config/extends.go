@@ -0,0 +1,291 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sha256Pattern matches a hex encoded sha256 checksum
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// Limits on the configs a config file extends
+const (
+	extendsCacheTTL  = 24 * time.Hour // cached unpinned remote configs are updated after this
+	extendsTimeout   = 10 * time.Second
+	maxExtendsDepth  = 5
+	maxExtendsSize   = 1 << 20
+	extendsPinPrefix = "#sha256="
+)
+
+// BaseConfig is a config that a config file extends, directly or through
+// another base
+type BaseConfig struct {
+	Source   string // absolute path or URL
+	Checksum string // sha256 of the content, hex encoded
+	Pinned   bool   // the entry pins the checksum
+	Cached   bool   // a remote config read from the cache instead of fetched
+}
+
+// extendsLoader reads the configs that config files extend, caching remote
+// ones under cacheDir
+type extendsLoader struct {
+	cacheDir string
+	client   *http.Client
+	refresh  bool // fetch unpinned remote configs even when the cache is fresh
+
+	bases    []BaseConfig
+	warnings []string
+}
+
+// withBases returns cfg, read from the file at source, over the configs it
+// extends, or cfg itself when it extends none. The files are merged as
+// JSON: objects key by key, and other values, lists included, replaced by
+// the later file.
+func (l *extendsLoader) withBases(cfg *Config, source string) *Config {
+	if len(cfg.Extends) == 0 {
+		return cfg
+	}
+	data, err := os.ReadFile(source)
+	var raw map[string]interface{}
+	if err == nil {
+		err = json.Unmarshal(data, &raw)
+	}
+	if err == nil {
+		data, err = json.Marshal(l.resolve(raw, source, []string{source}))
+	}
+	view := DefaultConfig()
+	if err == nil {
+		err = json.Unmarshal(data, view)
+	}
+	if err != nil {
+		l.warnings = append(l.warnings, fmt.Sprintf("%s: extends: %v", source, err))
+		return cfg
+	}
+	view.configPath = cfg.configPath
+	return view
+}
+
+// resolve merges raw, read from the file or URL from, over the configs it
+// extends
+func (l *extendsLoader) resolve(raw map[string]interface{}, from string, chain []string) map[string]interface{} {
+	merged := make(map[string]interface{})
+	entries, _ := raw["extends"].([]interface{})
+	for _, e := range entries {
+		entry, _ := e.(string)
+		ref, checksum, pinned := strings.Cut(entry, extendsPinPrefix)
+		source, err := resolveExtends(from, ref)
+		if err == nil && slices.Contains(chain, source) {
+			err = fmt.Errorf("extends itself through %s", strings.Join(chain, " -> "))
+		}
+		if err == nil && len(chain) > maxExtendsDepth {
+			err = fmt.Errorf("extends more than %d levels deep", maxExtendsDepth)
+		}
+		var data []byte
+		if err == nil {
+			data, err = l.read(source, strings.ToLower(checksum), pinned)
+		}
+		var base map[string]interface{}
+		if err == nil {
+			if err = json.Unmarshal(data, &base); err != nil {
+				err = fmt.Errorf("failed to parse config: %w", err)
+			}
+		}
+		if err != nil {
+			l.warnings = append(l.warnings, fmt.Sprintf("%s: extends %s: %v", from, ref, err))
+			continue
+		}
+		mergeJSON(merged, l.resolve(base, source, append(chain, source)))
+	}
+	mergeJSON(merged, raw)
+	return merged
+}
+
+// mergeJSON merges src into dst: objects key by key, other values replaced
+func mergeJSON(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if sub, ok := v.(map[string]interface{}); ok {
+			if existing, ok := dst[k].(map[string]interface{}); ok {
+				copied := make(map[string]interface{}, len(existing))
+				mergeJSON(copied, existing)
+				mergeJSON(copied, sub)
+				dst[k] = copied
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
+
+// resolveExtends resolves an extends entry against the file or URL it
+// appears in
+func resolveExtends(from, ref string) (string, error) {
+	if strings.HasPrefix(ref, "http://") {
+		return "", fmt.Errorf("remote configs must use https")
+	}
+	if strings.HasPrefix(ref, "https://") {
+		return ref, nil
+	}
+	if isRemote(from) {
+		base, err := url.Parse(from)
+		if err != nil {
+			return "", err
+		}
+		rel, err := url.Parse(ref)
+		if err != nil {
+			return "", err
+		}
+		return base.ResolveReference(rel).String(), nil
+	}
+	if rest, ok := strings.CutPrefix(ref, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		ref = filepath.Join(home, rest)
+	}
+	if !filepath.IsAbs(ref) {
+		ref = filepath.Join(filepath.Dir(from), ref)
+	}
+	return filepath.Clean(ref), nil
+}
+
+func isRemote(source string) bool {
+	return strings.HasPrefix(source, "https://")
+}
+
+// read returns the content of a base config, checking it against the
+// pinned checksum. Remote configs come from the cache when it has a copy,
+// unless pinned to another checksum; a stale copy is updated in the
+// background, and used when it cannot be fetched.
+func (l *extendsLoader) read(source, checksum string, pinned bool) ([]byte, error) {
+	base := BaseConfig{Source: source, Pinned: pinned}
+	var data []byte
+	var err error
+	if !isRemote(source) {
+		data, err = os.ReadFile(source)
+	} else {
+		data, base.Cached, err = l.fetch(source, checksum, pinned)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	base.Checksum = hex.EncodeToString(sum[:])
+	if pinned && base.Checksum != checksum {
+		return nil, fmt.Errorf("checksum mismatch: got sha256 %s, pinned %s", base.Checksum, checksum)
+	}
+	l.bases = append(l.bases, base)
+	return data, nil
+}
+
+// fetch returns a remote config, from the cache when possible, and
+// whether it came from the cache
+func (l *extendsLoader) fetch(source, checksum string, pinned bool) ([]byte, bool, error) {
+	key := sha256.Sum256([]byte(source))
+	cachePath := filepath.Join(l.cacheDir, hex.EncodeToString(key[:8])+".json")
+	cached, cacheErr := os.ReadFile(cachePath)
+	if cacheErr == nil && !l.refresh {
+		sum := sha256.Sum256(cached)
+		if pinned && hex.EncodeToString(sum[:]) == checksum {
+			return cached, true, nil
+		}
+		if !pinned {
+			// A stale copy is used as is and updated in the background
+			// for the next run, so that a slow or missing network does
+			// not hold up startup
+			if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) >= extendsCacheTTL {
+				go l.update(source, cachePath)
+			}
+			return cached, true, nil
+		}
+	}
+
+	data, err := l.downloadOnce(source)
+	if err != nil {
+		if cacheErr == nil {
+			l.warnings = append(l.warnings, fmt.Sprintf("using the cached copy of %s: %v", source, err))
+			return cached, true, nil
+		}
+		return nil, false, err
+	}
+	if sum := sha256.Sum256(data); !pinned || hex.EncodeToString(sum[:]) == checksum {
+		l.store(cachePath, data)
+	}
+	return data, false, nil
+}
+
+// update fetches an unpinned remote config into the cache
+func (l *extendsLoader) update(source, cachePath string) {
+	if data, err := l.downloadOnce(source); err == nil {
+		l.store(cachePath, data)
+	}
+}
+
+func (l *extendsLoader) store(cachePath string, data []byte) {
+	if err := os.MkdirAll(l.cacheDir, 0755); err == nil {
+		os.WriteFile(cachePath, data, 0644)
+	}
+}
+
+// downloads are the remote configs fetched by this process. A run loads
+// its config many times, and each URL is fetched at most once, so that an
+// unreachable one costs a single timeout.
+var downloads = struct {
+	sync.Mutex
+	m map[string]*download
+}{m: make(map[string]*download)}
+
+type download struct {
+	once sync.Once
+	data []byte
+	err  error
+}
+
+// downloadOnce returns source as this process first fetched it
+func (l *extendsLoader) downloadOnce(source string) ([]byte, error) {
+	downloads.Lock()
+	d, ok := downloads.m[source]
+	if !ok {
+		d = &download{}
+		downloads.m[source] = d
+	}
+	downloads.Unlock()
+	d.once.Do(func() { d.data, d.err = l.download(source) })
+	return d.data, d.err
+}
+
+func (l *extendsLoader) download(source string) ([]byte, error) {
+	client := l.client
+	if client == nil {
+		client = &http.Client{Timeout: extendsTimeout}
+	}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxExtendsSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxExtendsSize {
+		return nil, fmt.Errorf("larger than %d bytes", maxExtendsSize)
+	}
+	return data, nil
+}
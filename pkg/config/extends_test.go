@@ -0,0 +1,193 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestExtendsLocal(t *testing.T) {
+	dir := t.TempDir()
+	shared := `{"extends": ["rules.json"], "theme": "light", "destructive_actions": {"disable_rules": ["find-delete"]}}`
+	writeConfig(t, filepath.Join(dir, "shared", "agentic.json"), shared)
+	writeConfig(t, filepath.Join(dir, "shared", "rules.json"), `{"theme": "dark", "log_level": "debug", "allowed_tools": ["Read"]}`)
+	writeConfig(t, filepath.Join(dir, "home", "config.json"),
+		`{"extends": ["../shared/agentic.json#sha256=`+checksum(shared)+`"], "default_model": "opus"}`)
+	project := filepath.Join(dir, "project")
+	writeConfig(t, GetProjectConfigPath(project), `{"extends": ["../../shared/rules.json", "missing.json"], "log_level": "warn"}`)
+
+	cm := &ConfigManager{globalPath: filepath.Join(dir, "home", "config.json"), cacheDir: filepath.Join(dir, "cache")}
+	if err := cm.Load(project); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := cm.Get()
+	if cfg.LogLevel != "warn" || !slices.Equal(cfg.AllowedTools, []string{"Read"}) {
+		t.Errorf("unexpected merged config: log level %q, allowed tools %v", cfg.LogLevel, cfg.AllowedTools)
+	}
+	// A config overrides the configs it extends
+	if global := cm.Global(); global.Theme != "light" || global.DefaultModel != "opus" || global.LogLevel != "debug" || !slices.Contains(global.DestructiveActions.DisableRules, "find-delete") {
+		t.Errorf("expected the global config to include its bases, got theme %q and %v", global.Theme, global.DestructiveActions)
+	}
+	// The project's bases override the global config
+	if cfg.Theme != "dark" {
+		t.Errorf("expected the project's base to override the global theme, got %q", cfg.Theme)
+	}
+	// Saving writes only the file's own settings
+	if cm.globalConfig.Theme != "dark" || len(cm.globalConfig.Extends) != 1 {
+		t.Errorf("the global config should be left as read, got %+v", cm.globalConfig.Theme)
+	}
+
+	if len(cm.Bases()) != 3 || !cm.Bases()[0].Pinned {
+		t.Errorf("unexpected bases: %+v", cm.Bases())
+	}
+	if warnings := cm.Warnings(); len(warnings) != 1 || !strings.Contains(warnings[0], "missing.json") {
+		t.Errorf("expected a warning for the missing base, got %v", warnings)
+	}
+}
+
+func TestExtendsChecksumAndCycles(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, filepath.Join(dir, "a.json"), `{"extends": ["b.json"], "theme": "light"}`)
+	writeConfig(t, filepath.Join(dir, "b.json"), `{"extends": ["a.json"]}`)
+	writeConfig(t, filepath.Join(dir, "config.json"),
+		`{"extends": ["a.json", "b.json#sha256=`+strings.Repeat("0", 64)+`"]}`)
+
+	cm := &ConfigManager{globalPath: filepath.Join(dir, "config.json"), cacheDir: filepath.Join(dir, "cache")}
+	if err := cm.Load(""); err != nil {
+		t.Fatal(err)
+	}
+	warnings := strings.Join(cm.Warnings(), "\n")
+	if !strings.Contains(warnings, "extends itself") || !strings.Contains(warnings, "checksum mismatch") {
+		t.Errorf("expected cycle and checksum warnings, got:\n%s", warnings)
+	}
+	if cm.Get().Theme != "light" {
+		t.Errorf("expected the usable base to apply, got theme %q", cm.Get().Theme)
+	}
+}
+
+func TestExtendsRemote(t *testing.T) {
+	base := `{"extends": ["rules.json"], "hooks": [{"event": "PreToolUse", "matcher": "Bash", "command": "audit"}]}`
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/team/base.json":
+			w.Write([]byte(base))
+		case "/team/rules.json":
+			w.Write([]byte(`{"mcp_servers": [{"name": "docs", "type": "http", "url": "https://docs.test/mcp"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	dir := t.TempDir()
+	writeConfig(t, filepath.Join(dir, "config.json"),
+		`{"extends": ["`+server.URL+`/team/base.json#sha256=`+checksum(base)+`", "http://insecure.test/x.json"]}`)
+	load := func() *ConfigManager {
+		cm := &ConfigManager{globalPath: filepath.Join(dir, "config.json"), cacheDir: filepath.Join(dir, "cache"), client: server.Client()}
+		if err := cm.Load(""); err != nil {
+			t.Fatal(err)
+		}
+		return cm
+	}
+
+	cm := load()
+	cfg := cm.Get()
+	if len(cfg.Hooks) != 1 || len(cfg.MCPServers) != 1 {
+		t.Fatalf("expected the remote hooks and MCP servers, got %+v %+v", cfg.Hooks, cfg.MCPServers)
+	}
+	if warnings := cm.Warnings(); len(warnings) != 1 || !strings.Contains(warnings[0], "https") {
+		t.Errorf("expected plain http to be refused, got %v", warnings)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+
+	// Later loads use the cache, even when the server is gone
+	server.Close()
+	cm = load()
+	if len(cm.Get().MCPServers) != 1 || !cm.Bases()[0].Cached || !cm.Bases()[1].Cached {
+		t.Errorf("expected cached bases, got %+v", cm.Bases())
+	}
+
+	result := cm.Global().Validate()
+	if len(result.Errors) != 1 || result.Errors[0].Field != "extends" {
+		t.Errorf("expected an error for the http entry, got %v", result.Errors)
+	}
+}
+
+func TestExtendsRemoteFetchedOnce(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow.json":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte(`{"log_level": "warn"}`))
+		default:
+			requests++
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	load := func() *ConfigManager {
+		cm := &ConfigManager{globalPath: path, cacheDir: filepath.Join(dir, "cache"), client: server.Client()}
+		if err := cm.Load(""); err != nil {
+			t.Fatal(err)
+		}
+		return cm
+	}
+
+	// A URL that failed is not fetched again by later loads
+	writeConfig(t, path, `{"extends": ["`+server.URL+`/missing.json"]}`)
+	for i := 0; i < 3; i++ {
+		load()
+	}
+	if requests != 1 {
+		t.Errorf("expected the URL to be fetched once, got %d requests", requests)
+	}
+
+	// A stale cached copy is used without waiting, and updated after
+	source := server.URL + "/slow.json"
+	key := sha256.Sum256([]byte(source))
+	cachePath := filepath.Join(dir, "cache", hex.EncodeToString(key[:8])+".json")
+	writeConfig(t, cachePath, `{"log_level": "error"}`)
+	stale := time.Now().Add(-2 * extendsCacheTTL)
+	os.Chtimes(cachePath, stale, stale)
+	writeConfig(t, path, `{"extends": ["`+source+`"]}`)
+	start := time.Now()
+	if cm := load(); cm.Get().LogLevel != "error" || time.Since(start) > 100*time.Millisecond {
+		t.Errorf("expected the stale copy at once, got %q after %v", cm.Get().LogLevel, time.Since(start))
+	}
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if data, _ := os.ReadFile(cachePath); strings.Contains(string(data), "warn") {
+			return
+		}
+	}
+	t.Error("expected the stale copy to be updated in the background")
+}
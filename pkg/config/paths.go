@@ -41,6 +41,52 @@ func GetProjectSessionsDir(projectPath string) (string, error) {
 	return filepath.Join(sessionsDir, sanitizePath(projectPath)), nil
 }
 
+// GetProjectBenchmarksDir returns the project-specific benchmark baselines directory
+func GetProjectBenchmarksDir(projectPath string) (string, error) {
+	appDir, err := GetAppDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(appDir, "benchmarks", sanitizePath(projectPath)), nil
+}
+
+// GetProjectProfilesDir returns the project-specific profiles directory
+func GetProjectProfilesDir(projectPath string) (string, error) {
+	appDir, err := GetAppDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(appDir, "profiles", sanitizePath(projectPath)), nil
+}
+
+// GetUsageLedgerPath returns the cross-session usage ledger file
+func GetUsageLedgerPath() (string, error) {
+	appDir, err := GetAppDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(appDir, "usage.jsonl"), nil
+}
+
+// GetResponseCacheDir returns the directory of cached provider responses
+func GetResponseCacheDir() (string, error) {
+	appDir, err := GetAppDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(appDir, "cache", "responses"), nil
+}
+
+// GetTelemetryPath returns the file holding the telemetry setting and the
+// usage counts not yet sent
+func GetTelemetryPath() (string, error) {
+	appDir, err := GetAppDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(appDir, "telemetry.json"), nil
+}
+
 // GetConfigPath returns the global config file path
 func GetConfigPath() (string, error) {
 	appDir, err := GetAppDir()
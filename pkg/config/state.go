@@ -0,0 +1,84 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ProjectState is what agentic-coder remembers about a project outside of
+// the project's own files, which anyone with commit access can change
+type ProjectState struct {
+	MCPTrust    *MCPTrust    `json:"mcp_trust,omitempty"`
+	RemoteTrust *RemoteTrust `json:"remote_trust,omitempty"`
+}
+
+// MCPTrust records whether the user allowed the MCP servers a project
+// defines to start. It holds for the servers as they were when decided:
+// any change to them asks again.
+type MCPTrust struct {
+	Trusted   bool      `json:"trusted"`
+	Hash      string    `json:"hash"`
+	Servers   []string  `json:"servers"`
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// RemoteTrust records whether the user allowed the remote host a project
+// configures. Like MCPTrust, it holds only for the settings as they were
+// when decided.
+type RemoteTrust struct {
+	Trusted   bool      `json:"trusted"`
+	Hash      string    `json:"hash"`
+	Host      string    `json:"host"`
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// GetProjectStatePath returns the file holding a project's state
+func GetProjectStatePath(projectPath string) (string, error) {
+	appDir, err := GetAppDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(appDir, "projects", sanitizePath(projectPath)+".json"), nil
+}
+
+// LoadProjectState returns a project's state, empty if none was saved
+func LoadProjectState(projectPath string) (*ProjectState, error) {
+	path, err := GetProjectStatePath(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	state := &ProjectState{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// SaveProjectState saves a project's state
+func SaveProjectState(projectPath string, state *ProjectState) error {
+	path, err := GetProjectStatePath(projectPath)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal project state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write project state: %w", err)
+	}
+	return nil
+}
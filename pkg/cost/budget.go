@@ -0,0 +1,67 @@
+package cost
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultBudgetAlerts are the shares of the monthly budget that trigger a warning
+var DefaultBudgetAlerts = []float64{0.8, 1.0}
+
+// Budget caps spending per calendar month
+type Budget struct {
+	// Monthly is the budget in USD (0 = unlimited)
+	Monthly float64
+	// Alerts are shares of the budget, such as 0.8, that trigger a warning
+	Alerts []float64
+	// Model is a cheaper model to switch to once the budget is spent
+	Model string
+	// Enforce refuses paid providers once the budget is spent
+	Enforce bool
+}
+
+// Exceeded reports whether spent uses up the budget
+func (b *Budget) Exceeded(spent float64) bool {
+	return b != nil && b.Monthly > 0 && spent >= b.Monthly
+}
+
+// Crossed returns the highest alert threshold passed when spending grows from
+// before to after, or 0 if none was passed
+func (b *Budget) Crossed(before, after float64) float64 {
+	if b == nil || b.Monthly <= 0 {
+		return 0
+	}
+	alerts := b.Alerts
+	if len(alerts) == 0 {
+		alerts = DefaultBudgetAlerts
+	}
+	alerts = append([]float64(nil), alerts...)
+	sort.Float64s(alerts)
+
+	var crossed float64
+	for _, a := range alerts {
+		limit := a * b.Monthly
+		if before < limit && after >= limit {
+			crossed = a
+		}
+	}
+	return crossed
+}
+
+// MonthStart returns the start of the calendar month containing t
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// MonthSpend returns the cost recorded since the start of the month containing now
+func (l *Ledger) MonthSpend(now time.Time) (float64, error) {
+	records, err := l.Records(MonthStart(now))
+	if err != nil {
+		return 0, err
+	}
+	var spent float64
+	for _, r := range records {
+		spent += r.Cost
+	}
+	return spent, nil
+}
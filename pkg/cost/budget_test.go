@@ -0,0 +1,66 @@
+package cost
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBudgetCrossed(t *testing.T) {
+	budget := &Budget{Monthly: 100}
+	tests := []struct {
+		before, after float64
+		want          float64
+	}{
+		{0, 50, 0},
+		{50, 80, 0.8},
+		{79, 120, 1},
+		{80, 90, 0},
+		{100, 150, 0},
+	}
+	for _, tt := range tests {
+		if got := budget.Crossed(tt.before, tt.after); got != tt.want {
+			t.Errorf("Crossed(%v, %v) = %v, want %v", tt.before, tt.after, got, tt.want)
+		}
+	}
+
+	custom := &Budget{Monthly: 100, Alerts: []float64{0.9, 0.5}}
+	if got := custom.Crossed(0, 60); got != 0.5 {
+		t.Errorf("custom Crossed(0, 60) = %v, want 0.5", got)
+	}
+	if got := (&Budget{}).Crossed(0, 1000); got != 0 {
+		t.Errorf("unlimited budget crossed %v", got)
+	}
+}
+
+func TestBudgetExceeded(t *testing.T) {
+	var none *Budget
+	if none.Exceeded(1000) {
+		t.Error("nil budget should never be exceeded")
+	}
+	budget := &Budget{Monthly: 10}
+	if budget.Exceeded(9.99) || !budget.Exceeded(10) {
+		t.Error("unexpected Exceeded result at the limit")
+	}
+}
+
+func TestLedgerMonthSpend(t *testing.T) {
+	ledger := NewLedger(filepath.Join(t.TempDir(), "usage.jsonl"))
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.Local)
+	for _, rec := range []UsageRecord{
+		{Time: time.Date(2024, 5, 31, 23, 0, 0, 0, time.Local), Cost: 5},
+		{Time: time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local), Cost: 2},
+		{Time: time.Date(2024, 6, 14, 0, 0, 0, 0, time.Local), Cost: 3},
+	} {
+		if err := ledger.Record(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	spent, err := ledger.MonthSpend(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spent != 5 {
+		t.Errorf("expected $5 spent in June, got %v", spent)
+	}
+}
@@ -81,20 +81,24 @@ func (t *Tracker) GetCost() float64 {
 
 // calculateCost calculates cost (caller must hold lock)
 func (t *Tracker) calculateCost() float64 {
-	pricing, ok := ProviderPricing[t.model]
+	return ModelCost(t.model, t.InputTokens, t.OutputTokens)
+}
+
+// ModelCost calculates the cost in USD of token usage with a model
+func ModelCost(model string, inputTokens, outputTokens int64) float64 {
+	pricing, ok := ProviderPricing[model]
 	if !ok {
 		// Try to find a matching model by prefix
-		pricing = t.findPricingByPrefix()
+		pricing = findPricingByPrefix(model)
 	}
 
-	inputCost := float64(t.InputTokens) * pricing.InputPer1M / 1_000_000
-	outputCost := float64(t.OutputTokens) * pricing.OutputPer1M / 1_000_000
+	inputCost := float64(inputTokens) * pricing.InputPer1M / 1_000_000
+	outputCost := float64(outputTokens) * pricing.OutputPer1M / 1_000_000
 	return inputCost + outputCost
 }
 
 // findPricingByPrefix tries to match model by common prefixes
-func (t *Tracker) findPricingByPrefix() Pricing {
-	model := t.model
+func findPricingByPrefix(model string) Pricing {
 
 	// Claude models
 	if len(model) > 6 && model[:6] == "claude" {
@@ -0,0 +1,194 @@
+package cost
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UsageRecord is the token usage of one model response
+type UsageRecord struct {
+	Time         time.Time `json:"time"`
+	Provider     string    `json:"provider,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	Project      string    `json:"project,omitempty"`
+	Session      string    `json:"session,omitempty"`
+	InputTokens  int64     `json:"input_tokens"`
+	OutputTokens int64     `json:"output_tokens"`
+	Cost         float64   `json:"cost"`
+
+	// Speed of the call: milliseconds to the first token and output tokens
+	// per second after it, 0 when not measured
+	FirstTokenMS    int64   `json:"first_token_ms,omitempty"`
+	TokensPerSecond float64 `json:"tokens_per_second,omitempty"`
+}
+
+// Ledger persists usage across sessions as JSON lines in a file shared by
+// all processes. Each record is appended with a single write, so concurrent
+// sessions do not interleave records.
+type Ledger struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLedger creates a ledger stored at path
+func NewLedger(path string) *Ledger {
+	return &Ledger{path: path}
+}
+
+// Path returns the ledger file
+func (l *Ledger) Path() string {
+	return l.path
+}
+
+// Record appends a usage record, pricing it when Cost is not set
+func (l *Ledger) Record(rec UsageRecord) error {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	if rec.Cost == 0 {
+		rec.Cost = ModelCost(rec.Model, rec.InputTokens, rec.OutputTokens)
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create ledger directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write ledger: %w", err)
+	}
+	return nil
+}
+
+// Records returns the records made at or after since. Malformed lines, such
+// as a record cut short by a crash, are skipped.
+func (l *Ledger) Records(since time.Time) ([]UsageRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer f.Close()
+
+	var records []UsageRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec UsageRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		if rec.Time.Before(since) {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// UsageGroupings are the keys usage can be summarized by
+var UsageGroupings = []string{"day", "model", "provider", "project"}
+
+// UsageSummary is the total usage of records sharing a key
+type UsageSummary struct {
+	Key          string
+	Requests     int
+	InputTokens  int64
+	OutputTokens int64
+	Cost         float64
+}
+
+// Summarize totals records by day, model, provider, or project. Days are
+// listed in order; other keys by cost, highest first.
+func Summarize(records []UsageRecord, by string) ([]UsageSummary, error) {
+	var keyOf func(UsageRecord) string
+	switch by {
+	case "day", "":
+		by = "day"
+		keyOf = func(r UsageRecord) string { return r.Time.Local().Format("2006-01-02") }
+	case "model":
+		keyOf = func(r UsageRecord) string { return r.Model }
+	case "provider":
+		keyOf = func(r UsageRecord) string { return r.Provider }
+	case "project":
+		keyOf = func(r UsageRecord) string { return r.Project }
+	default:
+		return nil, fmt.Errorf("unknown grouping %q (use %s)", by, strings.Join(UsageGroupings, ", "))
+	}
+
+	totals := make(map[string]*UsageSummary)
+	for _, r := range records {
+		key := keyOf(r)
+		if key == "" {
+			key = "(unknown)"
+		}
+		s, ok := totals[key]
+		if !ok {
+			s = &UsageSummary{Key: key}
+			totals[key] = s
+		}
+		s.Requests++
+		s.InputTokens += r.InputTokens
+		s.OutputTokens += r.OutputTokens
+		s.Cost += r.Cost
+	}
+
+	summaries := make([]UsageSummary, 0, len(totals))
+	for _, s := range totals {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if by != "day" && summaries[i].Cost != summaries[j].Cost {
+			return summaries[i].Cost > summaries[j].Cost
+		}
+		return summaries[i].Key < summaries[j].Key
+	})
+	return summaries, nil
+}
+
+// ParseSince parses a report start: a duration such as "7d", "12h", or
+// "30m", or a date such as "2024-06-01"
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("invalid duration: %s", s)
+		}
+		return now.AddDate(0, 0, -n), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid duration or date: %s (use e.g. 7d, 12h, or 2024-06-01)", s)
+	}
+	return now.Add(-d), nil
+}
@@ -0,0 +1,146 @@
+package cost
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLedgerRecordAndRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "usage.jsonl")
+	ledger := NewLedger(path)
+
+	records, err := ledger.Records(time.Time{})
+	if err != nil {
+		t.Fatalf("Records on missing ledger: %v", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("expected no records, got %d", len(records))
+	}
+
+	now := time.Now()
+	old := UsageRecord{Time: now.AddDate(0, 0, -10), Model: "gpt-4o", InputTokens: 100, OutputTokens: 50, Cost: 1}
+	recent := UsageRecord{Time: now, Model: "gpt-4o", InputTokens: 1000000, OutputTokens: 0}
+	for _, rec := range []UsageRecord{old, recent} {
+		if err := ledger.Record(rec); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	// A record cut short by a crash must not break reading
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time": "broken` + "\n")
+	f.Close()
+
+	records, err = ledger.Records(time.Time{})
+	if err != nil {
+		t.Fatalf("Records: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Cost != 1 {
+		t.Errorf("expected explicit cost to be kept, got %f", records[0].Cost)
+	}
+	if want := ModelCost("gpt-4o", 1000000, 0); records[1].Cost != want || want == 0 {
+		t.Errorf("expected priced cost %f, got %f", want, records[1].Cost)
+	}
+
+	records, err = ledger.Records(now.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("Records: %v", err)
+	}
+	if len(records) != 1 || records[0].InputTokens != 1000000 {
+		t.Errorf("expected only the recent record, got %+v", records)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected ledger mode 0600, got %o", perm)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	day1 := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	records := []UsageRecord{
+		{Time: day2, Model: "cheap", Project: "/a", InputTokens: 10, OutputTokens: 5, Cost: 0.1},
+		{Time: day1, Model: "pricey", Project: "/b", InputTokens: 20, OutputTokens: 10, Cost: 2},
+		{Time: day1, Model: "cheap", InputTokens: 30, OutputTokens: 15, Cost: 0.2},
+	}
+
+	tests := []struct {
+		by   string
+		keys []string
+	}{
+		{"day", []string{"2024-06-01", "2024-06-02"}},
+		{"", []string{"2024-06-01", "2024-06-02"}},
+		{"model", []string{"pricey", "cheap"}},
+		{"project", []string{"/b", "(unknown)", "/a"}},
+	}
+	for _, tt := range tests {
+		summaries, err := Summarize(records, tt.by)
+		if err != nil {
+			t.Errorf("Summarize(%q): %v", tt.by, err)
+			continue
+		}
+		var keys []string
+		for _, s := range summaries {
+			keys = append(keys, s.Key)
+		}
+		if len(keys) != len(tt.keys) {
+			t.Errorf("Summarize(%q) keys = %v, want %v", tt.by, keys, tt.keys)
+			continue
+		}
+		for i := range keys {
+			if keys[i] != tt.keys[i] {
+				t.Errorf("Summarize(%q) keys = %v, want %v", tt.by, keys, tt.keys)
+				break
+			}
+		}
+	}
+
+	summaries, _ := Summarize(records, "model")
+	cheap := summaries[1]
+	if cheap.Requests != 2 || cheap.InputTokens != 40 || cheap.OutputTokens != 20 {
+		t.Errorf("unexpected cheap totals: %+v", cheap)
+	}
+
+	if _, err := Summarize(records, "week"); err == nil {
+		t.Error("expected error for unknown grouping")
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"7d", now.AddDate(0, 0, -7), false},
+		{"12h", now.Add(-12 * time.Hour), false},
+		{"2024-06-01", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), false},
+		{"xd", time.Time{}, true},
+		{"-1d", time.Time{}, true},
+		{"soon", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.input, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSince(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseSince(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
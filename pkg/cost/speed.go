@@ -0,0 +1,109 @@
+package cost
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SpeedWindow is the number of latest calls per model that speed
+// statistics cover, so they follow the provider's current behavior
+const SpeedWindow = 100
+
+// SpeedSummary is the speed of a model's latest calls
+type SpeedSummary struct {
+	Provider        string
+	Model           string
+	Calls           int
+	FirstToken      time.Duration // median time to the first token
+	FirstTokenP90   time.Duration // 90th percentile time to the first token
+	TokensPerSecond float64       // median output tokens per second
+}
+
+// SummarizeSpeed returns the speed of the latest window calls of each
+// model, fastest first token first. Records without a measured speed are
+// skipped; records are expected oldest first, as the ledger returns them.
+func SummarizeSpeed(records []UsageRecord, window int) []SpeedSummary {
+	type samples struct {
+		provider   string
+		firstToken []time.Duration
+		rates      []float64
+	}
+	byModel := make(map[string]*samples)
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		if r.FirstTokenMS <= 0 && r.TokensPerSecond <= 0 {
+			continue
+		}
+		key := r.Provider + "/" + r.Model
+		s, ok := byModel[key]
+		if !ok {
+			s = &samples{provider: r.Provider}
+			byModel[key] = s
+		}
+		if window > 0 && len(s.firstToken) >= window {
+			continue
+		}
+		s.firstToken = append(s.firstToken, time.Duration(r.FirstTokenMS)*time.Millisecond)
+		if r.TokensPerSecond > 0 {
+			s.rates = append(s.rates, r.TokensPerSecond)
+		}
+	}
+
+	summaries := make([]SpeedSummary, 0, len(byModel))
+	for key, s := range byModel {
+		sort.Slice(s.firstToken, func(i, j int) bool { return s.firstToken[i] < s.firstToken[j] })
+		sort.Float64s(s.rates)
+		summary := SpeedSummary{
+			Provider:      s.provider,
+			Model:         strings.TrimPrefix(key, s.provider+"/"),
+			Calls:         len(s.firstToken),
+			FirstToken:    percentile(s.firstToken, 0.5),
+			FirstTokenP90: percentile(s.firstToken, 0.9),
+		}
+		if len(s.rates) > 0 {
+			summary.TokensPerSecond = s.rates[len(s.rates)/2]
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].FirstToken != summaries[j].FirstToken {
+			return summaries[i].FirstToken < summaries[j].FirstToken
+		}
+		return summaries[i].Model < summaries[j].Model
+	})
+	return summaries
+}
+
+// percentile returns the p-th percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(p*float64(len(sorted)-1)+0.5)]
+}
+
+// FormatSpeed renders speed summaries as a table
+func FormatSpeed(summaries []SpeedSummary) string {
+	width := len("MODEL")
+	for _, s := range summaries {
+		width = max(width, len(s.Provider)+1+len(s.Model))
+	}
+
+	var b strings.Builder
+	row := func(model, calls, first, p90, rate string) {
+		fmt.Fprintf(&b, "%-*s  %6s  %11s  %9s  %9s\n", width, model, calls, first, p90, rate)
+	}
+	seconds := func(d time.Duration) string { return fmt.Sprintf("%.1fs", d.Seconds()) }
+
+	row("MODEL", "CALLS", "FIRST TOKEN", "P90", "TOK/S")
+	for _, s := range summaries {
+		rate := "-"
+		if s.TokensPerSecond > 0 {
+			rate = fmt.Sprintf("%.0f", s.TokensPerSecond)
+		}
+		row(s.Provider+"/"+s.Model, fmt.Sprint(s.Calls), seconds(s.FirstToken), seconds(s.FirstTokenP90), rate)
+	}
+	return b.String()
+}
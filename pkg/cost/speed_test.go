@@ -0,0 +1,33 @@
+package cost
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSummarizeSpeed(t *testing.T) {
+	records := []UsageRecord{
+		{Provider: "claude", Model: "sonnet", FirstTokenMS: 9000, TokensPerSecond: 10}, // outside the window
+		{Provider: "claude", Model: "sonnet", FirstTokenMS: 800, TokensPerSecond: 60},
+		{Provider: "claude", Model: "sonnet", FirstTokenMS: 1200, TokensPerSecond: 80},
+		{Provider: "claude", Model: "sonnet", FirstTokenMS: 1000, TokensPerSecond: 70},
+		{Provider: "ollama", Model: "llama3", FirstTokenMS: 300, TokensPerSecond: 30},
+		{Provider: "openai", Model: "gpt-4o", InputTokens: 10}, // no speed measured
+	}
+	summaries := SummarizeSpeed(records, 3)
+	if len(summaries) != 2 {
+		t.Fatalf("expected 2 models, got %+v", summaries)
+	}
+	if s := summaries[0]; s.Model != "llama3" || s.Calls != 1 {
+		t.Errorf("expected the fastest first token first, got %+v", s)
+	}
+	s := summaries[1]
+	if s.Calls != 3 || s.FirstToken.Milliseconds() != 1000 || s.FirstTokenP90.Milliseconds() != 1200 || s.TokensPerSecond != 70 {
+		t.Errorf("unexpected sonnet speed: %+v", s)
+	}
+
+	table := FormatSpeed(summaries)
+	if !strings.Contains(table, "claude/sonnet") || !strings.Contains(table, "1.0s") || !strings.HasPrefix(table, "MODEL") {
+		t.Errorf("unexpected table:\n%s", table)
+	}
+}
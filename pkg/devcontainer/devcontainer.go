@@ -0,0 +1,316 @@
+// Package devcontainer finds a project's development container and runs
+// commands inside it, so the agent builds and tests with the toolchain
+// versions the project pins rather than whatever is installed locally.
+package devcontainer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/tool/builtin"
+)
+
+// How commands reach the container
+const (
+	ExecAuto   = "auto"   // the devcontainer CLI when installed, otherwise docker
+	ExecCLI    = "cli"    // devcontainer exec, which also starts the container
+	ExecDocker = "docker" // docker exec into the running container
+)
+
+// Paths are where devcontainer.json may be, relative to the project
+var Paths = []string{filepath.Join(".devcontainer", "devcontainer.json"), ".devcontainer.json"}
+
+// Config is the part of devcontainer.json that places the project in the
+// container
+type Config struct {
+	Name            string `json:"name"`
+	WorkspaceFolder string `json:"workspaceFolder"`
+	RemoteUser      string `json:"remoteUser"`
+}
+
+// Find returns the path of the devcontainer.json of the project at root,
+// or "" when it has none
+func Find(root string) string {
+	for _, p := range Paths {
+		if _, err := os.Stat(filepath.Join(root, p)); err == nil {
+			return filepath.Join(root, p)
+		}
+	}
+	return ""
+}
+
+// Load reads a devcontainer.json, which may have comments and trailing
+// commas
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(stripJSONC(data), &cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Container runs commands in the devcontainer of the project at Root. It
+// implements builtin.Workspace: the container mounts the project, so files
+// are used in place, while commands run inside with local paths under Root
+// translated to Folder.
+type Container struct {
+	builtin.LocalWorkspace
+
+	Root   string   // local project directory
+	Folder string   // project directory in the container
+	User   string   // user commands run as, "" for the container's
+	Name   string   // from devcontainer.json, for display
+	Exec   string   // ExecCLI or ExecDocker; ExecAuto until Start
+	ID     string   // container ID, found by Start
+	Shell  string   // shell for Bash commands, found by Start
+	Env    []string // local variables forwarded into the container
+	CLI    string   // devcontainer binary, default devcontainer
+	Docker string   // docker binary, default docker
+}
+
+// New returns the devcontainer of the project at root, to be started with
+// Start
+func New(root, execMode string) (*Container, error) {
+	path := Find(root)
+	if path == "" {
+		return nil, fmt.Errorf("no %s in %s", Paths[0], root)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	folder := cfg.WorkspaceFolder
+	if folder == "" {
+		folder = "/workspaces/" + filepath.Base(root)
+	}
+	if execMode == "" {
+		execMode = ExecAuto
+	}
+	return &Container{Root: root, Folder: folder, User: cfg.RemoteUser, Name: cfg.Name, Exec: execMode}, nil
+}
+
+// String describes the container for display
+func (c *Container) String() string {
+	name := c.Name
+	if name == "" {
+		name = filepath.Base(c.Root)
+	}
+	return fmt.Sprintf("%s (%s)", name, shortID(c.ID))
+}
+
+// Start finds the container, starting it first with the devcontainer CLI.
+// docker exec needs it running already, as the CLI or an editor leaves it.
+func (c *Container) Start(ctx context.Context) error {
+	if c.Exec == ExecAuto {
+		c.Exec = ExecDocker
+		if _, err := exec.LookPath(c.cli()); err == nil {
+			c.Exec = ExecCLI
+		}
+	}
+
+	switch c.Exec {
+	case ExecCLI:
+		if err := c.up(ctx); err != nil {
+			return err
+		}
+	case ExecDocker:
+		out, err := exec.CommandContext(ctx, c.docker(), "ps", "-q", "--filter", "label=devcontainer.local_folder="+c.Root).Output()
+		if err != nil {
+			return fmt.Errorf("failed to list containers: %w", commandError(err))
+		}
+		c.ID, _, _ = strings.Cut(strings.TrimSpace(string(out)), "\n")
+		if c.ID == "" {
+			return fmt.Errorf("the devcontainer of %s is not running; start it with `devcontainer up --workspace-folder %s` or from your editor", c.Root, c.Root)
+		}
+	default:
+		return fmt.Errorf("unknown exec mode %q, expected %s, %s or %s", c.Exec, ExecAuto, ExecCLI, ExecDocker)
+	}
+
+	// Bash commands run with bash when the image has it
+	out, _ := c.Command(ctx, "", "sh", "-c", "command -v bash || true").Output()
+	c.Shell = "sh"
+	if strings.HasSuffix(strings.TrimSpace(string(out)), "/bash") {
+		c.Shell = "bash"
+	}
+	return nil
+}
+
+// upResult is the JSON devcontainer up prints last
+type upResult struct {
+	Outcome               string `json:"outcome"`
+	Message               string `json:"message"`
+	ContainerID           string `json:"containerId"`
+	RemoteUser            string `json:"remoteUser"`
+	RemoteWorkspaceFolder string `json:"remoteWorkspaceFolder"`
+}
+
+// up starts the container with the devcontainer CLI, or finds it running
+func (c *Container) up(ctx context.Context) error {
+	out, err := exec.CommandContext(ctx, c.cli(), "up", "--workspace-folder", c.Root).Output()
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	var result upResult
+	if jsonErr := json.Unmarshal([]byte(lines[len(lines)-1]), &result); jsonErr != nil {
+		if err != nil {
+			return fmt.Errorf("devcontainer up failed: %w", commandError(err))
+		}
+		return fmt.Errorf("unexpected output from devcontainer up: %w", jsonErr)
+	}
+	if result.Outcome != "success" {
+		return fmt.Errorf("devcontainer up failed: %s", result.Message)
+	}
+	c.ID = result.ContainerID
+	if result.RemoteUser != "" {
+		c.User = result.RemoteUser
+	}
+	if result.RemoteWorkspaceFolder != "" {
+		c.Folder = result.RemoteWorkspaceFolder
+	}
+	return nil
+}
+
+// Command runs a program in the container in dir. Programs come from the
+// container's PATH and see the container's environment plus the Env
+// variables set locally. Stopping the command stops the exec client; a
+// command it started keeps running in the container until it exits.
+func (c *Container) Command(ctx context.Context, dir, name string, args ...string) *exec.Cmd {
+	if filepath.IsAbs(name) {
+		name = filepath.Base(name) // a local binary; the container has its own
+	}
+	argv := []string{name}
+	for _, arg := range args {
+		argv = append(argv, c.containerPath(arg))
+	}
+	folder := c.Folder
+	if dir != "" {
+		folder = c.containerPath(dir)
+	}
+
+	var forward []string
+	for _, name := range c.Env {
+		if value, ok := os.LookupEnv(name); ok {
+			forward = append(forward, name+"="+value)
+		}
+	}
+
+	if c.Exec == ExecCLI {
+		// devcontainer exec has no working directory option, and takes
+		// variables only with their values
+		cli := []string{"exec", "--workspace-folder", c.Root}
+		for _, kv := range forward {
+			cli = append(cli, "--remote-env", kv)
+		}
+		cli = append(cli, "sh", "-c", `cd "$1" && shift && exec "$@"`, "sh", folder)
+		return exec.CommandContext(ctx, c.cli(), append(cli, argv...)...)
+	}
+	docker := []string{"exec", "-w", folder}
+	if c.User != "" {
+		docker = append(docker, "-u", c.User)
+	}
+	// docker exec -e NAME copies the value from its own environment, which
+	// keeps it out of the command line
+	for _, kv := range forward {
+		name, _, _ := strings.Cut(kv, "=")
+		docker = append(docker, "-e", name)
+	}
+	docker = append(docker, c.ID)
+	cmd := exec.CommandContext(ctx, c.docker(), append(docker, argv...)...)
+	if len(forward) > 0 {
+		cmd.Env = append(builtin.CommandEnv(), forward...)
+	}
+	return cmd
+}
+
+// containerPath translates a local path under Root to the container
+func (c *Container) containerPath(p string) string {
+	rel, err := filepath.Rel(c.Root, p)
+	if err != nil || !filepath.IsAbs(p) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return p
+	}
+	return path.Join(c.Folder, filepath.ToSlash(rel))
+}
+
+func (c *Container) cli() string {
+	if c.CLI != "" {
+		return c.CLI
+	}
+	return "devcontainer"
+}
+
+func (c *Container) docker() string {
+	if c.Docker != "" {
+		return c.Docker
+	}
+	return "docker"
+}
+
+// commandError adds a failed command's error output to its error
+func commandError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// stripJSONC removes the comments and trailing commas JSON with comments
+// allows, leaving strings as they are
+func stripJSONC(data []byte) []byte {
+	var out bytes.Buffer
+	inString := false
+	for i := 0; i < len(data); i++ {
+		ch := data[i]
+		switch {
+		case inString:
+			out.WriteByte(ch)
+			if ch == '\\' && i+1 < len(data) {
+				i++
+				out.WriteByte(data[i])
+			} else if ch == '"' {
+				inString = false
+			}
+		case ch == '"':
+			inString = true
+			out.WriteByte(ch)
+		case ch == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			out.WriteByte('\n')
+		case ch == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return out.Bytes()
+			}
+			i += end + 3
+		case ch == ']' || ch == '}':
+			// Drop a comma before the closing bracket
+			trimmed := bytes.TrimRight(out.Bytes(), " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				out.Truncate(len(trimmed) - 1)
+			}
+			out.WriteByte(ch)
+		default:
+			out.WriteByte(ch)
+		}
+	}
+	return out.Bytes()
+}
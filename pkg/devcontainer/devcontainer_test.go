@@ -0,0 +1,179 @@
+package devcontainer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+	"github.com/xinguang/agentic-coder/pkg/tool/builtin"
+)
+
+// fakeDocker is a docker that lists one container and runs exec'd commands
+// locally, in the -w directory
+const fakeDocker = `#!/bin/sh
+case "$1" in
+ps) echo c0ffee1234567890 ;;
+exec)
+	shift
+	while [ $# -gt 0 ]; do
+		case "$1" in
+		-w) dir=$2; shift 2 ;;
+		-u) echo "user=$2" >&2; shift 2 ;;
+		-e) shift 2 ;;
+		*) break ;;
+		esac
+	done
+	shift
+	cd "$dir" && exec "$@" ;;
+esac
+`
+
+// fakeCLI is a devcontainer CLI that reports a started container and runs
+// exec'd commands locally
+const fakeCLI = `#!/bin/sh
+case "$1" in
+up) echo '[1 ms] Start: Run: docker ps'; echo '{"outcome":"success","containerId":"feedface","remoteUser":"vscode","remoteWorkspaceFolder":"'"$FOLDER"'"}' ;;
+exec) shift 3; exec "$@" ;;
+esac
+`
+
+func writeExecutable(t *testing.T, name, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// project creates a project with a devcontainer.json whose workspace folder
+// is another directory, standing in for the container's mount
+func project(t *testing.T) (root, folder string) {
+	t.Helper()
+	root, folder = t.TempDir(), t.TempDir()
+	os.MkdirAll(filepath.Join(root, ".devcontainer"), 0755)
+	os.MkdirAll(filepath.Join(folder, "pkg"), 0755)
+	config := `{
+	// The Go toolchain the project pins
+	"name": "Go 1.22",
+	"image": "mcr.microsoft.com/devcontainers/go:1.22", /* from the registry */
+	"workspaceFolder": "` + folder + `",
+	"remoteUser": "vscode",
+	"forwardPorts": [8080, 9090,],
+}`
+	if err := os.WriteFile(filepath.Join(root, ".devcontainer", "devcontainer.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return root, folder
+}
+
+func TestNew(t *testing.T) {
+	root, folder := project(t)
+	c, err := New(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "Go 1.22" || c.Folder != folder || c.User != "vscode" || c.Exec != ExecAuto {
+		t.Errorf("unexpected container %+v", c)
+	}
+
+	os.Remove(filepath.Join(root, ".devcontainer", "devcontainer.json"))
+	if _, err := New(root, ""); err == nil {
+		t.Error("expected an error without devcontainer.json")
+	}
+	os.WriteFile(filepath.Join(root, ".devcontainer.json"), []byte(`{"name": "a // not a comment"}`), 0644)
+	if c, err := New(root, ""); err != nil || c.Name != "a // not a comment" || c.Folder != "/workspaces/"+filepath.Base(root) {
+		t.Errorf("unexpected container %+v, %v", c, err)
+	}
+}
+
+func TestContainerDocker(t *testing.T) {
+	root, folder := project(t)
+	c, err := New(root, ExecDocker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Docker = writeExecutable(t, "docker", fakeDocker)
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != "c0ffee1234567890" || c.String() != "Go 1.22 (c0ffee123456)" {
+		t.Errorf("unexpected container %s, ID %s", c, c.ID)
+	}
+
+	out, err := c.Command(context.Background(), filepath.Join(root, "pkg"), "/usr/bin/sh", "-c", `pwd; echo "$1"`, "sh", filepath.Join(root, "go.mod")).CombinedOutput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "user=vscode\n" + filepath.Join(folder, "pkg") + "\n" + filepath.Join(folder, "go.mod") + "\n"
+	if string(out) != want {
+		t.Errorf("expected %q, got %q", want, out)
+	}
+
+	// Forwarded variables reach the command, with their values kept out of
+	// docker's arguments
+	t.Setenv("BUILD_FLAVOR", "release")
+	t.Setenv("UNRELATED_TOKEN", "tok-123")
+	c.Env = []string{"BUILD_FLAVOR", "UNSET_VARIABLE"}
+	cmd := c.Command(context.Background(), "", "sh", "-c", `echo "$BUILD_FLAVOR/$UNRELATED_TOKEN"`)
+	if args := strings.Join(cmd.Args, " "); !strings.Contains(args, "-e BUILD_FLAVOR ") || strings.Contains(args, "release") || strings.Contains(args, "UNSET_VARIABLE") {
+		t.Errorf("unexpected docker arguments %q", args)
+	}
+	if out, err := cmd.Output(); err != nil || string(out) != "release/\n" {
+		t.Errorf("expected only the forwarded variable, got %q, %v", out, err)
+	}
+}
+
+func TestContainerCLI(t *testing.T) {
+	root, folder := project(t)
+	t.Setenv("FOLDER", folder)
+	c, err := New(root, ExecCLI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Folder = "/elsewhere"
+	c.CLI = writeExecutable(t, "devcontainer", fakeCLI)
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != "feedface" || c.Folder != folder {
+		t.Errorf("expected the container devcontainer up reported, got %+v", c)
+	}
+
+	registry := tool.NewRegistry()
+	bash, test := builtin.NewBashTool(), builtin.NewTestTool()
+	registry.Register(bash)
+	registry.Register(test)
+	Use(registry, nil, c)
+	if bash.Workspace != c || test.Workspace != c || bash.ShellPath != c.Shell {
+		t.Fatalf("expected Bash and Test to use the container")
+	}
+
+	out, err := bash.Execute(context.Background(), &tool.Input{
+		Context: &tool.ExecutionContext{CWD: filepath.Join(root, "pkg")},
+		Params:  map[string]interface{}{"command": "pwd"},
+	})
+	if err != nil || out.IsError {
+		t.Fatalf("bash failed: %+v, %v", out, err)
+	}
+	if got := strings.TrimSpace(out.Content); got != filepath.Join(folder, "pkg") {
+		t.Errorf("expected the command to run in the container's folder, got %q", got)
+	}
+}
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"{\"a\": 1, // one\n\"b\": [1, 2,],}", "{\"a\": 1, \n\"b\": [1, 2]}"},
+		{`{"url": "http://x/*y*/", /* gone */ "c": "\"//\""}`, `{"url": "http://x/*y*/",  "c": "\"//\""}`},
+	}
+	for _, tt := range tests {
+		if got := string(stripJSONC([]byte(tt.in))); got != tt.want {
+			t.Errorf("stripJSONC(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package devcontainer
+
+import (
+	"github.com/xinguang/agentic-coder/pkg/tool"
+	"github.com/xinguang/agentic-coder/pkg/tool/builtin"
+)
+
+// Use runs Bash, the background shells and the Test tool in registry inside
+// the container. The other tools keep working on the mounted files.
+func Use(registry *tool.Registry, shells *builtin.ShellManager, c *Container) {
+	if t, err := registry.Get("Bash"); err == nil {
+		if bash, ok := t.(*builtin.BashTool); ok {
+			bash.Workspace = c
+			bash.ShellPath = c.Shell
+		}
+	}
+	if t, err := registry.Get("Test"); err == nil {
+		if test, ok := t.(*builtin.TestTool); ok {
+			test.Workspace = c
+		}
+	}
+	if shells != nil {
+		shells.SetWorkspace(c, c.Shell)
+	}
+}
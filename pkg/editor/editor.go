@@ -0,0 +1,77 @@
+// Package editor opens text in the user's editor
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Editor is the program files are edited with
+type Editor struct {
+	// Command is the program, possibly with arguments ("" = $VISUAL,
+	// $EDITOR, or vi)
+	Command string
+	// Args are added before the file, such as --wait for editors that
+	// otherwise return at once
+	Args string
+}
+
+// Cmd returns the command that opens path. Its standard streams are not
+// set, so the caller can attach it to the terminal.
+func (e Editor) Cmd(path string) *exec.Cmd {
+	command := e.Command
+	if command == "" {
+		command = os.Getenv("VISUAL")
+	}
+	if command == "" {
+		command = os.Getenv("EDITOR")
+	}
+	if command == "" {
+		command = "vi"
+	}
+	fields := strings.Fields(command)
+	args := append(fields[1:], strings.Fields(e.Args)...)
+	return exec.Command(fields[0], append(args, path)...)
+}
+
+// Edit opens text in the editor, attached to the terminal, and returns it
+// as saved. pattern names the temporary file as in os.CreateTemp, so its
+// extension can select the editor's syntax.
+func (e Editor) Edit(text, pattern string) (string, error) {
+	path, err := TempFile(text, pattern)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(path)
+
+	cmd := e.Cmd(path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %s failed: %w", cmd.Args[0], err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// TempFile writes text to a new temporary file and returns its path
+func TempFile(text, pattern string) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,44 @@
+package editor
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestCmd(t *testing.T) {
+	tests := []struct {
+		name   string
+		editor Editor
+		visual string
+		env    string
+		want   []string
+	}{
+		{"configured", Editor{Command: "code --new-window", Args: "--wait"}, "nano", "vim", []string{"code", "--new-window", "--wait", "f.md"}},
+		{"visual", Editor{Args: "-n"}, "nano", "vim", []string{"nano", "-n", "f.md"}},
+		{"editor", Editor{}, "", "vim -u NONE", []string{"vim", "-u", "NONE", "f.md"}},
+		{"default", Editor{}, "", "", []string{"vi", "f.md"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("VISUAL", tt.visual)
+			t.Setenv("EDITOR", tt.env)
+			if got := tt.editor.Cmd("f.md").Args; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cmd() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEdit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sed")
+	}
+	got, err := Editor{Command: "sed -i", Args: "s/draft/final/"}.Edit("the draft prompt\n", "prompt-*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "the final prompt\n" {
+		t.Errorf("Edit() = %q", got)
+	}
+}
@@ -0,0 +1,311 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/agentignore"
+	"github.com/xinguang/agentic-coder/pkg/ui"
+)
+
+// DefaultAttachmentBudget bounds the tokens of file content that
+// @-mentions attach to a prompt
+const DefaultAttachmentBudget = 20000
+
+// Limits on expanding mentioned directories
+const (
+	maxAttachmentCandidates = 2000
+	maxSkippedListed        = 100
+)
+
+// mentionPattern matches @path at the start of the prompt or after a space
+var mentionPattern = regexp.MustCompile(`(?:^|\s)@([^\s@]+)`)
+
+// Attachment is a file mentioned in a prompt, directly or in a directory
+type Attachment struct {
+	Path    string // relative to the working directory
+	Tokens  int    // estimated tokens of its content
+	score   int
+	content string
+}
+
+// AttachmentPlan is the files a prompt mentions with @: those whose content
+// is attached, most relevant first, and those left out to keep within the
+// budget, which the agent is told to read when it needs them
+type AttachmentPlan struct {
+	Attached []Attachment
+	Skipped  []Attachment
+	Budget   int
+}
+
+// Tokens returns the estimated tokens of the attached content
+func (p *AttachmentPlan) Tokens() int {
+	total := 0
+	for _, a := range p.Attached {
+		total += a.Tokens
+	}
+	return total
+}
+
+// String summarizes the plan for the user
+func (p *AttachmentPlan) String() string {
+	s := fmt.Sprintf("Attached %d files (%s tokens)", len(p.Attached), ui.FormatTokens(p.Tokens()))
+	if len(p.Skipped) > 0 {
+		s += fmt.Sprintf("; %d more over the %s-token budget are listed for the agent to read", len(p.Skipped), ui.FormatTokens(p.Budget))
+	}
+	return s
+}
+
+// Context renders the plan as a notice for the prompt
+func (p *AttachmentPlan) Context() string {
+	var sb strings.Builder
+	sb.WriteString("<attached-files>\n")
+	sb.WriteString("The user mentioned these files with @. This is their current content.\n")
+	for _, a := range p.Attached {
+		fmt.Fprintf(&sb, "<file path=%q>\n%s\n</file>\n", a.Path, strings.TrimRight(a.content, "\n"))
+	}
+	if len(p.Skipped) > 0 {
+		fmt.Fprintf(&sb, "These mentioned files were not attached, to keep within %d tokens. Read the ones you need:\n", p.Budget)
+		for i, a := range p.Skipped {
+			if i == maxSkippedListed {
+				fmt.Fprintf(&sb, "... and %d more\n", len(p.Skipped)-i)
+				break
+			}
+			fmt.Fprintf(&sb, "- %s (~%d tokens)\n", a.Path, a.Tokens)
+		}
+	}
+	sb.WriteString("</attached-files>")
+	return sb.String()
+}
+
+// PlanAttachments finds the files and directories a prompt mentions with
+// @path and picks the files to attach within budget tokens. Files
+// mentioned by name come first; files in mentioned directories are ranked
+// by how well their path matches the words of the prompt and by recent git
+// changes. Paths the project's .agentignore excludes are never attached.
+// It returns nil when the prompt mentions no existing paths.
+func PlanAttachments(cwd, prompt string, budget int) *AttachmentPlan {
+	ignore := agentignore.Load(cwd)
+	var files, dirs []string
+	for _, m := range mentionPattern.FindAllStringSubmatch(prompt, -1) {
+		path := strings.TrimRight(m[1], ".,;:!?)]}'\"`")
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		info, err := os.Stat(path)
+		switch {
+		case err != nil, ignore.Ignored(path):
+		case info.IsDir():
+			dirs = append(dirs, path)
+		default:
+			files = append(files, path)
+		}
+	}
+	if len(files) == 0 && len(dirs) == 0 {
+		return nil
+	}
+
+	recent := recentGitChanges(cwd)
+	words := promptWords(prompt)
+	seen := make(map[string]bool)
+	var candidates []Attachment
+	add := func(path string, score int) {
+		name := path
+		if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+		if seen[name] || len(candidates) >= maxAttachmentCandidates || ignore.Ignored(path) {
+			return
+		}
+		seen[name] = true
+		data, err := os.ReadFile(path)
+		if err != nil || bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+			return // unreadable or binary
+		}
+		candidates = append(candidates, Attachment{
+			Path:    name,
+			Tokens:  EstimateTokens(string(data)) + 1,
+			score:   score,
+			content: string(data),
+		})
+	}
+
+	// Files mentioned by name come first, in the order mentioned
+	for i, path := range files {
+		add(path, 1<<20-i)
+	}
+	for _, dir := range dirs {
+		for _, path := range dirFiles(cwd, dir) {
+			add(path, attachmentScore(cwd, path, words, recent))
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.Tokens != b.Tokens {
+			return a.Tokens < b.Tokens
+		}
+		return a.Path < b.Path
+	})
+
+	plan := &AttachmentPlan{Budget: budget}
+	left := budget
+	for _, c := range candidates {
+		if c.Tokens <= left {
+			plan.Attached = append(plan.Attached, c)
+			left -= c.Tokens
+		} else {
+			plan.Skipped = append(plan.Skipped, c)
+		}
+	}
+	return plan
+}
+
+// dirFiles lists the files in dir, leaving out those git ignores when dir
+// is in a repository, and hidden directories and node_modules otherwise
+func dirFiles(cwd, dir string) []string {
+	if out, err := gitOutput(dir, "ls-files", "--cached", "--others", "--exclude-standard"); err == nil {
+		var paths []string
+		for _, line := range strings.Split(out, "\n") {
+			if line != "" {
+				paths = append(paths, filepath.Join(dir, line))
+			}
+		}
+		return paths
+	}
+
+	var paths []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(paths) >= maxAttachmentCandidates {
+			return filepath.SkipAll
+		}
+		if d.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths
+}
+
+// recentGitChanges scores the files under cwd by how recently git saw
+// them change: uncommitted changes score highest, then files in the
+// latest commits, newer commits more
+func recentGitChanges(cwd string) map[string]int {
+	scores := make(map[string]int)
+	if out, err := gitOutput(cwd, "log", "-n", "20", "--name-only", "--relative", "--format=%x00"); err == nil {
+		commits := strings.Split(out, "\x00")
+		for i, commit := range commits {
+			for _, line := range strings.Split(commit, "\n") {
+				if line = strings.TrimSpace(line); line != "" && scores[line] == 0 {
+					scores[line] = 20 - min(i, 19)
+				}
+			}
+		}
+	}
+	for _, args := range [][]string{
+		{"diff", "--name-only", "--relative", "HEAD"},
+		{"ls-files", "--others", "--exclude-standard"},
+	} {
+		if out, err := gitOutput(cwd, args...); err == nil {
+			for _, line := range strings.Split(out, "\n") {
+				if line != "" {
+					scores[line] = 30
+				}
+			}
+		}
+	}
+	return scores
+}
+
+// attachmentScore ranks a file in a mentioned directory
+func attachmentScore(cwd, path string, words []string, recent map[string]int) int {
+	rel, err := filepath.Rel(cwd, path)
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+	score := recent[rel]
+
+	lower := strings.ToLower(rel)
+	base := strings.ToLower(filepath.Base(rel))
+	for _, w := range words {
+		if strings.Contains(base, w) {
+			score += 15
+		} else if strings.Contains(lower, w) {
+			score += 10
+		}
+	}
+	return score
+}
+
+// promptWords returns the distinct words of a prompt that may name files,
+// without the mentions themselves
+func promptWords(prompt string) []string {
+	prompt = mentionPattern.ReplaceAllString(prompt, " ")
+	seen := make(map[string]bool)
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(prompt), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_')
+	}) {
+		if len(w) >= 3 && !stopWords[w] && !seen[w] {
+			seen[w] = true
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// stopWords are common words that say nothing about which files matter
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true, "that": true,
+	"from": true, "into": true, "all": true, "are": true, "how": true, "what": true,
+	"why": true, "can": true, "you": true, "please": true, "file": true, "files": true,
+	"code": true, "look": true, "use": true, "make": true, "add": true, "fix": true,
+}
+
+// gitOutput runs git in dir and returns its output
+func gitOutput(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// attachMentions attaches the files the prompt mentions to it, within the
+// attachment budget
+func (e *Engine) attachMentions(prompt string) {
+	if e.attachmentBudget < 0 || !strings.Contains(prompt, "@") {
+		return
+	}
+	plan := PlanAttachments(e.session.CWD, prompt, e.attachmentBudget)
+	if plan == nil {
+		return
+	}
+	e.pendingContext = append(e.pendingContext, plan.Context())
+	if e.onAttach != nil {
+		e.onAttach(plan)
+	}
+}
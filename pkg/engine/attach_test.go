@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func attachedPaths(list []Attachment) []string {
+	var paths []string
+	for _, a := range list {
+		paths = append(paths, a.Path)
+	}
+	return paths
+}
+
+func TestPlanAttachments(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"README.md":          "# Project\n",
+		"src/login.go":       "package src // login\n",
+		"src/util.go":        "package src // util\n",
+		"src/big.go":         strings.Repeat("x", 4000),
+		"src/logo.png":       "\x89PNG\x00\x00",
+		"src/.cache/skip.go": "package cache\n",
+	})
+
+	plan := PlanAttachments(dir, "Fix the login redirect in @src/, see @README.md.", 500)
+	if plan == nil {
+		t.Fatal("expected a plan")
+	}
+	want := []string{"README.md", filepath.Join("src", "login.go"), filepath.Join("src", "util.go")}
+	if got := attachedPaths(plan.Attached); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("attached %v, want %v", got, want)
+	}
+	if got := attachedPaths(plan.Skipped); len(got) != 1 || got[0] != filepath.Join("src", "big.go") {
+		t.Errorf("skipped %v, want the file over the budget", got)
+	}
+
+	context := plan.Context()
+	if !strings.Contains(context, "package src // login") || !strings.Contains(context, "- "+filepath.Join("src", "big.go")) {
+		t.Errorf("unexpected context:\n%s", context)
+	}
+
+	if PlanAttachments(dir, "mail me at dev@example.com or see @missing/", 500) != nil {
+		t.Error("expected no plan without existing paths")
+	}
+}
+
+func TestPlanAttachmentsAgentignore(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".agentignore":     "secrets/\n*.gen.go\n",
+		"src/api.go":       "package src\n",
+		"src/api.gen.go":   "package src // generated\n",
+		"secrets/prod.env": "TOKEN=x\n",
+	})
+
+	plan := PlanAttachments(dir, "Look at @src and @secrets/prod.env", 1000)
+	if got := attachedPaths(plan.Attached); len(got) != 1 || got[0] != filepath.Join("src", "api.go") {
+		t.Errorf("attached %v, want only src/api.go", got)
+	}
+	if len(plan.Skipped) != 0 {
+		t.Errorf("ignored files should not be listed, got %v", attachedPaths(plan.Skipped))
+	}
+}
+
+func TestPlanAttachmentsPrefersRecentChanges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("needs git")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"pkg/a.go": "package pkg\n", "pkg/b.go": "package pkg\n"})
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=t", "-c", "user.email=t@t", "commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	writeFiles(t, dir, map[string]string{"pkg/b.go": "package pkg // changed\n"})
+
+	plan := PlanAttachments(dir, "Review @pkg", 8)
+	if got := attachedPaths(plan.Attached); len(got) != 1 || got[0] != filepath.Join("pkg", "b.go") {
+		t.Errorf("attached %v, want the uncommitted change first", got)
+	}
+}
+
+func TestRunAttachesMentions(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"notes.txt": "remember the milk\n"})
+
+	prov := &MockProvider{responses: []*provider.Response{{
+		StopReason: provider.StopReasonEndTurn,
+		Content:    []provider.ContentBlock{&provider.TextBlock{Text: "Done"}},
+	}}}
+	sess := session.NewSession(&session.SessionOptions{CWD: dir})
+	eng := NewEngine(&EngineOptions{Provider: prov, Registry: tool.NewRegistry(), Session: sess})
+
+	var attached *AttachmentPlan
+	eng.SetCallbacks(&CallbackOptions{OnAttach: func(plan *AttachmentPlan) { attached = plan }})
+	if err := eng.Run(context.Background(), "Summarize @notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if attached == nil || len(attached.Attached) != 1 {
+		t.Fatalf("expected notes.txt to be attached, got %+v", attached)
+	}
+	notice := sess.GetMessages()[0].Content
+	if text, ok := notice[len(notice)-1].(*provider.TextBlock); !ok || !strings.Contains(text.Text, "remember the milk") {
+		t.Errorf("expected the file content with the prompt, got %+v", notice)
+	}
+}
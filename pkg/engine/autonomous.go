@@ -0,0 +1,281 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// CompletionMarker ends an autonomous run when the model writes it on a
+// line of its own
+const CompletionMarker = "TASK COMPLETE"
+
+// autonomousGuidance follows the task of an autonomous run
+const autonomousGuidance = `# Autonomous mode
+
+You are running unattended: nobody will answer questions or approve plans
+until the run ends. Make reasonable decisions yourself, state the assumptions
+you make, and keep working until the task is done. Verify your changes with
+the project's build and tests as you go.
+
+When the whole task is complete and verified, end your final message with a
+line containing only ` + CompletionMarker + `.`
+
+// continuePrompt resumes an autonomous run after the model ends its turn
+const continuePrompt = "Continue working on the task. If it is complete and verified, reply with " +
+	CompletionMarker + " on a line of its own."
+
+// progressPrompt asks for a progress report. It is sent outside the
+// session, so the report does not become part of the conversation.
+const progressPrompt = `[System notice] The user is away. Summarize the progress on the task so
+far: what is done, what is in progress, what remains, and any problems or
+assumptions. Reply in at most 10 short lines of plain text. Do not call tools.`
+
+// pausedReason ends an autonomous run that was paused. Its final report
+// has no summary, since the program may be shutting down.
+const pausedReason = "paused"
+
+// progressMaxTokens bounds the length of a progress report
+const progressMaxTokens = 1024
+
+// maxAutonomousFailures stops an autonomous run after this many runs in a
+// row end with an error
+const maxAutonomousFailures = 3
+
+// autonomousRetryDelay is the wait after a failed run, multiplied by the
+// number of failures in a row
+var autonomousRetryDelay = 30 * time.Second
+
+// AutonomousOptions bounds an autonomous run
+type AutonomousOptions struct {
+	ReportEvery time.Duration // progress report interval (0 = final report only)
+	MaxRuns     int           // turns before stopping (0 = unlimited)
+	MaxDuration time.Duration // wall time before stopping (0 = unlimited)
+	MaxCost     float64       // estimated dollars before stopping (0 = unlimited)
+
+	// Resume continues the run paused in the session before prompting again
+	Resume bool
+
+	// OnReport receives the periodic progress reports and the final one
+	OnReport func(report ProgressReport)
+}
+
+// ProgressReport is a checkpoint of an autonomous run
+type ProgressReport struct {
+	Elapsed time.Duration
+	Runs    int
+	Cost    float64  // estimated cost of the run so far
+	Summary string   // the model's summary, or why there is none
+	Files   []string // files changed so far
+	Final   bool
+	Reason  string // why the run stopped, in the final report
+}
+
+// RunAutonomous works on a task without user input: it runs the engine
+// turn after turn, prompting the model to continue until it reports the
+// task complete or a limit is reached, and reports progress every
+// opts.ReportEvery. It returns nil when the task is complete or a limit of
+// opts stops it, and the error that ended the run otherwise.
+func (e *Engine) RunAutonomous(ctx context.Context, task string, opts AutonomousOptions) error {
+	started := time.Now()
+	startSpend := e.spend
+	lastReport := started
+	runs, failures := 0, 0
+
+	var files []string
+	seen := make(map[string]bool)
+
+	report := func(final bool, reason string) {
+		if opts.OnReport == nil {
+			return
+		}
+		// The final report is also written after an interrupt
+		reportCtx := ctx
+		if final {
+			var cancel context.CancelFunc
+			reportCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), 2*time.Minute)
+			defer cancel()
+		}
+		summary := "Paused; the run continues where it stopped when resumed"
+		if reason != pausedReason {
+			var err error
+			if summary, err = e.summarizeProgress(reportCtx); err != nil {
+				summary = fmt.Sprintf("No summary: %v", err)
+			}
+		}
+		opts.OnReport(ProgressReport{
+			Elapsed: time.Since(started),
+			Runs:    runs,
+			Cost:    e.spend - startSpend,
+			Summary: summary,
+			Files:   append([]string(nil), files...),
+			Final:   final,
+			Reason:  reason,
+		})
+	}
+
+	prompt := task + "\n\n" + autonomousGuidance
+	if opts.Resume {
+		prompt = continuePrompt
+	}
+	var reason string
+	var runErr error
+
+	for reason == "" {
+		if reason = autonomousLimit(opts, runs, time.Since(started), e.spend-startSpend); reason != "" {
+			break
+		}
+
+		var err error
+		if opts.Resume && runs == 0 {
+			err = e.Resume(ctx)
+		} else {
+			err = e.Run(ctx, prompt)
+		}
+		runs++
+		for _, path := range e.ChangedFiles() {
+			if !seen[path] {
+				seen[path] = true
+				files = append(files, path)
+			}
+		}
+
+		var maxIter *MaxIterationsError
+		switch {
+		case err == nil:
+			failures = 0
+			if taskComplete(e.session.GetMessages()) {
+				reason = "task complete"
+			}
+			prompt = continuePrompt
+		case errors.Is(err, ErrPaused):
+			reason = pausedReason
+		case ctx.Err() != nil:
+			reason, runErr = "interrupted", ctx.Err()
+		case errors.Is(err, ErrBudgetExceeded):
+			reason, runErr = "monthly budget exceeded", err
+		case errors.As(err, &maxIter):
+			// A long turn is a checkpoint, not a failure
+			failures = 0
+			prompt = continuePrompt
+		default:
+			failures++
+			if failures >= maxAutonomousFailures {
+				reason, runErr = fmt.Sprintf("%d turns in a row failed", failures), err
+				break
+			}
+			if !sleepContext(ctx, time.Duration(failures)*autonomousRetryDelay) {
+				reason, runErr = "interrupted", ctx.Err()
+				break
+			}
+			prompt = fmt.Sprintf("[System notice] The last turn stopped with an error: %v\n\n%s", err, continuePrompt)
+		}
+
+		if reason == "" && opts.ReportEvery > 0 && time.Since(lastReport) >= opts.ReportEvery {
+			report(false, "")
+			lastReport = time.Now()
+		}
+	}
+
+	report(true, reason)
+	return runErr
+}
+
+// autonomousLimit returns which limit of opts an autonomous run has
+// reached, or "" while it may continue
+func autonomousLimit(opts AutonomousOptions, runs int, elapsed time.Duration, spent float64) string {
+	switch {
+	case opts.MaxRuns > 0 && runs >= opts.MaxRuns:
+		return fmt.Sprintf("reached %d turns", opts.MaxRuns)
+	case opts.MaxDuration > 0 && elapsed >= opts.MaxDuration:
+		return fmt.Sprintf("reached the time limit of %s", opts.MaxDuration)
+	case opts.MaxCost > 0 && spent >= opts.MaxCost:
+		return fmt.Sprintf("reached the cost limit of $%.2f", opts.MaxCost)
+	}
+	return ""
+}
+
+// taskComplete reports whether the last assistant message contains the
+// completion marker on a line of its own
+func taskComplete(messages []provider.Message) bool {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != provider.RoleAssistant {
+			continue
+		}
+		for _, block := range messages[i].Content {
+			text, ok := block.(*provider.TextBlock)
+			if !ok {
+				continue
+			}
+			for _, line := range strings.Split(text.Text, "\n") {
+				if strings.Trim(line, " \t*_`.") == CompletionMarker {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// summarizeProgress asks the model for a progress report on the
+// conversation so far, without adding the exchange to the session
+func (e *Engine) summarizeProgress(ctx context.Context) (string, error) {
+	req := e.buildRequest()
+	req.Stream = false
+	req.Thinking = nil
+	req.ToolChoice = nil
+	req.MaxTokens = progressMaxTokens
+
+	req.Messages = withNotice(req.Messages, progressPrompt)
+
+	resp, err := e.provider.CreateMessage(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	e.reportUsage(&resp.Usage, nil)
+
+	var parts []string
+	for _, block := range resp.Content {
+		if text, ok := block.(*provider.TextBlock); ok && strings.TrimSpace(text.Text) != "" {
+			parts = append(parts, strings.TrimSpace(text.Text))
+		}
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("empty response")
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// withNotice returns messages with a notice added to the last user
+// message, or in a new one, leaving messages unchanged
+func withNotice(messages []provider.Message, text string) []provider.Message {
+	notice := &provider.TextBlock{Text: text}
+	if n := len(messages); n > 0 && messages[n-1].Role == provider.RoleUser {
+		last := messages[n-1]
+		return append(messages[:n-1:n-1], provider.Message{
+			Role:    provider.RoleUser,
+			Content: append(append([]provider.ContentBlock(nil), last.Content...), notice),
+		})
+	}
+	return append(messages[:len(messages):len(messages)], provider.Message{Role: provider.RoleUser, Content: []provider.ContentBlock{notice}})
+}
+
+// sleepContext waits for d, returning false if ctx is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,210 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func textResponse(text string) *provider.Response {
+	return &provider.Response{
+		StopReason: provider.StopReasonEndTurn,
+		Content:    []provider.ContentBlock{&provider.TextBlock{Text: text}},
+	}
+}
+
+func newAutonomousEngine(prov provider.AIProvider) (*Engine, *session.Session) {
+	sess := session.NewSession(&session.SessionOptions{CWD: "/test", Model: "test-model"})
+	return NewEngine(&EngineOptions{Provider: prov, Registry: tool.NewRegistry(), Session: sess}), sess
+}
+
+func TestRunAutonomousUntilComplete(t *testing.T) {
+	prov := &MockProvider{responses: []*provider.Response{
+		textResponse("Renamed the package."),
+		textResponse("Updated the callers."),
+		textResponse("All tests pass.\n\n**TASK COMPLETE**"),
+		textResponse("Renamed the package and updated its callers; tests pass."),
+	}}
+	eng, sess := newAutonomousEngine(prov)
+
+	var reports []ProgressReport
+	err := eng.RunAutonomous(context.Background(), "Rename pkg/foo to pkg/bar", AutonomousOptions{
+		OnReport: func(report ProgressReport) { reports = append(reports, report) },
+	})
+	if err != nil {
+		t.Fatalf("RunAutonomous returned error: %v", err)
+	}
+
+	if len(reports) != 1 || !reports[0].Final {
+		t.Fatalf("expected only a final report, got %+v", reports)
+	}
+	final := reports[0]
+	if final.Runs != 3 || final.Reason != "task complete" {
+		t.Errorf("expected 3 runs ending with the task complete, got %+v", final)
+	}
+	if !strings.Contains(final.Summary, "updated its callers") {
+		t.Errorf("expected the model's summary, got %q", final.Summary)
+	}
+
+	prompts := sess.Prompts()
+	if len(prompts) != 3 {
+		t.Fatalf("expected 3 prompts in the session, got %d", len(prompts))
+	}
+	messages := sess.GetMessages()
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			if text, ok := block.(*provider.TextBlock); ok && strings.Contains(text.Text, "Summarize the progress") {
+				t.Error("the progress request must not be added to the session")
+			}
+		}
+	}
+	first := messages[0].Content[0].(*provider.TextBlock).Text
+	if !strings.HasPrefix(first, "Rename pkg/foo to pkg/bar") || !strings.Contains(first, CompletionMarker) {
+		t.Errorf("expected the task with autonomous guidance, got %q", first)
+	}
+}
+
+func TestRunAutonomousLimits(t *testing.T) {
+	eng, _ := newAutonomousEngine(&MockProvider{})
+
+	var final ProgressReport
+	err := eng.RunAutonomous(context.Background(), "Keep going", AutonomousOptions{
+		MaxRuns:  2,
+		OnReport: func(report ProgressReport) { final = report },
+	})
+	if err != nil {
+		t.Fatalf("a limit should stop the run without an error, got %v", err)
+	}
+	if final.Runs != 2 || final.Reason != "reached 2 turns" {
+		t.Errorf("unexpected final report: %+v", final)
+	}
+}
+
+func TestRunAutonomousReportsProgress(t *testing.T) {
+	eng, _ := newAutonomousEngine(&MockProvider{})
+
+	var reports []ProgressReport
+	err := eng.RunAutonomous(context.Background(), "Keep going", AutonomousOptions{
+		ReportEvery: time.Nanosecond,
+		MaxRuns:     3,
+		OnReport:    func(report ProgressReport) { reports = append(reports, report) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 4 {
+		t.Fatalf("expected a report after each of 3 turns and a final one, got %d", len(reports))
+	}
+	for i, report := range reports[:3] {
+		if report.Final || report.Runs != i+1 {
+			t.Errorf("report %d: unexpected %+v", i, report)
+		}
+	}
+	if !reports[3].Final {
+		t.Error("expected the last report to be final")
+	}
+}
+
+func TestRunAutonomousFailures(t *testing.T) {
+	delay := autonomousRetryDelay
+	autonomousRetryDelay = 0
+	defer func() { autonomousRetryDelay = delay }()
+
+	eng, _ := newAutonomousEngine(&MockProvider{err: errors.New("overloaded")})
+
+	var final ProgressReport
+	err := eng.RunAutonomous(context.Background(), "Fix the build", AutonomousOptions{
+		OnReport: func(report ProgressReport) { final = report },
+	})
+	if err == nil || !strings.Contains(err.Error(), "overloaded") {
+		t.Fatalf("expected the provider error, got %v", err)
+	}
+	if final.Runs != maxAutonomousFailures || !strings.HasPrefix(final.Summary, "No summary") {
+		t.Errorf("unexpected final report: %+v", final)
+	}
+}
+
+func TestRunAutonomousMaxIterationsContinues(t *testing.T) {
+	prov := &MockProvider{responses: []*provider.Response{
+		{StopReason: provider.StopReasonToolUse, Content: []provider.ContentBlock{
+			&provider.ToolUseBlock{ID: "1", Name: "Missing", Input: map[string]interface{}{}},
+		}},
+		textResponse("Started the refactor; the tool is missing."),
+		textResponse(CompletionMarker),
+	}}
+	eng, _ := newAutonomousEngine(prov)
+	eng.maxIterations = 1
+
+	var final ProgressReport
+	err := eng.RunAutonomous(context.Background(), "Refactor", AutonomousOptions{
+		OnReport: func(report ProgressReport) { final = report },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if final.Runs != 2 || final.Reason != "task complete" {
+		t.Errorf("expected the run to continue past the iteration limit, got %+v", final)
+	}
+}
+
+func TestRunAutonomousPauseAndResume(t *testing.T) {
+	prov := &MockProvider{responses: []*provider.Response{
+		{StopReason: provider.StopReasonToolUse, Content: []provider.ContentBlock{
+			&provider.ToolUseBlock{ID: "1", Name: "Missing", Input: map[string]interface{}{}},
+		}},
+		textResponse(CompletionMarker),
+		textResponse("Finished the refactor."),
+	}}
+	eng, sess := newAutonomousEngine(prov)
+	eng.SetCallbacks(&CallbackOptions{
+		OnToolUse: func(name string, input map[string]interface{}) { eng.Pause() },
+	})
+
+	var final ProgressReport
+	err := eng.RunAutonomous(context.Background(), "Refactor", AutonomousOptions{
+		OnReport: func(report ProgressReport) { final = report },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if final.Reason != pausedReason || sess.Paused() == nil {
+		t.Fatalf("expected the run to pause, got %+v", final)
+	}
+
+	err = eng.RunAutonomous(context.Background(), "Refactor", AutonomousOptions{
+		Resume:   true,
+		OnReport: func(report ProgressReport) { final = report },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if final.Reason != "task complete" || len(sess.Prompts()) != 1 {
+		t.Errorf("expected the resumed run to complete without a new prompt, got %+v", final)
+	}
+}
+
+func TestTaskComplete(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"Done.\nTASK COMPLETE", true},
+		{"**TASK COMPLETE**", true},
+		{"I will say TASK COMPLETE when done", false},
+		{"Still working", false},
+	}
+	for _, tt := range tests {
+		messages := []provider.Message{
+			{Role: provider.RoleAssistant, Content: []provider.ContentBlock{&provider.TextBlock{Text: tt.text}}},
+		}
+		if got := taskComplete(messages); got != tt.want {
+			t.Errorf("taskComplete(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
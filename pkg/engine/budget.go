@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/cost"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// ErrBudgetExceeded is returned by Run when the monthly budget is spent and
+// paid providers are refused
+var ErrBudgetExceeded = errors.New("monthly budget exceeded")
+
+// BudgetAlert reports that spending this month crossed an alert threshold
+type BudgetAlert struct {
+	Threshold float64 // share of the budget, such as 0.8
+	Spent     float64
+	Limit     float64
+	Model     string // model switched to, "" if unchanged
+}
+
+// String describes the alert, such as "Spent 80% of the monthly budget ($40.00 of $50.00)"
+func (a BudgetAlert) String() string {
+	msg := fmt.Sprintf("Spent %.0f%% of the monthly budget ($%.2f of $%.2f)", 100*a.Spent/a.Limit, a.Spent, a.Limit)
+	if a.Model != "" {
+		msg += "; switched to " + a.Model
+	}
+	return msg
+}
+
+// BudgetStatus is the spending this month against the budget
+type BudgetStatus struct {
+	Spent      float64
+	Limit      float64
+	Overridden bool
+}
+
+// String describes the spending, such as "$12.50 of $50.00 spent this month (25%)"
+func (s BudgetStatus) String() string {
+	msg := fmt.Sprintf("$%.2f of $%.2f spent this month (%.0f%%)", s.Spent, s.Limit, 100*s.Spent/s.Limit)
+	if s.Overridden {
+		msg += ", limit overridden"
+	}
+	return msg
+}
+
+// budgetGuard tracks spending this month against the budget
+type budgetGuard struct {
+	budget   *cost.Budget
+	month    time.Time // month spent was loaded for
+	spent    float64
+	override bool
+}
+
+// budgetActive reports whether there is a budget that can be tracked
+func (e *Engine) budgetActive() bool {
+	return e.budget.budget != nil && e.budget.budget.Monthly > 0 && e.ledger != nil
+}
+
+// refreshBudget loads this month's spending from the ledger when the month
+// changed, warning about thresholds that were already passed
+func (e *Engine) refreshBudget(now time.Time) {
+	month := cost.MonthStart(now)
+	if e.budget.month.Equal(month) {
+		return
+	}
+	spent, err := e.ledger.MonthSpend(now)
+	if err != nil {
+		return
+	}
+	if !e.budget.month.IsZero() {
+		e.budget.override = false
+	}
+	e.budget.month = month
+	e.budget.spent = spent
+	e.budgetCrossed(0, spent)
+}
+
+// checkBudget switches to the budget model or refuses the run when the
+// monthly budget is spent
+func (e *Engine) checkBudget() error {
+	if !e.budgetActive() {
+		return nil
+	}
+	e.refreshBudget(time.Now())
+
+	b := e.budget.budget
+	if !b.Exceeded(e.budget.spent) {
+		return nil
+	}
+	if model := e.switchToBudgetModel(); model != "" && e.onBudget != nil {
+		e.onBudget(BudgetAlert{Threshold: 1, Spent: e.budget.spent, Limit: b.Monthly, Model: model})
+	}
+	if b.Enforce && !e.budget.override && e.provider.Name() != string(provider.ProviderTypeOllama) {
+		return fmt.Errorf("%w: $%.2f of $%.2f spent; use /budget override to continue with %s",
+			ErrBudgetExceeded, e.budget.spent, b.Monthly, e.provider.Name())
+	}
+	return nil
+}
+
+// addSpend adds the cost of a response and warns about crossed thresholds
+func (e *Engine) addSpend(amount float64) {
+	if !e.budgetActive() || amount <= 0 {
+		return
+	}
+	e.refreshBudget(time.Now())
+	before := e.budget.spent
+	e.budget.spent += amount
+	e.budgetCrossed(before, e.budget.spent)
+}
+
+// budgetCrossed reports the highest threshold passed between before and after
+func (e *Engine) budgetCrossed(before, after float64) {
+	b := e.budget.budget
+	threshold := b.Crossed(before, after)
+	if threshold == 0 {
+		return
+	}
+	alert := BudgetAlert{Threshold: threshold, Spent: after, Limit: b.Monthly}
+	if b.Exceeded(after) {
+		alert.Model = e.switchToBudgetModel()
+	}
+	if e.onBudget != nil {
+		e.onBudget(alert)
+	}
+}
+
+// switchToBudgetModel switches the session to the budget model when the
+// current provider serves it, returning the new model or ""
+func (e *Engine) switchToBudgetModel() string {
+	b := e.budget.budget
+	if b.Model == "" || e.session == nil {
+		return ""
+	}
+	target := provider.ResolveModel(b.Model)
+	if e.session.Model == target {
+		return ""
+	}
+	current := provider.ProviderType(e.session.Provider)
+	if current == "" {
+		current = provider.DetectProviderFromModel(e.session.Model)
+	}
+	if provider.DetectProviderFromModel(b.Model) != current {
+		return ""
+	}
+	e.session.Model = target
+	return target
+}
+
+// OverrideBudget lets paid providers run for the rest of the month even
+// though the budget is spent
+func (e *Engine) OverrideBudget() {
+	e.budget.override = true
+}
+
+// BudgetStatus returns this month's spending against the budget; ok is false
+// when no budget is configured
+func (e *Engine) BudgetStatus() (status BudgetStatus, ok bool) {
+	if !e.budgetActive() {
+		return BudgetStatus{}, false
+	}
+	e.refreshBudget(time.Now())
+	return BudgetStatus{
+		Spent:      e.budget.spent,
+		Limit:      e.budget.budget.Monthly,
+		Overridden: e.budget.override,
+	}, true
+}
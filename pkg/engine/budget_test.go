@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/cost"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func newBudgetEngine(t *testing.T, spent float64, budget *cost.Budget) (*Engine, *[]BudgetAlert) {
+	t.Helper()
+	ledger := cost.NewLedger(filepath.Join(t.TempDir(), "usage.jsonl"))
+	if spent > 0 {
+		if err := ledger.Record(cost.UsageRecord{Time: time.Now(), Model: "test", Cost: spent}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eng := NewEngine(&EngineOptions{
+		Provider:    &MockProvider{},
+		Registry:    tool.NewRegistry(),
+		Session:     session.NewSession(&session.SessionOptions{CWD: t.TempDir(), Model: "claude-sonnet-4-20250514"}),
+		UsageLedger: ledger,
+		Budget:      budget,
+	})
+	var alerts []BudgetAlert
+	eng.SetCallbacks(&CallbackOptions{
+		OnBudget: func(alert BudgetAlert) { alerts = append(alerts, alert) },
+	})
+	return eng, &alerts
+}
+
+func TestBudgetAlerts(t *testing.T) {
+	eng, alerts := newBudgetEngine(t, 30, &cost.Budget{Monthly: 50})
+
+	if err := eng.Run(context.Background(), "hello"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(*alerts) != 0 {
+		t.Fatalf("expected no alerts below 80%%, got %v", *alerts)
+	}
+
+	eng.addSpend(11)
+	if len(*alerts) != 1 || (*alerts)[0].Threshold != 0.8 {
+		t.Fatalf("expected an 80%% alert, got %v", *alerts)
+	}
+	eng.addSpend(1)
+	if len(*alerts) != 1 {
+		t.Errorf("expected no repeated alert, got %v", *alerts)
+	}
+
+	status, ok := eng.BudgetStatus()
+	if !ok || status.Spent != 42 || status.Limit != 50 {
+		t.Errorf("unexpected status %+v (ok=%v)", status, ok)
+	}
+}
+
+func TestBudgetSwitchesModel(t *testing.T) {
+	eng, alerts := newBudgetEngine(t, 45, &cost.Budget{Monthly: 50, Model: "haiku"})
+
+	eng.addSpend(10)
+	// The first alert reports the 80% already spent when the ledger is loaded
+	if len(*alerts) != 2 || (*alerts)[1].Threshold != 1 {
+		t.Fatalf("expected 80%% and 100%% alerts, got %v", *alerts)
+	}
+	want := provider.ResolveModel("haiku")
+	if (*alerts)[1].Model != want || eng.session.Model != want {
+		t.Errorf("expected switch to %s, alert %v, session model %s", want, *alerts, eng.session.Model)
+	}
+
+	// A budget model from another provider needs a new session
+	eng, _ = newBudgetEngine(t, 60, &cost.Budget{Monthly: 50, Model: "gpt-4o"})
+	if err := eng.Run(context.Background(), "hello"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if eng.session.Model != "claude-sonnet-4-20250514" {
+		t.Errorf("expected model to be kept, got %s", eng.session.Model)
+	}
+}
+
+func TestBudgetEnforce(t *testing.T) {
+	eng, alerts := newBudgetEngine(t, 60, &cost.Budget{Monthly: 50, Enforce: true})
+
+	err := eng.Run(context.Background(), "hello")
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	if len(eng.session.Messages) != 0 {
+		t.Errorf("expected refused prompt not to be recorded, got %d messages", len(eng.session.Messages))
+	}
+	if len(*alerts) != 1 {
+		t.Errorf("expected one alert for the spent budget, got %v", *alerts)
+	}
+
+	eng.OverrideBudget()
+	if err := eng.Run(context.Background(), "hello"); err != nil {
+		t.Errorf("expected override to allow the run, got %v", err)
+	}
+}
+
+func TestBudgetInactive(t *testing.T) {
+	eng, _ := newBudgetEngine(t, 100, nil)
+	if _, ok := eng.BudgetStatus(); ok {
+		t.Error("expected no status without a budget")
+	}
+	if err := eng.Run(context.Background(), "hello"); err != nil {
+		t.Errorf("Run: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// maxCachedFileSize is the largest file whose Read result is cached
+const maxCachedFileSize = 10 * 1024 * 1024
+
+// cacheableTools are the idempotent tools whose results are cached
+var cacheableTools = map[string]bool{
+	"Read":    true,
+	"Glob":    true,
+	"Grep":    true,
+	"Tree":    true,
+	"Targets": true,
+}
+
+// cachedResult is a successful result of an idempotent tool call
+type cachedResult struct {
+	toolUseID  string
+	content    string
+	fileHash   [sha256.Size]byte // Read: hash of the file when it was read
+	generation int               // searches: tree generation when it ran
+}
+
+// resultCache caches results of idempotent tools within a session. Reads
+// stay valid while the file content is unchanged. Searches stay valid until
+// anything may have changed the tree: another tool ran or the user sent a
+// new message.
+type resultCache struct {
+	entries    map[string]*cachedResult
+	generation int
+
+	mu sync.Mutex
+}
+
+// newResultCache creates an empty result cache
+func newResultCache() *resultCache {
+	return &resultCache{entries: make(map[string]*cachedResult)}
+}
+
+// invalidateSearches marks cached search and tree results as stale
+func (c *resultCache) invalidateSearches() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+}
+
+// lookup returns the cached result for a tool call if it is still valid
+func (c *resultCache) lookup(toolName string, input map[string]interface{}, cwd string) (*cachedResult, bool) {
+	if !cacheableTools[toolName] {
+		return nil, false
+	}
+	key, ok := cacheKey(toolName, input, cwd)
+	if !ok {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if toolName == "Read" {
+		hash, ok := hashFile(readPath(input, cwd))
+		if !ok || hash != entry.fileHash {
+			delete(c.entries, key)
+			return nil, false
+		}
+	} else if entry.generation != c.generation {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return entry, true
+}
+
+// store caches the result of a successful tool call
+func (c *resultCache) store(toolName string, input map[string]interface{}, cwd, toolUseID, content string) {
+	if !cacheableTools[toolName] {
+		return
+	}
+	key, ok := cacheKey(toolName, input, cwd)
+	if !ok {
+		return
+	}
+
+	entry := &cachedResult{toolUseID: toolUseID, content: content}
+	if toolName == "Read" {
+		hash, ok := hashFile(readPath(input, cwd))
+		if !ok {
+			return
+		}
+		entry.fileHash = hash
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.generation = c.generation
+	c.entries[key] = entry
+}
+
+// cacheKey identifies a tool call by tool name, working directory, and arguments
+func cacheKey(toolName string, input map[string]interface{}, cwd string) (string, bool) {
+	// encoding/json sorts map keys, so equal arguments encode identically
+	args, err := json.Marshal(input)
+	if err != nil {
+		return "", false
+	}
+	return toolName + "\x00" + cwd + "\x00" + string(args), true
+}
+
+// readPath returns the absolute path of the file a Read call targets
+func readPath(input map[string]interface{}, cwd string) string {
+	path, _ := input["file_path"].(string)
+	if path != "" && !filepath.IsAbs(path) && cwd != "" {
+		path = filepath.Join(cwd, path)
+	}
+	return path
+}
+
+// hashFile returns the content hash of a regular file small enough to cache
+func hashFile(path string) ([sha256.Size]byte, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxCachedFileSize {
+		return [sha256.Size]byte{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, false
+	}
+	return sha256.Sum256(data), true
+}
+
+// cachedContent returns the tool result for a cache hit. When the earlier
+// result is still in the conversation, the model is pointed to it instead
+// of receiving the same content again.
+func cachedContent(toolName string, entry *cachedResult, inConversation bool) string {
+	unchanged := "the search results are unchanged"
+	if toolName == "Read" {
+		unchanged = "the file is unchanged"
+	}
+	if inConversation {
+		return fmt.Sprintf("[cached] Same result as the earlier %s call (%s): %s. Refer to that result instead of repeating the call.",
+			toolName, entry.toolUseID, unchanged)
+	}
+	return fmt.Sprintf("[cached] Result of an earlier %s call; %s.\n\n%s", toolName, unchanged, entry.content)
+}
@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestResultCacheRead(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	os.WriteFile(path, []byte("package a"), 0644)
+
+	c := newResultCache()
+	input := map[string]interface{}{"file_path": path}
+	c.store("Read", input, dir, "t1", "1\tpackage a")
+
+	if entry, ok := c.lookup("Read", input, dir); !ok || entry.toolUseID != "t1" {
+		t.Fatalf("expected cache hit for unchanged file, got %v, %v", entry, ok)
+	}
+	if _, ok := c.lookup("Read", map[string]interface{}{"file_path": path, "offset": 10}, dir); ok {
+		t.Error("expected miss for different arguments")
+	}
+
+	// Searches and other tools do not invalidate reads of unchanged files
+	c.invalidateSearches()
+	if _, ok := c.lookup("Read", input, dir); !ok {
+		t.Error("expected read to stay cached while the file is unchanged")
+	}
+
+	os.WriteFile(path, []byte("package b"), 0644)
+	if _, ok := c.lookup("Read", input, dir); ok {
+		t.Error("expected miss after the file changed")
+	}
+}
+
+func TestResultCacheSearch(t *testing.T) {
+	c := newResultCache()
+	input := map[string]interface{}{"pattern": "**/*.go"}
+	c.store("Glob", input, "/work", "t1", "a.go")
+
+	if _, ok := c.lookup("Glob", input, "/work"); !ok {
+		t.Error("expected cache hit for repeated search")
+	}
+	if _, ok := c.lookup("Glob", input, "/other"); ok {
+		t.Error("expected miss in another directory")
+	}
+
+	c.invalidateSearches()
+	if _, ok := c.lookup("Glob", input, "/work"); ok {
+		t.Error("expected miss after the tree may have changed")
+	}
+
+	c.store("Bash", map[string]interface{}{"command": "ls"}, "/work", "t2", "a.go")
+	if _, ok := c.lookup("Bash", map[string]interface{}{"command": "ls"}, "/work"); ok {
+		t.Error("expected non-idempotent tools not to be cached")
+	}
+}
+
+func TestRunServesRepeatedReadsFromCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	os.WriteFile(path, []byte("package a"), 0644)
+
+	read := func(id string) *provider.Response {
+		return &provider.Response{
+			StopReason: provider.StopReasonToolUse,
+			Content: []provider.ContentBlock{
+				&provider.ToolUseBlock{ID: id, Name: "Read", Input: map[string]interface{}{"file_path": path}},
+			},
+		}
+	}
+	prov := &MockProvider{
+		responses: []*provider.Response{
+			read("t1"),
+			read("t2"),
+			{StopReason: provider.StopReasonEndTurn, Content: []provider.ContentBlock{&provider.TextBlock{Text: "Done"}}},
+		},
+	}
+
+	executions := 0
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{
+		name: "Read",
+		executeFunc: func(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+			executions++
+			return &tool.Output{Content: "1\tpackage a"}, nil
+		},
+	})
+
+	sess := session.NewSession(&session.SessionOptions{CWD: dir})
+	eng := NewEngine(&EngineOptions{Provider: prov, Registry: registry, Session: sess})
+	if err := eng.Run(context.Background(), "Read it twice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if executions != 1 {
+		t.Errorf("expected the tool to run once, ran %d times", executions)
+	}
+	second, ok := sess.ToolResult("t2")
+	second = eng.results.unwrap(second)
+	if !ok || !strings.HasPrefix(second, "[cached]") || !strings.Contains(second, "t1") {
+		t.Errorf("expected cached marker pointing to t1, got %q", second)
+	}
+}
@@ -0,0 +1,19 @@
+package engine
+
+import "fmt"
+
+// CoverageRequest returns the user message asking the agent to close the
+// test coverage gaps of target
+func CoverageRequest(target string) string {
+	if target == "" {
+		target = "the whole project"
+	}
+	return fmt.Sprintf(`Improve the test coverage of %s.
+
+1. Run the Coverage tool to find the least covered functions and their uncovered lines.
+2. Write tests for the most important gaps first: error paths, edge cases, and branches with logic. Skip trivial getters and generated code.
+3. Follow the existing test layout and style of each package, and do not change the code under test unless a test reveals a bug.
+4. Run the Coverage tool again to confirm the new tests pass and coverage improved.
+
+Finish with the coverage before and after, and any bugs found.`, target)
+}
@@ -0,0 +1,15 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCoverageRequest(t *testing.T) {
+	if got := CoverageRequest("./pkg/engine/..."); !strings.Contains(got, "coverage of ./pkg/engine/...") {
+		t.Errorf("expected target in request, got:\n%s", got)
+	}
+	if got := CoverageRequest(""); !strings.Contains(got, "coverage of the whole project") {
+		t.Errorf("expected whole project by default, got:\n%s", got)
+	}
+}
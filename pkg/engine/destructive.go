@@ -0,0 +1,164 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/permission"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// DestructiveAction is a destructive tool call awaiting the user's approval
+type DestructiveAction struct {
+	permission.Destructive
+	Verdict string // why the verifying model rejected the call, "" if none was asked
+}
+
+// DestructiveVerifier is a second model that approves destructive tool
+// calls in place of the user
+type DestructiveVerifier struct {
+	Provider provider.AIProvider
+	Model    string
+}
+
+// verifyTimeout bounds a verification request
+const verifyTimeout = time.Minute
+
+// verifyPrompt instructs the verifying model
+const verifyPrompt = `You review destructive actions an AI coding agent wants to take on a
+user's machine before they run. Approve an action only when the user's
+request clearly calls for it and its effect is limited to what the request
+needs. Reject actions that could lose work, data, or history the user did
+not ask to remove, that reach beyond the project, or whose purpose is
+unclear.
+
+Reply with APPROVE or REJECT on the first line, then one sentence explaining
+why.`
+
+// checkDestructive gates tool calls the classifier finds destructive, in
+// every permission mode: the verifying model or the user must approve
+// them. It returns an error for the model when the call is refused.
+func (e *Engine) checkDestructive(ctx context.Context, toolName string, input map[string]interface{}) error {
+	found := e.destructive.Classify(toolName, input)
+	if found == nil {
+		return nil
+	}
+	action := DestructiveAction{Destructive: *found}
+
+	if e.verifier != nil {
+		approved, verdict, err := e.verifyDestructive(ctx, found)
+		if err == nil && approved {
+			return nil
+		}
+		if err != nil {
+			verdict = fmt.Sprintf("verification failed: %v", err)
+		}
+		action.Verdict = verdict
+	}
+
+	if e.onDestructive != nil && e.onDestructive(action) {
+		return nil
+	}
+	if action.Verdict != "" {
+		return fmt.Errorf("this command %s and was not approved (%s); find a safer way, or ask the user to run it", found.Reason, action.Verdict)
+	}
+	return fmt.Errorf("this command %s and the user did not approve it; do not retry it without asking the user", found.Reason)
+}
+
+// verifyDestructive asks the verifying model whether a destructive call
+// serves the user's request, returning its verdict
+func (e *Engine) verifyDestructive(ctx context.Context, action *permission.Destructive) (bool, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, verifyTimeout)
+	defer cancel()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "The user's request:\n%s\n\n", e.userRequest())
+	if reasoning := lastAssistantText(e.session.GetMessages()); reasoning != "" {
+		fmt.Fprintf(&b, "The agent's explanation:\n%s\n\n", reasoning)
+	}
+	fmt.Fprintf(&b, "The action, which %s:\n%s %s", action.Reason, action.Tool, action.Target)
+	if len(action.Analysis) > 0 {
+		b.WriteString("\n\nStatic analysis of the command:")
+		for _, f := range action.Analysis {
+			fmt.Fprintf(&b, "\n- %s", f.Explanation)
+		}
+	}
+
+	resp, err := e.verifier.Provider.CreateMessage(ctx, &provider.Request{
+		Model:     e.verifier.Model,
+		System:    []provider.ContentBlock{&provider.TextBlock{Text: verifyPrompt}},
+		Messages:  []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentBlock{&provider.TextBlock{Text: b.String()}}}},
+		MaxTokens: 256,
+	})
+	if err != nil {
+		return false, "", err
+	}
+
+	var reply string
+	for _, block := range resp.Content {
+		if text, ok := block.(*provider.TextBlock); ok {
+			reply += text.Text
+		}
+	}
+	return parseVerdict(reply)
+}
+
+// parseVerdict reads a verifying model's reply: APPROVE or REJECT on the
+// first line, then the reason. Anything else is an error.
+func parseVerdict(reply string) (bool, string, error) {
+	reply = strings.TrimSpace(reply)
+	first, reason, _ := strings.Cut(reply, "\n")
+	word := strings.ToUpper(strings.Trim(strings.TrimSpace(first), "*_`.:"))
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		reason = word
+	}
+	switch {
+	case strings.HasPrefix(word, "APPROVE"):
+		return true, reason, nil
+	case strings.HasPrefix(word, "REJECT"):
+		return false, reason, nil
+	}
+	return false, "", fmt.Errorf("unclear verdict %q", truncateVerdict(reply))
+}
+
+// userRequest returns the user's latest request, skipping the prompts
+// autonomous runs and system notices send in the user's place
+func (e *Engine) userRequest() string {
+	prompts := e.session.Prompts()
+	for i := len(prompts) - 1; i >= 0; i-- {
+		text := prompts[i].Text
+		if text == continuePrompt || strings.HasPrefix(text, "[System notice]") {
+			continue
+		}
+		return text
+	}
+	return "(unknown)"
+}
+
+// lastAssistantText returns the text of the last assistant message
+func lastAssistantText(messages []provider.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != provider.RoleAssistant {
+			continue
+		}
+		var parts []string
+		for _, block := range messages[i].Content {
+			if text, ok := block.(*provider.TextBlock); ok && strings.TrimSpace(text.Text) != "" {
+				parts = append(parts, strings.TrimSpace(text.Text))
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// truncateVerdict shortens a reply for an error message
+func truncateVerdict(reply string) string {
+	if len(reply) > 80 {
+		return reply[:80] + "..."
+	}
+	return reply
+}
@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/permission"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// runDestructive runs a turn in which the model calls Bash with command,
+// returning whether the command ran and the tool result in the session
+func runDestructive(t *testing.T, command string, verifier *DestructiveVerifier, approve func(DestructiveAction) bool) (bool, string) {
+	t.Helper()
+	prov := &MockProvider{responses: []*provider.Response{
+		{StopReason: provider.StopReasonToolUse, Content: []provider.ContentBlock{
+			&provider.TextBlock{Text: "Removing the stale build directory."},
+			&provider.ToolUseBlock{ID: "tool_1", Name: "Bash", Input: map[string]interface{}{"command": command}},
+		}},
+		textResponse("Done."),
+	}}
+
+	ran := false
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{name: "Bash", executeFunc: func(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+		ran = true
+		return &tool.Output{Content: "ok"}, nil
+	}})
+
+	classifier, err := permission.NewClassifier(permission.DefaultDestructiveRules())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := session.NewSession(&session.SessionOptions{CWD: "/test", Model: "test-model"})
+	eng := NewEngine(&EngineOptions{
+		Provider:            prov,
+		Registry:            registry,
+		Session:             sess,
+		Destructive:         classifier,
+		DestructiveVerifier: verifier,
+		NoStream:            true,
+	})
+	if approve != nil {
+		eng.SetCallbacks(&CallbackOptions{OnDestructive: approve})
+	}
+	if err := eng.Run(context.Background(), "Clean up the build output"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, _ := sess.ToolResult("tool_1")
+	return ran, result
+}
+
+func TestDestructiveNeedsApproval(t *testing.T) {
+	if ran, _ := runDestructive(t, "go build ./...", nil, nil); !ran {
+		t.Error("expected a safe command to run without approval")
+	}
+
+	ran, result := runDestructive(t, "rm -rf build", nil, nil)
+	if ran || !strings.Contains(result, "did not approve") {
+		t.Errorf("expected rm to be refused without a user, ran=%v result=%q", ran, result)
+	}
+
+	var asked DestructiveAction
+	ran, _ = runDestructive(t, "rm -rf build", nil, func(action DestructiveAction) bool {
+		asked = action
+		return true
+	})
+	if !ran || asked.Rule != "rm" || asked.Target != "rm -rf build" || asked.Verdict != "" {
+		t.Errorf("expected the user to approve rm, ran=%v action=%+v", ran, asked)
+	}
+}
+
+func TestDestructiveVerifier(t *testing.T) {
+	approving := &MockProvider{responses: []*provider.Response{textResponse("APPROVE\nThe user asked to clean the build output.")}}
+	ran, _ := runDestructive(t, "rm -rf build", &DestructiveVerifier{Provider: approving, Model: "haiku"}, func(DestructiveAction) bool {
+		t.Error("the user should not be asked when the verifier approves")
+		return false
+	})
+	if !ran {
+		t.Error("expected the verifier to approve the command")
+	}
+
+	rejecting := &MockProvider{responses: []*provider.Response{textResponse("**REJECT**\nThe request does not mention the home directory.")}}
+	var asked DestructiveAction
+	ran, result := runDestructive(t, "rm -rf ~", &DestructiveVerifier{Provider: rejecting, Model: "haiku"}, func(action DestructiveAction) bool {
+		asked = action
+		return false
+	})
+	if ran || asked.Verdict != "The request does not mention the home directory." {
+		t.Errorf("expected the user to see the rejection, ran=%v action=%+v", ran, asked)
+	}
+	if !strings.Contains(result, "home directory") {
+		t.Errorf("expected the verdict in the tool result, got %q", result)
+	}
+}
+
+func TestParseVerdict(t *testing.T) {
+	tests := []struct {
+		reply    string
+		approved bool
+		reason   string
+		err      bool
+	}{
+		{"APPROVE\nRequested by the user.", true, "Requested by the user.", false},
+		{"Reject: \nDeletes the repository.", false, "Deletes the repository.", false},
+		{"REJECT", false, "REJECT", false},
+		{"Sure, go ahead", false, "", true},
+	}
+	for _, tt := range tests {
+		approved, reason, err := parseVerdict(tt.reply)
+		if approved != tt.approved || reason != tt.reason || (err != nil) != tt.err {
+			t.Errorf("parseVerdict(%q) = %v, %q, %v", tt.reply, approved, reason, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExplainTools are the only tools enabled when explaining a repository
+var ExplainTools = []string{"Read", "Glob", "Grep", "Tree", "Targets"}
+
+// explainGuidance describes the architecture overview document
+const explainGuidance = `# Task
+
+You are onboarding a new team member to this codebase. You have read-only
+access: you can search and read files, but not modify them or run commands.
+
+Explore the repository before writing: read the README and build files, view
+the layout with Tree, find the entry points, and read the central packages.
+Use Targets to find how the project is built and tested.
+Base every statement on code you have read and cite file paths. Say so when
+something is unclear rather than guessing.
+
+When asked for an overview, reply with a single Markdown document with these
+sections:
+
+1. Purpose - what the project does and who uses it
+2. Layout - the top-level directories and what lives in each
+3. Key components - the main packages or modules and their responsibilities
+4. Data flow - how a typical request or operation moves through the system
+5. Entry points - binaries, commands, servers, or public APIs
+6. Building and testing - how to build, run, and test the project
+7. Where to start - the files a newcomer should read first
+
+When asked a question, answer it directly in Markdown with references to the
+relevant files instead of writing the full overview.
+
+Your final reply is saved as the document, so it must contain only the
+document: no preamble and no remarks about the exploration itself.`
+
+// BuildExplain returns the system prompt for read-only repository explanation
+func (p *PromptBuilder) BuildExplain() string {
+	sections := []string{
+		SubagentPrompts["Explore"],
+		explainGuidance,
+		p.buildEnvironmentInfo(),
+	}
+	if md := p.buildClaudeMD(); md != "" {
+		sections = append(sections, md)
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// ExplainRequest returns the user message asking for an overview of scope,
+// or an answer to question when one is given
+func ExplainRequest(scope, question string) string {
+	target := "this repository"
+	if scope != "" && scope != "." {
+		target = fmt.Sprintf("%s in this repository", scope)
+	}
+	if question != "" {
+		return fmt.Sprintf("Answer this question about %s:\n\n%s", target, question)
+	}
+	return fmt.Sprintf("Write an architecture overview of %s.", target)
+}
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildExplain(t *testing.T) {
+	p := NewPromptBuilder()
+	p.CWD = "/work/app"
+	p.AgentMD = "Use tabs."
+
+	got := p.BuildExplain()
+	for _, want := range []string{"read-only", "Where to start", "/work/app", "Use tabs."} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected explain prompt to contain %q", want)
+		}
+	}
+	if strings.Contains(got, "Git Commit Rules") {
+		t.Error("explain prompt should not include the coding prompt sections")
+	}
+}
+
+func TestExplainRequest(t *testing.T) {
+	tests := []struct {
+		scope, question string
+		want            string
+	}{
+		{"", "", "Write an architecture overview of this repository."},
+		{".", "", "Write an architecture overview of this repository."},
+		{"pkg/engine", "", "Write an architecture overview of pkg/engine in this repository."},
+		{"", "How are sessions saved?", "Answer this question about this repository:\n\nHow are sessions saved?"},
+	}
+
+	for _, tt := range tests {
+		if got := ExplainRequest(tt.scope, tt.question); got != tt.want {
+			t.Errorf("ExplainRequest(%q, %q) = %q, want %q", tt.scope, tt.question, got, tt.want)
+		}
+	}
+}
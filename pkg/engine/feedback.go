@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ToolFailureError is returned when a tool keeps failing and the run is aborted
+type ToolFailureError struct {
+	Tool      string
+	Failures  int
+	LastError string
+}
+
+func (e *ToolFailureError) Error() string {
+	return fmt.Sprintf("tool %s failed %d times in a row, aborting: %s", e.Tool, e.Failures, firstLine(e.LastError))
+}
+
+// toolErrorPattern maps a known error signature to its likely cause and fix
+type toolErrorPattern struct {
+	match      []string // lowercase substrings, any of which match
+	cause      string
+	correction string
+}
+
+var toolErrorPatterns = []toolErrorPattern{
+	{
+		match:      []string{"unknown tool", "tool not found"},
+		cause:      "The tool name does not exist in the registry.",
+		correction: "Use only the tools listed in the system prompt, with their exact names.",
+	},
+	{
+		match:      []string{"validation error", "is required", "missing required", "invalid parameter"},
+		cause:      "The tool input does not match its schema.",
+		correction: "Check the tool's input schema and provide all required parameters with the correct types.",
+	},
+	{
+		match:      []string{"old_string not found", "string not found", "no match"},
+		cause:      "The text to replace does not appear in the file exactly as given.",
+		correction: "Read the file again and copy the exact text, including whitespace and indentation.",
+	},
+	{
+		match:      []string{"not unique", "multiple matches", "appears more than once"},
+		cause:      "The text to replace matches more than one location.",
+		correction: "Include more surrounding context to make the match unique, or use replace_all.",
+	},
+	{
+		match:      []string{"no such file", "file not found", "does not exist", "cannot find the path"},
+		cause:      "The path does not exist.",
+		correction: "Verify the path with Glob or LS, and use an absolute path.",
+	},
+	{
+		match:      []string{"outside the project directory"},
+		cause:      "The path is outside the directories the agent may use.",
+		correction: "Work inside the project directory, or ask the user to allow the directory.",
+	},
+	{
+		match:      []string{"permission denied", "operation not permitted", "access is denied"},
+		cause:      "The process lacks permission for this operation.",
+		correction: "Use a path inside the project directory or a command that does not need elevated privileges.",
+	},
+	{
+		match:      []string{"command not found", "executable file not found", "is not recognized as"},
+		cause:      "The command is not installed or not on PATH.",
+		correction: "Check which tools are available, or use an alternative command.",
+	},
+	{
+		match:      []string{"timed out", "timeout", "deadline exceeded"},
+		cause:      "The operation took too long.",
+		correction: "Narrow the operation, run it in the background, or increase the timeout.",
+	},
+	{
+		match:      []string{"is a directory"},
+		cause:      "A directory was given where a file was expected.",
+		correction: "Pass a file path, or list the directory first.",
+	},
+	{
+		match:      []string{"blocked"},
+		cause:      "A hook or permission rule blocked the tool call.",
+		correction: "Do not retry the same call; choose a different approach or ask the user.",
+	},
+	{
+		match:      []string{"exit status", "exit code"},
+		cause:      "The command ran but exited with a non-zero status.",
+		correction: "Read the command output above for the actual error and fix that before rerunning.",
+	},
+}
+
+// analyzeToolError renders structured corrective feedback for a failed tool call
+func analyzeToolError(toolName string, errText string, streak int, repeated bool, maxFailures int) string {
+	cause := "Unknown; see the original error below."
+	correction := "Read the error carefully and adjust the input before retrying."
+
+	lower := strings.ToLower(errText)
+	for _, p := range toolErrorPatterns {
+		if containsAny(lower, p.match) {
+			cause, correction = p.cause, p.correction
+			break
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Tool call failed.\n\n")
+	fmt.Fprintf(&b, "What failed: %s: %s\n", toolName, firstLine(errText))
+	fmt.Fprintf(&b, "Likely cause: %s\n", cause)
+	fmt.Fprintf(&b, "Suggested correction: %s\n", correction)
+
+	if repeated {
+		b.WriteString("\nThis is the same input as the previous failed attempt. Retrying it unchanged will fail again; change your approach.\n")
+	}
+	if maxFailures > 0 && streak > 1 {
+		fmt.Fprintf(&b, "\n%s has failed %d times in a row; the run is aborted after %d consecutive failures.\n", toolName, streak, maxFailures)
+	}
+
+	fmt.Fprintf(&b, "\nOriginal error:\n%s", errText)
+	return b.String()
+}
+
+// failureStreak tracks consecutive failures of a single tool
+type failureStreak struct {
+	count     int
+	lastInput string
+	lastError string
+}
+
+// failureTracker tracks per-tool failure streaks within a run
+type failureTracker struct {
+	streaks map[string]*failureStreak
+}
+
+func newFailureTracker() *failureTracker {
+	return &failureTracker{streaks: make(map[string]*failureStreak)}
+}
+
+// recordFailure records a failure and reports the streak length and
+// whether the input is identical to the previous failed attempt
+func (f *failureTracker) recordFailure(toolName string, input map[string]interface{}, errText string) (int, bool) {
+	key := inputKey(input)
+
+	s, ok := f.streaks[toolName]
+	if !ok {
+		s = &failureStreak{}
+		f.streaks[toolName] = s
+	}
+	repeated := s.count > 0 && s.lastInput == key
+
+	s.count++
+	s.lastInput = key
+	s.lastError = errText
+	return s.count, repeated
+}
+
+// recordSuccess resets the streak for a tool
+func (f *failureTracker) recordSuccess(toolName string) {
+	delete(f.streaks, toolName)
+}
+
+// exceeded returns an error for the first tool whose streak reached max
+func (f *failureTracker) exceeded(max int) error {
+	if max <= 0 {
+		return nil
+	}
+	for name, s := range f.streaks {
+		if s.count >= max {
+			return &ToolFailureError{Tool: name, Failures: s.count, LastError: s.lastError}
+		}
+	}
+	return nil
+}
+
+// reset clears all streaks
+func (f *failureTracker) reset() {
+	f.streaks = make(map[string]*failureStreak)
+}
+
+// inputKey returns a stable key for a tool input
+func inputKey(input map[string]interface{}) string {
+	data, err := json.Marshal(input) // map keys are sorted
+	if err != nil {
+		return fmt.Sprintf("%v", input)
+	}
+	return string(data)
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestAnalyzeToolError(t *testing.T) {
+	tests := []struct {
+		name     string
+		errText  string
+		wantText string
+	}{
+		{"missing file", "open /tmp/x.go: no such file or directory", "The path does not exist."},
+		{"edit mismatch", "old_string not found in file", "does not appear in the file exactly"},
+		{"missing command", "bash: foo: command not found", "not installed or not on PATH"},
+		{"unknown", "something odd happened", "Unknown; see the original error below."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := analyzeToolError("Bash", tt.errText, 1, false, 5)
+			if !strings.Contains(got, tt.wantText) {
+				t.Errorf("expected %q in feedback:\n%s", tt.wantText, got)
+			}
+			if !strings.Contains(got, "What failed: Bash: ") || !strings.HasSuffix(got, tt.errText) {
+				t.Errorf("feedback missing tool name or original error:\n%s", got)
+			}
+		})
+	}
+
+	got := analyzeToolError("Bash", "exit status 1", 3, true, 5)
+	if !strings.Contains(got, "same input as the previous failed attempt") || !strings.Contains(got, "failed 3 times in a row") {
+		t.Errorf("expected repeat and streak notes:\n%s", got)
+	}
+}
+
+func TestFailureTracker(t *testing.T) {
+	f := newFailureTracker()
+	input := map[string]interface{}{"command": "make"}
+
+	if streak, repeated := f.recordFailure("Bash", input, "exit status 2"); streak != 1 || repeated {
+		t.Errorf("first failure: streak=%d repeated=%v", streak, repeated)
+	}
+	if streak, repeated := f.recordFailure("Bash", input, "exit status 2"); streak != 2 || !repeated {
+		t.Errorf("second failure: streak=%d repeated=%v", streak, repeated)
+	}
+	if _, repeated := f.recordFailure("Bash", map[string]interface{}{"command": "make test"}, "exit status 2"); repeated {
+		t.Error("different input should not be reported as repeated")
+	}
+
+	if err := f.exceeded(4); err != nil {
+		t.Errorf("unexpected error below max: %v", err)
+	}
+	var failErr *ToolFailureError
+	if err := f.exceeded(3); !errors.As(err, &failErr) || failErr.Tool != "Bash" || failErr.Failures != 3 {
+		t.Errorf("expected ToolFailureError, got %v", err)
+	}
+	if err := f.exceeded(0); err != nil {
+		t.Errorf("max 0 should never abort, got %v", err)
+	}
+
+	f.recordSuccess("Bash")
+	if err := f.exceeded(1); err != nil {
+		t.Errorf("success should reset the streak, got %v", err)
+	}
+}
+
+func TestRunAbortsOnToolFailureStreak(t *testing.T) {
+	failing := func(id string) *provider.Response {
+		return &provider.Response{
+			StopReason: provider.StopReasonToolUse,
+			Content: []provider.ContentBlock{
+				&provider.ToolUseBlock{ID: id, Name: "broken", Input: map[string]interface{}{"arg": "x"}},
+			},
+		}
+	}
+	prov := &MockProvider{
+		responses: []*provider.Response{failing("t1"), failing("t2"), failing("t3"), failing("t4")},
+	}
+
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{
+		name: "broken",
+		executeFunc: func(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+			return &tool.Output{Content: "open x: no such file or directory", IsError: true}, nil
+		},
+	})
+
+	sess := session.NewSession(&session.SessionOptions{CWD: "/test", Model: "test-model"})
+	eng := NewEngine(&EngineOptions{
+		Provider:           prov,
+		Registry:           registry,
+		Session:            sess,
+		CorrectiveFeedback: true,
+		MaxToolFailures:    3,
+	})
+
+	err := eng.Run(context.Background(), "Use the broken tool")
+
+	var failErr *ToolFailureError
+	if !errors.As(err, &failErr) || failErr.Failures != 3 {
+		t.Fatalf("expected abort after 3 failures, got %v", err)
+	}
+	if prov.responseIdx != 3 {
+		t.Errorf("expected 3 provider calls, got %d", prov.responseIdx)
+	}
+
+	// The last tool result carries corrective feedback
+	messages := sess.GetMessages()
+	last := messages[len(messages)-1].Content[0].(*provider.ToolResultBlock)
+	if !last.IsError || !strings.Contains(last.Content, "Likely cause: The path does not exist.") {
+		t.Errorf("unexpected tool result %+v", last)
+	}
+}
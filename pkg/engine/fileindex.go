@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxReportedChanges caps the number of files listed in a change notice
+const maxReportedChanges = 20
+
+// fileState is the state of a file when the agent last read or wrote it
+type fileState struct {
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+	exists  bool
+}
+
+// FileChange is a file that changed since the agent last saw it
+type FileChange struct {
+	Path    string
+	Deleted bool
+}
+
+// fileIndex tracks the files the agent has read or written, so that changes
+// made outside the agent, such as edits in the user's editor, can be
+// reported before the model acts on stale content
+type fileIndex struct {
+	files map[string]fileState
+	mu    sync.Mutex
+}
+
+// newFileIndex creates an empty file index
+func newFileIndex() *fileIndex {
+	return &fileIndex{files: make(map[string]fileState)}
+}
+
+// record stores the current state of path as seen by the agent
+func (x *fileIndex) record(path string) {
+	state, ok := statFile(path, nil)
+	if !ok || !state.exists {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.files[path] = state
+}
+
+// changes returns the tracked files whose content changed since they were
+// recorded. Each change is reported once.
+func (x *fileIndex) changes() []FileChange {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	var changes []FileChange
+	for path, old := range x.files {
+		current, ok := statFile(path, &old)
+		if !ok || current.exists == old.exists && current.hash == old.hash {
+			if ok {
+				x.files[path] = current // only the timestamp changed
+			}
+			continue
+		}
+		changes = append(changes, FileChange{Path: path, Deleted: !current.exists})
+		if current.exists {
+			x.files[path] = current
+		} else {
+			delete(x.files, path)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// statFile returns the state of path. When the size and modification time
+// match prev, the file is not read again.
+func statFile(path string, prev *fileState) (fileState, bool) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return fileState{}, true
+	}
+	if err != nil || !info.Mode().IsRegular() {
+		return fileState{}, false
+	}
+
+	state := fileState{modTime: info.ModTime(), size: info.Size(), exists: true}
+	if prev != nil && prev.exists && prev.size == state.size && prev.modTime.Equal(state.modTime) {
+		state.hash = prev.hash
+		return state, true
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileState{}, false
+	}
+	state.hash = sha256.Sum256(data)
+	return state, true
+}
+
+// changeNotice describes changed files for the model, with paths relative to cwd
+func changeNotice(changes []FileChange, cwd string) string {
+	var b strings.Builder
+	b.WriteString("[System notice] These files changed since you last read them, possibly edited by the user. Read them again before editing them:")
+	for i, change := range changes {
+		if i == maxReportedChanges {
+			fmt.Fprintf(&b, "\n- ... and %d more", len(changes)-i)
+			break
+		}
+		path := change.Path
+		if rel, err := filepath.Rel(cwd, path); cwd != "" && err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		status := "modified"
+		if change.Deleted {
+			status = "deleted"
+		}
+		fmt.Fprintf(&b, "\n- %s (%s)", path, status)
+	}
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestFileIndexChanges(t *testing.T) {
+	dir := t.TempDir()
+	edited := filepath.Join(dir, "edited.go")
+	touched := filepath.Join(dir, "touched.go")
+	deleted := filepath.Join(dir, "deleted.go")
+	for _, path := range []string{edited, touched, deleted} {
+		os.WriteFile(path, []byte("package a"), 0644)
+	}
+
+	x := newFileIndex()
+	for _, path := range []string{edited, touched, deleted} {
+		x.record(path)
+	}
+	x.record(filepath.Join(dir, "missing.go"))
+
+	if changes := x.changes(); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+
+	os.WriteFile(edited, []byte("package b"), 0644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(touched, later, later) // same content
+	os.Remove(deleted)
+
+	changes := x.changes()
+	want := []FileChange{{Path: deleted, Deleted: true}, {Path: edited}}
+	if len(changes) != len(want) || changes[0] != want[0] || changes[1] != want[1] {
+		t.Errorf("changes() = %v, want %v", changes, want)
+	}
+
+	// Each change is reported once
+	if changes := x.changes(); len(changes) != 0 {
+		t.Errorf("expected changes to be reported once, got %v", changes)
+	}
+}
+
+func TestChangeNotice(t *testing.T) {
+	notice := changeNotice([]FileChange{
+		{Path: "/work/app/main.go"},
+		{Path: "/work/app/old.go", Deleted: true},
+		{Path: "/etc/hosts"},
+	}, "/work/app")
+
+	for _, want := range []string{"- main.go (modified)", "- old.go (deleted)", "- /etc/hosts (modified)"} {
+		if !strings.Contains(notice, want) {
+			t.Errorf("expected notice to contain %q, got %q", want, notice)
+		}
+	}
+}
+
+func TestRunNoticesOutsideChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	os.WriteFile(path, []byte("package a"), 0644)
+
+	prov := &MockProvider{
+		responses: []*provider.Response{
+			{
+				StopReason: provider.StopReasonToolUse,
+				Content: []provider.ContentBlock{
+					&provider.ToolUseBlock{ID: "t1", Name: "Read", Input: map[string]interface{}{"file_path": path}},
+				},
+			},
+			{StopReason: provider.StopReasonEndTurn, Content: []provider.ContentBlock{&provider.TextBlock{Text: "Read it"}}},
+			{StopReason: provider.StopReasonEndTurn, Content: []provider.ContentBlock{&provider.TextBlock{Text: "Done"}}},
+		},
+	}
+
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{name: "Read"})
+
+	sess := session.NewSession(&session.SessionOptions{CWD: dir})
+	eng := NewEngine(&EngineOptions{Provider: prov, Registry: registry, Session: sess})
+	if err := eng.Run(context.Background(), "Read a.go"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The user edits the file between prompts
+	os.WriteFile(path, []byte("package b"), 0644)
+	if err := eng.Run(context.Background(), "Now change it"); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := sess.GetMessages()
+	prompt := msgs[len(msgs)-2]
+	if len(prompt.Content) != 2 {
+		t.Fatalf("expected notice appended to the prompt, got %d blocks", len(prompt.Content))
+	}
+	notice := prompt.Content[1].(*provider.TextBlock).Text
+	if !strings.Contains(notice, "- a.go (modified)") {
+		t.Errorf("unexpected notice: %q", notice)
+	}
+	if prompts := sess.Prompts(); len(prompts) != 2 || prompts[1].Text != "Now change it" {
+		t.Errorf("expected notices not to change prompts, got %+v", prompts)
+	}
+}
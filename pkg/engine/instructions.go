@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// maxImportDepth limits nested @import directives
+const maxImportDepth = 5
+
+// importDirective is the line prefix that pulls another file into an instruction file
+const importDirective = "@import "
+
+// readInstructionFile reads an instruction file and expands its @import directives
+func readInstructionFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	abs, _ := filepath.Abs(path)
+	return expandImports(string(data), filepath.Dir(path), map[string]bool{abs: true}, 0), nil
+}
+
+// ExpandImports replaces "@import <path>" lines with the referenced file's
+// content. Relative paths are resolved against baseDir.
+func ExpandImports(content, baseDir string) string {
+	return expandImports(content, baseDir, map[string]bool{}, 0)
+}
+
+func expandImports(content, baseDir string, seen map[string]bool, depth int) string {
+	if !strings.Contains(content, importDirective) {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, importDirective) {
+			continue
+		}
+
+		target := strings.TrimSpace(strings.TrimPrefix(trimmed, importDirective))
+		if strings.HasPrefix(target, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				target = filepath.Join(home, target[2:])
+			}
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(baseDir, target)
+		}
+		abs, _ := filepath.Abs(target)
+
+		switch {
+		case depth >= maxImportDepth:
+			lines[i] = fmt.Sprintf("<!-- import skipped, too deeply nested: %s -->", target)
+		case seen[abs]:
+			lines[i] = fmt.Sprintf("<!-- import skipped, already included: %s -->", target)
+		default:
+			data, err := os.ReadFile(target)
+			if err != nil {
+				lines[i] = fmt.Sprintf("<!-- import not found: %s -->", target)
+				continue
+			}
+			seen[abs] = true
+			lines[i] = strings.TrimRight(expandImports(string(data), filepath.Dir(target), seen, depth+1), "\n")
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// instructionFileNames are the per-directory instruction files, in priority order
+var instructionFileNames = []string{InstructionFileName, "CLAUDE.md"}
+
+// DirectoryInstructions discovers instruction files in subdirectories of a
+// project as the agent touches files under them, so that monorepo packages
+// can carry their own AGENT.md without growing the top-level prompt
+type DirectoryInstructions struct {
+	mu      sync.Mutex
+	root    string
+	checked map[string]bool // directories already searched for instruction files
+}
+
+// NewDirectoryInstructions creates a tracker rooted at the project directory
+func NewDirectoryInstructions(root string) *DirectoryInstructions {
+	return &DirectoryInstructions{
+		root:    filepath.Clean(root),
+		checked: make(map[string]bool),
+	}
+}
+
+// Root returns the project directory
+func (d *DirectoryInstructions) Root() string {
+	return d.root
+}
+
+// Discover returns instructions from directories between the root (exclusive)
+// and path that have not been searched yet, formatted for injection.
+// It returns "" when there is nothing new.
+func (d *DirectoryInstructions) Discover(path string) string {
+	if path == "" {
+		return ""
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(d.root, path)
+	}
+	path = filepath.Clean(path)
+
+	dir := path
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+
+	rel, err := filepath.Rel(d.root, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Walk from the outermost subdirectory inward so more specific rules come last
+	var sections []string
+	current := d.root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		if d.checked[current] {
+			continue
+		}
+		d.checked[current] = true
+
+		// AGENT.md takes priority over CLAUDE.md in the same directory
+		for _, name := range instructionFileNames {
+			file := filepath.Join(current, name)
+			content, err := readInstructionFile(file)
+			if err != nil {
+				continue
+			}
+			relFile, _ := filepath.Rel(d.root, file)
+			sections = append(sections, fmt.Sprintf("<directory_instructions path=%q>\n%s\n</directory_instructions>", relFile, strings.TrimSpace(content)))
+			break
+		}
+	}
+
+	if len(sections) == 0 {
+		return ""
+	}
+	return "The following instructions apply to files in this part of the project. Follow them when working here:\n\n" +
+		strings.Join(sections, "\n\n")
+}
+
+// toolInputPath returns the file or directory a tool call operates on
+func toolInputPath(input map[string]interface{}) string {
+	for _, key := range []string{"file_path", "notebook_path", "path"} {
+		if v, ok := input[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpandImports(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "docs", "style.md"), "Use tabs.\n@import ../AGENT.md\n@import nested.md")
+	writeFile(t, filepath.Join(dir, "docs", "nested.md"), "Nested rule.")
+	writeFile(t, filepath.Join(dir, "AGENT.md"), "Top rule.\n  @import docs/style.md\n@import missing.md")
+
+	got, err := readInstructionFile(filepath.Join(dir, "AGENT.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"Top rule.", "Use tabs.", "Nested rule.", "import skipped, already included", "import not found"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "Top rule.") != 1 {
+		t.Errorf("import cycle was expanded:\n%s", got)
+	}
+}
+
+func TestDirectoryInstructionsDiscover(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "AGENT.md"), "root rules")
+	writeFile(t, filepath.Join(root, "services", "AGENT.md"), "services rules")
+	writeFile(t, filepath.Join(root, "services", "api", "CLAUDE.md"), "api rules")
+	writeFile(t, filepath.Join(root, "services", "api", "main.go"), "package main")
+	writeFile(t, filepath.Join(root, "web", "index.js"), "")
+
+	d := NewDirectoryInstructions(root)
+
+	got := d.Discover(filepath.Join(root, "services", "api", "main.go"))
+	if strings.Contains(got, "root rules") {
+		t.Error("root instructions are loaded by the prompt builder and should not be injected")
+	}
+	services := strings.Index(got, "services rules")
+	api := strings.Index(got, "api rules")
+	if services < 0 || api < 0 || services > api {
+		t.Errorf("expected services then api rules, got:\n%s", got)
+	}
+	if !strings.Contains(got, `path="services/api/CLAUDE.md"`) {
+		t.Errorf("expected relative path attribute, got:\n%s", got)
+	}
+
+	// Already injected directories are not repeated
+	if got := d.Discover("services/api"); got != "" {
+		t.Errorf("expected nothing new, got:\n%s", got)
+	}
+	if got := d.Discover(filepath.Join(root, "web", "index.js")); got != "" {
+		t.Errorf("expected nothing for directory without instructions, got:\n%s", got)
+	}
+	if got := d.Discover("/elsewhere/file.go"); got != "" {
+		t.Errorf("expected nothing outside the root, got:\n%s", got)
+	}
+}
+
+func TestRunInjectsDirectoryInstructions(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "pkg", "AGENT.md"), "pkg rules")
+
+	prov := &MockProvider{
+		responses: []*provider.Response{
+			{
+				StopReason: provider.StopReasonToolUse,
+				Content: []provider.ContentBlock{
+					&provider.ToolUseBlock{ID: "t1", Name: "Read", Input: map[string]interface{}{"file_path": "pkg/a.go"}},
+				},
+			},
+		},
+	}
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{name: "Read"})
+
+	sess := session.NewSession(&session.SessionOptions{CWD: root, Model: "test-model"})
+	eng := NewEngine(&EngineOptions{Provider: prov, Registry: registry, Session: sess})
+
+	if err := eng.Run(context.Background(), "read"); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	var result *provider.ToolResultBlock
+	for _, msg := range sess.GetMessages() {
+		for _, block := range msg.Content {
+			if tr, ok := block.(*provider.ToolResultBlock); ok {
+				result = tr
+			}
+		}
+	}
+	if result == nil || !strings.HasPrefix(eng.results.unwrap(result.Content), "mock output") || !strings.Contains(result.Content, "pkg rules") {
+		t.Errorf("expected instructions appended to tool result, got %+v", result)
+	}
+}
@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"context"
+	"sync"
+)
+
+// interruptedContent is the tool result the model sees for an interrupted tool
+const interruptedContent = "Interrupted by user: the tool was stopped before it finished. Ask the user how to proceed or try a different approach."
+
+// toolInterrupt lets the user stop the running tool without ending the run
+type toolInterrupt struct {
+	mu          sync.Mutex
+	cancel      context.CancelFunc
+	interrupted bool
+}
+
+// start derives the context of a tool execution; done must be called when
+// the tool returns and reports whether it was interrupted
+func (t *toolInterrupt) start(ctx context.Context) (toolCtx context.Context, done func() bool) {
+	toolCtx, cancel := context.WithCancel(ctx)
+	t.mu.Lock()
+	t.cancel = cancel
+	t.interrupted = false
+	t.mu.Unlock()
+
+	return toolCtx, func() bool {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		cancel()
+		t.cancel = nil
+		// Cancelling the whole run is not an interruption of the tool
+		return t.interrupted && ctx.Err() == nil
+	}
+}
+
+// InterruptTool stops the tool that is running, which then returns an
+// "interrupted by user" result and the run continues. It reports whether a
+// tool was running.
+func (e *Engine) InterruptTool() bool {
+	t := &e.interrupt
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cancel == nil {
+		return false
+	}
+	t.interrupted = true
+	t.cancel()
+	return true
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestInterruptTool(t *testing.T) {
+	prov := &MockProvider{
+		responses: []*provider.Response{
+			{
+				StopReason: provider.StopReasonToolUse,
+				Content: []provider.ContentBlock{
+					&provider.ToolUseBlock{ID: "t1", Name: "Slow", Input: map[string]interface{}{}},
+				},
+			},
+			{
+				StopReason: provider.StopReasonEndTurn,
+				Content:    []provider.ContentBlock{&provider.TextBlock{Text: "Done"}},
+			},
+		},
+	}
+
+	started := make(chan struct{})
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{
+		name: "Slow",
+		executeFunc: func(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+			close(started)
+			<-ctx.Done()
+			return &tool.Output{Content: "partial"}, nil
+		},
+	})
+
+	sess := session.NewSession(&session.SessionOptions{})
+	eng := NewEngine(&EngineOptions{Provider: prov, Registry: registry, Session: sess})
+	if eng.InterruptTool() {
+		t.Error("expected no tool to interrupt while idle")
+	}
+
+	go func() {
+		<-started
+		if !eng.InterruptTool() {
+			t.Error("expected the running tool to be interrupted")
+		}
+	}()
+	if err := eng.Run(context.Background(), "Run it"); err != nil {
+		t.Fatalf("expected the run to continue after the interruption, got %v", err)
+	}
+
+	msgs := sess.GetMessages()
+	result, ok := msgs[2].Content[0].(*provider.ToolResultBlock)
+	if !ok {
+		t.Fatalf("expected tool result, got %#v", msgs[2].Content[0])
+	}
+	if !result.IsError || !strings.HasPrefix(eng.results.unwrap(result.Content), "Interrupted by user") {
+		t.Errorf("expected interrupted result, got %+v", result)
+	}
+	if last := msgs[len(msgs)-1]; last.Role != provider.RoleAssistant {
+		t.Errorf("expected the model to answer after the interruption, got %+v", last)
+	}
+	if eng.failures.streaks["Slow"] != nil {
+		t.Error("expected an interruption not to count as a tool failure")
+	}
+}
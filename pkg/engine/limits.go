@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+const (
+	// DefaultToolTimeout is above the Bash tool's own 10 minute maximum so
+	// that its timeout, which keeps partial output, is reported first
+	DefaultToolTimeout = 15 * time.Minute
+
+	// DefaultMaxToolOutput is the default limit on tool output, about 25k tokens
+	DefaultMaxToolOutput = 100 * 1024
+)
+
+// ToolLimit bounds the execution time and output size of a tool call.
+// Zero values mean no limit.
+type ToolLimit struct {
+	Timeout        time.Duration
+	MaxOutputBytes int
+}
+
+// ToolLimits holds the limit for all tools and per-tool overrides
+type ToolLimits struct {
+	Default ToolLimit
+	Tools   map[string]ToolLimit
+}
+
+// DefaultToolLimits returns the limits used when none are configured
+func DefaultToolLimits() ToolLimits {
+	return ToolLimits{
+		Default: ToolLimit{Timeout: DefaultToolTimeout, MaxOutputBytes: DefaultMaxToolOutput},
+	}
+}
+
+// For returns the limit for a tool; per-tool values override the default
+func (l ToolLimits) For(name string) ToolLimit {
+	limit := l.Default
+	if override, ok := l.Tools[name]; ok {
+		if override.Timeout != 0 {
+			limit.Timeout = override.Timeout
+		}
+		if override.MaxOutputBytes != 0 {
+			limit.MaxOutputBytes = override.MaxOutputBytes
+		}
+	}
+	return limit
+}
+
+// executeWithTimeout runs a tool, giving up after timeout (if positive) or
+// when ctx is cancelled even if the tool ignores context cancellation
+func executeWithTimeout(ctx context.Context, t tool.Tool, input *tool.Input, timeout time.Duration) (*tool.Output, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type result struct {
+		output *tool.Output
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := t.Execute(ctx, input)
+		done <- result{output, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%s timed out after %s", t.Name(), timeout)
+		}
+		return r.output, r.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%s timed out after %s", t.Name(), timeout)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// truncateOutput shortens content to about max bytes, keeping the start and
+// the end (where errors and summaries usually are) around a marker
+func truncateOutput(content string, max int) string {
+	if max <= 0 || len(content) <= max {
+		return content
+	}
+
+	head := runeBoundary(content, max*2/3)
+	tail := len(content) - (max - head)
+	for tail < len(content) && !utf8.RuneStart(content[tail]) {
+		tail++
+	}
+
+	return fmt.Sprintf("%s\n\n[... output truncated: %d of %d bytes omitted. Narrow the command, pattern, or line range to see the rest ...]\n\n%s",
+		content[:head], tail-head, len(content), content[tail:])
+}
+
+// runeBoundary returns the largest index <= n that starts a rune
+func runeBoundary(s string, n int) int {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return n
+}
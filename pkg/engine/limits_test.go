@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestToolLimitsFor(t *testing.T) {
+	limits := ToolLimits{
+		Default: ToolLimit{Timeout: time.Minute, MaxOutputBytes: 1000},
+		Tools: map[string]ToolLimit{
+			"Bash": {Timeout: 5 * time.Minute},
+			"Read": {MaxOutputBytes: 5000},
+		},
+	}
+
+	tests := []struct {
+		name string
+		want ToolLimit
+	}{
+		{"Bash", ToolLimit{Timeout: 5 * time.Minute, MaxOutputBytes: 1000}},
+		{"Read", ToolLimit{Timeout: time.Minute, MaxOutputBytes: 5000}},
+		{"Grep", ToolLimit{Timeout: time.Minute, MaxOutputBytes: 1000}},
+	}
+
+	for _, tt := range tests {
+		if got := limits.For(tt.name); got != tt.want {
+			t.Errorf("For(%q) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	if got := truncateOutput("short", 100); got != "short" {
+		t.Errorf("expected short output unchanged, got %q", got)
+	}
+	if got := truncateOutput(strings.Repeat("x", 500), 0); len(got) != 500 {
+		t.Error("expected no truncation without a limit")
+	}
+
+	content := "START" + strings.Repeat("é", 1000) + "END"
+	got := truncateOutput(content, 300)
+	if !strings.HasPrefix(got, "START") || !strings.HasSuffix(got, "END") {
+		t.Error("expected the start and end of the output to be kept")
+	}
+	if !strings.Contains(got, "output truncated") || !strings.Contains(got, "of 2008 bytes omitted") {
+		t.Errorf("expected truncation marker, got %q", got)
+	}
+	if !utf8.ValidString(got) {
+		t.Error("truncation split a multi-byte character")
+	}
+	if len(got) > 300+200 {
+		t.Errorf("truncated output too long: %d bytes", len(got))
+	}
+}
+
+func TestExecuteWithTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	// A tool that ignores cancellation must not stall the loop
+	stuck := &MockTool{
+		name: "Stuck",
+		executeFunc: func(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+			<-release
+			return &tool.Output{Content: "late"}, nil
+		},
+	}
+	_, err := executeWithTimeout(context.Background(), stuck, &tool.Input{}, 20*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "Stuck timed out after 20ms") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+
+	fast := &MockTool{name: "Fast"}
+	output, err := executeWithTimeout(context.Background(), fast, &tool.Input{}, time.Second)
+	if err != nil || output.Content != "mock output" {
+		t.Errorf("expected output from fast tool, got %v, %v", output, err)
+	}
+}
+
+func TestRunTruncatesToolOutput(t *testing.T) {
+	prov := &MockProvider{
+		responses: []*provider.Response{
+			{
+				StopReason: provider.StopReasonToolUse,
+				Content: []provider.ContentBlock{
+					&provider.ToolUseBlock{ID: "t1", Name: "Big", Input: map[string]interface{}{}},
+				},
+			},
+			{
+				StopReason: provider.StopReasonEndTurn,
+				Content:    []provider.ContentBlock{&provider.TextBlock{Text: "Done"}},
+			},
+		},
+	}
+
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{
+		name: "Big",
+		executeFunc: func(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+			return &tool.Output{Content: strings.Repeat("line\n", 1000)}, nil
+		},
+	})
+
+	sess := session.NewSession(&session.SessionOptions{})
+	eng := NewEngine(&EngineOptions{
+		Provider:   prov,
+		Registry:   registry,
+		Session:    sess,
+		ToolLimits: &ToolLimits{Tools: map[string]ToolLimit{"Big": {MaxOutputBytes: 1000}}},
+	})
+	if err := eng.Run(context.Background(), "Run it"); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := sess.GetMessages()
+	result, ok := msgs[2].Content[0].(*provider.ToolResultBlock)
+	if !ok {
+		t.Fatalf("expected tool result, got %#v", msgs[2].Content[0])
+	}
+	if len(result.Content) > 1200 || !strings.Contains(result.Content, "output truncated") {
+		t.Errorf("expected truncated tool result, got %d bytes", len(result.Content))
+	}
+}
@@ -0,0 +1,293 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultContextWindow is the context window assumed when none is given
+	DefaultContextWindow = 200000
+
+	// DefaultInstructionBudget is the default share of the context window
+	// that instruction files may use before a warning is reported
+	DefaultInstructionBudget = 0.05
+
+	// minRuleLength ignores short lines such as "Notes" when comparing rules
+	minRuleLength = 12
+)
+
+// InstructionFile is an instruction file found in a known location
+type InstructionFile struct {
+	Path    string
+	Kind    string // agent, claude, or directory
+	Lazy    bool   // loaded only when the agent works under its directory
+	Content string // content with @import directives expanded
+	Tokens  int
+}
+
+// InstructionIssue is a problem found in the instruction files
+type InstructionIssue struct {
+	Kind      string // duplicate, conflict, or budget
+	Message   string
+	Locations []string // path:line references
+}
+
+// InstructionReport is the result of checking the instruction files
+type InstructionReport struct {
+	Files         []InstructionFile
+	TotalTokens   int // tokens of files loaded into every system prompt
+	ContextWindow int
+	Budget        float64 // allowed share of the context window
+	Issues        []InstructionIssue
+}
+
+// Share returns the share of the context window used by always-loaded files
+func (r *InstructionReport) Share() float64 {
+	if r.ContextWindow <= 0 {
+		return 0
+	}
+	return float64(r.TotalTokens) / float64(r.ContextWindow)
+}
+
+// EstimateTokens returns a rough token count for text
+func EstimateTokens(text string) int {
+	return len(text) / 4
+}
+
+// InstructionFiles returns the instruction files that exist in the locations
+// searched by LoadInstructions, followed by per-directory files in the project
+func (p *PromptBuilder) InstructionFiles() []InstructionFile {
+	var files []InstructionFile
+	add := func(path, kind string, lazy bool) {
+		content, err := readInstructionFile(path)
+		if err != nil {
+			return
+		}
+		files = append(files, InstructionFile{
+			Path:    path,
+			Kind:    kind,
+			Lazy:    lazy,
+			Content: content,
+			Tokens:  EstimateTokens(content),
+		})
+	}
+
+	for _, loc := range p.agentMDLocations() {
+		add(loc, "agent", false)
+	}
+	for _, loc := range p.claudeMDLocations() {
+		add(loc, "claude", false)
+	}
+
+	if p.ProjectPath != "" {
+		for _, path := range findDirectoryInstructions(p.ProjectPath) {
+			add(path, "directory", true)
+		}
+	}
+
+	return files
+}
+
+// findDirectoryInstructions returns the per-directory instruction files below
+// root that DirectoryInstructions would discover, one per directory
+func findDirectoryInstructions(root string) []string {
+	var paths []string
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+		name := d.Name()
+		if strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" {
+			return filepath.SkipDir
+		}
+		for _, fileName := range instructionFileNames {
+			file := filepath.Join(path, fileName)
+			if _, err := os.Stat(file); err == nil {
+				paths = append(paths, file)
+				break
+			}
+		}
+		return nil
+	})
+	return paths
+}
+
+// CheckInstructions reports the token cost of the instruction files, rules
+// that are duplicated or contradict each other, and whether the files loaded
+// into every prompt exceed budget (a share of contextWindow)
+func CheckInstructions(files []InstructionFile, contextWindow int, budget float64) *InstructionReport {
+	if contextWindow <= 0 {
+		contextWindow = DefaultContextWindow
+	}
+	if budget <= 0 {
+		budget = DefaultInstructionBudget
+	}
+
+	report := &InstructionReport{
+		Files:         files,
+		ContextWindow: contextWindow,
+		Budget:        budget,
+	}
+	for _, f := range files {
+		if !f.Lazy {
+			report.TotalTokens += f.Tokens
+		}
+	}
+
+	rules := extractRules(files)
+	report.Issues = append(report.Issues, findDuplicateRules(rules)...)
+	report.Issues = append(report.Issues, findConflictingRules(rules)...)
+
+	if report.Share() > budget {
+		report.Issues = append(report.Issues, InstructionIssue{
+			Kind: "budget",
+			Message: fmt.Sprintf("instruction files use %d tokens (%.1f%% of a %d token context window), over the %.1f%% budget",
+				report.TotalTokens, report.Share()*100, contextWindow, budget*100),
+		})
+	}
+
+	return report
+}
+
+// instructionRule is a single rule line from an instruction file
+type instructionRule struct {
+	text     string // normalized text
+	location string
+	polarity int    // 1 for "always/use X", -1 for "never/don't X", 0 otherwise
+	subject  string // X, for rules with a polarity
+}
+
+// extractRules returns the rule lines of the files, skipping headings,
+// code blocks, and comments
+func extractRules(files []InstructionFile) []instructionRule {
+	var rules []instructionRule
+	for _, f := range files {
+		inCode := false
+		for i, line := range strings.Split(f.Content, "\n") {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "```") {
+				inCode = !inCode
+				continue
+			}
+			if inCode || trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "<!--") {
+				continue
+			}
+
+			text := normalizeRule(trimmed)
+			if len(text) < minRuleLength {
+				continue
+			}
+			polarity, subject := rulePolarity(text)
+			rules = append(rules, instructionRule{
+				text:     text,
+				location: fmt.Sprintf("%s:%d", f.Path, i+1),
+				polarity: polarity,
+				subject:  subject,
+			})
+		}
+	}
+	return rules
+}
+
+// normalizeRule lowercases a rule and strips list markers and punctuation
+func normalizeRule(line string) string {
+	line = strings.TrimLeft(line, "-*+> ")
+	if i := strings.Index(line, ". "); i > 0 && i <= 3 && strings.Trim(line[:i], "0123456789") == "" {
+		line = line[i+2:]
+	}
+	line = strings.ToLower(line)
+	line = strings.Map(func(r rune) rune {
+		switch r {
+		case '*', '_', '`', '.', '!', ',', ';', ':':
+			return -1
+		case '’':
+			return '\''
+		}
+		return r
+	}, line)
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// Rule prefixes, longest first so "do not use" wins over "do not"
+var (
+	negativePrefixes = []string{"do not use ", "don't use ", "never use ", "avoid using ", "do not ", "don't ", "never ", "avoid "}
+	positivePrefixes = []string{"always use ", "you must use ", "must use ", "always ", "you must ", "must ", "use ", "prefer "}
+)
+
+// rulePolarity splits a normalized rule into its polarity and subject
+func rulePolarity(text string) (int, string) {
+	for _, prefix := range negativePrefixes {
+		if strings.HasPrefix(text, prefix) {
+			return -1, strings.TrimPrefix(text, prefix)
+		}
+	}
+	for _, prefix := range positivePrefixes {
+		if strings.HasPrefix(text, prefix) {
+			return 1, strings.TrimPrefix(text, prefix)
+		}
+	}
+	return 0, ""
+}
+
+// findDuplicateRules reports rules that appear more than once
+func findDuplicateRules(rules []instructionRule) []InstructionIssue {
+	seen := make(map[string][]string)
+	var order []string
+	for _, r := range rules {
+		if _, ok := seen[r.text]; !ok {
+			order = append(order, r.text)
+		}
+		seen[r.text] = append(seen[r.text], r.location)
+	}
+
+	var issues []InstructionIssue
+	for _, text := range order {
+		if locs := seen[text]; len(locs) > 1 {
+			issues = append(issues, InstructionIssue{
+				Kind:      "duplicate",
+				Message:   fmt.Sprintf("rule appears %d times: %q", len(locs), text),
+				Locations: locs,
+			})
+		}
+	}
+	return issues
+}
+
+// findConflictingRules reports rules that require and forbid the same thing
+func findConflictingRules(rules []instructionRule) []InstructionIssue {
+	positive := make(map[string][]string)
+	negative := make(map[string][]string)
+	for _, r := range rules {
+		switch r.polarity {
+		case 1:
+			positive[r.subject] = append(positive[r.subject], r.location)
+		case -1:
+			negative[r.subject] = append(negative[r.subject], r.location)
+		}
+	}
+
+	var subjects []string
+	for subject := range positive {
+		if _, ok := negative[subject]; ok {
+			subjects = append(subjects, subject)
+		}
+	}
+	sort.Strings(subjects)
+
+	var issues []InstructionIssue
+	for _, subject := range subjects {
+		issues = append(issues, InstructionIssue{
+			Kind:      "conflict",
+			Message:   fmt.Sprintf("rules both require and forbid %q", subject),
+			Locations: append(append([]string{}, positive[subject]...), negative[subject]...),
+		})
+	}
+	return issues
+}
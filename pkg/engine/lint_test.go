@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeRule(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"- Always use **tabs**.", "always use tabs"},
+		{"1. Run `go test` before committing!", "run go test before committing"},
+		{"*   Never   commit secrets", "never commit secrets"},
+		{"> Don’t push to main", "don't push to main"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeRule(tt.line); got != tt.want {
+			t.Errorf("normalizeRule(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestRulePolarity(t *testing.T) {
+	tests := []struct {
+		text     string
+		polarity int
+		subject  string
+	}{
+		{"always use tabs", 1, "tabs"},
+		{"use tabs", 1, "tabs"},
+		{"never use tabs", -1, "tabs"},
+		{"do not use tabs", -1, "tabs"},
+		{"don't commit secrets", -1, "commit secrets"},
+		{"run go test before committing", 0, ""},
+	}
+
+	for _, tt := range tests {
+		polarity, subject := rulePolarity(tt.text)
+		if polarity != tt.polarity || subject != tt.subject {
+			t.Errorf("rulePolarity(%q) = %d, %q, want %d, %q", tt.text, polarity, subject, tt.polarity, tt.subject)
+		}
+	}
+}
+
+func TestCheckInstructions(t *testing.T) {
+	files := []InstructionFile{
+		{Path: "AGENT.md", Content: "# Rules\n- Always use tabs for indentation.\n- Run go test before committing.\n```\n- Run go test before committing.\n```", Tokens: 100},
+		{Path: "CLAUDE.md", Content: "- run go test before committing\n- Never use tabs for indentation", Tokens: 50},
+		{Path: "sub/AGENT.md", Content: "Lazy rules only apply here.", Tokens: 1000, Lazy: true},
+	}
+
+	report := CheckInstructions(files, 1000, 0.1)
+
+	if report.TotalTokens != 150 {
+		t.Errorf("TotalTokens = %d, want 150 (lazy files excluded)", report.TotalTokens)
+	}
+
+	kinds := make(map[string]InstructionIssue)
+	for _, issue := range report.Issues {
+		kinds[issue.Kind] = issue
+	}
+
+	dup, ok := kinds["duplicate"]
+	if !ok {
+		t.Fatalf("expected a duplicate issue, got %+v", report.Issues)
+	}
+	if want := []string{"AGENT.md:3", "CLAUDE.md:1"}; strings.Join(dup.Locations, ",") != strings.Join(want, ",") {
+		t.Errorf("duplicate locations = %v, want %v (code blocks skipped)", dup.Locations, want)
+	}
+
+	conflict, ok := kinds["conflict"]
+	if !ok {
+		t.Fatalf("expected a conflict issue, got %+v", report.Issues)
+	}
+	if !strings.Contains(conflict.Message, "tabs for indentation") {
+		t.Errorf("unexpected conflict message: %s", conflict.Message)
+	}
+
+	if _, ok := kinds["budget"]; !ok {
+		t.Errorf("expected a budget issue for 150 of 1000 tokens at 10%%")
+	}
+
+	if report := CheckInstructions(files, 0, 0); len(report.Issues) != 2 {
+		t.Errorf("expected no budget issue with defaults, got %+v", report.Issues)
+	}
+}
+
+func TestInstructionFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "AGENT.md"), "Project rule.\n@import docs/extra.md")
+	writeFile(t, filepath.Join(dir, "docs", "extra.md"), "Imported rule.")
+	writeFile(t, filepath.Join(dir, "CLAUDE.md"), "Legacy rule.")
+	writeFile(t, filepath.Join(dir, "pkg", "api", "CLAUDE.md"), "API rule.")
+	writeFile(t, filepath.Join(dir, "node_modules", "dep", "AGENT.md"), "Ignored.")
+
+	p := &PromptBuilder{ProjectPath: dir, CWD: dir}
+
+	var project []InstructionFile
+	for _, f := range p.InstructionFiles() {
+		if strings.HasPrefix(f.Path, dir) {
+			project = append(project, f)
+		}
+	}
+
+	if len(project) != 3 {
+		t.Fatalf("expected 3 project instruction files, got %+v", project)
+	}
+	if project[0].Kind != "agent" || !strings.Contains(project[0].Content, "Imported rule.") {
+		t.Errorf("expected AGENT.md with imports expanded, got %+v", project[0])
+	}
+	if project[0].Tokens != EstimateTokens(project[0].Content) {
+		t.Errorf("Tokens = %d, want %d", project[0].Tokens, EstimateTokens(project[0].Content))
+	}
+	if project[1].Kind != "claude" || project[1].Lazy {
+		t.Errorf("expected always-loaded CLAUDE.md, got %+v", project[1])
+	}
+	if project[2].Kind != "directory" || !project[2].Lazy || !strings.HasSuffix(project[2].Path, filepath.Join("pkg", "api", "CLAUDE.md")) {
+		t.Errorf("expected lazy pkg/api/CLAUDE.md, got %+v", project[2])
+	}
+}
@@ -7,9 +7,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/xinguang/agentic-coder/pkg/agentignore"
+	"github.com/xinguang/agentic-coder/pkg/cost"
+	"github.com/xinguang/agentic-coder/pkg/permission"
 	"github.com/xinguang/agentic-coder/pkg/provider"
 	"github.com/xinguang/agentic-coder/pkg/session"
 	"github.com/xinguang/agentic-coder/pkg/tool"
@@ -24,18 +29,127 @@ type Engine struct {
 	systemPrompt string
 
 	// Configuration
-	maxIterations int
-	maxTokens     int
-	temperature   float64
-	thinkingLevel string // high, medium, low, none
+	maxIterations   int
+	maxTokens       int
+	temperature     float64
+	topP            float64
+	topK            int
+	seed            *int64
+	stopSequences   []string
+	stopAtMaxTokens bool   // end the run at a truncated response instead of continuing it
+	thinkingLevel   string // ultra, high, medium, low, none
+	thinkingBudget  int    // explicit thinking budget in tokens (0 = from level)
+	turnThinking    string // level requested by the prompt of the current run
+
+	// Tool failure handling
+	correctiveFeedback bool // replace raw tool errors with structured feedback
+	maxToolFailures    int  // abort after this many consecutive failures of one tool (0 = never)
+	failures           *failureTracker
+
+	// Loop detection
+	loops              *loopDetector
+	stuckInterventions int
+
+	// Tool execution limits
+	toolLimits ToolLimits
+
+	// Results of idempotent tools
+	cache *resultCache
+
+	// Files the agent has seen, to report outside changes
+	files *fileIndex
+
+	// Per-directory instruction discovery
+	dirInstructions *DirectoryInstructions
+
+	// Use CreateMessage instead of streaming
+	noStream bool
+
+	// Cross-session usage ledger (nil = not recorded)
+	ledger *cost.Ledger
+
+	// Monthly budget, tracked through the ledger
+	budget budgetGuard
+
+	// Iteration summaries are written here as JSON lines (nil = not logged)
+	iterationLog io.Writer
+	toolErrors   int // tool errors in the current iteration
+
+	// Speed of the latest provider call
+	lastSpeed CallSpeed
+
+	// Cancels the running tool when the user interrupts it
+	interrupt toolInterrupt
+
+	// Wraps tool results to guard against prompt injection
+	results resultGuard
+
+	// Directories file tools may use without asking (nil = unrestricted)
+	scope *permission.PathScope
+
+	// Destructive tool calls need approval (nil = not classified), from
+	// the verifier when set and otherwise the user
+	destructive *permission.Classifier
+	verifier    *DestructiveVerifier
+
+	// Bash runs only commands that read, see ReadOnly
+	readOnly bool
+
+	// How far invalid tool arguments are repaired
+	jsonRepair tool.RepairMode
+
+	// Tokens of file content @-mentions may attach to a prompt (negative = none)
+	attachmentBudget int
+
+	// Providers whose built-in web search replaces the WebSearch tool
+	nativeWebSearch []string
+
+	// Tool choice of every request, and the tool the first request of a
+	// run must call, pending until that request is built
+	toolChoice        *provider.ToolChoice
+	firstTool         string
+	firstToolMinWords int
+	forceFirstTool    bool
+
+	// Text of the latest complete thinking block
+	lastThinking string
+
+	// Model change requested by SwitchModel
+	modelSwitch modelSwitch
+
+	// Tool results followed by this many responses are sent as summaries (0 = never)
+	staleResultTurns int
+
+	// Estimated cost of the responses since the engine was created
+	spend float64
+
+	// Content of the session's pinned files, by path
+	pins map[string]pinnedFile
+
+	// Context the user shared for the next prompt
+	pendingContext []string
+
+	// Pause requested by Pause
+	pause pauseRequest
 
 	// Callbacks
-	onText       func(text string)
-	onThinking   func(text string)
-	onToolUse    func(name string, input map[string]interface{})
-	onToolResult func(name string, result *tool.Output)
-	onUsage      func(inputTokens, outputTokens int)
-	onError      func(err error)
+	onText         func(text string)
+	onThinking     func(text string)
+	onThinkingDone func(thinking string)
+	onToolUse      func(name string, input map[string]interface{})
+	onToolResult   func(name string, result *tool.Output)
+	onUsage        func(inputTokens, outputTokens int)
+	onError        func(err error)
+	onStuck        func(reason string) string
+	onBudget       func(alert BudgetAlert)
+	onIteration    func(summary IterationSummary)
+	onPathAccess   func(access PathAccess) PathDecision
+	onDestructive  func(action DestructiveAction) bool
+	onTurnEnd      func(text string)
+	onWrapUp       func(summary string)
+	onSubtask      func(event *tool.SubtaskEvent)
+	onAttach       func(plan *AttachmentPlan)
+	onSources      func(sources []provider.Citation)
 
 	// External tool callbacks (for tools executed by external providers)
 	onExternalToolUse    func(name string, input map[string]interface{})
@@ -52,6 +166,77 @@ type EngineOptions struct {
 	Temperature   float64
 	ThinkingLevel string
 	SystemPrompt  string
+	// TopP, TopK and Seed are sent to providers that support them (0 and
+	// nil = the provider's default); a seed makes generations reproducible
+	// where the provider honors it
+	TopP float64
+	TopK int
+	Seed *int64
+	// ThinkingBudget sets the thinking budget in tokens, overriding
+	// ThinkingLevel (0 = from the level)
+	ThinkingBudget int
+	// StopSequences end a response where the model writes one of them,
+	// bounding output for callers that extract from it
+	StopSequences []string
+	// StopAtMaxTokens ends the run when a response reaches MaxTokens,
+	// instead of asking the model to continue it
+	StopAtMaxTokens bool
+
+	// CorrectiveFeedback replaces raw tool errors with an analysis of what
+	// failed, the likely cause and a suggested correction
+	CorrectiveFeedback bool
+	// MaxToolFailures aborts the run when a tool fails this many times in a row (0 = never)
+	MaxToolFailures int
+	// LoopThreshold is how many identical tool calls are treated as a loop
+	// (0 = default of 3, negative disables loop detection)
+	LoopThreshold int
+	// ToolLimits bounds tool execution time and output size (nil = DefaultToolLimits)
+	ToolLimits *ToolLimits
+	// NoStream requests complete responses instead of streams, for proxies
+	// and models that misbehave under SSE. Providers without streaming
+	// support are never streamed.
+	NoStream bool
+	// UsageLedger records the token usage of every response (nil = not recorded)
+	UsageLedger *cost.Ledger
+	// Budget caps monthly spending recorded in UsageLedger (nil = unlimited)
+	Budget *cost.Budget
+	// IterationLog receives a JSON line summarizing each loop iteration (nil = not logged)
+	IterationLog io.Writer
+	// StaleResultTurns sends tool results followed by at least this many
+	// responses as short summaries, keeping recent ones verbatim (0 = never)
+	StaleResultTurns int
+	// PathScope confines file tools to the project and additional
+	// directories, asking through OnPathAccess for anything else (nil = unrestricted)
+	PathScope *permission.PathScope
+	// Destructive classifies tool calls, such as rm or force pushes, that
+	// need approval whatever the permission mode: from
+	// DestructiveVerifier, or the user through OnDestructive (nil = not classified)
+	Destructive *permission.Classifier
+	// DestructiveVerifier is a second model that approves destructive
+	// calls; the user is asked when it rejects one (nil = ask the user)
+	DestructiveVerifier *DestructiveVerifier
+	// ReadOnly refuses Bash commands that may change files or other state.
+	// Tools that write are removed from the registry with RemoveWritingTools.
+	ReadOnly bool
+	// JSONRepair is how far tool arguments that are not valid JSON are
+	// repaired before validation ("" = tool.DefaultRepairMode)
+	JSONRepair tool.RepairMode
+	// AttachmentBudget bounds the tokens of file content that @path
+	// mentions attach to a prompt; files over it are listed for the agent
+	// to read (0 = DefaultAttachmentBudget, negative = mentions attach nothing)
+	AttachmentBudget int
+	// NativeWebSearch names the providers whose built-in web search is
+	// used instead of the WebSearch tool, where they support it
+	NativeWebSearch []string
+	// ToolChoice controls the tool calls of every request (nil = the
+	// provider's default, auto)
+	ToolChoice *provider.ToolChoice
+	// FirstTool is a tool the first request of each run must call, such as
+	// TodoWrite to plan before acting ("" = none)
+	FirstTool string
+	// FirstToolMinWords forces FirstTool only for prompts of at least this
+	// many words, leaving short questions alone (0 = every prompt)
+	FirstToolMinWords int
 }
 
 // NewEngine creates a new agent engine
@@ -66,16 +251,65 @@ func NewEngine(opts *EngineOptions) *Engine {
 		maxTokens = 16384
 	}
 
+	attachmentBudget := opts.AttachmentBudget
+	if attachmentBudget == 0 {
+		attachmentBudget = DefaultAttachmentBudget
+	}
+
+	jsonRepair := opts.JSONRepair
+	if jsonRepair == "" {
+		jsonRepair = tool.DefaultRepairMode
+	}
+
+	loopThreshold := opts.LoopThreshold
+	if loopThreshold == 0 {
+		loopThreshold = defaultLoopThreshold
+	}
+
+	toolLimits := DefaultToolLimits()
+	if opts.ToolLimits != nil {
+		toolLimits = *opts.ToolLimits
+	}
+
 	return &Engine{
-		provider:      opts.Provider,
-		registry:      opts.Registry,
-		session:       opts.Session,
-		hooks:         NewHookManager(),
-		systemPrompt:  opts.SystemPrompt,
-		maxIterations: maxIterations,
-		maxTokens:     maxTokens,
-		temperature:   opts.Temperature,
-		thinkingLevel: opts.ThinkingLevel,
+		provider:        opts.Provider,
+		registry:        opts.Registry,
+		session:         opts.Session,
+		hooks:           NewHookManager(),
+		systemPrompt:    opts.SystemPrompt,
+		maxIterations:   maxIterations,
+		maxTokens:       maxTokens,
+		temperature:     opts.Temperature,
+		topP:            opts.TopP,
+		topK:            opts.TopK,
+		seed:            opts.Seed,
+		stopSequences:   opts.StopSequences,
+		stopAtMaxTokens: opts.StopAtMaxTokens,
+		thinkingLevel:   opts.ThinkingLevel,
+		thinkingBudget:  opts.ThinkingBudget,
+
+		correctiveFeedback: opts.CorrectiveFeedback,
+		maxToolFailures:    opts.MaxToolFailures,
+		failures:           newFailureTracker(),
+		loops:              newLoopDetector(loopThreshold),
+		toolLimits:         toolLimits,
+		cache:              newResultCache(),
+		files:              newFileIndex(),
+		noStream:           opts.NoStream,
+		ledger:             opts.UsageLedger,
+		budget:             budgetGuard{budget: opts.Budget},
+		iterationLog:       opts.IterationLog,
+		scope:              opts.PathScope,
+		destructive:        opts.Destructive,
+		verifier:           opts.DestructiveVerifier,
+		readOnly:           opts.ReadOnly,
+		staleResultTurns:   opts.StaleResultTurns,
+		jsonRepair:         jsonRepair,
+		attachmentBudget:   attachmentBudget,
+		nativeWebSearch:    opts.NativeWebSearch,
+		toolChoice:         opts.ToolChoice,
+		firstTool:          opts.FirstTool,
+		firstToolMinWords:  opts.FirstToolMinWords,
 	}
 }
 
@@ -87,6 +321,9 @@ func (e *Engine) SetCallbacks(opts *CallbackOptions) {
 	if opts.OnThinking != nil {
 		e.onThinking = opts.OnThinking
 	}
+	if opts.OnThinkingDone != nil {
+		e.onThinkingDone = opts.OnThinkingDone
+	}
 	if opts.OnToolUse != nil {
 		e.onToolUse = opts.OnToolUse
 	}
@@ -99,6 +336,36 @@ func (e *Engine) SetCallbacks(opts *CallbackOptions) {
 	if opts.OnError != nil {
 		e.onError = opts.OnError
 	}
+	if opts.OnStuck != nil {
+		e.onStuck = opts.OnStuck
+	}
+	if opts.OnBudget != nil {
+		e.onBudget = opts.OnBudget
+	}
+	if opts.OnIteration != nil {
+		e.onIteration = opts.OnIteration
+	}
+	if opts.OnPathAccess != nil {
+		e.onPathAccess = opts.OnPathAccess
+	}
+	if opts.OnDestructive != nil {
+		e.onDestructive = opts.OnDestructive
+	}
+	if opts.OnTurnEnd != nil {
+		e.onTurnEnd = opts.OnTurnEnd
+	}
+	if opts.OnWrapUp != nil {
+		e.onWrapUp = opts.OnWrapUp
+	}
+	if opts.OnSubtask != nil {
+		e.onSubtask = opts.OnSubtask
+	}
+	if opts.OnAttach != nil {
+		e.onAttach = opts.OnAttach
+	}
+	if opts.OnSources != nil {
+		e.onSources = opts.OnSources
+	}
 	if opts.OnExternalToolUse != nil {
 		e.onExternalToolUse = opts.OnExternalToolUse
 	}
@@ -116,36 +383,146 @@ type CallbackOptions struct {
 	OnUsage      func(inputTokens, outputTokens int)
 	OnError      func(err error)
 
+	// OnThinkingDone is called with the full text of each thinking block once
+	// it is complete, after its pieces were passed to OnThinking
+	OnThinkingDone func(thinking string)
+
+	// OnStuck is called when the agent appears to be looping. It returns
+	// guidance from the user, or "" to let the agent recover on its own.
+	OnStuck func(reason string) string
+
+	// OnBudget is called when spending this month crosses a budget alert threshold
+	OnBudget func(alert BudgetAlert)
+
+	// OnIteration is called after each loop iteration with what it did
+	OnIteration func(summary IterationSummary)
+
+	// OnPathAccess asks the user whether a file tool may use a path outside
+	// the path scope. Without it such access is denied.
+	OnPathAccess func(access PathAccess) PathDecision
+
+	// OnDestructive asks the user whether a destructive tool call may run.
+	// Without it such calls are refused unless the verifier approves them.
+	OnDestructive func(action DestructiveAction) bool
+
+	// OnTurnEnd is called with the text of the final response when the
+	// model ends its turn
+	OnTurnEnd func(text string)
+
+	// OnWrapUp is called with the model's summary of a run stopped at the
+	// iteration limit: what it did and what remains
+	OnWrapUp func(summary string)
+
+	// OnSubtask receives the progress of subagents started by tools, such
+	// as their tool calls and token usage
+	OnSubtask func(event *tool.SubtaskEvent)
+
+	// OnAttach is called with the files attached to a prompt for its
+	// @-mentions, and those left for the agent to read
+	OnAttach func(plan *AttachmentPlan)
+
+	// OnSources is called when the model ends its turn with the web pages
+	// that informed its answer: pages it fetched, pages its provider's
+	// search cited, and search results it relied on
+	OnSources func(sources []provider.Citation)
+
 	// External tool callbacks (for tools executed by external providers like Claude CLI)
 	OnExternalToolUse    func(name string, input map[string]interface{})
 	OnExternalToolResult func(name string, result *tool.Output)
 }
 
+// Hooks returns the hooks run around tool calls
+func (e *Engine) Hooks() *HookManager {
+	return e.hooks
+}
+
+// SetSystemPrompt replaces the system prompt for subsequent requests
+func (e *Engine) SetSystemPrompt(prompt string) {
+	e.systemPrompt = prompt
+}
+
+// LastThinking returns the text of the latest complete thinking block
+func (e *Engine) LastThinking() string {
+	return e.lastThinking
+}
+
 // SetSession changes the current session
 func (e *Engine) SetSession(sess *session.Session) {
 	e.session = sess
 }
 
+// Session returns the current session
+func (e *Engine) Session() *session.Session {
+	return e.session
+}
+
+// AddContext attaches text to the next prompt, such as context the user
+// shares with a command between prompts
+func (e *Engine) AddContext(text string) {
+	e.pendingContext = append(e.pendingContext, text)
+}
+
 // Run executes a single turn of conversation
 func (e *Engine) Run(ctx context.Context, userMessage string) error {
-	// Add user message to session
-	e.session.AddUserMessage(userMessage)
+	if err := e.checkBudget(); err != nil {
+		return err
+	}
+
+	// Add user message to session, raising thinking for this run when the
+	// prompt asks for it ("think hard", "ultrathink")
+	level, triggers := ThinkingTriggers(userMessage)
+	e.turnThinking = level
+	entry := e.session.AddUserMessage(userMessage)
+	entry.ThinkingMetadata = e.thinkingMetadata(triggers)
+	e.attachMentions(userMessage)
+	e.forceFirstTool = e.firstTool != "" && len(strings.Fields(userMessage)) >= e.firstToolMinWords
+	for _, text := range e.pendingContext {
+		e.session.AddNotice(text)
+	}
+	e.pendingContext = nil
+
+	// A new prompt replaces a paused run
+	e.session.SetPaused(nil)
+	e.pause.requested.Store(false)
+
+	// Failure streaks and loops are tracked per run
+	e.failures.reset()
+	e.loops.reset()
+	e.stuckInterventions = 0
+
+	// The user may have changed files since the last run
+	e.cache.invalidateSearches()
 
 	// Run agent loop
-	return e.runLoop(ctx)
+	return e.runLoop(ctx, 0)
 }
 
-// runLoop executes the agent loop until completion
-func (e *Engine) runLoop(ctx context.Context) error {
-	for iteration := 0; iteration < e.maxIterations; iteration++ {
+// runLoop executes the agent loop until completion, starting after the
+// given number of iterations when a paused run resumes
+func (e *Engine) runLoop(ctx context.Context, first int) error {
+	for iteration := first; iteration < e.maxIterations; iteration++ {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
 		}
 
+		if e.takePause() {
+			return e.pauseRun(iteration)
+		}
+
+		started := time.Now()
+		e.toolErrors = 0
+
+		// A model chosen with /model takes over from the next request
+		e.applyModelSwitch()
+
+		// Warn about files changed outside the agent before it acts on them
+		e.noticeFileChanges()
+
 		// Build request
 		req := e.buildRequest()
+		e.applyFirstTool(req)
 
 		// Call AI provider
 		resp, err := e.callProvider(ctx, req)
@@ -161,28 +538,68 @@ func (e *Engine) runLoop(ctx context.Context) error {
 
 		// Process response
 		hasToolUse := false
-		for _, block := range resp.Content {
+		stuckReason := ""
+		for i, block := range resp.Content {
 			switch b := block.(type) {
 			case *provider.TextBlock:
 				if e.onText != nil && b.Text != "" {
-					e.onText(b.Text)
+					if req.Stream {
+						e.onText(b.Text)
+					} else {
+						e.replayText(ctx, b.Text)
+					}
 				}
 
 			case *provider.ThinkingBlock:
-				if e.onThinking != nil && b.Thinking != "" {
+				// Streamed thinking was already passed on piece by piece
+				if e.onThinking != nil && b.Thinking != "" && !req.Stream {
 					e.onThinking(b.Thinking)
 				}
+				if b.Thinking != "" {
+					e.lastThinking = b.Thinking
+					if e.onThinkingDone != nil {
+						e.onThinkingDone(b.Thinking)
+					}
+				}
 
 			case *provider.ToolUseBlock:
 				hasToolUse = true
+				if e.takePause() {
+					e.skipTools(resp.Content[i:])
+					return e.pauseRun(iteration + 1)
+				}
 				if err := e.executeToolUse(ctx, b); err != nil {
 					return err
 				}
+				if reason := e.loops.record(b.Name, b.Input); reason != "" {
+					stuckReason = reason
+				}
 			}
 		}
 
+		e.endIteration(iteration+1, started, resp, e.toolErrors)
+
+		// Abort when the model keeps retrying a broken tool call
+		if err := e.failures.exceeded(e.maxToolFailures); err != nil {
+			if e.onError != nil {
+				e.onError(err)
+			}
+			return err
+		}
+
+		// Break out of repetitive behavior
+		if stuckReason != "" {
+			if err := e.interveneStuck(stuckReason); err != nil {
+				if e.onError != nil {
+					e.onError(err)
+				}
+				return err
+			}
+			continue
+		}
+
 		// Check stop condition
-		if resp.StopReason == provider.StopReasonMaxTokens {
+		if resp.StopReason == provider.StopReasonMaxTokens && !e.stopAtMaxTokens {
 			// Response was truncated due to token limit, ask to continue
 			if e.onText != nil {
 				e.onText("\n")
@@ -191,17 +608,30 @@ func (e *Engine) runLoop(ctx context.Context) error {
 			continue
 		}
 		if resp.StopReason == provider.StopReasonEndTurn || !hasToolUse {
+			if sources := e.session.TurnSources(); len(sources) > 0 && e.onSources != nil {
+				e.onSources(sources)
+			}
+			if e.onTurnEnd != nil {
+				e.onTurnEnd(responseText(resp))
+			}
 			return nil
 		}
 	}
 
-	return fmt.Errorf("max iterations (%d) exceeded", e.maxIterations)
+	return e.wrapUp(ctx)
 }
 
 // buildRequest constructs the API request
 func (e *Engine) buildRequest() *provider.Request {
-	messages := e.session.GetMessages()
+	// Old tool results are summarized and the history, which may have been
+	// written by another provider, is translated for the current one
+	messages := e.summarizeStaleResults(e.session.GetMessages())
+	messages = sanitizeHistory(messages, e.provider.Name())
 	tools := e.registry.ToAPITools()
+	webSearch := e.useNativeWebSearch()
+	if webSearch {
+		tools = slices.DeleteFunc(tools, func(t provider.Tool) bool { return t.Name == webSearchTool })
+	}
 
 	req := &provider.Request{
 		Model:       e.session.Model,
@@ -209,7 +639,14 @@ func (e *Engine) buildRequest() *provider.Request {
 		Tools:       tools,
 		MaxTokens:   e.maxTokens,
 		Temperature: e.temperature,
-		Stream:      true,
+		Stream:      e.streaming(),
+		WebSearch:   webSearch,
+		ToolChoice:  e.toolChoice,
+
+		TopP:          e.topP,
+		TopK:          e.topK,
+		Seed:          e.seed,
+		StopSequences: e.stopSequences,
 	}
 
 	// Build system prompt
@@ -220,12 +657,23 @@ func (e *Engine) buildRequest() *provider.Request {
 		}
 	}
 
-	// Configure thinking
-	if e.thinkingLevel != "" && e.thinkingLevel != "none" {
+	// Configure thinking; the budget counts toward max tokens, so leave
+	// room for the answer
+	if e.thinkingEnabled() {
+		budget := e.getThinkingBudget()
 		req.Thinking = &provider.ThinkingConfig{
 			Type:         "enabled",
-			BudgetTokens: e.getThinkingBudget(),
+			BudgetTokens: budget,
 		}
+		if req.MaxTokens <= budget {
+			req.MaxTokens = budget + e.maxTokens
+		}
+	}
+
+	// The nonce of the result tags differs in every session, so a response
+	// cache would never match a rerun unless it leaves the nonce out
+	if masker, ok := e.provider.(provider.KeyMasker); ok {
+		masker.MaskInKey(e.results.nonce)
 	}
 
 	return req
@@ -252,6 +700,14 @@ func (e *Engine) buildSystemPrompt() string {
 		parts = append(parts, toolDesc)
 	}
 
+	// Explain how tool results are delimited
+	parts = append(parts, e.results.instructions(e.session.ID))
+
+	// Files the user pinned, as they are now
+	if pinned := e.pinnedContext(); pinned != "" {
+		parts = append(parts, pinned)
+	}
+
 	return strings.Join(parts, "\n\n")
 }
 
@@ -288,24 +744,31 @@ func (e *Engine) getToolDescriptions() string {
 	return sb.String()
 }
 
-// getThinkingBudget returns token budget for thinking
+// getThinkingBudget returns the token budget for thinking in the current
+// run: the explicit budget or that of the level, raised by prompt triggers
 func (e *Engine) getThinkingBudget() int {
-	switch e.thinkingLevel {
-	case "high":
-		return 10000
-	case "medium":
-		return 5000
-	case "low":
-		return 2000
-	default:
-		return 5000
+	budget := e.thinkingBudget
+	if budget <= 0 {
+		budget = thinkingBudgetFor(e.thinkingLevel)
+	}
+	if e.turnThinking != "" {
+		budget = max(budget, thinkingBudgetFor(e.turnThinking))
 	}
+	return budget
 }
 
 // callProvider calls the AI provider with streaming
 func (e *Engine) callProvider(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	started := time.Now()
+	e.lastSpeed = CallSpeed{}
 	if !req.Stream {
-		return e.provider.CreateMessage(ctx, req)
+		resp, err := e.provider.CreateMessage(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		e.lastSpeed = newCallSpeed(started, time.Time{}, time.Now(), resp.Usage.OutputTokens)
+		e.reportUsage(&resp.Usage, &e.lastSpeed)
+		return resp, nil
 	}
 
 	// Streaming request
@@ -318,6 +781,10 @@ func (e *Engine) callProvider(ctx context.Context, req *provider.Request) (*prov
 	var response *provider.Response
 	var currentBlockIndex int
 	var toolInputJSON strings.Builder
+	// Blocks by stream index; blocks the engine does not keep, such as
+	// searches the provider runs itself, leave gaps in the indexes
+	blocks := make(map[int]provider.ContentBlock)
+	var firstToken time.Time
 
 	for {
 		event, err := stream.Recv()
@@ -342,34 +809,45 @@ func (e *Engine) callProvider(ctx context.Context, req *provider.Request) (*prov
 			if ev.ContentBlock != nil {
 				response.Content = append(response.Content, ev.ContentBlock)
 			}
+			blocks[ev.Index] = ev.ContentBlock
 			toolInputJSON.Reset()
 
 		case *provider.ContentBlockDeltaEvent:
+			if firstToken.IsZero() {
+				firstToken = time.Now()
+			}
 			// Handle deltas
 			if ev.Delta != nil {
 				switch d := ev.Delta.(type) {
 				case *provider.TextDelta:
 					// Accumulate text to the current block
-					if currentBlockIndex < len(response.Content) {
-						if tb, ok := response.Content[currentBlockIndex].(*provider.TextBlock); ok {
-							tb.Text += d.Text
-						}
+					if tb, ok := blocks[currentBlockIndex].(*provider.TextBlock); ok {
+						tb.Text += d.Text
 					}
 					if e.onText != nil {
 						e.onText(d.Text)
 					}
 
+				case *provider.CitationsDelta:
+					if tb, ok := blocks[currentBlockIndex].(*provider.TextBlock); ok {
+						tb.Citations = append(tb.Citations, d.Citation)
+					}
+
 				case *provider.ThinkingDelta:
 					// Accumulate thinking to the current block
-					if currentBlockIndex < len(response.Content) {
-						if tb, ok := response.Content[currentBlockIndex].(*provider.ThinkingBlock); ok {
-							tb.Thinking += d.Thinking
-						}
+					if tb, ok := blocks[currentBlockIndex].(*provider.ThinkingBlock); ok {
+						tb.Thinking += d.Thinking
 					}
 					if e.onThinking != nil {
 						e.onThinking(d.Thinking)
 					}
 
+				case *provider.SignatureDelta:
+					// Keep the signature Claude requires to accept the thinking back
+					if tb, ok := blocks[currentBlockIndex].(*provider.ThinkingBlock); ok {
+						tb.Signature += d.Signature
+					}
+
 				case *provider.InputJSONDelta:
 					// Accumulate tool input JSON
 					toolInputJSON.WriteString(d.PartialJSON)
@@ -378,10 +856,14 @@ func (e *Engine) callProvider(ctx context.Context, req *provider.Request) (*prov
 
 		case *provider.ContentBlockStopEvent:
 			// Parse accumulated tool input JSON if applicable
-			if toolInputJSON.Len() > 0 && currentBlockIndex < len(response.Content) {
-				if tb, ok := response.Content[currentBlockIndex].(*provider.ToolUseBlock); ok {
-					// Parse the JSON string to map
-					tb.Input = parseJSONToMap(toolInputJSON.String())
+			if toolInputJSON.Len() > 0 {
+				if tb, ok := blocks[currentBlockIndex].(*provider.ToolUseBlock); ok {
+					// Parse the JSON string to map, keeping invalid JSON for repair
+					raw := toolInputJSON.String()
+					tb.Input = parseJSONToMap(raw)
+					if !json.Valid([]byte(raw)) {
+						tb.RawInput = raw
+					}
 				}
 			}
 
@@ -391,10 +873,8 @@ func (e *Engine) callProvider(ctx context.Context, req *provider.Request) (*prov
 			}
 			if ev.Usage != nil {
 				response.Usage = *ev.Usage
-				// Trigger usage callback
-				if e.onUsage != nil {
-					e.onUsage(ev.Usage.InputTokens, ev.Usage.OutputTokens)
-				}
+				e.lastSpeed = newCallSpeed(started, firstToken, time.Now(), ev.Usage.OutputTokens)
+				e.reportUsage(ev.Usage, &e.lastSpeed)
 			}
 
 		case *provider.MessageStopEvent:
@@ -441,6 +921,23 @@ func (e *Engine) executeToolUse(ctx context.Context, block *provider.ToolUseBloc
 		input = make(map[string]interface{})
 	}
 
+	// Repair arguments that were not valid JSON, such as trailing commas
+	// or fenced code, rather than running the tool without them. The block
+	// is in the session, so the repaired input is what the model sees next.
+	if block.RawInput != "" {
+		repaired, _, err := tool.ParseArguments(block.RawInput, e.jsonRepair)
+		if err != nil {
+			if e.onToolUse != nil {
+				e.onToolUse(toolName, input)
+			}
+			e.addToolError(toolID, toolName, input, fmt.Sprintf("Invalid arguments: %v. Send the arguments as a single JSON object.", err), nil)
+			return nil
+		}
+		input = repaired
+		block.Input = repaired
+		block.RawInput = ""
+	}
+
 	// Callback
 	if e.onToolUse != nil {
 		e.onToolUse(toolName, input)
@@ -449,14 +946,14 @@ func (e *Engine) executeToolUse(ctx context.Context, block *provider.ToolUseBloc
 	// Get tool
 	t, err := e.registry.Get(toolName)
 	if err != nil {
-		e.session.AddToolResult(toolID, fmt.Sprintf("Error: %v", err), true, nil)
+		e.addToolError(toolID, toolName, input, fmt.Sprintf("Error: %v", err), nil)
 		return nil
 	}
 
 	// Run pre-tool-use hooks
 	hookResult := e.hooks.RunPreToolUse(ctx, toolName, input)
 	if hookResult.Blocked {
-		e.session.AddToolResult(toolID, fmt.Sprintf("Tool blocked: %s", hookResult.Message), true, nil)
+		e.addToolError(toolID, toolName, input, fmt.Sprintf("Tool blocked: %s", hookResult.Message), nil)
 		return nil
 	}
 
@@ -466,22 +963,77 @@ func (e *Engine) executeToolUse(ctx context.Context, block *provider.ToolUseBloc
 		Context: &tool.ExecutionContext{
 			CWD:       e.session.CWD,
 			SessionID: e.session.ID,
+			Subtask:   e.onSubtask,
 		},
 	}
 
+	// Check the input against the tool's schema, fixing common mistakes such
+	// as numbers sent as strings, before the tool sees it
+	if err := tool.ValidateSchema(t.InputSchema(), input); err != nil {
+		e.addToolError(toolID, toolName, input, fmt.Sprintf("Validation error: %v", err), nil)
+		return nil
+	}
+
 	// Validate
 	if err := t.Validate(toolInput); err != nil {
-		e.session.AddToolResult(toolID, fmt.Sprintf("Validation error: %v", err), true, nil)
+		e.addToolError(toolID, toolName, input, fmt.Sprintf("Validation error: %v", err), nil)
+		return nil
+	}
+
+	// Keep file tools inside the project unless the user allows otherwise
+	if err := e.checkPathScope(toolName, input); err != nil {
+		e.addToolError(toolID, toolName, input, fmt.Sprintf("Access denied: %v", err), nil)
 		return nil
 	}
 
-	// Execute
-	output, err := t.Execute(ctx, toolInput)
+	// Read-only mode runs only commands that read
+	if err := e.checkReadOnly(t.Name(), input); err != nil {
+		e.addToolError(toolID, toolName, input, fmt.Sprintf("Not allowed: %v", err), nil)
+		return nil
+	}
+
+	// Destructive calls need approval whatever the permission mode
+	if err := e.checkDestructive(ctx, toolName, input); err != nil {
+		e.addToolError(toolID, toolName, input, fmt.Sprintf("Not approved: %v", err), nil)
+		return nil
+	}
+
+	// Serve repeated reads of unchanged files and repeated searches from the cache
+	if entry, ok := e.cache.lookup(toolName, input, e.session.CWD); ok {
+		earlier, found := e.session.ToolResult(entry.toolUseID)
+		inConversation := found && e.results.unwrap(earlier) == entry.content && !e.resultStale(entry.toolUseID)
+		output := &tool.Output{Content: cachedContent(toolName, entry, inConversation)}
+		if e.onToolResult != nil {
+			e.onToolResult(toolName, output)
+		}
+		e.failures.recordSuccess(toolName)
+		e.addToolResult(toolID, output.Content, false, nil)
+		return nil
+	}
+	if !cacheableTools[toolName] {
+		// Any other tool may change the tree under cached searches
+		defer e.cache.invalidateSearches()
+	}
+
+	// Checkpoint files before they change so the conversation can be rewound
+	e.checkpointFile(toolName, input)
+
+	// Execute within the tool's time limit
+	limit := e.toolLimits.For(toolName)
+	toolCtx, done := e.interrupt.start(ctx)
+	output, err := executeWithTimeout(toolCtx, t, toolInput, limit.Timeout)
+	if done() {
+		e.addToolInterrupted(toolID, toolName, output)
+		return nil
+	}
 	if err != nil {
-		e.session.AddToolResult(toolID, fmt.Sprintf("Execution error: %v", err), true, nil)
+		e.addToolError(toolID, toolName, input, fmt.Sprintf("Execution error: %v", err), nil)
 		return nil
 	}
 
+	// Keep oversized output from flooding the context
+	output.Content = truncateOutput(output.Content, limit.MaxOutputBytes)
+
 	// Callback
 	if e.onToolResult != nil {
 		e.onToolResult(toolName, output)
@@ -491,11 +1043,189 @@ func (e *Engine) executeToolUse(ctx context.Context, block *provider.ToolUseBloc
 	e.hooks.RunPostToolUse(ctx, toolName, input, output)
 
 	// Add result to session
-	e.session.AddToolResult(toolID, output.Content, output.IsError, output.Metadata)
+	if output.IsError {
+		e.addToolError(toolID, toolName, input, output.Content, output.Metadata)
+		return nil
+	}
+	e.failures.recordSuccess(toolName)
+
+	// Lazily inject instructions for subdirectories the agent works in,
+	// outside the tags so they are not mistaken for tool output
+	content := e.results.wrap(e.session.ID, output.Content)
+	if extra := e.discoverInstructions(input); extra != "" {
+		content += "\n\n" + extra
+	}
+	e.session.AddToolResultBlock(&provider.ToolResultBlock{
+		ToolUseID:  toolID,
+		Content:    content,
+		Images:     output.Images,
+		Structured: output.Structured,
+	}, output.Metadata)
+	e.cache.store(toolName, input, e.session.CWD, toolID, output.Content)
+	if toolName == "Read" || fileChangingTools[toolName] {
+		if path := toolInputPath(input); path != "" && !agentignore.Load(e.session.CWD).Ignored(e.resolvePath(path)) {
+			e.files.record(e.resolvePath(path))
+		}
+	}
+
+	return nil
+}
+
+// reportUsage records the usage of a response and passes it to the usage callback
+func (e *Engine) reportUsage(usage *provider.Usage, speed *CallSpeed) {
+	e.recordUsage(usage, speed)
+	if e.session != nil {
+		e.spend += cost.ModelCost(e.session.Model, int64(usage.InputTokens), int64(usage.OutputTokens))
+	}
+	if e.onUsage != nil {
+		e.onUsage(usage.InputTokens, usage.OutputTokens)
+	}
+}
+
+// recordUsage adds the usage of a response, and the speed of the call when
+// known, to the ledger. Failures are ignored: the ledger is informational
+// and must not interrupt a run.
+func (e *Engine) recordUsage(usage *provider.Usage, speed *CallSpeed) {
+	if e.ledger == nil || (usage.InputTokens == 0 && usage.OutputTokens == 0) {
+		return
+	}
+	rec := cost.UsageRecord{
+		Provider:     e.provider.Name(),
+		InputTokens:  int64(usage.InputTokens),
+		OutputTokens: int64(usage.OutputTokens),
+	}
+	if e.session != nil {
+		rec.Model = e.session.Model
+		rec.Project = e.session.CWD
+		rec.Session = e.session.ID
+	}
+	if speed != nil {
+		rec.FirstTokenMS = speed.FirstToken.Milliseconds()
+		rec.TokensPerSecond = speed.TokensPerSecond
+	}
+	rec.Cost = cost.ModelCost(rec.Model, rec.InputTokens, rec.OutputTokens)
+	_ = e.ledger.Record(rec)
+	e.addSpend(rec.Cost)
+}
+
+// discoverInstructions returns instruction files newly relevant to a tool call's path
+func (e *Engine) discoverInstructions(input map[string]interface{}) string {
+	path := toolInputPath(input)
+	if path == "" || e.session.CWD == "" {
+		return ""
+	}
+	if e.dirInstructions == nil || e.dirInstructions.Root() != filepath.Clean(e.session.CWD) {
+		e.dirInstructions = NewDirectoryInstructions(e.session.CWD)
+	}
+	return e.dirInstructions.Discover(path)
+}
+
+// fileChangingTools are the tools whose target file is checkpointed before execution
+var fileChangingTools = map[string]bool{
+	"Edit":         true,
+	"MultiEdit":    true,
+	"Write":        true,
+	"NotebookEdit": true,
+}
+
+// checkpointFile saves the file a tool is about to change
+func (e *Engine) checkpointFile(toolName string, input map[string]interface{}) {
+	if !fileChangingTools[toolName] {
+		return
+	}
+	path := toolInputPath(input)
+	if path == "" {
+		return
+	}
+	e.session.Checkpoint(toolName, e.resolvePath(path))
+}
+
+// ChangedFiles returns the files tools changed since the last user prompt
+func (e *Engine) ChangedFiles() []string {
+	return e.session.ChangedFiles(e.lastPromptIndex())
+}
 
+// TurnCheckpoints returns the content files had before tools first changed
+// them since the last user prompt
+func (e *Engine) TurnCheckpoints() []session.FileCheckpoint {
+	return e.session.FirstCheckpoints(e.lastPromptIndex())
+}
+
+// lastPromptIndex returns the message index of the last user prompt
+func (e *Engine) lastPromptIndex() int {
+	if prompts := e.session.Prompts(); len(prompts) > 0 {
+		return prompts[len(prompts)-1].MessageIndex
+	}
+	return 0
+}
+
+// resolvePath makes a tool path absolute relative to the session directory
+func (e *Engine) resolvePath(path string) string {
+	if !filepath.IsAbs(path) && e.session.CWD != "" {
+		return filepath.Join(e.session.CWD, path)
+	}
+	return path
+}
+
+// noticeFileChanges tells the model which files it has seen were changed
+// since, so it reads them again instead of editing stale content
+func (e *Engine) noticeFileChanges() {
+	changes := e.files.changes()
+	if len(changes) == 0 {
+		return
+	}
+	e.session.AddNotice(changeNotice(changes, e.session.CWD))
+}
+
+// interveneStuck interrupts a detected loop with user guidance or a
+// synthesized notice, and aborts when interventions keep failing
+func (e *Engine) interveneStuck(reason string) error {
+	e.stuckInterventions++
+	if e.stuckInterventions > maxStuckInterventions {
+		return &StuckError{Reason: reason}
+	}
+
+	message := ""
+	if e.onStuck != nil {
+		message = strings.TrimSpace(e.onStuck(reason))
+	}
+	if message == "" {
+		message = stuckMessage(reason)
+	} else {
+		message = fmt.Sprintf("[System notice] You appear to be stuck: %s.\n\nGuidance from the user: %s", reason, message)
+	}
+
+	e.session.AddUserMessage(message)
 	return nil
 }
 
+// addToolError records a failed tool call and adds its result to the session,
+// with corrective feedback in place of the raw error when enabled
+func (e *Engine) addToolError(toolID, toolName string, input map[string]interface{}, errText string, metadata interface{}) {
+	streak, repeated := e.failures.recordFailure(toolName, input, errText)
+	e.toolErrors++
+
+	content := errText
+	if e.correctiveFeedback {
+		content = analyzeToolError(toolName, errText, streak, repeated, e.maxToolFailures)
+	}
+	e.addToolResult(toolID, content, true, metadata)
+}
+
+// addToolInterrupted adds the result of a tool the user interrupted, keeping
+// any output it produced. It does not count as a tool failure.
+func (e *Engine) addToolInterrupted(toolID, toolName string, output *tool.Output) {
+	content := interruptedContent
+	if output != nil && output.Content != "" {
+		content += "\n\nOutput before the interruption:\n" + output.Content
+	}
+	result := &tool.Output{Content: content, IsError: true}
+	if e.onToolResult != nil {
+		e.onToolResult(toolName, result)
+	}
+	e.addToolResult(toolID, content, true, nil)
+}
+
 // getOS returns the operating system name
 func getOS() string {
 	switch os := os.Getenv("GOOS"); os {
@@ -584,3 +1314,14 @@ func (h *HookManager) RunOnStop(ctx context.Context, reason string) {
 		hook(ctx, reason)
 	}
 }
+
+// responseText joins the text blocks of a response
+func responseText(resp *provider.Response) string {
+	var parts []string
+	for _, block := range resp.Content {
+		if text, ok := block.(*provider.TextBlock); ok && strings.TrimSpace(text.Text) != "" {
+			parts = append(parts, strings.TrimSpace(text.Text))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
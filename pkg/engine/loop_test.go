@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/xinguang/agentic-coder/pkg/provider"
@@ -275,6 +278,57 @@ func TestBuildRequest(t *testing.T) {
 	}
 }
 
+func TestStopAtMaxTokens(t *testing.T) {
+	replayDelay = 0
+	truncated := &provider.Response{StopReason: provider.StopReasonMaxTokens, Content: []provider.ContentBlock{&provider.TextBlock{Text: `{"name": "par`}}}
+	for _, stop := range []bool{false, true} {
+		prov := &recordingProvider{name: "claude", scriptedProvider: scriptedProvider{responses: []*provider.Response{
+			truncated,
+			{StopReason: provider.StopReasonEndTurn, Content: []provider.ContentBlock{&provider.TextBlock{Text: `ser"}`}}},
+		}}}
+		eng := NewEngine(&EngineOptions{
+			Provider:        prov,
+			Registry:        tool.NewRegistry(),
+			Session:         session.NewSession(&session.SessionOptions{CWD: t.TempDir(), Model: "test"}),
+			MaxTokens:       64,
+			StopSequences:   []string{"\n\n"},
+			StopAtMaxTokens: stop,
+		})
+		if err := eng.Run(context.Background(), "name the package as JSON"); err != nil {
+			t.Fatal(err)
+		}
+
+		want := 2
+		if stop {
+			want = 1
+		}
+		if len(prov.requests) != want {
+			t.Errorf("StopAtMaxTokens %v: expected %d requests, got %d", stop, want, len(prov.requests))
+		}
+		if req := prov.requests[0]; req.MaxTokens != 64 || len(req.StopSequences) != 1 {
+			t.Errorf("unexpected request: max tokens %d, stop sequences %q", req.MaxTokens, req.StopSequences)
+		}
+	}
+}
+
+func TestBuildRequestSampling(t *testing.T) {
+	seed := int64(42)
+	eng := NewEngine(&EngineOptions{
+		Provider:    &MockProvider{},
+		Registry:    tool.NewRegistry(),
+		Session:     session.NewSession(&session.SessionOptions{CWD: "/test", Model: "test"}),
+		Temperature: 0.2,
+		TopP:        0.9,
+		TopK:        40,
+		Seed:        &seed,
+	})
+
+	req := eng.buildRequest()
+	if req.Temperature != 0.2 || req.TopP != 0.9 || req.TopK != 40 || req.Seed == nil || *req.Seed != 42 {
+		t.Errorf("unexpected sampling: %v %v %v %v", req.Temperature, req.TopP, req.TopK, req.Seed)
+	}
+}
+
 func TestBuildRequestWithThinking(t *testing.T) {
 	eng := NewEngine(&EngineOptions{
 		Provider:      &MockProvider{},
@@ -795,6 +849,78 @@ type validationError struct {
 
 func (e *validationError) Error() string { return e.msg }
 
+func TestExecuteToolUseChecksSchema(t *testing.T) {
+	sess := session.NewSession(&session.SessionOptions{CWD: "/test", Model: "test"})
+
+	var got interface{}
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{
+		name:   "Head",
+		schema: json.RawMessage(`{"type": "object", "properties": {"lines": {"type": "integer"}}, "required": ["lines"]}`),
+		executeFunc: func(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+			got = input.Params["lines"]
+			return &tool.Output{Content: "ok"}, nil
+		},
+	})
+
+	eng := NewEngine(&EngineOptions{
+		Provider: &MockProvider{},
+		Registry: registry,
+		Session:  sess,
+	})
+
+	ctx := context.Background()
+	if err := eng.executeToolUse(ctx, &provider.ToolUseBlock{ID: "t1", Name: "Head", Input: map[string]interface{}{"lines": "20"}}); err != nil {
+		t.Fatalf("executeToolUse() error = %v", err)
+	}
+	if got != float64(20) {
+		t.Errorf("expected the tool to get the coerced number 20, got %#v", got)
+	}
+
+	if err := eng.executeToolUse(ctx, &provider.ToolUseBlock{ID: "t2", Name: "Head", Input: map[string]interface{}{"lines": "many"}}); err != nil {
+		t.Fatalf("executeToolUse() error = %v", err)
+	}
+	msgs := sess.GetMessages()
+	result, ok := msgs[len(msgs)-1].Content[0].(*provider.ToolResultBlock)
+	if !ok {
+		t.Fatalf("expected tool result, got %#v", msgs[len(msgs)-1].Content[0])
+	}
+	if !result.IsError || !contains(result.Content, `lines: expected integer, got string "many"`) {
+		t.Errorf("expected a schema error for the model, got %+v", result)
+	}
+}
+
+func TestExecuteToolUseKeepsImages(t *testing.T) {
+	sess := session.NewSession(&session.SessionOptions{CWD: "/test", Model: "test"})
+
+	image := &provider.ImageBlock{Source: provider.ImageSource{Type: "base64", MediaType: "image/png", Data: "AAAA"}}
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{
+		name: "mcp__charts__render",
+		executeFunc: func(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+			return &tool.Output{Content: "rendered", Images: []*provider.ImageBlock{image}, Structured: json.RawMessage(`{"bars":3}`)}, nil
+		},
+	})
+
+	eng := NewEngine(&EngineOptions{
+		Provider: &MockProvider{},
+		Registry: registry,
+		Session:  sess,
+	})
+	if err := eng.executeToolUse(context.Background(), &provider.ToolUseBlock{ID: "t1", Name: "mcp__charts__render", Input: map[string]interface{}{}}); err != nil {
+		t.Fatalf("executeToolUse() error = %v", err)
+	}
+
+	msgs := sess.GetMessages()
+	result, ok := msgs[len(msgs)-1].Content[0].(*provider.ToolResultBlock)
+	if !ok {
+		t.Fatalf("expected tool result, got %#v", msgs[len(msgs)-1].Content[0])
+	}
+	if len(result.Images) != 1 || result.Images[0] != image || string(result.Structured) != `{"bars":3}` {
+		t.Errorf("expected the image and structured output in the result, got %+v", result)
+	}
+}
+
 func TestExecuteToolUseWithHookBlock(t *testing.T) {
 	sess := session.NewSession(&session.SessionOptions{CWD: "/test", Model: "test"})
 
@@ -838,3 +964,116 @@ func contains(s, substr string) bool {
 	}
 	return false
 }
+
+func TestRunCheckpointsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	prov := &MockProvider{
+		responses: []*provider.Response{
+			{
+				StopReason: provider.StopReasonToolUse,
+				Content: []provider.ContentBlock{
+					&provider.ToolUseBlock{ID: "t1", Name: "Write", Input: map[string]interface{}{"file_path": "a.txt"}},
+				},
+			},
+			{
+				StopReason: provider.StopReasonEndTurn,
+				Content:    []provider.ContentBlock{&provider.TextBlock{Text: "Done"}},
+			},
+		},
+	}
+
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{
+		name: "Write",
+		executeFunc: func(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+			return &tool.Output{Content: "written"}, os.WriteFile(path, []byte("changed"), 0644)
+		},
+	})
+
+	sess := session.NewSession(&session.SessionOptions{CWD: dir})
+	eng := NewEngine(&EngineOptions{Provider: prov, Registry: registry, Session: sess})
+
+	if err := eng.Run(context.Background(), "Change the file"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sess.Rewind(1, true); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "original" {
+		t.Errorf("expected file restored from checkpoint, got %q", data)
+	}
+}
+
+func TestAddContextJoinsNextPrompt(t *testing.T) {
+	eng, sess := newAutonomousEngine(&MockProvider{responses: []*provider.Response{textResponse("ok"), textResponse("ok")}})
+	eng.AddContext("<branch-diff>...</branch-diff>")
+
+	if err := eng.Run(context.Background(), "Review my branch"); err != nil {
+		t.Fatal(err)
+	}
+	messages := sess.GetMessages()
+	if len(messages) != 2 || len(messages[0].Content) != 2 {
+		t.Fatalf("expected the context in the prompt's message, got %+v", messages)
+	}
+	if text := messages[0].Content[1].(*provider.TextBlock).Text; text != "<branch-diff>...</branch-diff>" {
+		t.Errorf("unexpected context %q", text)
+	}
+
+	if err := eng.Run(context.Background(), "Thanks"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(sess.GetMessages()[2].Content); n != 1 {
+		t.Errorf("context should only join one prompt, got %d blocks", n)
+	}
+}
+
+func TestRunRepairsToolArguments(t *testing.T) {
+	prov := &MockProvider{
+		responses: []*provider.Response{
+			{
+				StopReason: provider.StopReasonToolUse,
+				Content: []provider.ContentBlock{
+					&provider.ToolUseBlock{ID: "t1", Name: "Read", RawInput: "```json\n{\"path\": \"a.go\",}\n```"},
+					&provider.ToolUseBlock{ID: "t2", Name: "Read", RawInput: `{"path": "b.go`},
+				},
+			},
+			{
+				StopReason: provider.StopReasonEndTurn,
+				Content:    []provider.ContentBlock{&provider.TextBlock{Text: "Done"}},
+			},
+		},
+	}
+
+	var paths []interface{}
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{
+		name: "Read",
+		executeFunc: func(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+			paths = append(paths, input.Params["path"])
+			return &tool.Output{Content: "ok"}, nil
+		},
+	})
+
+	sess := session.NewSession(&session.SessionOptions{})
+	eng := NewEngine(&EngineOptions{Provider: prov, Registry: registry, Session: sess, NoStream: true})
+	if err := eng.Run(context.Background(), "Read the files"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(paths) != 1 || paths[0] != "a.go" {
+		t.Errorf("expected only the repaired call to run, got %v", paths)
+	}
+	if result, _ := sess.ToolResult("t2"); !strings.Contains(result, "Invalid arguments") {
+		t.Errorf("expected an error for the cut-off arguments, got %q", result)
+	}
+	call := sess.GetMessages()[1].Content[0].(*provider.ToolUseBlock)
+	if call.Input["path"] != "a.go" || call.RawInput != "" {
+		t.Errorf("expected the repaired input in the session, got %+v", call)
+	}
+}
@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+)
+
+// migrationPointerMarker identifies CLAUDE.md files written by a migration
+const migrationPointerMarker = "<!-- agentic-coder: instructions moved to AGENT.md -->"
+
+// MigrationStep merges one or more CLAUDE.md files into an AGENT.md
+type MigrationStep struct {
+	Target   string   // AGENT.md to write
+	Sources  []string // CLAUDE.md files merged into the target
+	Existing bool     // the target already exists and is extended
+	Content  string   // full content of the target after migration
+}
+
+// PlanMigration returns the steps needed to move CLAUDE.md instructions into
+// AGENT.md files. Project CLAUDE.md files go to the project AGENT.md and
+// ~/.claude/CLAUDE.md goes to the global AGENT.md. Sources that were already
+// migrated, or whose content is already in the target, are skipped.
+func (p *PromptBuilder) PlanMigration() ([]*MigrationStep, error) {
+	home, _ := os.UserHomeDir()
+	appDir, _ := config.GetAppDir()
+
+	var planned []*MigrationStep
+	byTarget := make(map[string]*MigrationStep)
+
+	for _, source := range p.claudeMDLocations() {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			continue
+		}
+		content := strings.TrimSpace(string(data))
+		if content == "" || strings.Contains(content, migrationPointerMarker) {
+			continue
+		}
+
+		target := migrationTarget(source, home, appDir)
+		step, ok := byTarget[target]
+		if !ok {
+			step = &MigrationStep{Target: target}
+			if existing, err := os.ReadFile(target); err == nil {
+				step.Existing = true
+				step.Content = strings.TrimSpace(string(existing))
+			} else if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to read %s: %w", target, err)
+			}
+			byTarget[target] = step
+			planned = append(planned, step)
+		}
+
+		content = rebaseImports(content, filepath.Dir(source), filepath.Dir(target))
+		if strings.Contains(step.Content, content) {
+			continue
+		}
+
+		section := fmt.Sprintf("<!-- Migrated from %s -->\n%s", source, content)
+		if step.Content == "" {
+			step.Content = section
+		} else {
+			step.Content += "\n\n" + section
+		}
+		step.Sources = append(step.Sources, source)
+	}
+
+	var steps []*MigrationStep
+	for _, step := range planned {
+		if len(step.Sources) > 0 {
+			steps = append(steps, step)
+		}
+	}
+	return steps, nil
+}
+
+// Apply writes the target AGENT.md. With leavePointer, each source CLAUDE.md
+// is replaced by a short file pointing to the target.
+func (s *MigrationStep) Apply(leavePointer bool) error {
+	if err := os.MkdirAll(filepath.Dir(s.Target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(s.Target, []byte(s.Content+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.Target, err)
+	}
+
+	if !leavePointer {
+		return nil
+	}
+	for _, source := range s.Sources {
+		if err := os.WriteFile(source, []byte(PointerContent(source, s.Target)), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", source, err)
+		}
+	}
+	return nil
+}
+
+// PointerContent returns the CLAUDE.md content that points to a migrated AGENT.md.
+// The "@path" reference lets tools that only read CLAUDE.md import the new
+// file, while agentic-coder ignores it to avoid loading the instructions twice.
+func PointerContent(claudeMDPath, agentMDPath string) string {
+	ref := agentMDPath
+	if rel, err := filepath.Rel(filepath.Dir(claudeMDPath), agentMDPath); err == nil {
+		ref = filepath.ToSlash(rel)
+	}
+	return fmt.Sprintf("%s\n# Instructions moved\n\nInstructions now live in %s.\n\n@%s\n", migrationPointerMarker, ref, ref)
+}
+
+// migrationTarget returns the AGENT.md that a CLAUDE.md migrates to
+func migrationTarget(claudeMDPath, home, appDir string) string {
+	dir := filepath.Dir(claudeMDPath)
+	if filepath.Base(dir) == ".claude" {
+		dir = filepath.Dir(dir)
+	}
+	if home != "" && appDir != "" && dir == filepath.Clean(home) {
+		return filepath.Join(appDir, InstructionFileName)
+	}
+	return filepath.Join(dir, InstructionFileName)
+}
+
+// rebaseImports rewrites relative @import paths so they resolve from toDir
+func rebaseImports(content, fromDir, toDir string) string {
+	if fromDir == toDir || !strings.Contains(content, importDirective) {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, importDirective) {
+			continue
+		}
+		target := strings.TrimSpace(strings.TrimPrefix(trimmed, importDirective))
+		if filepath.IsAbs(target) || strings.HasPrefix(target, "~/") {
+			continue
+		}
+		if rel, err := filepath.Rel(toDir, filepath.Join(fromDir, target)); err == nil {
+			lines[i] = importDirective + filepath.ToSlash(rel)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrationTarget(t *testing.T) {
+	home := filepath.Join("/home", "user")
+	appDir := filepath.Join(home, ".agentic-coder")
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{filepath.Join("/proj", "CLAUDE.md"), filepath.Join("/proj", "AGENT.md")},
+		{filepath.Join("/proj", ".claude", "CLAUDE.md"), filepath.Join("/proj", "AGENT.md")},
+		{filepath.Join(home, ".claude", "CLAUDE.md"), filepath.Join(appDir, "AGENT.md")},
+	}
+
+	for _, tt := range tests {
+		if got := migrationTarget(tt.source, home, appDir); got != tt.want {
+			t.Errorf("migrationTarget(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestPlanMigration(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "AGENT.md"), "Existing rule.")
+	writeFile(t, filepath.Join(dir, "CLAUDE.md"), "Existing rule.")
+	writeFile(t, filepath.Join(dir, ".claude", "CLAUDE.md"), "Dot rule.\n@import ../docs/style.md")
+	writeFile(t, filepath.Join(home, ".claude", "CLAUDE.md"), "Global rule.")
+
+	p := &PromptBuilder{ProjectPath: dir, CWD: dir}
+	steps, err := p.PlanMigration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(steps))
+	}
+
+	project := steps[0]
+	if project.Target != filepath.Join(dir, "AGENT.md") || !project.Existing {
+		t.Errorf("unexpected project step: %+v", project)
+	}
+	if len(project.Sources) != 1 || project.Sources[0] != filepath.Join(dir, ".claude", "CLAUDE.md") {
+		t.Errorf("expected only .claude/CLAUDE.md to be merged (CLAUDE.md is already in AGENT.md), got %v", project.Sources)
+	}
+	if !strings.Contains(project.Content, "@import docs/style.md") {
+		t.Errorf("expected import rebased to the project directory:\n%s", project.Content)
+	}
+
+	global := steps[1]
+	if global.Target != filepath.Join(home, ".agentic-coder", "AGENT.md") || global.Existing {
+		t.Errorf("unexpected global step: %+v", global)
+	}
+
+	// Applying with pointers makes a second plan empty
+	for _, step := range steps {
+		if err := step.Apply(true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ".claude", "CLAUDE.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "@../AGENT.md") {
+		t.Errorf("expected pointer to ../AGENT.md, got:\n%s", data)
+	}
+
+	steps, err = p.PlanMigration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 0 {
+		t.Errorf("expected nothing left to migrate, got %d steps", len(steps))
+	}
+}
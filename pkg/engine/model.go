@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"sync"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// modelSwitch holds a model change requested while a run may be in progress
+type modelSwitch struct {
+	mu       sync.Mutex
+	pending  bool
+	model    string
+	provider provider.AIProvider // nil = keep the current provider
+}
+
+// SwitchModel changes the model from the next loop iteration on, whether
+// a run is in progress or not. The model may name its provider, as in
+// "ollama:qwen2.5-coder". When prov is not nil the engine also moves to
+// that provider. It is safe to call from another goroutine.
+func (e *Engine) SwitchModel(model string, prov provider.AIProvider) {
+	e.modelSwitch.mu.Lock()
+	defer e.modelSwitch.mu.Unlock()
+	e.modelSwitch.pending = true
+	e.modelSwitch.model = model
+	if prov != nil {
+		e.modelSwitch.provider = prov
+	}
+}
+
+// Provider returns the provider serving requests, including one requested
+// by SwitchModel that has not taken effect yet
+func (e *Engine) Provider() provider.AIProvider {
+	e.modelSwitch.mu.Lock()
+	defer e.modelSwitch.mu.Unlock()
+	if e.modelSwitch.pending && e.modelSwitch.provider != nil {
+		return e.modelSwitch.provider
+	}
+	return e.provider
+}
+
+// Model returns the session's model, or the one requested by SwitchModel
+// when it has not taken effect yet
+func (e *Engine) Model() string {
+	e.modelSwitch.mu.Lock()
+	defer e.modelSwitch.mu.Unlock()
+	if e.modelSwitch.pending {
+		return e.modelSwitch.model
+	}
+	return e.session.Model
+}
+
+// applyModelSwitch makes a requested model change take effect, reporting
+// whether there was one
+func (e *Engine) applyModelSwitch() bool {
+	e.modelSwitch.mu.Lock()
+	pending, model, prov := e.modelSwitch.pending, e.modelSwitch.model, e.modelSwitch.provider
+	e.modelSwitch.pending, e.modelSwitch.provider = false, nil
+	e.modelSwitch.mu.Unlock()
+
+	if !pending || e.session == nil {
+		return false
+	}
+
+	// The history is translated for the new provider by buildRequest
+	if prov != nil && prov != e.provider {
+		e.provider = prov
+		e.session.Provider = string(provider.DetectProviderFromModel(model))
+	}
+	e.session.Model = provider.ResolveModel(model)
+	return true
+}
@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// recordingProvider is a named scripted provider that keeps its requests
+type recordingProvider struct {
+	scriptedProvider
+	name     string
+	requests []*provider.Request
+}
+
+func (p *recordingProvider) Name() string { return p.name }
+
+func (p *recordingProvider) CreateMessage(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	p.requests = append(p.requests, req)
+	return p.scriptedProvider.CreateMessage(ctx, req)
+}
+
+func TestSwitchModelMidRun(t *testing.T) {
+	replayDelay = 0
+	first := &recordingProvider{name: "claude", scriptedProvider: scriptedProvider{responses: []*provider.Response{{
+		StopReason: provider.StopReasonToolUse,
+		Content: []provider.ContentBlock{
+			&provider.ThinkingBlock{Thinking: "look around", Signature: "sig"},
+			&provider.ToolUseBlock{ID: "t1", Name: "Missing", Input: map[string]interface{}{}},
+		},
+	}}}}
+	second := &recordingProvider{name: "openai", scriptedProvider: scriptedProvider{responses: []*provider.Response{{
+		StopReason: provider.StopReasonEndTurn,
+		Content:    []provider.ContentBlock{&provider.TextBlock{Text: "done"}},
+	}}}}
+
+	sess := session.NewSession(&session.SessionOptions{CWD: t.TempDir(), Provider: "claude", Model: "claude-sonnet-4-5"})
+	eng := NewEngine(&EngineOptions{Provider: first, Registry: tool.NewRegistry(), Session: sess})
+	eng.SetCallbacks(&CallbackOptions{
+		OnToolUse: func(name string, input map[string]interface{}) {
+			// The user switches models while the tool runs
+			eng.SwitchModel("gpt-4o", second)
+		},
+	})
+
+	if eng.Provider() != first {
+		t.Fatal("expected the initial provider")
+	}
+	if err := eng.Run(context.Background(), "hello"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if len(first.requests) != 1 || len(second.requests) != 1 {
+		t.Fatalf("expected one request to each provider, got %d and %d", len(first.requests), len(second.requests))
+	}
+	req := second.requests[0]
+	if req.Model != "gpt-4o" {
+		t.Errorf("expected the second request to use gpt-4o, got %q", req.Model)
+	}
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			if _, ok := block.(*provider.ThinkingBlock); ok {
+				t.Errorf("expected thinking to be dropped for the new provider, got %+v", msg)
+			}
+		}
+	}
+	if sess.Provider != "openai" || sess.Model != "gpt-4o" || eng.Provider() != second {
+		t.Errorf("expected the session on openai/gpt-4o, got %s/%s", sess.Provider, sess.Model)
+	}
+}
+
+func TestSwitchModelSameProvider(t *testing.T) {
+	prov := &recordingProvider{name: "claude"}
+	sess := session.NewSession(&session.SessionOptions{CWD: t.TempDir(), Provider: "claude", Model: "claude-sonnet-4-5"})
+	sess.AddAssistantMessage(&provider.Response{Content: []provider.ContentBlock{
+		&provider.ThinkingBlock{Thinking: "plan", Signature: "sig"},
+		&provider.TextBlock{Text: "ok"},
+	}})
+	eng := NewEngine(&EngineOptions{Provider: prov, Registry: tool.NewRegistry(), Session: sess})
+
+	eng.SwitchModel("claude-opus-4-1", nil)
+	if sess.Model != "claude-sonnet-4-5" {
+		t.Errorf("expected the switch to wait for the next iteration, got %s", sess.Model)
+	}
+	if !eng.applyModelSwitch() || sess.Model != "claude-opus-4-1" {
+		t.Errorf("expected the switch to apply, got %s", sess.Model)
+	}
+	if len(sess.Messages[0].Message.Content) != 2 {
+		t.Error("expected thinking to be kept on the same provider")
+	}
+	if eng.applyModelSwitch() {
+		t.Error("expected no pending switch")
+	}
+}
+
+func TestSwitchModelExplicitProvider(t *testing.T) {
+	sess := session.NewSession(&session.SessionOptions{CWD: t.TempDir(), Provider: "claude", Model: "claude-sonnet-4-5"})
+	eng := NewEngine(&EngineOptions{Provider: &recordingProvider{name: "claude"}, Registry: tool.NewRegistry(), Session: sess})
+
+	// gpt-oss would be guessed as Ollama; the prefix says OpenAI
+	eng.SwitchModel("openai:gpt-oss-120b", &recordingProvider{name: "openai"})
+	if !eng.applyModelSwitch() || sess.Provider != "openai" || sess.Model != "gpt-oss-120b" {
+		t.Errorf("expected the session on openai/gpt-oss-120b, got %s/%s", sess.Provider, sess.Model)
+	}
+}
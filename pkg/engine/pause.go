@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+)
+
+// ErrPaused ends a run stopped by Pause. The session records the paused
+// run, which Resume continues.
+var ErrPaused = errors.New("run paused")
+
+// skippedContent is the result of a tool call not run because the run paused
+const skippedContent = "Not run: the user paused the run before this tool started. Run it again if it is still needed."
+
+// resumedNotice tells the model that a paused run continues
+const resumedNotice = "[System notice] The user paused this run at %s and resumed it now. Files may have changed meanwhile; check before relying on earlier results."
+
+// pauseRequest is set by Pause and taken by the running loop
+type pauseRequest struct {
+	requested atomic.Bool
+}
+
+// Pause stops the running loop once the tool being run completes, or
+// before its next request when no tool is running; Run then returns
+// ErrPaused. It is safe to call from another goroutine.
+func (e *Engine) Pause() {
+	e.pause.requested.Store(true)
+}
+
+// Paused returns the paused run of the session, or nil when there is none
+func (e *Engine) Paused() *session.PausedRun {
+	if e.session == nil {
+		return nil
+	}
+	return e.session.Paused()
+}
+
+// Resume continues the run paused in the session, which may have been
+// paused by another process. Like Run, it returns ErrPaused when paused
+// again.
+func (e *Engine) Resume(ctx context.Context) error {
+	paused := e.Paused()
+	if paused == nil {
+		return fmt.Errorf("no paused run to continue")
+	}
+	if err := e.checkBudget(); err != nil {
+		return err
+	}
+	e.session.SetPaused(nil)
+	e.pause.requested.Store(false)
+	e.turnThinking = paused.Thinking
+	e.session.AddNotice(fmt.Sprintf(resumedNotice, paused.At.Format(time.RFC1123)))
+
+	e.failures.reset()
+	e.loops.reset()
+	e.stuckInterventions = 0
+	e.cache.invalidateSearches()
+
+	return e.runLoop(ctx, paused.Iteration)
+}
+
+// takePause reports whether a pause was requested, clearing the request
+func (e *Engine) takePause() bool {
+	return e.pause.requested.Swap(false)
+}
+
+// pauseRun records the run as paused after the given number of iterations
+// and returns ErrPaused
+func (e *Engine) pauseRun(iterations int) error {
+	e.session.SetPaused(&session.PausedRun{
+		At:        time.Now(),
+		Iteration: iterations,
+		Thinking:  e.turnThinking,
+	})
+	return ErrPaused
+}
+
+// skipTools adds results for the tool calls of a response that were not
+// run, so each tool call still has its result
+func (e *Engine) skipTools(blocks []provider.ContentBlock) {
+	for _, block := range blocks {
+		if b, ok := block.(*provider.ToolUseBlock); ok {
+			e.addToolResult(b.ID, skippedContent, true, nil)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestPauseAndResume(t *testing.T) {
+	prov := &MockProvider{
+		responses: []*provider.Response{
+			{
+				StopReason: provider.StopReasonToolUse,
+				Content: []provider.ContentBlock{
+					&provider.ToolUseBlock{ID: "t1", Name: "Step", Input: map[string]interface{}{}},
+					&provider.ToolUseBlock{ID: "t2", Name: "Step", Input: map[string]interface{}{}},
+				},
+			},
+			textResponse("Done"),
+		},
+	}
+
+	var eng *Engine
+	runs := 0
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{
+		name: "Step",
+		executeFunc: func(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+			runs++
+			eng.Pause() // the user pauses while the first tool runs
+			return &tool.Output{Content: "ok"}, nil
+		},
+	})
+
+	sess := session.NewSession(&session.SessionOptions{})
+	eng = NewEngine(&EngineOptions{Provider: prov, Registry: registry, Session: sess, NoStream: true})
+	if err := eng.Run(context.Background(), "Do both steps"); !errors.Is(err, ErrPaused) {
+		t.Fatalf("expected the run to pause, got %v", err)
+	}
+	if runs != 1 {
+		t.Errorf("expected the running tool to complete and the next to be skipped, ran %d", runs)
+	}
+	paused := eng.Paused()
+	if paused == nil || paused.Iteration != 1 {
+		t.Fatalf("expected the paused run in the session, got %+v", paused)
+	}
+	msgs := sess.GetMessages()
+	results := msgs[len(msgs)-1].Content
+	skipped, ok := results[len(results)-1].(*provider.ToolResultBlock)
+	if !ok || skipped.ToolUseID != "t2" || !strings.HasPrefix(eng.results.unwrap(skipped.Content), "Not run") {
+		t.Fatalf("expected a result for the skipped tool, got %#v", results[len(results)-1])
+	}
+
+	// Another engine continues the run, as after a restart
+	resumed := NewEngine(&EngineOptions{Provider: prov, Registry: registry, Session: sess, NoStream: true})
+	if err := resumed.Resume(context.Background()); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if resumed.Paused() != nil {
+		t.Error("expected the paused run to be cleared")
+	}
+	if got := lastAssistantText(sess.GetMessages()); got != "Done" {
+		t.Errorf("expected the resumed run to finish, got %q", got)
+	}
+	if err := resumed.Resume(context.Background()); err == nil {
+		t.Error("expected an error when there is no paused run")
+	}
+}
@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Limits on pinned content in the system prompt; larger files are cut off
+// with a note
+const (
+	maxPinnedFileBytes = 64 * 1024
+	maxPinnedBytes     = 256 * 1024
+)
+
+// pinnedFile is the content of a pinned file as of its last change
+type pinnedFile struct {
+	modTime time.Time
+	size    int64
+	content string
+}
+
+// pinnedContext returns the current content of the session's pinned files
+// for the system prompt. Files are read again only when they change.
+func (e *Engine) pinnedContext() string {
+	paths := e.session.PinnedFiles()
+	if len(paths) == 0 {
+		return ""
+	}
+	if e.pins == nil {
+		e.pins = make(map[string]pinnedFile)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<pinned-files>\n")
+	sb.WriteString("The user pinned these files so they stay in view for the whole session. This is their current content; prefer it over older copies in the conversation.\n")
+	budget := maxPinnedBytes
+	for _, path := range paths {
+		name := path
+		if rel, err := filepath.Rel(e.session.CWD, path); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+
+		content, err := e.readPinned(path)
+		if err != nil {
+			fmt.Fprintf(&sb, "<file path=%q>\n(unavailable: %v)\n</file>\n", name, err)
+			continue
+		}
+		limit := min(maxPinnedFileBytes, budget)
+		if len(content) > limit {
+			content = content[:limit] + fmt.Sprintf("\n[... truncated: the file is %d bytes; read it for the rest]", len(content))
+		}
+		budget -= min(len(content), budget)
+		fmt.Fprintf(&sb, "<file path=%q>\n%s\n</file>\n", name, strings.TrimRight(content, "\n"))
+	}
+	sb.WriteString("</pinned-files>")
+	return sb.String()
+}
+
+// readPinned returns the content of a pinned file, from the cache while
+// its size and modification time are unchanged
+func (e *Engine) readPinned(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		delete(e.pins, path)
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("is a directory")
+	}
+	if cached, ok := e.pins[path]; ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.content, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	e.pins[path] = pinnedFile{modTime: info.ModTime(), size: info.Size(), content: string(data)}
+	return string(data), nil
+}
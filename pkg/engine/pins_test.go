@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestPinnedFilesInSystemPrompt(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "api", "openapi.yaml")
+	os.MkdirAll(filepath.Dir(spec), 0o755)
+	if err := os.WriteFile(spec, []byte("paths:\n  /users: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sess := session.NewSession(&session.SessionOptions{CWD: dir, Model: "test-model"})
+	eng := NewEngine(&EngineOptions{Provider: &MockProvider{}, Registry: tool.NewRegistry(), Session: sess})
+	if strings.Contains(eng.buildSystemPrompt(), "<pinned-files>") {
+		t.Error("expected no pinned files section without pins")
+	}
+
+	sess.Pin(spec)
+	sess.Pin(filepath.Join(dir, "missing.sql"))
+	prompt := eng.buildSystemPrompt()
+	if !strings.Contains(prompt, "<file path=\"api/openapi.yaml\">\npaths:\n  /users: {}\n</file>") {
+		t.Errorf("expected the pinned file in the system prompt:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "<file path=\"missing.sql\">\n(unavailable:") {
+		t.Errorf("expected a note for the missing file:\n%s", prompt)
+	}
+
+	// Changes show up in the next request
+	if err := os.WriteFile(spec, []byte("paths:\n  /users: {}\n  /orders: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if prompt := eng.buildSystemPrompt(); !strings.Contains(prompt, "/orders") {
+		t.Errorf("expected the changed content:\n%s", prompt)
+	}
+
+	// Large files are cut off
+	os.WriteFile(spec, []byte(strings.Repeat("x", maxPinnedFileBytes+10)), 0o644)
+	if prompt := eng.buildSystemPrompt(); !strings.Contains(prompt, "truncated: the file is") {
+		t.Error("expected a large pinned file to be truncated")
+	}
+}
@@ -19,11 +19,13 @@ const InstructionFileName = "AGENT.md"
 // PromptBuilder builds system prompts for the agent
 type PromptBuilder struct {
 	// Environment
-	CWD         string
-	ProjectPath string
-	GitBranch   string
-	Platform    string
-	Version     string
+	CWD          string
+	ProjectPath  string
+	GitBranch    string
+	Platform     string
+	Version      string
+	RemoteHost   string // host:dir where the file tools and Bash run, "" for this machine
+	Devcontainer string // container where Bash and the Test tool run, "" for none
 
 	// Configuration
 	Model           string
@@ -37,6 +39,14 @@ type PromptBuilder struct {
 	CustomInstructions string
 	ClaudeMD           string // Legacy: also loads CLAUDE.md for compatibility
 	AgentMD            string // Our own instruction file
+
+	// Overrides
+	OverridePrompt string // replaces the built-in prompt entirely
+	AppendPrompt   string // appended after all other sections
+	OutputStyle    string // output style name, see OutputStyles
+
+	// ReadOnly tells the model it may not change anything, see EngineOptions.ReadOnly
+	ReadOnly bool
 }
 
 // NewPromptBuilder creates a new prompt builder
@@ -53,12 +63,31 @@ func NewPromptBuilder() *PromptBuilder {
 func (p *PromptBuilder) Build() string {
 	var sections []string
 
+	if p.OverridePrompt != "" {
+		sections = append(sections, p.OverridePrompt)
+		if p.ReadOnly {
+			sections = append(sections, readOnlyPrompt)
+		}
+		if style := p.buildOutputStyle(); style != "" {
+			sections = append(sections, style)
+		}
+		if p.AppendPrompt != "" {
+			sections = append(sections, p.AppendPrompt)
+		}
+		return strings.Join(sections, "\n\n")
+	}
+
 	// Core identity
 	sections = append(sections, p.buildIdentity())
 
 	// Tool usage policy
 	sections = append(sections, p.buildToolPolicy())
 
+	// Read-only mode
+	if p.ReadOnly {
+		sections = append(sections, readOnlyPrompt)
+	}
+
 	// Task guidance
 	sections = append(sections, p.buildTaskGuidance())
 
@@ -76,9 +105,47 @@ func (p *PromptBuilder) Build() string {
 		sections = append(sections, p.buildClaudeMD())
 	}
 
+	// Output style
+	if style := p.buildOutputStyle(); style != "" {
+		sections = append(sections, style)
+	}
+
+	// User-supplied additions
+	if p.AppendPrompt != "" {
+		sections = append(sections, p.AppendPrompt)
+	}
+
 	return strings.Join(sections, "\n\n")
 }
 
+// readOnlyPrompt describes read-only mode to the model
+const readOnlyPrompt = `# Read-only mode
+You are running in read-only mode: the user pointed you at a checkout that must not change, such as a production deployment or someone else's repository. The tools that edit files or run project code are not available, and Bash runs only commands that read, such as ls, cat, grep, git log and git diff.
+
+Work as you would in plan mode: explore, explain what you find, and when a change is called for, describe it or show it as a diff for the user to apply. Do not try to work around the restriction.`
+
+// buildOutputStyle returns the output style section, or "" for the default style
+func (p *PromptBuilder) buildOutputStyle() string {
+	style, err := GetOutputStyle(p.OutputStyle)
+	if err != nil || style.Instructions == "" {
+		return ""
+	}
+	return "# Output style: " + style.Name + "\n" + style.Instructions
+}
+
+// ReadPromptValue resolves a prompt flag or config value. Values starting
+// with "@" are read from the named file; other values are used as-is.
+func ReadPromptValue(value string) (string, error) {
+	if !strings.HasPrefix(value, "@") {
+		return value, nil
+	}
+	data, err := os.ReadFile(strings.TrimPrefix(value, "@"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
 // buildIdentity returns the core identity section
 func (p *PromptBuilder) buildIdentity() string {
 	return `You are an AI coding assistant powered by agentic-coder.
@@ -257,12 +324,20 @@ func (p *PromptBuilder) buildEnvironmentInfo() string {
 		osVersion = "Windows"
 	}
 
+	tools := ""
+	if p.RemoteHost != "" {
+		tools = "\nRemote host: " + p.RemoteHost + " (the file tools and Bash run there over SSH; paths under the working directory are the same files in its project directory)"
+	}
+	if p.Devcontainer != "" {
+		tools += "\nDevcontainer: " + p.Devcontainer + " (Bash and the Test tool run inside it; the other tools use the same files here)"
+	}
+
 	return fmt.Sprintf(`# Environment Information
 
 <env>
 Working directory: %s
 Is directory a git repo: %s
-Platform: %s
+Platform: %s%s
 Today's date: %s
 </env>
 
@@ -270,6 +345,7 @@ You are powered by agentic-coder version %s.`,
 		cwd,
 		isGitRepo,
 		osVersion,
+		tools,
 		time.Now().Format("2006-01-02"),
 		p.Version,
 	)
@@ -314,7 +390,20 @@ func (p *PromptBuilder) LoadInstructions() {
 
 // loadAgentMD loads our own AGENT.md files
 func (p *PromptBuilder) loadAgentMD() {
-	appDir, _ := config.GetAppDir()
+	if content := loadInstructionFiles(p.agentMDLocations()); content != "" {
+		p.AgentMD = content
+	}
+}
+
+// loadClaudeMD loads CLAUDE.md for compatibility
+func (p *PromptBuilder) loadClaudeMD() {
+	if content := loadInstructionFiles(p.claudeMDLocations()); content != "" {
+		p.ClaudeMD = content
+	}
+}
+
+// agentMDLocations returns candidate AGENT.md paths in load order
+func (p *PromptBuilder) agentMDLocations() []string {
 	locations := []string{}
 
 	// Project level
@@ -329,25 +418,15 @@ func (p *PromptBuilder) loadAgentMD() {
 	}
 
 	// User home
-	if appDir != "" {
+	if appDir, err := config.GetAppDir(); err == nil {
 		locations = append(locations, filepath.Join(appDir, InstructionFileName))
 	}
 
-	// Try each location
-	var contents []string
-	for _, loc := range locations {
-		if data, err := os.ReadFile(loc); err == nil {
-			contents = append(contents, fmt.Sprintf("# From %s\n%s", loc, string(data)))
-		}
-	}
-
-	if len(contents) > 0 {
-		p.AgentMD = strings.Join(contents, "\n\n---\n\n")
-	}
+	return locations
 }
 
-// loadClaudeMD loads CLAUDE.md for compatibility
-func (p *PromptBuilder) loadClaudeMD() {
+// claudeMDLocations returns candidate CLAUDE.md paths in load order
+func (p *PromptBuilder) claudeMDLocations() []string {
 	locations := []string{}
 
 	// Project level
@@ -366,17 +445,18 @@ func (p *PromptBuilder) loadClaudeMD() {
 		locations = append(locations, filepath.Join(home, ".claude", "CLAUDE.md"))
 	}
 
-	// Try each location
+	return locations
+}
+
+// loadInstructionFiles reads the existing files among locations into one section
+func loadInstructionFiles(locations []string) string {
 	var contents []string
 	for _, loc := range locations {
-		if data, err := os.ReadFile(loc); err == nil {
-			contents = append(contents, fmt.Sprintf("# From %s\n%s", loc, string(data)))
+		if data, err := readInstructionFile(loc); err == nil {
+			contents = append(contents, fmt.Sprintf("# From %s\n%s", loc, data))
 		}
 	}
-
-	if len(contents) > 0 {
-		p.ClaudeMD = strings.Join(contents, "\n\n---\n\n")
-	}
+	return strings.Join(contents, "\n\n---\n\n")
 }
 
 // LoadClaudeMD loads CLAUDE.md from various locations (deprecated, use LoadInstructions)
@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPromptBuilderOverride(t *testing.T) {
+	p := NewPromptBuilder()
+	p.AgentMD = "project rules"
+	p.OverridePrompt = "You are a pirate."
+	p.AppendPrompt = "Always say arr."
+
+	got := p.Build()
+	if got != "You are a pirate.\n\nAlways say arr." {
+		t.Errorf("unexpected prompt %q", got)
+	}
+}
+
+func TestPromptBuilderAppend(t *testing.T) {
+	p := NewPromptBuilder()
+	p.AppendPrompt = "Answer in French."
+
+	got := p.Build()
+	if !strings.HasPrefix(got, "You are an AI coding assistant") {
+		t.Error("expected built-in prompt to be kept")
+	}
+	if !strings.HasSuffix(got, "\n\nAnswer in French.") {
+		t.Errorf("expected appended text at the end, got %q", got[len(got)-40:])
+	}
+}
+
+func TestPromptBuilderExecutionEnvironment(t *testing.T) {
+	p := NewPromptBuilder()
+	if got := p.buildEnvironmentInfo(); strings.Contains(got, "Remote host") || strings.Contains(got, "Devcontainer") {
+		t.Errorf("expected the tools to run here by default, got %q", got)
+	}
+	p.RemoteHost = "build:/srv/app"
+	p.Devcontainer = "Go (c0ffee), with the project at /workspaces/app"
+	got := p.buildEnvironmentInfo()
+	if !strings.Contains(got, "Remote host: build:/srv/app") || !strings.Contains(got, "Devcontainer: Go (c0ffee)") {
+		t.Errorf("expected where the tools run, got %q", got)
+	}
+}
+
+func TestReadPromptValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "persona.md")
+	if err := os.WriteFile(path, []byte("Be terse.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"inline text", "inline text", false},
+		{"@" + path, "Be terse.", false},
+		{"@" + path + ".missing", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ReadPromptValue(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ReadPromptValue(%q) = %q, %v; want %q, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPromptBuilderOutputStyle(t *testing.T) {
+	p := NewPromptBuilder()
+	base := p.Build()
+
+	p.OutputStyle = "terse"
+	got := p.Build()
+	if !strings.Contains(got, "# Output style: terse") {
+		t.Error("expected terse style section")
+	}
+
+	p.OutputStyle = DefaultOutputStyle
+	if p.Build() != base {
+		t.Error("default style should not change the prompt")
+	}
+
+	p.OverridePrompt = "Custom."
+	p.OutputStyle = "teaching"
+	if got := p.Build(); !strings.HasPrefix(got, "Custom.\n\n# Output style: teaching") {
+		t.Errorf("expected style after override, got %q", got)
+	}
+}
+
+func TestGetOutputStyle(t *testing.T) {
+	for _, name := range []string{"", "default", "Explanatory", "terse", "teaching"} {
+		if _, err := GetOutputStyle(name); err != nil {
+			t.Errorf("GetOutputStyle(%q) error = %v", name, err)
+		}
+	}
+	if _, err := GetOutputStyle("pirate"); err == nil || !strings.Contains(err.Error(), "available: default, explanatory, terse, teaching") {
+		t.Errorf("expected unknown style error, got %v", err)
+	}
+}
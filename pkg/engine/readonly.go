@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/xinguang/agentic-coder/pkg/permission"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// WritingTools are the built-in tools that change files or run project
+// code. Read-only mode removes them, and plan mode with them, since there
+// is nothing to leave it for.
+var WritingTools = []string{
+	"Write", "Edit", "NotebookEdit", "Deps", "RunSnippet",
+	"Test", "Coverage", "Bench", "Profile", "EnterPlanMode", "ExitPlanMode",
+}
+
+// RemoveWritingTools removes WritingTools from a registry for read-only
+// mode, returning the names of those it had
+func RemoveWritingTools(registry *tool.Registry) []string {
+	var removed []string
+	for _, name := range WritingTools {
+		if registry.Unregister(name) {
+			removed = append(removed, name)
+		}
+	}
+	return removed
+}
+
+// checkReadOnly refuses Bash commands that may write in read-only mode
+func (e *Engine) checkReadOnly(toolName string, input map[string]interface{}) error {
+	if !e.readOnly || toolName != "Bash" {
+		return nil
+	}
+	command, _ := input["command"].(string)
+	if reason := permission.CommandWrites(command); reason != "" {
+		return fmt.Errorf("read-only mode runs only commands that read, and this one %s. Use commands such as ls, cat, grep, git log and git diff, and describe any change for the user to make", reason)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestReadOnly(t *testing.T) {
+	run := func(command string) (bool, string) {
+		t.Helper()
+		prov := &MockProvider{responses: []*provider.Response{
+			{StopReason: provider.StopReasonToolUse, Content: []provider.ContentBlock{
+				&provider.ToolUseBlock{ID: "tool_1", Name: "Bash", Input: map[string]interface{}{"command": command}},
+			}},
+			textResponse("Done."),
+		}}
+
+		ran := false
+		registry := tool.NewRegistry()
+		registry.Register(&MockTool{name: "Bash", executeFunc: func(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+			ran = true
+			return &tool.Output{Content: "ok"}, nil
+		}})
+		sess := session.NewSession(&session.SessionOptions{CWD: "/test", Model: "test-model"})
+		eng := NewEngine(&EngineOptions{Provider: prov, Registry: registry, Session: sess, ReadOnly: true, NoStream: true})
+		if err := eng.Run(context.Background(), "Look around"); err != nil {
+			t.Fatal(err)
+		}
+		result, _ := sess.ToolResult("tool_1")
+		return ran, result
+	}
+
+	if ran, _ := run("git log --oneline | head"); !ran {
+		t.Error("expected a command that reads to run")
+	}
+	ran, result := run("git commit -am wip")
+	if ran || !strings.Contains(result, "read-only mode") || !strings.Contains(result, "runs git commit") {
+		t.Errorf("expected the commit to be refused, ran=%v result=%q", ran, result)
+	}
+}
+
+func TestRemoveWritingTools(t *testing.T) {
+	registry := tool.NewRegistry()
+	for _, name := range []string{"Read", "Write", "Edit", "Bash"} {
+		registry.Register(&MockTool{name: name})
+	}
+
+	removed := RemoveWritingTools(registry)
+	if !slices.Equal(removed, []string{"Write", "Edit"}) {
+		t.Errorf("unexpected removed tools %v", removed)
+	}
+	names := registry.Names()
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"Bash", "Read"}) {
+		t.Errorf("unexpected remaining tools %v", names)
+	}
+}
+
+func TestPromptBuilderReadOnly(t *testing.T) {
+	p := NewPromptBuilder()
+	if strings.Contains(p.Build(), "# Read-only mode") {
+		t.Error("expected no read-only section by default")
+	}
+	p.ReadOnly = true
+	p.OverridePrompt = "You are a pirate."
+	if got := p.Build(); !strings.Contains(got, "# Read-only mode") {
+		t.Errorf("expected the read-only section to survive an override, got %q", got)
+	}
+}
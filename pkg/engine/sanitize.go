@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// maxToolIDLength is the longest tool use ID every ID-based provider accepts
+const maxToolIDLength = 64
+
+// sanitizeHistory rewrites messages into a form the named provider accepts.
+// A session can hold history written by another provider after /model or
+// when it is resumed with a different model, and providers reject parts of
+// each other's transcripts:
+//   - thinking is only sent back to Claude, and only when Claude signed it
+//   - Gemini matches tool results to calls by function name
+//   - Ollama reads the function name from "call_N_name" result IDs
+//   - the rest need unique IDs of letters, digits, '_' and '-'
+//
+// Messages that need no change are passed through as they are, so the
+// provider's prompt cache still matches.
+func sanitizeHistory(messages []provider.Message, target string) []provider.Message {
+	ids := newToolIDs(target)
+	out := make([]provider.Message, len(messages))
+
+	for i, msg := range messages {
+		out[i] = msg
+		content := make([]provider.ContentBlock, 0, len(msg.Content))
+		changed := false
+
+		for _, block := range msg.Content {
+			switch b := block.(type) {
+			case *provider.ThinkingBlock:
+				if target != "claude" || b.Signature == "" {
+					changed = true
+					continue
+				}
+
+			case *provider.RedactedThinkingBlock:
+				if target != "claude" {
+					changed = true
+					continue
+				}
+
+			case *provider.ToolUseBlock:
+				if id := ids.use(b.ID, b.Name); id != b.ID {
+					use := *b
+					use.ID = id
+					block = &use
+					changed = true
+				}
+
+			case *provider.ToolResultBlock:
+				if id := ids.result(b.ToolUseID); id != b.ToolUseID {
+					result := *b
+					result.ToolUseID = id
+					block = &result
+					changed = true
+				}
+			}
+			content = append(content, block)
+		}
+
+		if !changed {
+			continue
+		}
+		// Providers reject empty messages
+		if len(content) == 0 {
+			content = []provider.ContentBlock{&provider.TextBlock{Text: "(thinking omitted)"}}
+		}
+		out[i].Content = content
+	}
+	return out
+}
+
+// toolIDs rewrites tool use IDs into the target provider's scheme, keeping
+// tool results paired with their calls
+type toolIDs struct {
+	target  string
+	issued  map[string]bool     // IDs given to calls so far
+	pending map[string][]string // original ID -> rewritten IDs awaiting a result
+	n       int
+}
+
+func newToolIDs(target string) *toolIDs {
+	return &toolIDs{
+		target:  target,
+		issued:  make(map[string]bool),
+		pending: make(map[string][]string),
+	}
+}
+
+// use returns the ID for a tool call
+func (t *toolIDs) use(id, name string) string {
+	out := id
+	switch t.target {
+	case "gemini":
+		out = name
+	case "ollama":
+		if !strings.HasPrefix(id, "call_") || !strings.HasSuffix(id, "_"+name) {
+			out = fmt.Sprintf("call_%d_%s", t.n, name)
+		}
+	default:
+		for !validToolID(out) || t.issued[out] {
+			out = fmt.Sprintf("call_%d", t.n)
+			t.n++
+		}
+	}
+	t.n++
+	t.issued[out] = true
+	t.pending[id] = append(t.pending[id], out)
+	return out
+}
+
+// result returns the ID for a tool result, matching calls that share an
+// ID in order. Results without a call are left alone.
+func (t *toolIDs) result(id string) string {
+	queue := t.pending[id]
+	if len(queue) == 0 {
+		return id
+	}
+	t.pending[id] = queue[1:]
+	return queue[0]
+}
+
+// validToolID reports whether id is accepted by ID-based providers
+func validToolID(id string) bool {
+	if id == "" || len(id) > maxToolIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
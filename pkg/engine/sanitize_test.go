@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// geminiHistory is a turn written by Gemini, which names tool calls after
+// their function and so repeats IDs
+func geminiHistory() []provider.Message {
+	return []provider.Message{
+		{Role: provider.RoleUser, Content: []provider.ContentBlock{&provider.TextBlock{Text: "read both"}}},
+		{Role: provider.RoleAssistant, Content: []provider.ContentBlock{
+			&provider.ToolUseBlock{ID: "Read", Name: "Read"},
+			&provider.ToolUseBlock{ID: "Read", Name: "Read"},
+		}},
+		{Role: provider.RoleUser, Content: []provider.ContentBlock{
+			&provider.ToolResultBlock{ToolUseID: "Read", Content: "a"},
+			&provider.ToolResultBlock{ToolUseID: "Read", Content: "b"},
+		}},
+	}
+}
+
+// claudeHistory is a turn written by Claude with signed thinking
+func claudeHistory() []provider.Message {
+	return []provider.Message{
+		{Role: provider.RoleUser, Content: []provider.ContentBlock{&provider.TextBlock{Text: "list"}}},
+		{Role: provider.RoleAssistant, Content: []provider.ContentBlock{
+			&provider.ThinkingBlock{Thinking: "use Glob", Signature: "sig"},
+			&provider.ToolUseBlock{ID: "toolu_01A", Name: "Glob"},
+		}},
+		{Role: provider.RoleUser, Content: []provider.ContentBlock{&provider.ToolResultBlock{ToolUseID: "toolu_01A", Content: "x.go"}}},
+		{Role: provider.RoleAssistant, Content: []provider.ContentBlock{&provider.RedactedThinkingBlock{Data: "enc"}}},
+	}
+}
+
+// toolIDsOf returns the tool use and tool result IDs in messages
+func toolIDsOf(messages []provider.Message) (uses, results []string) {
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			switch b := block.(type) {
+			case *provider.ToolUseBlock:
+				uses = append(uses, b.ID)
+			case *provider.ToolResultBlock:
+				results = append(results, b.ToolUseID)
+			}
+		}
+	}
+	return uses, results
+}
+
+func TestSanitizeHistoryToolIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []provider.Message
+		target  string
+		want    []string
+	}{
+		{"gemini to claude", geminiHistory(), "claude", []string{"Read", "call_1"}},
+		{"gemini to ollama", geminiHistory(), "ollama", []string{"call_0_Read", "call_1_Read"}},
+		{"claude to gemini", claudeHistory(), "gemini", []string{"Glob"}},
+		{"claude to openai", claudeHistory(), "openai", []string{"toolu_01A"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uses, results := toolIDsOf(sanitizeHistory(tt.history, tt.target))
+			if len(uses) != len(tt.want) || len(results) != len(tt.want) {
+				t.Fatalf("got uses %v and results %v, want %v", uses, results, tt.want)
+			}
+			for i := range tt.want {
+				if uses[i] != tt.want[i] || results[i] != tt.want[i] {
+					t.Errorf("call %d: got use %q and result %q, want %q", i, uses[i], results[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	// The session's own blocks are not changed
+	history := geminiHistory()
+	sanitizeHistory(history, "claude")
+	if id := history[1].Content[1].(*provider.ToolUseBlock).ID; id != "Read" {
+		t.Errorf("expected the original history to be left alone, got %q", id)
+	}
+}
+
+func TestSanitizeHistoryThinking(t *testing.T) {
+	kept := sanitizeHistory(claudeHistory(), "claude")
+	if len(kept[1].Content) != 2 || len(kept[3].Content) != 1 {
+		t.Errorf("expected Claude to get its thinking back, got %+v", kept)
+	}
+	if _, ok := kept[3].Content[0].(*provider.RedactedThinkingBlock); !ok {
+		t.Errorf("expected redacted thinking for Claude, got %+v", kept[3].Content)
+	}
+
+	dropped := sanitizeHistory(claudeHistory(), "openai")
+	if len(dropped[1].Content) != 1 {
+		t.Errorf("expected thinking to be dropped for OpenAI, got %+v", dropped[1].Content)
+	}
+	if text, ok := dropped[3].Content[0].(*provider.TextBlock); !ok || text.Text == "" {
+		t.Errorf("expected a placeholder for a message of only thinking, got %+v", dropped[3].Content)
+	}
+
+	unsigned := []provider.Message{{Role: provider.RoleAssistant, Content: []provider.ContentBlock{
+		&provider.ThinkingBlock{Thinking: "from another model"},
+		&provider.TextBlock{Text: "hi"},
+	}}}
+	if got := sanitizeHistory(unsigned, "claude"); len(got[0].Content) != 1 {
+		t.Errorf("expected unsigned thinking to be dropped for Claude, got %+v", got[0].Content)
+	}
+}
+
+func TestValidToolID(t *testing.T) {
+	tests := map[string]bool{
+		"toolu_01A":              true,
+		"call_abc-1":             true,
+		"":                       false,
+		"functions.Read:0":       false,
+		string(make([]byte, 65)): false,
+	}
+	for id, want := range tests {
+		if got := validToolID(id); got != want {
+			t.Errorf("validToolID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
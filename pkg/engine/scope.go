@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PathAccess is a file tool call that reaches outside the project scope
+type PathAccess struct {
+	Tool string
+	Path string // absolute path outside the scope
+	Dir  string // directory allowed for the rest of the session by PathAllowDirectory
+}
+
+// PathDecision is the user's answer to a PathAccess
+type PathDecision int
+
+const (
+	PathDeny PathDecision = iota
+	PathAllowOnce
+	PathAllowDirectory
+)
+
+// ParsePathDecision reads the answer to a path access prompt: y or yes
+// allows once, a or always allows the directory, anything else denies
+func ParsePathDecision(answer string) PathDecision {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return PathAllowOnce
+	case "a", "always":
+		return PathAllowDirectory
+	}
+	return PathDeny
+}
+
+// scopedTools are the file tools confined to the path scope
+var scopedTools = map[string]bool{
+	"Read":         true,
+	"Write":        true,
+	"Edit":         true,
+	"MultiEdit":    true,
+	"NotebookEdit": true,
+	"Glob":         true,
+	"Grep":         true,
+}
+
+// scopedPath returns the path a file tool call reaches, "" for the working
+// directory. A Glob pattern's fixed leading directories count as its path.
+func scopedPath(toolName string, input map[string]interface{}) string {
+	path := toolInputPath(input)
+	if toolName != "Glob" {
+		return path
+	}
+	pattern, _ := input["pattern"].(string)
+	fixed := pattern
+	if i := strings.IndexAny(pattern, "*?[{"); i >= 0 {
+		fixed = filepath.Dir(pattern[:i] + "x")
+	}
+	if fixed == "." || fixed == "" {
+		return path
+	}
+	if filepath.IsAbs(fixed) || path == "" {
+		return fixed
+	}
+	return filepath.Join(path, fixed)
+}
+
+// checkPathScope asks the user before a file tool reaches outside the
+// project, returning an error for the model when access is denied
+func (e *Engine) checkPathScope(toolName string, input map[string]interface{}) error {
+	if e.scope == nil || !scopedTools[toolName] {
+		return nil
+	}
+	path := scopedPath(toolName, input)
+	if path == "" {
+		return nil
+	}
+	path = e.resolvePath(path)
+	if e.scope.Contains(path) {
+		return nil
+	}
+
+	dir := path
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	if e.onPathAccess == nil {
+		return fmt.Errorf("%s is outside the project directory %s; the user can allow it by adding it to additional_directories in the config", path, e.scope.Root())
+	}
+
+	switch e.onPathAccess(PathAccess{Tool: toolName, Path: path, Dir: dir}) {
+	case PathAllowOnce:
+		return nil
+	case PathAllowDirectory:
+		e.scope.Allow(dir)
+		return nil
+	}
+	return fmt.Errorf("the user denied access to %s, which is outside the project directory %s; do not retry without asking the user", path, e.scope.Root())
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/permission"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestScopedPath(t *testing.T) {
+	tests := []struct {
+		tool  string
+		input map[string]interface{}
+		want  string
+	}{
+		{"Read", map[string]interface{}{"file_path": "/etc/hosts"}, "/etc/hosts"},
+		{"Grep", map[string]interface{}{"pattern": "TODO"}, ""},
+		{"Glob", map[string]interface{}{"pattern": "**/*.go"}, ""},
+		{"Glob", map[string]interface{}{"pattern": "*.go", "path": "/src"}, "/src"},
+		{"Glob", map[string]interface{}{"pattern": "/etc/*.conf"}, "/etc"},
+		{"Glob", map[string]interface{}{"pattern": "../../.ssh/*", "path": "/src/app"}, "/.ssh"},
+		{"Glob", map[string]interface{}{"pattern": "docs/readme.md"}, "docs/readme.md"},
+	}
+	for _, tt := range tests {
+		if got := scopedPath(tt.tool, tt.input); got != tt.want {
+			t.Errorf("scopedPath(%s, %v) = %q, want %q", tt.tool, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParsePathDecision(t *testing.T) {
+	tests := map[string]PathDecision{
+		"y\n":    PathAllowOnce,
+		"Yes":    PathAllowOnce,
+		" a ":    PathAllowDirectory,
+		"always": PathAllowDirectory,
+		"":       PathDeny,
+		"n":      PathDeny,
+		"sure":   PathDeny,
+	}
+	for answer, want := range tests {
+		if got := ParsePathDecision(answer); got != want {
+			t.Errorf("ParsePathDecision(%q) = %v, want %v", answer, got, want)
+		}
+	}
+}
+
+func TestExecuteToolUseChecksPathScope(t *testing.T) {
+	project := t.TempDir()
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret.txt")
+	if err := os.WriteFile(secret, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	executions := 0
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{
+		name: "Read",
+		executeFunc: func(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+			executions++
+			return &tool.Output{Content: "contents"}, nil
+		},
+	})
+
+	sess := session.NewSession(&session.SessionOptions{CWD: project})
+	eng := NewEngine(&EngineOptions{
+		Provider:  &MockProvider{},
+		Registry:  registry,
+		Session:   sess,
+		PathScope: permission.NewPathScope(project),
+	})
+
+	read := func(id, path string) *provider.ToolResultBlock {
+		t.Helper()
+		if err := eng.executeToolUse(context.Background(), &provider.ToolUseBlock{ID: id, Name: "Read", Input: map[string]interface{}{"file_path": path}}); err != nil {
+			t.Fatal(err)
+		}
+		msgs := sess.GetMessages()
+		return msgs[len(msgs)-1].Content[0].(*provider.ToolResultBlock)
+	}
+
+	// Inside the project nothing is asked
+	if result := read("t1", "main.go"); result.IsError || executions != 1 {
+		t.Errorf("expected a read inside the project to run, got %+v", result)
+	}
+
+	// Without a callback access outside is denied
+	if result := read("t2", secret); !result.IsError || !strings.Contains(result.Content, "additional_directories") || executions != 1 {
+		t.Errorf("expected access outside the project to be denied, got %+v", result)
+	}
+
+	var asked []PathAccess
+	decision := PathDeny
+	eng.SetCallbacks(&CallbackOptions{OnPathAccess: func(access PathAccess) PathDecision {
+		asked = append(asked, access)
+		return decision
+	}})
+
+	if result := read("t3", secret); !result.IsError || !strings.Contains(result.Content, "denied") || executions != 1 {
+		t.Errorf("expected the user's denial to block the read, got %+v", result)
+	}
+	if len(asked) != 1 || asked[0].Tool != "Read" || asked[0].Dir != outside {
+		t.Errorf("unexpected access request: %+v", asked)
+	}
+
+	decision = PathAllowOnce
+	if result := read("t4", secret); result.IsError || executions != 2 {
+		t.Errorf("expected the read to be allowed once, got %+v", result)
+	}
+
+	decision = PathAllowDirectory
+	read("t5", secret)
+	decision = PathDeny
+	if result := read("t6", secret); result.IsError || len(asked) != 3 {
+		t.Errorf("expected the directory to stay allowed without asking, got %+v after %d requests", result, len(asked))
+	}
+}
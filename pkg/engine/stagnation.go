@@ -0,0 +1,181 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+const (
+	// defaultLoopThreshold is how many identical tool calls count as a loop
+	defaultLoopThreshold = 3
+
+	// maxStuckInterventions aborts the run after this many detections
+	maxStuckInterventions = 3
+
+	// loopHistorySize is the number of recent tool calls kept for detection
+	loopHistorySize = 30
+)
+
+// StuckError is returned when the agent keeps looping after repeated interventions
+type StuckError struct {
+	Reason string
+}
+
+func (e *StuckError) Error() string {
+	return fmt.Sprintf("agent appears to be stuck: %s", e.Reason)
+}
+
+// MaxIterationsError is returned when a run reaches the iteration limit
+// before the model ends its turn
+type MaxIterationsError struct {
+	Limit int
+	// Summary is the model's wrap-up of the run, or "" when there is none
+	Summary string
+}
+
+func (e *MaxIterationsError) Error() string {
+	return fmt.Sprintf("max iterations (%d) exceeded", e.Limit)
+}
+
+// loopDetector detects repetitive tool use within a run
+type loopDetector struct {
+	threshold int
+	calls     []string            // recent tool call keys, oldest first
+	contents  map[string][]string // file path -> recent content fingerprints
+	reverts   map[string]int      // file path -> number of reverting changes
+}
+
+func newLoopDetector(threshold int) *loopDetector {
+	d := &loopDetector{threshold: threshold}
+	d.reset()
+	return d
+}
+
+// reset clears all recorded history
+func (d *loopDetector) reset() {
+	d.calls = nil
+	d.contents = make(map[string][]string)
+	d.reverts = make(map[string]int)
+}
+
+// record records a tool call and returns a non-empty reason when a loop is detected
+func (d *loopDetector) record(toolName string, input map[string]interface{}) string {
+	if d.threshold <= 0 {
+		return ""
+	}
+
+	if reason := d.recordRepeat(toolName, input); reason != "" {
+		return reason
+	}
+	return d.recordRevert(toolName, input)
+}
+
+// recordRepeat detects the same tool being called with the same input
+func (d *loopDetector) recordRepeat(toolName string, input map[string]interface{}) string {
+	key := toolName + ":" + inputKey(input)
+
+	d.calls = append(d.calls, key)
+	if len(d.calls) > loopHistorySize {
+		d.calls = d.calls[len(d.calls)-loopHistorySize:]
+	}
+
+	count := 0
+	for _, k := range d.calls {
+		if k == key {
+			count++
+		}
+	}
+	if count < d.threshold {
+		return ""
+	}
+
+	// Forget this call so the next detection needs a fresh run of repeats
+	kept := d.calls[:0]
+	for _, k := range d.calls {
+		if k != key {
+			kept = append(kept, k)
+		}
+	}
+	d.calls = kept
+
+	return fmt.Sprintf("called %s %d times with identical input", toolName, count)
+}
+
+// recordRevert detects file changes that undo an earlier change
+func (d *loopDetector) recordRevert(toolName string, input map[string]interface{}) string {
+	path, _ := input["file_path"].(string)
+	if path == "" {
+		return ""
+	}
+
+	// Fingerprint the change so that an edit and its inverse are recognizable
+	var before, after string
+	switch toolName {
+	case "Edit":
+		oldStr, _ := input["old_string"].(string)
+		newStr, _ := input["new_string"].(string)
+		before, after = fingerprint(oldStr), fingerprint(newStr)
+	case "Write":
+		content, _ := input["content"].(string)
+		after = fingerprint(content)
+	default:
+		return ""
+	}
+
+	history := d.contents[path]
+	reverted := false
+	if before != "" {
+		// Edit: the new text was replaced away earlier, and the old text was introduced
+		for i := len(history) - 1; i > 0; i-- {
+			if history[i] == before && history[i-1] == after {
+				reverted = true
+				break
+			}
+		}
+		history = append(history, before, after)
+	} else {
+		// Write: the content matches an earlier write that was since replaced
+		for i := len(history) - 2; i >= 0; i-- {
+			if history[i] == after && history[len(history)-1] != after {
+				reverted = true
+				break
+			}
+		}
+		history = append(history, after)
+	}
+	if len(history) > loopHistorySize {
+		history = history[len(history)-loopHistorySize:]
+	}
+	d.contents[path] = history
+
+	if !reverted {
+		return ""
+	}
+	d.reverts[path]++
+	if d.reverts[path] < d.threshold-1 {
+		return ""
+	}
+
+	d.reverts[path] = 0
+	d.contents[path] = nil
+	return fmt.Sprintf("changes to %s keep reverting each other", path)
+}
+
+// stuckMessage renders the synthesized intervention for a detected loop
+func stuckMessage(reason string) string {
+	var b strings.Builder
+	b.WriteString("[System notice] You appear to be stuck: ")
+	b.WriteString(reason)
+	b.WriteString(".\n\n")
+	b.WriteString("Repeating the same actions will not produce a different result. Before continuing:\n")
+	b.WriteString("1. Summarize what you have tried and why it did not work.\n")
+	b.WriteString("2. Re-read the relevant files or error output instead of relying on memory.\n")
+	b.WriteString("3. Choose a different approach, or stop and ask the user for guidance.")
+	return b.String()
+}
+
+func fingerprint(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return fmt.Sprintf("%x", sum[:8])
+}
@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestLoopDetectorRepeatedCalls(t *testing.T) {
+	d := newLoopDetector(3)
+	input := map[string]interface{}{"file_path": "/a.go"}
+
+	if r := d.record("Read", input); r != "" {
+		t.Fatalf("unexpected detection %q", r)
+	}
+	if r := d.record("Read", map[string]interface{}{"file_path": "/b.go"}); r != "" {
+		t.Fatalf("unexpected detection %q", r)
+	}
+	if r := d.record("Read", input); r != "" {
+		t.Fatalf("unexpected detection %q", r)
+	}
+	r := d.record("Read", input)
+	if !strings.Contains(r, "called Read 3 times") {
+		t.Errorf("expected repeat detection, got %q", r)
+	}
+
+	// Detection starts over after firing
+	if r := d.record("Read", input); r != "" {
+		t.Errorf("expected no detection right after firing, got %q", r)
+	}
+}
+
+func TestLoopDetectorRevertingEdits(t *testing.T) {
+	d := newLoopDetector(3)
+	edit := func(oldStr, newStr string) string {
+		return d.record("Edit", map[string]interface{}{"file_path": "/a.go", "old_string": oldStr, "new_string": newStr})
+	}
+
+	if r := edit("x := 1", "x := 2"); r != "" {
+		t.Fatalf("unexpected detection %q", r)
+	}
+	if r := edit("x := 2", "x := 1"); r != "" {
+		t.Fatalf("a single revert should not be detected, got %q", r)
+	}
+	if r := edit("x := 1", "x := 2"); !strings.Contains(r, "/a.go keep reverting") {
+		t.Errorf("expected revert detection, got %q", r)
+	}
+}
+
+func TestLoopDetectorRevertingWrites(t *testing.T) {
+	d := newLoopDetector(3)
+	write := func(content string) string {
+		return d.record("Write", map[string]interface{}{"file_path": "/a.go", "content": content})
+	}
+
+	for _, content := range []string{"v1", "v2", "v1"} {
+		if r := write(content); r != "" {
+			t.Fatalf("unexpected detection %q", r)
+		}
+	}
+	if r := write("v2"); r == "" {
+		t.Error("expected revert detection for alternating writes")
+	}
+}
+
+func TestLoopDetectorDisabled(t *testing.T) {
+	d := newLoopDetector(-1)
+	for i := 0; i < 10; i++ {
+		if r := d.record("Read", nil); r != "" {
+			t.Fatalf("disabled detector fired: %q", r)
+		}
+	}
+}
+
+func TestRunInterruptsLoop(t *testing.T) {
+	repeat := &provider.Response{
+		StopReason: provider.StopReasonToolUse,
+		Content: []provider.ContentBlock{
+			&provider.ToolUseBlock{ID: "t", Name: "test_tool", Input: map[string]interface{}{"param": "same"}},
+		},
+	}
+	prov := &MockProvider{responses: []*provider.Response{repeat, repeat, repeat}}
+
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{name: "test_tool"})
+
+	sess := session.NewSession(&session.SessionOptions{CWD: "/test", Model: "test-model"})
+	eng := NewEngine(&EngineOptions{Provider: prov, Registry: registry, Session: sess})
+
+	var stuckReason string
+	eng.SetCallbacks(&CallbackOptions{
+		OnStuck: func(reason string) string {
+			stuckReason = reason
+			return "try reading the docs"
+		},
+	})
+
+	if err := eng.Run(context.Background(), "Loop"); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if !strings.Contains(stuckReason, "test_tool") {
+		t.Errorf("expected OnStuck to be called, got %q", stuckReason)
+	}
+
+	found := false
+	for _, msg := range sess.GetMessages() {
+		for _, block := range msg.Content {
+			if tb, ok := block.(*provider.TextBlock); ok && strings.Contains(tb.Text, "Guidance from the user: try reading the docs") {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Error("expected guidance message in session")
+	}
+}
+
+func TestRunAbortsWhenStuck(t *testing.T) {
+	repeat := &provider.Response{
+		StopReason: provider.StopReasonToolUse,
+		Content: []provider.ContentBlock{
+			&provider.ToolUseBlock{ID: "t", Name: "test_tool", Input: map[string]interface{}{}},
+		},
+	}
+	responses := make([]*provider.Response, 20)
+	for i := range responses {
+		responses[i] = repeat
+	}
+
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{name: "test_tool"})
+
+	sess := session.NewSession(&session.SessionOptions{CWD: "/test", Model: "test-model"})
+	eng := NewEngine(&EngineOptions{Provider: &MockProvider{responses: responses}, Registry: registry, Session: sess, LoopThreshold: 2})
+
+	var stuckErr *StuckError
+	if err := eng.Run(context.Background(), "Loop"); !errors.As(err, &stuckErr) {
+		t.Errorf("expected StuckError, got %v", err)
+	}
+}
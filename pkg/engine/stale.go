@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// minSummarizedResult is the smallest tool result worth summarizing; a
+// summary of anything shorter would not save context
+const minSummarizedResult = 500
+
+// summarizeStaleResults replaces tool results followed by at least
+// staleResultTurns responses with a short summary, so long sessions keep
+// room in the context window without a full compaction. Recent results and
+// the session itself are left as they are.
+func (e *Engine) summarizeStaleResults(messages []provider.Message) []provider.Message {
+	turns := e.staleResultTurns
+	if turns <= 0 {
+		return messages
+	}
+
+	// Responses after each message
+	after := make([]int, len(messages))
+	responses := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		after[i] = responses
+		if messages[i].Role == provider.RoleAssistant {
+			responses++
+		}
+	}
+
+	calls := make(map[string]*provider.ToolUseBlock)
+	out := make([]provider.Message, len(messages))
+	for i, msg := range messages {
+		out[i] = msg
+
+		var content []provider.ContentBlock
+		for j, block := range msg.Content {
+			switch b := block.(type) {
+			case *provider.ToolUseBlock:
+				calls[b.ID] = b
+			case *provider.ToolResultBlock:
+				if after[i] < turns || len(b.Content) < minSummarizedResult {
+					continue
+				}
+				if content == nil {
+					content = slices.Clone(msg.Content)
+				}
+				summary := *b
+				text := summarizeResult(calls[b.ToolUseID], e.results.unwrap(b.Content), after[i])
+				summary.Content = e.results.wrap(e.session.ID, text)
+				content[j] = &summary
+			}
+		}
+		if content != nil {
+			out[i].Content = content
+		}
+	}
+	return out
+}
+
+// summarizeResult describes a tool result that is no longer sent in full
+func summarizeResult(call *provider.ToolUseBlock, content string, ago int) string {
+	what := "A tool call"
+	if call != nil {
+		what = "The " + call.Name + " call"
+		if target := callTarget(call.Input); target != "" {
+			what += " on " + target
+		}
+	}
+	lines := strings.Count(strings.TrimRight(content, "\n"), "\n") + 1
+	return fmt.Sprintf("[Summarized to save context] %s from %d responses ago returned %d lines (%d bytes), starting: %s\nCall the tool again if you need the full result.",
+		what, ago, lines, len(content), clip(firstLine(content), 120))
+}
+
+// callTarget returns the path, command, or query a tool call was about
+func callTarget(input map[string]interface{}) string {
+	if path := toolInputPath(input); path != "" {
+		return path
+	}
+	for _, key := range []string{"command", "pattern", "url", "query"} {
+		if v, ok := input[key].(string); ok && v != "" {
+			return clip(firstLine(v), 80)
+		}
+	}
+	return ""
+}
+
+// clip shortens s to about n bytes
+func clip(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:runeBoundary(s, n)] + "..."
+}
+
+// resultStale reports whether the latest result of a tool call is sent
+// to the provider as a summary
+func (e *Engine) resultStale(toolUseID string) bool {
+	if e.staleResultTurns <= 0 {
+		return false
+	}
+	messages := e.session.GetMessages()
+	responses := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == provider.RoleAssistant {
+			responses++
+			continue
+		}
+		for _, block := range messages[i].Content {
+			if r, ok := block.(*provider.ToolResultBlock); ok && r.ToolUseID == toolUseID {
+				return responses >= e.staleResultTurns && len(r.Content) >= minSummarizedResult
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestSummarizeStaleResults(t *testing.T) {
+	sess := session.NewSession(&session.SessionOptions{CWD: t.TempDir()})
+	eng := NewEngine(&EngineOptions{Provider: &MockProvider{}, Registry: tool.NewRegistry(), Session: sess, StaleResultTurns: 2})
+
+	// Two reads, each answered by a response
+	big := strings.Repeat("line of file content\n", 50)
+	sess.AddUserMessage("read the files")
+	for _, id := range []string{"t1", "t2"} {
+		sess.AddAssistantMessage(&provider.Response{Content: []provider.ContentBlock{
+			&provider.ToolUseBlock{ID: id, Name: "Read", Input: map[string]interface{}{"file_path": id + ".go"}},
+		}})
+		eng.addToolResult(id, big, false, nil)
+	}
+	sess.AddAssistantMessage(&provider.Response{Content: []provider.ContentBlock{&provider.TextBlock{Text: "done"}}})
+
+	messages := eng.summarizeStaleResults(sess.GetMessages())
+	old := messages[2].Content[0].(*provider.ToolResultBlock).Content
+	recent := messages[4].Content[0].(*provider.ToolResultBlock).Content
+	if !strings.Contains(old, "The Read call on t1.go from 2 responses ago returned 50 lines") {
+		t.Errorf("expected the first result to be summarized, got %q", old)
+	}
+	if eng.results.unwrap(old) == old {
+		t.Error("expected the summary to be wrapped like other tool results")
+	}
+	if eng.results.unwrap(recent) != big {
+		t.Errorf("expected the recent result verbatim, got %q", recent)
+	}
+	if content, _ := sess.ToolResult("t1"); eng.results.unwrap(content) != big {
+		t.Error("expected the session to keep the full result")
+	}
+
+	if !eng.resultStale("t1") || eng.resultStale("t2") {
+		t.Error("expected only t1 to be stale")
+	}
+
+	eng.staleResultTurns = 0
+	if got := eng.summarizeStaleResults(sess.GetMessages()); got[2].Content[0].(*provider.ToolResultBlock).Content != sess.GetMessages()[2].Content[0].(*provider.ToolResultBlock).Content {
+		t.Error("expected no summaries when disabled")
+	}
+}
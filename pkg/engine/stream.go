@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+const (
+	// replayChunkSize is the approximate size of the pieces a response that
+	// was not streamed is shown in
+	replayChunkSize = 48
+	// replayMaxDelay caps the total pause spent replaying one text block
+	replayMaxDelay = 750 * time.Millisecond
+)
+
+// replayDelay is the pause between replayed pieces (variable for tests)
+var replayDelay = 10 * time.Millisecond
+
+// streaming reports whether requests are streamed: unless disabled, whenever
+// the provider supports it
+func (e *Engine) streaming() bool {
+	return !e.noStream && e.provider.SupportsFeature(provider.FeatureStreaming)
+}
+
+// replayText shows the text of a response that was not streamed in small
+// pieces, so it appears incrementally like a streamed one
+func (e *Engine) replayText(ctx context.Context, text string) {
+	chunks := textChunks(text, replayChunkSize)
+	delay := replayDelay
+	if n := time.Duration(len(chunks)); n > 1 && delay*n > replayMaxDelay {
+		delay = replayMaxDelay / n
+	}
+	for i, chunk := range chunks {
+		if i > 0 && delay > 0 && ctx.Err() == nil {
+			time.Sleep(delay)
+		}
+		e.onText(chunk)
+	}
+}
+
+// textChunks splits text into pieces of about size bytes, breaking after
+// whitespace so words stay whole
+func textChunks(text string, size int) []string {
+	var chunks []string
+	for len(text) > size {
+		cut := strings.IndexAny(text[size:], " \n\t")
+		if cut < 0 {
+			break
+		}
+		cut += size + 1
+		chunks = append(chunks, text[:cut])
+		text = text[cut:]
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// nonStreamingProvider records whether requests asked for streaming
+type nonStreamingProvider struct {
+	MockProvider
+	streaming bool
+	requests  []bool
+}
+
+func (p *nonStreamingProvider) SupportsFeature(feature provider.Feature) bool {
+	return feature != provider.FeatureStreaming || p.streaming
+}
+
+func (p *nonStreamingProvider) CreateMessage(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	p.requests = append(p.requests, req.Stream)
+	return &provider.Response{
+		StopReason: provider.StopReasonEndTurn,
+		Content:    []provider.ContentBlock{&provider.TextBlock{Text: strings.Repeat("word ", 30)}},
+		Usage:      provider.Usage{InputTokens: 10, OutputTokens: 20},
+	}, nil
+}
+
+func (p *nonStreamingProvider) CreateMessageStream(ctx context.Context, req *provider.Request) (provider.StreamReader, error) {
+	p.requests = append(p.requests, req.Stream)
+	return p.MockProvider.CreateMessageStream(ctx, req)
+}
+
+func TestNonStreamingRun(t *testing.T) {
+	replayDelay = 0
+	tests := []struct {
+		name      string
+		streaming bool
+		noStream  bool
+		want      bool
+	}{
+		{"streaming provider", true, false, true},
+		{"no-stream option", true, true, false},
+		{"provider without streaming", false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prov := &nonStreamingProvider{streaming: tt.streaming}
+			eng := NewEngine(&EngineOptions{
+				Provider: prov,
+				Registry: tool.NewRegistry(),
+				Session:  session.NewSession(&session.SessionOptions{CWD: t.TempDir(), Model: "test-model"}),
+				NoStream: tt.noStream,
+			})
+			var chunks []string
+			var output int
+			eng.SetCallbacks(&CallbackOptions{
+				OnText:  func(text string) { chunks = append(chunks, text) },
+				OnUsage: func(in, out int) { output += out },
+			})
+
+			if err := eng.Run(context.Background(), "hello"); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if len(prov.requests) != 1 || prov.requests[0] != tt.want {
+				t.Fatalf("expected one request with stream=%v, got %v", tt.want, prov.requests)
+			}
+			if tt.want {
+				return
+			}
+			if len(chunks) < 2 || strings.Join(chunks, "") != strings.Repeat("word ", 30) {
+				t.Errorf("expected the text replayed in pieces, got %q", chunks)
+			}
+			if output != 20 {
+				t.Errorf("expected usage to be reported, got %d output tokens", output)
+			}
+		})
+	}
+}
+
+func TestTextChunks(t *testing.T) {
+	tests := []struct {
+		text string
+		size int
+		want []string
+	}{
+		{"", 4, nil},
+		{"short", 10, []string{"short"}},
+		{"one two three four", 4, []string{"one two ", "three ", "four"}},
+		{"unbroken-long-word", 4, []string{"unbroken-long-word"}},
+		{"line one\nline two", 6, []string{"line one\n", "line two"}},
+	}
+	for _, tt := range tests {
+		got := textChunks(tt.text, tt.size)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("textChunks(%q, %d) = %q, want %q", tt.text, tt.size, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultOutputStyle is the style that adds no instructions
+const DefaultOutputStyle = "default"
+
+// OutputStyle is a named set of instructions that shapes how the agent responds
+type OutputStyle struct {
+	Name         string
+	Description  string
+	Instructions string
+}
+
+var outputStyles = []*OutputStyle{
+	{
+		Name:        DefaultOutputStyle,
+		Description: "Concise responses focused on completing the task",
+	},
+	{
+		Name:        "explanatory",
+		Description: "Explains implementation choices and codebase patterns while working",
+		Instructions: `Explain your reasoning as you work:
+- Before non-trivial changes, briefly state why you chose this approach over alternatives.
+- Point out relevant patterns and conventions in the codebase when you rely on them.
+- After completing a task, summarize what changed and any trade-offs made.
+Keep explanations focused on insights specific to this codebase rather than general programming concepts.`,
+	},
+	{
+		Name:        "terse",
+		Description: "Minimal prose; diffs and results only",
+		Instructions: `Be as brief as possible:
+- Do not explain what you are about to do; just do it.
+- Do not summarize changes after making them unless asked.
+- Answer questions in one or two sentences, or with only the code or command requested.
+- Report only failures, blockers and final results.`,
+	},
+	{
+		Name:        "teaching",
+		Description: "Guides the user to learn by doing, leaving small parts for them to write",
+		Instructions: `Act as a patient teacher:
+- Explain concepts as they come up, assuming the user wants to understand, not just finish.
+- For small, self-contained pieces of logic, describe what is needed and ask the user to write it, marking the spot with a TODO comment.
+- After the user contributes, review their code and explain improvements.
+- Check understanding with a short question at natural stopping points.`,
+	},
+}
+
+// OutputStyles returns the available output styles
+func OutputStyles() []*OutputStyle {
+	return outputStyles
+}
+
+// GetOutputStyle returns the output style with the given name
+func GetOutputStyle(name string) (*OutputStyle, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = DefaultOutputStyle
+	}
+	for _, s := range outputStyles {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+
+	names := make([]string, 0, len(outputStyles))
+	for _, s := range outputStyles {
+		names = append(names, s.Name)
+	}
+	return nil, fmt.Errorf("unknown output style: %s (available: %s)", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// CallSpeed is how fast a provider answered: the time until the first
+// token arrived and the output tokens per second after it. Without
+// streaming the whole response arrives at once, so both cover the call.
+type CallSpeed struct {
+	FirstToken      time.Duration
+	TokensPerSecond float64
+}
+
+// newCallSpeed measures a call that started, produced its first token, and
+// finished at the given times; a zero firstToken means it was not streamed
+func newCallSpeed(started, firstToken, finished time.Time, outputTokens int) CallSpeed {
+	if firstToken.IsZero() {
+		firstToken = finished
+	}
+	speed := CallSpeed{FirstToken: firstToken.Sub(started)}
+	generating := finished.Sub(firstToken)
+	if generating <= 0 {
+		generating = finished.Sub(started)
+	}
+	if generating > 0 && outputTokens > 0 {
+		speed.TokensPerSecond = float64(outputTokens) / generating.Seconds()
+	}
+	return speed
+}
+
+// String describes the speed, such as "first token 0.8s · 85 tok/s"
+func (s CallSpeed) String() string {
+	if s.FirstToken == 0 && s.TokensPerSecond == 0 {
+		return ""
+	}
+	text := fmt.Sprintf("first token %.1fs", s.FirstToken.Seconds())
+	if s.TokensPerSecond > 0 {
+		text += fmt.Sprintf(" · %.0f tok/s", s.TokensPerSecond)
+	}
+	return text
+}
+
+// LastSpeed returns the speed of the latest provider call
+func (e *Engine) LastSpeed() CallSpeed {
+	return e.lastSpeed
+}
+
+// IterationSummary describes one iteration of the agent loop: a model
+// response and the tools it called
+type IterationSummary struct {
+	Time         time.Time     `json:"time"`
+	Session      string        `json:"session,omitempty"`
+	Iteration    int           `json:"iteration"`
+	Model        string        `json:"model,omitempty"`
+	Tools        []string      `json:"tools,omitempty"`
+	ToolErrors   int           `json:"tool_errors,omitempty"`
+	InputTokens  int           `json:"input_tokens"`
+	OutputTokens int           `json:"output_tokens"`
+	Elapsed      time.Duration `json:"-"`
+	StopReason   string        `json:"stop_reason,omitempty"`
+
+	// Speed of the provider call
+	Speed CallSpeed `json:"-"`
+}
+
+// MarshalJSON reports the elapsed time in milliseconds
+func (s IterationSummary) MarshalJSON() ([]byte, error) {
+	type alias IterationSummary
+	return json.Marshal(struct {
+		alias
+		ElapsedMS       int64   `json:"elapsed_ms"`
+		FirstTokenMS    int64   `json:"first_token_ms,omitempty"`
+		TokensPerSecond float64 `json:"tokens_per_second,omitempty"`
+	}{alias(s), s.Elapsed.Milliseconds(), s.Speed.FirstToken.Milliseconds(), s.Speed.TokensPerSecond})
+}
+
+// String describes the iteration on one line, such as
+// "#3 · Read, Edit (1 failed) · 1200 in / 340 out · 4.1s · first token 0.8s · 85 tok/s · tool_use"
+func (s IterationSummary) String() string {
+	parts := []string{fmt.Sprintf("#%d", s.Iteration)}
+	if len(s.Tools) > 0 {
+		tools := strings.Join(s.Tools, ", ")
+		if s.ToolErrors > 0 {
+			tools += fmt.Sprintf(" (%d failed)", s.ToolErrors)
+		}
+		parts = append(parts, tools)
+	}
+	parts = append(parts,
+		fmt.Sprintf("%d in / %d out", s.InputTokens, s.OutputTokens),
+		s.Elapsed.Round(100*time.Millisecond).String())
+	if speed := s.Speed.String(); speed != "" {
+		parts = append(parts, speed)
+	}
+	if s.StopReason != "" {
+		parts = append(parts, s.StopReason)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// endIteration reports the summary of an iteration to the callback and log
+func (e *Engine) endIteration(iteration int, started time.Time, resp *provider.Response, toolErrors int) {
+	if e.onIteration == nil && e.iterationLog == nil {
+		return
+	}
+	summary := IterationSummary{
+		Time:         started,
+		Iteration:    iteration,
+		Model:        resp.Model,
+		ToolErrors:   toolErrors,
+		InputTokens:  resp.Usage.InputTokens,
+		OutputTokens: resp.Usage.OutputTokens,
+		Elapsed:      time.Since(started),
+		StopReason:   string(resp.StopReason),
+		Speed:        e.lastSpeed,
+	}
+	if e.session != nil {
+		summary.Session = e.session.ID
+		if summary.Model == "" {
+			summary.Model = e.session.Model
+		}
+	}
+	for _, block := range resp.Content {
+		if b, ok := block.(*provider.ToolUseBlock); ok {
+			summary.Tools = append(summary.Tools, b.Name)
+		}
+	}
+
+	if e.onIteration != nil {
+		e.onIteration(summary)
+	}
+	if e.iterationLog != nil {
+		if data, err := json.Marshal(summary); err == nil {
+			e.iterationLog.Write(append(data, '\n'))
+		}
+	}
+}
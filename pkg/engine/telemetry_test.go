@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// scriptedProvider returns its responses in turn without streaming
+type scriptedProvider struct {
+	MockProvider
+	responses []*provider.Response
+}
+
+func (p *scriptedProvider) SupportsFeature(feature provider.Feature) bool {
+	return feature != provider.FeatureStreaming
+}
+
+func (p *scriptedProvider) CreateMessage(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	resp := p.responses[0]
+	p.responses = p.responses[1:]
+	return resp, nil
+}
+
+func TestIterationSummaries(t *testing.T) {
+	replayDelay = 0
+	prov := &scriptedProvider{responses: []*provider.Response{
+		{
+			Model:      "test-model",
+			StopReason: provider.StopReasonToolUse,
+			Content: []provider.ContentBlock{
+				&provider.ToolUseBlock{ID: "t1", Name: "Missing", Input: map[string]interface{}{}},
+			},
+			Usage: provider.Usage{InputTokens: 100, OutputTokens: 10},
+		},
+		{
+			Model:      "test-model",
+			StopReason: provider.StopReasonEndTurn,
+			Content:    []provider.ContentBlock{&provider.TextBlock{Text: "done"}},
+			Usage:      provider.Usage{InputTokens: 150, OutputTokens: 5},
+		},
+	}}
+	var log bytes.Buffer
+	eng := NewEngine(&EngineOptions{
+		Provider:     prov,
+		Registry:     tool.NewRegistry(),
+		Session:      session.NewSession(&session.SessionOptions{CWD: t.TempDir(), Model: "test-model"}),
+		IterationLog: &log,
+	})
+	var summaries []IterationSummary
+	var final string
+	eng.SetCallbacks(&CallbackOptions{
+		OnIteration: func(s IterationSummary) { summaries = append(summaries, s) },
+		OnTurnEnd:   func(text string) { final = text },
+	})
+
+	if err := eng.Run(context.Background(), "hello"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("expected 2 summaries, got %+v", summaries)
+	}
+	if final != "done" {
+		t.Errorf("expected the turn to end with %q, got %q", "done", final)
+	}
+	first := summaries[0]
+	if first.Iteration != 1 || len(first.Tools) != 1 || first.Tools[0] != "Missing" || first.ToolErrors != 1 {
+		t.Errorf("unexpected first summary: %+v", first)
+	}
+	if first.InputTokens != 100 || first.OutputTokens != 10 || first.StopReason != "tool_use" {
+		t.Errorf("unexpected first usage: %+v", first)
+	}
+	second := summaries[1]
+	if second.Iteration != 2 || len(second.Tools) != 0 || second.ToolErrors != 0 || second.StopReason != "end_turn" {
+		t.Errorf("unexpected second summary: %+v", second)
+	}
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %q", log.String())
+	}
+	var logged map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &logged); err != nil {
+		t.Fatalf("log line is not JSON: %v", err)
+	}
+	for _, key := range []string{"time", "iteration", "tools", "tool_errors", "input_tokens", "elapsed_ms", "stop_reason"} {
+		if _, ok := logged[key]; !ok {
+			t.Errorf("log line missing %q: %s", key, lines[0])
+		}
+	}
+}
+
+func TestIterationSummaryString(t *testing.T) {
+	s := IterationSummary{
+		Iteration:    3,
+		Tools:        []string{"Read", "Edit"},
+		ToolErrors:   1,
+		InputTokens:  1200,
+		OutputTokens: 340,
+		Elapsed:      4120 * time.Millisecond,
+		StopReason:   "tool_use",
+	}
+	want := "#3 · Read, Edit (1 failed) · 1200 in / 340 out · 4.1s · tool_use"
+	if got := s.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	s.Speed = CallSpeed{FirstToken: 820 * time.Millisecond, TokensPerSecond: 85.2}
+	want = "#3 · Read, Edit (1 failed) · 1200 in / 340 out · 4.1s · first token 0.8s · 85 tok/s · tool_use"
+	if got := s.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestNewCallSpeed(t *testing.T) {
+	start := time.Now()
+	streamed := newCallSpeed(start, start.Add(500*time.Millisecond), start.Add(2500*time.Millisecond), 100)
+	if streamed.FirstToken != 500*time.Millisecond || streamed.TokensPerSecond != 50 {
+		t.Errorf("unexpected streamed speed: %+v", streamed)
+	}
+	whole := newCallSpeed(start, time.Time{}, start.Add(4*time.Second), 100)
+	if whole.FirstToken != 4*time.Second || whole.TokensPerSecond != 25 {
+		t.Errorf("unexpected speed without streaming: %+v", whole)
+	}
+}
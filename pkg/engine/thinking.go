@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/session"
+)
+
+// thinkingBudgets are the thinking budgets in tokens of each level
+var thinkingBudgets = map[string]int{
+	"ultra":  31999,
+	"high":   10000,
+	"medium": 5000,
+	"low":    2000,
+}
+
+// thinkingTriggers map phrases in a prompt to the thinking level they ask
+// for, strongest first
+var thinkingTriggers = []struct {
+	pattern *regexp.Regexp
+	level   string
+}{
+	{regexp.MustCompile(`(?i)\bultrathink\b`), "ultra"},
+	{regexp.MustCompile(`(?i)\bthink (?:really |super |very )?harder\b`), "ultra"},
+	{regexp.MustCompile(`(?i)\bthink (?:really|super|very) hard\b`), "ultra"},
+	{regexp.MustCompile(`(?i)\bmegathink\b`), "high"},
+	{regexp.MustCompile(`(?i)\bthink (?:hard|deeply|a lot)\b`), "high"},
+	{regexp.MustCompile(`(?i)\bthink (?:carefully|step by step|it through)\b`), "medium"},
+}
+
+// ThinkingTriggers returns the thinking level a prompt asks for with phrases
+// such as "think hard" or "ultrathink", and the phrases found. The level is
+// "" when the prompt has none.
+func ThinkingTriggers(prompt string) (level string, triggers []string) {
+	for _, t := range thinkingTriggers {
+		match := t.pattern.FindString(prompt)
+		if match == "" {
+			continue
+		}
+		if level == "" {
+			level = t.level
+		}
+		triggers = append(triggers, strings.ToLower(match))
+	}
+	return level, triggers
+}
+
+// thinkingBudgetFor returns the thinking budget of a level
+func thinkingBudgetFor(level string) int {
+	if budget, ok := thinkingBudgets[level]; ok {
+		return budget
+	}
+	return thinkingBudgets["medium"]
+}
+
+// thinkingEnabled reports whether the current run uses extended thinking
+func (e *Engine) thinkingEnabled() bool {
+	if e.thinkingBudget > 0 || e.turnThinking != "" {
+		return true
+	}
+	return e.thinkingLevel != "" && e.thinkingLevel != "none"
+}
+
+// thinkingMetadata describes the thinking of the current run for the transcript
+func (e *Engine) thinkingMetadata(triggers []string) *session.ThinkingMetadata {
+	level := e.thinkingLevel
+	if e.turnThinking != "" && thinkingBudgetFor(e.turnThinking) > thinkingBudgetFor(level) {
+		level = e.turnThinking
+	}
+	return &session.ThinkingMetadata{
+		Level:    level,
+		Disabled: !e.thinkingEnabled(),
+		Triggers: triggers,
+	}
+}
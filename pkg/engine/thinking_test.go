@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// eventStream replays fixed streaming events
+type eventStream struct {
+	events []provider.StreamingEvent
+}
+
+func (s *eventStream) Recv() (provider.StreamingEvent, error) {
+	if len(s.events) == 0 {
+		return nil, io.EOF
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, nil
+}
+
+func (s *eventStream) Close() error { return nil }
+
+// eventProvider streams fixed events
+type eventProvider struct {
+	MockProvider
+	events []provider.StreamingEvent
+}
+
+func (p *eventProvider) CreateMessageStream(ctx context.Context, req *provider.Request) (provider.StreamReader, error) {
+	return &eventStream{events: p.events}, nil
+}
+
+func TestStreamedThinking(t *testing.T) {
+	prov := &eventProvider{events: []provider.StreamingEvent{
+		&provider.MessageStartEvent{Message: &provider.Response{ID: "msg"}},
+		&provider.ContentBlockStartEvent{Index: 0, ContentBlock: &provider.ThinkingBlock{}},
+		&provider.ContentBlockDeltaEvent{Index: 0, Delta: &provider.ThinkingDelta{Thinking: "first, "}},
+		&provider.ContentBlockDeltaEvent{Index: 0, Delta: &provider.ThinkingDelta{Thinking: "then"}},
+		&provider.ContentBlockDeltaEvent{Index: 0, Delta: &provider.SignatureDelta{Signature: "sig"}},
+		&provider.ContentBlockStopEvent{Index: 0},
+		&provider.ContentBlockStartEvent{Index: 1, ContentBlock: &provider.RedactedThinkingBlock{Data: "opaque"}},
+		&provider.ContentBlockStopEvent{Index: 1},
+		&provider.MessageDeltaEvent{Delta: &provider.MessageDelta{StopReason: provider.StopReasonEndTurn}},
+	}}
+	sess := session.NewSession(&session.SessionOptions{CWD: t.TempDir(), Model: "test-model"})
+	eng := NewEngine(&EngineOptions{Provider: prov, Registry: tool.NewRegistry(), Session: sess})
+
+	var pieces, done []string
+	eng.SetCallbacks(&CallbackOptions{
+		OnThinking:     func(text string) { pieces = append(pieces, text) },
+		OnThinkingDone: func(thinking string) { done = append(done, thinking) },
+	})
+	if err := eng.Run(context.Background(), "hello"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if strings.Join(pieces, "|") != "first, |then" {
+		t.Errorf("expected each streamed piece once, got %q", pieces)
+	}
+	if len(done) != 1 || done[0] != "first, then" || eng.LastThinking() != "first, then" {
+		t.Errorf("expected one complete thinking block, got %q (last %q)", done, eng.LastThinking())
+	}
+
+	content := sess.GetMessages()[1].Content
+	if tb, ok := content[0].(*provider.ThinkingBlock); !ok || tb.Signature != "sig" {
+		t.Errorf("expected signed thinking in history, got %#v", content[0])
+	}
+	if _, ok := content[1].(*provider.RedactedThinkingBlock); !ok {
+		t.Errorf("expected redacted thinking in history, got %#v", content[1])
+	}
+}
+
+func TestThinkingTriggers(t *testing.T) {
+	tests := []struct {
+		prompt string
+		level  string
+	}{
+		{"fix the bug", ""},
+		{"I think the bug is in parse", ""},
+		{"ultrathink about this design", "ultra"},
+		{"Think harder before answering", "ultra"},
+		{"think really hard about the race", "ultra"},
+		{"please think hard about it", "high"},
+		{"think step by step", "medium"},
+		{"think hard, no, ultrathink", "ultra"},
+	}
+	for _, tt := range tests {
+		level, triggers := ThinkingTriggers(tt.prompt)
+		if level != tt.level {
+			t.Errorf("ThinkingTriggers(%q) level = %q, want %q", tt.prompt, level, tt.level)
+		}
+		if (level == "") != (len(triggers) == 0) {
+			t.Errorf("ThinkingTriggers(%q) triggers = %v with level %q", tt.prompt, triggers, level)
+		}
+	}
+}
+
+func TestThinkingBudgetRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		level     string
+		budget    int
+		prompt    string
+		want      int // 0 = thinking disabled
+		maxTokens int
+	}{
+		{"disabled", "none", 0, "fix it", 0, 16384},
+		{"level", "low", 0, "fix it", 2000, 16384},
+		{"trigger enables thinking", "none", 0, "think hard about it", 10000, 16384},
+		{"trigger never lowers", "high", 0, "think step by step", 10000, 16384},
+		{"explicit budget", "low", 8000, "fix it", 8000, 16384},
+		{"ultrathink raises max tokens", "medium", 0, "ultrathink", 31999, 31999 + 16384},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eng := NewEngine(&EngineOptions{
+				Provider:       &MockProvider{},
+				Registry:       tool.NewRegistry(),
+				Session:        session.NewSession(&session.SessionOptions{CWD: t.TempDir(), Model: "test"}),
+				ThinkingLevel:  tt.level,
+				ThinkingBudget: tt.budget,
+			})
+			if err := eng.Run(context.Background(), tt.prompt); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+
+			req := eng.buildRequest()
+			if tt.want == 0 {
+				if req.Thinking != nil {
+					t.Errorf("expected thinking disabled, got %+v", req.Thinking)
+				}
+			} else if req.Thinking == nil || req.Thinking.BudgetTokens != tt.want {
+				t.Errorf("expected budget %d, got %+v", tt.want, req.Thinking)
+			}
+			if req.MaxTokens != tt.maxTokens {
+				t.Errorf("expected max tokens %d, got %d", tt.maxTokens, req.MaxTokens)
+			}
+
+			meta := eng.session.Messages[0].ThinkingMetadata
+			if meta == nil || meta.Disabled != (tt.want == 0) {
+				t.Errorf("unexpected thinking metadata %+v", meta)
+			}
+		})
+	}
+}
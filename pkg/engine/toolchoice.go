@@ -0,0 +1,20 @@
+package engine
+
+import (
+	"slices"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// applyFirstTool has the first request of a run call FirstTool, when the
+// prompt called for it and the tool is offered. Later requests keep the
+// configured tool choice, so the model is free to go on from there.
+func (e *Engine) applyFirstTool(req *provider.Request) {
+	if !e.forceFirstTool {
+		return
+	}
+	e.forceFirstTool = false
+	if slices.ContainsFunc(req.Tools, func(t provider.Tool) bool { return t.Name == e.firstTool }) {
+		req.ToolChoice = &provider.ToolChoice{Type: provider.ToolChoiceTool, Name: e.firstTool}
+	}
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestFirstTool(t *testing.T) {
+	replayDelay = 0
+	prov := &recordingProvider{name: "claude", scriptedProvider: scriptedProvider{responses: []*provider.Response{
+		{
+			StopReason: provider.StopReasonToolUse,
+			Content:    []provider.ContentBlock{&provider.ToolUseBlock{ID: "t1", Name: "TodoWrite", Input: map[string]interface{}{}}},
+		},
+		{StopReason: provider.StopReasonEndTurn, Content: []provider.ContentBlock{&provider.TextBlock{Text: "done"}}},
+		{StopReason: provider.StopReasonEndTurn, Content: []provider.ContentBlock{&provider.TextBlock{Text: "4"}}},
+	}}}
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{name: "TodoWrite", schema: json.RawMessage(`{"type":"object"}`)})
+	sess := session.NewSession(&session.SessionOptions{CWD: t.TempDir(), Model: "test-model"})
+	eng := NewEngine(&EngineOptions{
+		Provider:          prov,
+		Registry:          registry,
+		Session:           sess,
+		ThinkingLevel:     "high",
+		ToolChoice:        &provider.ToolChoice{Type: provider.ToolChoiceAuto},
+		FirstTool:         "TodoWrite",
+		FirstToolMinWords: 5,
+	})
+
+	if err := eng.Run(context.Background(), "refactor the parser and add tests for it"); err != nil {
+		t.Fatal(err)
+	}
+	if err := eng.Run(context.Background(), "what is 2+2?"); err != nil {
+		t.Fatal(err)
+	}
+	if len(prov.requests) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(prov.requests))
+	}
+
+	// Only the first request of a long enough prompt is forced
+	want := []provider.ToolChoice{
+		{Type: provider.ToolChoiceTool, Name: "TodoWrite"},
+		{Type: provider.ToolChoiceAuto},
+		{Type: provider.ToolChoiceAuto},
+	}
+	for i, req := range prov.requests {
+		if req.ToolChoice == nil || *req.ToolChoice != want[i] {
+			t.Errorf("request %d: tool choice = %+v, want %+v", i, req.ToolChoice, want[i])
+		}
+		if !req.ToolChoice.Forced() && req.Thinking == nil {
+			t.Errorf("request %d: expected thinking", i)
+		}
+	}
+}
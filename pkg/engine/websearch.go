@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// webSearchTool is the local tool that native web search replaces
+const webSearchTool = "WebSearch"
+
+// useNativeWebSearch reports whether requests have the provider search the
+// web itself instead of offering the WebSearch tool
+func (e *Engine) useNativeWebSearch() bool {
+	return slices.Contains(e.nativeWebSearch, e.provider.Name()) &&
+		e.provider.SupportsFeature(provider.FeatureWebSearch)
+}
+
+// FormatSources renders the pages that informed an answer as a numbered list
+func FormatSources(sources []provider.Citation) string {
+	var sb strings.Builder
+	sb.WriteString("Sources:")
+	for i, c := range sources {
+		title := c.Title
+		if title == "" {
+			title = c.URL
+		}
+		fmt.Fprintf(&sb, "\n  [%d] %s", i+1, title)
+		if title != c.URL {
+			fmt.Fprintf(&sb, " - %s", c.URL)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// searchProvider streams scripted events and records the request
+type searchProvider struct {
+	MockProvider
+	events []provider.StreamingEvent
+	req    *provider.Request
+}
+
+func (p *searchProvider) Name() string { return "claude" }
+
+func (p *searchProvider) CreateMessageStream(ctx context.Context, req *provider.Request) (provider.StreamReader, error) {
+	p.req = req
+	return &scriptedStream{events: p.events}, nil
+}
+
+type scriptedStream struct {
+	events []provider.StreamingEvent
+}
+
+func (s *scriptedStream) Recv() (provider.StreamingEvent, error) {
+	if len(s.events) == 0 {
+		return nil, io.EOF
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, nil
+}
+
+func (s *scriptedStream) Close() error { return nil }
+
+func TestNativeWebSearch(t *testing.T) {
+	citation := provider.Citation{URL: "https://go.dev/doc/go1.24", Title: "Go 1.24 Release Notes"}
+	prov := &searchProvider{events: []provider.StreamingEvent{
+		&provider.MessageStartEvent{Message: &provider.Response{}},
+		// The search the provider runs itself is not a block the engine keeps
+		&provider.ContentBlockStartEvent{Index: 0, ContentBlock: nil},
+		&provider.ToolInfoEvent{Name: "WebSearch", Input: map[string]interface{}{"query": "go 1.24"}},
+		&provider.ContentBlockStartEvent{Index: 1, ContentBlock: &provider.TextBlock{}},
+		&provider.ContentBlockDeltaEvent{Index: 1, Delta: &provider.TextDelta{Text: "Go 1.24 adds generic type aliases."}},
+		&provider.ContentBlockDeltaEvent{Index: 1, Delta: &provider.CitationsDelta{Citation: citation}},
+		&provider.ContentBlockDeltaEvent{Index: 1, Delta: &provider.CitationsDelta{Citation: citation}},
+		&provider.ContentBlockStopEvent{Index: 1},
+		&provider.MessageDeltaEvent{Delta: &provider.MessageDelta{StopReason: provider.StopReasonEndTurn}},
+	}}
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{name: "WebSearch", schema: json.RawMessage(`{"type":"object"}`)})
+	registry.Register(&MockTool{name: "Read", schema: json.RawMessage(`{"type":"object"}`)})
+	sess := session.NewSession(&session.SessionOptions{CWD: t.TempDir(), Model: "test-model"})
+
+	eng := NewEngine(&EngineOptions{
+		Provider:        prov,
+		Registry:        registry,
+		Session:         sess,
+		NativeWebSearch: []string{"claude"},
+	})
+	var sources []provider.Citation
+	var searches []string
+	eng.SetCallbacks(&CallbackOptions{
+		OnSources: func(citations []provider.Citation) { sources = citations },
+		OnExternalToolUse: func(name string, input map[string]interface{}) {
+			searches = append(searches, input["query"].(string))
+		},
+	})
+
+	if err := eng.Run(context.Background(), "What is new in Go 1.24?"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !prov.req.WebSearch {
+		t.Error("expected the request to use the provider's web search")
+	}
+	for _, tl := range prov.req.Tools {
+		if tl.Name == "WebSearch" {
+			t.Error("expected the WebSearch tool to be left out")
+		}
+	}
+	if len(searches) != 1 || searches[0] != "go 1.24" {
+		t.Errorf("searches = %v", searches)
+	}
+	if len(sources) != 1 || sources[0] != citation {
+		t.Errorf("sources = %v", sources)
+	}
+
+	// The text after the skipped block is kept with its citations
+	msgs := sess.GetMessages()
+	text, ok := msgs[len(msgs)-1].Content[0].(*provider.TextBlock)
+	if !ok || text.Text != "Go 1.24 adds generic type aliases." || len(text.Citations) != 2 {
+		t.Errorf("unexpected assistant message: %#v", msgs[len(msgs)-1].Content)
+	}
+
+	formatted := FormatSources(sources)
+	if !strings.Contains(formatted, "[1] Go 1.24 Release Notes - https://go.dev/doc/go1.24") {
+		t.Errorf("unexpected sources:\n%s", formatted)
+	}
+}
+
+func TestNativeWebSearchOtherProvider(t *testing.T) {
+	prov := &searchProvider{events: []provider.StreamingEvent{
+		&provider.MessageStartEvent{Message: &provider.Response{}},
+		&provider.MessageDeltaEvent{Delta: &provider.MessageDelta{StopReason: provider.StopReasonEndTurn}},
+	}}
+	registry := tool.NewRegistry()
+	registry.Register(&MockTool{name: "WebSearch", schema: json.RawMessage(`{"type":"object"}`)})
+	sess := session.NewSession(&session.SessionOptions{CWD: t.TempDir(), Model: "test-model"})
+
+	eng := NewEngine(&EngineOptions{
+		Provider:        prov,
+		Registry:        registry,
+		Session:         sess,
+		NativeWebSearch: []string{"gemini"},
+	})
+	if err := eng.Run(context.Background(), "Search for it"); err != nil {
+		t.Fatal(err)
+	}
+	if prov.req.WebSearch || len(prov.req.Tools) != 1 {
+		t.Errorf("expected the local WebSearch tool, got web search %v with tools %v", prov.req.WebSearch, prov.req.Tools)
+	}
+}
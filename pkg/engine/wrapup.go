@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// wrapUpPrompt asks for a final report when a run reaches the iteration
+// limit. It is added to the session with the reply, so the next prompt
+// starts from the report.
+const wrapUpPrompt = `[System notice] This run has reached its iteration limit and stops after
+this reply. Summarize what you did, what remains to be done, and anything
+needed to pick the work up again: open problems, assumptions, next steps.
+Reply in plain text. Do not call tools.`
+
+// wrapUpMaxTokens bounds the length of the wrap-up report
+const wrapUpMaxTokens = 2048
+
+// wrapUp ends a run that reached the iteration limit with one more
+// request, asking the model to summarize what it did and what remains,
+// so that a truncated run still leaves a useful state. The summary is
+// shown, passed to OnWrapUp and carried by the returned error.
+func (e *Engine) wrapUp(ctx context.Context) error {
+	limitErr := &MaxIterationsError{Limit: e.maxIterations}
+	if ctx.Err() != nil || e.checkBudget() != nil {
+		return limitErr
+	}
+
+	req := e.buildRequest()
+	req.Stream = false
+	req.Thinking = nil
+	req.ToolChoice = nil
+	req.MaxTokens = wrapUpMaxTokens
+	req.Messages = withNotice(req.Messages, wrapUpPrompt)
+
+	resp, err := e.callProvider(ctx, req)
+	if err != nil {
+		if e.onError != nil {
+			e.onError(err)
+		}
+		return limitErr
+	}
+	summary := responseText(resp)
+	if summary == "" {
+		return limitErr
+	}
+
+	// Only the text is kept: tool calls would have no results
+	e.session.AddNotice(wrapUpPrompt)
+	reply := *resp
+	reply.Content = []provider.ContentBlock{&provider.TextBlock{Text: summary}}
+	e.session.AddAssistantMessage(&reply)
+
+	if e.onText != nil {
+		e.onText("\n" + summary + "\n")
+	}
+	if e.onWrapUp != nil {
+		e.onWrapUp(summary)
+	}
+	limitErr.Summary = summary
+	return limitErr
+}
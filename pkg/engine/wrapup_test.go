@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+func TestWrapUpAtIterationLimit(t *testing.T) {
+	toolUse := &provider.Response{StopReason: provider.StopReasonToolUse, Content: []provider.ContentBlock{
+		&provider.ToolUseBlock{ID: "1", Name: "Missing", Input: map[string]interface{}{}},
+	}}
+	prov := &MockProvider{responses: []*provider.Response{
+		toolUse,
+		{StopReason: provider.StopReasonToolUse, Content: []provider.ContentBlock{
+			&provider.TextBlock{Text: "Renamed pkg/foo; the callers remain."},
+			&provider.ToolUseBlock{ID: "2", Name: "Missing", Input: map[string]interface{}{}},
+		}},
+	}}
+	eng, sess := newAutonomousEngine(prov)
+	eng.maxIterations = 1
+
+	var wrapUp string
+	eng.SetCallbacks(&CallbackOptions{OnWrapUp: func(summary string) { wrapUp = summary }})
+
+	err := eng.Run(context.Background(), "Rename pkg/foo")
+	var limitErr *MaxIterationsError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("expected the iteration limit error, got %v", err)
+	}
+	if limitErr.Summary != "Renamed pkg/foo; the callers remain." || wrapUp != limitErr.Summary {
+		t.Errorf("unexpected summary %q, callback %q", limitErr.Summary, wrapUp)
+	}
+
+	messages := sess.GetMessages()
+	last := messages[len(messages)-1]
+	if last.Role != provider.RoleAssistant || len(last.Content) != 1 {
+		t.Fatalf("expected the wrap-up reply without tool calls, got %+v", last)
+	}
+	notice := messages[len(messages)-2].Content
+	if text, ok := notice[len(notice)-1].(*provider.TextBlock); !ok || !strings.Contains(text.Text, "iteration limit") {
+		t.Errorf("expected the wrap-up request in the session, got %+v", notice)
+	}
+}
+
+func TestWrapUpFailureKeepsLimitError(t *testing.T) {
+	prov := &MockProvider{responses: []*provider.Response{
+		{StopReason: provider.StopReasonToolUse, Content: []provider.ContentBlock{
+			&provider.ToolUseBlock{ID: "1", Name: "Missing", Input: map[string]interface{}{}},
+		}},
+		{StopReason: provider.StopReasonEndTurn},
+	}}
+	eng, sess := newAutonomousEngine(prov)
+	eng.maxIterations = 1
+
+	err := eng.Run(context.Background(), "Rename pkg/foo")
+	var limitErr *MaxIterationsError
+	if !errors.As(err, &limitErr) || limitErr.Summary != "" {
+		t.Fatalf("expected the iteration limit error without a summary, got %v", err)
+	}
+	if n := len(sess.GetMessages()); n != 3 {
+		t.Errorf("expected nothing added for an empty wrap-up, got %d messages", n)
+	}
+}
@@ -0,0 +1,307 @@
+// Package enginetest runs the agent loop against scripted provider
+// responses, for end-to-end tests of the engine and of tools. A Scenario
+// lists the prompts, the responses the model gives and the tools it may
+// call; Run plays it and returns the session, the requests the engine sent
+// and a transcript that can be compared with a golden file:
+//
+//	result := enginetest.Run(t, enginetest.Scenario{
+//		Prompts: []string{"Count the lines of a.txt"},
+//		Tools:   []tool.Tool{myTool},
+//		Responses: []*provider.Response{
+//			enginetest.ToolUse(enginetest.Call{Name: "MyTool", Input: map[string]interface{}{"path": "a.txt"}}),
+//			enginetest.Text("a.txt has 3 lines"),
+//		},
+//	})
+//	enginetest.Golden(t, "testdata/count.golden", result.Transcript)
+//
+// Golden files are written instead of compared when ENGINETEST_UPDATE=1.
+package enginetest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/engine"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// UpdateEnv is the environment variable that makes Golden write golden
+// files instead of comparing with them
+const UpdateEnv = "ENGINETEST_UPDATE"
+
+// Scenario is a scripted conversation with the engine
+type Scenario struct {
+	// Prompts are sent one after another; each runs the loop until the
+	// model ends its turn or the run fails
+	Prompts []string
+
+	// Responses are what the model replies to each request, in order. A
+	// request after the last one fails the run.
+	Responses []*provider.Response
+
+	// Tools the model may call
+	Tools []tool.Tool
+
+	// Options adjusts the engine options, such as the iteration limit or
+	// the path scope, before the engine is created
+	Options func(opts *engine.EngineOptions)
+
+	// Setup adjusts the engine before the first prompt, for example to
+	// register hooks or callbacks
+	Setup func(eng *engine.Engine)
+
+	// BeforePrompt runs before each prompt after the first, for example
+	// to compact the session
+	BeforePrompt func(eng *engine.Engine, prompt int)
+}
+
+// Result is the outcome of a scenario
+type Result struct {
+	Engine  *engine.Engine
+	Session *session.Session
+
+	// Errors holds the error of each prompt's run, nil when it succeeded
+	Errors []error
+
+	// Requests are the requests the engine sent, in order
+	Requests []*provider.Request
+
+	// Unused counts the responses the engine did not ask for
+	Unused int
+
+	// Transcript is the conversation in the session, see Transcript
+	Transcript string
+}
+
+// Run plays a scenario in a fresh session whose working directory is a
+// temporary directory
+func Run(t testing.TB, sc Scenario) *Result {
+	t.Helper()
+
+	prov := NewProvider(sc.Responses...)
+	registry := tool.NewRegistry()
+	for _, tl := range sc.Tools {
+		registry.Register(tl)
+	}
+	sess := session.NewSession(&session.SessionOptions{CWD: t.TempDir(), Model: "test-model"})
+
+	opts := &engine.EngineOptions{
+		Provider: prov,
+		Registry: registry,
+		Session:  sess,
+	}
+	if sc.Options != nil {
+		sc.Options(opts)
+	}
+	eng := engine.NewEngine(opts)
+	if sc.Setup != nil {
+		sc.Setup(eng)
+	}
+
+	result := &Result{Engine: eng, Session: sess}
+	for i, prompt := range sc.Prompts {
+		if i > 0 && sc.BeforePrompt != nil {
+			sc.BeforePrompt(eng, i)
+		}
+		result.Errors = append(result.Errors, eng.Run(context.Background(), prompt))
+	}
+	result.Requests = prov.Requests()
+	result.Unused = prov.Remaining()
+	result.Transcript = Transcript(sess)
+	return result
+}
+
+// Provider replies to requests with scripted responses and records the
+// requests. It does not stream, so the engine asks for complete responses.
+type Provider struct {
+	mu        sync.Mutex
+	responses []*provider.Response
+	requests  []*provider.Request
+}
+
+// NewProvider creates a provider that replies with responses in order
+func NewProvider(responses ...*provider.Response) *Provider {
+	return &Provider{responses: responses}
+}
+
+func (p *Provider) Name() string { return "enginetest" }
+
+func (p *Provider) SupportedModels() []string { return []string{"test-model"} }
+
+func (p *Provider) SupportsFeature(feature provider.Feature) bool {
+	return feature == provider.FeatureToolUse
+}
+
+func (p *Provider) CreateMessage(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, req)
+	if len(p.responses) == 0 {
+		return nil, fmt.Errorf("enginetest: no response scripted for request %d", len(p.requests))
+	}
+	resp := p.responses[0]
+	p.responses = p.responses[1:]
+	return resp, nil
+}
+
+func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Request) (provider.StreamReader, error) {
+	return nil, fmt.Errorf("enginetest: streaming is not supported")
+}
+
+// Requests returns the requests received so far
+func (p *Provider) Requests() []*provider.Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*provider.Request(nil), p.requests...)
+}
+
+// Remaining returns how many scripted responses have not been used
+func (p *Provider) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.responses)
+}
+
+// Text is a response that ends the turn with text
+func Text(text string) *provider.Response {
+	return &provider.Response{
+		StopReason: provider.StopReasonEndTurn,
+		Content:    []provider.ContentBlock{&provider.TextBlock{Text: text}},
+	}
+}
+
+// Call is a tool call in a scripted response
+type Call struct {
+	Name  string
+	Input map[string]interface{}
+}
+
+// ToolUse is a response that calls tools, with IDs numbered from the
+// first call of the response
+func ToolUse(calls ...Call) *provider.Response {
+	resp := &provider.Response{StopReason: provider.StopReasonToolUse}
+	for i, c := range calls {
+		input := c.Input
+		if input == nil {
+			input = map[string]interface{}{}
+		}
+		resp.Content = append(resp.Content, &provider.ToolUseBlock{
+			ID:    fmt.Sprintf("call_%s_%d", c.Name, i+1),
+			Name:  c.Name,
+			Input: input,
+		})
+	}
+	return resp
+}
+
+// Func is a tool that runs a function, for scenarios that need a tool
+// without writing a type for it
+type Func struct {
+	ToolName string
+	Run      func(input map[string]interface{}) (*tool.Output, error)
+}
+
+func (f *Func) Name() string { return f.ToolName }
+
+func (f *Func) Description() string { return "Test tool " + f.ToolName }
+
+func (f *Func) InputSchema() json.RawMessage {
+	return json.RawMessage(`{"type": "object", "properties": {}}`)
+}
+
+func (f *Func) Validate(input *tool.Input) error { return nil }
+
+func (f *Func) Execute(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+	return f.Run(input.Params)
+}
+
+// resultTags are the tags the engine wraps tool results in, with a random
+// nonce per session
+var resultTags = regexp.MustCompile(`</?tool-result-[0-9a-f]+>\n?`)
+
+// Transcript renders the conversation in a session as text that does not
+// change from run to run: one line per text, tool call and tool result,
+// marked ">" for the user's side and "<" for the model's. Tool results
+// lose the tags the engine wraps them in.
+func Transcript(sess *session.Session) string {
+	var b strings.Builder
+	names := make(map[string]string)
+	for _, msg := range sess.GetMessages() {
+		side := "<"
+		if msg.Role == provider.RoleUser {
+			side = ">"
+		}
+		for _, block := range msg.Content {
+			switch bl := block.(type) {
+			case *provider.TextBlock:
+				fmt.Fprintf(&b, "%s %s: %s\n", side, msg.Role, indent(bl.Text))
+			case *provider.ThinkingBlock:
+				fmt.Fprintf(&b, "%s thinking: %s\n", side, indent(bl.Thinking))
+			case *provider.ToolUseBlock:
+				names[bl.ID] = bl.Name
+				fmt.Fprintf(&b, "%s tool_use %s %s\n", side, bl.Name, formatInput(bl.Input))
+			case *provider.ToolResultBlock:
+				label := "tool_result " + names[bl.ToolUseID]
+				if bl.IsError {
+					label += " (error)"
+				}
+				content := strings.TrimSpace(resultTags.ReplaceAllString(bl.Content, ""))
+				fmt.Fprintf(&b, "%s %s: %s\n", side, label, indent(content))
+			}
+		}
+	}
+	return b.String()
+}
+
+// formatInput writes tool input as JSON with sorted keys
+func formatInput(input map[string]interface{}) string {
+	keys := make([]string, 0, len(input))
+	for k := range input {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		v, _ := json.Marshal(input[k])
+		parts[i] = fmt.Sprintf("%q: %s", k, v)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// indent keeps the lines of multi-line text under their entry
+func indent(text string) string {
+	return strings.ReplaceAll(strings.TrimSpace(text), "\n", "\n    ")
+}
+
+// Golden compares got with the golden file at path, or writes it when
+// ENGINETEST_UPDATE=1
+func Golden(t testing.TB, path, got string) {
+	t.Helper()
+	if os.Getenv(UpdateEnv) == "1" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with %s=1 to create it): %v", UpdateEnv, err)
+	}
+	if string(want) != got {
+		t.Errorf("transcript differs from %s (run with %s=1 to update):\n--- got\n%s--- want\n%s", path, UpdateEnv, got, want)
+	}
+}
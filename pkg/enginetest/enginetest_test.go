@@ -0,0 +1,162 @@
+package enginetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/engine"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/session"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestToolRetry(t *testing.T) {
+	attempts := 0
+	flaky := &Func{ToolName: "Fetch", Run: func(input map[string]interface{}) (*tool.Output, error) {
+		attempts++
+		if attempts == 1 {
+			return &tool.Output{Content: "connection reset", IsError: true}, nil
+		}
+		return &tool.Output{Content: "200 OK\nhello"}, nil
+	}}
+
+	result := Run(t, Scenario{
+		Prompts: []string{"Fetch the greeting"},
+		Tools:   []tool.Tool{flaky},
+		Responses: []*provider.Response{
+			ToolUse(Call{Name: "Fetch", Input: map[string]interface{}{"url": "http://example.test"}}),
+			ToolUse(Call{Name: "Fetch", Input: map[string]interface{}{"url": "http://example.test"}}),
+			Text("The greeting is hello."),
+		},
+	})
+	if result.Errors[0] != nil {
+		t.Fatal(result.Errors[0])
+	}
+	if attempts != 2 || result.Unused != 0 {
+		t.Errorf("attempts = %d, unused responses = %d", attempts, result.Unused)
+	}
+	Golden(t, "testdata/tool_retry.golden", result.Transcript)
+}
+
+func TestHookBlocksTool(t *testing.T) {
+	ran := false
+	remove := &Func{ToolName: "Remove", Run: func(input map[string]interface{}) (*tool.Output, error) {
+		ran = true
+		return &tool.Output{Content: "removed"}, nil
+	}}
+
+	result := Run(t, Scenario{
+		Prompts: []string{"Clean up the build directory"},
+		Tools:   []tool.Tool{remove},
+		Responses: []*provider.Response{
+			ToolUse(Call{Name: "Remove", Input: map[string]interface{}{"path": "/"}}),
+			Text("I was not allowed to remove /."),
+		},
+		Setup: func(eng *engine.Engine) {
+			eng.Hooks().RegisterPreToolUse(func(ctx context.Context, name string, input map[string]interface{}) *engine.HookResult {
+				if input["path"] == "/" {
+					return &engine.HookResult{Blocked: true, Message: "refusing to remove /"}
+				}
+				return nil
+			})
+		},
+	})
+	if result.Errors[0] != nil {
+		t.Fatal(result.Errors[0])
+	}
+	if ran {
+		t.Error("blocked tool ran")
+	}
+	Golden(t, "testdata/hook_block.golden", result.Transcript)
+}
+
+func TestCompactionBetweenPrompts(t *testing.T) {
+	var prompts []string
+	for i := 0; i < 4; i++ {
+		prompts = append(prompts, "Next step")
+	}
+	var responses []*provider.Response
+	for range prompts {
+		responses = append(responses, Text("Done."))
+	}
+
+	var compacted *session.CompactResult
+	result := Run(t, Scenario{
+		Prompts:   prompts,
+		Responses: responses,
+		BeforePrompt: func(eng *engine.Engine, prompt int) {
+			if prompt == 3 {
+				compacted = eng.Session().Compact(&session.CompactOptions{KeepRecentMessages: 2})
+			}
+		},
+	})
+	for i, err := range result.Errors {
+		if err != nil {
+			t.Fatalf("prompt %d: %v", i, err)
+		}
+	}
+	if compacted == nil || compacted.RemainingMessages >= compacted.OriginalMessages {
+		t.Fatalf("expected the session to be compacted, got %+v", compacted)
+	}
+
+	// The last request is built from the compacted session
+	last := result.Requests[len(result.Requests)-1]
+	if len(last.Messages) != compacted.RemainingMessages+1 {
+		t.Errorf("last request has %d messages, want %d", len(last.Messages), compacted.RemainingMessages+1)
+	}
+	Golden(t, "testdata/compaction.golden", result.Transcript)
+}
+
+func TestScriptExhausted(t *testing.T) {
+	result := Run(t, Scenario{Prompts: []string{"Hello"}})
+	if result.Errors[0] == nil {
+		t.Fatal("expected the run to fail without responses")
+	}
+	if len(result.Requests) == 0 {
+		t.Error("expected the request to be recorded")
+	}
+
+	var limitErr *engine.MaxIterationsError
+	if errors.As(result.Errors[0], &limitErr) {
+		t.Errorf("unexpected iteration limit error %v", limitErr)
+	}
+}
+
+func TestResponseCacheRerun(t *testing.T) {
+	dir, cwd := t.TempDir(), t.TempDir()
+	read := &Func{ToolName: "Read", Run: func(input map[string]interface{}) (*tool.Output, error) {
+		return &tool.Output{Content: "package main"}, nil
+	}}
+	var cache *provider.CachedProvider
+	run := func() *Result {
+		return Run(t, Scenario{
+			Prompts: []string{"What package is main.go in?"},
+			Tools:   []tool.Tool{read},
+			Responses: []*provider.Response{
+				ToolUse(Call{Name: "Read", Input: map[string]interface{}{"path": "main.go"}}),
+				Text("It is in package main."),
+			},
+			Options: func(opts *engine.EngineOptions) {
+				// A rerun works in the same directory
+				opts.Session = session.NewSession(&session.SessionOptions{CWD: cwd, Model: "test-model"})
+				cache = provider.NewCachedProvider(opts.Provider, dir)
+				opts.Provider = cache
+			},
+		})
+	}
+
+	if result := run(); result.Errors[0] != nil || result.Unused != 0 {
+		t.Fatalf("first run: %v, %d unused responses", result.Errors[0], result.Unused)
+	}
+
+	// A rerun in a new session, with a new nonce in its tool result tags,
+	// is answered from the cache
+	result := run()
+	if result.Errors[0] != nil {
+		t.Fatal(result.Errors[0])
+	}
+	if hits, misses := cache.Stats(); hits != 2 || misses != 0 || result.Unused != 2 {
+		t.Errorf("expected both requests from the cache, got hits=%d misses=%d unused=%d", hits, misses, result.Unused)
+	}
+}
@@ -0,0 +1,290 @@
+// Package gateway serves an OpenAI-compatible chat completions API backed
+// by the configured providers, so other local tools can share their
+// credentials, retries, and usage ledger
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/cost"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// maxBodySize bounds a request body
+const maxBodySize = 32 << 20
+
+// retryDelay is the wait before the first retry, doubled for each one after
+var retryDelay = time.Second
+
+// maxRetryDelay caps the wait between retries
+const maxRetryDelay = 30 * time.Second
+
+// Route returns the provider serving a requested model and the model ID to
+// send it
+type Route func(model string) (provider.AIProvider, string, error)
+
+// Options configure a gateway server
+type Options struct {
+	Route        Route
+	DefaultModel string       // used when a request names no model
+	Models       []string     // listed by /v1/models
+	Token        string       // bearer token clients must send, "" for local non-browser clients only
+	Retries      int          // retries of failed provider calls
+	Ledger       *cost.Ledger // records the usage of each response, may be nil
+	Project      string       // project recorded in the ledger
+	Log          func(Entry)  // called after each completion, may be nil
+}
+
+// Entry describes a served completion
+type Entry struct {
+	Model        string
+	Provider     string
+	Stream       bool
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+	Retries      int
+	Duration     time.Duration
+	Err          error
+}
+
+// Server handles OpenAI-compatible requests
+type Server struct {
+	opts  Options
+	mux   *http.ServeMux
+	seq   atomic.Int64
+	spent atomic.Uint64 // total cost in micro-dollars
+}
+
+// NewServer creates a gateway server
+func NewServer(opts Options) *Server {
+	s := &Server{opts: opts, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/v1/chat/completions", s.handleChat)
+	s.mux.HandleFunc("/v1/models", s.handleModels)
+	return s
+}
+
+// Spent returns the estimated cost of the completions served so far
+func (s *Server) Spent() float64 {
+	return float64(s.spent.Load()) / 1e6
+}
+
+// ServeHTTP checks the token and dispatches the request. Without a token,
+// it refuses browsers and requests addressed to other hosts: any web page
+// can post to a local port, and with DNS rebinding read the replies.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Token != "" {
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid_api_key", "missing or invalid bearer token")
+			return
+		}
+	} else if r.Header.Get("Origin") != "" {
+		writeError(w, http.StatusForbidden, "forbidden", "requests from web pages need the gateway to have a token")
+		return
+	} else if !isLocalHost(r.Host) {
+		writeError(w, http.StatusForbidden, "forbidden", "requests for hosts other than localhost need the gateway to have a token")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// isLocalHost reports whether a Host header names this machine
+func isLocalHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// handleModels lists the models the gateway routes
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "use GET")
+		return
+	}
+	type model struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		OwnedBy string `json:"owned_by"`
+	}
+	list := struct {
+		Object string  `json:"object"`
+		Data   []model `json:"data"`
+	}{Object: "list", Data: []model{}}
+	for _, id := range s.opts.Models {
+		list.Data = append(list.Data, model{ID: id, Object: "model", OwnedBy: string(provider.DetectProviderFromModel(id))})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// handleChat serves a chat completion
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "use POST")
+		return
+	}
+	var in chatRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "invalid JSON body: "+err.Error())
+		return
+	}
+	name := in.Model
+	if name == "" {
+		name = s.opts.DefaultModel
+	}
+	prov, model, err := s.opts.Route(name)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "model_not_found", err.Error())
+		return
+	}
+	req, err := toRequest(&in, model)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	entry := Entry{Model: model, Provider: prov.Name(), Stream: in.Stream}
+	start := time.Now()
+	id := fmt.Sprintf("chatcmpl-%d%d", start.UnixNano(), s.seq.Add(1))
+	var usage provider.Usage
+	if in.Stream {
+		includeUsage := in.StreamOptions != nil && in.StreamOptions.IncludeUsage
+		usage, entry.Retries, entry.Err = s.stream(r.Context(), w, prov, req, id, name, includeUsage)
+	} else {
+		var resp *provider.Response
+		entry.Retries, entry.Err = s.retry(r.Context(), prov, func(ctx context.Context) error {
+			var err error
+			resp, err = prov.CreateMessage(ctx, req)
+			return err
+		})
+		if entry.Err != nil {
+			writeError(w, http.StatusBadGateway, "upstream_error", entry.Err.Error())
+		} else {
+			usage = resp.Usage
+			writeJSON(w, http.StatusOK, fromResponse(resp, id, name, start.Unix()))
+		}
+	}
+
+	entry.InputTokens, entry.OutputTokens = usage.InputTokens, usage.OutputTokens
+	entry.Cost = s.record(prov.Name(), model, usage)
+	entry.Duration = time.Since(start)
+	if s.opts.Log != nil {
+		s.opts.Log(entry)
+	}
+}
+
+// record adds the usage of a response to the ledger and returns its cost
+func (s *Server) record(providerName, model string, usage provider.Usage) float64 {
+	if usage.InputTokens == 0 && usage.OutputTokens == 0 {
+		return 0
+	}
+	spent := cost.ModelCost(model, int64(usage.InputTokens), int64(usage.OutputTokens))
+	s.spent.Add(uint64(spent * 1e6))
+	if s.opts.Ledger != nil {
+		// The ledger is informational; a failure must not fail the request
+		_ = s.opts.Ledger.Record(cost.UsageRecord{
+			Provider:     providerName,
+			Model:        model,
+			Project:      s.opts.Project,
+			Session:      "gateway",
+			InputTokens:  int64(usage.InputTokens),
+			OutputTokens: int64(usage.OutputTokens),
+			Cost:         spent,
+		})
+	}
+	return spent
+}
+
+// retry calls fn until it succeeds, fails with an error that retrying
+// cannot fix, or runs out of retries, and returns the number of retries
+func (s *Server) retry(ctx context.Context, prov provider.AIProvider, fn func(context.Context) error) (int, error) {
+	for attempt := 0; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= s.opts.Retries || !retryable(err) || ctx.Err() != nil {
+			return attempt, err
+		}
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(backoff(prov, attempt)):
+		}
+	}
+}
+
+// backoff returns the wait before a retry, preferring the provider's
+// Retry-After
+func backoff(prov provider.AIProvider, attempt int) time.Duration {
+	if reporter, ok := prov.(provider.RateLimitReporter); ok {
+		if limits := reporter.RateLimits(); limits != nil && limits.RetryAfter > 0 {
+			return min(limits.RetryAfter, maxRetryDelay)
+		}
+	}
+	return min(retryDelay<<attempt, maxRetryDelay)
+}
+
+// statusPattern finds the HTTP status in a provider error
+var statusPattern = regexp.MustCompile(`status (\d{3})`)
+
+// retryable reports whether a failed call may succeed when repeated:
+// rate limits, server errors, and dropped connections
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if m := statusPattern.FindStringSubmatch(err.Error()); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		return status == http.StatusTooManyRequests || status == http.StatusRequestTimeout || status >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "connection reset") || strings.Contains(msg, "overloaded")
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// apiError is an OpenAI error body
+type apiError struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    string `json:"code,omitempty"`
+	} `json:"error"`
+}
+
+// writeError writes an error in the OpenAI format
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	var body apiError
+	body.Error.Message = message
+	body.Error.Type = "invalid_request_error"
+	if status >= 500 {
+		body.Error.Type = "api_error"
+	}
+	body.Error.Code = code
+	writeJSON(w, status, body)
+}
@@ -0,0 +1,326 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/cost"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// fakeProvider answers with a fixed response after failing a number of calls
+type fakeProvider struct {
+	fail     []error
+	response *provider.Response
+	events   []provider.StreamingEvent
+	requests []*provider.Request
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) CreateMessage(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	p.requests = append(p.requests, req)
+	if len(p.fail) > 0 {
+		err := p.fail[0]
+		p.fail = p.fail[1:]
+		return nil, err
+	}
+	return p.response, nil
+}
+
+func (p *fakeProvider) CreateMessageStream(ctx context.Context, req *provider.Request) (provider.StreamReader, error) {
+	p.requests = append(p.requests, req)
+	return &fakeStream{events: p.events}, nil
+}
+
+func (p *fakeProvider) SupportedModels() []string { return nil }
+
+func (p *fakeProvider) SupportsFeature(feature provider.Feature) bool { return true }
+
+type fakeStream struct {
+	events []provider.StreamingEvent
+}
+
+func (s *fakeStream) Recv() (provider.StreamingEvent, error) {
+	if len(s.events) == 0 {
+		return nil, io.EOF
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, nil
+}
+
+func (s *fakeStream) Close() error { return nil }
+
+func newTestServer(t *testing.T, prov *fakeProvider, opts Options) (*httptest.Server, *cost.Ledger) {
+	t.Helper()
+	retryDelay = time.Millisecond
+	ledger := cost.NewLedger(filepath.Join(t.TempDir(), "usage.jsonl"))
+	opts.Ledger = ledger
+	opts.Route = func(model string) (provider.AIProvider, string, error) {
+		if model == "unknown" {
+			return nil, "", fmt.Errorf("no provider for %s", model)
+		}
+		return prov, provider.ResolveModel(model), nil
+	}
+	server := httptest.NewServer(NewServer(opts))
+	t.Cleanup(server.Close)
+	return server, ledger
+}
+
+func post(t *testing.T, url, token, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url+"/v1/chat/completions", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestChatCompletion(t *testing.T) {
+	prov := &fakeProvider{
+		fail: []error{errors.New("API error (status 429): rate limited")},
+		response: &provider.Response{
+			Content: []provider.ContentBlock{
+				&provider.TextBlock{Text: "Checking."},
+				&provider.ToolUseBlock{ID: "call_2", Name: "read", Input: map[string]interface{}{"path": "go.mod"}},
+			},
+			StopReason: provider.StopReasonToolUse,
+			Usage:      provider.Usage{InputTokens: 1000, OutputTokens: 100},
+		},
+	}
+	var logged Entry
+	server, ledger := newTestServer(t, prov, Options{Retries: 1, Log: func(e Entry) { logged = e }})
+
+	body := `{
+		"model": "sonnet",
+		"max_tokens": 200,
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": [{"type": "text", "text": "Look"}, {"type": "image_url", "image_url": {"url": "data:image/png;base64,AAAA"}}]},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_0", "type": "function", "function": {"name": "ls", "arguments": "{}"}},
+				{"id": "call_1", "type": "function", "function": {"name": "ls", "arguments": "{\"dir\":\"pkg\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_0", "content": "a"},
+			{"role": "tool", "tool_call_id": "call_1", "content": "b"}
+		],
+		"tools": [{"type": "function", "function": {"name": "read", "parameters": {"type": "object"}}}]
+	}`
+	resp := post(t, server.URL, "", body)
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		t.Fatalf("status %d: %s", resp.StatusCode, data)
+	}
+	var out chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	choice := out.Choices[0]
+	if *choice.FinishReason != "tool_calls" || *choice.Message.Content != "Checking." {
+		t.Errorf("unexpected choice: %+v", choice.Message)
+	}
+	if calls := choice.Message.ToolCalls; len(calls) != 1 || calls[0].Function.Arguments != `{"path":"go.mod"}` {
+		t.Errorf("unexpected tool calls: %+v", calls)
+	}
+	if out.Model != "sonnet" || out.Usage.TotalTokens != 1100 {
+		t.Errorf("unexpected response: %+v", out)
+	}
+
+	if len(prov.requests) != 2 {
+		t.Fatalf("expected one retry, got %d requests", len(prov.requests))
+	}
+	req := prov.requests[1]
+	if req.Model != provider.ResolveModel("sonnet") || req.MaxTokens != 200 || len(req.System) != 1 || len(req.Tools) != 1 {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if len(req.Messages) != 3 {
+		t.Fatalf("expected tool results merged into one message, got %d messages", len(req.Messages))
+	}
+	if img, ok := req.Messages[0].Content[1].(*provider.ImageBlock); !ok || img.Source.MediaType != "image/png" || img.Source.Data != "AAAA" {
+		t.Errorf("unexpected image: %+v", req.Messages[0].Content[1])
+	}
+	if use, ok := req.Messages[1].Content[1].(*provider.ToolUseBlock); !ok || use.Input["dir"] != "pkg" {
+		t.Errorf("unexpected tool use: %+v", req.Messages[1].Content[1])
+	}
+	if len(req.Messages[2].Content) != 2 {
+		t.Errorf("unexpected tool results: %+v", req.Messages[2].Content)
+	}
+
+	records, err := ledger.Records(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Provider != "fake" || records[0].InputTokens != 1000 || records[0].Cost == 0 {
+		t.Errorf("unexpected ledger: %+v", records)
+	}
+	if logged.Retries != 1 || logged.Cost != records[0].Cost {
+		t.Errorf("unexpected log entry: %+v", logged)
+	}
+}
+
+func TestChatCompletionStream(t *testing.T) {
+	prov := &fakeProvider{events: []provider.StreamingEvent{
+		&provider.MessageStartEvent{Message: &provider.Response{ID: "msg_1"}},
+		&provider.ContentBlockStartEvent{Index: 0, ContentBlock: &provider.TextBlock{}},
+		&provider.ContentBlockDeltaEvent{Index: 0, Delta: &provider.TextDelta{Text: "Hel"}},
+		&provider.ContentBlockDeltaEvent{Index: 0, Delta: &provider.TextDelta{Text: "lo"}},
+		&provider.ContentBlockStopEvent{Index: 0},
+		&provider.ContentBlockStartEvent{Index: 1, ContentBlock: &provider.ToolUseBlock{ID: "call_1", Name: "read"}},
+		&provider.ContentBlockDeltaEvent{Index: 1, Delta: &provider.InputJSONDelta{PartialJSON: `{"path":`}},
+		&provider.ContentBlockDeltaEvent{Index: 1, Delta: &provider.InputJSONDelta{PartialJSON: `"a"}`}},
+		&provider.ContentBlockStopEvent{Index: 1},
+		&provider.MessageDeltaEvent{Delta: &provider.MessageDelta{StopReason: provider.StopReasonToolUse}, Usage: &provider.Usage{InputTokens: 10, OutputTokens: 5}},
+		&provider.MessageStopEvent{},
+	}}
+	server, _ := newTestServer(t, prov, Options{})
+
+	resp := post(t, server.URL, "", `{"model":"gpt-4o","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"hi"}]}`)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	var text, args, finish string
+	var usage *chatUsage
+	done := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+		var chunk chatResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatal(err)
+		}
+		if chunk.Object != "chat.completion.chunk" {
+			t.Errorf("unexpected object %q", chunk.Object)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		for _, c := range chunk.Choices {
+			if c.Delta.Content != nil {
+				text += *c.Delta.Content
+			}
+			for _, call := range c.Delta.ToolCalls {
+				args += call.Function.Arguments
+			}
+			if c.FinishReason != nil {
+				finish = *c.FinishReason
+			}
+		}
+	}
+	if !done || text != "Hello" || args != `{"path":"a"}` || finish != "tool_calls" {
+		t.Errorf("got done=%v text=%q args=%q finish=%q", done, text, args, finish)
+	}
+	if usage == nil || usage.TotalTokens != 15 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+}
+
+func TestGatewayErrors(t *testing.T) {
+	prov := &fakeProvider{fail: []error{
+		errors.New("API error (status 400): bad request"),
+		errors.New("API error (status 503): unavailable"),
+		errors.New("API error (status 503): unavailable"),
+	}}
+	server, _ := newTestServer(t, prov, Options{Token: "secret", Retries: 1, Models: []string{"sonnet"}})
+
+	tests := []struct {
+		name, token, body string
+		status            int
+	}{
+		{"no token", "", `{"messages":[{"role":"user","content":"hi"}]}`, http.StatusUnauthorized},
+		{"bad json", "secret", `{`, http.StatusBadRequest},
+		{"unknown model", "secret", `{"model":"unknown","messages":[{"role":"user","content":"hi"}]}`, http.StatusBadRequest},
+		{"bad role", "secret", `{"messages":[{"role":"robot","content":"hi"}]}`, http.StatusBadRequest},
+		{"not retried", "secret", `{"messages":[{"role":"user","content":"hi"}]}`, http.StatusBadGateway},
+		{"retries exhausted", "secret", `{"messages":[{"role":"user","content":"hi"}]}`, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		resp := post(t, server.URL, tt.token, tt.body)
+		var body apiError
+		json.NewDecoder(resp.Body).Decode(&body)
+		if resp.StatusCode != tt.status || body.Error.Message == "" {
+			t.Errorf("%s: got %d %+v, want %d", tt.name, resp.StatusCode, body, tt.status)
+		}
+	}
+	if len(prov.requests) != 3 {
+		t.Errorf("expected 3 provider calls, got %d", len(prov.requests))
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(data), `"id":"sonnet"`) {
+		t.Errorf("unexpected models: %d %s", resp.StatusCode, data)
+	}
+}
+
+func TestGatewayWithoutToken(t *testing.T) {
+	prov := &fakeProvider{response: &provider.Response{
+		Content:    []provider.ContentBlock{&provider.TextBlock{Text: "hi"}},
+		StopReason: "end_turn",
+	}}
+	server, _ := newTestServer(t, prov, Options{})
+
+	tests := []struct {
+		name, host, origin string
+		status             int
+	}{
+		{"local client", "", "", http.StatusOK},
+		{"localhost", "localhost:8788", "", http.StatusOK},
+		{"web page", "", "https://evil.example", http.StatusForbidden},
+		{"rebound host", "evil.example:8788", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/chat/completions", strings.NewReader(`{"messages":[{"role":"user","content":"hi"}]}`))
+		req.Header.Set("Content-Type", "text/plain")
+		if tt.host != "" {
+			req.Host = tt.host
+		}
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: got %d, want %d", tt.name, resp.StatusCode, tt.status)
+		}
+	}
+	if len(prov.requests) != 2 {
+		t.Errorf("expected 2 provider calls, got %d", len(prov.requests))
+	}
+}
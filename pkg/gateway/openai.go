@@ -0,0 +1,334 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// defaultMaxTokens is the response limit when a request sets none; the
+// Claude API requires one
+const defaultMaxTokens = 4096
+
+// chatRequest is an OpenAI chat completions request
+type chatRequest struct {
+	Model               string        `json:"model"`
+	Messages            []chatMessage `json:"messages"`
+	Tools               []chatTool    `json:"tools,omitempty"`
+	MaxTokens           int           `json:"max_tokens,omitempty"`
+	MaxCompletionTokens int           `json:"max_completion_tokens,omitempty"`
+	Temperature         *float64      `json:"temperature,omitempty"`
+	Stream              bool          `json:"stream,omitempty"`
+	StreamOptions       *struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options,omitempty"`
+}
+
+// chatMessage is a message of a request or the message of a response.
+// Request content is a string or a list of parts.
+type chatMessage struct {
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content,omitempty"`
+	ToolCalls  []toolCall      `json:"tool_calls,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+}
+
+// contentPart is one part of a message with mixed content
+type contentPart struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	ImageURL *struct {
+		URL string `json:"url"`
+	} `json:"image_url,omitempty"`
+}
+
+// toolCall is a function call made by the assistant
+type toolCall struct {
+	Index    *int   `json:"index,omitempty"` // streaming only
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// chatTool is a function the model may call
+type chatTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description,omitempty"`
+		Parameters  json.RawMessage `json:"parameters,omitempty"`
+	} `json:"function"`
+}
+
+// chatResponse is a chat completion, or a chunk of one when streaming
+type chatResponse struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []chatChoice `json:"choices"`
+	Usage   *chatUsage   `json:"usage,omitempty"`
+}
+
+// chatChoice is the single choice of a response
+type chatChoice struct {
+	Index        int           `json:"index"`
+	Message      *responseText `json:"message,omitempty"`
+	Delta        *responseText `json:"delta,omitempty"`
+	FinishReason *string       `json:"finish_reason"`
+}
+
+// responseText is the assistant message of a response or a chunk delta
+type responseText struct {
+	Role      string     `json:"role,omitempty"`
+	Content   *string    `json:"content,omitempty"`
+	ToolCalls []toolCall `json:"tool_calls,omitempty"`
+}
+
+// chatUsage is the token usage of a response
+type chatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// toRequest converts an OpenAI request into a provider request for model
+func toRequest(in *chatRequest, model string) (*provider.Request, error) {
+	req := &provider.Request{
+		Model:     model,
+		MaxTokens: in.MaxTokens,
+		Stream:    in.Stream,
+	}
+	if in.MaxCompletionTokens > 0 {
+		req.MaxTokens = in.MaxCompletionTokens
+	}
+	if req.MaxTokens <= 0 {
+		req.MaxTokens = defaultMaxTokens
+	}
+	if in.Temperature != nil {
+		req.Temperature = *in.Temperature
+	}
+
+	for _, t := range in.Tools {
+		if t.Type != "" && t.Type != "function" {
+			return nil, fmt.Errorf("unsupported tool type %q", t.Type)
+		}
+		schema := t.Function.Parameters
+		if len(schema) == 0 || string(schema) == "null" {
+			schema = json.RawMessage(`{"type":"object","properties":{}}`)
+		}
+		req.Tools = append(req.Tools, provider.Tool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: schema,
+		})
+	}
+
+	for i, m := range in.Messages {
+		switch m.Role {
+		case "system", "developer":
+			text, err := messageText(m.Content)
+			if err != nil {
+				return nil, fmt.Errorf("messages[%d]: %w", i, err)
+			}
+			req.System = append(req.System, &provider.TextBlock{Text: text})
+
+		case "user":
+			blocks, err := userContent(m.Content)
+			if err != nil {
+				return nil, fmt.Errorf("messages[%d]: %w", i, err)
+			}
+			req.Messages = append(req.Messages, provider.Message{Role: provider.RoleUser, Content: blocks})
+
+		case "assistant":
+			var blocks []provider.ContentBlock
+			text, err := messageText(m.Content)
+			if err != nil {
+				return nil, fmt.Errorf("messages[%d]: %w", i, err)
+			}
+			if text != "" {
+				blocks = append(blocks, &provider.TextBlock{Text: text})
+			}
+			for _, call := range m.ToolCalls {
+				input := make(map[string]interface{})
+				if args := strings.TrimSpace(call.Function.Arguments); args != "" {
+					if err := json.Unmarshal([]byte(args), &input); err != nil {
+						return nil, fmt.Errorf("messages[%d]: invalid arguments for %s: %w", i, call.Function.Name, err)
+					}
+				}
+				blocks = append(blocks, &provider.ToolUseBlock{ID: call.ID, Name: call.Function.Name, Input: input})
+			}
+			req.Messages = append(req.Messages, provider.Message{Role: provider.RoleAssistant, Content: blocks})
+
+		case "tool":
+			text, err := messageText(m.Content)
+			if err != nil {
+				return nil, fmt.Errorf("messages[%d]: %w", i, err)
+			}
+			result := &provider.ToolResultBlock{ToolUseID: m.ToolCallID, Content: text}
+			// Results of parallel calls go back in one user message
+			if n := len(req.Messages); n > 0 && isToolResults(req.Messages[n-1]) {
+				req.Messages[n-1].Content = append(req.Messages[n-1].Content, result)
+				continue
+			}
+			req.Messages = append(req.Messages, provider.Message{Role: provider.RoleUser, Content: []provider.ContentBlock{result}})
+
+		default:
+			return nil, fmt.Errorf("messages[%d]: unsupported role %q", i, m.Role)
+		}
+	}
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("messages must include a user message")
+	}
+	return req, nil
+}
+
+// isToolResults reports whether a message holds only tool results
+func isToolResults(m provider.Message) bool {
+	if m.Role != provider.RoleUser || len(m.Content) == 0 {
+		return false
+	}
+	for _, block := range m.Content {
+		if _, ok := block.(*provider.ToolResultBlock); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// messageText returns content given as a string or as text parts
+func messageText(content json.RawMessage) (string, error) {
+	if len(content) == 0 || string(content) == "null" {
+		return "", nil
+	}
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text, nil
+	}
+	var parts []contentPart
+	if err := json.Unmarshal(content, &parts); err != nil {
+		return "", fmt.Errorf("content must be a string or a list of parts")
+	}
+	var texts []string
+	for _, p := range parts {
+		if p.Type == "text" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// userContent converts user content, which may include images
+func userContent(content json.RawMessage) ([]provider.ContentBlock, error) {
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return []provider.ContentBlock{&provider.TextBlock{Text: text}}, nil
+	}
+	var parts []contentPart
+	if err := json.Unmarshal(content, &parts); err != nil {
+		return nil, fmt.Errorf("content must be a string or a list of parts")
+	}
+	var blocks []provider.ContentBlock
+	for _, p := range parts {
+		switch p.Type {
+		case "text":
+			blocks = append(blocks, &provider.TextBlock{Text: p.Text})
+		case "image_url":
+			if p.ImageURL == nil {
+				return nil, fmt.Errorf("image_url part without a url")
+			}
+			blocks = append(blocks, &provider.ImageBlock{Source: imageSource(p.ImageURL.URL)})
+		default:
+			return nil, fmt.Errorf("unsupported content part %q", p.Type)
+		}
+	}
+	return blocks, nil
+}
+
+// imageSource converts an image URL, which may be a base64 data URL
+func imageSource(url string) provider.ImageSource {
+	if rest, ok := strings.CutPrefix(url, "data:"); ok {
+		if meta, data, ok := strings.Cut(rest, ","); ok {
+			if mediaType, ok := strings.CutSuffix(meta, ";base64"); ok {
+				return provider.ImageSource{Type: "base64", MediaType: mediaType, Data: data}
+			}
+		}
+	}
+	return provider.ImageSource{Type: "url", Data: url}
+}
+
+// fromResponse converts a provider response into an OpenAI completion
+func fromResponse(resp *provider.Response, id, model string, created int64) *chatResponse {
+	msg := &responseText{Role: "assistant"}
+	var text strings.Builder
+	for _, block := range resp.Content {
+		switch b := block.(type) {
+		case *provider.TextBlock:
+			text.WriteString(b.Text)
+		case *provider.ToolUseBlock:
+			msg.ToolCalls = append(msg.ToolCalls, newToolCall(b.ID, b.Name, arguments(b.Input)))
+		}
+	}
+	if text.Len() > 0 || len(msg.ToolCalls) == 0 {
+		content := text.String()
+		msg.Content = &content
+	}
+	finish := finishReason(resp.StopReason, len(msg.ToolCalls) > 0)
+	return &chatResponse{
+		ID:      id,
+		Object:  "chat.completion",
+		Created: created,
+		Model:   model,
+		Choices: []chatChoice{{Message: msg, FinishReason: &finish}},
+		Usage:   toUsage(resp.Usage),
+	}
+}
+
+// newToolCall creates a function call
+func newToolCall(id, name, args string) toolCall {
+	call := toolCall{ID: id, Type: "function"}
+	call.Function.Name = name
+	call.Function.Arguments = args
+	return call
+}
+
+// arguments encodes tool input as the JSON string OpenAI clients expect
+func arguments(input map[string]interface{}) string {
+	if input == nil {
+		return "{}"
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// finishReason maps a stop reason to an OpenAI finish reason
+func finishReason(reason provider.StopReason, toolCalls bool) string {
+	switch reason {
+	case provider.StopReasonToolUse:
+		return "tool_calls"
+	case provider.StopReasonMaxTokens:
+		return "length"
+	}
+	if toolCalls {
+		return "tool_calls"
+	}
+	return "stop"
+}
+
+// toUsage converts token usage
+func toUsage(u provider.Usage) *chatUsage {
+	in := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return &chatUsage{
+		PromptTokens:     in,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      in + u.OutputTokens,
+	}
+}
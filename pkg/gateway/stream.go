@@ -0,0 +1,169 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// streamWriter writes chat.completion.chunk events
+type streamWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	id      string
+	model   string
+	created int64
+}
+
+// chunk writes one event with a delta and an optional finish reason
+func (s *streamWriter) chunk(delta *responseText, finish *string, usage *chatUsage) error {
+	choices := []chatChoice{}
+	if delta != nil {
+		choices = append(choices, chatChoice{Delta: delta, FinishReason: finish})
+	}
+	return s.event(chatResponse{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: choices,
+		Usage:   usage,
+	})
+}
+
+// event writes one server-sent event
+func (s *streamWriter) event(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+	return nil
+}
+
+// streamedTool is a tool call being streamed
+type streamedTool struct {
+	index int
+	input map[string]interface{} // from the block start, sent if no deltas follow
+	sent  bool                   // whether argument deltas were sent
+}
+
+// stream serves a streaming completion. Failures before the first event
+// are retried and reported as an error response; later ones end the stream
+// with an error event.
+func (s *Server) stream(ctx context.Context, w http.ResponseWriter, prov provider.AIProvider, req *provider.Request, id, model string, includeUsage bool) (provider.Usage, int, error) {
+	var usage provider.Usage
+	var reader provider.StreamReader
+	retries, err := s.retry(ctx, prov, func(ctx context.Context) error {
+		var err error
+		reader, err = prov.CreateMessageStream(ctx, req)
+		return err
+	})
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "upstream_error", err.Error())
+		return usage, retries, err
+	}
+	defer reader.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	out := &streamWriter{w: w, id: id, model: model, created: time.Now().Unix()}
+	out.flusher, _ = w.(http.Flusher)
+
+	text := func(t string) *responseText { return &responseText{Content: &t} }
+	if err := out.chunk(&responseText{Role: "assistant", Content: new(string)}, nil, nil); err != nil {
+		return usage, retries, err
+	}
+
+	tools := make(map[int]*streamedTool)
+	toolCount := 0
+	var stopReason provider.StopReason
+	for {
+		event, err := reader.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var body apiError
+			body.Error.Message = err.Error()
+			body.Error.Type = "api_error"
+			out.event(body)
+			return usage, retries, err
+		}
+
+		switch ev := event.(type) {
+		case *provider.ContentBlockStartEvent:
+			tb, ok := ev.ContentBlock.(*provider.ToolUseBlock)
+			if !ok {
+				continue
+			}
+			tools[ev.Index] = &streamedTool{index: toolCount, input: tb.Input}
+			call := newToolCall(tb.ID, tb.Name, "")
+			call.Index = &tools[ev.Index].index
+			toolCount++
+			err = out.chunk(&responseText{ToolCalls: []toolCall{call}}, nil, nil)
+
+		case *provider.ContentBlockDeltaEvent:
+			switch d := ev.Delta.(type) {
+			case *provider.TextDelta:
+				err = out.chunk(text(d.Text), nil, nil)
+			case *provider.InputJSONDelta:
+				if t, ok := tools[ev.Index]; ok {
+					t.sent = true
+					err = out.chunk(argumentsDelta(t.index, d.PartialJSON), nil, nil)
+				}
+			}
+
+		case *provider.ContentBlockStopEvent:
+			// Providers that send the whole input with the block start
+			if t, ok := tools[ev.Index]; ok && !t.sent && len(t.input) > 0 {
+				t.sent = true
+				err = out.chunk(argumentsDelta(t.index, arguments(t.input)), nil, nil)
+			}
+
+		case *provider.MessageDeltaEvent:
+			if ev.Delta != nil {
+				stopReason = ev.Delta.StopReason
+			}
+			if ev.Usage != nil {
+				usage = *ev.Usage
+			}
+		}
+		if err != nil {
+			return usage, retries, err
+		}
+	}
+
+	finish := finishReason(stopReason, toolCount > 0)
+	if err := out.chunk(&responseText{}, &finish, nil); err != nil {
+		return usage, retries, err
+	}
+	if includeUsage {
+		if err := out.chunk(nil, nil, toUsage(usage)); err != nil {
+			return usage, retries, err
+		}
+	}
+	_, err = io.WriteString(w, "data: [DONE]\n\n")
+	if out.flusher != nil {
+		out.flusher.Flush()
+	}
+	return usage, retries, err
+}
+
+// argumentsDelta is a chunk delta adding to the arguments of a tool call
+func argumentsDelta(index int, args string) *responseText {
+	call := toolCall{Index: &index}
+	call.Function.Arguments = args
+	return &responseText{ToolCalls: []toolCall{call}}
+}
@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// serverEntry is a server in the mcpServers format shared by .mcp.json and
+// Claude Desktop's claude_desktop_config.json
+type serverEntry struct {
+	Type    string            `json:"type"`
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"`
+	URL     string            `json:"url"`
+}
+
+// ParseServers parses a config in the mcpServers format, sorted by name
+func ParseServers(data []byte) ([]ServerConfig, error) {
+	var config struct {
+		MCPServers map[string]json.RawMessage `json:"mcpServers"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	servers := make([]ServerConfig, 0, len(config.MCPServers))
+	for name, raw := range config.MCPServers {
+		server, err := ParseServer(name, raw)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, server)
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+	return servers, nil
+}
+
+// ParseServer parses one server entry of the mcpServers format, such as
+// {"command": "npx", "args": ["-y", "server"]} or {"type": "http", "url": "..."}.
+// Entries with a URL and no type are HTTP servers.
+func ParseServer(name string, data []byte) (ServerConfig, error) {
+	var entry serverEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return ServerConfig{}, fmt.Errorf("server %s: %w", name, err)
+	}
+
+	server := ServerConfig{
+		Name:      name,
+		Type:      ServerType(entry.Type),
+		Command:   entry.Command,
+		Args:      entry.Args,
+		URL:       entry.URL,
+		Env:       entry.Env,
+		AutoStart: true,
+	}
+	if server.Type == "" {
+		server.Type = ServerTypeStdio
+		if entry.URL != "" && entry.Command == "" {
+			server.Type = ServerTypeHTTP
+		}
+	}
+
+	switch server.Type {
+	case ServerTypeStdio:
+		if server.Command == "" {
+			return ServerConfig{}, fmt.Errorf("server %s: command is required", name)
+		}
+	case ServerTypeSSE, ServerTypeHTTP:
+		if server.URL == "" {
+			return ServerConfig{}, fmt.Errorf("server %s: url is required", name)
+		}
+	default:
+		return ServerConfig{}, fmt.Errorf("server %s: unsupported type %q", name, server.Type)
+	}
+	return server, nil
+}
+
+// ClaudeDesktopConfigPath returns where Claude Desktop keeps its MCP servers
+func ClaudeDesktopConfigPath() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		appData := os.Getenv("APPDATA")
+		if appData == "" {
+			return "", fmt.Errorf("APPDATA is not set")
+		}
+		return filepath.Join(appData, "Claude", "claude_desktop_config.json"), nil
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "Application Support", "Claude", "claude_desktop_config.json"), nil
+	default:
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "Claude", "claude_desktop_config.json"), nil
+	}
+}
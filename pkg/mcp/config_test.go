@@ -0,0 +1,52 @@
+package mcp
+
+import "testing"
+
+func TestParseServers(t *testing.T) {
+	data := []byte(`{"mcpServers": {
+		"fs": {"command": "npx", "args": ["-y", "@modelcontextprotocol/server-filesystem", "/tmp"], "env": {"DEBUG": "1"}},
+		"docs": {"url": "https://example.com/mcp"},
+		"events": {"type": "sse", "url": "https://example.com/sse"}
+	}}`)
+	servers, err := ParseServers(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 3 {
+		t.Fatalf("expected 3 servers, got %d", len(servers))
+	}
+
+	tests := []struct {
+		name, command, url string
+		typ                ServerType
+	}{
+		{"docs", "", "https://example.com/mcp", ServerTypeHTTP},
+		{"events", "", "https://example.com/sse", ServerTypeSSE},
+		{"fs", "npx", "", ServerTypeStdio},
+	}
+	for i, tt := range tests {
+		s := servers[i]
+		if s.Name != tt.name || s.Type != tt.typ || s.Command != tt.command || s.URL != tt.url || !s.AutoStart {
+			t.Errorf("server %d: got %+v", i, s)
+		}
+	}
+	if servers[2].Env["DEBUG"] != "1" || len(servers[2].Args) != 3 {
+		t.Errorf("unexpected args or env: %+v", servers[2])
+	}
+}
+
+func TestParseServerErrors(t *testing.T) {
+	tests := []struct {
+		name, data string
+	}{
+		{"not json", `{`},
+		{"no command", `{"args": ["x"]}`},
+		{"http without url", `{"type": "http"}`},
+		{"unknown type", `{"type": "ws", "url": "ws://localhost"}`},
+	}
+	for _, tt := range tests {
+		if _, err := ParseServer("s", []byte(tt.data)); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
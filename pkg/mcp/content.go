@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CallResult is the result of a tool call
+type CallResult struct {
+	// Text of the text content and of embedded text resources, with notes
+	// for content that cannot be passed on
+	Text string
+	// Images of image content and of embedded image resources
+	Images []Image
+	// Structured is the structuredContent of the result, if any
+	Structured json.RawMessage
+	IsError    bool
+}
+
+// Image is base64 image data
+type Image struct {
+	Data     string
+	MimeType string
+}
+
+// content is a content block of a tool result
+type content struct {
+	Type     string           `json:"type"`
+	Text     string           `json:"text"`
+	Data     string           `json:"data"`
+	MimeType string           `json:"mimeType"`
+	Resource *resourceContent `json:"resource"`
+
+	// resource_link blocks
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+// resourceContent is an embedded resource
+type resourceContent struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Blob     string `json:"blob"`
+}
+
+// parseCallResult converts the result of tools/call. Results with only
+// structured content get it as their text, which servers are asked to
+// include anyway for clients that do not read it.
+func parseCallResult(data json.RawMessage) (*CallResult, error) {
+	var raw struct {
+		Content           []content       `json:"content"`
+		StructuredContent json.RawMessage `json:"structuredContent"`
+		IsError           bool            `json:"isError"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse result: %w", err)
+	}
+
+	result := &CallResult{IsError: raw.IsError}
+	if len(raw.StructuredContent) > 0 && string(raw.StructuredContent) != "null" {
+		result.Structured = raw.StructuredContent
+	}
+
+	var parts []string
+	for _, c := range raw.Content {
+		switch c.Type {
+		case "text":
+			parts = append(parts, c.Text)
+		case "image":
+			result.Images = append(result.Images, Image{Data: c.Data, MimeType: c.MimeType})
+		case "resource":
+			if c.Resource == nil {
+				continue
+			}
+			r := c.Resource
+			switch {
+			case r.Text != "":
+				parts = append(parts, fmt.Sprintf("Resource %s:\n%s", r.URI, r.Text))
+			case strings.HasPrefix(r.MimeType, "image/"):
+				result.Images = append(result.Images, Image{Data: r.Blob, MimeType: r.MimeType})
+			default:
+				parts = append(parts, fmt.Sprintf("[Resource %s: %s, %d bytes, not shown]", r.URI, mimeTypeOrUnknown(r.MimeType), base64.StdEncoding.DecodedLen(len(r.Blob))))
+			}
+		case "resource_link":
+			name := c.Name
+			if name == "" {
+				name = c.URI
+			}
+			parts = append(parts, fmt.Sprintf("[Resource link: %s <%s>; read it with ReadMcpResourceTool]", name, c.URI))
+		default:
+			parts = append(parts, fmt.Sprintf("[%s content (%s), not shown]", c.Type, mimeTypeOrUnknown(c.MimeType)))
+		}
+	}
+	result.Text = strings.Join(parts, "\n")
+	if result.Text == "" && result.Structured != nil {
+		result.Text = string(result.Structured)
+	}
+	return result, nil
+}
+
+func mimeTypeOrUnknown(mimeType string) string {
+	if mimeType == "" {
+		return "unknown type"
+	}
+	return mimeType
+}
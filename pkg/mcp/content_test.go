@@ -0,0 +1,53 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCallResult(t *testing.T) {
+	result, err := parseCallResult([]byte(`{
+		"content": [
+			{"type": "text", "text": "Rendered the chart."},
+			{"type": "image", "data": "AAAA", "mimeType": "image/png"},
+			{"type": "resource", "resource": {"uri": "file:///notes.md", "mimeType": "text/markdown", "text": "# Notes"}},
+			{"type": "resource", "resource": {"uri": "file:///logo.jpg", "mimeType": "image/jpeg", "blob": "BBBB"}},
+			{"type": "resource", "resource": {"uri": "file:///data.bin", "blob": "CCCCCCCC"}},
+			{"type": "resource_link", "uri": "file:///big.csv", "name": "big.csv"},
+			{"type": "audio", "data": "DDDD", "mimeType": "audio/wav"}
+		],
+		"structuredContent": {"points": 3},
+		"isError": false
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Images) != 2 || result.Images[0].MimeType != "image/png" || result.Images[1].Data != "BBBB" {
+		t.Errorf("unexpected images: %+v", result.Images)
+	}
+	for _, want := range []string{
+		"Rendered the chart.",
+		"Resource file:///notes.md:\n# Notes",
+		"[Resource file:///data.bin: unknown type, 6 bytes, not shown]",
+		"[Resource link: big.csv <file:///big.csv>",
+		"[audio content (audio/wav), not shown]",
+	} {
+		if !strings.Contains(result.Text, want) {
+			t.Errorf("expected %q in %q", want, result.Text)
+		}
+	}
+	if string(result.Structured) != `{"points": 3}` {
+		t.Errorf("unexpected structured content: %s", result.Structured)
+	}
+}
+
+func TestParseCallResultStructuredOnly(t *testing.T) {
+	result, err := parseCallResult([]byte(`{"content": [], "structuredContent": {"ok": true}, "isError": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Text != `{"ok": true}` || !result.IsError {
+		t.Errorf("expected the structured content as text, got %+v", result)
+	}
+}
@@ -3,6 +3,7 @@ package mcp
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"strings"
 	"sync"
 	"time"
 )
@@ -77,6 +79,9 @@ type Client struct {
 	// Response channels
 	responses map[int]chan *Response
 	respMu    sync.Mutex
+
+	// Session of an HTTP server, sent back with each request
+	sessionID string
 }
 
 // Request represents an MCP JSON-RPC request
@@ -173,6 +178,16 @@ func (m *Manager) RemoveServer(name string) error {
 	return nil
 }
 
+// Close stops all servers
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, server := range m.servers {
+		m.stopServer(server)
+	}
+}
+
 // StartServer starts an MCP server
 func (m *Manager) StartServer(name string) error {
 	m.mu.Lock()
@@ -359,14 +374,18 @@ func (m *Manager) discoverTools(server *Server) error {
 	// Register tools
 	for _, tool := range toolsResult.Tools {
 		tool.ServerName = server.config.Name
-		fullName := fmt.Sprintf("mcp__%s__%s", server.config.Name, tool.Name)
-		m.tools[fullName] = &tool
+		m.tools[ToolName(server.config.Name, tool.Name)] = &tool
 		server.tools = append(server.tools, tool)
 	}
 
 	return nil
 }
 
+// ToolName returns the name a server's tool is registered under
+func ToolName(server, tool string) string {
+	return fmt.Sprintf("mcp__%s__%s", server, tool)
+}
+
 // GetTools returns all available MCP tools
 func (m *Manager) GetTools() []*Tool {
 	m.mu.RLock()
@@ -391,7 +410,7 @@ func (m *Manager) GetTool(name string) (*Tool, error) {
 }
 
 // CallTool calls an MCP tool
-func (m *Manager) CallTool(ctx context.Context, toolName string, arguments map[string]interface{}) (interface{}, error) {
+func (m *Manager) CallTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*CallResult, error) {
 	m.mu.RLock()
 	tool, ok := m.tools[toolName]
 	if !ok {
@@ -415,30 +434,7 @@ func (m *Manager) CallTool(ctx context.Context, toolName string, arguments map[s
 		return nil, err
 	}
 
-	var callResult struct {
-		Content []struct {
-			Type string `json:"type"`
-			Text string `json:"text,omitempty"`
-		} `json:"content"`
-		IsError bool `json:"isError,omitempty"`
-	}
-
-	if err := json.Unmarshal(result, &callResult); err != nil {
-		return nil, fmt.Errorf("failed to parse result: %w", err)
-	}
-
-	// Extract text content
-	var text string
-	for _, content := range callResult.Content {
-		if content.Type == "text" {
-			text += content.Text
-		}
-	}
-
-	return map[string]interface{}{
-		"content": text,
-		"isError": callResult.IsError,
-	}, nil
+	return parseCallResult(result)
 }
 
 // ListResources lists resources from all servers or a specific server
@@ -590,38 +586,77 @@ func (c *Client) sendRequest(req *Request) error {
 	}
 }
 
-// sendHTTPRequest sends a request via HTTP
+// sendHTTPRequest posts a request and delivers the response, which the
+// server sends as JSON or as a stream of events
 func (c *Client) sendHTTPRequest(data []byte) error {
-	resp, err := http.Post(c.server.config.URL, "application/json", nil)
+	req, err := http.NewRequest(http.MethodPost, c.server.config.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	c.mu.Lock()
+	if c.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", c.sessionID)
+	}
+	c.mu.Unlock()
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusAccepted {
+		return nil // notification
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("HTTP error: %d", resp.StatusCode)
 	}
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+		c.mu.Lock()
+		c.sessionID = id
+		c.mu.Unlock()
+	}
 
-	return nil
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		c.deliver(body)
+		return nil
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data:"); ok {
+			c.deliver([]byte(strings.TrimSpace(data)))
+		}
+	}
+	return scanner.Err()
 }
 
 // readResponses reads responses from stdio
 func (c *Client) readResponses() {
 	scanner := bufio.NewScanner(c.server.stdout)
 	for scanner.Scan() {
-		line := scanner.Text()
+		c.deliver(scanner.Bytes())
+	}
+}
 
-		var resp Response
-		if err := json.Unmarshal([]byte(line), &resp); err != nil {
-			continue
-		}
+// deliver hands a response to the call waiting for it
+func (c *Client) deliver(data []byte) {
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return
+	}
 
-		c.respMu.Lock()
-		if ch, ok := c.responses[resp.ID]; ok {
-			ch <- &resp
-		}
-		c.respMu.Unlock()
+	c.respMu.Lock()
+	if ch, ok := c.responses[resp.ID]; ok {
+		ch <- &resp
 	}
+	c.respMu.Unlock()
 }
 
 // LoadConfigFromFile loads MCP configuration from .mcp.json
@@ -630,30 +665,5 @@ func LoadConfigFromFile(path string) ([]ServerConfig, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	var config struct {
-		MCPServers map[string]struct {
-			Command string            `json:"command"`
-			Args    []string          `json:"args"`
-			Env     map[string]string `json:"env"`
-		} `json:"mcpServers"`
-	}
-
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
-	}
-
-	var servers []ServerConfig
-	for name, srv := range config.MCPServers {
-		servers = append(servers, ServerConfig{
-			Name:      name,
-			Type:      ServerTypeStdio,
-			Command:   srv.Command,
-			Args:      srv.Args,
-			Env:       srv.Env,
-			AutoStart: true,
-		})
-	}
-
-	return servers, nil
+	return ParseServers(data)
 }
@@ -0,0 +1,45 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPServer(t *testing.T) {
+	var sessions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sessions = append(sessions, r.Header.Get("Mcp-Session-Id"))
+		switch req.Method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", "s1")
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{}}`, req.ID)
+		case "tools/list":
+			// Answered as a stream of events
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":%d,\"result\":{\"tools\":[{\"name\":\"search\"}]}}\n\n", req.ID)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+
+	m := NewManager()
+	if err := m.AddServer(ServerConfig{Name: "docs", Type: ServerTypeHTTP, URL: server.URL, AutoStart: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetTool("mcp__docs__search"); err != nil {
+		t.Error(err)
+	}
+	if len(sessions) != 3 || sessions[0] != "" || sessions[1] != "s1" || sessions[2] != "s1" {
+		t.Errorf("unexpected session headers: %q", sessions)
+	}
+}
@@ -0,0 +1,130 @@
+package permission
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DestructiveRule classifies tool calls as destructive. Pattern is matched
+// against the command of Bash calls and the JSON input of other tools.
+type DestructiveRule struct {
+	Name    string `json:"name"`
+	Tool    string `json:"tool,omitempty"` // tool name pattern (supports wildcards), default Bash
+	Pattern string `json:"pattern"`        // regular expression
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Destructive is a tool call matched by a destructive rule
+type Destructive struct {
+	Rule   string // name of the matching rule
+	Reason string
+	Tool   string
+	Target string // the command, or the input of other tools
+
+	Analysis []ShellFinding // what AnalyzeCommand found in Bash commands
+}
+
+// String describes the action, such as "Bash: rm -rf build (deletes files)"
+func (d *Destructive) String() string {
+	return fmt.Sprintf("%s: %s (%s)", d.Tool, d.Target, d.Reason)
+}
+
+// commandStart matches where a shell command begins: the start of the
+// line or after a separator, optionally behind sudo, env or xargs
+const commandStart = `(?:^|[;&|(\n]\s*|\$\(\s*|\b(?:sudo|xargs|env|exec|nohup|time)\s+(?:-\S+\s+)*)`
+
+// DefaultDestructiveRules returns the built-in destructive rules
+func DefaultDestructiveRules() []DestructiveRule {
+	return []DestructiveRule{
+		{Name: "rm", Pattern: commandStart + `(?:rm|rmdir|shred|unlink)(?:\s|$)`, Reason: "deletes files"},
+		{Name: "find-delete", Pattern: `\bfind\b.*\s-(?:delete|exec\s+rm)\b`, Reason: "deletes files"},
+		{Name: "git-force-push", Pattern: `\bgit\s+push\b.*(?:\s--force(?:-with-lease)?\b|\s-\w*f\b|\s\+\S+|\s--delete\b|\s--mirror\b)`, Reason: "rewrites or deletes remote history"},
+		{Name: "git-discard", Pattern: `\bgit\s+(?:reset\s+(?:.*\s)?--hard\b|clean\s+(?:-\w+\s+)*-\w*f|checkout\s+(?:\S+\s+)?--\s+\.|restore\s+(?:-\S+\s+)*\.(?:\s|$)|branch\s+(?:-\w+\s+)*-D\b|stash\s+(?:drop|clear)\b)`, Reason: "discards uncommitted work or branches"},
+		{Name: "sql-write", Pattern: `(?i)\b(?:drop\s+(?:table|database|schema|index|view)|truncate\s+table|delete\s+from|alter\s+table|update\s+\w+\s+set\s+\w+\s*=)`, Reason: "changes or deletes database data"},
+		{Name: "db-flush", Pattern: `(?i)\b(?:redis-cli\b.*\bflush(?:all|db)|dropdb|mongo(?:sh)?\b.*\.drop(?:Database)?\()`, Reason: "deletes database data"},
+		{Name: "disk", Pattern: commandStart + `(?:mkfs(?:\.\w+)?|fdisk|wipefs)\b|\bdd\b.*\bof=/dev/`, Reason: "overwrites a disk"},
+		{Name: "infrastructure", Pattern: `\b(?:kubectl\s+delete|terraform\s+(?:destroy|apply\s+.*-auto-approve)|helm\s+(?:uninstall|delete)|docker\s+(?:system\s+prune|volume\s+(?:rm|prune)))\b`, Reason: "deletes deployed resources"},
+	}
+}
+
+// compiledRule is a destructive rule with its pattern compiled
+type compiledRule struct {
+	DestructiveRule
+	re *regexp.Regexp
+}
+
+// Classifier finds destructive tool calls with a set of rules and, for
+// Bash commands, the shell checks of AnalyzeCommand
+type Classifier struct {
+	rules          []compiledRule
+	disabledChecks []string
+}
+
+// NewClassifier compiles rules into a classifier
+func NewClassifier(rules []DestructiveRule) (*Classifier, error) {
+	c := &Classifier{}
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("destructive rule %q: %w", rule.Name, err)
+		}
+		if rule.Tool == "" {
+			rule.Tool = "Bash"
+		}
+		if rule.Reason == "" {
+			rule.Reason = "matches destructive rule " + rule.Name
+		}
+		c.rules = append(c.rules, compiledRule{DestructiveRule: rule, re: re})
+	}
+	return c, nil
+}
+
+// DisableChecks turns off shell checks by name
+func (c *Classifier) DisableChecks(names ...string) {
+	c.disabledChecks = append(c.disabledChecks, names...)
+}
+
+// Classify returns the first rule a tool call matches, or nil when the
+// call is not destructive. Bash commands that match no rule are
+// destructive when the shell analysis finds something dangerous in them.
+func (c *Classifier) Classify(toolName string, params map[string]interface{}) *Destructive {
+	if c == nil {
+		return nil
+	}
+	target := classifyTarget(toolName, params)
+	var analysis []ShellFinding
+	if toolName == "Bash" {
+		analysis = slices.DeleteFunc(AnalyzeCommand(target), func(f ShellFinding) bool {
+			return slices.Contains(c.disabledChecks, f.Check)
+		})
+	}
+	for _, rule := range c.rules {
+		if !matchPattern(rule.Tool, toolName) {
+			continue
+		}
+		if rule.re.MatchString(target) {
+			return &Destructive{Rule: rule.Name, Reason: rule.Reason, Tool: toolName, Target: target, Analysis: analysis}
+		}
+	}
+	if len(analysis) > 0 {
+		return &Destructive{Rule: analysis[0].Check, Reason: analysis[0].Reason, Tool: toolName, Target: target, Analysis: analysis}
+	}
+	return nil
+}
+
+// classifyTarget returns the text rules are matched against: the command
+// of Bash calls and the JSON input of other tools
+func classifyTarget(toolName string, params map[string]interface{}) string {
+	if toolName == "Bash" {
+		command, _ := params["command"].(string)
+		return strings.TrimSpace(command)
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Sprint(params)
+	}
+	return string(data)
+}
@@ -0,0 +1,84 @@
+package permission
+
+import "testing"
+
+func TestClassifyDestructive(t *testing.T) {
+	c, err := NewClassifier(DefaultDestructiveRules())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		command string
+		rule    string
+	}{
+		{"rm -rf build", "rm"},
+		{"go test ./... && rm coverage.out", "rm"},
+		{"sudo rm /etc/hosts", "rm"},
+		{"ls *.tmp | xargs rm", "rm"},
+		{"find . -name '*.orig' -delete", "find-delete"},
+		{"git push --force origin main", "git-force-push"},
+		{"git push -f", "git-force-push"},
+		{"git push origin +main", "git-force-push"},
+		{"git push origin --delete feature", "git-force-push"},
+		{"git reset --hard HEAD~3", "git-discard"},
+		{"git clean -fdx", "git-discard"},
+		{"git checkout -- .", "git-discard"},
+		{"git branch -D old", "git-discard"},
+		{`psql -c "DROP TABLE users"`, "sql-write"},
+		{`sqlite3 app.db "delete from sessions"`, "sql-write"},
+		{`mysql -e "UPDATE users SET admin = 1"`, "sql-write"},
+		{"redis-cli FLUSHALL", "db-flush"},
+		{"dd if=/dev/zero of=/dev/sda", "disk"},
+		{"kubectl delete namespace prod", "infrastructure"},
+		{"terraform destroy", "infrastructure"},
+
+		{"go build ./...", ""},
+		{"git push origin feature/fix-ff", ""},
+		{"git push -u origin main", ""},
+		{"git rm --cached secrets.env", ""},
+		{"git reset HEAD file.go", ""},
+		{"grep -r 'delete from' .", "sql-write"},
+		{"npm run format", ""},
+		{"echo firmware", ""},
+		{"apt update && set -e", ""},
+	}
+	for _, tt := range tests {
+		got := c.Classify("Bash", map[string]interface{}{"command": tt.command})
+		rule := ""
+		if got != nil {
+			rule = got.Rule
+		}
+		if rule != tt.rule {
+			t.Errorf("Classify(%q) = %q, want %q", tt.command, rule, tt.rule)
+		}
+	}
+}
+
+func TestClassifyCustomRules(t *testing.T) {
+	c, err := NewClassifier([]DestructiveRule{
+		{Name: "prod-db", Tool: "mcp__postgres__*", Pattern: `(?i)\b(insert|update|delete)\b`, Reason: "writes to the production database"},
+		{Name: "deploy", Pattern: `\bmake\s+deploy\b`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := c.Classify("mcp__postgres__query", map[string]interface{}{"sql": "DELETE FROM orders"})
+	if got == nil || got.Rule != "prod-db" || got.Reason != "writes to the production database" {
+		t.Errorf("expected the MCP tool call to match prod-db, got %+v", got)
+	}
+	if got := c.Classify("mcp__postgres__query", map[string]interface{}{"sql": "SELECT 1"}); got != nil {
+		t.Errorf("expected a read to pass, got %+v", got)
+	}
+	if got := c.Classify("Bash", map[string]interface{}{"command": "make deploy"}); got == nil || got.Reason != "matches destructive rule deploy" {
+		t.Errorf("expected make deploy to match, got %+v", got)
+	}
+	if got := c.Classify("Read", map[string]interface{}{"file_path": "make deploy"}); got != nil {
+		t.Errorf("rules without a tool apply to Bash only, got %+v", got)
+	}
+
+	if _, err := NewClassifier([]DestructiveRule{{Name: "bad", Pattern: "("}}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
@@ -241,7 +241,7 @@ func (m *Manager) Check(req *Request) *Result {
 // matchRule checks if a rule matches the request
 func (m *Manager) matchRule(rule *Rule, req *Request) bool {
 	// Check tool pattern
-	if !m.matchPattern(rule.Tool, req.Tool) {
+	if !matchPattern(rule.Tool, req.Tool) {
 		return false
 	}
 
@@ -271,7 +271,7 @@ func (m *Manager) matchRule(rule *Rule, req *Request) bool {
 		}
 		matched := false
 		for _, pattern := range rule.Commands {
-			if m.matchPattern(pattern, cmd) {
+			if matchPattern(pattern, cmd) {
 				matched = true
 				break
 			}
@@ -285,7 +285,7 @@ func (m *Manager) matchRule(rule *Rule, req *Request) bool {
 }
 
 // matchPattern matches a pattern against a string (supports wildcards)
-func (m *Manager) matchPattern(pattern, str string) bool {
+func matchPattern(pattern, str string) bool {
 	if pattern == "*" {
 		return true
 	}
@@ -330,7 +330,7 @@ func (m *Manager) matchPath(pattern, path string) bool {
 // matchToolPattern checks if a tool matches any allowed pattern
 func (m *Manager) matchToolPattern(toolName string) bool {
 	for name := range m.allowedTools {
-		if m.matchPattern(name, toolName) {
+		if matchPattern(name, toolName) {
 			return true
 		}
 	}
@@ -503,12 +503,19 @@ func DefaultRules() []Rule {
 		{Tool: "Read", Action: DecisionAllow},
 		{Tool: "Glob", Action: DecisionAllow},
 		{Tool: "Grep", Action: DecisionAllow},
+		{Tool: "Tree", Action: DecisionAllow},
+		{Tool: "Targets", Action: DecisionAllow},
 		{Tool: "LSP", Action: DecisionAllow},
 
 		// Ask for write operations
 		{Tool: "Write", Action: DecisionAsk},
 		{Tool: "Edit", Action: DecisionAsk},
 		{Tool: "Bash", Action: DecisionAsk},
+		{Tool: "Deps", Action: DecisionAsk},
+		{Tool: "Coverage", Action: DecisionAsk},
+		{Tool: "Bench", Action: DecisionAsk},
+		{Tool: "Profile", Action: DecisionAsk},
+		{Tool: "RunSnippet", Action: DecisionAsk},
 
 		// Deny dangerous patterns
 		{Tool: "Bash", Action: DecisionDeny, Commands: []string{"rm -rf /*", "sudo rm -rf *"}},
@@ -0,0 +1,286 @@
+package permission
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// readOnlyPrograms only read files and print, whatever their arguments
+var readOnlyPrograms = map[string]bool{
+	"ls": true, "cat": true, "head": true, "tail": true, "tac": true, "nl": true, "wc": true,
+	"grep": true, "egrep": true, "fgrep": true, "rg": true, "ag": true,
+	"cut": true, "tr": true, "uniq": true, "rev": true, "fold": true, "column": true, "paste": true,
+	"diff": true, "cmp": true, "comm": true, "file": true, "stat": true, "du": true, "df": true,
+	"tree": true, "basename": true, "dirname": true, "realpath": true, "readlink": true,
+	"pwd": true, "echo": true, "printf": true, "cd": true, "true": true, "false": true, "test": true, "[": true,
+	"which": true, "type": true, "whereis": true, "whoami": true, "id": true, "uname": true,
+	"printenv": true, "seq": true, "sleep": true,
+	"jq": true, "strings": true, "od": true, "hexdump": true, "nm": true, "objdump": true, "readelf": true,
+	"sha1sum": true, "sha256sum": true, "sha512sum": true, "md5sum": true, "shasum": true, "cksum": true,
+	"ps": true, "pgrep": true, "lsof": true,
+	// Shell keywords around other commands
+	"for": true, "done": true, "fi": true,
+}
+
+// readOnlyGit are the git subcommands that do not change the repository
+var readOnlyGit = map[string]bool{
+	"status": true, "log": true, "diff": true, "show": true, "blame": true, "grep": true,
+	"ls-files": true, "ls-tree": true, "rev-parse": true, "rev-list": true, "describe": true,
+	"shortlog": true, "cat-file": true, "merge-base": true, "name-rev": true, "whatchanged": true,
+}
+
+// sedPrint matches sed scripts that print a line or range of lines
+var sedPrint = regexp.MustCompile(`^(\d+|\$)(,(\d+|\$))?p$`)
+
+// readOnlyGo are the go subcommands that do not change the module
+var readOnlyGo = map[string]bool{"list": true, "doc": true, "env": true, "version": true, "vet": true}
+
+// CommandWrites explains how a shell command may change files or other
+// state, or returns "" when it only reads. It allows a fixed set of
+// programs known to only read, so commands it does not recognize, and
+// commands that do not parse, count as writing.
+func CommandWrites(command string) string {
+	return commandWrites(command, 0)
+}
+
+func commandWrites(script string, depth int) string {
+	if depth > maxShellDepth {
+		return "nests too many commands to check"
+	}
+	pipelines, err := parseShell(script)
+	if err != nil {
+		return fmt.Sprintf("cannot be checked (%v)", err)
+	}
+	for _, pipeline := range pipelines {
+		for _, cmd := range pipeline {
+			for _, body := range cmd.substs {
+				if reason := commandWrites(body, depth+1); reason != "" {
+					return reason
+				}
+			}
+			for _, r := range cmd.redirects {
+				if writesTo(r) {
+					return "redirects output into " + r.target
+				}
+			}
+
+			args, root, nested, assigns := unwrapCommand(cmd.args)
+			for _, assign := range assigns {
+				if name, _, _ := strings.Cut(assign, "="); runsPrograms(name) {
+					return "sets " + name + ", which can make programs run other commands"
+				}
+			}
+			if root != "" {
+				return "runs as root with " + root
+			}
+			if nested != "" {
+				if reason := commandWrites(nested, depth+1); reason != "" {
+					return reason
+				}
+				continue
+			}
+			if len(args) == 0 {
+				continue
+			}
+			if reason := programWrites(path.Base(args[0]), args[1:]); reason != "" {
+				return reason
+			}
+		}
+	}
+	return ""
+}
+
+// writesTo reports whether a redirect writes to a file
+func writesTo(r shellRedirect) bool {
+	switch r.op {
+	case "<", "<<", "<<-", "<<<", "<&", ">&":
+		return false
+	}
+	return r.target != "/dev/null" && r.target != "/dev/stdout" && r.target != "/dev/stderr"
+}
+
+// programWrites explains how a program run with args may write, or ""
+func programWrites(program string, args []string) string {
+	switch program {
+	case "git":
+		// -c and --config-env can set a pager or alias that runs any
+		// command, and --exec-path chooses where git finds its commands
+		if slices.Contains(args, "-c") {
+			return "runs git with -c"
+		}
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "--config-env") || strings.HasPrefix(arg, "--exec-path") {
+				option, _, _ := strings.Cut(arg, "=")
+				return "runs git with " + option
+			}
+		}
+		args = skipOptions(args, "-C", "--git-dir", "--work-tree")
+		if slices.ContainsFunc(args, func(arg string) bool { return strings.HasPrefix(arg, "--output") }) {
+			return "writes git output to a file"
+		}
+		// git grep -O runs the given pager on the matching files
+		if len(args) > 0 && args[0] == "grep" && slices.ContainsFunc(args[1:], func(arg string) bool {
+			return strings.HasPrefix(arg, "--open-files-in-pager") || !strings.HasPrefix(arg, "--") && strings.HasPrefix(arg, "-") && strings.Contains(arg, "O")
+		}) {
+			return "runs git grep --open-files-in-pager"
+		}
+		if len(args) == 0 || readOnlyGit[args[0]] || listsOnly(args) {
+			return ""
+		}
+		return "runs git " + args[0]
+	case "go":
+		// -vettool and -toolexec run the given program
+		for _, arg := range args {
+			option, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if strings.HasPrefix(arg, "-") && (option == "vettool" || option == "toolexec") {
+				return "runs go with -" + option
+			}
+		}
+		// go env -w and -u change the user's go env file
+		if len(args) > 0 && args[0] == "env" && slices.ContainsFunc(args[1:], func(arg string) bool {
+			flag, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			return strings.HasPrefix(arg, "-") && (flag == "w" || flag == "u")
+		}) {
+			return "runs go env, which changes the go env file"
+		}
+		if len(args) == 0 || readOnlyGo[args[0]] {
+			return ""
+		}
+		return "runs go " + args[0]
+	case "hostname":
+		// Given a name or -F, hostname sets it
+		for _, arg := range args {
+			if !slices.Contains(hostnameDisplay, arg) {
+				return "runs hostname " + arg
+			}
+		}
+		return ""
+	case "date":
+		return dateSets(args)
+	case "find":
+		for _, arg := range args {
+			switch arg {
+			case "-delete", "-exec", "-execdir", "-ok", "-okdir", "-fprint", "-fprint0", "-fprintf", "-fls":
+				return "runs find " + arg
+			}
+		}
+		return ""
+	case "sed":
+		// Besides -i, sed scripts can write files and run commands, so
+		// only printing lines, as in sed -n '10,20p', counts as reading
+		if len(args) >= 2 && args[0] == "-n" && sedPrint.MatchString(args[1]) {
+			return ""
+		}
+		return "runs sed, which can edit files"
+	case "sort":
+		if slices.ContainsFunc(args, func(arg string) bool {
+			return strings.HasPrefix(arg, "-o") || strings.HasPrefix(arg, "--output")
+		}) {
+			return "writes sorted output to a file"
+		}
+		return ""
+	case "rg", "tree", "file":
+		// rg --pre runs a command on each file; tree -o and file -C write
+		for _, arg := range args {
+			if program == "rg" && strings.HasPrefix(arg, "--pre") || program == "tree" && arg == "-o" || program == "file" && arg == "-C" {
+				return "runs " + program + " " + arg
+			}
+		}
+		return ""
+	}
+	if readOnlyPrograms[program] {
+		return ""
+	}
+	return "runs " + program + ", which is not known to only read"
+}
+
+// runsPrograms reports whether setting the environment variable name can
+// make a read-only program run other commands, as GIT_EXTERNAL_DIFF,
+// LD_PRELOAD and PAGER do
+func runsPrograms(name string) bool {
+	for _, prefix := range []string{"GIT_", "LD_", "DYLD_"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	switch name {
+	case "PAGER", "EDITOR", "VISUAL", "PATH", "BASH_ENV", "ENV", "IFS":
+		return true
+	}
+	return strings.HasSuffix(name, "_EDITOR") || strings.HasSuffix(name, "_PAGER")
+}
+
+// hostnameDisplay are the options of hostname that only print
+var hostnameDisplay = []string{"-a", "--alias", "-A", "--all-fqdns", "-d", "--domain", "-f", "--fqdn", "--long", "-i", "--ip-address", "-I", "--all-ip-addresses", "-s", "--short", "-y", "--yp", "--nis"}
+
+// dateSets explains how date run with args sets the clock, or "": with -s,
+// or with an argument other than a +FORMAT, as in date 010112002025
+func dateSets(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "+"):
+		case strings.HasPrefix(arg, "--set"):
+			return "sets the clock with date " + arg
+		case arg == "--date" || arg == "--reference" || arg == "--file":
+			i++ // the value
+		case strings.HasPrefix(arg, "--"):
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// Short options may be combined, and -d, -r and -f take the
+			// rest of the argument or the next one as their value
+			for j, c := range arg[1:] {
+				if c == 's' {
+					return "sets the clock with date -s"
+				}
+				if c == 'd' || c == 'r' || c == 'f' {
+					if j == len(arg)-2 {
+						i++
+					}
+					break
+				}
+			}
+		default:
+			return "sets the clock with date " + arg
+		}
+	}
+	return ""
+}
+
+// listOptions are the options of git branch and tag that only list
+var listOptions = []string{"-l", "--list", "-v", "-vv", "--verbose", "-r", "--remotes", "--all", "--show-current", "--contains", "--no-contains", "--merged", "--no-merged", "--points-at", "--sort", "--format", "--column"}
+
+// listsOnly reports whether git branch, tag, remote, stash, worktree,
+// reflog or config only lists
+func listsOnly(args []string) bool {
+	sub, rest := args[0], args[1:]
+	switch sub {
+	case "stash", "worktree", "reflog":
+		return len(rest) > 0 && (rest[0] == "list" || rest[0] == "show")
+	case "remote":
+		return len(rest) == 0 || rest[0] == "-v" || rest[0] == "--verbose" || rest[0] == "show" || rest[0] == "get-url"
+	case "config":
+		return slices.ContainsFunc(rest, func(arg string) bool {
+			return arg == "-l" || arg == "--list" || arg == "--get" || arg == "--get-all" || arg == "--get-regexp"
+		})
+	case "branch", "tag":
+		// Without --list, a name creates a branch or tag
+		listing := slices.Contains(rest, "-l") || slices.Contains(rest, "--list")
+		for _, arg := range rest {
+			option, _, _ := strings.Cut(arg, "=")
+			switch {
+			case !strings.HasPrefix(arg, "-"):
+				if !listing {
+					return false
+				}
+			case arg == "-a" && sub == "branch":
+			case !slices.Contains(listOptions, option):
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package permission
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommandWrites(t *testing.T) {
+	tests := []struct {
+		command string
+		reason  string // part of the reason, "" for a read-only command
+	}{
+		{"ls -la", ""},
+		{"cat go.mod | grep require", ""},
+		{"grep -rn TODO . 2>/dev/null | head -20", ""},
+		{"go test ./... 2>&1 | tail", "runs go test"},
+		{"git log --oneline -5 && git diff HEAD~1 --stat", ""},
+		{"git -C sub status", ""},
+		{"git branch -a", ""},
+		{"git tag --list 'v1.*'", ""},
+		{"git remote -v", ""},
+		{"git config --get user.email", ""},
+		{"find . -name '*.go' | xargs wc -l", ""},
+		{"sed -n '10,20p' main.go", ""},
+		{"timeout 5 cat /etc/hosts", ""},
+		{"for f in *.go; do head -1 $f; done", ""},
+		{"echo $(git rev-parse HEAD)", ""},
+		{"git grep -n TODO", ""},
+		{"go env GOPATH GOFLAGS", ""},
+		{"hostname", ""},
+		{"hostname -f", ""},
+		{"date", ""},
+		{"date -u +%Y-%m-%d", ""},
+		{"date -d yesterday +%s", ""},
+		{"date --date='2 days ago'", ""},
+		{"LC_ALL=C grep -r TODO .", ""},
+
+		{"echo hi > notes.txt", "redirects output into notes.txt"},
+		{"cat a >> b", "redirects output into b"},
+		{"rm -rf build", "runs rm"},
+		{"touch x", "runs touch"},
+		{"git commit -am wip", "runs git commit"},
+		{"git checkout main", "runs git checkout"},
+		{"git branch feature", "runs git branch"},
+		{"git branch -D old", "runs git branch"},
+		{"git tag v1.0", "runs git tag"},
+		{"git -c core.pager=sh log", "-c"},
+		{"git diff --output=patch.diff", "to a file"},
+		{"git stash", "runs git stash"},
+		{"find . -name '*.tmp' -delete", "-delete"},
+		{"find . -exec rm {} +", "-exec"},
+		{"sed -i 's/a/b/' main.go", "sed"},
+		{"sed 's/a/b/w out' main.go", "sed"},
+		{"sort -o sorted.txt list.txt", "sorted output"},
+		{"rg --pre ./convert foo", "--pre"},
+		{"find . | xargs rm", "runs rm"},
+		{"sh -c 'echo hi > f'", "redirects"},
+		{"echo $(touch x)", "runs touch"},
+		{"sudo cat /etc/shadow", "as root"},
+		{"npm install", "runs npm"},
+		{"make", "runs make"},
+		{"$EDITOR main.go", "runs $EDITOR"},
+		{"git grep -Osh x", "--open-files-in-pager"},
+		{"git grep -nOvim x", "--open-files-in-pager"},
+		{"git grep --open-files-in-pager=sh x", "--open-files-in-pager"},
+		{"go env -w GOFLAGS=-mod=mod", "go env file"},
+		{"go env -u GOFLAGS", "go env file"},
+		{"go env --w=true GOFLAGS=x", "go env file"},
+		{"hostname evil", "runs hostname evil"},
+		{"hostname -F /etc/name", "runs hostname -F"},
+		{"date -s '2020-01-01'", "sets the clock"},
+		{"date -us 10:00", "sets the clock"},
+		{"date --set=10:00", "sets the clock"},
+		{"date 010112002025", "sets the clock"},
+		{"env -S 'touch /tmp/pwn' cat", "runs touch"},
+		{"env --split-string='touch /tmp/pwn' cat", "runs touch"},
+		{"env -iS'touch /tmp/pwn' cat", "runs touch"},
+		{"env -S 'cat go.mod'", ""},
+		{"GIT_EXTERNAL_DIFF=./evil.sh git diff", "sets GIT_EXTERNAL_DIFF"},
+		{"env GIT_PAGER=./evil.sh git log", "sets GIT_PAGER"},
+		{"LD_PRELOAD=./x.so ls", "sets LD_PRELOAD"},
+		{"DYLD_INSERT_LIBRARIES=./x.dylib ls", "sets DYLD_INSERT_LIBRARIES"},
+		{"PAGER=./evil.sh git log", "sets PAGER"},
+		{"GIT_EDITOR=./evil.sh; git log", "sets GIT_EDITOR"},
+		{"git --config-env=core.pager=EVIL log", "runs git with --config-env"},
+		{"git --exec-path=./bin log", "runs git with --exec-path"},
+		{"go vet -vettool=./x ./...", "runs go with -vettool"},
+		{"go list -toolexec ./x ./...", "runs go with -toolexec"},
+		{"echo 'unterminated", "cannot be checked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			reason := CommandWrites(tt.command)
+			if tt.reason == "" {
+				if reason != "" {
+					t.Errorf("expected a read-only command, got %q", reason)
+				}
+				return
+			}
+			if !strings.Contains(reason, tt.reason) {
+				t.Errorf("expected a reason containing %q, got %q", tt.reason, reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package permission
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// PathScope confines file access to the project root and additional
+// directories. Paths are compared after resolving symlinks, so a link
+// inside the project cannot lead outside it.
+type PathScope struct {
+	mu    sync.RWMutex
+	root  string
+	roots []string
+}
+
+// NewPathScope creates a scope of the project root and additional
+// directories. Additional directories may start with ~/ and are relative
+// to the root unless absolute.
+func NewPathScope(root string, additional ...string) *PathScope {
+	s := &PathScope{root: ResolvePath(root)}
+	s.roots = append(s.roots, s.root)
+	for _, dir := range additional {
+		s.Allow(ExpandDir(dir, root))
+	}
+	return s
+}
+
+// ExpandDir makes a configured directory absolute, expanding ~/ to the home
+// directory and resolving relative directories against base
+func ExpandDir(dir, base string) string {
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[1:])
+		}
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(base, dir)
+	}
+	return filepath.Clean(dir)
+}
+
+// Root returns the resolved project root
+func (s *PathScope) Root() string {
+	return s.root
+}
+
+// Roots returns the directories in the scope
+func (s *PathScope) Roots() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.roots...)
+}
+
+// Allow adds a directory to the scope
+func (s *PathScope) Allow(dir string) {
+	dir = ResolvePath(dir)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, root := range s.roots {
+		if root == dir {
+			return
+		}
+	}
+	s.roots = append(s.roots, dir)
+}
+
+// Contains reports whether an absolute path is inside the scope
+func (s *PathScope) Contains(path string) bool {
+	path = ResolvePath(path)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, root := range s.roots {
+		if IsWithin(root, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWithin reports whether path is dir or inside it
+func IsWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// ResolvePath cleans an absolute path and resolves symlinks in the part of
+// it that exists, so paths that are about to be created resolve too
+func ResolvePath(path string) string {
+	path = filepath.Clean(path)
+	rest := ""
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
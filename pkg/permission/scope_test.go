@@ -0,0 +1,75 @@
+package permission
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPathScope(t *testing.T) {
+	base := t.TempDir()
+	project := filepath.Join(base, "project")
+	shared := filepath.Join(base, "shared")
+	secrets := filepath.Join(base, "secrets")
+	for _, dir := range []string{project, shared, secrets} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A link inside the project that leads out of it
+	if err := os.Symlink(secrets, filepath.Join(project, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	scope := NewPathScope(project, "../shared")
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{project, true},
+		{filepath.Join(project, "main.go"), true},
+		{filepath.Join(project, "new", "dir", "file.go"), true},
+		{filepath.Join(shared, "api.proto"), true},
+		{filepath.Join(project, "..", "secrets", "key"), false},
+		{filepath.Join(project, "escape", "key"), false},
+		{secrets, false},
+		{base + "/project-other/file", false},
+		{"/etc/passwd", false},
+	}
+	for _, tt := range tests {
+		if got := scope.Contains(tt.path); got != tt.want {
+			t.Errorf("Contains(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	scope.Allow(secrets)
+	if !scope.Contains(filepath.Join(project, "escape", "key")) {
+		t.Error("expected an allowed directory to be in the scope")
+	}
+	if got := len(scope.Roots()); got != 3 {
+		t.Errorf("expected 3 roots, got %d", got)
+	}
+}
+
+func TestExpandDir(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"~/shared", filepath.Join(home, "shared")},
+		{"~", home},
+		{"/opt/data/", "/opt/data"},
+		{"../sibling", "/work/sibling"},
+	}
+	for _, tt := range tests {
+		if got := ExpandDir(tt.dir, "/work/project"); got != tt.want {
+			t.Errorf("ExpandDir(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
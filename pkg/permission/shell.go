@@ -0,0 +1,684 @@
+package permission
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// ShellFinding is a dangerous part of a shell command found by AnalyzeCommand
+type ShellFinding struct {
+	Check       string // name of the check, such as "pipe-to-shell"
+	Reason      string // short form, such as "runs a downloaded script"
+	Explanation string // what the command does and why it is risky
+}
+
+// Shell checks, which disable_rules can turn off like the built-in rules
+const (
+	CheckRecursiveDelete = "recursive-delete"
+	CheckPipeToShell     = "pipe-to-shell"
+	CheckSudo            = "sudo"
+	CheckSystemWrite     = "system-write"
+	CheckUnparsed        = "unparsed"
+)
+
+// maxShellDepth bounds how deep nested scripts (sh -c, eval, $(...)) are analyzed
+const maxShellDepth = 4
+
+// AnalyzeCommand parses a shell command and explains the parts of it that
+// can do serious damage: recursive deletes, downloaded scripts piped into a
+// shell, commands run as root and redirects into system paths. Commands
+// that do not parse, such as ones with an unterminated quote, or that nest
+// too deep to check, are a finding themselves, so they are not let through
+// unchecked.
+func AnalyzeCommand(command string) []ShellFinding {
+	a := &shellAnalyzer{}
+	a.analyze(command, 0)
+	return a.findings
+}
+
+type shellAnalyzer struct {
+	findings []ShellFinding
+}
+
+func (a *shellAnalyzer) add(check, reason, format string, args ...interface{}) {
+	f := ShellFinding{Check: check, Reason: reason, Explanation: fmt.Sprintf(format, args...)}
+	if !slices.Contains(a.findings, f) {
+		a.findings = append(a.findings, f)
+	}
+}
+
+func (a *shellAnalyzer) analyze(script string, depth int) {
+	if depth > maxShellDepth {
+		a.add(CheckUnparsed, "cannot be checked",
+			"nests commands more than %d levels deep, too deep to check what it runs", maxShellDepth)
+		return
+	}
+	pipelines, err := parseShell(script)
+	if err != nil {
+		a.add(CheckUnparsed, "cannot be checked",
+			"cannot be parsed (%v), so what it runs cannot be checked", err)
+		return
+	}
+	for _, pipeline := range pipelines {
+		downloader := ""
+		for _, cmd := range pipeline {
+			for _, body := range cmd.substs {
+				a.analyze(body, depth+1)
+			}
+			args, root, nested, _ := unwrapCommand(cmd.args)
+			if nested != "" {
+				a.analyze(nested, depth+1)
+			}
+
+			program := ""
+			if len(args) > 0 {
+				program = path.Base(args[0])
+			}
+			// A download in a substitution, as in cat <(curl ...), flows
+			// down the pipeline like one run directly
+			if downloader == "" && !interpreters[program] {
+				for _, body := range cmd.substs {
+					if d := downloadIn(body); d != "" {
+						downloader = d
+						break
+					}
+				}
+			}
+			switch {
+			case program == "rm":
+				a.checkDelete(args)
+			case program == "tee":
+				for _, arg := range args[1:] {
+					if !strings.HasPrefix(arg, "-") {
+						a.checkWrite(arg)
+					}
+				}
+			case downloaders[program]:
+				if downloader == "" {
+					downloader = describeDownload(program, args)
+				}
+			}
+			if interpreters[program] {
+				if downloader != "" && readsScriptFromStdin(args) {
+					a.add(CheckPipeToShell, "runs a script downloaded from the internet",
+						"pipes %s into %s, which runs it without you seeing it first", downloader, program)
+				}
+			}
+			if interpreters[program] || program == "eval" || program == "source" || program == "." {
+				for _, body := range cmd.substs {
+					if d := downloadIn(body); d != "" {
+						a.add(CheckPipeToShell, "runs a script downloaded from the internet",
+							"runs %s with %s without you seeing it first", d, program)
+					}
+				}
+			}
+			for _, r := range cmd.redirects {
+				if r.op != "<" && r.op != "<<" && r.op != "<<-" && r.op != "<<<" && r.op != "<&" && r.op != ">&" {
+					a.checkWrite(r.target)
+				}
+			}
+
+			switch {
+			case root != "" && len(args) > 0:
+				a.add(CheckSudo, "runs as root",
+					"runs %s as root with %s, so a mistake can change or break the whole system", strings.Join(args, " "), root)
+			case root != "" && nested != "":
+				a.add(CheckSudo, "runs as root",
+					"runs %s as root with %s, so a mistake can change or break the whole system", nested, root)
+			case root == "su":
+				a.add(CheckSudo, "runs as root", "opens a root shell with su, where every command can change the whole system")
+			}
+		}
+	}
+}
+
+// checkDelete explains rm commands that delete directories recursively
+func (a *shellAnalyzer) checkDelete(args []string) {
+	recursive, force, noPreserveRoot := false, false, false
+	var targets []string
+	options := true
+	for _, arg := range args[1:] {
+		switch {
+		case options && arg == "--":
+			options = false
+		case options && strings.HasPrefix(arg, "--"):
+			recursive = recursive || arg == "--recursive"
+			force = force || arg == "--force"
+			noPreserveRoot = noPreserveRoot || arg == "--no-preserve-root"
+		case options && strings.HasPrefix(arg, "-") && arg != "-":
+			recursive = recursive || strings.ContainsAny(arg, "rR")
+			force = force || strings.Contains(arg, "f")
+		default:
+			targets = append(targets, arg)
+		}
+	}
+	if !recursive {
+		return
+	}
+
+	confirm := ""
+	if force {
+		confirm = " without asking for confirmation"
+	}
+	for _, target := range targets {
+		what, risk := deleteRisk(target)
+		switch {
+		case what != "":
+			a.add(CheckRecursiveDelete, "deletes "+what, "recursively deletes %s%s; %s", target, confirm, risk)
+		case noPreserveRoot:
+			a.add(CheckRecursiveDelete, "deletes files", "recursively deletes %s with --no-preserve-root, which lets it delete the entire filesystem%s", target, confirm)
+		default:
+			a.add(CheckRecursiveDelete, "deletes files", "recursively deletes %s and everything in it%s", target, confirm)
+		}
+	}
+}
+
+// deleteRisk describes recursive delete targets that are almost never
+// meant: what they delete, and why, or "" for an ordinary path
+func deleteRisk(target string) (what, risk string) {
+	switch target {
+	case "/", "/*":
+		what = "the entire filesystem"
+	case "~", "~/", "~/*", "$HOME", "$HOME/", "$HOME/*", "${HOME}", "${HOME}/", "${HOME}/*":
+		what = "your home directory"
+	case "*", ".", "./", "./*", ".*":
+		what = "everything in the current directory"
+	case "..", "../", "../*":
+		what = "the parent directory"
+	default:
+		switch {
+		case isSystemPath(target):
+			return "a system directory", "it is a system directory"
+		case strings.Contains(target, "$") && !strings.Contains(target, ":?"):
+			return "a path built from a variable", "it is built from a variable, so if the variable is empty it deletes a different directory, possibly /"
+		}
+		return "", ""
+	}
+	return what, "it is " + what
+}
+
+// checkWrite explains writes to system files and disk devices
+func (a *shellAnalyzer) checkWrite(target string) {
+	switch {
+	case isDevice(target):
+		a.add(CheckSystemWrite, "writes to a disk device",
+			"writes directly to the device %s, which destroys the data on it", target)
+	case isSystemPath(target):
+		a.add(CheckSystemWrite, "writes to a system file",
+			"writes to %s, a system file that other programs depend on", target)
+	}
+}
+
+// systemDirs hold files that the operating system depends on
+var systemDirs = []string{"/bin", "/boot", "/etc", "/lib", "/lib32", "/lib64", "/proc", "/sbin", "/sys", "/usr", "/var/lib", "/System", "/Library"}
+
+func isSystemPath(p string) bool {
+	if !strings.HasPrefix(p, "/") {
+		return false
+	}
+	p = path.Clean(p)
+	for _, dir := range systemDirs {
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// safeDevices are the files under /dev that are fine to write to
+var safeDevices = []string{"/dev/null", "/dev/zero", "/dev/stdout", "/dev/stderr", "/dev/stdin", "/dev/tty", "/dev/fd/", "/dev/shm/", "/dev/pts/"}
+
+func isDevice(p string) bool {
+	if !strings.HasPrefix(p, "/dev/") {
+		return false
+	}
+	for _, safe := range safeDevices {
+		if p == safe || strings.HasSuffix(safe, "/") && strings.HasPrefix(p, safe) {
+			return false
+		}
+	}
+	return true
+}
+
+// downloaders fetch content from the network
+var downloaders = map[string]bool{"curl": true, "wget": true, "fetch": true}
+
+// shells run a script given with -c
+var shells = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true, "fish": true}
+
+// interpreters run a script given on stdin or as an argument
+var interpreters = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true, "fish": true,
+	"python": true, "python3": true, "perl": true, "ruby": true, "node": true,
+}
+
+// readsScriptFromStdin reports whether an interpreter runs the script on its
+// stdin rather than a file or -c argument
+func readsScriptFromStdin(args []string) bool {
+	for _, arg := range args[1:] {
+		switch {
+		case arg == "-" || arg == "-s":
+			return true
+		case arg == "--":
+			return false
+		case strings.HasPrefix(arg, "-"):
+			if arg == "-c" || arg == "-e" || arg == "-m" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// describeDownload names what a downloader fetches, such as "a script from
+// https://example.com/install.sh"
+func describeDownload(program string, args []string) string {
+	for _, arg := range args[1:] {
+		if strings.Contains(arg, "://") {
+			return "a script from " + arg
+		}
+	}
+	return "a script downloaded with " + program
+}
+
+// downloadIn describes the download a substituted command makes, or ""
+func downloadIn(body string) string {
+	pipelines, err := parseShell(body)
+	if err != nil {
+		return ""
+	}
+	for _, pipeline := range pipelines {
+		for _, cmd := range pipeline {
+			args, _, _, _ := unwrapCommand(cmd.args)
+			if len(args) > 0 && downloaders[path.Base(args[0])] {
+				return describeDownload(path.Base(args[0]), args)
+			}
+		}
+	}
+	return ""
+}
+
+// unwrapCommand strips variable assignments and wrappers such as sudo, env
+// and xargs from a command. It returns the command they run, the wrapper
+// that runs it as root, the script of sh -c, su -c, env -S and eval, and
+// the assignments it stripped.
+func unwrapCommand(args []string) (rest []string, root, script string, assigns []string) {
+	for len(args) > 0 {
+		name := path.Base(args[0])
+		switch {
+		case isAssignment(args[0]):
+			assigns = append(assigns, args[0])
+			args = args[1:]
+		case name == "sudo" || name == "doas" || name == "pkexec":
+			root = name
+			args = skipOptions(args[1:], "-u", "-g", "-C", "-D", "-h", "-p", "-r", "-t", "-U")
+		case name == "su":
+			root = name
+			for i, arg := range args[1:] {
+				if (arg == "-c" || arg == "--command") && i+2 < len(args) {
+					return nil, root, args[i+2], assigns
+				}
+			}
+			return nil, root, "", assigns
+		case name == "env":
+			if script, ok := envScript(args[1:]); ok {
+				return nil, root, script, assigns
+			}
+			args = skipOptions(args[1:], "-u", "-C", "--unset", "--chdir")
+		case name == "xargs":
+			args = skipOptions(args[1:], "-I", "-n", "-P", "-L", "-d", "-E", "-s", "-a")
+		case name == "timeout":
+			args = skipOptions(args[1:], "-s", "-k")
+			if len(args) > 0 {
+				args = args[1:]
+			}
+		case name == "nice":
+			args = skipOptions(args[1:], "-n")
+		case name == "nohup" || name == "exec" || name == "command" || name == "builtin" || name == "time" ||
+			name == "!" || name == "{" || name == "then" || name == "do" || name == "else" || name == "if" || name == "while" || name == "until":
+			args = args[1:]
+		case name == "eval":
+			return nil, root, strings.Join(args[1:], " "), assigns
+		default:
+			if shells[name] {
+				for i, arg := range args[1:] {
+					if arg == "-c" && i+2 < len(args) {
+						return args, root, args[i+2], assigns
+					}
+				}
+			}
+			return args, root, "", assigns
+		}
+	}
+	return nil, root, "", assigns
+}
+
+// envScript returns the command env -S (--split-string) runs: the option's
+// value, which env splits into words like a shell, followed by the
+// arguments after it
+func envScript(args []string) (string, bool) {
+	for i := 0; i < len(args) && strings.HasPrefix(args[i], "-") && args[i] != "--"; i++ {
+		arg := args[i]
+		var value string
+		rest := i + 1
+		switch {
+		case arg == "-u" || arg == "-C" || arg == "--unset" || arg == "--chdir":
+			i++
+			continue
+		case strings.HasPrefix(arg, "--split-string="):
+			value = strings.TrimPrefix(arg, "--split-string=")
+		case arg == "--split-string" || arg == "-S":
+			if rest < len(args) {
+				value = args[rest]
+				rest++
+			}
+		case !strings.HasPrefix(arg, "--") && strings.Contains(arg, "S"):
+			// Combined short options, as in -iS 'cmd' or -S'cmd'
+			if value = arg[strings.Index(arg, "S")+1:]; value == "" && rest < len(args) {
+				value = args[rest]
+				rest++
+			}
+		default:
+			continue
+		}
+		return strings.TrimSpace(strings.Join(append([]string{value}, args[min(rest, len(args)):]...), " ")), true
+	}
+	return "", false
+}
+
+// skipOptions drops leading options, and the values of those listed
+func skipOptions(args []string, withValue ...string) []string {
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if args[0] == "--" {
+			return args[1:]
+		}
+		if slices.Contains(withValue, args[0]) && len(args) > 1 {
+			args = args[1:]
+		}
+		args = args[1:]
+	}
+	return args
+}
+
+// isAssignment reports whether a word is a variable assignment like FOO=bar
+func isAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i, c := range name {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// shellCommand is a simple command: its words after quote removal, its
+// redirects and the bodies of the substitutions in its words
+type shellCommand struct {
+	args      []string
+	redirects []shellRedirect
+	substs    []string
+}
+
+type shellRedirect struct {
+	op     string
+	target string
+}
+
+// shellToken is a word or an operator
+type shellToken struct {
+	text   string
+	op     bool
+	substs []string // bodies of $(...), `...`, <(...) and >(...) in a word
+}
+
+// shellOperators are the control and redirect operators, longest first
+var shellOperators = []string{"&>>", "<<<", "<<-", "&&", "||", ";;", "|&", "&>", ">>", ">|", ">&", "<<", "<&", "<>", ";", "&", "|", "(", ")", "<", ">"}
+
+// parseShell splits a script into pipelines of simple commands
+func parseShell(script string) ([][]*shellCommand, error) {
+	tokens, err := tokenizeShell(script)
+	if err != nil {
+		return nil, err
+	}
+
+	var pipelines [][]*shellCommand
+	var pipeline []*shellCommand
+	cmd := &shellCommand{}
+	redirect := ""
+	endCommand := func() {
+		if len(cmd.args) > 0 || len(cmd.redirects) > 0 {
+			pipeline = append(pipeline, cmd)
+		}
+		cmd = &shellCommand{}
+		redirect = ""
+	}
+	endPipeline := func() {
+		endCommand()
+		if len(pipeline) > 0 {
+			pipelines = append(pipelines, pipeline)
+		}
+		pipeline = nil
+	}
+
+	for _, tok := range tokens {
+		if !tok.op {
+			cmd.substs = append(cmd.substs, tok.substs...)
+			if redirect != "" {
+				cmd.redirects = append(cmd.redirects, shellRedirect{op: redirect, target: tok.text})
+				redirect = ""
+				continue
+			}
+			cmd.args = append(cmd.args, tok.text)
+			continue
+		}
+		switch tok.text {
+		case "|", "|&":
+			endCommand()
+		case ";", ";;", "&", "&&", "||", "(", ")", "\n":
+			endPipeline()
+		default:
+			redirect = tok.text
+		}
+	}
+	endPipeline()
+	return pipelines, nil
+}
+
+// tokenizeShell splits a script into words and operators the way a POSIX
+// shell does, removing quotes and skipping comments and here-documents.
+// Expansions are kept as written.
+func tokenizeShell(s string) ([]shellToken, error) {
+	var tokens []shellToken
+	var word strings.Builder
+	var substs []string
+	var heredocs []string
+	inWord, delimiter := false, false
+
+	flush := func() {
+		if !inWord {
+			return
+		}
+		if delimiter {
+			heredocs = append(heredocs, word.String())
+			delimiter = false
+		}
+		tokens = append(tokens, shellToken{text: word.String(), substs: substs})
+		word.Reset()
+		substs = nil
+		inWord = false
+	}
+	// substitution reads the body of $(...) or similar starting at i, the
+	// index after the opening parenthesis
+	substitution := func(i, prefix int) (int, error) {
+		body, end, err := matchParen(s, i)
+		if err != nil {
+			return 0, err
+		}
+		// $((...)) is arithmetic, not a command
+		if !strings.HasPrefix(body, "(") {
+			substs = append(substs, body)
+		}
+		word.WriteString(s[i-prefix : end])
+		inWord = true
+		return end, nil
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 < len(s) && s[i+1] != '\n' {
+				word.WriteByte(s[i+1])
+				inWord = true
+			}
+			i += 2
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			inWord = true
+			i += end + 2
+		case c == '"':
+			inWord = true
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				switch {
+				case s[j] == '\\' && j+1 < len(s):
+					if !strings.ContainsRune("$`\"\\\n", rune(s[j+1])) {
+						word.WriteByte('\\')
+					}
+					j++
+					word.WriteByte(s[j])
+				case s[j] == '$' && j+1 < len(s) && s[j+1] == '(':
+					end, err := substitution(j+2, 2)
+					if err != nil {
+						return nil, err
+					}
+					j = end - 1
+				case s[j] == '`':
+					end := strings.IndexByte(s[j+1:], '`')
+					if end < 0 {
+						return nil, fmt.Errorf("unterminated backquote")
+					}
+					substs = append(substs, s[j+1:j+1+end])
+					word.WriteString(s[j : j+end+2])
+					j += end + 1
+				default:
+					word.WriteByte(s[j])
+				}
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			i = j + 1
+		case c == '$' && i+1 < len(s) && s[i+1] == '(',
+			(c == '<' || c == '>') && !inWord && i+1 < len(s) && s[i+1] == '(':
+			end, err := substitution(i+2, 2)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		case c == '`':
+			end := strings.IndexByte(s[i+1:], '`')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated backquote")
+			}
+			substs = append(substs, s[i+1:i+1+end])
+			word.WriteString(s[i : i+end+2])
+			inWord = true
+			i += end + 2
+		case c == '#' && !inWord:
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == ' ' || c == '\t' || c == '\r':
+			flush()
+			i++
+		case c == '\n':
+			flush()
+			tokens = append(tokens, shellToken{text: "\n", op: true})
+			i++
+			for _, delim := range heredocs {
+				for i < len(s) {
+					line := s[i:]
+					if end := strings.IndexByte(line, '\n'); end >= 0 {
+						line = line[:end]
+					}
+					i += len(line) + 1
+					if strings.TrimLeft(line, "\t") == delim {
+						break
+					}
+				}
+			}
+			heredocs = nil
+		case strings.IndexByte(";&|()<>", c) >= 0:
+			// A file descriptor number belongs to the redirect, as in 2>
+			if (c == '<' || c == '>') && inWord && substs == nil && isDigits(word.String()) {
+				word.Reset()
+				inWord = false
+			}
+			flush()
+			for _, op := range shellOperators {
+				if strings.HasPrefix(s[i:], op) {
+					tokens = append(tokens, shellToken{text: op, op: true})
+					delimiter = op == "<<" || op == "<<-"
+					i += len(op)
+					break
+				}
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+			i++
+		}
+	}
+	flush()
+	return tokens, nil
+}
+
+// matchParen returns the text up to the parenthesis closing the one before
+// start, and the index after it
+func matchParen(s string, start int) (string, int, error) {
+	depth := 1
+	for j := start; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '\'':
+			end := strings.IndexByte(s[j+1:], '\'')
+			if end < 0 {
+				return "", 0, fmt.Errorf("unterminated single quote")
+			}
+			j += end + 1
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s[start:j], j + 1, nil
+			}
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated substitution")
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
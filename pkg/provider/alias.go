@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"maps"
+	"strings"
+	"sync"
+)
+
+// ModelAlias maps the built-in model aliases to actual model IDs
+var ModelAlias = map[string]string{
+	// Claude models
+	"sonnet": "claude-sonnet-4-5-20250929",
+	"opus":   "claude-opus-4-5-20251101",
+	"haiku":  "claude-haiku-4-5-20251101",
+	// Gemini models
+	"gemini":       "gemini-2.0-flash",
+	"gemini2":      "gemini-2.0-flash",
+	"gemini-pro":   "gemini-1.5-pro",
+	"gemini-flash": "gemini-2.0-flash",
+	// OpenAI models
+	"gpt5":     "gpt-5.2",
+	"gpt4":     "gpt-4-turbo",
+	"gpt4o":    "gpt-4o",
+	"gpt4mini": "gpt-4o-mini",
+	"o3":       "o3",
+	// DeepSeek models
+	"deepseek": "deepseek-chat",
+	"coder":    "deepseek-coder",
+	"reasoner": "deepseek-reasoner",
+	"r1":       "deepseek-reasoner",
+	// Ollama models
+	"ollama": "qwen3",
+	"llama":  "llama3.3",
+	"qwen":   "qwen3",
+	"gemma":  "gemma3",
+	"phi":    "phi4",
+}
+
+// userAliases are the aliases of the model_aliases setting. They take
+// precedence over ModelAlias.
+var (
+	userAliasesMu sync.RWMutex
+	userAliases   map[string]string
+)
+
+// SetModelAliases replaces the user's model aliases. An alias stands for a
+// model ID, a built-in alias, or "provider:model".
+func SetModelAliases(aliases map[string]string) {
+	userAliasesMu.Lock()
+	defer userAliasesMu.Unlock()
+	userAliases = maps.Clone(aliases)
+}
+
+// UserModelAliases returns a copy of the user's model aliases
+func UserModelAliases() map[string]string {
+	userAliasesMu.RLock()
+	defer userAliasesMu.RUnlock()
+	return maps.Clone(userAliases)
+}
+
+// ModelAliases returns every alias: the built-in ones, overridden by the
+// user's
+func ModelAliases() map[string]string {
+	aliases := maps.Clone(ModelAlias)
+	maps.Copy(aliases, UserModelAliases())
+	return aliases
+}
+
+// ExpandAlias returns the model a user alias stands for, or model itself
+// when it is not one. The result may still be a built-in alias.
+func ExpandAlias(model string) string {
+	userAliasesMu.RLock()
+	defer userAliasesMu.RUnlock()
+	if target, ok := userAliases[model]; ok {
+		return target
+	}
+	return model
+}
+
+// ResolveModel resolves a model alias to the actual model ID, dropping the
+// provider of an explicit "provider:model". GitHub Models keep their
+// "github/" form, which their provider expects.
+func ResolveModel(model string) string {
+	model = ExpandAlias(model)
+	if providerType, name, ok := SplitProviderPrefix(model); ok {
+		if providerType == ProviderTypeGitHub {
+			return "github/" + strings.TrimPrefix(name, "github/")
+		}
+		model = name
+	}
+	if resolved, ok := ModelAlias[model]; ok {
+		return resolved
+	}
+	return model
+}
@@ -0,0 +1,307 @@
+// Package azureopenai implements a provider for Azure OpenAI, which serves
+// OpenAI models from deployments in an Azure resource. Requests are routed
+// to a deployment by model name and authenticated with an API key or a
+// Microsoft Entra ID (Azure AD) token.
+package azureopenai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/provider/openai"
+)
+
+const (
+	// DefaultAPIVersion is the GA data-plane API version requests use
+	// unless another is configured
+	DefaultAPIVersion = "2024-10-21"
+
+	// TokenScope is the resource Entra ID tokens are requested for
+	TokenScope = "https://cognitiveservices.azure.com"
+
+	// tokenRefresh renews a cached token this long before it expires
+	tokenRefresh = 5 * time.Minute
+)
+
+// TokenFunc returns an Entra ID access token for TokenScope
+type TokenFunc func(ctx context.Context) (string, error)
+
+// Provider implements the Azure OpenAI provider. Each deployment is served
+// by an OpenAI provider whose base URL is the deployment's.
+type Provider struct {
+	endpoint    string
+	apiVersion  string
+	apiKey      string
+	token       TokenFunc
+	deployments map[string]string // model name to deployment name
+	timeout     time.Duration
+
+	mu      sync.Mutex
+	clients map[string]*openai.Provider // by deployment
+	last    *openai.Provider            // the latest used, for RateLimits
+}
+
+// Option configures the Provider
+type Option func(*Provider)
+
+// WithAPIKey authenticates with a key of the Azure resource
+func WithAPIKey(key string) Option {
+	return func(p *Provider) {
+		p.apiKey = key
+	}
+}
+
+// WithToken authenticates with Entra ID tokens from token, which is called
+// for each request and should cache
+func WithToken(token TokenFunc) Option {
+	return func(p *Provider) {
+		p.token = token
+	}
+}
+
+// WithAPIVersion sets the api-version of requests
+func WithAPIVersion(version string) Option {
+	return func(p *Provider) {
+		if version != "" {
+			p.apiVersion = version
+		}
+	}
+}
+
+// WithDeployment routes requests for model to deployment. Models without a
+// deployment are sent to the deployment named like the model.
+func WithDeployment(model, deployment string) Option {
+	return func(p *Provider) {
+		p.deployments[model] = deployment
+	}
+}
+
+// WithTimeout sets a custom timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.timeout = timeout
+	}
+}
+
+// New creates an Azure OpenAI provider for the resource at endpoint, such
+// as https://myresource.openai.azure.com
+func New(endpoint string, opts ...Option) *Provider {
+	p := &Provider{
+		endpoint:    strings.TrimRight(endpoint, "/"),
+		apiVersion:  DefaultAPIVersion,
+		deployments: make(map[string]string),
+		clients:     make(map[string]*openai.Provider),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// FromEnv creates a provider from AZURE_OPENAI_ENDPOINT, authenticated with
+// AZURE_OPENAI_API_KEY, else AZURE_OPENAI_AD_TOKEN, else a token from the
+// Azure CLI. AZURE_OPENAI_API_VERSION overrides the API version, and
+// AZURE_OPENAI_DEPLOYMENTS lists model=deployment pairs separated by commas.
+func FromEnv() (*Provider, error) {
+	endpoint := os.Getenv("AZURE_OPENAI_ENDPOINT")
+	if endpoint == "" {
+		return nil, fmt.Errorf("AZURE_OPENAI_ENDPOINT not set")
+	}
+	opts := []Option{WithAPIVersion(os.Getenv("AZURE_OPENAI_API_VERSION"))}
+	for _, pair := range strings.Split(os.Getenv("AZURE_OPENAI_DEPLOYMENTS"), ",") {
+		model, deployment, ok := strings.Cut(pair, "=")
+		model, deployment = strings.TrimSpace(model), strings.TrimSpace(deployment)
+		if !ok || model == "" || deployment == "" {
+			continue
+		}
+		opts = append(opts, WithDeployment(model, deployment))
+	}
+	switch {
+	case os.Getenv("AZURE_OPENAI_API_KEY") != "":
+		opts = append(opts, WithAPIKey(os.Getenv("AZURE_OPENAI_API_KEY")))
+	case os.Getenv("AZURE_OPENAI_AD_TOKEN") != "":
+		opts = append(opts, WithToken(StaticToken(os.Getenv("AZURE_OPENAI_AD_TOKEN"))))
+	default:
+		if _, err := exec.LookPath("az"); err != nil {
+			return nil, fmt.Errorf("no credentials for Azure OpenAI: set AZURE_OPENAI_API_KEY or AZURE_OPENAI_AD_TOKEN, or sign in with the Azure CLI")
+		}
+		opts = append(opts, WithToken(AzureCLIToken()))
+	}
+	return New(endpoint, opts...), nil
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return "azureopenai"
+}
+
+// Endpoint returns the resource endpoint
+func (p *Provider) Endpoint() string {
+	return p.endpoint
+}
+
+// SupportedModels returns the models with a configured deployment; any
+// other model is sent to the deployment of its name
+func (p *Provider) SupportedModels() []string {
+	models := make([]string, 0, len(p.deployments))
+	for model := range p.deployments {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
+
+// SupportsFeature checks if a feature is supported
+func (p *Provider) SupportsFeature(feature provider.Feature) bool {
+	switch feature {
+	case provider.FeatureStreaming,
+		provider.FeatureToolUse,
+		provider.FeatureVision:
+		return true
+	default:
+		return false
+	}
+}
+
+// RateLimits returns the quotas reported with the latest response
+func (p *Provider) RateLimits() *provider.RateLimits {
+	p.mu.Lock()
+	last := p.last
+	p.mu.Unlock()
+	if last == nil {
+		return nil
+	}
+	return last.RateLimits()
+}
+
+// CreateMessage performs a chat completion on the model's deployment
+func (p *Provider) CreateMessage(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	return p.client(req.Model).CreateMessage(ctx, req)
+}
+
+// CreateMessageStream performs a streaming chat completion on the model's
+// deployment
+func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Request) (provider.StreamReader, error) {
+	return p.client(req.Model).CreateMessageStream(ctx, req)
+}
+
+// Deployment returns the deployment requests for model are sent to
+func (p *Provider) Deployment(model string) string {
+	if deployment, ok := p.deployments[model]; ok {
+		return deployment
+	}
+	return model
+}
+
+// client returns the OpenAI provider of the model's deployment
+func (p *Provider) client(model string) *openai.Provider {
+	deployment := p.Deployment(model)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c, ok := p.clients[deployment]
+	if !ok {
+		opts := []openai.Option{
+			openai.WithBaseURL(p.endpoint + "/openai/deployments/" + url.PathEscape(deployment)),
+			openai.WithTransport(&transport{p: p}),
+		}
+		if p.timeout > 0 {
+			opts = append(opts, openai.WithTimeout(p.timeout))
+		}
+		c = openai.New("", opts...)
+		p.clients[deployment] = c
+	}
+	p.last = c
+	return c
+}
+
+// transport adds the api-version and the Azure authentication to requests
+// the OpenAI provider makes
+type transport struct {
+	p *Provider
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	q := r.URL.Query()
+	q.Set("api-version", t.p.apiVersion)
+	r.URL.RawQuery = q.Encode()
+
+	r.Header.Del("Authorization")
+	switch {
+	case t.p.apiKey != "":
+		r.Header.Set("api-key", t.p.apiKey)
+	case t.p.token != nil:
+		token, err := t.p.token(r.Context())
+		if err != nil {
+			closeBody(req)
+			return nil, fmt.Errorf("failed to get an Azure AD token: %w", err)
+		}
+		r.Header.Set("Authorization", "Bearer "+token)
+	default:
+		closeBody(req)
+		return nil, errors.New("no API key or Azure AD token for Azure OpenAI")
+	}
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// closeBody closes the body of a request that is not sent, as a
+// RoundTripper must
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// StaticToken returns a TokenFunc for a token obtained elsewhere
+func StaticToken(token string) TokenFunc {
+	return func(context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// AzureCLIToken returns a TokenFunc that gets tokens from the signed-in
+// Azure CLI, caching each until shortly before it expires
+func AzureCLIToken() TokenFunc {
+	var mu sync.Mutex
+	var token string
+	var expires time.Time
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if token != "" && time.Until(expires) > tokenRefresh {
+			return token, nil
+		}
+		out, err := exec.CommandContext(ctx, "az", "account", "get-access-token", "--resource", TokenScope, "--output", "json").Output()
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+				err = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return "", fmt.Errorf("az account get-access-token failed: %w", err)
+		}
+		var result struct {
+			AccessToken string `json:"accessToken"`
+			ExpiresOn   int64  `json:"expires_on"`
+		}
+		if err := json.Unmarshal(out, &result); err != nil || result.AccessToken == "" {
+			return "", fmt.Errorf("unexpected output from az account get-access-token")
+		}
+		token = result.AccessToken
+		expires = time.Now().Add(time.Hour)
+		if result.ExpiresOn > 0 {
+			expires = time.Unix(result.ExpiresOn, 0)
+		}
+		return token, nil
+	}
+}
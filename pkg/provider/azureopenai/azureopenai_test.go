@@ -0,0 +1,123 @@
+package azureopenai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// request is what the fake Azure OpenAI endpoint saw
+type request struct {
+	path, version, apiKey, auth string
+}
+
+func fakeEndpoint(t *testing.T) (*httptest.Server, *request) {
+	t.Helper()
+	got := &request{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*got = request{
+			path:    r.URL.Path,
+			version: r.URL.Query().Get("api-version"),
+			apiKey:  r.Header.Get("api-key"),
+			auth:    r.Header.Get("Authorization"),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, got
+}
+
+func newRequest(model string) *provider.Request {
+	return &provider.Request{
+		Model:    model,
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentBlock{&provider.TextBlock{Text: "hello"}}}},
+	}
+}
+
+func TestCreateMessage(t *testing.T) {
+	server, got := fakeEndpoint(t)
+	p := New(server.URL+"/", WithAPIKey("azure-key"), WithDeployment("gpt-4o", "prod-gpt4o"))
+	if p.Name() != "azureopenai" {
+		t.Errorf("Name() = %q", p.Name())
+	}
+
+	tests := []struct {
+		model string
+		path  string
+	}{
+		{"gpt-4o", "/openai/deployments/prod-gpt4o/chat/completions"},
+		{"o3-mini", "/openai/deployments/o3-mini/chat/completions"},
+	}
+	for _, tt := range tests {
+		resp, err := p.CreateMessage(context.Background(), newRequest(tt.model))
+		if err != nil {
+			t.Fatalf("CreateMessage(%s): %v", tt.model, err)
+		}
+		if got.path != tt.path || got.version != DefaultAPIVersion || got.apiKey != "azure-key" || got.auth != "" {
+			t.Errorf("unexpected request for %s: %+v", tt.model, *got)
+		}
+		if text, ok := resp.Content[0].(*provider.TextBlock); !ok || text.Text != "hi" {
+			t.Errorf("unexpected response content: %#v", resp.Content)
+		}
+	}
+	if models := p.SupportedModels(); len(models) != 1 || models[0] != "gpt-4o" {
+		t.Errorf("unexpected models %v", models)
+	}
+}
+
+func TestToken(t *testing.T) {
+	server, got := fakeEndpoint(t)
+	calls := 0
+	token := func(context.Context) (string, error) {
+		calls++
+		return "ad-token", nil
+	}
+	p := New(server.URL, WithToken(token), WithAPIVersion("2025-01-01-preview"))
+	for i := 0; i < 2; i++ {
+		if _, err := p.CreateMessage(context.Background(), newRequest("gpt-4o")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got.auth != "Bearer ad-token" || got.apiKey != "" || got.version != "2025-01-01-preview" {
+		t.Errorf("unexpected request %+v", *got)
+	}
+	if calls != 2 {
+		t.Errorf("expected a token for each request, got %d", calls)
+	}
+
+	failing := New(server.URL, WithToken(func(context.Context) (string, error) {
+		return "", errors.New("not signed in")
+	}))
+	if _, err := failing.CreateMessage(context.Background(), newRequest("gpt-4o")); err == nil {
+		t.Error("expected an error without a token")
+	}
+	if _, err := New(server.URL).CreateMessage(context.Background(), newRequest("gpt-4o")); err == nil {
+		t.Error("expected an error without credentials")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "")
+	if _, err := FromEnv(); err == nil {
+		t.Error("expected an error without an endpoint")
+	}
+
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "https://res.openai.azure.com/")
+	t.Setenv("AZURE_OPENAI_API_KEY", "key")
+	t.Setenv("AZURE_OPENAI_DEPLOYMENTS", "gpt-4o=prod, o3-mini = reasoning ,bad")
+	p, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Endpoint() != "https://res.openai.azure.com" || p.apiKey != "key" || p.apiVersion != DefaultAPIVersion {
+		t.Errorf("unexpected provider %+v", p)
+	}
+	if p.Deployment("gpt-4o") != "prod" || p.Deployment("o3-mini") != "reasoning" || p.Deployment("gpt-4.1") != "gpt-4.1" {
+		t.Errorf("unexpected deployments %v", p.deployments)
+	}
+}
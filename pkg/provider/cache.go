@@ -0,0 +1,357 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// CachedProvider answers requests it has answered before from disk. The key
+// is a hash of everything sent (model, system prompt, messages, tools, and
+// settings), so only an exact rerun hits: a headless job retried in CI
+// replays its earlier responses without calling the provider or paying
+// for them.
+type CachedProvider struct {
+	AIProvider
+	dir string
+
+	mu     sync.Mutex
+	masked []string
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewCachedProvider caches the responses of p in dir
+func NewCachedProvider(p AIProvider, dir string) *CachedProvider {
+	return &CachedProvider{AIProvider: p, dir: dir}
+}
+
+// KeyMasker is implemented by providers whose behavior depends on a hash of
+// requests, like CachedProvider. Text passed to MaskInKey, such as a nonce
+// made anew for each session, is left out of the hash, so that otherwise
+// identical requests hash the same.
+type KeyMasker interface {
+	MaskInKey(text string)
+}
+
+// MaskInKey leaves text out of the keys of later requests
+func (c *CachedProvider) MaskInKey(text string) {
+	if text == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !slices.Contains(c.masked, text) {
+		c.masked = append(c.masked, text)
+	}
+}
+
+// Stats returns the number of requests answered from the cache and sent
+func (c *CachedProvider) Stats() (hits, misses int64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+// RateLimits passes on the rate limits of the cached provider
+func (c *CachedProvider) RateLimits() *RateLimits {
+	if reporter, ok := c.AIProvider.(RateLimitReporter); ok {
+		return reporter.RateLimits()
+	}
+	return nil
+}
+
+// CreateMessage returns the cached response to req, or sends it and caches
+// a complete response
+func (c *CachedProvider) CreateMessage(ctx context.Context, req *Request) (*Response, error) {
+	key, err := c.key(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp, ok := c.load(key); ok {
+		c.hits.Add(1)
+		return resp, nil
+	}
+	c.misses.Add(1)
+	resp, err := c.AIProvider.CreateMessage(ctx, req)
+	if err == nil {
+		c.store(key, resp)
+	}
+	return resp, err
+}
+
+// CreateMessageStream replays the cached response to req as stream events,
+// or streams it from the provider and caches it once complete
+func (c *CachedProvider) CreateMessageStream(ctx context.Context, req *Request) (StreamReader, error) {
+	key, err := c.key(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp, ok := c.load(key); ok {
+		c.hits.Add(1)
+		return &replayStream{events: responseEvents(resp)}, nil
+	}
+	c.misses.Add(1)
+	stream, err := c.AIProvider.CreateMessageStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingStream{StreamReader: stream, done: func(resp *Response) { c.store(key, resp) }}, nil
+}
+
+// key hashes everything a request sends except whether it streams, which
+// does not change the answer
+func (c *CachedProvider) key(req *Request) (string, error) {
+	data, err := json.Marshal(struct {
+		Provider    string                 `json:"provider"`
+		Model       string                 `json:"model"`
+		System      []ContentBlock         `json:"system"`
+		Messages    []Message              `json:"messages"`
+		Tools       []Tool                 `json:"tools"`
+		MaxTokens   int                    `json:"max_tokens"`
+		Temperature float64                `json:"temperature"`
+		Thinking    *ThinkingConfig        `json:"thinking"`
+		Extra       map[string]interface{} `json:"extra"`
+		ToolChoice  *ToolChoice            `json:"tool_choice,omitempty"`
+		Stop        []string               `json:"stop_sequences,omitempty"`
+		TopP        float64                `json:"top_p,omitempty"`
+		TopK        int                    `json:"top_k,omitempty"`
+		Seed        *int64                 `json:"seed,omitempty"`
+	}{c.Name(), req.Model, req.System, req.Messages, req.Tools, req.MaxTokens, req.Temperature, req.Thinking, req.Extra, req.ToolChoice, req.StopSequences, req.TopP, req.TopK, req.Seed})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash request: %w", err)
+	}
+	c.mu.Lock()
+	for _, text := range c.masked {
+		data = bytes.ReplaceAll(data, []byte(text), []byte("(masked)"))
+	}
+	c.mu.Unlock()
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// cachedResponse is a response as stored on disk
+type cachedResponse struct {
+	ID         string            `json:"id"`
+	Model      string            `json:"model"`
+	Content    []json.RawMessage `json:"content"`
+	StopReason StopReason        `json:"stop_reason"`
+}
+
+// load returns the cached response for key. A replayed response cost
+// nothing, so it reports no usage.
+func (c *CachedProvider) load(key string) (*Response, bool) {
+	data, err := os.ReadFile(filepath.Join(c.dir, key+".json"))
+	if err != nil {
+		return nil, false
+	}
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, false
+	}
+	resp := &Response{ID: cached.ID, Model: cached.Model, StopReason: cached.StopReason}
+	for _, raw := range cached.Content {
+		block, err := UnmarshalContentBlock(raw)
+		if err != nil {
+			return nil, false
+		}
+		resp.Content = append(resp.Content, block)
+	}
+	return resp, true
+}
+
+// store caches a complete response. Failing to cache only costs a later
+// hit, so errors are ignored.
+func (c *CachedProvider) store(key string, resp *Response) {
+	if resp == nil || resp.StopReason == "" {
+		return
+	}
+	cached := cachedResponse{ID: resp.ID, Model: resp.Model, StopReason: resp.StopReason}
+	for _, block := range resp.Content {
+		data, err := json.Marshal(block)
+		if err != nil {
+			return
+		}
+		cached.Content = append(cached.Content, data)
+	}
+	data, err := json.Marshal(cached)
+	if err != nil || os.MkdirAll(c.dir, 0700) != nil {
+		return
+	}
+	// Write then rename, so concurrent jobs never read half a response
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || os.Rename(tmp.Name(), filepath.Join(c.dir, key+".json")) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// responseEvents turns a response into the stream events that deliver it
+func responseEvents(resp *Response) []StreamingEvent {
+	events := []StreamingEvent{&MessageStartEvent{Message: &Response{ID: resp.ID, Model: resp.Model}}}
+	for i, block := range resp.Content {
+		var start ContentBlock
+		var delta DeltaBlock
+		switch b := block.(type) {
+		case *TextBlock:
+			start, delta = &TextBlock{}, &TextDelta{Text: b.Text}
+		case *ThinkingBlock:
+			start, delta = &ThinkingBlock{}, &ThinkingDelta{Thinking: b.Thinking}
+		case *ToolUseBlock:
+			input, _ := json.Marshal(b.Input)
+			start, delta = &ToolUseBlock{ID: b.ID, Name: b.Name}, &InputJSONDelta{PartialJSON: string(input)}
+		default:
+			start = block
+		}
+		events = append(events, &ContentBlockStartEvent{Index: i, ContentBlock: start})
+		if delta != nil {
+			events = append(events, &ContentBlockDeltaEvent{Index: i, Delta: delta})
+		}
+		if b, ok := block.(*ThinkingBlock); ok && b.Signature != "" {
+			events = append(events, &ContentBlockDeltaEvent{Index: i, Delta: &SignatureDelta{Signature: b.Signature}})
+		}
+		events = append(events, &ContentBlockStopEvent{Index: i})
+	}
+	return append(events,
+		&MessageDeltaEvent{Delta: &MessageDelta{StopReason: resp.StopReason}, Usage: &Usage{}},
+		&MessageStopEvent{},
+	)
+}
+
+// replayStream delivers prepared events
+type replayStream struct {
+	events []StreamingEvent
+}
+
+func (s *replayStream) Recv() (StreamingEvent, error) {
+	if len(s.events) == 0 {
+		return nil, io.EOF
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, nil
+}
+
+func (s *replayStream) Close() error { return nil }
+
+// recordingStream passes on the events of a stream while assembling the
+// response they deliver, which it hands to done when the stream ends
+type recordingStream struct {
+	StreamReader
+	done func(*Response)
+
+	resp      *Response
+	toolInput map[int]*strings.Builder
+	failed    bool
+}
+
+func (s *recordingStream) Recv() (StreamingEvent, error) {
+	event, err := s.StreamReader.Recv()
+	if err == io.EOF && !s.failed && s.resp != nil {
+		s.finish()
+		s.resp = nil
+	}
+	if err != nil {
+		if err != io.EOF {
+			s.failed = true
+		}
+		return event, err
+	}
+	s.record(event)
+	return event, nil
+}
+
+// record adds an event to the response being assembled
+func (s *recordingStream) record(event StreamingEvent) {
+	if s.resp == nil {
+		if start, ok := event.(*MessageStartEvent); ok && start.Message != nil {
+			s.resp = &Response{ID: start.Message.ID, Model: start.Message.Model}
+			s.toolInput = make(map[int]*strings.Builder)
+		}
+		return
+	}
+	switch ev := event.(type) {
+	case *ContentBlockStartEvent:
+		if ev.Index != len(s.resp.Content) {
+			s.failed = true // blocks out of order; do not cache a guess
+			return
+		}
+		s.resp.Content = append(s.resp.Content, copyBlock(ev.ContentBlock))
+	case *ContentBlockDeltaEvent:
+		if ev.Index >= len(s.resp.Content) {
+			return
+		}
+		switch d := ev.Delta.(type) {
+		case *TextDelta:
+			if b, ok := s.resp.Content[ev.Index].(*TextBlock); ok {
+				b.Text += d.Text
+			}
+		case *ThinkingDelta:
+			if b, ok := s.resp.Content[ev.Index].(*ThinkingBlock); ok {
+				b.Thinking += d.Thinking
+			}
+		case *SignatureDelta:
+			if b, ok := s.resp.Content[ev.Index].(*ThinkingBlock); ok {
+				b.Signature += d.Signature
+			}
+		case *InputJSONDelta:
+			if s.toolInput[ev.Index] == nil {
+				s.toolInput[ev.Index] = &strings.Builder{}
+			}
+			s.toolInput[ev.Index].WriteString(d.PartialJSON)
+		}
+	case *MessageDeltaEvent:
+		if ev.Delta != nil {
+			s.resp.StopReason = ev.Delta.StopReason
+		}
+	case *ToolInfoEvent, *ToolResultInfoEvent:
+		s.failed = true // tools ran inside the provider and cannot be replayed
+	}
+}
+
+// finish completes the tool inputs and hands over the response
+func (s *recordingStream) finish() {
+	for i, input := range s.toolInput {
+		b, ok := s.resp.Content[i].(*ToolUseBlock)
+		if !ok {
+			continue
+		}
+		b.Input = make(map[string]interface{})
+		if err := json.Unmarshal([]byte(input.String()), &b.Input); err != nil {
+			return
+		}
+	}
+	s.done(s.resp)
+}
+
+// copyBlock copies a block from a start event, which the reader of the
+// stream may fill in as deltas arrive
+func copyBlock(block ContentBlock) ContentBlock {
+	switch b := block.(type) {
+	case *TextBlock:
+		c := *b
+		return &c
+	case *ThinkingBlock:
+		c := *b
+		return &c
+	case *ToolUseBlock:
+		c := *b
+		return &c
+	}
+	return block
+}
@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// streamingProvider streams fixed events, optionally failing at the end
+type streamingProvider struct {
+	events []StreamingEvent
+	fail   error
+	calls  int
+}
+
+func (p *streamingProvider) Name() string { return "streaming" }
+
+func (p *streamingProvider) CreateMessage(ctx context.Context, req *Request) (*Response, error) {
+	p.calls++
+	return &Response{ID: "msg_2", StopReason: StopReasonEndTurn, Content: []ContentBlock{&TextBlock{Text: "plain"}}, Usage: Usage{InputTokens: 5}}, nil
+}
+
+func (p *streamingProvider) CreateMessageStream(ctx context.Context, req *Request) (StreamReader, error) {
+	p.calls++
+	return &testStream{events: append([]StreamingEvent(nil), p.events...), fail: p.fail}, nil
+}
+
+func (p *streamingProvider) SupportedModels() []string { return nil }
+
+func (p *streamingProvider) SupportsFeature(feature Feature) bool { return true }
+
+type testStream struct {
+	events []StreamingEvent
+	fail   error
+}
+
+func (s *testStream) Recv() (StreamingEvent, error) {
+	if len(s.events) == 0 {
+		if s.fail != nil {
+			return nil, s.fail
+		}
+		return nil, io.EOF
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, nil
+}
+
+func (s *testStream) Close() error { return nil }
+
+// drain reads a stream to the end and returns its text, tool input, and
+// stop reason
+func drain(t *testing.T, stream StreamReader) (text, input string, stop StopReason) {
+	t.Helper()
+	for {
+		ev, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch e := ev.(type) {
+		case *ContentBlockDeltaEvent:
+			switch d := e.Delta.(type) {
+			case *TextDelta:
+				text += d.Text
+			case *InputJSONDelta:
+				input += d.PartialJSON
+			}
+		case *MessageDeltaEvent:
+			stop = e.Delta.StopReason
+		}
+	}
+}
+
+func TestCachedProvider(t *testing.T) {
+	prov := &streamingProvider{events: []StreamingEvent{
+		&MessageStartEvent{Message: &Response{ID: "msg_1", Model: "m"}},
+		&ContentBlockStartEvent{Index: 0, ContentBlock: &TextBlock{}},
+		&ContentBlockDeltaEvent{Index: 0, Delta: &TextDelta{Text: "Read"}},
+		&ContentBlockDeltaEvent{Index: 0, Delta: &TextDelta{Text: "ing."}},
+		&ContentBlockStopEvent{Index: 0},
+		&ContentBlockStartEvent{Index: 1, ContentBlock: &ToolUseBlock{ID: "t1", Name: "Read"}},
+		&ContentBlockDeltaEvent{Index: 1, Delta: &InputJSONDelta{PartialJSON: `{"path":`}},
+		&ContentBlockDeltaEvent{Index: 1, Delta: &InputJSONDelta{PartialJSON: `"go.mod"}`}},
+		&ContentBlockStopEvent{Index: 1},
+		&MessageDeltaEvent{Delta: &MessageDelta{StopReason: StopReasonToolUse}, Usage: &Usage{InputTokens: 10}},
+		&MessageStopEvent{},
+	}}
+	cache := NewCachedProvider(prov, t.TempDir())
+	req := &Request{Model: "m", Stream: true, Messages: []Message{{Role: RoleUser, Content: []ContentBlock{&TextBlock{Text: "hi"}}}}}
+	ctx := context.Background()
+
+	stream, err := cache.CreateMessageStream(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, input, stop := drain(t, stream); text != "Reading." || input != `{"path":"go.mod"}` || stop != StopReasonToolUse {
+		t.Fatalf("unexpected stream: %q %q %q", text, input, stop)
+	}
+
+	// The same request, streamed or not, is answered from the cache
+	stream, err = cache.CreateMessageStream(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, input, stop := drain(t, stream); text != "Reading." || input != `{"path":"go.mod"}` || stop != StopReasonToolUse {
+		t.Errorf("unexpected replay: %q %q %q", text, input, stop)
+	}
+	plain := *req
+	plain.Stream = false
+	resp, err := cache.CreateMessage(ctx, &plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Content) != 2 || resp.Usage.InputTokens != 0 || resp.ID != "msg_1" {
+		t.Errorf("unexpected cached response: %+v", resp)
+	}
+	if use, ok := resp.Content[1].(*ToolUseBlock); !ok || use.Input["path"] != "go.mod" {
+		t.Errorf("unexpected tool use: %+v", resp.Content[1])
+	}
+	if hits, misses := cache.Stats(); hits != 2 || misses != 1 || prov.calls != 1 {
+		t.Errorf("got %d hits, %d misses, %d calls", hits, misses, prov.calls)
+	}
+
+	// A different request is sent
+	other := plain
+	other.MaxTokens = 100
+	if resp, err := cache.CreateMessage(ctx, &other); err != nil || resp.ID != "msg_2" {
+		t.Errorf("expected a provider call, got %+v, %v", resp, err)
+	}
+}
+
+func TestCachedProviderSkipsFailedStreams(t *testing.T) {
+	prov := &streamingProvider{
+		events: []StreamingEvent{
+			&MessageStartEvent{Message: &Response{ID: "msg_1"}},
+			&ContentBlockStartEvent{Index: 0, ContentBlock: &TextBlock{}},
+			&ContentBlockDeltaEvent{Index: 0, Delta: &TextDelta{Text: "Par"}},
+		},
+		fail: errors.New("connection reset"),
+	}
+	cache := NewCachedProvider(prov, t.TempDir())
+	req := &Request{Model: "m", Stream: true}
+	for i := 0; i < 2; i++ {
+		stream, err := cache.CreateMessageStream(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			if _, err := stream.Recv(); err != nil {
+				break
+			}
+		}
+	}
+	if prov.calls != 2 {
+		t.Errorf("expected the failed response not to be cached, got %d calls", prov.calls)
+	}
+}
@@ -36,6 +36,9 @@ type Provider struct {
 	baseURL     string
 	client      *http.Client
 	betaHeader  string
+
+	// Quotas from the latest response headers
+	rateLimits provider.RateLimitTracker
 }
 
 // Option is a function that configures the Provider
@@ -111,6 +114,11 @@ func (p *Provider) Name() string {
 	return "claude"
 }
 
+// RateLimits returns the quotas reported with the latest response
+func (p *Provider) RateLimits() *provider.RateLimits {
+	return p.rateLimits.RateLimits()
+}
+
 // SupportedModels returns the list of supported models
 func (p *Provider) SupportedModels() []string {
 	return []string{
@@ -129,6 +137,7 @@ func (p *Provider) SupportsFeature(feature provider.Feature) bool {
 		provider.FeatureToolUse,
 		provider.FeatureVision,
 		provider.FeatureThinking,
+		provider.FeatureWebSearch,
 		provider.FeatureCaching:
 		return true
 	default:
@@ -145,6 +154,14 @@ type claudeRequest struct {
 	Tools     []claudeTool             `json:"tools,omitempty"`
 	Stream    bool                     `json:"stream,omitempty"`
 	Thinking  *provider.ThinkingConfig `json:"thinking,omitempty"`
+
+	ToolChoice *provider.ToolChoice `json:"tool_choice,omitempty"` // same format as Claude's
+
+	StopSequences []string `json:"stop_sequences,omitempty"`
+
+	Temperature float64 `json:"temperature,omitempty"`
+	TopP        float64 `json:"top_p,omitempty"`
+	TopK        int     `json:"top_k,omitempty"`
 }
 
 type claudeMessage struct {
@@ -153,11 +170,16 @@ type claudeMessage struct {
 }
 
 type claudeTool struct {
+	Type        string          `json:"type,omitempty"` // server tools only
 	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	InputSchema json.RawMessage `json:"input_schema"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema,omitempty"`
+	MaxUses     int             `json:"max_uses,omitempty"`
 }
 
+// webSearchTool is Claude's built-in web search, run by the API
+var webSearchTool = claudeTool{Type: "web_search_20250305", Name: "web_search", MaxUses: 5}
+
 // claudeResponse is the response format from Claude API
 type claudeResponse struct {
 	ID           string               `json:"id"`
@@ -178,6 +200,50 @@ type claudeContentBlock struct {
 	Input     map[string]interface{} `json:"input,omitempty"`
 	Thinking  string                 `json:"thinking,omitempty"`
 	Signature string                 `json:"signature,omitempty"`
+	Data      string                 `json:"data,omitempty"`
+	Citations []claudeCitation       `json:"citations,omitempty"`
+	ToolUseID string                 `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage        `json:"content,omitempty"` // web search results
+}
+
+// claudeCitation is a source Claude cited for a text block
+type claudeCitation struct {
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	Title     string `json:"title"`
+	CitedText string `json:"cited_text"`
+}
+
+// convertCitations keeps the web search citations, which have a URL
+func convertCitations(citations []claudeCitation) []provider.Citation {
+	var result []provider.Citation
+	for _, c := range citations {
+		if c.URL != "" {
+			result = append(result, provider.Citation{URL: c.URL, Title: c.Title, CitedText: c.CitedText})
+		}
+	}
+	return result
+}
+
+// searchResults summarizes the content of a web_search_tool_result block,
+// a list of results or an error
+func searchResults(content json.RawMessage) (string, bool) {
+	var results []struct {
+		URL   string `json:"url"`
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal(content, &results); err != nil {
+		var failed struct {
+			ErrorCode string `json:"error_code"`
+		}
+		json.Unmarshal(content, &failed)
+		return "web search failed: " + failed.ErrorCode, true
+	}
+	lines := make([]string, 0, len(results))
+	for _, r := range results {
+		lines = append(lines, fmt.Sprintf("%s - %s", r.Title, r.URL))
+	}
+	return strings.Join(lines, "\n"), false
 }
 
 // CreateMessage performs a non-streaming chat completion
@@ -201,6 +267,7 @@ func (p *Provider) CreateMessage(ctx context.Context, req *provider.Request) (*p
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
+	p.rateLimits.Update(provider.ParseAnthropicRateLimits(resp, time.Now()))
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
@@ -237,6 +304,7 @@ func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Reques
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
+	p.rateLimits.Update(provider.ParseAnthropicRateLimits(resp, time.Now()))
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
@@ -254,15 +322,26 @@ type sseStreamReader struct {
 	scanner *bufio.Scanner
 	event   string
 	data    strings.Builder
+
+	// Searches Claude runs itself, by block index, whose queries are
+	// reported once their input is complete
+	searches map[int]*searchUse
+}
+
+// searchUse is a server-side web search in a stream
+type searchUse struct {
+	id    string
+	input strings.Builder
 }
 
 func newSSEStreamReader(ctx context.Context, body io.ReadCloser) *sseStreamReader {
 	scanner := bufio.NewScanner(body)
 	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024) // 10MB buffer
 	return &sseStreamReader{
-		ctx:     ctx,
-		body:    body,
-		scanner: scanner,
+		ctx:      ctx,
+		body:     body,
+		scanner:  scanner,
+		searches: make(map[int]*searchUse),
 	}
 }
 
@@ -329,6 +408,16 @@ func (r *sseStreamReader) parseSSEEvent(event string, data string) provider.Stre
 		if err := json.Unmarshal([]byte(data), &block); err == nil {
 			var cb provider.ContentBlock
 			switch block.ContentBlock.Type {
+			case "server_tool_use":
+				r.searches[block.Index] = &searchUse{id: block.ContentBlock.ID}
+			case "web_search_tool_result":
+				content, isError := searchResults(block.ContentBlock.Content)
+				return &provider.ToolResultInfoEvent{
+					ToolUseID: block.ContentBlock.ToolUseID,
+					Name:      "WebSearch",
+					Content:   content,
+					IsError:   isError,
+				}
 			case "text":
 				cb = &provider.TextBlock{Text: block.ContentBlock.Text}
 			case "tool_use":
@@ -337,7 +426,9 @@ func (r *sseStreamReader) parseSSEEvent(event string, data string) provider.Stre
 					Name: block.ContentBlock.Name,
 				}
 			case "thinking":
-				cb = &provider.ThinkingBlock{Thinking: block.ContentBlock.Thinking}
+				cb = &provider.ThinkingBlock{Thinking: block.ContentBlock.Thinking, Signature: block.ContentBlock.Signature}
+			case "redacted_thinking":
+				cb = &provider.RedactedThinkingBlock{Data: block.ContentBlock.Data}
 			}
 			return &provider.ContentBlockStartEvent{
 				Index:        block.Index,
@@ -353,6 +444,9 @@ func (r *sseStreamReader) parseSSEEvent(event string, data string) provider.Stre
 				Text        string `json:"text,omitempty"`
 				PartialJSON string `json:"partial_json,omitempty"`
 				Thinking    string `json:"thinking,omitempty"`
+				Signature   string `json:"signature,omitempty"`
+
+				Citation claudeCitation `json:"citation"`
 			} `json:"delta"`
 		}
 		if err := json.Unmarshal([]byte(data), &delta); err == nil {
@@ -360,10 +454,22 @@ func (r *sseStreamReader) parseSSEEvent(event string, data string) provider.Stre
 			switch delta.Delta.Type {
 			case "text_delta":
 				db = &provider.TextDelta{Text: delta.Delta.Text}
+			case "citations_delta":
+				citations := convertCitations([]claudeCitation{delta.Delta.Citation})
+				if len(citations) == 0 {
+					return nil
+				}
+				db = &provider.CitationsDelta{Citation: citations[0]}
 			case "input_json_delta":
+				if search := r.searches[delta.Index]; search != nil {
+					search.input.WriteString(delta.Delta.PartialJSON)
+					return nil
+				}
 				db = &provider.InputJSONDelta{PartialJSON: delta.Delta.PartialJSON}
 			case "thinking_delta":
 				db = &provider.ThinkingDelta{Thinking: delta.Delta.Thinking}
+			case "signature_delta":
+				db = &provider.SignatureDelta{Signature: delta.Delta.Signature}
 			}
 			return &provider.ContentBlockDeltaEvent{
 				Index: delta.Index,
@@ -376,6 +482,12 @@ func (r *sseStreamReader) parseSSEEvent(event string, data string) provider.Stre
 			Index int `json:"index"`
 		}
 		if err := json.Unmarshal([]byte(data), &stop); err == nil {
+			if search := r.searches[stop.Index]; search != nil {
+				delete(r.searches, stop.Index)
+				input := make(map[string]interface{})
+				json.Unmarshal([]byte(search.input.String()), &input)
+				return &provider.ToolInfoEvent{ID: search.id, Name: "WebSearch", Input: input}
+			}
 			return &provider.ContentBlockStopEvent{Index: stop.Index}
 		}
 
@@ -426,7 +538,9 @@ func (p *Provider) convertRequest(req *provider.Request) *claudeRequest {
 	for _, msg := range req.Messages {
 		content := make([]interface{}, 0, len(msg.Content))
 		for _, block := range msg.Content {
-			content = append(content, p.convertContentBlock(block))
+			if converted := p.convertContentBlock(block); converted != nil {
+				content = append(content, converted)
+			}
 		}
 		messages = append(messages, claudeMessage{
 			Role:    string(msg.Role),
@@ -442,6 +556,9 @@ func (p *Provider) convertRequest(req *provider.Request) *claudeRequest {
 			InputSchema: t.InputSchema,
 		})
 	}
+	if req.WebSearch {
+		tools = append(tools, webSearchTool)
+	}
 
 	maxTokens := req.MaxTokens
 	if maxTokens == 0 {
@@ -467,13 +584,39 @@ func (p *Provider) convertRequest(req *provider.Request) *claudeRequest {
 		}
 	}
 
+	// Claude rejects thinking in requests that force a tool call
+	var toolChoice *provider.ToolChoice
+	thinking := req.Thinking
+	if len(tools) > 0 && req.ToolChoice != nil {
+		toolChoice = req.ToolChoice
+		if toolChoice.Forced() {
+			thinking = nil
+		}
+	}
+
+	// Claude has no seed, takes temperatures up to 1, and while thinking
+	// allows neither temperature nor top_k and only a top_p of 0.95 or more
+	temperature, topP, topK := min(req.Temperature, 1), req.TopP, req.TopK
+	if thinking != nil {
+		temperature, topK = 0, 0
+		if topP < 0.95 {
+			topP = 0
+		}
+	}
+
 	return &claudeRequest{
-		Model:     provider.ResolveModel(req.Model),
-		Messages:  messages,
-		MaxTokens: maxTokens,
-		System:    system,
-		Tools:     tools,
-		Thinking:  req.Thinking,
+		Model:      provider.ResolveModel(req.Model),
+		Messages:   messages,
+		MaxTokens:  maxTokens,
+		System:     system,
+		Tools:      tools,
+		Thinking:   thinking,
+		ToolChoice: toolChoice,
+
+		StopSequences: req.StopSequences,
+		Temperature:   temperature,
+		TopP:          topP,
+		TopK:          topK,
 	}
 }
 
@@ -498,12 +641,36 @@ func (p *Provider) convertContentBlock(block provider.ContentBlock) interface{}
 			"input": b.Input,
 		}
 	case *provider.ToolResultBlock:
+		var content interface{} = b.Content
+		if len(b.Images) > 0 {
+			var blocks []interface{}
+			for _, block := range b.ContentBlocks() {
+				blocks = append(blocks, p.convertContentBlock(block))
+			}
+			content = blocks
+		}
 		return map[string]interface{}{
 			"type":        "tool_result",
 			"tool_use_id": b.ToolUseID,
-			"content":     b.Content,
+			"content":     content,
 			"is_error":    b.IsError,
 		}
+	case *provider.ThinkingBlock:
+		// Thinking is only accepted back with the signature Claude issued for
+		// it; thinking from other providers or stripped transcripts is dropped
+		if b.Signature == "" {
+			return nil
+		}
+		return map[string]interface{}{
+			"type":      "thinking",
+			"thinking":  b.Thinking,
+			"signature": b.Signature,
+		}
+	case *provider.RedactedThinkingBlock:
+		return map[string]interface{}{
+			"type": "redacted_thinking",
+			"data": b.Data,
+		}
 	default:
 		return nil
 	}
@@ -516,7 +683,7 @@ func (p *Provider) convertResponse(resp *claudeResponse) *provider.Response {
 	for _, block := range resp.Content {
 		switch block.Type {
 		case "text":
-			content = append(content, &provider.TextBlock{Text: block.Text})
+			content = append(content, &provider.TextBlock{Text: block.Text, Citations: convertCitations(block.Citations)})
 		case "tool_use":
 			content = append(content, &provider.ToolUseBlock{
 				ID:    block.ID,
@@ -528,6 +695,8 @@ func (p *Provider) convertResponse(resp *claudeResponse) *provider.Response {
 				Thinking:  block.Thinking,
 				Signature: block.Signature,
 			})
+		case "redacted_thinking":
+			content = append(content, &provider.RedactedThinkingBlock{Data: block.Data})
 		}
 	}
 
@@ -0,0 +1,224 @@
+package claude
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+func TestConvertThinkingBlocks(t *testing.T) {
+	p := New("key")
+	req := p.convertRequest(&provider.Request{
+		Model: "sonnet",
+		Messages: []provider.Message{{
+			Role: provider.RoleAssistant,
+			Content: []provider.ContentBlock{
+				&provider.ThinkingBlock{Thinking: "signed", Signature: "sig"},
+				&provider.ThinkingBlock{Thinking: "from another provider"},
+				&provider.RedactedThinkingBlock{Data: "opaque"},
+				&provider.TextBlock{Text: "answer"},
+			},
+		}},
+	})
+
+	content := req.Messages[0].Content
+	if len(content) != 3 {
+		t.Fatalf("expected unsigned thinking to be dropped, got %v", content)
+	}
+	thinking := content[0].(map[string]interface{})
+	if thinking["type"] != "thinking" || thinking["signature"] != "sig" {
+		t.Errorf("unexpected thinking block: %v", thinking)
+	}
+	redacted := content[1].(map[string]interface{})
+	if redacted["type"] != "redacted_thinking" || redacted["data"] != "opaque" {
+		t.Errorf("unexpected redacted thinking block: %v", redacted)
+	}
+}
+
+func TestConvertToolResultImages(t *testing.T) {
+	p := New("key")
+	image := &provider.ImageBlock{Source: provider.ImageSource{Type: "base64", MediaType: "image/png", Data: "AAAA"}}
+	req := p.convertRequest(&provider.Request{
+		Model: "sonnet",
+		Messages: []provider.Message{{
+			Role: provider.RoleUser,
+			Content: []provider.ContentBlock{
+				&provider.ToolResultBlock{ToolUseID: "t1", Content: "text only"},
+				&provider.ToolResultBlock{ToolUseID: "t2", Content: "chart", Images: []*provider.ImageBlock{image}},
+			},
+		}},
+	})
+
+	content := req.Messages[0].Content
+	if text := content[0].(map[string]interface{})["content"]; text != "text only" {
+		t.Errorf("expected text content, got %v", text)
+	}
+	blocks, ok := content[1].(map[string]interface{})["content"].([]interface{})
+	if !ok || len(blocks) != 2 {
+		t.Fatalf("expected text and image blocks, got %v", content[1])
+	}
+	if img := blocks[1].(map[string]interface{}); img["type"] != "image" {
+		t.Errorf("unexpected image block: %v", img)
+	}
+}
+
+func TestStreamThinkingEvents(t *testing.T) {
+	stream := strings.Join([]string{
+		`event: content_block_start`,
+		`data: {"index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		``,
+		`event: content_block_delta`,
+		`data: {"index":0,"delta":{"type":"thinking_delta","thinking":"hmm"}}`,
+		``,
+		`event: content_block_delta`,
+		`data: {"index":0,"delta":{"type":"signature_delta","signature":"sig"}}`,
+		``,
+		`event: content_block_start`,
+		`data: {"index":1,"content_block":{"type":"redacted_thinking","data":"opaque"}}`,
+		``,
+	}, "\n") + "\n"
+	r := newSSEStreamReader(context.Background(), io.NopCloser(strings.NewReader(stream)))
+
+	var events []provider.StreamingEvent
+	for {
+		ev, err := r.Recv()
+		if err != nil {
+			break
+		}
+		events = append(events, ev)
+	}
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d: %#v", len(events), events)
+	}
+	if d, ok := events[2].(*provider.ContentBlockDeltaEvent).Delta.(*provider.SignatureDelta); !ok || d.Signature != "sig" {
+		t.Errorf("expected signature delta, got %#v", events[2])
+	}
+	if b, ok := events[3].(*provider.ContentBlockStartEvent).ContentBlock.(*provider.RedactedThinkingBlock); !ok || b.Data != "opaque" {
+		t.Errorf("expected redacted thinking block, got %#v", events[3])
+	}
+}
+
+func TestWebSearch(t *testing.T) {
+	p := New("key")
+	req := p.convertRequest(&provider.Request{
+		Model:     "sonnet",
+		Tools:     []provider.Tool{{Name: "Read", Description: "Read a file", InputSchema: []byte(`{"type":"object"}`)}},
+		WebSearch: true,
+	})
+	if len(req.Tools) != 2 || req.Tools[1].Type != "web_search_20250305" || req.Tools[1].Name != "web_search" {
+		t.Errorf("expected the web search tool, got %+v", req.Tools)
+	}
+
+	resp := p.convertResponse(&claudeResponse{Content: []claudeContentBlock{
+		{Type: "server_tool_use", ID: "srv_1", Name: "web_search"},
+		{Type: "web_search_tool_result", ToolUseID: "srv_1"},
+		{Type: "text", Text: "Go 1.24 is out.", Citations: []claudeCitation{
+			{Type: "web_search_result_location", URL: "https://go.dev/doc/go1.24", Title: "Go 1.24", CitedText: "Go 1.24 is released"},
+		}},
+	}})
+	if len(resp.Content) != 1 {
+		t.Fatalf("expected only the text block, got %#v", resp.Content)
+	}
+	text := resp.Content[0].(*provider.TextBlock)
+	if len(text.Citations) != 1 || text.Citations[0].URL != "https://go.dev/doc/go1.24" || text.Citations[0].CitedText != "Go 1.24 is released" {
+		t.Errorf("unexpected citations: %+v", text.Citations)
+	}
+}
+
+func TestStreamWebSearchEvents(t *testing.T) {
+	stream := strings.Join([]string{
+		`event: content_block_start`,
+		`data: {"index":0,"content_block":{"type":"server_tool_use","id":"srv_1","name":"web_search","input":{}}}`,
+		``,
+		`event: content_block_delta`,
+		`data: {"index":0,"delta":{"type":"input_json_delta","partial_json":"{\"query\": \"go 1.24\"}"}}`,
+		``,
+		`event: content_block_stop`,
+		`data: {"index":0}`,
+		``,
+		`event: content_block_start`,
+		`data: {"index":1,"content_block":{"type":"web_search_tool_result","tool_use_id":"srv_1","content":[{"type":"web_search_result","url":"https://go.dev/doc/go1.24","title":"Go 1.24","encrypted_content":"x"}]}}`,
+		``,
+		`event: content_block_start`,
+		`data: {"index":2,"content_block":{"type":"text","text":""}}`,
+		``,
+		`event: content_block_delta`,
+		`data: {"index":2,"delta":{"type":"citations_delta","citation":{"type":"web_search_result_location","url":"https://go.dev/doc/go1.24","title":"Go 1.24","cited_text":"released","encrypted_index":"y"}}}`,
+		``,
+	}, "\n") + "\n"
+	r := newSSEStreamReader(context.Background(), io.NopCloser(strings.NewReader(stream)))
+
+	var events []provider.StreamingEvent
+	for {
+		ev, err := r.Recv()
+		if err != nil {
+			break
+		}
+		events = append(events, ev)
+	}
+	if len(events) != 5 {
+		t.Fatalf("expected 5 events, got %d: %#v", len(events), events)
+	}
+	if start := events[0].(*provider.ContentBlockStartEvent); start.ContentBlock != nil {
+		t.Errorf("expected the search block to have no content block, got %#v", start.ContentBlock)
+	}
+	if info := events[1].(*provider.ToolInfoEvent); info.Name != "WebSearch" || info.Input["query"] != "go 1.24" {
+		t.Errorf("unexpected search event: %#v", info)
+	}
+	if result := events[2].(*provider.ToolResultInfoEvent); result.IsError || result.Content != "Go 1.24 - https://go.dev/doc/go1.24" {
+		t.Errorf("unexpected search result event: %#v", result)
+	}
+	if d, ok := events[4].(*provider.ContentBlockDeltaEvent).Delta.(*provider.CitationsDelta); !ok || d.Citation.CitedText != "released" {
+		t.Errorf("expected citation delta, got %#v", events[4])
+	}
+}
+
+func TestConvertToolChoice(t *testing.T) {
+	p := New("key")
+	thinking := &provider.ThinkingConfig{Type: "enabled", BudgetTokens: 4000}
+	tools := []provider.Tool{{Name: "TodoWrite"}}
+
+	req := p.convertRequest(&provider.Request{Tools: tools, Thinking: thinking, ToolChoice: &provider.ToolChoice{Type: provider.ToolChoiceTool, Name: "TodoWrite"}})
+	if req.ToolChoice == nil || req.ToolChoice.Name != "TodoWrite" || req.Thinking != nil {
+		t.Errorf("expected the forced tool without thinking, got %+v, %+v", req.ToolChoice, req.Thinking)
+	}
+	req = p.convertRequest(&provider.Request{Tools: tools, Thinking: thinking, ToolChoice: &provider.ToolChoice{Type: provider.ToolChoiceNone}})
+	if req.ToolChoice == nil || req.ToolChoice.Type != "none" || req.Thinking == nil {
+		t.Errorf("expected none with thinking, got %+v, %+v", req.ToolChoice, req.Thinking)
+	}
+	// Claude rejects a tool choice without tools
+	req = p.convertRequest(&provider.Request{ToolChoice: &provider.ToolChoice{Type: provider.ToolChoiceAny}})
+	if req.ToolChoice != nil {
+		t.Errorf("expected no tool choice without tools, got %+v", req.ToolChoice)
+	}
+}
+
+func TestConvertStopSequences(t *testing.T) {
+	req := New("key").convertRequest(&provider.Request{MaxTokens: 50, StopSequences: []string{"</answer>"}})
+	if req.MaxTokens != 50 || len(req.StopSequences) != 1 || req.StopSequences[0] != "</answer>" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+}
+
+func TestConvertSampling(t *testing.T) {
+	p := New("key")
+	seed := int64(7)
+	req := p.convertRequest(&provider.Request{Temperature: 1.5, TopP: 0.9, TopK: 40, Seed: &seed})
+	if req.Temperature != 1 || req.TopP != 0.9 || req.TopK != 40 {
+		t.Errorf("unexpected sampling: %v %v %v", req.Temperature, req.TopP, req.TopK)
+	}
+
+	// Thinking leaves out what Claude rejects with it
+	thinking := &provider.ThinkingConfig{Type: "enabled", BudgetTokens: 4000}
+	req = p.convertRequest(&provider.Request{Temperature: 0.5, TopP: 0.9, TopK: 40, Thinking: thinking})
+	if req.Temperature != 0 || req.TopP != 0 || req.TopK != 0 {
+		t.Errorf("expected no sampling with thinking, got %v %v %v", req.Temperature, req.TopP, req.TopK)
+	}
+	req = p.convertRequest(&provider.Request{TopP: 0.97, Thinking: thinking})
+	if req.TopP != 0.97 {
+		t.Errorf("expected a top_p of 0.95 or more to be kept, got %v", req.TopP)
+	}
+}
@@ -13,10 +13,22 @@ import (
 	"github.com/xinguang/agentic-coder/pkg/provider"
 )
 
+// Spec describes the Claude Code CLI. Its stream-json output has been
+// stable since 1.0, so a single output format is supported.
+var Spec = provider.CLISpec{
+	Name:       "Claude Code CLI",
+	Provider:   provider.ProviderTypeClaudeCLI,
+	Binary:     "claude",
+	Install:    "npm install -g @anthropic-ai/claude-code",
+	MinVersion: "1.0.0",
+}
+
 // Provider implements a provider using local Claude Code CLI
 type Provider struct {
 	model   string
 	cliPath string
+	version string // pinned version constraint, "" = any supported version
+	check   provider.CLICheck
 }
 
 // Option configures the Provider
@@ -36,11 +48,18 @@ func WithCLIPath(path string) Option {
 	}
 }
 
+// WithVersion pins the CLI to versions matching a constraint such as ">=1.0.30"
+func WithVersion(constraint string) Option {
+	return func(p *Provider) {
+		p.version = constraint
+	}
+}
+
 // New creates a new Claude CLI provider
 func New(opts ...Option) *Provider {
 	p := &Provider{
 		model:   "sonnet",
-		cliPath: "claude",
+		cliPath: Spec.Binary,
 	}
 
 	for _, opt := range opts {
@@ -55,6 +74,11 @@ func (p *Provider) Name() string {
 	return "claude-cli"
 }
 
+// Check finds the CLI and verifies its version
+func (p *Provider) Check(ctx context.Context) (*provider.CLIInfo, error) {
+	return p.check.Run(ctx, Spec, p.cliPath, p.version)
+}
+
 // SupportedModels returns supported models
 func (p *Provider) SupportedModels() []string {
 	return []string{"sonnet", "opus", "haiku"}
@@ -103,6 +127,11 @@ func (p *Provider) CreateMessage(ctx context.Context, req *provider.Request) (*p
 
 // CreateMessageStream performs a streaming completion using Claude CLI
 func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Request) (provider.StreamReader, error) {
+	info, err := p.Check(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build prompt from messages
 	var prompt strings.Builder
 	for _, msg := range req.Messages {
@@ -126,7 +155,7 @@ func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Reques
 		prompt.String(),
 	}
 
-	cmd := exec.CommandContext(ctx, p.cliPath, args...)
+	cmd := exec.CommandContext(ctx, info.Path, args...)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -0,0 +1,214 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CLIVersion is the version a CLI tool reports with --version
+type CLIVersion struct {
+	Major, Minor, Patch int
+}
+
+var cliVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+var constraintVersionPattern = regexp.MustCompile(`^(\d+)(?:\.(\d+))?(?:\.(\d+))?$`)
+
+// ParseCLIVersion finds the first version number, such as 1.0.44, in s
+func ParseCLIVersion(s string) (CLIVersion, bool) {
+	m := cliVersionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return CLIVersion{}, false
+	}
+	var v CLIVersion
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+	return v, true
+}
+
+// Compare returns -1, 0 or 1 as v is older than, equal to or newer than o
+func (v CLIVersion) Compare(o CLIVersion) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// AtLeast reports whether v is the same as or newer than "major.minor.patch"
+func (v CLIVersion) AtLeast(min string) bool {
+	o, ok := ParseCLIVersion(min)
+	return !ok || v.Compare(o) >= 0
+}
+
+func (v CLIVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// CheckVersionConstraint reports whether v satisfies a constraint: a
+// comma-separated list of versions prefixed with >=, <=, >, < or =. A bare
+// version pins releases: "1.2" matches 1.2.x and "1.2.3" only 1.2.3.
+func CheckVersionConstraint(v CLIVersion, constraint string) (bool, error) {
+	for _, part := range strings.Split(constraint, ",") {
+		part = strings.TrimSpace(part)
+		op := ""
+		for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+			if strings.HasPrefix(part, candidate) {
+				op = candidate
+				break
+			}
+		}
+		m := constraintVersionPattern.FindStringSubmatch(strings.TrimSpace(part[len(op):]))
+		if m == nil {
+			return false, fmt.Errorf("invalid version constraint %q", part)
+		}
+		var o CLIVersion
+		o.Major, _ = strconv.Atoi(m[1])
+		o.Minor, _ = strconv.Atoi(m[2])
+		o.Patch, _ = strconv.Atoi(m[3])
+
+		cmp := v.Compare(o)
+		var match bool
+		switch op {
+		case ">=":
+			match = cmp >= 0
+		case "<=":
+			match = cmp <= 0
+		case ">":
+			match = cmp > 0
+		case "<":
+			match = cmp < 0
+		default:
+			// Compare only the parts the pin names
+			match = v.Major == o.Major &&
+				(m[2] == "" || v.Minor == o.Minor) &&
+				(m[3] == "" || v.Patch == o.Patch)
+		}
+		if !match {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// CLISpec describes a CLI tool a provider shells out to
+type CLISpec struct {
+	Name       string // display name, such as "Claude Code CLI"
+	Provider   ProviderType
+	Binary     string // default binary looked up on PATH
+	Install    string // command that installs the tool
+	MinVersion string // oldest supported version
+}
+
+// CLIInfo describes the installed CLI tool found by CheckCLI
+type CLIInfo struct {
+	Path    string
+	Version CLIVersion
+	Known   bool // whether --version reported a version
+}
+
+// String describes the tool, such as "1.0.44 (/usr/local/bin/claude)"
+func (i *CLIInfo) String() string {
+	if !i.Known {
+		return fmt.Sprintf("unknown version (%s)", i.Path)
+	}
+	return fmt.Sprintf("%s (%s)", i.Version, i.Path)
+}
+
+// CLIError explains why a CLI tool cannot be used and how to fix it
+type CLIError struct {
+	Spec   CLISpec
+	Reason string
+	Err    error
+}
+
+func (e *CLIError) Error() string {
+	return fmt.Sprintf("%s %s\n  install or upgrade with: %s\n  or set cli_providers.%s.path in config to the binary",
+		e.Spec.Name, e.Reason, e.Spec.Install, e.Spec.Provider)
+}
+
+func (e *CLIError) Unwrap() error { return e.Err }
+
+// cliVersionTimeout bounds how long a CLI tool may take to report its version
+const cliVersionTimeout = 10 * time.Second
+
+// CheckCLI finds the tool at path (or the default binary on PATH), asks it
+// for its version and checks it against the supported minimum and the
+// optional pinned constraint
+func CheckCLI(ctx context.Context, spec CLISpec, path, constraint string) (*CLIInfo, error) {
+	if path == "" {
+		path = spec.Binary
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, &CLIError{Spec: spec, Reason: fmt.Sprintf("not found (looked for %q)", path), Err: err}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cliVersionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, resolved, "--version").CombinedOutput()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = ctx.Err()
+		}
+		return nil, &CLIError{Spec: spec, Reason: fmt.Sprintf("at %s does not run: %v", resolved, err), Err: err}
+	}
+
+	info := &CLIInfo{Path: resolved}
+	info.Version, info.Known = ParseCLIVersion(string(out))
+	if !info.Known {
+		if constraint != "" {
+			return nil, &CLIError{Spec: spec, Reason: fmt.Sprintf("at %s does not report a version to check against the pinned version %q", resolved, constraint)}
+		}
+		// Without a version the newest output format is assumed
+		return info, nil
+	}
+	if !info.Version.AtLeast(spec.MinVersion) {
+		return nil, &CLIError{Spec: spec, Reason: fmt.Sprintf("%s is too old; version %s or newer is required", info.Version, spec.MinVersion)}
+	}
+	if constraint != "" {
+		ok, err := CheckVersionConstraint(info.Version, constraint)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, &CLIError{Spec: spec, Reason: fmt.Sprintf("%s does not match the pinned version %q", info.Version, constraint)}
+		}
+	}
+	return info, nil
+}
+
+// CLIChecker is implemented by providers that shell out to a CLI tool
+type CLIChecker interface {
+	// Check finds the tool and verifies its version, caching the result
+	Check(ctx context.Context) (*CLIInfo, error)
+}
+
+// CLICheck runs CheckCLI once and keeps its result
+type CLICheck struct {
+	once sync.Once
+	info *CLIInfo
+	err  error
+}
+
+// Run checks the tool on the first call and returns the same result afterwards
+func (c *CLICheck) Run(ctx context.Context, spec CLISpec, path, constraint string) (*CLIInfo, error) {
+	c.once.Do(func() {
+		c.info, c.err = CheckCLI(ctx, spec, path, constraint)
+	})
+	return c.info, c.err
+}
@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseCLIVersion(t *testing.T) {
+	tests := []struct {
+		input string
+		want  CLIVersion
+		ok    bool
+	}{
+		{"1.0.44 (Claude Code)", CLIVersion{1, 0, 44}, true},
+		{"codex-cli 0.46.0", CLIVersion{0, 46, 0}, true},
+		{"v0.11", CLIVersion{0, 11, 0}, true},
+		{"unknown", CLIVersion{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseCLIVersion(tt.input)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseCLIVersion(%q) = %v, %v, want %v, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCheckVersionConstraint(t *testing.T) {
+	v := CLIVersion{1, 2, 3}
+	tests := []struct {
+		constraint string
+		want       bool
+		wantErr    bool
+	}{
+		{"1.2.3", true, false},
+		{"1.2", true, false},
+		{"1.2.4", false, false},
+		{"=1.3", false, false},
+		{">=1.2.0", true, false},
+		{">1.2.3", false, false},
+		{">=1.0, <2", true, false},
+		{">=1.0,<1.2", false, false},
+		{"<=1.2.3", true, false},
+		{"latest", false, true},
+		{">=1.0,", false, true},
+		{"~1.2", false, true},
+	}
+	for _, tt := range tests {
+		got, err := CheckVersionConstraint(v, tt.constraint)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckVersionConstraint(%q) error = %v, wantErr %v", tt.constraint, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("CheckVersionConstraint(%q) = %v, want %v", tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestCheckCLI(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the CLI")
+	}
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "fake-cli")
+	if err := os.WriteFile(fake, []byte("#!/bin/sh\necho '1.0.44 (Fake CLI)'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	spec := CLISpec{Name: "Fake CLI", Provider: ProviderTypeClaudeCLI, Binary: "fake-cli-missing", Install: "npm install -g fake", MinVersion: "1.0.0"}
+
+	info, err := CheckCLI(context.Background(), spec, fake, "")
+	if err != nil {
+		t.Fatalf("CheckCLI: %v", err)
+	}
+	if !info.Known || info.Version != (CLIVersion{1, 0, 44}) || info.Path != fake {
+		t.Errorf("unexpected info: %+v", info)
+	}
+
+	tests := []struct {
+		name       string
+		spec       CLISpec
+		path       string
+		constraint string
+		reason     string
+	}{
+		{"missing", spec, "", "", "not found"},
+		{"too old", CLISpec{Name: "Fake CLI", MinVersion: "2.0.0"}, fake, "", "too old"},
+		{"pinned", spec, fake, "1.1", "pinned version"},
+	}
+	for _, tt := range tests {
+		_, err := CheckCLI(context.Background(), tt.spec, tt.path, tt.constraint)
+		var cliErr *CLIError
+		if !errors.As(err, &cliErr) || !strings.Contains(cliErr.Reason, tt.reason) {
+			t.Errorf("%s: expected CLIError about %q, got %v", tt.name, tt.reason, err)
+		}
+	}
+
+	_, err = CheckCLI(context.Background(), spec, "", "")
+	if !strings.Contains(err.Error(), "npm install -g fake") || !strings.Contains(err.Error(), "cli_providers.claudecli.path") {
+		t.Errorf("expected install instructions, got %q", err)
+	}
+}
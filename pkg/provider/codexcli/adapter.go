@@ -0,0 +1,180 @@
+package codexcli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// outputAdapter translates one line of `codex exec --json` output into
+// stream events. The format changed between Codex releases.
+type outputAdapter interface {
+	translate(line []byte) (events []provider.StreamingEvent, done bool, err error)
+}
+
+// Releases that changed the JSON output
+const (
+	// rustVersion is the first release of the Rust rewrite, which wraps
+	// events in {"id": ..., "msg": {...}}
+	rustVersion = "0.2.0"
+	// itemsVersion is the first release emitting thread, turn and item events
+	itemsVersion = "0.44.0"
+)
+
+// adapterFor picks the adapter for the installed Codex version, assuming
+// the newest format when the version is unknown
+func adapterFor(info *provider.CLIInfo) outputAdapter {
+	switch {
+	case info == nil || !info.Known || info.Version.AtLeast(itemsVersion):
+		return &itemsAdapter{}
+	case info.Version.AtLeast(rustVersion):
+		return &msgAdapter{}
+	default:
+		return &legacyAdapter{}
+	}
+}
+
+// textDelta emits text as a delta of the single text block
+func textDelta(text string) provider.StreamingEvent {
+	return &provider.ContentBlockDeltaEvent{Index: 0, Delta: &provider.TextDelta{Text: text}}
+}
+
+// legacyAdapter reads the TypeScript CLI's output, which repeats the full
+// assistant message as it grows
+type legacyAdapter struct {
+	lastText string
+}
+
+func (a *legacyAdapter) translate(line []byte) ([]provider.StreamingEvent, bool, error) {
+	var event struct {
+		Type    string `json:"type"`
+		Message struct {
+			Role    string `json:"role"`
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(line, &event); err != nil {
+		return nil, false, nil
+	}
+
+	switch event.Type {
+	case "message":
+		if event.Message.Role != "assistant" {
+			return nil, false, nil
+		}
+		for _, block := range event.Message.Content {
+			if block.Type == "text" && len(block.Text) > len(a.lastText) {
+				delta := block.Text[len(a.lastText):]
+				a.lastText = block.Text
+				return []provider.StreamingEvent{textDelta(delta)}, false, nil
+			}
+		}
+	case "task_complete", "agent_finished":
+		return []provider.StreamingEvent{&provider.MessageStopEvent{}}, true, nil
+	}
+	return nil, false, nil
+}
+
+// msgAdapter reads the {"id": ..., "msg": {...}} events of Rust releases
+// before 0.44
+type msgAdapter struct {
+	wroteText bool
+}
+
+func (a *msgAdapter) translate(line []byte) ([]provider.StreamingEvent, bool, error) {
+	var event struct {
+		Msg struct {
+			Type         string `json:"type"`
+			Message      string `json:"message"`
+			InputTokens  int    `json:"input_tokens"`
+			OutputTokens int    `json:"output_tokens"`
+		} `json:"msg"`
+	}
+	if err := json.Unmarshal(line, &event); err != nil {
+		return nil, false, nil
+	}
+
+	msg := event.Msg
+	switch msg.Type {
+	case "agent_message":
+		if msg.Message == "" {
+			return nil, false, nil
+		}
+		text := msg.Message
+		if a.wroteText {
+			text = "\n\n" + text
+		}
+		a.wroteText = true
+		return []provider.StreamingEvent{textDelta(text)}, false, nil
+	case "token_count":
+		return []provider.StreamingEvent{&provider.MessageDeltaEvent{
+			Usage: &provider.Usage{InputTokens: msg.InputTokens, OutputTokens: msg.OutputTokens},
+		}}, false, nil
+	case "error":
+		return nil, true, fmt.Errorf("codex cli: %s", msg.Message)
+	case "task_complete":
+		return []provider.StreamingEvent{&provider.MessageStopEvent{}}, true, nil
+	}
+	return nil, false, nil
+}
+
+// itemsAdapter reads the thread, turn and item events of 0.44 and newer
+type itemsAdapter struct {
+	wroteText bool
+}
+
+func (a *itemsAdapter) translate(line []byte) ([]provider.StreamingEvent, bool, error) {
+	var event struct {
+		Type string `json:"type"`
+		Item struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"item"`
+		Usage struct {
+			InputTokens       int `json:"input_tokens"`
+			CachedInputTokens int `json:"cached_input_tokens"`
+			OutputTokens      int `json:"output_tokens"`
+		} `json:"usage"`
+		Message string `json:"message"`
+		Error   struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(line, &event); err != nil {
+		return nil, false, nil
+	}
+
+	switch event.Type {
+	case "item.completed":
+		if event.Item.Type != "agent_message" || event.Item.Text == "" {
+			return nil, false, nil
+		}
+		text := event.Item.Text
+		if a.wroteText {
+			text = "\n\n" + text
+		}
+		a.wroteText = true
+		return []provider.StreamingEvent{textDelta(text)}, false, nil
+	case "turn.completed":
+		return []provider.StreamingEvent{
+			&provider.MessageDeltaEvent{
+				Delta: &provider.MessageDelta{StopReason: provider.StopReasonEndTurn},
+				Usage: &provider.Usage{
+					InputTokens:          event.Usage.InputTokens,
+					OutputTokens:         event.Usage.OutputTokens,
+					CacheReadInputTokens: event.Usage.CachedInputTokens,
+				},
+			},
+			&provider.MessageStopEvent{},
+		}, true, nil
+	case "turn.failed":
+		return nil, true, fmt.Errorf("codex cli: %s", event.Error.Message)
+	case "error":
+		return nil, true, fmt.Errorf("codex cli: %s", event.Message)
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,114 @@
+package codexcli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+func TestAdapterFor(t *testing.T) {
+	tests := []struct {
+		info *provider.CLIInfo
+		want outputAdapter
+	}{
+		{nil, &itemsAdapter{}},
+		{&provider.CLIInfo{}, &itemsAdapter{}},
+		{&provider.CLIInfo{Known: true, Version: provider.CLIVersion{Minor: 46}}, &itemsAdapter{}},
+		{&provider.CLIInfo{Known: true, Version: provider.CLIVersion{Minor: 20}}, &msgAdapter{}},
+		{&provider.CLIInfo{Known: true, Version: provider.CLIVersion{Minor: 1, Patch: 2504}}, &legacyAdapter{}},
+	}
+	for _, tt := range tests {
+		got := adapterFor(tt.info)
+		if fmt.Sprintf("%T", got) != fmt.Sprintf("%T", tt.want) {
+			t.Errorf("adapterFor(%+v) = %T, want %T", tt.info, got, tt.want)
+		}
+	}
+}
+
+func TestStreamReaderAdapters(t *testing.T) {
+	tests := []struct {
+		name    string
+		adapter outputAdapter
+		output  string
+		text    string
+		usage   int
+		wantErr bool
+	}{
+		{
+			name:    "legacy",
+			adapter: &legacyAdapter{},
+			output: `{"type":"message","message":{"role":"assistant","content":[{"type":"text","text":"Hel"}]}}
+{"type":"message","message":{"role":"assistant","content":[{"type":"text","text":"Hello"}]}}
+{"type":"task_complete"}
+`,
+			text: "Hello",
+		},
+		{
+			name:    "msg",
+			adapter: &msgAdapter{},
+			output: `{"id":"0","msg":{"type":"task_started"}}
+{"id":"0","msg":{"type":"agent_message","message":"First"}}
+{"id":"0","msg":{"type":"agent_message","message":"Second"}}
+{"id":"0","msg":{"type":"token_count","input_tokens":100,"output_tokens":20}}
+{"id":"0","msg":{"type":"task_complete"}}
+`,
+			text:  "First\n\nSecond",
+			usage: 20,
+		},
+		{
+			name:    "items",
+			adapter: &itemsAdapter{},
+			output: `{"type":"thread.started","thread_id":"t"}
+{"type":"item.completed","item":{"id":"item_0","type":"reasoning","text":"thinking"}}
+{"type":"item.completed","item":{"id":"item_1","type":"agent_message","text":"Done"}}
+{"type":"turn.completed","usage":{"input_tokens":100,"cached_input_tokens":50,"output_tokens":7}}
+`,
+			text:  "Done",
+			usage: 7,
+		},
+		{
+			name:    "items failure",
+			adapter: &itemsAdapter{},
+			output:  `{"type":"turn.failed","error":{"message":"quota exceeded"}}` + "\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := &streamReader{
+				scanner: bufio.NewScanner(strings.NewReader(tt.output)),
+				adapter: tt.adapter,
+			}
+			var text strings.Builder
+			var usage int
+			for {
+				event, err := sr.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					if !tt.wantErr {
+						t.Fatalf("Recv: %v", err)
+					}
+					return
+				}
+				switch ev := event.(type) {
+				case *provider.ContentBlockDeltaEvent:
+					text.WriteString(ev.Delta.(*provider.TextDelta).Text)
+				case *provider.MessageDeltaEvent:
+					usage += ev.Usage.OutputTokens
+				}
+			}
+			if tt.wantErr {
+				t.Fatal("expected an error")
+			}
+			if text.String() != tt.text || usage != tt.usage {
+				t.Errorf("got text %q and %d output tokens, want %q and %d", text.String(), usage, tt.text, tt.usage)
+			}
+		})
+	}
+}
@@ -4,7 +4,6 @@ package codexcli
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os/exec"
@@ -13,10 +12,21 @@ import (
 	"github.com/xinguang/agentic-coder/pkg/provider"
 )
 
+// Spec describes the Codex CLI
+var Spec = provider.CLISpec{
+	Name:       "Codex CLI",
+	Provider:   provider.ProviderTypeCodexCLI,
+	Binary:     "codex",
+	Install:    "npm install -g @openai/codex",
+	MinVersion: "0.1.0",
+}
+
 // Provider implements a provider using local Codex CLI
 type Provider struct {
 	model   string
 	cliPath string
+	version string // pinned version constraint, "" = any supported version
+	check   provider.CLICheck
 }
 
 // Option configures the Provider
@@ -36,11 +46,18 @@ func WithCLIPath(path string) Option {
 	}
 }
 
+// WithVersion pins the CLI to versions matching a constraint such as ">=0.44"
+func WithVersion(constraint string) Option {
+	return func(p *Provider) {
+		p.version = constraint
+	}
+}
+
 // New creates a new Codex CLI provider
 func New(opts ...Option) *Provider {
 	p := &Provider{
 		model:   "o3-mini",
-		cliPath: "codex",
+		cliPath: Spec.Binary,
 	}
 
 	for _, opt := range opts {
@@ -55,6 +72,11 @@ func (p *Provider) Name() string {
 	return "codex-cli"
 }
 
+// Check finds the CLI and verifies its version
+func (p *Provider) Check(ctx context.Context) (*provider.CLIInfo, error) {
+	return p.check.Run(ctx, Spec, p.cliPath, p.version)
+}
+
 // SupportedModels returns supported models
 func (p *Provider) SupportedModels() []string {
 	return []string{"o3-mini", "o3", "o4-mini", "gpt-4o"}
@@ -103,6 +125,11 @@ func (p *Provider) CreateMessage(ctx context.Context, req *provider.Request) (*p
 
 // CreateMessageStream performs a streaming completion using Codex CLI
 func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Request) (provider.StreamReader, error) {
+	info, err := p.Check(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build prompt from messages
 	var prompt strings.Builder
 	for _, msg := range req.Messages {
@@ -125,7 +152,7 @@ func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Reques
 		prompt.String(),
 	}
 
-	cmd := exec.CommandContext(ctx, p.cliPath, args...)
+	cmd := exec.CommandContext(ctx, info.Path, args...)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -144,25 +171,24 @@ func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Reques
 		cmd:     cmd,
 		stdout:  stdout,
 		scanner: scanner,
-		done:    false,
+		adapter: adapterFor(info),
 	}, nil
 }
 
 // streamReader implements provider.StreamReader
 type streamReader struct {
-	cmd      *exec.Cmd
-	stdout   io.ReadCloser
-	scanner  *bufio.Scanner
-	done     bool
-	started  bool
-	lastText string
+	cmd     *exec.Cmd
+	stdout  io.ReadCloser
+	scanner *bufio.Scanner
+	adapter outputAdapter
+	done    bool
+	started bool
+
+	// Events translated from a line but not yet returned
+	eventQueue []provider.StreamingEvent
 }
 
 func (r *streamReader) Recv() (provider.StreamingEvent, error) {
-	if r.done {
-		return nil, io.EOF
-	}
-
 	// Send MessageStartEvent first
 	if !r.started {
 		r.started = true
@@ -175,41 +201,25 @@ func (r *streamReader) Recv() (provider.StreamingEvent, error) {
 		}, nil
 	}
 
-	for r.scanner.Scan() {
-		line := r.scanner.Text()
-		if line == "" {
-			continue
-		}
-
-		var event cliEvent
-		if err := json.Unmarshal([]byte(line), &event); err != nil {
+	for len(r.eventQueue) == 0 && !r.done && r.scanner.Scan() {
+		line := r.scanner.Bytes()
+		if len(line) == 0 {
 			continue
 		}
-
-		switch event.Type {
-		case "message":
-			// Extract text content
-			if event.Message.Role == "assistant" {
-				for _, block := range event.Message.Content {
-					if block.Type == "text" && block.Text != "" {
-						fullText := block.Text
-						if len(fullText) > len(r.lastText) {
-							delta := fullText[len(r.lastText):]
-							r.lastText = fullText
-							return &provider.ContentBlockDeltaEvent{
-								Index: 0,
-								Delta: &provider.TextDelta{Text: delta},
-							}, nil
-						}
-					}
-				}
-			}
-		case "task_complete", "agent_finished":
+		events, done, err := r.adapter.translate(line)
+		if err != nil {
 			r.done = true
-			return &provider.MessageStopEvent{}, nil
+			return nil, err
 		}
+		r.eventQueue = append(r.eventQueue, events...)
+		r.done = done
 	}
 
+	if len(r.eventQueue) > 0 {
+		event := r.eventQueue[0]
+		r.eventQueue = r.eventQueue[1:]
+		return event, nil
+	}
 	if err := r.scanner.Err(); err != nil {
 		return nil, err
 	}
@@ -222,15 +232,3 @@ func (r *streamReader) Close() error {
 	r.stdout.Close()
 	return r.cmd.Wait()
 }
-
-// cliEvent represents a Codex CLI JSON event
-type cliEvent struct {
-	Type    string `json:"type"`
-	Message struct {
-		Role    string `json:"role"`
-		Content []struct {
-			Type string `json:"type"`
-			Text string `json:"text"`
-		} `json:"content"`
-	} `json:"message"`
-}
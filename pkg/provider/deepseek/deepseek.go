@@ -97,6 +97,9 @@ type deepseekRequest struct {
 	MaxTokens   int               `json:"max_tokens,omitempty"`
 	Temperature float64           `json:"temperature,omitempty"`
 	Tools       []deepseekTool    `json:"tools,omitempty"`
+	ToolChoice  interface{}       `json:"tool_choice,omitempty"` // string or a function
+	Stop        []string          `json:"stop,omitempty"`
+	TopP        float64           `json:"top_p,omitempty"`
 	Stream      bool              `json:"stream,omitempty"`
 }
 
@@ -274,12 +277,41 @@ func (p *Provider) convertRequest(req *provider.Request) *deepseekRequest {
 		maxTokens = 4096
 	}
 
+	var choice interface{}
+	if len(tools) > 0 {
+		choice = convertToolChoice(req.ToolChoice)
+	}
+
 	return &deepseekRequest{
-		Model:       p.resolveModel(req.Model),
+		Model:       provider.ResolveModel(req.Model),
 		Messages:    messages,
 		MaxTokens:   maxTokens,
 		Temperature: req.Temperature,
 		Tools:       tools,
+		ToolChoice:  choice,
+		Stop:        req.StopSequences,
+		TopP:        req.TopP, // DeepSeek has neither top_k nor a seed
+	}
+}
+
+// convertToolChoice converts a tool choice to DeepSeek's tool_choice, nil for
+// the default
+func convertToolChoice(c *provider.ToolChoice) interface{} {
+	if c == nil {
+		return nil
+	}
+	switch c.Type {
+	case provider.ToolChoiceAny:
+		return "required"
+	case provider.ToolChoiceNone:
+		return "none"
+	case provider.ToolChoiceTool:
+		return map[string]interface{}{
+			"type":     "function",
+			"function": map[string]string{"name": c.Name},
+		}
+	default:
+		return "auto"
 	}
 }
 
@@ -391,12 +423,16 @@ func (p *Provider) convertResponse(resp *deepseekResponse) *provider.Response {
 	// Add tool calls
 	for _, tc := range choice.Message.ToolCalls {
 		var input map[string]interface{}
-		json.Unmarshal([]byte(tc.Function.Arguments), &input)
+		var rawInput string
+		if tc.Function.Arguments != "" && json.Unmarshal([]byte(tc.Function.Arguments), &input) != nil {
+			rawInput = tc.Function.Arguments
+		}
 
 		content = append(content, &provider.ToolUseBlock{
-			ID:    tc.ID,
-			Name:  tc.Function.Name,
-			Input: input,
+			ID:       tc.ID,
+			Name:     tc.Function.Name,
+			Input:    input,
+			RawInput: rawInput,
 		})
 	}
 
@@ -425,20 +461,6 @@ func (p *Provider) convertResponse(resp *deepseekResponse) *provider.Response {
 	}
 }
 
-// resolveModel maps model aliases to actual model IDs
-func (p *Provider) resolveModel(model string) string {
-	aliases := map[string]string{
-		"deepseek":  "deepseek-chat",
-		"coder":     "deepseek-coder",
-		"reasoner":  "deepseek-reasoner",
-		"r1":        "deepseek-reasoner",
-	}
-	if resolved, ok := aliases[model]; ok {
-		return resolved
-	}
-	return model
-}
-
 // SSE Stream Reader for DeepSeek
 type sseStreamReader struct {
 	ctx     context.Context
@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+)
+
+// EmbedRequest asks for vector embeddings of texts
+type EmbedRequest struct {
+	Model string   // embedding model, "" for the provider's default
+	Texts []string // embedded in order
+}
+
+// EmbedResponse holds one vector per text of the request, in order
+type EmbedResponse struct {
+	Model       string
+	Vectors     [][]float32
+	InputTokens int // 0 if not reported
+}
+
+// EmbeddingProvider is implemented by providers that turn text into vectors
+// for semantic search and memory
+type EmbeddingProvider interface {
+	// Name returns the provider name
+	Name() string
+
+	// Embed returns the embeddings of the texts of a request
+	Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse, error)
+}
+
+// CheckEmbeddings verifies that a provider returned one vector per text
+func CheckEmbeddings(resp *EmbedResponse, texts []string) error {
+	if len(resp.Vectors) != len(texts) {
+		return fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Vectors))
+	}
+	return nil
+}
+
+// EmbedBatches embeds texts in requests of at most size texts, for providers
+// that limit how many inputs one request may have
+func EmbedBatches(ctx context.Context, p EmbeddingProvider, model string, texts []string, size int) (*EmbedResponse, error) {
+	if size <= 0 {
+		size = len(texts)
+	}
+	all := &EmbedResponse{Model: model}
+	for start := 0; start < len(texts); start += size {
+		batch := texts[start:min(start+size, len(texts))]
+		resp, err := p.Embed(ctx, &EmbedRequest{Model: model, Texts: batch})
+		if err != nil {
+			return nil, err
+		}
+		if err := CheckEmbeddings(resp, batch); err != nil {
+			return nil, err
+		}
+		all.Model = resp.Model
+		all.Vectors = append(all.Vectors, resp.Vectors...)
+		all.InputTokens += resp.InputTokens
+	}
+	return all, nil
+}
@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"context"
+	"testing"
+)
+
+// countingEmbedder returns the length of each text as its vector
+type countingEmbedder struct {
+	requests int
+}
+
+func (e *countingEmbedder) Name() string { return "counting" }
+
+func (e *countingEmbedder) Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse, error) {
+	e.requests++
+	resp := &EmbedResponse{Model: "m", InputTokens: len(req.Texts)}
+	for _, text := range req.Texts {
+		resp.Vectors = append(resp.Vectors, []float32{float32(len(text))})
+	}
+	return resp, nil
+}
+
+func TestEmbedBatches(t *testing.T) {
+	e := &countingEmbedder{}
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	resp, err := EmbedBatches(context.Background(), e, "", texts, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.requests != 3 || resp.InputTokens != 5 || resp.Model != "m" {
+		t.Errorf("unexpected batching: %d requests, %+v", e.requests, resp)
+	}
+	for i, v := range resp.Vectors {
+		if int(v[0]) != len(texts[i]) {
+			t.Errorf("vector %d out of order: %v", i, v)
+		}
+	}
+}
@@ -11,14 +11,16 @@ import (
 type ProviderType string
 
 const (
-	ProviderTypeClaude    ProviderType = "claude"
-	ProviderTypeClaudeCLI ProviderType = "claudecli"
-	ProviderTypeOpenAI    ProviderType = "openai"
-	ProviderTypeCodexCLI  ProviderType = "codexcli"
-	ProviderTypeGemini    ProviderType = "gemini"
-	ProviderTypeGeminiCLI ProviderType = "geminicli"
-	ProviderTypeDeepSeek  ProviderType = "deepseek"
-	ProviderTypeOllama    ProviderType = "ollama"
+	ProviderTypeClaude      ProviderType = "claude"
+	ProviderTypeClaudeCLI   ProviderType = "claudecli"
+	ProviderTypeOpenAI      ProviderType = "openai"
+	ProviderTypeCodexCLI    ProviderType = "codexcli"
+	ProviderTypeGemini      ProviderType = "gemini"
+	ProviderTypeGeminiCLI   ProviderType = "geminicli"
+	ProviderTypeDeepSeek    ProviderType = "deepseek"
+	ProviderTypeOllama      ProviderType = "ollama"
+	ProviderTypeGitHub      ProviderType = "github"
+	ProviderTypeAzureOpenAI ProviderType = "azureopenai"
 )
 
 // ProviderFactory creates providers
@@ -121,8 +123,51 @@ func (f *ProviderFactory) Create(providerType ProviderType) (CreateProviderFunc,
 // CreateProviderFunc is a function that creates a provider
 type CreateProviderFunc func() (AIProvider, error)
 
-// DetectProviderFromModel determines the provider from model name
+// providerPrefixes are the names accepted before the colon of an explicit
+// "provider:model"
+var providerPrefixes = map[string]ProviderType{
+	"claude":      ProviderTypeClaude,
+	"anthropic":   ProviderTypeClaude,
+	"claudecli":   ProviderTypeClaudeCLI,
+	"claude-cli":  ProviderTypeClaudeCLI,
+	"openai":      ProviderTypeOpenAI,
+	"codexcli":    ProviderTypeCodexCLI,
+	"codex-cli":   ProviderTypeCodexCLI,
+	"gemini":      ProviderTypeGemini,
+	"google":      ProviderTypeGemini,
+	"geminicli":   ProviderTypeGeminiCLI,
+	"gemini-cli":  ProviderTypeGeminiCLI,
+	"deepseek":    ProviderTypeDeepSeek,
+	"ollama":      ProviderTypeOllama,
+	"github":      ProviderTypeGitHub,
+	"azure":       ProviderTypeAzureOpenAI,
+	"azureopenai": ProviderTypeAzureOpenAI,
+}
+
+// SplitProviderPrefix splits an explicit "provider:model", such as
+// "ollama:qwen2.5-coder" or "openai:gpt-4o-mini", into the provider and
+// the model. ok is false when the text before the first colon is not a
+// provider, so Ollama tags such as "qwen2.5-coder:7b" are left alone.
+func SplitProviderPrefix(model string) (providerType ProviderType, name string, ok bool) {
+	prefix, name, found := strings.Cut(model, ":")
+	if !found || name == "" {
+		return "", model, false
+	}
+	providerType, ok = providerPrefixes[strings.ToLower(prefix)]
+	if !ok {
+		return "", model, false
+	}
+	return providerType, name, true
+}
+
+// DetectProviderFromModel determines the provider from model name, after
+// expanding a user alias: the explicit provider of "provider:model", or else
+// a guess from the name
 func DetectProviderFromModel(model string) ProviderType {
+	model = ExpandAlias(model)
+	if providerType, _, ok := SplitProviderPrefix(model); ok {
+		return providerType
+	}
 	model = strings.ToLower(model)
 
 	// Claude CLI (local Claude Code)
@@ -140,6 +185,11 @@ func DetectProviderFromModel(model string) ProviderType {
 		return ProviderTypeGeminiCLI
 	}
 
+	// GitHub Models (hosted models for GitHub accounts)
+	if strings.HasPrefix(model, "github/") || model == "github" {
+		return ProviderTypeGitHub
+	}
+
 	// Claude models
 	if strings.HasPrefix(model, "claude") ||
 		model == "sonnet" || model == "opus" || model == "haiku" {
@@ -0,0 +1,81 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// DefaultEmbeddingModel is used when an embed request names no model
+const DefaultEmbeddingModel = "text-embedding-004"
+
+type geminiEmbedRequest struct {
+	Requests []geminiEmbedContent `json:"requests"`
+}
+
+type geminiEmbedContent struct {
+	Model   string        `json:"model"`
+	Content geminiContent `json:"content"`
+}
+
+type geminiEmbedResponse struct {
+	Embeddings []struct {
+		Values []float32 `json:"values"`
+	} `json:"embeddings"`
+}
+
+// Embed returns the embeddings of texts with one batch request
+func (p *Provider) Embed(ctx context.Context, req *provider.EmbedRequest) (*provider.EmbedResponse, error) {
+	model := req.Model
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+	batch := geminiEmbedRequest{Requests: make([]geminiEmbedContent, len(req.Texts))}
+	for i, text := range req.Texts {
+		batch.Requests[i] = geminiEmbedContent{
+			Model:   "models/" + model,
+			Content: geminiContent{Parts: []geminiPart{{Text: text}}},
+		}
+	}
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/models/%s:batchEmbedContents?key=%s", p.baseURL, model, p.apiKey)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var embResp geminiEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	result := &provider.EmbedResponse{Model: model}
+	for _, e := range embResp.Embeddings {
+		result.Vectors = append(result.Vectors, e.Values)
+	}
+	if err := provider.CheckEmbeddings(result, req.Texts); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -81,7 +81,8 @@ func (p *Provider) SupportsFeature(feature provider.Feature) bool {
 	switch feature {
 	case provider.FeatureStreaming,
 		provider.FeatureToolUse,
-		provider.FeatureVision:
+		provider.FeatureVision,
+		provider.FeatureWebSearch:
 		return true
 	case provider.FeatureThinking:
 		return true // Gemini 2.0 supports thinking
@@ -95,9 +96,20 @@ type geminiRequest struct {
 	Contents         []geminiContent        `json:"contents"`
 	SystemInstruction *geminiContent        `json:"systemInstruction,omitempty"`
 	Tools            []geminiTool           `json:"tools,omitempty"`
+	ToolConfig       *geminiToolConfig       `json:"toolConfig,omitempty"`
 	GenerationConfig *geminiGenerationConfig `json:"generationConfig,omitempty"`
 }
 
+// geminiToolConfig is Gemini's equivalent of tool_choice
+type geminiToolConfig struct {
+	FunctionCallingConfig geminiFunctionCalling `json:"functionCallingConfig"`
+}
+
+type geminiFunctionCalling struct {
+	Mode                 string   `json:"mode"` // AUTO, ANY, or NONE
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
 type geminiContent struct {
 	Role  string       `json:"role,omitempty"`
 	Parts []geminiPart `json:"parts"`
@@ -122,13 +134,18 @@ type geminiFunctionCall struct {
 
 type geminiFunctionResponse struct {
 	Name     string `json:"name"`
-	Response struct {
-		Content string `json:"content"`
-	} `json:"response"`
+	Response geminiToolResponse `json:"response"`
+}
+
+// geminiToolResponse is a tool result, with its structured output if any
+type geminiToolResponse struct {
+	Content           string          `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
 }
 
 type geminiTool struct {
-	FunctionDeclarations []geminiFunctionDecl `json:"functionDeclarations"`
+	FunctionDeclarations []geminiFunctionDecl `json:"functionDeclarations,omitempty"`
+	GoogleSearch         *struct{}            `json:"googleSearch,omitempty"` // grounding with Google Search
 }
 
 type geminiFunctionDecl struct {
@@ -142,6 +159,9 @@ type geminiGenerationConfig struct {
 	Temperature     float64 `json:"temperature,omitempty"`
 	TopP            float64 `json:"topP,omitempty"`
 	TopK            int     `json:"topK,omitempty"`
+
+	StopSequences []string `json:"stopSequences,omitempty"`
+	Seed          *int64   `json:"seed,omitempty"`
 }
 
 type geminiResponse struct {
@@ -159,6 +179,32 @@ type geminiCandidate struct {
 		Category    string `json:"category"`
 		Probability string `json:"probability"`
 	} `json:"safetyRatings,omitempty"`
+	GroundingMetadata *geminiGrounding `json:"groundingMetadata,omitempty"`
+}
+
+// geminiGrounding is the Google Search results a response was grounded in
+type geminiGrounding struct {
+	WebSearchQueries []string `json:"webSearchQueries"`
+	GroundingChunks  []struct {
+		Web *struct {
+			URI   string `json:"uri"`
+			Title string `json:"title"`
+		} `json:"web"`
+	} `json:"groundingChunks"`
+}
+
+// citations returns the web pages a response was grounded in
+func (g *geminiGrounding) citations() []provider.Citation {
+	if g == nil {
+		return nil
+	}
+	var citations []provider.Citation
+	for _, chunk := range g.GroundingChunks {
+		if chunk.Web != nil && chunk.Web.URI != "" {
+			citations = append(citations, provider.Citation{URL: chunk.Web.URI, Title: chunk.Web.Title})
+		}
+	}
+	return citations
 }
 
 type geminiUsage struct {
@@ -169,7 +215,7 @@ type geminiUsage struct {
 
 // CreateMessage performs a non-streaming chat completion
 func (p *Provider) CreateMessage(ctx context.Context, req *provider.Request) (*provider.Response, error) {
-	model := p.resolveModel(req.Model)
+	model := provider.ResolveModel(req.Model)
 	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", p.baseURL, model, p.apiKey)
 
 	geminiReq := p.convertRequest(req)
@@ -207,7 +253,7 @@ func (p *Provider) CreateMessage(ctx context.Context, req *provider.Request) (*p
 
 // CreateMessageStream performs a streaming chat completion
 func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Request) (provider.StreamReader, error) {
-	model := p.resolveModel(req.Model)
+	model := provider.ResolveModel(req.Model)
 	url := fmt.Sprintf("%s/models/%s:streamGenerateContent?key=%s&alt=sse", p.baseURL, model, p.apiKey)
 
 	geminiReq := p.convertRequest(req)
@@ -280,6 +326,10 @@ func (p *Provider) convertRequest(req *provider.Request) *geminiRequest {
 			})
 		}
 		geminiReq.Tools = []geminiTool{{FunctionDeclarations: funcDecls}}
+		geminiReq.ToolConfig = convertToolChoice(req.ToolChoice)
+	}
+	if req.WebSearch {
+		geminiReq.Tools = append(geminiReq.Tools, geminiTool{GoogleSearch: &struct{}{}})
 	}
 
 	// Generation config
@@ -290,6 +340,10 @@ func (p *Provider) convertRequest(req *provider.Request) *geminiRequest {
 	geminiReq.GenerationConfig = &geminiGenerationConfig{
 		MaxOutputTokens: maxTokens,
 		Temperature:     req.Temperature,
+		TopP:            req.TopP,
+		TopK:            req.TopK,
+		StopSequences:   req.StopSequences,
+		Seed:            req.Seed,
 	}
 
 	return geminiReq
@@ -335,12 +389,18 @@ func (p *Provider) convertMessage(msg provider.Message) *geminiContent {
 		case *provider.ToolResultBlock:
 			content.Parts = append(content.Parts, geminiPart{
 				FunctionResponse: &geminiFunctionResponse{
-					Name: b.ToolUseID, // Gemini uses function name, not ID
-					Response: struct {
-						Content string `json:"content"`
-					}{Content: b.Content},
+					Name:     b.ToolUseID, // Gemini uses function name, not ID
+					Response: geminiToolResponse{Content: b.Content, StructuredContent: b.Structured},
 				},
 			})
+			for _, img := range b.Images {
+				content.Parts = append(content.Parts, geminiPart{
+					InlineData: &geminiInlineData{
+						MimeType: img.Source.MediaType,
+						Data:     img.Source.Data,
+					},
+				})
+			}
 		}
 	}
 
@@ -381,6 +441,21 @@ func (p *Provider) convertResponse(resp *geminiResponse, model string) *provider
 		}
 	}
 
+	// Sources from Google Search go with the text they grounded
+	if citations := candidate.GroundingMetadata.citations(); len(citations) > 0 {
+		var text *provider.TextBlock
+		for _, block := range providerResp.Content {
+			if tb, ok := block.(*provider.TextBlock); ok {
+				text = tb
+			}
+		}
+		if text == nil {
+			text = &provider.TextBlock{}
+			providerResp.Content = append(providerResp.Content, text)
+		}
+		text.Citations = append(text.Citations, citations...)
+	}
+
 	// Map finish reason
 	switch candidate.FinishReason {
 	case "STOP":
@@ -458,18 +533,23 @@ func cleanSchemaMap(m map[string]interface{}) {
 	}
 }
 
-// resolveModel maps model aliases to actual model IDs
-func (p *Provider) resolveModel(model string) string {
-	aliases := map[string]string{
-		"gemini":      "gemini-1.5-pro",
-		"gemini-pro":  "gemini-1.5-pro",
-		"gemini-flash": "gemini-1.5-flash",
-		"gemini2":     "gemini-2.0-flash-exp",
-	}
-	if resolved, ok := aliases[model]; ok {
-		return resolved
+// convertToolChoice converts a tool choice to Gemini's function calling
+// mode; a single allowed function in ANY mode forces that one
+func convertToolChoice(c *provider.ToolChoice) *geminiToolConfig {
+	if c == nil {
+		return nil
 	}
-	return model
+	calling := geminiFunctionCalling{Mode: "AUTO"}
+	switch c.Type {
+	case provider.ToolChoiceAny:
+		calling.Mode = "ANY"
+	case provider.ToolChoiceNone:
+		calling.Mode = "NONE"
+	case provider.ToolChoiceTool:
+		calling.Mode = "ANY"
+		calling.AllowedFunctionNames = []string{c.Name}
+	}
+	return &geminiToolConfig{FunctionCallingConfig: calling}
 }
 
 // SSE Stream Reader for Gemini
@@ -478,6 +558,12 @@ type sseStreamReader struct {
 	body    io.ReadCloser
 	scanner *bufio.Scanner
 	model   string
+
+	// A chunk can carry several events, which are returned one at a time
+	pending []provider.StreamingEvent
+	started bool
+	blocks  int  // content blocks started
+	inText  bool // the latest block is text
 }
 
 func newSSEStreamReader(ctx context.Context, body io.ReadCloser, model string) *sseStreamReader {
@@ -492,7 +578,13 @@ func newSSEStreamReader(ctx context.Context, body io.ReadCloser, model string) *
 }
 
 func (r *sseStreamReader) Recv() (provider.StreamingEvent, error) {
-	for r.scanner.Scan() {
+	for len(r.pending) == 0 {
+		if !r.scanner.Scan() {
+			if err := r.scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
 		select {
 		case <-r.ctx.Done():
 			return nil, r.ctx.Err()
@@ -500,71 +592,104 @@ func (r *sseStreamReader) Recv() (provider.StreamingEvent, error) {
 		}
 
 		line := r.scanner.Text()
-
 		if !strings.HasPrefix(line, "data: ") {
 			continue
 		}
 
-		data := strings.TrimPrefix(line, "data: ")
-
 		var resp geminiResponse
-		if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &resp); err != nil {
 			continue
 		}
+		r.pending = r.events(&resp)
+	}
 
-		if len(resp.Candidates) == 0 {
-			continue
-		}
+	event := r.pending[0]
+	r.pending = r.pending[1:]
+	return event, nil
+}
 
-		candidate := resp.Candidates[0]
+// events converts a streamed chunk to the events it carries: text and
+// function calls as content blocks, grounding sources as citations of the
+// latest text, and the finish reason
+func (r *sseStreamReader) events(resp *geminiResponse) []provider.StreamingEvent {
+	var events []provider.StreamingEvent
+	if !r.started {
+		r.started = true
+		events = append(events, &provider.MessageStartEvent{Message: &provider.Response{Model: r.model}})
+	}
+	if len(resp.Candidates) == 0 {
+		return events
+	}
+	candidate := resp.Candidates[0]
 
-		// Process parts
-		for _, part := range candidate.Content.Parts {
-			if part.Text != "" {
-				return &provider.ContentBlockDeltaEvent{
-					Index: 0,
-					Delta: &provider.TextDelta{Text: part.Text},
-				}, nil
-			}
+	startText := func() {
+		if !r.inText {
+			events = append(events, &provider.ContentBlockStartEvent{Index: r.blocks, ContentBlock: &provider.TextBlock{}})
+			r.blocks++
+			r.inText = true
+		}
+	}
+	for _, part := range candidate.Content.Parts {
+		if part.Text != "" {
+			startText()
+			events = append(events, &provider.ContentBlockDeltaEvent{
+				Index: r.blocks - 1,
+				Delta: &provider.TextDelta{Text: part.Text},
+			})
+		}
 
-			if part.FunctionCall != nil {
-				return &provider.ContentBlockStartEvent{
-					Index: 0,
-					ContentBlock: &provider.ToolUseBlock{
-						ID:    part.FunctionCall.Name,
-						Name:  part.FunctionCall.Name,
-						Input: part.FunctionCall.Args,
-					},
-				}, nil
-			}
+		if part.FunctionCall != nil {
+			events = append(events, &provider.ContentBlockStartEvent{
+				Index: r.blocks,
+				ContentBlock: &provider.ToolUseBlock{
+					ID:    part.FunctionCall.Name,
+					Name:  part.FunctionCall.Name,
+					Input: part.FunctionCall.Args,
+				},
+			})
+			r.blocks++
+			r.inText = false
 		}
+	}
 
-		// Check for finish
-		if candidate.FinishReason != "" {
-			var stopReason provider.StopReason
-			switch candidate.FinishReason {
-			case "STOP":
-				stopReason = provider.StopReasonEndTurn
-			case "MAX_TOKENS":
-				stopReason = provider.StopReasonMaxTokens
-			default:
-				stopReason = provider.StopReasonEndTurn
+	if grounding := candidate.GroundingMetadata; grounding != nil {
+		for _, query := range grounding.WebSearchQueries {
+			events = append(events, &provider.ToolInfoEvent{Name: "WebSearch", Input: map[string]interface{}{"query": query}})
+		}
+		if citations := grounding.citations(); len(citations) > 0 {
+			startText()
+			for _, c := range citations {
+				events = append(events, &provider.ContentBlockDeltaEvent{
+					Index: r.blocks - 1,
+					Delta: &provider.CitationsDelta{Citation: c},
+				})
 			}
-
-			return &provider.MessageDeltaEvent{
-				Delta: &provider.MessageDelta{StopReason: stopReason},
-				Usage: &provider.Usage{
-					InputTokens:  resp.UsageMetadata.PromptTokenCount,
-					OutputTokens: resp.UsageMetadata.CandidatesTokenCount,
-				},
-			}, nil
 		}
 	}
 
-	if err := r.scanner.Err(); err != nil {
-		return nil, err
+	// Check for finish
+	if candidate.FinishReason != "" {
+		var stopReason provider.StopReason
+		switch candidate.FinishReason {
+		case "STOP":
+			stopReason = provider.StopReasonEndTurn
+		case "MAX_TOKENS":
+			stopReason = provider.StopReasonMaxTokens
+		default:
+			stopReason = provider.StopReasonEndTurn
+		}
+
+		usage := &provider.Usage{}
+		if resp.UsageMetadata != nil {
+			usage.InputTokens = resp.UsageMetadata.PromptTokenCount
+			usage.OutputTokens = resp.UsageMetadata.CandidatesTokenCount
+		}
+		events = append(events, &provider.MessageDeltaEvent{
+			Delta: &provider.MessageDelta{StopReason: stopReason},
+			Usage: usage,
+		})
 	}
-	return nil, io.EOF
+	return events
 }
 
 func (r *sseStreamReader) Close() error {
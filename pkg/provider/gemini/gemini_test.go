@@ -1,8 +1,15 @@
 package gemini
 
 import (
+	"context"
 	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
 )
 
 func TestCleanSchemaForGemini(t *testing.T) {
@@ -87,3 +94,118 @@ func TestCleanSchemaForGeminiArray(t *testing.T) {
 		t.Error("additionalProperties should be removed from array items")
 	}
 }
+
+func TestEmbed(t *testing.T) {
+	var got geminiEmbedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/text-embedding-004:batchEmbedContents" || r.URL.Query().Get("key") != "key" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"embeddings":[{"values":[0.1]},{"values":[0.2]}]}`))
+	}))
+	defer server.Close()
+
+	p := New("key", WithBaseURL(server.URL))
+	resp, err := p.Embed(context.Background(), &provider.EmbedRequest{Texts: []string{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Requests) != 2 || got.Requests[1].Model != "models/text-embedding-004" || got.Requests[1].Content.Parts[0].Text != "b" {
+		t.Errorf("unexpected request: %+v", got)
+	}
+	if len(resp.Vectors) != 2 || resp.Vectors[1][0] != 0.2 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestGoogleSearchGrounding(t *testing.T) {
+	p := New("key")
+	req := p.convertRequest(&provider.Request{
+		Tools:     []provider.Tool{{Name: "Read", InputSchema: json.RawMessage(`{"type":"object"}`)}},
+		WebSearch: true,
+	})
+	if len(req.Tools) != 2 || req.Tools[1].GoogleSearch == nil || req.Tools[1].FunctionDeclarations != nil {
+		t.Errorf("expected a Google Search tool, got %+v", req.Tools)
+	}
+
+	chunk := `{"candidates":[{"content":{"parts":[{"text":"Go 1.24 is out."}]},"finishReason":"STOP",` +
+		`"groundingMetadata":{"webSearchQueries":["go 1.24"],"groundingChunks":[{"web":{"uri":"https://example.test/r/1","title":"go.dev"}}]}}]}`
+
+	var resp geminiResponse
+	if err := json.Unmarshal([]byte(chunk), &resp); err != nil {
+		t.Fatal(err)
+	}
+	converted := p.convertResponse(&resp, "gemini-2.0-flash")
+	text := converted.Content[0].(*provider.TextBlock)
+	if len(text.Citations) != 1 || text.Citations[0].URL != "https://example.test/r/1" || text.Citations[0].Title != "go.dev" {
+		t.Errorf("unexpected citations: %+v", text.Citations)
+	}
+
+	r := newSSEStreamReader(context.Background(), io.NopCloser(strings.NewReader("data: "+chunk+"\n\n")), "gemini-2.0-flash")
+	var events []provider.StreamingEvent
+	for {
+		ev, err := r.Recv()
+		if err != nil {
+			break
+		}
+		events = append(events, ev)
+	}
+	if len(events) != 6 {
+		t.Fatalf("expected 6 events, got %d: %#v", len(events), events)
+	}
+	if _, ok := events[0].(*provider.MessageStartEvent); !ok {
+		t.Errorf("expected the message to start, got %#v", events[0])
+	}
+	if info, ok := events[3].(*provider.ToolInfoEvent); !ok || info.Input["query"] != "go 1.24" {
+		t.Errorf("expected the search query, got %#v", events[3])
+	}
+	if d, ok := events[4].(*provider.ContentBlockDeltaEvent); !ok || d.Index != 0 {
+		t.Errorf("expected a citation for the text block, got %#v", events[4])
+	}
+	if _, ok := events[5].(*provider.MessageDeltaEvent); !ok {
+		t.Errorf("expected the finish reason, got %#v", events[5])
+	}
+}
+
+func TestConvertToolChoice(t *testing.T) {
+	p := New("key")
+	tests := []struct {
+		choice  *provider.ToolChoice
+		mode    string
+		allowed []string
+	}{
+		{&provider.ToolChoice{Type: provider.ToolChoiceAuto}, "AUTO", nil},
+		{&provider.ToolChoice{Type: provider.ToolChoiceAny}, "ANY", nil},
+		{&provider.ToolChoice{Type: provider.ToolChoiceNone}, "NONE", nil},
+		{&provider.ToolChoice{Type: provider.ToolChoiceTool, Name: "Read"}, "ANY", []string{"Read"}},
+	}
+	for _, tt := range tests {
+		req := p.convertRequest(&provider.Request{
+			Tools:      []provider.Tool{{Name: "Read", InputSchema: json.RawMessage(`{"type":"object"}`)}},
+			ToolChoice: tt.choice,
+		})
+		calling := req.ToolConfig.FunctionCallingConfig
+		if calling.Mode != tt.mode || strings.Join(calling.AllowedFunctionNames, ",") != strings.Join(tt.allowed, ",") {
+			t.Errorf("%+v: got %+v", tt.choice, calling)
+		}
+	}
+	if req := p.convertRequest(&provider.Request{Tools: []provider.Tool{{Name: "Read"}}}); req.ToolConfig != nil {
+		t.Errorf("expected the default tool config, got %+v", req.ToolConfig)
+	}
+}
+
+func TestConvertStopSequences(t *testing.T) {
+	req := New("key").convertRequest(&provider.Request{MaxTokens: 50, StopSequences: []string{"</answer>"}})
+	if config := req.GenerationConfig; config.MaxOutputTokens != 50 || len(config.StopSequences) != 1 {
+		t.Errorf("unexpected generation config: %+v", config)
+	}
+}
+
+func TestConvertSampling(t *testing.T) {
+	seed := int64(7)
+	req := New("key").convertRequest(&provider.Request{TopP: 0.9, TopK: 40, Seed: &seed})
+	if config := req.GenerationConfig; config.TopP != 0.9 || config.TopK != 40 || config.Seed == nil || *config.Seed != 7 {
+		t.Errorf("unexpected generation config: %+v", config)
+	}
+}
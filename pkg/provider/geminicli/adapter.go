@@ -0,0 +1,180 @@
+package geminicli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// outputAdapter translates the Gemini CLI's output into stream events. The
+// output format depends on the CLI version.
+type outputAdapter interface {
+	// format is the value passed to --output-format
+	format() string
+	// translate handles one line of output
+	translate(line string) (events []provider.StreamingEvent, done bool)
+	// finish is called when the output ends
+	finish() ([]provider.StreamingEvent, error)
+}
+
+// Releases that changed the output format
+const (
+	// jsonVersion is the first release with --output-format json, which
+	// prints a single JSON object once the response is complete
+	jsonVersion = "0.6.0"
+	// streamJSONVersion is the first release with --output-format stream-json
+	streamJSONVersion = "0.11.0"
+)
+
+// adapterFor picks the adapter for the installed Gemini CLI version,
+// assuming the newest format when the version is unknown
+func adapterFor(info *provider.CLIInfo) outputAdapter {
+	if info != nil && info.Known && !info.Version.AtLeast(streamJSONVersion) {
+		return &jsonAdapter{}
+	}
+	return &streamJSONAdapter{}
+}
+
+// streamJSONAdapter reads one JSON event per line
+type streamJSONAdapter struct {
+	started      bool
+	blockStarted bool
+	lastText     string
+}
+
+// streamEvent represents a Gemini CLI stream-json event
+type streamEvent struct {
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	SessionID string `json:"session_id,omitempty"`
+	Model     string `json:"model,omitempty"`
+	Role      string `json:"role,omitempty"`
+	Content   string `json:"content,omitempty"`
+	Delta     bool   `json:"delta,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Stats     struct {
+		TotalTokens  int `json:"total_tokens"`
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+		DurationMS   int `json:"duration_ms"`
+		ToolCalls    int `json:"tool_calls"`
+	} `json:"stats,omitempty"`
+}
+
+func (a *streamJSONAdapter) format() string { return "stream-json" }
+
+func (a *streamJSONAdapter) translate(line string) ([]provider.StreamingEvent, bool) {
+	// Skip non-JSON lines (startup logs)
+	if !strings.HasPrefix(line, "{") {
+		return nil, false
+	}
+	var event streamEvent
+	if err := json.Unmarshal([]byte(line), &event); err != nil {
+		return nil, false
+	}
+
+	switch event.Type {
+	case "init":
+		// Session started
+		if !a.started {
+			a.started = true
+			return []provider.StreamingEvent{&provider.MessageStartEvent{
+				Message: &provider.Response{
+					ID:      event.SessionID,
+					Model:   event.Model,
+					Content: make([]provider.ContentBlock, 0),
+				},
+			}}, false
+		}
+
+	case "message":
+		// Only process assistant messages
+		if event.Role != "assistant" {
+			return nil, false
+		}
+		var events []provider.StreamingEvent
+		if !a.blockStarted {
+			a.blockStarted = true
+			events = append(events, &provider.ContentBlockStartEvent{
+				Index:        0,
+				ContentBlock: &provider.TextBlock{},
+			})
+		}
+		// Gemini CLI sends full content with delta:true
+		// We need to compute the actual delta
+		fullText := event.Content
+		if len(fullText) > len(a.lastText) {
+			events = append(events, textDelta(fullText[len(a.lastText):]))
+			a.lastText = fullText
+		} else if fullText != "" && fullText != a.lastText {
+			// Content changed completely (shouldn't happen often)
+			events = append(events, textDelta(fullText))
+			a.lastText = fullText
+		}
+		return events, false
+
+	case "result":
+		// Turn complete
+		return []provider.StreamingEvent{&provider.MessageDeltaEvent{
+			Delta: &provider.MessageDelta{
+				StopReason: provider.StopReasonEndTurn,
+			},
+			Usage: &provider.Usage{
+				InputTokens:  event.Stats.InputTokens,
+				OutputTokens: event.Stats.OutputTokens,
+			},
+		}}, true
+	}
+	return nil, false
+}
+
+func (a *streamJSONAdapter) finish() ([]provider.StreamingEvent, error) { return nil, nil }
+
+// jsonAdapter collects the single JSON object printed by releases before
+// stream-json and turns its response into one text block
+type jsonAdapter struct {
+	output strings.Builder
+}
+
+func (a *jsonAdapter) format() string { return "json" }
+
+func (a *jsonAdapter) translate(line string) ([]provider.StreamingEvent, bool) {
+	a.output.WriteString(line)
+	a.output.WriteString("\n")
+	return nil, false
+}
+
+func (a *jsonAdapter) finish() ([]provider.StreamingEvent, error) {
+	output := a.output.String()
+	// Startup logs may precede the JSON object
+	start := strings.Index(output, "{")
+	if start < 0 {
+		return nil, fmt.Errorf("gemini cli printed no JSON response")
+	}
+	var result struct {
+		Response string `json:"response"`
+		Error    *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(output[start:]), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse gemini cli response: %w", err)
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("gemini cli: %s", result.Error.Message)
+	}
+
+	return []provider.StreamingEvent{
+		&provider.MessageStartEvent{Message: &provider.Response{Content: make([]provider.ContentBlock, 0)}},
+		&provider.ContentBlockStartEvent{Index: 0, ContentBlock: &provider.TextBlock{}},
+		textDelta(result.Response),
+		&provider.MessageDeltaEvent{Delta: &provider.MessageDelta{StopReason: provider.StopReasonEndTurn}},
+	}, nil
+}
+
+// textDelta emits text as a delta of the single text block
+func textDelta(text string) provider.StreamingEvent {
+	return &provider.ContentBlockDeltaEvent{Index: 0, Delta: &provider.TextDelta{Text: text}}
+}
@@ -0,0 +1,99 @@
+package geminicli
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+func TestAdapterFor(t *testing.T) {
+	old := &provider.CLIInfo{Known: true, Version: provider.CLIVersion{Minor: 8}}
+	if _, ok := adapterFor(old).(*jsonAdapter); !ok {
+		t.Error("expected json output before stream-json existed")
+	}
+	current := &provider.CLIInfo{Known: true, Version: provider.CLIVersion{Minor: 12}}
+	if _, ok := adapterFor(current).(*streamJSONAdapter); !ok {
+		t.Error("expected stream-json output for current releases")
+	}
+	if _, ok := adapterFor(&provider.CLIInfo{}).(*streamJSONAdapter); !ok {
+		t.Error("expected stream-json output for an unknown version")
+	}
+}
+
+func TestStreamReaderAdapters(t *testing.T) {
+	tests := []struct {
+		name    string
+		adapter outputAdapter
+		output  string
+		text    string
+		usage   int
+	}{
+		{
+			name:    "stream-json",
+			adapter: &streamJSONAdapter{},
+			output: `Loaded cached credentials.
+{"type":"init","session_id":"s1","model":"gemini-2.5-pro"}
+{"type":"message","role":"user","content":"hi"}
+{"type":"message","role":"assistant","content":"Hel","delta":true}
+{"type":"message","role":"assistant","content":"Hello","delta":true}
+{"type":"result","status":"success","stats":{"input_tokens":10,"output_tokens":3}}
+`,
+			text:  "Hello",
+			usage: 3,
+		},
+		{
+			name:    "json",
+			adapter: &jsonAdapter{},
+			output: `Loaded cached credentials.
+{
+  "response": "Hello there",
+  "stats": {}
+}
+`,
+			text: "Hello there",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := &streamReader{
+				scanner: bufio.NewScanner(strings.NewReader(tt.output)),
+				adapter: tt.adapter,
+			}
+			var text strings.Builder
+			var usage int
+			var stopped bool
+			for {
+				event, err := sr.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Recv: %v", err)
+				}
+				switch ev := event.(type) {
+				case *provider.ContentBlockDeltaEvent:
+					text.WriteString(ev.Delta.(*provider.TextDelta).Text)
+				case *provider.MessageDeltaEvent:
+					stopped = ev.Delta.StopReason == provider.StopReasonEndTurn
+					if ev.Usage != nil {
+						usage += ev.Usage.OutputTokens
+					}
+				}
+			}
+			if text.String() != tt.text || usage != tt.usage || !stopped {
+				t.Errorf("got text %q, %d output tokens, stopped %v; want %q and %d", text.String(), usage, stopped, tt.text, tt.usage)
+			}
+		})
+	}
+
+	sr := &streamReader{
+		scanner: bufio.NewScanner(strings.NewReader(`{"error": {"message": "quota exceeded"}}`)),
+		adapter: &jsonAdapter{},
+	}
+	if _, err := sr.Recv(); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("expected the CLI error, got %v", err)
+	}
+}
@@ -4,7 +4,6 @@ package geminicli
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -14,6 +13,15 @@ import (
 	"github.com/xinguang/agentic-coder/pkg/provider"
 )
 
+// Spec describes the Gemini CLI
+var Spec = provider.CLISpec{
+	Name:       "Gemini CLI",
+	Provider:   provider.ProviderTypeGeminiCLI,
+	Binary:     "gemini",
+	Install:    "npm install -g @google/gemini-cli",
+	MinVersion: jsonVersion,
+}
+
 // Provider implements a provider using local Gemini CLI
 type Provider struct {
 	model      string
@@ -21,6 +29,8 @@ type Provider struct {
 	yoloMode   bool   // Auto approve all actions
 	sandbox    bool   // Run in sandbox mode
 	systemPrompt string
+	version    string // pinned version constraint, "" = any supported version
+	check      provider.CLICheck
 }
 
 // Option configures the Provider
@@ -61,11 +71,18 @@ func WithSystemPrompt(prompt string) Option {
 	}
 }
 
+// WithVersion pins the CLI to versions matching a constraint such as ">=0.11"
+func WithVersion(constraint string) Option {
+	return func(p *Provider) {
+		p.version = constraint
+	}
+}
+
 // New creates a new Gemini CLI provider
 func New(opts ...Option) *Provider {
 	p := &Provider{
 		model:    "", // empty means auto (gemini-3)
-		cliPath:  "gemini",
+		cliPath:  Spec.Binary,
 		yoloMode: true, // Default to yolo mode for agentic use
 	}
 
@@ -81,6 +98,11 @@ func (p *Provider) Name() string {
 	return "gemini-cli"
 }
 
+// Check finds the CLI and verifies its version
+func (p *Provider) Check(ctx context.Context) (*provider.CLIInfo, error) {
+	return p.check.Run(ctx, Spec, p.cliPath, p.version)
+}
+
 // SupportedModels returns supported models
 func (p *Provider) SupportedModels() []string {
 	return []string{"gemini-3", "gemini-2.5-pro", "gemini-2.5-flash", "gemini-2.0-flash"}
@@ -129,6 +151,12 @@ func (p *Provider) CreateMessage(ctx context.Context, req *provider.Request) (*p
 
 // CreateMessageStream performs a streaming completion using Gemini CLI
 func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Request) (provider.StreamReader, error) {
+	info, err := p.Check(ctx)
+	if err != nil {
+		return nil, err
+	}
+	adapter := adapterFor(info)
+
 	// Build prompt from the last user message
 	var prompt string
 	for i := len(req.Messages) - 1; i >= 0; i-- {
@@ -150,7 +178,7 @@ func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Reques
 
 	// Build command arguments
 	args := []string{
-		"-o", "stream-json", // Stream JSON output
+		"-o", adapter.format(),
 	}
 
 	if p.yoloMode {
@@ -168,7 +196,7 @@ func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Reques
 	// Add the prompt as positional argument
 	args = append(args, prompt)
 
-	cmd := exec.CommandContext(ctx, p.cliPath, args...)
+	cmd := exec.CommandContext(ctx, info.Path, args...)
 
 	// Redirect stderr to discard (contains startup logs)
 	cmd.Stderr = os.Stderr // or io.Discard if you want to hide all stderr
@@ -190,117 +218,47 @@ func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Reques
 		cmd:     cmd,
 		stdout:  stdout,
 		scanner: scanner,
-		done:    false,
+		adapter: adapter,
 	}, nil
 }
 
 // streamReader implements provider.StreamReader
 type streamReader struct {
-	cmd       *exec.Cmd
-	stdout    io.ReadCloser
-	scanner   *bufio.Scanner
-	done      bool
-	started   bool
-	blockStarted bool
-	lastText  string
-	sessionID string
-	model     string
+	cmd     *exec.Cmd
+	stdout  io.ReadCloser
+	scanner *bufio.Scanner
+	adapter outputAdapter
+	done    bool
+
+	// Events translated from the output but not yet returned
+	eventQueue []provider.StreamingEvent
 }
 
 func (r *streamReader) Recv() (provider.StreamingEvent, error) {
-	if r.done {
-		return nil, io.EOF
-	}
-
-	for r.scanner.Scan() {
-		line := r.scanner.Text()
-		if line == "" {
-			continue
-		}
-
-		// Skip non-JSON lines (startup logs)
-		if !strings.HasPrefix(line, "{") {
-			continue
-		}
-
-		var event cliEvent
-		if err := json.Unmarshal([]byte(line), &event); err != nil {
-			continue
-		}
-
-		switch event.Type {
-		case "init":
-			// Session started
-			r.sessionID = event.SessionID
-			r.model = event.Model
-			if !r.started {
-				r.started = true
-				return &provider.MessageStartEvent{
-					Message: &provider.Response{
-						ID:      r.sessionID,
-						Model:   r.model,
-						Content: make([]provider.ContentBlock, 0),
-					},
-				}, nil
-			}
-
-		case "message":
-			// Only process assistant messages
-			if event.Role != "assistant" {
-				continue
-			}
-
-			// Send ContentBlockStartEvent if not started
-			if !r.blockStarted {
-				r.blockStarted = true
-				return &provider.ContentBlockStartEvent{
-					Index:        0,
-					ContentBlock: &provider.TextBlock{},
-				}, nil
-			}
-
-			// Handle delta content
-			if event.Content != "" {
-				// Gemini CLI sends full content with delta:true
-				// We need to compute the actual delta
-				fullText := event.Content
-				if len(fullText) > len(r.lastText) {
-					delta := fullText[len(r.lastText):]
-					r.lastText = fullText
-					return &provider.ContentBlockDeltaEvent{
-						Index: 0,
-						Delta: &provider.TextDelta{Text: delta},
-					}, nil
-				} else if fullText != r.lastText {
-					// Content changed completely (shouldn't happen often)
-					r.lastText = fullText
-					return &provider.ContentBlockDeltaEvent{
-						Index: 0,
-						Delta: &provider.TextDelta{Text: fullText},
-					}, nil
-				}
+	for len(r.eventQueue) == 0 && !r.done {
+		if !r.scanner.Scan() {
+			if err := r.scanner.Err(); err != nil {
+				return nil, err
 			}
-
-		case "result":
-			// Turn complete
 			r.done = true
-			return &provider.MessageDeltaEvent{
-				Delta: &provider.MessageDelta{
-					StopReason: provider.StopReasonEndTurn,
-				},
-				Usage: &provider.Usage{
-					InputTokens:  event.Stats.InputTokens,
-					OutputTokens: event.Stats.OutputTokens,
-				},
-			}, nil
+			events, err := r.adapter.finish()
+			if err != nil {
+				return nil, err
+			}
+			r.eventQueue = append(r.eventQueue, events...)
+			break
 		}
-	}
 
-	if err := r.scanner.Err(); err != nil {
-		return nil, err
+		events, done := r.adapter.translate(r.scanner.Text())
+		r.eventQueue = append(r.eventQueue, events...)
+		r.done = done
 	}
 
-	r.done = true
+	if len(r.eventQueue) > 0 {
+		event := r.eventQueue[0]
+		r.eventQueue = r.eventQueue[1:]
+		return event, nil
+	}
 	return nil, io.EOF
 }
 
@@ -308,22 +266,3 @@ func (r *streamReader) Close() error {
 	r.stdout.Close()
 	return r.cmd.Wait()
 }
-
-// cliEvent represents a Gemini CLI JSON event
-type cliEvent struct {
-	Type      string `json:"type"`
-	Timestamp string `json:"timestamp"`
-	SessionID string `json:"session_id,omitempty"`
-	Model     string `json:"model,omitempty"`
-	Role      string `json:"role,omitempty"`
-	Content   string `json:"content,omitempty"`
-	Delta     bool   `json:"delta,omitempty"`
-	Status    string `json:"status,omitempty"`
-	Stats     struct {
-		TotalTokens  int `json:"total_tokens"`
-		InputTokens  int `json:"input_tokens"`
-		OutputTokens int `json:"output_tokens"`
-		DurationMS   int `json:"duration_ms"`
-		ToolCalls    int `json:"tool_calls"`
-	} `json:"stats,omitempty"`
-}
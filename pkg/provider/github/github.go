@@ -0,0 +1,112 @@
+// Package github implements a provider for GitHub Models, which serves
+// OpenAI, Meta and other hosted models to GitHub accounts with an
+// OpenAI-compatible API
+package github
+
+import (
+	"context"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/provider/openai"
+)
+
+const (
+	defaultBaseURL = "https://models.github.ai/inference"
+
+	// ModelPrefix selects GitHub Models for a model, as in "github/gpt-4o"
+	ModelPrefix = "github/"
+
+	// DefaultModel is used when only "github" is given as the model
+	DefaultModel = "openai/gpt-4o"
+)
+
+// modelAliases maps short names to GitHub Models IDs, which are
+// "publisher/model"
+var modelAliases = map[string]string{
+	"github":        DefaultModel,
+	"gpt-4o":        "openai/gpt-4o",
+	"gpt4o":         "openai/gpt-4o",
+	"gpt-4o-mini":   "openai/gpt-4o-mini",
+	"gpt-4.1":       "openai/gpt-4.1",
+	"gpt-4.1-mini":  "openai/gpt-4.1-mini",
+	"o3-mini":       "openai/o3-mini",
+	"o4-mini":       "openai/o4-mini",
+	"llama":         "meta/Llama-3.3-70B-Instruct",
+	"llama-3.3-70b": "meta/Llama-3.3-70B-Instruct",
+	"llama-4-scout": "meta/Llama-4-Scout-17B-16E-Instruct",
+	"deepseek-r1":   "deepseek/DeepSeek-R1",
+	"mistral-small": "mistral-ai/mistral-small-2503",
+	"phi-4":         "microsoft/Phi-4",
+}
+
+// Provider implements the GitHub Models provider
+type Provider struct {
+	*openai.Provider
+}
+
+// Option configures the Provider, as for the OpenAI provider
+type Option = openai.Option
+
+// New creates a GitHub Models provider authenticated with a GitHub token
+func New(token string, opts ...Option) *Provider {
+	opts = append([]Option{
+		openai.WithBaseURL(defaultBaseURL),
+		openai.WithHeader("Accept", "application/vnd.github+json"),
+		openai.WithHeader("X-GitHub-Api-Version", "2022-11-28"),
+	}, opts...)
+	return &Provider{Provider: openai.New(token, opts...)}
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return "github"
+}
+
+// SupportedModels returns the list of supported models
+func (p *Provider) SupportedModels() []string {
+	return []string{
+		"openai/gpt-4o",
+		"openai/gpt-4o-mini",
+		"openai/gpt-4.1",
+		"openai/gpt-4.1-mini",
+		"openai/o3-mini",
+		"openai/o4-mini",
+		"meta/Llama-3.3-70B-Instruct",
+		"meta/Llama-4-Scout-17B-16E-Instruct",
+		"deepseek/DeepSeek-R1",
+		"mistral-ai/mistral-small-2503",
+		"microsoft/Phi-4",
+	}
+}
+
+// CreateMessage performs a chat completion
+func (p *Provider) CreateMessage(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	return p.Provider.CreateMessage(ctx, withModel(req))
+}
+
+// CreateMessageStream performs a streaming chat completion
+func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Request) (provider.StreamReader, error) {
+	return p.Provider.CreateMessageStream(ctx, withModel(req))
+}
+
+// withModel returns a copy of req naming the GitHub Models ID of its model
+func withModel(req *provider.Request) *provider.Request {
+	model := ResolveModel(req.Model)
+	if model == req.Model {
+		return req
+	}
+	r := *req
+	r.Model = model
+	return &r
+}
+
+// ResolveModel maps "github/<model>" and short names such as gpt-4o or
+// llama to GitHub Models IDs; IDs already naming a publisher are kept
+func ResolveModel(model string) string {
+	name := strings.TrimPrefix(model, ModelPrefix)
+	if id, ok := modelAliases[strings.ToLower(name)]; ok {
+		return id
+	}
+	return name
+}
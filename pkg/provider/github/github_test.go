@@ -0,0 +1,71 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/provider/openai"
+)
+
+func TestResolveModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"github", DefaultModel},
+		{"github/gpt-4o", "openai/gpt-4o"},
+		{"github/Llama", "meta/Llama-3.3-70B-Instruct"},
+		{"github/meta/Llama-4-Scout-17B-16E-Instruct", "meta/Llama-4-Scout-17B-16E-Instruct"},
+		{"openai/gpt-4.1", "openai/gpt-4.1"},
+	}
+	for _, tt := range tests {
+		if got := ResolveModel(tt.model); got != tt.want {
+			t.Errorf("ResolveModel(%q) = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestCreateMessage(t *testing.T) {
+	var gotModel, gotAuth, gotVersion string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/inference/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		gotModel = body.Model
+		gotAuth = r.Header.Get("Authorization")
+		gotVersion = r.Header.Get("X-GitHub-Api-Version")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	p := New("gh-token", openai.WithBaseURL(server.URL+"/inference"))
+	if p.Name() != "github" {
+		t.Errorf("Name() = %q", p.Name())
+	}
+	req := &provider.Request{
+		Model:    "github/gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentBlock{&provider.TextBlock{Text: "hello"}}}},
+	}
+	resp, err := p.CreateMessage(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CreateMessage: %v", err)
+	}
+	if gotModel != "openai/gpt-4o" || gotAuth != "Bearer gh-token" || gotVersion == "" {
+		t.Errorf("unexpected request: model %q, auth %q, version %q", gotModel, gotAuth, gotVersion)
+	}
+	if req.Model != "github/gpt-4o" {
+		t.Errorf("expected the caller's request to be unchanged, got model %q", req.Model)
+	}
+	if text, ok := resp.Content[0].(*provider.TextBlock); !ok || text.Text != "hi" {
+		t.Errorf("unexpected response content: %#v", resp.Content)
+	}
+}
@@ -0,0 +1,70 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// DefaultEmbeddingModel is used when an embed request names no model
+const DefaultEmbeddingModel = "nomic-embed-text"
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Model           string      `json:"model"`
+	Embeddings      [][]float32 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+}
+
+// Embed returns the embeddings of texts from a local embedding model
+func (p *Provider) Embed(ctx context.Context, req *provider.EmbedRequest) (*provider.EmbedResponse, error) {
+	model := req.Model
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+	body, err := json.Marshal(ollamaEmbedRequest{Model: model, Input: req.Texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error (status %d): %s (pull the model with: ollama pull %s)", resp.StatusCode, string(body), model)
+	}
+
+	var embResp ollamaEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	result := &provider.EmbedResponse{
+		Model:       model,
+		Vectors:     embResp.Embeddings,
+		InputTokens: embResp.PromptEvalCount,
+	}
+	if err := provider.CheckEmbeddings(result, req.Texts); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
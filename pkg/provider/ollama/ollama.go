@@ -142,6 +142,9 @@ type ollamaOptions struct {
 	Temperature float64 `json:"temperature,omitempty"`
 	TopP        float64 `json:"top_p,omitempty"`
 	TopK        int     `json:"top_k,omitempty"`
+
+	Stop []string `json:"stop,omitempty"`
+	Seed *int64   `json:"seed,omitempty"`
 }
 
 type ollamaResponse struct {
@@ -279,6 +282,10 @@ func (p *Provider) convertRequest(req *provider.Request) *ollamaRequest {
 	ollamaReq.Options = &ollamaOptions{
 		NumPredict:  maxTokens,
 		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		TopK:        req.TopK,
+		Stop:        req.StopSequences,
+		Seed:        req.Seed,
 	}
 
 	return ollamaReq
@@ -333,6 +340,11 @@ func (p *Provider) convertMessage(msg provider.Message) *ollamaMessage {
 			}
 			ollamaMsg.ToolName = toolName
 			textContent.WriteString(b.Content)
+			for _, img := range b.Images {
+				if img.Source.Type == "base64" {
+					images = append(images, img.Source.Data)
+				}
+			}
 		}
 	}
 
@@ -398,25 +410,13 @@ func (p *Provider) convertResponse(resp *ollamaResponse) *provider.Response {
 	return providerResp
 }
 
-// resolveModel maps model aliases to actual model names
+// resolveModel maps model aliases to actual model names, defaulting to the
+// provider's model
 func (p *Provider) resolveModel(model string) string {
 	if model == "" {
 		return p.model
 	}
-
-	aliases := map[string]string{
-		"ollama":    "qwen3",
-		"llama":     "llama3.3",
-		"qwen":      "qwen3",
-		"codellama": "codellama",
-		"deepseek":  "deepseek-r1",
-		"gemma":     "gemma3",
-		"phi":       "phi4",
-	}
-	if resolved, ok := aliases[model]; ok {
-		return resolved
-	}
-	return model
+	return provider.ResolveModel(model)
 }
 
 // cleanSchemaForOllama removes unsupported fields from JSON schema
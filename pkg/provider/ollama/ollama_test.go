@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -244,3 +246,43 @@ func TestStreamReaderEventSequence(t *testing.T) {
 		t.Errorf("Event 6: expected EOF, got %v", err)
 	}
 }
+
+func TestEmbed(t *testing.T) {
+	var got ollamaEmbedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"model":"nomic-embed-text","embeddings":[[0.5,0.25]],"prompt_eval_count":3}`))
+	}))
+	defer server.Close()
+
+	p := New(WithBaseURL(server.URL))
+	resp, err := p.Embed(context.Background(), &provider.EmbedRequest{Texts: []string{"hello"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Model != DefaultEmbeddingModel || len(resp.Vectors) != 1 || resp.Vectors[0][1] != 0.25 || resp.InputTokens != 3 {
+		t.Errorf("unexpected embedding: request %+v, response %+v", got, resp)
+	}
+
+	if _, err := p.Embed(context.Background(), &provider.EmbedRequest{Texts: []string{"a", "b"}}); err == nil {
+		t.Error("expected an error when fewer embeddings come back than texts")
+	}
+}
+
+func TestConvertStopSequences(t *testing.T) {
+	req := New().convertRequest(&provider.Request{MaxTokens: 50, StopSequences: []string{"</answer>"}})
+	if req.Options.NumPredict != 50 || len(req.Options.Stop) != 1 {
+		t.Errorf("unexpected options: %+v", req.Options)
+	}
+}
+
+func TestConvertSampling(t *testing.T) {
+	seed := int64(7)
+	req := New().convertRequest(&provider.Request{TopP: 0.9, TopK: 40, Seed: &seed})
+	if req.Options.TopP != 0.9 || req.Options.TopK != 40 || req.Options.Seed == nil || *req.Options.Seed != 7 {
+		t.Errorf("unexpected options: %+v", req.Options)
+	}
+}
@@ -0,0 +1,85 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// DefaultEmbeddingModel is used when an embed request names no model
+const DefaultEmbeddingModel = "text-embedding-3-small"
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Model string `json:"model"`
+	Data  []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Usage struct {
+		PromptTokens int `json:"prompt_tokens"`
+	} `json:"usage"`
+}
+
+// Embed returns the embeddings of texts from the embeddings endpoint
+func (p *Provider) Embed(ctx context.Context, req *provider.EmbedRequest) (*provider.EmbedResponse, error) {
+	model := req.Model
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+	body, err := json.Marshal(embeddingRequest{Model: model, Input: req.Texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	p.setHeaders(httpReq)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	p.rateLimits.Update(provider.ParseOpenAIRateLimits(resp, time.Now()))
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var embResp embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	result := &provider.EmbedResponse{
+		Model:       model,
+		Vectors:     make([][]float32, len(req.Texts)),
+		InputTokens: embResp.Usage.PromptTokens,
+	}
+	for _, d := range embResp.Data {
+		if d.Index < 0 || d.Index >= len(result.Vectors) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		result.Vectors[d.Index] = d.Embedding
+	}
+	for i, v := range result.Vectors {
+		if v == nil {
+			return nil, fmt.Errorf("no embedding returned for input %d", i)
+		}
+	}
+	return result, nil
+}
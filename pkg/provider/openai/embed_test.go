@@ -0,0 +1,35 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+func TestEmbed(t *testing.T) {
+	var got embeddingRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[1,2]},{"index":0,"embedding":[3,4]}],"usage":{"prompt_tokens":4}}`))
+	}))
+	defer server.Close()
+
+	p := New("key", WithBaseURL(server.URL))
+	resp, err := p.Embed(context.Background(), &provider.EmbedRequest{Model: "text-embedding-3-large", Texts: []string{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Model != "text-embedding-3-large" || len(got.Input) != 2 {
+		t.Errorf("unexpected request: %+v", got)
+	}
+	if resp.Vectors[0][0] != 3 || resp.Vectors[1][0] != 1 || resp.InputTokens != 4 {
+		t.Errorf("expected vectors in input order, got %+v", resp)
+	}
+}
@@ -25,6 +25,10 @@ type Provider struct {
 	baseURL string
 	client  *http.Client
 	orgID   string
+	headers map[string]string // extra headers sent with each request
+
+	// Quotas from the latest response headers
+	rateLimits provider.RateLimitTracker
 }
 
 // Option is a function that configures the Provider
@@ -44,6 +48,14 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithTransport sends requests through rt, for services that rewrite the
+// URL or authentication of each request
+func WithTransport(rt http.RoundTripper) Option {
+	return func(p *Provider) {
+		p.client.Transport = rt
+	}
+}
+
 // WithOrganization sets the organization ID
 func WithOrganization(orgID string) Option {
 	return func(p *Provider) {
@@ -51,6 +63,17 @@ func WithOrganization(orgID string) Option {
 	}
 }
 
+// WithHeader sends an extra header with each request, for OpenAI-compatible
+// services that need one
+func WithHeader(key, value string) Option {
+	return func(p *Provider) {
+		if p.headers == nil {
+			p.headers = make(map[string]string)
+		}
+		p.headers[key] = value
+	}
+}
+
 // New creates a new OpenAI provider
 func New(apiKey string, opts ...Option) *Provider {
 	p := &Provider{
@@ -99,6 +122,11 @@ func (p *Provider) Name() string {
 	return "openai"
 }
 
+// RateLimits returns the quotas reported with the latest response
+func (p *Provider) RateLimits() *provider.RateLimits {
+	return p.rateLimits.RateLimits()
+}
+
 // SupportedModels returns the list of supported models
 func (p *Provider) SupportedModels() []string {
 	return []string{
@@ -137,6 +165,10 @@ type openaiRequest struct {
 	MaxTokens   int             `json:"max_tokens,omitempty"`
 	Temperature float64         `json:"temperature,omitempty"`
 	Tools       []openaiTool    `json:"tools,omitempty"`
+	ToolChoice  interface{}     `json:"tool_choice,omitempty"` // string or a function
+	Stop        []string        `json:"stop,omitempty"`
+	TopP        float64         `json:"top_p,omitempty"`
+	Seed        *int64          `json:"seed,omitempty"`
 	Stream      bool            `json:"stream,omitempty"`
 }
 
@@ -227,6 +259,7 @@ func (p *Provider) CreateMessage(ctx context.Context, req *provider.Request) (*p
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
+	p.rateLimits.Update(provider.ParseOpenAIRateLimits(resp, time.Now()))
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
@@ -263,6 +296,7 @@ func (p *Provider) CreateMessageStream(ctx context.Context, req *provider.Reques
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
+	p.rateLimits.Update(provider.ParseOpenAIRateLimits(resp, time.Now()))
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
@@ -280,6 +314,9 @@ func (p *Provider) setHeaders(req *http.Request) {
 	if p.orgID != "" {
 		req.Header.Set("OpenAI-Organization", p.orgID)
 	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
 }
 
 // convertRequest converts a provider.Request to OpenAI format
@@ -326,12 +363,42 @@ func (p *Provider) convertRequest(req *provider.Request) *openaiRequest {
 		maxTokens = 4096
 	}
 
+	var choice interface{}
+	if len(tools) > 0 {
+		choice = convertToolChoice(req.ToolChoice)
+	}
+
 	return &openaiRequest{
-		Model:       p.resolveModel(req.Model),
+		Model:       provider.ResolveModel(req.Model),
 		Messages:    messages,
 		MaxTokens:   maxTokens,
 		Temperature: req.Temperature,
 		Tools:       tools,
+		ToolChoice:  choice,
+		Stop:        req.StopSequences,
+		TopP:        req.TopP, // OpenAI has no top_k
+		Seed:        req.Seed,
+	}
+}
+
+// convertToolChoice converts a tool choice to OpenAI's tool_choice, nil for
+// the default
+func convertToolChoice(c *provider.ToolChoice) interface{} {
+	if c == nil {
+		return nil
+	}
+	switch c.Type {
+	case provider.ToolChoiceAny:
+		return "required"
+	case provider.ToolChoiceNone:
+		return "none"
+	case provider.ToolChoiceTool:
+		return map[string]interface{}{
+			"type":     "function",
+			"function": map[string]string{"name": c.Name},
+		}
+	default:
+		return "auto"
 	}
 }
 
@@ -365,6 +432,15 @@ func (p *Provider) convertMessage(msg provider.Message) []openaiMessage {
 					Content:    b.Content,
 					ToolCallID: b.ToolUseID,
 				})
+				// Tool messages are text only; images follow as user content
+				for _, img := range b.Images {
+					contentParts = append(contentParts, contentPart{
+						Type: "image_url",
+						ImageURL: &imageURL{
+							URL: fmt.Sprintf("data:%s;base64,%s", img.Source.MediaType, img.Source.Data),
+						},
+					})
+				}
 			}
 		}
 
@@ -447,12 +523,16 @@ func (p *Provider) convertResponse(resp *openaiResponse) *provider.Response {
 	// Add tool calls
 	for _, tc := range choice.Message.ToolCalls {
 		var input map[string]interface{}
-		json.Unmarshal([]byte(tc.Function.Arguments), &input)
+		var rawInput string
+		if tc.Function.Arguments != "" && json.Unmarshal([]byte(tc.Function.Arguments), &input) != nil {
+			rawInput = tc.Function.Arguments
+		}
 
 		content = append(content, &provider.ToolUseBlock{
-			ID:    tc.ID,
-			Name:  tc.Function.Name,
-			Input: input,
+			ID:       tc.ID,
+			Name:     tc.Function.Name,
+			Input:    input,
+			RawInput: rawInput,
 		})
 	}
 
@@ -481,19 +561,6 @@ func (p *Provider) convertResponse(resp *openaiResponse) *provider.Response {
 	}
 }
 
-// resolveModel maps model aliases to actual model IDs
-func (p *Provider) resolveModel(model string) string {
-	aliases := map[string]string{
-		"gpt4":     "gpt-4o",
-		"gpt4o":    "gpt-4o",
-		"gpt4mini": "gpt-4o-mini",
-	}
-	if resolved, ok := aliases[model]; ok {
-		return resolved
-	}
-	return model
-}
-
 // SSE Stream Reader for OpenAI
 type sseStreamReader struct {
 	ctx     context.Context
@@ -0,0 +1,53 @@
+package openai
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+func TestConvertToolChoice(t *testing.T) {
+	p := New("key")
+	tests := []struct {
+		choice *provider.ToolChoice
+		want   string
+	}{
+		{nil, `null`},
+		{&provider.ToolChoice{Type: provider.ToolChoiceAuto}, `"auto"`},
+		{&provider.ToolChoice{Type: provider.ToolChoiceAny}, `"required"`},
+		{&provider.ToolChoice{Type: provider.ToolChoiceNone}, `"none"`},
+		{&provider.ToolChoice{Type: provider.ToolChoiceTool, Name: "Read"}, `{"function":{"name":"Read"},"type":"function"}`},
+	}
+	for _, tt := range tests {
+		req := p.convertRequest(&provider.Request{Tools: []provider.Tool{{Name: "Read"}}, ToolChoice: tt.choice})
+		got, _ := json.Marshal(req.ToolChoice)
+		if string(got) != tt.want {
+			t.Errorf("%+v: tool_choice = %s, want %s", tt.choice, got, tt.want)
+		}
+	}
+
+	// OpenAI rejects a tool choice without tools
+	req := p.convertRequest(&provider.Request{ToolChoice: &provider.ToolChoice{Type: provider.ToolChoiceAny}})
+	if req.ToolChoice != nil {
+		t.Errorf("expected no tool_choice without tools, got %v", req.ToolChoice)
+	}
+}
+
+func TestConvertStopSequences(t *testing.T) {
+	req := New("key").convertRequest(&provider.Request{MaxTokens: 50, StopSequences: []string{"END"}})
+	data, _ := json.Marshal(req)
+	if !strings.Contains(string(data), `"max_tokens":50`) || !strings.Contains(string(data), `"stop":["END"]`) {
+		t.Errorf("unexpected request: %s", data)
+	}
+}
+
+func TestConvertSampling(t *testing.T) {
+	seed := int64(0)
+	req := New("key").convertRequest(&provider.Request{Temperature: 0.2, TopP: 0.9, TopK: 40, Seed: &seed})
+	data, _ := json.Marshal(req)
+	if !strings.Contains(string(data), `"top_p":0.9,"seed":0`) || strings.Contains(string(data), "top_k") {
+		t.Errorf("expected top_p and seed without top_k, got %s", data)
+	}
+}
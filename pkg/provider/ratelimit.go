@@ -0,0 +1,169 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit is one quota reported by a provider, such as requests or tokens
+type RateLimit struct {
+	Name      string    // requests, tokens, input-tokens, output-tokens
+	Limit     int64     // -1 if not reported
+	Remaining int64     // -1 if not reported
+	Reset     time.Time // zero if not reported
+}
+
+// RateLimits are the quotas reported with a provider response
+type RateLimits struct {
+	Provider   string
+	Captured   time.Time
+	Status     int           // HTTP status of the response
+	RetryAfter time.Duration // from a Retry-After header, 0 if absent
+	Limits     []RateLimit
+}
+
+// RateLimitReporter is implemented by providers that capture rate-limit
+// headers from their responses
+type RateLimitReporter interface {
+	// RateLimits returns the quotas from the latest response, or nil
+	RateLimits() *RateLimits
+}
+
+// RateLimitTracker keeps the rate limits of the latest response
+type RateLimitTracker struct {
+	mu     sync.Mutex
+	latest *RateLimits
+}
+
+// Update records the rate limits of a response, ignoring responses without any
+func (t *RateLimitTracker) Update(limits *RateLimits) {
+	if limits == nil || (len(limits.Limits) == 0 && limits.RetryAfter == 0) {
+		return
+	}
+	t.mu.Lock()
+	t.latest = limits
+	t.mu.Unlock()
+}
+
+// RateLimits returns the latest rate limits, or nil if none were captured
+func (t *RateLimitTracker) RateLimits() *RateLimits {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.latest
+}
+
+// anthropicRateLimits are the quotas in anthropic-ratelimit-<name>-* headers
+var anthropicRateLimits = []string{"requests", "tokens", "input-tokens", "output-tokens"}
+
+// ParseAnthropicRateLimits reads anthropic-ratelimit-* headers, whose resets
+// are RFC 3339 timestamps
+func ParseAnthropicRateLimits(resp *http.Response, now time.Time) *RateLimits {
+	limits := newRateLimits("claude", resp, now)
+	for _, name := range anthropicRateLimits {
+		prefix := "Anthropic-Ratelimit-" + name
+		limit := RateLimit{
+			Name:      name,
+			Limit:     headerInt(resp.Header, prefix+"-Limit"),
+			Remaining: headerInt(resp.Header, prefix+"-Remaining"),
+		}
+		if reset := resp.Header.Get(prefix + "-Reset"); reset != "" {
+			limit.Reset, _ = time.Parse(time.RFC3339, reset)
+		}
+		limits.add(limit)
+	}
+	return limits
+}
+
+// openaiRateLimits are the quotas in x-ratelimit-*-<name> headers
+var openaiRateLimits = []string{"requests", "tokens"}
+
+// ParseOpenAIRateLimits reads x-ratelimit-* headers, whose resets are
+// durations such as "6m0s" or "20ms"
+func ParseOpenAIRateLimits(resp *http.Response, now time.Time) *RateLimits {
+	limits := newRateLimits("openai", resp, now)
+	for _, name := range openaiRateLimits {
+		limit := RateLimit{
+			Name:      name,
+			Limit:     headerInt(resp.Header, "X-Ratelimit-Limit-"+name),
+			Remaining: headerInt(resp.Header, "X-Ratelimit-Remaining-"+name),
+		}
+		if reset := resp.Header.Get("X-Ratelimit-Reset-" + name); reset != "" {
+			if d, err := time.ParseDuration(reset); err == nil {
+				limit.Reset = now.Add(d)
+			}
+		}
+		limits.add(limit)
+	}
+	return limits
+}
+
+// newRateLimits creates the rate limits of a response with its Retry-After
+func newRateLimits(provider string, resp *http.Response, now time.Time) *RateLimits {
+	limits := &RateLimits{Provider: provider, Captured: now, Status: resp.StatusCode}
+	if secs, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); err == nil && secs > 0 {
+		limits.RetryAfter = time.Duration(secs * float64(time.Second))
+	}
+	return limits
+}
+
+// add appends a limit if any of its headers were present
+func (l *RateLimits) add(limit RateLimit) {
+	if limit.Limit < 0 && limit.Remaining < 0 && limit.Reset.IsZero() {
+		return
+	}
+	l.Limits = append(l.Limits, limit)
+}
+
+func headerInt(h http.Header, key string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(h.Get(key)), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// Format describes the rate limits as of now, one quota per line
+func (l *RateLimits) Format(now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Rate limits for %s (as of %s ago", l.Provider, now.Sub(l.Captured).Round(time.Second))
+	if l.Status != 0 && l.Status != http.StatusOK {
+		fmt.Fprintf(&b, ", status %d", l.Status)
+	}
+	b.WriteString(")\n")
+
+	for _, limit := range l.Limits {
+		fmt.Fprintf(&b, "  %-14s %s", limit.Name, formatQuota(limit.Remaining, limit.Limit))
+		if !limit.Reset.IsZero() {
+			if wait := limit.Reset.Sub(now); wait > 0 {
+				fmt.Fprintf(&b, ", resets in %s", wait.Round(time.Second))
+			} else {
+				b.WriteString(", reset")
+			}
+		}
+		if limit.Remaining == 0 {
+			b.WriteString("  (exhausted)")
+		}
+		b.WriteString("\n")
+	}
+	if l.RetryAfter > 0 {
+		fmt.Fprintf(&b, "  retry after    %s\n", l.RetryAfter.Round(time.Second))
+	}
+	return b.String()
+}
+
+func formatQuota(remaining, limit int64) string {
+	switch {
+	case remaining >= 0 && limit > 0:
+		return fmt.Sprintf("%d of %d remaining", remaining, limit)
+	case remaining >= 0:
+		return fmt.Sprintf("%d remaining", remaining)
+	case limit > 0:
+		return fmt.Sprintf("limit %d", limit)
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAnthropicRateLimits(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set("anthropic-ratelimit-requests-limit", "50")
+	resp.Header.Set("anthropic-ratelimit-requests-remaining", "0")
+	resp.Header.Set("anthropic-ratelimit-requests-reset", "2024-06-01T12:00:30Z")
+	resp.Header.Set("anthropic-ratelimit-input-tokens-limit", "40000")
+	resp.Header.Set("anthropic-ratelimit-input-tokens-remaining", "12000")
+	resp.Header.Set("retry-after", "30")
+
+	limits := ParseAnthropicRateLimits(resp, now)
+	if len(limits.Limits) != 2 {
+		t.Fatalf("expected 2 limits, got %+v", limits.Limits)
+	}
+	req := limits.Limits[0]
+	if req.Name != "requests" || req.Limit != 50 || req.Remaining != 0 || !req.Reset.Equal(now.Add(30*time.Second)) {
+		t.Errorf("unexpected requests limit: %+v", req)
+	}
+	if in := limits.Limits[1]; in.Name != "input-tokens" || in.Remaining != 12000 || !in.Reset.IsZero() {
+		t.Errorf("unexpected input-tokens limit: %+v", in)
+	}
+	if limits.RetryAfter != 30*time.Second {
+		t.Errorf("expected retry after 30s, got %v", limits.RetryAfter)
+	}
+
+	out := limits.Format(now.Add(5 * time.Second))
+	for _, want := range []string{"status 429", "0 of 50 remaining, resets in 25s  (exhausted)", "12000 of 40000 remaining", "retry after    30s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Format missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestParseOpenAIRateLimits(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	resp.Header.Set("x-ratelimit-limit-requests", "500")
+	resp.Header.Set("x-ratelimit-remaining-requests", "499")
+	resp.Header.Set("x-ratelimit-reset-requests", "120ms")
+	resp.Header.Set("x-ratelimit-limit-tokens", "30000")
+	resp.Header.Set("x-ratelimit-remaining-tokens", "29000")
+	resp.Header.Set("x-ratelimit-reset-tokens", "6m0s")
+
+	limits := ParseOpenAIRateLimits(resp, now)
+	if len(limits.Limits) != 2 {
+		t.Fatalf("expected 2 limits, got %+v", limits.Limits)
+	}
+	if tok := limits.Limits[1]; tok.Remaining != 29000 || !tok.Reset.Equal(now.Add(6*time.Minute)) {
+		t.Errorf("unexpected tokens limit: %+v", tok)
+	}
+	if strings.Contains(limits.Format(now), "status") {
+		t.Error("expected no status for a successful response")
+	}
+}
+
+func TestRateLimitTracker(t *testing.T) {
+	var tracker RateLimitTracker
+	if tracker.RateLimits() != nil {
+		t.Error("expected no limits before a response")
+	}
+
+	first := &RateLimits{Limits: []RateLimit{{Name: "requests", Limit: 10, Remaining: 9}}}
+	tracker.Update(first)
+	// Responses without rate-limit headers keep the last known limits
+	tracker.Update(&RateLimits{})
+	tracker.Update(nil)
+	if tracker.RateLimits() != first {
+		t.Errorf("expected first limits to be kept, got %+v", tracker.RateLimits())
+	}
+}
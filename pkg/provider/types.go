@@ -4,6 +4,8 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 )
 
 // Role represents the role of a message sender
@@ -24,6 +26,9 @@ const (
 	ContentTypeToolUse    ContentType = "tool_use"
 	ContentTypeToolResult ContentType = "tool_result"
 	ContentTypeThinking   ContentType = "thinking"
+
+	// ContentTypeRedactedThinking is thinking encrypted by the provider
+	ContentTypeRedactedThinking ContentType = "redacted_thinking"
 )
 
 // ContentBlock is the interface for all content block types
@@ -35,15 +40,29 @@ type ContentBlock interface {
 // TextBlock represents text content
 type TextBlock struct {
 	Text string `json:"text"`
+
+	// Sources the provider's built-in web search cited for the text
+	Citations []Citation `json:"citations,omitempty"`
 }
 
 func (t *TextBlock) Type() ContentType { return ContentTypeText }
 
 func (t *TextBlock) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	m := map[string]interface{}{
 		"type": ContentTypeText,
 		"text": t.Text,
-	})
+	}
+	if len(t.Citations) > 0 {
+		m["citations"] = t.Citations
+	}
+	return json.Marshal(m)
+}
+
+// Citation is a web page a provider's built-in search cited for a response
+type Citation struct {
+	URL       string `json:"url"`
+	Title     string `json:"title,omitempty"`
+	CitedText string `json:"cited_text,omitempty"` // the passage relied on, when the provider reports it
 }
 
 // ImageBlock represents image content
@@ -71,6 +90,9 @@ type ToolUseBlock struct {
 	ID    string                 `json:"id"`
 	Name  string                 `json:"name"`
 	Input map[string]interface{} `json:"input"`
+	// RawInput holds the arguments as the model sent them when they were
+	// not valid JSON, for the engine to repair (empty otherwise)
+	RawInput string `json:"-"`
 }
 
 func (t *ToolUseBlock) Type() ContentType { return ContentTypeToolUse }
@@ -89,17 +111,39 @@ type ToolResultBlock struct {
 	ToolUseID string `json:"tool_use_id"`
 	Content   string `json:"content"`
 	IsError   bool   `json:"is_error,omitempty"`
+
+	// Images returned with the result, sent where the provider accepts them
+	Images []*ImageBlock `json:"-"`
+	// Structured is a JSON value the tool returned besides its text, for
+	// providers that take structured tool results
+	Structured json.RawMessage `json:"-"`
 }
 
 func (t *ToolResultBlock) Type() ContentType { return ContentTypeToolResult }
 
+// ContentBlocks returns the content as a text block followed by the images
+func (t *ToolResultBlock) ContentBlocks() []ContentBlock {
+	blocks := []ContentBlock{&TextBlock{Text: t.Content}}
+	for _, img := range t.Images {
+		blocks = append(blocks, img)
+	}
+	return blocks
+}
+
 func (t *ToolResultBlock) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	m := map[string]interface{}{
 		"type":        ContentTypeToolResult,
 		"tool_use_id": t.ToolUseID,
 		"content":     t.Content,
 		"is_error":    t.IsError,
-	})
+	}
+	if len(t.Images) > 0 {
+		m["content"] = t.ContentBlocks()
+	}
+	if len(t.Structured) > 0 {
+		m["structured_content"] = t.Structured
+	}
+	return json.Marshal(m)
 }
 
 // ThinkingBlock represents the thinking process (Claude extended thinking)
@@ -118,6 +162,113 @@ func (t *ThinkingBlock) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// RedactedThinkingBlock is thinking the provider encrypted for safety. It
+// can't be displayed but must be sent back unchanged with the conversation.
+type RedactedThinkingBlock struct {
+	Data string `json:"data"`
+}
+
+func (t *RedactedThinkingBlock) Type() ContentType { return ContentTypeRedactedThinking }
+
+func (t *RedactedThinkingBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"type": ContentTypeRedactedThinking,
+		"data": t.Data,
+	})
+}
+
+// UnmarshalContentBlock decodes a content block from its JSON form.
+// Tool result content may be a string or a list of text blocks.
+func UnmarshalContentBlock(data []byte) (ContentBlock, error) {
+	var raw struct {
+		Type      ContentType            `json:"type"`
+		Text      string                 `json:"text"`
+		Source    ImageSource            `json:"source"`
+		ID        string                 `json:"id"`
+		Name      string                 `json:"name"`
+		Input     map[string]interface{} `json:"input"`
+		ToolUseID string                 `json:"tool_use_id"`
+		Content   json.RawMessage        `json:"content"`
+		IsError   bool                   `json:"is_error"`
+		Thinking  string                 `json:"thinking"`
+		Signature string                 `json:"signature"`
+		Data      string                 `json:"data"`
+		Citations []Citation             `json:"citations"`
+
+		StructuredContent json.RawMessage `json:"structured_content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	switch raw.Type {
+	case ContentTypeText:
+		return &TextBlock{Text: raw.Text, Citations: raw.Citations}, nil
+	case ContentTypeImage:
+		return &ImageBlock{Source: raw.Source}, nil
+	case ContentTypeToolUse:
+		return &ToolUseBlock{ID: raw.ID, Name: raw.Name, Input: raw.Input}, nil
+	case ContentTypeToolResult:
+		return &ToolResultBlock{
+			ToolUseID:  raw.ToolUseID,
+			Content:    toolResultText(raw.Content),
+			IsError:    raw.IsError,
+			Images:     toolResultImages(raw.Content),
+			Structured: raw.StructuredContent,
+		}, nil
+	case ContentTypeThinking:
+		return &ThinkingBlock{Thinking: raw.Thinking, Signature: raw.Signature}, nil
+	case ContentTypeRedactedThinking:
+		return &RedactedThinkingBlock{Data: raw.Data}, nil
+	default:
+		return nil, fmt.Errorf("unknown content block type: %q", raw.Type)
+	}
+}
+
+// toolResultText flattens tool result content given as a string or text blocks
+func toolResultText(content json.RawMessage) string {
+	if len(content) == 0 {
+		return ""
+	}
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text
+	}
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return string(content)
+	}
+	var parts []string
+	for _, b := range blocks {
+		if b.Type == "text" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// toolResultImages returns the image blocks of tool result content given
+// as a list of blocks
+func toolResultImages(content json.RawMessage) []*ImageBlock {
+	var blocks []struct {
+		Type   ContentType `json:"type"`
+		Source ImageSource `json:"source"`
+	}
+	if json.Unmarshal(content, &blocks) != nil {
+		return nil
+	}
+	var images []*ImageBlock
+	for _, b := range blocks {
+		if b.Type == ContentTypeImage && b.Source.Data != "" {
+			images = append(images, &ImageBlock{Source: b.Source})
+		}
+	}
+	return images
+}
+
 // Message represents a conversation message
 type Message struct {
 	Role    Role           `json:"role"`
@@ -170,9 +321,28 @@ type Request struct {
 	System      []ContentBlock `json:"system,omitempty"`
 	Stream      bool           `json:"stream,omitempty"`
 
+	// Sampling beyond Temperature: TopP and TopK (0 = the provider's
+	// default) and a Seed for reproducible generations (nil = none).
+	// Providers leave out those they do not support.
+	TopP float64 `json:"top_p,omitempty"`
+	TopK int     `json:"top_k,omitempty"`
+	Seed *int64  `json:"seed,omitempty"`
+
+	// StopSequences end the response where the model writes one of them,
+	// with StopReasonStop where the provider reports it
+	StopSequences []string `json:"stop_sequences,omitempty"`
+
 	// Extended thinking (Claude specific)
 	Thinking *ThinkingConfig `json:"thinking,omitempty"`
 
+	// ToolChoice controls whether and which tools the model calls (nil =
+	// the provider's default, auto). It applies only when Tools is not empty.
+	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+
+	// WebSearch has the model search the web with the provider's built-in
+	// search, for providers that support FeatureWebSearch
+	WebSearch bool `json:"web_search,omitempty"`
+
 	// Provider-specific extra fields
 	Extra map[string]interface{} `json:"-"`
 }
@@ -183,6 +353,46 @@ type ThinkingConfig struct {
 	BudgetTokens int    `json:"budget_tokens"` // max tokens for thinking
 }
 
+// ToolChoiceType says whether the model must call a tool
+type ToolChoiceType string
+
+const (
+	ToolChoiceAuto ToolChoiceType = "auto" // the model decides
+	ToolChoiceAny  ToolChoiceType = "any"  // the model must call some tool
+	ToolChoiceNone ToolChoiceType = "none" // the model must not call tools
+	ToolChoiceTool ToolChoiceType = "tool" // the model must call the tool Name
+)
+
+// ToolChoice controls the model's tool calls. Claude cannot think while
+// forced to call a tool, so its provider drops thinking from requests that
+// force one.
+type ToolChoice struct {
+	Type ToolChoiceType `json:"type"`
+	Name string         `json:"name,omitempty"` // for ToolChoiceTool
+}
+
+// Forced reports whether the model must call a tool
+func (c *ToolChoice) Forced() bool {
+	return c != nil && (c.Type == ToolChoiceAny || c.Type == ToolChoiceTool)
+}
+
+// ParseToolChoice parses auto, any (or required), none, or the name of a
+// tool the model must call
+func ParseToolChoice(s string) (*ToolChoice, error) {
+	switch strings.TrimSpace(s) {
+	case "":
+		return nil, fmt.Errorf("empty tool choice")
+	case "auto":
+		return &ToolChoice{Type: ToolChoiceAuto}, nil
+	case "any", "required":
+		return &ToolChoice{Type: ToolChoiceAny}, nil
+	case "none":
+		return &ToolChoice{Type: ToolChoiceNone}, nil
+	default:
+		return &ToolChoice{Type: ToolChoiceTool, Name: strings.TrimSpace(s)}, nil
+	}
+}
+
 // Response represents an AI completion response
 type Response struct {
 	ID         string         `json:"id"`
@@ -320,6 +530,20 @@ type ThinkingDelta struct {
 
 func (d *ThinkingDelta) DeltaType() string { return "thinking_delta" }
 
+// SignatureDelta carries the signature that verifies a thinking block
+type SignatureDelta struct {
+	Signature string `json:"signature"`
+}
+
+func (d *SignatureDelta) DeltaType() string { return "signature_delta" }
+
+// CitationsDelta adds a citation to a text block
+type CitationsDelta struct {
+	Citation Citation `json:"citation"`
+}
+
+func (d *CitationsDelta) DeltaType() string { return "citations_delta" }
+
 // InputJSONDelta represents a tool input delta
 type InputJSONDelta struct {
 	PartialJSON string `json:"partial_json"`
@@ -338,35 +562,3 @@ type ProviderConfig struct {
 	BaseURL string
 	Timeout int // seconds
 }
-
-// ModelAlias maps model aliases to actual model IDs
-var ModelAlias = map[string]string{
-	// Claude models
-	"sonnet": "claude-sonnet-4-5-20250929",
-	"opus":   "claude-opus-4-5-20251101",
-	"haiku":  "claude-haiku-4-5-20251101",
-	// Gemini models
-	"gemini":       "gemini-2.0-flash",
-	"gemini-pro":   "gemini-1.5-pro",
-	"gemini-flash": "gemini-2.0-flash",
-	// OpenAI models
-	"gpt5":  "gpt-5.2",
-	"gpt4":  "gpt-4-turbo",
-	"gpt4o": "gpt-4o",
-	"o3":    "o3",
-	// DeepSeek models
-	"deepseek": "deepseek-chat",
-	"r1":       "deepseek-reasoner",
-	// Ollama models
-	"ollama": "qwen3",
-	"llama":  "llama3.3",
-	"qwen":   "qwen3",
-}
-
-// ResolveModel resolves a model alias to the actual model ID
-func ResolveModel(model string) string {
-	if resolved, ok := ModelAlias[model]; ok {
-		return resolved
-	}
-	return model
-}
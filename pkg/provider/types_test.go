@@ -144,6 +144,11 @@ func TestResolveModel(t *testing.T) {
 		{"haiku", "claude-haiku-4-5-20251101"},
 		{"gpt-4", "gpt-4"},
 		{"custom-model", "custom-model"},
+		{"claude:opus", "claude-opus-4-5-20251101"},
+		{"ollama:qwen2.5-coder:7b", "qwen2.5-coder:7b"},
+		{"qwen2.5-coder:7b", "qwen2.5-coder:7b"},
+		{"github:gpt-4o", "github/gpt-4o"},
+		{"azure:gpt-4o-prod", "gpt-4o-prod"},
 	}
 
 	for _, tt := range tests {
@@ -156,6 +161,40 @@ func TestResolveModel(t *testing.T) {
 	}
 }
 
+func TestModelAliases(t *testing.T) {
+	SetModelAliases(map[string]string{
+		"fast":   "gemini-1.5-flash",
+		"local":  "ollama:qwen2.5-coder:7b",
+		"cheap":  "haiku",
+		"sonnet": "opus",
+	})
+	defer SetModelAliases(nil)
+
+	tests := []struct {
+		model    string
+		resolved string
+		provider ProviderType
+	}{
+		{"fast", "gemini-1.5-flash", ProviderTypeGemini},
+		{"local", "qwen2.5-coder:7b", ProviderTypeOllama},
+		{"cheap", ModelAlias["haiku"], ProviderTypeClaude},
+		{"sonnet", ModelAlias["opus"], ProviderTypeClaude},
+		{"gpt4o", "gpt-4o", ProviderTypeOpenAI},
+	}
+	for _, tt := range tests {
+		if got := ResolveModel(tt.model); got != tt.resolved {
+			t.Errorf("ResolveModel(%q) = %q, want %q", tt.model, got, tt.resolved)
+		}
+		if got := DetectProviderFromModel(tt.model); got != tt.provider {
+			t.Errorf("DetectProviderFromModel(%q) = %q, want %q", tt.model, got, tt.provider)
+		}
+	}
+
+	if aliases := ModelAliases(); aliases["fast"] != "gemini-1.5-flash" || aliases["sonnet"] != "opus" || aliases["haiku"] != ModelAlias["haiku"] {
+		t.Errorf("unexpected aliases: %v", aliases)
+	}
+}
+
 func TestDetectProviderFromModel(t *testing.T) {
 	tests := []struct {
 		model    string
@@ -173,7 +212,18 @@ func TestDetectProviderFromModel(t *testing.T) {
 		{"deepseek-chat", ProviderTypeDeepSeek},
 		{"deepseek-coder", ProviderTypeDeepSeek},
 		{"r1", ProviderTypeDeepSeek},
+		{"github", ProviderTypeGitHub},
+		{"github/gpt-4o", ProviderTypeGitHub},
+		{"github/meta/Llama-3.3-70B-Instruct", ProviderTypeGitHub},
 		{"unknown-model", ProviderTypeClaude}, // default
+		{"ollama:qwen2.5-coder", ProviderTypeOllama},
+		{"openai:gpt-4o-mini", ProviderTypeOpenAI},
+		{"claude:opus", ProviderTypeClaude},
+		{"ollama:gpt-4o", ProviderTypeOllama}, // explicit provider wins
+		{"OpenAI:llama-3", ProviderTypeOpenAI},
+		{"azure:gpt-4o-prod", ProviderTypeAzureOpenAI},
+		{"azureopenai:o3-mini", ProviderTypeAzureOpenAI},
+		{"qwen2.5-coder:7b", ProviderTypeOllama}, // a tag, not a provider
 	}
 
 	for _, tt := range tests {
@@ -257,3 +307,76 @@ func TestFeatureConstants(t *testing.T) {
 		seen[f] = true
 	}
 }
+
+func TestUnmarshalContentBlock(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want ContentBlock
+	}{
+		{"text", `{"type":"text","text":"hi"}`, &TextBlock{Text: "hi"}},
+		{"tool_use", `{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"a.go"}}`,
+			&ToolUseBlock{ID: "t1", Name: "Read", Input: map[string]interface{}{"file_path": "a.go"}}},
+		{"tool_result string", `{"type":"tool_result","tool_use_id":"t1","content":"ok","is_error":true}`,
+			&ToolResultBlock{ToolUseID: "t1", Content: "ok", IsError: true}},
+		{"tool_result blocks", `{"type":"tool_result","tool_use_id":"t1","content":[{"type":"text","text":"a"},{"type":"image"},{"type":"text","text":"b"}]}`,
+			&ToolResultBlock{ToolUseID: "t1", Content: "a\nb"}},
+		{"tool_result image", `{"type":"tool_result","tool_use_id":"t1","content":[{"type":"text","text":"chart"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AAAA"}}],"structured_content":{"rows":2}}`,
+			&ToolResultBlock{ToolUseID: "t1", Content: "chart", Images: []*ImageBlock{{Source: ImageSource{Type: "base64", MediaType: "image/png", Data: "AAAA"}}}, Structured: json.RawMessage(`{"rows":2}`)}},
+		{"thinking", `{"type":"thinking","thinking":"hmm","signature":"sig"}`, &ThinkingBlock{Thinking: "hmm", Signature: "sig"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalContentBlock([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotJSON, _ := got.MarshalJSON()
+			wantJSON, _ := tt.want.MarshalJSON()
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("got %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+
+	if _, err := UnmarshalContentBlock([]byte(`{"type":"server_tool_use"}`)); err == nil {
+		t.Error("expected error for unknown block type")
+	}
+}
+
+func TestRedactedThinkingBlockRoundTrip(t *testing.T) {
+	data, err := json.Marshal(&RedactedThinkingBlock{Data: "opaque"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := UnmarshalContentBlock(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rb, ok := block.(*RedactedThinkingBlock); !ok || rb.Data != "opaque" || rb.Type() != ContentTypeRedactedThinking {
+		t.Errorf("unexpected block after round trip: %#v", block)
+	}
+}
+
+func TestParseToolChoice(t *testing.T) {
+	tests := []struct {
+		input string
+		want  ToolChoice
+	}{
+		{"auto", ToolChoice{Type: ToolChoiceAuto}},
+		{"any", ToolChoice{Type: ToolChoiceAny}},
+		{"required", ToolChoice{Type: ToolChoiceAny}},
+		{"none", ToolChoice{Type: ToolChoiceNone}},
+		{"TodoWrite", ToolChoice{Type: ToolChoiceTool, Name: "TodoWrite"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseToolChoice(tt.input)
+		if err != nil || *got != tt.want {
+			t.Errorf("ParseToolChoice(%q) = %+v, %v, want %+v", tt.input, got, err, tt.want)
+		}
+	}
+	if _, err := ParseToolChoice(""); err == nil {
+		t.Error("expected an error for an empty tool choice")
+	}
+}
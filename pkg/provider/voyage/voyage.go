@@ -0,0 +1,132 @@
+// Package voyage implements embeddings with the Voyage AI API, which has no
+// chat models
+package voyage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+const (
+	defaultBaseURL = "https://api.voyageai.com/v1"
+
+	// DefaultEmbeddingModel is used when an embed request names no model
+	DefaultEmbeddingModel = "voyage-3"
+)
+
+// Provider implements the Voyage AI embeddings provider
+type Provider struct {
+	apiKey    string
+	baseURL   string
+	client    *http.Client
+	inputType string
+}
+
+// Option is a function that configures the Provider
+type Option func(*Provider)
+
+// WithBaseURL sets a custom base URL
+func WithBaseURL(url string) Option {
+	return func(p *Provider) {
+		p.baseURL = url
+	}
+}
+
+// WithInputType marks the texts as a "query" or a "document", which
+// Voyage uses to tune the embeddings for retrieval
+func WithInputType(inputType string) Option {
+	return func(p *Provider) {
+		p.inputType = inputType
+	}
+}
+
+// New creates a new Voyage AI provider
+func New(apiKey string, opts ...Option) *Provider {
+	p := &Provider{
+		apiKey:  apiKey,
+		baseURL: defaultBaseURL,
+		client: &http.Client{
+			Timeout: 2 * time.Minute,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return "voyage"
+}
+
+type embeddingRequest struct {
+	Model     string   `json:"model"`
+	Input     []string `json:"input"`
+	InputType string   `json:"input_type,omitempty"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Usage struct {
+		TotalTokens int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// Embed returns the embeddings of texts
+func (p *Provider) Embed(ctx context.Context, req *provider.EmbedRequest) (*provider.EmbedResponse, error) {
+	model := req.Model
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+	body, err := json.Marshal(embeddingRequest{Model: model, Input: req.Texts, InputType: p.inputType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var embResp embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	sort.Slice(embResp.Data, func(i, j int) bool { return embResp.Data[i].Index < embResp.Data[j].Index })
+
+	result := &provider.EmbedResponse{Model: model, InputTokens: embResp.Usage.TotalTokens}
+	for _, d := range embResp.Data {
+		result.Vectors = append(result.Vectors, d.Embedding)
+	}
+	if err := provider.CheckEmbeddings(result, req.Texts); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
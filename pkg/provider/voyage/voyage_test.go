@@ -0,0 +1,48 @@
+package voyage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+func TestEmbed(t *testing.T) {
+	var got embeddingRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		// Out of order, as the API does not promise the order
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0.3,0.4]},{"index":0,"embedding":[0.1,0.2]}],"usage":{"total_tokens":7}}`))
+	}))
+	defer server.Close()
+
+	p := New("key", WithBaseURL(server.URL), WithInputType("document"))
+	resp, err := p.Embed(context.Background(), &provider.EmbedRequest{Texts: []string{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Model != DefaultEmbeddingModel || got.InputType != "document" || len(got.Input) != 2 {
+		t.Errorf("unexpected request: %+v", got)
+	}
+	if len(resp.Vectors) != 2 || resp.Vectors[0][0] != 0.1 || resp.Vectors[1][1] != 0.4 || resp.InputTokens != 7 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestEmbedCountMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"index":0,"embedding":[0.1]}]}`))
+	}))
+	defer server.Close()
+
+	p := New("key", WithBaseURL(server.URL))
+	if _, err := p.Embed(context.Background(), &provider.EmbedRequest{Texts: []string{"a", "b"}}); err == nil {
+		t.Error("expected an error when fewer embeddings come back than texts")
+	}
+}
@@ -0,0 +1,175 @@
+package release
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Artifact is a packaged binary for one platform
+type Artifact struct {
+	Platform string
+	Path     string // archive path
+	SHA256   string
+}
+
+// BuildInfo is substituted into the configured ldflags
+type BuildInfo struct {
+	Version string
+	Tag     string
+	Commit  string
+}
+
+// ldflags expands the build info placeholders in the configured ldflags
+func (p *Project) ldflags(info BuildInfo) string {
+	return strings.NewReplacer(
+		"{{.Version}}", info.Version,
+		"{{.Tag}}", info.Tag,
+		"{{.Commit}}", info.Commit,
+	).Replace(p.Ldflags)
+}
+
+// Build cross-compiles the main package for each platform into the dist
+// directory, packages each binary as a .tar.gz (.zip for Windows), and
+// writes checksums.txt. Build output goes to log.
+func (p *Project) Build(ctx context.Context, info BuildInfo, log io.Writer) ([]Artifact, error) {
+	dist := filepath.Join(p.Dir, p.Dist)
+	if err := os.RemoveAll(dist); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dist, 0755); err != nil {
+		return nil, err
+	}
+
+	var artifacts []Artifact
+	for _, platform := range p.Platforms {
+		goos, goarch, _ := strings.Cut(platform, "/")
+		name := fmt.Sprintf("%s_%s_%s_%s", p.Binary, info.Version, goos, goarch)
+		binary := p.Binary
+		if goos == "windows" {
+			binary += ".exe"
+		}
+		binPath := filepath.Join(dist, name, binary)
+
+		fmt.Fprintf(log, "  building %s\n", platform)
+		cmd := exec.CommandContext(ctx, "go", "build", "-trimpath", "-ldflags", p.ldflags(info), "-o", binPath, p.Main)
+		cmd.Dir = p.Dir
+		cmd.Env = append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch, "CGO_ENABLED=0")
+		cmd.Stdout, cmd.Stderr = log, log
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("build for %s failed: %w", platform, err)
+		}
+
+		var archive string
+		var err error
+		if goos == "windows" {
+			archive, err = zipBinary(filepath.Join(dist, name+".zip"), binPath, binary)
+		} else {
+			archive, err = tarBinary(filepath.Join(dist, name+".tar.gz"), binPath, binary)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to package %s: %w", platform, err)
+		}
+		sum, err := fileSHA256(archive)
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, Artifact{Platform: platform, Path: archive, SHA256: sum})
+	}
+
+	if err := writeChecksums(filepath.Join(dist, "checksums.txt"), artifacts); err != nil {
+		return nil, err
+	}
+	return artifacts, nil
+}
+
+// tarBinary packages a binary as a gzipped tarball
+func tarBinary(archive, binPath, name string) (string, error) {
+	f, err := os.Create(archive)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	data, err := os.ReadFile(binPath)
+	if err != nil {
+		return "", err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(data))}); err != nil {
+		return "", err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return "", err
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+	return archive, f.Close()
+}
+
+// zipBinary packages a binary as a zip archive
+func zipBinary(archive, binPath, name string) (string, error) {
+	f, err := os.Create(archive)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+
+	data, err := os.ReadFile(binPath)
+	if err != nil {
+		return "", err
+	}
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	header.SetMode(0755)
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return archive, f.Close()
+}
+
+// fileSHA256 returns the hex SHA-256 of a file
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeChecksums writes the artifacts' checksums in sha256sum format
+func writeChecksums(path string, artifacts []Artifact) error {
+	lines := make([]string, 0, len(artifacts))
+	for _, a := range artifacts {
+		lines = append(lines, a.SHA256+"  "+filepath.Base(a.Path))
+	}
+	sort.Strings(lines)
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
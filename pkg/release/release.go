@@ -0,0 +1,211 @@
+// Package release versions, builds, and packages Go projects for
+// "agentic-coder release"
+package release
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+)
+
+// DefaultPlatforms are the GOOS/GOARCH pairs built when none are configured
+var DefaultPlatforms = []string{
+	"linux/amd64", "linux/arm64",
+	"darwin/amd64", "darwin/arm64",
+	"windows/amd64", "windows/arm64",
+}
+
+// Project is a project's release settings with the defaults filled in
+type Project struct {
+	Dir         string
+	VersionFile string // relative to Dir; empty until found
+	VersionVar  string
+	TagPrefix   string
+	Main        string
+	Binary      string
+	Platforms   []string
+	Ldflags     string
+	Dist        string
+	TestCommand string
+	Changelog   string
+}
+
+// NewProject applies the defaults to a project's release settings
+func NewProject(dir string, cfg config.ReleaseConfig) *Project {
+	p := &Project{
+		Dir:         dir,
+		VersionFile: cfg.VersionFile,
+		VersionVar:  cfg.VersionVar,
+		TagPrefix:   cfg.TagPrefix,
+		Main:        cfg.Main,
+		Binary:      cfg.Binary,
+		Platforms:   cfg.Platforms,
+		Ldflags:     cfg.Ldflags,
+		Dist:        cfg.Dist,
+		TestCommand: cfg.TestCommand,
+		Changelog:   cfg.Changelog,
+	}
+	if p.VersionVar == "" {
+		p.VersionVar = "version"
+	}
+	switch p.TagPrefix {
+	case "":
+		p.TagPrefix = "v"
+	case "none":
+		p.TagPrefix = ""
+	}
+	if p.Main == "" {
+		p.Main = "."
+	}
+	if p.Binary == "" {
+		p.Binary = filepath.Base(filepath.Join(dir, p.Main))
+	}
+	if len(p.Platforms) == 0 {
+		p.Platforms = DefaultPlatforms
+	}
+	if p.Ldflags == "" {
+		p.Ldflags = "-s -w"
+	}
+	if p.Dist == "" {
+		p.Dist = "dist"
+	}
+	if p.TestCommand == "" {
+		p.TestCommand = "go test ./..."
+	}
+	if p.Changelog == "" {
+		p.Changelog = "CHANGELOG.md"
+	}
+	return p
+}
+
+// Tag returns the git tag of a version
+func (p *Project) Tag(version string) string {
+	return p.TagPrefix + version
+}
+
+// versionPattern matches the declaration of a version variable or constant
+// with a string literal, in a var or const block or on its own
+func versionPattern(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^(\s*(?:(?:var|const)\s+)?` + regexp.QuoteMeta(name) + `\s*(?:string\s*)?=\s*")([^"]*)(")`)
+}
+
+// FindVersion returns the current version declared in the version file,
+// searching the project's Go files for it when none is configured
+func (p *Project) FindVersion() (string, error) {
+	re := versionPattern(p.VersionVar)
+	if p.VersionFile != "" {
+		data, err := os.ReadFile(filepath.Join(p.Dir, p.VersionFile))
+		if err != nil {
+			return "", err
+		}
+		m := re.FindSubmatch(data)
+		if m == nil {
+			return "", fmt.Errorf("%s does not declare %s as a string", p.VersionFile, p.VersionVar)
+		}
+		return string(m[2]), nil
+	}
+
+	var found, version string
+	err := filepath.WalkDir(p.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != p.Dir && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if m := re.FindSubmatch(data); m != nil {
+			found, version = path, string(m[2])
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if found == "" {
+		return "", fmt.Errorf("no Go file declares %s = \"...\"; set release.version_file", p.VersionVar)
+	}
+	rel, err := filepath.Rel(p.Dir, found)
+	if err != nil {
+		return "", err
+	}
+	p.VersionFile = rel
+	return version, nil
+}
+
+// SetVersion rewrites the version in the version file
+func (p *Project) SetVersion(version string) error {
+	path := filepath.Join(p.Dir, p.VersionFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	re := versionPattern(p.VersionVar)
+	loc := re.FindSubmatchIndex(data)
+	if loc == nil {
+		return fmt.Errorf("%s does not declare %s as a string", p.VersionFile, p.VersionVar)
+	}
+	// Replace only the first declaration's literal
+	out := append([]byte{}, data[:loc[4]]...)
+	out = append(out, version...)
+	out = append(out, data[loc[5]:]...)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, info.Mode().Perm())
+}
+
+// semver matches major.minor.patch with an optional pre-release suffix
+var semver = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)(-[0-9A-Za-z.-]+)?$`)
+
+// NextVersion returns the version after current: bump is major, minor,
+// patch, or an explicit version. A leading v is ignored on both.
+func NextVersion(current, bump string) (string, error) {
+	bump = strings.TrimPrefix(bump, "v")
+	if semver.MatchString(bump) {
+		return bump, nil
+	}
+
+	m := semver.FindStringSubmatch(strings.TrimPrefix(current, "v"))
+	if m == nil {
+		return "", fmt.Errorf("current version %q is not major.minor.patch; give the new version explicitly", current)
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+	prerelease := m[4] != ""
+
+	switch bump {
+	case "major":
+		major, minor, patch = major+1, 0, 0
+	case "minor":
+		minor, patch = minor+1, 0
+	case "", "patch":
+		// A pre-release is released as its own version
+		if !prerelease {
+			patch++
+		}
+	default:
+		return "", fmt.Errorf("unknown bump %q: use major, minor, patch, or a version such as 1.2.3", bump)
+	}
+	return fmt.Sprintf("%d.%d.%d", major, minor, patch), nil
+}
@@ -0,0 +1,136 @@
+package release
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+)
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		current, bump, want string
+	}{
+		{"0.1.0", "patch", "0.1.1"},
+		{"0.1.0", "", "0.1.1"},
+		{"v0.1.9", "minor", "0.2.0"},
+		{"1.4.2", "major", "2.0.0"},
+		{"1.0.0-rc.1", "patch", "1.0.0"},
+		{"0.1.0", "v0.3.0", "0.3.0"},
+		{"dev", "1.0.0", "1.0.0"},
+	}
+	for _, tt := range tests {
+		got, err := NextVersion(tt.current, tt.bump)
+		if err != nil || got != tt.want {
+			t.Errorf("NextVersion(%q, %q) = %q, %v; want %q", tt.current, tt.bump, got, err, tt.want)
+		}
+	}
+
+	if _, err := NextVersion("dev", "patch"); err == nil {
+		t.Error("expected an error bumping a version that is not semver")
+	}
+	if _, err := NextVersion("0.1.0", "huge"); err == nil {
+		t.Error("expected an error for an unknown bump")
+	}
+}
+
+func TestNewProjectDefaults(t *testing.T) {
+	p := NewProject("/src/tool", config.ReleaseConfig{Main: "./cmd/toolctl"})
+	if p.Binary != "toolctl" || p.TagPrefix != "v" || p.Dist != "dist" || len(p.Platforms) != len(DefaultPlatforms) {
+		t.Errorf("unexpected defaults: %+v", p)
+	}
+	if p.Tag("1.2.3") != "v1.2.3" {
+		t.Errorf("unexpected tag %q", p.Tag("1.2.3"))
+	}
+
+	bare := NewProject("/src/tool", config.ReleaseConfig{TagPrefix: "none"})
+	if bare.Binary != "tool" || bare.Tag("1.2.3") != "1.2.3" {
+		t.Errorf("unexpected project: %+v", bare)
+	}
+}
+
+func TestFindAndSetVersion(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "cmd", "tool"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	main := "package main\n\nvar (\n\tversion = \"0.1.0\"\n\tname    = \"tool\"\n)\n\nfunc main() {}\n"
+	if err := os.WriteFile(filepath.Join(dir, "cmd", "tool", "main.go"), []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cmd", "tool", "main_test.go"), []byte("package main\n\nconst version = \"9.9.9\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewProject(dir, config.ReleaseConfig{})
+	version, err := p.FindVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "0.1.0" || p.VersionFile != filepath.Join("cmd", "tool", "main.go") {
+		t.Errorf("found %q in %q", version, p.VersionFile)
+	}
+
+	if err := p.SetVersion("0.2.0"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, p.VersionFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Replace(main, "0.1.0", "0.2.0", 1); string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+
+	missing := NewProject(dir, config.ReleaseConfig{VersionVar: "Version"})
+	if _, err := missing.FindVersion(); err == nil {
+		t.Error("expected an error when no file declares the variable")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a binary")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module example.com/hello\n\ngo 1.21\n",
+		"main.go": "package main\n\nvar version = \"dev\"\n\nfunc main() { println(version) }\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := NewProject(dir, config.ReleaseConfig{
+		Binary:    "hello",
+		Platforms: []string{runtime.GOOS + "/" + runtime.GOARCH, "windows/amd64"},
+		Ldflags:   "-s -w -X main.version={{.Version}}",
+	})
+	artifacts, err := p.Build(context.Background(), BuildInfo{Version: "1.0.0", Tag: "v1.0.0"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != 2 {
+		t.Fatalf("expected 2 artifacts, got %+v", artifacts)
+	}
+	if !strings.HasSuffix(artifacts[1].Path, "hello_1.0.0_windows_amd64.zip") {
+		t.Errorf("unexpected windows archive %s", artifacts[1].Path)
+	}
+
+	sums, err := os.ReadFile(filepath.Join(dir, "dist", "checksums.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range artifacts {
+		if len(a.SHA256) != 64 || !strings.Contains(string(sums), a.SHA256+"  "+filepath.Base(a.Path)) {
+			t.Errorf("checksums.txt is missing %s", a.Path)
+		}
+	}
+}
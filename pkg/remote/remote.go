@@ -0,0 +1,295 @@
+// Package remote runs the file tools and Bash on another host over SSH, so
+// the project can be edited from a laptop and built on a build server while
+// the UI and providers stay local.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/tool/builtin"
+)
+
+// DefaultShell runs Bash commands on the host when none is configured
+const DefaultShell = "bash"
+
+// notFoundStatus is the exit status of the scripts below for a missing file
+const notFoundStatus = 44
+
+// Host is a project directory on a remote host, reached with the system ssh
+// client so the user's ssh config, agent and known hosts apply. It
+// implements builtin.Workspace: local paths under Root are translated to
+// Dir on the host, and programs run from the host's PATH.
+type Host struct {
+	Host         string   // [user@]host
+	Root         string   // local project directory
+	Dir          string   // project directory on the host
+	Port         int      // 0 for the ssh default
+	IdentityFile string   // "" for ssh's default keys
+	Options      []string // extra -o options
+	ControlDir   string   // directory of the shared connection's socket, "" for a connection per command
+	SSH          string   // ssh binary, default ssh
+
+	inputOnce sync.Once
+	input     *os.File // see commandInput
+	inputEnd  *os.File
+}
+
+// New returns the host configured for the project at root. Commands share
+// one connection, whose socket is kept in controlDir.
+func New(cfg config.RemoteConfig, root, controlDir string) *Host {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = filepath.ToSlash(root)
+	}
+	return &Host{
+		Host:         cfg.Host,
+		Root:         root,
+		Dir:          dir,
+		Port:         cfg.Port,
+		IdentityFile: cfg.IdentityFile,
+		Options:      cfg.SSHOptions,
+		ControlDir:   controlDir,
+	}
+}
+
+// String describes the host for display, as host:dir
+func (h *Host) String() string {
+	return h.Host + ":" + h.Dir
+}
+
+// sshArgs returns the arguments of ssh up to the host
+func (h *Host) sshArgs() []string {
+	args := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=15"}
+	if h.ControlDir != "" {
+		args = append(args,
+			"-o", "ControlMaster=auto",
+			"-o", "ControlPath="+filepath.Join(h.ControlDir, "%C"),
+			"-o", "ControlPersist=10m")
+	}
+	if h.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(h.Port))
+	}
+	if h.IdentityFile != "" {
+		args = append(args, "-i", h.IdentityFile)
+	}
+	for _, opt := range h.Options {
+		args = append(args, "-o", opt)
+	}
+	return append(args, h.Host)
+}
+
+// script prepares a shell script to run on the host. ssh runs its command
+// with the user's login shell, so the script is handed to sh.
+func (h *Host) script(ctx context.Context, script string) *exec.Cmd {
+	ssh := h.SSH
+	if ssh == "" {
+		ssh = "ssh"
+	}
+	if h.ControlDir != "" {
+		os.MkdirAll(h.ControlDir, 0700)
+	}
+	return exec.CommandContext(ctx, ssh, append(h.sshArgs(), "sh -c "+quote(script))...)
+}
+
+// run runs a script on the host with stdin and returns its output. The
+// scripts exit with notFoundStatus when the file at p does not exist.
+func (h *Host) run(op, p, script string, stdin []byte) ([]byte, error) {
+	cmd := h.script(context.Background(), script)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == notFoundStatus {
+			return nil, &fs.PathError{Op: op, Path: p, Err: fs.ErrNotExist}
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = errors.New(msg)
+		}
+		return nil, &fs.PathError{Op: op, Path: p, Err: fmt.Errorf("on %s: %w", h.Host, err)}
+	}
+	return stdout.Bytes(), nil
+}
+
+// remotePath translates a local path under Root to the host
+func (h *Host) remotePath(p string) string {
+	rel, err := filepath.Rel(h.Root, p)
+	if err != nil || !filepath.IsAbs(p) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return p
+	}
+	return path.Join(h.Dir, filepath.ToSlash(rel))
+}
+
+func (h *Host) Open(p string) (io.ReadCloser, error) {
+	data, err := h.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (h *Host) ReadFile(p string) ([]byte, error) {
+	q := quote(h.remotePath(p))
+	return h.run("open", p, fmt.Sprintf("test -e %s || exit %d; exec cat -- %s", q, notFoundStatus, q), nil)
+}
+
+func (h *Host) WriteFile(p string, data []byte) error {
+	remote := h.remotePath(p)
+	_, err := h.run("write", p, fmt.Sprintf("mkdir -p -- %s && cat > %s", quote(path.Dir(remote)), quote(remote)), data)
+	return err
+}
+
+func (h *Host) Stat(p string) (fs.FileInfo, error) {
+	q := quote(h.remotePath(p))
+	out, err := h.run("stat", p, fmt.Sprintf("test -e %s || exit %d; exec find -L %s -maxdepth 0 -printf '%%s %%T@ %%y\\n'", q, notFoundStatus, q), nil)
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	var mtime float64
+	var kind string
+	if _, err := fmt.Sscan(string(out), &size, &mtime, &kind); err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: p, Err: fmt.Errorf("unexpected find output %q", out)}
+	}
+	return &fileInfo{name: filepath.Base(p), size: size, modTime: unixTime(mtime), dir: kind == "d"}, nil
+}
+
+// Glob lists the files under the pattern's fixed prefix on the host and
+// matches them locally, so one command answers the whole pattern
+func (h *Host) Glob(pattern string) ([]builtin.GlobMatch, error) {
+	base, rest := doublestar.SplitPattern(filepath.ToSlash(pattern))
+	if !doublestar.ValidatePattern(rest) {
+		return nil, doublestar.ErrBadPattern
+	}
+	if rest == "" {
+		info, err := h.Stat(pattern)
+		if err != nil {
+			return nil, nil
+		}
+		return []builtin.GlobMatch{{Path: pattern, ModTime: info.ModTime()}}, nil
+	}
+
+	remote := quote(h.remotePath(filepath.FromSlash(base)))
+	depth := ""
+	if !strings.Contains(rest, "**") {
+		depth = fmt.Sprintf(" -maxdepth %d", strings.Count(rest, "/")+1)
+	}
+	out, err := h.run("glob", pattern, fmt.Sprintf("test -d %s || exit 0; exec find %s -mindepth 1%s -printf '%%T@ %%P\\n'", remote, remote, depth), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []builtin.GlobMatch
+	for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+		stamp, rel, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		if matched, _ := doublestar.Match(rest, rel); !matched {
+			continue
+		}
+		mtime, _ := strconv.ParseFloat(stamp, 64)
+		matches = append(matches, builtin.GlobMatch{
+			Path:    filepath.Join(filepath.FromSlash(base), filepath.FromSlash(rel)),
+			ModTime: unixTime(mtime),
+		})
+	}
+	return matches, nil
+}
+
+// Command runs a program on the host in dir, translating local paths in its
+// arguments. Killing the local ssh client, as a timeout does, ends the
+// connection's input, and the script then kills the process group sshd
+// started it in, rather than leaving the program running on the host.
+func (h *Host) Command(ctx context.Context, dir, name string, args ...string) *exec.Cmd {
+	if filepath.IsAbs(name) {
+		name = filepath.Base(name) // a local binary; the host has its own
+	}
+	words := []string{quote(name)}
+	for _, arg := range args {
+		words = append(words, quote(h.remotePath(arg)))
+	}
+
+	// Asynchronous commands read /dev/null, so the watcher reads the
+	// connection's input from a copy
+	var script strings.Builder
+	script.WriteString("exec 3<&0\n")
+	if dir != "" {
+		fmt.Fprintf(&script, "cd %s || exit 1\n", quote(h.remotePath(dir)))
+	}
+	fmt.Fprintf(&script, "%s </dev/null 3<&- &\n", strings.Join(words, " "))
+	script.WriteString("pid=$!\n")
+	script.WriteString("(cat <&3 >/dev/null; kill -TERM 0) >/dev/null 2>&1 &\n")
+	script.WriteString("watcher=$!\n")
+	script.WriteString("wait $pid\n")
+	script.WriteString("status=$?\n")
+	script.WriteString("kill $watcher 2>/dev/null\n")
+	script.WriteString("exit $status\n")
+
+	cmd := h.script(ctx, script.String())
+	if input := h.commandInput(); input != nil {
+		cmd.Stdin = input
+	}
+	return cmd
+}
+
+// commandInput returns the input of commands: a pipe nothing writes to,
+// shared by all commands so that none holds a pipe of its own to close.
+// Its write end stays open while the program runs, so a command's input
+// ends only when its ssh client exits or is killed, or the program dies.
+func (h *Host) commandInput() *os.File {
+	h.inputOnce.Do(func() {
+		if r, w, err := os.Pipe(); err == nil {
+			h.input, h.inputEnd = r, w
+		}
+	})
+	return h.input
+}
+
+// quote quotes s as one word for sh
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func unixTime(seconds float64) time.Time {
+	sec := int64(seconds)
+	return time.Unix(sec, int64((seconds-float64(sec))*1e9))
+}
+
+// fileInfo describes a file on the host
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (f *fileInfo) Name() string       { return f.name }
+func (f *fileInfo) Size() int64        { return f.size }
+func (f *fileInfo) ModTime() time.Time { return f.modTime }
+func (f *fileInfo) IsDir() bool        { return f.dir }
+func (f *fileInfo) Sys() any           { return nil }
+
+func (f *fileInfo) Mode() fs.FileMode {
+	if f.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
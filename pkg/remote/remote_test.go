@@ -0,0 +1,190 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+	"github.com/xinguang/agentic-coder/pkg/tool/builtin"
+)
+
+// fakeSSH runs the command locally in a new session, as sshd does. Its
+// input is relayed through a fifo that is closed when the input ends or
+// the client dies, as sshd closes it when the connection does.
+const fakeSSH = `#!/bin/sh
+for arg; do last=$arg; done
+fifo=$(mktemp -u) && mkfifo "$fifo" || exit 1
+exec 4<&0
+setsid sh -c "$last" <"$fifo" 4<&- &
+session=$! client=$$
+(
+	exec 3>"$fifo"
+	rm "$fifo"
+	cat <&4 >&3 4<&- &
+	relay=$!
+	while kill -0 $client && kill -0 $relay; do sleep 0.05; done 2>/dev/null
+	kill $relay 2>/dev/null
+) &
+wait $session
+`
+
+// fakeHost returns a host whose ssh is fakeSSH, with the project at root
+// kept in another directory as if on the host
+func fakeHost(t *testing.T) (*Host, string, string) {
+	t.Helper()
+	root, dir := t.TempDir(), t.TempDir()
+	ssh := filepath.Join(t.TempDir(), "ssh")
+	script := fakeSSH
+	if err := os.WriteFile(ssh, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return &Host{Host: "build", Root: root, Dir: dir, SSH: ssh}, root, dir
+}
+
+func TestHostFiles(t *testing.T) {
+	h, root, dir := fakeHost(t)
+
+	local := filepath.Join(root, "cmd", "it's.go")
+	if err := h.WriteFile(local, []byte("package main\n")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "cmd", "it's.go")); err != nil || string(data) != "package main\n" {
+		t.Fatalf("expected the file on the host, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(local); !os.IsNotExist(err) {
+		t.Errorf("expected no local file, got %v", err)
+	}
+
+	data, err := h.ReadFile(local)
+	if err != nil || string(data) != "package main\n" {
+		t.Errorf("unexpected content %q, %v", data, err)
+	}
+	if _, err := h.ReadFile(filepath.Join(root, "missing.go")); !os.IsNotExist(err) {
+		t.Errorf("expected a missing file, got %v", err)
+	}
+
+	info, err := h.Stat(local)
+	if err != nil || info.Size() != 13 || info.IsDir() {
+		t.Errorf("unexpected file info %+v, %v", info, err)
+	}
+	if info, err := h.Stat(filepath.Join(root, "cmd")); err != nil || !info.IsDir() {
+		t.Errorf("expected a directory, got %+v, %v", info, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "cmd", "util.go"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "README.md"), nil, 0644)
+	matches, err := h.Glob(filepath.Join(root, "**", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, m := range matches {
+		paths = append(paths, m.Path)
+	}
+	slices.Sort(paths)
+	want := []string{local, filepath.Join(root, "cmd", "util.go")}
+	if !slices.Equal(paths, want) {
+		t.Errorf("expected %v, got %v", want, paths)
+	}
+	if matches, _ := h.Glob(filepath.Join(root, "*.md")); len(matches) != 1 || matches[0].Path != filepath.Join(root, "README.md") {
+		t.Errorf("unexpected matches %v", matches)
+	}
+}
+
+func TestHostCommand(t *testing.T) {
+	h, root, dir := fakeHost(t)
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+
+	var out bytes.Buffer
+	cmd := h.Command(context.Background(), filepath.Join(root, "sub"), "/usr/local/bin/sh", "-c", `pwd; echo "$1"`, "x", filepath.Join(root, "a.txt"))
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(dir, "sub") + "\n" + filepath.Join(dir, "a.txt") + "\n"
+	if out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+
+	// A timeout stops the command on the host, not just the ssh client
+	marker := filepath.Join(dir, "finished")
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	h.Command(ctx, "", "sh", "-c", "sleep 1; touch "+marker).Run()
+	time.Sleep(1500 * time.Millisecond)
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Errorf("expected the command to be stopped, got %v", err)
+	}
+}
+
+func TestHostTools(t *testing.T) {
+	h, root, dir := fakeHost(t)
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("func main() {\n\tprintln(\"hi\")\n}\n"), 0644)
+	ctx := &tool.ExecutionContext{CWD: root}
+
+	edit := builtin.NewEditTool()
+	edit.Workspace = h
+	out, err := edit.Execute(context.Background(), &tool.Input{Context: ctx, Params: map[string]interface{}{
+		"file_path":  filepath.Join(root, "main.go"),
+		"old_string": `println("hi")`,
+		"new_string": `println("hello")`,
+	}})
+	if err != nil || out.IsError {
+		t.Fatalf("edit failed: %+v, %v", out, err)
+	}
+
+	bash := builtin.NewBashTool()
+	bash.Workspace = h
+	out, err = bash.Execute(context.Background(), &tool.Input{Context: ctx, Params: map[string]interface{}{
+		"command": "grep -c hello main.go && pwd",
+	}})
+	if err != nil || out.IsError {
+		t.Fatalf("bash failed: %+v, %v", out, err)
+	}
+	if want := "1\n" + dir; strings.TrimSpace(out.Content) != want {
+		t.Errorf("expected %q, got %q", want, out.Content)
+	}
+}
+
+func TestUse(t *testing.T) {
+	registry := tool.NewRegistry()
+	read, bash := builtin.NewReadTool(), builtin.NewBashTool()
+	registry.Register(read)
+	registry.Register(bash)
+	registry.Register(builtin.NewTreeTool())
+	h := &Host{Host: "build"}
+
+	removed := Use(registry, builtin.NewShellManager(), h, "")
+	if !slices.Equal(removed, []string{"Tree"}) {
+		t.Errorf("unexpected removed tools %v", removed)
+	}
+	if read.Workspace != h || bash.Workspace != h || bash.ShellPath != DefaultShell {
+		t.Errorf("expected the tools to use the host, got %v, %v, %q", read.Workspace, bash.Workspace, bash.ShellPath)
+	}
+}
+
+func TestNew(t *testing.T) {
+	h := New(config.RemoteConfig{Host: "me@build", Port: 2222, IdentityFile: "~/.ssh/build", SSHOptions: []string{"ProxyJump=bastion"}}, "/home/me/app", "/tmp/ctl")
+	if h.Dir != "/home/me/app" {
+		t.Errorf("expected the local path on the host by default, got %q", h.Dir)
+	}
+	args := strings.Join(h.sshArgs(), " ")
+	for _, want := range []string{"BatchMode=yes", "ControlPath=/tmp/ctl/%C", "-p 2222", "-i ~/.ssh/build", "-o ProxyJump=bastion"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("expected %q in %q", want, args)
+		}
+	}
+	if !strings.HasSuffix(args, " me@build") {
+		t.Errorf("expected the host last, got %q", args)
+	}
+	if got := h.remotePath("/home/me/other"); got != "/home/me/other" {
+		t.Errorf("expected paths outside the project unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,51 @@
+package remote
+
+import (
+	"github.com/xinguang/agentic-coder/pkg/tool"
+	"github.com/xinguang/agentic-coder/pkg/tool/builtin"
+)
+
+// LocalOnlyTools work on local files or run local processes without a
+// workspace, so they are removed when the project is on a remote host
+var LocalOnlyTools = []string{"Tree", "Targets", "Deps", "Test", "Coverage", "Bench", "Profile", "NotebookEdit"}
+
+// Use makes the file tools and Bash in registry, and the background shells,
+// work on the host, running Bash commands with shell. It removes the tools
+// that can only work locally and returns their names.
+func Use(registry *tool.Registry, shells *builtin.ShellManager, h *Host, shell string) []string {
+	if shell == "" {
+		shell = DefaultShell
+	}
+	for _, name := range []string{"Read", "Write", "Edit", "Glob", "Grep", "Bash"} {
+		t, err := registry.Get(name)
+		if err != nil {
+			continue
+		}
+		switch t := t.(type) {
+		case *builtin.ReadTool:
+			t.Workspace = h
+		case *builtin.WriteTool:
+			t.Workspace = h
+		case *builtin.EditTool:
+			t.Workspace = h
+		case *builtin.GlobTool:
+			t.Workspace = h
+		case *builtin.GrepTool:
+			t.Workspace = h
+		case *builtin.BashTool:
+			t.Workspace = h
+			t.ShellPath = shell
+		}
+	}
+	if shells != nil {
+		shells.SetWorkspace(h, shell)
+	}
+
+	var removed []string
+	for _, name := range LocalOnlyTools {
+		if registry.Unregister(name) {
+			removed = append(removed, name)
+		}
+	}
+	return removed
+}
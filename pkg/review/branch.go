@@ -0,0 +1,175 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Limits on the diff included in a branch summary; long file diffs are cut
+// off and files past the total are left to the diff stat
+const (
+	maxBranchFileLines = 200
+	maxBranchDiffBytes = 60 * 1024
+	maxBranchCommits   = 50
+)
+
+// BranchChanges are the changes of the current branch against its base,
+// including uncommitted work
+type BranchChanges struct {
+	Branch    string
+	Base      string
+	Commits   []string // one line per commit, newest first
+	Stat      string   // git diff --stat
+	Files     []BranchFile
+	Untracked []string // new files not yet added to git
+}
+
+// BranchFile is the diff of one changed file
+type BranchFile struct {
+	Path string
+	Diff string
+}
+
+// BranchDiff collects the changes of the current branch in dir since it
+// forked from base. An empty base means the remote's default branch, main
+// or master, whichever exists first.
+func BranchDiff(ctx context.Context, dir, base string) (*BranchChanges, error) {
+	if _, err := git(ctx, dir, "rev-parse", "--git-dir"); err != nil {
+		return nil, fmt.Errorf("not a git repository: %s", dir)
+	}
+	if base == "" {
+		base = defaultBase(ctx, dir)
+		if base == "" {
+			return nil, fmt.Errorf("no base branch found (tried origin/HEAD, main and master); name one")
+		}
+	}
+	mergeBase, err := git(ctx, dir, "merge-base", base, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("no common history with %s: %w", base, err)
+	}
+	mergeBase = strings.TrimSpace(mergeBase)
+
+	changes := &BranchChanges{Base: base}
+	if branch, err := git(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil {
+		changes.Branch = strings.TrimSpace(branch)
+	}
+
+	log, err := git(ctx, dir, "log", "--oneline", "--no-decorate", fmt.Sprintf("--max-count=%d", maxBranchCommits), mergeBase+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	changes.Commits = nonEmptyLines(log)
+
+	// Diffing the merge base against the working tree includes uncommitted changes
+	if changes.Stat, err = git(ctx, dir, "diff", "--stat", mergeBase); err != nil {
+		return nil, err
+	}
+	names, err := git(ctx, dir, "diff", "--name-only", mergeBase)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range nonEmptyLines(names) {
+		diff, err := git(ctx, dir, "diff", mergeBase, "--", path)
+		if err != nil {
+			return nil, err
+		}
+		changes.Files = append(changes.Files, BranchFile{Path: path, Diff: diff})
+	}
+	untracked, err := git(ctx, dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	changes.Untracked = nonEmptyLines(untracked)
+	return changes, nil
+}
+
+// Empty reports whether the branch has no changes against its base
+func (c *BranchChanges) Empty() bool {
+	return len(c.Commits) == 0 && len(c.Files) == 0 && len(c.Untracked) == 0
+}
+
+// Summary renders the changes for the model: the commits, the diff stat,
+// and the diff of each file while it fits the limits
+func (c *BranchChanges) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<branch-diff branch=%q base=%q>\n", c.Branch, c.Base)
+	b.WriteString("The user shared the changes of their current branch against its base, including uncommitted work. Use them instead of running git to rediscover the changes.\n")
+
+	if len(c.Commits) > 0 {
+		fmt.Fprintf(&b, "\nCommits (%d):\n", len(c.Commits))
+		for _, commit := range c.Commits {
+			b.WriteString(commit + "\n")
+		}
+		if len(c.Commits) == maxBranchCommits {
+			b.WriteString("(older commits omitted)\n")
+		}
+	}
+	if stat := strings.TrimRight(c.Stat, "\n"); stat != "" {
+		b.WriteString("\nChanged files:\n" + stat + "\n")
+	}
+
+	budget := maxBranchDiffBytes
+	var omitted []string
+	for _, file := range c.Files {
+		diff := strings.TrimRight(file.Diff, "\n")
+		lines := strings.Count(diff, "\n") + 1
+		if lines > maxBranchFileLines {
+			diff = strings.Join(strings.SplitN(diff, "\n", maxBranchFileLines+1)[:maxBranchFileLines], "\n")
+			diff += fmt.Sprintf("\n[... %d more lines; run git diff %s -- %s for the rest]", lines-maxBranchFileLines, c.Base, file.Path)
+		}
+		if len(diff) > budget {
+			omitted = append(omitted, file.Path)
+			continue
+		}
+		budget -= len(diff)
+		fmt.Fprintf(&b, "\n<file-diff path=%q>\n%s\n</file-diff>\n", file.Path, diff)
+	}
+	if len(c.Untracked) > 0 {
+		fmt.Fprintf(&b, "\nNew files not yet added to git: %s\n", strings.Join(c.Untracked, ", "))
+	}
+	if len(omitted) > 0 {
+		fmt.Fprintf(&b, "\nDiffs left out for size (see the stat above): %s\n", strings.Join(omitted, ", "))
+	}
+	b.WriteString("</branch-diff>")
+	return b.String()
+}
+
+// defaultBase returns the branch the current one most likely forked from
+func defaultBase(ctx context.Context, dir string) string {
+	if ref, err := git(ctx, dir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimSpace(ref)
+	}
+	for _, name := range []string{"main", "master", "origin/main", "origin/master"} {
+		if _, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// git runs a git command in dir and returns its output
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if exit, ok := err.(*exec.ExitError); ok && len(exit.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exit.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// nonEmptyLines splits output into its non-empty lines
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
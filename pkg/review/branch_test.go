@@ -0,0 +1,104 @@
+package review
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q", "-b", "main")
+	write("a.go", "package a\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "initial")
+
+	run("checkout", "-q", "-b", "feature")
+	write("a.go", "package a\n\nfunc A() {}\n")
+	run("commit", "-q", "-am", "add A")
+	write("b.go", "package a\n") // untracked
+	write("a.go", "package a\n\nfunc A() {}\n\nfunc B() {}\n")
+	return dir
+}
+
+func TestBranchDiff(t *testing.T) {
+	dir := gitRepo(t)
+
+	changes, err := BranchDiff(context.Background(), dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes.Base != "main" || changes.Branch != "feature" {
+		t.Errorf("expected feature against main, got %s against %s", changes.Branch, changes.Base)
+	}
+	if len(changes.Commits) != 1 || !strings.HasSuffix(changes.Commits[0], "add A") {
+		t.Errorf("unexpected commits: %v", changes.Commits)
+	}
+	if len(changes.Files) != 1 || changes.Files[0].Path != "a.go" {
+		t.Fatalf("unexpected files: %+v", changes.Files)
+	}
+	if !strings.Contains(changes.Files[0].Diff, "+func B() {}") {
+		t.Errorf("expected uncommitted changes in the diff, got:\n%s", changes.Files[0].Diff)
+	}
+	if len(changes.Untracked) != 1 || changes.Untracked[0] != "b.go" {
+		t.Errorf("unexpected untracked files: %v", changes.Untracked)
+	}
+
+	summary := changes.Summary()
+	for _, want := range []string{`branch="feature" base="main"`, "add A", `<file-diff path="a.go">`, "+func A() {}", "b.go"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary is missing %q:\n%s", want, summary)
+		}
+	}
+
+	if _, err := BranchDiff(context.Background(), dir, "missing"); err == nil {
+		t.Error("expected an error for an unknown base")
+	}
+	if _, err := BranchDiff(context.Background(), t.TempDir(), ""); err == nil {
+		t.Error("expected an error outside a git repository")
+	}
+}
+
+func TestBranchSummaryLimits(t *testing.T) {
+	long := strings.Repeat("+line\n", maxBranchFileLines+10)
+	huge := strings.Repeat("+"+strings.Repeat("x", 99)+"\n", maxBranchFileLines-1)
+	changes := &BranchChanges{Branch: "feature", Base: "main", Files: []BranchFile{{Path: "long.go", Diff: long}}}
+	for i := 0; i < 4; i++ {
+		changes.Files = append(changes.Files, BranchFile{Path: "huge" + string(rune('a'+i)) + ".go", Diff: huge})
+	}
+
+	summary := changes.Summary()
+	if !strings.Contains(summary, "[... 10 more lines") {
+		t.Error("expected the long diff to be cut off")
+	}
+	if !strings.Contains(summary, "Diffs left out for size") || !strings.Contains(summary, "huged.go") {
+		t.Errorf("expected the diffs past the total to be left out")
+	}
+	if len(summary) > maxBranchDiffBytes+2048 {
+		t.Errorf("summary is %d bytes, over the limit", len(summary))
+	}
+}
@@ -3,33 +3,117 @@ package review
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 )
 
-// IncrementalReviewer supports reviewing only changed content
+// IncrementalReviewer limits review to the files a turn changed, diffing
+// each against its content before the turn, and skips files whose content
+// already passed review
 type IncrementalReviewer struct {
-	cache    map[string]CacheEntry
+	reviewed map[string]string // path -> hash of the content that passed review
 	reviewer *Reviewer
 	mu       sync.RWMutex
 }
 
-// CacheEntry stores a previously reviewed content hash and result
-type CacheEntry struct {
-	Hash     string
-	Passed   bool
-	Issues   string
-	Feedback string
-}
-
 // NewIncrementalReviewer creates a new incremental reviewer
 func NewIncrementalReviewer(reviewer *Reviewer) *IncrementalReviewer {
 	return &IncrementalReviewer{
-		cache:    make(map[string]CacheEntry),
+		reviewed: make(map[string]string),
 		reviewer: reviewer,
 	}
 }
 
+// FileChange is a file changed by Edit, Write, or another file tool, with
+// the content it had before the turn
+type FileChange struct {
+	Path    string // absolute path
+	Before  []byte
+	Existed bool // false if the turn created the file
+}
+
+// FileDiff is the change to one file that needs review
+type FileDiff struct {
+	Path    string `json:"path"` // relative to the project directory when inside it
+	Hash    string `json:"hash"` // hash of the new content, empty if deleted
+	Deleted bool   `json:"deleted,omitempty"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Diff    string `json:"diff"` // unified diff of the change
+
+	abs string
+}
+
+// ReviewChanges diffs the files a turn changed against their content before
+// it. Files that ended up unchanged, or whose content already passed review,
+// need no review and are left out of the diffs.
+func (ir *IncrementalReviewer) ReviewChanges(dir string, changes []FileChange) (*IncrementalResult, error) {
+	result := &IncrementalResult{TotalFiles: len(changes)}
+
+	for _, change := range changes {
+		after, err := os.ReadFile(change.Path)
+		deleted := os.IsNotExist(err)
+		if err != nil && !deleted {
+			return nil, fmt.Errorf("read %s: %w", change.Path, err)
+		}
+
+		hash := ""
+		if !deleted {
+			hash = hashContent(string(after))
+		}
+		unchanged := deleted == !change.Existed && string(after) == string(change.Before)
+		ir.mu.RLock()
+		passed := !deleted && ir.reviewed[change.Path] == hash
+		ir.mu.RUnlock()
+		if unchanged || passed {
+			result.UnchangedFiles++
+			continue
+		}
+
+		name := change.Path
+		if rel, err := filepath.Rel(dir, change.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			name = filepath.ToSlash(rel)
+		}
+		text, added, removed := UnifiedDiff(name, string(change.Before), string(after))
+		result.Diffs = append(result.Diffs, FileDiff{
+			Path:    name,
+			Hash:    hash,
+			Deleted: deleted,
+			Added:   added,
+			Removed: removed,
+			Diff:    text,
+			abs:     change.Path,
+		})
+	}
+
+	result.ChangedFiles = len(result.Diffs)
+	return result, nil
+}
+
+// MarkReviewed records that the content of the diffed files passed review,
+// so they are skipped until they change again
+func (ir *IncrementalReviewer) MarkReviewed(diffs []FileDiff) {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	for _, d := range diffs {
+		if d.Deleted {
+			delete(ir.reviewed, d.abs)
+		} else {
+			ir.reviewed[d.abs] = d.Hash
+		}
+	}
+}
+
+// ClearCache forgets which files passed review
+func (ir *IncrementalReviewer) ClearCache() {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	ir.reviewed = make(map[string]string)
+}
+
 // ExtractCodeBlocks extracts code blocks from a response
 func ExtractCodeBlocks(response string) []CodeBlock {
 	var blocks []CodeBlock
@@ -72,94 +156,37 @@ type CodeBlock struct {
 	FilePath string `json:"file_path,omitempty"` // If associated with a file
 }
 
-// ReviewChanges reviews only the changed code blocks
-func (ir *IncrementalReviewer) ReviewChanges(oldResponse, newResponse string) (*IncrementalResult, error) {
-	oldBlocks := ExtractCodeBlocks(oldResponse)
-	newBlocks := ExtractCodeBlocks(newResponse)
-
-	result := &IncrementalResult{
-		TotalBlocks:     len(newBlocks),
-		ChangedBlocks:   0,
-		UnchangedBlocks: 0,
-		NewBlocks:       0,
-		ChangedContent:  make([]CodeBlock, 0),
-	}
-
-	// Build hash map of old blocks
-	oldHashes := make(map[string]bool)
-	for _, block := range oldBlocks {
-		oldHashes[block.Hash] = true
-	}
-
-	// Find changed and new blocks
-	for _, block := range newBlocks {
-		if oldHashes[block.Hash] {
-			result.UnchangedBlocks++
-		} else {
-			// Check if it's in cache
-			ir.mu.RLock()
-			_, cached := ir.cache[block.Hash]
-			ir.mu.RUnlock()
-
-			if cached {
-				result.UnchangedBlocks++
-			} else {
-				result.ChangedBlocks++
-				result.ChangedContent = append(result.ChangedContent, block)
-			}
-		}
-	}
-
-	// Count truly new blocks (not in old response)
-	result.NewBlocks = result.ChangedBlocks
-
-	return result, nil
+// IncrementalResult contains the files of a turn that need review
+type IncrementalResult struct {
+	TotalFiles     int        `json:"total_files"`
+	ChangedFiles   int        `json:"changed_files"`
+	UnchangedFiles int        `json:"unchanged_files"`
+	Diffs          []FileDiff `json:"diffs"`
 }
 
-// CacheResult caches a review result for a code block
-func (ir *IncrementalReviewer) CacheResult(block CodeBlock, passed bool, issues, feedback string) {
-	ir.mu.Lock()
-	defer ir.mu.Unlock()
-
-	ir.cache[block.Hash] = CacheEntry{
-		Hash:     block.Hash,
-		Passed:   passed,
-		Issues:   issues,
-		Feedback: feedback,
-	}
+// NeedsReview returns true if there are changes that need review
+func (ir *IncrementalResult) NeedsReview() bool {
+	return ir.ChangedFiles > 0
 }
 
-// GetCached returns a cached result if available
-func (ir *IncrementalReviewer) GetCached(hash string) (*CacheEntry, bool) {
-	ir.mu.RLock()
-	defer ir.mu.RUnlock()
-
-	entry, ok := ir.cache[hash]
-	if ok {
-		return &entry, true
+// ChangedLines returns the number of added and removed lines to review
+func (ir *IncrementalResult) ChangedLines() int {
+	n := 0
+	for _, d := range ir.Diffs {
+		n += d.Added + d.Removed
 	}
-	return nil, false
+	return n
 }
 
-// ClearCache clears the review cache
-func (ir *IncrementalReviewer) ClearCache() {
-	ir.mu.Lock()
-	defer ir.mu.Unlock()
-	ir.cache = make(map[string]CacheEntry)
-}
-
-// IncrementalResult contains the result of incremental review analysis
-type IncrementalResult struct {
-	TotalBlocks     int         `json:"total_blocks"`
-	ChangedBlocks   int         `json:"changed_blocks"`
-	UnchangedBlocks int         `json:"unchanged_blocks"`
-	NewBlocks       int         `json:"new_blocks"`
-	ChangedContent  []CodeBlock `json:"changed_content"`
-}
-
-// NeedsReview returns true if there are changes that need review
-func (ir *IncrementalResult) NeedsReview() bool {
-	return ir.ChangedBlocks > 0
+// Text returns the diffs as a fenced block for the reviewer
+func (ir *IncrementalResult) Text() string {
+	var b strings.Builder
+	b.WriteString("```diff\n")
+	for _, d := range ir.Diffs {
+		b.WriteString(d.Diff)
+	}
+	b.WriteString("```\n")
+	return b.String()
 }
 
 // hashContent generates a SHA256 hash of content
@@ -1,6 +1,9 @@
 package review
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -68,57 +71,75 @@ func TestHashContent(t *testing.T) {
 }
 
 func TestIncrementalReviewer_ReviewChanges(t *testing.T) {
-	ir := NewIncrementalReviewer(nil)
+	dir := t.TempDir()
+	edited := filepath.Join(dir, "calc.go")
+	created := filepath.Join(dir, "new.go")
+	reverted := filepath.Join(dir, "same.go")
+	writeFile(t, edited, "package calc\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n")
+	writeFile(t, created, "package calc\n")
+	writeFile(t, reverted, "package calc\n")
 
-	oldResponse := "```go\nfunc old() {}\n```"
-	newResponse := "```go\nfunc new() {}\n```"
+	ir := NewIncrementalReviewer(nil)
+	changes := []FileChange{
+		{Path: edited, Before: []byte("package calc\n\nfunc Add(a, b int) int {\n\treturn a - b\n}\n"), Existed: true},
+		{Path: created},
+		{Path: reverted, Before: []byte("package calc\n"), Existed: true},
+	}
 
-	result, err := ir.ReviewChanges(oldResponse, newResponse)
+	result, err := ir.ReviewChanges(dir, changes)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-
-	if result.TotalBlocks != 1 {
-		t.Errorf("expected 1 total block, got %d", result.TotalBlocks)
+	if result.TotalFiles != 3 || result.ChangedFiles != 2 || result.UnchangedFiles != 1 {
+		t.Errorf("unexpected counts: %+v", result)
 	}
-
-	if !result.NeedsReview() {
-		t.Error("expected to need review for changed content")
+	if !result.NeedsReview() || result.ChangedLines() != 3 {
+		t.Errorf("expected 3 changed lines to review, got %d", result.ChangedLines())
 	}
-}
-
-func TestIncrementalReviewer_UnchangedContent(t *testing.T) {
-	ir := NewIncrementalReviewer(nil)
-
-	response := "```go\nfunc same() {}\n```"
-
-	result, err := ir.ReviewChanges(response, response)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	want := "--- a/calc.go\n+++ b/calc.go\n@@ -1,5 +1,5 @@\n package calc\n \n func Add(a, b int) int {\n-\treturn a - b\n+\treturn a + b\n }\n"
+	if result.Diffs[0].Diff != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", result.Diffs[0].Diff, want)
 	}
 
+	// Files that passed review are skipped until they change again
+	ir.MarkReviewed(result.Diffs)
+	result, _ = ir.ReviewChanges(dir, changes)
 	if result.NeedsReview() {
-		t.Error("should not need review for unchanged content")
+		t.Errorf("expected reviewed files to be skipped, got %+v", result.Diffs)
 	}
-}
 
-func TestIncrementalReviewer_CacheResult(t *testing.T) {
-	ir := NewIncrementalReviewer(nil)
+	writeFile(t, created, "package calc\n\nvar x = 1\n")
+	result, _ = ir.ReviewChanges(dir, changes)
+	if result.ChangedFiles != 1 || result.Diffs[0].Path != "new.go" {
+		t.Errorf("expected only the changed file to be reviewed, got %+v", result.Diffs)
+	}
 
-	block := CodeBlock{
-		Language: "go",
-		Content:  "func test() {}",
-		Hash:     hashContent("func test() {}"),
+	ir.ClearCache()
+	if result, _ = ir.ReviewChanges(dir, changes); result.ChangedFiles != 2 {
+		t.Errorf("expected the cleared cache to review both files, got %d", result.ChangedFiles)
 	}
+}
 
-	ir.CacheResult(block, true, "", "")
+func TestUnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nL\nm\n"
 
-	cached, ok := ir.GetCached(block.Hash)
-	if !ok {
-		t.Error("expected to find cached result")
+	text, added, removed := UnifiedDiff("x.txt", before, after)
+	if added != 3 || removed != 2 {
+		t.Errorf("expected 3 added and 2 removed lines, got %d and %d", added, removed)
+	}
+	if got := strings.Count(text, "@@ "); got != 2 {
+		t.Errorf("expected 2 hunks, got %d:\n%s", got, text)
+	}
+	if !strings.Contains(text, "@@ -9,4 +9,5 @@\n i\n j\n k\n-l\n+L\n+m\n") {
+		t.Errorf("unexpected second hunk:\n%s", text)
 	}
-	if !cached.Passed {
-		t.Error("expected cached result to be passed")
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
 	}
 }
 
@@ -17,6 +17,7 @@ const (
 	CheckTypePerformance CheckType = "performance"
 	CheckTypeStyle       CheckType = "style"
 	CheckTypeTests       CheckType = "tests"
+	CheckTypeLint        CheckType = "lint"
 )
 
 // CheckResult represents the result of a single check
@@ -26,6 +27,7 @@ type CheckResult struct {
 	Score       int       `json:"score"` // 0-100
 	Issues      []string  `json:"issues"`
 	Suggestions []string  `json:"suggestions"`
+	Findings    []Finding `json:"findings,omitempty"` // located issues, filtered by the ReviewConfig
 }
 
 // PipelineResult contains results from all pipeline stages
@@ -40,6 +42,7 @@ type PipelineResult struct {
 type Pipeline struct {
 	stages   []PipelineStage
 	parallel bool
+	config   *ReviewConfig // severity threshold and ignored paths for findings
 	mu       sync.Mutex
 }
 
@@ -66,6 +69,13 @@ func (p *Pipeline) AddStage(stage PipelineStage) {
 	p.stages = append(p.stages, stage)
 }
 
+// SetConfig sets the configuration deciding which findings fail a check
+func (p *Pipeline) SetConfig(cfg *ReviewConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config = cfg
+}
+
 // Run executes the pipeline
 func (p *Pipeline) Run(ctx context.Context, code string) (*PipelineResult, error) {
 	if p.parallel {
@@ -93,6 +103,7 @@ func (p *Pipeline) runSequential(ctx context.Context, code string) (*PipelineRes
 		if err != nil {
 			return nil, fmt.Errorf("stage %s failed: %w", stage.Name, err)
 		}
+		p.config.apply(checkResult)
 
 		result.Checks = append(result.Checks, *checkResult)
 		totalScore += checkResult.Score
@@ -159,6 +170,7 @@ func (p *Pipeline) runParallel(ctx context.Context, code string) (*PipelineResul
 				return
 			}
 
+			p.config.apply(checkResult)
 			result.Checks[idx] = *checkResult
 			if !checkResult.Passed {
 				result.Passed = false
@@ -217,6 +229,9 @@ func (p *Pipeline) generateSummary(result *PipelineResult) string {
 // CreateDefaultPipeline creates a pipeline with default checks
 func CreateDefaultPipeline(reviewer *Reviewer, parallel bool) *Pipeline {
 	p := NewPipeline(parallel)
+	if reviewer != nil {
+		p.config = reviewer.config
+	}
 
 	// Use real syntax checker
 	p.AddStage(CreateSyntaxCheckStage())
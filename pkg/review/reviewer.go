@@ -12,11 +12,12 @@ import (
 
 // ReviewResult contains the result of a review
 type ReviewResult struct {
-	Passed       bool   `json:"passed"`
-	Issues       string `json:"issues"`
-	Feedback     string `json:"feedback"`
-	InputTokens  int    `json:"-"` // Token usage tracking
-	OutputTokens int    `json:"-"`
+	Passed       bool      `json:"passed"`
+	Issues       string    `json:"issues"`
+	Feedback     string    `json:"feedback"`
+	Findings     []Finding `json:"findings,omitempty"`
+	InputTokens  int       `json:"-"` // Token usage tracking
+	OutputTokens int       `json:"-"`
 }
 
 // ReviewConfig defines what aspects to check during review
@@ -28,6 +29,10 @@ type ReviewConfig struct {
 	CheckTests       bool     // Check if tests are included
 	CustomCriteria   []string // Custom review criteria
 	StrictMode       bool     // Be more strict in evaluation
+
+	FailOn              Severity // Lowest severity that fails the review (default warning)
+	IgnorePaths         []string // Globs of paths whose issues are ignored, e.g. "gen/**"
+	MaxCorrectionTokens int      // Tokens auto-correction may spend per request (0 = unlimited)
 }
 
 // DefaultReviewConfig returns a balanced default configuration
@@ -87,6 +92,11 @@ func (r *Reviewer) SetConfig(cfg *ReviewConfig) {
 	}
 }
 
+// Config returns the review configuration
+func (r *Reviewer) Config() *ReviewConfig {
+	return r.config
+}
+
 // buildReviewCriteria generates review criteria based on config
 func (r *Reviewer) buildReviewCriteria() string {
 	var criteria []string
@@ -184,9 +194,14 @@ Respond in JSON format:
 {
   "passed": true/false,
   "issues": "description of issues found, or empty if passed",
-  "feedback": "specific instructions for fixing the issues, or empty if passed"
+  "feedback": "specific instructions for fixing the issues, or empty if passed",
+  "findings": [
+    {"severity": "error|warning|nit", "file": "path if known", "line": 0, "message": "one issue"}
+  ]
 }
 
+List each issue as a finding. Use "error" for bugs and missing functionality, "warning" for likely problems, and "nit" for naming, style, and other preferences.
+
 %s
 
 Respond ONLY with the JSON, no other text.`, userRequest, truncateResponse(aiResponse, 8000), criteria, r.getStrictnessNote())
@@ -267,6 +282,8 @@ Respond ONLY with the JSON, no other text.`, userRequest, truncateResponse(aiRes
 		}, nil
 	}
 
+	r.applyThresholds(result)
+
 	// Add token usage to result
 	result.InputTokens = inputTokens
 	result.OutputTokens = outputTokens
@@ -274,6 +291,29 @@ Respond ONLY with the JSON, no other text.`, userRequest, truncateResponse(aiRes
 	return result, nil
 }
 
+// applyThresholds decides the result from its findings when the reviewer
+// listed them: only findings at or above FailOn outside ignored paths fail
+// the review, and only those are passed on for correction
+func (r *Reviewer) applyThresholds(result *ReviewResult) {
+	if len(result.Findings) == 0 {
+		return
+	}
+	var blocking []Finding
+	var issues []string
+	for _, f := range result.Findings {
+		if r.config.Blocks(f) {
+			blocking = append(blocking, f)
+			issues = append(issues, "- "+f.String())
+		}
+	}
+	result.Findings = blocking
+	result.Passed = len(blocking) == 0
+	result.Issues = strings.Join(issues, "\n")
+	if result.Passed {
+		result.Feedback = ""
+	}
+}
+
 // GenerateCorrectionPrompt creates a prompt for the AI to fix issues
 func (r *Reviewer) GenerateCorrectionPrompt(issues, feedback string) string {
 	return fmt.Sprintf(`The previous response has issues that need to be fixed:
@@ -0,0 +1,132 @@
+package review
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Severity is how serious a review issue is
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // bugs, failing tests, security problems
+	SeverityWarning Severity = "warning" // likely problems worth fixing
+	SeverityNit     Severity = "nit"     // naming, style, and other preferences
+)
+
+// rank orders severities from nit (1) to error (3)
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 3
+	case SeverityWarning:
+		return 2
+	case SeverityNit:
+		return 1
+	}
+	return 2
+}
+
+// ParseSeverity reads a severity, accepting the names analyzers use such
+// as "high" or "info". An empty string is a warning.
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "error", "critical", "high":
+		return SeverityError, nil
+	case "", "warning", "warn", "medium":
+		return SeverityWarning, nil
+	case "nit", "info", "low", "style", "note", "suggestion":
+		return SeverityNit, nil
+	}
+	return "", fmt.Errorf("unknown severity %q (use error, warning, or nit)", s)
+}
+
+// severityOf returns a finding's severity, treating unknown names as warnings
+func severityOf(f Finding) Severity {
+	if s, err := ParseSeverity(f.Severity); err == nil {
+		return s
+	}
+	return SeverityWarning
+}
+
+// failOn returns the lowest severity that fails the review
+func (c *ReviewConfig) failOn() Severity {
+	if c == nil || c.FailOn == "" {
+		return SeverityWarning
+	}
+	return c.FailOn
+}
+
+// Blocks reports whether a finding fails the review: it is at least as
+// severe as FailOn and not in an ignored path
+func (c *ReviewConfig) Blocks(f Finding) bool {
+	return !c.Ignored(f.File) && severityOf(f).rank() >= c.failOn().rank()
+}
+
+// Ignored reports whether issues in file are skipped by IgnorePaths
+func (c *ReviewConfig) Ignored(file string) bool {
+	if c == nil || file == "" {
+		return false
+	}
+	file = filepath.ToSlash(filepath.Clean(file))
+	for _, pattern := range c.IgnorePaths {
+		if matchGlob(filepath.ToSlash(pattern), file) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply filters a check's findings: ignored ones are dropped, those below
+// FailOn become suggestions, and the check fails only on the rest. Checks
+// without findings are left as they are.
+func (c *ReviewConfig) apply(result *CheckResult) {
+	if len(result.Findings) == 0 {
+		return
+	}
+
+	var blocking []Finding
+	var issues, suggestions []string
+	for _, f := range result.Findings {
+		switch {
+		case c.Ignored(f.File):
+		case c.Blocks(f):
+			blocking = append(blocking, f)
+			issues = append(issues, f.String())
+		default:
+			suggestions = append(suggestions, f.String())
+		}
+	}
+
+	result.Findings = blocking
+	result.Issues = capIssues(issues)
+	result.Suggestions = append(result.Suggestions, capIssues(suggestions)...)
+	result.Passed = len(blocking) == 0
+	result.Score = max(100-10*len(blocking), 0)
+}
+
+// capIssues limits a list of issues to maxStaticIssues
+func capIssues(issues []string) []string {
+	if len(issues) <= maxStaticIssues {
+		return issues
+	}
+	return append(issues[:maxStaticIssues:maxStaticIssues], fmt.Sprintf("... and %d more", len(issues)-maxStaticIssues))
+}
+
+// matchGlob matches a slash-separated path against a pattern in which **
+// matches any number of directories. Patterns without a slash match in any
+// directory, as in .gitignore, and a directory covers everything below it.
+func matchGlob(pattern, file string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	if ok, _ := doublestar.Match(pattern, file); ok {
+		return true
+	}
+	ok, _ := doublestar.Match(strings.TrimSuffix(pattern, "/")+"/**", file)
+	return ok
+}
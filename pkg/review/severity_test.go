@@ -0,0 +1,123 @@
+package review
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseSeverity(t *testing.T) {
+	tests := map[string]Severity{
+		"error":   SeverityError,
+		"HIGH":    SeverityError,
+		"":        SeverityWarning,
+		"warning": SeverityWarning,
+		"info":    SeverityNit,
+		"nit":     SeverityNit,
+	}
+	for in, want := range tests {
+		if got, err := ParseSeverity(in); err != nil || got != want {
+			t.Errorf("ParseSeverity(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseSeverity("fatal-ish"); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"gen/**", "gen/api/types.go", true},
+		{"gen/**", "pkg/gen/types.go", false},
+		{"**/testdata/**", "pkg/review/testdata/a.go", true},
+		{"*_mock.go", "pkg/store/store_mock.go", true},
+		{"vendor", "vendor/github.com/x/y.go", true},
+		{"./docs/*.md", "docs/README.md", true},
+		{"docs/*.md", "docs/api/README.md", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.file); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestReviewConfigApply(t *testing.T) {
+	findings := []Finding{
+		{File: "calc.go", Line: 3, Severity: "error", Message: "nil dereference"},
+		{File: "calc.go", Line: 9, Severity: "info", Message: "rename x to count"},
+		{File: "gen/api.go", Line: 1, Severity: "error", Message: "unused import"},
+	}
+
+	tests := []struct {
+		name            string
+		cfg             *ReviewConfig
+		wantPassed      bool
+		wantIssues      int
+		wantSuggestions int
+	}{
+		{"defaults", nil, false, 2, 1},
+		{"ignored path", &ReviewConfig{IgnorePaths: []string{"gen/**"}}, false, 1, 1},
+		{"nits fail", &ReviewConfig{FailOn: SeverityNit, IgnorePaths: []string{"gen/**"}}, false, 2, 0},
+		{"only errors outside gen", &ReviewConfig{FailOn: SeverityError, IgnorePaths: []string{"gen/**", "calc.go"}}, true, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &CheckResult{Findings: append([]Finding(nil), findings...)}
+			tt.cfg.apply(result)
+			if result.Passed != tt.wantPassed || len(result.Issues) != tt.wantIssues || len(result.Suggestions) != tt.wantSuggestions {
+				t.Errorf("got passed=%v issues=%v suggestions=%v", result.Passed, result.Issues, result.Suggestions)
+			}
+		})
+	}
+}
+
+func TestPipelineAppliesConfig(t *testing.T) {
+	p := NewPipeline(false)
+	p.SetConfig(&ReviewConfig{FailOn: SeverityError})
+	p.AddStage(PipelineStage{
+		Name:      "lint",
+		CheckType: CheckTypeLint,
+		Check: func(ctx context.Context, code string) (*CheckResult, error) {
+			return &CheckResult{
+				CheckType: CheckTypeLint,
+				Issues:    []string{"a.go:1: exported func should have a comment"},
+				Findings:  []Finding{{File: "a.go", Line: 1, Severity: "warning", Message: "exported func should have a comment"}},
+			}, nil
+		},
+	})
+
+	result, err := p.Run(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Passed || len(result.Checks[0].Suggestions) != 1 {
+		t.Errorf("expected warnings to pass with FailOn error, got %+v", result.Checks[0])
+	}
+}
+
+func TestReviewerApplyThresholds(t *testing.T) {
+	r := NewReviewerWithConfig(nil, &ReviewConfig{FailOn: SeverityWarning})
+	result := &ReviewResult{
+		Passed: false,
+		Issues: "naming",
+		Findings: []Finding{
+			{Severity: "nit", Message: "rename handler to h"},
+			{Severity: "nit", File: "api.go", Line: 4, Message: "prefer early return"},
+		},
+		Feedback: "rename things",
+	}
+	r.applyThresholds(result)
+	if !result.Passed || result.Issues != "" || result.Feedback != "" {
+		t.Errorf("expected nits not to fail the review, got %+v", result)
+	}
+
+	result = &ReviewResult{Findings: []Finding{{Severity: "error", File: "api.go", Line: 7, Message: "handler ignores the error"}}}
+	r.applyThresholds(result)
+	if result.Passed || result.Issues != "- api.go:7: handler ignores the error" {
+		t.Errorf("expected the error to fail the review, got %+v", result)
+	}
+}
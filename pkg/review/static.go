@@ -0,0 +1,308 @@
+package review
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// maxStaticIssues caps the findings a static analysis stage reports
+const maxStaticIssues = 20
+
+// Finding is a problem a static analyzer reported at a source location
+type Finding struct {
+	Analyzer string `json:"analyzer,omitempty"`
+	File     string `json:"file,omitempty"` // relative to the analyzed directory
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Rule     string `json:"rule,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message"`
+}
+
+// String formats the finding as file:line:col: message (rule)
+func (f Finding) String() string {
+	msg := strings.TrimSpace(f.Message)
+	if f.Rule != "" {
+		msg += " (" + f.Rule + ")"
+	}
+	if f.File == "" {
+		return msg
+	}
+	loc := f.File
+	if f.Line > 0 {
+		loc += fmt.Sprintf(":%d", f.Line)
+		if f.Column > 0 {
+			loc += fmt.Sprintf(":%d", f.Column)
+		}
+	}
+	return loc + ": " + msg
+}
+
+// Analyzer runs an external static analysis tool on a set of files
+type Analyzer struct {
+	Name       string
+	Command    string   // executable looked up in PATH
+	Extensions []string // file extensions it analyzes, all if empty
+	CheckType  CheckType
+
+	args  func(ctx context.Context, files []string) []string
+	parse func(output []byte) ([]Finding, error)
+}
+
+// GolangciLint returns an analyzer running golangci-lint on the packages of
+// the given Go files
+func GolangciLint() *Analyzer {
+	return &Analyzer{
+		Name:       "golangci-lint",
+		Command:    "golangci-lint",
+		Extensions: []string{".go"},
+		CheckType:  CheckTypeLint,
+		args:       golangciArgs,
+		parse:      parseGolangci,
+	}
+}
+
+// Semgrep returns an analyzer running semgrep with a ruleset, such as
+// "auto" or "p/golang". An empty config uses "auto".
+func Semgrep(config string) *Analyzer {
+	if config == "" {
+		config = "auto"
+	}
+	return &Analyzer{
+		Name:      "semgrep",
+		Command:   "semgrep",
+		CheckType: CheckTypeSecurity,
+		args: func(ctx context.Context, files []string) []string {
+			return append([]string{"scan", "--json", "--quiet", "--config", config}, files...)
+		},
+		parse: parseSemgrep,
+	}
+}
+
+// golangciArgs lints the packages containing files. golangci-lint 2 renamed
+// the flag selecting JSON output.
+func golangciArgs(ctx context.Context, files []string) []string {
+	args := []string{"run", "--issues-exit-code=0"}
+	version, _ := exec.CommandContext(ctx, "golangci-lint", "--version").Output()
+	if strings.Contains(string(version), "version 1.") {
+		args = append(args, "--out-format=json")
+	} else {
+		args = append(args, "--output.json.path=stdout")
+	}
+
+	seen := make(map[string]bool)
+	for _, file := range files {
+		pkg := "./" + filepath.ToSlash(filepath.Dir(file))
+		if !seen[pkg] {
+			seen[pkg] = true
+			args = append(args, pkg)
+		}
+	}
+	return args
+}
+
+// parseGolangci reads golangci-lint's JSON report
+func parseGolangci(output []byte) ([]Finding, error) {
+	var report struct {
+		Issues []struct {
+			FromLinter string
+			Text       string
+			Severity   string
+			Pos        struct {
+				Filename string
+				Line     int
+				Column   int
+			}
+		}
+	}
+	if err := json.Unmarshal(skipToJSON(output), &report); err != nil {
+		return nil, fmt.Errorf("parse golangci-lint output: %w", err)
+	}
+
+	findings := make([]Finding, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		findings = append(findings, Finding{
+			Analyzer: "golangci-lint",
+			File:     issue.Pos.Filename,
+			Line:     issue.Pos.Line,
+			Column:   issue.Pos.Column,
+			Rule:     issue.FromLinter,
+			Severity: issue.Severity,
+			Message:  issue.Text,
+		})
+	}
+	return findings, nil
+}
+
+// parseSemgrep reads semgrep's JSON report
+func parseSemgrep(output []byte) ([]Finding, error) {
+	var report struct {
+		Results []struct {
+			CheckID string `json:"check_id"`
+			Path    string `json:"path"`
+			Start   struct {
+				Line int `json:"line"`
+				Col  int `json:"col"`
+			} `json:"start"`
+			Extra struct {
+				Message  string `json:"message"`
+				Severity string `json:"severity"`
+			} `json:"extra"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(skipToJSON(output), &report); err != nil {
+		return nil, fmt.Errorf("parse semgrep output: %w", err)
+	}
+
+	findings := make([]Finding, 0, len(report.Results))
+	for _, r := range report.Results {
+		// Rule IDs are prefixed with the path of the ruleset
+		rule := r.CheckID
+		if i := strings.LastIndex(rule, "."); i >= 0 {
+			rule = rule[i+1:]
+		}
+		findings = append(findings, Finding{
+			Analyzer: "semgrep",
+			File:     r.Path,
+			Line:     r.Start.Line,
+			Column:   r.Start.Col,
+			Rule:     rule,
+			Severity: strings.ToLower(r.Extra.Severity),
+			Message:  r.Extra.Message,
+		})
+	}
+	return findings, nil
+}
+
+// skipToJSON skips anything a tool printed before its JSON report
+func skipToJSON(output []byte) []byte {
+	if i := bytes.IndexByte(output, '{'); i > 0 {
+		return output[i:]
+	}
+	return output
+}
+
+// Run analyzes the files under dir that the analyzer handles and returns
+// the findings in those files, ordered by location. Findings in other
+// files, such as the rest of a linted package, are dropped.
+func (a *Analyzer) Run(ctx context.Context, dir string, files []string) ([]Finding, error) {
+	targets := a.targets(dir, files)
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	cmd := exec.CommandContext(ctx, a.Command, a.args(ctx, targets)...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	findings, err := a.parse(stdout.Bytes())
+	if err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("%s: %w: %s", a.Name, runErr, strings.TrimSpace(stderr.String()))
+		}
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		wanted[t] = true
+	}
+	kept := findings[:0]
+	for _, f := range findings {
+		if filepath.IsAbs(f.File) {
+			if rel, err := filepath.Rel(dir, f.File); err == nil {
+				f.File = rel
+			}
+		}
+		f.File = filepath.Clean(f.File)
+		if wanted[f.File] {
+			kept = append(kept, f)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		if kept[i].File != kept[j].File {
+			return kept[i].File < kept[j].File
+		}
+		return kept[i].Line < kept[j].Line
+	})
+	return kept, nil
+}
+
+// targets returns the existing files under dir with a handled extension,
+// relative to dir
+func (a *Analyzer) targets(dir string, files []string) []string {
+	var targets []string
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if len(a.Extensions) > 0 && !slices.Contains(a.Extensions, filepath.Ext(file)) {
+			continue
+		}
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			continue
+		}
+		targets = append(targets, rel)
+	}
+	return targets
+}
+
+// CreateStaticAnalysisStage creates a pipeline stage that runs an analyzer
+// on the files returned by files, typically those changed in the current
+// turn, and reports each finding as an issue with its location. The stage
+// passes with a note when the analyzer is not installed or cannot run.
+func CreateStaticAnalysisStage(a *Analyzer, dir string, files func() []string) PipelineStage {
+	return PipelineStage{
+		Name:      a.Name,
+		CheckType: a.CheckType,
+		Required:  false,
+		Check: func(ctx context.Context, code string) (*CheckResult, error) {
+			if _, err := exec.LookPath(a.Command); err != nil {
+				return &CheckResult{
+					CheckType:   a.CheckType,
+					Passed:      true,
+					Score:       100,
+					Suggestions: []string{a.Name + " is not installed; static analysis skipped"},
+				}, nil
+			}
+
+			findings, err := a.Run(ctx, dir, files())
+			if err != nil {
+				return &CheckResult{
+					CheckType:   a.CheckType,
+					Passed:      true,
+					Score:       100,
+					Suggestions: []string{fmt.Sprintf("%s could not run: %v", a.Name, err)},
+				}, nil
+			}
+
+			issues := make([]string, 0, len(findings))
+			for _, f := range findings {
+				issues = append(issues, f.String())
+			}
+
+			return &CheckResult{
+				CheckType: a.CheckType,
+				Passed:    len(findings) == 0,
+				Score:     max(100-10*len(findings), 0),
+				Issues:    capIssues(issues),
+				Findings:  findings,
+			}, nil
+		},
+	}
+}
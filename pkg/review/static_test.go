@@ -0,0 +1,126 @@
+package review
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseGolangci(t *testing.T) {
+	output := `level=warning msg="[runner] deprecated option"
+{"Issues":[{"FromLinter":"errcheck","Text":"Error return value of ` + "`f.Close`" + ` is not checked","Severity":"","Pos":{"Filename":"pkg/a/a.go","Line":12,"Column":10}}],"Report":{}}`
+
+	findings, err := parseGolangci([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(findings))
+	}
+	want := "pkg/a/a.go:12:10: Error return value of `f.Close` is not checked (errcheck)"
+	if got := findings[0].String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := parseGolangci([]byte("not json")); err == nil {
+		t.Error("expected an error for invalid output")
+	}
+}
+
+func TestParseSemgrep(t *testing.T) {
+	output := `{"results":[{"check_id":"go.lang.security.audit.sqli.string-formatted-query","path":"db.go","start":{"line":7,"col":2},"extra":{"message":"SQL built with fmt.Sprintf","severity":"ERROR"}}],"errors":[]}`
+
+	findings, err := parseSemgrep([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(findings))
+	}
+	f := findings[0]
+	if f.Rule != "string-formatted-query" || f.Severity != "error" || f.File != "db.go" || f.Line != 7 {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+}
+
+func TestAnalyzerTargets(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := []string{
+		filepath.Join(dir, "main.go"),
+		filepath.Join(dir, "README.md"),
+		filepath.Join(dir, "deleted.go"),
+		"/elsewhere/other.go",
+	}
+	got := GolangciLint().targets(dir, files)
+	if len(got) != 1 || got[0] != "main.go" {
+		t.Errorf("expected only main.go, got %v", got)
+	}
+	if got := Semgrep("").targets(dir, files); len(got) != 2 {
+		t.Errorf("expected semgrep to analyze all existing files, got %v", got)
+	}
+}
+
+func TestStaticAnalysisStage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+
+	// A fake golangci-lint reporting one issue in each of two files
+	bin := t.TempDir()
+	script := `#!/bin/sh
+[ "$1" = "--version" ] && exit 0
+echo '{"Issues":[
+ {"FromLinter":"unused","Text":"func helper is unused","Pos":{"Filename":"pkg/util.go","Line":3,"Column":6}},
+ {"FromLinter":"govet","Text":"unreachable code","Pos":{"Filename":"pkg/other.go","Line":9,"Column":2}}]}'
+`
+	if err := os.WriteFile(filepath.Join(bin, "golangci-lint"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "pkg"), 0755)
+	changed := filepath.Join(dir, "pkg", "util.go")
+	if err := os.WriteFile(changed, []byte("package pkg\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stage := CreateStaticAnalysisStage(GolangciLint(), dir, func() []string { return []string{changed} })
+	result, err := stage.Check(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Passed || len(result.Issues) != 1 {
+		t.Fatalf("expected one issue in the changed file, got %+v", result)
+	}
+	if want := filepath.Join("pkg", "util.go") + ":3:6: func helper is unused (unused)"; result.Issues[0] != want {
+		t.Errorf("got issue %q, want %q", result.Issues[0], want)
+	}
+
+	// Nothing changed, nothing to check
+	stage = CreateStaticAnalysisStage(GolangciLint(), dir, func() []string { return nil })
+	if result, _ := stage.Check(context.Background(), ""); !result.Passed {
+		t.Errorf("expected a pass without changed files, got %+v", result)
+	}
+}
+
+func TestStaticAnalysisStageNotInstalled(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	stage := CreateStaticAnalysisStage(Semgrep(""), t.TempDir(), func() []string { return []string{"a.go"} })
+	result, err := stage.Check(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Passed || len(result.Suggestions) != 1 || !strings.Contains(result.Suggestions[0], "not installed") {
+		t.Errorf("expected a skipped pass, got %+v", result)
+	}
+}
@@ -0,0 +1,70 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// testResults is the metadata a test runner tool returns, such as the
+// report of the Test tool
+type testResults interface {
+	OK() bool
+	Issues() []string
+}
+
+// CreateTestGateStage creates a required pipeline stage that runs the
+// project's tests with a test runner tool after a turn that changed files,
+// failing the review with each parsed failure. Turns that changed nothing
+// pass without running the tests.
+func CreateTestGateStage(runner tool.Tool, dir string, files func() []string) PipelineStage {
+	return PipelineStage{
+		Name:      "Test Gate",
+		CheckType: CheckTypeTests,
+		Required:  true,
+		Check: func(ctx context.Context, code string) (*CheckResult, error) {
+			if len(files()) == 0 {
+				return &CheckResult{CheckType: CheckTypeTests, Passed: true, Score: 100}, nil
+			}
+
+			output, err := runner.Execute(ctx, &tool.Input{
+				Name:    runner.Name(),
+				Params:  map[string]interface{}{},
+				Context: &tool.ExecutionContext{CWD: dir, ProjectPath: dir},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("run tests: %w", err)
+			}
+			if output.IsError {
+				// No test suite to enforce
+				return &CheckResult{
+					CheckType:   CheckTypeTests,
+					Passed:      true,
+					Score:       100,
+					Suggestions: []string{strings.TrimPrefix(output.Content, "Error: ")},
+				}, nil
+			}
+
+			results, ok := output.Metadata.(testResults)
+			if !ok {
+				return nil, fmt.Errorf("%s did not return a test report", runner.Name())
+			}
+			if results.OK() {
+				return &CheckResult{CheckType: CheckTypeTests, Passed: true, Score: 100}, nil
+			}
+
+			issues := results.Issues()
+			if len(issues) > maxStaticIssues {
+				issues = append(issues[:maxStaticIssues], fmt.Sprintf("... and %d more test failures", len(issues)-maxStaticIssues))
+			}
+			return &CheckResult{
+				CheckType: CheckTypeTests,
+				Passed:    false,
+				Score:     0,
+				Issues:    issues,
+			}, nil
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// fakeReport is a test runner report
+type fakeReport struct {
+	failures []string
+}
+
+func (r *fakeReport) OK() bool         { return len(r.failures) == 0 }
+func (r *fakeReport) Issues() []string { return r.failures }
+
+// fakeRunner is a test runner tool returning a fixed output
+type fakeRunner struct {
+	output *tool.Output
+	runs   int
+}
+
+func (f *fakeRunner) Name() string                     { return "Test" }
+func (f *fakeRunner) Description() string              { return "" }
+func (f *fakeRunner) InputSchema() json.RawMessage     { return json.RawMessage(`{}`) }
+func (f *fakeRunner) Validate(input *tool.Input) error { return nil }
+func (f *fakeRunner) Execute(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+	f.runs++
+	return f.output, nil
+}
+
+func TestTestGateStage(t *testing.T) {
+	changed := []string{"calc.go"}
+	files := func() []string { return changed }
+
+	failing := &fakeRunner{output: &tool.Output{Metadata: &fakeReport{failures: []string{"calc_test.go:9: TestDiv: want 2"}}}}
+	result, err := CreateTestGateStage(failing, "/project", files).Check(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Passed || len(result.Issues) != 1 || result.Issues[0] != "calc_test.go:9: TestDiv: want 2" {
+		t.Errorf("expected the test failure as an issue, got %+v", result)
+	}
+
+	passing := &fakeRunner{output: &tool.Output{Metadata: &fakeReport{}}}
+	if result, _ := CreateTestGateStage(passing, "/project", files).Check(context.Background(), ""); !result.Passed {
+		t.Errorf("expected passing tests to pass the gate, got %+v", result)
+	}
+
+	noSuite := &fakeRunner{output: &tool.Output{Content: "Error: no test suite found", IsError: true}}
+	if result, _ := CreateTestGateStage(noSuite, "/project", files).Check(context.Background(), ""); !result.Passed {
+		t.Errorf("expected a project without tests to pass, got %+v", result)
+	}
+
+	// A turn that changed nothing does not run the tests
+	changed = nil
+	if result, _ := CreateTestGateStage(failing, "/project", files).Check(context.Background(), ""); !result.Passed || failing.runs != 1 {
+		t.Errorf("expected the tests not to run, got %+v after %d runs", result, failing.runs)
+	}
+}
@@ -0,0 +1,93 @@
+// Package secret recognizes environment variables that hold credentials and
+// masks their values in text shown to the user, so provider keys and
+// tokens do not end up in terminal scrollback or screen shares.
+package secret
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// namePatterns are the parts of a variable name that mark it as secret
+var namePatterns = []string{
+	"API_KEY",
+	"APIKEY",
+	"ACCESS_KEY",
+	"PRIVATE_KEY",
+	"SECRET",
+	"TOKEN",
+	"PASSWORD",
+	"PASSWD",
+	"CREDENTIAL",
+}
+
+// minLength is the shortest environment value masked wherever it appears;
+// shorter ones would mask ordinary words
+const minLength = 8
+
+// minAssigned is the shortest value masked in an assignment, where the
+// name says what it is
+const minAssigned = 6
+
+// assignment matches NAME=value and NAME: value, as in commands, JSON and
+// YAML, with the value unquoted or quoted
+var assignment = regexp.MustCompile(`\b([A-Za-z_][A-Za-z0-9_]*)("?\s*[=:]\s*)("[^"]*"|'[^']*'|[^\s"',}]+)`)
+
+// IsName reports whether an environment variable name looks like it holds
+// a secret, such as OPENAI_API_KEY or GITHUB_TOKEN
+func IsName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, pattern := range namePatterns {
+		if strings.Contains(upper, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask hides a secret value, keeping the first characters of long values
+// so the user can tell which key it is
+func Mask(value string) string {
+	if len(value) < 16 {
+		return "********"
+	}
+	return value[:4] + "********"
+}
+
+// MaskText masks, in text to be displayed, the values of secret-looking
+// variables of the environment and of NAME=value assignments to
+// secret-looking names
+func MaskText(text string) string {
+	for _, value := range values(os.Environ()) {
+		text = strings.ReplaceAll(text, value, Mask(value))
+	}
+	return assignment.ReplaceAllStringFunc(text, func(match string) string {
+		m := assignment.FindStringSubmatch(match)
+		value := strings.Trim(m[3], `"'`)
+		if !IsName(m[1]) || len(value) < minAssigned || strings.HasSuffix(value, "********") ||
+			strings.HasPrefix(value, "$") || strings.Trim(value, "0123456789") == "" {
+			return match
+		}
+		quote := ""
+		if m[3] != value {
+			quote = m[3][:1]
+		}
+		return m[1] + m[2] + quote + Mask(value) + quote
+	})
+}
+
+// values returns the values of the secret-looking variables of env, longest
+// first so a value containing another is masked whole
+func values(env []string) []string {
+	var secrets []string
+	for _, kv := range env {
+		name, value, ok := strings.Cut(kv, "=")
+		if ok && IsName(name) && len(value) >= minLength {
+			secrets = append(secrets, value)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	return secrets
+}
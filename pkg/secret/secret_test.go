@@ -0,0 +1,45 @@
+package secret
+
+import "testing"
+
+func TestIsName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"OPENAI_API_KEY", true},
+		{"GITHUB_TOKEN", true},
+		{"AWS_SECRET_ACCESS_KEY", true},
+		{"db_password", true},
+		{"AZURE_OPENAI_AD_TOKEN", true},
+		{"PATH", false},
+		{"SSH_AUTH_SOCK", false},
+		{"GIT_AUTHOR_NAME", false},
+	}
+	for _, tt := range tests {
+		if got := IsName(tt.name); got != tt.want {
+			t.Errorf("IsName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMaskText(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-proj-abcdefghijklmnop")
+	t.Setenv("SHORT_TOKEN", "abc")
+	t.Setenv("HOME_DIR", "/home/someone/projects")
+
+	tests := []struct {
+		in, want string
+	}{
+		{"key is sk-proj-abcdefghijklmnop.", "key is sk-p********."},
+		{"export GITHUB_TOKEN=ghp_1234567890abcdefgh && make", "export GITHUB_TOKEN=ghp_******** && make"},
+		{`{"client_secret": "hunter22", "max_tokens": 4096}`, `{"client_secret": "********", "max_tokens": 4096}`},
+		{"curl -H token:$API_TOKEN", "curl -H token:$API_TOKEN"},
+		{"cd /home/someone/projects && abc", "cd /home/someone/projects && abc"},
+	}
+	for _, tt := range tests {
+		if got := MaskText(tt.in); got != tt.want {
+			t.Errorf("MaskText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
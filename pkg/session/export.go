@@ -0,0 +1,75 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// Markdown renders the conversation for reading outside the agent: the
+// prompts, the answers with the tools they called, and after each answer
+// the web pages that informed it
+func (s *Session) Markdown() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var sb strings.Builder
+	title := s.Title
+	if title == "" {
+		title = "Session " + s.ID
+	}
+	fmt.Fprintf(&sb, "# %s\n", title)
+	if s.Model != "" {
+		fmt.Fprintf(&sb, "\n_%s_\n", s.Model)
+	}
+
+	start := -1
+	answered := false
+	endTurn := func(end int) {
+		if start < 0 {
+			return
+		}
+		if sources := Sources(s.Messages[start:end]); len(sources) > 0 {
+			sb.WriteString("\n**Sources**\n\n")
+			for i, src := range sources {
+				title := src.Title
+				if title == "" {
+					title = src.URL
+				}
+				fmt.Fprintf(&sb, "%d. [%s](%s)\n", i+1, title, src.URL)
+			}
+		}
+	}
+
+	for i, entry := range s.Messages {
+		if text, ok := promptText(entry); ok {
+			endTurn(i)
+			start = i
+			answered = false
+			fmt.Fprintf(&sb, "\n## User\n\n%s\n", strings.TrimSpace(text))
+			continue
+		}
+		if entry.Type != EntryTypeAssistant || entry.Message == nil {
+			continue
+		}
+		if !answered {
+			sb.WriteString("\n## Assistant\n")
+			answered = true
+		}
+		for _, block := range entry.Message.Content {
+			switch b := block.(type) {
+			case *provider.TextBlock:
+				if text := strings.TrimSpace(b.Text); text != "" {
+					fmt.Fprintf(&sb, "\n%s\n", text)
+				}
+			case *provider.ToolUseBlock:
+				input, _ := json.Marshal(b.Input)
+				fmt.Fprintf(&sb, "\n- `%s` `%s`\n", b.Name, input)
+			}
+		}
+	}
+	endTurn(len(s.Messages))
+	return sb.String()
+}
@@ -0,0 +1,183 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArchiveDirName is the directory, inside a project's sessions directory,
+// that archived sessions are moved to. Archived sessions are not listed
+// or resumed, but can be moved back by hand.
+const ArchiveDirName = "archive"
+
+// GCOptions selects the sessions CollectGarbage removes
+type GCOptions struct {
+	OlderThan time.Duration // only sessions not updated for this long (0 = any age)
+	Keep      int           // always keep this many of the most recent sessions
+	Archive   bool          // move sessions to ArchiveDirName instead of deleting them
+	DryRun    bool          // report what would be removed without removing it
+	Now       time.Time     // reference time for OlderThan (zero = now)
+}
+
+// GCReport is what CollectGarbage removed, or would remove in a dry run
+type GCReport struct {
+	Dir           string
+	Sessions      []*SessionInfo // sessions deleted or archived, oldest first
+	Orphans       []string       // side files without a session
+	Freed         int64          // bytes removed from the sessions directory
+	Kept          int            // sessions left in place
+	KeptBytes     int64          // size of the sessions left in place
+	ArchivedBytes int64          // bytes moved to the archive
+}
+
+// CollectGarbage removes old sessions from a project's sessions directory,
+// dir, together with side files left without a session: transcripts
+// without metadata, metadata without a transcript, and temporary files
+// from interrupted writes. The newest opts.Keep sessions are always kept.
+func CollectGarbage(dir string, opts GCOptions) (*GCReport, error) {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	storage := &FileStorage{projectDir: dir}
+	sessions, err := storage.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUpdated.After(sessions[j].LastUpdated)
+	})
+
+	report := &GCReport{Dir: dir}
+	rank := 0
+	for _, info := range sessions {
+		if _, err := os.Stat(filepath.Join(dir, info.ID+".jsonl")); err != nil {
+			continue // metadata alone is an orphan
+		}
+		size := sessionSize(dir, info.ID)
+		rank++
+		if rank <= opts.Keep || (opts.OlderThan > 0 && opts.Now.Sub(info.LastUpdated) < opts.OlderThan) {
+			report.Kept++
+			report.KeptBytes += size
+			continue
+		}
+		report.Sessions = append(report.Sessions, info)
+		if opts.Archive {
+			report.ArchivedBytes += size
+		} else {
+			report.Freed += size
+		}
+	}
+	// Oldest first reads naturally in a report
+	for i, j := 0, len(report.Sessions)-1; i < j; i, j = i+1, j-1 {
+		report.Sessions[i], report.Sessions[j] = report.Sessions[j], report.Sessions[i]
+	}
+
+	orphans, err := orphanedFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range orphans {
+		if info, err := os.Stat(path); err == nil {
+			report.Freed += info.Size()
+		}
+		report.Orphans = append(report.Orphans, path)
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	for _, info := range report.Sessions {
+		if opts.Archive {
+			err = archiveSession(dir, info.ID)
+		} else {
+			err = storage.Delete(info.ID)
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to remove session %s: %w", info.ID, err)
+		}
+	}
+	for _, path := range report.Orphans {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return report, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	return report, nil
+}
+
+// sessionFiles are the files a session is stored in
+func sessionFiles(dir, id string) []string {
+	return []string{
+		filepath.Join(dir, id+".meta.json"),
+		filepath.Join(dir, id+".jsonl"),
+		filepath.Join(dir, id+".sig.json"),
+	}
+}
+
+// sessionSize returns the size of a session's files
+func sessionSize(dir, id string) int64 {
+	var size int64
+	for _, path := range sessionFiles(dir, id) {
+		if info, err := os.Stat(path); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// archiveSession moves a session's files into the archive directory
+func archiveSession(dir, id string) error {
+	archive := filepath.Join(dir, ArchiveDirName)
+	if err := os.MkdirAll(archive, 0755); err != nil {
+		return err
+	}
+	for _, path := range sessionFiles(dir, id) {
+		err := os.Rename(path, filepath.Join(archive, filepath.Base(path)))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// orphanedFiles finds the side files in dir that belong to no session
+func orphanedFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+
+	var orphans []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		switch {
+		case strings.HasSuffix(name, ".meta.json"):
+			if !names[strings.TrimSuffix(name, ".meta.json")+".jsonl"] {
+				orphans = append(orphans, filepath.Join(dir, name))
+			}
+		case strings.HasSuffix(name, ".sig.json"):
+			if !names[strings.TrimSuffix(name, ".sig.json")+".jsonl"] {
+				orphans = append(orphans, filepath.Join(dir, name))
+			}
+		case strings.HasSuffix(name, ".jsonl"):
+			if !names[strings.TrimSuffix(name, ".jsonl")+".meta.json"] {
+				orphans = append(orphans, filepath.Join(dir, name))
+			}
+		case strings.HasSuffix(name, ".tmp"):
+			orphans = append(orphans, filepath.Join(dir, name))
+		}
+	}
+	return orphans, nil
+}
@@ -0,0 +1,114 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// saveAged saves a session last updated age before now
+func saveAged(t *testing.T, storage *FileStorage, now time.Time, age time.Duration) string {
+	t.Helper()
+	sess := NewSession(&SessionOptions{CWD: "/project"})
+	sess.AddUserMessage("hello")
+	sess.Messages[0].Timestamp = now.Add(-age)
+	if err := storage.Save(sess); err != nil {
+		t.Fatal(err)
+	}
+	return sess.ID
+}
+
+func newGCStorage(t *testing.T) (*FileStorage, time.Time, []string) {
+	t.Helper()
+	storage, err := NewFileStorage(t.TempDir(), "/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	var ids []string
+	for _, days := range []int{1, 10, 40, 60} {
+		ids = append(ids, saveAged(t, storage, now, time.Duration(days)*24*time.Hour))
+	}
+	// A transcript whose metadata is gone, and a leftover temporary file
+	os.WriteFile(filepath.Join(storage.projectDir, "lost.jsonl"), []byte("{}\n"), 0644)
+	os.WriteFile(filepath.Join(storage.projectDir, "x.meta.json.tmp"), nil, 0644)
+	return storage, now, ids
+}
+
+func TestCollectGarbage(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    GCOptions
+		removed []int // indexes into the sessions aged 1, 10, 40 and 60 days
+	}{
+		{"older than", GCOptions{OlderThan: 30 * 24 * time.Hour}, []int{3, 2}},
+		{"keep", GCOptions{Keep: 3}, []int{3}},
+		{"keep wins over age", GCOptions{OlderThan: 5 * 24 * time.Hour, Keep: 3}, []int{3}},
+		{"both", GCOptions{OlderThan: 50 * 24 * time.Hour, Keep: 1}, []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, now, ids := newGCStorage(t)
+			tt.opts.Now = now
+
+			report, err := CollectGarbage(storage.projectDir, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Sessions) != len(tt.removed) {
+				t.Fatalf("removed %d sessions, want %d", len(report.Sessions), len(tt.removed))
+			}
+			for i, idx := range tt.removed {
+				if report.Sessions[i].ID != ids[idx] {
+					t.Errorf("removed session %d = %s, want %s", i, report.Sessions[i].ID, ids[idx])
+				}
+			}
+			if len(report.Orphans) != 2 {
+				t.Errorf("orphans = %v, want the lost transcript and the temporary file", report.Orphans)
+			}
+			if report.Kept != 4-len(tt.removed) || report.Freed == 0 {
+				t.Errorf("kept %d sessions, freed %d bytes", report.Kept, report.Freed)
+			}
+
+			left, _ := storage.List()
+			if len(left) != report.Kept {
+				t.Errorf("%d sessions left, want %d", len(left), report.Kept)
+			}
+			if _, err := os.Stat(filepath.Join(storage.projectDir, "lost.jsonl")); !os.IsNotExist(err) {
+				t.Error("expected the orphaned transcript to be removed")
+			}
+		})
+	}
+}
+
+func TestCollectGarbageDryRunAndArchive(t *testing.T) {
+	storage, now, ids := newGCStorage(t)
+
+	report, err := CollectGarbage(storage.projectDir, GCOptions{Keep: 1, DryRun: true, Now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Sessions) != 3 {
+		t.Fatalf("dry run would remove %d sessions, want 3", len(report.Sessions))
+	}
+	if left, _ := storage.List(); len(left) != 4 {
+		t.Errorf("dry run removed sessions: %d left", len(left))
+	}
+
+	report, err = CollectGarbage(storage.projectDir, GCOptions{Keep: 1, Archive: true, Now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.ArchivedBytes == 0 || report.Freed == 0 {
+		t.Errorf("archived %d bytes, freed %d", report.ArchivedBytes, report.Freed)
+	}
+	if left, _ := storage.List(); len(left) != 1 || left[0].ID != ids[0] {
+		t.Errorf("expected only the newest session to stay, got %+v", left)
+	}
+	archived := filepath.Join(storage.projectDir, ArchiveDirName, ids[3]+".jsonl")
+	if _, err := os.Stat(archived); err != nil {
+		t.Errorf("expected the oldest session in the archive: %v", err)
+	}
+}
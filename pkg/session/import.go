@@ -0,0 +1,401 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// ImportedTag is the user tag added to imported sessions
+const ImportedTag = "imported"
+
+// Import sources
+const (
+	SourceClaudeCode = "claude-code"
+	SourceCodex      = "codex"
+)
+
+// maxTranscriptLine bounds a single JSONL line in imported transcripts
+const maxTranscriptLine = 64 * 1024 * 1024
+
+// ClaudeCodeTranscripts returns the Claude Code transcripts for a project,
+// stored under ~/.claude/projects/<project path with separators as dashes>
+func ClaudeCodeTranscripts(home, projectPath string) ([]string, error) {
+	dir := filepath.Join(home, ".claude", "projects", claudeCodeProjectDir(projectPath))
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// claudeCodeProjectDir returns the directory name Claude Code uses for a project
+func claudeCodeProjectDir(projectPath string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return '-'
+	}, projectPath)
+}
+
+// CodexTranscripts returns the Codex CLI session logs under ~/.codex/sessions
+// that were recorded in projectPath
+func CodexTranscripts(home, projectPath string) ([]string, error) {
+	root := filepath.Join(home, ".codex", "sessions")
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".jsonl") {
+			return nil
+		}
+		if cwd, _ := codexSessionCWD(path); cwd != "" && filepath.Clean(cwd) == filepath.Clean(projectPath) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// codexSessionCWD reads the working directory from a Codex session log header
+func codexSessionCWD(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := newTranscriptScanner(f)
+	if !scanner.Scan() {
+		return "", scanner.Err()
+	}
+	var line codexLine
+	if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+		return "", err
+	}
+	if line.Type == "session_meta" {
+		var meta codexMeta
+		json.Unmarshal(line.Payload, &meta)
+		return meta.CWD, nil
+	}
+	return "", nil
+}
+
+// claudeCodeEntry is a line of a Claude Code transcript
+type claudeCodeEntry struct {
+	Type        string    `json:"type"`
+	UUID        string    `json:"uuid"`
+	Timestamp   time.Time `json:"timestamp"`
+	SessionID   string    `json:"sessionId"`
+	CWD         string    `json:"cwd"`
+	GitBranch   string    `json:"gitBranch"`
+	IsSidechain bool      `json:"isSidechain"`
+	IsMeta      bool      `json:"isMeta"`
+	Message     *Message  `json:"message"`
+}
+
+// ImportClaudeCode converts a Claude Code transcript into a session.
+// The session keeps the Claude Code session ID so importing twice replaces it.
+func ImportClaudeCode(path string) (*Session, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sess := newImportedSession(strings.TrimSuffix(filepath.Base(path), ".jsonl"), "claude")
+
+	var last *TranscriptEntry
+	scanner := newTranscriptScanner(f)
+	for scanner.Scan() {
+		var e claudeCodeEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // skip lines from newer transcript formats
+		}
+		if (e.Type != "user" && e.Type != "assistant") || e.IsSidechain || e.IsMeta || e.Message == nil || len(e.Message.Content) == 0 {
+			continue
+		}
+
+		if e.SessionID != "" && len(sess.Messages) == 0 {
+			sess.ID = e.SessionID
+		}
+		if e.CWD != "" {
+			sess.CWD = e.CWD
+			if sess.ProjectPath == "" {
+				sess.ProjectPath = e.CWD
+			}
+		}
+		if e.GitBranch != "" {
+			sess.GitBranch = e.GitBranch
+		}
+		if e.Message.Model != "" {
+			sess.Model = e.Message.Model
+		}
+
+		if e.Type == "user" && isClaudeCodeCommand(e.Message) {
+			continue
+		}
+
+		// Claude Code writes each block of a streamed response, and each tool
+		// result, as its own line; merge them so roles alternate
+		if last != nil && EntryType(e.Type) == last.Type {
+			last.Message.Content = append(last.Message.Content, e.Message.Content...)
+			if e.Message.StopReason != "" {
+				last.Message.StopReason = e.Message.StopReason
+			}
+			continue
+		}
+
+		last = &TranscriptEntry{
+			Type:      EntryType(e.Type),
+			UUID:      e.UUID,
+			Timestamp: e.Timestamp,
+			Message:   e.Message,
+		}
+		sess.addImported(last)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if len(sess.Messages) == 0 {
+		return nil, fmt.Errorf("no conversation found in %s", path)
+	}
+	return sess, nil
+}
+
+// isClaudeCodeCommand reports whether a user message only records a local
+// slash command or its output rather than a prompt
+func isClaudeCodeCommand(msg *Message) bool {
+	for _, block := range msg.Content {
+		text, ok := block.(*provider.TextBlock)
+		if !ok {
+			return false
+		}
+		t := strings.TrimSpace(text.Text)
+		if !strings.HasPrefix(t, "<command-") && !strings.HasPrefix(t, "<local-command-") {
+			return false
+		}
+	}
+	return true
+}
+
+// codexLine is a line of a Codex CLI session log. Newer logs wrap items in
+// {"type": ..., "payload": ...}; older logs store response items directly.
+type codexLine struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// codexMeta is the session header of a Codex CLI session log
+type codexMeta struct {
+	ID  string `json:"id"`
+	CWD string `json:"cwd"`
+	Git *struct {
+		Branch string `json:"branch"`
+	} `json:"git"`
+}
+
+// codexItem is a Codex CLI response item
+type codexItem struct {
+	Type    string `json:"type"`
+	Role    string `json:"role"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	CallID    string `json:"call_id"`
+	Output    string `json:"output"`
+}
+
+// ImportCodex converts a Codex CLI session log into a session
+func ImportCodex(path string) (*Session, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	id := strings.TrimSuffix(filepath.Base(path), ".jsonl")
+	sess := newImportedSession(id, "openai")
+
+	var last *TranscriptEntry
+	add := func(entryType EntryType, block provider.ContentBlock, ts time.Time) {
+		// Merge consecutive items of the same role into one message
+		if last != nil && last.Type == entryType {
+			last.Message.Content = append(last.Message.Content, block)
+			return
+		}
+		last = &TranscriptEntry{
+			Type:      entryType,
+			Timestamp: ts,
+			Message:   &Message{Role: string(entryType), Content: []provider.ContentBlock{block}},
+		}
+		sess.addImported(last)
+	}
+
+	scanner := newTranscriptScanner(f)
+	for scanner.Scan() {
+		var line codexLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+
+		payload := json.RawMessage(scanner.Bytes())
+		switch line.Type {
+		case "session_meta":
+			var meta codexMeta
+			if json.Unmarshal(line.Payload, &meta) == nil {
+				if meta.ID != "" {
+					sess.ID = meta.ID
+				}
+				sess.CWD, sess.ProjectPath = meta.CWD, meta.CWD
+				if meta.Git != nil {
+					sess.GitBranch = meta.Git.Branch
+				}
+			}
+			continue
+		case "response_item":
+			payload = line.Payload
+		case "message", "function_call", "function_call_output":
+			// older logs: the line is the item itself
+		default:
+			continue
+		}
+
+		var item codexItem
+		if err := json.Unmarshal(payload, &item); err != nil {
+			continue
+		}
+
+		switch item.Type {
+		case "message":
+			var parts []string
+			for _, c := range item.Content {
+				if c.Text != "" {
+					parts = append(parts, c.Text)
+				}
+			}
+			text := strings.Join(parts, "\n")
+			if text == "" || isCodexContext(text) {
+				continue
+			}
+			switch item.Role {
+			case "user":
+				add(EntryTypeUser, &provider.TextBlock{Text: text}, line.Timestamp)
+			case "assistant":
+				add(EntryTypeAssistant, &provider.TextBlock{Text: text}, line.Timestamp)
+			}
+
+		case "function_call":
+			name, input := codexToolCall(item.Name, item.Arguments)
+			add(EntryTypeAssistant, &provider.ToolUseBlock{ID: item.CallID, Name: name, Input: input}, line.Timestamp)
+
+		case "function_call_output":
+			add(EntryTypeUser, &provider.ToolResultBlock{ToolUseID: item.CallID, Content: codexToolOutput(item.Output)}, line.Timestamp)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if len(sess.Messages) == 0 {
+		return nil, fmt.Errorf("no conversation found in %s", path)
+	}
+	return sess, nil
+}
+
+// isCodexContext reports whether a user message is context injected by Codex
+func isCodexContext(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasPrefix(text, "<environment_context>") || strings.HasPrefix(text, "<user_instructions>")
+}
+
+// codexToolCall converts a Codex function call, mapping its shell tool to Bash
+func codexToolCall(name, arguments string) (string, map[string]interface{}) {
+	input := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(arguments), &input); err != nil {
+		input = map[string]interface{}{"arguments": arguments}
+	}
+
+	if name != "shell" {
+		return name, input
+	}
+	args, ok := input["command"].([]interface{})
+	if !ok {
+		return name, input
+	}
+	var parts []string
+	for _, a := range args {
+		parts = append(parts, fmt.Sprint(a))
+	}
+	// ["bash", "-lc", "<script>"] runs a single script
+	if len(parts) == 3 && (parts[0] == "bash" || parts[0] == "sh" || parts[0] == "zsh") && parts[1] == "-lc" {
+		return "Bash", map[string]interface{}{"command": parts[2]}
+	}
+	return "Bash", map[string]interface{}{"command": strings.Join(parts, " ")}
+}
+
+// codexToolOutput extracts the output text from a Codex function call result
+func codexToolOutput(output string) string {
+	var wrapped struct {
+		Output string `json:"output"`
+	}
+	if err := json.Unmarshal([]byte(output), &wrapped); err == nil && wrapped.Output != "" {
+		return wrapped.Output
+	}
+	return output
+}
+
+// newImportedSession creates an empty session for an import
+func newImportedSession(id, providerName string) *Session {
+	return &Session{
+		ID:             id,
+		Provider:       providerName,
+		Tags:           []string{ImportedTag},
+		Messages:       make([]*TranscriptEntry, 0),
+		MessageTree:    make(map[string]*TranscriptEntry),
+		MaxTokens:      200000,
+		CompactPercent: 0.95,
+	}
+}
+
+// addImported adds an entry keeping its original timestamp, and sets the
+// title from the first user text
+func (s *Session) addImported(entry *TranscriptEntry) {
+	if s.Title == "" && entry.Type == EntryTypeUser {
+		for _, block := range entry.Message.Content {
+			if text, ok := block.(*provider.TextBlock); ok && text.Text != "" {
+				s.Title = truncateTitle(text.Text, 50)
+				break
+			}
+		}
+	}
+	s.AddEntry(entry)
+}
+
+func newTranscriptScanner(f *os.File) *bufio.Scanner {
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTranscriptLine)
+	return scanner
+}
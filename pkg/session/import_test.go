@@ -0,0 +1,205 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+func writeTranscript(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportClaudeCode(t *testing.T) {
+	home := t.TempDir()
+	path := filepath.Join(home, ".claude", "projects", "-work-app", "abc.jsonl")
+	writeTranscript(t, path,
+		`{"type":"summary","summary":"Fix bug"}`,
+		`{"type":"user","uuid":"u1","sessionId":"sess-1","cwd":"/work/app","gitBranch":"main","message":{"role":"user","content":"<command-name>/clear</command-name>"}}`,
+		`{"type":"user","uuid":"u2","sessionId":"sess-1","cwd":"/work/app","gitBranch":"main","message":{"role":"user","content":"Fix the failing test"}}`,
+		`{"type":"assistant","uuid":"a1","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"Looking."}]}}`,
+		`{"type":"assistant","uuid":"a2","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4","content":[{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"a.go"}}],"stop_reason":"tool_use"}}`,
+		`{"type":"user","uuid":"u3","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":[{"type":"text","text":"package a"}]}]}}`,
+		`{"type":"assistant","uuid":"s1","isSidechain":true,"message":{"id":"msg_2","role":"assistant","content":[{"type":"text","text":"subagent"}]}}`,
+		`not json`,
+		`{"type":"assistant","uuid":"a3","message":{"id":"msg_3","role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"Fixed."}]}}`,
+	)
+
+	paths, err := ClaudeCodeTranscripts(home, "/work/app")
+	if err != nil || len(paths) != 1 {
+		t.Fatalf("ClaudeCodeTranscripts() = %v, %v", paths, err)
+	}
+
+	sess, err := ImportClaudeCode(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sess.ID != "sess-1" || sess.Title != "Fix the failing test" || sess.Model != "claude-sonnet-4" {
+		t.Errorf("unexpected session metadata: id=%s title=%q model=%s", sess.ID, sess.Title, sess.Model)
+	}
+	if sess.CWD != "/work/app" || sess.GitBranch != "main" || sess.Provider != "claude" {
+		t.Errorf("unexpected session context: cwd=%s branch=%s provider=%s", sess.CWD, sess.GitBranch, sess.Provider)
+	}
+
+	msgs := sess.GetMessages()
+	roles := make([]string, len(msgs))
+	for i, m := range msgs {
+		roles[i] = string(m.Role)
+	}
+	if got := strings.Join(roles, ","); got != "user,assistant,user,assistant" {
+		t.Fatalf("roles = %s, want user,assistant,user,assistant", got)
+	}
+	if len(msgs[1].Content) != 2 {
+		t.Errorf("expected streamed assistant blocks to be merged, got %d blocks", len(msgs[1].Content))
+	}
+	result, ok := msgs[2].Content[0].(*provider.ToolResultBlock)
+	if !ok || result.ToolUseID != "t1" || result.Content != "package a" {
+		t.Errorf("unexpected tool result: %#v", msgs[2].Content[0])
+	}
+}
+
+func TestImportCodex(t *testing.T) {
+	home := t.TempDir()
+	path := filepath.Join(home, ".codex", "sessions", "2025", "01", "02", "rollout-1.jsonl")
+	writeTranscript(t, path,
+		`{"timestamp":"2025-01-02T10:00:00Z","type":"session_meta","payload":{"id":"codex-1","cwd":"/work/app","git":{"branch":"dev"}}}`,
+		`{"timestamp":"2025-01-02T10:00:01Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"<environment_context>cwd</environment_context>"}]}}`,
+		`{"timestamp":"2025-01-02T10:00:02Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"List files"}]}}`,
+		`{"timestamp":"2025-01-02T10:00:03Z","type":"response_item","payload":{"type":"reasoning","summary":[]}}`,
+		`{"timestamp":"2025-01-02T10:00:04Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"bash\",\"-lc\",\"ls\"]}","call_id":"call_1"}}`,
+		`{"timestamp":"2025-01-02T10:00:05Z","type":"response_item","payload":{"type":"function_call_output","call_id":"call_1","output":"{\"output\":\"a.go\\n\",\"metadata\":{\"exit_code\":0}}"}}`,
+		`{"timestamp":"2025-01-02T10:00:06Z","type":"event_msg","payload":{"type":"token_count"}}`,
+		`{"timestamp":"2025-01-02T10:00:07Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"One file."}]}}`,
+	)
+	writeTranscript(t, filepath.Join(home, ".codex", "sessions", "2025", "01", "02", "rollout-2.jsonl"),
+		`{"type":"session_meta","payload":{"id":"codex-2","cwd":"/other"}}`,
+	)
+
+	paths, err := CodexTranscripts(home, "/work/app")
+	if err != nil || len(paths) != 1 || paths[0] != path {
+		t.Fatalf("CodexTranscripts() = %v, %v", paths, err)
+	}
+
+	sess, err := ImportCodex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.ID != "codex-1" || sess.Title != "List files" || sess.GitBranch != "dev" || sess.Provider != "openai" {
+		t.Errorf("unexpected session: id=%s title=%q branch=%s provider=%s", sess.ID, sess.Title, sess.GitBranch, sess.Provider)
+	}
+
+	msgs := sess.GetMessages()
+	if len(msgs) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(msgs))
+	}
+	call, ok := msgs[1].Content[0].(*provider.ToolUseBlock)
+	if !ok || call.Name != "Bash" || call.Input["command"] != "ls" || call.ID != "call_1" {
+		t.Errorf("unexpected tool call: %#v", msgs[1].Content[0])
+	}
+	result, ok := msgs[2].Content[0].(*provider.ToolResultBlock)
+	if !ok || result.Content != "a.go\n" {
+		t.Errorf("unexpected tool result: %#v", msgs[2].Content[0])
+	}
+	if sess.Messages[0].Timestamp.Year() != 2025 {
+		t.Errorf("expected original timestamps to be kept, got %v", sess.Messages[0].Timestamp)
+	}
+}
+
+func TestImportEmptyTranscript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.jsonl")
+	writeTranscript(t, path, `{"type":"summary","summary":"nothing"}`)
+
+	if _, err := ImportClaudeCode(path); err == nil {
+		t.Error("expected error for transcript without conversation")
+	}
+	if _, err := ImportCodex(path); err == nil {
+		t.Error("expected error for log without conversation")
+	}
+}
+
+func TestFileStorageRoundTrip(t *testing.T) {
+	storage, err := NewFileStorage(t.TempDir(), "/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess := NewSession(&SessionOptions{Model: "sonnet"})
+	sess.AddUserMessage("hello")
+	sess.AddAssistantMessage(&provider.Response{Content: []provider.ContentBlock{
+		&provider.TextBlock{Text: "hi"},
+		&provider.ToolUseBlock{ID: "t1", Name: "Read", Input: map[string]interface{}{"file_path": "a.go"}},
+	}})
+	sess.AddToolResult("t1", "package a", false, nil)
+	if err := storage.Save(sess); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := storage.Load(sess.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := loaded.GetMessages()
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 messages after reload, got %d", len(msgs))
+	}
+	if use, ok := msgs[1].Content[1].(*provider.ToolUseBlock); !ok || use.Input["file_path"] != "a.go" {
+		t.Errorf("unexpected tool use after reload: %#v", msgs[1].Content[1])
+	}
+}
+
+func TestFileStorageThinking(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		storage, err := NewFileStorage(t.TempDir(), "/project")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sess := NewSession(&SessionOptions{Model: "sonnet"})
+		sess.KeepThinking = keep
+		sess.AddUserMessage("hello")
+		sess.AddAssistantMessage(&provider.Response{Content: []provider.ContentBlock{
+			&provider.ThinkingBlock{Thinking: "let me see", Signature: "sig"},
+			&provider.RedactedThinkingBlock{Data: "opaque"},
+			&provider.TextBlock{Text: "hi"},
+		}})
+		if err := storage.Save(sess); err != nil {
+			t.Fatal(err)
+		}
+
+		// Thinking stays in memory for the rest of the turn
+		if n := len(sess.GetMessages()[1].Content); n != 3 {
+			t.Errorf("keep=%v: expected 3 blocks in memory, got %d", keep, n)
+		}
+
+		loaded, err := storage.Load(sess.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := loaded.GetMessages()[1].Content
+		if !keep {
+			if len(content) != 1 {
+				t.Errorf("expected thinking to be stripped, got %#v", content)
+			}
+			continue
+		}
+		if len(content) != 3 {
+			t.Fatalf("expected thinking to be saved, got %#v", content)
+		}
+		if tb, ok := content[0].(*provider.ThinkingBlock); !ok || tb.Signature != "sig" {
+			t.Errorf("unexpected thinking block: %#v", content[0])
+		}
+		if rb, ok := content[1].(*provider.RedactedThinkingBlock); !ok || rb.Data != "opaque" {
+			t.Errorf("unexpected redacted thinking block: %#v", content[1])
+		}
+	}
+}
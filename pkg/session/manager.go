@@ -2,6 +2,8 @@
 package session
 
 import (
+	"bytes"
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -28,6 +30,10 @@ type SessionManager struct {
 type ManagerOptions struct {
 	ProjectPath string
 	AppDir      string // defaults to ~/.agentic-coder
+
+	// SigningKey, when set, chain-hashes saved transcripts and signs them,
+	// see TranscriptSignature
+	SigningKey ed25519.PrivateKey
 }
 
 // NewSessionManager creates a new session manager
@@ -50,6 +56,7 @@ func NewSessionManager(opts *ManagerOptions) (*SessionManager, error) {
 	if err != nil {
 		return nil, err
 	}
+	storage.signingKey = opts.SigningKey
 
 	return &SessionManager{
 		storage:     storage,
@@ -102,6 +109,12 @@ func (m *SessionManager) SaveSession(sess *Session) error {
 	return m.storage.Save(sess)
 }
 
+// Transcript returns the saved JSONL transcript of a session and its
+// signature, which is nil when the transcript was saved unsigned
+func (m *SessionManager) Transcript(id string) ([]byte, *TranscriptSignature, error) {
+	return m.storage.Transcript(id)
+}
+
 // ListSessions lists all sessions
 func (m *SessionManager) ListSessions() ([]*SessionInfo, error) {
 	return m.storage.List()
@@ -137,13 +150,18 @@ func (m *SessionManager) ResumeLatest() (*Session, error) {
 
 // SessionInfo holds session metadata
 type SessionInfo struct {
-	ID           string    `json:"id"`
-	Title        string    `json:"title"`
-	ProjectPath  string    `json:"projectPath"`
-	Model        string    `json:"model"`
-	Created      time.Time `json:"created"`
-	LastUpdated  time.Time `json:"lastUpdated"`
-	MessageCount int       `json:"messageCount"`
+	ID           string     `json:"id"`
+	Title        string     `json:"title"`
+	ProjectPath  string     `json:"projectPath"`
+	Model        string     `json:"model"`
+	Provider     string     `json:"provider,omitempty"`
+	GitBranch    string     `json:"gitBranch,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	Pinned       []string   `json:"pinned,omitempty"`
+	Paused       *PausedRun `json:"paused,omitempty"`
+	Created      time.Time  `json:"created"`
+	LastUpdated  time.Time  `json:"lastUpdated"`
+	MessageCount int        `json:"messageCount"`
 }
 
 // Storage interface for session persistence
@@ -153,6 +171,7 @@ type Storage interface {
 	List() ([]*SessionInfo, error)
 	Delete(id string) error
 	AppendEntry(sessionID string, entry *TranscriptEntry) error
+	Transcript(id string) ([]byte, *TranscriptSignature, error)
 }
 
 // FileStorage implements Storage using file system
@@ -160,6 +179,7 @@ type FileStorage struct {
 	baseDir     string
 	projectDir  string
 	projectPath string
+	signingKey  ed25519.PrivateKey
 }
 
 // NewFileStorage creates a new file-based storage
@@ -196,6 +216,11 @@ func (s *FileStorage) Save(sess *Session) error {
 		Title:        title,
 		ProjectPath:  sess.ProjectPath,
 		Model:        sess.Model,
+		Provider:     sess.Provider,
+		GitBranch:    sess.GitBranch,
+		Tags:         sess.Tags,
+		Pinned:       sess.Pinned,
+		Paused:       sess.paused,
 		MessageCount: len(sess.Messages),
 	}
 
@@ -218,6 +243,13 @@ func (s *FileStorage) Save(sess *Session) error {
 		return err
 	}
 
+	// Edits made on disk since the last save must not be signed over, in
+	// case the session was loaded with them
+	var modified error
+	if s.signingKey != nil {
+		_, modified = s.signedChain(sess.ID)
+	}
+
 	// Save transcript as JSONL
 	transcriptPath := filepath.Join(s.projectDir, sess.ID+".jsonl")
 	f, err := os.Create(transcriptPath)
@@ -226,13 +258,64 @@ func (s *FileStorage) Save(sess *Session) error {
 	}
 	defer f.Close()
 
-	encoder := json.NewEncoder(f)
+	var chain transcriptChain
 	for _, entry := range sess.Messages {
-		if err := encoder.Encode(entry); err != nil {
+		if !sess.KeepThinking {
+			entry = withoutThinking(entry)
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(append(data, '\n')); err != nil {
 			return err
 		}
+		chain.add(data)
 	}
 
+	if modified != nil {
+		return modified
+	}
+	return s.saveSignature(sess.ID, &chain)
+}
+
+// signedChain returns the chain of a transcript as its signature vouches for
+// it, or nil when it has no signature. When the transcript no longer matches
+// the signature, the error wraps ErrTranscriptModified and the signature is
+// left as it is, so that the transcript keeps failing verification.
+func (s *FileStorage) signedChain(id string) (*transcriptChain, error) {
+	transcriptPath := filepath.Join(s.projectDir, id+".jsonl")
+	sig, err := LoadSignature(SignaturePath(transcriptPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(transcriptPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	public := s.signingKey.Public().(ed25519.PublicKey)
+	if err := VerifyTranscript(bytes.NewReader(data), sig, public); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTranscriptModified, err)
+	}
+	return &transcriptChain{hashes: sig.Hashes}, nil
+}
+
+// saveSignature signs a transcript's chain of hashes. Without a signing key
+// it removes the signature of an earlier save, which no longer matches.
+func (s *FileStorage) saveSignature(id string, chain *transcriptChain) error {
+	sigPath := SignaturePath(filepath.Join(s.projectDir, id+".jsonl"))
+	if s.signingKey == nil {
+		if err := os.Remove(sigPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := WriteSignature(sigPath, chain.sign(id, s.signingKey)); err != nil {
+		return fmt.Errorf("failed to sign transcript: %w", err)
+	}
 	return nil
 }
 
@@ -266,6 +349,11 @@ func (s *FileStorage) Load(id string) (*Session, error) {
 		Title:       meta.Title,
 		ProjectPath: meta.ProjectPath,
 		Model:       meta.Model,
+		Provider:    meta.Provider,
+		GitBranch:   meta.GitBranch,
+		Tags:        meta.Tags,
+		Pinned:      meta.Pinned,
+		paused:      meta.Paused,
 		Messages:    make([]*TranscriptEntry, 0),
 		MessageTree: make(map[string]*TranscriptEntry),
 	}
@@ -392,21 +480,69 @@ func (s *FileStorage) Delete(id string) error {
 
 	os.Remove(metaPath)
 	os.Remove(transcriptPath)
+	os.Remove(SignaturePath(transcriptPath))
 
 	return nil
 }
 
-// AppendEntry appends a single entry to the transcript file
+// AppendEntry appends a single entry to the transcript file. A signed
+// transcript is checked against its signature first, and its chain extended
+// by the entry.
 func (s *FileStorage) AppendEntry(sessionID string, entry *TranscriptEntry) error {
 	transcriptPath := filepath.Join(s.projectDir, sessionID+".jsonl")
 
+	var chain *transcriptChain
+	var modified error
+	if s.signingKey != nil {
+		chain, modified = s.signedChain(sessionID)
+		if chain == nil && modified == nil {
+			// Signed for the first time: the entries saved unsigned so far
+			// start the chain
+			data, err := os.ReadFile(transcriptPath)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			chain = chainLines(data)
+		}
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
 	f, err := os.OpenFile(transcriptPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	return json.NewEncoder(f).Encode(entry)
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if modified != nil {
+		return modified
+	}
+	if chain != nil {
+		chain.add(data)
+	}
+	return s.saveSignature(sessionID, chain)
+}
+
+// Transcript returns the JSONL transcript of a session and its signature
+func (s *FileStorage) Transcript(id string) ([]byte, *TranscriptSignature, error) {
+	transcriptPath := filepath.Join(s.projectDir, id+".jsonl")
+	data, err := os.ReadFile(transcriptPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("session not found: %s", id)
+		}
+		return nil, nil, err
+	}
+	sig, err := LoadSignature(SignaturePath(transcriptPath))
+	if os.IsNotExist(err) {
+		return data, nil, nil
+	}
+	return data, sig, err
 }
 
 // sanitizePath converts a file path to a safe directory name
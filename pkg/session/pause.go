@@ -0,0 +1,25 @@
+package session
+
+import "time"
+
+// PausedRun records where a run stopped when the user paused it, so it
+// can continue later, also after the program restarted
+type PausedRun struct {
+	At        time.Time `json:"at"`
+	Iteration int       `json:"iteration"`          // loop iterations the run had used
+	Thinking  string    `json:"thinking,omitempty"` // thinking level requested by its prompt
+}
+
+// SetPaused records a paused run, or clears it when run is nil
+func (s *Session) SetPaused(run *PausedRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = run
+}
+
+// Paused returns the paused run, or nil when there is none
+func (s *Session) Paused() *PausedRun {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.paused
+}
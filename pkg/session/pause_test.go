@@ -0,0 +1,39 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionPaused(t *testing.T) {
+	sess := NewSession(&SessionOptions{})
+	if sess.Paused() != nil {
+		t.Fatal("a new session has no paused run")
+	}
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	sess.SetPaused(&PausedRun{At: at, Iteration: 7, Thinking: "high"})
+
+	storage, err := NewFileStorage(t.TempDir(), "/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Save(sess); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := storage.Load(sess.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := loaded.Paused()
+	if p == nil || !p.At.Equal(at) || p.Iteration != 7 || p.Thinking != "high" {
+		t.Fatalf("loaded paused run = %+v", p)
+	}
+
+	loaded.SetPaused(nil)
+	if err := storage.Save(loaded); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err := storage.Load(sess.ID); err != nil || reloaded.Paused() != nil {
+		t.Errorf("expected the paused run to be cleared, got %+v (%v)", reloaded.Paused(), err)
+	}
+}
@@ -0,0 +1,37 @@
+package session
+
+import "slices"
+
+// Pin adds a file whose current content is kept in the system prompt for
+// the rest of the session, and reports whether it was new. path should be
+// absolute.
+func (s *Session) Pin(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.Contains(s.Pinned, path) {
+		return false
+	}
+	s.Pinned = append(s.Pinned, path)
+	return true
+}
+
+// Unpin removes a pinned file and reports whether it was pinned
+func (s *Session) Unpin(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.Index(s.Pinned, path)
+	if i < 0 {
+		return false
+	}
+	s.Pinned = slices.Delete(s.Pinned, i, i+1)
+	return true
+}
+
+// PinnedFiles returns the pinned files in the order they were pinned
+func (s *Session) PinnedFiles() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.Pinned)
+}
@@ -0,0 +1,34 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSessionPins(t *testing.T) {
+	sess := NewSession(&SessionOptions{})
+	if !sess.Pin("/project/api.yaml") || !sess.Pin("/project/schema.sql") {
+		t.Fatal("expected new pins")
+	}
+	if sess.Pin("/project/api.yaml") {
+		t.Error("expected a repeated pin to be ignored")
+	}
+	if !sess.Unpin("/project/api.yaml") || sess.Unpin("/project/api.yaml") {
+		t.Error("expected unpin to remove the file once")
+	}
+
+	storage, err := NewFileStorage(t.TempDir(), "/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Save(sess); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := storage.Load(sess.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/project/schema.sql"}; !reflect.DeepEqual(loaded.PinnedFiles(), want) {
+		t.Errorf("loaded pins = %v, want %v", loaded.PinnedFiles(), want)
+	}
+}
@@ -0,0 +1,205 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/xinguang/agentic-coder/pkg/agentignore"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// maxCheckpointSize skips checkpoints for files larger than this
+const maxCheckpointSize = 10 * 1024 * 1024
+
+// FileCheckpoint is the content of a file before a tool changed it
+type FileCheckpoint struct {
+	Path         string
+	Content      []byte
+	Existed      bool // false if the tool created the file
+	MessageIndex int  // number of session messages when the checkpoint was taken
+	Tool         string
+}
+
+// Prompt is a user prompt in the conversation
+type Prompt struct {
+	Number       int // 1-based position among prompts
+	MessageIndex int // index in Messages
+	Text         string
+}
+
+// RewindResult describes the effect of a rewind
+type RewindResult struct {
+	Prompt          Prompt   // the prompt the conversation was rewound to
+	RemovedMessages int      // messages removed from the session
+	Restored        []string // files restored from checkpoints
+	Errors          []error  // files that could not be restored
+}
+
+// Checkpoint records the current content of path before a tool changes it.
+// Checkpoints are kept in memory for the lifetime of the session. Files the
+// project's .agentignore excludes are not checkpointed.
+func (s *Session) Checkpoint(toolName, path string) {
+	if agentignore.Load(s.CWD).Ignored(path) {
+		return
+	}
+	cp := FileCheckpoint{Path: path, Tool: toolName}
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() || info.Size() > maxCheckpointSize {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		cp.Content, cp.Existed = data, true
+	} else if !os.IsNotExist(err) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp.MessageIndex = len(s.Messages)
+	s.checkpoints = append(s.checkpoints, cp)
+}
+
+// Prompts returns the user prompts in the conversation, excluding tool results
+func (s *Session) Prompts() []Prompt {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var prompts []Prompt
+	for i, entry := range s.Messages {
+		if text, ok := promptText(entry); ok {
+			prompts = append(prompts, Prompt{Number: len(prompts) + 1, MessageIndex: i, Text: text})
+		}
+	}
+	return prompts
+}
+
+// ChangedFiles returns the files tools changed since the conversation had
+// messageIndex messages, in the order they were first changed
+func (s *Session) ChangedFiles(messageIndex int) []string {
+	var paths []string
+	for _, cp := range s.FirstCheckpoints(messageIndex) {
+		paths = append(paths, cp.Path)
+	}
+	return paths
+}
+
+// FirstCheckpoints returns the earliest checkpoint of each file tools
+// changed since the conversation had messageIndex messages, holding the
+// content the file had before
+func (s *Session) FirstCheckpoints(messageIndex int) []FileCheckpoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var first []FileCheckpoint
+	for _, cp := range s.checkpoints {
+		if cp.MessageIndex >= messageIndex && !seen[cp.Path] {
+			seen[cp.Path] = true
+			first = append(first, cp)
+		}
+	}
+	return first
+}
+
+// Rewind truncates the conversation to the state before prompt n was sent,
+// removing the agent turns and tool results that followed it. With
+// restoreFiles, files changed by tools since then are restored from their
+// checkpoints. Changes made by shell commands are not tracked.
+func (s *Session) Rewind(n int, restoreFiles bool) (*RewindResult, error) {
+	prompts := s.Prompts()
+	if n < 1 || n > len(prompts) {
+		return nil, fmt.Errorf("no prompt %d (conversation has %d prompts)", n, len(prompts))
+	}
+	target := prompts[n-1]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := &RewindResult{
+		Prompt:          target,
+		RemovedMessages: len(s.Messages) - target.MessageIndex,
+	}
+
+	for _, entry := range s.Messages[target.MessageIndex:] {
+		delete(s.MessageTree, entry.UUID)
+	}
+	s.Messages = s.Messages[:target.MessageIndex]
+	s.CurrentUUID = ""
+	if len(s.Messages) > 0 {
+		s.CurrentUUID = s.Messages[len(s.Messages)-1].UUID
+	}
+
+	// Split off checkpoints taken after the rewind point
+	var later []FileCheckpoint
+	kept := s.checkpoints[:0]
+	for _, cp := range s.checkpoints {
+		if cp.MessageIndex >= target.MessageIndex {
+			later = append(later, cp)
+		} else {
+			kept = append(kept, cp)
+		}
+	}
+	s.checkpoints = kept
+
+	if restoreFiles {
+		result.Restored, result.Errors = restoreCheckpoints(later)
+	}
+
+	s.estimateTokensUnsafe()
+	return result, nil
+}
+
+// restoreCheckpoints restores each file to its earliest checkpoint
+func restoreCheckpoints(checkpoints []FileCheckpoint) ([]string, []error) {
+	earliest := make(map[string]FileCheckpoint)
+	var order []string
+	for _, cp := range checkpoints {
+		if _, ok := earliest[cp.Path]; !ok {
+			earliest[cp.Path] = cp
+			order = append(order, cp.Path)
+		}
+	}
+
+	var restored []string
+	var errs []error
+	for _, path := range order {
+		cp := earliest[path]
+		var err error
+		if cp.Existed {
+			if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+				err = os.WriteFile(path, cp.Content, 0644)
+			}
+		} else if err = os.Remove(path); os.IsNotExist(err) {
+			err = nil
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("restore %s: %w", path, err))
+			continue
+		}
+		restored = append(restored, path)
+	}
+	return restored, errs
+}
+
+// promptText returns the text of a user entry that is a prompt rather than tool results
+func promptText(entry *TranscriptEntry) (string, bool) {
+	if entry.Type != EntryTypeUser || entry.Message == nil {
+		return "", false
+	}
+	text := ""
+	for _, block := range entry.Message.Content {
+		switch b := block.(type) {
+		case *provider.ToolResultBlock:
+			return "", false // notices may follow tool results
+		case *provider.TextBlock:
+			if text == "" {
+				text = b.Text
+			}
+		}
+	}
+	return text, text != ""
+}
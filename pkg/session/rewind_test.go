@@ -0,0 +1,140 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// addTurn adds a prompt, a tool call and its result, and a final answer
+func addTurn(sess *Session, prompt, toolID string) {
+	sess.AddUserMessage(prompt)
+	sess.AddAssistantMessage(&provider.Response{Content: []provider.ContentBlock{
+		&provider.ToolUseBlock{ID: toolID, Name: "Write"},
+	}})
+	sess.AddToolResult(toolID, "ok", false, nil)
+	sess.AddAssistantMessage(&provider.Response{Content: []provider.ContentBlock{
+		&provider.TextBlock{Text: "done"},
+	}})
+}
+
+func TestSessionPrompts(t *testing.T) {
+	sess := NewSession(&SessionOptions{})
+	addTurn(sess, "first", "t1")
+	addTurn(sess, "second", "t2")
+
+	prompts := sess.Prompts()
+	if len(prompts) != 2 {
+		t.Fatalf("expected 2 prompts, got %d", len(prompts))
+	}
+	if prompts[1].Number != 2 || prompts[1].MessageIndex != 4 || prompts[1].Text != "second" {
+		t.Errorf("unexpected prompt: %+v", prompts[1])
+	}
+}
+
+func TestSessionRewind(t *testing.T) {
+	dir := t.TempDir()
+	edited := filepath.Join(dir, "edited.txt")
+	created := filepath.Join(dir, "created.txt")
+	if err := os.WriteFile(edited, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sess := NewSession(&SessionOptions{})
+	addTurn(sess, "first", "t1")
+
+	// Turn 2 edits a file twice and creates another
+	sess.AddUserMessage("second")
+	sess.Checkpoint("Edit", edited)
+	os.WriteFile(edited, []byte("v2"), 0644)
+	sess.Checkpoint("Edit", edited)
+	os.WriteFile(edited, []byte("v3"), 0644)
+	sess.Checkpoint("Write", created)
+	os.WriteFile(created, []byte("new"), 0644)
+	sess.AddAssistantMessage(&provider.Response{Content: []provider.ContentBlock{&provider.TextBlock{Text: "done"}}})
+
+	addTurn(sess, "third", "t3")
+
+	result, err := sess.Rewind(2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sess.Messages) != 4 || result.RemovedMessages != 6 {
+		t.Errorf("expected 4 messages left and 6 removed, got %d and %d", len(sess.Messages), result.RemovedMessages)
+	}
+	if len(sess.MessageTree) != 4 || sess.CurrentUUID != sess.Messages[3].UUID {
+		t.Errorf("message tree not truncated: %d entries, current %s", len(sess.MessageTree), sess.CurrentUUID)
+	}
+	if result.Prompt.Text != "second" {
+		t.Errorf("expected to rewind to prompt 'second', got %q", result.Prompt.Text)
+	}
+
+	if data, _ := os.ReadFile(edited); string(data) != "v1" {
+		t.Errorf("expected edited file restored to v1, got %q", data)
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Errorf("expected created file to be removed, got %v", err)
+	}
+	if len(result.Restored) != 2 || len(result.Errors) != 0 {
+		t.Errorf("unexpected restore result: %+v", result)
+	}
+
+	// The messages left are a complete turn ending with the assistant
+	msgs := sess.GetMessages()
+	if msgs[len(msgs)-1].Role != provider.RoleAssistant {
+		t.Errorf("expected conversation to end with the assistant, got %s", msgs[len(msgs)-1].Role)
+	}
+
+	if _, err := sess.Rewind(5, false); err == nil {
+		t.Error("expected error for out-of-range prompt")
+	}
+}
+
+func TestSessionRewindWithoutRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	os.WriteFile(path, []byte("before"), 0644)
+
+	sess := NewSession(&SessionOptions{})
+	sess.AddUserMessage("change it")
+	sess.Checkpoint("Write", path)
+	os.WriteFile(path, []byte("after"), 0644)
+
+	result, err := sess.Rewind(1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sess.Messages) != 0 || len(result.Restored) != 0 {
+		t.Errorf("expected empty session and no restores, got %d messages, %v", len(sess.Messages), result.Restored)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "after" {
+		t.Errorf("expected file untouched, got %q", data)
+	}
+}
+
+func TestSessionChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.go")
+
+	sess := NewSession(&SessionOptions{})
+	sess.AddUserMessage("first")
+	sess.AddAssistantMessage(&provider.Response{Content: []provider.ContentBlock{&provider.ToolUseBlock{ID: "t1", Name: "Write"}}})
+	sess.Checkpoint("Write", a)
+	sess.AddToolResult("t1", "ok", false, nil)
+	since := len(sess.Messages)
+	sess.AddUserMessage("second")
+	sess.Checkpoint("Write", b)
+	sess.Checkpoint("Edit", a)
+	sess.Checkpoint("Edit", b)
+
+	got := sess.ChangedFiles(since)
+	if len(got) != 2 || got[0] != b || got[1] != a {
+		t.Errorf("ChangedFiles(%d) = %v, want [%s %s]", since, got, b, a)
+	}
+	if got := sess.ChangedFiles(0); len(got) != 2 || got[0] != a {
+		t.Errorf("ChangedFiles(0) = %v", got)
+	}
+}
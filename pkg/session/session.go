@@ -3,6 +3,7 @@ package session
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
@@ -86,8 +87,15 @@ type Session struct {
 	ProjectPath string
 	CWD         string
 	GitBranch   string
+	Provider    string
 	Model       string
 	Version     string
+	Tags        []string // user-defined tags, see AddTag
+	Pinned      []string // files kept in the system prompt, see Pin
+
+	// KeepThinking saves thinking blocks with the transcript. They are always
+	// kept in memory, since Claude needs them back within a tool-use turn.
+	KeepThinking bool
 
 	// Message history
 	Messages    []*TranscriptEntry
@@ -108,6 +116,12 @@ type Session struct {
 	IsSidechain bool
 	AgentID     string
 
+	// File checkpoints taken before tools changed files, for Rewind
+	checkpoints []FileCheckpoint
+
+	// Run paused by the user, see SetPaused
+	paused *PausedRun
+
 	mu sync.RWMutex
 }
 
@@ -119,6 +133,8 @@ func NewSession(opts *SessionOptions) *Session {
 		ID:             id,
 		ProjectPath:    opts.ProjectPath,
 		CWD:            opts.CWD,
+		GitBranch:      opts.GitBranch,
+		Provider:       opts.Provider,
 		Model:          opts.Model,
 		Version:        opts.Version,
 		Messages:       make([]*TranscriptEntry, 0),
@@ -133,6 +149,8 @@ func NewSession(opts *SessionOptions) *Session {
 type SessionOptions struct {
 	ProjectPath string
 	CWD         string
+	GitBranch   string
+	Provider    string
 	Model       string
 	Version     string
 	MaxTokens   int
@@ -192,6 +210,29 @@ func (s *Session) AddUserMessage(content string) *TranscriptEntry {
 	return entry
 }
 
+// AddNotice adds a system notice for the model. It is appended to the last
+// message when that is from the user, since providers expect the roles to
+// alternate.
+func (s *Session) AddNotice(text string) {
+	s.mu.Lock()
+	if n := len(s.Messages); n > 0 {
+		if last := s.Messages[n-1]; last.Type == EntryTypeUser && last.Message != nil {
+			last.Message.Content = append(last.Message.Content, &provider.TextBlock{Text: text})
+			s.mu.Unlock()
+			return
+		}
+	}
+	s.mu.Unlock()
+
+	s.AddEntry(&TranscriptEntry{
+		Type: EntryTypeUser,
+		Message: &Message{
+			Role:    "user",
+			Content: []provider.ContentBlock{&provider.TextBlock{Text: text}},
+		},
+	})
+}
+
 // truncateTitle creates a short title from content
 func truncateTitle(content string, maxLen int) string {
 	// Remove newlines and extra spaces
@@ -206,17 +247,21 @@ func truncateTitle(content string, maxLen int) string {
 
 // AddToolResult adds a tool result message
 func (s *Session) AddToolResult(toolUseID string, content string, isError bool, metadata interface{}) *TranscriptEntry {
+	return s.AddToolResultBlock(&provider.ToolResultBlock{
+		ToolUseID: toolUseID,
+		Content:   content,
+		IsError:   isError,
+	}, metadata)
+}
+
+// AddToolResultBlock adds a tool result message with images or structured
+// output
+func (s *Session) AddToolResultBlock(result *provider.ToolResultBlock, metadata interface{}) *TranscriptEntry {
 	entry := &TranscriptEntry{
 		Type: EntryTypeUser,
 		Message: &Message{
-			Role: "user",
-			Content: []provider.ContentBlock{
-				&provider.ToolResultBlock{
-					ToolUseID: toolUseID,
-					Content:   content,
-					IsError:   isError,
-				},
-			},
+			Role:    "user",
+			Content: []provider.ContentBlock{result},
 		},
 		ToolUseResult: metadata,
 	}
@@ -225,6 +270,25 @@ func (s *Session) AddToolResult(toolUseID string, content string, isError bool,
 	return entry
 }
 
+// ToolResult returns the content of the tool result for toolUseID, if it is
+// still in the conversation
+func (s *Session) ToolResult(toolUseID string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, entry := range s.Messages {
+		if entry.Message == nil {
+			continue
+		}
+		for _, block := range entry.Message.Content {
+			if result, ok := block.(*provider.ToolResultBlock); ok && result.ToolUseID == toolUseID {
+				return result.Content, true
+			}
+		}
+	}
+	return "", false
+}
+
 // AddAssistantMessage adds an assistant message
 func (s *Session) AddAssistantMessage(resp *provider.Response) *TranscriptEntry {
 	entry := &TranscriptEntry{
@@ -542,3 +606,64 @@ func (e *TranscriptEntry) MarshalJSON() ([]byte, error) {
 		Alias: (*Alias)(e),
 	})
 }
+
+// withoutThinking returns the entry with thinking blocks removed, or the
+// entry itself when it has none
+func withoutThinking(entry *TranscriptEntry) *TranscriptEntry {
+	if entry.Message == nil {
+		return entry
+	}
+	content := make([]provider.ContentBlock, 0, len(entry.Message.Content))
+	for _, block := range entry.Message.Content {
+		switch block.(type) {
+		case *provider.ThinkingBlock, *provider.RedactedThinkingBlock:
+		default:
+			content = append(content, block)
+		}
+	}
+	if len(content) == len(entry.Message.Content) {
+		return entry
+	}
+	stripped := *entry
+	msg := *entry.Message
+	msg.Content = content
+	stripped.Message = &msg
+	return &stripped
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Message. Content may be a
+// plain string or a list of content blocks; unknown block types are skipped.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type Alias Message
+	aux := &struct {
+		Content json.RawMessage `json:"content"`
+		*Alias
+	}{
+		Alias: (*Alias)(m),
+	}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	m.Content = nil
+	if len(aux.Content) == 0 || string(aux.Content) == "null" {
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(aux.Content, &text); err == nil {
+		m.Content = []provider.ContentBlock{&provider.TextBlock{Text: text}}
+		return nil
+	}
+
+	var blocks []json.RawMessage
+	if err := json.Unmarshal(aux.Content, &blocks); err != nil {
+		return fmt.Errorf("invalid message content: %w", err)
+	}
+	for _, raw := range blocks {
+		if block, err := provider.UnmarshalContentBlock(raw); err == nil {
+			m.Content = append(m.Content, block)
+		}
+	}
+	return nil
+}
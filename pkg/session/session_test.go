@@ -423,3 +423,26 @@ func TestSessionConcurrency(t *testing.T) {
 		t.Errorf("Expected 10 messages, got %d", len(messages))
 	}
 }
+
+func TestSessionAddNotice(t *testing.T) {
+	sess := NewSession(&SessionOptions{})
+
+	// Without a user message to attach to, the notice is its own message
+	sess.AddNotice("first notice")
+	sess.AddAssistantMessage(&provider.Response{Content: []provider.ContentBlock{
+		&provider.ToolUseBlock{ID: "t1", Name: "Read"},
+	}})
+	sess.AddToolResult("t1", "content", false, nil)
+	sess.AddNotice("second notice")
+
+	msgs := sess.GetMessages()
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(msgs))
+	}
+	if len(msgs[2].Content) != 2 {
+		t.Fatalf("expected notice appended to the tool results, got %d blocks", len(msgs[2].Content))
+	}
+	if text, ok := msgs[2].Content[1].(*provider.TextBlock); !ok || text.Text != "second notice" {
+		t.Errorf("unexpected notice block: %#v", msgs[2].Content[1])
+	}
+}
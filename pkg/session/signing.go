@@ -0,0 +1,231 @@
+package session
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SigningKeyFile is the name of the transcript signing key in the app directory
+const SigningKeyFile = "transcript-key.pem"
+
+// TranscriptSignature is saved next to a signed transcript, as <id>.sig.json.
+// Hashes chain the transcript's JSONL lines: each is the SHA-256 of the
+// previous hash and the line, so changing, dropping, or reordering an entry
+// changes every hash after it. The last hash, the head, is signed.
+type TranscriptSignature struct {
+	Session   string    `json:"session"`
+	Entries   int       `json:"entries"`
+	Hashes    []string  `json:"hashes"`
+	Head      string    `json:"head"`
+	PublicKey string    `json:"publicKey"` // base64 Ed25519 public key
+	Signature string    `json:"signature"` // base64 signature of the session, entry count and head
+	Signed    time.Time `json:"signed"`
+}
+
+// ErrTranscriptModified means a signed transcript was changed on disk since
+// it was signed, so it was saved without signing it again
+var ErrTranscriptModified = errors.New("transcript was modified since it was signed")
+
+// SignaturePath returns the path of the signature of a JSONL transcript:
+// session.jsonl is signed in session.sig.json
+func SignaturePath(transcriptPath string) string {
+	return strings.TrimSuffix(transcriptPath, ".jsonl") + ".sig.json"
+}
+
+// transcriptChain computes the hashes of a transcript line by line
+type transcriptChain struct {
+	hashes []string
+}
+
+// add hashes the next line, without its trailing newline
+func (c *transcriptChain) add(line []byte) {
+	h := sha256.New()
+	h.Write([]byte(c.head()))
+	h.Write([]byte{'\n'})
+	h.Write(line)
+	c.hashes = append(c.hashes, hex.EncodeToString(h.Sum(nil)))
+}
+
+func (c *transcriptChain) head() string {
+	if len(c.hashes) == 0 {
+		return ""
+	}
+	return c.hashes[len(c.hashes)-1]
+}
+
+// signedMessage is what the key signs: binding the session ID and entry count
+// keeps a signature from vouching for another transcript or a truncated one
+func signedMessage(sessionID string, entries int, head string) []byte {
+	return []byte("agentic-coder transcript v1\n" + sessionID + "\n" + strconv.Itoa(entries) + "\n" + head)
+}
+
+// sign signs the chain's head for a session
+func (c *transcriptChain) sign(sessionID string, key ed25519.PrivateKey) *TranscriptSignature {
+	head := c.head()
+	return &TranscriptSignature{
+		Session:   sessionID,
+		Entries:   len(c.hashes),
+		Hashes:    c.hashes,
+		Head:      head,
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, signedMessage(sessionID, len(c.hashes), head))),
+		Signed:    time.Now(),
+	}
+}
+
+// VerifyTranscript checks a JSONL transcript against its signature: that no
+// entry was changed, added or removed since it was signed, and that the
+// signature is good. With a trusted key, the transcript must also have been
+// signed with it; otherwise the key in the signature is taken at its word,
+// which shows the transcript was not edited afterwards but not by whom it
+// was signed.
+func VerifyTranscript(transcript io.Reader, sig *TranscriptSignature, trusted ed25519.PublicKey) error {
+	publicKey, err := ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key in signature: %w", err)
+	}
+	if trusted != nil && !publicKey.Equal(trusted) {
+		return fmt.Errorf("signed with key %s, not the trusted key %s", KeyFingerprint(publicKey), KeyFingerprint(trusted))
+	}
+	signature, err := base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if sig.Entries != len(sig.Hashes) || (sig.Entries > 0 && sig.Head != sig.Hashes[sig.Entries-1]) {
+		return errors.New("the signature's hashes do not match its head")
+	}
+	if !ed25519.Verify(publicKey, signedMessage(sig.Session, sig.Entries, sig.Head), signature) {
+		return errors.New("bad signature: the signature file was altered or is for another transcript")
+	}
+
+	data, err := io.ReadAll(transcript)
+	if err != nil {
+		return fmt.Errorf("failed to read transcript: %w", err)
+	}
+	chain := chainLines(data)
+	for i, hash := range chain.hashes {
+		if i >= sig.Entries {
+			return fmt.Errorf("entry %d was added after the transcript was signed", i+1)
+		}
+		if hash != sig.Hashes[i] {
+			return fmt.Errorf("entry %d was modified after the transcript was signed", i+1)
+		}
+	}
+	if len(chain.hashes) < sig.Entries {
+		return fmt.Errorf("%d of %d signed entries are missing", sig.Entries-len(chain.hashes), sig.Entries)
+	}
+	return nil
+}
+
+// LoadSignature reads a transcript signature file
+func LoadSignature(path string) (*TranscriptSignature, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sig TranscriptSignature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return nil, fmt.Errorf("invalid signature file %s: %w", path, err)
+	}
+	return &sig, nil
+}
+
+// WriteSignature writes a transcript signature file
+func WriteSignature(path string, sig *TranscriptSignature) error {
+	data, err := json.MarshalIndent(sig, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadSigningKey returns the transcript signing key in appDir, generating
+// it the first time. The key never leaves the machine; verifiers are given
+// its public half.
+func LoadSigningKey(appDir string) (ed25519.PrivateKey, error) {
+	path := filepath.Join(appDir, SigningKeyFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return generateSigningKey(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid signing key %s: not PEM", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key %s: %w", path, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid signing key %s: not an Ed25519 key", path)
+	}
+	return key, nil
+}
+
+func generateSigningKey(path string) (ed25519.PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to save signing key: %w", err)
+	}
+	return key, nil
+}
+
+// ParsePublicKey parses a base64 Ed25519 public key
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("expected %d bytes, got %d", ed25519.PublicKeySize, len(data))
+	}
+	return ed25519.PublicKey(data), nil
+}
+
+// KeyFingerprint returns a short fingerprint of a public key for display
+func KeyFingerprint(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])[:16]
+}
+
+// chainLines hashes the lines of a JSONL transcript
+func chainLines(data []byte) *transcriptChain {
+	chain := &transcriptChain{}
+	for _, line := range bytes.SplitAfter(data, []byte{'\n'}) {
+		if len(line) > 0 {
+			chain.add(bytes.TrimSuffix(line, []byte{'\n'}))
+		}
+	}
+	return chain
+}
@@ -0,0 +1,146 @@
+package session
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+func TestTranscriptSigning(t *testing.T) {
+	appDir := t.TempDir()
+	key, err := LoadSigningKey(appDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := LoadSigningKey(appDir); err != nil || !again.Equal(key) {
+		t.Fatalf("expected the saved key to be reused, got %v", err)
+	}
+
+	storage, err := NewFileStorage(appDir, "/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.signingKey = key
+	sess := NewSession(&SessionOptions{CWD: "/project"})
+	sess.AddUserMessage("update the production nginx config")
+	sess.AddAssistantMessage(&provider.Response{Content: []provider.ContentBlock{&provider.TextBlock{Text: "done"}}})
+	sess.AddUserMessage("thanks")
+	if err := storage.Save(sess); err != nil {
+		t.Fatal(err)
+	}
+
+	data, sig, err := storage.Transcript(sess.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig == nil || sig.Entries != 3 {
+		t.Fatalf("expected a signature over 3 entries, got %+v", sig)
+	}
+	public := key.Public().(ed25519.PublicKey)
+	if err := VerifyTranscript(bytes.NewReader(data), sig, public); err != nil {
+		t.Fatalf("expected the saved transcript to verify, got %v", err)
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	other, _, _ := ed25519.GenerateKey(nil)
+	tests := []struct {
+		name       string
+		transcript string
+		trusted    ed25519.PublicKey
+		want       string
+	}{
+		{"modified", lines[0] + strings.Replace(lines[1], "done", "skipped", 1) + lines[2], nil, "entry 2 was modified"},
+		{"reordered", lines[1] + lines[0] + lines[2], nil, "entry 1 was modified"},
+		{"truncated", lines[0] + lines[1], nil, "1 of 3 signed entries are missing"},
+		{"appended", string(data) + lines[2], nil, "entry 4 was added"},
+		{"other signer", string(data), other, "not the trusted key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyTranscript(strings.NewReader(tt.transcript), sig, tt.trusted)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// A signature moved to another session does not verify
+	forged := *sig
+	forged.Session = "other"
+	if err := VerifyTranscript(bytes.NewReader(data), &forged, nil); err == nil || !strings.Contains(err.Error(), "bad signature") {
+		t.Errorf("expected a bad signature, got %v", err)
+	}
+
+	// Saving without a key drops the signature, which no longer matches
+	storage.signingKey = nil
+	sess.AddUserMessage("one more thing")
+	if err := storage.Save(sess); err != nil {
+		t.Fatal(err)
+	}
+	if _, sig, err := storage.Transcript(sess.ID); err != nil || sig != nil {
+		t.Errorf("expected an unsigned transcript, got %+v, %v", sig, err)
+	}
+	if _, err := os.Stat(SignaturePath(filepath.Join(storage.projectDir, sess.ID+".jsonl"))); !os.IsNotExist(err) {
+		t.Errorf("expected the signature file to be removed, got %v", err)
+	}
+}
+
+func TestTranscriptEditedBetweenAppends(t *testing.T) {
+	appDir := t.TempDir()
+	key, err := LoadSigningKey(appDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage, err := NewFileStorage(appDir, "/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.signingKey = key
+	public := key.Public().(ed25519.PublicKey)
+
+	sess := NewSession(&SessionOptions{CWD: "/project"})
+	sess.AddUserMessage("rotate the api keys")
+	if err := storage.Save(sess); err != nil {
+		t.Fatal(err)
+	}
+	appendEntry := func(text string) error {
+		return storage.AppendEntry(sess.ID, &TranscriptEntry{Type: EntryTypeUser, UUID: text, SessionID: sess.ID})
+	}
+	if err := appendEntry("first"); err != nil {
+		t.Fatal(err)
+	}
+	data, sig, err := storage.Transcript(sess.ID)
+	if err != nil || sig == nil || sig.Entries != 2 {
+		t.Fatalf("expected a signature over 2 entries, got %+v, %v", sig, err)
+	}
+	if err := VerifyTranscript(bytes.NewReader(data), sig, public); err != nil {
+		t.Fatalf("expected the appended transcript to verify, got %v", err)
+	}
+
+	// An edit before the next append is not signed over
+	path := filepath.Join(storage.projectDir, sess.ID+".jsonl")
+	if err := os.WriteFile(path, bytes.Replace(data, []byte("rotate"), []byte("leak"), 1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendEntry("second"); !errors.Is(err, ErrTranscriptModified) {
+		t.Fatalf("expected the edit to be reported, got %v", err)
+	}
+	data, sig, err = storage.Transcript(sess.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyTranscript(bytes.NewReader(data), sig, public); err == nil || !strings.Contains(err.Error(), "entry 1 was modified") {
+		t.Errorf("expected the edited transcript to fail verification, got %v", err)
+	}
+
+	// Nor by the next save, which might come from a session loaded with it
+	if err := storage.Save(sess); !errors.Is(err, ErrTranscriptModified) {
+		t.Errorf("expected saving over the edit to be reported, got %v", err)
+	}
+}
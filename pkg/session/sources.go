@@ -0,0 +1,130 @@
+package session
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+// maxSearchSources is how many results of each web search are sources of
+// an answer that neither links to nor fetched any page
+const maxSearchSources = 3
+
+// searchResultPattern matches a result in the output of the WebSearch tool:
+// "1. **Title**" followed by "   URL: https://..."
+var searchResultPattern = regexp.MustCompile(`(?m)^\d+\. \*\*(.*)\*\*\n\s+URL: (\S+)`)
+
+// Sources returns the web pages that informed the answers in entries, in
+// the order they were first used: pages the WebFetch tool fetched, pages a
+// provider's built-in search cited, and WebSearch results the answers link
+// to. When an answer relied on search results alone, the top results of
+// each search are its sources.
+func Sources(entries []*TranscriptEntry) []provider.Citation {
+	var fetched, cited, searched, top []provider.Citation
+	uses := make(map[string]*provider.ToolUseBlock)
+	var answer strings.Builder
+	for _, entry := range entries {
+		if entry.Message == nil {
+			continue
+		}
+		for _, block := range entry.Message.Content {
+			switch b := block.(type) {
+			case *provider.ToolUseBlock:
+				uses[b.ID] = b
+			case *provider.TextBlock:
+				if entry.Type == EntryTypeAssistant {
+					answer.WriteString(b.Text)
+					answer.WriteByte('\n')
+					cited = append(cited, b.Citations...)
+				}
+			case *provider.ToolResultBlock:
+				use := uses[b.ToolUseID]
+				if use == nil || b.IsError {
+					continue
+				}
+				switch use.Name {
+				case "WebFetch":
+					if url, _ := use.Input["url"].(string); url != "" {
+						fetched = append(fetched, provider.Citation{URL: url})
+					}
+				case "WebSearch":
+					results := searchResults(b.Content)
+					searched = append(searched, results...)
+					top = append(top, results[:min(len(results), maxSearchSources)]...)
+				}
+			}
+		}
+	}
+
+	titles := make(map[string]string)
+	for _, r := range searched {
+		titles[sourceKey(r.URL)] = r.Title
+	}
+	sources := newSourceList(titles)
+	sources.add(fetched...)
+	sources.add(cited...)
+	text := answer.String()
+	for _, r := range searched {
+		if strings.Contains(text, r.URL) {
+			sources.add(r)
+		}
+	}
+	if len(sources.list) == 0 {
+		sources.add(top...)
+	}
+	return sources.list
+}
+
+// TurnSources returns the web pages that informed the answer to the latest prompt
+func (s *Session) TurnSources() []provider.Citation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start := 0
+	for i, entry := range s.Messages {
+		if _, ok := promptText(entry); ok {
+			start = i
+		}
+	}
+	return Sources(s.Messages[start:])
+}
+
+// searchResults parses the results out of WebSearch output
+func searchResults(output string) []provider.Citation {
+	var results []provider.Citation
+	for _, m := range searchResultPattern.FindAllStringSubmatch(output, -1) {
+		results = append(results, provider.Citation{URL: m[2], Title: m[1]})
+	}
+	return results
+}
+
+// sourceKey identifies a page regardless of a trailing slash
+func sourceKey(url string) string {
+	return strings.TrimSuffix(url, "/")
+}
+
+// sourceList collects distinct sources, filling in missing titles
+type sourceList struct {
+	list   []provider.Citation
+	seen   map[string]bool
+	titles map[string]string
+}
+
+func newSourceList(titles map[string]string) *sourceList {
+	return &sourceList{seen: make(map[string]bool), titles: titles}
+}
+
+func (l *sourceList) add(sources ...provider.Citation) {
+	for _, s := range sources {
+		key := sourceKey(s.URL)
+		if l.seen[key] {
+			continue
+		}
+		l.seen[key] = true
+		if s.Title == "" {
+			s.Title = l.titles[key]
+		}
+		l.list = append(l.list, s)
+	}
+}
@@ -0,0 +1,101 @@
+package session
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+)
+
+const searchOutput = `Search results for: go 1.24
+
+1. **Go 1.24 Release Notes**
+   URL: https://go.dev/doc/go1.24
+   Generic type aliases are fully supported.
+
+2. **Go 1.24 is released**
+   URL: https://go.dev/blog/go1.24/
+   The Go team is happy to announce Go 1.24.
+
+3. **Swiss tables in Go**
+   URL: https://go.dev/blog/swisstable
+   Maps are now faster.
+
+4. **Unrelated**
+   URL: https://example.test/
+`
+
+// addToolCall adds a tool call and its result
+func addToolCall(sess *Session, id, name string, input map[string]interface{}, output string, isError bool) {
+	sess.AddAssistantMessage(&provider.Response{Content: []provider.ContentBlock{
+		&provider.ToolUseBlock{ID: id, Name: name, Input: input},
+	}})
+	sess.AddToolResult(id, output, isError, nil)
+}
+
+func addAnswer(sess *Session, text string, citations ...provider.Citation) {
+	sess.AddAssistantMessage(&provider.Response{Content: []provider.ContentBlock{
+		&provider.TextBlock{Text: text, Citations: citations},
+	}})
+}
+
+func TestSources(t *testing.T) {
+	sess := NewSession(&SessionOptions{})
+	sess.AddUserMessage("What is new in Go 1.24?")
+	addToolCall(sess, "s1", "WebSearch", map[string]interface{}{"query": "go 1.24"}, searchOutput, false)
+	addToolCall(sess, "f1", "WebFetch", map[string]interface{}{"url": "https://go.dev/blog/go1.24"}, "Fetched content", false)
+	addToolCall(sess, "f2", "WebFetch", map[string]interface{}{"url": "https://broken.test"}, "HTTP error: 404", true)
+	addAnswer(sess, "Generic type aliases (https://go.dev/doc/go1.24) and faster maps.",
+		provider.Citation{URL: "https://pkg.go.dev/weak", Title: "weak"})
+
+	want := []provider.Citation{
+		{URL: "https://go.dev/blog/go1.24", Title: "Go 1.24 is released"},
+		{URL: "https://pkg.go.dev/weak", Title: "weak"},
+		{URL: "https://go.dev/doc/go1.24", Title: "Go 1.24 Release Notes"},
+	}
+	if got := sess.TurnSources(); !reflect.DeepEqual(got, want) {
+		t.Errorf("TurnSources() = %+v, want %+v", got, want)
+	}
+
+	// An answer from search results alone cites the top results
+	sess.AddUserMessage("And the maps?")
+	addToolCall(sess, "s2", "WebSearch", map[string]interface{}{"query": "go maps"}, searchOutput, false)
+	addAnswer(sess, "Maps use Swiss tables now.")
+	got := sess.TurnSources()
+	if len(got) != maxSearchSources || got[2].URL != "https://go.dev/blog/swisstable" {
+		t.Errorf("expected the top search results, got %+v", got)
+	}
+
+	// A turn without web content has no sources
+	sess.AddUserMessage("Thanks")
+	addAnswer(sess, "You're welcome.")
+	if got := sess.TurnSources(); len(got) != 0 {
+		t.Errorf("expected no sources, got %+v", got)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	sess := NewSession(&SessionOptions{Model: "test-model"})
+	sess.AddUserMessage("What is new in Go 1.24?")
+	addToolCall(sess, "f1", "WebFetch", map[string]interface{}{"url": "https://go.dev/doc/go1.24"}, "Fetched content", false)
+	addAnswer(sess, "Generic type aliases.")
+	sess.AddUserMessage("Thanks")
+	addAnswer(sess, "You're welcome.")
+
+	md := sess.Markdown()
+	for _, want := range []string{
+		"# What is new in Go 1.24?\n",
+		"_test-model_",
+		"## User\n\nWhat is new in Go 1.24?\n",
+		"- `WebFetch` `{\"url\":\"https://go.dev/doc/go1.24\"}`",
+		"Generic type aliases.\n\n**Sources**\n\n1. [https://go.dev/doc/go1.24](https://go.dev/doc/go1.24)\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected %q in:\n%s", want, md)
+		}
+	}
+	if strings.Count(md, "**Sources**") != 1 {
+		t.Errorf("expected sources only under the first answer:\n%s", md)
+	}
+}
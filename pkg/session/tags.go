@@ -0,0 +1,126 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Prefixes of tags derived automatically from session metadata
+const (
+	TagPrefixProvider = "provider:"
+	TagPrefixModel    = "model:"
+	TagPrefixBranch   = "branch:"
+)
+
+// NormalizeTag lowercases a user tag and replaces whitespace with dashes.
+// Tags containing ":" are reserved for automatic tags.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.Join(strings.Fields(tag), "-"))
+	if tag == "" {
+		return "", fmt.Errorf("tag is empty")
+	}
+	if strings.Contains(tag, ":") {
+		return "", fmt.Errorf("tag %q is reserved: tags containing ':' are added automatically", tag)
+	}
+	return tag, nil
+}
+
+// AddTag adds a user tag and reports whether it was new
+func (s *Session) AddTag(tag string) (bool, error) {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.Tags {
+		if t == tag {
+			return false, nil
+		}
+	}
+	s.Tags = append(s.Tags, tag)
+	sort.Strings(s.Tags)
+	return true, nil
+}
+
+// RemoveTag removes a user tag and reports whether it was present
+func (s *Session) RemoveTag(tag string) bool {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, t := range s.Tags {
+		if t == tag {
+			s.Tags = append(s.Tags[:i], s.Tags[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// GetTags returns the user tags followed by the automatic tags
+func (s *Session) GetTags() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return allTags(s.Tags, s.Provider, s.Model, s.GitBranch)
+}
+
+// AllTags returns the user tags followed by the automatic tags
+func (i *SessionInfo) AllTags() []string {
+	return allTags(i.Tags, i.Provider, i.Model, i.GitBranch)
+}
+
+// HasTags reports whether the session has all of the given tags
+func (i *SessionInfo) HasTags(tags ...string) bool {
+	all := i.AllTags()
+	for _, want := range tags {
+		want = strings.ToLower(strings.TrimSpace(want))
+		found := false
+		for _, t := range all {
+			if t == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterByTags returns the sessions that have all of the given tags
+func FilterByTags(sessions []*SessionInfo, tags ...string) []*SessionInfo {
+	if len(tags) == 0 {
+		return sessions
+	}
+	var result []*SessionInfo
+	for _, s := range sessions {
+		if s.HasTags(tags...) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func allTags(user []string, providerName, model, branch string) []string {
+	tags := append([]string{}, user...)
+	if providerName != "" {
+		tags = append(tags, TagPrefixProvider+strings.ToLower(providerName))
+	}
+	if model != "" {
+		tags = append(tags, TagPrefixModel+strings.ToLower(model))
+	}
+	if branch != "" {
+		tags = append(tags, TagPrefixBranch+strings.ToLower(branch))
+	}
+	return tags
+}
@@ -0,0 +1,119 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    string
+		wantErr bool
+	}{
+		{"Refactor", "refactor", false},
+		{"  bug  fix ", "bug-fix", false},
+		{"", "", true},
+		{"provider:claude", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeTag(tt.tag)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeTag(%q) error = %v, wantErr %v", tt.tag, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeTag(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestSessionTags(t *testing.T) {
+	sess := NewSession(&SessionOptions{
+		Provider:  "claude",
+		Model:     "claude-sonnet-4",
+		GitBranch: "Feature/X",
+	})
+
+	if added, err := sess.AddTag("Refactor"); err != nil || !added {
+		t.Fatalf("AddTag() = %v, %v", added, err)
+	}
+	if added, _ := sess.AddTag("refactor"); added {
+		t.Error("expected duplicate tag not to be added")
+	}
+	sess.AddTag("api")
+
+	want := []string{"api", "refactor", "provider:claude", "model:claude-sonnet-4", "branch:feature/x"}
+	if got := sess.GetTags(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTags() = %v, want %v", got, want)
+	}
+
+	if !sess.RemoveTag("API") {
+		t.Error("expected RemoveTag to remove api")
+	}
+	if sess.RemoveTag("missing") {
+		t.Error("expected RemoveTag to report a missing tag")
+	}
+	if !reflect.DeepEqual(sess.Tags, []string{"refactor"}) {
+		t.Errorf("Tags = %v, want [refactor]", sess.Tags)
+	}
+}
+
+func TestFilterByTags(t *testing.T) {
+	sessions := []*SessionInfo{
+		{ID: "a", Provider: "claude", Tags: []string{"refactor"}},
+		{ID: "b", Provider: "openai", Tags: []string{"refactor", "api"}},
+		{ID: "c", Provider: "openai", GitBranch: "main"},
+	}
+
+	tests := []struct {
+		tags []string
+		want []string
+	}{
+		{nil, []string{"a", "b", "c"}},
+		{[]string{"refactor"}, []string{"a", "b"}},
+		{[]string{"refactor", "provider:openai"}, []string{"b"}},
+		{[]string{"Branch:main"}, []string{"c"}},
+		{[]string{"missing"}, nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, s := range FilterByTags(sessions, tt.tags...) {
+			got = append(got, s.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FilterByTags(%v) = %v, want %v", tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestFileStorageTags(t *testing.T) {
+	storage, err := NewFileStorage(t.TempDir(), "/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess := NewSession(&SessionOptions{Provider: "claude", GitBranch: "main", Model: "sonnet"})
+	sess.AddTag("refactor")
+	if err := storage.Save(sess); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := storage.Load(sess.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.GetTags(), sess.GetTags()) {
+		t.Errorf("loaded tags = %v, want %v", loaded.GetTags(), sess.GetTags())
+	}
+
+	infos, err := storage.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || !infos[0].HasTags("refactor", "branch:main") {
+		t.Errorf("expected listed session with tags, got %+v", infos)
+	}
+}
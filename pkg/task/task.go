@@ -3,6 +3,7 @@ package task
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -68,9 +69,23 @@ type Task struct {
 	// RunInBackground indicates if task runs async
 	RunInBackground bool
 
+	// Model overrides the agent's model
+	Model string
+
+	// CWD is the working directory of the task's session
+	CWD string
+
+	// Token usage and tool calls so far
+	InputTokens  int
+	OutputTokens int
+	ToolCalls    int
+
 	// Cancel function
 	cancel context.CancelFunc
 
+	// Receives the task's progress (nil = not reported)
+	onEvent func(event *tool.SubtaskEvent)
+
 	mu sync.RWMutex
 }
 
@@ -100,6 +115,12 @@ type Manager struct {
 	// Subagent configurations
 	agentConfigs map[string]*AgentConfig
 
+	// Options shared by subagent engines, such as the path scope
+	engineDefaults engine.EngineOptions
+
+	// Model of agents without their own (nil = "sonnet")
+	defaultModel func() string
+
 	mu sync.RWMutex
 }
 
@@ -146,6 +167,24 @@ func NewManager(providerFactory func(string) provider.AIProvider, registry *tool
 	return m
 }
 
+// SetEngineDefaults sets the options subagent engines start from, so that
+// they share limits and safeguards such as the path scope with the parent.
+// The provider, registry, session, system prompt and iteration limit are
+// set per task.
+func (m *Manager) SetEngineDefaults(opts engine.EngineOptions) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.engineDefaults = opts
+}
+
+// SetDefaultModel sets where agents without a model of their own get
+// theirs, such as the parent session
+func (m *Manager) SetDefaultModel(model func() string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaultModel = model
+}
+
 // registerBuiltinAgents registers default subagent types
 func (m *Manager) registerBuiltinAgents() {
 	// Explore agent - for codebase exploration
@@ -233,6 +272,9 @@ func (m *Manager) CreateTask(opts *TaskOptions) (*Task, error) {
 		ParentID:        opts.ParentID,
 		Created:         time.Now(),
 		RunInBackground: opts.RunInBackground,
+		Model:           opts.Model,
+		CWD:             opts.CWD,
+		onEvent:         opts.OnEvent,
 	}
 
 	m.tasks[task.ID] = task
@@ -250,6 +292,8 @@ type TaskOptions struct {
 	Model           string
 	RunInBackground bool
 	CWD             string
+	// OnEvent receives the task's progress (nil = not reported)
+	OnEvent func(event *tool.SubtaskEvent)
 }
 
 // RunTask runs a task
@@ -270,30 +314,39 @@ func (m *Manager) RunTask(ctx context.Context, taskID string) error {
 
 	// Create context with cancellation
 	ctx, cancel := context.WithCancel(ctx)
-	task.cancel = cancel
+	defer cancel()
 
 	// Update status
 	task.mu.Lock()
+	task.cancel = cancel
 	task.Status = TaskStatusRunning
 	now := time.Now()
 	task.Started = &now
 	task.mu.Unlock()
 
 	// Create provider
-	model := config.Model
+	m.mu.RLock()
+	defaults := m.engineDefaults
+	defaultModel := m.defaultModel
+	m.mu.RUnlock()
+	model := task.Model
+	if model == "" {
+		model = config.Model
+	}
+	if model == "" && defaultModel != nil {
+		model = defaultModel()
+	}
 	if model == "" {
 		model = "sonnet"
 	}
 	prov := m.providerFactory(model)
 
-	// Create filtered registry
-	registry := m.baseRegistry
-	if len(config.AllowedTools) > 0 || len(config.DisallowedTools) > 0 {
-		registry = m.baseRegistry.FilteredRegistry(config.AllowedTools, config.DisallowedTools)
-	}
+	// Create filtered registry; subagents do not start subagents of their own
+	registry := m.baseRegistry.FilteredRegistry(config.AllowedTools, append([]string{"Task", "TaskOutput"}, config.DisallowedTools...))
 
 	// Create session for this task
 	sess := session.NewSession(&session.SessionOptions{
+		CWD:     task.CWD,
 		Model:   provider.ResolveModel(model),
 		Version: "1.0",
 	})
@@ -302,22 +355,39 @@ func (m *Manager) RunTask(ctx context.Context, taskID string) error {
 	task.Session = sess
 
 	// Create engine
-	eng := engine.NewEngine(&engine.EngineOptions{
-		Provider:      prov,
-		Registry:      registry,
-		Session:       sess,
-		MaxIterations: config.MaxIterations,
-		SystemPrompt:  config.SystemPrompt,
-	})
+	opts := defaults
+	opts.Provider = prov
+	opts.Registry = registry
+	opts.Session = sess
+	opts.MaxIterations = config.MaxIterations
+	opts.SystemPrompt = config.SystemPrompt
+	eng := engine.NewEngine(&opts)
 	task.Engine = eng
 
-	// Collect output
+	// Collect output and report progress
 	var output string
 	eng.SetCallbacks(&engine.CallbackOptions{
 		OnText: func(text string) {
 			output += text
 		},
+		OnToolUse: func(name string, input map[string]interface{}) {
+			task.mu.Lock()
+			task.ToolCalls++
+			task.mu.Unlock()
+			task.report(&tool.SubtaskEvent{Kind: tool.SubtaskToolUse, Tool: name, Input: input})
+		},
+		OnToolResult: func(name string, result *tool.Output) {
+			task.report(&tool.SubtaskEvent{Kind: tool.SubtaskToolResult, Tool: name, Result: result})
+		},
+		OnUsage: func(inputTokens, outputTokens int) {
+			task.mu.Lock()
+			task.InputTokens += inputTokens
+			task.OutputTokens += outputTokens
+			task.mu.Unlock()
+			task.report(&tool.SubtaskEvent{Kind: tool.SubtaskUsage})
+		},
 	})
+	task.report(&tool.SubtaskEvent{Kind: tool.SubtaskStarted})
 
 	// Run engine
 	err := eng.Run(ctx, task.Prompt)
@@ -329,6 +399,7 @@ func (m *Manager) RunTask(ctx context.Context, taskID string) error {
 
 	if ctx.Err() == context.Canceled {
 		task.Status = TaskStatusCancelled
+		err = ErrCancelled
 	} else if err != nil {
 		task.Status = TaskStatusFailed
 		task.Error = err.Error()
@@ -339,10 +410,50 @@ func (m *Manager) RunTask(ctx context.Context, taskID string) error {
 		}
 	}
 	task.mu.Unlock()
+	task.report(&tool.SubtaskEvent{Kind: tool.SubtaskFinished})
 
 	return err
 }
 
+// report passes an event to the task's observer, filling in the task and
+// its usage so far
+func (t *Task) report(event *tool.SubtaskEvent) {
+	if t.onEvent == nil {
+		return
+	}
+	t.mu.RLock()
+	event.ID = t.ID
+	event.Agent = t.SubagentType
+	event.Description = t.Description
+	event.ToolCalls = t.ToolCalls
+	event.InputTokens = t.InputTokens
+	event.OutputTokens = t.OutputTokens
+	event.Status = string(t.Status)
+	event.Error = t.Error
+	t.mu.RUnlock()
+	t.onEvent(event)
+}
+
+// Info returns a copy of the task's state, safe to read while it runs
+func (t *Task) Info() Task {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return Task{
+		ID:              t.ID,
+		Description:     t.Description,
+		SubagentType:    t.SubagentType,
+		Status:          t.Status,
+		Created:         t.Created,
+		Started:         t.Started,
+		Completed:       t.Completed,
+		Error:           t.Error,
+		RunInBackground: t.RunInBackground,
+		InputTokens:     t.InputTokens,
+		OutputTokens:    t.OutputTokens,
+		ToolCalls:       t.ToolCalls,
+	}
+}
+
 // RunTaskAsync runs a task in the background
 func (m *Manager) RunTaskAsync(ctx context.Context, taskID string) {
 	go func() {
@@ -364,9 +475,13 @@ func (m *Manager) CancelTask(id string) error {
 		return ErrTaskNotFound
 	}
 
-	if task.cancel != nil {
-		task.cancel()
+	task.mu.RLock()
+	cancel, status := task.cancel, task.Status
+	task.mu.RUnlock()
+	if status != TaskStatusRunning || cancel == nil {
+		return ErrNotRunning
 	}
+	cancel()
 
 	return nil
 }
@@ -380,6 +495,7 @@ func (m *Manager) ListTasks() []*Task {
 	for _, t := range m.tasks {
 		tasks = append(tasks, t)
 	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Created.Before(tasks[j].Created) })
 	return tasks
 }
 
@@ -427,6 +543,7 @@ var (
 	ErrTaskNotFound = &TaskError{Message: "task not found"}
 	ErrTimeout      = &TaskError{Message: "task timed out"}
 	ErrCancelled    = &TaskError{Message: "task cancelled"}
+	ErrNotRunning   = &TaskError{Message: "task is not running"}
 )
 
 // TaskError represents a task error
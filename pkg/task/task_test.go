@@ -0,0 +1,150 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// scriptedProvider returns its responses in order, then blocks until the
+// request is cancelled
+type scriptedProvider struct {
+	mu        sync.Mutex
+	responses []*provider.Response
+	tools     [][]string
+}
+
+func (p *scriptedProvider) Name() string              { return "scripted" }
+func (p *scriptedProvider) SupportedModels() []string { return nil }
+
+func (p *scriptedProvider) SupportsFeature(feature provider.Feature) bool {
+	return feature == provider.FeatureToolUse
+}
+
+func (p *scriptedProvider) CreateMessage(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	p.mu.Lock()
+	var names []string
+	for _, t := range req.Tools {
+		names = append(names, t.Name)
+	}
+	p.tools = append(p.tools, names)
+	if len(p.responses) > 0 {
+		resp := p.responses[0]
+		p.responses = p.responses[1:]
+		p.mu.Unlock()
+		return resp, nil
+	}
+	p.mu.Unlock()
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (p *scriptedProvider) CreateMessageStream(ctx context.Context, req *provider.Request) (provider.StreamReader, error) {
+	return nil, errors.New("not supported")
+}
+
+type fakeTool struct{ name string }
+
+func (t *fakeTool) Name() string                     { return t.name }
+func (t *fakeTool) Description() string              { return t.name }
+func (t *fakeTool) InputSchema() json.RawMessage     { return json.RawMessage(`{"type": "object"}`) }
+func (t *fakeTool) Validate(input *tool.Input) error { return nil }
+func (t *fakeTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+	return &tool.Output{Content: "pkg/foo.go"}, nil
+}
+
+func newTestManager(prov provider.AIProvider) *Manager {
+	registry := tool.NewRegistry()
+	registry.Register(&fakeTool{name: "Glob"})
+	registry.Register(&fakeTool{name: "Task"})
+	return NewManager(func(string) provider.AIProvider { return prov }, registry)
+}
+
+func TestRunTaskReportsProgress(t *testing.T) {
+	prov := &scriptedProvider{responses: []*provider.Response{
+		{StopReason: provider.StopReasonToolUse, Usage: provider.Usage{InputTokens: 100, OutputTokens: 10}, Content: []provider.ContentBlock{
+			&provider.ToolUseBlock{ID: "1", Name: "Glob", Input: map[string]interface{}{"pattern": "*.go"}},
+		}},
+		{StopReason: provider.StopReasonEndTurn, Usage: provider.Usage{InputTokens: 200, OutputTokens: 20}, Content: []provider.ContentBlock{
+			&provider.TextBlock{Text: "Found pkg/foo.go"},
+		}},
+	}}
+	m := newTestManager(prov)
+
+	var kinds []tool.SubtaskEventKind
+	var last *tool.SubtaskEvent
+	task, err := m.CreateTask(&TaskOptions{
+		Description:  "Find Go files",
+		Prompt:       "Find the Go files",
+		SubagentType: "general-purpose",
+		OnEvent: func(event *tool.SubtaskEvent) {
+			kinds = append(kinds, event.Kind)
+			last = event
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RunTask(context.Background(), task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []tool.SubtaskEventKind{tool.SubtaskStarted, tool.SubtaskUsage, tool.SubtaskToolUse, tool.SubtaskToolResult, tool.SubtaskUsage, tool.SubtaskFinished}
+	if len(kinds) != len(want) {
+		t.Fatalf("events = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("events = %v, want %v", kinds, want)
+		}
+	}
+	if last.Status != string(TaskStatusCompleted) || last.ToolCalls != 1 || last.InputTokens != 300 || last.OutputTokens != 30 {
+		t.Errorf("unexpected final event %+v", last)
+	}
+
+	for _, names := range prov.tools {
+		for _, name := range names {
+			if name == "Task" {
+				t.Error("subagents must not be able to start subagents")
+			}
+		}
+	}
+}
+
+func TestCancelTask(t *testing.T) {
+	m := newTestManager(&scriptedProvider{})
+
+	started := make(chan struct{})
+	task, _ := m.CreateTask(&TaskOptions{
+		Description:  "Wait",
+		Prompt:       "Wait",
+		SubagentType: "general-purpose",
+		OnEvent: func(event *tool.SubtaskEvent) {
+			if event.Kind == tool.SubtaskStarted {
+				close(started)
+			}
+		},
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- m.RunTask(context.Background(), task.ID) }()
+	<-started
+
+	if err := m.CancelTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; !errors.Is(err, ErrCancelled) {
+		t.Errorf("RunTask() = %v, want %v", err, ErrCancelled)
+	}
+	if info := task.Info(); info.Status != TaskStatusCancelled {
+		t.Errorf("status = %s, want %s", info.Status, TaskStatusCancelled)
+	}
+	if err := m.CancelTask(task.ID); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("cancelling a finished task = %v, want %v", err, ErrNotRunning)
+	}
+}
@@ -0,0 +1,363 @@
+// Package telemetry records opt-in anonymous usage metrics: how often
+// commands are used, which providers run, and the classes of errors. It
+// never records code, prompts, paths, or error messages.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoint receives the metrics. Release builds set it with
+// -ldflags "-X github.com/xinguang/agentic-coder/pkg/telemetry.Endpoint=<url>";
+// without one, counts are kept locally and never sent.
+var Endpoint = ""
+
+// sendInterval is the minimum time between uploads
+const sendInterval = 24 * time.Hour
+
+// sendTimeout bounds an upload, which runs as the program exits
+const sendTimeout = 3 * time.Second
+
+// Counts are the usage counts since the last upload
+type Counts struct {
+	Commands  map[string]int `json:"commands,omitempty"`
+	Providers map[string]int `json:"providers,omitempty"`
+	Errors    map[string]int `json:"errors,omitempty"`
+}
+
+// empty reports whether nothing was counted
+func (c Counts) empty() bool {
+	return len(c.Commands) == 0 && len(c.Providers) == 0 && len(c.Errors) == 0
+}
+
+// state is the telemetry file
+type state struct {
+	Enabled  bool      `json:"enabled"`
+	ID       string    `json:"id,omitempty"` // random, not derived from the user or machine
+	Since    time.Time `json:"since,omitempty"`
+	LastSent time.Time `json:"last_sent,omitempty"`
+	Counts   Counts    `json:"counts"`
+}
+
+// Payload is what is sent: the counts with the version and platform
+type Payload struct {
+	ID      string    `json:"id"`
+	Version string    `json:"version"`
+	OS      string    `json:"os"`
+	Arch    string    `json:"arch"`
+	Since   time.Time `json:"since"`
+	Counts
+}
+
+// Recorder counts usage while telemetry is enabled. A nil Recorder records
+// nothing.
+type Recorder struct {
+	path    string
+	mu      sync.Mutex
+	state   state
+	pending Counts // counted since the state was loaded
+}
+
+// Open loads the telemetry state stored at path
+func Open(path string) (*Recorder, error) {
+	st, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{path: path, state: st}, nil
+}
+
+// load reads the telemetry file, which may not exist yet
+func load(path string) (state, error) {
+	var st state
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, fmt.Errorf("invalid telemetry file %s: %w", path, err)
+	}
+	return st, nil
+}
+
+// DisabledByEnv reports whether the environment turns telemetry off,
+// overriding the setting: DO_NOT_TRACK=1 or AGENTIC_CODER_TELEMETRY=off
+func DisabledByEnv() bool {
+	if v := os.Getenv("DO_NOT_TRACK"); v != "" && v != "0" {
+		return true
+	}
+	switch strings.ToLower(os.Getenv("AGENTIC_CODER_TELEMETRY")) {
+	case "0", "off", "false", "no":
+		return true
+	}
+	return false
+}
+
+// Enabled reports whether the user opted in and the environment allows it
+func (r *Recorder) Enabled() bool {
+	if r == nil || DisabledByEnv() {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.Enabled
+}
+
+// SetEnabled turns telemetry on or off and saves the setting. Turning it
+// off discards the counts not yet sent; turning it on starts with a new
+// random ID.
+func (r *Recorder) SetEnabled(enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if enabled && !r.state.Enabled {
+		r.state.ID = newID()
+		r.state.Since = time.Now().UTC()
+	}
+	if !enabled {
+		r.state = state{}
+		r.pending = Counts{}
+	}
+	r.state.Enabled = enabled
+	return r.write()
+}
+
+// ID returns the anonymous ID sent with the metrics
+func (r *Recorder) ID() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.ID
+}
+
+// commandName matches what is counted as a command name
+var commandName = regexp.MustCompile(`^/?[a-z][a-z0-9-]{0,31}( [a-z][a-z0-9-]{0,31})?$`)
+
+// Command counts a use of a command, such as "changelog" or "/pin". Names
+// that do not look like commands are not counted.
+func (r *Recorder) Command(name string) {
+	if commandName.MatchString(name) {
+		r.count(func(c *Counts) *map[string]int { return &c.Commands }, name)
+	}
+}
+
+// Provider counts a run with a provider
+func (r *Recorder) Provider(name string) {
+	if commandName.MatchString(name) {
+		r.count(func(c *Counts) *map[string]int { return &c.Providers }, name)
+	}
+}
+
+// Error counts an error by its class; the message is not recorded
+func (r *Recorder) Error(err error) {
+	if err != nil {
+		r.count(func(c *Counts) *map[string]int { return &c.Errors }, ErrorClass(err))
+	}
+}
+
+// count increments a counter while telemetry is enabled
+func (r *Recorder) count(counter func(*Counts) *map[string]int, key string) {
+	if !r.Enabled() {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := counter(&r.pending)
+	if *m == nil {
+		*m = make(map[string]int)
+	}
+	(*m)[key]++
+}
+
+// add adds the counts of other to c
+func (c *Counts) add(other Counts) {
+	for _, pair := range []struct {
+		dst *map[string]int
+		src map[string]int
+	}{
+		{&c.Commands, other.Commands},
+		{&c.Providers, other.Providers},
+		{&c.Errors, other.Errors},
+	} {
+		for k, v := range pair.src {
+			if *pair.dst == nil {
+				*pair.dst = make(map[string]int)
+			}
+			(*pair.dst)[k] += v
+		}
+	}
+}
+
+// Payload returns what the next upload would send
+func (r *Recorder) Payload(version string) Payload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var counts Counts
+	counts.add(r.state.Counts)
+	counts.add(r.pending)
+	return Payload{
+		ID:      r.state.ID,
+		Version: version,
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Since:   r.state.Since,
+		Counts:  counts,
+	}
+}
+
+// Save adds the counts of this process to the file, keeping what other
+// sessions saved meanwhile. Nothing is saved once telemetry was turned off.
+func (r *Recorder) Save() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending.empty() {
+		return nil
+	}
+	st, err := load(r.path)
+	if err != nil {
+		return err
+	}
+	r.state = st
+	if st.Enabled {
+		r.state.Counts.add(r.pending)
+	}
+	r.pending = Counts{}
+	if !st.Enabled {
+		return nil
+	}
+	return r.write()
+}
+
+// write saves the state; the caller holds the lock
+func (r *Recorder) write() error {
+	data, err := json.MarshalIndent(r.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0600)
+}
+
+// Close sends the counts when an upload is due and saves the state
+func (r *Recorder) Close(ctx context.Context, version string) error {
+	if r == nil {
+		return nil
+	}
+	if err := r.Save(); err != nil {
+		return err
+	}
+	if r.Enabled() && Endpoint != "" {
+		return r.send(ctx, Endpoint, version)
+	}
+	return nil
+}
+
+// send uploads the counts to endpoint once per interval and resets them
+func (r *Recorder) send(ctx context.Context, endpoint, version string) error {
+	r.mu.Lock()
+	due := time.Since(r.state.LastSent) >= sendInterval && !r.state.Counts.empty()
+	r.mu.Unlock()
+	if !due {
+		return nil
+	}
+
+	data, err := json.Marshal(r.Payload(version))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("telemetry upload failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry upload failed: %s", resp.Status)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UTC()
+	r.state.Counts = Counts{}
+	r.state.Since = now
+	r.state.LastSent = now
+	return r.write()
+}
+
+// ErrorClass returns the class of an error, such as "timeout" or
+// "rate_limit", without any of its message
+func ErrorClass(err error) string {
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, class := range []struct {
+		name     string
+		keywords []string
+	}{
+		{"auth", []string{"401", "403", "unauthorized", "forbidden", "api key", "authentication"}},
+		{"rate_limit", []string{"429", "rate limit", "too many requests"}},
+		{"overloaded", []string{"529", "overloaded", "503", "502", "500 internal"}},
+		{"timeout", []string{"timeout", "timed out", "deadline"}},
+		{"network", []string{"connection refused", "no such host", "connection reset", "eof"}},
+		{"context_length", []string{"context length", "too long", "maximum context", "prompt is too long"}},
+		{"budget", []string{"budget"}},
+		{"max_iterations", []string{"max iterations"}},
+		{"stuck", []string{"appears to be stuck"}},
+		{"permission", []string{"permission denied", "not approved"}},
+	} {
+		for _, keyword := range class.keywords {
+			if strings.Contains(msg, keyword) {
+				return class.name
+			}
+		}
+	}
+	return "other"
+}
+
+// newID returns a random anonymous ID
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
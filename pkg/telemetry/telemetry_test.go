@@ -0,0 +1,188 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openTemp(t *testing.T) (*Recorder, string) {
+	t.Helper()
+	t.Setenv("DO_NOT_TRACK", "")
+	t.Setenv("AGENTIC_CODER_TELEMETRY", "")
+	path := filepath.Join(t.TempDir(), "telemetry.json")
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, path
+}
+
+func TestDisabledByDefault(t *testing.T) {
+	r, path := openTemp(t)
+
+	r.Command("/pin")
+	r.Error(errors.New("boom"))
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if r.Enabled() {
+		t.Error("telemetry must be off until the user turns it on")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("nothing should be written while telemetry is off")
+	}
+
+	var nilRecorder *Recorder
+	nilRecorder.Command("/pin")
+	if nilRecorder.Enabled() || nilRecorder.Close(context.Background(), "0.1.0") != nil {
+		t.Error("a nil recorder should record nothing")
+	}
+}
+
+func TestRecordAndMerge(t *testing.T) {
+	r, path := openTemp(t)
+	if err := r.SetEnabled(true); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.ID()) != 32 {
+		t.Errorf("expected a random ID, got %q", r.ID())
+	}
+
+	r.Command("/pin")
+	r.Command("/pin")
+	r.Command("/Users/me/secret project") // not a command name
+	r.Provider("claude")
+	r.Error(fmt.Errorf("request failed: 429 Too Many Requests for key sk-123"))
+
+	// Another session saves its counts meanwhile
+	other, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Command("changelog")
+	if err := other.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		t.Fatal(err)
+	}
+	c := st.Counts
+	if c.Commands["/pin"] != 2 || c.Commands["changelog"] != 1 || len(c.Commands) != 2 {
+		t.Errorf("unexpected commands: %v", c.Commands)
+	}
+	if c.Providers["claude"] != 1 || c.Errors["rate_limit"] != 1 {
+		t.Errorf("unexpected counts: %+v", c)
+	}
+	if strings.Contains(string(data), "sk-123") || strings.Contains(string(data), "secret") {
+		t.Error("the telemetry file must not contain messages or paths")
+	}
+
+	if err := r.SetEnabled(false); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Enabled() || !reopened.Payload("0.1.0").empty() {
+		t.Error("turning telemetry off should discard the counts")
+	}
+}
+
+func TestEnvironmentOverrides(t *testing.T) {
+	r, _ := openTemp(t)
+	if err := r.SetEnabled(true); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("DO_NOT_TRACK", "1")
+	if r.Enabled() {
+		t.Error("DO_NOT_TRACK should turn telemetry off")
+	}
+	t.Setenv("DO_NOT_TRACK", "")
+	t.Setenv("AGENTIC_CODER_TELEMETRY", "off")
+	if r.Enabled() {
+		t.Error("AGENTIC_CODER_TELEMETRY=off should turn telemetry off")
+	}
+}
+
+func TestSend(t *testing.T) {
+	var received Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := json.NewDecoder(req.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	r, _ := openTemp(t)
+	if err := r.SetEnabled(true); err != nil {
+		t.Fatal(err)
+	}
+	r.Command("release")
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.send(context.Background(), server.URL, "0.2.0"); err != nil {
+		t.Fatal(err)
+	}
+	if received.ID != r.ID() || received.Version != "0.2.0" || received.Commands["release"] != 1 {
+		t.Errorf("unexpected payload: %+v", received)
+	}
+	if p := r.Payload("0.2.0"); !p.empty() {
+		t.Errorf("expected the counts to reset after sending, got %+v", p.Counts)
+	}
+
+	// Not due again within the interval
+	received = Payload{}
+	r.Command("release")
+	r.Save()
+	if err := r.send(context.Background(), server.URL, "0.2.0"); err != nil {
+		t.Fatal(err)
+	}
+	if received.ID != "" {
+		t.Error("expected no upload within the send interval")
+	}
+	if time.Since(r.state.LastSent) > time.Minute {
+		t.Error("expected the upload time to be saved")
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{context.Canceled, "canceled"},
+		{fmt.Errorf("run: %w", context.DeadlineExceeded), "timeout"},
+		{errors.New("API error 401: invalid x-api-key"), "auth"},
+		{errors.New("529 overloaded_error"), "overloaded"},
+		{errors.New("dial tcp: lookup api.example.com: no such host"), "network"},
+		{errors.New("max iterations (50) exceeded"), "max_iterations"},
+		{errors.New("something odd"), "other"},
+	}
+	for _, tt := range tests {
+		if got := ErrorClass(tt.err); got != tt.want {
+			t.Errorf("ErrorClass(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -19,6 +19,13 @@ type BashTool struct {
 	ShellPath      string
 	MaxOutputLen   int
 	DefaultTimeout time.Duration
+
+	// Supervisor limits the processes commands may spawn (nil = unlimited)
+	Supervisor *Supervisor
+	// Shells runs commands with run_in_background (nil = run in the foreground)
+	Shells *ShellManager
+	// Workspace is where commands run (nil = the local machine)
+	Workspace Workspace
 }
 
 // BashInput represents the input for Bash tool
@@ -61,7 +68,9 @@ func (b *BashTool) Description() string {
 - Always quote file paths that contain spaces
 - The command argument is required
 - You can specify an optional timeout in milliseconds (max 600000ms / 10 minutes)
-- If the output exceeds 30000 characters, it will be truncated`
+- If the output exceeds 30000 characters, it will be truncated
+- Set run_in_background to start a long-running command and get a shell ID; read its output with BashOutput and stop it with KillShell
+- The number of background shells, processes and the CPU time commands may use are limited per session`
 }
 
 func (b *BashTool) InputSchema() json.RawMessage {
@@ -112,6 +121,10 @@ func (b *BashTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 		return nil, err
 	}
 
+	if params.RunInBackground && b.Shells != nil {
+		return b.startBackground(params, input)
+	}
+
 	// Set timeout
 	timeout := b.DefaultTimeout
 	if params.Timeout > 0 {
@@ -121,24 +134,27 @@ func (b *BashTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	// Create command
-	cmd := exec.CommandContext(ctx, b.ShellPath, "-c", params.Command)
-
-	// Set working directory
-	if input.Context != nil && input.Context.CWD != "" {
-		cmd.Dir = input.Context.CWD
+	// Create command in the working directory
+	dir := ""
+	if input.Context != nil {
+		dir = input.Context.CWD
 	}
+	cmd := workspaceOr(b.Workspace).Command(ctx, dir, b.ShellPath, "-c", params.Command)
 
 	// Capture output
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	// Set environment with filtered sensitive variables
-	cmd.Env = filterSensitiveEnvVars(os.Environ())
+	// Pass only the variables the environment policy allows
+	setCommandEnv(cmd)
 
 	// Run command
-	err = cmd.Run()
+	proc, wait, err := b.startForeground(cmd, params)
+	if err != nil {
+		return &tool.Output{Content: fmt.Sprintf("Command not started: %v", err), IsError: true}, nil
+	}
+	err = wait()
 
 	// Get exit code
 	exitCode := 0
@@ -182,6 +198,9 @@ func (b *BashTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 	if strings.TrimSpace(content) == "" {
 		content = "(no output)"
 	}
+	if reason := proc.KillReason(); reason != "" {
+		content += fmt.Sprintf("\n[%s]", reason)
+	}
 
 	// Write audit log
 	logBashExecution(params.Command, exitCode, interrupted)
@@ -198,39 +217,50 @@ func (b *BashTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 	}, nil
 }
 
-// filterSensitiveEnvVars removes sensitive environment variables from the environment
-// to prevent API keys and secrets from leaking to executed commands
-func filterSensitiveEnvVars(env []string) []string {
-	sensitivePatterns := []string{
-		"API_KEY",
-		"SECRET",
-		"TOKEN",
-		"PASSWORD",
-		"CREDENTIALS",
-		"ANTHROPIC_API_KEY",
-		"OPENAI_API_KEY",
-		"GOOGLE_API_KEY",
-		"DEEPSEEK_API_KEY",
-	}
-
-	filtered := make([]string, 0, len(env))
-	for _, envVar := range env {
-		keep := true
-		upperVar := strings.ToUpper(envVar)
-
-		for _, pattern := range sensitivePatterns {
-			if strings.Contains(upperVar, pattern) {
-				keep = false
-				break
-			}
+// startForeground starts a command and returns the function that waits for
+// it. With a shell manager, the command is tracked as a shell while it runs.
+func (b *BashTool) startForeground(cmd *exec.Cmd, params *BashInput) (*Process, func() error, error) {
+	if b.Shells == nil {
+		proc, err := b.Supervisor.Start(cmd, params.Command, false)
+		if err != nil {
+			return nil, nil, err
 		}
+		return proc, proc.Wait, nil
+	}
+	shell, err := b.Shells.StartForeground(cmd, params.Command, params.Description)
+	if err != nil {
+		return nil, nil, err
+	}
+	return shell.proc, func() error { return b.Shells.Wait(shell) }, nil
+}
 
-		if keep {
-			filtered = append(filtered, envVar)
-		}
+// startBackground starts a command in a background shell and returns its ID
+func (b *BashTool) startBackground(params *BashInput, input *tool.Input) (*tool.Output, error) {
+	cwd := ""
+	if input.Context != nil {
+		cwd = input.Context.CWD
+	}
+	shell, err := b.Shells.StartBackground(params.Command, params.Description, cwd)
+	if err != nil {
+		return &tool.Output{Content: fmt.Sprintf("Command not started: %v", err), IsError: true}, nil
+	}
+	logBashExecution(params.Command, 0, false)
+
+	content := fmt.Sprintf("Started background shell '%s'. Read its output with BashOutput and stop it with KillShell.", shell.ID)
+	switch {
+	case shell.PaneError != nil:
+		content += fmt.Sprintf("\nThe output pane could not be opened: %v", shell.PaneError)
+	case shell.LogPath != "":
+		content += "\nThe user can follow its output in a terminal pane."
 	}
 
-	return filtered
+	return &tool.Output{
+		Content: content,
+		Metadata: map[string]interface{}{
+			"shell_id": shell.ID,
+			"command":  params.Command,
+		},
+	}, nil
 }
 
 // logBashExecution writes an audit log entry for bash command execution
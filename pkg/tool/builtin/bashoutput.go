@@ -0,0 +1,102 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// BashOutputTool reads the output of a background shell
+type BashOutputTool struct {
+	manager *ShellManager
+}
+
+// NewBashOutputTool creates a new bash output tool
+func NewBashOutputTool(manager *ShellManager) *BashOutputTool {
+	return &BashOutputTool{
+		manager: manager,
+	}
+}
+
+func (t *BashOutputTool) Name() string {
+	return "BashOutput"
+}
+
+func (t *BashOutputTool) Description() string {
+	return `Reads the output of a background bash shell started with run_in_background.
+
+- Takes a shell_id parameter identifying the shell
+- Returns the shell's state, exit code and the latest output
+- Use this to check on long-running commands instead of waiting on them`
+}
+
+func (t *BashOutputTool) InputSchema() json.RawMessage {
+	return bashOutputSchema
+}
+
+type bashOutputParams struct {
+	ShellID string `json:"shell_id" desc:"The ID of the background shell to read"`
+}
+
+var bashOutputSchema = tool.SchemaFor[bashOutputParams]()
+
+// bashOutputMaxLen is how much of the latest output is returned
+const bashOutputMaxLen = 30000
+
+func (t *BashOutputTool) Validate(input *tool.Input) error {
+	params, err := tool.ParamsTo[bashOutputParams](input.Params)
+	if err != nil {
+		return err
+	}
+
+	if params.ShellID == "" {
+		return fmt.Errorf("shell_id is required")
+	}
+
+	return nil
+}
+
+func (t *BashOutputTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+	params, err := tool.ParamsTo[bashOutputParams](input.Params)
+	if err != nil {
+		return &tool.Output{Content: fmt.Sprintf("Error parsing parameters: %v", err), IsError: true}, nil
+	}
+
+	if t.manager == nil {
+		return &tool.Output{Content: "Shell manager not configured", IsError: true}, nil
+	}
+
+	shell := t.manager.Get(params.ShellID)
+	if shell == nil {
+		return &tool.Output{
+			Content: fmt.Sprintf("Shell '%s' not found. Use /ps to list running shells.", params.ShellID),
+			IsError: true,
+		}, nil
+	}
+
+	output, state, err := t.manager.GetOutput(params.ShellID, bashOutputMaxLen)
+	if err != nil {
+		return &tool.Output{Content: err.Error(), IsError: true}, nil
+	}
+	if output == "" {
+		output = "(no output yet)"
+	}
+
+	status := string(state)
+	if state != ShellStateRunning {
+		shell.mu.Lock()
+		status = fmt.Sprintf("%s, exit code %d", state, shell.ExitCode)
+		shell.mu.Unlock()
+	}
+
+	return &tool.Output{
+		Content: fmt.Sprintf("Shell '%s' (%s) after %s:\n%s", params.ShellID, status, shell.Duration().Round(100*time.Millisecond), output),
+		Metadata: map[string]interface{}{
+			"shell_id": params.ShellID,
+			"state":    string(state),
+		},
+	}, nil
+}
@@ -0,0 +1,525 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+const (
+	defaultBenchCount = 5
+	maxBenchCount     = 50
+)
+
+// baselineNamePattern restricts baseline names to safe file names
+var baselineNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Benchmark holds the samples of one benchmark, by unit
+type Benchmark struct {
+	Package string               `json:"package,omitempty"`
+	Name    string               `json:"name"`
+	Metrics map[string][]float64 `json:"metrics"` // e.g. "ns/op", "B/op", "s"
+}
+
+// key identifies a benchmark across runs
+func (b *Benchmark) key() string {
+	if b.Package == "" {
+		return b.Name
+	}
+	return b.Package + " " + b.Name
+}
+
+// BenchBaseline is a saved benchmark run to compare later runs against
+type BenchBaseline struct {
+	Name       string       `json:"name"`
+	Command    string       `json:"command"`
+	CreatedAt  time.Time    `json:"created_at"`
+	Benchmarks []*Benchmark `json:"benchmarks"`
+}
+
+// BenchTool runs benchmarks and compares them with saved baselines
+type BenchTool struct {
+	// baselineDir returns the directory holding a project's baselines
+	baselineDir func(projectPath string) (string, error)
+}
+
+// BenchInput represents the input for the Bench tool
+type BenchInput struct {
+	Bench     string `json:"bench,omitempty"`
+	Package   string `json:"package,omitempty"`
+	Command   string `json:"command,omitempty"`
+	Count     int    `json:"count,omitempty"`
+	SaveAs    string `json:"save_as,omitempty"`
+	CompareTo string `json:"compare_to,omitempty"`
+}
+
+// NewBenchTool creates a new Bench tool storing baselines under the app directory
+func NewBenchTool() *BenchTool {
+	return &BenchTool{baselineDir: config.GetProjectBenchmarksDir}
+}
+
+func (t *BenchTool) Name() string {
+	return "Bench"
+}
+
+func (t *BenchTool) Description() string {
+	return `Runs benchmarks, saves named baselines, and reports changes against a baseline.
+- Without command, runs Go benchmarks: go test -run=^$ -bench=<bench> -count=<count> <package>
+- With command, times an arbitrary shell command with hyperfine
+- save_as stores the results as a named baseline for this project
+- compare_to reports the change of each metric against a saved baseline, marking changes within run-to-run noise
+- Save a baseline before optimizing, then compare after each change to verify improvements numerically`
+}
+
+func (t *BenchTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"bench": {
+				"type": "string",
+				"description": "Regular expression selecting Go benchmarks (default \".\")"
+			},
+			"package": {
+				"type": "string",
+				"description": "Go package pattern to benchmark (default \"./...\")"
+			},
+			"command": {
+				"type": "string",
+				"description": "A shell command to time with hyperfine instead of running Go benchmarks"
+			},
+			"count": {
+				"type": "integer",
+				"minimum": 1,
+				"maximum": 50,
+				"description": "Number of runs of each benchmark (default 5)"
+			},
+			"save_as": {
+				"type": "string",
+				"description": "Save the results as a baseline with this name"
+			},
+			"compare_to": {
+				"type": "string",
+				"description": "Compare the results with the baseline of this name"
+			}
+		}
+	}`)
+}
+
+func (t *BenchTool) Validate(input *tool.Input) error {
+	params, err := tool.ParamsTo[BenchInput](input.Params)
+	if err != nil {
+		return err
+	}
+
+	if params.Count < 0 || params.Count > maxBenchCount {
+		return fmt.Errorf("count must be between 1 and %d", maxBenchCount)
+	}
+	if strings.HasPrefix(params.Package, "-") || strings.ContainsAny(params.Package, " \t\n") {
+		return fmt.Errorf("invalid package: %q", params.Package)
+	}
+	if params.Bench != "" {
+		if _, err := regexp.Compile(params.Bench); err != nil {
+			return fmt.Errorf("invalid bench pattern: %w", err)
+		}
+	}
+	for _, name := range []string{params.SaveAs, params.CompareTo} {
+		if name != "" && !baselineNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid baseline name: %q (use letters, digits, '.', '_', '-')", name)
+		}
+	}
+
+	return nil
+}
+
+func (t *BenchTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+	params, err := tool.ParamsTo[BenchInput](input.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := "."
+	if input.Context != nil && input.Context.CWD != "" {
+		dir = input.Context.CWD
+	}
+	count := params.Count
+	if count == 0 {
+		count = defaultBenchCount
+	}
+
+	// Load the baseline first, so a typo fails before a long run
+	var baseline *BenchBaseline
+	if params.CompareTo != "" {
+		baseline, err = t.loadBaseline(dir, params.CompareTo)
+		if err != nil {
+			return &tool.Output{Content: "Error: " + err.Error(), IsError: true}, nil
+		}
+	}
+
+	var run *BenchBaseline
+	var out string
+	if params.Command != "" {
+		run, out, err = runHyperfine(ctx, dir, params.Command, count)
+	} else {
+		run, out, err = runGoBench(ctx, dir, params.Bench, params.Package, count)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return &tool.Output{Content: fmt.Sprintf("Error: %v\n%s", err, lastLines(out, 40)), IsError: true}, nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "$ %s\n\n", run.Command)
+	b.WriteString(formatBenchmarks(run.Benchmarks))
+
+	if baseline != nil {
+		fmt.Fprintf(&b, "\n\nCompared with baseline %q (%s, saved %s):\n", baseline.Name, baseline.Command, baseline.CreatedAt.Format("2006-01-02 15:04"))
+		b.WriteString(compareBenchmarks(baseline.Benchmarks, run.Benchmarks))
+	}
+
+	if params.SaveAs != "" {
+		run.Name = params.SaveAs
+		path, err := t.saveBaseline(dir, run)
+		if err != nil {
+			fmt.Fprintf(&b, "\n\nWarning: failed to save baseline: %v", err)
+		} else {
+			fmt.Fprintf(&b, "\n\nSaved baseline %q to %s", params.SaveAs, path)
+		}
+	}
+
+	return &tool.Output{
+		Content:  b.String(),
+		Metadata: run,
+	}, nil
+}
+
+// baselinePath returns the file of a named baseline for the project in dir
+func (t *BenchTool) baselinePath(dir, name string) (string, error) {
+	baseDir, err := t.baselineDir(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(baseDir, name+".json"), nil
+}
+
+// loadBaseline reads a named baseline
+func (t *BenchTool) loadBaseline(dir, name string) (*BenchBaseline, error) {
+	path, err := t.baselinePath(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no baseline named %q; run with save_as first", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var baseline BenchBaseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline: %w", err)
+	}
+	return &baseline, nil
+}
+
+// saveBaseline writes a baseline and returns its path
+func (t *BenchTool) saveBaseline(dir string, baseline *BenchBaseline) (string, error) {
+	path, err := t.baselinePath(dir, baseline.Name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, data, 0644)
+}
+
+// runGoBench runs Go benchmarks and parses their results
+func runGoBench(ctx context.Context, dir, bench, pkg string, count int) (*BenchBaseline, string, error) {
+	if bench == "" {
+		bench = "."
+	}
+	if pkg == "" {
+		pkg = "./..."
+	}
+	args := []string{"go", "test", "-run=^$", "-bench=" + bench, "-benchmem", "-count=" + strconv.Itoa(count), pkg}
+
+	out, exitCode, err := runProjectCommand(ctx, dir, args)
+	if err != nil {
+		return nil, out, err
+	}
+	if exitCode != 0 {
+		return nil, out, fmt.Errorf("go test exited with code %d", exitCode)
+	}
+
+	benchmarks := parseGoBench(out)
+	if len(benchmarks) == 0 {
+		return nil, out, fmt.Errorf("no benchmarks matched %q in %s", bench, pkg)
+	}
+	return &BenchBaseline{Command: strings.Join(args, " "), CreatedAt: time.Now(), Benchmarks: benchmarks}, out, nil
+}
+
+// procsSuffix is the GOMAXPROCS suffix of a benchmark name
+var procsSuffix = regexp.MustCompile(`-\d+$`)
+
+// parseGoBench parses go test -bench output, collecting the samples of
+// repeated runs in order of first appearance
+func parseGoBench(out string) []*Benchmark {
+	var benchmarks []*Benchmark
+	byKey := make(map[string]*Benchmark)
+	pkg := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if p, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(p)
+			continue
+		}
+		fields := strings.Fields(line)
+		// BenchmarkName-8  1000  123 ns/op  [value unit]...
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") || len(fields)%2 != 0 {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+
+		bench := &Benchmark{Package: pkg, Name: procsSuffix.ReplaceAllString(fields[0], "")}
+		if existing, ok := byKey[bench.key()]; ok {
+			bench = existing
+		} else {
+			bench.Metrics = make(map[string][]float64)
+			byKey[bench.key()] = bench
+			benchmarks = append(benchmarks, bench)
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				continue
+			}
+			bench.Metrics[fields[i+1]] = append(bench.Metrics[fields[i+1]], value)
+		}
+	}
+	return benchmarks
+}
+
+// runHyperfine times a shell command with hyperfine
+func runHyperfine(ctx context.Context, dir, command string, count int) (*BenchBaseline, string, error) {
+	if _, err := exec.LookPath("hyperfine"); err != nil {
+		return nil, "", fmt.Errorf("hyperfine is not installed; see https://github.com/sharkdp/hyperfine")
+	}
+
+	report, err := os.CreateTemp("", "hyperfine-*.json")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create report file: %w", err)
+	}
+	report.Close()
+	defer os.Remove(report.Name())
+
+	args := []string{"hyperfine", "--style=basic", "--runs=" + strconv.Itoa(max(count, 2)), "--export-json=" + report.Name(), command}
+	out, exitCode, err := runProjectCommand(ctx, dir, args)
+	if err != nil {
+		return nil, out, err
+	}
+	if exitCode != 0 {
+		return nil, out, fmt.Errorf("hyperfine exited with code %d", exitCode)
+	}
+
+	data, err := os.ReadFile(report.Name())
+	if err != nil {
+		return nil, out, fmt.Errorf("failed to read hyperfine report: %w", err)
+	}
+	benchmarks, err := parseHyperfine(data)
+	if err != nil {
+		return nil, out, err
+	}
+	return &BenchBaseline{Command: fmt.Sprintf("hyperfine %q", command), CreatedAt: time.Now(), Benchmarks: benchmarks}, out, nil
+}
+
+// parseHyperfine reads the run times of a hyperfine JSON report
+func parseHyperfine(data []byte) ([]*Benchmark, error) {
+	var report struct {
+		Results []struct {
+			Command string    `json:"command"`
+			Times   []float64 `json:"times"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse hyperfine report: %w", err)
+	}
+
+	var benchmarks []*Benchmark
+	for _, r := range report.Results {
+		benchmarks = append(benchmarks, &Benchmark{Name: r.Command, Metrics: map[string][]float64{"s": r.Times}})
+	}
+	return benchmarks, nil
+}
+
+// formatBenchmarks lists the median of each metric with its spread
+func formatBenchmarks(benchmarks []*Benchmark) string {
+	var b strings.Builder
+	pkg := ""
+	for _, bench := range benchmarks {
+		if bench.Package != pkg {
+			pkg = bench.Package
+			fmt.Fprintf(&b, "%s\n", pkg)
+		}
+		var parts []string
+		for _, unit := range sortedUnits(bench.Metrics) {
+			samples := bench.Metrics[unit]
+			part := fmt.Sprintf("%s %s", formatBenchValue(median(samples)), unit)
+			if s := spread(samples); s >= 0.005 {
+				part += fmt.Sprintf(" ±%.0f%%", s*100)
+			}
+			parts = append(parts, part)
+		}
+		fmt.Fprintf(&b, "  %s  %s\n", bench.Name, strings.Join(parts, "  "))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// compareBenchmarks reports the change of each metric between two runs.
+// Changes smaller than the run-to-run spread are marked as noise.
+func compareBenchmarks(old, new []*Benchmark) string {
+	oldByKey := make(map[string]*Benchmark)
+	for _, bench := range old {
+		oldByKey[bench.key()] = bench
+	}
+
+	var b strings.Builder
+	matched := 0
+	for _, bench := range new {
+		prev, ok := oldByKey[bench.key()]
+		if !ok {
+			continue
+		}
+		matched++
+		for _, unit := range sortedUnits(bench.Metrics) {
+			before, ok := prev.Metrics[unit]
+			if !ok || len(before) == 0 {
+				continue
+			}
+			oldValue, newValue := median(before), median(bench.Metrics[unit])
+			fmt.Fprintf(&b, "  %s  %s: %s → %s  %s\n", bench.Name, unit,
+				formatBenchValue(oldValue), formatBenchValue(newValue),
+				describeDelta(unit, oldValue, newValue, max(spread(before), spread(bench.Metrics[unit]))))
+		}
+	}
+	if matched == 0 {
+		return "  No benchmarks in common with the baseline."
+	}
+	if missing := len(old) - matched; missing > 0 {
+		fmt.Fprintf(&b, "  (%d baseline benchmarks were not run)\n", missing)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// describeDelta describes a metric change as a percentage and a verdict
+func describeDelta(unit string, oldValue, newValue, noise float64) string {
+	if oldValue == 0 {
+		if newValue == 0 {
+			return "no change"
+		}
+		return "new"
+	}
+	delta := (newValue - oldValue) / oldValue
+	if math.Abs(delta) < 0.005 {
+		return "no change"
+	}
+	text := fmt.Sprintf("%+.1f%%", delta*100)
+	if math.Abs(delta) <= noise {
+		return text + " (within noise)"
+	}
+
+	// Throughput units such as MB/s improve upward; times and counts downward
+	better := delta < 0
+	if strings.HasSuffix(unit, "/s") {
+		better = !better
+	}
+	if better {
+		return text + " better"
+	}
+	return text + " worse"
+}
+
+// sortedUnits returns the units of metrics with time first
+func sortedUnits(metrics map[string][]float64) []string {
+	units := make([]string, 0, len(metrics))
+	for unit := range metrics {
+		units = append(units, unit)
+	}
+	rank := map[string]int{"s": 0, "ns/op": 0, "B/op": 1, "allocs/op": 2}
+	sort.Slice(units, func(i, j int) bool {
+		ri, ok := rank[units[i]]
+		if !ok {
+			ri = 3
+		}
+		rj, ok := rank[units[j]]
+		if !ok {
+			rj = 3
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		return units[i] < units[j]
+	})
+	return units
+}
+
+// median returns the median of samples
+func median(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// spread returns the largest deviation of samples from their median,
+// relative to the median
+func spread(samples []float64) float64 {
+	m := median(samples)
+	if m == 0 {
+		return 0
+	}
+	deviation := 0.0
+	for _, s := range samples {
+		deviation = max(deviation, math.Abs(s-m))
+	}
+	return deviation / m
+}
+
+// formatBenchValue formats a metric value with about four significant digits
+func formatBenchValue(v float64) string {
+	switch {
+	case v >= 1000 || v == math.Trunc(v):
+		return strconv.FormatFloat(math.Round(v), 'f', -1, 64)
+	case v >= 1:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
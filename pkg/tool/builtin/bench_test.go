@@ -0,0 +1,141 @@
+package builtin
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+const goBenchOutput = `goos: linux
+goarch: amd64
+pkg: example.com/calc
+cpu: Some CPU
+BenchmarkAbs-8   	100000000	        10.00 ns/op	       0 B/op	       0 allocs/op
+BenchmarkAbs-8   	100000000	        12.00 ns/op	       0 B/op	       0 allocs/op
+BenchmarkAbs-8   	100000000	        11.00 ns/op	       0 B/op	       0 allocs/op
+BenchmarkCopy/small-8 	 5000000	       250.0 ns/op	  400.00 MB/s	     128 B/op	       1 allocs/op
+PASS
+ok  	example.com/calc	3.2s
+`
+
+func TestParseGoBench(t *testing.T) {
+	got := parseGoBench(goBenchOutput)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 benchmarks, got %d", len(got))
+	}
+
+	abs := got[0]
+	if abs.Package != "example.com/calc" || abs.Name != "BenchmarkAbs" {
+		t.Errorf("unexpected benchmark: %s %s", abs.Package, abs.Name)
+	}
+	if want := []float64{10, 12, 11}; !reflect.DeepEqual(abs.Metrics["ns/op"], want) {
+		t.Errorf("ns/op = %v, want %v", abs.Metrics["ns/op"], want)
+	}
+	if got[1].Name != "BenchmarkCopy/small" || got[1].Metrics["MB/s"][0] != 400 {
+		t.Errorf("unexpected benchmark: %+v", got[1])
+	}
+
+	formatted := formatBenchmarks(got)
+	for _, want := range []string{
+		"example.com/calc\n  BenchmarkAbs  11 ns/op ±9%  0 B/op  0 allocs/op",
+		"BenchmarkCopy/small  250 ns/op  128 B/op  1 allocs/op  400 MB/s",
+	} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("expected %q in:\n%s", want, formatted)
+		}
+	}
+}
+
+func TestParseHyperfine(t *testing.T) {
+	got, err := parseHyperfine([]byte(`{"results": [{"command": "make build", "mean": 1.5, "times": [1.4, 1.5, 1.6]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "make build" || median(got[0].Metrics["s"]) != 1.5 {
+		t.Errorf("unexpected benchmarks: %+v", got)
+	}
+}
+
+func TestCompareBenchmarks(t *testing.T) {
+	old := []*Benchmark{
+		{Name: "BenchmarkFast", Metrics: map[string][]float64{"ns/op": {100, 101, 99}, "MB/s": {50}}},
+		{Name: "BenchmarkNoisy", Metrics: map[string][]float64{"ns/op": {100, 130, 80}}},
+		{Name: "BenchmarkRemoved", Metrics: map[string][]float64{"ns/op": {1}}},
+	}
+	new := []*Benchmark{
+		{Name: "BenchmarkFast", Metrics: map[string][]float64{"ns/op": {80, 81, 79}, "MB/s": {60}}},
+		{Name: "BenchmarkNoisy", Metrics: map[string][]float64{"ns/op": {110, 112, 108}}},
+		{Name: "BenchmarkAdded", Metrics: map[string][]float64{"ns/op": {5}}},
+	}
+
+	got := compareBenchmarks(old, new)
+	for _, want := range []string{
+		"BenchmarkFast  ns/op: 100 → 80  -20.0% better",
+		"BenchmarkFast  MB/s: 50 → 60  +20.0% better",
+		"BenchmarkNoisy  ns/op: 100 → 110  +10.0% (within noise)",
+		"(1 baseline benchmarks were not run)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "BenchmarkAdded") {
+		t.Errorf("benchmarks missing from the baseline should not be compared:\n%s", got)
+	}
+
+	if got := describeDelta("ns/op", 100, 150, 0.01); got != "+50.0% worse" {
+		t.Errorf("describeDelta() = %q", got)
+	}
+}
+
+func TestBenchBaselines(t *testing.T) {
+	dir := t.TempDir()
+	bench := &BenchTool{baselineDir: func(string) (string, error) { return dir, nil }}
+
+	saved := &BenchBaseline{
+		Name:       "before",
+		Command:    "go test -bench=.",
+		CreatedAt:  time.Now().Truncate(time.Second),
+		Benchmarks: parseGoBench(goBenchOutput),
+	}
+	if _, err := bench.saveBaseline("/project", saved); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := bench.loadBaseline("/project", "before")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.CreatedAt.Equal(saved.CreatedAt) || !reflect.DeepEqual(loaded.Benchmarks, saved.Benchmarks) {
+		t.Errorf("loaded baseline differs: %+v", loaded)
+	}
+
+	if _, err := bench.loadBaseline("/project", "after"); err == nil || !strings.Contains(err.Error(), "save_as") {
+		t.Errorf("expected missing baseline error, got %v", err)
+	}
+}
+
+func TestBenchValidate(t *testing.T) {
+	bench := NewBenchTool()
+	tests := []struct {
+		params  map[string]interface{}
+		wantErr bool
+	}{
+		{map[string]interface{}{}, false},
+		{map[string]interface{}{"bench": "Abs$", "save_as": "v1.2_fast"}, false},
+		{map[string]interface{}{"compare_to": "../secrets"}, true},
+		{map[string]interface{}{"bench": "("}, true},
+		{map[string]interface{}{"count": 100}, true},
+		{map[string]interface{}{"package": "-exec=evil"}, true},
+	}
+
+	for _, tt := range tests {
+		err := bench.Validate(&tool.Input{Params: tt.params})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%v) error = %v, wantErr %v", tt.params, err, tt.wantErr)
+		}
+	}
+}
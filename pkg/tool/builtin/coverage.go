@@ -0,0 +1,484 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+const (
+	// maxCoverageFunctions is the number of least covered functions reported
+	maxCoverageFunctions = 40
+
+	// maxUncoveredRanges is the number of uncovered line ranges listed per function
+	maxUncoveredRanges = 8
+)
+
+// LineRange is an inclusive range of 1-based lines
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// FunctionCoverage is the statement coverage of one function
+type FunctionCoverage struct {
+	Name       string      `json:"name"`
+	StartLine  int         `json:"start_line"`
+	EndLine    int         `json:"end_line"`
+	Statements int         `json:"statements"`
+	Covered    int         `json:"covered"`
+	Uncovered  []LineRange `json:"uncovered,omitempty"`
+}
+
+// FileCoverage is the statement coverage of one source file
+type FileCoverage struct {
+	Path       string             `json:"path"`
+	Statements int                `json:"statements"`
+	Covered    int                `json:"covered"`
+	Functions  []FunctionCoverage `json:"functions,omitempty"`
+}
+
+// CoverageReport is the statement coverage of a test run
+type CoverageReport struct {
+	Command    string         `json:"command"`
+	Statements int            `json:"statements"`
+	Covered    int            `json:"covered"`
+	Files      []FileCoverage `json:"files"`
+}
+
+// Percent returns the share of covered statements
+func (r *CoverageReport) Percent() float64 {
+	return percent(r.Covered, r.Statements)
+}
+
+// percent returns covered as a percentage of total
+func percent(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(covered) * 100 / float64(total)
+}
+
+// coverBlock is a run of statements with one execution count
+type coverBlock struct {
+	StartLine int
+	EndLine   int
+	Stmts     int
+	Count     int
+}
+
+// CoverageTool runs the tests with coverage and reports untested code
+type CoverageTool struct{}
+
+// CoverageInput represents the input for the Coverage tool
+type CoverageInput struct {
+	Target string `json:"target,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+// NewCoverageTool creates a new Coverage tool
+func NewCoverageTool() *CoverageTool {
+	return &CoverageTool{}
+}
+
+func (t *CoverageTool) Name() string {
+	return "Coverage"
+}
+
+func (t *CoverageTool) Description() string {
+	return `Runs the project's tests with coverage and reports the least covered functions with their uncovered lines.
+- Go projects run go test -coverprofile; Python projects run pytest with pytest-cov
+- target is a Go package pattern (default ./...) or a Python package or directory
+- Use the reported functions and line ranges to decide which tests to write`
+}
+
+func (t *CoverageTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"target": {
+				"type": "string",
+				"description": "The packages to measure, e.g. \"./pkg/engine/...\" for Go or \"mypkg\" for Python (default: the whole project)"
+			},
+			"path": {
+				"type": "string",
+				"description": "The project directory. If not specified, the current working directory will be used."
+			}
+		}
+	}`)
+}
+
+func (t *CoverageTool) Validate(input *tool.Input) error {
+	params, err := tool.ParamsTo[CoverageInput](input.Params)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(params.Target, "-") || strings.ContainsAny(params.Target, " \t\n") {
+		return fmt.Errorf("invalid target: %q", params.Target)
+	}
+
+	return nil
+}
+
+func (t *CoverageTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+	params, err := tool.ParamsTo[CoverageInput](input.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := params.Path
+	if dir == "" && input.Context != nil {
+		dir = input.Context.CWD
+	}
+	if dir == "" {
+		dir = "."
+	}
+	if !filepath.IsAbs(dir) && input.Context != nil && input.Context.CWD != "" {
+		dir = filepath.Join(input.Context.CWD, dir)
+	}
+
+	ecosystem := ""
+	for _, m := range detectPackageManagers(dir) {
+		if m.Ecosystem == "go" || m.Ecosystem == "python" {
+			ecosystem = m.Ecosystem
+			break
+		}
+	}
+
+	profile, err := os.CreateTemp("", "coverage-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create coverage profile: %w", err)
+	}
+	profile.Close()
+	defer os.Remove(profile.Name())
+
+	var args []string
+	switch ecosystem {
+	case "go":
+		target := params.Target
+		if target == "" {
+			target = "./..."
+		}
+		args = []string{"go", "test", "-covermode=set", "-coverprofile=" + profile.Name(), target}
+	case "python":
+		target := params.Target
+		if target == "" {
+			target = "."
+		}
+		args = []string{pythonCommand(), "-m", "pytest", "-q", "--cov=" + target, "--cov-report=json:" + profile.Name()}
+	default:
+		return &tool.Output{
+			Content: "Error: coverage is supported for Go (go.mod) and Python (pyproject.toml, requirements.txt) projects",
+			IsError: true,
+		}, nil
+	}
+
+	out, exitCode, err := runProjectCommand(ctx, dir, args)
+	if err != nil {
+		return nil, err
+	}
+	command := strings.Join(args, " ")
+
+	var report *CoverageReport
+	if info, statErr := os.Stat(profile.Name()); statErr == nil && info.Size() > 0 {
+		if ecosystem == "go" {
+			report, err = goCoverage(ctx, dir, profile.Name())
+		} else {
+			report, err = pythonCoverage(dir, profile.Name())
+		}
+	} else {
+		err = fmt.Errorf("no coverage profile was written")
+	}
+	if err != nil {
+		return &tool.Output{
+			Content: fmt.Sprintf("Error: %v\n$ %s\n%s", err, command, lastLines(out, 40)),
+			IsError: true,
+		}, nil
+	}
+	report.Command = command
+
+	content := formatCoverage(report)
+	if exitCode != 0 {
+		content = fmt.Sprintf("Some tests failed (exit code %d); coverage is partial.\n%s\n\n%s", exitCode, lastLines(out, 20), content)
+	}
+
+	return &tool.Output{
+		Content:  content,
+		Metadata: report,
+	}, nil
+}
+
+// goCoverage builds the report from a Go cover profile
+func goCoverage(ctx context.Context, dir, profilePath string) (*CoverageReport, error) {
+	f, err := os.Open(profilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	blocks, err := parseGoProfile(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cover profile: %w", err)
+	}
+
+	// The profile names files by import path; map packages to directories
+	out, _, err := runProjectCommand(ctx, dir, []string{"go", "list", "-f", "{{.ImportPath}}={{.Dir}}", "./..."})
+	if err != nil {
+		return nil, err
+	}
+	dirs := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if importPath, pkgDir, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			dirs[importPath] = pkgDir
+		}
+	}
+
+	local := make(map[string][]coverBlock, len(blocks))
+	for name, fileBlocks := range blocks {
+		if pkgDir, ok := dirs[path.Dir(name)]; ok {
+			name = filepath.Join(pkgDir, path.Base(name))
+		}
+		local[name] = fileBlocks
+	}
+	return buildCoverage(dir, local), nil
+}
+
+// parseGoProfile reads the blocks of a Go cover profile by file name,
+// merging blocks reported by several test binaries
+func parseGoProfile(r io.Reader) (map[string][]coverBlock, error) {
+	type blockKey struct {
+		file  string
+		block string
+	}
+	merged := make(map[blockKey]*coverBlock)
+	var order []blockKey
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// file.go:startLine.startCol,endLine.endCol numStmts count
+		i := strings.LastIndex(line, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid line: %q", line)
+		}
+		fields := strings.Fields(line[i+1:])
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid line: %q", line)
+		}
+		start, end, ok := strings.Cut(fields[0], ",")
+		if !ok {
+			return nil, fmt.Errorf("invalid line: %q", line)
+		}
+		startLine, err1 := strconv.Atoi(strings.SplitN(start, ".", 2)[0])
+		endLine, err2 := strconv.Atoi(strings.SplitN(end, ".", 2)[0])
+		stmts, err3 := strconv.Atoi(fields[1])
+		count, err4 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			return nil, fmt.Errorf("invalid line: %q", line)
+		}
+
+		key := blockKey{file: line[:i], block: fields[0]}
+		if b, ok := merged[key]; ok {
+			b.Count = max(b.Count, count)
+			continue
+		}
+		merged[key] = &coverBlock{StartLine: startLine, EndLine: endLine, Stmts: stmts, Count: count}
+		order = append(order, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	blocks := make(map[string][]coverBlock)
+	for _, key := range order {
+		blocks[key.file] = append(blocks[key.file], *merged[key])
+	}
+	return blocks, nil
+}
+
+// pythonCoverage builds the report from a coverage.py JSON report
+func pythonCoverage(dir, reportPath string) (*CoverageReport, error) {
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Files map[string]struct {
+			ExecutedLines []int `json:"executed_lines"`
+			MissingLines  []int `json:"missing_lines"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse coverage report: %w", err)
+	}
+
+	blocks := make(map[string][]coverBlock)
+	for name, file := range raw.Files {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		for _, line := range file.ExecutedLines {
+			blocks[name] = append(blocks[name], coverBlock{StartLine: line, EndLine: line, Stmts: 1, Count: 1})
+		}
+		for _, line := range file.MissingLines {
+			blocks[name] = append(blocks[name], coverBlock{StartLine: line, EndLine: line, Stmts: 1})
+		}
+	}
+	return buildCoverage(dir, blocks), nil
+}
+
+// buildCoverage attributes blocks to the functions of each file
+func buildCoverage(dir string, blocks map[string][]coverBlock) *CoverageReport {
+	report := &CoverageReport{}
+	for name, fileBlocks := range blocks {
+		sort.Slice(fileBlocks, func(i, j int) bool { return fileBlocks[i].StartLine < fileBlocks[j].StartLine })
+
+		file := FileCoverage{Path: name}
+		if rel, err := filepath.Rel(dir, name); err == nil && !strings.HasPrefix(rel, "..") {
+			file.Path = rel
+		}
+		for _, b := range fileBlocks {
+			file.Statements += b.Stmts
+			if b.Count > 0 {
+				file.Covered += b.Stmts
+			}
+		}
+
+		var symbols []Symbol
+		if content, err := os.ReadFile(name); err == nil {
+			symbols = Outline(name, content)
+		}
+		for _, sym := range symbols {
+			if sym.Kind == "type" || sym.Kind == "class" {
+				continue
+			}
+			fn := FunctionCoverage{Name: sym.Name, StartLine: sym.StartLine, EndLine: sym.EndLine}
+			for _, b := range fileBlocks {
+				if b.StartLine < sym.StartLine || b.StartLine > sym.EndLine {
+					continue
+				}
+				fn.Statements += b.Stmts
+				if b.Count > 0 {
+					fn.Covered += b.Stmts
+					continue
+				}
+				if n := len(fn.Uncovered); n > 0 && b.StartLine <= fn.Uncovered[n-1].End+1 {
+					fn.Uncovered[n-1].End = max(fn.Uncovered[n-1].End, b.EndLine)
+				} else {
+					fn.Uncovered = append(fn.Uncovered, LineRange{Start: b.StartLine, End: b.EndLine})
+				}
+			}
+			if fn.Statements > 0 {
+				file.Functions = append(file.Functions, fn)
+			}
+		}
+
+		report.Statements += file.Statements
+		report.Covered += file.Covered
+		report.Files = append(report.Files, file)
+	}
+
+	sort.Slice(report.Files, func(i, j int) bool {
+		a, b := report.Files[i], report.Files[j]
+		if a.Statements-a.Covered != b.Statements-b.Covered {
+			return a.Statements-a.Covered > b.Statements-b.Covered
+		}
+		return a.Path < b.Path
+	})
+	return report
+}
+
+// formatCoverage summarizes a report: the total, then the functions with
+// the most uncovered statements grouped by file
+func formatCoverage(report *CoverageReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Coverage: %.1f%% of statements (%d/%d)\n$ %s\n", report.Percent(), report.Covered, report.Statements, report.Command)
+
+	type entry struct {
+		file string
+		fn   FunctionCoverage
+	}
+	var gaps []entry
+	for _, file := range report.Files {
+		for _, fn := range file.Functions {
+			if fn.Covered < fn.Statements {
+				gaps = append(gaps, entry{file.Path, fn})
+			}
+		}
+	}
+	if len(gaps) == 0 {
+		b.WriteString("\nNo uncovered functions.")
+		return b.String()
+	}
+	sort.SliceStable(gaps, func(i, j int) bool {
+		return gaps[i].fn.Statements-gaps[i].fn.Covered > gaps[j].fn.Statements-gaps[j].fn.Covered
+	})
+	hidden := 0
+	if len(gaps) > maxCoverageFunctions {
+		hidden = len(gaps) - maxCoverageFunctions
+		gaps = gaps[:maxCoverageFunctions]
+	}
+
+	// Group by file, keeping the files in order of their first entry
+	var files []string
+	byFile := make(map[string][]FunctionCoverage)
+	for _, g := range gaps {
+		if _, ok := byFile[g.file]; !ok {
+			files = append(files, g.file)
+		}
+		byFile[g.file] = append(byFile[g.file], g.fn)
+	}
+
+	b.WriteString("\nLeast covered functions:\n")
+	for _, file := range files {
+		fmt.Fprintf(&b, "%s\n", file)
+		for _, fn := range byFile[file] {
+			fmt.Fprintf(&b, "  %s (lines %d-%d): %.0f%% of %d statements, uncovered lines %s\n",
+				fn.Name, fn.StartLine, fn.EndLine, percent(fn.Covered, fn.Statements), fn.Statements, formatRanges(fn.Uncovered))
+		}
+	}
+	if hidden > 0 {
+		fmt.Fprintf(&b, "... and %d more partially covered functions\n", hidden)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// formatRanges lists line ranges as "3-5, 9"
+func formatRanges(ranges []LineRange) string {
+	parts := make([]string, 0, len(ranges))
+	for i, r := range ranges {
+		if i == maxUncoveredRanges {
+			parts = append(parts, "...")
+			break
+		}
+		if r.Start == r.End {
+			parts = append(parts, strconv.Itoa(r.Start))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", r.Start, r.End))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// lastLines returns the last n lines of s
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,163 @@
+package builtin
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+const coverageSource = `package calc
+
+import "errors"
+
+// Abs returns the absolute value of n
+func Abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Div divides a by b
+func Div(a, b int) (int, error) {
+	if b == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return a / b, nil
+}
+`
+
+func TestParseGoProfile(t *testing.T) {
+	profile := `mode: set
+example.com/calc/calc.go:4.21,5.11 1 1
+example.com/calc/calc.go:5.11,7.3 1 0
+example.com/calc/calc.go:5.11,7.3 1 1
+example.com/calc/calc.go:8.2,8.10 1 1
+`
+	got, err := parseGoProfile(strings.NewReader(profile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]coverBlock{
+		"example.com/calc/calc.go": {
+			{StartLine: 4, EndLine: 5, Stmts: 1, Count: 1},
+			{StartLine: 5, EndLine: 7, Stmts: 1, Count: 1},
+			{StartLine: 8, EndLine: 8, Stmts: 1, Count: 1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseGoProfile() = %+v, want %+v", got, want)
+	}
+
+	if _, err := parseGoProfile(strings.NewReader("mode: set\ngarbage\n")); err == nil {
+		t.Error("expected error for invalid profile")
+	}
+}
+
+func TestBuildCoverage(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"calc.go": coverageSource})
+
+	blocks := map[string][]coverBlock{
+		filepath.Join(dir, "calc.go"): {
+			{StartLine: 6, EndLine: 7, Stmts: 1, Count: 1},
+			{StartLine: 7, EndLine: 9, Stmts: 1, Count: 0},
+			{StartLine: 10, EndLine: 10, Stmts: 1, Count: 1},
+			{StartLine: 14, EndLine: 15, Stmts: 1, Count: 0},
+			{StartLine: 15, EndLine: 17, Stmts: 1, Count: 0},
+			{StartLine: 18, EndLine: 18, Stmts: 1, Count: 0},
+		},
+	}
+	report := buildCoverage(dir, blocks)
+	report.Command = "go test ./..."
+
+	if report.Statements != 6 || report.Covered != 2 {
+		t.Fatalf("expected 2/6 statements covered, got %d/%d", report.Covered, report.Statements)
+	}
+	file := report.Files[0]
+	if file.Path != "calc.go" || len(file.Functions) != 2 {
+		t.Fatalf("unexpected file coverage: %+v", file)
+	}
+	if fn := file.Functions[0]; fn.Name != "Abs" || fn.Covered != 2 || !reflect.DeepEqual(fn.Uncovered, []LineRange{{7, 9}}) {
+		t.Errorf("unexpected Abs coverage: %+v", fn)
+	}
+	if fn := file.Functions[1]; fn.Name != "Div" || fn.Covered != 0 || !reflect.DeepEqual(fn.Uncovered, []LineRange{{14, 18}}) {
+		t.Errorf("unexpected Div coverage: %+v", fn)
+	}
+
+	content := formatCoverage(report)
+	for _, want := range []string{
+		"Coverage: 33.3% of statements (2/6)",
+		"calc.go\n  Div (lines 13-19): 0% of 3 statements, uncovered lines 14-18\n  Abs (lines 5-11): 67% of 3 statements, uncovered lines 7-9",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %q in:\n%s", want, content)
+		}
+	}
+}
+
+func TestPythonCoverage(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"app/util.py":   "def clamp(x, lo, hi):\n    if x < lo:\n        return lo\n    if x > hi:\n        return hi\n    return x\n",
+		"coverage.json": `{"files": {"app/util.py": {"executed_lines": [1, 2, 4, 6], "missing_lines": [3, 5]}}}`,
+	})
+
+	report, err := pythonCoverage(dir, filepath.Join(dir, "coverage.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Statements != 6 || report.Covered != 4 {
+		t.Fatalf("expected 4/6 statements covered, got %d/%d", report.Covered, report.Statements)
+	}
+	fn := report.Files[0].Functions[0]
+	if fn.Name != "clamp" || !reflect.DeepEqual(fn.Uncovered, []LineRange{{3, 3}, {5, 5}}) {
+		t.Errorf("unexpected clamp coverage: %+v", fn)
+	}
+}
+
+func TestCoverageToolGo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not installed")
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":  "module example.com/calc\n\ngo 1.21\n",
+		"calc.go": coverageSource,
+		"calc_test.go": `package calc
+
+import "testing"
+
+func TestAbs(t *testing.T) {
+	if Abs(3) != 3 {
+		t.Fail()
+	}
+}
+`,
+	})
+
+	output, err := NewCoverageTool().Execute(context.Background(), &tool.Input{
+		Params:  map[string]interface{}{},
+		Context: &tool.ExecutionContext{CWD: dir},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.IsError {
+		t.Fatalf("unexpected error: %s", output.Content)
+	}
+	for _, want := range []string{"Coverage: 33.3% of statements (2/6)", "calc.go\n  Div", "Abs (lines 5-11): 67%"} {
+		if !strings.Contains(output.Content, want) {
+			t.Errorf("expected %q in:\n%s", want, output.Content)
+		}
+	}
+}
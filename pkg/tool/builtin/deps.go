@@ -0,0 +1,743 @@
+package builtin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// depsActions are the operations supported by the Deps tool
+var depsActions = map[string]bool{
+	"list":    true,
+	"add":     true,
+	"upgrade": true,
+	"remove":  true,
+	"audit":   true,
+}
+
+// packageManager describes how a project's dependencies are managed
+type packageManager struct {
+	Name      string   // go, npm, yarn, pnpm, bun, pip, poetry, uv, pipenv
+	Ecosystem string   // go, node, python
+	Manifest  string   // file declaring the dependencies
+	Lockfiles []string // files pinning resolved versions
+}
+
+// packageManagers lists the supported managers; within an ecosystem, the
+// first one whose lockfile exists is used
+var packageManagers = []packageManager{
+	{Name: "go", Ecosystem: "go", Manifest: "go.mod", Lockfiles: []string{"go.sum"}},
+	{Name: "pnpm", Ecosystem: "node", Manifest: "package.json", Lockfiles: []string{"pnpm-lock.yaml"}},
+	{Name: "yarn", Ecosystem: "node", Manifest: "package.json", Lockfiles: []string{"yarn.lock"}},
+	{Name: "bun", Ecosystem: "node", Manifest: "package.json", Lockfiles: []string{"bun.lockb", "bun.lock"}},
+	{Name: "npm", Ecosystem: "node", Manifest: "package.json", Lockfiles: []string{"package-lock.json"}},
+	{Name: "poetry", Ecosystem: "python", Manifest: "pyproject.toml", Lockfiles: []string{"poetry.lock"}},
+	{Name: "uv", Ecosystem: "python", Manifest: "pyproject.toml", Lockfiles: []string{"uv.lock"}},
+	{Name: "pipenv", Ecosystem: "python", Manifest: "Pipfile", Lockfiles: []string{"Pipfile.lock"}},
+	{Name: "pip", Ecosystem: "python", Manifest: "requirements.txt"},
+}
+
+// detectPackageManagers returns the package manager of each ecosystem used in dir
+func detectPackageManagers(dir string) []packageManager {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	var found []packageManager
+	seen := make(map[string]bool)
+	// Prefer the manager whose lockfile is present
+	for _, m := range packageManagers {
+		if seen[m.Ecosystem] || !exists(m.Manifest) {
+			continue
+		}
+		for _, lock := range m.Lockfiles {
+			if exists(lock) {
+				found = append(found, m)
+				seen[m.Ecosystem] = true
+				break
+			}
+		}
+	}
+	// Otherwise fall back to the ecosystem default
+	for _, name := range []string{"go", "npm", "pip"} {
+		m, _ := findPackageManager(name)
+		if !seen[m.Ecosystem] && exists(m.Manifest) {
+			found = append(found, m)
+			seen[m.Ecosystem] = true
+		}
+	}
+	if !seen["python"] && exists("pyproject.toml") {
+		m, _ := findPackageManager("pip")
+		m.Manifest = "pyproject.toml"
+		found = append(found, m)
+	}
+	return found
+}
+
+// findPackageManager returns the package manager with the given name
+func findPackageManager(name string) (packageManager, bool) {
+	for _, m := range packageManagers {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return packageManager{}, false
+}
+
+// DepsTool manages project dependencies with the native package managers
+type DepsTool struct{}
+
+// DepsInput represents the input for the Deps tool
+type DepsInput struct {
+	Action   string   `json:"action"`
+	Packages []string `json:"packages,omitempty"`
+	Dev      bool     `json:"dev,omitempty"`
+	Manager  string   `json:"manager,omitempty"`
+	Path     string   `json:"path,omitempty"`
+}
+
+// NewDepsTool creates a new Deps tool
+func NewDepsTool() *DepsTool {
+	return &DepsTool{}
+}
+
+func (t *DepsTool) Name() string {
+	return "Deps"
+}
+
+func (t *DepsTool) Description() string {
+	return `Lists, adds, upgrades, removes, and audits project dependencies using the project's own package manager.
+- Supports Go modules, npm, yarn, pnpm, bun, pip (requirements.txt), poetry, uv, and pipenv
+- The package manager is chosen from the lockfile present, so lockfiles stay consistent
+- Reports which manifest and lockfiles changed
+- audit runs govulncheck, npm/yarn/pnpm audit, or pip-audit
+- Use this instead of running install commands with Bash`
+}
+
+func (t *DepsTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"action": {
+				"type": "string",
+				"enum": ["list", "add", "upgrade", "remove", "audit"],
+				"description": "The operation to perform"
+			},
+			"packages": {
+				"type": "array",
+				"items": {"type": "string"},
+				"description": "Packages to add, upgrade, or remove, optionally with a version (e.g. \"lodash@4.17.21\", \"requests==2.32.0\", \"golang.org/x/mod@v0.20.0\"). Upgrade without packages upgrades all dependencies."
+			},
+			"dev": {
+				"type": "boolean",
+				"description": "Add as a development dependency (Node and Python managers)"
+			},
+			"manager": {
+				"type": "string",
+				"enum": ["go", "npm", "yarn", "pnpm", "bun", "pip", "poetry", "uv", "pipenv"],
+				"description": "The package manager to use when the project has several ecosystems"
+			},
+			"path": {
+				"type": "string",
+				"description": "The project directory. If not specified, the current working directory will be used."
+			}
+		},
+		"required": ["action"]
+	}`)
+}
+
+func (t *DepsTool) Validate(input *tool.Input) error {
+	params, err := tool.ParamsTo[DepsInput](input.Params)
+	if err != nil {
+		return err
+	}
+
+	if !depsActions[params.Action] {
+		return fmt.Errorf("action must be one of list, add, upgrade, remove, audit")
+	}
+	if params.Manager != "" {
+		if _, ok := findPackageManager(params.Manager); !ok {
+			return fmt.Errorf("unknown package manager: %s", params.Manager)
+		}
+	}
+	if (params.Action == "add" || params.Action == "remove") && len(params.Packages) == 0 {
+		return fmt.Errorf("packages are required for %s", params.Action)
+	}
+	for _, pkg := range params.Packages {
+		// Packages are passed as arguments, never to a shell, but must not be flags
+		if pkg == "" || strings.HasPrefix(pkg, "-") || strings.ContainsAny(pkg, " \t\n") {
+			return fmt.Errorf("invalid package: %q", pkg)
+		}
+	}
+
+	return nil
+}
+
+func (t *DepsTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+	params, err := tool.ParamsTo[DepsInput](input.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := params.Path
+	if dir == "" && input.Context != nil {
+		dir = input.Context.CWD
+	}
+	if dir == "" {
+		dir = "."
+	}
+	if !filepath.IsAbs(dir) && input.Context != nil && input.Context.CWD != "" {
+		dir = filepath.Join(input.Context.CWD, dir)
+	}
+
+	manager, err := selectPackageManager(dir, params.Manager)
+	if err != nil {
+		return &tool.Output{Content: "Error: " + err.Error(), IsError: true}, nil
+	}
+
+	if params.Action == "list" {
+		return listDependencies(ctx, dir, manager)
+	}
+
+	commands, err := manager.commands(params.Action, params.Packages, params.Dev)
+	if err != nil {
+		return &tool.Output{Content: "Error: " + err.Error(), IsError: true}, nil
+	}
+	if _, err := exec.LookPath(commands[0][0]); err != nil {
+		return &tool.Output{
+			Content: fmt.Sprintf("Error: %s is not installed. %s", commands[0][0], installHint(commands[0][0])),
+			IsError: true,
+		}, nil
+	}
+
+	tracked := append([]string{manager.Manifest}, manager.Lockfiles...)
+	before := digestFiles(dir, tracked)
+
+	var b strings.Builder
+	exitCode := 0
+	for _, args := range commands {
+		fmt.Fprintf(&b, "$ %s\n", strings.Join(args, " "))
+		out, code, err := runProjectCommand(ctx, dir, args)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(out)
+		if !strings.HasSuffix(out, "\n") {
+			b.WriteString("\n")
+		}
+		if code != 0 {
+			exitCode = code
+			break
+		}
+	}
+
+	if exitCode == 0 && manager.Name == "pip" && manager.Manifest == "requirements.txt" {
+		if err := updateRequirements(filepath.Join(dir, manager.Manifest), params.Action, params.Packages); err != nil {
+			fmt.Fprintf(&b, "Warning: could not update requirements.txt: %v\n", err)
+		}
+	}
+
+	changed := changedFiles(before, digestFiles(dir, tracked))
+	if len(changed) > 0 {
+		fmt.Fprintf(&b, "\nUpdated: %s", strings.Join(changed, ", "))
+	} else if params.Action != "audit" {
+		b.WriteString("\nNo manifest or lockfile changes.")
+	}
+
+	content := strings.TrimSuffix(b.String(), "\n")
+	isError := exitCode != 0
+	if params.Action == "audit" && exitCode != 0 {
+		// Audit tools exit non-zero when they find vulnerabilities
+		content = fmt.Sprintf("Vulnerabilities reported (exit code %d):\n%s", exitCode, content)
+		isError = false
+	} else if isError {
+		content = fmt.Sprintf("Exit code %d\n%s", exitCode, content)
+	}
+
+	return &tool.Output{
+		Content: content,
+		IsError: isError,
+		Metadata: map[string]interface{}{
+			"manager": manager.Name,
+			"changed": changed,
+		},
+	}, nil
+}
+
+// selectPackageManager returns the named manager, or the one detected in dir
+func selectPackageManager(dir, name string) (packageManager, error) {
+	detected := detectPackageManagers(dir)
+	if name != "" {
+		m, _ := findPackageManager(name)
+		for _, d := range detected {
+			if d.Ecosystem == m.Ecosystem {
+				m.Manifest = d.Manifest
+			}
+		}
+		return m, nil
+	}
+
+	switch len(detected) {
+	case 0:
+		return packageManager{}, fmt.Errorf("no go.mod, package.json, pyproject.toml, Pipfile, or requirements.txt in %s", dir)
+	case 1:
+		return detected[0], nil
+	}
+	names := make([]string, len(detected))
+	for i, m := range detected {
+		names[i] = m.Name
+	}
+	return packageManager{}, fmt.Errorf("several package managers apply (%s); specify manager", strings.Join(names, ", "))
+}
+
+// commands returns the commands performing action with the package manager
+func (m packageManager) commands(action string, pkgs []string, dev bool) ([][]string, error) {
+	with := func(args ...string) []string {
+		return append(args, pkgs...)
+	}
+	devFlag := func(flag string) []string {
+		if dev {
+			return []string{flag}
+		}
+		return nil
+	}
+
+	switch m.Name {
+	case "go":
+		switch action {
+		case "add":
+			return [][]string{with("go", "get")}, nil
+		case "upgrade":
+			if len(pkgs) == 0 {
+				return [][]string{{"go", "get", "-u", "./..."}, {"go", "mod", "tidy"}}, nil
+			}
+			return [][]string{with("go", "get", "-u")}, nil
+		case "remove":
+			removed := make([]string, len(pkgs))
+			for i, pkg := range pkgs {
+				removed[i] = strings.SplitN(pkg, "@", 2)[0] + "@none"
+			}
+			return [][]string{append([]string{"go", "get"}, removed...)}, nil
+		case "audit":
+			return [][]string{{"govulncheck", "./..."}}, nil
+		}
+
+	case "npm":
+		switch action {
+		case "add":
+			return [][]string{append(with("npm", "install"), devFlag("--save-dev")...)}, nil
+		case "upgrade":
+			return [][]string{with("npm", "update")}, nil
+		case "remove":
+			return [][]string{with("npm", "uninstall")}, nil
+		case "audit":
+			return [][]string{{"npm", "audit"}}, nil
+		}
+
+	case "yarn":
+		switch action {
+		case "add":
+			return [][]string{append(with("yarn", "add"), devFlag("--dev")...)}, nil
+		case "upgrade":
+			return [][]string{with("yarn", "upgrade")}, nil
+		case "remove":
+			return [][]string{with("yarn", "remove")}, nil
+		case "audit":
+			return [][]string{{"yarn", "audit"}}, nil
+		}
+
+	case "pnpm":
+		switch action {
+		case "add":
+			return [][]string{append(with("pnpm", "add"), devFlag("--save-dev")...)}, nil
+		case "upgrade":
+			return [][]string{with("pnpm", "update")}, nil
+		case "remove":
+			return [][]string{with("pnpm", "remove")}, nil
+		case "audit":
+			return [][]string{{"pnpm", "audit"}}, nil
+		}
+
+	case "bun":
+		switch action {
+		case "add":
+			return [][]string{append(with("bun", "add"), devFlag("--dev")...)}, nil
+		case "upgrade":
+			return [][]string{with("bun", "update")}, nil
+		case "remove":
+			return [][]string{with("bun", "remove")}, nil
+		case "audit":
+			return [][]string{{"bun", "audit"}}, nil
+		}
+
+	case "poetry":
+		switch action {
+		case "add":
+			return [][]string{append(with("poetry", "add"), devFlag("--group=dev")...)}, nil
+		case "upgrade":
+			return [][]string{with("poetry", "update")}, nil
+		case "remove":
+			return [][]string{with("poetry", "remove")}, nil
+		case "audit":
+			return [][]string{{"pip-audit"}}, nil
+		}
+
+	case "uv":
+		switch action {
+		case "add":
+			return [][]string{append(with("uv", "add"), devFlag("--dev")...)}, nil
+		case "upgrade":
+			if len(pkgs) == 0 {
+				return [][]string{{"uv", "lock", "--upgrade"}, {"uv", "sync"}}, nil
+			}
+			args := []string{"uv", "lock"}
+			for _, pkg := range pkgs {
+				args = append(args, "--upgrade-package", pkg)
+			}
+			return [][]string{args, {"uv", "sync"}}, nil
+		case "remove":
+			return [][]string{with("uv", "remove")}, nil
+		case "audit":
+			return [][]string{{"pip-audit"}}, nil
+		}
+
+	case "pipenv":
+		switch action {
+		case "add":
+			return [][]string{append(with("pipenv", "install"), devFlag("--dev")...)}, nil
+		case "upgrade":
+			return [][]string{with("pipenv", "update")}, nil
+		case "remove":
+			return [][]string{with("pipenv", "uninstall")}, nil
+		case "audit":
+			return [][]string{{"pipenv", "check"}}, nil
+		}
+
+	case "pip":
+		python := pythonCommand()
+		switch action {
+		case "add":
+			return [][]string{with(python, "-m", "pip", "install")}, nil
+		case "upgrade":
+			if len(pkgs) == 0 {
+				if m.Manifest != "requirements.txt" {
+					return nil, fmt.Errorf("specify the packages to upgrade")
+				}
+				return [][]string{{python, "-m", "pip", "install", "--upgrade", "-r", "requirements.txt"}}, nil
+			}
+			return [][]string{with(python, "-m", "pip", "install", "--upgrade")}, nil
+		case "remove":
+			names := make([]string, len(pkgs))
+			for i, pkg := range pkgs {
+				names[i] = requirementName(pkg)
+			}
+			return [][]string{append([]string{python, "-m", "pip", "uninstall", "-y"}, names...)}, nil
+		case "audit":
+			if m.Manifest == "requirements.txt" {
+				return [][]string{{"pip-audit", "-r", "requirements.txt"}}, nil
+			}
+			return [][]string{{"pip-audit"}}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s does not support %s", m.Name, action)
+}
+
+// pythonCommand returns the Python interpreter to run pip with
+func pythonCommand() string {
+	if _, err := exec.LookPath("python3"); err == nil {
+		return "python3"
+	}
+	return "python"
+}
+
+// installHint explains how to install a missing command
+func installHint(command string) string {
+	switch command {
+	case "govulncheck":
+		return "Install it with: go install golang.org/x/vuln/cmd/govulncheck@latest"
+	case "pip-audit":
+		return "Install it with: pip install pip-audit"
+	}
+	return "Install it or specify another manager."
+}
+
+// runProjectCommand runs a project tool in dir and returns its combined output
+func runProjectCommand(ctx context.Context, dir string, args []string) (string, int, error) {
+	return runWorkspaceCommand(ctx, nil, dir, args)
+}
+
+// runWorkspaceCommand is runProjectCommand in a workspace (nil = the local machine)
+func runWorkspaceCommand(ctx context.Context, ws Workspace, dir string, args []string) (string, int, error) {
+	cmd := workspaceOr(ws).Command(ctx, dir, args[0], args[1:]...)
+	setCommandEnv(cmd)
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return "", 0, ctx.Err()
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return out.String(), exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to run %s: %w", args[0], err)
+	}
+	return out.String(), 0, nil
+}
+
+// digestFiles hashes the existing files among names
+func digestFiles(dir string, names []string) map[string][sha256.Size]byte {
+	digests := make(map[string][sha256.Size]byte)
+	for _, name := range names {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+			digests[name] = sha256.Sum256(data)
+		}
+	}
+	return digests
+}
+
+// changedFiles returns the files created, modified, or deleted between two digests
+func changedFiles(before, after map[string][sha256.Size]byte) []string {
+	var changed []string
+	for name, digest := range after {
+		if old, ok := before[name]; !ok || old != digest {
+			changed = append(changed, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Dependency is a dependency declared in a project manifest
+type Dependency struct {
+	Name     string
+	Version  string
+	Dev      bool
+	Indirect bool
+}
+
+// listDependencies describes the declared dependencies of the project
+func listDependencies(ctx context.Context, dir string, manager packageManager) (*tool.Output, error) {
+	var deps []Dependency
+	var err error
+
+	switch {
+	case manager.Ecosystem == "go":
+		deps, err = goModDependencies(filepath.Join(dir, manager.Manifest))
+	case manager.Ecosystem == "node":
+		deps, err = packageJSONDependencies(filepath.Join(dir, manager.Manifest))
+	case manager.Manifest == "requirements.txt":
+		deps, err = requirementsDependencies(filepath.Join(dir, manager.Manifest))
+	default:
+		// pyproject.toml and Pipfile are listed by the manager itself
+		args := map[string][]string{
+			"poetry": {"poetry", "show", "--top-level"},
+			"uv":     {"uv", "tree", "--depth", "1"},
+			"pipenv": {"pipenv", "graph"},
+			"pip":    {pythonCommand(), "-m", "pip", "list", "--not-required"},
+		}[manager.Name]
+		out, code, err := runProjectCommand(ctx, dir, args)
+		if err != nil {
+			return &tool.Output{Content: "Error: " + err.Error(), IsError: true}, nil
+		}
+		return &tool.Output{Content: strings.TrimSpace(out), IsError: code != 0}, nil
+	}
+	if err != nil {
+		return &tool.Output{Content: "Error: " + err.Error(), IsError: true}, nil
+	}
+
+	return &tool.Output{
+		Content: formatDependencies(manager, deps),
+		Metadata: map[string]interface{}{
+			"manager":      manager.Name,
+			"dependencies": deps,
+		},
+	}, nil
+}
+
+// formatDependencies lists dependencies grouped as direct, dev, and indirect
+func formatDependencies(manager packageManager, deps []Dependency) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s, %d dependencies)\n", manager.Manifest, manager.Name, len(deps))
+
+	groups := []struct {
+		title string
+		match func(Dependency) bool
+	}{
+		{"Dependencies", func(d Dependency) bool { return !d.Dev && !d.Indirect }},
+		{"Dev dependencies", func(d Dependency) bool { return d.Dev }},
+		{"Indirect dependencies", func(d Dependency) bool { return d.Indirect }},
+	}
+	for _, g := range groups {
+		var lines []string
+		for _, d := range deps {
+			if !g.match(d) {
+				continue
+			}
+			if d.Version == "" {
+				lines = append(lines, "  "+d.Name)
+			} else {
+				lines = append(lines, fmt.Sprintf("  %s %s", d.Name, d.Version))
+			}
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&b, "\n%s:\n%s\n", g.title, strings.Join(lines, "\n"))
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// goModDependencies parses the require directives of a go.mod file
+func goModDependencies(path string) ([]Dependency, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+
+	var deps []Dependency
+	inBlock := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require"))
+		case !inBlock:
+			continue
+		}
+
+		indirect := strings.HasSuffix(line, "// indirect")
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		deps = append(deps, Dependency{Name: fields[0], Version: fields[1], Indirect: indirect})
+	}
+	return deps, nil
+}
+
+// packageJSONDependencies reads the dependencies of a package.json file
+func packageJSONDependencies(path string) ([]Dependency, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package.json: %w", err)
+	}
+	var manifest struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %w", err)
+	}
+
+	var deps []Dependency
+	add := func(m map[string]string, dev bool) {
+		names := make([]string, 0, len(m))
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			deps = append(deps, Dependency{Name: name, Version: m[name], Dev: dev})
+		}
+	}
+	add(manifest.Dependencies, false)
+	add(manifest.DevDependencies, true)
+	return deps, nil
+}
+
+// requirementNamePattern matches the distribution name of a requirement
+var requirementNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*`)
+
+// requirementName returns the normalized name of a requirement specifier
+func requirementName(spec string) string {
+	name := requirementNamePattern.FindString(strings.TrimSpace(spec))
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+// requirementsDependencies reads the requirements of a requirements.txt file
+func requirementsDependencies(path string) ([]Dependency, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read requirements.txt: %w", err)
+	}
+	defer f.Close()
+
+	var deps []Dependency
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+		name := requirementNamePattern.FindString(line)
+		deps = append(deps, Dependency{Name: name, Version: strings.TrimSpace(line[len(name):])})
+	}
+	return deps, scanner.Err()
+}
+
+// updateRequirements records added and removed packages in requirements.txt,
+// replacing existing lines for the same package
+func updateRequirements(path, action string, pkgs []string) error {
+	if action != "add" && action != "remove" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	targets := make(map[string]string)
+	for _, pkg := range pkgs {
+		targets[requirementName(pkg)] = pkg
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if _, ok := targets[requirementName(line)]; ok && !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if action == "add" {
+		lines = append(lines, pkgs...)
+	}
+
+	content := strings.Join(lines, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
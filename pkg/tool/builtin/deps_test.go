@@ -0,0 +1,199 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetectPackageManagers(t *testing.T) {
+	tests := []struct {
+		files []string
+		want  []string
+	}{
+		{[]string{"go.mod", "go.sum"}, []string{"go"}},
+		{[]string{"package.json"}, []string{"npm"}},
+		{[]string{"package.json", "yarn.lock"}, []string{"yarn"}},
+		{[]string{"package.json", "pnpm-lock.yaml", "package-lock.json"}, []string{"pnpm"}},
+		{[]string{"pyproject.toml", "poetry.lock"}, []string{"poetry"}},
+		{[]string{"pyproject.toml", "uv.lock"}, []string{"uv"}},
+		{[]string{"pyproject.toml"}, []string{"pip"}},
+		{[]string{"requirements.txt"}, []string{"pip"}},
+		{[]string{"go.mod", "package.json"}, []string{"go", "npm"}},
+		{[]string{"README.md"}, nil},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		files := make(map[string]string)
+		for _, name := range tt.files {
+			files[name] = ""
+		}
+		writeFiles(t, dir, files)
+
+		var got []string
+		for _, m := range detectPackageManagers(dir) {
+			got = append(got, m.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("detectPackageManagers(%v) = %v, want %v", tt.files, got, tt.want)
+		}
+	}
+}
+
+func TestPackageManagerCommands(t *testing.T) {
+	tests := []struct {
+		manager string
+		action  string
+		pkgs    []string
+		dev     bool
+		want    [][]string
+	}{
+		{"go", "add", []string{"golang.org/x/mod@v0.20.0"}, false, [][]string{{"go", "get", "golang.org/x/mod@v0.20.0"}}},
+		{"go", "upgrade", nil, false, [][]string{{"go", "get", "-u", "./..."}, {"go", "mod", "tidy"}}},
+		{"go", "remove", []string{"golang.org/x/mod@v0.20.0"}, false, [][]string{{"go", "get", "golang.org/x/mod@none"}}},
+		{"go", "audit", nil, false, [][]string{{"govulncheck", "./..."}}},
+		{"npm", "add", []string{"vitest"}, true, [][]string{{"npm", "install", "vitest", "--save-dev"}}},
+		{"yarn", "remove", []string{"lodash"}, false, [][]string{{"yarn", "remove", "lodash"}}},
+		{"pnpm", "upgrade", []string{"react"}, false, [][]string{{"pnpm", "update", "react"}}},
+		{"poetry", "add", []string{"pytest"}, true, [][]string{{"poetry", "add", "pytest", "--group=dev"}}},
+		{"uv", "upgrade", []string{"httpx"}, false, [][]string{{"uv", "lock", "--upgrade-package", "httpx"}, {"uv", "sync"}}},
+	}
+
+	for _, tt := range tests {
+		m, _ := findPackageManager(tt.manager)
+		got, err := m.commands(tt.action, tt.pkgs, tt.dev)
+		if err != nil {
+			t.Errorf("%s %s: unexpected error: %v", tt.manager, tt.action, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %s = %v, want %v", tt.manager, tt.action, got, tt.want)
+		}
+	}
+
+	pip, _ := findPackageManager("pip")
+	got, err := pip.commands("remove", []string{"Requests==2.32.0"}, false)
+	if err != nil || strings.Join(got[0][1:], " ") != "-m pip uninstall -y requests" {
+		t.Errorf("pip remove = %v, %v", got, err)
+	}
+}
+
+func TestDepsValidate(t *testing.T) {
+	deps := NewDepsTool()
+	tests := []struct {
+		params  map[string]interface{}
+		wantErr bool
+	}{
+		{map[string]interface{}{"action": "list"}, false},
+		{map[string]interface{}{"action": "add", "packages": []interface{}{"lodash"}}, false},
+		{map[string]interface{}{"action": "add"}, true},
+		{map[string]interface{}{"action": "install"}, true},
+		{map[string]interface{}{"action": "add", "packages": []interface{}{"--registry=evil"}}, true},
+		{map[string]interface{}{"action": "list", "manager": "cargo"}, true},
+	}
+
+	for _, tt := range tests {
+		err := deps.Validate(&tool.Input{Params: tt.params})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%v) error = %v, wantErr %v", tt.params, err, tt.wantErr)
+		}
+	}
+}
+
+func TestListDependencies(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": `module example.com/x
+
+go 1.22
+
+require github.com/spf13/cobra v1.8.0
+
+require (
+	github.com/google/uuid v1.6.0
+	golang.org/x/sys v0.20.0 // indirect
+)
+`,
+		"web/package.json":     `{"dependencies": {"react": "^18.2.0"}, "devDependencies": {"vitest": "^1.0.0"}}`,
+		"api/requirements.txt": "# runtime\nrequests==2.32.0\nflask>=3.0  # web\n-r dev.txt\n",
+	})
+
+	deps := NewDepsTool()
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"", []string{"go.mod (go, 3 dependencies)", "github.com/spf13/cobra v1.8.0", "github.com/google/uuid v1.6.0", "Indirect dependencies:\n  golang.org/x/sys v0.20.0"}},
+		{"web", []string{"package.json (npm, 2 dependencies)", "Dependencies:\n  react ^18.2.0", "Dev dependencies:\n  vitest ^1.0.0"}},
+		{"api", []string{"requirements.txt (pip, 2 dependencies)", "requests ==2.32.0", "flask >=3.0"}},
+	}
+
+	for _, tt := range tests {
+		input := &tool.Input{
+			Params:  map[string]interface{}{"action": "list", "path": tt.path},
+			Context: &tool.ExecutionContext{CWD: dir},
+		}
+		output, err := deps.Execute(context.Background(), input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output.IsError {
+			t.Errorf("list %q: unexpected error: %s", tt.path, output.Content)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(output.Content, want) {
+				t.Errorf("list %q: expected %q in:\n%s", tt.path, want, output.Content)
+			}
+		}
+	}
+}
+
+func TestUpdateRequirements(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requirements.txt")
+	writeFiles(t, filepath.Dir(path), map[string]string{"requirements.txt": "# pinned\nrequests==2.31.0\nflask\n"})
+
+	if err := updateRequirements(path, "add", []string{"requests==2.32.0", "httpx"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateRequirements(path, "remove", []string{"Flask"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	want := "# pinned\nrequests==2.32.0\nhttpx\n"
+	if string(data) != want {
+		t.Errorf("requirements.txt = %q, want %q", data, want)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"go.mod": "module x\n", "go.sum": "a\n"})
+	tracked := []string{"go.mod", "go.sum", "vendor.lock"}
+
+	before := digestFiles(dir, tracked)
+	writeFiles(t, dir, map[string]string{"go.sum": "b\n", "vendor.lock": ""})
+	got := changedFiles(before, digestFiles(dir, tracked))
+
+	if want := []string{"go.sum", "vendor.lock"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changedFiles = %v, want %v", got, want)
+	}
+}
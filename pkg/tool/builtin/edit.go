@@ -14,6 +14,8 @@ import (
 type EditTool struct {
 	// FileHistory tracks file changes for undo (optional)
 	FileHistory FileHistory
+	// Workspace is where files are edited (nil = the local machine)
+	Workspace Workspace
 }
 
 // FileHistory interface for tracking file history
@@ -27,6 +29,7 @@ type EditInput struct {
 	OldString  string `json:"old_string"`
 	NewString  string `json:"new_string"`
 	ReplaceAll bool   `json:"replace_all,omitempty"`
+	Occurrence int    `json:"occurrence,omitempty"` // 1-based occurrence to replace when old_string is not unique
 }
 
 // NewEditTool creates a new Edit tool
@@ -41,8 +44,9 @@ func (e *EditTool) Name() string {
 func (e *EditTool) Description() string {
 	return `Performs exact string replacements in files.
 - You must use your Read tool at least once before editing.
-- The edit will FAIL if old_string is not unique in the file. Either provide more context or use replace_all.
-- Use replace_all for replacing and renaming strings across the file.`
+- The edit will FAIL if old_string is not unique in the file. Either provide more context, set occurrence to the 1-based occurrence to replace, or use replace_all.
+- Use replace_all for replacing and renaming strings across the file.
+- When old_string is not found, the error shows the closest match in the file and how it differs, including whitespace and indentation.`
 }
 
 func (e *EditTool) InputSchema() json.RawMessage {
@@ -65,6 +69,11 @@ func (e *EditTool) InputSchema() json.RawMessage {
 				"type": "boolean",
 				"default": false,
 				"description": "Replace all occurrences of old_string (default false)"
+			},
+			"occurrence": {
+				"type": "integer",
+				"minimum": 1,
+				"description": "Replace only this occurrence of old_string (1-based), when it is not unique"
 			}
 		},
 		"required": ["file_path", "old_string", "new_string"]
@@ -94,6 +103,14 @@ func (e *EditTool) Validate(input *tool.Input) error {
 		return fmt.Errorf("old_string and new_string must be different")
 	}
 
+	if params.Occurrence < 0 {
+		return fmt.Errorf("occurrence must be 1 or greater")
+	}
+
+	if params.Occurrence > 0 && params.ReplaceAll {
+		return fmt.Errorf("occurrence and replace_all cannot be used together")
+	}
+
 	return nil
 }
 
@@ -104,7 +121,8 @@ func (e *EditTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 	}
 
 	// Read file
-	content, err := os.ReadFile(params.FilePath)
+	ws := workspaceOr(e.Workspace)
+	content, err := ws.ReadFile(params.FilePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return &tool.Output{
@@ -119,19 +137,46 @@ func (e *EditTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 	}
 
 	oldContent := string(content)
+	oldString, newString := params.OldString, params.NewString
+
+	// Files with Windows line endings match when old_string uses \n
+	count := strings.Count(oldContent, oldString)
+	if count == 0 && strings.Contains(oldContent, "\r\n") && !strings.Contains(oldString, "\r") {
+		oldString = strings.ReplaceAll(oldString, "\n", "\r\n")
+		newString = strings.ReplaceAll(newString, "\n", "\r\n")
+		count = strings.Count(oldContent, oldString)
+	}
 
 	// Check if old_string exists and is unique
-	count := strings.Count(oldContent, params.OldString)
 	if count == 0 {
+		msg := "Error: old_string not found in file."
+		if match := closestMatch(strings.ReplaceAll(oldContent, "\r\n", "\n"), params.OldString); match != nil {
+			msg += "\n\n" + describeMismatch(params.OldString, match)
+		} else {
+			msg += " No similar text was found. Make sure the string matches exactly, including whitespace and indentation."
+		}
 		return &tool.Output{
-			Content: "Error: old_string not found in file. Make sure the string matches exactly, including whitespace and indentation.",
+			Content: msg,
 			IsError: true,
 		}, nil
 	}
 
-	if count > 1 && !params.ReplaceAll {
+	if params.Occurrence > count {
 		return &tool.Output{
-			Content: fmt.Sprintf("Error: old_string found %d times in file. Either provide more surrounding context to make it unique, or use replace_all to change all occurrences.", count),
+			Content: fmt.Sprintf("Error: occurrence %d requested but old_string found %d time(s) in file.", params.Occurrence, count),
+			IsError: true,
+		}, nil
+	}
+
+	if count > 1 && !params.ReplaceAll && params.Occurrence == 0 {
+		lines := occurrenceLines(oldContent, oldString)
+		lineList := make([]string, len(lines))
+		for i, line := range lines {
+			lineList[i] = fmt.Sprintf("%d", line)
+		}
+		return &tool.Output{
+			Content: fmt.Sprintf("Error: old_string found %d times in file (at lines %s). Either provide more surrounding context to make it unique, set occurrence to the one to replace, or use replace_all to change all occurrences.",
+				count, strings.Join(lineList, ", ")),
 			IsError: true,
 		}, nil
 	}
@@ -145,14 +190,17 @@ func (e *EditTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 
 	// Perform replacement
 	var newContent string
-	if params.ReplaceAll {
-		newContent = strings.ReplaceAll(oldContent, params.OldString, params.NewString)
-	} else {
-		newContent = strings.Replace(oldContent, params.OldString, params.NewString, 1)
+	switch {
+	case params.ReplaceAll:
+		newContent = strings.ReplaceAll(oldContent, oldString, newString)
+	case params.Occurrence > 0:
+		newContent = replaceOccurrence(oldContent, oldString, newString, params.Occurrence)
+	default:
+		newContent = strings.Replace(oldContent, oldString, newString, 1)
 	}
 
 	// Write back
-	if err := os.WriteFile(params.FilePath, []byte(newContent), 0644); err != nil {
+	if err := ws.WriteFile(params.FilePath, []byte(newContent)); err != nil {
 		return &tool.Output{
 			Content: fmt.Sprintf("Error writing file: %v", err),
 			IsError: true,
@@ -0,0 +1,97 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func runEdit(t *testing.T, content string, params map[string]interface{}) (string, *tool.Output) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	params["file_path"] = path
+
+	edit := NewEditTool()
+	input := &tool.Input{Params: params}
+	if err := edit.Validate(input); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+	output, err := edit.Execute(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	return string(data), output
+}
+
+func TestEditOccurrence(t *testing.T) {
+	content := "x := 1\nx := 1\nx := 1\n"
+
+	got, output := runEdit(t, content, map[string]interface{}{"old_string": "x := 1", "new_string": "x := 2", "occurrence": 2})
+	if output.IsError || got != "x := 1\nx := 2\nx := 1\n" {
+		t.Errorf("expected second occurrence replaced, got %q (%s)", got, output.Content)
+	}
+
+	_, output = runEdit(t, content, map[string]interface{}{"old_string": "x := 1", "new_string": "x := 2"})
+	if !output.IsError || !strings.Contains(output.Content, "at lines 1, 2, 3") {
+		t.Errorf("expected ambiguity error with line numbers, got %q", output.Content)
+	}
+
+	_, output = runEdit(t, content, map[string]interface{}{"old_string": "x := 1", "new_string": "x := 2", "occurrence": 4})
+	if !output.IsError {
+		t.Error("expected error for occurrence past the last match")
+	}
+}
+
+func TestEditValidateOccurrence(t *testing.T) {
+	input := &tool.Input{Params: map[string]interface{}{
+		"file_path": "/tmp/a.go", "old_string": "a", "new_string": "b", "occurrence": 1, "replace_all": true,
+	}}
+	if err := NewEditTool().Validate(input); err == nil {
+		t.Error("expected error for occurrence with replace_all")
+	}
+}
+
+func TestEditMismatchDiagnostics(t *testing.T) {
+	content := "func main() {\n\tfmt.Println(\"hello\")\n\treturn\n}\n"
+
+	_, output := runEdit(t, content, map[string]interface{}{
+		"old_string": "func main() {\n    fmt.Println(\"hello\")\n",
+		"new_string": "func main() {\n",
+	})
+	if !output.IsError {
+		t.Fatal("expected error for indentation mismatch")
+	}
+	for _, want := range []string{"Closest match is lines 1-2", "(whitespace only)", "old_string indents with spaces, the file uses tabs"} {
+		if !strings.Contains(output.Content, want) {
+			t.Errorf("expected diagnostics to contain %q, got:\n%s", want, output.Content)
+		}
+	}
+
+	_, output = runEdit(t, content, map[string]interface{}{
+		"old_string": "\tfmt.Println(\"helo\")",
+		"new_string": "x",
+	})
+	if !strings.Contains(output.Content, "-     2  \tfmt.Println(\"helo\")") || !strings.Contains(output.Content, "+     2  \tfmt.Println(\"hello\")") {
+		t.Errorf("expected line diff against the closest match, got:\n%s", output.Content)
+	}
+
+	_, output = runEdit(t, content, map[string]interface{}{"old_string": "completely unrelated text", "new_string": "x"})
+	if !strings.Contains(output.Content, "No similar text was found") {
+		t.Errorf("expected no match, got:\n%s", output.Content)
+	}
+}
+
+func TestEditWindowsLineEndings(t *testing.T) {
+	got, output := runEdit(t, "a\r\nb\r\nc\r\n", map[string]interface{}{"old_string": "a\nb", "new_string": "a\nx"})
+	if output.IsError || got != "a\r\nx\r\nc\r\n" {
+		t.Errorf("expected CRLF edit, got %q (%s)", got, output.Content)
+	}
+}
@@ -0,0 +1,201 @@
+package builtin
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// minMatchScore is the similarity below which no closest match is reported
+	minMatchScore = 0.5
+
+	// Fuzzy matching is skipped for inputs larger than these
+	maxFuzzyAnchorLines = 200
+	maxFuzzyFileLines   = 50000
+)
+
+// fuzzyMatch is the part of a file most similar to an edit anchor
+type fuzzyMatch struct {
+	StartLine int      // 1-based
+	Lines     []string // file lines of the match
+	Score     float64  // average line similarity, 0-1
+}
+
+// occurrenceLines returns the 1-based line numbers where s occurs in content
+func occurrenceLines(content, s string) []int {
+	var lines []int
+	offset := 0
+	for {
+		i := strings.Index(content[offset:], s)
+		if i < 0 {
+			return lines
+		}
+		offset += i
+		lines = append(lines, strings.Count(content[:offset], "\n")+1)
+		offset += len(s)
+	}
+}
+
+// replaceOccurrence replaces the n-th (1-based) occurrence of old in content
+func replaceOccurrence(content, old, new string, n int) string {
+	offset := 0
+	for i := 1; ; i++ {
+		j := strings.Index(content[offset:], old)
+		if j < 0 {
+			return content
+		}
+		offset += j
+		if i == n {
+			return content[:offset] + new + content[offset+len(old):]
+		}
+		offset += len(old)
+	}
+}
+
+// closestMatch finds the window of file lines most similar to anchor,
+// comparing lines with surrounding whitespace ignored
+func closestMatch(content, anchor string) *fuzzyMatch {
+	anchorLines := strings.Split(strings.TrimRight(anchor, "\n"), "\n")
+	fileLines := strings.Split(content, "\n")
+	n := len(anchorLines)
+	if n > maxFuzzyAnchorLines || len(fileLines) > maxFuzzyFileLines || n > len(fileLines) {
+		return nil
+	}
+
+	anchorGrams := make([]map[string]int, n)
+	for i, line := range anchorLines {
+		anchorGrams[i] = bigrams(strings.TrimSpace(line))
+	}
+	fileGrams := make([]map[string]int, len(fileLines))
+	for i, line := range fileLines {
+		fileGrams[i] = bigrams(strings.TrimSpace(line))
+	}
+
+	var best *fuzzyMatch
+	for start := 0; start+n <= len(fileLines); start++ {
+		total := 0.0
+		for i := 0; i < n; i++ {
+			total += lineSimilarity(anchorLines[i], fileLines[start+i], anchorGrams[i], fileGrams[start+i])
+		}
+		score := total / float64(n)
+		if best == nil || score > best.Score {
+			best = &fuzzyMatch{StartLine: start + 1, Lines: fileLines[start : start+n], Score: score}
+		}
+	}
+
+	if best == nil || best.Score < minMatchScore {
+		return nil
+	}
+	return best
+}
+
+// lineSimilarity compares two lines ignoring surrounding whitespace
+func lineSimilarity(a, b string, aGrams, bGrams map[string]int) float64 {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if a == b {
+		return 1
+	}
+	if len(a) < 2 || len(b) < 2 {
+		return 0
+	}
+
+	// Dice coefficient over character bigrams
+	shared := 0
+	for gram, count := range aGrams {
+		shared += min(count, bGrams[gram])
+	}
+	return 2 * float64(shared) / float64(len(a)-1+len(b)-1)
+}
+
+// bigrams counts the character pairs of s
+func bigrams(s string) map[string]int {
+	grams := make(map[string]int)
+	for i := 0; i+1 < len(s); i++ {
+		grams[s[i:i+2]]++
+	}
+	return grams
+}
+
+// describeMismatch explains how anchor differs from its closest match: a
+// line diff and an analysis of whitespace differences
+func describeMismatch(anchor string, match *fuzzyMatch) string {
+	anchorLines := strings.Split(strings.TrimRight(anchor, "\n"), "\n")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Closest match is lines %d-%d (%.0f%% similar). Differences (- old_string, + file):\n",
+		match.StartLine, match.StartLine+len(match.Lines)-1, match.Score*100)
+
+	for i, want := range anchorLines {
+		got := match.Lines[i]
+		lineNo := match.StartLine + i
+		switch {
+		case want == got:
+			fmt.Fprintf(&b, "  %5d  %s\n", lineNo, got)
+		case strings.TrimSpace(want) == strings.TrimSpace(got):
+			fmt.Fprintf(&b, "- %5d  %s\n+ %5d  %s    (whitespace only)\n", lineNo, visibleWhitespace(want), lineNo, visibleWhitespace(got))
+		default:
+			fmt.Fprintf(&b, "- %5d  %s\n+ %5d  %s\n", lineNo, want, lineNo, got)
+		}
+	}
+
+	if notes := whitespaceNotes(anchorLines, match.Lines); len(notes) > 0 {
+		b.WriteString("\nWhitespace:\n")
+		for _, note := range notes {
+			fmt.Fprintf(&b, "- %s\n", note)
+		}
+	}
+
+	b.WriteString("\nCopy the exact text from the file (use Read to see it) and retry.")
+	return b.String()
+}
+
+// whitespaceNotes summarizes indentation and trailing whitespace differences
+// between anchor lines and file lines that otherwise match
+func whitespaceNotes(anchorLines, fileLines []string) []string {
+	var notes []string
+	tabsVsSpaces, spacesVsTabs, depth, trailing := false, false, false, false
+
+	for i, want := range anchorLines {
+		got := fileLines[i]
+		if want == got || strings.TrimSpace(want) != strings.TrimSpace(got) {
+			continue
+		}
+		wantIndent, gotIndent := leadingWhitespace(want), leadingWhitespace(got)
+		switch {
+		case wantIndent == gotIndent:
+			trailing = true
+		case strings.Contains(wantIndent, " ") && strings.Contains(gotIndent, "\t"):
+			spacesVsTabs = true
+		case strings.Contains(wantIndent, "\t") && strings.Contains(gotIndent, " "):
+			tabsVsSpaces = true
+		default:
+			depth = true
+		}
+	}
+
+	if spacesVsTabs {
+		notes = append(notes, "old_string indents with spaces, the file uses tabs")
+	}
+	if tabsVsSpaces {
+		notes = append(notes, "old_string indents with tabs, the file uses spaces")
+	}
+	if depth {
+		notes = append(notes, "indentation depth differs from the file")
+	}
+	if trailing {
+		notes = append(notes, "trailing whitespace differs from the file")
+	}
+	return notes
+}
+
+// leadingWhitespace returns the indentation of a line
+func leadingWhitespace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// visibleWhitespace shows tabs and trailing spaces in a line
+func visibleWhitespace(line string) string {
+	line = strings.ReplaceAll(line, "\t", "→   ")
+	trimmed := strings.TrimRight(line, " ")
+	return trimmed + strings.Repeat("·", len(line)-len(trimmed))
+}
@@ -0,0 +1,117 @@
+package builtin
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/xinguang/agentic-coder/pkg/secret"
+)
+
+// How the environment of commands is chosen
+const (
+	EnvAllowlist = "allowlist" // only DefaultEnvAllow and configured variables
+	EnvDenylist  = "denylist"  // every variable but secret-looking ones
+)
+
+// DefaultEnvAllow are the variables commands receive in allowlist mode:
+// what shells, toolchains and package managers need to find themselves.
+// A trailing * matches any suffix. Secret-looking names are withheld even
+// when they match.
+var DefaultEnvAllow = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "PWD", "HOSTNAME",
+	"TERM", "COLORTERM", "NO_COLOR", "LANG", "LANGUAGE", "LC_*", "TZ",
+	"TMPDIR", "TMP", "TEMP", "XDG_*", "EDITOR", "VISUAL", "PAGER",
+	"SSH_AUTH_SOCK", "GIT_*", "CI",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy",
+	"SSL_CERT_FILE", "SSL_CERT_DIR",
+	"GO*", "CGO_*", "CC", "CXX", "CFLAGS", "CXXFLAGS", "LDFLAGS", "PKG_CONFIG_PATH", "LD_LIBRARY_PATH",
+	"NODE_ENV", "NODE_OPTIONS", "NODE_PATH", "NVM_*", "PNPM_HOME", "npm_config_*",
+	"PYTHONPATH", "PYTHONHOME", "VIRTUAL_ENV", "CONDA_*", "PYENV_*",
+	"CARGO_HOME", "RUSTUP_HOME", "RUSTFLAGS", "JAVA_HOME", "GRADLE_USER_HOME", "MAVEN_OPTS",
+	"DOCKER_HOST", "DOCKER_CONFIG", "DOCKER_CONTEXT", "KUBECONFIG",
+}
+
+// EnvPolicy decides which of the agent's environment variables the
+// commands it runs receive
+type EnvPolicy struct {
+	Mode  string   // EnvAllowlist or EnvDenylist; "" is EnvAllowlist
+	Allow []string // passed besides the defaults, even when they look secret
+	Deny  []string // never passed
+}
+
+// Passes reports whether commands receive the variable name
+func (p EnvPolicy) Passes(name string) bool {
+	if matchEnvName(p.Deny, name) {
+		return false
+	}
+	if matchEnvName(p.Allow, name) {
+		return true
+	}
+	if secret.IsName(name) {
+		return false
+	}
+	return p.Mode == EnvDenylist || matchEnvName(DefaultEnvAllow, name)
+}
+
+// Filter returns the NAME=value entries of env the policy passes
+func (p EnvPolicy) Filter(env []string) []string {
+	filtered := make([]string, 0, len(env))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if p.Passes(name) {
+			filtered = append(filtered, kv)
+		}
+	}
+	return filtered
+}
+
+// matchEnvName reports whether name is one of patterns, which may end in *
+func matchEnvName(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if pattern == name {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	envMu     sync.RWMutex
+	envPolicy EnvPolicy
+)
+
+// SetEnvPolicy sets the policy for the environment of every command the
+// tools run: Bash, background shells, and project tools such as tests
+func SetEnvPolicy(p EnvPolicy) {
+	envMu.Lock()
+	defer envMu.Unlock()
+	envPolicy = p
+}
+
+// CurrentEnvPolicy returns the policy set with SetEnvPolicy
+func CurrentEnvPolicy() EnvPolicy {
+	envMu.RLock()
+	defer envMu.RUnlock()
+	return envPolicy
+}
+
+// CommandEnv returns the environment commands receive under the current
+// policy
+func CommandEnv() []string {
+	return CurrentEnvPolicy().Filter(os.Environ())
+}
+
+// setCommandEnv gives cmd the environment the policy passes, unless its
+// workspace already chose the environment of its client, as a container
+// does when it forwards variables into the container
+func setCommandEnv(cmd *exec.Cmd) {
+	if cmd.Env == nil {
+		cmd.Env = CommandEnv()
+	}
+}
@@ -0,0 +1,74 @@
+package builtin
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestEnvPolicy(t *testing.T) {
+	env := []string{
+		"PATH=/usr/bin",
+		"HOME=/home/me",
+		"LC_ALL=C",
+		"GOFLAGS=-mod=mod",
+		"OPENAI_API_KEY=sk-1",
+		"GITHUB_TOKEN=ghp_1",
+		"NPM_TOKEN=npm_1",
+		"DATABASE_URL=postgres://app",
+		"MY_TOOL_HOME=/opt/tool",
+	}
+	tests := []struct {
+		name   string
+		policy EnvPolicy
+		want   []string
+	}{
+		{"default allowlist", EnvPolicy{}, []string{"PATH", "HOME", "LC_ALL", "GOFLAGS"}},
+		{"allowed names", EnvPolicy{Allow: []string{"MY_TOOL_*", "NPM_TOKEN"}}, []string{"PATH", "HOME", "LC_ALL", "GOFLAGS", "NPM_TOKEN", "MY_TOOL_HOME"}},
+		{"denied names", EnvPolicy{Deny: []string{"GO*"}, Allow: []string{"GOFLAGS"}}, []string{"PATH", "HOME", "LC_ALL"}},
+		{"denylist", EnvPolicy{Mode: EnvDenylist}, []string{"PATH", "HOME", "LC_ALL", "GOFLAGS", "DATABASE_URL", "MY_TOOL_HOME"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, kv := range tt.policy.Filter(env) {
+			name, _, _ := strings.Cut(kv, "=")
+			got = append(got, name)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestBashEnvPolicy(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-secret")
+	t.Setenv("BUILD_FLAVOR", "release")
+	defer SetEnvPolicy(EnvPolicy{})
+
+	bash := NewBashTool()
+	run := func() string {
+		out, err := bash.Execute(context.Background(), &tool.Input{
+			Context: &tool.ExecutionContext{CWD: t.TempDir()},
+			Params:  map[string]interface{}{"command": `echo "[$ANTHROPIC_API_KEY][$BUILD_FLAVOR]"`},
+		})
+		if err != nil || out.IsError {
+			t.Fatalf("bash failed: %+v, %v", out, err)
+		}
+		return strings.TrimSpace(out.Content)
+	}
+
+	if got := run(); got != "[][]" {
+		t.Errorf("expected neither variable by default, got %q", got)
+	}
+	SetEnvPolicy(EnvPolicy{Allow: []string{"BUILD_*"}})
+	if got := run(); got != "[][release]" {
+		t.Errorf("expected the allowed variable, got %q", got)
+	}
+	SetEnvPolicy(EnvPolicy{Mode: EnvDenylist})
+	if got := run(); got != "[][release]" {
+		t.Errorf("expected all but the secret, got %q", got)
+	}
+}
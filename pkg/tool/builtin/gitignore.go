@@ -0,0 +1,101 @@
+package builtin
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// alwaysIgnored are directories skipped whether or not they are gitignored
+var alwaysIgnored = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"__pycache__":  true,
+	".venv":        true,
+}
+
+// ignoreRule is one pattern from a .gitignore file
+type ignoreRule struct {
+	base     string // slash-separated directory of the .gitignore, relative to the root
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool // matched against the path relative to base, not just the name
+}
+
+// gitignore matches paths against the .gitignore files from the root down
+type gitignore struct {
+	rules []ignoreRule
+}
+
+// withDir returns the matcher extended with the .gitignore in dir, if any.
+// rel is the slash-separated path of dir relative to the root.
+func (g gitignore) withDir(dir, rel string) gitignore {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return g
+	}
+	defer f.Close()
+
+	rules := append([]ignoreRule(nil), g.rules...)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: rel}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return gitignore{rules: rules}
+}
+
+// ignored reports whether the slash-separated path rel is ignored; the last
+// matching rule wins
+func (g gitignore) ignored(rel string, isDir bool) bool {
+	if alwaysIgnored[path.Base(rel)] && isDir {
+		return true
+	}
+
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			sub = strings.TrimPrefix(rel, rule.base+"/")
+		}
+		target := path.Base(sub)
+		if rule.anchored {
+			target = sub
+		}
+		if ok, _ := doublestar.Match(rule.pattern, target); ok {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
@@ -4,18 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 
-	"github.com/bmatcuk/doublestar/v4"
-
+	"github.com/xinguang/agentic-coder/pkg/agentignore"
 	"github.com/xinguang/agentic-coder/pkg/tool"
 )
 
 // GlobTool implements file pattern matching
-type GlobTool struct{}
+type GlobTool struct {
+	// Workspace is where files are found (nil = the local machine)
+	Workspace Workspace
+}
 
 // GlobInput represents the input for Glob tool
 type GlobInput struct {
@@ -36,6 +37,7 @@ func (g *GlobTool) Description() string {
 	return `Fast file pattern matching tool that works with any codebase size.
 - Supports glob patterns like "**/*.js" or "src/**/*.ts"
 - Returns matching file paths sorted by modification time
+- Leaves out files excluded by the project's .agentignore
 - Use this tool when you need to find files by name patterns`
 }
 
@@ -95,7 +97,7 @@ func (g *GlobTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 	fullPattern := filepath.Join(basePath, params.Pattern)
 
 	// Find matches
-	matches, err := doublestar.FilepathGlob(fullPattern)
+	matches, err := workspaceOr(g.Workspace).Glob(fullPattern)
 	if err != nil {
 		return &tool.Output{
 			Content: fmt.Sprintf("Error matching pattern: %v", err),
@@ -115,18 +117,27 @@ func (g *GlobTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 		modTime int64
 	}
 
+	var ignore *agentignore.Matcher
+	if input.Context != nil {
+		ignore = agentignore.Load(input.Context.CWD)
+	}
 	filesWithTime := make([]fileWithTime, 0, len(matches))
 	for _, match := range matches {
-		info, err := os.Stat(match)
-		if err != nil {
+		if ignore.Ignored(match.Path) {
 			continue
 		}
 		filesWithTime = append(filesWithTime, fileWithTime{
-			path:    match,
-			modTime: info.ModTime().Unix(),
+			path:    match.Path,
+			modTime: match.ModTime.Unix(),
 		})
 	}
 
+	if len(filesWithTime) == 0 {
+		return &tool.Output{
+			Content: "No files found",
+		}, nil
+	}
+
 	// Sort by modification time (newest first)
 	sort.Slice(filesWithTime, func(i, j int) bool {
 		return filesWithTime[i].modTime > filesWithTime[j].modTime
@@ -6,15 +6,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/xinguang/agentic-coder/pkg/agentignore"
 	"github.com/xinguang/agentic-coder/pkg/tool"
 )
 
 // GrepTool implements content searching using ripgrep
 type GrepTool struct {
 	RipgrepPath string
+
+	// Workspace is where ripgrep runs (nil = the local machine)
+	Workspace Workspace
 }
 
 // GrepInput represents the input for Grep tool
@@ -174,10 +179,7 @@ func (g *GrepTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 		args = append(args, "-U", "--multiline-dotall")
 	}
 
-	// Add pattern
-	args = append(args, params.Pattern)
-
-	// Add path
+	// Determine search path
 	searchPath := params.Path
 	if searchPath == "" && input.Context != nil {
 		searchPath = input.Context.CWD
@@ -185,10 +187,28 @@ func (g *GrepTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 	if searchPath == "" {
 		searchPath = "."
 	}
-	args = append(args, searchPath)
+
+	// Files the project's .agentignore excludes are left out of the results,
+	// which need file names to filter
+	var ignore *agentignore.Matcher
+	if input.Context != nil {
+		ignore = agentignore.Load(input.Context.CWD)
+	}
+	if ignore.Ignored(absPath(searchPath)) {
+		return &tool.Output{
+			Content: fmt.Sprintf("%s is excluded by %s", searchPath, agentignore.FileName),
+			IsError: true,
+		}, nil
+	}
+	if ignore != nil && params.OutputMode != "files_with_matches" && params.OutputMode != "" {
+		args = append(args, "--with-filename", "--null")
+	}
+
+	// Add pattern and path
+	args = append(args, params.Pattern, searchPath)
 
 	// Execute ripgrep
-	cmd := exec.CommandContext(ctx, g.RipgrepPath, args...)
+	cmd := workspaceOr(g.Workspace).Command(ctx, "", g.RipgrepPath, args...)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -198,6 +218,9 @@ func (g *GrepTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 
 	// ripgrep returns exit code 1 for no matches, which is not an error
 	output := stdout.String()
+	if ignore != nil {
+		output = withoutIgnored(output, ignore)
+	}
 	if output == "" && stderr.Len() > 0 {
 		output = stderr.String()
 	}
@@ -234,3 +257,45 @@ func (g *GrepTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 		},
 	}, nil
 }
+
+// withoutIgnored drops the results in ignored files from ripgrep output.
+// Lines with a NUL after the file name, from --null, get the usual separator
+// back.
+func withoutIgnored(output string, ignore *agentignore.Matcher) string {
+	var kept []string
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		file, rest, hasNull := strings.Cut(line, "\x00")
+		switch {
+		case line == "--":
+			// Context separators are kept between the remaining results only
+			if len(kept) > 0 && kept[len(kept)-1] != "--" {
+				kept = append(kept, line)
+			}
+			continue
+		case line == "" || ignore.Ignored(absPath(file)):
+			continue
+		case hasNull:
+			sep := ":"
+			if after := strings.TrimLeft(rest, "0123456789"); after != rest && strings.HasPrefix(after, "-") {
+				sep = "-" // a context line
+			}
+			line = file + sep + rest
+		}
+		kept = append(kept, line)
+	}
+	if len(kept) > 0 && kept[len(kept)-1] == "--" {
+		kept = kept[:len(kept)-1]
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	return strings.Join(kept, "\n") + "\n"
+}
+
+// absPath resolves a path ripgrep printed against the working directory
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,74 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// agentignoreProject creates a project whose .agentignore hides secrets/ and
+// generated files
+func agentignoreProject(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		".agentignore":     "secrets/\n*.gen.go\n",
+		"main.go":          "package main // token\n",
+		"api.gen.go":       "package main // token\n",
+		"secrets/prod.env": "token=x\n",
+	} {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+	return root
+}
+
+func TestGlobAgentignore(t *testing.T) {
+	root := agentignoreProject(t)
+	out, err := NewGlobTool().Execute(context.Background(), &tool.Input{
+		Params:  map[string]interface{}{"pattern": "**/*"},
+		Context: &tool.ExecutionContext{CWD: root},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.Content, "secrets") || strings.Contains(out.Content, "api.gen.go") || !strings.Contains(out.Content, "main.go") {
+		t.Errorf("unexpected matches:\n%s", out.Content)
+	}
+}
+
+func TestGrepAgentignore(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("ripgrep not installed")
+	}
+	root := agentignoreProject(t)
+	grep := NewGrepTool()
+
+	for _, mode := range []string{"files_with_matches", "content", "count"} {
+		out, err := grep.Execute(context.Background(), &tool.Input{
+			Params:  map[string]interface{}{"pattern": "token", "output_mode": mode},
+			Context: &tool.ExecutionContext{CWD: root},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, want := strings.TrimSpace(out.Content), filepath.Join(root, "main.go")
+		if got != want && got != want+":1:package main // token" && got != want+":1" {
+			t.Errorf("%s: unexpected output %q", mode, out.Content)
+		}
+	}
+
+	out, _ := grep.Execute(context.Background(), &tool.Input{
+		Params:  map[string]interface{}{"pattern": "token", "path": filepath.Join(root, "secrets")},
+		Context: &tool.ExecutionContext{CWD: root},
+	})
+	if !out.IsError {
+		t.Errorf("expected searching an ignored directory to fail, got %q", out.Content)
+	}
+}
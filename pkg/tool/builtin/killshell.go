@@ -31,26 +31,19 @@ func (t *KillShellTool) Description() string {
 - Takes a shell_id parameter identifying the shell to kill
 - Returns a success or failure status
 - Use this tool when you need to terminate a long-running shell
-- Shell IDs can be found using the /tasks command`
+- Shell IDs are returned by Bash with run_in_background`
 }
 
 func (t *KillShellTool) InputSchema() json.RawMessage {
-	return json.RawMessage(`{
-		"type": "object",
-		"properties": {
-			"shell_id": {
-				"type": "string",
-				"description": "The ID of the background shell to kill"
-			}
-		},
-		"required": ["shell_id"]
-	}`)
+	return killShellSchema
 }
 
 type killShellParams struct {
-	ShellID string `json:"shell_id"`
+	ShellID string `json:"shell_id" desc:"The ID of the background shell to kill"`
 }
 
+var killShellSchema = tool.SchemaFor[killShellParams]()
+
 func (t *KillShellTool) Validate(input *tool.Input) error {
 	params, err := tool.ParamsTo[killShellParams](input.Params)
 	if err != nil {
@@ -77,7 +70,7 @@ func (t *KillShellTool) Execute(ctx context.Context, input *tool.Input) (*tool.O
 	shell := t.manager.Get(params.ShellID)
 	if shell == nil {
 		return &tool.Output{
-			Content: fmt.Sprintf("Shell '%s' not found. Use /tasks to list running shells.", params.ShellID),
+			Content: fmt.Sprintf("Shell '%s' not found. Use /ps to list running shells.", params.ShellID),
 			IsError: true,
 		}, nil
 	}
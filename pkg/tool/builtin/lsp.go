@@ -28,8 +28,9 @@ type LSPServer struct {
 	stdout   io.ReadCloser
 	reader   *bufio.Reader
 	msgID    int
-	pending  map[int]chan json.RawMessage
+	pending  map[int]chan lspResponse
 	mu       sync.Mutex
+	writeMu  sync.Mutex // one message is written at a time
 }
 
 // LSPInput represents the input for LSP tool
@@ -334,7 +335,7 @@ func startLSPServer(lang string) (*LSPServer, error) {
 		stdin:   stdin,
 		stdout:  stdout,
 		reader:  bufio.NewReader(stdout),
-		pending: make(map[int]chan json.RawMessage),
+		pending: make(map[int]chan lspResponse),
 	}
 
 	// Start response reader
@@ -354,7 +355,7 @@ func (s *LSPServer) sendRequest(ctx context.Context, method string, params inter
 	s.mu.Lock()
 	s.msgID++
 	id := s.msgID
-	responseCh := make(chan json.RawMessage, 1)
+	responseCh := make(chan lspResponse, 1)
 	s.pending[id] = responseCh
 	s.mu.Unlock()
 
@@ -378,8 +379,11 @@ func (s *LSPServer) sendRequest(ctx context.Context, method string, params inter
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
-	case result := <-responseCh:
-		return result, nil
+	case resp := <-responseCh:
+		if resp.Error != nil {
+			return nil, fmt.Errorf("%s: %s", method, resp.Error.Message)
+		}
+		return resp.Result, nil
 	}
 }
 
@@ -400,6 +404,9 @@ func (s *LSPServer) writeMessage(msg interface{}) error {
 		return err
 	}
 
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	header := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(data))
 	_, err = s.stdin.Write([]byte(header))
 	if err != nil {
@@ -440,6 +447,19 @@ func (s *LSPServer) readResponses() {
 			return
 		}
 
+		// Requests from the server get an empty reply and its notifications
+		// are ignored, so neither is taken for a response
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if json.Unmarshal(content, &msg) == nil && msg.Method != "" {
+			if len(msg.ID) > 0 {
+				s.writeMessage(map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID, "result": nil})
+			}
+			continue
+		}
+
 		// Parse response
 		var resp lspResponse
 		if err := json.Unmarshal(content, &resp); err != nil {
@@ -449,7 +469,7 @@ func (s *LSPServer) readResponses() {
 		// Deliver to waiting request
 		s.mu.Lock()
 		if ch, ok := s.pending[resp.ID]; ok {
-			ch <- resp.Result
+			ch <- resp
 		}
 		s.mu.Unlock()
 	}
@@ -469,9 +489,11 @@ func (s *LSPServer) initialize() error {
 				"references":     map[string]interface{}{"dynamicRegistration": true},
 				"hover":          map[string]interface{}{"dynamicRegistration": true},
 				"documentSymbol": map[string]interface{}{"dynamicRegistration": true},
+				"rename":         map[string]interface{}{"dynamicRegistration": true},
 			},
 			"workspace": map[string]interface{}{
-				"symbol": map[string]interface{}{"dynamicRegistration": true},
+				"symbol":        map[string]interface{}{"dynamicRegistration": true},
+				"workspaceEdit": map[string]interface{}{"documentChanges": true},
 			},
 		},
 	}
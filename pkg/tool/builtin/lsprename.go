@@ -0,0 +1,427 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RenameEdit is a workspace-wide rename computed by a language server,
+// holding the old and new content of every file it changes
+type RenameEdit struct {
+	Files []FileRewrite
+
+	server *LSPServer
+}
+
+// FileRewrite is the content of a file before and after an edit
+type FileRewrite struct {
+	Path   string
+	Before []byte
+	After  []byte
+}
+
+// textEdit is an LSP text edit
+type textEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+// symbolPosition matches a file:line:column symbol argument
+var symbolPosition = regexp.MustCompile(`^(.+):(\d+):(\d+)$`)
+
+// Rename asks the language server to rename a symbol across the workspace
+// and returns the edit without applying it. The symbol is a name such as
+// "parseConfig" or "Server.Start", looked up with workspace/symbol, or a
+// file:line:column position (1-based).
+func (l *LSPTool) Rename(ctx context.Context, cwd, symbol, newName string) (*RenameEdit, error) {
+	file, pos, server, err := l.locateSymbol(ctx, cwd, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := server.sendRequest(ctx, "textDocument/rename", map[string]interface{}{
+		"textDocument": textDocumentIdentifier{URI: "file://" + file},
+		"position":     pos,
+		"newName":      newName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	edit, err := parseWorkspaceEdit(result)
+	if err != nil {
+		return nil, err
+	}
+	edit.server = server
+	return edit, nil
+}
+
+// locateSymbol returns the file and position of a symbol argument and the
+// server for its language
+func (l *LSPTool) locateSymbol(ctx context.Context, cwd, symbol string) (string, position, *LSPServer, error) {
+	if m := symbolPosition.FindStringSubmatch(symbol); m != nil {
+		path := m[1]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		line, _ := strconv.Atoi(m[2])
+		character, _ := strconv.Atoi(m[3])
+		lang := detectLanguage(path)
+		if lang == "" {
+			return "", position{}, nil, fmt.Errorf("unsupported file type: %s", filepath.Ext(path))
+		}
+		server, err := l.getServer(lang)
+		if err != nil {
+			return "", position{}, nil, fmt.Errorf("failed to start LSP server for %s: %w", lang, err)
+		}
+		return path, position{Line: line - 1, Character: character - 1}, server, nil
+	}
+
+	lang := projectLanguage(cwd)
+	if lang == "" {
+		return "", position{}, nil, fmt.Errorf("cannot tell the project language; give the symbol as file:line:column")
+	}
+	server, err := l.getServer(lang)
+	if err != nil {
+		return "", position{}, nil, fmt.Errorf("failed to start LSP server for %s: %w", lang, err)
+	}
+
+	found, err := server.workspaceSymbol(ctx, shortName(symbol))
+	if err != nil {
+		return "", position{}, nil, err
+	}
+	matches := matchSymbols(found.([]symbolInformation), symbol, cwd)
+	switch len(matches) {
+	case 0:
+		return "", position{}, nil, fmt.Errorf("symbol %q not found", symbol)
+	case 1:
+	default:
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "symbol %q is ambiguous; give one as file:line:column:", symbol)
+		for i, sym := range matches {
+			if i == 10 {
+				fmt.Fprintf(&sb, "\n  ... and %d more", len(matches)-i)
+				break
+			}
+			path := uriPath(sym.Location.URI)
+			if rel, err := filepath.Rel(cwd, path); err == nil {
+				path = rel
+			}
+			fmt.Fprintf(&sb, "\n  %s (%s) %s:%d", sym.Name, symbolKindName(sym.Kind), path, sym.Location.Range.Start.Line+1)
+		}
+		return "", position{}, nil, fmt.Errorf("%s", sb.String())
+	}
+
+	path := uriPath(matches[0].Location.URI)
+	return path, identifierPosition(path, matches[0].Location.Range, shortName(symbol)), server, nil
+}
+
+// Apply writes every file of the edit or none of them. New contents are
+// written to temporary files next to the originals, which are replaced
+// only once all were written; a failed replacement restores the files
+// already replaced. Files changed since the rename was computed are an
+// error.
+func (e *RenameEdit) Apply() error {
+	temps := make([]string, 0, len(e.Files))
+	defer func() {
+		for _, name := range temps {
+			os.Remove(name)
+		}
+	}()
+
+	for _, f := range e.Files {
+		current, err := os.ReadFile(f.Path)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(current, f.Before) {
+			return fmt.Errorf("%s changed since the rename was prepared", f.Path)
+		}
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			return err
+		}
+
+		tmp, err := os.CreateTemp(filepath.Dir(f.Path), "."+filepath.Base(f.Path)+".*")
+		if err != nil {
+			return err
+		}
+		temps = append(temps, tmp.Name())
+		_, err = tmp.Write(f.After)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(tmp.Name(), info.Mode().Perm())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+	}
+
+	for i, f := range e.Files {
+		if err := os.Rename(temps[i], f.Path); err != nil {
+			for _, done := range e.Files[:i] {
+				os.WriteFile(done.Path, done.Before, 0644)
+			}
+			return fmt.Errorf("failed to replace %s: %w", f.Path, err)
+		}
+	}
+
+	// Let the server see the new contents before the next request
+	if e.server != nil {
+		changes := make([]map[string]interface{}, 0, len(e.Files))
+		for _, f := range e.Files {
+			changes = append(changes, map[string]interface{}{"uri": "file://" + f.Path, "type": 2})
+		}
+		e.server.sendNotification("workspace/didChangeWatchedFiles", map[string]interface{}{"changes": changes})
+	}
+	return nil
+}
+
+// parseWorkspaceEdit reads the files and edits of a WorkspaceEdit and
+// computes their new contents
+func parseWorkspaceEdit(raw json.RawMessage) (*RenameEdit, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, fmt.Errorf("the language server cannot rename this symbol")
+	}
+
+	var we struct {
+		Changes         map[string][]textEdit `json:"changes"`
+		DocumentChanges []json.RawMessage     `json:"documentChanges"`
+	}
+	if err := json.Unmarshal(raw, &we); err != nil {
+		return nil, fmt.Errorf("invalid workspace edit: %w", err)
+	}
+
+	edits := make(map[string][]textEdit)
+	if len(we.DocumentChanges) > 0 {
+		for _, dc := range we.DocumentChanges {
+			var change struct {
+				Kind         string                 `json:"kind"`
+				TextDocument textDocumentIdentifier `json:"textDocument"`
+				Edits        []textEdit             `json:"edits"`
+			}
+			if err := json.Unmarshal(dc, &change); err != nil {
+				return nil, fmt.Errorf("invalid workspace edit: %w", err)
+			}
+			if change.Kind != "" {
+				return nil, fmt.Errorf("the rename needs to %s files, which is not supported", change.Kind)
+			}
+			path := uriPath(change.TextDocument.URI)
+			edits[path] = append(edits[path], change.Edits...)
+		}
+	} else {
+		for uri, changes := range we.Changes {
+			path := uriPath(uri)
+			edits[path] = append(edits[path], changes...)
+		}
+	}
+
+	paths := make([]string, 0, len(edits))
+	for path, fileEdits := range edits {
+		if len(fileEdits) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("the rename changes nothing")
+	}
+	sort.Strings(paths)
+
+	edit := &RenameEdit{}
+	for _, path := range paths {
+		before, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		after, err := applyTextEdits(before, edits[path])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		edit.Files = append(edit.Files, FileRewrite{Path: path, Before: before, After: after})
+	}
+	return edit, nil
+}
+
+// applyTextEdits returns content with non-overlapping LSP edits applied
+func applyTextEdits(content []byte, edits []textEdit) ([]byte, error) {
+	lines := strings.SplitAfter(string(content), "\n")
+	offset := func(pos position) (int, error) {
+		start := 0
+		for _, line := range lines[:min(pos.Line, len(lines))] {
+			start += len(line)
+		}
+		if pos.Line >= len(lines) {
+			if pos.Line == len(lines) && pos.Character == 0 {
+				return len(content), nil
+			}
+			return 0, fmt.Errorf("edit at line %d is past the end of the file", pos.Line+1)
+		}
+		return start + byteOffset(lines[pos.Line], pos.Character), nil
+	}
+
+	type span struct {
+		start, end int
+		text       string
+	}
+	spans := make([]span, 0, len(edits))
+	for _, e := range edits {
+		start, err := offset(e.Range.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := offset(e.Range.End)
+		if err != nil {
+			return nil, err
+		}
+		if end < start {
+			return nil, fmt.Errorf("edit at line %d ends before it starts", e.Range.Start.Line+1)
+		}
+		spans = append(spans, span{start, end, e.NewText})
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var out bytes.Buffer
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			return nil, fmt.Errorf("overlapping edits")
+		}
+		out.Write(content[last:s.start])
+		out.WriteString(s.text)
+		last = s.end
+	}
+	out.Write(content[last:])
+	return out.Bytes(), nil
+}
+
+// byteOffset converts a UTF-16 column, as LSP counts them, to a byte
+// offset in line
+func byteOffset(line string, character int) int {
+	units := 0
+	for i, r := range line {
+		if units >= character || r == '\n' {
+			return i
+		}
+		units += utf16Len(r)
+	}
+	return len(line)
+}
+
+// utf16Len returns the number of UTF-16 code units of r
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// matchSymbols returns the workspace symbols under cwd named symbol, which
+// may be qualified by its container as in "Server.Start"
+func matchSymbols(symbols []symbolInformation, symbol, cwd string) []symbolInformation {
+	var matches []symbolInformation
+	for _, sym := range symbols {
+		path := uriPath(sym.Location.URI)
+		if rel, err := filepath.Rel(cwd, path); err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		full := sym.Name
+		if sym.ContainerName != "" {
+			full = sym.ContainerName + "." + sym.Name
+		}
+		if sym.Name == symbol || full == symbol || strings.HasSuffix(sym.Name, "."+symbol) || strings.HasSuffix(full, "."+symbol) {
+			matches = append(matches, sym)
+		}
+	}
+	return matches
+}
+
+// identifierPosition returns the position of name within a symbol's range,
+// which some servers start at the declaration keyword, falling back to the
+// start of the range
+func identifierPosition(path string, r lspRange, name string) position {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return r.Start
+	}
+	lines := strings.Split(string(content), "\n")
+	for line := r.Start.Line; line <= r.End.Line && line < len(lines); line++ {
+		text := lines[line]
+		from := 0
+		if line == r.Start.Line {
+			from = byteOffset(text, r.Start.Character)
+		}
+		if i := indexIdentifier(text[from:], name); i >= 0 {
+			units := 0
+			for _, c := range text[:from+i] {
+				units += utf16Len(c)
+			}
+			return position{Line: line, Character: units}
+		}
+	}
+	return r.Start
+}
+
+// indexIdentifier returns the index of name in s as a whole word, or -1
+func indexIdentifier(s, name string) int {
+	for offset := 0; ; {
+		i := strings.Index(s[offset:], name)
+		if i < 0 {
+			return -1
+		}
+		start, end := offset+i, offset+i+len(name)
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if !isIdentRune(before) && !isIdentRune(after) {
+			return start
+		}
+		offset = end
+	}
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// shortName returns the last part of a qualified symbol name
+func shortName(symbol string) string {
+	return symbol[strings.LastIndex(symbol, ".")+1:]
+}
+
+// uriPath returns the file path of a file:// URI
+func uriPath(uri string) string {
+	return strings.TrimPrefix(uri, "file://")
+}
+
+// projectLanguage guesses the language of the project in dir from its
+// build files
+func projectLanguage(dir string) string {
+	markers := []struct{ file, lang string }{
+		{"go.mod", "go"},
+		{"Cargo.toml", "rust"},
+		{"tsconfig.json", "typescript"},
+		{"package.json", "javascript"},
+		{"pyproject.toml", "python"},
+		{"setup.py", "python"},
+		{"requirements.txt", "python"},
+		{"compile_commands.json", "cpp"},
+		{"CMakeLists.txt", "cpp"},
+	}
+	for _, m := range markers {
+		if _, err := os.Stat(filepath.Join(dir, m.file)); err == nil {
+			return m.lang
+		}
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package builtin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestApplyTextEdits(t *testing.T) {
+	content := []byte("func old() {}\n// 😀 old\nx := old()\n")
+	edits := []textEdit{
+		{Range: lspRange{Start: position{2, 5}, End: position{2, 8}}, NewText: "renamed"},
+		{Range: lspRange{Start: position{0, 5}, End: position{0, 8}}, NewText: "renamed"},
+		// The emoji is two UTF-16 code units
+		{Range: lspRange{Start: position{1, 6}, End: position{1, 9}}, NewText: "renamed"},
+	}
+	got, err := applyTextEdits(content, edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "func renamed() {}\n// 😀 renamed\nx := renamed()\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	overlapping := []textEdit{
+		{Range: lspRange{Start: position{0, 0}, End: position{0, 8}}, NewText: "a"},
+		{Range: lspRange{Start: position{0, 5}, End: position{0, 9}}, NewText: "b"},
+	}
+	if _, err := applyTextEdits(content, overlapping); err == nil {
+		t.Error("expected an error for overlapping edits")
+	}
+}
+
+func TestParseWorkspaceEdit(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.go")
+	writeFiles(t, dir, map[string]string{
+		"a.go": "package p\n\nfunc Old() {}\n",
+		"b.go": "package p\n\nvar _ = Old\n",
+	})
+
+	edit := func(path string, line, char int) string {
+		return `{"textDocument":{"uri":"file://` + path + `","version":1},"edits":[{"range":{"start":{"line":` +
+			strconv.Itoa(line) + `,"character":` + strconv.Itoa(char) + `},"end":{"line":` + strconv.Itoa(line) + `,"character":` + strconv.Itoa(char+3) + `}},"newText":"New"}]}`
+	}
+	raw := json.RawMessage(`{"documentChanges":[` + edit(b, 2, 8) + `,` + edit(a, 2, 5) + `]}`)
+
+	result, err := parseWorkspaceEdit(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 2 || result.Files[0].Path != a || string(result.Files[1].After) != "package p\n\nvar _ = New\n" {
+		t.Fatalf("unexpected edit: %+v", result.Files)
+	}
+
+	if err := result.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(a); string(data) != "package p\n\nfunc New() {}\n" {
+		t.Errorf("a.go was not renamed: %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("expected no temporary files left, got %d entries", len(entries))
+	}
+
+	// Applying again fails because the files changed since
+	if err := result.Apply(); err == nil || !strings.Contains(err.Error(), "changed since") {
+		t.Errorf("expected a changed file error, got %v", err)
+	}
+
+	if _, err := parseWorkspaceEdit(json.RawMessage(`{"documentChanges":[{"kind":"rename","oldUri":"file:///a","newUri":"file:///b"}]}`)); err == nil {
+		t.Error("expected file operations to be refused")
+	}
+	if _, err := parseWorkspaceEdit(json.RawMessage(`null`)); err == nil {
+		t.Error("expected an error for a null edit")
+	}
+}
+
+func TestMatchSymbols(t *testing.T) {
+	sym := func(name, container, path string) symbolInformation {
+		return symbolInformation{Name: name, ContainerName: container, Location: location{URI: "file://" + path}}
+	}
+	symbols := []symbolInformation{
+		sym("Start", "Server", "/p/server.go"),
+		sym("Client.Start", "", "/p/client.go"),
+		sym("StartAll", "", "/p/all.go"),
+		sym("Start", "Server", "/elsewhere/server.go"),
+	}
+
+	if got := matchSymbols(symbols, "Server.Start", "/p"); len(got) != 1 || got[0].Location.URI != "file:///p/server.go" {
+		t.Errorf("expected the project's Server.Start, got %+v", got)
+	}
+	if got := matchSymbols(symbols, "Start", "/p"); len(got) != 2 {
+		t.Errorf("expected Start to match two methods, got %+v", got)
+	}
+}
+
+func TestIdentifierPosition(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"s.go": "package p\n\nfunc (s *Server) Start() {}\n"})
+
+	// The range starts at the func keyword
+	r := lspRange{Start: position{2, 0}, End: position{2, 27}}
+	if got := identifierPosition(filepath.Join(dir, "s.go"), r, "Start"); got != (position{2, 17}) {
+		t.Errorf("got %+v", got)
+	}
+	if i := indexIdentifier("StartAll(Start)", "Start"); i != 9 {
+		t.Errorf("expected the whole word at 9, got %d", i)
+	}
+}
@@ -0,0 +1,64 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/xinguang/agentic-coder/pkg/mcp"
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// MCPCallTool is a tool of an MCP server, registered as mcp__<server>__<tool>
+type MCPCallTool struct {
+	manager *mcp.Manager
+	tool    *mcp.Tool
+}
+
+// NewMCPCallTool creates a tool that calls t on its server
+func NewMCPCallTool(manager *mcp.Manager, t *mcp.Tool) *MCPCallTool {
+	return &MCPCallTool{manager: manager, tool: t}
+}
+
+func (t *MCPCallTool) Name() string {
+	return mcp.ToolName(t.tool.ServerName, t.tool.Name)
+}
+
+func (t *MCPCallTool) Description() string {
+	if t.tool.Description == "" {
+		return fmt.Sprintf("Tool %s of the %s MCP server", t.tool.Name, t.tool.ServerName)
+	}
+	return t.tool.Description
+}
+
+func (t *MCPCallTool) InputSchema() json.RawMessage {
+	if len(t.tool.InputSchema) == 0 {
+		return json.RawMessage(`{"type": "object", "properties": {}}`)
+	}
+	return t.tool.InputSchema
+}
+
+func (t *MCPCallTool) Validate(input *tool.Input) error {
+	return nil
+}
+
+func (t *MCPCallTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+	result, err := t.manager.CallTool(ctx, t.Name(), input.Params)
+	if err != nil {
+		return &tool.Output{Content: fmt.Sprintf("Error calling %s: %v", t.Name(), err), IsError: true}, nil
+	}
+
+	out := &tool.Output{
+		Content:    result.Text,
+		IsError:    result.IsError,
+		Structured: result.Structured,
+		Metadata:   map[string]interface{}{"server": t.tool.ServerName, "images": len(result.Images)},
+	}
+	for _, img := range result.Images {
+		out.Images = append(out.Images, &provider.ImageBlock{
+			Source: provider.ImageSource{Type: "base64", MediaType: img.MimeType, Data: img.Data},
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,293 @@
+package builtin
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Symbol is a named declaration in a source file
+type Symbol struct {
+	Name      string // "Name", or "Type.Name" for methods
+	Kind      string // func, method, type, class, ...
+	StartLine int    // 1-based, including the doc comment
+	EndLine   int
+}
+
+// Outline returns the top-level symbols declared in a source file. Go files
+// are parsed; other languages are scanned with declaration patterns.
+func Outline(path string, content []byte) []Symbol {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return goOutline(content)
+	case ".py", ".pyi":
+		return indentOutline(content, pythonDecl)
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs", ".java", ".kt", ".rs", ".c", ".h", ".cc", ".cpp", ".hpp", ".cs", ".swift", ".scala", ".php":
+		return braceOutline(content, braceDecls)
+	}
+	return nil
+}
+
+// FindSymbol returns the symbols matching name: an exact name, or the method
+// name alone ("Start" matches "Server.Start")
+func FindSymbol(symbols []Symbol, name string) []Symbol {
+	var exact, suffix []Symbol
+	for _, s := range symbols {
+		switch {
+		case s.Name == name:
+			exact = append(exact, s)
+		case strings.HasSuffix(s.Name, "."+name):
+			suffix = append(suffix, s)
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return suffix
+}
+
+// goOutline lists the functions, methods, and types of a Go file
+func goOutline(content []byte) []Symbol {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil && file == nil {
+		return nil
+	}
+
+	var symbols []Symbol
+	span := func(start, end token.Pos, doc *ast.CommentGroup) (int, int) {
+		if doc != nil {
+			start = doc.Pos()
+		}
+		return fset.Position(start).Line, fset.Position(end).Line
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			sym := Symbol{Name: d.Name.Name, Kind: "func"}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				sym.Name = receiverType(d.Recv.List[0].Type) + "." + d.Name.Name
+				sym.Kind = "method"
+			}
+			sym.StartLine, sym.EndLine = span(d.Pos(), d.End(), d.Doc)
+			symbols = append(symbols, sym)
+
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				sym := Symbol{Name: ts.Name.Name, Kind: "type"}
+				if len(d.Specs) == 1 {
+					sym.StartLine, sym.EndLine = span(d.Pos(), d.End(), d.Doc)
+				} else {
+					sym.StartLine, sym.EndLine = span(ts.Pos(), ts.End(), ts.Doc)
+				}
+				symbols = append(symbols, sym)
+			}
+		}
+	}
+	return symbols
+}
+
+// receiverType returns the type name of a method receiver
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return "?"
+}
+
+// declPattern matches a declaration line; the kind and name are submatches
+type declPattern struct {
+	re        *regexp.Regexp
+	kind      int // submatch index of the kind, 0 for a fixed kind
+	name      int
+	fixedKind string
+}
+
+var pythonDecl = []declPattern{
+	{re: regexp.MustCompile(`^(\s*)(?:async\s+)?(def|class)\s+(\w+)`), kind: 2, name: 3},
+}
+
+var braceDecls = []declPattern{
+	{re: regexp.MustCompile(`^(\s*)(?:export\s+)?(?:default\s+)?(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(function|fn|class|struct|enum|trait|interface|impl|object)\*?\s+(\w+)`), kind: 2, name: 3},
+	{re: regexp.MustCompile(`^(\s*)(?:export\s+)?(?:const|let|var)\s+(\w+)\s*=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*=>|\w+\s*=>)`), name: 2, fixedKind: "function"},
+	{re: regexp.MustCompile(`^(\s*)(?:(?:public|private|protected|static|final|abstract|override|async|virtual|inline)\s+)+[\w<>\[\],.? ]*?\b(\w+)\s*\([^;]*$`), name: 2, fixedKind: "method"},
+}
+
+// matchDecl returns the indentation, kind, and name of a declaration line
+func matchDecl(line string, patterns []declPattern) (indent int, kind, name string, ok bool) {
+	for _, p := range patterns {
+		m := p.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		kind = p.fixedKind
+		if p.kind > 0 {
+			kind = m[p.kind]
+		}
+		return len(m[1]), kind, m[p.name], true
+	}
+	return 0, "", "", false
+}
+
+// indentOutline lists declarations whose body extends while lines are indented deeper
+func indentOutline(content []byte, patterns []declPattern) []Symbol {
+	lines := strings.Split(string(content), "\n")
+	var symbols []Symbol
+	var classes []Symbol // enclosing classes, for method names
+
+	for i, line := range lines {
+		indent, kind, name, ok := matchDecl(line, patterns)
+		if !ok {
+			continue
+		}
+
+		end := i
+		for j := i + 1; j < len(lines); j++ {
+			if strings.TrimSpace(lines[j]) == "" {
+				continue
+			}
+			if len(lines[j])-len(strings.TrimLeft(lines[j], " \t")) <= indent {
+				break
+			}
+			end = j
+		}
+
+		for len(classes) > 0 && classes[len(classes)-1].EndLine < i+1 {
+			classes = classes[:len(classes)-1]
+		}
+		if indent > 0 && len(classes) == 0 {
+			continue // nested function
+		}
+		if len(classes) > 0 {
+			name = classes[len(classes)-1].Name + "." + name
+			if kind == "def" {
+				kind = "method"
+			}
+		}
+
+		sym := Symbol{Name: name, Kind: kind, StartLine: decoratedStart(lines, i) + 1, EndLine: end + 1}
+		symbols = append(symbols, sym)
+		if kind == "class" {
+			classes = append(classes, sym)
+		}
+	}
+	return symbols
+}
+
+// decoratedStart moves a declaration start up over decorators
+func decoratedStart(lines []string, i int) int {
+	for i > 0 && strings.HasPrefix(strings.TrimSpace(lines[i-1]), "@") {
+		i--
+	}
+	return i
+}
+
+// braceOutline lists declarations whose body is the following brace block
+func braceOutline(content []byte, patterns []declPattern) []Symbol {
+	lines := strings.Split(string(content), "\n")
+	var symbols []Symbol
+
+	for i := 0; i < len(lines); i++ {
+		_, kind, name, ok := matchDecl(lines[i], patterns)
+		if !ok || isKeyword(name) {
+			continue
+		}
+		end, ok := braceBlockEnd(lines, i)
+		if !ok {
+			continue
+		}
+		symbols = append(symbols, Symbol{Name: name, Kind: kind, StartLine: docStart(lines, i) + 1, EndLine: end + 1})
+	}
+	return symbols
+}
+
+// braceBlockEnd returns the line closing the first brace block opened at or
+// after line start, skipping braces in strings and line comments
+func braceBlockEnd(lines []string, start int) (int, bool) {
+	depth, opened := 0, false
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		var quote byte
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+			switch {
+			case quote != 0:
+				if c == '\\' {
+					j++
+				} else if c == quote {
+					quote = 0
+				}
+			case c == '"' || c == '\'' || c == '`':
+				quote = c
+			case c == '/' && j+1 < len(line) && line[j+1] == '/':
+				j = len(line)
+			case c == ';' && !opened:
+				return 0, false // declaration without a body
+			case c == '{':
+				depth++
+				opened = true
+			case c == '}':
+				depth--
+				if opened && depth == 0 {
+					return i, true
+				}
+			}
+		}
+		if !opened && i-start >= 5 {
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// docStart moves a declaration start up over its comment block and annotations
+func docStart(lines []string, i int) int {
+	for i > 0 {
+		prev := strings.TrimSpace(lines[i-1])
+		if strings.HasPrefix(prev, "//") || strings.HasPrefix(prev, "*") || strings.HasPrefix(prev, "/*") ||
+			strings.HasPrefix(prev, "@") || strings.HasPrefix(prev, "#[") {
+			i--
+			continue
+		}
+		break
+	}
+	return i
+}
+
+// isKeyword filters control statements matched as method declarations
+func isKeyword(name string) bool {
+	switch name {
+	case "if", "for", "while", "switch", "catch", "return", "new", "else", "do", "try":
+		return true
+	}
+	return false
+}
+
+// formatOutline lists symbols with their line ranges, at most max entries
+func formatOutline(symbols []Symbol, max int) string {
+	var b strings.Builder
+	for i, s := range symbols {
+		if i == max {
+			fmt.Fprintf(&b, "  ... and %d more\n", len(symbols)-i)
+			break
+		}
+		fmt.Fprintf(&b, "  %s %s (lines %d-%d)\n", s.Kind, s.Name, s.StartLine, s.EndLine)
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package builtin
+
+import (
+	"testing"
+)
+
+func TestOutlineGo(t *testing.T) {
+	src := `package a
+
+// Server serves requests
+type Server struct {
+	addr string
+}
+
+// Start starts the server
+func (s *Server) Start() error {
+	return nil
+}
+
+func helper[T any](v T) T {
+	return v
+}
+`
+	symbols := Outline("a.go", []byte(src))
+	want := []Symbol{
+		{Name: "Server", Kind: "type", StartLine: 3, EndLine: 6},
+		{Name: "Server.Start", Kind: "method", StartLine: 8, EndLine: 11},
+		{Name: "helper", Kind: "func", StartLine: 13, EndLine: 15},
+	}
+	if len(symbols) != len(want) {
+		t.Fatalf("Outline() = %+v, want %+v", symbols, want)
+	}
+	for i := range want {
+		if symbols[i] != want[i] {
+			t.Errorf("symbol %d = %+v, want %+v", i, symbols[i], want[i])
+		}
+	}
+}
+
+func TestOutlinePython(t *testing.T) {
+	src := `import os
+
+class Cache:
+    def __init__(self):
+        self.items = {}
+
+    @property
+    def size(self):
+        return len(self.items)
+
+def load(path):
+    def inner():
+        pass
+    return inner
+`
+	symbols := Outline("cache.py", []byte(src))
+	names := make(map[string]Symbol)
+	for _, s := range symbols {
+		names[s.Name] = s
+	}
+
+	if s := names["Cache"]; s.StartLine != 3 || s.EndLine != 9 {
+		t.Errorf("unexpected class range: %+v", s)
+	}
+	if s := names["Cache.size"]; s.Kind != "method" || s.StartLine != 7 || s.EndLine != 9 {
+		t.Errorf("unexpected method: %+v", s)
+	}
+	if s := names["load"]; s.StartLine != 11 || s.EndLine != 14 {
+		t.Errorf("unexpected function range: %+v", s)
+	}
+	if _, ok := names["inner"]; ok {
+		t.Error("nested functions should not be listed")
+	}
+}
+
+func TestOutlineBraceLanguages(t *testing.T) {
+	src := `import x from "y";
+
+/** Adds numbers. */
+export function add(a, b) {
+  if (a) {
+    return a + b;
+  }
+  return b; // "}"
+}
+
+export const mul = (a, b) => {
+  return a * b;
+};
+`
+	symbols := Outline("math.ts", []byte(src))
+	if len(symbols) != 2 {
+		t.Fatalf("expected 2 symbols, got %+v", symbols)
+	}
+	if s := symbols[0]; s.Name != "add" || s.StartLine != 3 || s.EndLine != 9 {
+		t.Errorf("unexpected symbol: %+v", s)
+	}
+	if s := symbols[1]; s.Name != "mul" || s.StartLine != 11 || s.EndLine != 13 {
+		t.Errorf("unexpected symbol: %+v", s)
+	}
+}
+
+func TestFindSymbol(t *testing.T) {
+	symbols := []Symbol{{Name: "Start"}, {Name: "Server.Start"}, {Name: "Client.Stop"}}
+
+	if got := FindSymbol(symbols, "Start"); len(got) != 1 || got[0].Name != "Start" {
+		t.Errorf("expected exact match first, got %+v", got)
+	}
+	if got := FindSymbol(symbols, "Stop"); len(got) != 1 || got[0].Name != "Client.Stop" {
+		t.Errorf("expected method name match, got %+v", got)
+	}
+	if got := FindSymbol(symbols, "Missing"); len(got) != 0 {
+		t.Errorf("expected no match, got %+v", got)
+	}
+}
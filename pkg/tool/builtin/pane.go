@@ -0,0 +1,86 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// paneTemplates are the built-in commands that show a background shell's
+// log in a new pane, without taking focus from the chat
+var paneTemplates = map[string]string{
+	"tmux":   "tmux split-window -d -v tail -n +1 -f {log}",
+	"zellij": "zellij run --name {title} --direction down -- tail -n +1 -f {log}",
+}
+
+// paneTimeout bounds the command that opens a pane
+const paneTimeout = 5 * time.Second
+
+// unsafeTitle matches the characters removed from pane titles
+var unsafeTitle = regexp.MustCompile(`[^A-Za-z0-9 ._:/-]+`)
+
+// PaneTemplate resolves the background_pane setting to a command template:
+// "auto" uses tmux or zellij when running inside one, "tmux" and "zellij"
+// use the built-in commands, and anything else is a custom template. It
+// returns "" when panes are off or no multiplexer is running.
+func PaneTemplate(setting string) (string, error) {
+	switch setting {
+	case "", "off":
+		return "", nil
+	case "auto":
+		switch {
+		case os.Getenv("TMUX") != "":
+			return paneTemplates["tmux"], nil
+		case os.Getenv("ZELLIJ") != "":
+			return paneTemplates["zellij"], nil
+		}
+		return "", nil
+	case "tmux", "zellij":
+		return paneTemplates[setting], nil
+	}
+	if !strings.Contains(setting, "{log}") {
+		return "", fmt.Errorf("background_pane must be auto, tmux, zellij, off, or a command containing {log}")
+	}
+	return setting, nil
+}
+
+// expandPaneTemplate fills in {log}, {title}, and {id} with shell-quoted
+// values, so the template is run with sh -c without quoting them itself
+func expandPaneTemplate(template, log, title, id string) string {
+	title = strings.TrimSpace(unsafeTitle.ReplaceAllString(title, ""))
+	if len(title) > 40 {
+		title = title[:40]
+	}
+	if title == "" {
+		title = "shell " + id
+	}
+	return strings.NewReplacer(
+		"{log}", shellQuote(log),
+		"{title}", shellQuote(title),
+		"{id}", shellQuote(id),
+	).Replace(template)
+}
+
+// openPane runs an expanded pane command
+func openPane(command string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), paneTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "/bin/sh", "-c", command).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// shellQuote quotes s as a single sh word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
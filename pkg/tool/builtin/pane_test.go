@@ -0,0 +1,85 @@
+package builtin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPaneTemplate(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("ZELLIJ", "")
+
+	tests := []struct {
+		setting string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"off", "", false},
+		{"auto", "", false},
+		{"zellij", paneTemplates["zellij"], false},
+		{"wezterm cli split-pane -- tail -f {log}", "wezterm cli split-pane -- tail -f {log}", false},
+		{"wezterm cli split-pane", "", true},
+	}
+	for _, tt := range tests {
+		got, err := PaneTemplate(tt.setting)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("PaneTemplate(%q) = %q, %v", tt.setting, got, err)
+		}
+	}
+
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	if got, _ := PaneTemplate("auto"); got != paneTemplates["tmux"] {
+		t.Errorf("expected tmux inside tmux, got %q", got)
+	}
+}
+
+func TestExpandPaneTemplate(t *testing.T) {
+	got := expandPaneTemplate("open --name {title} {id} {log}", "/tmp/it's.log", "run `rm -rf` $(dev server)", "ab12")
+	want := `open --name 'run rm -rf dev server' 'ab12' '/tmp/it'\''s.log'`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestShellManagerPane(t *testing.T) {
+	dir := t.TempDir()
+	opened := filepath.Join(dir, "opened")
+
+	m := NewShellManager()
+	m.SetPane("echo {log} > " + shellQuote(opened))
+	shell, err := m.StartBackground("echo hello", "greet", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shell.PaneError != nil {
+		t.Fatal(shell.PaneError)
+	}
+	if data, _ := os.ReadFile(opened); strings.TrimSpace(string(data)) != shell.LogPath {
+		t.Errorf("expected the pane command to get the log path, got %q", data)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, state, _ := m.GetOutput(shell.ID, 0); state != ShellStateRunning || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	log, _ := os.ReadFile(shell.LogPath)
+	if !strings.Contains(string(log), "$ echo hello\nhello\n") || !strings.Contains(string(log), "[completed, exit code 0]") {
+		t.Errorf("unexpected log %q", log)
+	}
+	if out, _, _ := m.GetOutput(shell.ID, 0); out != "hello\n" {
+		t.Errorf("expected BashOutput to see the output, got %q", out)
+	}
+
+	m.Cleanup()
+	if _, err := os.Stat(shell.LogPath); !os.IsNotExist(err) {
+		t.Error("expected the log to be removed with the shell")
+	}
+}
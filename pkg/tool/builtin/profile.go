@@ -0,0 +1,424 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+const (
+	defaultProfileTop     = 20
+	defaultProfileSeconds = 10
+	maxProfileSeconds     = 120
+
+	// maxListLines bounds the annotated source of list
+	maxListLines = 200
+)
+
+// Hotspot is a function from the top of a profile
+type Hotspot struct {
+	Function string  `json:"function"`
+	Flat     string  `json:"flat"` // e.g. "120ms" or "4.50MB"
+	FlatPct  float64 `json:"flat_pct"`
+	Cum      string  `json:"cum"`
+	CumPct   float64 `json:"cum_pct"`
+}
+
+// ProfileResult is the summary of a profile
+type ProfileResult struct {
+	Path     string    `json:"path"`
+	Kind     string    `json:"kind"`
+	Header   []string  `json:"header"` // pprof lines such as "Type: cpu" and the totals
+	Hotspots []Hotspot `json:"hotspots"`
+}
+
+// ProfileTool profiles Go tests and programs and summarizes the hotspots
+type ProfileTool struct {
+	// profileDir returns the directory holding a project's profiles
+	profileDir func(projectPath string) (string, error)
+}
+
+// ProfileInput represents the input for the Profile tool
+type ProfileInput struct {
+	Kind    string `json:"kind,omitempty"`
+	Package string `json:"package,omitempty"`
+	Bench   string `json:"bench,omitempty"`
+	Run     string `json:"run,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Seconds int    `json:"seconds,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	Top     int    `json:"top,omitempty"`
+	List    string `json:"list,omitempty"`
+}
+
+// NewProfileTool creates a new Profile tool storing profiles under the app directory
+func NewProfileTool() *ProfileTool {
+	return &ProfileTool{profileDir: config.GetProjectProfilesDir}
+}
+
+func (t *ProfileTool) Name() string {
+	return "Profile"
+}
+
+func (t *ProfileTool) Description() string {
+	return `Profiles Go code for CPU time or memory allocations and summarizes the top hotspots with go tool pprof.
+- By default, runs the benchmarks of package with profiling; set run to profile tests instead
+- url profiles a running program that serves net/http/pprof (e.g. http://localhost:6060)
+- profile summarizes an existing profile file
+- list shows the annotated source of functions matching a regular expression, with time or allocations per line
+- The profile file path is included so later calls can summarize or list it again`
+}
+
+func (t *ProfileTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"kind": {
+				"type": "string",
+				"enum": ["cpu", "mem"],
+				"description": "Profile CPU time or memory allocations (default cpu)"
+			},
+			"package": {
+				"type": "string",
+				"description": "The Go package whose tests or benchmarks to profile (default \".\")"
+			},
+			"bench": {
+				"type": "string",
+				"description": "Regular expression selecting the benchmarks to run (default \".\" unless run is set)"
+			},
+			"run": {
+				"type": "string",
+				"description": "Regular expression selecting the tests to run"
+			},
+			"url": {
+				"type": "string",
+				"description": "Base URL of a running program serving net/http/pprof"
+			},
+			"seconds": {
+				"type": "integer",
+				"minimum": 1,
+				"maximum": 120,
+				"description": "CPU profiling duration for url (default 10)"
+			},
+			"profile": {
+				"type": "string",
+				"description": "Path of an existing profile to summarize instead of collecting a new one"
+			},
+			"top": {
+				"type": "integer",
+				"minimum": 1,
+				"maximum": 100,
+				"description": "Number of hotspots to report (default 20)"
+			},
+			"list": {
+				"type": "string",
+				"description": "Regular expression of functions to show annotated source for"
+			}
+		}
+	}`)
+}
+
+func (t *ProfileTool) Validate(input *tool.Input) error {
+	params, err := tool.ParamsTo[ProfileInput](input.Params)
+	if err != nil {
+		return err
+	}
+
+	if params.Kind != "" && params.Kind != "cpu" && params.Kind != "mem" {
+		return fmt.Errorf("kind must be cpu or mem")
+	}
+	sources := 0
+	for _, set := range []bool{params.URL != "", params.Profile != "", params.Package != "" || params.Bench != "" || params.Run != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return fmt.Errorf("specify only one of url, profile, or package/bench/run")
+	}
+	if params.URL != "" && !strings.HasPrefix(params.URL, "http://") && !strings.HasPrefix(params.URL, "https://") {
+		return fmt.Errorf("url must start with http:// or https://")
+	}
+	if params.Seconds < 0 || params.Seconds > maxProfileSeconds {
+		return fmt.Errorf("seconds must be between 1 and %d", maxProfileSeconds)
+	}
+	if params.Top < 0 || params.Top > 100 {
+		return fmt.Errorf("top must be between 1 and 100")
+	}
+	if strings.HasPrefix(params.Package, "-") || strings.ContainsAny(params.Package, " \t\n") {
+		return fmt.Errorf("invalid package: %q", params.Package)
+	}
+	for _, pattern := range []string{params.Bench, params.Run, params.List} {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+func (t *ProfileTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+	params, err := tool.ParamsTo[ProfileInput](input.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := "."
+	if input.Context != nil && input.Context.CWD != "" {
+		dir = input.Context.CWD
+	}
+	kind := params.Kind
+	if kind == "" {
+		kind = "cpu"
+	}
+
+	var b strings.Builder
+	path := params.Profile
+	switch {
+	case path != "":
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+	case params.URL != "":
+		path, err = t.newProfilePath(dir, kind)
+		if err == nil {
+			err = fetchProfile(ctx, params.URL, kind, params.Seconds, path)
+		}
+	default:
+		path, err = t.newProfilePath(dir, kind)
+		if err == nil {
+			var out string
+			out, err = profileGoTest(ctx, dir, params, kind, path)
+			if err != nil {
+				err = fmt.Errorf("%w\n%s", err, lastLines(out, 40))
+			}
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return &tool.Output{Content: "Error: " + err.Error(), IsError: true}, nil
+	}
+
+	top := params.Top
+	if top == 0 {
+		top = defaultProfileTop
+	}
+	args := []string{"go", "tool", "pprof", "-top", "-nodecount=" + strconv.Itoa(top)}
+	if kind == "mem" {
+		args = append(args, "-sample_index=alloc_space")
+	}
+	out, exitCode, err := runProjectCommand(ctx, dir, append(args, path))
+	if err != nil {
+		return nil, err
+	}
+	if exitCode != 0 {
+		return &tool.Output{Content: fmt.Sprintf("Error: pprof failed on %s\n%s", path, lastLines(out, 20)), IsError: true}, nil
+	}
+
+	result := parsePprofTop(out)
+	result.Path = path
+	result.Kind = kind
+	b.WriteString(formatProfile(result))
+
+	if params.List != "" {
+		listArgs := []string{"go", "tool", "pprof", "-list=" + params.List}
+		if kind == "mem" {
+			listArgs = append(listArgs, "-sample_index=alloc_space")
+		}
+		out, _, err := runProjectCommand(ctx, dir, append(listArgs, path))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "\n\nAnnotated source for %q:\n%s", params.List, firstLines(strings.TrimSpace(out), maxListLines))
+	}
+
+	return &tool.Output{
+		Content:  b.String(),
+		Metadata: result,
+	}, nil
+}
+
+// newProfilePath returns a new file name for a profile of the project in dir
+func (t *ProfileTool) newProfilePath(dir, kind string) (string, error) {
+	profileDir, err := t.profileDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	return filepath.Join(profileDir, fmt.Sprintf("%s-%s.pprof", kind, time.Now().Format("20060102-150405.000"))), nil
+}
+
+// profileGoTest runs the tests or benchmarks of a package with profiling
+func profileGoTest(ctx context.Context, dir string, params *ProfileInput, kind, path string) (string, error) {
+	pkg := params.Package
+	if pkg == "" {
+		pkg = "."
+	}
+	run, bench := params.Run, params.Bench
+	if run == "" {
+		run = "^$"
+		if bench == "" {
+			bench = "."
+		}
+	}
+
+	// Keep the test binary out of the project directory
+	binary, err := os.CreateTemp("", "profile-*.test")
+	if err != nil {
+		return "", fmt.Errorf("failed to create test binary: %w", err)
+	}
+	binary.Close()
+	defer os.Remove(binary.Name())
+
+	args := []string{"go", "test", "-run=" + run, "-o", binary.Name()}
+	if bench != "" {
+		args = append(args, "-bench="+bench, "-benchmem")
+	}
+	if kind == "mem" {
+		args = append(args, "-memprofile="+path)
+	} else {
+		args = append(args, "-cpuprofile="+path)
+	}
+	args = append(args, pkg)
+
+	out, exitCode, err := runProjectCommand(ctx, dir, args)
+	if err != nil {
+		return out, err
+	}
+	if exitCode != 0 {
+		return out, fmt.Errorf("go test exited with code %d", exitCode)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		return out, fmt.Errorf("no profile was written; check that the package has matching tests or benchmarks")
+	}
+	return out, nil
+}
+
+// fetchProfile downloads a profile from a program serving net/http/pprof
+func fetchProfile(ctx context.Context, baseURL, kind string, seconds int, path string) error {
+	if seconds == 0 {
+		seconds = defaultProfileSeconds
+	}
+	url := strings.TrimSuffix(baseURL, "/")
+	if !strings.Contains(url, "/debug/pprof") {
+		url += "/debug/pprof"
+	}
+	if strings.HasSuffix(url, "/debug/pprof") {
+		if kind == "mem" {
+			url += "/allocs"
+		} else {
+			url += fmt.Sprintf("/profile?seconds=%d", seconds)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch profile: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to fetch profile from %s: %s %s", url, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+	return nil
+}
+
+// parsePprofTop parses the output of go tool pprof -top
+func parsePprofTop(out string) *ProfileResult {
+	result := &ProfileResult{}
+	inTable := false
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !inTable {
+			if strings.HasPrefix(line, "flat ") {
+				inTable = true
+			} else if !strings.HasPrefix(line, "File:") && !strings.HasPrefix(line, "Build ID:") {
+				result.Header = append(result.Header, line)
+			}
+			continue
+		}
+
+		// flat flat% sum% cum cum% function
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+		flatPct, err1 := strconv.ParseFloat(strings.TrimSuffix(fields[1], "%"), 64)
+		cumPct, err2 := strconv.ParseFloat(strings.TrimSuffix(fields[4], "%"), 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		result.Hotspots = append(result.Hotspots, Hotspot{
+			Function: strings.Join(fields[5:], " "),
+			Flat:     fields[0],
+			FlatPct:  flatPct,
+			Cum:      fields[3],
+			CumPct:   cumPct,
+		})
+	}
+	return result
+}
+
+// formatProfile lists the hotspots of a profile as a table
+func formatProfile(result *ProfileResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Profile: %s\n", result.Path)
+	for _, line := range result.Header {
+		fmt.Fprintf(&b, "%s\n", line)
+	}
+
+	if len(result.Hotspots) == 0 {
+		b.WriteString("\nNo samples in the profile.")
+		return b.String()
+	}
+
+	b.WriteString("\n      flat  flat%        cum   cum%  function\n")
+	for _, h := range result.Hotspots {
+		fmt.Fprintf(&b, "%10s %5.1f%% %10s %5.1f%%  %s\n", h.Flat, h.FlatPct, h.Cum, h.CumPct, h.Function)
+	}
+	b.WriteString("\nflat is spent in the function itself, cum includes its callees. Use list to see the hot lines of a function.")
+	return b.String()
+}
+
+// firstLines returns the first n lines of s, noting how many were cut
+func firstLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= n {
+		return s
+	}
+	return strings.Join(lines[:n], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-n)
+}
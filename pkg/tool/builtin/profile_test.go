@@ -0,0 +1,156 @@
+package builtin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+const pprofTopOutput = `File: calc.test
+Build ID: abc123
+Type: cpu
+Time: Oct 18, 2026 at 10:00am (UTC)
+Duration: 1.20s, Total samples = 1.10s (91.67%)
+Showing nodes accounting for 1.05s, 95.45% of 1.10s total
+      flat  flat%   sum%        cum   cum%
+     0.50s 45.45% 45.45%      0.60s 54.55%  example.com/calc.Abs
+     0.30s 27.27% 72.73%      1.00s 90.91%  example.com/calc.(*Table).Sum
+         0     0% 72.73%      1.05s 95.45%  testing.(*B).runN
+`
+
+func TestParsePprofTop(t *testing.T) {
+	result := parsePprofTop(pprofTopOutput)
+
+	if len(result.Header) != 4 || result.Header[0] != "Type: cpu" {
+		t.Errorf("unexpected header: %q", result.Header)
+	}
+	if len(result.Hotspots) != 3 {
+		t.Fatalf("expected 3 hotspots, got %d", len(result.Hotspots))
+	}
+	want := Hotspot{Function: "example.com/calc.(*Table).Sum", Flat: "0.30s", FlatPct: 27.27, Cum: "1.00s", CumPct: 90.91}
+	if result.Hotspots[1] != want {
+		t.Errorf("hotspot = %+v, want %+v", result.Hotspots[1], want)
+	}
+
+	result.Path = "/tmp/cpu.pprof"
+	formatted := formatProfile(result)
+	for _, s := range []string{"Profile: /tmp/cpu.pprof", "Duration: 1.20s", "     0.50s  45.5%      0.60s  54.5%  example.com/calc.Abs"} {
+		if !strings.Contains(formatted, s) {
+			t.Errorf("expected %q in:\n%s", s, formatted)
+		}
+	}
+}
+
+func TestFetchProfile(t *testing.T) {
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.String()
+		w.Write([]byte("profile data"))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cpu.pprof")
+	if err := fetchProfile(context.Background(), server.URL+"/", "cpu", 3, path); err != nil {
+		t.Fatal(err)
+	}
+	if requested != "/debug/pprof/profile?seconds=3" {
+		t.Errorf("requested %q", requested)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "profile data" {
+		t.Errorf("saved %q", data)
+	}
+
+	if err := fetchProfile(context.Background(), server.URL, "mem", 0, path); err != nil || requested != "/debug/pprof/allocs" {
+		t.Errorf("mem profile: requested %q, err %v", requested, err)
+	}
+}
+
+func TestProfileValidate(t *testing.T) {
+	profile := NewProfileTool()
+	tests := []struct {
+		params  map[string]interface{}
+		wantErr bool
+	}{
+		{map[string]interface{}{}, false},
+		{map[string]interface{}{"package": "./pkg/engine", "bench": "Loop", "kind": "mem"}, false},
+		{map[string]interface{}{"url": "http://localhost:6060", "seconds": 5}, false},
+		{map[string]interface{}{"url": "localhost:6060"}, true},
+		{map[string]interface{}{"url": "http://localhost:6060", "package": "."}, true},
+		{map[string]interface{}{"kind": "block"}, true},
+		{map[string]interface{}{"list": "Sum("}, true},
+	}
+
+	for _, tt := range tests {
+		err := profile.Validate(&tool.Input{Params: tt.params})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%v) error = %v, wantErr %v", tt.params, err, tt.wantErr)
+		}
+	}
+}
+
+func TestProfileToolGoTest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not installed")
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/work\n\ngo 1.21\n",
+		"work.go": `package work
+
+func Build(n int) []string {
+	var out []string
+	for i := 0; i < n; i++ {
+		out = append(out, string(rune('a'+i%26)))
+	}
+	return out
+}
+`,
+		"work_test.go": `package work
+
+import "testing"
+
+func TestBuild(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		Build(10000)
+	}
+}
+`,
+	})
+
+	profiles := t.TempDir()
+	profile := &ProfileTool{profileDir: func(string) (string, error) { return profiles, nil }}
+	output, err := profile.Execute(context.Background(), &tool.Input{
+		Params:  map[string]interface{}{"run": "TestBuild", "kind": "mem", "list": "Build"},
+		Context: &tool.ExecutionContext{CWD: dir},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.IsError {
+		t.Fatalf("unexpected error: %s", output.Content)
+	}
+
+	result := output.Metadata.(*ProfileResult)
+	if !strings.HasPrefix(result.Path, profiles) {
+		t.Errorf("profile saved to %s, want under %s", result.Path, profiles)
+	}
+	for _, want := range []string{"example.com/work.Build", "Annotated source for \"Build\""} {
+		if !strings.Contains(output.Content, want) {
+			t.Errorf("expected %q in:\n%s", want, output.Content)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 3 {
+		t.Errorf("expected no files left in the project, got %d entries", len(entries))
+	}
+}
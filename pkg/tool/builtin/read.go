@@ -2,9 +2,11 @@
 package builtin
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -15,6 +17,9 @@ import (
 type ReadTool struct {
 	MaxLines   int
 	MaxLineLen int
+
+	// Workspace is where files are read (nil = the local machine)
+	Workspace Workspace
 }
 
 // ReadInput represents the input for Read tool
@@ -22,8 +27,15 @@ type ReadInput struct {
 	FilePath string `json:"file_path"`
 	Offset   int    `json:"offset,omitempty"`
 	Limit    int    `json:"limit,omitempty"`
+	Symbol   string `json:"symbol,omitempty"`
 }
 
+// maxOutlineFileSize is the largest file that is outlined to suggest symbols
+const maxOutlineFileSize = 4 * 1024 * 1024
+
+// maxOutlineSymbols is the number of symbols listed when a file is too long to read at once
+const maxOutlineSymbols = 40
+
 // NewReadTool creates a new Read tool
 func NewReadTool() *ReadTool {
 	return &ReadTool{
@@ -41,6 +53,8 @@ func (r *ReadTool) Description() string {
 - The file_path parameter must be an absolute path, not a relative path
 - By default, it reads up to 2000 lines starting from the beginning of the file
 - You can optionally specify a line offset and limit
+- For long files, the result ends with the remaining line count and an outline of the file's functions and types
+- Use symbol to read just one function, method, type, or class by name (e.g. "ParseConfig" or "Server.Start"), supported for Go, Python, JavaScript/TypeScript, Java, Rust, C-family and similar languages
 - Results are returned with line numbers starting at 1
 - This tool can read images, PDFs, and Jupyter notebooks`
 }
@@ -60,6 +74,10 @@ func (r *ReadTool) InputSchema() json.RawMessage {
 			"limit": {
 				"type": "number",
 				"description": "The number of lines to read. Only provide if the file is too large to read at once"
+			},
+			"symbol": {
+				"type": "string",
+				"description": "Read only this function, method, type, or class, e.g. \"ParseConfig\" or \"Server.Start\""
 			}
 		},
 		"required": ["file_path"]
@@ -90,8 +108,8 @@ func (r *ReadTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 		return nil, err
 	}
 
-	// Read file
-	content, err := os.ReadFile(params.FilePath)
+	ws := workspaceOr(r.Workspace)
+	f, err := ws.Open(params.FilePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return &tool.Output{
@@ -104,53 +122,155 @@ func (r *ReadTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 			IsError: true,
 		}, nil
 	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
 
 	// Handle binary files (simple check)
-	if isBinary(content) {
+	head, _ := reader.Peek(512)
+	if isBinary(head) {
+		var size int64
+		if info, err := ws.Stat(params.FilePath); err == nil {
+			size = info.Size()
+		}
 		return &tool.Output{
-			Content: fmt.Sprintf("Binary file: %s (%d bytes)", params.FilePath, len(content)),
+			Content: fmt.Sprintf("Binary file: %s (%d bytes)", params.FilePath, size),
 		}, nil
 	}
 
-	// Split into lines
-	lines := strings.Split(string(content), "\n")
+	if symbol := strings.TrimPrefix(params.Symbol, "symbol:"); symbol != "" {
+		return r.readSymbol(params.FilePath, reader, symbol)
+	}
 
 	// Apply offset and limit
 	offset := params.Offset
 	if offset > 0 {
 		offset-- // Convert to 0-based
 	}
-	if offset >= len(lines) {
-		return &tool.Output{
-			Content: "Offset exceeds file length",
-			IsError: true,
-		}, nil
-	}
 
 	limit := params.Limit
 	if limit == 0 {
 		limit = r.MaxLines
 	}
 
-	endLine := offset + limit
-	if endLine > len(lines) {
-		endLine = len(lines)
+	// Stream the file, keeping only the requested lines
+	lines, total, err := readLineWindow(reader, offset, limit)
+	if err != nil {
+		return &tool.Output{
+			Content: fmt.Sprintf("Error reading file: %v", err),
+			IsError: true,
+		}, nil
+	}
+	if offset >= total && total > 0 {
+		return &tool.Output{
+			Content: fmt.Sprintf("Offset exceeds file length (%d lines)", total),
+			IsError: true,
+		}, nil
 	}
-
-	lines = lines[offset:endLine]
 
 	// Format with line numbers
 	result := r.formatWithLineNumbers(lines, offset+1)
 
+	// Tell the model how to continue when only part of the file was read
+	if end := offset + len(lines); end < total {
+		result += fmt.Sprintf("\n[Showing lines %d-%d of %d. Continue with offset=%d, or read a single symbol with symbol=<name>.]\n",
+			offset+1, end, total, end+1)
+		if params.Offset == 0 && params.Limit == 0 {
+			result += r.outlineHint(params.FilePath)
+		}
+	}
+
+	return &tool.Output{
+		Content: result,
+		Metadata: map[string]interface{}{
+			"lines_read":  len(lines),
+			"total_lines": total,
+		},
+	}, nil
+}
+
+// readSymbol returns the lines of one declaration in the file
+func (r *ReadTool) readSymbol(path string, reader io.Reader, name string) (*tool.Output, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return &tool.Output{
+			Content: fmt.Sprintf("Error reading file: %v", err),
+			IsError: true,
+		}, nil
+	}
+
+	symbols := Outline(path, content)
+	if len(symbols) == 0 {
+		return &tool.Output{
+			Content: fmt.Sprintf("Error: no symbols found in %s (unsupported language or no declarations). Use offset and limit instead.", path),
+			IsError: true,
+		}, nil
+	}
+
+	matches := FindSymbol(symbols, name)
+	if len(matches) == 0 {
+		return &tool.Output{
+			Content: fmt.Sprintf("Error: symbol %q not found in %s. Symbols in the file:\n%s", name, path, formatOutline(symbols, maxOutlineSymbols)),
+			IsError: true,
+		}, nil
+	}
+
+	lines := strings.Split(string(content), "\n")
+	sym := matches[0]
+	result := r.formatWithLineNumbers(lines[sym.StartLine-1:sym.EndLine], sym.StartLine)
+	if len(matches) > 1 {
+		result += fmt.Sprintf("\n[%d symbols match %q; showing the first. Others:\n%s]\n", len(matches), name, formatOutline(matches[1:], maxOutlineSymbols))
+	}
+
 	return &tool.Output{
 		Content: result,
 		Metadata: map[string]interface{}{
-			"lines_read": len(lines),
-			"total_lines": len(strings.Split(string(content), "\n")),
+			"symbol":      sym.Name,
+			"lines_read":  sym.EndLine - sym.StartLine + 1,
+			"total_lines": len(lines),
 		},
 	}, nil
 }
 
+// outlineHint lists the symbols of a long file so the model can read just the one it needs
+func (r *ReadTool) outlineHint(path string) string {
+	ws := workspaceOr(r.Workspace)
+	info, err := ws.Stat(path)
+	if err != nil || info.Size() > maxOutlineFileSize {
+		return ""
+	}
+	content, err := ws.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	symbols := Outline(path, content)
+	if len(symbols) == 0 {
+		return ""
+	}
+	return "[Symbols in this file:\n" + formatOutline(symbols, maxOutlineSymbols) + "]\n"
+}
+
+// readLineWindow reads lines [offset, offset+limit) and counts all lines
+func readLineWindow(reader *bufio.Reader, offset, limit int) ([]string, int, error) {
+	var lines []string
+	total := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if total >= offset && total < offset+limit {
+				lines = append(lines, strings.TrimRight(line, "\r\n"))
+			}
+			total++
+		}
+		if err == io.EOF {
+			return lines, total, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+}
+
 func (r *ReadTool) formatWithLineNumbers(lines []string, startLine int) string {
 	var buf strings.Builder
 
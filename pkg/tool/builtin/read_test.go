@@ -0,0 +1,67 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func readFile(t *testing.T, path string, params map[string]interface{}) *tool.Output {
+	t.Helper()
+	params["file_path"] = path
+	output, err := NewReadTool().Execute(context.Background(), &tool.Input{Params: params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return output
+}
+
+func TestReadLargeFileWindow(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("package big\n\n")
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&b, "func F%d() {\n\treturn\n}\n\n", i)
+	}
+	path := filepath.Join(t.TempDir(), "big.go")
+	os.WriteFile(path, []byte(b.String()), 0644)
+
+	output := readFile(t, path, map[string]interface{}{})
+	if !strings.Contains(output.Content, "[Showing lines 1-2000 of 4002. Continue with offset=2001") {
+		t.Errorf("expected continuation hint, got tail %q", output.Content[len(output.Content)-300:])
+	}
+	if !strings.Contains(output.Content, "func F0 (lines 3-5)") {
+		t.Error("expected an outline of the file on the first read")
+	}
+
+	output = readFile(t, path, map[string]interface{}{"offset": 4001, "limit": 10})
+	if output.IsError || !strings.HasPrefix(output.Content, "  4001\t}") || strings.Contains(output.Content, "Showing lines") {
+		t.Errorf("unexpected window at the end of the file: %q", output.Content)
+	}
+
+	output = readFile(t, path, map[string]interface{}{"offset": 5000})
+	if !output.IsError {
+		t.Error("expected error for offset past the end")
+	}
+}
+
+func TestReadSymbol(t *testing.T) {
+	src := "package a\n\nfunc A() {}\n\n// B does b\nfunc B() int {\n\treturn 1\n}\n"
+	path := filepath.Join(t.TempDir(), "a.go")
+	os.WriteFile(path, []byte(src), 0644)
+
+	output := readFile(t, path, map[string]interface{}{"symbol": "symbol:B"})
+	want := "     5\t// B does b\n     6\tfunc B() int {\n     7\t\treturn 1\n     8\t}\n"
+	if output.IsError || output.Content != want {
+		t.Errorf("unexpected symbol read: %q", output.Content)
+	}
+
+	output = readFile(t, path, map[string]interface{}{"symbol": "C"})
+	if !output.IsError || !strings.Contains(output.Content, "func A (lines 3-3)") {
+		t.Errorf("expected error listing symbols, got %q", output.Content)
+	}
+}
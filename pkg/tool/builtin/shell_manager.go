@@ -8,6 +8,8 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -24,7 +26,9 @@ const (
 	ShellStateKilled    ShellState = "killed"
 )
 
-// BackgroundShell represents a running background shell process
+// BackgroundShell represents a running background shell process. Foreground
+// Bash commands are tracked as shells too while they run, so that they are
+// listed and stopped with the session.
 type BackgroundShell struct {
 	ID          string
 	Command     string
@@ -35,17 +39,46 @@ type BackgroundShell struct {
 	ExitCode    int
 	Output      *bytes.Buffer
 	Error       error
+	Foreground  bool
+
+	// LogPath is the file the output is copied to for a pane, and
+	// PaneError why the pane could not be opened
+	LogPath   string
+	PaneError error
 
 	cmd    *exec.Cmd
+	proc   *Process
 	cancel context.CancelFunc
+	log    *os.File
 	mu     sync.Mutex
 }
 
+// shellLogPrefix starts the names of the temporary files that copy a
+// shell's output for a pane
+const shellLogPrefix = "agentic-coder-shell-"
+
+// StaleShellLogs returns the shell output copies in the temporary
+// directory not written to for olderThan. A shell removes its copy when it
+// ends, so these are left by sessions that did not exit cleanly.
+func StaleShellLogs(olderThan time.Duration) []string {
+	paths, _ := filepath.Glob(filepath.Join(os.TempDir(), shellLogPrefix+"*.log"))
+	var stale []string
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) >= olderThan {
+			stale = append(stale, path)
+		}
+	}
+	return stale
+}
+
 // ShellManager manages background shell processes
 type ShellManager struct {
-	shells    map[string]*BackgroundShell
-	shellPath string
-	mu        sync.RWMutex
+	shells     map[string]*BackgroundShell
+	shellPath  string
+	supervisor *Supervisor
+	pane       string // command template that shows a shell's output, see PaneTemplate
+	workspace  Workspace
+	mu         sync.RWMutex
 }
 
 // NewShellManager creates a new shell manager
@@ -61,18 +94,50 @@ func NewShellManager() *ShellManager {
 	}
 }
 
+// SetSupervisor limits the processes background shells may spawn
+func (m *ShellManager) SetSupervisor(s *Supervisor) {
+	m.supervisor = s
+}
+
+// SetPane shows the output of each background shell in a terminal pane
+// opened with template, or in none when template is empty
+func (m *ShellManager) SetPane(template string) {
+	m.pane = template
+}
+
+// SetWorkspace runs background shells in w with the given shell, or locally
+// with $SHELL when w is nil
+func (m *ShellManager) SetWorkspace(w Workspace, shell string) {
+	m.workspace = w
+	if shell != "" {
+		m.shellPath = shell
+	}
+}
+
 // StartBackground starts a command in the background
 func (m *ShellManager) StartBackground(command, description, cwd string) (*BackgroundShell, error) {
 	id := uuid.New().String()[:8]
 
 	ctx, cancel := context.WithCancel(context.Background())
-	cmd := exec.CommandContext(ctx, m.shellPath, "-c", command)
-	cmd.Dir = cwd
-	cmd.Env = os.Environ()
+	cmd := workspaceOr(m.workspace).Command(ctx, cwd, m.shellPath, "-c", command)
+	setCommandEnv(cmd)
 
 	output := &bytes.Buffer{}
-	cmd.Stdout = output
-	cmd.Stderr = output
+
+	// A pane follows a copy of the output, so BashOutput keeps working and
+	// the shell outlives the pane
+	var log *os.File
+	var w io.Writer = output
+	if m.pane != "" {
+		var err error
+		log, err = os.CreateTemp("", shellLogPrefix+id+"-*.log")
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create shell log: %w", err)
+		}
+		fmt.Fprintf(log, "$ %s\n", command)
+		w = io.MultiWriter(output, log)
+	}
 
 	shell := &BackgroundShell{
 		ID:          id,
@@ -83,12 +148,30 @@ func (m *ShellManager) StartBackground(command, description, cwd string) (*Backg
 		Output:      output,
 		cmd:         cmd,
 		cancel:      cancel,
+		log:         log,
 	}
+	cmd.Stdout = &shellOutput{shell: shell, w: w}
+	cmd.Stderr = cmd.Stdout
 
-	if err := cmd.Start(); err != nil {
+	proc, err := m.supervisor.Start(cmd, command, true)
+	if err != nil {
 		cancel()
+		if log != nil {
+			log.Close()
+			os.Remove(log.Name())
+		}
 		return nil, fmt.Errorf("failed to start command: %w", err)
 	}
+	shell.proc = proc
+
+	if log != nil {
+		shell.LogPath = log.Name()
+		title := description
+		if title == "" {
+			title = command
+		}
+		shell.PaneError = openPane(expandPaneTemplate(m.pane, log.Name(), title, id))
+	}
 
 	m.mu.Lock()
 	m.shells[id] = shell
@@ -100,18 +183,75 @@ func (m *ShellManager) StartBackground(command, description, cwd string) (*Backg
 	return shell, nil
 }
 
+// StartForeground starts a command whose caller collects its output and
+// waits for it with Wait
+func (m *ShellManager) StartForeground(cmd *exec.Cmd, command, description string) (*BackgroundShell, error) {
+	proc, err := m.supervisor.Start(cmd, command, false)
+	if err != nil {
+		return nil, err
+	}
+	shell := &BackgroundShell{
+		ID:          uuid.New().String()[:8],
+		Command:     command,
+		Description: description,
+		StartTime:   proc.Started,
+		State:       ShellStateRunning,
+		Output:      &bytes.Buffer{},
+		Foreground:  true,
+		cmd:         cmd,
+		proc:        proc,
+	}
+
+	m.mu.Lock()
+	m.shells[shell.ID] = shell
+	m.mu.Unlock()
+	return shell, nil
+}
+
+// Wait waits for a foreground shell to end and stops tracking it
+func (m *ShellManager) Wait(shell *BackgroundShell) error {
+	err := shell.proc.Wait()
+
+	m.mu.Lock()
+	delete(m.shells, shell.ID)
+	m.mu.Unlock()
+
+	now := time.Now()
+	shell.mu.Lock()
+	shell.EndTime = &now
+	if shell.State == ShellStateRunning {
+		shell.State = ShellStateCompleted
+		if err != nil {
+			shell.State = ShellStateFailed
+		}
+	}
+	shell.mu.Unlock()
+	return err
+}
+
 // monitorShell monitors a background shell until completion
 func (m *ShellManager) monitorShell(shell *BackgroundShell) {
-	err := shell.cmd.Wait()
+	err := shell.proc.Wait()
 	now := time.Now()
+	if shell.cancel != nil {
+		shell.cancel() // releases the command's context
+	}
 
 	shell.mu.Lock()
 	shell.EndTime = &now
+	if reason := shell.proc.KillReason(); reason != "" {
+		fmt.Fprintf(shell.Output, "\n[%s]\n", reason)
+		if shell.State == ShellStateRunning {
+			shell.State = ShellStateKilled
+		}
+	}
 
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			shell.ExitCode = exitErr.ExitCode()
-			shell.State = ShellStateFailed
+			if shell.State != ShellStateKilled {
+				shell.State = ShellStateFailed
+			}
 		} else if shell.State == ShellStateKilled {
 			// Already marked as killed
 		} else {
@@ -122,6 +262,10 @@ func (m *ShellManager) monitorShell(shell *BackgroundShell) {
 		shell.ExitCode = 0
 		shell.State = ShellStateCompleted
 	}
+	if shell.log != nil {
+		fmt.Fprintf(shell.log, "\n[%s, exit code %d]\n", shell.State, shell.ExitCode)
+		shell.log.Close()
+	}
 	shell.mu.Unlock()
 }
 
@@ -136,24 +280,62 @@ func (m *ShellManager) Kill(id string) error {
 	}
 
 	shell.mu.Lock()
-	defer shell.mu.Unlock()
-
 	if shell.State != ShellStateRunning {
+		shell.mu.Unlock()
 		return fmt.Errorf("shell '%s' is not running (state: %s)", id, shell.State)
 	}
-
 	shell.State = ShellStateKilled
-	shell.cancel()
+	shell.mu.Unlock()
 
-	// Give it a moment to terminate gracefully
-	time.Sleep(100 * time.Millisecond)
+	if shell.cancel != nil {
+		shell.cancel()
+		// Give it a moment to terminate gracefully
+		time.Sleep(100 * time.Millisecond)
+	}
 
 	// Force kill if still running
+	m.stop(shell)
+	return nil
+}
+
+// stop kills the command of a shell, with its children when supervised
+func (m *ShellManager) stop(shell *BackgroundShell) {
+	if m.supervisor != nil && shell.proc != nil {
+		_ = m.supervisor.Kill(shell.proc.PID)
+		return
+	}
 	if shell.cmd.Process != nil {
 		_ = shell.cmd.Process.Kill()
 	}
+}
 
-	return nil
+// Shutdown stops every running shell and forgets them all, so that no
+// command outlives the session. It returns the number of shells stopped.
+func (m *ShellManager) Shutdown() int {
+	m.mu.Lock()
+	shells := m.shells
+	m.shells = make(map[string]*BackgroundShell)
+	m.mu.Unlock()
+
+	stopped := 0
+	for _, shell := range shells {
+		shell.mu.Lock()
+		running := shell.State == ShellStateRunning
+		if running {
+			shell.State = ShellStateKilled
+		}
+		shell.mu.Unlock()
+
+		if running {
+			if shell.cancel != nil {
+				shell.cancel()
+			}
+			m.stop(shell)
+			stopped++
+		}
+		shell.removeLog()
+	}
+	return stopped
 }
 
 // Get returns a background shell by ID
@@ -163,7 +345,7 @@ func (m *ShellManager) Get(id string) *BackgroundShell {
 	return m.shells[id]
 }
 
-// List returns all background shells
+// List returns all shells, oldest first
 func (m *ShellManager) List() []*BackgroundShell {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -172,6 +354,7 @@ func (m *ShellManager) List() []*BackgroundShell {
 	for _, shell := range m.shells {
 		shells = append(shells, shell)
 	}
+	sort.Slice(shells, func(i, j int) bool { return shells[i].StartTime.Before(shells[j].StartTime) })
 	return shells
 }
 
@@ -230,6 +413,7 @@ func (m *ShellManager) Remove(id string) error {
 		return fmt.Errorf("cannot remove running shell '%s'", id)
 	}
 
+	shell.removeLog()
 	delete(m.shells, id)
 	return nil
 }
@@ -246,6 +430,7 @@ func (m *ShellManager) Cleanup() int {
 		shell.mu.Unlock()
 
 		if state != ShellStateRunning {
+			shell.removeLog()
 			delete(m.shells, id)
 			count++
 		}
@@ -253,6 +438,26 @@ func (m *ShellManager) Cleanup() int {
 	return count
 }
 
+// shellOutput writes a shell's output under its lock, so the output can be
+// read while the command runs
+type shellOutput struct {
+	shell *BackgroundShell
+	w     io.Writer
+}
+
+func (o *shellOutput) Write(p []byte) (int, error) {
+	o.shell.mu.Lock()
+	defer o.shell.mu.Unlock()
+	return o.w.Write(p)
+}
+
+// removeLog deletes the pane log of a finished shell
+func (s *BackgroundShell) removeLog() {
+	if s.LogPath != "" {
+		os.Remove(s.LogPath)
+	}
+}
+
 // ReadOutput implements io.Reader for streaming output
 func (s *BackgroundShell) ReadOutput() io.Reader {
 	return s.Output
@@ -0,0 +1,67 @@
+package builtin
+
+import (
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestShellManagerForeground(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	m := NewShellManager()
+	m.SetSupervisor(NewSupervisor(DefaultProcessLimits()))
+
+	shell, err := m.StartForeground(exec.Command("sh", "-c", "true"), "true", "nothing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list := m.List(); len(list) != 1 || list[0].ID != shell.ID || !list[0].Foreground {
+		t.Fatalf("expected the running command to be listed, got %+v", list)
+	}
+	if err := m.Wait(shell); err != nil {
+		t.Fatal(err)
+	}
+	if list := m.List(); len(list) != 0 {
+		t.Errorf("expected the finished command to be forgotten, got %+v", list)
+	}
+	if shell.State != ShellStateCompleted {
+		t.Errorf("state = %s, want %s", shell.State, ShellStateCompleted)
+	}
+}
+
+func TestShellManagerShutdown(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	m := NewShellManager()
+	m.SetSupervisor(NewSupervisor(DefaultProcessLimits()))
+
+	if _, err := m.StartBackground("sleep 30", "background", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	fg, err := m.StartForeground(exec.Command("sh", "-c", "sleep 30"), "sleep 30", "foreground")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := m.Shutdown(); n != 2 {
+		t.Errorf("Shutdown() = %d, want 2", n)
+	}
+	if list := m.List(); len(list) != 0 {
+		t.Errorf("expected no shells after shutdown, got %+v", list)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- m.Wait(fg) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("foreground command did not stop")
+	}
+	if fg.State != ShellStateKilled {
+		t.Errorf("state = %s, want %s", fg.State, ShellStateKilled)
+	}
+}
@@ -0,0 +1,273 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+const (
+	// defaultSnippetTimeout is how long a snippet may run by default
+	defaultSnippetTimeout = 10 * time.Second
+
+	// maxSnippetTimeout is the longest timeout a snippet may ask for
+	maxSnippetTimeout = 60 * time.Second
+
+	// maxSnippetOutput is the number of bytes kept of each output stream
+	maxSnippetOutput = 10000
+)
+
+// packageClause matches the package clause of a Go file
+var packageClause = regexp.MustCompile(`(?m)^\s*package\s+\w+`)
+
+// SnippetTool runs a short Go, Python, or JavaScript program in a
+// temporary directory, outside the project
+type SnippetTool struct{}
+
+// SnippetInput represents the input for the RunSnippet tool
+type SnippetInput struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+	Stdin    string `json:"stdin,omitempty"`
+	Timeout  int    `json:"timeout,omitempty"` // seconds
+}
+
+// SnippetResult is the outcome of running a snippet
+type SnippetResult struct {
+	ExitCode int    `json:"exit_code"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	TimedOut bool   `json:"timed_out,omitempty"`
+}
+
+// NewSnippetTool creates a new RunSnippet tool
+func NewSnippetTool() *SnippetTool {
+	return &SnippetTool{}
+}
+
+func (s *SnippetTool) Name() string {
+	return "RunSnippet"
+}
+
+func (s *SnippetTool) Description() string {
+	return `Runs a short Go, Python, or JavaScript program in a temporary directory and returns its stdout and stderr.
+- Use this to check an algorithm, a regular expression, or a library behavior before relying on it
+- Nothing is written to the project; the temporary directory is removed afterwards
+- Go code must be a complete program with func main; the package clause may be omitted
+- JavaScript runs with node; Python runs with python3
+- Snippets run for at most 10 seconds unless timeout is given (max 60)`
+}
+
+func (s *SnippetTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"language": {
+				"type": "string",
+				"enum": ["go", "python", "javascript"],
+				"description": "The language of the snippet"
+			},
+			"code": {
+				"type": "string",
+				"description": "The program to run"
+			},
+			"stdin": {
+				"type": "string",
+				"description": "Text passed to the program on standard input"
+			},
+			"timeout": {
+				"type": "integer",
+				"description": "Timeout in seconds (default 10, max 60)"
+			}
+		},
+		"required": ["language", "code"]
+	}`)
+}
+
+func (s *SnippetTool) Validate(input *tool.Input) error {
+	params, err := tool.ParamsTo[SnippetInput](input.Params)
+	if err != nil {
+		return err
+	}
+
+	if snippetLanguage(params.Language) == "" {
+		return fmt.Errorf("unsupported language %q: use go, python, or javascript", params.Language)
+	}
+	if strings.TrimSpace(params.Code) == "" {
+		return fmt.Errorf("code is required")
+	}
+	if params.Timeout < 0 || time.Duration(params.Timeout)*time.Second > maxSnippetTimeout {
+		return fmt.Errorf("timeout must be between 0 and %d seconds", int(maxSnippetTimeout.Seconds()))
+	}
+
+	return nil
+}
+
+func (s *SnippetTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+	params, err := tool.ParamsTo[SnippetInput](input.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := defaultSnippetTimeout
+	if params.Timeout > 0 {
+		timeout = time.Duration(params.Timeout) * time.Second
+	}
+
+	result, err := RunSnippet(ctx, params.Language, params.Code, params.Stdin, timeout)
+	if err != nil {
+		return &tool.Output{Content: "Error: " + err.Error(), IsError: true}, nil
+	}
+
+	return &tool.Output{
+		Content:  formatSnippetResult(result, timeout),
+		IsError:  result.ExitCode != 0 || result.TimedOut,
+		Metadata: result,
+	}, nil
+}
+
+// RunSnippet runs code in a fresh temporary directory with the given
+// timeout. A nonzero exit is reported in the result, not as an error.
+func RunSnippet(ctx context.Context, language, code, stdin string, timeout time.Duration) (*SnippetResult, error) {
+	language = snippetLanguage(language)
+	if language == "" {
+		return nil, fmt.Errorf("unsupported language")
+	}
+
+	dir, err := os.MkdirTemp("", "agentic-snippet-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var file string
+	var args []string
+	switch language {
+	case "go":
+		if !packageClause.MatchString(code) {
+			code = "package main\n\n" + code
+		}
+		file = "main.go"
+		args = []string{"go", "build", "-o", "snippet", file}
+	case "python":
+		file = "main.py"
+		args = []string{pythonCommand(), file}
+	case "javascript":
+		file = "main.js"
+		args = []string{"node", file}
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return nil, fmt.Errorf("%s is not installed", args[0])
+	}
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(code), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write snippet: %w", err)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	env := append(CommandEnv(), "HOME="+dir, "TMPDIR="+dir)
+	if language == "go" {
+		// Keep the build cache outside the sandbox so it is reused
+		if cache, err := os.UserCacheDir(); err == nil && os.Getenv("GOCACHE") == "" {
+			env = append(env, "GOCACHE="+filepath.Join(cache, "go-build"))
+		}
+		result, err := runSandboxed(runCtx, dir, args, "", append(env, "GO111MODULE=off", "GOFLAGS="))
+		if err != nil || result.ExitCode != 0 || result.TimedOut {
+			return result, err
+		}
+		args = []string{filepath.Join(dir, "snippet")}
+	}
+	return runSandboxed(runCtx, dir, args, stdin, env)
+}
+
+// runSandboxed runs a snippet command in dir, killing its process group
+// when ctx expires
+func runSandboxed(ctx context.Context, dir string, args []string, stdin string, env []string) (*SnippetResult, error) {
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error { return killProcessGroup(cmd.Process.Pid) }
+	cmd.WaitDelay = time.Second
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdin = strings.NewReader(stdin)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	result := &SnippetResult{}
+	err := cmd.Run()
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result.TimedOut = true
+		result.ExitCode = -1
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case err != nil:
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return nil, fmt.Errorf("failed to run %s: %w", filepath.Base(args[0]), err)
+		}
+		result.ExitCode = exitErr.ExitCode()
+	}
+
+	// Compiler messages and tracebacks name the sandbox file; drop the directory
+	result.Stdout = truncateSnippetOutput(stdout.String())
+	result.Stderr = truncateSnippetOutput(strings.ReplaceAll(stderr.String(), dir+string(filepath.Separator), ""))
+	return result, nil
+}
+
+// snippetLanguage normalizes a language name, returning "" if unsupported
+func snippetLanguage(language string) string {
+	switch strings.ToLower(strings.TrimSpace(language)) {
+	case "go", "golang":
+		return "go"
+	case "python", "py", "python3":
+		return "python"
+	case "javascript", "js", "node":
+		return "javascript"
+	}
+	return ""
+}
+
+// truncateSnippetOutput keeps the start of a long output stream
+func truncateSnippetOutput(s string) string {
+	if len(s) <= maxSnippetOutput {
+		return s
+	}
+	return s[:maxSnippetOutput] + "\n... (output truncated)"
+}
+
+// formatSnippetResult shows the exit status followed by both streams
+func formatSnippetResult(r *SnippetResult, timeout time.Duration) string {
+	var b strings.Builder
+	switch {
+	case r.TimedOut:
+		fmt.Fprintf(&b, "Timed out after %v\n", timeout)
+	case r.ExitCode != 0:
+		fmt.Fprintf(&b, "Exit code %d\n", r.ExitCode)
+	}
+	if r.Stdout != "" {
+		fmt.Fprintf(&b, "stdout:\n%s", r.Stdout)
+		if !strings.HasSuffix(r.Stdout, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	if r.Stderr != "" {
+		fmt.Fprintf(&b, "stderr:\n%s", r.Stderr)
+	}
+	if b.Len() == 0 {
+		return "(no output)"
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,69 @@
+package builtin
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunSnippet(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		code     string
+		stdin    string
+		stdout   string
+		exitCode int
+	}{
+		{"go without package clause", "go", "import \"fmt\"\n\nfunc main() { fmt.Println(1 + 2) }\n", "", "3\n", 0},
+		{"go exit code", "golang", "package main\n\nimport \"os\"\n\nfunc main() { os.Exit(3) }\n", "", "", 3},
+		{"python stdin", "python", "import sys\nprint(sys.stdin.read().upper())", "abc", "ABC\n", 0},
+		{"javascript", "js", "console.log([3, 1, 2].sort().join(','))", "", "1,2,3\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			language := snippetLanguage(tt.language)
+			command := map[string]string{"go": "go", "python": pythonCommand(), "javascript": "node"}[language]
+			if _, err := exec.LookPath(command); err != nil {
+				t.Skipf("%s is not installed", command)
+			}
+
+			result, err := RunSnippet(context.Background(), tt.language, tt.code, tt.stdin, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Stdout != tt.stdout || result.ExitCode != tt.exitCode {
+				t.Errorf("got stdout %q exit %d, want %q exit %d (stderr %q)", result.Stdout, result.ExitCode, tt.stdout, tt.exitCode, result.Stderr)
+			}
+		})
+	}
+}
+
+func TestRunSnippetErrors(t *testing.T) {
+	if _, err := exec.LookPath(pythonCommand()); err != nil {
+		t.Skip("python is not installed")
+	}
+
+	result, err := RunSnippet(context.Background(), "python", "import time\ntime.sleep(10)", "", 200*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.TimedOut {
+		t.Errorf("expected a timeout, got %+v", result)
+	}
+
+	result, err = RunSnippet(context.Background(), "python", "raise ValueError('bad')", "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode == 0 || !strings.Contains(result.Stderr, "ValueError: bad") || strings.Contains(result.Stderr, "agentic-snippet-") {
+		t.Errorf("expected the traceback without the sandbox path, got %+v", result)
+	}
+
+	if _, err := RunSnippet(context.Background(), "ruby", "puts 1", "", time.Minute); err == nil {
+		t.Error("expected an error for an unsupported language")
+	}
+}
@@ -0,0 +1,368 @@
+package builtin
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProcessLimits caps what shell commands may spawn in a session
+type ProcessLimits struct {
+	MaxBackground int           // concurrent background shells (0 = unlimited)
+	MaxProcesses  int           // concurrent processes, counting children (0 = unlimited)
+	MaxCPUTime    time.Duration // cumulative CPU time of all commands (0 = unlimited)
+}
+
+// DefaultProcessLimits returns the limits used when none are configured
+func DefaultProcessLimits() ProcessLimits {
+	return ProcessLimits{
+		MaxBackground: 4,
+		MaxProcesses:  64,
+		MaxCPUTime:    30 * time.Minute,
+	}
+}
+
+// superviseInterval is how often running commands are checked against the limits
+var superviseInterval = 500 * time.Millisecond
+
+// Supervisor starts shell commands in their own process groups and stops
+// them when they spawn too many processes or use too much CPU time. A nil
+// Supervisor starts commands without limits or tracking.
+type Supervisor struct {
+	limits ProcessLimits
+
+	mu       sync.Mutex
+	procs    map[int]*Process
+	cpuSpent time.Duration // CPU time of finished commands
+	watching bool
+}
+
+// Process is a shell command started through a Supervisor
+type Process struct {
+	PID        int
+	Command    string
+	Background bool
+	Started    time.Time
+
+	cmd *exec.Cmd
+	sup *Supervisor
+
+	// Updated by the supervisor while the command runs
+	members    int
+	cpu        time.Duration
+	killReason string
+}
+
+// ProcessInfo describes a running command for listing
+type ProcessInfo struct {
+	PID        int
+	Command    string
+	Background bool
+	Started    time.Time
+	Processes  int           // the command and its children
+	CPU        time.Duration // CPU time used so far
+}
+
+// NewSupervisor creates a supervisor enforcing limits
+func NewSupervisor(limits ProcessLimits) *Supervisor {
+	return &Supervisor{
+		limits: limits,
+		procs:  make(map[int]*Process),
+	}
+}
+
+// Limits returns the limits the supervisor enforces
+func (s *Supervisor) Limits() ProcessLimits {
+	return s.limits
+}
+
+// Start starts a command in its own process group if the limits allow
+// another one
+func (s *Supervisor) Start(cmd *exec.Cmd, command string, background bool) (*Process, error) {
+	p := &Process{Command: command, Background: background, cmd: cmd, sup: s}
+	if s == nil {
+		if err := cmd.Start(); err != nil {
+			return nil, err
+		}
+		p.PID = cmd.Process.Pid
+		p.Started = time.Now()
+		return p, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.admit(background); err != nil {
+		return nil, err
+	}
+
+	setProcessGroup(cmd)
+	if cmd.Cancel != nil {
+		// Cancelling a CommandContext command stops its children too
+		cmd.Cancel = func() error {
+			return killProcessGroup(cmd.Process.Pid)
+		}
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p.PID = cmd.Process.Pid
+	p.Started = time.Now()
+	p.members = 1
+	s.procs[p.PID] = p
+
+	if !s.watching {
+		s.watching = true
+		go s.watch()
+	}
+	return p, nil
+}
+
+// admit checks whether another command may start. The caller holds s.mu.
+func (s *Supervisor) admit(background bool) error {
+	l := s.limits
+	if l.MaxCPUTime > 0 && s.cpuUsed() >= l.MaxCPUTime {
+		return fmt.Errorf("shell commands used their CPU time budget of %s for this session; no more commands can run", l.MaxCPUTime)
+	}
+	if background && l.MaxBackground > 0 {
+		running := 0
+		for _, p := range s.procs {
+			if p.Background {
+				running++
+			}
+		}
+		if running >= l.MaxBackground {
+			return fmt.Errorf("%d background shells are already running (limit %d); wait for one to finish or stop one with KillShell", running, l.MaxBackground)
+		}
+	}
+	if l.MaxProcesses > 0 && s.processCount() >= l.MaxProcesses {
+		return fmt.Errorf("agent commands are running %d processes (limit %d); wait for them to finish or stop some", s.processCount(), l.MaxProcesses)
+	}
+	return nil
+}
+
+// Wait waits for the command to exit, recording the CPU time it used
+func (p *Process) Wait() error {
+	err := p.cmd.Wait()
+	s := p.sup
+	if s == nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.procs, p.PID)
+	if state := p.cmd.ProcessState; state != nil {
+		// Includes children the shell waited for
+		s.cpuSpent += max(state.UserTime()+state.SystemTime(), p.cpu)
+	} else {
+		s.cpuSpent += p.cpu
+	}
+	return err
+}
+
+// KillReason returns why the supervisor stopped the command, or ""
+func (p *Process) KillReason() string {
+	if p.sup == nil {
+		return ""
+	}
+	p.sup.mu.Lock()
+	defer p.sup.mu.Unlock()
+	return p.killReason
+}
+
+// List returns the running commands, oldest first
+func (s *Supervisor) List() []ProcessInfo {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]ProcessInfo, 0, len(s.procs))
+	for _, p := range s.procs {
+		infos = append(infos, ProcessInfo{
+			PID:        p.PID,
+			Command:    p.Command,
+			Background: p.Background,
+			Started:    p.Started,
+			Processes:  p.members,
+			CPU:        p.cpu,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Started.Before(infos[j].Started) })
+	return infos
+}
+
+// Usage returns the processes running and the CPU time used in the session
+func (s *Supervisor) Usage() (processes int, cpu time.Duration) {
+	if s == nil {
+		return 0, 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.processCount(), s.cpuUsed()
+}
+
+// Kill stops a running command and all of its children
+func (s *Supervisor) Kill(pid int) error {
+	if s == nil {
+		return fmt.Errorf("no process %d", pid)
+	}
+	s.mu.Lock()
+	p, ok := s.procs[pid]
+	if ok && p.killReason == "" {
+		p.killReason = "stopped by the user"
+	}
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no agent command with PID %d", pid)
+	}
+	return killProcessGroup(pid)
+}
+
+func (s *Supervisor) processCount() int {
+	n := 0
+	for _, p := range s.procs {
+		n += p.members
+	}
+	return n
+}
+
+func (s *Supervisor) cpuUsed() time.Duration {
+	cpu := s.cpuSpent
+	for _, p := range s.procs {
+		cpu += p.cpu
+	}
+	return cpu
+}
+
+// watch samples running commands until none are left, stopping those
+// that break the limits
+func (s *Supervisor) watch() {
+	ticker := time.NewTicker(superviseInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !s.check() {
+			return
+		}
+	}
+}
+
+// check samples the running commands and enforces the limits, reporting
+// whether any are still running
+func (s *Supervisor) check() bool {
+	groups := sampleProcessGroups()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.procs) == 0 {
+		s.watching = false
+		return false
+	}
+
+	for pid, p := range s.procs {
+		if g, ok := groups[pid]; ok {
+			p.members = g.members
+			p.cpu = max(p.cpu, g.cpu)
+		}
+	}
+
+	l := s.limits
+	if l.MaxCPUTime > 0 && s.cpuUsed() >= l.MaxCPUTime {
+		for pid, p := range s.procs {
+			s.stop(pid, p, fmt.Sprintf("stopped: shell commands used their CPU time budget of %s", l.MaxCPUTime))
+		}
+		return true
+	}
+	if l.MaxProcesses > 0 {
+		// Stop the largest commands until the rest fit
+		for s.processCount() > l.MaxProcesses {
+			var largest *Process
+			for _, p := range s.procs {
+				if p.killReason == "" && (largest == nil || p.members > largest.members) {
+					largest = p
+				}
+			}
+			if largest == nil {
+				break
+			}
+			s.stop(largest.PID, largest, fmt.Sprintf("stopped: spawned %d processes, over the limit of %d", largest.members, l.MaxProcesses))
+			largest.members = 0
+		}
+	}
+	return true
+}
+
+// stop kills a command's process group once. The caller holds s.mu.
+func (s *Supervisor) stop(pid int, p *Process, reason string) {
+	if p.killReason != "" {
+		return
+	}
+	p.killReason = reason
+	_ = killProcessGroup(pid)
+}
+
+// groupSample is what /proc reports about a process group
+type groupSample struct {
+	members int
+	cpu     time.Duration
+}
+
+// clockTicks is the USER_HZ unit of CPU times in /proc
+const clockTicks = 100
+
+// sampleProcessGroups counts the processes and CPU time of each process
+// group from /proc. It returns nothing where /proc is unavailable, so
+// commands count as a single process there.
+func sampleProcessGroups() map[int]groupSample {
+	groups := make(map[int]groupSample)
+	stats, _ := filepath.Glob("/proc/[0-9]*/stat")
+	for _, path := range stats {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		pgrp, ticks, ok := parseProcStat(string(data))
+		if !ok {
+			continue
+		}
+		g := groups[pgrp]
+		g.members++
+		g.cpu += time.Duration(ticks) * time.Second / clockTicks
+		groups[pgrp] = g
+	}
+	return groups
+}
+
+// parseProcStat reads the process group and the CPU ticks of a process
+// and its waited-for children from a /proc/<pid>/stat line
+func parseProcStat(stat string) (pgrp int, ticks int64, ok bool) {
+	// The command name may contain spaces, so fields start after its ")"
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, 0, false
+	}
+	fields := strings.Fields(stat[end+1:])
+	// state ppid pgrp session tty tpgid flags minflt cminflt majflt cmajflt utime stime cutime cstime
+	if len(fields) < 15 {
+		return 0, 0, false
+	}
+	pgrp, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return 0, 0, false
+	}
+	for _, f := range fields[11:15] {
+		n, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		ticks += n
+	}
+	return pgrp, ticks, true
+}
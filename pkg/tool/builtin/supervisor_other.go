@@ -0,0 +1,20 @@
+//go:build !unix
+
+package builtin
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing where process groups are unavailable
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills just the process, as its children cannot be found
+func killProcessGroup(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
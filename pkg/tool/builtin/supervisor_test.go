@@ -0,0 +1,107 @@
+package builtin
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseProcStat(t *testing.T) {
+	tests := []struct {
+		stat      string
+		wantPgrp  int
+		wantTicks int64
+		wantOK    bool
+	}{
+		{"1234 (bash) S 1 1234 1234 34816 1234 4194304 100 0 0 0 7 3 2 1 20 0 1 0", 1234, 13, true},
+		{"99 (my (odd) cmd) R 1 42 42 0 -1 0 0 0 0 0 10 20 0 0 20 0", 42, 30, true},
+		{"99 (short) R 1 42", 0, 0, false},
+		{"no parenthesis here", 0, 0, false},
+	}
+	for _, tt := range tests {
+		pgrp, ticks, ok := parseProcStat(tt.stat)
+		if pgrp != tt.wantPgrp || ticks != tt.wantTicks || ok != tt.wantOK {
+			t.Errorf("parseProcStat(%q) = %d, %d, %v; want %d, %d, %v",
+				tt.stat, pgrp, ticks, ok, tt.wantPgrp, tt.wantTicks, tt.wantOK)
+		}
+	}
+}
+
+func TestSupervisorAdmit(t *testing.T) {
+	s := NewSupervisor(ProcessLimits{MaxBackground: 1, MaxProcesses: 3, MaxCPUTime: time.Minute})
+	s.procs[1] = &Process{PID: 1, Background: true, members: 1}
+
+	if err := s.admit(false); err != nil {
+		t.Errorf("expected a foreground command to be admitted, got %v", err)
+	}
+	if err := s.admit(true); err == nil || !strings.Contains(err.Error(), "background") {
+		t.Errorf("expected the background limit to apply, got %v", err)
+	}
+
+	s.procs[2] = &Process{PID: 2, members: 2}
+	if err := s.admit(false); err == nil || !strings.Contains(err.Error(), "processes") {
+		t.Errorf("expected the process limit to apply, got %v", err)
+	}
+
+	delete(s.procs, 2)
+	s.cpuSpent = time.Minute
+	if err := s.admit(false); err == nil || !strings.Contains(err.Error(), "CPU time") {
+		t.Errorf("expected the CPU budget to apply, got %v", err)
+	}
+}
+
+func TestSupervisorStartAndKill(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	s := NewSupervisor(DefaultProcessLimits())
+
+	p, err := s.Start(exec.Command("sh", "-c", "sleep 30"), "sleep 30", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := s.List()
+	if len(list) != 1 || list[0].PID != p.PID || !list[0].Background {
+		t.Fatalf("expected the command to be listed, got %+v", list)
+	}
+
+	if err := s.Kill(p.PID); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- p.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("command did not stop")
+	}
+
+	if reason := p.KillReason(); reason != "stopped by the user" {
+		t.Errorf("unexpected kill reason %q", reason)
+	}
+	if n, _ := s.Usage(); n != 0 || len(s.List()) != 0 {
+		t.Errorf("expected no running processes, got %d", n)
+	}
+	if err := s.Kill(p.PID); err == nil {
+		t.Error("expected killing a finished command to fail")
+	}
+}
+
+func TestNilSupervisor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	var s *Supervisor
+	p, err := s.Start(exec.Command("sh", "-c", "exit 0"), "exit 0", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Wait(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if p.KillReason() != "" || s.List() != nil {
+		t.Error("expected a nil supervisor to track nothing")
+	}
+}
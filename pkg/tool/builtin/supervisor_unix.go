@@ -0,0 +1,22 @@
+//go:build unix
+
+package builtin
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup runs a command in a new process group, so it can be
+// stopped together with its children
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// killProcessGroup kills every process in the group led by pid
+func killProcessGroup(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGKILL)
+}
@@ -0,0 +1,356 @@
+package builtin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+	"gopkg.in/yaml.v3"
+)
+
+// Target is a build target, task, or script defined by the project
+type Target struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     bool   `json:"default,omitempty"`
+}
+
+// TargetSource is a file defining targets and the command that runs them
+type TargetSource struct {
+	File    string   `json:"file"`
+	Run     string   `json:"run"` // command prefix, e.g. "make" or "npm run"
+	Targets []Target `json:"targets"`
+}
+
+// TargetsTool lists the build, test, and other targets a project defines
+type TargetsTool struct{}
+
+// TargetsInput represents the input for the Targets tool
+type TargetsInput struct {
+	Path   string `json:"path,omitempty"`
+	Filter string `json:"filter,omitempty"`
+}
+
+// NewTargetsTool creates a new Targets tool
+func NewTargetsTool() *TargetsTool {
+	return &TargetsTool{}
+}
+
+func (t *TargetsTool) Name() string {
+	return "Targets"
+}
+
+func (t *TargetsTool) Description() string {
+	return `Lists the targets a project defines in its Makefile, Taskfile, and package.json scripts, with descriptions and the command to run each.
+- Use this before building, testing, linting, or formatting, and run the project's own target with Bash instead of inventing commands
+- filter narrows the list to targets whose name or description contains the text (e.g. "test")`
+}
+
+func (t *TargetsTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"path": {
+				"type": "string",
+				"description": "The project directory. If not specified, the current working directory will be used."
+			},
+			"filter": {
+				"type": "string",
+				"description": "Only list targets whose name or description contains this text (case-insensitive)"
+			}
+		}
+	}`)
+}
+
+func (t *TargetsTool) Validate(input *tool.Input) error {
+	_, err := tool.ParamsTo[TargetsInput](input.Params)
+	return err
+}
+
+func (t *TargetsTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+	params, err := tool.ParamsTo[TargetsInput](input.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := params.Path
+	if dir == "" && input.Context != nil {
+		dir = input.Context.CWD
+	}
+	if dir == "" {
+		dir = "."
+	}
+	if !filepath.IsAbs(dir) && input.Context != nil && input.Context.CWD != "" {
+		dir = filepath.Join(input.Context.CWD, dir)
+	}
+
+	sources, errs := DiscoverTargets(dir)
+	if params.Filter != "" {
+		sources = filterTargets(sources, params.Filter)
+	}
+
+	var b strings.Builder
+	for _, src := range sources {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s (run with: %s <name>)\n", src.File, src.Run)
+		width := 0
+		for _, target := range src.Targets {
+			width = max(width, len(target.Name))
+		}
+		for _, target := range src.Targets {
+			line := fmt.Sprintf("  %-*s  %s", width, target.Name, target.Description)
+			if target.Default {
+				line += " (default)"
+			}
+			b.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	}
+	for _, err := range errs {
+		fmt.Fprintf(&b, "\nWarning: %v\n", err)
+	}
+
+	if len(sources) == 0 {
+		msg := "No Makefile, Taskfile, or package.json scripts found in " + dir
+		if params.Filter != "" {
+			msg = fmt.Sprintf("No targets matching %q in %s", params.Filter, dir)
+		}
+		b.WriteString(msg)
+	}
+
+	return &tool.Output{
+		Content: strings.TrimSuffix(b.String(), "\n"),
+		Metadata: map[string]interface{}{
+			"sources": sources,
+		},
+	}, nil
+}
+
+// DiscoverTargets returns the targets defined in dir. Files that fail to
+// parse are reported as errors without hiding the others.
+func DiscoverTargets(dir string) ([]TargetSource, []error) {
+	var sources []TargetSource
+	var errs []error
+
+	for _, name := range []string{"GNUmakefile", "Makefile", "makefile"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if targets := makefileTargets(data); len(targets) > 0 {
+			sources = append(sources, TargetSource{File: name, Run: "make", Targets: targets})
+		}
+		break
+	}
+
+	for _, name := range []string{"Taskfile.yml", "Taskfile.yaml", "taskfile.yml", "taskfile.yaml"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		targets, err := taskfileTargets(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		} else if len(targets) > 0 {
+			sources = append(sources, TargetSource{File: name, Run: "task", Targets: targets})
+		}
+		break
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		targets, err := packageScripts(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("package.json: %w", err))
+		} else if len(targets) > 0 {
+			sources = append(sources, TargetSource{File: "package.json", Run: scriptRunner(dir), Targets: targets})
+		}
+	}
+
+	return sources, errs
+}
+
+// scriptRunner returns the command running package.json scripts with the
+// project's package manager
+func scriptRunner(dir string) string {
+	for _, m := range detectPackageManagers(dir) {
+		switch m.Name {
+		case "yarn":
+			return "yarn run"
+		case "pnpm":
+			return "pnpm run"
+		case "bun":
+			return "bun run"
+		}
+	}
+	return "npm run"
+}
+
+// filterTargets keeps the targets whose name or description contains filter
+func filterTargets(sources []TargetSource, filter string) []TargetSource {
+	filter = strings.ToLower(filter)
+	var filtered []TargetSource
+	for _, src := range sources {
+		var targets []Target
+		for _, target := range src.Targets {
+			if strings.Contains(strings.ToLower(target.Name), filter) ||
+				strings.Contains(strings.ToLower(target.Description), filter) {
+				targets = append(targets, target)
+			}
+		}
+		if len(targets) > 0 {
+			src.Targets = targets
+			filtered = append(filtered, src)
+		}
+	}
+	return filtered
+}
+
+// makeRulePattern matches a rule line: the targets before a colon
+var makeRulePattern = regexp.MustCompile(`^([^\s:#=][^:#=]*?)\s*:`)
+
+// makefileTargets lists the explicit targets of a Makefile. A target is
+// described by a trailing "## text" comment, the comment line above it, or
+// its first recipe line.
+func makefileTargets(data []byte) []Target {
+	var targets []Target
+	seen := make(map[string]bool)
+	var comment string
+	var pending []int // targets still waiting for a recipe line
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "\t") {
+			recipe := strings.TrimLeft(strings.TrimSpace(line), "@-+")
+			if recipe != "" && !strings.HasPrefix(recipe, "#") {
+				for _, i := range pending {
+					targets[i].Description = recipe
+				}
+				pending = nil
+			}
+			continue
+		}
+		pending = nil
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			comment = ""
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			comment = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			continue
+		}
+		m := makeRulePattern.FindStringSubmatch(line)
+		if m == nil || isMakeAssignment(line[len(m[0])-1:]) || strings.Contains(m[1], "$") {
+			comment = ""
+			continue
+		}
+
+		description := comment
+		if i := strings.Index(line, "##"); i >= 0 {
+			description = strings.TrimSpace(line[i+2:])
+		}
+		comment = ""
+
+		for _, name := range strings.Fields(m[1]) {
+			// Skip special targets such as .PHONY, pattern rules, and files
+			if strings.HasPrefix(name, ".") || strings.Contains(name, "%") || seen[name] {
+				continue
+			}
+			seen[name] = true
+			targets = append(targets, Target{Name: name, Description: description, Default: len(targets) == 0})
+			if description == "" {
+				pending = append(pending, len(targets)-1)
+			}
+		}
+	}
+	return targets
+}
+
+// isMakeAssignment reports whether the text from a colon on is a ":=",
+// "::=", or ":::=" variable assignment
+func isMakeAssignment(rest string) bool {
+	return strings.HasPrefix(strings.TrimLeft(rest, ":"), "=")
+}
+
+// taskfileTargets lists the tasks of a Taskfile in definition order
+func taskfileTargets(data []byte) ([]Target, error) {
+	var doc struct {
+		Tasks yaml.Node `yaml:"tasks"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Tasks.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	var targets []Target
+	for i := 0; i+1 < len(doc.Tasks.Content); i += 2 {
+		name := doc.Tasks.Content[i].Value
+		var task struct {
+			Desc     string `yaml:"desc"`
+			Summary  string `yaml:"summary"`
+			Internal bool   `yaml:"internal"`
+		}
+		// Tasks may also be a command string or list; those have no description
+		if node := doc.Tasks.Content[i+1]; node.Kind == yaml.MappingNode {
+			if err := node.Decode(&task); err != nil {
+				return nil, fmt.Errorf("task %s: %w", name, err)
+			}
+		}
+		if task.Internal {
+			continue
+		}
+		description := task.Desc
+		if description == "" {
+			description = strings.SplitN(strings.TrimSpace(task.Summary), "\n", 2)[0]
+		}
+		targets = append(targets, Target{Name: name, Description: description, Default: name == "default"})
+	}
+	return targets, nil
+}
+
+// packageScripts lists the scripts of a package.json in definition order,
+// described by their commands
+func packageScripts(data []byte) ([]Target, error) {
+	var manifest struct {
+		Scripts json.RawMessage `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	if len(manifest.Scripts) == 0 {
+		return nil, nil
+	}
+
+	// Decode token by token, since a map would lose the order
+	dec := json.NewDecoder(bytes.NewReader(manifest.Scripts))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("scripts must be an object")
+	}
+	var targets []Target
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var command interface{}
+		if err := dec.Decode(&command); err != nil {
+			return nil, err
+		}
+		script, _ := command.(string)
+		targets = append(targets, Target{Name: key.(string), Description: script})
+	}
+	return targets, nil
+}
@@ -0,0 +1,142 @@
+package builtin
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestMakefileTargets(t *testing.T) {
+	makefile := `.PHONY: build test lint
+BINARY := app
+VERSION ?= 1.0
+LDFLAGS ::= -s -w
+
+# Build the binary
+build: generate
+	@echo "building"
+	go build -o $(BINARY) ./cmd/app
+
+test: ## Run the unit tests
+	go test ./...
+
+lint fmt:
+	-golangci-lint run
+
+# stray comment
+
+%.o: %.c
+	cc -c $<
+
+$(BINARY): build
+
+build: extra
+`
+	got := makefileTargets([]byte(makefile))
+	want := []Target{
+		{Name: "build", Description: "Build the binary", Default: true},
+		{Name: "test", Description: "Run the unit tests"},
+		{Name: "lint", Description: "golangci-lint run"},
+		{Name: "fmt", Description: "golangci-lint run"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("makefileTargets() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTaskfileTargets(t *testing.T) {
+	taskfile := `version: '3'
+tasks:
+  default:
+    cmds: [task build]
+  build:
+    desc: Build the app
+    cmds:
+      - go build ./...
+  release:
+    summary: |
+      Publish a release.
+      Requires credentials.
+  helper:
+    internal: true
+  fmt: gofmt -w .
+`
+	got, err := taskfileTargets([]byte(taskfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Target{
+		{Name: "default", Default: true},
+		{Name: "build", Description: "Build the app"},
+		{Name: "release", Description: "Publish a release."},
+		{Name: "fmt"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("taskfileTargets() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPackageScripts(t *testing.T) {
+	got, err := packageScripts([]byte(`{"name": "web", "scripts": {"test": "vitest run", "build": "vite build", "dev": "vite"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Target{
+		{Name: "test", Description: "vitest run"},
+		{Name: "build", Description: "vite build"},
+		{Name: "dev", Description: "vite"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("packageScripts() = %+v, want %+v", got, want)
+	}
+
+	if _, err := packageScripts([]byte(`{"scripts": ["test"]}`)); err == nil {
+		t.Error("expected error for non-object scripts")
+	}
+}
+
+func TestTargetsTool(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Makefile":     "build:\n\tgo build ./...\n\ntest:\n\tgo test ./...\n",
+		"package.json": `{"scripts": {"test": "jest", "start": "node server.js"}}`,
+		"yarn.lock":    "",
+	})
+
+	targets := NewTargetsTool()
+	run := func(params map[string]interface{}) string {
+		output, err := targets.Execute(context.Background(), &tool.Input{Params: params, Context: &tool.ExecutionContext{CWD: dir}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output.Content
+	}
+
+	content := run(map[string]interface{}{})
+	for _, want := range []string{
+		"Makefile (run with: make <name>)",
+		"  build  go build ./... (default)",
+		"package.json (run with: yarn run <name>)",
+		"  start  node server.js",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %q in:\n%s", want, content)
+		}
+	}
+
+	content = run(map[string]interface{}{"filter": "TEST"})
+	if strings.Contains(content, "build") || strings.Contains(content, "start") {
+		t.Errorf("filter kept unrelated targets:\n%s", content)
+	}
+	if !strings.Contains(content, "go test ./...") || !strings.Contains(content, "jest") {
+		t.Errorf("filter dropped test targets:\n%s", content)
+	}
+
+	content = run(map[string]interface{}{"filter": "deploy"})
+	if !strings.HasPrefix(content, `No targets matching "deploy"`) {
+		t.Errorf("unexpected output for unmatched filter:\n%s", content)
+	}
+}
@@ -3,6 +3,7 @@ package builtin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -167,14 +168,19 @@ func (t *TaskTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 		}, nil
 	}
 
-	// Create new task
-	newTask, err := t.manager.CreateTask(&task.TaskOptions{
+	// Create new task, reporting its progress to the caller
+	opts := &task.TaskOptions{
 		Description:     params.Description,
 		Prompt:          params.Prompt,
 		SubagentType:    params.SubagentType,
 		Model:           params.Model,
 		RunInBackground: params.RunInBackground,
-	})
+	}
+	if input.Context != nil {
+		opts.CWD = input.Context.CWD
+		opts.OnEvent = input.Context.Subtask
+	}
+	newTask, err := t.manager.CreateTask(opts)
 	if err != nil {
 		return &tool.Output{
 			Content: fmt.Sprintf("Failed to create task: %v", err),
@@ -194,9 +200,19 @@ func (t *TaskTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output
 		}, nil
 	}
 
-	// Run synchronously
+	// Run synchronously; the user may cancel the task alone
 	err = t.manager.RunTask(ctx, newTask.ID)
 
+	if errors.Is(err, task.ErrCancelled) && ctx.Err() == nil {
+		return &tool.Output{
+			Content: "Task cancelled by the user",
+			IsError: true,
+			Metadata: map[string]interface{}{
+				"task_id": newTask.ID,
+				"status":  string(task.TaskStatusCancelled),
+			},
+		}, nil
+	}
 	if err != nil {
 		return &tool.Output{
 			Content: fmt.Sprintf("Task failed: %v", err),
@@ -0,0 +1,470 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+const (
+	// maxTestFailures is the number of failures reported in detail
+	maxTestFailures = 20
+
+	// maxFailureLines is the number of output lines kept per failure
+	maxFailureLines = 10
+)
+
+// TestFailure is a failed test or a package that failed to build
+type TestFailure struct {
+	Package string `json:"package,omitempty"`
+	Test    string `json:"test,omitempty"`
+	File    string `json:"file,omitempty"` // relative to the project directory when known
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// String formats the failure as file:line: test: message
+func (f TestFailure) String() string {
+	var b strings.Builder
+	if f.File != "" {
+		b.WriteString(f.File)
+		if f.Line > 0 {
+			fmt.Fprintf(&b, ":%d", f.Line)
+		}
+		b.WriteString(": ")
+	}
+	switch {
+	case f.Test != "":
+		b.WriteString(f.Test + ": ")
+	case f.Package != "":
+		b.WriteString(f.Package + ": ")
+	}
+	b.WriteString(f.Message)
+	return b.String()
+}
+
+// TestReport is the outcome of a test run
+type TestReport struct {
+	Command  string        `json:"command"`
+	ExitCode int           `json:"exit_code"`
+	Passed   int           `json:"passed"`
+	Failures []TestFailure `json:"failures,omitempty"`
+}
+
+// OK reports whether the run succeeded
+func (r *TestReport) OK() bool {
+	return r.ExitCode == 0 && len(r.Failures) == 0
+}
+
+// Issues returns the failures formatted with their locations
+func (r *TestReport) Issues() []string {
+	issues := make([]string, 0, len(r.Failures))
+	for _, f := range r.Failures {
+		issues = append(issues, f.String())
+	}
+	return issues
+}
+
+// TestTool runs the project's tests and reports the failures
+type TestTool struct {
+	// Workspace is where the tests run (nil = the local machine)
+	Workspace Workspace
+}
+
+// TestInput represents the input for the Test tool
+type TestInput struct {
+	Target string `json:"target,omitempty"`
+	Run    string `json:"run,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+// NewTestTool creates a new Test tool
+func NewTestTool() *TestTool {
+	return &TestTool{}
+}
+
+func (t *TestTool) Name() string {
+	return "Test"
+}
+
+func (t *TestTool) Description() string {
+	return `Runs the project's tests and reports each failure with its file and line.
+- Go projects run go test; Python projects run pytest; Node projects run the package.json test script
+- target is a Go package pattern (default ./...) or a Python test file or directory
+- run selects tests by name (go test -run, pytest -k)
+- Run this after changing code and fix every reported failure`
+}
+
+func (t *TestTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"target": {
+				"type": "string",
+				"description": "The packages or files to test, e.g. \"./pkg/engine/...\" for Go or \"tests/test_api.py\" for Python (default: the whole project)"
+			},
+			"run": {
+				"type": "string",
+				"description": "Only run tests whose name matches, e.g. \"TestParse\" (Go) or \"parse\" (Python)"
+			},
+			"path": {
+				"type": "string",
+				"description": "The project directory. If not specified, the current working directory will be used."
+			}
+		}
+	}`)
+}
+
+func (t *TestTool) Validate(input *tool.Input) error {
+	params, err := tool.ParamsTo[TestInput](input.Params)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range map[string]string{"target": params.Target, "run": params.Run} {
+		if strings.HasPrefix(value, "-") || strings.ContainsAny(value, " \t\n") {
+			return fmt.Errorf("invalid %s: %q", name, value)
+		}
+	}
+
+	return nil
+}
+
+func (t *TestTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+	params, err := tool.ParamsTo[TestInput](input.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := params.Path
+	if dir == "" && input.Context != nil {
+		dir = input.Context.CWD
+	}
+	if dir == "" {
+		dir = "."
+	}
+	if !filepath.IsAbs(dir) && input.Context != nil && input.Context.CWD != "" {
+		dir = filepath.Join(input.Context.CWD, dir)
+	}
+
+	report, err := runTests(ctx, t.Workspace, dir, params.Target, params.Run)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return &tool.Output{
+			Content: "Error: no test suite found; tests are run for Go (go.mod), Python (pyproject.toml, requirements.txt) and Node (package.json) projects",
+			IsError: true,
+		}, nil
+	}
+
+	return &tool.Output{
+		Content:  formatTestReport(report),
+		Metadata: report,
+	}, nil
+}
+
+// RunTests runs the tests of the project in dir, returning nil if no
+// supported test suite is found. target and run narrow the run as in the
+// Test tool.
+func RunTests(ctx context.Context, dir, target, run string) (*TestReport, error) {
+	return runTests(ctx, nil, dir, target, run)
+}
+
+// runTests is RunTests in a workspace (nil = the local machine)
+func runTests(ctx context.Context, ws Workspace, dir, target, run string) (*TestReport, error) {
+	managers := detectPackageManagers(dir)
+	if len(managers) == 0 {
+		return nil, nil
+	}
+	manager := managers[0]
+
+	var args []string
+	switch manager.Ecosystem {
+	case "go":
+		if target == "" {
+			target = "./..."
+		}
+		args = []string{"go", "test", "-json"}
+		if run != "" {
+			args = append(args, "-run", run)
+		}
+		args = append(args, target)
+	case "python":
+		args = []string{pythonCommand(), "-m", "pytest", "-q", "-rf"}
+		if run != "" {
+			args = append(args, "-k", run)
+		}
+		if target != "" {
+			args = append(args, target)
+		}
+	case "node":
+		args = []string{manager.Name, "test"}
+		if manager.Name == "bun" {
+			args = []string{"bun", "run", "test"}
+		}
+	default:
+		return nil, nil
+	}
+
+	out, exitCode, err := runWorkspaceCommand(ctx, ws, dir, args)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TestReport{Command: strings.Join(args, " "), ExitCode: exitCode}
+	switch manager.Ecosystem {
+	case "go":
+		module, _, _ := runWorkspaceCommand(ctx, ws, dir, []string{"go", "list", "-m"})
+		module, _, _ = strings.Cut(strings.TrimSpace(module), "\n")
+		report.Passed, report.Failures = parseGoTestJSON(out, module)
+	case "python":
+		report.Passed, report.Failures = parsePytest(out)
+	}
+	if exitCode != 0 && len(report.Failures) == 0 {
+		report.Failures = []TestFailure{{Message: fmt.Sprintf("%s exited with code %d:\n%s", report.Command, exitCode, lastLines(out, 20))}}
+	}
+	return report, nil
+}
+
+// goTestEvent is a line of go test -json output
+type goTestEvent struct {
+	Action     string
+	Package    string
+	ImportPath string // build events
+	Test       string
+	Output     string
+}
+
+// locationPattern matches the file:line prefix of test and compiler messages
+var locationPattern = regexp.MustCompile(`^\s*(\S+\.go):(\d+)(?::\d+)?: (.*)$`)
+
+// parseGoTestJSON reads go test -json output, returning the number of
+// passed tests and the failures. Files are made relative to the project
+// using the module path.
+func parseGoTestJSON(out, module string) (int, []TestFailure) {
+	type key struct{ pkg, test string }
+	output := make(map[key][]string)
+	var failed []key
+	var failedPkgs []string
+	var stray []string
+	passed := 0
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var ev goTestEvent
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &ev) != nil {
+			stray = append(stray, line) // build errors before Go 1.24
+			continue
+		}
+		pkg := ev.Package
+		if pkg == "" {
+			// Test builds are named like "example.com/pkg [example.com/pkg.test]"
+			pkg, _, _ = strings.Cut(ev.ImportPath, " [")
+		}
+		k := key{pkg, ev.Test}
+		switch ev.Action {
+		case "output", "build-output":
+			output[k] = append(output[k], strings.TrimRight(ev.Output, "\n"))
+		case "pass":
+			if ev.Test != "" {
+				passed++
+			}
+		case "fail", "build-fail":
+			if ev.Test != "" {
+				failed = append(failed, k)
+			} else if !slices.Contains(failedPkgs, pkg) {
+				failedPkgs = append(failedPkgs, pkg)
+			}
+		}
+	}
+
+	pkgDir := func(pkg string) string {
+		if module != "" && strings.HasPrefix(pkg, module+"/") {
+			return filepath.FromSlash(strings.TrimPrefix(pkg, module+"/"))
+		}
+		return ""
+	}
+
+	var failures []TestFailure
+	hasFailedTest := make(map[string]bool)
+	for _, k := range failed {
+		hasFailedTest[k.pkg] = true
+		// A parent test fails with its subtests; report the subtests
+		parent := false
+		for _, other := range failed {
+			if other.pkg == k.pkg && strings.HasPrefix(other.test, k.test+"/") {
+				parent = true
+				break
+			}
+		}
+		if parent {
+			continue
+		}
+		f := TestFailure{Package: k.pkg, Test: k.test}
+		f.File, f.Line, f.Message = locateFailure(output[k])
+		if f.File != "" {
+			f.File = filepath.Join(pkgDir(k.pkg), f.File)
+		}
+		failures = append(failures, f)
+	}
+
+	// Packages that failed without a failing test did not build or crashed
+	for _, pkg := range failedPkgs {
+		if hasFailedTest[pkg] {
+			continue
+		}
+		lines := trimLines(output[key{pkg, ""}])
+		errs := compilerErrors(lines)
+		for _, f := range errs {
+			f.Package = pkg
+			if dir := pkgDir(pkg); dir != "" && !strings.Contains(f.File, string(filepath.Separator)) {
+				f.File = filepath.Join(dir, f.File)
+			}
+			failures = append(failures, f)
+		}
+		if len(errs) == 0 && len(lines) > 0 {
+			if len(lines) > maxFailureLines {
+				lines = lines[len(lines)-maxFailureLines:]
+			}
+			failures = append(failures, TestFailure{Package: pkg, Message: strings.Join(lines, "\n")})
+		}
+	}
+	failures = append(failures, compilerErrors(stray)...)
+
+	return passed, failures
+}
+
+// locateFailure finds the first file:line message in a test's output
+func locateFailure(lines []string) (file string, line int, message string) {
+	lines = trimLines(lines)
+	for i, l := range lines {
+		m := locationPattern.FindStringSubmatch(l)
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[2])
+		rest := []string{m[3]}
+		for _, more := range lines[i+1:] {
+			if len(rest) == maxFailureLines || locationPattern.MatchString(more) {
+				break
+			}
+			rest = append(rest, strings.TrimSpace(more))
+		}
+		return m[1], n, strings.Join(rest, "\n")
+	}
+	if len(lines) > maxFailureLines {
+		lines = lines[len(lines)-maxFailureLines:]
+	}
+	return "", 0, strings.Join(lines, "\n")
+}
+
+// compilerErrors returns the file:line errors among lines of build output
+func compilerErrors(lines []string) []TestFailure {
+	var failures []TestFailure
+	for _, l := range lines {
+		m := locationPattern.FindStringSubmatch(l)
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[2])
+		failures = append(failures, TestFailure{
+			Test:    "build",
+			File:    filepath.Clean(m[1]),
+			Line:    n,
+			Message: m[3],
+		})
+	}
+	return failures
+}
+
+// trimLines drops the run markers and summaries go test prints around
+// test output
+func trimLines(lines []string) []string {
+	var kept []string
+	for _, l := range lines {
+		t := strings.TrimSpace(l)
+		switch {
+		case t == "", t == "FAIL", t == "PASS",
+			strings.HasPrefix(t, "=== "),
+			strings.HasPrefix(t, "--- FAIL"),
+			strings.HasPrefix(t, "--- PASS"),
+			strings.HasPrefix(t, "FAIL\t"),
+			strings.HasPrefix(t, "ok  \t"):
+			continue
+		}
+		kept = append(kept, l)
+	}
+	return kept
+}
+
+var (
+	// pytestFailedPattern matches the short summary lines of pytest -rf
+	pytestFailedPattern = regexp.MustCompile(`^FAILED (\S+?)::(\S+)(?: - (.*))?$`)
+
+	// pytestPassedPattern matches the passed count in pytest's final line
+	pytestPassedPattern = regexp.MustCompile(`(\d+) passed`)
+
+	// pytestLocationPattern matches a traceback location such as tests/test_a.py:12: AssertionError
+	pytestLocationPattern = regexp.MustCompile(`^(\S+\.py):(\d+): `)
+)
+
+// parsePytest reads pytest -q -rf output
+func parsePytest(out string) (int, []TestFailure) {
+	lines := strings.Split(out, "\n")
+
+	// Tracebacks name the failing line of each test file
+	locations := make(map[string]int)
+	for _, l := range lines {
+		if m := pytestLocationPattern.FindStringSubmatch(l); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			locations[m[1]] = n
+		}
+	}
+
+	passed := 0
+	var failures []TestFailure
+	for _, l := range lines {
+		if m := pytestFailedPattern.FindStringSubmatch(strings.TrimSpace(l)); m != nil {
+			message := m[3]
+			if message == "" {
+				message = "failed"
+			}
+			failures = append(failures, TestFailure{Test: m[2], File: m[1], Line: locations[m[1]], Message: message})
+		}
+		if m := pytestPassedPattern.FindStringSubmatch(l); m != nil {
+			passed, _ = strconv.Atoi(m[1])
+		}
+	}
+	return passed, failures
+}
+
+// formatTestReport summarizes a test run for the model
+func formatTestReport(r *TestReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "$ %s\n", r.Command)
+	if r.OK() {
+		fmt.Fprintf(&b, "PASS: %d tests passed\n", r.Passed)
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "FAIL: %d failures, %d tests passed (exit code %d)\n", len(r.Failures), r.Passed, r.ExitCode)
+	for i, issue := range r.Issues() {
+		if i == maxTestFailures {
+			fmt.Fprintf(&b, "\n... and %d more failures\n", len(r.Failures)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\n%s\n", issue)
+	}
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package builtin
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestParseGoTestJSON(t *testing.T) {
+	out := strings.Join([]string{
+		`{"Action":"run","Package":"example.com/m/calc","Test":"TestAbs"}`,
+		`{"Action":"pass","Package":"example.com/m/calc","Test":"TestAbs"}`,
+		`{"Action":"run","Package":"example.com/m/calc","Test":"TestDiv"}`,
+		`{"Action":"output","Package":"example.com/m/calc","Test":"TestDiv/zero","Output":"=== RUN   TestDiv/zero\n"}`,
+		`{"Action":"output","Package":"example.com/m/calc","Test":"TestDiv/zero","Output":"    calc_test.go:21: Div(1, 0) returned no error\n"}`,
+		`{"Action":"output","Package":"example.com/m/calc","Test":"TestDiv/zero","Output":"        want: division by zero\n"}`,
+		`{"Action":"output","Package":"example.com/m/calc","Test":"TestDiv/zero","Output":"    --- FAIL: TestDiv/zero (0.00s)\n"}`,
+		`{"Action":"fail","Package":"example.com/m/calc","Test":"TestDiv/zero"}`,
+		`{"Action":"fail","Package":"example.com/m/calc","Test":"TestDiv"}`,
+		`{"Action":"fail","Package":"example.com/m/calc"}`,
+		`{"ImportPath":"example.com/m/broken [example.com/m/broken.test]","Action":"build-output","Output":"# example.com/m/broken\n"}`,
+		`{"ImportPath":"example.com/m/broken [example.com/m/broken.test]","Action":"build-output","Output":"broken/broken.go:7:2: undefined: helper\n"}`,
+		`{"ImportPath":"example.com/m/broken [example.com/m/broken.test]","Action":"build-fail"}`,
+		`{"Action":"output","Package":"example.com/m/broken","Output":"FAIL\texample.com/m/broken [build failed]\n"}`,
+		`{"Action":"fail","Package":"example.com/m/broken"}`,
+	}, "\n")
+
+	passed, failures := parseGoTestJSON(out, "example.com/m")
+	if passed != 1 {
+		t.Errorf("expected 1 passed test, got %d", passed)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("expected 2 failures, got %+v", failures)
+	}
+
+	want := filepath.Join("calc", "calc_test.go") + ":21: TestDiv/zero: Div(1, 0) returned no error\nwant: division by zero"
+	if got := failures[0].String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	want = filepath.Join("broken", "broken.go") + ":7: build: undefined: helper"
+	if got := failures[1].String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParsePytest(t *testing.T) {
+	out := `..F
+=================================== FAILURES ===================================
+___________________________________ test_div ___________________________________
+
+    def test_div():
+>       assert div(1, 2) == 1
+E       assert 0.5 == 1
+
+tests/test_calc.py:9: AssertionError
+=========================== short test summary info ============================
+FAILED tests/test_calc.py::test_div - assert 0.5 == 1
+1 failed, 2 passed in 0.03s
+`
+	passed, failures := parsePytest(out)
+	if passed != 2 || len(failures) != 1 {
+		t.Fatalf("expected 2 passed and 1 failure, got %d and %+v", passed, failures)
+	}
+	if got, want := failures[0].String(), "tests/test_calc.py:9: test_div: assert 0.5 == 1"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTestToolGo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not installed")
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":       "module example.com/calc\n\ngo 1.21\n",
+		"calc/calc.go": coverageSource,
+		"calc/calc_test.go": `package calc
+
+import "testing"
+
+func TestAbs(t *testing.T) {
+	if Abs(-3) != 3 {
+		t.Fail()
+	}
+}
+
+func TestDiv(t *testing.T) {
+	if _, err := Div(1, 1); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+`,
+	})
+
+	output, err := NewTestTool().Execute(context.Background(), &tool.Input{
+		Params:  map[string]interface{}{},
+		Context: &tool.ExecutionContext{CWD: dir},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, ok := output.Metadata.(*TestReport)
+	if !ok || report.OK() {
+		t.Fatalf("expected a failing report, got:\n%s", output.Content)
+	}
+	if report.Passed != 1 || len(report.Failures) != 1 {
+		t.Fatalf("expected 1 passed test and 1 failure, got %+v", report)
+	}
+	want := filepath.Join("calc", "calc_test.go") + ":13: TestDiv: expected an error"
+	if !strings.Contains(output.Content, want) {
+		t.Errorf("expected %q in:\n%s", want, output.Content)
+	}
+}
@@ -0,0 +1,307 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/agentignore"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+const (
+	defaultTreeDepth = 3
+	maxTreeDepth     = 10
+
+	// maxTreeFilesPerDir is the number of files listed per directory
+	maxTreeFilesPerDir = 25
+
+	// maxTreeEntries bounds the walk on very large trees
+	maxTreeEntries = 100000
+)
+
+// languages maps file extensions to language names for tree statistics
+var languages = map[string]string{
+	".go": "Go", ".py": "Python", ".js": "JavaScript", ".jsx": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+	".ts": "TypeScript", ".tsx": "TypeScript", ".java": "Java", ".kt": "Kotlin", ".rs": "Rust", ".rb": "Ruby",
+	".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".hpp": "C++", ".cs": "C#", ".swift": "Swift",
+	".scala": "Scala", ".php": "PHP", ".sh": "Shell", ".bash": "Shell", ".sql": "SQL", ".proto": "Protobuf",
+	".html": "HTML", ".css": "CSS", ".scss": "CSS", ".vue": "Vue", ".svelte": "Svelte", ".lua": "Lua",
+	".md": "Markdown", ".yaml": "YAML", ".yml": "YAML", ".json": "JSON", ".toml": "TOML", ".tf": "Terraform",
+}
+
+// TreeTool shows an annotated directory tree
+type TreeTool struct{}
+
+// TreeInput represents the input for the Tree tool
+type TreeInput struct {
+	Path  string `json:"path,omitempty"`
+	Depth int    `json:"depth,omitempty"`
+}
+
+// NewTreeTool creates a new Tree tool
+func NewTreeTool() *TreeTool {
+	return &TreeTool{}
+}
+
+func (t *TreeTool) Name() string {
+	return "Tree"
+}
+
+func (t *TreeTool) Description() string {
+	return `Shows the directory tree of a project for quick orientation.
+- Each directory is annotated with its total file count, size, and dominant languages
+- Skips files and directories ignored by .gitignore or .agentignore, and .git and node_modules
+- Depth-limited (default 3); deeper directories are summarized
+- Prefer this over many Glob calls when exploring an unfamiliar codebase`
+}
+
+func (t *TreeTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"path": {
+				"type": "string",
+				"description": "The directory to show. If not specified, the current working directory will be used."
+			},
+			"depth": {
+				"type": "integer",
+				"minimum": 1,
+				"maximum": 10,
+				"description": "How many levels of directories to show (default 3)"
+			}
+		}
+	}`)
+}
+
+func (t *TreeTool) Validate(input *tool.Input) error {
+	params, err := tool.ParamsTo[TreeInput](input.Params)
+	if err != nil {
+		return err
+	}
+
+	if params.Depth < 0 || params.Depth > maxTreeDepth {
+		return fmt.Errorf("depth must be between 1 and %d", maxTreeDepth)
+	}
+
+	return nil
+}
+
+// treeNode is a file or directory with statistics for everything below it
+type treeNode struct {
+	name     string
+	dir      bool
+	size     int64
+	files    int
+	langs    map[string]int
+	children []*treeNode
+}
+
+func (t *TreeTool) Execute(ctx context.Context, input *tool.Input) (*tool.Output, error) {
+	params, err := tool.ParamsTo[TreeInput](input.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Determine base path
+	basePath := params.Path
+	if basePath == "" && input.Context != nil {
+		basePath = input.Context.CWD
+	}
+	if basePath == "" {
+		basePath = "."
+	}
+	if !filepath.IsAbs(basePath) && input.Context != nil && input.Context.CWD != "" {
+		basePath = filepath.Join(input.Context.CWD, basePath)
+	}
+
+	info, err := os.Stat(basePath)
+	if err != nil || !info.IsDir() {
+		return &tool.Output{
+			Content: fmt.Sprintf("Error: not a directory: %s", basePath),
+			IsError: true,
+		}, nil
+	}
+
+	depth := params.Depth
+	if depth == 0 {
+		depth = defaultTreeDepth
+	}
+
+	w := &treeWalker{ctx: ctx}
+	if input.Context != nil {
+		w.agentIgnore = agentignore.Load(input.Context.CWD)
+	}
+	root := w.walk(basePath, "", gitignore{}.withDir(basePath, ""))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	root.name = basePath
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s/ %s\n", root.name, nodeSummary(root))
+	renderTree(&b, root, "", depth)
+	if w.truncated {
+		fmt.Fprintf(&b, "\n[Stopped after %d entries; counts are incomplete. Show a subdirectory for details.]\n", maxTreeEntries)
+	}
+
+	return &tool.Output{
+		Content: strings.TrimSuffix(b.String(), "\n"),
+		Metadata: map[string]interface{}{
+			"numFiles": root.files,
+		},
+	}, nil
+}
+
+// treeWalker builds the tree, stopping after maxTreeEntries entries
+type treeWalker struct {
+	ctx         context.Context
+	entries     int
+	truncated   bool
+	agentIgnore *agentignore.Matcher
+}
+
+// walk returns the node for dir, whose slash-separated path relative to the root is rel
+func (w *treeWalker) walk(dir, rel string, ignore gitignore) *treeNode {
+	node := &treeNode{name: filepath.Base(dir), dir: true, langs: make(map[string]int)}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return node
+	}
+
+	for _, entry := range entries {
+		if w.entries >= maxTreeEntries || w.ctx.Err() != nil {
+			w.truncated = w.entries >= maxTreeEntries
+			break
+		}
+		w.entries++
+
+		childRel := entry.Name()
+		if rel != "" {
+			childRel = rel + "/" + entry.Name()
+		}
+		if ignore.ignored(childRel, entry.IsDir()) || w.agentIgnore.Ignored(filepath.Join(dir, entry.Name())) {
+			continue
+		}
+
+		if entry.IsDir() {
+			path := filepath.Join(dir, entry.Name())
+			child := w.walk(path, childRel, ignore.withDir(path, childRel))
+			node.children = append(node.children, child)
+			node.files += child.files
+			node.size += child.size
+			for lang, n := range child.langs {
+				node.langs[lang] += n
+			}
+			continue
+		}
+
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		child := &treeNode{name: entry.Name()}
+		if info, err := entry.Info(); err == nil {
+			child.size = info.Size()
+		}
+		node.children = append(node.children, child)
+		node.files++
+		node.size += child.size
+		if lang, ok := languages[strings.ToLower(filepath.Ext(entry.Name()))]; ok {
+			node.langs[lang]++
+		}
+	}
+	return node
+}
+
+// renderTree writes the children of node, directories first
+func renderTree(b *strings.Builder, node *treeNode, prefix string, depth int) {
+	var dirs, files []*treeNode
+	for _, child := range node.children {
+		if child.dir {
+			dirs = append(dirs, child)
+		} else {
+			files = append(files, child)
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].name < dirs[j].name })
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+
+	hidden := 0
+	if len(files) > maxTreeFilesPerDir {
+		hidden = len(files) - maxTreeFilesPerDir
+		files = files[:maxTreeFilesPerDir]
+	}
+
+	items := append(dirs, files...)
+	for i, child := range items {
+		last := i == len(items)-1 && hidden == 0
+		branch, indent := "├── ", "│   "
+		if last {
+			branch, indent = "└── ", "    "
+		}
+
+		if child.dir {
+			fmt.Fprintf(b, "%s%s%s/ %s\n", prefix, branch, child.name, nodeSummary(child))
+			if depth > 1 {
+				renderTree(b, child, prefix+indent, depth-1)
+			}
+		} else {
+			fmt.Fprintf(b, "%s%s%s (%s)\n", prefix, branch, child.name, formatSize(child.size))
+		}
+	}
+	if hidden > 0 {
+		fmt.Fprintf(b, "%s└── ... %d more files\n", prefix, hidden)
+	}
+}
+
+// nodeSummary describes a directory: file count, size, and dominant languages
+func nodeSummary(node *treeNode) string {
+	summary := fmt.Sprintf("(%d files, %s", node.files, formatSize(node.size))
+	if langs := dominantLanguages(node.langs, 3); langs != "" {
+		summary += "; " + langs
+	}
+	return summary + ")"
+}
+
+// dominantLanguages lists the most common languages by file count with their share
+func dominantLanguages(langs map[string]int, max int) string {
+	total := 0
+	names := make([]string, 0, len(langs))
+	for lang, n := range langs {
+		names = append(names, lang)
+		total += n
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if langs[names[i]] != langs[names[j]] {
+			return langs[names[i]] > langs[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > max {
+		names = names[:max]
+	}
+
+	parts := make([]string, len(names))
+	for i, lang := range names {
+		parts[i] = fmt.Sprintf("%s %d%%", lang, langs[lang]*100/total)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatSize formats a byte count for display
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
@@ -0,0 +1,93 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+func TestGitignore(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, ".gitignore"), []byte("# build output\n*.log\n/dist/\nbuild/\n!keep.log\n"), 0644)
+	os.MkdirAll(filepath.Join(root, "web"), 0755)
+	os.WriteFile(filepath.Join(root, "web", ".gitignore"), []byte("cache\n"), 0644)
+
+	ignore := gitignore{}.withDir(root, "")
+	web := ignore.withDir(filepath.Join(root, "web"), "web")
+
+	tests := []struct {
+		matcher gitignore
+		path    string
+		dir     bool
+		want    bool
+	}{
+		{ignore, "app.log", false, true},
+		{ignore, "src/app.log", false, true},
+		{ignore, "keep.log", false, false},
+		{ignore, "dist", true, true},
+		{ignore, "src/dist", true, false},
+		{ignore, "src/build", true, true},
+		{ignore, "build", false, false},
+		{ignore, ".git", true, true},
+		{web, "web/cache", true, true},
+		{ignore, "cache", true, false},
+		{ignore, "main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.matcher.ignored(tt.path, tt.dir); got != tt.want {
+			t.Errorf("ignored(%q, dir=%v) = %v, want %v", tt.path, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestTreeTool(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":        "bin/\n",
+		"go.mod":            "module x\n",
+		"main.go":           "package main\n",
+		"pkg/a/a.go":        "package a\n",
+		"pkg/a/a_test.go":   "package a\n",
+		"pkg/a/deep/d.go":   "package deep\n",
+		"pkg/b/README.md":   "# b\n",
+		"bin/tool":          "binary",
+		"node_modules/x.js": "x",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	tree := NewTreeTool()
+	input := &tool.Input{Params: map[string]interface{}{"depth": 2}, Context: &tool.ExecutionContext{CWD: root}}
+	if err := tree.Validate(input); err != nil {
+		t.Fatal(err)
+	}
+	output, err := tree.Execute(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		root + "/ (7 files",
+		"Go 80%, Markdown 20%",
+		"├── pkg/ (4 files",
+		"│   ├── a/ (3 files",
+		"└── main.go (13 B)",
+	} {
+		if !strings.Contains(output.Content, want) {
+			t.Errorf("expected tree to contain %q, got:\n%s", want, output.Content)
+		}
+	}
+	for _, unwanted := range []string{"bin", "node_modules", "deep/", "a.go"} {
+		if strings.Contains(output.Content, unwanted) {
+			t.Errorf("expected tree not to contain %q, got:\n%s", unwanted, output.Content)
+		}
+	}
+}
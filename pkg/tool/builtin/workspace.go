@@ -0,0 +1,92 @@
+package builtin
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Workspace is where the file tools read and write files and where Bash and
+// Grep run commands. Tools with a nil Workspace use the local machine; a
+// remote workspace runs them on another host while the rest of the agent
+// stays local.
+type Workspace interface {
+	// Open opens a file for reading
+	Open(path string) (io.ReadCloser, error)
+	// ReadFile reads a whole file
+	ReadFile(path string) ([]byte, error)
+	// WriteFile writes a file, creating its directory if needed
+	WriteFile(path string, data []byte) error
+	// Stat describes a file, following symlinks
+	Stat(path string) (fs.FileInfo, error)
+	// Glob returns the files matching a doublestar pattern
+	Glob(pattern string) ([]GlobMatch, error)
+	// Command prepares a program to run in dir
+	Command(ctx context.Context, dir, name string, args ...string) *exec.Cmd
+}
+
+// GlobMatch is a file found by Workspace.Glob
+type GlobMatch struct {
+	Path    string
+	ModTime time.Time
+}
+
+// workspaceOr returns w, or the local machine when w is nil
+func workspaceOr(w Workspace) Workspace {
+	if w == nil {
+		return LocalWorkspace{}
+	}
+	return w
+}
+
+// LocalWorkspace uses the local filesystem and runs local processes, as
+// tools with a nil Workspace do. Workspaces that only run commands
+// elsewhere embed it for the files.
+type LocalWorkspace struct{}
+
+func (LocalWorkspace) Open(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+func (LocalWorkspace) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func (LocalWorkspace) WriteFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func (LocalWorkspace) Stat(path string) (fs.FileInfo, error) {
+	return os.Stat(path)
+}
+
+func (LocalWorkspace) Glob(pattern string) ([]GlobMatch, error) {
+	paths, err := doublestar.FilepathGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]GlobMatch, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		matches = append(matches, GlobMatch{Path: path, ModTime: info.ModTime()})
+	}
+	return matches, nil
+}
+
+func (LocalWorkspace) Command(ctx context.Context, dir, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	return cmd
+}
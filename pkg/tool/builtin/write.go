@@ -4,14 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
 
 	"github.com/xinguang/agentic-coder/pkg/tool"
 )
 
 // WriteTool implements file writing
-type WriteTool struct{}
+type WriteTool struct {
+	// Workspace is where files are written (nil = the local machine)
+	Workspace Workspace
+}
 
 // WriteInput represents the input for Write tool
 type WriteInput struct {
@@ -76,17 +77,8 @@ func (w *WriteTool) Execute(ctx context.Context, input *tool.Input) (*tool.Outpu
 		return nil, err
 	}
 
-	// Create directory if not exists
-	dir := filepath.Dir(params.FilePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return &tool.Output{
-			Content: fmt.Sprintf("Error creating directory: %v", err),
-			IsError: true,
-		}, nil
-	}
-
-	// Write file
-	if err := os.WriteFile(params.FilePath, []byte(params.Content), 0644); err != nil {
+	// Write file, creating its directory if needed
+	if err := workspaceOr(w.Workspace).WriteFile(params.FilePath, []byte(params.Content)); err != nil {
 		return &tool.Output{
 			Content: fmt.Sprintf("Error writing file: %v", err),
 			IsError: true,
@@ -0,0 +1,213 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RepairMode is how far tool arguments that are not valid JSON are repaired
+type RepairMode string
+
+const (
+	// RepairOff rejects arguments that are not valid JSON
+	RepairOff RepairMode = "off"
+	// RepairSafe makes only edits that cannot change a value: markdown
+	// fences and prose around the object, trailing commas, and raw
+	// newlines and tabs inside strings
+	RepairSafe RepairMode = "safe"
+	// RepairLenient also quotes bare keys, converts single-quoted strings,
+	// Python's True, False and None, drops comments, and closes brackets
+	// left open at the end
+	RepairLenient RepairMode = "lenient"
+)
+
+// DefaultRepairMode is used when no mode is configured
+const DefaultRepairMode = RepairLenient
+
+// ParseRepairMode parses a repair mode name ("" = DefaultRepairMode)
+func ParseRepairMode(name string) (RepairMode, error) {
+	switch mode := RepairMode(strings.ToLower(strings.TrimSpace(name))); mode {
+	case "":
+		return DefaultRepairMode, nil
+	case RepairOff, RepairSafe, RepairLenient:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown JSON repair mode %q (off, safe, lenient)", name)
+	}
+}
+
+// ParseArguments parses tool arguments sent by the model into an object,
+// repairing them as far as mode allows when they are not valid JSON. It
+// reports whether a repair was needed. Strings that were cut off are
+// never closed, so a truncated value is not mistaken for a whole one.
+func ParseArguments(raw string, mode RepairMode) (map[string]interface{}, bool, error) {
+	params := make(map[string]interface{})
+	if strings.TrimSpace(raw) == "" {
+		return params, false, nil
+	}
+	err := json.Unmarshal([]byte(raw), &params)
+	if err == nil {
+		return params, false, nil
+	}
+	if mode == RepairOff {
+		return nil, false, fmt.Errorf("arguments are not a JSON object: %w", err)
+	}
+
+	params = make(map[string]interface{})
+	if json.Unmarshal([]byte(repairJSON(raw, mode)), &params) != nil {
+		return nil, false, fmt.Errorf("arguments are not a JSON object: %w", err)
+	}
+	return params, true, nil
+}
+
+// repairJSON rewrites the common mistakes in raw that mode allows fixing
+func repairJSON(raw string, mode RepairMode) string {
+	s := extractObject(raw)
+	lenient := mode == RepairLenient
+
+	var b strings.Builder
+	var open []byte // brackets not yet closed
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || (c == '\'' && lenient):
+			end, ok := copyString(&b, s, i)
+			if !ok {
+				// Cut off inside a string: leave it for the parser to reject
+				b.WriteString(s[i:])
+				return b.String()
+			}
+			i = end
+		case lenient && c == '/' && i+1 < len(s) && s[i+1] == '/':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case lenient && c == '/' && i+1 < len(s) && s[i+1] == '*':
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 3
+		case c == '{' || c == '[':
+			open = append(open, c)
+			b.WriteByte(c)
+		case c == '}' || c == ']':
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+			b.WriteByte(c)
+		case c == ',':
+			next := nextNonSpace(s, i+1)
+			if next < len(s) && s[next] != '}' && s[next] != ']' {
+				b.WriteByte(c)
+			}
+		case lenient && isIdentStart(c):
+			end := i
+			for end < len(s) && isIdentPart(s[end]) {
+				end++
+			}
+			word := s[i:end]
+			switch {
+			case word == "True":
+				word = "true"
+			case word == "False":
+				word = "false"
+			case word == "None":
+				word = "null"
+			case word != "true" && word != "false" && word != "null":
+				if next := nextNonSpace(s, end); next < len(s) && s[next] == ':' {
+					word = `"` + word + `"`
+				}
+			}
+			b.WriteString(word)
+			i = end - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	if lenient {
+		for j := len(open) - 1; j >= 0; j-- {
+			if open[j] == '{' {
+				b.WriteByte('}')
+			} else {
+				b.WriteByte(']')
+			}
+		}
+	}
+	return b.String()
+}
+
+// extractObject strips markdown fences and any prose around the outermost
+// object
+func extractObject(raw string) string {
+	s := strings.TrimSpace(raw)
+	if strings.HasPrefix(s, "```") {
+		if nl := strings.IndexByte(s, '\n'); nl >= 0 {
+			s = s[nl+1:]
+		}
+		s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+	}
+	start := strings.IndexByte(s, '{')
+	if start < 0 {
+		return s
+	}
+	if end := strings.LastIndexByte(s, '}'); end > start {
+		return s[start : end+1]
+	}
+	return s[start:]
+}
+
+// copyString writes the string starting at s[start] as a double-quoted
+// JSON string, escaping raw control characters, and returns the index of
+// its closing quote. It reports false when the string is not closed.
+func copyString(b *strings.Builder, s string, start int) (int, bool) {
+	quote := s[start]
+	var out strings.Builder
+	out.WriteByte('"')
+	for i := start + 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			if quote == '\'' && s[i+1] == '\'' {
+				out.WriteByte('\'')
+			} else {
+				out.WriteByte(c)
+				out.WriteByte(s[i+1])
+			}
+			i++
+		case c == quote:
+			out.WriteByte('"')
+			b.WriteString(out.String())
+			return i, true
+		case c == '"':
+			// Only inside single quotes
+			out.WriteString(`\"`)
+		case c == '\n':
+			out.WriteString(`\n`)
+		case c == '\r':
+			out.WriteString(`\r`)
+		case c == '\t':
+			out.WriteString(`\t`)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return len(s), false
+}
+
+func nextNonSpace(s string, i int) int {
+	for i < len(s) && strings.IndexByte(" \t\r\n", s[i]) >= 0 {
+		i++
+	}
+	return i
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c == '-' || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,64 @@
+package tool
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseArguments(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		mode     RepairMode
+		want     map[string]interface{}
+		repaired bool
+		wantErr  bool
+	}{
+		{"valid", `{"path": "a.go"}`, RepairOff, map[string]interface{}{"path": "a.go"}, false, false},
+		{"empty", "", RepairOff, map[string]interface{}{}, false, false},
+		{"off rejects", `{"path": "a.go",}`, RepairOff, nil, false, true},
+		{"trailing comma", `{"paths": ["a", "b",], "n": 1,}`, RepairSafe, map[string]interface{}{"paths": []interface{}{"a", "b"}, "n": float64(1)}, true, false},
+		{"fenced", "```json\n{\"path\": \"a.go\"}\n```", RepairSafe, map[string]interface{}{"path": "a.go"}, true, false},
+		{"prose around", `Here are the arguments: {"path": "a.go"} Done.`, RepairSafe, map[string]interface{}{"path": "a.go"}, true, false},
+		{"raw newline", "{\"content\": \"a\nb\tc\"}", RepairSafe, map[string]interface{}{"content": "a\nb\tc"}, true, false},
+		{"comma in string kept", `{"s": "a,}", }`, RepairSafe, map[string]interface{}{"s": "a,}"}, true, false},
+		{"safe leaves bare keys", `{path: "a.go"}`, RepairSafe, nil, false, true},
+		{"bare keys", `{path: "a.go", line_count: 3}`, RepairLenient, map[string]interface{}{"path": "a.go", "line_count": float64(3)}, true, false},
+		{"single quotes", `{'s': 'it\'s "x"'}`, RepairLenient, map[string]interface{}{"s": `it's "x"`}, true, false},
+		{"python literals", `{"a": True, "b": False, "c": None}`, RepairLenient, map[string]interface{}{"a": true, "b": false, "c": nil}, true, false},
+		{"comments", "{\n  // the file\n  \"path\": \"a.go\" /* here */\n}", RepairLenient, map[string]interface{}{"path": "a.go"}, true, false},
+		{"unclosed brackets", `{"paths": ["a", "b"`, RepairLenient, map[string]interface{}{"paths": []interface{}{"a", "b"}}, true, false},
+		{"cut off string", `{"content": "package main`, RepairLenient, nil, false, true},
+		{"not an object", `["a"]`, RepairLenient, nil, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, repaired, err := ParseArguments(tt.raw, tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if repaired != tt.repaired {
+				t.Errorf("repaired = %v, want %v", repaired, tt.repaired)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRepairMode(t *testing.T) {
+	if mode, err := ParseRepairMode(""); err != nil || mode != DefaultRepairMode {
+		t.Errorf("ParseRepairMode(\"\") = %q, %v", mode, err)
+	}
+	if mode, err := ParseRepairMode("Safe"); err != nil || mode != RepairSafe {
+		t.Errorf("ParseRepairMode(\"Safe\") = %q, %v", mode, err)
+	}
+	if _, err := ParseRepairMode("loose"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
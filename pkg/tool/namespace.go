@@ -0,0 +1,110 @@
+package tool
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// NamespaceBuiltin is the namespace of tools without a namespace prefix
+const NamespaceBuiltin = "builtin"
+
+// namespaceSeparator separates the parts of namespaced tool names (mcp__server__tool)
+const namespaceSeparator = "__"
+
+// Info describes a registered tool and whether it is enabled
+type Info struct {
+	Name      string
+	Namespace string
+	Enabled   bool
+}
+
+// Namespace returns the namespace of a tool name: "mcp__github" for
+// "mcp__github__create_issue", and NamespaceBuiltin for names without one
+func Namespace(name string) string {
+	if i := strings.LastIndex(name, namespaceSeparator); i > 0 {
+		return name[:i]
+	}
+	return NamespaceBuiltin
+}
+
+// Tools returns all registered tools, enabled or not, sorted by namespace and name
+func (r *Registry) Tools() []Info {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]Info, 0, len(r.tools))
+	for name := range r.tools {
+		infos = append(infos, Info{
+			Name:      name,
+			Namespace: Namespace(name),
+			Enabled:   !r.disabled[name],
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Namespace != infos[j].Namespace {
+			// Built-in tools first
+			if infos[i].Namespace == NamespaceBuiltin || infos[j].Namespace == NamespaceBuiltin {
+				return infos[i].Namespace == NamespaceBuiltin
+			}
+			return infos[i].Namespace < infos[j].Namespace
+		}
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// Match returns the registered tools selected by pattern: a tool name or
+// alias, a namespace ("mcp__github", "builtin"), or a glob ("Web*"). Names
+// are matched case-insensitively.
+func (r *Registry) Match(pattern string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if actual, ok := r.aliases[pattern]; ok {
+		pattern = actual
+	}
+	pattern = strings.ToLower(pattern)
+
+	var names []string
+	for name := range r.tools {
+		lower := strings.ToLower(name)
+		if lower == pattern || strings.ToLower(Namespace(name)) == pattern {
+			names = append(names, name)
+			continue
+		}
+		if ok, _ := path.Match(pattern, lower); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetEnabled enables or disables every tool matching pattern and returns
+// the names of the tools it changed. Disabled tools are left out of the
+// tool list sent to the provider from the next request on.
+func (r *Registry) SetEnabled(pattern string, enabled bool) ([]string, error) {
+	names := r.Match(pattern)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no tool matches %q", pattern)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var changed []string
+	for _, name := range names {
+		if r.disabled[name] != enabled {
+			continue // already in the requested state
+		}
+		if enabled {
+			delete(r.disabled, name)
+		} else {
+			r.disabled[name] = true
+		}
+		changed = append(changed, name)
+	}
+	return changed, nil
+}
@@ -0,0 +1,99 @@
+package tool
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Read", NamespaceBuiltin},
+		{"mcp__github__create_issue", "mcp__github"},
+		{"mcp__my_server__list__all", "mcp__my_server__list"},
+		{"__odd", NamespaceBuiltin},
+	}
+
+	for _, tt := range tests {
+		if got := Namespace(tt.name); got != tt.want {
+			t.Errorf("Namespace(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func newNamespacedRegistry() *Registry {
+	registry := NewRegistry()
+	for _, name := range []string{"Read", "WebSearch", "WebFetch", "mcp__github__create_issue", "mcp__github__list_prs", "mcp__slack__post"} {
+		registry.Register(&MockTool{name: name})
+	}
+	registry.RegisterAlias("search", "WebSearch")
+	return registry
+}
+
+func TestRegistryMatch(t *testing.T) {
+	registry := newNamespacedRegistry()
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"Read", []string{"Read"}},
+		{"websearch", []string{"WebSearch"}},
+		{"search", []string{"WebSearch"}},
+		{"Web*", []string{"WebFetch", "WebSearch"}},
+		{"mcp__github", []string{"mcp__github__create_issue", "mcp__github__list_prs"}},
+		{"builtin", []string{"Read", "WebFetch", "WebSearch"}},
+		{"Missing", nil},
+	}
+
+	for _, tt := range tests {
+		if got := registry.Match(tt.pattern); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Match(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestRegistrySetEnabled(t *testing.T) {
+	registry := newNamespacedRegistry()
+
+	changed, err := registry.SetEnabled("mcp__github", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 {
+		t.Errorf("expected 2 tools disabled, got %v", changed)
+	}
+	if _, err := registry.Get("mcp__github__list_prs"); err == nil {
+		t.Error("expected disabled tool to be unavailable")
+	}
+	for _, tool := range registry.ToAPITools() {
+		if Namespace(tool.Name) == "mcp__github" {
+			t.Errorf("disabled tool %s sent to the provider", tool.Name)
+		}
+	}
+
+	// Disabling again changes nothing
+	if changed, _ := registry.SetEnabled("mcp__github__list_prs", false); len(changed) != 0 {
+		t.Errorf("expected no changes, got %v", changed)
+	}
+
+	if changed, _ := registry.SetEnabled("mcp__github__list_prs", true); len(changed) != 1 {
+		t.Errorf("expected 1 tool enabled, got %v", changed)
+	}
+
+	infos := registry.Tools()
+	if infos[0].Namespace != NamespaceBuiltin || len(infos) != 6 {
+		t.Errorf("expected built-in tools first and all 6 tools listed, got %+v", infos)
+	}
+	for _, info := range infos {
+		if info.Name == "mcp__github__create_issue" && info.Enabled {
+			t.Error("expected create_issue to stay disabled")
+		}
+	}
+
+	if _, err := registry.SetEnabled("nothing*", false); err == nil {
+		t.Error("expected error for pattern without matches")
+	}
+}
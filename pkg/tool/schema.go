@@ -0,0 +1,337 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SchemaError is an input that violates a tool's input schema
+type SchemaError struct {
+	Path    string // dotted path to the value, such as "edits[0].old_string"
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// SchemaErrors are all the violations found in an input
+type SchemaErrors []SchemaError
+
+func (e SchemaErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = "- " + err.Error()
+	}
+	return "input does not match the schema:\n" + strings.Join(lines, "\n")
+}
+
+// ValidateSchema checks params against a tool's JSON Schema. Values the
+// model commonly gets wrong are coerced in place first: numbers and booleans
+// sent as strings, JSON-encoded arrays and objects sent as strings, single
+// values for arrays, and nulls for optional properties. Only the keywords
+// type, properties, required, additionalProperties, items, enum, minimum
+// and maximum are checked; others are ignored.
+func ValidateSchema(schema json.RawMessage, params map[string]interface{}) error {
+	if len(schema) == 0 {
+		return nil
+	}
+	var s map[string]interface{}
+	if err := json.Unmarshal(schema, &s); err != nil {
+		// A schema we cannot read is the tool's problem, not the model's
+		return nil
+	}
+
+	var errs SchemaErrors
+	validateObject(s, params, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateValue checks one value, returning it coerced to the schema's type
+func validateValue(s map[string]interface{}, v interface{}, path string, errs *SchemaErrors) interface{} {
+	types := schemaTypes(s)
+	if len(types) > 0 && !matchesAny(types, v) {
+		coerced, ok := coerce(types, v)
+		if !ok {
+			*errs = append(*errs, SchemaError{path, fmt.Sprintf("expected %s, got %s", strings.Join(types, " or "), describeValue(v))})
+			return v
+		}
+		v = coerced
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok && !inEnum(enum, v) {
+		*errs = append(*errs, SchemaError{path, fmt.Sprintf("must be one of %s, got %s", formatEnum(enum), describeValue(v))})
+	}
+
+	switch val := v.(type) {
+	case float64:
+		if min, ok := s["minimum"].(float64); ok && val < min {
+			*errs = append(*errs, SchemaError{path, fmt.Sprintf("must be at least %v, got %v", min, val)})
+		}
+		if max, ok := s["maximum"].(float64); ok && val > max {
+			*errs = append(*errs, SchemaError{path, fmt.Sprintf("must be at most %v, got %v", max, val)})
+		}
+	case map[string]interface{}:
+		validateObject(s, val, path, errs)
+	case []interface{}:
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				val[i] = validateValue(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	}
+	return v
+}
+
+// validateObject checks the properties of an object in place
+func validateObject(s map[string]interface{}, obj map[string]interface{}, path string, errs *SchemaErrors) {
+	props, _ := s["properties"].(map[string]interface{})
+	required := make(map[string]bool)
+	if req, ok := s["required"].([]interface{}); ok {
+		for _, r := range req {
+			if name, ok := r.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	renameProperties(obj, props)
+
+	// Sorted for stable error messages
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := obj[name]
+		propPath := joinPath(path, name)
+		prop, known := props[name].(map[string]interface{})
+		if !known {
+			if extra, ok := s["additionalProperties"].(bool); ok && !extra {
+				*errs = append(*errs, SchemaError{propPath, "unknown property"})
+			}
+			continue
+		}
+		if value == nil && !required[name] && !allowsNull(prop) {
+			// Models often send null for properties they mean to leave out
+			delete(obj, name)
+			continue
+		}
+		obj[name] = validateValue(prop, value, propPath, errs)
+	}
+
+	missing := make([]string, 0)
+	for name := range required {
+		if _, ok := obj[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		*errs = append(*errs, SchemaError{joinPath(path, name), "required property is missing"})
+	}
+}
+
+// schemaTypes returns the types a schema allows, none if unconstrained
+func schemaTypes(s map[string]interface{}) []string {
+	switch t := s["type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func allowsNull(s map[string]interface{}) bool {
+	for _, t := range schemaTypes(s) {
+		if t == "null" {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(types []string, v interface{}) bool {
+	for _, t := range types {
+		if matchesType(t, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesType checks a value decoded from JSON, or set by Go code, against
+// a JSON Schema type
+func matchesType(t string, v interface{}) bool {
+	if v == nil {
+		return t == "null"
+	}
+	kind := reflect.TypeOf(v).Kind()
+	switch t {
+	case "string":
+		return kind == reflect.String
+	case "number":
+		return isNumber(kind)
+	case "integer":
+		if !isNumber(kind) {
+			return false
+		}
+		f := reflect.ValueOf(v).Convert(reflect.TypeOf(float64(0))).Float()
+		return f == math.Trunc(f)
+	case "boolean":
+		return kind == reflect.Bool
+	case "array":
+		return kind == reflect.Slice
+	case "object":
+		return kind == reflect.Map || kind == reflect.Struct
+	case "null":
+		return false
+	}
+	// Unknown types are not checked
+	return true
+}
+
+func isNumber(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
+// coerce converts a value to the first of the types it can be read as
+func coerce(types []string, v interface{}) (interface{}, bool) {
+	for _, t := range types {
+		if c, ok := coerceTo(t, v); ok {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+func coerceTo(t string, v interface{}) (interface{}, bool) {
+	s, isString := v.(string)
+	s = strings.TrimSpace(s)
+	switch t {
+	case "number", "integer":
+		if !isString {
+			return nil, false
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || (t == "integer" && f != math.Trunc(f)) {
+			return nil, false
+		}
+		return f, true
+	case "boolean":
+		if !isString {
+			return nil, false
+		}
+		b, err := strconv.ParseBool(s)
+		return b, err == nil
+	case "string":
+		switch val := v.(type) {
+		case float64:
+			return strconv.FormatFloat(val, 'f', -1, 64), true
+		case bool:
+			return strconv.FormatBool(val), true
+		}
+	case "array":
+		if isString && strings.HasPrefix(s, "[") {
+			var arr []interface{}
+			if json.Unmarshal([]byte(s), &arr) == nil {
+				return arr, true
+			}
+		}
+		if _, isObject := v.(map[string]interface{}); v != nil && !isObject {
+			return []interface{}{v}, true
+		}
+	case "object":
+		if isString && strings.HasPrefix(s, "{") {
+			var obj map[string]interface{}
+			if json.Unmarshal([]byte(s), &obj) == nil {
+				return obj, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, e := range enum {
+		data, _ := json.Marshal(e)
+		values[i] = string(data)
+	}
+	return strings.Join(values, ", ")
+}
+
+// describeValue names a value's JSON type and shows short values
+func describeValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		if len(val) > 40 {
+			val = val[:40] + "..."
+		}
+		return fmt.Sprintf("string %q", val)
+	case float64:
+		return fmt.Sprintf("number %v", val)
+	case bool:
+		return fmt.Sprintf("boolean %v", val)
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// renameProperties renames properties that differ from a missing known
+// property only in case or separators, such as filePath for file_path
+func renameProperties(obj map[string]interface{}, props map[string]interface{}) {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(s))
+	}
+	for name, value := range obj {
+		if _, known := props[name]; known {
+			continue
+		}
+		for prop := range props {
+			if _, present := obj[prop]; !present && normalize(prop) == normalize(name) {
+				obj[prop] = value
+				delete(obj, name)
+				break
+			}
+		}
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,117 @@
+package tool
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+	"type": "object",
+	"properties": {
+		"file_path": {"type": "string"},
+		"limit": {"type": "integer", "minimum": 1},
+		"replace_all": {"type": "boolean"},
+		"mode": {"type": "string", "enum": ["fast", "slow"]},
+		"paths": {"type": "array", "items": {"type": "string"}},
+		"options": {"type": "object", "properties": {"depth": {"type": "number", "maximum": 5}}}
+	},
+	"required": ["file_path"],
+	"additionalProperties": false
+}`
+
+func TestValidateSchemaCoerces(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"valid", `{"file_path": "a.go", "limit": 10}`, `{"file_path": "a.go", "limit": 10}`},
+		{"number as string", `{"file_path": "a.go", "limit": "10"}`, `{"file_path": "a.go", "limit": 10}`},
+		{"boolean as string", `{"file_path": "a.go", "replace_all": "true"}`, `{"file_path": "a.go", "replace_all": true}`},
+		{"number for string", `{"file_path": 42}`, `{"file_path": "42"}`},
+		{"array as JSON string", `{"file_path": "a.go", "paths": "[\"x\", \"y\"]"}`, `{"file_path": "a.go", "paths": ["x", "y"]}`},
+		{"single value for array", `{"file_path": "a.go", "paths": "x"}`, `{"file_path": "a.go", "paths": ["x"]}`},
+		{"object as JSON string", `{"file_path": "a.go", "options": "{\"depth\": \"2\"}"}`, `{"file_path": "a.go", "options": {"depth": 2}}`},
+		{"null optional property", `{"file_path": "a.go", "limit": null}`, `{"file_path": "a.go"}`},
+		{"camel case property", `{"filePath": "a.go", "replaceAll": "false"}`, `{"file_path": "a.go", "replace_all": false}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params, want map[string]interface{}
+			json.Unmarshal([]byte(tt.input), &params)
+			json.Unmarshal([]byte(tt.want), &want)
+
+			if err := ValidateSchema(json.RawMessage(testSchema), params); err != nil {
+				t.Fatalf("ValidateSchema() error = %v", err)
+			}
+			if !reflect.DeepEqual(params, want) {
+				t.Errorf("params = %v, want %v", params, want)
+			}
+		})
+	}
+}
+
+func TestValidateSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"missing required", `{"limit": 1}`, []string{"file_path: required property is missing"}},
+		{"wrong type", `{"file_path": "a.go", "limit": "ten"}`, []string{`limit: expected integer, got string "ten"`}},
+		{"fractional integer", `{"file_path": "a.go", "limit": 1.5}`, []string{"limit: expected integer, got number 1.5"}},
+		{"enum", `{"file_path": "a.go", "mode": "medium"}`, []string{`mode: must be one of "fast", "slow", got string "medium"`}},
+		{"minimum", `{"file_path": "a.go", "limit": 0}`, []string{"limit: must be at least 1, got 0"}},
+		{"nested maximum", `{"file_path": "a.go", "options": {"depth": 9}}`, []string{"options.depth: must be at most 5, got 9"}},
+		{"array item", `{"file_path": "a.go", "paths": ["x", {}]}`, []string{"paths[1]: expected string, got object"}},
+		{"unknown property", `{"file_path": "a.go", "colour": "red"}`, []string{"colour: unknown property"}},
+		{"several", `{"limit": "x", "mode": 1}`, []string{"limit: expected integer", "mode: must be one of", "file_path: required"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params map[string]interface{}
+			json.Unmarshal([]byte(tt.input), &params)
+
+			err := ValidateSchema(json.RawMessage(testSchema), params)
+			if err == nil {
+				t.Fatal("ValidateSchema() error = nil, want an error")
+			}
+			errs, ok := err.(SchemaErrors)
+			if !ok {
+				t.Fatalf("error type = %T, want SchemaErrors", err)
+			}
+			if len(errs) != len(tt.want) {
+				t.Fatalf("got %d errors (%v), want %d", len(errs), err, len(tt.want))
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateSchemaIgnoresUnusableSchemas(t *testing.T) {
+	params := map[string]interface{}{"anything": 1}
+	for _, schema := range []string{"", "not json"} {
+		if err := ValidateSchema(json.RawMessage(schema), params); err != nil {
+			t.Errorf("ValidateSchema(%q) error = %v, want nil", schema, err)
+		}
+	}
+}
+
+func TestValidateSchemaAcceptsGoValues(t *testing.T) {
+	params := map[string]interface{}{
+		"file_path": "a.go",
+		"limit":     3,
+		"paths":     []interface{}{"x"},
+	}
+	if err := ValidateSchema(json.RawMessage(testSchema), params); err != nil {
+		t.Errorf("ValidateSchema() error = %v", err)
+	}
+}
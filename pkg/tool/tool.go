@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/xinguang/agentic-coder/pkg/provider"
@@ -48,6 +49,42 @@ type ExecutionContext struct {
 	// Callbacks
 	RequestPermission func(req *PermissionRequest) (bool, error)
 	Output            func(content string)
+	// Subtask receives the progress of subagents the tool runs (nil = not reported)
+	Subtask func(event *SubtaskEvent)
+}
+
+// SubtaskEventKind is what a SubtaskEvent reports
+type SubtaskEventKind string
+
+const (
+	SubtaskStarted    SubtaskEventKind = "started"
+	SubtaskToolUse    SubtaskEventKind = "tool_use"
+	SubtaskToolResult SubtaskEventKind = "tool_result"
+	SubtaskUsage      SubtaskEventKind = "usage"
+	SubtaskFinished   SubtaskEventKind = "finished"
+)
+
+// SubtaskEvent reports the progress of a subagent, such as one started by
+// the Task tool
+type SubtaskEvent struct {
+	Kind        SubtaskEventKind
+	ID          string
+	Agent       string
+	Description string
+
+	// Tool calls of the subagent
+	Tool   string
+	Input  map[string]interface{}
+	Result *Output
+
+	// Tool calls and tokens of the subagent so far
+	ToolCalls    int
+	InputTokens  int
+	OutputTokens int
+
+	// Status the subagent finished with, and why it failed
+	Status string
+	Error  string
 }
 
 // PermissionMode represents the permission mode
@@ -74,6 +111,10 @@ type Output struct {
 	Content  string      `json:"content"`
 	IsError  bool        `json:"is_error,omitempty"`
 	Metadata interface{} `json:"metadata,omitempty"`
+
+	// Images and structured output sent to the model with the content
+	Images     []*provider.ImageBlock `json:"-"`
+	Structured json.RawMessage        `json:"-"`
 }
 
 // Registry is the tool registry
@@ -108,6 +149,25 @@ func (r *Registry) Register(tool Tool) error {
 	return nil
 }
 
+// Unregister removes a tool and its aliases. Unlike a disabled tool, it
+// cannot be enabled again.
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tools[name]; !ok {
+		return false
+	}
+	delete(r.tools, name)
+	delete(r.disabled, name)
+	for alias, target := range r.aliases {
+		if target == name {
+			delete(r.aliases, alias)
+		}
+	}
+	return true
+}
+
 // RegisterAlias registers an alias for a tool
 func (r *Registry) RegisterAlias(alias, toolName string) {
 	r.mu.Lock()
@@ -152,7 +212,7 @@ func (r *Registry) Enable(name string) {
 	delete(r.disabled, name)
 }
 
-// List returns all enabled tools
+// List returns all enabled tools sorted by name
 func (r *Registry) List() []Tool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -163,6 +223,7 @@ func (r *Registry) List() []Tool {
 			tools = append(tools, tool)
 		}
 	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name() < tools[j].Name() })
 	return tools
 }
 
@@ -132,6 +132,28 @@ func TestRegistryDisable(t *testing.T) {
 	}
 }
 
+func TestRegistryUnregister(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&MockTool{name: "test_tool", description: "A test tool"})
+	registry.RegisterAlias("alias_name", "test_tool")
+	registry.Disable("test_tool")
+
+	if !registry.Unregister("test_tool") {
+		t.Fatal("Expected the tool to be removed")
+	}
+	if registry.Unregister("test_tool") {
+		t.Error("Expected nothing to remove the second time")
+	}
+
+	// Enabling does not bring it back
+	registry.Enable("test_tool")
+	for _, name := range []string{"test_tool", "alias_name"} {
+		if _, err := registry.Get(name); err == nil {
+			t.Errorf("Expected %s to be gone", name)
+		}
+	}
+}
+
 func TestRegistryList(t *testing.T) {
 	registry := NewRegistry()
 
@@ -0,0 +1,215 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TypedFunc executes a typed tool with its decoded parameters
+type TypedFunc[T any] func(ctx context.Context, params *T, input *Input) (*Output, error)
+
+// Validator is implemented by parameter types that check their own values
+type Validator interface {
+	Validate() error
+}
+
+// TypedTool is a Tool whose input is decoded into T and whose schema is
+// generated from T's struct tags
+type TypedTool[T any] struct {
+	name        string
+	description string
+	schema      json.RawMessage
+	fn          TypedFunc[T]
+}
+
+// NewTypedTool creates a tool that decodes its input into T. The schema is
+// generated from T: see SchemaFor for the tags it reads. If *T implements
+// Validator, its Validate method is called before fn.
+func NewTypedTool[T any](name, description string, fn TypedFunc[T]) *TypedTool[T] {
+	return &TypedTool[T]{
+		name:        name,
+		description: description,
+		schema:      SchemaFor[T](),
+		fn:          fn,
+	}
+}
+
+func (t *TypedTool[T]) Name() string                 { return t.name }
+func (t *TypedTool[T]) Description() string          { return t.description }
+func (t *TypedTool[T]) InputSchema() json.RawMessage { return t.schema }
+
+func (t *TypedTool[T]) Validate(input *Input) error {
+	_, err := t.decode(input)
+	return err
+}
+
+func (t *TypedTool[T]) Execute(ctx context.Context, input *Input) (*Output, error) {
+	params, err := t.decode(input)
+	if err != nil {
+		return &Output{Content: fmt.Sprintf("Error parsing parameters: %v", err), IsError: true}, nil
+	}
+	return t.fn(ctx, params, input)
+}
+
+// decode converts the input into T and runs its own validation
+func (t *TypedTool[T]) decode(input *Input) (*T, error) {
+	params, err := ParamsTo[T](input.Params)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := interface{}(params).(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return params, nil
+}
+
+// SchemaFor generates the JSON Schema of a struct's JSON encoding. Fields
+// are required unless they are pointers or tagged omitempty, and these tags
+// add constraints:
+//
+//	desc:"..."        the property description
+//	enum:"a,b,c"      the allowed values
+//	minimum:"1"       the smallest allowed number
+//	maximum:"10"      the largest allowed number
+//	default:"..."     the value used when the property is left out
+func SchemaFor[T any]() json.RawMessage {
+	schema := typeSchema(reflect.TypeOf((*T)(nil)).Elem(), make(map[reflect.Type]bool))
+	data, err := json.Marshal(schema)
+	if err != nil {
+		// Schemas only hold strings, numbers, booleans, slices and maps
+		panic(fmt.Sprintf("tool: schema for %T: %v", *new(T), err))
+	}
+	return data
+}
+
+// typeSchema returns the schema of a Go type; seen holds the structs being
+// expanded, so recursive types stop at an unconstrained schema
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes []byte as a base64 string
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		return structSchema(t, seen)
+	}
+	// Interfaces accept any value
+	return map[string]interface{}{}
+}
+
+// structSchema returns the object schema of a struct's exported fields
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	props := make(map[string]interface{})
+	required := make([]string, 0)
+	addStructFields(t, props, &required, seen)
+
+	schema := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func addStructFields(t reflect.Type, props map[string]interface{}, required *[]string, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs are flattened, as encoding/json does
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(ft, props, required, seen)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := typeSchema(field.Type, seen)
+		applyTags(prop, field)
+		props[name] = prop
+
+		optional := field.Type.Kind() == reflect.Ptr || strings.Contains(","+opts+",", ",omitempty,")
+		if !optional {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// applyTags adds the constraints in a field's tags to its schema
+func applyTags(prop map[string]interface{}, field reflect.StructField) {
+	if desc := field.Tag.Get("desc"); desc != "" {
+		prop["description"] = desc
+	}
+	if enum := field.Tag.Get("enum"); enum != "" {
+		values := make([]interface{}, 0)
+		for _, v := range strings.Split(enum, ",") {
+			values = append(values, tagValue(prop, strings.TrimSpace(v)))
+		}
+		prop["enum"] = values
+	}
+	for _, key := range []string{"minimum", "maximum"} {
+		if v := field.Tag.Get(key); v != "" {
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				prop[key] = n
+			}
+		}
+	}
+	if def, ok := field.Tag.Lookup("default"); ok {
+		prop["default"] = tagValue(prop, def)
+	}
+}
+
+// tagValue converts a tag value to the type of the property it describes
+func tagValue(prop map[string]interface{}, s string) interface{} {
+	switch prop["type"] {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return s
+}
@@ -0,0 +1,134 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type searchParams struct {
+	Query   string   `json:"query" desc:"What to search for"`
+	Limit   int      `json:"limit,omitempty" minimum:"1" maximum:"50" default:"10"`
+	Mode    string   `json:"mode,omitempty" enum:"fast,thorough"`
+	Paths   []string `json:"paths,omitempty"`
+	Exact   *bool    `json:"exact"`
+	Ignored string   `json:"-"`
+	hidden  string
+}
+
+func (p *searchParams) Validate() error {
+	if strings.TrimSpace(p.Query) == "" {
+		return errors.New("query must not be empty")
+	}
+	return nil
+}
+
+type treeNode struct {
+	Name     string     `json:"name"`
+	Children []treeNode `json:"children,omitempty"`
+}
+
+type pagedParams struct {
+	Page
+	Filter string `json:"filter"`
+}
+
+type Page struct {
+	Offset int `json:"offset,omitempty"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema json.RawMessage
+		want   string
+	}{
+		{
+			name:   "tags",
+			schema: SchemaFor[searchParams](),
+			want: `{"type": "object", "required": ["query"], "properties": {
+				"query": {"type": "string", "description": "What to search for"},
+				"limit": {"type": "integer", "minimum": 1, "maximum": 50, "default": 10},
+				"mode": {"type": "string", "enum": ["fast", "thorough"]},
+				"paths": {"type": "array", "items": {"type": "string"}},
+				"exact": {"type": "boolean"}
+			}}`,
+		},
+		{
+			name:   "recursive",
+			schema: SchemaFor[treeNode](),
+			want: `{"type": "object", "required": ["name"], "properties": {
+				"name": {"type": "string"},
+				"children": {"type": "array", "items": {"type": "object"}}
+			}}`,
+		},
+		{
+			name:   "embedded",
+			schema: SchemaFor[pagedParams](),
+			want: `{"type": "object", "required": ["filter"], "properties": {
+				"offset": {"type": "integer"},
+				"filter": {"type": "string"}
+			}}`,
+		},
+		{
+			name:   "map",
+			schema: SchemaFor[map[string]float64](),
+			want:   `{"type": "object", "additionalProperties": {"type": "number"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, want interface{}
+			if err := json.Unmarshal(tt.schema, &got); err != nil {
+				t.Fatalf("schema is not JSON: %v", err)
+			}
+			json.Unmarshal([]byte(tt.want), &want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("SchemaFor() = %s, want %s", tt.schema, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypedTool(t *testing.T) {
+	var got *searchParams
+	search := NewTypedTool("Search", "Searches things", func(ctx context.Context, params *searchParams, input *Input) (*Output, error) {
+		got = params
+		return &Output{Content: "found " + params.Query}, nil
+	})
+
+	var _ Tool = search
+	if search.Name() != "Search" || search.Description() != "Searches things" {
+		t.Errorf("unexpected name or description: %q, %q", search.Name(), search.Description())
+	}
+	if !reflect.DeepEqual(search.InputSchema(), SchemaFor[searchParams]()) {
+		t.Errorf("InputSchema() = %s", search.InputSchema())
+	}
+
+	input := &Input{Params: map[string]interface{}{"query": "foo", "limit": float64(5), "paths": []interface{}{"a"}}}
+	if err := search.Validate(input); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	out, err := search.Execute(context.Background(), input)
+	if err != nil || out.Content != "found foo" {
+		t.Fatalf("Execute() = %+v, %v", out, err)
+	}
+	if got.Limit != 5 || !reflect.DeepEqual(got.Paths, []string{"a"}) {
+		t.Errorf("decoded params = %+v", got)
+	}
+
+	empty := &Input{Params: map[string]interface{}{"query": " "}}
+	if err := search.Validate(empty); err == nil || err.Error() != "query must not be empty" {
+		t.Errorf("Validate() error = %v, want the params' own validation error", err)
+	}
+
+	bad := &Input{Params: map[string]interface{}{"query": "foo", "limit": "many"}}
+	out, err = search.Execute(context.Background(), bad)
+	if err != nil || !out.IsError || !strings.Contains(out.Content, "Error parsing parameters") {
+		t.Errorf("Execute() = %+v, %v, want a parse error output", out, err)
+	}
+}
@@ -0,0 +1,213 @@
+// Package analysis produces natural-language market briefs and signal
+// explanations using the agent AI providers
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+const systemPrompt = `You are a market analyst assisting a rule-based trading system.
+You receive signals produced by technical strategies together with recent price data.
+Be concise and factual. Base statements only on the data provided, point out
+conflicting signals and risks, and never present output as financial advice.`
+
+// Analyst summarizes trading activity with an AI provider
+type Analyst struct {
+	provider  provider.AIProvider
+	model     string
+	maxTokens int
+}
+
+// NewAnalyst creates a new analyst using the given provider and model
+func NewAnalyst(p provider.AIProvider, model string) *Analyst {
+	return &Analyst{
+		provider:  p,
+		model:     provider.ResolveModel(model),
+		maxTokens: 1024,
+	}
+}
+
+// BriefInput is the market context for a daily brief
+type BriefInput struct {
+	Date      time.Time
+	Signals   []*trading.TradingSignal
+	Positions map[string]*trading.Position
+	Market    map[string][]*trading.StockData // recent prices per symbol, oldest first
+}
+
+// DailyBrief produces a natural-language market brief
+func (a *Analyst) DailyBrief(ctx context.Context, input *BriefInput) (string, error) {
+	return a.complete(ctx, buildBriefPrompt(input))
+}
+
+// ExplainSignal produces a short explanation of a single signal
+func (a *Analyst) ExplainSignal(ctx context.Context, sig *trading.TradingSignal, history []*trading.StockData) (string, error) {
+	return a.complete(ctx, buildExplainPrompt(sig, history))
+}
+
+// complete sends a single-turn prompt and collects the streamed text
+func (a *Analyst) complete(ctx context.Context, prompt string) (string, error) {
+	stream, err := a.provider.CreateMessageStream(ctx, &provider.Request{
+		Model:     a.model,
+		MaxTokens: a.maxTokens,
+		System:    []provider.ContentBlock{&provider.TextBlock{Text: systemPrompt}},
+		Messages: []provider.Message{
+			{
+				Role:    provider.RoleUser,
+				Content: []provider.ContentBlock{&provider.TextBlock{Text: prompt}},
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create analysis stream: %w", err)
+	}
+	defer stream.Close()
+
+	var response strings.Builder
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		switch e := event.(type) {
+		case *provider.ContentBlockStartEvent:
+			// Some providers deliver complete text blocks at block start
+			if block, ok := e.ContentBlock.(*provider.TextBlock); ok {
+				response.WriteString(block.Text)
+			}
+		case *provider.ContentBlockDeltaEvent:
+			if delta, ok := e.Delta.(*provider.TextDelta); ok {
+				response.WriteString(delta.Text)
+			}
+		}
+	}
+
+	text := strings.TrimSpace(response.String())
+	if text == "" {
+		return "", fmt.Errorf("empty analysis response from %s", a.provider.Name())
+	}
+	return text, nil
+}
+
+// buildBriefPrompt renders the daily brief prompt
+func buildBriefPrompt(input *BriefInput) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Write a daily market brief for %s.\n\n", input.Date.Format("Monday, 2006-01-02"))
+
+	b.WriteString("## Market data\n")
+	symbols := make([]string, 0, len(input.Market))
+	for symbol := range input.Market {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	if len(symbols) == 0 {
+		b.WriteString("No price data available.\n")
+	}
+	for _, symbol := range symbols {
+		b.WriteString(summarizePrices(symbol, input.Market[symbol]))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n## Signals\n")
+	if len(input.Signals) == 0 {
+		b.WriteString("No signals were generated.\n")
+	}
+	for _, sig := range input.Signals {
+		b.WriteString(formatSignal(sig))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n## Open positions\n")
+	held := 0
+	for _, symbol := range sortedKeys(input.Positions) {
+		pos := input.Positions[symbol]
+		if pos.Quantity == 0 {
+			continue
+		}
+		held++
+		fmt.Fprintf(&b, "- %s: %d shares @ %.2f avg", symbol, pos.Quantity, pos.AvgPrice)
+		if pos.CurrentPrice > 0 {
+			fmt.Fprintf(&b, ", last %.2f, PnL %.2f", pos.CurrentPrice, pos.PnL)
+		}
+		b.WriteString("\n")
+	}
+	if held == 0 {
+		b.WriteString("None.\n")
+	}
+
+	b.WriteString(`
+Structure the brief as:
+1. Overview (2-3 sentences)
+2. Notable moves per symbol
+3. Signal review: what the strategies agree or disagree on
+4. Risks and what to watch next session`)
+
+	return b.String()
+}
+
+// buildExplainPrompt renders the signal explanation prompt
+func buildExplainPrompt(sig *trading.TradingSignal, history []*trading.StockData) string {
+	var b strings.Builder
+
+	b.WriteString("Explain the following trading signal in plain language for a trader.\n\n")
+	b.WriteString("## Signal\n")
+	b.WriteString(formatSignal(sig))
+	b.WriteString("\n\n## Recent prices\n")
+	b.WriteString(summarizePrices(sig.Symbol, history))
+	b.WriteString(`
+
+In at most 4 sentences: what triggered the signal, how strong it looks given
+the recent price action, and the main risk if it turns out to be wrong.`)
+
+	return b.String()
+}
+
+// formatSignal renders a signal as a single line
+func formatSignal(sig *trading.TradingSignal) string {
+	return fmt.Sprintf("- %s %s %s @ %.2f (confidence %.0f%%): %s",
+		sig.Timestamp.Format("01-02 15:04"), sig.Type, sig.Symbol, sig.Price, sig.Confidence*100, sig.Reason)
+}
+
+// summarizePrices renders a one-line price summary for a symbol
+func summarizePrices(symbol string, data []*trading.StockData) string {
+	if len(data) == 0 {
+		return fmt.Sprintf("- %s: no data", symbol)
+	}
+
+	first, last := data[0], data[len(data)-1]
+	high, low := first.High, first.Low
+	for _, d := range data {
+		if d.High > high {
+			high = d.High
+		}
+		if d.Low < low || low == 0 {
+			low = d.Low
+		}
+	}
+
+	change := 0.0
+	if first.Price > 0 {
+		change = (last.Price - first.Price) / first.Price * 100
+	}
+
+	return fmt.Sprintf("- %s: last %.2f, change %+.2f%% over %d points, range %.2f-%.2f",
+		symbol, last.Price, change, len(data), low, high)
+}
+
+// sortedKeys returns the position symbols in sorted order
+func sortedKeys(positions map[string]*trading.Position) []string {
+	keys := make([]string, 0, len(positions))
+	for k := range positions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
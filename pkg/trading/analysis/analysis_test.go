@@ -0,0 +1,113 @@
+package analysis
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/provider"
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// mockProvider streams a fixed text response and records the request
+type mockProvider struct {
+	text string
+	req  *provider.Request
+}
+
+func (m *mockProvider) Name() string              { return "mock" }
+func (m *mockProvider) SupportedModels() []string { return []string{"mock-model"} }
+func (m *mockProvider) SupportsFeature(provider.Feature) bool {
+	return false
+}
+
+func (m *mockProvider) CreateMessage(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	return nil, io.EOF
+}
+
+func (m *mockProvider) CreateMessageStream(ctx context.Context, req *provider.Request) (provider.StreamReader, error) {
+	m.req = req
+	return &textStream{chunks: strings.SplitAfter(m.text, " ")}, nil
+}
+
+type textStream struct {
+	chunks []string
+}
+
+func (s *textStream) Recv() (provider.StreamingEvent, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return &provider.ContentBlockDeltaEvent{Delta: &provider.TextDelta{Text: chunk}}, nil
+}
+
+func (s *textStream) Close() error { return nil }
+
+func sampleData() map[string][]*trading.StockData {
+	return map[string][]*trading.StockData{
+		"AAPL": {
+			{Symbol: "AAPL", Price: 100, High: 101, Low: 99},
+			{Symbol: "AAPL", Price: 110, High: 112, Low: 98},
+		},
+	}
+}
+
+func TestDailyBrief(t *testing.T) {
+	mock := &mockProvider{text: "Markets rallied on strong tech."}
+	analyst := NewAnalyst(mock, "sonnet")
+
+	brief, err := analyst.DailyBrief(context.Background(), &BriefInput{
+		Date:    time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC),
+		Signals: []*trading.TradingSignal{{Symbol: "AAPL", Type: trading.SignalBuy, Price: 110, Confidence: 0.7, Reason: "Golden cross"}},
+		Positions: map[string]*trading.Position{
+			"AAPL": {Symbol: "AAPL", Quantity: 10, AvgPrice: 100},
+		},
+		Market: sampleData(),
+	})
+	if err != nil {
+		t.Fatalf("DailyBrief() error = %v", err)
+	}
+	if brief != "Markets rallied on strong tech." {
+		t.Errorf("unexpected brief %q", brief)
+	}
+
+	if mock.req.Model != provider.ResolveModel("sonnet") {
+		t.Errorf("expected resolved model, got %s", mock.req.Model)
+	}
+	prompt := mock.req.Messages[0].Content[0].(*provider.TextBlock).Text
+	for _, want := range []string{"Monday, 2024-06-03", "AAPL: last 110.00, change +10.00%", "BUY AAPL @ 110.00", "Golden cross", "10 shares @ 100.00"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestExplainSignal(t *testing.T) {
+	mock := &mockProvider{text: "RSI recovered."}
+	analyst := NewAnalyst(mock, "mock-model")
+
+	sig := &trading.TradingSignal{Symbol: "AAPL", Type: trading.SignalSell, Price: 110, Confidence: 0.5, Reason: "RSI overbought"}
+	text, err := analyst.ExplainSignal(context.Background(), sig, sampleData()["AAPL"])
+	if err != nil {
+		t.Fatalf("ExplainSignal() error = %v", err)
+	}
+	if text != "RSI recovered." {
+		t.Errorf("unexpected explanation %q", text)
+	}
+
+	prompt := mock.req.Messages[0].Content[0].(*provider.TextBlock).Text
+	if !strings.Contains(prompt, "SELL AAPL") || !strings.Contains(prompt, "range 98.00-112.00") {
+		t.Errorf("unexpected prompt:\n%s", prompt)
+	}
+}
+
+func TestEmptyResponse(t *testing.T) {
+	analyst := NewAnalyst(&mockProvider{}, "mock-model")
+	if _, err := analyst.DailyBrief(context.Background(), &BriefInput{}); err == nil {
+		t.Error("expected error for empty response")
+	}
+}
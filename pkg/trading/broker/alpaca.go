@@ -0,0 +1,206 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+const (
+	// AlpacaPaperURL is the Alpaca paper trading API endpoint
+	AlpacaPaperURL = "https://paper-api.alpaca.markets"
+)
+
+// AlpacaExecutor places orders through the Alpaca trading API
+type AlpacaExecutor struct {
+	baseURL   string
+	keyID     string
+	secretKey string
+	client    *http.Client
+}
+
+// NewAlpacaExecutor creates a new Alpaca executor.
+// An empty baseURL defaults to the paper trading endpoint.
+func NewAlpacaExecutor(keyID, secretKey, baseURL string) *AlpacaExecutor {
+	if baseURL == "" {
+		baseURL = AlpacaPaperURL
+	}
+	return &AlpacaExecutor{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		keyID:     keyID,
+		secretKey: secretKey,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// NewAlpacaExecutorFromEnv creates an Alpaca executor from
+// ALPACA_API_KEY_ID, ALPACA_API_SECRET_KEY and optional ALPACA_BASE_URL
+func NewAlpacaExecutorFromEnv() (*AlpacaExecutor, error) {
+	keyID := os.Getenv("ALPACA_API_KEY_ID")
+	secretKey := os.Getenv("ALPACA_API_SECRET_KEY")
+	if keyID == "" || secretKey == "" {
+		return nil, fmt.Errorf("ALPACA_API_KEY_ID and ALPACA_API_SECRET_KEY must be set")
+	}
+	return NewAlpacaExecutor(keyID, secretKey, os.Getenv("ALPACA_BASE_URL")), nil
+}
+
+// Name implements OrderExecutor interface
+func (a *AlpacaExecutor) Name() string {
+	return "alpaca"
+}
+
+// alpacaOrderRequest is the request body for POST /v2/orders
+type alpacaOrderRequest struct {
+	Symbol      string `json:"symbol"`
+	Qty         string `json:"qty"`
+	Side        string `json:"side"`
+	Type        string `json:"type"`
+	TimeInForce string `json:"time_in_force"`
+	LimitPrice  string `json:"limit_price,omitempty"`
+}
+
+// alpacaOrder is the order object returned by the Alpaca API
+type alpacaOrder struct {
+	ID             string    `json:"id"`
+	Symbol         string    `json:"symbol"`
+	Qty            string    `json:"qty"`
+	Side           string    `json:"side"`
+	Status         string    `json:"status"`
+	FilledQty      string    `json:"filled_qty"`
+	FilledAvgPrice string    `json:"filled_avg_price"`
+	SubmittedAt    time.Time `json:"submitted_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// PlaceOrder implements OrderExecutor interface.
+// Orders with a price are sent as day limit orders, otherwise as market orders.
+func (a *AlpacaExecutor) PlaceOrder(ctx context.Context, order *trading.Order) (*OrderResult, error) {
+	req := alpacaOrderRequest{
+		Symbol:      order.Symbol,
+		Qty:         strconv.Itoa(order.Quantity),
+		Side:        strings.ToLower(string(order.Side)),
+		Type:        "market",
+		TimeInForce: "day",
+	}
+	if order.Price > 0 {
+		req.Type = "limit"
+		req.LimitPrice = strconv.FormatFloat(order.Price, 'f', 2, 64)
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal order: %w", err)
+	}
+
+	var resp alpacaOrder
+	if err := a.do(ctx, http.MethodPost, "/v2/orders", body, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.toResult(order), nil
+}
+
+// CancelOrder implements OrderExecutor interface
+func (a *AlpacaExecutor) CancelOrder(ctx context.Context, id string) error {
+	return a.do(ctx, http.MethodDelete, "/v2/orders/"+id, nil, nil)
+}
+
+// GetOrder implements OrderExecutor interface
+func (a *AlpacaExecutor) GetOrder(ctx context.Context, id string) (*OrderResult, error) {
+	var resp alpacaOrder
+	if err := a.do(ctx, http.MethodGet, "/v2/orders/"+id, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	qty, _ := strconv.Atoi(resp.Qty)
+	order := &trading.Order{
+		Symbol:   resp.Symbol,
+		Side:     trading.OrderSide(strings.ToUpper(resp.Side)),
+		Quantity: qty,
+	}
+	return resp.toResult(order), nil
+}
+
+// do performs an authenticated API request and decodes the JSON response
+func (a *AlpacaExecutor) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("APCA-API-KEY-ID", a.keyID)
+	req.Header.Set("APCA-API-SECRET-KEY", a.secretKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alpaca API error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// toResult converts an Alpaca order into an OrderResult
+func (o *alpacaOrder) toResult(order *trading.Order) *OrderResult {
+	filledQty, _ := strconv.Atoi(o.FilledQty)
+	filledPrice, _ := strconv.ParseFloat(o.FilledAvgPrice, 64)
+
+	return &OrderResult{
+		ID:          o.ID,
+		Order:       order,
+		Status:      mapAlpacaStatus(o.Status),
+		FilledQty:   filledQty,
+		FilledPrice: filledPrice,
+		SubmittedAt: o.SubmittedAt,
+		UpdatedAt:   o.UpdatedAt,
+	}
+}
+
+// mapAlpacaStatus maps Alpaca order statuses onto OrderStatus
+func mapAlpacaStatus(status string) OrderStatus {
+	switch status {
+	case "filled":
+		return StatusFilled
+	case "partially_filled":
+		return StatusPartiallyFilled
+	case "canceled", "expired", "done_for_day", "replaced":
+		return StatusCanceled
+	case "rejected", "suspended":
+		return StatusRejected
+	default:
+		return StatusNew
+	}
+}
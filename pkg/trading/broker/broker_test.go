@@ -0,0 +1,109 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+func TestSimulatorFillsImmediately(t *testing.T) {
+	sim := NewSimulator(0.01)
+	ctx := context.Background()
+
+	result, err := sim.PlaceOrder(ctx, &trading.Order{Symbol: "AAPL", Side: trading.OrderBuy, Quantity: 10, Price: 100})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	if result.Status != StatusFilled || result.FilledQty != 10 {
+		t.Errorf("expected filled 10, got %s %d", result.Status, result.FilledQty)
+	}
+	if math.Abs(result.FilledPrice-101) > 1e-9 {
+		t.Errorf("expected buy slippage to 101, got %.2f", result.FilledPrice)
+	}
+
+	got, err := sim.GetOrder(ctx, result.ID)
+	if err != nil || got.ID != result.ID {
+		t.Errorf("GetOrder() = %v, %v", got, err)
+	}
+
+	// Filled orders cannot be cancelled
+	if err := sim.CancelOrder(ctx, result.ID); err == nil {
+		t.Error("expected error cancelling filled order")
+	}
+	if _, err := sim.PlaceOrder(ctx, &trading.Order{Symbol: "AAPL", Quantity: 0}); err == nil {
+		t.Error("expected error for zero quantity")
+	}
+}
+
+func TestAlpacaPlaceAndCancel(t *testing.T) {
+	var placed alpacaOrderRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("APCA-API-KEY-ID") != "key" || r.Header.Get("APCA-API-SECRET-KEY") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/orders":
+			json.NewDecoder(r.Body).Decode(&placed)
+			json.NewEncoder(w).Encode(alpacaOrder{ID: "abc", Symbol: placed.Symbol, Qty: placed.Qty, Side: placed.Side, Status: "accepted", FilledQty: "0"})
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/orders/abc":
+			json.NewEncoder(w).Encode(alpacaOrder{ID: "abc", Symbol: "MSFT", Qty: "5", Side: "buy", Status: "filled", FilledQty: "5", FilledAvgPrice: "301.5"})
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/orders/abc":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	alpaca := NewAlpacaExecutor("key", "secret", server.URL)
+	ctx := context.Background()
+
+	result, err := alpaca.PlaceOrder(ctx, &trading.Order{Symbol: "MSFT", Side: trading.OrderBuy, Quantity: 5, Price: 301.456})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	if result.ID != "abc" || result.Status != StatusNew {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if placed.Type != "limit" || placed.LimitPrice != "301.46" || placed.Side != "buy" || placed.Qty != "5" {
+		t.Errorf("unexpected request %+v", placed)
+	}
+
+	got, err := alpaca.GetOrder(ctx, "abc")
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+	if got.Status != StatusFilled || got.FilledQty != 5 || got.FilledPrice != 301.5 || got.Order.Side != trading.OrderBuy {
+		t.Errorf("unexpected order %+v", got)
+	}
+
+	if err := alpaca.CancelOrder(ctx, "abc"); err != nil {
+		t.Errorf("CancelOrder() error = %v", err)
+	}
+	if err := alpaca.CancelOrder(ctx, "missing"); err == nil {
+		t.Error("expected error cancelling unknown order")
+	}
+}
+
+func TestMapAlpacaStatus(t *testing.T) {
+	tests := map[string]OrderStatus{
+		"new":              StatusNew,
+		"accepted":         StatusNew,
+		"partially_filled": StatusPartiallyFilled,
+		"filled":           StatusFilled,
+		"expired":          StatusCanceled,
+		"rejected":         StatusRejected,
+	}
+	for in, want := range tests {
+		if got := mapAlpacaStatus(in); got != want {
+			t.Errorf("mapAlpacaStatus(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
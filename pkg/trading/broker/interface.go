@@ -0,0 +1,50 @@
+package broker
+
+import (
+	"context"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// OrderStatus represents the lifecycle state of a submitted order
+type OrderStatus string
+
+const (
+	StatusNew             OrderStatus = "new"
+	StatusPartiallyFilled OrderStatus = "partially_filled"
+	StatusFilled          OrderStatus = "filled"
+	StatusCanceled        OrderStatus = "canceled"
+	StatusRejected        OrderStatus = "rejected"
+)
+
+// IsFinal reports whether the order can no longer change
+func (s OrderStatus) IsFinal() bool {
+	return s == StatusFilled || s == StatusCanceled || s == StatusRejected
+}
+
+// OrderResult represents the broker-side state of an order
+type OrderResult struct {
+	ID          string         // broker order ID
+	Order       *trading.Order // original order
+	Status      OrderStatus    // current status
+	FilledQty   int            // shares filled so far
+	FilledPrice float64        // average fill price
+	SubmittedAt time.Time      // submission time
+	UpdatedAt   time.Time      // last status change
+}
+
+// OrderExecutor interface for order execution backends
+type OrderExecutor interface {
+	// Name returns the executor name
+	Name() string
+
+	// PlaceOrder submits an order and returns its initial state
+	PlaceOrder(ctx context.Context, order *trading.Order) (*OrderResult, error)
+
+	// CancelOrder cancels an open order
+	CancelOrder(ctx context.Context, id string) error
+
+	// GetOrder fetches the current state of an order
+	GetOrder(ctx context.Context, id string) (*OrderResult, error)
+}
@@ -0,0 +1,97 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// Simulator is a dry-run executor that fills orders immediately without
+// contacting a broker
+type Simulator struct {
+	mu       sync.Mutex
+	slippage float64 // fractional price slippage applied against the order side
+	orders   map[string]*OrderResult
+	nextID   int
+}
+
+// NewSimulator creates a new dry-run executor
+func NewSimulator(slippage float64) *Simulator {
+	return &Simulator{
+		slippage: slippage,
+		orders:   make(map[string]*OrderResult),
+	}
+}
+
+// Name implements OrderExecutor interface
+func (s *Simulator) Name() string {
+	return "dry-run"
+}
+
+// PlaceOrder implements OrderExecutor interface
+func (s *Simulator) PlaceOrder(ctx context.Context, order *trading.Order) (*OrderResult, error) {
+	if order.Quantity <= 0 {
+		return nil, fmt.Errorf("invalid quantity %d", order.Quantity)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	price := order.Price
+	if order.Side == trading.OrderBuy {
+		price *= 1 + s.slippage
+	} else {
+		price *= 1 - s.slippage
+	}
+
+	s.nextID++
+	now := time.Now()
+	result := &OrderResult{
+		ID:          fmt.Sprintf("sim-%d", s.nextID),
+		Order:       order,
+		Status:      StatusFilled,
+		FilledQty:   order.Quantity,
+		FilledPrice: price,
+		SubmittedAt: now,
+		UpdatedAt:   now,
+	}
+	s.orders[result.ID] = result
+
+	copied := *result
+	return &copied, nil
+}
+
+// CancelOrder implements OrderExecutor interface
+func (s *Simulator) CancelOrder(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, ok := s.orders[id]
+	if !ok {
+		return fmt.Errorf("order not found: %s", id)
+	}
+	if result.Status.IsFinal() {
+		return fmt.Errorf("order %s already %s", id, result.Status)
+	}
+
+	result.Status = StatusCanceled
+	result.UpdatedAt = time.Now()
+	return nil
+}
+
+// GetOrder implements OrderExecutor interface
+func (s *Simulator) GetOrder(ctx context.Context, id string) (*OrderResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, ok := s.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("order not found: %s", id)
+	}
+
+	copied := *result
+	return &copied, nil
+}
@@ -0,0 +1,185 @@
+// Package config loads and validates trading system configuration
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/xinguang/agentic-coder/pkg/trading/engine"
+	"github.com/xinguang/agentic-coder/pkg/trading/notify"
+	"github.com/xinguang/agentic-coder/pkg/trading/provider"
+	"github.com/xinguang/agentic-coder/pkg/trading/risk"
+	"github.com/xinguang/agentic-coder/pkg/trading/strategy"
+)
+
+// Config holds trading system configuration
+type Config struct {
+	Symbols        []string         `yaml:"symbols" json:"symbols"`
+	Provider       string           `yaml:"provider" json:"provider"`               // mock, sina, tencent
+	Executor       string           `yaml:"executor" json:"executor"`               // dry-run, alpaca
+	UpdateInterval time.Duration    `yaml:"update_interval" json:"update_interval"` // e.g. "10s"
+	Database       string           `yaml:"database,omitempty" json:"database,omitempty"`
+	HTTPAddr       string           `yaml:"http_addr,omitempty" json:"http_addr,omitempty"` // dashboard address, e.g. "127.0.0.1:8080"
+	Strategies     []StrategyConfig `yaml:"strategies" json:"strategies"`
+	Risk           *risk.Config     `yaml:"risk,omitempty" json:"risk,omitempty"`
+	Analysis       *AnalysisConfig  `yaml:"analysis,omitempty" json:"analysis,omitempty"`
+	Alerts         *notify.Config   `yaml:"alerts,omitempty" json:"alerts,omitempty"`
+}
+
+// AnalysisConfig configures AI market briefs and signal explanations
+type AnalysisConfig struct {
+	Model          string  `yaml:"model" json:"model"`                           // agent model, e.g. sonnet, gpt-4o, ollama
+	ExplainSignals bool    `yaml:"explain_signals" json:"explain_signals"`       // explain signals as they are generated
+	MinConfidence  float64 `yaml:"min_confidence" json:"min_confidence"`         // only explain signals at or above this confidence
+	BriefAt        string  `yaml:"brief_at,omitempty" json:"brief_at,omitempty"` // daily brief time (HH:MM, local), empty to disable
+}
+
+// StrategyConfig configures a single strategy instance
+type StrategyConfig struct {
+	Type   string             `yaml:"type" json:"type"` // ma_cross, rsi, macd, bollinger, breakout
+	Params map[string]float64 `yaml:"params,omitempty" json:"params,omitempty"`
+}
+
+// DefaultConfig returns the default trading configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Symbols:        []string{"AAPL", "GOOGL", "MSFT", "TSLA", "AMZN"},
+		Provider:       "mock",
+		Executor:       "dry-run",
+		UpdateInterval: 10 * time.Second,
+		Strategies: []StrategyConfig{
+			{Type: "ma_cross", Params: map[string]float64{"short": 5, "long": 20}},
+			{Type: "ma_cross", Params: map[string]float64{"short": 10, "long": 50}},
+			{Type: "rsi"},
+			{Type: "macd"},
+			{Type: "bollinger"},
+			{Type: "breakout"},
+		},
+		Risk: risk.DefaultConfig(),
+	}
+}
+
+// Load reads a YAML configuration file on top of the defaults.
+// Sections present in the file replace the defaults entirely.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	cfg := DefaultConfig()
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Validate validates the configuration
+func (c *Config) Validate() error {
+	if len(c.Symbols) == 0 {
+		return fmt.Errorf("at least one symbol is required")
+	}
+	for i, s := range c.Symbols {
+		c.Symbols[i] = strings.TrimSpace(s)
+		if c.Symbols[i] == "" {
+			return fmt.Errorf("symbol %d is empty", i+1)
+		}
+	}
+
+	if c.UpdateInterval < time.Second {
+		return fmt.Errorf("update_interval must be at least 1s, got %v", c.UpdateInterval)
+	}
+
+	if _, err := provider.New(c.Provider); err != nil {
+		return err
+	}
+
+	switch c.Executor {
+	case "", "dry-run", "alpaca":
+	default:
+		return fmt.Errorf("unknown executor: %s (available: dry-run, alpaca)", c.Executor)
+	}
+
+	if _, err := c.BuildStrategies(); err != nil {
+		return err
+	}
+
+	if c.Risk != nil {
+		if err := c.Risk.Validate(); err != nil {
+			return fmt.Errorf("risk: %w", err)
+		}
+	}
+
+	if c.Analysis != nil {
+		if err := c.Analysis.Validate(); err != nil {
+			return fmt.Errorf("analysis: %w", err)
+		}
+	}
+
+	if c.Alerts != nil {
+		if err := c.Alerts.Validate(); err != nil {
+			return fmt.Errorf("alerts: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// BuildStrategies creates the configured strategies
+func (c *Config) BuildStrategies() ([]engine.Strategy, error) {
+	if len(c.Strategies) == 0 {
+		return nil, fmt.Errorf("at least one strategy is required")
+	}
+
+	strategies := make([]engine.Strategy, 0, len(c.Strategies))
+	for i, sc := range c.Strategies {
+		s, err := strategy.New(sc.Type, sc.Params)
+		if err != nil {
+			return nil, fmt.Errorf("strategies[%d]: %w", i, err)
+		}
+		strategies = append(strategies, s)
+	}
+
+	return strategies, nil
+}
+
+// Validate validates the analysis configuration
+func (a *AnalysisConfig) Validate() error {
+	if a.Model == "" {
+		return fmt.Errorf("model is required")
+	}
+	if a.MinConfidence < 0 || a.MinConfidence > 1 {
+		return fmt.Errorf("min_confidence must be between 0 and 1, got %v", a.MinConfidence)
+	}
+	if a.BriefAt != "" {
+		if _, err := time.Parse("15:04", a.BriefAt); err != nil {
+			return fmt.Errorf("brief_at must be HH:MM, got %q", a.BriefAt)
+		}
+	}
+	return nil
+}
+
+// NextBrief returns the next daily brief time after now, or zero if disabled
+func (a *AnalysisConfig) NextBrief(now time.Time) time.Time {
+	if a.BriefAt == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse("15:04", a.BriefAt)
+	if err != nil {
+		return time.Time{}
+	}
+	next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
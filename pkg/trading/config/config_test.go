@@ -0,0 +1,149 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "trading.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("default config invalid: %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfig(t, `
+symbols: [AAPL, MSFT]
+provider: sina
+update_interval: 30s
+strategies:
+  - type: rsi
+    params: {period: 7, oversold: 25}
+risk:
+  capital: 5000
+  position_size: 0.5
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(cfg.Symbols) != 2 || cfg.Provider != "sina" || cfg.UpdateInterval != 30*time.Second {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if cfg.Executor != "dry-run" {
+		t.Errorf("expected default executor, got %s", cfg.Executor)
+	}
+	if cfg.Risk.Capital != 5000 || cfg.Risk.PositionSize != 0.5 {
+		t.Errorf("unexpected risk config %+v", cfg.Risk)
+	}
+
+	strategies, err := cfg.BuildStrategies()
+	if err != nil {
+		t.Fatalf("BuildStrategies() error = %v", err)
+	}
+	if len(strategies) != 1 || strategies[0].Name() != "RSI_7_25_70" {
+		t.Errorf("unexpected strategies %v", strategies)
+	}
+}
+
+func TestLoadAlertsDefaults(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `
+alerts:
+  rate_limit: 3
+  webhook: {url: "http://localhost/hook"}
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Alerts.RateLimit != 3 || cfg.Alerts.MinConfidence != 0.6 || cfg.Alerts.DedupWindow != 30*time.Minute {
+		t.Errorf("expected unset alert settings to keep their defaults, got %+v", cfg.Alerts)
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"no symbols", "symbols: []", "symbol"},
+		{"short interval", "update_interval: 100ms", "update_interval"},
+		{"bad provider", "provider: bloomberg", "unknown data provider"},
+		{"bad executor", "executor: ib", "unknown executor"},
+		{"bad strategy", "strategies: [{type: ichimoku}]", "unknown strategy type"},
+		{"bad param", "strategies: [{type: rsi, params: {window: 3}}]", "unknown parameter"},
+		{"inverted periods", "strategies: [{type: ma_cross, params: {short: 50, long: 20}}]", "short period"},
+		{"bad risk", "risk: {capital: 1000, position_size: 2}", "position_size"},
+		{"analysis without model", "analysis: {explain_signals: true}", "model is required"},
+		{"alerts webhook without url", "alerts: {webhook: {}}", "webhook: url"},
+		{"bad brief time", "analysis: {model: sonnet, brief_at: 25:00}", "brief_at"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWatchReloadsOnChange(t *testing.T) {
+	path := writeConfig(t, "strategies: [{type: rsi}]")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloaded := make(chan *Config, 1)
+	go Watch(ctx, path, 10*time.Millisecond, func(cfg *Config) { reloaded <- cfg })
+
+	// Ensure the new modification time differs from the initial one
+	time.Sleep(20 * time.Millisecond)
+	future := time.Now().Add(time.Second)
+	if err := os.WriteFile(path, []byte("strategies: [{type: macd}]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, future, future)
+
+	select {
+	case cfg := <-reloaded:
+		if len(cfg.Strategies) != 1 || cfg.Strategies[0].Type != "macd" {
+			t.Errorf("unexpected reloaded strategies %+v", cfg.Strategies)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("config was not reloaded")
+	}
+}
+
+func TestNextBrief(t *testing.T) {
+	a := &AnalysisConfig{Model: "sonnet", BriefAt: "16:30"}
+	now := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+
+	if got := a.NextBrief(now); !got.Equal(time.Date(2024, 6, 3, 16, 30, 0, 0, time.UTC)) {
+		t.Errorf("NextBrief() before brief time = %v", got)
+	}
+	if got := a.NextBrief(now.Add(8 * time.Hour)); !got.Equal(time.Date(2024, 6, 4, 16, 30, 0, 0, time.UTC)) {
+		t.Errorf("NextBrief() after brief time = %v", got)
+	}
+
+	a.BriefAt = ""
+	if got := a.NextBrief(now); !got.IsZero() {
+		t.Errorf("expected zero time when disabled, got %v", got)
+	}
+}
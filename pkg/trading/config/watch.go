@@ -0,0 +1,41 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Watch polls the configuration file and calls onChange with the newly
+// loaded configuration whenever its modification time changes. Invalid
+// configurations are reported and skipped so the running setup is kept.
+func Watch(ctx context.Context, path string, interval time.Duration, onChange func(*Config)) {
+	var lastMod time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil || !info.ModTime().After(lastMod) {
+				continue
+			}
+			lastMod = info.ModTime()
+
+			cfg, err := Load(path)
+			if err != nil {
+				fmt.Printf("Config reload skipped: %v\n", err)
+				continue
+			}
+			onChange(cfg)
+		}
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/xinguang/agentic-coder/pkg/trading"
+	"github.com/xinguang/agentic-coder/pkg/trading/broker"
 	"github.com/xinguang/agentic-coder/pkg/trading/provider"
+	"github.com/xinguang/agentic-coder/pkg/trading/risk"
 	"github.com/xinguang/agentic-coder/pkg/trading/signal"
 	"github.com/xinguang/agentic-coder/pkg/trading/storage"
 )
@@ -20,34 +22,66 @@ type Strategy interface {
 
 // Config holds engine configuration
 type Config struct {
-	Symbols       []string      // stock symbols to monitor
-	UpdateInterval time.Duration // data update interval
+	Symbols        []string        // stock symbols to monitor
+	UpdateInterval time.Duration   // data update interval
+	Risk           *risk.Config    // risk rules applied to signals (nil disables)
+	Storage        storage.Storage // persistence backend (nil uses in-memory storage)
+}
+
+// signalEvent pairs a signal with the order approved by risk management
+type signalEvent struct {
+	signal *trading.TradingSignal
+	order  *trading.Order // nil when risk management is disabled
+	result *broker.OrderResult
+	err    error // execution error
+}
+
+// trackedOrder is an open order awaiting fills
+type trackedOrder struct {
+	result *broker.OrderResult
+	filled int // quantity already applied to positions
 }
 
 // Engine is the main trading engine
 type Engine struct {
-	config       *Config
-	provider     provider.DataProvider
-	strategies   []Strategy
-	generator    *signal.Generator
-	storage      *storage.MemoryStorage
-	mu           sync.RWMutex
-	running      bool
-	ctx          context.Context
-	cancel       context.CancelFunc
-	signalChan   chan *trading.TradingSignal
+	config     *Config
+	provider   provider.DataProvider
+	strategies []Strategy
+	generator  *signal.Generator
+	risk       *risk.Manager
+	executor   broker.OrderExecutor
+	openOrders map[string]*trackedOrder
+	storage    storage.Storage
+	mu         sync.RWMutex
+	running    bool
+	ctx        context.Context
+	cancel     context.CancelFunc
+	signalChan chan *signalEvent
+
+	subMu       sync.Mutex
+	subscribers map[chan *trading.TradingSignal]struct{}
 }
 
 // NewEngine creates a new trading engine
 func NewEngine(config *Config, dataProvider provider.DataProvider, strategies []Strategy) *Engine {
-	return &Engine{
+	e := &Engine{
 		config:     config,
 		provider:   dataProvider,
 		strategies: strategies,
 		generator:  signal.NewGenerator(),
-		storage:    storage.NewMemoryStorage(1000, 500),
-		signalChan: make(chan *trading.TradingSignal, 100),
+		storage:    config.Storage,
+		signalChan: make(chan *signalEvent, 100),
+		openOrders: make(map[string]*trackedOrder),
+
+		subscribers: make(map[chan *trading.TradingSignal]struct{}),
+	}
+	if e.storage == nil {
+		e.storage = storage.NewMemoryStorage(1000, 500)
 	}
+	if config.Risk != nil {
+		e.risk = risk.NewManager(config.Risk)
+	}
+	return e
 }
 
 // Start starts the trading engine
@@ -61,6 +95,11 @@ func (e *Engine) Start() error {
 	e.ctx, e.cancel = context.WithCancel(context.Background())
 	e.mu.Unlock()
 
+	// Restore positions and open orders from previous runs
+	if err := e.recover(); err != nil {
+		fmt.Printf("Error recovering state: %v\n", err)
+	}
+
 	fmt.Printf("Trading engine started, monitoring %d symbols: %v\n", len(e.config.Symbols), e.config.Symbols)
 
 	// Start data collection
@@ -82,6 +121,7 @@ func (e *Engine) Stop() error {
 	e.running = false
 	e.mu.Unlock()
 
+	e.cancelOpenOrders()
 	e.cancel()
 	if err := e.provider.Close(); err != nil {
 		return fmt.Errorf("error closing provider: %w", err)
@@ -91,6 +131,39 @@ func (e *Engine) Stop() error {
 	return nil
 }
 
+// SetStrategies replaces the active strategies. New strategies are warmed
+// up with stored price history so they can signal without a fresh lookback.
+func (e *Engine) SetStrategies(strategies []Strategy) {
+	e.warmUp(strategies)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.strategies = strategies
+}
+
+// Strategies returns the active strategies
+func (e *Engine) Strategies() []Strategy {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.strategies
+}
+
+// warmUp replays stored stock data through strategies, discarding signals
+func (e *Engine) warmUp(strategies []Strategy) {
+	for _, symbol := range e.config.Symbols {
+		history, err := e.storage.GetStockData(symbol, 200)
+		if err != nil {
+			fmt.Printf("Error loading history for %s: %v\n", symbol, err)
+			continue
+		}
+		for _, d := range history {
+			for _, strat := range strategies {
+				strat.Analyze([]*trading.StockData{d}, nil)
+			}
+		}
+	}
+}
+
 // collectData continuously collects stock data
 func (e *Engine) collectData() {
 	ticker := time.NewTicker(e.config.UpdateInterval)
@@ -108,6 +181,9 @@ func (e *Engine) collectData() {
 
 // fetchAndAnalyze fetches data and generates signals
 func (e *Engine) fetchAndAnalyze() {
+	// Pick up fills for orders placed earlier
+	e.syncOrders()
+
 	// Fetch current data
 	data, err := e.provider.GetStockData(e.ctx, e.config.Symbols)
 	if err != nil {
@@ -127,7 +203,7 @@ func (e *Engine) fetchAndAnalyze() {
 
 	// Run all strategies
 	allSignals := make([]*trading.TradingSignal, 0)
-	for _, strat := range e.strategies {
+	for _, strat := range e.Strategies() {
 		signals, err := strat.Analyze(data, positions)
 		if err != nil {
 			fmt.Printf("Error in strategy %s: %v\n", strat.Name(), err)
@@ -136,6 +212,11 @@ func (e *Engine) fetchAndAnalyze() {
 		allSignals = append(allSignals, signals...)
 	}
 
+	// Protective exits from stop-loss/take-profit levels
+	if e.risk != nil {
+		allSignals = append(allSignals, e.risk.CheckStops(data)...)
+	}
+
 	// Add signals to generator
 	if len(allSignals) > 0 {
 		e.generator.AddSignals(allSignals)
@@ -148,23 +229,87 @@ func (e *Engine) fetchAndAnalyze() {
 			fmt.Printf("Error saving signal: %v\n", err)
 		}
 
+		e.Publish(sig)
+
+		// Filter through risk rules
+		event := &signalEvent{signal: sig}
+		if e.risk != nil {
+			order, err := e.risk.Evaluate(sig)
+			if err != nil {
+				fmt.Printf("Signal %s %s rejected by risk rules: %v\n", sig.Type, sig.Symbol, err)
+				continue
+			}
+			event.order = order
+
+			if e.executor != nil {
+				event.result, event.err = e.executeOrder(order)
+			}
+		}
+
 		// Send to signal channel
 		select {
-		case e.signalChan <- sig:
+		case e.signalChan <- event:
 		default:
 			fmt.Println("Signal channel full, dropping signal")
 		}
 	}
 }
 
+// Subscribe registers for new signals. The returned cancel function must be
+// called to unsubscribe. Slow subscribers miss signals rather than block.
+func (e *Engine) Subscribe() (<-chan *trading.TradingSignal, func()) {
+	ch := make(chan *trading.TradingSignal, 32)
+
+	e.subMu.Lock()
+	e.subscribers[ch] = struct{}{}
+	e.subMu.Unlock()
+
+	return ch, func() {
+		e.subMu.Lock()
+		defer e.subMu.Unlock()
+		if _, ok := e.subscribers[ch]; ok {
+			delete(e.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Publish sends a signal to all subscribers without blocking
+func (e *Engine) Publish(sig *trading.TradingSignal) {
+	e.subMu.Lock()
+	defer e.subMu.Unlock()
+
+	for ch := range e.subscribers {
+		select {
+		case ch <- sig:
+		default:
+		}
+	}
+}
+
+// Running reports whether the engine is running
+func (e *Engine) Running() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.running
+}
+
+// Symbols returns the monitored symbols
+func (e *Engine) Symbols() []string {
+	return e.config.Symbols
+}
+
 // monitorSignals monitors and displays trading signals
 func (e *Engine) monitorSignals() {
 	for {
 		select {
 		case <-e.ctx.Done():
 			return
-		case sig := <-e.signalChan:
-			e.displaySignal(sig)
+		case event := <-e.signalChan:
+			e.displaySignal(event.signal)
+			if event.order != nil {
+				e.displayOrder(event)
+			}
 		}
 	}
 }
@@ -198,11 +343,248 @@ func (e *Engine) displaySignal(sig *trading.TradingSignal) {
 	fmt.Println()
 }
 
+// displayOrder displays an order approved by risk management
+func (e *Engine) displayOrder(event *signalEvent) {
+	order := event.order
+	fmt.Printf("📋 Order:      %s %d %s @ $%.2f (value $%.2f)\n",
+		order.Side, order.Quantity, order.Symbol, order.Price, order.Price*float64(order.Quantity))
+	if order.StopLoss > 0 || order.TakeProfit > 0 {
+		fmt.Printf("🛡️  Stop-loss:  $%.2f  Take-profit: $%.2f\n", order.StopLoss, order.TakeProfit)
+	}
+	switch {
+	case event.err != nil:
+		fmt.Printf("❌ Execution failed: %v\n", event.err)
+	case event.result != nil:
+		fmt.Printf("✅ Submitted via %s: id=%s status=%s filled=%d\n",
+			e.executor.Name(), event.result.ID, event.result.Status, event.result.FilledQty)
+	}
+	fmt.Println()
+}
+
+// SetExecutor sets the order executor. Without an executor, approved
+// orders are only displayed.
+func (e *Engine) SetExecutor(executor broker.OrderExecutor) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.executor = executor
+}
+
+// executeOrder submits an order, cancelling any open order on the same
+// symbol in the opposite direction first
+func (e *Engine) executeOrder(order *trading.Order) (*broker.OrderResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for id, tracked := range e.openOrders {
+		if tracked.result.Order.Symbol != order.Symbol || tracked.result.Order.Side == order.Side {
+			continue
+		}
+		if err := e.executor.CancelOrder(e.ctx, id); err != nil {
+			fmt.Printf("Error cancelling order %s: %v\n", id, err)
+			continue
+		}
+		e.markCanceled(tracked)
+		delete(e.openOrders, id)
+	}
+
+	result, err := e.executor.PlaceOrder(e.ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	tracked := &trackedOrder{result: result}
+	e.saveOrder(result)
+	e.applyFills(tracked)
+	if !result.Status.IsFinal() {
+		e.openOrders[result.ID] = tracked
+	}
+
+	return result, nil
+}
+
+// syncOrders refreshes open orders and applies any new fills
+func (e *Engine) syncOrders() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.executor == nil {
+		return
+	}
+
+	for id, tracked := range e.openOrders {
+		result, err := e.executor.GetOrder(e.ctx, id)
+		if err != nil {
+			fmt.Printf("Error fetching order %s: %v\n", id, err)
+			continue
+		}
+		result.Order = tracked.result.Order
+		tracked.result = result
+		e.saveOrder(result)
+		e.applyFills(tracked)
+		if result.Status.IsFinal() {
+			delete(e.openOrders, id)
+		}
+	}
+}
+
+// applyFills records newly filled quantity with risk management and
+// mirrors the resulting position into the signal generator
+func (e *Engine) applyFills(tracked *trackedOrder) {
+	delta := tracked.result.FilledQty - tracked.filled
+	if delta <= 0 {
+		return
+	}
+	tracked.filled = tracked.result.FilledQty
+
+	fill := *tracked.result.Order
+	fill.Quantity = delta
+	e.risk.RecordFill(&fill, tracked.result.FilledPrice)
+
+	if err := e.storage.SaveFill(&trading.Fill{
+		OrderID:   tracked.result.ID,
+		Symbol:    fill.Symbol,
+		Side:      fill.Side,
+		Quantity:  delta,
+		Price:     tracked.result.FilledPrice,
+		Timestamp: time.Now(),
+	}); err != nil {
+		fmt.Printf("Error saving fill: %v\n", err)
+	}
+
+	if pos, ok := e.risk.Positions()[fill.Symbol]; ok {
+		e.generator.UpdatePosition(fill.Symbol, pos.Quantity, pos.AvgPrice)
+	} else {
+		e.generator.UpdatePosition(fill.Symbol, 0, 0)
+	}
+}
+
+// cancelOpenOrders cancels all orders that have not completed
+func (e *Engine) cancelOpenOrders() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.executor == nil {
+		return
+	}
+
+	for id, tracked := range e.openOrders {
+		if err := e.executor.CancelOrder(e.ctx, id); err != nil {
+			fmt.Printf("Error cancelling order %s: %v\n", id, err)
+			continue
+		}
+		e.markCanceled(tracked)
+		fmt.Printf("Cancelled open order %s\n", id)
+		delete(e.openOrders, id)
+	}
+}
+
+// markCanceled records a successful cancellation
+func (e *Engine) markCanceled(tracked *trackedOrder) {
+	tracked.result.Status = broker.StatusCanceled
+	tracked.result.UpdatedAt = time.Now()
+	e.saveOrder(tracked.result)
+}
+
+// saveOrder persists the latest order state
+func (e *Engine) saveOrder(result *broker.OrderResult) {
+	if err := e.storage.SaveOrder(result); err != nil {
+		fmt.Printf("Error saving order %s: %v\n", result.ID, err)
+	}
+}
+
+// recover rebuilds positions from stored fills and resumes tracking of
+// orders that were still open when the engine last stopped
+func (e *Engine) recover() error {
+	fills, err := e.storage.GetFills("", 0)
+	if err != nil {
+		return fmt.Errorf("load fills: %w", err)
+	}
+	for symbol, pos := range rebuildPositions(fills) {
+		e.UpdatePosition(symbol, pos.Quantity, pos.AvgPrice)
+	}
+
+	openOrders, err := e.storage.GetOpenOrders()
+	if err != nil {
+		return fmt.Errorf("load open orders: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, result := range openOrders {
+		e.openOrders[result.ID] = &trackedOrder{result: result, filled: result.FilledQty}
+	}
+
+	if len(fills) > 0 || len(openOrders) > 0 {
+		fmt.Printf("Recovered %d fills and %d open orders from storage\n", len(fills), len(openOrders))
+	}
+	return nil
+}
+
+// rebuildPositions replays fills into net positions with average cost
+func rebuildPositions(fills []*trading.Fill) map[string]*trading.Position {
+	positions := make(map[string]*trading.Position)
+	for _, fill := range fills {
+		pos, ok := positions[fill.Symbol]
+		if !ok {
+			pos = &trading.Position{Symbol: fill.Symbol}
+			positions[fill.Symbol] = pos
+		}
+
+		switch fill.Side {
+		case trading.OrderBuy:
+			cost := pos.AvgPrice*float64(pos.Quantity) + fill.Price*float64(fill.Quantity)
+			pos.Quantity += fill.Quantity
+			pos.AvgPrice = cost / float64(pos.Quantity)
+		case trading.OrderSell:
+			pos.Quantity -= fill.Quantity
+			if pos.Quantity <= 0 {
+				pos.Quantity = 0
+				pos.AvgPrice = 0
+			}
+		}
+		pos.UpdatedAt = fill.Timestamp
+	}
+
+	for symbol, pos := range positions {
+		if pos.Quantity == 0 {
+			delete(positions, symbol)
+		}
+	}
+	return positions
+}
+
+// GetOrderHistory returns recent orders from storage
+func (e *Engine) GetOrderHistory(limit int) ([]*broker.OrderResult, error) {
+	return e.storage.GetOrders(limit)
+}
+
+// GetFills returns recent fills, optionally filtered by symbol
+func (e *Engine) GetFills(symbol string, limit int) ([]*trading.Fill, error) {
+	return e.storage.GetFills(symbol, limit)
+}
+
+// OpenOrders returns orders that are still awaiting fills
+func (e *Engine) OpenOrders() []*broker.OrderResult {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	orders := make([]*broker.OrderResult, 0, len(e.openOrders))
+	for _, tracked := range e.openOrders {
+		orders = append(orders, tracked.result)
+	}
+	return orders
+}
+
 // GetRecentSignals returns recent trading signals
 func (e *Engine) GetRecentSignals(limit int) ([]*trading.TradingSignal, error) {
 	return e.storage.GetSignals(limit)
 }
 
+// GetSignalsBySymbol returns recent trading signals for a symbol
+func (e *Engine) GetSignalsBySymbol(symbol string, limit int) ([]*trading.TradingSignal, error) {
+	return e.storage.GetSignalsBySymbol(symbol, limit)
+}
+
 // GetStockData returns historical stock data
 func (e *Engine) GetStockData(symbol string, limit int) ([]*trading.StockData, error) {
 	return e.storage.GetStockData(symbol, limit)
@@ -216,6 +598,14 @@ func (e *Engine) GetPositions() map[string]*trading.Position {
 // UpdatePosition updates a position
 func (e *Engine) UpdatePosition(symbol string, quantity int, avgPrice float64) {
 	e.generator.UpdatePosition(symbol, quantity, avgPrice)
+	if e.risk != nil {
+		e.risk.SetPosition(symbol, quantity, avgPrice)
+	}
+}
+
+// RiskManager returns the risk manager, or nil if risk management is disabled
+func (e *Engine) RiskManager() *risk.Manager {
+	return e.risk
 }
 
 // formatDuration formats a duration in human-readable format
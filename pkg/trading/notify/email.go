@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// EmailNotifier sends alerts over SMTP
+type EmailNotifier struct {
+	config   *EmailConfig
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailNotifier creates a new SMTP notifier.
+// A zero port defaults to 587.
+func NewEmailNotifier(config *EmailConfig) *EmailNotifier {
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	return &EmailNotifier{
+		config:   config,
+		sendMail: smtp.SendMail,
+	}
+}
+
+// Name implements Notifier interface
+func (e *EmailNotifier) Name() string {
+	return "email"
+}
+
+// Notify implements Notifier interface
+func (e *EmailNotifier) Notify(ctx context.Context, sig *trading.TradingSignal) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+	}
+
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	if err := e.sendMail(addr, auth, e.config.From, e.config.To, e.buildMessage(sig)); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
+
+// buildMessage renders the RFC 822 message
+func (e *EmailNotifier) buildMessage(sig *trading.TradingSignal) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.config.To, ", "))
+	fmt.Fprintf(&b, "Subject: [trading] %s %s @ %.2f\r\n", sig.Type, sig.Symbol, sig.Price)
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(formatMessage(sig), "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,230 @@
+// Package notify pushes trading signals to external alert sinks
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// Notifier delivers a signal alert to a single sink
+type Notifier interface {
+	// Name returns the sink name
+	Name() string
+
+	// Notify sends an alert for the signal
+	Notify(ctx context.Context, sig *trading.TradingSignal) error
+}
+
+// Config holds alerting configuration
+type Config struct {
+	MinConfidence float64         `yaml:"min_confidence" json:"min_confidence"` // only alert on signals at or above this confidence
+	DedupWindow   time.Duration   `yaml:"dedup_window" json:"dedup_window"`     // suppress repeated symbol/type alerts within this window
+	RateLimit     int             `yaml:"rate_limit" json:"rate_limit"`         // max alerts per minute (0 = unlimited)
+	Webhook       *WebhookConfig  `yaml:"webhook,omitempty" json:"webhook,omitempty"`
+	Telegram      *TelegramConfig `yaml:"telegram,omitempty" json:"telegram,omitempty"`
+	Email         *EmailConfig    `yaml:"email,omitempty" json:"email,omitempty"`
+}
+
+// WebhookConfig configures a generic JSON webhook
+type WebhookConfig struct {
+	URL string `yaml:"url" json:"url"`
+}
+
+// TelegramConfig configures a Telegram bot sink.
+// An empty BotToken falls back to TELEGRAM_BOT_TOKEN.
+type TelegramConfig struct {
+	BotToken string `yaml:"bot_token,omitempty" json:"bot_token,omitempty"`
+	ChatID   string `yaml:"chat_id" json:"chat_id"`
+}
+
+// EmailConfig configures an SMTP sink.
+// An empty Password falls back to SMTP_PASSWORD.
+type EmailConfig struct {
+	Host     string   `yaml:"host" json:"host"`
+	Port     int      `yaml:"port" json:"port"`
+	Username string   `yaml:"username,omitempty" json:"username,omitempty"`
+	Password string   `yaml:"password,omitempty" json:"password,omitempty"`
+	From     string   `yaml:"from" json:"from"`
+	To       []string `yaml:"to" json:"to"`
+}
+
+// DefaultConfig returns the default alerting configuration without sinks
+func DefaultConfig() *Config {
+	return &Config{
+		MinConfidence: 0.6,
+		DedupWindow:   30 * time.Minute,
+		RateLimit:     10,
+	}
+}
+
+// UnmarshalYAML decodes an alerts section over the defaults, so settings it
+// leaves out keep their default instead of turning off
+func (c *Config) UnmarshalYAML(value *yaml.Node) error {
+	type plain Config
+	cfg := (*plain)(DefaultConfig())
+	if err := value.Decode(cfg); err != nil {
+		return err
+	}
+	*c = Config(*cfg)
+	return nil
+}
+
+// Validate checks the configuration values
+func (c *Config) Validate() error {
+	if c.MinConfidence < 0 || c.MinConfidence > 1 {
+		return fmt.Errorf("min_confidence must be in [0, 1]")
+	}
+	if c.DedupWindow < 0 {
+		return fmt.Errorf("dedup_window must not be negative")
+	}
+	if c.RateLimit < 0 {
+		return fmt.Errorf("rate_limit must not be negative")
+	}
+	if c.Webhook != nil && c.Webhook.URL == "" {
+		return fmt.Errorf("webhook: url is required")
+	}
+	if c.Telegram != nil && c.Telegram.ChatID == "" {
+		return fmt.Errorf("telegram: chat_id is required")
+	}
+	if c.Email != nil {
+		if c.Email.Host == "" || c.Email.From == "" || len(c.Email.To) == 0 {
+			return fmt.Errorf("email: host, from and to are required")
+		}
+	}
+	return nil
+}
+
+// BuildNotifiers creates the configured sinks
+func (c *Config) BuildNotifiers() ([]Notifier, error) {
+	var notifiers []Notifier
+
+	if c.Webhook != nil {
+		notifiers = append(notifiers, NewWebhookNotifier(c.Webhook.URL))
+	}
+
+	if c.Telegram != nil {
+		token := c.Telegram.BotToken
+		if token == "" {
+			token = os.Getenv("TELEGRAM_BOT_TOKEN")
+		}
+		if token == "" {
+			return nil, fmt.Errorf("telegram: bot_token or TELEGRAM_BOT_TOKEN is required")
+		}
+		notifiers = append(notifiers, NewTelegramNotifier(token, c.Telegram.ChatID))
+	}
+
+	if c.Email != nil {
+		cfg := *c.Email
+		if cfg.Password == "" {
+			cfg.Password = os.Getenv("SMTP_PASSWORD")
+		}
+		notifiers = append(notifiers, NewEmailNotifier(&cfg))
+	}
+
+	return notifiers, nil
+}
+
+// Dispatcher filters signals and fans alerts out to the sinks
+type Dispatcher struct {
+	mu        sync.Mutex
+	config    *Config
+	notifiers []Notifier
+	lastSent  map[string]time.Time // symbol/type -> last alert time
+	sent      []time.Time          // alert times within the rate limit window
+	now       func() time.Time
+}
+
+// NewDispatcher creates a new dispatcher
+func NewDispatcher(config *Config, notifiers ...Notifier) *Dispatcher {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Dispatcher{
+		config:    config,
+		notifiers: notifiers,
+		lastSent:  make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+// Notifiers returns the configured sinks
+func (d *Dispatcher) Notifiers() []Notifier {
+	return d.notifiers
+}
+
+// Dispatch sends an alert for the signal to all sinks. It returns false
+// when the signal was filtered, deduplicated or rate limited.
+func (d *Dispatcher) Dispatch(ctx context.Context, sig *trading.TradingSignal) (bool, error) {
+	if !d.allow(sig) {
+		return false, nil
+	}
+
+	var errs []error
+	for _, n := range d.notifiers {
+		if err := n.Notify(ctx, sig); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", n.Name(), err))
+		}
+	}
+	return true, errors.Join(errs...)
+}
+
+// allow applies the confidence filter, dedup window and rate limit
+func (d *Dispatcher) allow(sig *trading.TradingSignal) bool {
+	if sig.Type != trading.SignalBuy && sig.Type != trading.SignalSell {
+		return false
+	}
+	if sig.Confidence < d.config.MinConfidence {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+
+	key := sig.Symbol + "/" + string(sig.Type)
+	if last, ok := d.lastSent[key]; ok && now.Sub(last) < d.config.DedupWindow {
+		return false
+	}
+
+	if d.config.RateLimit > 0 {
+		cutoff := now.Add(-time.Minute)
+		recent := d.sent[:0]
+		for _, t := range d.sent {
+			if t.After(cutoff) {
+				recent = append(recent, t)
+			}
+		}
+		d.sent = recent
+		if len(d.sent) >= d.config.RateLimit {
+			return false
+		}
+		d.sent = append(d.sent, now)
+	}
+
+	d.lastSent[key] = now
+	return true
+}
+
+// formatMessage renders a signal as a plain-text alert
+func formatMessage(sig *trading.TradingSignal) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s @ %.2f\n", sig.Type, sig.Symbol, sig.Price)
+	fmt.Fprintf(&b, "Confidence: %.0f%%\n", sig.Confidence*100)
+	if sig.Reason != "" {
+		fmt.Fprintf(&b, "Reason: %s\n", sig.Reason)
+	}
+	if !sig.ExecuteAt.IsZero() {
+		fmt.Fprintf(&b, "Execute at: %s\n", sig.ExecuteAt.Format("2006-01-02 15:04"))
+	}
+	return b.String()
+}
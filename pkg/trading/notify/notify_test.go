@@ -0,0 +1,198 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// recorder is a Notifier that records delivered signals
+type recorder struct {
+	signals []*trading.TradingSignal
+	err     error
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+func (r *recorder) Notify(ctx context.Context, sig *trading.TradingSignal) error {
+	r.signals = append(r.signals, sig)
+	return r.err
+}
+
+func buy(symbol string, confidence float64) *trading.TradingSignal {
+	return &trading.TradingSignal{Symbol: symbol, Type: trading.SignalBuy, Price: 100, Confidence: confidence, Reason: "test"}
+}
+
+func TestDispatcherFilters(t *testing.T) {
+	rec := &recorder{}
+	d := NewDispatcher(&Config{MinConfidence: 0.5, DedupWindow: time.Minute}, rec)
+
+	now := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	tests := []struct {
+		name string
+		sig  *trading.TradingSignal
+		want bool
+	}{
+		{"low confidence", buy("AAPL", 0.4), false},
+		{"hold", &trading.TradingSignal{Symbol: "AAPL", Type: trading.SignalHold, Confidence: 0.9}, false},
+		{"first buy", buy("AAPL", 0.8), true},
+		{"duplicate buy", buy("AAPL", 0.9), false},
+		{"sell same symbol", &trading.TradingSignal{Symbol: "AAPL", Type: trading.SignalSell, Confidence: 0.8}, true},
+		{"other symbol", buy("MSFT", 0.8), true},
+	}
+
+	for _, tt := range tests {
+		sent, err := d.Dispatch(context.Background(), tt.sig)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+		if sent != tt.want {
+			t.Errorf("%s: sent = %v, want %v", tt.name, sent, tt.want)
+		}
+	}
+
+	// The dedup window expires
+	now = now.Add(2 * time.Minute)
+	if sent, _ := d.Dispatch(context.Background(), buy("AAPL", 0.8)); !sent {
+		t.Error("expected alert after dedup window")
+	}
+
+	if len(rec.signals) != 4 {
+		t.Errorf("expected 4 delivered alerts, got %d", len(rec.signals))
+	}
+}
+
+func TestDispatcherRateLimit(t *testing.T) {
+	rec := &recorder{}
+	d := NewDispatcher(&Config{RateLimit: 2}, rec)
+
+	now := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	for _, symbol := range []string{"A", "B", "C"} {
+		d.Dispatch(context.Background(), buy(symbol, 1))
+	}
+	if len(rec.signals) != 2 {
+		t.Fatalf("expected 2 alerts within the limit, got %d", len(rec.signals))
+	}
+
+	now = now.Add(61 * time.Second)
+	if sent, _ := d.Dispatch(context.Background(), buy("D", 1)); !sent {
+		t.Error("expected alert after the rate limit window")
+	}
+}
+
+func TestDispatcherCollectsErrors(t *testing.T) {
+	ok := &recorder{}
+	failing := &recorder{err: errors.New("boom")}
+	d := NewDispatcher(&Config{}, failing, ok)
+
+	sent, err := d.Dispatch(context.Background(), buy("AAPL", 1))
+	if !sent || err == nil || !strings.Contains(err.Error(), "recorder: boom") {
+		t.Errorf("unexpected result sent=%v err=%v", sent, err)
+	}
+	if len(ok.signals) != 1 {
+		t.Error("expected remaining sinks to be notified")
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got webhookPayload
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	if err := NewWebhookNotifier(ts.URL).Notify(context.Background(), buy("AAPL", 0.8)); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if got.Symbol != "AAPL" || got.Type != "BUY" || got.Confidence != 0.8 || !strings.Contains(got.Text, "BUY AAPL") {
+		t.Errorf("unexpected payload %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	if err := NewWebhookNotifier(failing.URL).Notify(context.Background(), buy("AAPL", 0.8)); err == nil {
+		t.Error("expected error for non-2xx status")
+	}
+}
+
+func TestTelegramNotifier(t *testing.T) {
+	var path string
+	var body map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer ts.Close()
+
+	n := NewTelegramNotifier("TOKEN", "42")
+	n.baseURL = ts.URL
+	if err := n.Notify(context.Background(), buy("AAPL", 0.8)); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if path != "/botTOKEN/sendMessage" || body["chat_id"] != "42" || !strings.Contains(body["text"], "AAPL") {
+		t.Errorf("unexpected request %s %v", path, body)
+	}
+
+	ts.Close()
+	err := n.Notify(context.Background(), buy("AAPL", 0.8))
+	if err == nil || strings.Contains(err.Error(), "TOKEN") {
+		t.Errorf("expected an error without the token, got %v", err)
+	}
+}
+
+func TestEmailNotifier(t *testing.T) {
+	n := NewEmailNotifier(&EmailConfig{Host: "smtp.example.com", From: "bot@example.com", To: []string{"me@example.com"}})
+
+	var addr string
+	var msg []byte
+	n.sendMail = func(a string, auth smtp.Auth, from string, to []string, m []byte) error {
+		addr, msg = a, m
+		return nil
+	}
+
+	if err := n.Notify(context.Background(), buy("AAPL", 0.8)); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if addr != "smtp.example.com:587" {
+		t.Errorf("unexpected address %s", addr)
+	}
+	if !strings.Contains(string(msg), "Subject: [trading] BUY AAPL @ 100.00") {
+		t.Errorf("unexpected message:\n%s", msg)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr bool
+	}{
+		{"default", DefaultConfig(), false},
+		{"bad confidence", &Config{MinConfidence: 2}, true},
+		{"webhook without url", &Config{Webhook: &WebhookConfig{}}, true},
+		{"telegram without chat", &Config{Telegram: &TelegramConfig{BotToken: "x"}}, true},
+		{"email without recipients", &Config{Email: &EmailConfig{Host: "h", From: "f"}}, true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
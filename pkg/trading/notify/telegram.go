@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+const (
+	// TelegramAPIURL is the Telegram Bot API endpoint
+	TelegramAPIURL = "https://api.telegram.org"
+)
+
+// TelegramNotifier sends alerts through a Telegram bot
+type TelegramNotifier struct {
+	baseURL string
+	token   string
+	chatID  string
+	client  *http.Client
+}
+
+// NewTelegramNotifier creates a new Telegram notifier
+func NewTelegramNotifier(token, chatID string) *TelegramNotifier {
+	return &TelegramNotifier{
+		baseURL: TelegramAPIURL,
+		token:   token,
+		chatID:  chatID,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements Notifier interface
+func (t *TelegramNotifier) Name() string {
+	return "telegram"
+}
+
+// Notify implements Notifier interface
+func (t *TelegramNotifier) Notify(ctx context.Context, sig *trading.TradingSignal) error {
+	body, err := json.Marshal(map[string]string{
+		"chat_id": t.chatID,
+		"text":    formatMessage(sig),
+	})
+	if err != nil {
+		return err
+	}
+
+	return postJSON(ctx, t.client, t.baseURL+"/bot"+t.token+"/sendMessage", body)
+}
@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// WebhookNotifier posts signals as JSON to a URL
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a new webhook notifier
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements Notifier interface
+func (w *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// webhookPayload is the JSON body posted to the webhook
+type webhookPayload struct {
+	Symbol     string    `json:"symbol"`
+	Type       string    `json:"type"`
+	Price      float64   `json:"price"`
+	Confidence float64   `json:"confidence"`
+	Reason     string    `json:"reason"`
+	Timestamp  time.Time `json:"timestamp"`
+	ExecuteAt  time.Time `json:"execute_at"`
+	Text       string    `json:"text"`
+}
+
+// Notify implements Notifier interface
+func (w *WebhookNotifier) Notify(ctx context.Context, sig *trading.TradingSignal) error {
+	body, err := json.Marshal(webhookPayload{
+		Symbol:     sig.Symbol,
+		Type:       string(sig.Type),
+		Price:      sig.Price,
+		Confidence: sig.Confidence,
+		Reason:     sig.Reason,
+		Timestamp:  sig.Timestamp,
+		ExecuteAt:  sig.ExecuteAt,
+		Text:       formatMessage(sig),
+	})
+	if err != nil {
+		return err
+	}
+
+	return postJSON(ctx, w.client, w.url, body)
+}
+
+// postJSON posts a JSON body and checks for a 2xx response. Errors leave
+// out the URL, which can hold a bot token or a webhook secret.
+func postJSON(ctx context.Context, client *http.Client, target string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", withoutURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", withoutURL(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// withoutURL returns the cause of a *url.Error, whose message includes the
+// URL, or err itself
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
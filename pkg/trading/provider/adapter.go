@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// QuoteAdapter adapts a StockDataProvider to the DataProvider interface
+// by polling real-time quotes
+type QuoteAdapter struct {
+	source StockDataProvider
+}
+
+// NewQuoteAdapter creates a DataProvider backed by a StockDataProvider
+func NewQuoteAdapter(source StockDataProvider) *QuoteAdapter {
+	return &QuoteAdapter{source: source}
+}
+
+// GetStockData implements DataProvider interface
+func (a *QuoteAdapter) GetStockData(ctx context.Context, symbols []string) ([]*trading.StockData, error) {
+	quotes, err := a.source.GetMultipleQuotes(ctx, symbols)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", a.source.Name(), err)
+	}
+
+	result := make([]*trading.StockData, 0, len(quotes))
+	for _, q := range quotes {
+		result = append(result, &trading.StockData{
+			Symbol:    q.Symbol,
+			Price:     q.Price,
+			Open:      q.Open,
+			High:      q.High,
+			Low:       q.Low,
+			Volume:    q.Volume,
+			Timestamp: q.Timestamp,
+		})
+	}
+
+	return result, nil
+}
+
+// Subscribe implements DataProvider interface
+func (a *QuoteAdapter) Subscribe(ctx context.Context, symbols []string, callback func(*trading.StockData)) error {
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				data, err := a.GetStockData(ctx, symbols)
+				if err != nil {
+					fmt.Printf("Error fetching data: %v\n", err)
+					continue
+				}
+				for _, d := range data {
+					callback(d)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close implements DataProvider interface
+func (a *QuoteAdapter) Close() error {
+	return nil
+}
+
+// New creates a DataProvider by name: mock, sina or tencent
+func New(name string) (DataProvider, error) {
+	switch name {
+	case "", "mock":
+		return NewMockProvider(), nil
+	case "sina":
+		return NewQuoteAdapter(NewSinaProvider()), nil
+	case "tencent":
+		return NewQuoteAdapter(NewTencentProvider()), nil
+	default:
+		return nil, fmt.Errorf("unknown data provider: %s", name)
+	}
+}
@@ -0,0 +1,356 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// Config holds risk management configuration.
+// Percentages are fractions of total capital (0.1 = 10%).
+type Config struct {
+	Capital           float64 `yaml:"capital" json:"capital"`                         // total capital available for trading
+	PositionSize      float64 `yaml:"position_size" json:"position_size"`             // capital fraction allocated per new position
+	MaxSymbolExposure float64 `yaml:"max_symbol_exposure" json:"max_symbol_exposure"` // max capital fraction held in a single symbol
+	StopLoss          float64 `yaml:"stop_loss" json:"stop_loss"`                     // stop-loss distance from entry (0 disables)
+	TakeProfit        float64 `yaml:"take_profit" json:"take_profit"`                 // take-profit distance from entry (0 disables)
+	MaxDailyLoss      float64 `yaml:"max_daily_loss" json:"max_daily_loss"`           // daily loss that trips the circuit breaker (0 disables)
+	MinConfidence     float64 `yaml:"min_confidence" json:"min_confidence"`           // signals below this confidence are rejected
+}
+
+// DefaultConfig returns a conservative default configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Capital:           100000,
+		PositionSize:      0.1,
+		MaxSymbolExposure: 0.2,
+		StopLoss:          0.05,
+		TakeProfit:        0.1,
+		MaxDailyLoss:      0.03,
+		MinConfidence:     0.3,
+	}
+}
+
+// Validate checks that the configuration values are within range
+func (c *Config) Validate() error {
+	if c.Capital <= 0 {
+		return fmt.Errorf("capital must be positive")
+	}
+	if c.PositionSize <= 0 || c.PositionSize > 1 {
+		return fmt.Errorf("position_size must be in (0, 1]")
+	}
+	fractions := []struct {
+		name  string
+		value float64
+	}{
+		{"max_symbol_exposure", c.MaxSymbolExposure},
+		{"stop_loss", c.StopLoss},
+		{"max_daily_loss", c.MaxDailyLoss},
+		{"min_confidence", c.MinConfidence},
+	}
+	for _, f := range fractions {
+		if f.value < 0 || f.value > 1 {
+			return fmt.Errorf("%s must be in [0, 1]", f.name)
+		}
+	}
+	if c.TakeProfit < 0 {
+		return fmt.Errorf("take_profit must not be negative")
+	}
+	return nil
+}
+
+// holding tracks an open position with its protective levels
+type holding struct {
+	quantity   int
+	avgPrice   float64
+	lastPrice  float64
+	stopLoss   float64
+	takeProfit float64
+}
+
+// Manager filters signals through risk rules and tracks the portfolio
+type Manager struct {
+	mu          sync.Mutex
+	config      *Config
+	holdings    map[string]*holding
+	day         time.Time
+	dayStartPnL float64 // total PnL at the start of the current day
+	realizedPnL float64
+	halted      bool
+	now         func() time.Time
+}
+
+// NewManager creates a new risk manager
+func NewManager(config *Config) *Manager {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Manager{
+		config:   config,
+		holdings: make(map[string]*holding),
+		now:      time.Now,
+	}
+}
+
+// Evaluate converts a signal into a sized order, or returns an error
+// describing which risk rule rejected it
+func (m *Manager) Evaluate(sig *trading.TradingSignal) (*trading.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rollDay()
+
+	if sig.Price <= 0 {
+		return nil, fmt.Errorf("invalid price %.2f", sig.Price)
+	}
+
+	switch sig.Type {
+	case trading.SignalBuy:
+		return m.evaluateBuy(sig)
+	case trading.SignalSell:
+		return m.evaluateSell(sig)
+	default:
+		return nil, fmt.Errorf("signal type %s is not actionable", sig.Type)
+	}
+}
+
+// evaluateBuy applies entry rules: circuit breaker, confidence, sizing and exposure
+func (m *Manager) evaluateBuy(sig *trading.TradingSignal) (*trading.Order, error) {
+	if m.halted {
+		return nil, fmt.Errorf("circuit breaker active: daily loss limit reached")
+	}
+	if sig.Confidence < m.config.MinConfidence {
+		return nil, fmt.Errorf("confidence %.2f below minimum %.2f", sig.Confidence, m.config.MinConfidence)
+	}
+
+	budget := m.config.Capital * m.config.PositionSize
+
+	// Cap by remaining per-symbol exposure
+	if m.config.MaxSymbolExposure > 0 {
+		exposure := 0.0
+		if h, ok := m.holdings[sig.Symbol]; ok {
+			exposure = float64(h.quantity) * h.lastPrice
+		}
+		remaining := m.config.Capital*m.config.MaxSymbolExposure - exposure
+		if remaining < budget {
+			budget = remaining
+		}
+	}
+
+	quantity := int(math.Floor(budget / sig.Price))
+	if quantity <= 0 {
+		return nil, fmt.Errorf("exposure limit reached for %s", sig.Symbol)
+	}
+
+	order := &trading.Order{
+		Symbol:    sig.Symbol,
+		Side:      trading.OrderBuy,
+		Quantity:  quantity,
+		Price:     sig.Price,
+		Reason:    sig.Reason,
+		CreatedAt: m.now(),
+	}
+	if m.config.StopLoss > 0 {
+		order.StopLoss = sig.Price * (1 - m.config.StopLoss)
+	}
+	if m.config.TakeProfit > 0 {
+		order.TakeProfit = sig.Price * (1 + m.config.TakeProfit)
+	}
+
+	return order, nil
+}
+
+// evaluateSell closes the whole position; exits are always allowed
+func (m *Manager) evaluateSell(sig *trading.TradingSignal) (*trading.Order, error) {
+	h, ok := m.holdings[sig.Symbol]
+	if !ok || h.quantity <= 0 {
+		return nil, fmt.Errorf("no position in %s to sell", sig.Symbol)
+	}
+
+	return &trading.Order{
+		Symbol:    sig.Symbol,
+		Side:      trading.OrderSell,
+		Quantity:  h.quantity,
+		Price:     sig.Price,
+		Reason:    sig.Reason,
+		CreatedAt: m.now(),
+	}, nil
+}
+
+// RecordFill updates the portfolio after an order has been filled
+func (m *Manager) RecordFill(order *trading.Order, fillPrice float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rollDay()
+
+	h, ok := m.holdings[order.Symbol]
+	switch order.Side {
+	case trading.OrderBuy:
+		if !ok {
+			h = &holding{}
+			m.holdings[order.Symbol] = h
+		}
+		cost := h.avgPrice*float64(h.quantity) + fillPrice*float64(order.Quantity)
+		h.quantity += order.Quantity
+		h.avgPrice = cost / float64(h.quantity)
+		h.lastPrice = fillPrice
+		if order.StopLoss > 0 {
+			h.stopLoss = order.StopLoss
+		}
+		if order.TakeProfit > 0 {
+			h.takeProfit = order.TakeProfit
+		}
+	case trading.OrderSell:
+		if !ok {
+			return
+		}
+		quantity := order.Quantity
+		if quantity > h.quantity {
+			quantity = h.quantity
+		}
+		m.realizedPnL += (fillPrice - h.avgPrice) * float64(quantity)
+		h.quantity -= quantity
+		h.lastPrice = fillPrice
+		if h.quantity == 0 {
+			delete(m.holdings, order.Symbol)
+		}
+	}
+
+	m.checkCircuitBreaker()
+}
+
+// CheckStops marks positions to market and returns SELL signals for
+// positions that hit their stop-loss or take-profit levels
+func (m *Manager) CheckStops(data []*trading.StockData) []*trading.TradingSignal {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rollDay()
+
+	now := m.now()
+	signals := make([]*trading.TradingSignal, 0)
+	for _, d := range data {
+		h, ok := m.holdings[d.Symbol]
+		if !ok {
+			continue
+		}
+		h.lastPrice = d.Price
+
+		var reason string
+		switch {
+		case h.stopLoss > 0 && d.Price <= h.stopLoss:
+			reason = fmt.Sprintf("Stop-loss hit: %.2f <= %.2f", d.Price, h.stopLoss)
+		case h.takeProfit > 0 && d.Price >= h.takeProfit:
+			reason = fmt.Sprintf("Take-profit hit: %.2f >= %.2f", d.Price, h.takeProfit)
+		default:
+			continue
+		}
+
+		signals = append(signals, &trading.TradingSignal{
+			Symbol:     d.Symbol,
+			Type:       trading.SignalSell,
+			Price:      d.Price,
+			Timestamp:  now,
+			ExecuteAt:  now, // protective exits execute immediately
+			Reason:     reason,
+			Confidence: 1.0,
+		})
+	}
+
+	m.checkCircuitBreaker()
+	return signals
+}
+
+// Positions returns a snapshot of current positions
+func (m *Manager) Positions() map[string]*trading.Position {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	positions := make(map[string]*trading.Position, len(m.holdings))
+	for symbol, h := range m.holdings {
+		positions[symbol] = &trading.Position{
+			Symbol:       symbol,
+			Quantity:     h.quantity,
+			AvgPrice:     h.avgPrice,
+			CurrentPrice: h.lastPrice,
+			PnL:          (h.lastPrice - h.avgPrice) * float64(h.quantity),
+			UpdatedAt:    now,
+		}
+	}
+	return positions
+}
+
+// DailyPnL returns realized plus unrealized PnL since the start of the day
+func (m *Manager) DailyPnL() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rollDay()
+	return m.totalPnL() - m.dayStartPnL
+}
+
+// Halted reports whether the daily loss circuit breaker has tripped
+func (m *Manager) Halted() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rollDay()
+	return m.halted
+}
+
+// totalPnL returns realized plus unrealized PnL
+func (m *Manager) totalPnL() float64 {
+	pnl := m.realizedPnL
+	for _, h := range m.holdings {
+		pnl += (h.lastPrice - h.avgPrice) * float64(h.quantity)
+	}
+	return pnl
+}
+
+// checkCircuitBreaker trips the breaker when the daily loss limit is exceeded
+func (m *Manager) checkCircuitBreaker() {
+	if m.config.MaxDailyLoss <= 0 || m.halted {
+		return
+	}
+	if m.totalPnL()-m.dayStartPnL <= -m.config.Capital*m.config.MaxDailyLoss {
+		m.halted = true
+	}
+}
+
+// rollDay resets the daily counters when the calendar day changes
+func (m *Manager) rollDay() {
+	now := m.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if today.Equal(m.day) {
+		return
+	}
+	m.day = today
+	m.dayStartPnL = m.totalPnL()
+	m.halted = false
+}
+
+// SetPosition overrides a position, e.g. when seeding existing holdings.
+// Stop-loss and take-profit levels are derived from the configured distances.
+func (m *Manager) SetPosition(symbol string, quantity int, avgPrice float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if quantity <= 0 {
+		delete(m.holdings, symbol)
+		return
+	}
+
+	h := &holding{quantity: quantity, avgPrice: avgPrice, lastPrice: avgPrice}
+	if m.config.StopLoss > 0 {
+		h.stopLoss = avgPrice * (1 - m.config.StopLoss)
+	}
+	if m.config.TakeProfit > 0 {
+		h.takeProfit = avgPrice * (1 + m.config.TakeProfit)
+	}
+	m.holdings[symbol] = h
+}
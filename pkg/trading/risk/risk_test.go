@@ -0,0 +1,157 @@
+package risk
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+func newTestManager(config *Config) (*Manager, *time.Time) {
+	m := NewManager(config)
+	now := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func buySignal(symbol string, price, confidence float64) *trading.TradingSignal {
+	return &trading.TradingSignal{Symbol: symbol, Type: trading.SignalBuy, Price: price, Confidence: confidence}
+}
+
+func TestEvaluateBuySizing(t *testing.T) {
+	m, _ := newTestManager(DefaultConfig())
+
+	order, err := m.Evaluate(buySignal("AAPL", 150, 0.8))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 10% of 100000 at 150 per share
+	if order.Quantity != 66 {
+		t.Errorf("expected 66 shares, got %d", order.Quantity)
+	}
+	if math.Abs(order.StopLoss-142.5) > 1e-9 {
+		t.Errorf("expected stop-loss 142.5, got %.2f", order.StopLoss)
+	}
+	if math.Abs(order.TakeProfit-165) > 1e-9 {
+		t.Errorf("expected take-profit 165, got %.2f", order.TakeProfit)
+	}
+}
+
+func TestEvaluateRejections(t *testing.T) {
+	m, _ := newTestManager(DefaultConfig())
+
+	if _, err := m.Evaluate(buySignal("AAPL", 150, 0.1)); err == nil || !strings.Contains(err.Error(), "confidence") {
+		t.Errorf("expected confidence rejection, got %v", err)
+	}
+
+	sell := &trading.TradingSignal{Symbol: "AAPL", Type: trading.SignalSell, Price: 150}
+	if _, err := m.Evaluate(sell); err == nil {
+		t.Error("expected rejection of sell without position")
+	}
+
+	hold := &trading.TradingSignal{Symbol: "AAPL", Type: trading.SignalHold, Price: 150}
+	if _, err := m.Evaluate(hold); err == nil {
+		t.Error("expected rejection of HOLD signal")
+	}
+}
+
+func TestExposureLimit(t *testing.T) {
+	m, _ := newTestManager(DefaultConfig())
+
+	// Two full-size buys reach the 20% exposure cap
+	for i := 0; i < 2; i++ {
+		order, err := m.Evaluate(buySignal("MSFT", 100, 0.8))
+		if err != nil {
+			t.Fatalf("buy %d: unexpected error: %v", i, err)
+		}
+		m.RecordFill(order, 100)
+	}
+
+	if _, err := m.Evaluate(buySignal("MSFT", 100, 0.8)); err == nil || !strings.Contains(err.Error(), "exposure") {
+		t.Errorf("expected exposure rejection, got %v", err)
+	}
+
+	// Other symbols are unaffected
+	if _, err := m.Evaluate(buySignal("AAPL", 100, 0.8)); err != nil {
+		t.Errorf("unexpected error for other symbol: %v", err)
+	}
+}
+
+func TestCheckStops(t *testing.T) {
+	m, _ := newTestManager(DefaultConfig())
+	m.SetPosition("TSLA", 10, 200)
+
+	signals := m.CheckStops([]*trading.StockData{{Symbol: "TSLA", Price: 195}})
+	if len(signals) != 0 {
+		t.Fatalf("expected no signals, got %d", len(signals))
+	}
+
+	signals = m.CheckStops([]*trading.StockData{{Symbol: "TSLA", Price: 189}})
+	if len(signals) != 1 || signals[0].Type != trading.SignalSell {
+		t.Fatalf("expected stop-loss sell, got %+v", signals)
+	}
+	if !strings.Contains(signals[0].Reason, "Stop-loss") {
+		t.Errorf("unexpected reason %q", signals[0].Reason)
+	}
+
+	signals = m.CheckStops([]*trading.StockData{{Symbol: "TSLA", Price: 221}})
+	if len(signals) != 1 || !strings.Contains(signals[0].Reason, "Take-profit") {
+		t.Fatalf("expected take-profit sell, got %+v", signals)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	m, now := newTestManager(DefaultConfig())
+
+	order, err := m.Evaluate(buySignal("AMZN", 100, 0.8))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.RecordFill(order, 100)
+
+	// 100 shares losing 40 each = -4000, beyond the 3% limit of 3000
+	sell, err := m.Evaluate(&trading.TradingSignal{Symbol: "AMZN", Type: trading.SignalSell, Price: 60})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.RecordFill(sell, 60)
+
+	if !m.Halted() {
+		t.Fatal("expected circuit breaker to trip")
+	}
+	if _, err := m.Evaluate(buySignal("AAPL", 100, 0.8)); err == nil || !strings.Contains(err.Error(), "circuit breaker") {
+		t.Errorf("expected circuit breaker rejection, got %v", err)
+	}
+
+	// The breaker resets on the next day
+	*now = now.Add(24 * time.Hour)
+	if m.Halted() {
+		t.Error("expected circuit breaker to reset on a new day")
+	}
+	if pnl := m.DailyPnL(); pnl != 0 {
+		t.Errorf("expected daily PnL reset, got %.2f", pnl)
+	}
+}
+
+func TestRecordFillAveragesPrice(t *testing.T) {
+	m, _ := newTestManager(&Config{Capital: 100000, PositionSize: 0.1})
+
+	m.RecordFill(&trading.Order{Symbol: "AAPL", Side: trading.OrderBuy, Quantity: 10}, 100)
+	m.RecordFill(&trading.Order{Symbol: "AAPL", Side: trading.OrderBuy, Quantity: 10}, 120)
+
+	pos := m.Positions()["AAPL"]
+	if pos.Quantity != 20 || math.Abs(pos.AvgPrice-110) > 1e-9 {
+		t.Errorf("expected 20 @ 110, got %d @ %.2f", pos.Quantity, pos.AvgPrice)
+	}
+
+	m.RecordFill(&trading.Order{Symbol: "AAPL", Side: trading.OrderSell, Quantity: 20}, 130)
+	if _, ok := m.Positions()["AAPL"]; ok {
+		t.Error("expected position to be closed")
+	}
+	if pnl := m.DailyPnL(); math.Abs(pnl-400) > 1e-9 {
+		t.Errorf("expected realized PnL 400, got %.2f", pnl)
+	}
+}
@@ -0,0 +1,298 @@
+// Package server exposes the trading engine over HTTP
+package server
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+	"github.com/xinguang/agentic-coder/pkg/trading/broker"
+	"github.com/xinguang/agentic-coder/pkg/trading/engine"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// Server serves the dashboard and JSON API for a trading engine
+type Server struct {
+	engine *engine.Engine
+	http   *http.Server
+}
+
+// New creates a new server listening on addr
+func New(addr string, eng *engine.Engine) *Server {
+	s := &Server{engine: eng}
+	s.http = &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the HTTP handler with all routes registered
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	static, _ := fs.Sub(staticFiles, "static")
+	mux.Handle("GET /", http.FileServer(http.FS(static)))
+
+	mux.HandleFunc("GET /api/status", s.handleStatus)
+	mux.HandleFunc("GET /api/positions", s.handlePositions)
+	mux.HandleFunc("GET /api/signals", s.handleSignals)
+	mux.HandleFunc("GET /api/orders", s.handleOrders)
+	mux.HandleFunc("GET /api/strategies", s.handleStrategies)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+
+	return mux
+}
+
+// Start listens on the server's address and serves in the background. It
+// returns the error of listening, such as the port being in use.
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := s.http.Serve(ln); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Dashboard server error: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+// Stop gracefully shuts down the server
+func (s *Server) Stop(ctx context.Context) error {
+	return s.http.Shutdown(ctx)
+}
+
+// Status is the response for /api/status
+type Status struct {
+	Running     bool     `json:"running"`
+	Symbols     []string `json:"symbols"`
+	RiskEnabled bool     `json:"risk_enabled"`
+	Halted      bool     `json:"halted"`
+	DailyPnL    float64  `json:"daily_pnl"`
+	OpenOrders  int      `json:"open_orders"`
+}
+
+// StrategyStatus is an entry in the /api/strategies response
+type StrategyStatus struct {
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+}
+
+// SignalJSON is the JSON representation of a trading signal
+type SignalJSON struct {
+	Symbol     string    `json:"symbol"`
+	Type       string    `json:"type"`
+	Price      float64   `json:"price"`
+	Timestamp  time.Time `json:"timestamp"`
+	ExecuteAt  time.Time `json:"execute_at"`
+	Reason     string    `json:"reason"`
+	Confidence float64   `json:"confidence"`
+}
+
+// PositionJSON is the JSON representation of a position
+type PositionJSON struct {
+	Symbol       string    `json:"symbol"`
+	Quantity     int       `json:"quantity"`
+	AvgPrice     float64   `json:"avg_price"`
+	CurrentPrice float64   `json:"current_price"`
+	PnL          float64   `json:"pnl"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// OrderJSON is the JSON representation of an order
+type OrderJSON struct {
+	ID          string    `json:"id"`
+	Symbol      string    `json:"symbol"`
+	Side        string    `json:"side"`
+	Quantity    int       `json:"quantity"`
+	Price       float64   `json:"price"`
+	Status      string    `json:"status"`
+	FilledQty   int       `json:"filled_qty"`
+	FilledPrice float64   `json:"filled_price"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status := Status{
+		Running:    s.engine.Running(),
+		Symbols:    s.engine.Symbols(),
+		OpenOrders: len(s.engine.OpenOrders()),
+	}
+	if rm := s.engine.RiskManager(); rm != nil {
+		status.RiskEnabled = true
+		status.Halted = rm.Halted()
+		status.DailyPnL = rm.DailyPnL()
+	}
+	writeJSON(w, status)
+}
+
+func (s *Server) handlePositions(w http.ResponseWriter, r *http.Request) {
+	positions := s.engine.GetPositions()
+	if rm := s.engine.RiskManager(); rm != nil {
+		// The risk manager tracks marked-to-market prices and PnL
+		positions = rm.Positions()
+	}
+
+	result := make([]PositionJSON, 0, len(positions))
+	for _, p := range positions {
+		if p.Quantity == 0 {
+			continue
+		}
+		result = append(result, PositionJSON{
+			Symbol:       p.Symbol,
+			Quantity:     p.Quantity,
+			AvgPrice:     p.AvgPrice,
+			CurrentPrice: p.CurrentPrice,
+			PnL:          p.PnL,
+			UpdatedAt:    p.UpdatedAt,
+		})
+	}
+	writeJSON(w, result)
+}
+
+func (s *Server) handleSignals(w http.ResponseWriter, r *http.Request) {
+	limit := queryInt(r, "limit", 50)
+	symbol := r.URL.Query().Get("symbol")
+
+	var signals []*trading.TradingSignal
+	var err error
+	if symbol != "" {
+		signals, err = s.engine.GetSignalsBySymbol(symbol, limit)
+	} else {
+		signals, err = s.engine.GetRecentSignals(limit)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]SignalJSON, 0, len(signals))
+	for _, sig := range signals {
+		result = append(result, toSignalJSON(sig))
+	}
+	writeJSON(w, result)
+}
+
+func (s *Server) handleOrders(w http.ResponseWriter, r *http.Request) {
+	orders, err := s.engine.GetOrderHistory(queryInt(r, "limit", 50))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]OrderJSON, 0, len(orders))
+	for _, o := range orders {
+		result = append(result, toOrderJSON(o))
+	}
+	writeJSON(w, result)
+}
+
+func (s *Server) handleStrategies(w http.ResponseWriter, r *http.Request) {
+	strategies := s.engine.Strategies()
+	result := make([]StrategyStatus, 0, len(strategies))
+	for _, strat := range strategies {
+		result = append(result, StrategyStatus{Name: strat.Name(), Active: s.engine.Running()})
+	}
+	writeJSON(w, result)
+}
+
+// handleEvents streams new signals as server-sent events
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	signals, cancel := s.engine.Subscribe()
+	defer cancel()
+
+	// Comment line so clients see the connection open immediately
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case sig, ok := <-signals:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(toSignalJSON(sig))
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: signal\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+func toSignalJSON(sig *trading.TradingSignal) SignalJSON {
+	return SignalJSON{
+		Symbol:     sig.Symbol,
+		Type:       string(sig.Type),
+		Price:      sig.Price,
+		Timestamp:  sig.Timestamp,
+		ExecuteAt:  sig.ExecuteAt,
+		Reason:     sig.Reason,
+		Confidence: sig.Confidence,
+	}
+}
+
+func toOrderJSON(o *broker.OrderResult) OrderJSON {
+	result := OrderJSON{
+		ID:          o.ID,
+		Status:      string(o.Status),
+		FilledQty:   o.FilledQty,
+		FilledPrice: o.FilledPrice,
+		SubmittedAt: o.SubmittedAt,
+	}
+	if o.Order != nil {
+		result.Symbol = o.Order.Symbol
+		result.Side = string(o.Order.Side)
+		result.Quantity = o.Order.Quantity
+		result.Price = o.Order.Price
+	}
+	return result
+}
+
+// queryInt parses an integer query parameter with a default
+func queryInt(r *http.Request, name string, def int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+	"github.com/xinguang/agentic-coder/pkg/trading/engine"
+	"github.com/xinguang/agentic-coder/pkg/trading/provider"
+	"github.com/xinguang/agentic-coder/pkg/trading/risk"
+	"github.com/xinguang/agentic-coder/pkg/trading/storage"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *engine.Engine, storage.Storage) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage(100, 100)
+	eng := engine.NewEngine(&engine.Config{
+		Symbols:        []string{"AAPL"},
+		UpdateInterval: time.Hour,
+		Risk:           risk.DefaultConfig(),
+		Storage:        store,
+	}, provider.NewMockProvider(), nil)
+
+	ts := httptest.NewServer(New("", eng).Handler())
+	t.Cleanup(ts.Close)
+	return ts, eng, store
+}
+
+func getJSON(t *testing.T, url string, v interface{}) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("decode %s: %v", url, err)
+	}
+}
+
+func TestAPIEndpoints(t *testing.T) {
+	ts, eng, store := newTestServer(t)
+
+	eng.UpdatePosition("AAPL", 10, 150)
+	store.SaveSignal(&trading.TradingSignal{Symbol: "AAPL", Type: trading.SignalBuy, Price: 150, Confidence: 0.8})
+	store.SaveSignal(&trading.TradingSignal{Symbol: "MSFT", Type: trading.SignalSell, Price: 300, Confidence: 0.6})
+
+	var status Status
+	getJSON(t, ts.URL+"/api/status", &status)
+	if status.Running || !status.RiskEnabled || len(status.Symbols) != 1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	var positions []PositionJSON
+	getJSON(t, ts.URL+"/api/positions", &positions)
+	if len(positions) != 1 || positions[0].Quantity != 10 || positions[0].AvgPrice != 150 {
+		t.Errorf("unexpected positions %+v", positions)
+	}
+
+	var signals []SignalJSON
+	getJSON(t, ts.URL+"/api/signals?limit=1", &signals)
+	if len(signals) != 1 || signals[0].Symbol != "MSFT" {
+		t.Errorf("unexpected signals %+v", signals)
+	}
+	getJSON(t, ts.URL+"/api/signals?symbol=AAPL", &signals)
+	if len(signals) != 1 || signals[0].Type != "BUY" {
+		t.Errorf("unexpected filtered signals %+v", signals)
+	}
+
+	var orders []OrderJSON
+	getJSON(t, ts.URL+"/api/orders", &orders)
+	if len(orders) != 0 {
+		t.Errorf("expected no orders, got %+v", orders)
+	}
+
+	resp, err := http.Get(ts.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("dashboard: status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	ts, eng, _ := newTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	// Wait for the connection comment so the subscription is registered
+	if line, err := reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, ": connected") {
+		t.Fatalf("unexpected first line %q, err %v", line, err)
+	}
+
+	eng.Publish(&trading.TradingSignal{Symbol: "TSLA", Type: trading.SignalBuy, Price: 250})
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var sig SignalJSON
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &sig); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		if sig.Symbol != "TSLA" || sig.Type != "BUY" {
+			t.Errorf("unexpected event %+v", sig)
+		}
+		return
+	}
+}
+
+func TestStartReportsListenErrors(t *testing.T) {
+	ts, eng, _ := newTestServer(t)
+
+	// The test server holds its port, so listening there again fails
+	s := New(strings.TrimPrefix(ts.URL, "http://"), eng)
+	if err := s.Start(); err == nil {
+		s.Stop(context.Background())
+		t.Fatal("expected an error for a port in use")
+	}
+
+	s = New("127.0.0.1:0", eng)
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	s.Stop(context.Background())
+}
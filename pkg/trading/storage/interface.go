@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+	"github.com/xinguang/agentic-coder/pkg/trading/broker"
+)
+
+// Storage interface for trading data persistence
+type Storage interface {
+	// SaveStockData saves stock data
+	SaveStockData(data *trading.StockData) error
+
+	// GetStockData retrieves the most recent stock data for a symbol
+	GetStockData(symbol string, limit int) ([]*trading.StockData, error)
+
+	// SaveSignal saves a trading signal
+	SaveSignal(signal *trading.TradingSignal) error
+
+	// GetSignals retrieves recent signals, oldest first
+	GetSignals(limit int) ([]*trading.TradingSignal, error)
+
+	// GetSignalsBySymbol retrieves recent signals for a symbol
+	GetSignalsBySymbol(symbol string, limit int) ([]*trading.TradingSignal, error)
+
+	// GetSignalsByTimeRange retrieves signals within a time range
+	GetSignalsByTimeRange(start, end time.Time) ([]*trading.TradingSignal, error)
+
+	// SaveOrder inserts or updates an order by its ID
+	SaveOrder(order *broker.OrderResult) error
+
+	// GetOrders retrieves recent orders, oldest first
+	GetOrders(limit int) ([]*broker.OrderResult, error)
+
+	// GetOpenOrders retrieves orders that have not reached a final status
+	GetOpenOrders() ([]*broker.OrderResult, error)
+
+	// SaveFill saves an order fill
+	SaveFill(fill *trading.Fill) error
+
+	// GetFills retrieves recent fills, optionally filtered by symbol
+	GetFills(symbol string, limit int) ([]*trading.Fill, error)
+
+	// Close releases storage resources
+	Close() error
+}
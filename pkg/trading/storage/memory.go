@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/xinguang/agentic-coder/pkg/trading"
+	"github.com/xinguang/agentic-coder/pkg/trading/broker"
 )
 
 // MemoryStorage is an in-memory storage for trading data
@@ -12,6 +13,8 @@ type MemoryStorage struct {
 	mu            sync.RWMutex
 	stockData     map[string][]*trading.StockData
 	signals       []*trading.TradingSignal
+	orders        []*broker.OrderResult
+	fills         []*trading.Fill
 	maxDataPoints int
 	maxSignals    int
 }
@@ -134,3 +137,84 @@ func (s *MemoryStorage) GetSignalsByTimeRange(start, end time.Time) ([]*trading.
 
 	return result, nil
 }
+
+// SaveOrder inserts or updates an order by its ID
+func (s *MemoryStorage) SaveOrder(order *broker.OrderResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *order
+	for i, existing := range s.orders {
+		if existing.ID == order.ID {
+			s.orders[i] = &copied
+			return nil
+		}
+	}
+	s.orders = append(s.orders, &copied)
+
+	return nil
+}
+
+// GetOrders retrieves recent orders
+func (s *MemoryStorage) GetOrders(limit int) ([]*broker.OrderResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if limit <= 0 || limit > len(s.orders) {
+		limit = len(s.orders)
+	}
+
+	start := len(s.orders) - limit
+	result := make([]*broker.OrderResult, limit)
+	copy(result, s.orders[start:])
+
+	return result, nil
+}
+
+// GetOpenOrders retrieves orders that have not reached a final status
+func (s *MemoryStorage) GetOpenOrders() ([]*broker.OrderResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*broker.OrderResult, 0)
+	for _, order := range s.orders {
+		if !order.Status.IsFinal() {
+			result = append(result, order)
+		}
+	}
+
+	return result, nil
+}
+
+// SaveFill saves an order fill
+func (s *MemoryStorage) SaveFill(fill *trading.Fill) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fills = append(s.fills, fill)
+	return nil
+}
+
+// GetFills retrieves recent fills, optionally filtered by symbol
+func (s *MemoryStorage) GetFills(symbol string, limit int) ([]*trading.Fill, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	filtered := make([]*trading.Fill, 0)
+	for _, fill := range s.fills {
+		if symbol == "" || fill.Symbol == symbol {
+			filtered = append(filtered, fill)
+		}
+	}
+
+	if limit <= 0 || limit > len(filtered) {
+		limit = len(filtered)
+	}
+
+	return filtered[len(filtered)-limit:], nil
+}
+
+// Close implements Storage interface
+func (s *MemoryStorage) Close() error {
+	return nil
+}
@@ -0,0 +1,334 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+	"github.com/xinguang/agentic-coder/pkg/trading/broker"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// schema creates the tables used by SQLiteStorage.
+// Timestamps are stored as Unix nanoseconds.
+const schema = `
+CREATE TABLE IF NOT EXISTS stock_data (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	symbol    TEXT NOT NULL,
+	price     REAL NOT NULL,
+	open      REAL NOT NULL,
+	high      REAL NOT NULL,
+	low       REAL NOT NULL,
+	volume    INTEGER NOT NULL,
+	timestamp INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_stock_data_symbol ON stock_data(symbol, id);
+
+CREATE TABLE IF NOT EXISTS signals (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	symbol     TEXT NOT NULL,
+	type       TEXT NOT NULL,
+	price      REAL NOT NULL,
+	timestamp  INTEGER NOT NULL,
+	execute_at INTEGER NOT NULL,
+	reason     TEXT NOT NULL,
+	confidence REAL NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_signals_symbol ON signals(symbol, id);
+CREATE INDEX IF NOT EXISTS idx_signals_timestamp ON signals(timestamp);
+
+CREATE TABLE IF NOT EXISTS orders (
+	seq          INTEGER PRIMARY KEY AUTOINCREMENT,
+	id           TEXT NOT NULL UNIQUE,
+	symbol       TEXT NOT NULL,
+	side         TEXT NOT NULL,
+	quantity     INTEGER NOT NULL,
+	price        REAL NOT NULL,
+	stop_loss    REAL NOT NULL,
+	take_profit  REAL NOT NULL,
+	reason       TEXT NOT NULL,
+	created_at   INTEGER NOT NULL,
+	status       TEXT NOT NULL,
+	filled_qty   INTEGER NOT NULL,
+	filled_price REAL NOT NULL,
+	submitted_at INTEGER NOT NULL,
+	updated_at   INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS fills (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	order_id  TEXT NOT NULL,
+	symbol    TEXT NOT NULL,
+	side      TEXT NOT NULL,
+	quantity  INTEGER NOT NULL,
+	price     REAL NOT NULL,
+	timestamp INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_fills_symbol ON fills(symbol, id);
+`
+
+// SQLiteStorage persists trading data in a SQLite database
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// NewSQLiteStorage opens (or creates) a SQLite database at path
+func NewSQLiteStorage(path string) (*SQLiteStorage, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// SQLite allows a single writer; serialize access through one connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+
+	return &SQLiteStorage{db: db}, nil
+}
+
+// SaveStockData implements Storage interface
+func (s *SQLiteStorage) SaveStockData(data *trading.StockData) error {
+	_, err := s.db.Exec(
+		`INSERT INTO stock_data (symbol, price, open, high, low, volume, timestamp) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		data.Symbol, data.Price, data.Open, data.High, data.Low, data.Volume, toUnix(data.Timestamp),
+	)
+	if err != nil {
+		return fmt.Errorf("insert stock data: %w", err)
+	}
+	return nil
+}
+
+// GetStockData implements Storage interface
+func (s *SQLiteStorage) GetStockData(symbol string, limit int) ([]*trading.StockData, error) {
+	rows, err := s.db.Query(
+		`SELECT symbol, price, open, high, low, volume, timestamp FROM (
+			SELECT * FROM stock_data WHERE symbol = ? ORDER BY id DESC LIMIT ?
+		) ORDER BY id ASC`,
+		symbol, sqlLimit(limit),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query stock data: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]*trading.StockData, 0)
+	for rows.Next() {
+		var d trading.StockData
+		var ts int64
+		if err := rows.Scan(&d.Symbol, &d.Price, &d.Open, &d.High, &d.Low, &d.Volume, &ts); err != nil {
+			return nil, fmt.Errorf("scan stock data: %w", err)
+		}
+		d.Timestamp = fromUnix(ts)
+		result = append(result, &d)
+	}
+
+	return result, rows.Err()
+}
+
+// SaveSignal implements Storage interface
+func (s *SQLiteStorage) SaveSignal(signal *trading.TradingSignal) error {
+	_, err := s.db.Exec(
+		`INSERT INTO signals (symbol, type, price, timestamp, execute_at, reason, confidence) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		signal.Symbol, string(signal.Type), signal.Price, toUnix(signal.Timestamp), toUnix(signal.ExecuteAt), signal.Reason, signal.Confidence,
+	)
+	if err != nil {
+		return fmt.Errorf("insert signal: %w", err)
+	}
+	return nil
+}
+
+// GetSignals implements Storage interface
+func (s *SQLiteStorage) GetSignals(limit int) ([]*trading.TradingSignal, error) {
+	return s.querySignals(
+		`SELECT * FROM (SELECT * FROM signals ORDER BY id DESC LIMIT ?) ORDER BY id ASC`,
+		sqlLimit(limit),
+	)
+}
+
+// GetSignalsBySymbol implements Storage interface
+func (s *SQLiteStorage) GetSignalsBySymbol(symbol string, limit int) ([]*trading.TradingSignal, error) {
+	return s.querySignals(
+		`SELECT * FROM (SELECT * FROM signals WHERE symbol = ? ORDER BY id DESC LIMIT ?) ORDER BY id ASC`,
+		symbol, sqlLimit(limit),
+	)
+}
+
+// GetSignalsByTimeRange implements Storage interface
+func (s *SQLiteStorage) GetSignalsByTimeRange(start, end time.Time) ([]*trading.TradingSignal, error) {
+	return s.querySignals(
+		`SELECT * FROM signals WHERE timestamp > ? AND timestamp < ? ORDER BY id ASC`,
+		toUnix(start), toUnix(end),
+	)
+}
+
+// querySignals runs a query returning full signal rows
+func (s *SQLiteStorage) querySignals(query string, args ...interface{}) ([]*trading.TradingSignal, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query signals: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]*trading.TradingSignal, 0)
+	for rows.Next() {
+		var sig trading.TradingSignal
+		var id, ts, executeAt int64
+		var sigType string
+		if err := rows.Scan(&id, &sig.Symbol, &sigType, &sig.Price, &ts, &executeAt, &sig.Reason, &sig.Confidence); err != nil {
+			return nil, fmt.Errorf("scan signal: %w", err)
+		}
+		sig.Type = trading.SignalType(sigType)
+		sig.Timestamp = fromUnix(ts)
+		sig.ExecuteAt = fromUnix(executeAt)
+		result = append(result, &sig)
+	}
+
+	return result, rows.Err()
+}
+
+// SaveOrder implements Storage interface
+func (s *SQLiteStorage) SaveOrder(order *broker.OrderResult) error {
+	o := order.Order
+	if o == nil {
+		o = &trading.Order{}
+	}
+
+	_, err := s.db.Exec(
+		`INSERT INTO orders (id, symbol, side, quantity, price, stop_loss, take_profit, reason, created_at,
+			status, filled_qty, filled_price, submitted_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			status = excluded.status,
+			filled_qty = excluded.filled_qty,
+			filled_price = excluded.filled_price,
+			updated_at = excluded.updated_at`,
+		order.ID, o.Symbol, string(o.Side), o.Quantity, o.Price, o.StopLoss, o.TakeProfit, o.Reason, toUnix(o.CreatedAt),
+		string(order.Status), order.FilledQty, order.FilledPrice, toUnix(order.SubmittedAt), toUnix(order.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("upsert order: %w", err)
+	}
+	return nil
+}
+
+// GetOrders implements Storage interface
+func (s *SQLiteStorage) GetOrders(limit int) ([]*broker.OrderResult, error) {
+	return s.queryOrders(
+		`SELECT * FROM (SELECT * FROM orders ORDER BY seq DESC LIMIT ?) ORDER BY seq ASC`,
+		sqlLimit(limit),
+	)
+}
+
+// GetOpenOrders implements Storage interface
+func (s *SQLiteStorage) GetOpenOrders() ([]*broker.OrderResult, error) {
+	return s.queryOrders(
+		`SELECT * FROM orders WHERE status NOT IN (?, ?, ?) ORDER BY seq ASC`,
+		string(broker.StatusFilled), string(broker.StatusCanceled), string(broker.StatusRejected),
+	)
+}
+
+// queryOrders runs a query returning full order rows
+func (s *SQLiteStorage) queryOrders(query string, args ...interface{}) ([]*broker.OrderResult, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query orders: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]*broker.OrderResult, 0)
+	for rows.Next() {
+		var r broker.OrderResult
+		var o trading.Order
+		var seq, createdAt, submittedAt, updatedAt int64
+		var side, status string
+		if err := rows.Scan(&seq, &r.ID, &o.Symbol, &side, &o.Quantity, &o.Price, &o.StopLoss, &o.TakeProfit, &o.Reason,
+			&createdAt, &status, &r.FilledQty, &r.FilledPrice, &submittedAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scan order: %w", err)
+		}
+		o.Side = trading.OrderSide(side)
+		o.CreatedAt = fromUnix(createdAt)
+		r.Order = &o
+		r.Status = broker.OrderStatus(status)
+		r.SubmittedAt = fromUnix(submittedAt)
+		r.UpdatedAt = fromUnix(updatedAt)
+		result = append(result, &r)
+	}
+
+	return result, rows.Err()
+}
+
+// SaveFill implements Storage interface
+func (s *SQLiteStorage) SaveFill(fill *trading.Fill) error {
+	_, err := s.db.Exec(
+		`INSERT INTO fills (order_id, symbol, side, quantity, price, timestamp) VALUES (?, ?, ?, ?, ?, ?)`,
+		fill.OrderID, fill.Symbol, string(fill.Side), fill.Quantity, fill.Price, toUnix(fill.Timestamp),
+	)
+	if err != nil {
+		return fmt.Errorf("insert fill: %w", err)
+	}
+	return nil
+}
+
+// GetFills implements Storage interface
+func (s *SQLiteStorage) GetFills(symbol string, limit int) ([]*trading.Fill, error) {
+	rows, err := s.db.Query(
+		`SELECT order_id, symbol, side, quantity, price, timestamp FROM (
+			SELECT * FROM fills WHERE ? = '' OR symbol = ? ORDER BY id DESC LIMIT ?
+		) ORDER BY id ASC`,
+		symbol, symbol, sqlLimit(limit),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query fills: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]*trading.Fill, 0)
+	for rows.Next() {
+		var f trading.Fill
+		var side string
+		var ts int64
+		if err := rows.Scan(&f.OrderID, &f.Symbol, &side, &f.Quantity, &f.Price, &ts); err != nil {
+			return nil, fmt.Errorf("scan fill: %w", err)
+		}
+		f.Side = trading.OrderSide(side)
+		f.Timestamp = fromUnix(ts)
+		result = append(result, &f)
+	}
+
+	return result, rows.Err()
+}
+
+// Close implements Storage interface
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// sqlLimit maps a non-positive limit to SQLite's "no limit"
+func sqlLimit(limit int) int {
+	if limit <= 0 {
+		return -1
+	}
+	return limit
+}
+
+// toUnix converts a time to Unix nanoseconds, keeping zero times as 0
+func toUnix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnix converts Unix nanoseconds back to a time
+func fromUnix(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
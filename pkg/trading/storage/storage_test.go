@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+	"github.com/xinguang/agentic-coder/pkg/trading/broker"
+)
+
+func newStorages(t *testing.T) map[string]Storage {
+	t.Helper()
+
+	sqlite, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "trading.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage() error = %v", err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+
+	return map[string]Storage{
+		"memory": NewMemoryStorage(100, 100),
+		"sqlite": sqlite,
+	}
+}
+
+func TestStorageSignals(t *testing.T) {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	for name, s := range newStorages(t) {
+		t.Run(name, func(t *testing.T) {
+			for i, symbol := range []string{"AAPL", "MSFT", "AAPL"} {
+				sig := &trading.TradingSignal{
+					Symbol:     symbol,
+					Type:       trading.SignalBuy,
+					Price:      100 + float64(i),
+					Timestamp:  base.Add(time.Duration(i) * time.Hour),
+					ExecuteAt:  base.Add(24 * time.Hour),
+					Reason:     "test",
+					Confidence: 0.5,
+				}
+				if err := s.SaveSignal(sig); err != nil {
+					t.Fatalf("SaveSignal() error = %v", err)
+				}
+			}
+
+			signals, err := s.GetSignals(2)
+			if err != nil {
+				t.Fatalf("GetSignals() error = %v", err)
+			}
+			if len(signals) != 2 || signals[0].Symbol != "MSFT" || signals[1].Price != 102 {
+				t.Errorf("unexpected recent signals %+v", signals)
+			}
+			if !signals[1].Timestamp.Equal(base.Add(2 * time.Hour)) {
+				t.Errorf("timestamp not preserved: %v", signals[1].Timestamp)
+			}
+
+			bySymbol, err := s.GetSignalsBySymbol("AAPL", 0)
+			if err != nil || len(bySymbol) != 2 {
+				t.Errorf("GetSignalsBySymbol() = %d signals, err %v", len(bySymbol), err)
+			}
+
+			inRange, err := s.GetSignalsByTimeRange(base, base.Add(90*time.Minute))
+			if err != nil || len(inRange) != 1 || inRange[0].Symbol != "MSFT" {
+				t.Errorf("GetSignalsByTimeRange() = %+v, err %v", inRange, err)
+			}
+		})
+	}
+}
+
+func TestStorageOrdersAndFills(t *testing.T) {
+	for name, s := range newStorages(t) {
+		t.Run(name, func(t *testing.T) {
+			order := &broker.OrderResult{
+				ID:     "o1",
+				Order:  &trading.Order{Symbol: "TSLA", Side: trading.OrderBuy, Quantity: 10, Price: 200, StopLoss: 190},
+				Status: broker.StatusNew,
+			}
+			if err := s.SaveOrder(order); err != nil {
+				t.Fatalf("SaveOrder() error = %v", err)
+			}
+			if err := s.SaveOrder(&broker.OrderResult{ID: "o2", Order: &trading.Order{Symbol: "AAPL"}, Status: broker.StatusFilled}); err != nil {
+				t.Fatalf("SaveOrder() error = %v", err)
+			}
+
+			open, err := s.GetOpenOrders()
+			if err != nil || len(open) != 1 || open[0].ID != "o1" || open[0].Order.StopLoss != 190 {
+				t.Fatalf("GetOpenOrders() = %+v, err %v", open, err)
+			}
+
+			// Updating the same ID replaces the stored state
+			order.Status = broker.StatusFilled
+			order.FilledQty = 10
+			order.FilledPrice = 201
+			if err := s.SaveOrder(order); err != nil {
+				t.Fatalf("SaveOrder() error = %v", err)
+			}
+
+			open, _ = s.GetOpenOrders()
+			if len(open) != 0 {
+				t.Errorf("expected no open orders, got %d", len(open))
+			}
+			orders, _ := s.GetOrders(0)
+			if len(orders) != 2 || orders[0].FilledQty != 10 || orders[0].FilledPrice != 201 {
+				t.Errorf("unexpected orders %+v", orders)
+			}
+
+			for _, fill := range []*trading.Fill{
+				{OrderID: "o1", Symbol: "TSLA", Side: trading.OrderBuy, Quantity: 10, Price: 201},
+				{OrderID: "o2", Symbol: "AAPL", Side: trading.OrderBuy, Quantity: 5, Price: 150},
+			} {
+				if err := s.SaveFill(fill); err != nil {
+					t.Fatalf("SaveFill() error = %v", err)
+				}
+			}
+
+			fills, err := s.GetFills("TSLA", 0)
+			if err != nil || len(fills) != 1 || fills[0].Price != 201 {
+				t.Errorf("GetFills(TSLA) = %+v, err %v", fills, err)
+			}
+			fills, _ = s.GetFills("", 0)
+			if len(fills) != 2 {
+				t.Errorf("expected 2 fills, got %d", len(fills))
+			}
+		})
+	}
+}
+
+func TestSQLiteStoragePersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trading.db")
+
+	s, err := NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage() error = %v", err)
+	}
+	if err := s.SaveStockData(&trading.StockData{Symbol: "AMZN", Price: 180, Timestamp: time.Now()}); err != nil {
+		t.Fatalf("SaveStockData() error = %v", err)
+	}
+	s.Close()
+
+	s, err = NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer s.Close()
+
+	data, err := s.GetStockData("AMZN", 10)
+	if err != nil || len(data) != 1 || data[0].Price != 180 {
+		t.Errorf("GetStockData() = %+v, err %v", data, err)
+	}
+}
@@ -0,0 +1,90 @@
+package strategy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// BollingerStrategy implements Bollinger band mean-reversion strategy
+type BollingerStrategy struct {
+	name           string
+	period         int                             // moving average period
+	numStdDev      float64                         // band width in standard deviations
+	historicalData map[string][]*trading.StockData // historical data cache
+	maxHistorySize int
+}
+
+// NewBollingerStrategy creates a new Bollinger band strategy
+func NewBollingerStrategy(period int, numStdDev float64) *BollingerStrategy {
+	return &BollingerStrategy{
+		name:           fmt.Sprintf("Bollinger_%d_%.1f", period, numStdDev),
+		period:         period,
+		numStdDev:      numStdDev,
+		historicalData: make(map[string][]*trading.StockData),
+		maxHistorySize: period * 2,
+	}
+}
+
+// Name implements Strategy interface
+func (s *BollingerStrategy) Name() string {
+	return s.name
+}
+
+// Analyze implements Strategy interface
+func (s *BollingerStrategy) Analyze(data []*trading.StockData, positions map[string]*trading.Position) ([]*trading.TradingSignal, error) {
+	signals := make([]*trading.TradingSignal, 0)
+
+	for _, currentData := range data {
+		symbol := currentData.Symbol
+		history := appendHistory(s.historicalData, currentData, s.maxHistorySize)
+
+		// Need one extra point to detect the band cross
+		if len(history) < s.period+1 {
+			continue
+		}
+
+		middle, upper, lower := s.bands(history)
+		_, prevUpper, prevLower := s.bands(history[:len(history)-1])
+		price := currentData.Price
+		prevPrice := history[len(history)-2].Price
+		now := time.Now()
+
+		// Price closes below the lower band (buy signal)
+		if prevPrice >= prevLower && price < lower && !isHolding(positions, symbol) {
+			signals = append(signals, &trading.TradingSignal{
+				Symbol:     symbol,
+				Type:       trading.SignalBuy,
+				Price:      price,
+				Timestamp:  now,
+				ExecuteAt:  getNextTradingTime(now),
+				Reason:     fmt.Sprintf("Price %.2f below lower Bollinger band %.2f (middle %.2f)", price, lower, middle),
+				Confidence: clampConfidence(0.5 + (lower-price)/(middle-lower+1e-9)),
+			})
+			continue
+		}
+
+		// Price closes above the upper band (sell signal)
+		if prevPrice <= prevUpper && price > upper && isHolding(positions, symbol) {
+			signals = append(signals, &trading.TradingSignal{
+				Symbol:     symbol,
+				Type:       trading.SignalSell,
+				Price:      price,
+				Timestamp:  now,
+				ExecuteAt:  getNextTradingTime(now),
+				Reason:     fmt.Sprintf("Price %.2f above upper Bollinger band %.2f (middle %.2f)", price, upper, middle),
+				Confidence: clampConfidence(0.5 + (price-upper)/(upper-middle+1e-9)),
+			})
+		}
+	}
+
+	return signals, nil
+}
+
+// bands calculates the middle, upper and lower Bollinger bands
+func (s *BollingerStrategy) bands(history []*trading.StockData) (float64, float64, float64) {
+	middle := calculateMA(history, s.period)
+	stdDev := calculateStdDev(prices(history), s.period)
+	return middle, middle + s.numStdDev*stdDev, middle - s.numStdDev*stdDev
+}
@@ -0,0 +1,90 @@
+package strategy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// BreakoutStrategy implements volatility breakout strategy.
+// A breakout is a move from the previous price larger than multiplier times
+// the average high-low range over the lookback period.
+type BreakoutStrategy struct {
+	name           string
+	lookback       int                             // bars used to measure volatility
+	multiplier     float64                         // range multiplier required for a breakout
+	historicalData map[string][]*trading.StockData // historical data cache
+	maxHistorySize int
+}
+
+// NewBreakoutStrategy creates a new volatility breakout strategy
+func NewBreakoutStrategy(lookback int, multiplier float64) *BreakoutStrategy {
+	return &BreakoutStrategy{
+		name:           fmt.Sprintf("Breakout_%d_%.1f", lookback, multiplier),
+		lookback:       lookback,
+		multiplier:     multiplier,
+		historicalData: make(map[string][]*trading.StockData),
+		maxHistorySize: lookback * 2,
+	}
+}
+
+// Name implements Strategy interface
+func (s *BreakoutStrategy) Name() string {
+	return s.name
+}
+
+// Analyze implements Strategy interface
+func (s *BreakoutStrategy) Analyze(data []*trading.StockData, positions map[string]*trading.Position) ([]*trading.TradingSignal, error) {
+	signals := make([]*trading.TradingSignal, 0)
+
+	for _, currentData := range data {
+		symbol := currentData.Symbol
+		history := appendHistory(s.historicalData, currentData, s.maxHistorySize)
+
+		// Volatility is measured on the bars before the current one
+		if len(history) < s.lookback+1 {
+			continue
+		}
+
+		previous := history[:len(history)-1]
+		avgRange := calculateAverageRange(previous, s.lookback)
+		if avgRange <= 0 {
+			continue
+		}
+
+		prevPrice := previous[len(previous)-1].Price
+		move := currentData.Price - prevPrice
+		threshold := s.multiplier * avgRange
+		now := time.Now()
+
+		// Upside breakout (buy signal)
+		if move > threshold && !isHolding(positions, symbol) {
+			signals = append(signals, &trading.TradingSignal{
+				Symbol:     symbol,
+				Type:       trading.SignalBuy,
+				Price:      currentData.Price,
+				Timestamp:  now,
+				ExecuteAt:  getNextTradingTime(now),
+				Reason:     fmt.Sprintf("Upside breakout: move %.2f > %.1fx avg range %.2f", move, s.multiplier, avgRange),
+				Confidence: clampConfidence(move / threshold / 2),
+			})
+			continue
+		}
+
+		// Downside breakout (sell signal)
+		if -move > threshold && isHolding(positions, symbol) {
+			signals = append(signals, &trading.TradingSignal{
+				Symbol:     symbol,
+				Type:       trading.SignalSell,
+				Price:      currentData.Price,
+				Timestamp:  now,
+				ExecuteAt:  getNextTradingTime(now),
+				Reason:     fmt.Sprintf("Downside breakout: move %.2f < -%.1fx avg range %.2f", move, s.multiplier, avgRange),
+				Confidence: clampConfidence(-move / threshold / 2),
+			})
+		}
+	}
+
+	return signals, nil
+}
@@ -0,0 +1,109 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// paramSpec describes the parameters accepted by a strategy type
+type paramSpec struct {
+	defaults map[string]float64
+	build    func(p map[string]float64) Strategy
+}
+
+// registry maps strategy types to their parameter specs
+var registry = map[string]paramSpec{
+	"ma_cross": {
+		defaults: map[string]float64{"short": 5, "long": 20},
+		build: func(p map[string]float64) Strategy {
+			return NewMACrossStrategy(int(p["short"]), int(p["long"]))
+		},
+	},
+	"rsi": {
+		defaults: map[string]float64{"period": 14, "oversold": 30, "overbought": 70},
+		build: func(p map[string]float64) Strategy {
+			return NewRSIStrategy(int(p["period"]), p["oversold"], p["overbought"])
+		},
+	},
+	"macd": {
+		defaults: map[string]float64{"fast": 12, "slow": 26, "signal": 9},
+		build: func(p map[string]float64) Strategy {
+			return NewMACDStrategy(int(p["fast"]), int(p["slow"]), int(p["signal"]))
+		},
+	},
+	"bollinger": {
+		defaults: map[string]float64{"period": 20, "stddev": 2},
+		build: func(p map[string]float64) Strategy {
+			return NewBollingerStrategy(int(p["period"]), p["stddev"])
+		},
+	},
+	"breakout": {
+		defaults: map[string]float64{"lookback": 20, "multiplier": 1.5},
+		build: func(p map[string]float64) Strategy {
+			return NewBreakoutStrategy(int(p["lookback"]), p["multiplier"])
+		},
+	},
+}
+
+// Types returns the registered strategy types
+func Types() []string {
+	types := make([]string, 0, len(registry))
+	for t := range registry {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New creates a strategy by type. Missing parameters use defaults;
+// unknown or invalid parameters are rejected.
+func New(strategyType string, params map[string]float64) (Strategy, error) {
+	spec, ok := registry[strategyType]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy type %q (available: %s)", strategyType, strings.Join(Types(), ", "))
+	}
+
+	merged := make(map[string]float64, len(spec.defaults))
+	for k, v := range spec.defaults {
+		merged[k] = v
+	}
+	for k, v := range params {
+		if _, known := spec.defaults[k]; !known {
+			return nil, fmt.Errorf("strategy %s: unknown parameter %q", strategyType, k)
+		}
+		merged[k] = v
+	}
+
+	if err := validateParams(strategyType, merged); err != nil {
+		return nil, fmt.Errorf("strategy %s: %w", strategyType, err)
+	}
+
+	return spec.build(merged), nil
+}
+
+// validateParams checks parameter ranges and relationships
+func validateParams(strategyType string, p map[string]float64) error {
+	for k, v := range p {
+		if v <= 0 {
+			return fmt.Errorf("parameter %s must be positive, got %v", k, v)
+		}
+	}
+
+	switch strategyType {
+	case "ma_cross":
+		if p["short"] >= p["long"] {
+			return fmt.Errorf("short period (%v) must be less than long period (%v)", p["short"], p["long"])
+		}
+	case "rsi":
+		if p["oversold"] >= p["overbought"] || p["overbought"] >= 100 {
+			return fmt.Errorf("require 0 < oversold (%v) < overbought (%v) < 100", p["oversold"], p["overbought"])
+		}
+	case "macd":
+		if p["fast"] >= p["slow"] {
+			return fmt.Errorf("fast period (%v) must be less than slow period (%v)", p["fast"], p["slow"])
+		}
+	}
+
+	return nil
+}
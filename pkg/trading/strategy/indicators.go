@@ -0,0 +1,150 @@
+package strategy
+
+import (
+	"math"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// appendHistory appends data to the symbol's history and trims it to maxSize
+func appendHistory(history map[string][]*trading.StockData, data *trading.StockData, maxSize int) []*trading.StockData {
+	if _, exists := history[data.Symbol]; !exists {
+		history[data.Symbol] = make([]*trading.StockData, 0, maxSize)
+	}
+	history[data.Symbol] = append(history[data.Symbol], data)
+
+	// Keep only recent data
+	if len(history[data.Symbol]) > maxSize {
+		history[data.Symbol] = history[data.Symbol][len(history[data.Symbol])-maxSize:]
+	}
+
+	return history[data.Symbol]
+}
+
+// prices extracts prices from stock data
+func prices(data []*trading.StockData) []float64 {
+	result := make([]float64, len(data))
+	for i, d := range data {
+		result[i] = d.Price
+	}
+	return result
+}
+
+// calculateEMA calculates the exponential moving average series.
+// The first value is seeded with the simple average of the first period values,
+// so the returned slice has len(values)-period+1 entries.
+func calculateEMA(values []float64, period int) []float64 {
+	if period <= 0 || len(values) < period {
+		return nil
+	}
+
+	seed := 0.0
+	for i := 0; i < period; i++ {
+		seed += values[i]
+	}
+	seed /= float64(period)
+
+	k := 2.0 / float64(period+1)
+	result := make([]float64, 0, len(values)-period+1)
+	result = append(result, seed)
+	for i := period; i < len(values); i++ {
+		prev := result[len(result)-1]
+		result = append(result, values[i]*k+prev*(1-k))
+	}
+
+	return result
+}
+
+// calculateRSI calculates the relative strength index using Wilder's smoothing
+func calculateRSI(values []float64, period int) float64 {
+	if period <= 0 || len(values) < period+1 {
+		return 0
+	}
+
+	avgGain, avgLoss := 0.0, 0.0
+	for i := 1; i <= period; i++ {
+		change := values[i] - values[i-1]
+		if change > 0 {
+			avgGain += change
+		} else {
+			avgLoss -= change
+		}
+	}
+	avgGain /= float64(period)
+	avgLoss /= float64(period)
+
+	for i := period + 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+		gain, loss := 0.0, 0.0
+		if change > 0 {
+			gain = change
+		} else {
+			loss = -change
+		}
+		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+	}
+
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+
+	rs := avgGain / avgLoss
+	return 100 - 100/(1+rs)
+}
+
+// calculateStdDev calculates the population standard deviation of the last period values
+func calculateStdDev(values []float64, period int) float64 {
+	if period <= 0 || len(values) < period {
+		return 0
+	}
+
+	window := values[len(values)-period:]
+	mean := 0.0
+	for _, v := range window {
+		mean += v
+	}
+	mean /= float64(period)
+
+	variance := 0.0
+	for _, v := range window {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(period)
+
+	return math.Sqrt(variance)
+}
+
+// calculateAverageRange calculates the mean high-low range of the last period bars
+func calculateAverageRange(data []*trading.StockData, period int) float64 {
+	if period <= 0 || len(data) < period {
+		return 0
+	}
+
+	sum := 0.0
+	for _, d := range data[len(data)-period:] {
+		sum += d.High - d.Low
+	}
+
+	return sum / float64(period)
+}
+
+// clampConfidence keeps a confidence value within [0.3, 1.0]
+func clampConfidence(confidence float64) float64 {
+	if confidence > 1.0 {
+		return 1.0
+	}
+	if confidence < 0.3 {
+		return 0.3
+	}
+	return confidence
+}
+
+// isHolding reports whether there is an open position for symbol
+func isHolding(positions map[string]*trading.Position, symbol string) bool {
+	pos, exists := positions[symbol]
+	return exists && pos.Quantity > 0
+}
@@ -0,0 +1,108 @@
+package strategy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// MACDStrategy implements MACD signal line crossover strategy
+type MACDStrategy struct {
+	name           string
+	fastPeriod     int                             // fast EMA period
+	slowPeriod     int                             // slow EMA period
+	signalPeriod   int                             // signal line EMA period
+	historicalData map[string][]*trading.StockData // historical data cache
+	maxHistorySize int
+}
+
+// NewMACDStrategy creates a new MACD crossover strategy
+func NewMACDStrategy(fastPeriod, slowPeriod, signalPeriod int) *MACDStrategy {
+	return &MACDStrategy{
+		name:           fmt.Sprintf("MACD_%d_%d_%d", fastPeriod, slowPeriod, signalPeriod),
+		fastPeriod:     fastPeriod,
+		slowPeriod:     slowPeriod,
+		signalPeriod:   signalPeriod,
+		historicalData: make(map[string][]*trading.StockData),
+		maxHistorySize: (slowPeriod + signalPeriod) * 3,
+	}
+}
+
+// Name implements Strategy interface
+func (s *MACDStrategy) Name() string {
+	return s.name
+}
+
+// Analyze implements Strategy interface
+func (s *MACDStrategy) Analyze(data []*trading.StockData, positions map[string]*trading.Position) ([]*trading.TradingSignal, error) {
+	signals := make([]*trading.TradingSignal, 0)
+
+	for _, currentData := range data {
+		symbol := currentData.Symbol
+		history := appendHistory(s.historicalData, currentData, s.maxHistorySize)
+
+		macd, signalLine := s.calculateMACD(prices(history))
+		if len(signalLine) < 2 {
+			continue
+		}
+
+		cur := macd[len(macd)-1]
+		prev := macd[len(macd)-2]
+		curSignal := signalLine[len(signalLine)-1]
+		prevSignal := signalLine[len(signalLine)-2]
+		now := time.Now()
+
+		// MACD crosses above signal line (buy signal)
+		if prev <= prevSignal && cur > curSignal && !isHolding(positions, symbol) {
+			signals = append(signals, &trading.TradingSignal{
+				Symbol:     symbol,
+				Type:       trading.SignalBuy,
+				Price:      currentData.Price,
+				Timestamp:  now,
+				ExecuteAt:  getNextTradingTime(now),
+				Reason:     fmt.Sprintf("MACD bullish crossover: MACD(%.3f) > signal(%.3f)", cur, curSignal),
+				Confidence: clampConfidence((cur - curSignal) / currentData.Price * 100),
+			})
+			continue
+		}
+
+		// MACD crosses below signal line (sell signal)
+		if prev >= prevSignal && cur < curSignal && isHolding(positions, symbol) {
+			signals = append(signals, &trading.TradingSignal{
+				Symbol:     symbol,
+				Type:       trading.SignalSell,
+				Price:      currentData.Price,
+				Timestamp:  now,
+				ExecuteAt:  getNextTradingTime(now),
+				Reason:     fmt.Sprintf("MACD bearish crossover: MACD(%.3f) < signal(%.3f)", cur, curSignal),
+				Confidence: clampConfidence((curSignal - cur) / currentData.Price * 100),
+			})
+		}
+	}
+
+	return signals, nil
+}
+
+// calculateMACD returns the MACD line and its signal line, aligned at the end
+func (s *MACDStrategy) calculateMACD(values []float64) ([]float64, []float64) {
+	fast := calculateEMA(values, s.fastPeriod)
+	slow := calculateEMA(values, s.slowPeriod)
+	if slow == nil || fast == nil {
+		return nil, nil
+	}
+
+	// Align the fast EMA with the shorter slow EMA series
+	offset := len(fast) - len(slow)
+	macd := make([]float64, len(slow))
+	for i := range slow {
+		macd[i] = fast[i+offset] - slow[i]
+	}
+
+	signalLine := calculateEMA(macd, s.signalPeriod)
+	if signalLine == nil {
+		return nil, nil
+	}
+
+	return macd[len(macd)-len(signalLine):], signalLine
+}
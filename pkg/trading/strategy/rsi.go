@@ -0,0 +1,84 @@
+package strategy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// RSIStrategy implements RSI mean-reversion strategy
+type RSIStrategy struct {
+	name           string
+	period         int                             // RSI lookback period
+	oversold       float64                         // buy when RSI recovers above this level
+	overbought     float64                         // sell when RSI falls back below this level
+	historicalData map[string][]*trading.StockData // historical data cache
+	maxHistorySize int
+}
+
+// NewRSIStrategy creates a new RSI mean-reversion strategy
+func NewRSIStrategy(period int, oversold, overbought float64) *RSIStrategy {
+	return &RSIStrategy{
+		name:           fmt.Sprintf("RSI_%d_%.0f_%.0f", period, oversold, overbought),
+		period:         period,
+		oversold:       oversold,
+		overbought:     overbought,
+		historicalData: make(map[string][]*trading.StockData),
+		maxHistorySize: period * 4,
+	}
+}
+
+// Name implements Strategy interface
+func (s *RSIStrategy) Name() string {
+	return s.name
+}
+
+// Analyze implements Strategy interface
+func (s *RSIStrategy) Analyze(data []*trading.StockData, positions map[string]*trading.Position) ([]*trading.TradingSignal, error) {
+	signals := make([]*trading.TradingSignal, 0)
+
+	for _, currentData := range data {
+		symbol := currentData.Symbol
+		history := appendHistory(s.historicalData, currentData, s.maxHistorySize)
+
+		// Need one extra point to compare with the previous RSI
+		if len(history) < s.period+2 {
+			continue
+		}
+
+		values := prices(history)
+		rsi := calculateRSI(values, s.period)
+		prevRSI := calculateRSI(values[:len(values)-1], s.period)
+		now := time.Now()
+
+		// RSI leaves oversold territory (buy signal)
+		if prevRSI < s.oversold && rsi >= s.oversold && !isHolding(positions, symbol) {
+			signals = append(signals, &trading.TradingSignal{
+				Symbol:     symbol,
+				Type:       trading.SignalBuy,
+				Price:      currentData.Price,
+				Timestamp:  now,
+				ExecuteAt:  getNextTradingTime(now),
+				Reason:     fmt.Sprintf("RSI%d recovered from oversold: %.1f -> %.1f (threshold %.0f)", s.period, prevRSI, rsi, s.oversold),
+				Confidence: clampConfidence((s.oversold - prevRSI) / s.oversold * 3),
+			})
+			continue
+		}
+
+		// RSI leaves overbought territory (sell signal)
+		if prevRSI > s.overbought && rsi <= s.overbought && isHolding(positions, symbol) {
+			signals = append(signals, &trading.TradingSignal{
+				Symbol:     symbol,
+				Type:       trading.SignalSell,
+				Price:      currentData.Price,
+				Timestamp:  now,
+				ExecuteAt:  getNextTradingTime(now),
+				Reason:     fmt.Sprintf("RSI%d fell from overbought: %.1f -> %.1f (threshold %.0f)", s.period, prevRSI, rsi, s.overbought),
+				Confidence: clampConfidence((prevRSI - s.overbought) / (100 - s.overbought) * 3),
+			})
+		}
+	}
+
+	return signals, nil
+}
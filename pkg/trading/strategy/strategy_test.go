@@ -0,0 +1,183 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/trading"
+)
+
+// bars builds a price fixture with a fixed high-low range around each price
+func bars(symbol string, values ...float64) []*trading.StockData {
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	result := make([]*trading.StockData, len(values))
+	for i, v := range values {
+		result[i] = &trading.StockData{
+			Symbol:    symbol,
+			Price:     v,
+			Open:      v,
+			High:      v + 0.5,
+			Low:       v - 0.5,
+			Timestamp: start.Add(time.Duration(i) * 24 * time.Hour),
+		}
+	}
+	return result
+}
+
+// feed runs a strategy over the fixture one bar at a time and collects all signals
+func feed(t *testing.T, s Strategy, data []*trading.StockData, positions map[string]*trading.Position) []*trading.TradingSignal {
+	t.Helper()
+
+	var all []*trading.TradingSignal
+	for _, d := range data {
+		signals, err := s.Analyze([]*trading.StockData{d}, positions)
+		if err != nil {
+			t.Fatalf("Analyze() error = %v", err)
+		}
+		all = append(all, signals...)
+	}
+	return all
+}
+
+func holding(symbol string) map[string]*trading.Position {
+	return map[string]*trading.Position{
+		symbol: {Symbol: symbol, Quantity: 100, AvgPrice: 100},
+	}
+}
+
+func assertSignals(t *testing.T, signals []*trading.TradingSignal, want ...trading.SignalType) {
+	t.Helper()
+
+	if len(signals) != len(want) {
+		t.Fatalf("expected %d signals, got %d: %+v", len(want), len(signals), signals)
+	}
+	for i, sig := range signals {
+		if sig.Type != want[i] {
+			t.Errorf("signal %d: expected %s, got %s", i, want[i], sig.Type)
+		}
+		if sig.Confidence < 0.3 || sig.Confidence > 1.0 {
+			t.Errorf("signal %d: confidence %.2f out of range", i, sig.Confidence)
+		}
+	}
+}
+
+func TestCalculateEMA(t *testing.T) {
+	got := calculateEMA([]float64{1, 2, 3, 4, 5}, 3)
+	want := []float64{2, 3, 4}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Errorf("ema[%d]: expected %.4f, got %.4f", i, want[i], got[i])
+		}
+	}
+}
+
+func TestCalculateRSI(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   float64
+	}{
+		{"all gains", []float64{1, 2, 3, 4}, 100},
+		{"all losses", []float64{4, 3, 2, 1}, 0},
+		{"flat", []float64{5, 5, 5, 5}, 50},
+		// avgGain=(0*2+1)/3, avgLoss=(1*2)/3 -> RS=0.5
+		{"recovery", []float64{10, 9, 8, 7, 8}, 100 - 100/1.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateRSI(tt.values, 3)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("expected %.4f, got %.4f", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCalculateStdDev(t *testing.T) {
+	got := calculateStdDev([]float64{2, 4, 4, 4, 5, 5, 7, 9}, 8)
+	if math.Abs(got-2) > 1e-9 {
+		t.Errorf("expected 2, got %.4f", got)
+	}
+}
+
+func TestRSIStrategy(t *testing.T) {
+	s := NewRSIStrategy(3, 30, 70)
+	if s.Name() != "RSI_3_30_70" {
+		t.Errorf("unexpected name %s", s.Name())
+	}
+
+	signals := feed(t, s, bars("AAPL", 10, 9, 8, 7, 6, 7), nil)
+	assertSignals(t, signals, trading.SignalBuy)
+
+	// Overbought exit only fires when holding a position
+	s = NewRSIStrategy(3, 30, 70)
+	signals = feed(t, s, bars("AAPL", 6, 7, 8, 9, 10, 9), nil)
+	assertSignals(t, signals)
+
+	s = NewRSIStrategy(3, 30, 70)
+	signals = feed(t, s, bars("AAPL", 6, 7, 8, 9, 10, 9), holding("AAPL"))
+	assertSignals(t, signals, trading.SignalSell)
+}
+
+func TestMACDStrategy(t *testing.T) {
+	s := NewMACDStrategy(2, 4, 2)
+	if s.Name() != "MACD_2_4_2" {
+		t.Errorf("unexpected name %s", s.Name())
+	}
+
+	down := []float64{20, 19, 18, 17, 16, 15, 14}
+	up := []float64{15, 17, 19}
+
+	signals := feed(t, s, bars("MSFT", append(down, up...)...), nil)
+	assertSignals(t, signals, trading.SignalBuy)
+
+	s = NewMACDStrategy(2, 4, 2)
+	signals = feed(t, s, bars("MSFT", 10, 11, 12, 13, 14, 15, 16, 15, 13, 11), holding("MSFT"))
+	assertSignals(t, signals, trading.SignalSell)
+}
+
+func TestBollingerStrategy(t *testing.T) {
+	s := NewBollingerStrategy(10, 2)
+	if s.Name() != "Bollinger_10_2.0" {
+		t.Errorf("unexpected name %s", s.Name())
+	}
+
+	flat := []float64{100, 101, 100, 101, 100, 101, 100, 101, 100, 101}
+
+	signals := feed(t, s, bars("TSLA", append(flat, 90)...), nil)
+	assertSignals(t, signals, trading.SignalBuy)
+
+	s = NewBollingerStrategy(10, 2)
+	signals = feed(t, s, bars("TSLA", append(flat, 110)...), holding("TSLA"))
+	assertSignals(t, signals, trading.SignalSell)
+
+	// No signal while price stays inside the bands
+	s = NewBollingerStrategy(10, 2)
+	signals = feed(t, s, bars("TSLA", append(flat, 100)...), nil)
+	assertSignals(t, signals)
+}
+
+func TestBreakoutStrategy(t *testing.T) {
+	s := NewBreakoutStrategy(3, 1.5)
+	if s.Name() != "Breakout_3_1.5" {
+		t.Errorf("unexpected name %s", s.Name())
+	}
+
+	// Average range is 1.0, so a move above 1.5 is a breakout
+	signals := feed(t, s, bars("AMZN", 100, 100.5, 100, 101), nil)
+	assertSignals(t, signals)
+
+	s = NewBreakoutStrategy(3, 1.5)
+	signals = feed(t, s, bars("AMZN", 100, 100.5, 100, 102), nil)
+	assertSignals(t, signals, trading.SignalBuy)
+
+	s = NewBreakoutStrategy(3, 1.5)
+	signals = feed(t, s, bars("AMZN", 100, 100.5, 100, 98), holding("AMZN"))
+	assertSignals(t, signals, trading.SignalSell)
+}
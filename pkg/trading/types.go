@@ -42,3 +42,33 @@ type Position struct {
 	PnL          float64   // profit/loss
 	UpdatedAt    time.Time // last update time
 }
+
+// OrderSide represents the side of an order
+type OrderSide string
+
+const (
+	OrderBuy  OrderSide = "BUY"
+	OrderSell OrderSide = "SELL"
+)
+
+// Order represents an order derived from a trading signal
+type Order struct {
+	Symbol     string    // stock symbol
+	Side       OrderSide // buy or sell
+	Quantity   int       // number of shares
+	Price      float64   // limit/reference price
+	StopLoss   float64   // stop-loss price (0 if none)
+	TakeProfit float64   // take-profit price (0 if none)
+	Reason     string    // originating signal reason
+	CreatedAt  time.Time // order creation time
+}
+
+// Fill represents an executed (partial) order fill
+type Fill struct {
+	OrderID   string    // broker order ID
+	Symbol    string    // stock symbol
+	Side      OrderSide // buy or sell
+	Quantity  int       // filled shares
+	Price     float64   // fill price
+	Timestamp time.Time // fill time
+}
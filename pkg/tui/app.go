@@ -12,6 +12,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/xinguang/agentic-coder/pkg/editor"
 )
 
 // Styles
@@ -43,8 +44,55 @@ type (
 
 	// doneMsg signals completion
 	doneMsg struct{}
+
+	// questionMsg asks the user a question; the next input line is sent to reply
+	questionMsg struct {
+		prompt string
+		reply  chan<- string
+	}
+
+	// tokenMsg updates the token count display
+	tokenMsg struct {
+		count int
+	}
+
+	// inputMsg adds text, such as a voice transcript, to the input for
+	// review before it is sent
+	inputMsg struct {
+		text string
+	}
+
+	// editMsg opens text in the editor, to be sent when it is saved
+	editMsg struct {
+		text string
+	}
+
+	// editedMsg carries the text saved in the editor
+	editedMsg struct {
+		text string
+		err  error
+	}
+
+	// tabMsg carries a message from the run of a session tab, which is
+	// applied to that tab whether it is shown or not
+	tabMsg struct {
+		tab int
+		msg tea.Msg
+	}
 )
 
+// tabView holds the screen state of a session tab while another is shown
+type tabView struct {
+	content      strings.Builder
+	statusText   string
+	isWorking    bool
+	startTime    time.Time
+	tokenCount   int
+	pendingInput string
+	question     chan<- string
+	unread       bool // output arrived while hidden
+}
+
 // AppModel represents the TUI state
 type AppModel struct {
 	viewport    viewport.Model
@@ -63,9 +111,23 @@ type AppModel struct {
 	// Pending input queue
 	pendingInput string
 
-	// Callbacks
-	onSubmit func(input string)
-	onCancel func()
+	// Receives the answer to the question being asked, if any
+	question chan<- string
+
+	// Session tabs; the shown tab's state is in the fields above
+	tabs   []*tabView
+	active int
+
+	// Callbacks, told which tab the input is for
+	onSubmit func(tab int, input string)
+	onCancel func(tab int)
+	// onInterrupt stops just the running tool, reporting whether one was running
+	onInterrupt func(tab int) bool
+	// onVoice starts or stops voice input
+	onVoice func()
+
+	// Editor that composes prompts on Ctrl+G and /edit
+	editor editor.Editor
 }
 
 // NewAppModel creates a new TUI model
@@ -88,15 +150,31 @@ func NewAppModel() *AppModel {
 		textarea:   ta,
 		statusText: "Ready",
 		mdRenderer: renderer,
+		tabs:       []*tabView{{}},
 	}
 }
 
 // SetCallbacks sets the submit and cancel callbacks
-func (m *AppModel) SetCallbacks(onSubmit func(string), onCancel func()) {
+func (m *AppModel) SetCallbacks(onSubmit func(tab int, input string), onCancel func(tab int)) {
 	m.onSubmit = onSubmit
 	m.onCancel = onCancel
 }
 
+// SetInterrupt sets the callback that stops just the running tool on Ctrl+C
+func (m *AppModel) SetInterrupt(onInterrupt func(tab int) bool) {
+	m.onInterrupt = onInterrupt
+}
+
+// SetVoice sets the callback that starts or stops voice input on Ctrl+R
+func (m *AppModel) SetVoice(onVoice func()) {
+	m.onVoice = onVoice
+}
+
+// SetEditor sets the editor that Ctrl+G and /edit open the input in
+func (m *AppModel) SetEditor(ed editor.Editor) {
+	m.editor = ed
+}
+
 // Init implements tea.Model
 func (m *AppModel) Init() tea.Cmd {
 	return tea.Batch(textarea.Blink, m.tickCmd())
@@ -116,32 +194,47 @@ func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC:
+			if m.question != nil {
+				// Decline the question instead of stopping the run
+				m.AppendContent("\n")
+				m.answer("")
+				return m, nil
+			}
+			if m.isWorking && m.onInterrupt != nil && m.onInterrupt(m.active) {
+				m.AppendContent(fmt.Sprintf("\n%s[Tool interrupted; press Ctrl+C again or Esc to stop the run]%s\n", ansiDim, ansiReset))
+				return m, nil
+			}
 			if m.isWorking && m.onCancel != nil {
-				m.onCancel()
+				m.onCancel(m.active)
 				return m, nil
 			}
 			return m, tea.Quit
 
 		case tea.KeyEsc:
+			if m.question != nil {
+				// Decline the question instead of stopping the run
+				m.AppendContent("\n")
+				m.answer("")
+				return m, nil
+			}
 			if m.isWorking && m.onCancel != nil {
-				m.onCancel()
+				m.onCancel(m.active)
 			}
 			return m, nil
 
+		case tea.KeyCtrlR:
+			if m.onVoice != nil {
+				m.onVoice()
+			}
+			return m, nil
+
+		case tea.KeyCtrlG:
+			return m, m.editInput(m.textarea.Value())
+
 		case tea.KeyEnter:
 			input := strings.TrimSpace(m.textarea.Value())
-			if input != "" {
-				m.textarea.Reset()
-				if m.isWorking {
-					// Queue the input for later
-					m.pendingInput = input
-					m.AppendContent(fmt.Sprintf("\n%s[Queued: %s]%s\n", ansiDim, input, ansiReset))
-				} else {
-					if m.onSubmit != nil {
-						m.onSubmit(input)
-					}
-				}
-			}
+			m.textarea.Reset()
+			m.submit(input)
 			return m, nil
 		}
 
@@ -177,11 +270,7 @@ func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if os.Getenv("DEBUG") != "" {
 			fmt.Fprintf(os.Stderr, "[DEBUG] contentMsg received: %d bytes\n", len(msg.content))
 		}
-		if msg.isError {
-			m.content.WriteString(fmt.Sprintf("\n\033[31m%s\033[0m\n", msg.content))
-		} else {
-			m.content.WriteString(msg.content)
-		}
+		m.content.WriteString(msg.text())
 		m.viewport.SetContent(m.content.String())
 		m.viewport.GotoBottom()
 		return m, nil
@@ -194,6 +283,12 @@ func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case questionMsg:
+		m.answer("")
+		m.question = msg.reply
+		m.AppendContent(fmt.Sprintf("\n%s%s%s ", ansiYellow, msg.prompt, ansiReset))
+		return m, nil
+
 	case doneMsg:
 		m.isWorking = false
 		m.statusText = "Ready"
@@ -201,8 +296,45 @@ func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.pendingInput != "" && m.onSubmit != nil {
 			input := m.pendingInput
 			m.pendingInput = ""
-			m.onSubmit(input)
+			m.onSubmit(m.active, input)
+		}
+		return m, nil
+
+	case tokenMsg:
+		m.tokenCount = msg.count
+		return m, nil
+
+	case editMsg:
+		return m, m.editInput(msg.text)
+
+	case editedMsg:
+		if msg.err != nil {
+			m.AppendContent(fmt.Sprintf("%sEditor: %v%s\n\n", ansiRed, msg.err, ansiReset))
+			return m, nil
+		}
+		input := strings.TrimSpace(msg.text)
+		if input == "" {
+			m.AppendContent(fmt.Sprintf("%s[Editor closed without text; nothing sent]%s\n", ansiDim, ansiReset))
+			return m, nil
+		}
+		m.textarea.Reset()
+		m.submit(input)
+		return m, nil
+
+	case inputMsg:
+		value := m.textarea.Value()
+		if value != "" && !strings.HasSuffix(value, " ") {
+			value += " "
+		}
+		m.textarea.SetValue(value + msg.text)
+		m.textarea.CursorEnd()
+		return m, nil
+
+	case tabMsg:
+		if msg.tab == m.active {
+			return m.Update(msg.msg)
 		}
+		m.updateHidden(msg.tab, msg.msg)
 		return m, nil
 	}
 
@@ -218,6 +350,45 @@ func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// submit handles entered input: it answers the pending question, or is
+// sent, or queued while a run is working
+func (m *AppModel) submit(input string) {
+	if m.question != nil {
+		m.AppendContent(input + "\n")
+		m.answer(input)
+		return
+	}
+	if input == "" {
+		return
+	}
+	if m.isWorking && input != "/pause" && input != "/voice" && !strings.HasPrefix(input, "/tasks") && !strings.HasPrefix(input, "/edit") {
+		// Queue the input for later
+		m.pendingInput = input
+		m.AppendContent(fmt.Sprintf("\n%s[Queued: %s]%s\n", ansiDim, input, ansiReset))
+		return
+	}
+	if m.onSubmit != nil {
+		m.onSubmit(m.active, input)
+	}
+}
+
+// editInput opens text in the editor, suspending the TUI until the editor
+// exits; the saved text is then submitted
+func (m *AppModel) editInput(text string) tea.Cmd {
+	path, err := editor.TempFile(text, "prompt-*.md")
+	if err != nil {
+		return func() tea.Msg { return editedMsg{err: err} }
+	}
+	return tea.ExecProcess(m.editor.Cmd(path), func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return editedMsg{err: err}
+		}
+		data, err := os.ReadFile(path)
+		return editedMsg{text: string(data), err: err}
+	})
+}
+
 // View implements tea.Model
 func (m *AppModel) View() string {
 	if !m.ready {
@@ -244,6 +415,9 @@ func (m *AppModel) View() string {
 
 func (m *AppModel) buildStatusBar() string {
 	var parts []string
+	if len(m.tabs) > 1 {
+		parts = append(parts, m.tabBar())
+	}
 
 	if m.isWorking {
 		// Spinner + status text
@@ -271,6 +445,124 @@ func (m *AppModel) buildStatusBar() string {
 	return statusStyle.Width(m.width).Render(text)
 }
 
+// tabBar lists the tabs, marking the shown one and hidden ones that wait
+// for an answer (?) or have new output (•)
+func (m *AppModel) tabBar() string {
+	labels := make([]string, len(m.tabs))
+	for i, tab := range m.tabs {
+		label := fmt.Sprintf("%d", i+1)
+		switch {
+		case i == m.active:
+			label = "[" + label + "]"
+		case tab.question != nil:
+			label += "?"
+		case tab.unread:
+			label += "•"
+		}
+		labels[i] = label
+	}
+	return strings.Join(labels, " ")
+}
+
+// updateHidden applies a message from the run of a tab that is not shown
+func (m *AppModel) updateHidden(index int, msg tea.Msg) {
+	if index < 0 || index >= len(m.tabs) {
+		return
+	}
+	tab := m.tabs[index]
+	switch msg := msg.(type) {
+	case contentMsg:
+		tab.content.WriteString(msg.text())
+		tab.unread = true
+	case statusMsg:
+		tab.statusText = msg.text
+		tab.isWorking = msg.isWorking
+		if msg.isWorking {
+			tab.startTime = time.Now()
+		}
+	case tokenMsg:
+		tab.tokenCount = msg.count
+	case questionMsg:
+		if tab.question != nil {
+			tab.question <- ""
+		}
+		tab.question = msg.reply
+		tab.content.WriteString(fmt.Sprintf("\n%s%s%s ", ansiYellow, msg.prompt, ansiReset))
+		tab.unread = true
+	case doneMsg:
+		tab.isWorking = false
+		tab.statusText = "Ready"
+		if tab.pendingInput != "" && m.onSubmit != nil {
+			input := tab.pendingInput
+			tab.pendingInput = ""
+			m.onSubmit(index, input)
+		}
+	}
+}
+
+// AddTab adds an empty tab, returning its index
+func (m *AppModel) AddTab() int {
+	m.tabs = append(m.tabs, &tabView{statusText: "Ready"})
+	return len(m.tabs) - 1
+}
+
+// ShowTab shows the tab at index, keeping the state of the shown one
+func (m *AppModel) ShowTab(index int) {
+	if index == m.active || index < 0 || index >= len(m.tabs) {
+		return
+	}
+	cur := m.tabs[m.active]
+	cur.content.Reset()
+	cur.content.WriteString(m.content.String())
+	cur.statusText, cur.isWorking, cur.startTime = m.statusText, m.isWorking, m.startTime
+	cur.tokenCount, cur.pendingInput, cur.question = m.tokenCount, m.pendingInput, m.question
+
+	next := m.tabs[index]
+	m.content.Reset()
+	m.content.WriteString(next.content.String())
+	next.content.Reset()
+	m.statusText, m.isWorking, m.startTime = next.statusText, next.isWorking, next.startTime
+	m.tokenCount, m.pendingInput, m.question = next.tokenCount, next.pendingInput, next.question
+	next.question, next.unread = nil, false
+	m.active = index
+
+	m.viewport.SetContent(m.content.String())
+	m.viewport.GotoBottom()
+}
+
+// TabStatus describes the run state of the tab at index
+func (m *AppModel) TabStatus(index int) string {
+	status, working, question := m.statusText, m.isWorking, m.question
+	if index != m.active {
+		tab := m.tabs[index]
+		status, working, question = tab.statusText, tab.isWorking, tab.question
+	}
+	switch {
+	case question != nil:
+		return "waiting for an answer"
+	case working:
+		return strings.ToLower(status)
+	}
+	return "ready"
+}
+
+// text returns the content to show for the message
+func (msg contentMsg) text() string {
+	if msg.isError {
+		return fmt.Sprintf("\n\033[31m%s\033[0m\n", msg.content)
+	}
+	return msg.content
+}
+
+// answer replies to the question being asked, if any
+func (m *AppModel) answer(input string) {
+	if m.question == nil {
+		return
+	}
+	m.question <- input
+	m.question = nil
+}
+
 // AppendContent adds content to the viewport
 func (m *AppModel) AppendContent(content string) {
 	m.content.WriteString(content)
@@ -3,15 +3,20 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/xinguang/agentic-coder/pkg/engine"
+	"github.com/xinguang/agentic-coder/pkg/provider"
 	"github.com/xinguang/agentic-coder/pkg/tool"
+	"github.com/xinguang/agentic-coder/pkg/ui"
 )
 
 // Debug logging to stderr (won't interfere with TUI)
@@ -25,52 +30,96 @@ func debugLog(format string, args ...interface{}) {
 
 // AppRunner wraps the bubbletea app with engine integration
 type AppRunner struct {
-	engine  *engine.Engine
 	config  Config
 	model   *AppModel
 	program *tea.Program
 
+	// Session tabs, in the order of the model's tabs
+	tabs []*sessionTab
+	mu   sync.Mutex
+}
+
+// sessionTab is a session shown in one of the tabs, with its own engine
+// and token counts
+type sessionTab struct {
+	index  int
+	engine *engine.Engine
+	model  string
+	save   SaveSessionCallback
+
 	// State
 	ctx    context.Context
 	cancel context.CancelFunc
-	mu     sync.Mutex
 
 	// Token tracking
 	inputTokens  int
 	outputTokens int
 
 	// Tool tracking
-	toolCount    int
-	currentTool  string
+	toolCount   int
+	currentTool string
+
+	// Subagents started by the run, by task ID; guarded by the runner's mu
+	running  bool
+	subtasks map[string]*subtaskView
+}
+
+// subtaskView is the latest progress of a subagent
+type subtaskView struct {
+	event   *tool.SubtaskEvent
+	started time.Time
 }
 
 // NewAppRunner creates a new app runner
 func NewAppRunner(eng *engine.Engine, cfg Config) *AppRunner {
 	model := NewAppModel()
 	r := &AppRunner{
-		engine: eng,
 		config: cfg,
 		model:  model,
+		tabs:   []*sessionTab{{engine: eng, model: cfg.Model, save: cfg.OnSaveSession}},
 	}
 
 	// Set callbacks
 	model.SetCallbacks(r.handleSubmit, r.handleCancel)
+	model.SetInterrupt(func(tab int) bool {
+		return r.tabs[tab].engine.InterruptTool()
+	})
+	model.SetVoice(r.voice)
+	model.SetEditor(cfg.Editor)
 
 	return r
 }
 
+// current returns the tab being shown
+func (r *AppRunner) current() *sessionTab {
+	return r.tabs[r.model.active]
+}
+
+// send sends a message from the run of tab t, so it reaches t's view
+// even while another tab is shown
+func (r *AppRunner) send(t *sessionTab, msg tea.Msg) {
+	r.program.Send(tabMsg{tab: t.index, msg: msg})
+}
+
 // Run starts the TUI
 func (r *AppRunner) Run() error {
 	// Add welcome message
 	r.model.AppendContent(fmt.Sprintf("\n%sAgentic Coder v%s%s\n", ansiCyan, r.config.Version, ansiReset))
 	r.model.AppendContent(fmt.Sprintf("%s%s • %s%s\n\n", ansiDim, r.config.Model, r.config.CWD, ansiReset))
+	if paused := r.tabs[0].engine.Paused(); paused != nil {
+		r.model.AppendContent(fmt.Sprintf("A run was paused at %s; /continue resumes it\n\n", paused.At.Format("01/02 15:04")))
+	}
 
 	r.program = tea.NewProgram(r.model, tea.WithAltScreen())
 	_, err := r.program.Run()
+	if r.config.Voice != nil {
+		// Do not leave a recorder running after exiting mid-recording
+		r.config.Voice.Cancel()
+	}
 	return err
 }
 
-func (r *AppRunner) handleSubmit(input string) {
+func (r *AppRunner) handleSubmit(tab int, input string) {
 	// Handle commands
 	if strings.HasPrefix(input, "/") {
 		r.handleCommand(input)
@@ -78,51 +127,98 @@ func (r *AppRunner) handleSubmit(input string) {
 	}
 
 	// Run engine in goroutine
-	go r.runEngine(input)
+	go r.runEngine(r.tabs[tab], input)
 }
 
-func (r *AppRunner) handleCancel() {
+func (r *AppRunner) handleCancel(tab int) {
 	r.mu.Lock()
-	if r.cancel != nil {
-		r.cancel()
+	if t := r.tabs[tab]; t.cancel != nil {
+		t.cancel()
 	}
 	r.mu.Unlock()
 }
 
-func (r *AppRunner) runEngine(input string) {
+// ask shows a question in tab t and waits for the next input line there,
+// returning "" if the run is cancelled first
+func (r *AppRunner) ask(ctx context.Context, t *sessionTab, prompt string) string {
+	reply := make(chan string, 1)
+	r.send(t, questionMsg{prompt: prompt, reply: reply})
+	select {
+	case answer := <-reply:
+		return answer
+	case <-ctx.Done():
+		return ""
+	}
+}
+
+func (r *AppRunner) runEngine(t *sessionTab, input string) {
+	r.execute(t, input, func(ctx context.Context) error {
+		return t.engine.Run(ctx, input)
+	})
+}
+
+// execute shows input in tab t and runs its engine with run
+func (r *AppRunner) execute(t *sessionTab, input string, run func(ctx context.Context) error) {
 	r.mu.Lock()
-	r.ctx, r.cancel = context.WithCancel(context.Background())
-	ctx := r.ctx
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	ctx := t.ctx
+	t.running = true
 	r.mu.Unlock()
 
 	// Show user input
-	r.program.Send(contentMsg{content: fmt.Sprintf("\n%s> %s%s\n\n", ansiCyan, input, ansiReset)})
-	r.program.Send(statusMsg{text: "Thinking", isWorking: true})
+	r.send(t, contentMsg{content: fmt.Sprintf("\n%s> %s%s\n\n", ansiCyan, input, ansiReset)})
+	r.send(t, statusMsg{text: "Thinking", isWorking: true})
 
 	// Reset tool counter
-	r.toolCount = 0
-	r.currentTool = ""
+	t.toolCount = 0
+	t.currentTool = ""
 
 	var responseBuffer strings.Builder
 
+	// Streamed thinking shown so far
+	thinkingOpen := false
+	thinkingLen := 0
+
 	// Set up callbacks
-	r.engine.SetCallbacks(&engine.CallbackOptions{
+	t.engine.SetCallbacks(&engine.CallbackOptions{
 		OnText: func(text string) {
 			responseBuffer.WriteString(text)
-			r.program.Send(contentMsg{content: text})
-			r.program.Send(statusMsg{text: "Responding", isWorking: true})
+			r.send(t, contentMsg{content: text})
+			r.send(t, statusMsg{text: "Responding", isWorking: true})
 		},
 		OnThinking: func(text string) {
-			r.program.Send(statusMsg{text: "Thinking", isWorking: true})
-			r.program.Send(contentMsg{content: fmt.Sprintf("%s💭 %s%s", ansiDim, text, ansiReset)})
+			thinkingLen += len(text)
+			switch r.config.ThinkingDisplay {
+			case ui.ThinkingCollapse, ui.ThinkingHide:
+				r.send(t, statusMsg{text: "Thinking… " + ui.FormatTokens(thinkingLen/4) + " tokens", isWorking: true})
+			default:
+				r.send(t, statusMsg{text: "Thinking", isWorking: true})
+				prefix := ""
+				if !thinkingOpen {
+					prefix = "\n💭 "
+					thinkingOpen = true
+				}
+				r.send(t, contentMsg{content: fmt.Sprintf("%s%s%s%s", ansiDim, prefix, text, ansiReset)})
+			}
+		},
+		OnThinkingDone: func(thinking string) {
+			thinkingLen = 0
+			switch r.config.ThinkingDisplay {
+			case ui.ThinkingHide:
+			case ui.ThinkingCollapse:
+				r.send(t, contentMsg{content: fmt.Sprintf("%s💭 %s (/thinking to expand)%s\n", ansiDim, ui.ThinkingSummary(thinking), ansiReset)})
+			default:
+				thinkingOpen = false
+				r.send(t, contentMsg{content: "\n"})
+			}
 		},
 		OnToolUse: func(name string, params map[string]interface{}) {
-			r.toolCount++
-			r.currentTool = name
-			debugLog("OnToolUse: %s (#%d)", name, r.toolCount)
+			t.toolCount++
+			t.currentTool = name
+			debugLog("OnToolUse: %s (#%d)", name, t.toolCount)
 			// Send status and content updates
-			r.program.Send(statusMsg{text: fmt.Sprintf("Tool #%d: %s ⏳", r.toolCount, name), isWorking: true})
-			r.program.Send(contentMsg{content: r.formatToolUse(name, params)})
+			r.send(t, statusMsg{text: fmt.Sprintf("Tool #%d: %s ⏳", t.toolCount, name), isWorking: true})
+			r.send(t, contentMsg{content: r.formatToolUse(name, maskParams(params))})
 			// Force a small delay to allow UI to render
 			time.Sleep(10 * time.Millisecond)
 		},
@@ -132,27 +228,61 @@ func (r *AppRunner) runEngine(input string) {
 				status = "✗"
 			}
 			debugLog("OnToolResult: %s %s", name, status)
-			r.program.Send(statusMsg{text: fmt.Sprintf("Tool #%d: %s %s", r.toolCount, name, status), isWorking: true})
-			r.program.Send(contentMsg{content: r.formatToolResult(name, result)})
+			r.send(t, statusMsg{text: fmt.Sprintf("Tool #%d: %s %s", t.toolCount, name, status), isWorking: true})
+			r.send(t, contentMsg{content: r.formatToolResult(name, maskOutput(result))})
 			// Force a small delay to allow UI to render
 			time.Sleep(10 * time.Millisecond)
 		},
 		OnUsage: func(inputTokens, outputTokens int) {
-			r.inputTokens += inputTokens
-			r.outputTokens += outputTokens
-			r.model.SetTokenCount(r.inputTokens + r.outputTokens)
+			t.inputTokens += inputTokens
+			t.outputTokens += outputTokens
+			r.send(t, tokenMsg{count: t.inputTokens + t.outputTokens})
+		},
+		OnSubtask: func(event *tool.SubtaskEvent) {
+			r.subtask(t, event)
+		},
+		OnAttach: func(plan *engine.AttachmentPlan) {
+			r.send(t, contentMsg{content: fmt.Sprintf("%s📎 %s%s\n", ansiDim, plan, ansiReset)})
+		},
+		OnSources: func(citations []provider.Citation) {
+			r.send(t, contentMsg{content: fmt.Sprintf("\n%s%s%s\n", ansiDim, engine.FormatSources(citations), ansiReset)})
 		},
 		OnError: func(err error) {
-			r.program.Send(contentMsg{content: err.Error(), isError: true})
+			r.send(t, contentMsg{content: err.Error(), isError: true})
+		},
+		OnBudget: func(alert engine.BudgetAlert) {
+			r.send(t, contentMsg{content: fmt.Sprintf("\n%s⚠ %s%s\n", ansiYellow, alert, ansiReset)})
+		},
+		OnIteration: func(summary engine.IterationSummary) {
+			if r.config.Verbose {
+				r.send(t, contentMsg{content: fmt.Sprintf("%s↻ %s%s\n", ansiDim, summary, ansiReset)})
+			}
+		},
+		OnPathAccess: func(access engine.PathAccess) engine.PathDecision {
+			r.send(t, contentMsg{content: fmt.Sprintf("\n%s⚠ %s wants to access %s, outside the project%s\n", ansiYellow, access.Tool, access.Path, ansiReset)})
+			answer := r.ask(ctx, t, fmt.Sprintf("Allow? [y]es once, [a]lways for %s, [N]o:", access.Dir))
+			return engine.ParsePathDecision(answer)
+		},
+		OnDestructive: func(action engine.DestructiveAction) bool {
+			msg := fmt.Sprintf("\n%s⚠ The agent wants to run a command that %s:%s\n  %s\n", ansiYellow, action.Reason, ansiReset, action.Target)
+			for _, f := range action.Analysis {
+				msg += fmt.Sprintf("%s  • It %s%s\n", ansiYellow, f.Explanation, ansiReset)
+			}
+			if action.Verdict != "" {
+				msg += fmt.Sprintf("%sThe verifying model did not approve it: %s%s\n", ansiDim, action.Verdict, ansiReset)
+			}
+			r.send(t, contentMsg{content: msg})
+			answer := strings.ToLower(strings.TrimSpace(r.ask(ctx, t, "Run it? [y/N]:")))
+			return answer == "y" || answer == "yes"
 		},
 		// External tool callbacks (for Claude CLI executed tools)
 		OnExternalToolUse: func(name string, params map[string]interface{}) {
-			r.toolCount++
-			r.currentTool = name
-			debugLog("OnExternalToolUse: %s (#%d)", name, r.toolCount)
+			t.toolCount++
+			t.currentTool = name
+			debugLog("OnExternalToolUse: %s (#%d)", name, t.toolCount)
 			// Send status and content updates with "Claude Code" label
-			r.program.Send(statusMsg{text: fmt.Sprintf("[Claude Code] Tool #%d: %s ⏳", r.toolCount, name), isWorking: true})
-			r.program.Send(contentMsg{content: r.formatExternalToolUse(name, params)})
+			r.send(t, statusMsg{text: fmt.Sprintf("[Claude Code] Tool #%d: %s ⏳", t.toolCount, name), isWorking: true})
+			r.send(t, contentMsg{content: r.formatExternalToolUse(name, params)})
 			time.Sleep(10 * time.Millisecond)
 		},
 		OnExternalToolResult: func(name string, result *tool.Output) {
@@ -161,28 +291,136 @@ func (r *AppRunner) runEngine(input string) {
 				status = "✗"
 			}
 			debugLog("OnExternalToolResult: %s %s", name, status)
-			r.program.Send(statusMsg{text: fmt.Sprintf("[Claude Code] Tool #%d: %s %s", r.toolCount, name, status), isWorking: true})
-			r.program.Send(contentMsg{content: r.formatExternalToolResult(name, result)})
+			r.send(t, statusMsg{text: fmt.Sprintf("[Claude Code] Tool #%d: %s %s", t.toolCount, name, status), isWorking: true})
+			r.send(t, contentMsg{content: r.formatExternalToolResult(name, result)})
 			time.Sleep(10 * time.Millisecond)
 		},
 	})
 
 	// Run
-	if err := r.engine.Run(ctx, input); err != nil {
+	if err := run(ctx); errors.Is(err, engine.ErrPaused) {
+		r.send(t, contentMsg{content: fmt.Sprintf("\n%sPaused; /continue resumes the run, also after a restart%s\n", ansiDim, ansiReset)})
+	} else if err != nil {
 		if ctx.Err() == nil {
-			r.program.Send(contentMsg{content: err.Error(), isError: true})
+			if r.config.OnError != nil {
+				r.config.OnError(err)
+			}
+			r.send(t, contentMsg{content: err.Error(), isError: true})
 		}
 	}
 
-	r.program.Send(contentMsg{content: "\n"})
-	r.program.Send(doneMsg{})
+	r.mu.Lock()
+	t.running = false
+	r.mu.Unlock()
+	r.send(t, contentMsg{content: "\n"})
+	r.send(t, doneMsg{})
 
 	// Save session
-	if r.config.OnSaveSession != nil {
-		r.config.OnSaveSession()
+	if t.save != nil {
+		t.save()
 	}
 }
 
+// subtask shows the progress of a subagent of tab t nested under the Task
+// call that started it, and lists the running subagents with their token
+// counts in the status bar while the tab's run goes on
+func (r *AppRunner) subtask(t *sessionTab, event *tool.SubtaskEvent) {
+	r.mu.Lock()
+	if t.subtasks == nil {
+		t.subtasks = make(map[string]*subtaskView)
+	}
+	view := t.subtasks[event.ID]
+	if view == nil {
+		view = &subtaskView{started: time.Now()}
+		t.subtasks[event.ID] = view
+	}
+	view.event = event
+	elapsed := time.Since(view.started).Round(time.Second)
+	if event.Kind == tool.SubtaskFinished {
+		delete(t.subtasks, event.ID)
+	}
+	status := ""
+	if t.running {
+		status = t.subtaskStatus()
+	}
+	r.mu.Unlock()
+
+	if content := formatSubtaskEvent(event, elapsed); content != "" {
+		r.send(t, contentMsg{content: content})
+	}
+	if status != "" {
+		r.send(t, statusMsg{text: status, isWorking: true})
+	}
+}
+
+// subtaskStatus describes the running subagents for the status bar, or
+// returns "" when there are none; the caller holds the runner's mu
+func (t *sessionTab) subtaskStatus() string {
+	if len(t.subtasks) == 0 {
+		return ""
+	}
+	views := make([]*subtaskView, 0, len(t.subtasks))
+	for _, view := range t.subtasks {
+		views = append(views, view)
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].started.Before(views[j].started) })
+
+	parts := make([]string, len(views))
+	for i, view := range views {
+		ev := view.event
+		parts[i] = fmt.Sprintf("🤖 %s %s: %d tools, %s tokens", ev.Agent, ev.ID[:8], ev.ToolCalls,
+			ui.FormatTokens(ev.InputTokens+ev.OutputTokens))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatSubtaskEvent formats a subagent event as indented lines under the
+// Task call, or returns "" for events only shown in the status bar
+func formatSubtaskEvent(event *tool.SubtaskEvent, elapsed time.Duration) string {
+	switch event.Kind {
+	case tool.SubtaskStarted:
+		return fmt.Sprintf("   %s┌ 🤖 %s %s: %s%s\n", ansiDim, event.Agent, event.ID[:8], event.Description, ansiReset)
+	case tool.SubtaskToolUse:
+		return fmt.Sprintf("   %s│%s %s %s%s%s\n", ansiDim, ansiReset, event.Tool, ansiDim, subtaskTarget(event.Input), ansiReset)
+	case tool.SubtaskToolResult:
+		if event.Result == nil || !event.Result.IsError {
+			return ""
+		}
+		line := strings.SplitN(strings.TrimSpace(event.Result.Content), "\n", 2)[0]
+		if len(line) > 80 {
+			line = line[:80] + "..."
+		}
+		return fmt.Sprintf("   %s│%s   %s✗ %s%s\n", ansiDim, ansiReset, ansiRed, line, ansiReset)
+	case tool.SubtaskFinished:
+		mark, color := "✓", ansiGreen
+		if event.Status != "completed" {
+			mark, color = "✗", ansiRed
+		}
+		summary := fmt.Sprintf("%s in %s, %d tools, %s tokens", event.Status, elapsed, event.ToolCalls,
+			ui.FormatTokens(event.InputTokens+event.OutputTokens))
+		if event.Error != "" {
+			summary += ": " + event.Error
+		}
+		return fmt.Sprintf("   %s└%s %s%s %s%s\n", ansiDim, ansiReset, color, mark, summary, ansiReset)
+	}
+	return ""
+}
+
+// subtaskTarget returns what a subagent's tool call works on, such as a
+// file or command
+func subtaskTarget(params map[string]interface{}) string {
+	for _, key := range []string{"file_path", "command", "pattern", "path", "url", "query"} {
+		if v, ok := params[key].(string); ok && v != "" {
+			v = strings.SplitN(v, "\n", 2)[0]
+			if len(v) > 70 {
+				v = v[:70] + "..."
+			}
+			return v
+		}
+	}
+	return ""
+}
+
 func (r *AppRunner) formatToolUse(name string, params map[string]interface{}) string {
 	var sb strings.Builder
 
@@ -527,6 +765,9 @@ func (r *AppRunner) handleCommand(input string) {
 	}
 
 	cmd := strings.ToLower(parts[0])
+	if r.config.OnCommand != nil {
+		r.config.OnCommand(cmd)
+	}
 
 	switch cmd {
 	case "/help", "/h", "/?":
@@ -539,12 +780,212 @@ func (r *AppRunner) handleCommand(input string) {
 	case "/exit", "/quit", "/q":
 		r.program.Quit()
 
+	case "/output-style":
+		if r.config.OnOutputStyle == nil {
+			r.program.Send(contentMsg{content: "Output styles are not available\n\n"})
+			return
+		}
+		name := ""
+		if len(parts) > 1 {
+			name = parts[1]
+		}
+		msg, err := r.config.OnOutputStyle(name)
+		if err != nil {
+			r.program.Send(contentMsg{content: fmt.Sprintf("%s%v%s\n\n", ansiRed, err, ansiReset)})
+			return
+		}
+		r.program.Send(contentMsg{content: msg + "\n"})
+
+	case "/tag":
+		if r.config.OnTag == nil {
+			r.program.Send(contentMsg{content: "Session tags are not available\n\n"})
+			return
+		}
+		msg, err := r.config.OnTag(parts[1:])
+		if err != nil {
+			r.program.Send(contentMsg{content: fmt.Sprintf("%s%v%s\n\n", ansiRed, err, ansiReset)})
+			return
+		}
+		r.program.Send(contentMsg{content: msg + "\n\n"})
+
+	case "/pin", "/unpin":
+		if r.config.OnPin == nil {
+			r.program.Send(contentMsg{content: "Pinning is not available\n\n"})
+			return
+		}
+		msg, err := r.config.OnPin(parts[1:], cmd == "/unpin")
+		if err != nil {
+			r.program.Send(contentMsg{content: fmt.Sprintf("%s%v%s\n\n", ansiRed, err, ansiReset)})
+			return
+		}
+		r.program.Send(contentMsg{content: msg + "\n\n"})
+
+	case "/diff-context":
+		if r.config.OnDiffContext == nil {
+			r.program.Send(contentMsg{content: "Diff context is not available\n\n"})
+			return
+		}
+		msg, err := r.config.OnDiffContext(parts[1:])
+		if err != nil {
+			r.program.Send(contentMsg{content: fmt.Sprintf("%s%v%s\n\n", ansiRed, err, ansiReset)})
+			return
+		}
+		r.program.Send(contentMsg{content: msg + "\n\n"})
+
+	case "/rewind":
+		if r.config.OnRewind == nil {
+			r.program.Send(contentMsg{content: "Rewind is not available\n\n"})
+			return
+		}
+		msg, err := r.config.OnRewind(parts[1:])
+		if err != nil {
+			r.program.Send(contentMsg{content: fmt.Sprintf("%s%v%s\n\n", ansiRed, err, ansiReset)})
+			return
+		}
+		r.program.Send(contentMsg{content: msg + "\n\n"})
+
+	case "/tools":
+		if r.config.OnTools == nil {
+			r.program.Send(contentMsg{content: "Tool management is not available\n\n"})
+			return
+		}
+		msg, err := r.config.OnTools(parts[1:])
+		if err != nil {
+			r.program.Send(contentMsg{content: fmt.Sprintf("%s%v%s\n\n", ansiRed, err, ansiReset)})
+			return
+		}
+		r.program.Send(contentMsg{content: msg + "\n\n"})
+
+	case "/cover":
+		target := ""
+		if len(parts) > 1 {
+			target = parts[1]
+		}
+		go r.runEngine(r.current(), engine.CoverageRequest(target))
+
 	case "/cost":
-		cost := float64(r.inputTokens)*0.000003 + float64(r.outputTokens)*0.000015
-		r.program.Send(contentMsg{content: fmt.Sprintf(
-			"\nInput tokens:  %d\nOutput tokens: %d\nTotal cost:    $%.4f\n\n",
-			r.inputTokens, r.outputTokens, cost,
-		)})
+		t := r.current()
+		cost := float64(t.inputTokens)*0.000003 + float64(t.outputTokens)*0.000015
+		msg := fmt.Sprintf("\nInput tokens:  %d\nOutput tokens: %d\nTotal cost:    $%.4f\n", t.inputTokens, t.outputTokens, cost)
+		if speed := t.engine.LastSpeed().String(); speed != "" {
+			msg += fmt.Sprintf("Last call:     %s\n", speed)
+		}
+		if r.config.OnSpeed != nil {
+			msg += "\nModel speed (latest calls, last 30 days):\n" + r.config.OnSpeed()
+		}
+		r.program.Send(contentMsg{content: msg + "\n"})
+
+	case "/thinking":
+		thinking := r.current().engine.LastThinking()
+		if thinking == "" {
+			r.program.Send(contentMsg{content: "No thinking yet\n\n"})
+			return
+		}
+		r.program.Send(contentMsg{content: fmt.Sprintf("%s💭 %s%s\n\n", ansiDim, thinking, ansiReset)})
+
+	case "/limits":
+		if r.config.OnLimits == nil {
+			r.program.Send(contentMsg{content: "Rate limits are not available\n\n"})
+			return
+		}
+		r.program.Send(contentMsg{content: r.config.OnLimits() + "\n"})
+
+	case "/env":
+		if r.config.OnEnv == nil {
+			r.program.Send(contentMsg{content: "The command environment is not available\n\n"})
+			return
+		}
+		r.program.Send(contentMsg{content: r.config.OnEnv() + "\n"})
+
+	case "/model":
+		if len(parts) < 2 {
+			r.program.Send(contentMsg{content: fmt.Sprintf("Current model: %s\n\n", r.current().model)})
+			return
+		}
+		if r.config.OnModel == nil {
+			r.program.Send(contentMsg{content: "Model switching is not available\n\n"})
+			return
+		}
+		model, err := r.config.OnModel(parts[1])
+		if err != nil {
+			r.program.Send(contentMsg{content: fmt.Sprintf("%s%v%s\n\n", ansiRed, err, ansiReset)})
+			return
+		}
+		r.current().model = model
+		r.program.Send(contentMsg{content: fmt.Sprintf("%sModel changed to: %s%s\n\n", ansiGreen, model, ansiReset)})
+
+	case "/tab":
+		r.tab(parts[1:])
+
+	case "/pause":
+		if !r.model.isWorking {
+			r.model.AppendContent("Nothing is running\n\n")
+			return
+		}
+		r.current().engine.Pause()
+		r.model.AppendContent(fmt.Sprintf("\n%s[Pausing after the current tool]%s\n", ansiDim, ansiReset))
+
+	case "/continue":
+		t := r.current()
+		if r.model.isWorking || t.engine.Paused() == nil {
+			r.model.AppendContent("No paused run to continue\n\n")
+			return
+		}
+		go r.execute(t, input, t.engine.Resume)
+
+	case "/edit":
+		// handleCommand runs in Update, which must return before the
+		// editor can take over the terminal
+		text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(input), parts[0]))
+		go r.program.Send(editMsg{text: text})
+
+	case "/voice":
+		r.voice()
+
+	case "/refactor":
+		if r.config.OnRefactor == nil {
+			r.program.Send(contentMsg{content: "Refactoring is not available\n\n"})
+			return
+		}
+		go r.refactor(r.current(), parts[1:])
+
+	case "/ps":
+		if r.config.OnProcesses == nil {
+			r.program.Send(contentMsg{content: "Process listing is not available\n\n"})
+			return
+		}
+		msg, err := r.config.OnProcesses(parts[1:])
+		if err != nil {
+			r.program.Send(contentMsg{content: fmt.Sprintf("%s%v%s\n\n", ansiRed, err, ansiReset)})
+			return
+		}
+		r.program.Send(contentMsg{content: msg + "\n"})
+
+	case "/tasks":
+		if r.config.OnTasks == nil {
+			r.program.Send(contentMsg{content: "Subagent tasks are not available\n\n"})
+			return
+		}
+		msg, err := r.config.OnTasks(parts[1:])
+		if err != nil {
+			r.program.Send(contentMsg{content: fmt.Sprintf("%s%v%s\n\n", ansiRed, err, ansiReset)})
+			return
+		}
+		r.program.Send(contentMsg{content: msg + "\n"})
+
+	case "/budget":
+		eng := r.current().engine
+		status, ok := eng.BudgetStatus()
+		if !ok {
+			r.program.Send(contentMsg{content: "No monthly budget configured (set monthly_budget_usd)\n\n"})
+			return
+		}
+		if len(parts) > 1 && parts[1] == "override" {
+			eng.OverrideBudget()
+			r.program.Send(contentMsg{content: "Budget limit overridden for the rest of the month\n\n"})
+			return
+		}
+		r.program.Send(contentMsg{content: status.String() + "\n\n"})
 
 	default:
 		r.program.Send(contentMsg{content: fmt.Sprintf(
@@ -554,6 +995,103 @@ func (r *AppRunner) handleCommand(input string) {
 	}
 }
 
+// refactor runs "/refactor" for tab t, asking before the change is applied
+func (r *AppRunner) refactor(t *sessionTab, args []string) {
+	ctx := context.Background()
+	msg, err := r.config.OnRefactor(ctx, args, func(preview string) bool {
+		r.send(t, contentMsg{content: preview})
+		answer := strings.ToLower(strings.TrimSpace(r.ask(ctx, t, "Apply this refactor? [y/N]:")))
+		return answer == "y" || answer == "yes"
+	})
+	if err != nil {
+		r.send(t, contentMsg{content: fmt.Sprintf("%s%v%s\n\n", ansiRed, err, ansiReset)})
+		return
+	}
+	r.send(t, contentMsg{content: msg + "\n\n"})
+}
+
+// tab lists, opens, or shows session tabs for "/tab". It runs within the
+// model's Update, so it changes the model directly.
+func (r *AppRunner) tab(args []string) {
+	if len(args) == 0 {
+		var sb strings.Builder
+		for i, t := range r.tabs {
+			marker := " "
+			if i == r.model.active {
+				marker = "*"
+			}
+			sb.WriteString(fmt.Sprintf("%s %d  %s  %s tokens  %s\n", marker, i+1, t.model,
+				ui.FormatTokens(t.inputTokens+t.outputTokens), r.model.TabStatus(i)))
+		}
+		sb.WriteString(fmt.Sprintf("%s/tab new opens a tab, /tab <n> shows one%s\n\n", ansiDim, ansiReset))
+		r.model.AppendContent(sb.String())
+		return
+	}
+
+	if args[0] == "new" {
+		if r.config.OnNewTab == nil {
+			r.model.AppendContent("Tabs are not available\n\n")
+			return
+		}
+		eng, model, save, err := r.config.OnNewTab()
+		if err != nil {
+			r.model.AppendContent(fmt.Sprintf("%s%v%s\n\n", ansiRed, err, ansiReset))
+			return
+		}
+		t := &sessionTab{index: r.model.AddTab(), engine: eng, model: model, save: save}
+		r.tabs = append(r.tabs, t)
+		r.showTab(t.index)
+		r.model.AppendContent(fmt.Sprintf("\n%sTab %d%s\n", ansiCyan, t.index+1, ansiReset))
+		r.model.AppendContent(fmt.Sprintf("%s%s • %s%s\n\n", ansiDim, model, r.config.CWD, ansiReset))
+		return
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > len(r.tabs) {
+		r.model.AppendContent(fmt.Sprintf("%sNo tab %s; /tab lists the tabs%s\n\n", ansiRed, args[0], ansiReset))
+		return
+	}
+	r.showTab(n - 1)
+}
+
+// showTab shows the tab at index and points the session callbacks at it
+func (r *AppRunner) showTab(index int) {
+	if r.config.OnSwitchTab != nil {
+		r.config.OnSwitchTab(r.tabs[index].engine)
+	}
+	r.model.ShowTab(index)
+}
+
+// voice starts recording, or stops it and puts the transcript in the input
+// for review. It runs in Update, so it writes to the model directly; only
+// the transcription, which runs in the background, sends messages.
+func (r *AppRunner) voice() {
+	in := r.config.Voice
+	if in == nil {
+		r.model.AppendContent("Voice input is not available\n\n")
+		return
+	}
+	if !in.Recording() {
+		if err := in.Start(); err != nil {
+			r.model.AppendContent(fmt.Sprintf("%sVoice input: %v%s\n\n", ansiRed, err, ansiReset))
+			return
+		}
+		r.model.AppendContent(fmt.Sprintf("\n%s[Recording; press Ctrl+R or enter /voice to stop]%s\n", ansiYellow, ansiReset))
+		return
+	}
+
+	r.model.AppendContent(fmt.Sprintf("%s[Transcribing...]%s\n", ansiDim, ansiReset))
+	go func() {
+		text, err := in.Stop(context.Background())
+		if err != nil {
+			r.program.Send(contentMsg{content: fmt.Sprintf("%sVoice input: %v%s\n\n", ansiRed, err, ansiReset)})
+			return
+		}
+		r.program.Send(contentMsg{content: fmt.Sprintf("%s[Transcript is in the input; edit it and press Enter to send]%s\n\n", ansiDim, ansiReset)})
+		r.program.Send(inputMsg{text: text})
+	}()
+}
+
 func (r *AppRunner) helpText() string {
 	return fmt.Sprintf(`
 %sCommands%s
@@ -561,10 +1099,31 @@ func (r *AppRunner) helpText() string {
   /clear         Clear screen
   /exit          Exit
   /cost          Show token usage and cost
+  /budget        Show or override the monthly budget
+  /model         Show or change the model
+  /limits        Show provider rate limits
+  /ps            List agent processes and shells (/ps kill <pid|shell>)
+  /tasks         List subagents (/tasks cancel <id> stops one, also mid-run)
+  /thinking      Show the latest thinking in full
+  /output-style  Show or set the output style
+  /tag           List, add, or remove session tags
+  /pin           Keep a file's current content in view (/unpin removes)
+  /diff-context  Share the current branch's changes with the next prompt
+  /rewind        Rewind the conversation to an earlier prompt
+  /pause         Pause the run after the current tool
+  /continue      Resume a paused run, also after a restart
+  /tab           List session tabs (/tab new opens one, /tab 2 shows it)
+  /tools         List, enable, or disable tools
+  /refactor      Rename a symbol across the workspace
+  /cover         Write tests for uncovered code
+  /voice         Record speech and put the transcript in the input
+  /edit          Compose a prompt in your editor; it is sent when saved
 
 %sShortcuts%s
-  Ctrl+C         Cancel current operation / Exit
+  Ctrl+C         Interrupt running tool / Cancel current operation / Exit
   Esc            Cancel current operation
+  Ctrl+R         Start or stop voice input
+  Ctrl+G         Open the input in your editor; it is sent when saved
 
 `, ansiCyan, ansiReset, ansiCyan, ansiReset)
 }
@@ -0,0 +1,40 @@
+package tui
+
+import (
+	"github.com/xinguang/agentic-coder/pkg/secret"
+	"github.com/xinguang/agentic-coder/pkg/tool"
+)
+
+// maskParams returns a copy of tool parameters for display, with secret
+// values masked
+func maskParams(params map[string]interface{}) map[string]interface{} {
+	masked := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		masked[k] = maskValue(v)
+	}
+	return masked
+}
+
+func maskValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return secret.MaskText(v)
+	case map[string]interface{}:
+		return maskParams(v)
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = maskValue(item)
+		}
+		return masked
+	}
+	return v
+}
+
+// maskOutput returns a copy of a tool result for display, with secret
+// values masked
+func maskOutput(result *tool.Output) *tool.Output {
+	masked := *result
+	masked.Content = secret.MaskText(result.Content)
+	return &masked
+}
@@ -94,9 +94,10 @@ func (r *Runner) handleSubmit(input string, opID uint64) tea.Cmd {
 				r.sendMsg(StreamThinkingMsg{Text: text})
 			},
 			OnToolUse: func(name string, params map[string]interface{}) {
-				r.sendMsg(ToolUseMsg{Name: name, Params: params})
+				r.sendMsg(ToolUseMsg{Name: name, Params: maskParams(params)})
 			},
 			OnToolResult: func(name string, result *tool.Output) {
+				result = maskOutput(result)
 				success := !result.IsError
 				summary := ""
 				if result.IsError {
@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -115,7 +116,8 @@ type SimpleRunner struct {
 	// Incremental and pipeline review
 	incrementalReviewer *review.IncrementalReviewer
 	pipeline            *review.Pipeline
-	lastResponse        string // For incremental review comparison
+	lastResponse        string   // For incremental review comparison
+	reviewFiles         []string // Files changed since the reviewed request
 
 	// Token tracking
 	inputTokens  int
@@ -173,6 +175,29 @@ func (r *SimpleRunner) SetReviewPipeline(pipeline *review.Pipeline) {
 	r.pipeline = pipeline
 }
 
+// EnableStaticAnalysis adds golangci-lint and semgrep stages to the review
+// pipeline. They check the files changed since the reviewed request,
+// including those changed by auto-corrections.
+func (r *SimpleRunner) EnableStaticAnalysis(semgrepConfig string) {
+	if r.pipeline == nil {
+		r.pipeline = review.CreateDefaultPipeline(r.reviewer, false)
+	}
+	files := func() []string { return r.reviewFiles }
+	r.pipeline.AddStage(review.CreateStaticAnalysisStage(review.GolangciLint(), r.config.CWD, files))
+	r.pipeline.AddStage(review.CreateStaticAnalysisStage(review.Semgrep(semgrepConfig), r.config.CWD, files))
+}
+
+// EnableTestGate adds a stage to the review pipeline that runs the tests
+// with runner, such as the Test tool, after a request changed files. Test
+// failures fail the review so auto-correction fixes them.
+func (r *SimpleRunner) EnableTestGate(runner tool.Tool) {
+	if r.pipeline == nil {
+		r.pipeline = review.CreateDefaultPipeline(r.reviewer, false)
+	}
+	files := func() []string { return r.reviewFiles }
+	r.pipeline.AddStage(review.CreateTestGateStage(runner, r.config.CWD, files))
+}
+
 // Run starts the simple TUI
 func (r *SimpleRunner) Run() error {
 	r.printWelcome()
@@ -309,11 +334,12 @@ func (r *SimpleRunner) runEngine(input string) string {
 			r.spinner.Stop()
 			// Flush any pending text before showing tool use
 			r.flushMarkdown(&textBuffer)
-			r.printToolUse(name, params)
+			r.printToolUse(name, maskParams(params))
 			// Record tool use in full response
 			fullResponse.WriteString(fmt.Sprintf("\n[Tool: %s]\n", name))
 		},
 		OnToolResult: func(name string, result *tool.Output) {
+			result = maskOutput(result)
 			success := !result.IsError
 			summary := ""
 			if result.IsError {
@@ -399,22 +425,36 @@ func (r *SimpleRunner) runReviewCycle(originalRequest, response string) {
 
 	currentResponse := response
 	totalReviewTokens := 0
+	r.reviewFiles = nil
+	startTokens := r.inputTokens + r.outputTokens
 
-	// Check if incremental review is applicable
-	if r.incrementalReviewer != nil && r.lastResponse != "" {
-		incResult, err := r.incrementalReviewer.ReviewChanges(r.lastResponse, currentResponse)
-		if err == nil && !incResult.NeedsReview() {
-			fmt.Fprintf(os.Stdout, "\n%s✓ No significant changes detected, skipping review%s\n\n", ansiGreen, ansiReset)
-			r.lastResponse = currentResponse
-			return
+	for cycle := 1; cycle <= maxCycles; cycle++ {
+		// Files changed by the request or the last correction
+		for _, path := range r.engine.ChangedFiles() {
+			if !slices.Contains(r.reviewFiles, path) {
+				r.reviewFiles = append(r.reviewFiles, path)
+			}
 		}
-		if err == nil && incResult.ChangedBlocks < incResult.TotalBlocks {
-			fmt.Fprintf(os.Stdout, "\n%s📊 Incremental review: %d/%d blocks changed%s\n",
-				ansiDim, incResult.ChangedBlocks, incResult.TotalBlocks, ansiReset)
+
+		// With incremental review, review the diffs of the changed files
+		// instead of the response's code blocks
+		reviewed := currentResponse
+		var incResult *review.IncrementalResult
+		if changes := r.turnChanges(); r.incrementalReviewer != nil && len(changes) > 0 {
+			res, err := r.incrementalReviewer.ReviewChanges(r.config.CWD, changes)
+			if err == nil && !res.NeedsReview() {
+				fmt.Fprintf(os.Stdout, "\n%s✓ No file changes need review, skipping review%s\n\n", ansiGreen, ansiReset)
+				r.lastResponse = currentResponse
+				return
+			}
+			if err == nil {
+				incResult = res
+				reviewed = currentResponse + "\n\n## File changes\n\n" + res.Text()
+				fmt.Fprintf(os.Stdout, "\n%s📊 Incremental review: %d/%d files, %d changed lines%s\n",
+					ansiDim, res.ChangedFiles, res.TotalFiles, res.ChangedLines(), ansiReset)
+			}
 		}
-	}
 
-	for cycle := 1; cycle <= maxCycles; cycle++ {
 		// Show review spinner
 		fmt.Println() // New line before spinner
 		r.spinner.Start(fmt.Sprintf("Reviewing... (cycle %d/%d)", cycle, maxCycles))
@@ -427,7 +467,7 @@ func (r *SimpleRunner) runReviewCycle(originalRequest, response string) {
 
 		if r.pipeline != nil {
 			// Use pipeline-based review
-			pipelineResult, pErr := r.pipeline.Run(ctx, currentResponse)
+			pipelineResult, pErr := r.pipeline.Run(ctx, reviewed)
 			if pErr != nil {
 				err = pErr
 			} else {
@@ -440,7 +480,7 @@ func (r *SimpleRunner) runReviewCycle(originalRequest, response string) {
 			}
 		} else {
 			// Standard review
-			result, err = r.reviewer.Review(ctx, originalRequest, currentResponse)
+			result, err = r.reviewer.Review(ctx, originalRequest, reviewed)
 		}
 		durationMs := timeNow().Sub(startTime).Milliseconds()
 
@@ -468,10 +508,8 @@ func (r *SimpleRunner) runReviewCycle(originalRequest, response string) {
 			fmt.Fprintf(os.Stdout, " %s(review tokens: %d)%s\n\n", ansiDim, totalReviewTokens, ansiReset)
 
 			// Cache for incremental review
-			if r.incrementalReviewer != nil {
-				for _, block := range review.ExtractCodeBlocks(currentResponse) {
-					r.incrementalReviewer.CacheResult(block, true, "", "")
-				}
+			if incResult != nil {
+				r.incrementalReviewer.MarkReviewed(incResult.Diffs)
 			}
 			r.lastResponse = currentResponse
 			return
@@ -493,6 +531,16 @@ func (r *SimpleRunner) runReviewCycle(originalRequest, response string) {
 			return
 		}
 
+		// Stop before spending more than the correction budget
+		if budget := r.reviewer.Config().MaxCorrectionTokens; budget > 0 {
+			if spent := r.inputTokens + r.outputTokens - startTokens; spent >= budget {
+				fmt.Fprintf(os.Stdout, "\n%s❌ Correction token budget reached (%d of %d tokens). Suggestions:%s\n", ansiRed, spent, budget, ansiReset)
+				fmt.Fprintf(os.Stdout, "%s%s%s\n\n", ansiDim, result.Feedback, ansiReset)
+				r.lastResponse = currentResponse
+				return
+			}
+		}
+
 		// Generate correction prompt and run engine again
 		correctionPrompt := r.reviewer.GenerateCorrectionPrompt(result.Issues, result.Feedback)
 		fmt.Fprintf(os.Stdout, "\n%s🔄 Auto-correcting...%s\n\n", ansiCyan, ansiReset)
@@ -501,6 +549,16 @@ func (r *SimpleRunner) runReviewCycle(originalRequest, response string) {
 	}
 }
 
+// turnChanges returns the files changed since the last prompt with their
+// content before it
+func (r *SimpleRunner) turnChanges() []review.FileChange {
+	var changes []review.FileChange
+	for _, cp := range r.engine.TurnCheckpoints() {
+		changes = append(changes, review.FileChange{Path: cp.Path, Before: cp.Content, Existed: cp.Existed})
+	}
+	return changes
+}
+
 // extractSuggestions extracts suggestions from pipeline result
 func (r *SimpleRunner) extractSuggestions(result *review.PipelineResult) string {
 	var suggestions []string
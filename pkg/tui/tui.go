@@ -2,6 +2,7 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -9,6 +10,9 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/xinguang/agentic-coder/pkg/editor"
+	"github.com/xinguang/agentic-coder/pkg/engine"
+	"github.com/xinguang/agentic-coder/pkg/voice"
 )
 
 // ANSI color codes
@@ -117,6 +121,64 @@ type NewSessionCallback func() (sessionID string, err error)
 // SaveSessionCallback is called to save the session
 type SaveSessionCallback func() error
 
+// OutputStyleCallback switches the output style, or describes the
+// available styles when name is empty. It returns a message to display.
+type OutputStyleCallback func(name string) (string, error)
+
+// TagCallback handles "/tag" with its arguments and returns a message to display
+type TagCallback func(args []string) (string, error)
+
+// PinCallback handles "/pin" and "/unpin" with their arguments and returns
+// a message to display
+type PinCallback func(args []string, unpin bool) (string, error)
+
+// DiffContextCallback handles "/diff-context" with its arguments and
+// returns a message to display
+type DiffContextCallback func(args []string) (string, error)
+
+// NewTabCallback starts a new session with an engine of its own for
+// "/tab new", returning the engine, its model, and how to save its session
+type NewTabCallback func() (*engine.Engine, string, SaveSessionCallback, error)
+
+// SwitchTabCallback is told the engine of the tab being shown; the session
+// callbacks act on its session from then on
+type SwitchTabCallback func(eng *engine.Engine)
+
+// CommandCallback is told the name of each slash command the user runs
+type CommandCallback func(name string)
+
+// ErrorCallback is told of errors that end a run
+type ErrorCallback func(err error)
+
+// RewindCallback handles "/rewind" with its arguments and returns a message to display
+type RewindCallback func(args []string) (string, error)
+
+// ToolsCallback handles "/tools" with its arguments and returns a message to display
+type ToolsCallback func(args []string) (string, error)
+
+// LimitsCallback describes the provider's rate limits for "/limits"
+type LimitsCallback func() string
+
+// EnvCallback describes the environment commands receive for "/env"
+type EnvCallback func() string
+
+// SpeedCallback describes the rolling speed of each model for "/cost"
+type SpeedCallback func() string
+
+// ProcessesCallback handles "/ps" with its arguments and returns a message to display
+type ProcessesCallback func(args []string) (string, error)
+
+// TasksCallback handles "/tasks" with its arguments and returns a message to display
+type TasksCallback func(args []string) (string, error)
+
+// ModelCallback switches to the model named in "/model <name>" and returns
+// its resolved name
+type ModelCallback func(name string) (string, error)
+
+// RefactorCallback handles "/refactor" with its arguments, showing the
+// change to confirm before applying it, and returns a message to display
+type RefactorCallback func(ctx context.Context, args []string, confirm func(preview string) bool) (string, error)
+
 // Config holds TUI configuration
 type Config struct {
 	Model           string
@@ -125,12 +187,37 @@ type Config struct {
 	SessionID       string
 	SessionName     string
 	MessageCount    int
+	ThinkingDisplay string // show, collapse, hide
+	Verbose         bool   // Show a summary line after each agent iteration
 	OnSubmit        SubmitCallback
 	OnBash          BashCallback
 	OnListSessions  ListSessionsCallback
 	OnResumeSession ResumeSessionCallback
 	OnNewSession    NewSessionCallback
 	OnSaveSession   SaveSessionCallback
+	OnOutputStyle   OutputStyleCallback
+	OnTag           TagCallback
+	OnPin           PinCallback
+	OnDiffContext   DiffContextCallback
+	OnNewTab        NewTabCallback
+	OnSwitchTab     SwitchTabCallback
+	OnCommand       CommandCallback
+	OnError         ErrorCallback
+	OnRewind        RewindCallback
+	OnTools         ToolsCallback
+	OnLimits        LimitsCallback
+	OnSpeed         SpeedCallback
+	OnEnv           EnvCallback
+	OnProcesses     ProcessesCallback
+	OnTasks         TasksCallback
+	OnModel         ModelCallback
+	OnRefactor      RefactorCallback
+
+	// Voice input for /voice and Ctrl+R, nil when unavailable
+	Voice *voice.Input
+
+	// Editor for /edit and Ctrl+G
+	Editor editor.Editor
 
 	// Review settings
 	EnableReview    bool // Enable automatic review after each response
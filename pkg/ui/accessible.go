@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// AccessibleMode makes new printers write plain, labeled, linear text for
+// screen readers and dumb terminals: no color, icons, box drawing, or
+// cursor movement. It defaults to on for TERM=dumb and when
+// AGENTIC_CODER_ACCESSIBLE is set, and the --accessible flag turns it on.
+var AccessibleMode = os.Getenv("AGENTIC_CODER_ACCESSIBLE") != "" || os.Getenv("TERM") == "dumb"
+
+// plainSymbols replaces the icons and drawing characters used in output
+// with text, or removes them where they are decoration
+var plainSymbols = strings.NewReplacer(
+	IconSuccess+" ", "", IconError+" ", "", IconWarning+" ", "", IconInfo+" ", "",
+	IconArrow, "->", IconBullet, "-", IconStar, "*",
+	IconThinking+" ", "", IconTool+" ", "", IconFile+" ", "", IconFolder+" ", "",
+	IconCode+" ", "", IconRocket+" ", "", IconKey+" ", "", IconGear+" ", "",
+	IconClock+" ", "", IconSave+" ", "", IconLoad+" ", "", IconChat+" ", "",
+	IconBot+" ", "", IconUser+" ", "", IconWork+" ", "",
+	"↻ ", "", "…", "...", "—", "-", "│", ",", "─", "-",
+)
+
+// plainText strips icons and drawing characters from text
+func plainText(text string) string {
+	return plainSymbols.Replace(text)
+}
+
+// icon prefixes a message with an icon, or in accessible mode with a label
+// such as "ERROR: "
+func (p *Printer) icon(icon, label string) string {
+	if p.Accessible {
+		return label + ": "
+	}
+	return icon + " "
+}
+
+// rule prints a horizontal line under a heading. Screen readers would read
+// every character, so accessible mode leaves it out.
+func (p *Printer) rule(width int) {
+	if p.Accessible {
+		return
+	}
+	fmt.Println(p.color(Dim, strings.Repeat("─", width)))
+}
+
+// helpLine prints a command or shortcut with its description
+func (p *Printer) helpLine(name, desc string) {
+	if p.Accessible {
+		fmt.Printf("  %s: %s\n", name, desc)
+		return
+	}
+	fmt.Printf("  %s%-20s%s %s%s%s\n", BrightYellow, name, Reset, Dim, desc, Reset)
+}
+
+// Status announces a change of state, such as a response starting or
+// finishing. Other modes show state with the prompt and spinners, so it
+// prints only in accessible mode.
+func (p *Printer) Status(format string, args ...interface{}) {
+	if !p.Accessible {
+		return
+	}
+	fmt.Println("STATUS: " + fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Thinking display modes
+const (
+	ThinkingShow     = "show"     // stream thinking as it arrives
+	ThinkingCollapse = "collapse" // one summary line per thinking block
+	ThinkingHide     = "hide"     // no thinking output
+)
+
+// ThinkingDisplayModes lists the valid thinking display modes
+var ThinkingDisplayModes = []string{ThinkingShow, ThinkingCollapse, ThinkingHide}
+
+// ValidThinkingDisplay reports whether mode is a thinking display mode
+func ValidThinkingDisplay(mode string) bool {
+	for _, m := range ThinkingDisplayModes {
+		if mode == m {
+			return true
+		}
+	}
+	return false
+}
+
+// ThinkingSummary describes a collapsed thinking block, such as "Thinking… 2.3k tokens"
+func ThinkingSummary(thinking string) string {
+	return "Thinking… " + FormatTokens(len(thinking)/4) + " tokens"
+}
+
+// FormatTokens abbreviates a token count, such as 2300 as "2.3k"
+func FormatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return trimZero(fmt.Sprintf("%.1f", float64(n)/1_000_000)) + "M"
+	case n >= 1000:
+		return trimZero(fmt.Sprintf("%.1f", float64(n)/1000)) + "k"
+	default:
+		return fmt.Sprint(n)
+	}
+}
+
+func trimZero(s string) string {
+	return strings.TrimSuffix(s, ".0")
+}
+
+// ThinkingText continues thinking output started with Thinking
+func (p *Printer) ThinkingText(text string) {
+	fmt.Print(p.color(Gray, text))
+}
+
+// ThinkingCollapsed prints the summary line of a collapsed thinking block
+func (p *Printer) ThinkingCollapsed(thinking string) {
+	fmt.Println(p.color(Gray, p.icon(IconThinking, "THINKING")+ThinkingSummary(thinking)) + " " + p.color(Dim, "(/thinking to expand)"))
+}
@@ -77,18 +77,22 @@ const (
 
 // Printer handles formatted output
 type Printer struct {
-	NoColor bool
+	NoColor    bool
+	Accessible bool // plain labeled text for screen readers, see AccessibleMode
 }
 
 // NewPrinter creates a new printer
 func NewPrinter() *Printer {
 	// Check if NO_COLOR env is set or output is not a terminal
-	noColor := os.Getenv("NO_COLOR") != ""
-	return &Printer{NoColor: noColor}
+	noColor := os.Getenv("NO_COLOR") != "" || AccessibleMode
+	return &Printer{NoColor: noColor, Accessible: AccessibleMode}
 }
 
 // color applies color if enabled
 func (p *Printer) color(c, text string) string {
+	if p.Accessible {
+		return plainText(text)
+	}
 	if p.NoColor {
 		return text
 	}
@@ -98,25 +102,25 @@ func (p *Printer) color(c, text string) string {
 // Success prints a success message
 func (p *Printer) Success(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Println(p.color(Green, IconSuccess+" "+msg))
+	fmt.Println(p.color(Green, p.icon(IconSuccess, "OK")+msg))
 }
 
 // Error prints an error message
 func (p *Printer) Error(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Println(p.color(Red, IconError+" "+msg))
+	fmt.Println(p.color(Red, p.icon(IconError, "ERROR")+msg))
 }
 
 // Warning prints a warning message
 func (p *Printer) Warning(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Println(p.color(Yellow, IconWarning+" "+msg))
+	fmt.Println(p.color(Yellow, p.icon(IconWarning, "WARNING")+msg))
 }
 
 // Info prints an info message
 func (p *Printer) Info(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Println(p.color(Blue, IconInfo+" "+msg))
+	fmt.Println(p.color(Blue, p.icon(IconInfo, "INFO")+msg))
 }
 
 // Dim prints dimmed text
@@ -136,7 +140,7 @@ func (p *Printer) Title(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
 	fmt.Println()
 	fmt.Println(p.color(Bold+BrightCyan, msg))
-	fmt.Println(p.color(Dim, strings.Repeat("─", len(msg))))
+	p.rule(len(msg))
 }
 
 // Section prints a section header
@@ -148,7 +152,7 @@ func (p *Printer) Section(format string, args ...interface{}) {
 
 // Tool prints tool usage info
 func (p *Printer) Tool(name string) {
-	fmt.Print(p.color(Yellow, IconTool+" "+name))
+	fmt.Print(p.color(Yellow, p.icon(IconTool, "TOOL")+name))
 }
 
 // ToolParam prints a tool parameter
@@ -157,26 +161,35 @@ func (p *Printer) ToolParam(key string, value string) {
 		value = value[:100] + "..."
 	}
 	value = strings.ReplaceAll(value, "\n", "\\n")
-	fmt.Printf("   %s%s: %s%s\n", Gray, key, value, Reset)
+	fmt.Println(p.color(Gray, fmt.Sprintf("   %s: %s", key, value)))
 }
 
 // ToolSuccess prints tool success
 func (p *Printer) ToolSuccess(name string, summary string) {
-	if summary != "" {
-		fmt.Printf("%s%s %s: %s%s\n", Green, IconSuccess, name, summary, Reset)
-	} else {
-		fmt.Printf("%s%s %s completed%s\n", Green, IconSuccess, name, Reset)
+	switch {
+	case p.Accessible && summary != "":
+		fmt.Println(p.color(Green, "TOOL: "+name+" - OK: "+summary))
+	case p.Accessible:
+		fmt.Println(p.color(Green, "TOOL: "+name+" - OK"))
+	case summary != "":
+		fmt.Println(p.color(Green, IconSuccess+" "+name+": "+summary))
+	default:
+		fmt.Println(p.color(Green, IconSuccess+" "+name+" completed"))
 	}
 }
 
 // ToolError prints tool error
 func (p *Printer) ToolError(name string, err string) {
-	fmt.Printf("%s%s %s: %s%s\n", Red, IconError, name, err, Reset)
+	if p.Accessible {
+		fmt.Println(p.color(Red, "TOOL: "+name+" - FAILED: "+err))
+		return
+	}
+	fmt.Println(p.color(Red, IconError+" "+name+": "+err))
 }
 
 // Thinking prints thinking indicator
 func (p *Printer) Thinking(text string) {
-	fmt.Printf("%s%s %s%s", Gray, IconThinking, text, Reset)
+	fmt.Print(p.color(Gray, p.icon(IconThinking, "THINKING")+text))
 }
 
 // Prompt prints the input prompt
@@ -191,6 +204,10 @@ func (p *Printer) PromptContinue() {
 
 // StatusLine prints a status line
 func (p *Printer) StatusLine(model, cwd string, msgCount int) {
+	if p.Accessible {
+		fmt.Printf("Model: %s, directory: %s, %d messages\n", model, cwd, msgCount)
+		return
+	}
 	status := fmt.Sprintf("%s %s │ %s %s │ %s %d messages",
 		IconBot, model,
 		IconFolder, shortenPath(cwd, 30),
@@ -200,6 +217,14 @@ func (p *Printer) StatusLine(model, cwd string, msgCount int) {
 
 // WelcomeBanner prints the welcome banner
 func (p *Printer) WelcomeBanner(version, model, cwd string) {
+	if p.Accessible {
+		fmt.Printf("Agentic Coder version %s, accessible output\n", version)
+		fmt.Printf("Model: %s\n", model)
+		fmt.Printf("Directory: %s\n", cwd)
+		fmt.Println("Type /help for commands, Ctrl+C to interrupt")
+		fmt.Println()
+		return
+	}
 	fmt.Println()
 	fmt.Println(p.color(Bold+BrightCyan, "  ╭─────────────────────────────────────────╮"))
 	fmt.Println(p.color(Bold+BrightCyan, "  │")+p.color(Bold+White, "     Agentic Coder ")+p.color(Dim, "v"+version)+p.color(Bold+BrightCyan, strings.Repeat(" ", 22-len(version))+"│"))
@@ -213,46 +238,80 @@ func (p *Printer) WelcomeBanner(version, model, cwd string) {
 	fmt.Println()
 }
 
+// helpCommands are the slash commands listed by HelpMenu
+var helpCommands = []struct {
+	cmd  string
+	desc string
+}{
+	{"/help, /h", "Show this help"},
+	{"/clear, /cls", "Clear the screen"},
+	{"/model [name]", "Show or change the model, from the next request on"},
+	{"/session", "Show current session info"},
+	{"/sessions", "List recent sessions"},
+	{"/resume [id]", "Resume a previous session"},
+	{"/new", "Start a new session"},
+	{"/save", "Save current session"},
+	{"/work", "Manage work context"},
+	{"/work new <title>", "Create new work context"},
+	{"/work list", "List work contexts"},
+	{"/work show <id>", "Show work context"},
+	{"/work done <text>", "Mark item as done"},
+	{"/work todo <text>", "Add pending item"},
+	{"/work handoff", "Generate handoff summary"},
+	{"/compact", "Compact conversation history"},
+	{"/cost", "Show token usage and cost"},
+	{"/budget [override]", "Show or override the monthly budget"},
+	{"/limits", "Show provider rate limits and reset times"},
+	{"/env", "Show the environment variables commands receive, with secrets masked"},
+	{"/ps", "List processes and shells started by the agent; /ps kill <pid|shell> stops one"},
+	{"/tasks [cancel <id>]", "List subagents started by the Task tool, or cancel one"},
+	{"/thinking", "Show the latest thinking in full"},
+	{"/output-style [name]", "Show or set the output style"},
+	{"/tag [add|remove] <tag>", "List, add, or remove session tags"},
+	{"/pin [file]", "List pinned files or pin one; its current content stays in view"},
+	{"/unpin <file>|all", "Unpin files"},
+	{"/diff-context [base]", "Share the current branch's changes with the next prompt"},
+	{"/rewind [n] [--restore]", "Rewind to before prompt n, restoring files"},
+	{"/pause", "Pause the running task after the current tool (TUI)"},
+	{"/continue", "Resume a paused run, also after a restart"},
+	{"/tab [new|n]", "List, open, or show session tabs (TUI only)"},
+	{"/tools [enable|disable]", "List, enable, or disable tools"},
+	{"/refactor rename <s> <new>", "Rename a symbol across the workspace with the language server"},
+	{"/cover [package]", "Write tests for uncovered code"},
+	{"/voice", "Record speech and review the transcript before sending (Ctrl+R in the TUI)"},
+	{"/edit", "Compose a prompt in your editor and send it when saved (Ctrl+G in the TUI)"},
+	{"/exit, /quit, /q", "Exit the program"},
+}
+
+// IsCommand reports whether name, such as "/pin", is a slash command in
+// the help menu
+func IsCommand(name string) bool {
+	for _, c := range helpCommands {
+		for _, alias := range strings.Split(c.cmd, ",") {
+			if fields := strings.Fields(alias); len(fields) > 0 && fields[0] == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // HelpMenu prints the help menu
 func (p *Printer) HelpMenu() {
 	fmt.Println()
 	fmt.Println(p.color(Bold+BrightCyan, "  Commands"))
-	fmt.Println(p.color(Dim, "  "+strings.Repeat("─", 50)))
-
-	commands := []struct {
-		cmd  string
-		desc string
-	}{
-		{"/help, /h", "Show this help"},
-		{"/clear, /cls", "Clear the screen"},
-		{"/model [name]", "Show or change the model"},
-		{"/session", "Show current session info"},
-		{"/sessions", "List recent sessions"},
-		{"/resume [id]", "Resume a previous session"},
-		{"/new", "Start a new session"},
-		{"/save", "Save current session"},
-		{"/work", "Manage work context"},
-		{"/work new <title>", "Create new work context"},
-		{"/work list", "List work contexts"},
-		{"/work show <id>", "Show work context"},
-		{"/work done <text>", "Mark item as done"},
-		{"/work todo <text>", "Add pending item"},
-		{"/work handoff", "Generate handoff summary"},
-		{"/compact", "Compact conversation history"},
-		{"/cost", "Show token usage and cost"},
-		{"/exit, /quit, /q", "Exit the program"},
-	}
+	p.rule(50)
 
-	for _, c := range commands {
-		fmt.Printf("  %s%-20s%s %s%s%s\n", BrightYellow, c.cmd, Reset, Dim, c.desc, Reset)
+	for _, c := range helpCommands {
+		p.helpLine(c.cmd, c.desc)
 	}
 
 	fmt.Println()
 	fmt.Println(p.color(Bold+BrightCyan, "  Keyboard Shortcuts"))
-	fmt.Println(p.color(Dim, "  "+strings.Repeat("─", 50)))
-	fmt.Printf("  %sCtrl+C%s           %sInterrupt current operation%s\n", BrightYellow, Reset, Dim, Reset)
-	fmt.Printf("  %sCtrl+C (twice)%s   %sExit the program%s\n", BrightYellow, Reset, Dim, Reset)
-	fmt.Printf("  %sCtrl+D%s           %sExit the program%s\n", BrightYellow, Reset, Dim, Reset)
+	p.rule(50)
+	p.helpLine("Ctrl+C", "Interrupt running tool, or current operation")
+	p.helpLine("Ctrl+C (twice)", "Exit the program")
+	p.helpLine("Ctrl+D", "Exit the program")
 	fmt.Println()
 }
 
@@ -260,12 +319,12 @@ func (p *Printer) HelpMenu() {
 func (p *Printer) SessionInfo(id, model string, msgCount int, created, updated time.Time) {
 	fmt.Println()
 	fmt.Println(p.color(Bold, "Session Info"))
-	fmt.Println(p.color(Dim, strings.Repeat("─", 40)))
-	fmt.Printf("  ID:       %s%s%s\n", BrightCyan, id, Reset)
-	fmt.Printf("  Model:    %s%s%s\n", Green, model, Reset)
-	fmt.Printf("  Messages: %s%d%s\n", Yellow, msgCount, Reset)
-	fmt.Printf("  Created:  %s%s%s\n", Dim, created.Format("2006-01-02 15:04:05"), Reset)
-	fmt.Printf("  Updated:  %s%s%s\n", Dim, updated.Format("2006-01-02 15:04:05"), Reset)
+	p.rule(40)
+	fmt.Printf("  ID:       %s\n", p.color(BrightCyan, id))
+	fmt.Printf("  Model:    %s\n", p.color(Green, model))
+	fmt.Printf("  Messages: %s\n", p.color(Yellow, fmt.Sprint(msgCount)))
+	fmt.Printf("  Created:  %s\n", p.color(Dim, created.Format("2006-01-02 15:04:05")))
+	fmt.Printf("  Updated:  %s\n", p.color(Dim, updated.Format("2006-01-02 15:04:05")))
 	fmt.Println()
 }
 
@@ -278,12 +337,15 @@ func (p *Printer) SessionList(sessions []SessionListItem) {
 
 	fmt.Println()
 	fmt.Println(p.color(Bold, "Recent Sessions"))
-	fmt.Println(p.color(Dim, strings.Repeat("─", 60)))
+	p.rule(60)
 
 	for i, s := range sessions {
 		marker := " "
 		if s.IsCurrent {
 			marker = p.color(Green, IconArrow)
+			if p.Accessible {
+				marker = "(current)"
+			}
 		}
 
 		age := formatAge(s.UpdatedAt)
@@ -292,16 +354,16 @@ func (p *Printer) SessionList(sessions []SessionListItem) {
 			preview = preview[:40] + "..."
 		}
 
-		fmt.Printf(" %s %s%s%s  %s%-8s%s  %s%s%s\n",
+		fmt.Printf(" %s %s  %s  %s\n",
 			marker,
-			BrightCyan, s.ID[:8], Reset,
-			Dim, age, Reset,
-			Gray, preview, Reset)
+			p.color(BrightCyan, s.ID[:8]),
+			p.color(Dim, fmt.Sprintf("%-8s", age)),
+			p.color(Gray, preview))
 
 		if i >= 9 {
 			remaining := len(sessions) - 10
 			if remaining > 0 {
-				fmt.Printf("   %s... and %d more%s\n", Dim, remaining, Reset)
+				fmt.Println(p.color(Dim, fmt.Sprintf("   ... and %d more", remaining)))
 			}
 			break
 		}
@@ -326,7 +388,7 @@ func (p *Printer) WorkContextList(contexts []WorkContextItem) {
 
 	fmt.Println()
 	fmt.Println(p.color(Bold, IconWork+" Work Contexts"))
-	fmt.Println(p.color(Dim, strings.Repeat("─", 60)))
+	p.rule(60)
 
 	for _, ctx := range contexts {
 		pct := 0
@@ -336,10 +398,10 @@ func (p *Printer) WorkContextList(contexts []WorkContextItem) {
 
 		progressBar := p.progressBar(pct, 10)
 
-		fmt.Printf("  %s%s%s  %s %s%3d%%%s  %s (%d/%d)\n",
-			BrightCyan, ctx.ID, Reset,
+		fmt.Printf("  %s  %s %s  %s (%d/%d)\n",
+			p.color(BrightCyan, ctx.ID),
 			progressBar,
-			Dim, pct, Reset,
+			p.color(Dim, fmt.Sprintf("%3d%%", pct)),
 			ctx.Title, ctx.Done, ctx.Done+ctx.Pending)
 	}
 	fmt.Println()
@@ -355,6 +417,9 @@ type WorkContextItem struct {
 
 // progressBar creates a progress bar
 func (p *Printer) progressBar(pct, width int) string {
+	if p.Accessible {
+		return ""
+	}
 	filled := pct * width / 100
 	empty := width - filled
 
@@ -374,19 +439,19 @@ func (p *Printer) progressBar(pct, width int) string {
 func (p *Printer) CostSummary(inputTokens, outputTokens int64, cost float64) {
 	fmt.Println()
 	fmt.Println(p.color(Bold, "Token Usage"))
-	fmt.Println(p.color(Dim, strings.Repeat("─", 30)))
-	fmt.Printf("  Input:   %s%d%s tokens\n", BrightCyan, inputTokens, Reset)
-	fmt.Printf("  Output:  %s%d%s tokens\n", BrightCyan, outputTokens, Reset)
-	fmt.Printf("  Total:   %s%d%s tokens\n", Yellow, inputTokens+outputTokens, Reset)
+	p.rule(30)
+	fmt.Printf("  Input:   %s tokens\n", p.color(BrightCyan, fmt.Sprint(inputTokens)))
+	fmt.Printf("  Output:  %s tokens\n", p.color(BrightCyan, fmt.Sprint(outputTokens)))
+	fmt.Printf("  Total:   %s tokens\n", p.color(Yellow, fmt.Sprint(inputTokens+outputTokens)))
 	if cost > 0 {
-		fmt.Printf("  Cost:    %s$%.4f%s\n", Green, cost, Reset)
+		fmt.Printf("  Cost:    %s\n", p.color(Green, fmt.Sprintf("$%.4f", cost)))
 	}
 	fmt.Println()
 }
 
 // Divider prints a divider line
 func (p *Printer) Divider() {
-	fmt.Println(p.color(Dim, strings.Repeat("─", 50)))
+	p.rule(50)
 }
 
 // NewLine prints a new line
@@ -0,0 +1,92 @@
+package voice
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+)
+
+// Input is push-to-talk voice input: Start begins a recording and Stop ends
+// it and returns the transcript
+type Input struct {
+	cfg       config.VoiceConfig
+	openAIKey string
+
+	mu  sync.Mutex
+	rec *Recording
+}
+
+// NewInput creates voice input with the voice settings and, for the OpenAI
+// transcriber, an API key
+func NewInput(cfg config.VoiceConfig, openAIKey string) *Input {
+	return &Input{cfg: cfg, openAIKey: openAIKey}
+}
+
+// Recording reports whether a recording is in progress
+func (in *Input) Recording() bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.rec != nil
+}
+
+// Start begins recording
+func (in *Input) Start() error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.rec != nil {
+		return fmt.Errorf("already recording")
+	}
+	// Fail before recording when nothing could transcribe it
+	if _, err := NewTranscriber(in.cfg, in.openAIKey); err != nil {
+		return err
+	}
+	rec, err := Record(in.cfg.Recorder)
+	if err != nil {
+		return err
+	}
+	in.rec = rec
+	return nil
+}
+
+// Stop ends the recording and transcribes it
+func (in *Input) Stop(ctx context.Context) (string, error) {
+	in.mu.Lock()
+	rec := in.rec
+	in.rec = nil
+	in.mu.Unlock()
+	if rec == nil {
+		return "", fmt.Errorf("not recording")
+	}
+
+	path, err := rec.Stop()
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(path)
+	transcriber, err := NewTranscriber(in.cfg, in.openAIKey)
+	if err != nil {
+		return "", err
+	}
+	text, err := transcriber.Transcribe(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if text == "" {
+		return "", fmt.Errorf("no speech was recognized")
+	}
+	return text, nil
+}
+
+// Cancel discards a recording in progress
+func (in *Input) Cancel() {
+	in.mu.Lock()
+	rec := in.rec
+	in.rec = nil
+	in.mu.Unlock()
+	if rec != nil {
+		rec.Cancel()
+	}
+}
@@ -0,0 +1,131 @@
+// Package voice records speech from the microphone and transcribes it with
+// whisper.cpp or the OpenAI transcription API
+package voice
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// stopTimeout bounds the wait for a recorder to finish the file after it
+// was told to stop
+const stopTimeout = 5 * time.Second
+
+// lookPath finds a command on PATH; replaced in tests
+var lookPath = exec.LookPath
+
+// Recording is a recording in progress
+type Recording struct {
+	cmd  *exec.Cmd
+	path string
+	done chan error
+}
+
+// Record starts recording 16 kHz mono WAV, which whisper.cpp requires, to a
+// temporary file. command is a recorder command line with {file} in place
+// of the output file; when empty, sox, arecord, or ffmpeg is used.
+func Record(command string) (*Recording, error) {
+	f, err := os.CreateTemp("", "agentic-coder-voice-*.wav")
+	if err != nil {
+		return nil, err
+	}
+	path := f.Name()
+	f.Close()
+
+	args, err := recorderArgs(command, path)
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to start %s: %w", args[0], err)
+	}
+
+	r := &Recording{cmd: cmd, path: path, done: make(chan error, 1)}
+	go func() {
+		err := cmd.Wait()
+		if err != nil && stderr.Len() > 0 {
+			err = fmt.Errorf("%s: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+		r.done <- err
+	}()
+	return r, nil
+}
+
+// Stop ends the recording and returns the audio file, which the caller
+// removes. Recorders finish the file when interrupted, so they exit with an
+// error that is ignored as long as audio was written.
+func (r *Recording) Stop() (string, error) {
+	select {
+	case err := <-r.done:
+		// The recorder quit on its own, such as without a microphone
+		os.Remove(r.path)
+		if err == nil {
+			err = fmt.Errorf("recorder exited")
+		}
+		return "", fmt.Errorf("recording failed: %w", err)
+	default:
+	}
+
+	if err := r.cmd.Process.Signal(os.Interrupt); err != nil {
+		r.cmd.Process.Kill()
+	}
+	select {
+	case <-r.done:
+	case <-time.After(stopTimeout):
+		r.cmd.Process.Kill()
+		<-r.done
+	}
+
+	info, err := os.Stat(r.path)
+	if err != nil || info.Size() <= 44 { // a WAV header alone
+		os.Remove(r.path)
+		return "", fmt.Errorf("no audio was recorded; check the microphone")
+	}
+	return r.path, nil
+}
+
+// Cancel ends the recording and discards it
+func (r *Recording) Cancel() {
+	if path, err := r.Stop(); err == nil {
+		os.Remove(path)
+	}
+}
+
+// recorderArgs returns the command line recording to path
+func recorderArgs(command, path string) ([]string, error) {
+	if command != "" {
+		fields := strings.Fields(command)
+		for i, f := range fields {
+			fields[i] = strings.ReplaceAll(f, "{file}", path)
+		}
+		return fields, nil
+	}
+
+	if _, err := lookPath("rec"); err == nil {
+		return []string{"rec", "-q", "-c", "1", "-r", "16000", "-b", "16", path}, nil
+	}
+	if _, err := lookPath("arecord"); err == nil {
+		return []string{"arecord", "-q", "-f", "S16_LE", "-c", "1", "-r", "16000", path}, nil
+	}
+	if _, err := lookPath("ffmpeg"); err == nil {
+		input := []string{"-f", "pulse", "-i", "default"}
+		switch runtime.GOOS {
+		case "darwin":
+			input = []string{"-f", "avfoundation", "-i", ":0"}
+		case "windows":
+			input = []string{"-f", "dshow", "-i", "audio=default"}
+		}
+		args := append([]string{"ffmpeg", "-loglevel", "error", "-y"}, input...)
+		return append(args, "-ac", "1", "-ar", "16000", path), nil
+	}
+	return nil, fmt.Errorf("no audio recorder found: install sox (rec), alsa-utils (arecord), or ffmpeg, or set voice.recorder")
+}
@@ -0,0 +1,272 @@
+package voice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+)
+
+// maxSummary is the longest read-out, in characters
+const maxSummary = 280
+
+// Speaker reads text aloud
+type Speaker interface {
+	Speak(ctx context.Context, text string) error
+}
+
+// SystemSpeaker speaks with the platform's speech command: say on macOS,
+// espeak-ng, espeak, or spd-say on Linux, and SAPI on Windows
+type SystemSpeaker struct {
+	args  []string // the command, to which the text is added
+	stdin bool     // the text is given on stdin instead
+}
+
+// Speak runs the speech command and waits until it has finished
+func (s *SystemSpeaker) Speak(ctx context.Context, text string) error {
+	args := s.args
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	if s.stdin {
+		cmd.Stdin = strings.NewReader(text)
+	} else {
+		cmd.Args = append(cmd.Args, text)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("%s failed: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// newSystemSpeaker finds the speech command of the platform
+func newSystemSpeaker(voiceName string) (*SystemSpeaker, error) {
+	if runtime.GOOS == "windows" {
+		script := "Add-Type -AssemblyName System.Speech; $s = New-Object System.Speech.Synthesis.SpeechSynthesizer; "
+		if voiceName != "" {
+			script += "$s.SelectVoice('" + strings.ReplaceAll(voiceName, "'", "''") + "'); "
+		}
+		script += "$s.Speak([Console]::In.ReadToEnd())"
+		return &SystemSpeaker{args: []string{"powershell", "-NoProfile", "-Command", script}, stdin: true}, nil
+	}
+	for _, name := range []string{"say", "espeak-ng", "espeak", "spd-say"} {
+		path, err := lookPath(name)
+		if err != nil {
+			continue
+		}
+		args := []string{path}
+		if name == "spd-say" {
+			args = append(args, "--wait")
+		}
+		if voiceName != "" {
+			flag := "-v"
+			if name == "spd-say" {
+				flag = "-y"
+			}
+			args = append(args, flag, voiceName)
+		}
+		return &SystemSpeaker{args: args}, nil
+	}
+	return nil, fmt.Errorf("no speech command found: install espeak-ng or speech-dispatcher, or set voice.speaker to openai")
+}
+
+// OpenAISpeaker speaks with the OpenAI speech API and plays the audio with
+// afplay, aplay, paplay, or ffplay
+type OpenAISpeaker struct {
+	APIKey  string
+	BaseURL string // default https://api.openai.com/v1
+	Model   string // default tts-1
+	Voice   string // default alloy
+}
+
+// Speak synthesizes text and plays it
+func (o *OpenAISpeaker) Speak(ctx context.Context, text string) error {
+	player, err := audioPlayer()
+	if err != nil {
+		return err
+	}
+	baseURL, model, voiceName := o.BaseURL, o.Model, o.Voice
+	if baseURL == "" {
+		baseURL = defaultAPIURL
+	}
+	if model == "" {
+		model = "tts-1"
+	}
+	if voiceName == "" {
+		voiceName = "alloy"
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"model":           model,
+		"voice":           voiceName,
+		"input":           text,
+		"response_format": "wav",
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/audio/speech", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+o.APIKey)
+
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(data))
+	}
+
+	f, err := os.CreateTemp("", "agentic-coder-speech-*.wav")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, resp.Body)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	play := exec.CommandContext(ctx, player[0], append(player[1:], f.Name())...)
+	if out, err := play.CombinedOutput(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("%s failed: %w: %s", player[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// audioPlayer finds a command that plays a WAV file given as its last argument
+func audioPlayer() ([]string, error) {
+	for _, player := range [][]string{
+		{"afplay"},
+		{"aplay", "-q"},
+		{"paplay"},
+		{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"},
+	} {
+		if _, err := lookPath(player[0]); err == nil {
+			return player, nil
+		}
+	}
+	return nil, fmt.Errorf("no audio player found: install alsa-utils (aplay) or ffmpeg (ffplay)")
+}
+
+// NewSpeaker returns the speaker the voice settings select: the system
+// speech command, or the OpenAI speech API when speaker is openai or the
+// system has none and a key is available
+func NewSpeaker(cfg config.VoiceConfig, openAIKey string) (Speaker, error) {
+	api := func() (Speaker, error) {
+		if openAIKey == "" {
+			return nil, fmt.Errorf("speaking with OpenAI needs OPENAI_API_KEY or agentic-coder auth login openai")
+		}
+		return &OpenAISpeaker{APIKey: openAIKey, Voice: cfg.SpeechVoice}, nil
+	}
+	if cfg.Speaker == "openai" {
+		return api()
+	}
+	system, err := newSystemSpeaker(cfg.SpeechVoice)
+	if err == nil {
+		return system, nil
+	}
+	if cfg.Speaker == "" && openAIKey != "" {
+		return api()
+	}
+	return nil, err
+}
+
+// Announcer speaks in the background. A new announcement interrupts the
+// one being spoken, so read-outs never pile up behind a busy agent.
+type Announcer struct {
+	speaker Speaker
+	onError func(err error)
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewAnnouncer creates an announcer; onError, which may be nil, receives
+// failures to speak
+func NewAnnouncer(speaker Speaker, onError func(err error)) *Announcer {
+	return &Announcer{speaker: speaker, onError: onError}
+}
+
+// Announce starts speaking text, stopping any announcement in progress
+func (a *Announcer) Announce(text string) {
+	if text == "" {
+		return
+	}
+	a.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	a.mu.Lock()
+	a.cancel, a.done = cancel, done
+	a.mu.Unlock()
+	go func() {
+		defer close(done)
+		if err := a.speaker.Speak(ctx, text); err != nil && a.onError != nil {
+			a.onError(err)
+		}
+	}()
+}
+
+// Stop interrupts the announcement in progress and waits for it to end
+func (a *Announcer) Stop() {
+	a.mu.Lock()
+	cancel, done := a.cancel, a.done
+	a.cancel, a.done = nil, nil
+	a.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+var (
+	codeBlock    = regexp.MustCompile("(?s)```.*?(```|$)")
+	markdownLink = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	sentenceEnd  = regexp.MustCompile(`[.!?](\s|$)`)
+)
+
+// Summary returns a short read-out of a response: its first paragraph of
+// prose, without code or markdown, cut to two sentences
+func Summary(text string) string {
+	text = codeBlock.ReplaceAllString(text, "\n\n")
+	text = markdownLink.ReplaceAllString(text, "$1")
+	text = strings.NewReplacer("`", "", "**", "", "__", "", "#", "", "> ", "").Replace(text)
+
+	var paragraph string
+	for _, p := range strings.Split(text, "\n\n") {
+		if p = strings.Join(strings.Fields(p), " "); p != "" {
+			paragraph = p
+			break
+		}
+	}
+
+	// Two sentences at most
+	if ends := sentenceEnd.FindAllStringIndex(paragraph, 2); len(ends) == 2 {
+		paragraph = paragraph[:ends[1][0]+1]
+	}
+	if len(paragraph) > maxSummary {
+		cut := strings.LastIndex(paragraph[:maxSummary], " ")
+		if cut <= 0 {
+			cut = maxSummary
+		}
+		paragraph = paragraph[:cut] + "..."
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(paragraph, "- "), "* ")
+}
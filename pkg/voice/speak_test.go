@@ -0,0 +1,91 @@
+package voice
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+)
+
+func TestNewSpeaker(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows always speaks with SAPI")
+	}
+	stubPath(t, "espeak", "spd-say")
+	sp, err := NewSpeaker(config.VoiceConfig{SpeechVoice: "en-us"}, "")
+	if s, ok := sp.(*SystemSpeaker); err != nil || !ok || strings.Join(s.args, " ") != "/usr/bin/espeak -v en-us" {
+		t.Errorf("expected espeak with the voice, got %+v, %v", sp, err)
+	}
+	if sp, err := NewSpeaker(config.VoiceConfig{Speaker: "openai"}, "key"); err != nil || sp.(*OpenAISpeaker).APIKey != "key" {
+		t.Errorf("expected OpenAI when configured, got %T, %v", sp, err)
+	}
+
+	stubPath(t)
+	if sp, err := NewSpeaker(config.VoiceConfig{}, "key"); err != nil || sp.(*OpenAISpeaker) == nil {
+		t.Errorf("expected OpenAI without a speech command, got %T, %v", sp, err)
+	}
+	if _, err := NewSpeaker(config.VoiceConfig{Speaker: "system"}, "key"); err == nil {
+		t.Error("expected an error without a speech command")
+	}
+	if _, err := NewSpeaker(config.VoiceConfig{Speaker: "openai"}, ""); err == nil {
+		t.Error("expected an error without an OpenAI key")
+	}
+}
+
+// blockingSpeaker speaks until cancelled and records what it was given
+type blockingSpeaker struct {
+	started chan string
+}
+
+func (b *blockingSpeaker) Speak(ctx context.Context, text string) error {
+	b.started <- text
+	<-ctx.Done()
+	return errors.New("interrupted")
+}
+
+func TestAnnouncerInterrupts(t *testing.T) {
+	sp := &blockingSpeaker{started: make(chan string, 2)}
+	var failures int
+	a := NewAnnouncer(sp, func(err error) { failures++ })
+
+	a.Announce("first")
+	a.Announce("")
+	a.Announce("second")
+	for _, want := range []string{"first", "second"} {
+		select {
+		case got := <-sp.started:
+			if got != want {
+				t.Errorf("expected %q, got %q", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%q was not spoken", want)
+		}
+	}
+	a.Stop()
+	if failures != 2 {
+		t.Errorf("expected both interrupted announcements reported, got %d", failures)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	long := strings.Repeat("word ", 100)
+	tests := []struct {
+		name, text, want string
+	}{
+		{"two sentences", "I fixed the bug. Tests pass now. Also cleaned up imports.", "I fixed the bug. Tests pass now."},
+		{"markdown", "## Done\n\nUpdated **config.go** and [the docs](README.md).", "Done"},
+		{"code first", "```go\nfunc main() {}\n```\n\nAdded `main`. That is all.", "Added main. That is all."},
+		{"list", "- Renamed the flag\n- Updated tests", "Renamed the flag - Updated tests"},
+		{"long", long, strings.TrimSpace(long[:280]) + "..."},
+		{"empty", "```\nonly code\n```", ""},
+	}
+	for _, tt := range tests {
+		if got := Summary(tt.text); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
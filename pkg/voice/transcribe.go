@@ -0,0 +1,184 @@
+package voice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+)
+
+// Transcriber turns recorded speech into text
+type Transcriber interface {
+	Transcribe(ctx context.Context, path string) (string, error)
+}
+
+// WhisperCPP transcribes locally with the whisper.cpp command line tool
+type WhisperCPP struct {
+	Binary   string // whisper-cli, or whisper-cpp in older releases
+	Model    string // ggml model file
+	Language string // "" to detect
+}
+
+// Transcribe runs whisper.cpp on a WAV file
+func (w *WhisperCPP) Transcribe(ctx context.Context, path string) (string, error) {
+	args := []string{"-m", w.Model, "-f", path, "-nt", "-np"}
+	if w.Language != "" {
+		args = append(args, "-l", w.Language)
+	}
+	cmd := exec.CommandContext(ctx, w.Binary, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w: %s", filepath.Base(w.Binary), err, strings.TrimSpace(stderr.String()))
+	}
+	return cleanTranscript(string(out)), nil
+}
+
+// defaultAPIURL is the OpenAI API, whose transcription endpoint is used
+// when whisper.cpp is not set up
+const defaultAPIURL = "https://api.openai.com/v1"
+
+// OpenAI transcribes with the OpenAI audio transcription API
+type OpenAI struct {
+	APIKey   string
+	BaseURL  string // default https://api.openai.com/v1
+	Model    string // default whisper-1
+	Language string
+}
+
+// Transcribe uploads a recording for transcription
+func (o *OpenAI) Transcribe(ctx context.Context, path string) (string, error) {
+	audio, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	model := o.Model
+	if model == "" {
+		model = "whisper-1"
+	}
+	baseURL := o.BaseURL
+	if baseURL == "" {
+		baseURL = defaultAPIURL
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("model", model)
+	if o.Language != "" {
+		form.WriteField("language", o.Language)
+	}
+	part, err := form.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	part.Write(audio)
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+o.APIKey)
+
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(data))
+	}
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	return cleanTranscript(result.Text), nil
+}
+
+// NewTranscriber returns the transcriber the voice settings select:
+// whisper.cpp when a model is set and the tool is installed, otherwise the
+// OpenAI API when a key is available
+func NewTranscriber(cfg config.VoiceConfig, openAIKey string) (Transcriber, error) {
+	local := func() (Transcriber, error) {
+		if cfg.WhisperModel == "" {
+			return nil, fmt.Errorf("set voice.whisper_model to a whisper.cpp ggml model file")
+		}
+		binary, err := whisperBinary(cfg.WhisperBinary)
+		if err != nil {
+			return nil, err
+		}
+		return &WhisperCPP{Binary: binary, Model: expandHome(cfg.WhisperModel), Language: cfg.Language}, nil
+	}
+	api := func() (Transcriber, error) {
+		if openAIKey == "" {
+			return nil, fmt.Errorf("transcribing with OpenAI needs OPENAI_API_KEY or agentic-coder auth login openai")
+		}
+		return &OpenAI{APIKey: openAIKey, Model: cfg.APIModel, Language: cfg.Language}, nil
+	}
+
+	switch cfg.Transcriber {
+	case "local":
+		return local()
+	case "openai":
+		return api()
+	}
+	if t, err := local(); err == nil {
+		return t, nil
+	}
+	if openAIKey != "" {
+		return api()
+	}
+	return nil, fmt.Errorf("no transcriber: install whisper.cpp and set voice.whisper_model, or set OPENAI_API_KEY")
+}
+
+// whisperBinary finds the whisper.cpp command line tool
+func whisperBinary(configured string) (string, error) {
+	candidates := []string{"whisper-cli", "whisper-cpp"}
+	if configured != "" {
+		candidates = []string{expandHome(configured)}
+	}
+	for _, name := range candidates {
+		if path, err := lookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("whisper.cpp not found: install it (whisper-cli) or set voice.whisper_binary")
+}
+
+// expandHome expands a leading ~/ in a path
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// soundMarker matches the markers whisper adds for sounds other than
+// speech, such as [BLANK_AUDIO] or [Music]
+var soundMarker = regexp.MustCompile(`\[[^\]]*\]`)
+
+// cleanTranscript joins the lines of a transcript and drops sound markers
+func cleanTranscript(text string) string {
+	return strings.Join(strings.Fields(soundMarker.ReplaceAllString(text, " ")), " ")
+}
@@ -0,0 +1,167 @@
+package voice
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xinguang/agentic-coder/pkg/config"
+)
+
+// stubPath makes only the named commands found on PATH
+func stubPath(t *testing.T, found ...string) {
+	t.Helper()
+	t.Cleanup(func() { lookPath = exec.LookPath })
+	lookPath = func(name string) (string, error) {
+		for _, f := range found {
+			if f == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+}
+
+func TestRecorderArgs(t *testing.T) {
+	stubPath(t, "arecord", "ffmpeg")
+	args, err := recorderArgs("", "/tmp/a.wav")
+	if err != nil || args[0] != "arecord" || args[len(args)-1] != "/tmp/a.wav" {
+		t.Errorf("expected arecord before ffmpeg, got %v, %v", args, err)
+	}
+
+	args, err = recorderArgs("parec --file-format=wav {file}", "/tmp/a.wav")
+	if err != nil || strings.Join(args, " ") != "parec --file-format=wav /tmp/a.wav" {
+		t.Errorf("unexpected configured recorder: %v, %v", args, err)
+	}
+
+	stubPath(t)
+	if _, err := recorderArgs("", "/tmp/a.wav"); err == nil {
+		t.Error("expected an error without a recorder")
+	}
+}
+
+func TestNewTranscriber(t *testing.T) {
+	stubPath(t, "whisper-cli")
+	tr, err := NewTranscriber(config.VoiceConfig{WhisperModel: "model.bin"}, "key")
+	if w, ok := tr.(*WhisperCPP); err != nil || !ok || w.Binary != "/usr/bin/whisper-cli" {
+		t.Errorf("expected whisper.cpp when set up, got %T, %v", tr, err)
+	}
+	if tr, err := NewTranscriber(config.VoiceConfig{}, "key"); err != nil || tr.(*OpenAI).APIKey != "key" {
+		t.Errorf("expected OpenAI without a whisper model, got %T, %v", tr, err)
+	}
+	if _, err := NewTranscriber(config.VoiceConfig{Transcriber: "local"}, "key"); err == nil {
+		t.Error("expected an error for local without a model")
+	}
+
+	stubPath(t)
+	if _, err := NewTranscriber(config.VoiceConfig{WhisperModel: "model.bin"}, ""); err == nil {
+		t.Error("expected an error with no transcriber available")
+	}
+}
+
+func TestOpenAITranscribe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audio/transcriptions" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(file)
+		if string(data) != "RIFF" || r.FormValue("model") != "whisper-1" || r.FormValue("language") != "en" {
+			t.Errorf("unexpected upload %q, %q", data, r.FormValue("model"))
+		}
+		w.Write([]byte(`{"text":" Fix the failing test. "}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "a.wav")
+	os.WriteFile(path, []byte("RIFF"), 0644)
+	o := &OpenAI{APIKey: "key", BaseURL: server.URL, Language: "en"}
+	text, err := o.Transcribe(context.Background(), path)
+	if err != nil || text != "Fix the failing test." {
+		t.Errorf("got %q, %v", text, err)
+	}
+}
+
+func TestRecordAndTranscribeLocally(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts")
+	}
+	dir := t.TempDir()
+	// A recorder that writes audio until interrupted
+	recorder := filepath.Join(dir, "recorder")
+	os.WriteFile(recorder, []byte("#!/bin/sh\ntrap 'exit 130' INT\nhead -c 1000 /dev/zero > \"$1\"\nwhile true; do sleep 0.05; done\n"), 0755)
+	whisper := filepath.Join(dir, "whisper-cli")
+	os.WriteFile(whisper, []byte("#!/bin/sh\necho ' [BLANK_AUDIO]'\necho ' Run the tests'\necho ' and commit.'\n"), 0755)
+
+	in := NewInput(config.VoiceConfig{
+		Transcriber:   "local",
+		WhisperBinary: whisper,
+		WhisperModel:  "model.bin",
+		Recorder:      recorder + " {file}",
+	}, "")
+	if err := in.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if !in.Recording() {
+		t.Error("expected a recording in progress")
+	}
+	// Speak until the recorder has written some audio
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if info, err := os.Stat(in.rec.path); err == nil && info.Size() > 44 {
+			break
+		}
+	}
+	text, err := in.Stop(context.Background())
+	if err != nil || text != "Run the tests and commit." {
+		t.Errorf("got %q, %v", text, err)
+	}
+	if _, err := in.Stop(context.Background()); err == nil || in.Recording() {
+		t.Error("expected no recording after stopping")
+	}
+}
+
+func TestStopWithoutAudio(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts")
+	}
+	// A recorder that fails at once, as without a microphone
+	rec, err := Record("false {file}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-waitExit(rec)
+	if _, err := rec.Stop(); err == nil || !strings.Contains(err.Error(), "recording failed") {
+		t.Errorf("expected a recording error, got %v", err)
+	}
+}
+
+// waitExit waits for the recorder process to exit, keeping its result for Stop
+func waitExit(rec *Recording) <-chan struct{} {
+	exited := make(chan struct{})
+	go func() {
+		err := <-rec.done
+		rec.done <- err
+		close(exited)
+	}()
+	return exited
+}
+
+func TestCleanTranscript(t *testing.T) {
+	if got := cleanTranscript("[BLANK_AUDIO]\n  Hello\n world [MUSIC PLAYING]"); got != "Hello world" {
+		t.Errorf("got %q", got)
+	}
+	if got := cleanTranscript(" [BLANK_AUDIO] "); got != "" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -50,7 +50,7 @@ func (a *BaseAgent) Provider() provider.AIProvider {
 // Chat sends a message to the AI and returns the response
 func (a *BaseAgent) Chat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
 	req := &provider.Request{
-		Model:     a.model,
+		Model:     provider.ResolveModel(a.model),
 		MaxTokens: 8192,
 		Messages: []provider.Message{
 			{